@@ -192,6 +192,45 @@ def validate_ip(ip):
         return ip, False
 
 
+def excluded_network(ip):
+    """Return the GOPHERSTRIKE_EXCLUSIONS address or range containing ip, if any"""
+    path = os.environ.get("GOPHERSTRIKE_EXCLUSIONS")
+    if not path:
+        return None
+    address = ipaddress.ip_address(ip)
+    with open(path) as exclusions:
+        for line in exclusions:
+            line = line.strip()
+            if not line or line.startswith("#") or line.startswith("re:"):
+                continue
+            try:
+                network = ipaddress.ip_network(line, strict=False)
+            except ValueError:
+                continue  # Hostnames do not match an address target
+            if address.version == network.version and address in network:
+                return line
+    return None
+
+
+def get_targets():
+    """Return the addresses passed by GopherStrike, or ask for one"""
+    if len(sys.argv) < 2:
+        return [get_target_ip()]
+
+    targets = []
+    for target in sys.argv[1:]:
+        ip, is_valid = validate_ip(target)
+        if not is_valid:
+            logger.warning(f"[-] Invalid IP address: {target}")
+            continue
+        excluded = excluded_network(ip)
+        if excluded:
+            logger.warning(f"[-] {ip} is excluded from scanning ({excluded})")
+            continue
+        targets.append(ip)
+    return targets
+
+
 def get_target_ip():
     """Get and validate target IP with user feedback"""
     while True:
@@ -199,6 +238,10 @@ def get_target_ip():
         ip, is_valid = validate_ip(target)
 
         if is_valid:
+            excluded = excluded_network(ip)
+            if excluded:
+                logger.warning(f"[-] {ip} is excluded from scanning ({excluded})")
+                continue
             return ip
         else:
             logger.warning(f"[-] Invalid IP address: {target}")
@@ -909,23 +952,27 @@ if __name__ == "__main__":
         logger.info("Starting advanced port scanner")
 
         # Get target information
-        target = get_target_ip()
-        logger.info(f"Target selected: {target}")
+        targets = get_targets()
+        if not targets:
+            logger.warning("[-] No targets to scan")
+            sys.exit(1)
+        logger.info(f"Targets selected: {', '.join(targets) if len(targets) <= 10 else f'{len(targets)} addresses'}")
 
         start_port, end_port = get_port_range()
         logger.info(f"Port range selected: {start_port}-{end_port}")
 
-        # Start scanning
-        scan_start_time = datetime.now()
-        logger.info(f"\nStarting scan at: {scan_start_time.strftime('%Y-%m-%d %H:%M:%S')}")
+        for target in targets:
+            # Start scanning
+            scan_start_time = datetime.now()
+            logger.info(f"\nStarting scan of {target} at: {scan_start_time.strftime('%Y-%m-%d %H:%M:%S')}")
 
-        open_ports = scan_ports(target, start_port, end_port)
+            open_ports = scan_ports(target, start_port, end_port)
 
-        if open_ports:
-            print_summary(target, open_ports, scan_start_time)
-            nmap_logger(open_ports, target, start_port, end_port, scan_start_time)
-        else:
-            logger.info("\nNo open ports found.")
+            if open_ports:
+                print_summary(target, open_ports, scan_start_time)
+                nmap_logger(open_ports, target, start_port, end_port, scan_start_time)
+            else:
+                logger.info(f"\nNo open ports found on {target}.")
     except KeyboardInterrupt:
         logger.info("\n\nScan interrupted by user. Exiting...")
     except Exception as e:
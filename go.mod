@@ -3,6 +3,15 @@ module GopherStrike
 go 1.23
 
 require (
-	github.com/russross/blackfriday/v2 v2.1.0
+	go.starlark.net v0.0.0-20250417143717-f57e51f710eb
 	golang.org/x/crypto v0.31.0
+	golang.org/x/net v0.29.0
+	google.golang.org/grpc v1.68.1
+)
+
+require (
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240903143218-8af14fe29dc1 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
 )
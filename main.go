@@ -2,16 +2,44 @@ package main
 
 import (
 	"GopherStrike/pkg" // Import the pkg package to access exported functions
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/bandwidth"
+	"GopherStrike/pkg/budget"
+	"GopherStrike/pkg/config"
+	"GopherStrike/pkg/dedup"
+	"GopherStrike/pkg/egress"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/logging"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/pii"
+	"GopherStrike/pkg/policy"
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/robots"
+	"GopherStrike/pkg/scope"
+	"GopherStrike/pkg/session"
+	"GopherStrike/pkg/siem"
+	"GopherStrike/pkg/signing"
+	"GopherStrike/pkg/stats"
+	"GopherStrike/pkg/term"
+	"GopherStrike/pkg/timing"
 	"GopherStrike/pkg/tools"
+	"GopherStrike/pkg/triage"
+	"GopherStrike/pkg/watch"
 	"GopherStrike/utils"
 	"bufio"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 )
 
 // Global variable to track if we're currently in a tool
@@ -199,9 +227,7 @@ func mainMenu() {
 		fmt.Println(osintArt)
 		fmt.Println("\nRunning OSINT & Vulnerability Tool...")
 		// Run OSINT tool
-		if err := pkg.RunOSINTTool(); err != nil {
-			fmt.Println("Error:", err)
-		}
+		runScan(pkg.RunOSINTTool)
 		utils.ClearScreen()
 		mainMenu()
 	case 4:
@@ -209,9 +235,7 @@ func mainMenu() {
 		fmt.Println(webVulnArt)
 		fmt.Println("\nRunning Web Application Security Scanner...")
 		// Call the web vulnerability scanner
-		if err := pkg.RunWebVulnScanner(); err != nil {
-			fmt.Println("Error:", err)
-		}
+		runScan(pkg.RunWebVulnScanner)
 		utils.ClearScreen()
 		mainMenu()
 	case 5:
@@ -219,9 +243,7 @@ func mainMenu() {
 		fmt.Println(s3ScannerArt)
 		fmt.Println("\nRunning S3 Bucket Scanner...")
 		// Call the S3 bucket scanner
-		if err := tools.RunS3Scanner(); err != nil {
-			fmt.Println("Error:", err)
-		}
+		runScan(tools.RunS3Scanner)
 		utils.ClearScreen()
 		mainMenu()
 	case 6:
@@ -229,9 +251,7 @@ func mainMenu() {
 		fmt.Println(emailHarvesterArt)
 		fmt.Println("\nRunning Email Harvester...")
 		// Call the email harvester
-		if err := tools.RunEmailHarvester(); err != nil {
-			fmt.Println("Error:", err)
-		}
+		runScan(tools.RunEmailHarvester)
 		utils.ClearScreen()
 		mainMenu()
 	case 7:
@@ -239,9 +259,7 @@ func mainMenu() {
 		fmt.Println(dirBruteforceArt)
 		fmt.Println("\nRunning Directory Bruteforcer...")
 		// Call the directory bruteforcer
-		if err := tools.RunDirBruteforcer(); err != nil {
-			fmt.Println("Error:", err)
-		}
+		runScan(tools.RunDirBruteforcer)
 		utils.ClearScreen()
 		mainMenu()
 	case 8:
@@ -259,9 +277,7 @@ func mainMenu() {
 		fmt.Println(resolverArt)
 		fmt.Println("\nRunning Host & Subdomain Resolver...")
 		// Run host & subdomain resolver
-		if err := pkg.RunHostResolver(); err != nil {
-			fmt.Println("Error:", err)
-		}
+		runScan(pkg.RunHostResolver)
 		utils.ClearScreen()
 		mainMenu()
 	case 10:
@@ -293,6 +309,76 @@ func showHelp() {
 	fmt.Println("  ./GopherStrike              # Interactive mode")
 	fmt.Println("  ./GopherStrike --help       # Show this help")
 	fmt.Println("  ./GopherStrike -h           # Show this help")
+	fmt.Println("  ./GopherStrike --version    # Show the version (also -V)")
+	fmt.Println("  ./GopherStrike -q|-v|-vv <command> ...")
+	fmt.Println("                              # Quiet: only findings as JSON lines on stdout, errors on stderr;")
+	fmt.Println("                              # verbose: progress details; -vv: debug logs of every HTTP request")
+	fmt.Println("  ./GopherStrike --headless <command> ...")
+	fmt.Println("                              # Never prompt, log JSON lines to stdout (also GOPHERSTRIKE_HEADLESS=1)")
+	fmt.Println("  ./GopherStrike --no-color ...")
+	fmt.Println("                              # Disable colors (also NO_COLOR=1 or color_output: false)")
+	fmt.Println("  ./GopherStrike --fail-on critical|high|medium|low|info <command> ...")
+	fmt.Println("                              # Exit with status 1 when a finding reaches this severity (also policy.fail_on)")
+	fmt.Println("                              # Commands exit with 0 (clean), 1 (findings at or above --fail-on) or 2 (error)")
+	fmt.Println("                              # and write a JSON summary of the run to stderr as their last line")
+	fmt.Println("  ./GopherStrike --timing T0-T5|paranoid|sneaky|polite|normal|aggressive|insane <command> ...")
+	fmt.Println("                              # Bound workers, timeouts, retries and pauses of every tool (also -T0 to -T5")
+	fmt.Println("                              # and scanning.timing); slow profiles stay under IDS thresholds")
+	fmt.Println("  ./GopherStrike --max-duration 2h <command> ...")
+	fmt.Println("                              # Stop scanning when the run's time budget elapses and keep the partial results")
+	fmt.Println("                              # (also scanning.max_duration; scan commands take their own --max-duration)")
+	fmt.Println("  ./GopherStrike --max-bandwidth 512KB ...")
+	fmt.Println("                              # Cap the bytes per second of all HTTP connections (also network.max_bandwidth)")
+	fmt.Println("  ./GopherStrike --ignore-robots ...")
+	fmt.Println("                              # Crawl without following robots.txt or crawl delays (also scanning.ignore_robots)")
+	fmt.Println("  ./GopherStrike --record session.jsonl <command> ...")
+	fmt.Println("                              # Record the command line, settings, random seed, HTTP exchanges and findings")
+	fmt.Println("  ./GopherStrike replay session.jsonl")
+	fmt.Println("                              # Run a recorded session again from its recording and report the differences")
+	fmt.Println("  ./GopherStrike search [--tag t] [--target host] [--kind k] [--text s]")
+	fmt.Println("                 [--severity level] [--since YYYY-MM-DD] [--until YYYY-MM-DD] [--json]")
+	fmt.Println("                              # Search stored results of the workspace")
+	fmt.Println("  ./GopherStrike tag <result-path> <tag>[,<tag>...]")
+	fmt.Println("                              # Tag a stored result")
+	fmt.Println("  ./GopherStrike inventory [--format table|csv|json] [--output file]")
+	fmt.Println("                              # Asset inventory with finding counts")
+	fmt.Println("  ./GopherStrike export [--format dot|graphml|neo4j|maltego|spiderfoot|stix|cyclonedx|spdx] [--output file]")
+	fmt.Println("                              # Export the relationship graph or OSINT entities")
+	fmt.Println("  ./GopherStrike serve [--listen addr] [--agents addr] [--grpc addr] [--auth] [--drain-timeout 25s]")
+	fmt.Println("                              # Run the API server (scans, /metrics for Prometheus), reloading changed settings")
+	fmt.Println("  ./GopherStrike users add|list|rotate|remove [name] [--role admin|operator|read-only] [--tenant name]")
+	fmt.Println("                              # Manage API users and tokens")
+	fmt.Println("  ./GopherStrike tenants add|list|remove [name] [--scope targets]")
+	fmt.Println("                              # Manage tenants: business units with their own users, scope and workspace")
+	fmt.Println("  ./GopherStrike agent --coordinator host:port [--name n] [--concurrency n] [--drain-timeout 25s]")
+	fmt.Println("                              # Run a worker agent for distributed scans")
+	fmt.Println("  ./GopherStrike events --server host:port [--scan id] [--types scan.*,finding.new]")
+	fmt.Println("                              # Stream scan events and findings from the gRPC API as JSON lines")
+	fmt.Println("  ./GopherStrike certcheck [--preset name] [--hosts file] [--warn-days n] [--probes=false] [--json] [--output file] [host...]")
+	fmt.Println("                              # Check certificate expiry, chains, algorithms and hostnames, probe for Heartbleed,")
+	fmt.Println("                              # CCS injection, insecure renegotiation and ROBOT")
+	fmt.Println("  ./GopherStrike sshaudit [--preset name] [--hosts file] [--inventory] [--json] [--output file] [host...]")
+	fmt.Println("                              # Flag weak SSH key exchange, host key, cipher and MAC algorithms")
+	fmt.Println("  ./GopherStrike smtpcheck [--preset name] [--hosts file] [--json] [--output file] [domain|server:port...]")
+	fmt.Println("                              # Test MX servers for open relay, STARTTLS and VRFY/EXPN")
+	fmt.Println("  ./GopherStrike ampcheck [--preset name] [--hosts file] [--inventory] [--json] [address|cidr|host...]")
+	fmt.Println("                              # Find open DNS resolvers and NTP monlist amplifiers")
+	fmt.Println("  ./GopherStrike wpscan [--preset name] [--max-author-id n] [--probe-plugins] [--offline] [--sources list] [--json] [--output file] url...")
+	fmt.Println("                              # Enumerate WordPress users, plugins and themes, check XML-RPC and look up CVEs of the versions")
+	fmt.Println("  ./GopherStrike correlate [--min-confidence 0.0-1.0] [--sources list] [--api-key key] [--ports list] [--json] [--output file] host|result.json...")
+	fmt.Println("                              # Correlate servers and saved OSINT results with vulnerability databases; CPE matches weigh")
+	fmt.Println("                              # more than affected systems, which weigh more than keywords")
+	fmt.Println("  ./GopherStrike depscan [--nvd] [--include-dev] [--report file.md|file.html] [--json] [--output file] repo|manifest...")
+	fmt.Println("                              # Look up the dependencies of go.mod, package-lock.json, requirements.txt")
+	fmt.Println("                              # and CycloneDX SBOMs in OSV and report the vulnerable versions")
+	fmt.Println("  ./GopherStrike passive [--sources virustotal,securitytrails,otx,chaos] [--json] [--output file] domain...")
+	fmt.Println("                              # Look up subdomains in passive datasets, with the API keys of the configuration")
+	fmt.Println("  ./GopherStrike verify [--workspace name] [--timeout s] [--insecure] [--json] <finding-id>")
+	fmt.Println("                              # Replay the requests of a stored finding and mark it open or fixed")
+	fmt.Println("  ./GopherStrike verify-report [--key signer.pub] [--json] file|directory...")
+	fmt.Println("                              # Check reports and results against their signatures (output.signing_key)")
+	fmt.Println("  ./GopherStrike cleanup [--max-age days] [--max-size MB] [--compress] [--compress-after days] [--dry-run]")
+	fmt.Println("                              # Remove old results and rotated logs, compress old JSON results")
 	fmt.Println("\nAvailable Tools in Interactive Mode:")
 	fmt.Println("=====================================")
 	fmt.Println("1. Subdomain Scanner         - Discover subdomains of target domains")
@@ -305,18 +391,850 @@ func showHelp() {
 	fmt.Println("8. Report Generator          - Generate comprehensive reports")
 	fmt.Println("9. Host & Subdomain Resolver - DNS resolution and validation")
 	fmt.Println("10. Check Dependencies       - Verify required tools installation")
+	fmt.Println("\nResults:")
+	fmt.Println("========")
+	fmt.Println("Results are stored per target under workspaces/<workspace>/targets/<host>/")
+	fmt.Println("Set GOPHERSTRIKE_WORKSPACE to select a workspace (default: \"default\")")
 	fmt.Println("\nFor more information, visit: https://github.com/your-repo/GopherStrike")
 }
 
+// configureHeadless enables headless mode for --headless, which may appear
+// anywhere on the command line and is removed before the command runs, or
+// GOPHERSTRIKE_HEADLESS. Headless mode never prompts: the interactive menu is
+// unavailable and logs are written to stdout as JSON lines.
+func configureHeadless() {
+	args := []string{}
+	headless, _ := strconv.ParseBool(os.Getenv(term.HeadlessEnvVar))
+	for _, arg := range os.Args {
+		if arg == "--headless" || arg == "-headless" {
+			headless = true
+			continue
+		}
+		args = append(args, arg)
+	}
+	os.Args = args
+	if !headless {
+		return
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "Error: headless mode needs a command, the interactive menu is not available (see --help)")
+		os.Exit(exitError)
+	}
+	term.SetHeadless(true)
+	logging.SetHeadless()
+}
+
+// configProblem reports a configuration problem. Headless runs stop, as
+// nobody would notice the warning before the scan runs with the wrong settings.
+func configProblem(format string, args ...interface{}) {
+	if term.Headless() {
+		fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
+		os.Exit(exitError)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: "+format+"\n", args...)
+}
+
+// loadConfig applies the configuration file, if there is one, and then the
+// GOPHERSTRIKE_* environment variables overriding its settings
+func loadConfig() {
+	cfg := config.Get()
+	configFile := config.DefaultConfigFile()
+	if _, err := os.Stat(configFile); err == nil {
+		if err := cfg.LoadFromFile(configFile); err != nil {
+			configProblem("%v", err)
+			return
+		}
+	} else if os.Getenv(config.FileEnvVar) != "" {
+		configProblem("configuration file from %s not found: %s", config.FileEnvVar, configFile)
+	}
+
+	if err := cfg.LoadFromEnv(); err != nil {
+		configProblem("%v", err)
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		configProblem("invalid configuration: %v", err)
+	}
+}
+
+// overrides holds the settings given on the command line, and the
+// exclusions file of GOPHERSTRIKE_EXCLUSIONS, which keep precedence over
+// the configuration when it is reloaded
+var overrides = map[string]string{}
+
+// configureTiming selects the timing profile of the scanning.timing setting,
+// or of --timing or -T0 to -T5, which may appear anywhere on the command line
+// and are removed before the command runs
+func configureTiming() {
+	name := config.Get().Scanning.Timing
+	args := []string{}
+	for i := 0; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		switch {
+		case arg == "--timing" || arg == "-timing":
+			if i+1 == len(os.Args) {
+				fmt.Fprintln(os.Stderr, "Error: --timing needs a profile: T0 to T5 or paranoid, sneaky, polite, normal, aggressive, insane")
+				os.Exit(exitError)
+			}
+			i++
+			name = os.Args[i]
+		case strings.HasPrefix(arg, "--timing="):
+			name = strings.TrimPrefix(arg, "--timing=")
+		case len(arg) == 3 && strings.HasPrefix(arg, "-T") && arg[2] >= '0' && arg[2] <= '9':
+			name = arg[1:]
+		default:
+			args = append(args, arg)
+		}
+	}
+	if len(args) < len(os.Args) {
+		overrides["timing"] = name
+	}
+	os.Args = args
+
+	profile, err := timing.Parse(name)
+	if err != nil {
+		// An invalid setting is already reported by the configuration validation
+		if name == config.Get().Scanning.Timing {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: invalid --timing: %v\n", err)
+		os.Exit(exitError)
+	}
+	timing.Set(profile)
+	if profile.Level != timing.Normal && len(os.Args) > 1 {
+		fmt.Printf("[i] Timing profile %s: %s\n", profile, profile.Description)
+	}
+}
+
+// configureBandwidth caps the bandwidth of the scans at the
+// network.max_bandwidth setting, or at --max-bandwidth, which may appear
+// anywhere on the command line and is removed before the command runs
+func configureBandwidth() {
+	value := config.Get().Network.MaxBandwidth
+	args := []string{}
+	for i := 0; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		switch {
+		case arg == "--max-bandwidth" || arg == "-max-bandwidth":
+			if i+1 == len(os.Args) {
+				fmt.Fprintln(os.Stderr, "Error: --max-bandwidth needs bytes per second, e.g. 512KB or 2MB")
+				os.Exit(exitError)
+			}
+			i++
+			value = os.Args[i]
+		case strings.HasPrefix(arg, "--max-bandwidth="):
+			value = strings.TrimPrefix(arg, "--max-bandwidth=")
+		default:
+			args = append(args, arg)
+		}
+	}
+	if len(args) < len(os.Args) {
+		overrides["max_bandwidth"] = value
+	}
+	os.Args = args
+
+	limit, err := bandwidth.Parse(value)
+	if err != nil {
+		// An invalid setting is already reported by the configuration validation
+		if value == config.Get().Network.MaxBandwidth {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: invalid --max-bandwidth: %v\n", err)
+		os.Exit(exitError)
+	}
+	bandwidth.Set(limit)
+	if limit > 0 && len(os.Args) > 1 {
+		fmt.Printf("[i] Bandwidth capped at %s\n", bandwidth.Format(limit))
+	}
+}
+
+// configureRobots applies the crawl policy settings and --ignore-robots,
+// which may appear anywhere on the command line and is removed before the
+// command runs. --ignore-robots overrides the scanning.ignore_robots setting.
+func configureRobots() {
+	options := robots.Defaults()
+	args := []string{}
+	for _, arg := range os.Args {
+		if arg == "--ignore-robots" || arg == "-ignore-robots" {
+			options.Ignore = true
+			overrides["ignore_robots"] = "true"
+			continue
+		}
+		args = append(args, arg)
+	}
+	os.Args = args
+
+	if options.Ignore {
+		fmt.Fprintln(os.Stderr, "Warning: robots.txt rules and crawl delays are ignored, only crawl sites you are authorized to test")
+	}
+	robots.SetDefaults(options)
+}
+
+// configureBudget sets the time budget of command-line runs from the
+// scanning.max_duration setting, or from --max-duration given before the
+// command, which is removed. The --max-duration option of a command, after
+// its name, sets the budget of that command's scan.
+func configureBudget() {
+	value := config.Get().Scanning.MaxDuration
+	flagged := false
+	if len(os.Args) > 1 {
+		switch arg := os.Args[1]; {
+		case arg == "--max-duration" || arg == "-max-duration":
+			if len(os.Args) == 2 {
+				fmt.Fprintln(os.Stderr, "Error: --max-duration needs a duration, e.g. 30m or 2h")
+				os.Exit(exitError)
+			}
+			value, flagged = os.Args[2], true
+			os.Args = append(os.Args[:1], os.Args[3:]...)
+		case strings.HasPrefix(arg, "--max-duration="):
+			value, flagged = strings.TrimPrefix(arg, "--max-duration="), true
+			os.Args = append(os.Args[:1], os.Args[2:]...)
+		}
+	}
+
+	limit, err := budget.Parse(value)
+	if err != nil {
+		// An invalid setting is already reported by the configuration validation
+		if !flagged {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: invalid --max-duration: %v\n", err)
+		os.Exit(exitError)
+	}
+	if flagged && len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "Warning: --max-duration only applies to commands, ignoring it in interactive mode")
+	}
+	budget.SetLimit(limit)
+}
+
+// configureSession records the session of the run to the file given with
+// --record, which may appear anywhere on the command line and is removed
+// before the command runs, or replays the session given to the replay
+// command, whose command line and settings then replace those of the run.
+// The returned function ends the session and reports how a replay departed
+// from its recording.
+func configureSession() func() {
+	if len(os.Args) > 1 && strings.ToLower(os.Args[1]) == "replay" {
+		return replaySession()
+	}
+
+	path := ""
+	args := []string{}
+	for i := 0; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		switch {
+		case arg == "--record" || arg == "-record":
+			if i+1 == len(os.Args) {
+				fmt.Fprintln(os.Stderr, "Error: --record needs the file to record the session to")
+				os.Exit(exitError)
+			}
+			i++
+			path = os.Args[i]
+		case strings.HasPrefix(arg, "--record="):
+			path = strings.TrimPrefix(arg, "--record=")
+		default:
+			args = append(args, arg)
+		}
+	}
+	os.Args = args
+	if path == "" {
+		return func() {}
+	}
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "Warning: --record only applies to commands, ignoring it in interactive mode")
+		return func() {}
+	}
+
+	if err := session.Record(path, os.Args[1:], config.Get()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot record the session: %v\n", err)
+		os.Exit(exitError)
+	}
+	fmt.Printf("[i] Recording the session to %s\n", path)
+	return func() {
+		if _, err := session.Stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: the session recorded to %s may be incomplete: %v\n", path, err)
+		}
+	}
+}
+
+// replaySession starts replaying the session file given to the replay
+// command. The output settings and hooks of the configuration are kept, so
+// that the replay neither notifies nor writes where the recorded run did.
+func replaySession() func() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "Error: usage: GopherStrike replay <session-file>")
+		os.Exit(exitError)
+	}
+	recorded, err := session.Load(os.Args[2])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot replay the session: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	cfg := config.Get()
+	output, hooks := cfg.Output, cfg.Hooks
+	if len(recorded.Header.Config) > 0 {
+		if err := json.Unmarshal(recorded.Header.Config, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid configuration in %s: %v\n", os.Args[2], err)
+			os.Exit(exitError)
+		}
+	}
+	cfg.Output, cfg.Hooks = output, hooks
+
+	fmt.Printf("[i] Replaying %q recorded on %s (%d exchanges)\n", strings.Join(recorded.Header.Args, " "),
+		recorded.Header.Started.Format("2006-01-02 15:04"), len(recorded.Exchanges))
+	os.Args = append(os.Args[:1], recorded.Header.Args...)
+	session.Replay(recorded)
+	return func() {
+		discrepancies, _ := session.Stop()
+		printDiscrepancies(discrepancies)
+	}
+}
+
+// printDiscrepancies prints how a replay departed from its recording
+func printDiscrepancies(d session.Discrepancies) {
+	fmt.Printf("\n[i] %d requests answered from the recording\n", d.Replayed)
+	if d.Empty() {
+		fmt.Println("[+] The replay matches the recording")
+		return
+	}
+	list := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Printf("[!] %s: %d\n", title, len(items))
+		for i, item := range items {
+			if i == 10 {
+				fmt.Printf("    ... and %d more\n", len(items)-i)
+				break
+			}
+			fmt.Printf("    %s\n", item)
+		}
+	}
+	describe := func(findings []model.Finding) []string {
+		items := []string{}
+		for _, f := range findings {
+			items = append(items, fmt.Sprintf("[%s] %s %s", f.Severity, f.Name, f.URL))
+		}
+		return items
+	}
+	list("Requests not in the recording", d.NotRecorded)
+	list("Recorded requests not sent again", d.NotReplayed)
+	list("Findings of the recording only", describe(d.FindingsLost))
+	list("Findings of the replay only", describe(d.FindingsAdded))
+}
+
+// configureDedupe applies the scanning.dedupe setting to the stages fed by
+// the results of another
+func configureDedupe() {
+	// An invalid setting is already reported by the configuration validation
+	if policy, err := dedup.ParsePolicy(config.Get().Scanning.Dedupe); err == nil {
+		dedup.SetDefault(policy)
+	}
+}
+
+// loadExclusions enforces the exclusions file named by GOPHERSTRIKE_EXCLUSIONS
+// or the scanning.exclusions_file setting. Nothing is scanned if the file
+// cannot be loaded, as the client-mandated exclusions could not be honored.
+func loadExclusions() {
+	path := os.Getenv(scope.EnvVar)
+	if path != "" {
+		overrides["exclusions_file"] = path
+	} else {
+		path = config.Get().Scanning.ExclusionsFile
+	}
+	if path == "" {
+		return
+	}
+
+	exclusions, err := scope.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	scope.Set(exclusions)
+	// Child processes such as the Python port scanner read the same file
+	os.Setenv(scope.EnvVar, path)
+}
+
+// loadRedaction applies the redaction rules of the workspace, masking the
+// secrets they list in the logs, results and reports. Nothing is scanned if
+// the rules cannot be loaded, as the secrets would be written in clear.
+func loadRedaction() {
+	path := filepath.Join(artifacts.Default().WorkspaceDir(), redact.File)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return
+	}
+
+	rules, err := redact.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	redact.Set(rules)
+}
+
+// loadEgress applies the egress routes of the workspace, sending the
+// connections of the tools through their proxies. Nothing is scanned if the
+// routes cannot be loaded, as traffic required to go through a proxy would
+// leave directly.
+func loadEgress() {
+	path := filepath.Join(artifacts.Default().WorkspaceDir(), egress.File)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return
+	}
+
+	routes, err := egress.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	egress.Set(routes)
+}
+
+// loadSigningKey turns on the signing of results and reports with the key
+// of the output.signing_key setting, generating the key on first use.
+// Nothing is scanned if the key cannot be loaded, as the deliverables would
+// go out unsigned.
+func loadSigningKey() {
+	path := config.Get().Output.SigningKey
+	if path == "" {
+		return
+	}
+
+	key, created, err := signing.LoadOrCreateKey(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	if created {
+		fmt.Printf("[i] Generated signing key %s, give recipients %s%s (fingerprint %s)\n",
+			path, path, signing.PublicExt, signing.Fingerprint(key.Public().(ed25519.PublicKey)))
+	}
+	signing.SetKey(key)
+}
+
+// configureColors disables colored output for --no-color, which may appear
+// anywhere on the command line and is removed before the command runs, and
+// for the output.color_output setting. Colors are also off when NO_COLOR is
+// set or stdout is not a terminal.
+func configureColors() {
+	args := []string{}
+	noColor := !config.Get().Output.ColorOutput
+	for _, arg := range os.Args {
+		if arg == "--no-color" || arg == "-no-color" {
+			noColor = true
+			continue
+		}
+		args = append(args, arg)
+	}
+	os.Args = args
+
+	if noColor {
+		term.SetEnabled(false)
+		// Child processes such as the Python port scanner follow NO_COLOR
+		os.Setenv(term.NoColorEnvVar, "1")
+	}
+}
+
+// configureVerbosity applies the -q, -v and -vv flags, which may appear
+// anywhere on the command line and are removed before the command runs. The
+// output.verbose setting selects verbose mode when no flag is given. In quiet
+// mode stdout only receives the findings as JSON lines: everything the tools
+// print is discarded and log errors go to stderr.
+func configureVerbosity() {
+	verbosity, args := logging.ParseVerbosity(os.Args)
+	os.Args = args
+	if verbosity == logging.Normal && config.Get().Output.Verbose {
+		verbosity = logging.Verbose
+	}
+
+	if verbosity == logging.Quiet {
+		if len(os.Args) < 2 {
+			fmt.Fprintln(os.Stderr, "Warning: -q only applies to commands, ignoring it in interactive mode")
+			verbosity = logging.Normal
+		} else {
+			devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: quiet mode unavailable: %v\n", err)
+				os.Exit(exitError)
+			}
+			siem.WriteFindings(os.Stdout)
+			os.Stdout = devNull
+		}
+	}
+	logging.SetVerbosity(verbosity)
+}
+
+// configurePolicy applies the severity policy settings and --fail-on, which
+// may appear anywhere on the command line and is removed before the command
+// runs. --fail-on overrides the policy.fail_on setting.
+func configurePolicy() {
+	cfg := config.Get().Policy
+	args := []string{}
+	failOn := ""
+	for i := 0; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		switch {
+		case arg == "--fail-on" || arg == "-fail-on":
+			if i+1 == len(os.Args) {
+				fmt.Fprintln(os.Stderr, "Error: --fail-on needs a severity: critical, high, medium, low or info")
+				os.Exit(exitError)
+			}
+			i++
+			failOn = os.Args[i]
+		case strings.HasPrefix(arg, "--fail-on="):
+			failOn = strings.TrimPrefix(arg, "--fail-on=")
+		default:
+			args = append(args, arg)
+		}
+	}
+	os.Args = args
+
+	if failOn != "" {
+		if _, err := model.ParseSeverity(failOn); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --fail-on: %v\n", err)
+			os.Exit(exitError)
+		}
+		cfg.FailOn = failOn
+		overrides["fail_on"] = failOn
+	}
+	p, err := policy.New(cfg)
+	if err != nil {
+		// Already reported by the configuration validation
+		p = &policy.Policy{}
+	}
+	policy.Set(p)
+}
+
+var (
+	hooksMutex  sync.Mutex
+	removeHooks = func() {} // Removes the registered hooks
+)
+
+// registerHooks subscribes the configured event hooks, in place of those
+// registered before, and returns a function that waits for their queued
+// events
+func registerHooks() func() {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+
+	removeHooks()
+	stop, err := eventbus.RegisterHooks(config.Get().Hooks)
+	if err != nil {
+		fmt.Printf("Warning: event hooks disabled: %v\n", err)
+		stop = func() {}
+	}
+	removeHooks = stop
+	return func() {
+		hooksMutex.Lock()
+		defer hooksMutex.Unlock()
+		removeHooks()
+		removeHooks = func() {}
+	}
+}
+
+// daemons are the long-running commands that reload their settings when
+// the files they come from change
+var daemons = map[string]bool{"serve": true, "agent": true}
+
+// watchSettings reloads the settings of daemons when the configuration
+// file, the exclusions file or the redaction rules or egress routes of the
+// workspace change,
+// so that a server or agent picks them up without dropping the scans it
+// runs. Running scans see the new settings from their next request. The
+// returned function stops watching.
+func watchSettings(name string) func() {
+	if !daemons[name] {
+		return func() {}
+	}
+	workspace := artifacts.Default().WorkspaceDir()
+	paths := []string{config.DefaultConfigFile(), filepath.Join(workspace, redact.File), filepath.Join(workspace, egress.File)}
+	if path := exclusionsFile(); path != "" {
+		paths = append(paths, path)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watch.Watch(ctx, watch.DefaultInterval, paths, reloadSettings)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// exclusionsFile returns the path of the exclusions in use, if any
+func exclusionsFile() string {
+	if path, ok := overrides["exclusions_file"]; ok {
+		return path
+	}
+	return config.Get().Scanning.ExclusionsFile
+}
+
+// reloadSettings applies the configuration, exclusions and redaction rules
+// as they are now on disk. Settings that cannot be loaded are reported and
+// keep their previous value, rather than stopping the daemon. The SIEM
+// output, logging and console settings apply on the next start only.
+func reloadSettings(changed []string) {
+	fmt.Printf("[i] Reloading settings, changed: %s\n", strings.Join(changed, ", "))
+	if err := config.Reload(config.DefaultConfigFile()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: configuration not reloaded, keeping the current one: %v\n", err)
+		return
+	}
+	cfg := config.Get()
+
+	policyConfig := cfg.Policy
+	if failOn, ok := overrides["fail_on"]; ok {
+		policyConfig.FailOn = failOn
+	}
+	if p, err := policy.New(policyConfig); err == nil {
+		policy.Set(p)
+	}
+	pii.Reset()
+
+	name := cfg.Scanning.Timing
+	if flagged, ok := overrides["timing"]; ok {
+		name = flagged
+	}
+	if profile, err := timing.Parse(name); err == nil {
+		timing.Set(profile)
+	}
+	value := cfg.Network.MaxBandwidth
+	if flagged, ok := overrides["max_bandwidth"]; ok {
+		value = flagged
+	}
+	if limit, err := bandwidth.Parse(value); err == nil {
+		bandwidth.Set(limit)
+	}
+	_, ignoreRobots := overrides["ignore_robots"]
+	robots.SetDefaults(robots.Options{
+		Ignore:             ignoreRobots || cfg.Scanning.IgnoreRobots,
+		MaxRequestsPerHost: cfg.Scanning.MaxRequestsPerHost,
+	})
+	configureDedupe()
+
+	if path := exclusionsFile(); path == "" {
+		scope.Set(nil)
+		os.Unsetenv(scope.EnvVar)
+	} else if exclusions, err := scope.Load(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: exclusions not reloaded, keeping the current ones: %v\n", err)
+	} else {
+		scope.Set(exclusions)
+		os.Setenv(scope.EnvVar, path)
+	}
+
+	rulesPath := filepath.Join(artifacts.Default().WorkspaceDir(), redact.File)
+	if _, err := os.Stat(rulesPath); os.IsNotExist(err) {
+		redact.Set(nil)
+	} else if rules, err := redact.Load(rulesPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: redaction rules not reloaded, keeping the current ones: %v\n", err)
+	} else {
+		redact.Set(rules)
+	}
+
+	routesPath := filepath.Join(artifacts.Default().WorkspaceDir(), egress.File)
+	if _, err := os.Stat(routesPath); os.IsNotExist(err) {
+		egress.Set(nil)
+	} else if routes, err := egress.Load(routesPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: egress routes not reloaded, keeping the current ones: %v\n", err)
+	} else {
+		egress.Set(routes)
+	}
+
+	if path := cfg.Output.SigningKey; path == "" {
+		signing.SetKey(nil)
+	} else if key, _, err := signing.LoadOrCreateKey(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: signing key not reloaded, keeping the current one: %v\n", err)
+	} else {
+		signing.SetKey(key)
+	}
+
+	registerHooks()
+	fmt.Println("[+] Settings reloaded")
+}
+
+// recordFindings records the findings reported in the workspace, so that
+// they can be verified later, and in the session of the run, and returns a
+// function that stops recording. A replay leaves the workspace findings as
+// they are, and the API server records the findings of its scans in the
+// workspaces of their tenants itself.
+func recordFindings() func() {
+	store := artifacts.Default()
+	serving := len(os.Args) > 1 && strings.ToLower(os.Args[1]) == "serve"
+	return eventbus.Subscribe(eventbus.FindingNew, func(event eventbus.Event) {
+		if finding, ok := event.Data.(model.Finding); ok {
+			session.AddFinding(finding)
+			if session.Replaying() || serving {
+				return
+			}
+			if err := store.RecordFinding(finding); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to record finding %s: %v\n", finding.ID, err)
+			}
+		}
+	})
+}
+
+// streamFindings prints the findings reaching the live_severity setting as
+// soon as they are found, and returns a function that stops printing them
+func streamFindings() func() {
+	min, err := model.ParseSeverity(config.Get().Output.LiveSeverity)
+	if err != nil || min == model.SeverityNone {
+		return func() {}
+	}
+	return triage.Stream(os.Stdout, min)
+}
+
+// runScan runs a tool from the menu and lets the user browse the findings
+// it reported, or read its output, before the screen is cleared
+func runScan(run func() error) {
+	collected := triage.Collect()
+	stopStreaming := streamFindings()
+	traffic := stats.Watch()
+	if err := run(); err != nil {
+		fmt.Println("Error:", err)
+	}
+	stopStreaming()
+	traffic.Stop()
+	traffic.Print(os.Stdout)
+
+	findings := collected()
+	if len(findings) == 0 {
+		fmt.Print("\nPress Enter to return to the menu...")
+		bufio.NewReader(os.Stdin).ReadString('\n')
+		return
+	}
+	triage.Browse(findings, artifacts.Default(), os.Stdin, os.Stdout)
+}
+
+// commands are the command-line commands, run with runCommand
+var commands = map[string]func(args []string) error{
+	"search":    pkg.RunSearch,
+	"tag":       pkg.RunTag,
+	"inventory": pkg.RunInventory,
+	"export":    pkg.RunExport,
+	"serve":     pkg.RunServer,
+	"users":     pkg.RunUsers,
+	"tenants":   pkg.RunTenants,
+	"agent":     pkg.RunAgent,
+	"events":    pkg.RunEvents,
+	"certcheck": pkg.RunCertCheck,
+	"sshaudit":  pkg.RunSSHAudit,
+	"smtpcheck": pkg.RunSMTPCheck,
+	"ampcheck":  pkg.RunAmpCheck,
+	"wpscan":    pkg.RunWPScan,
+	"cleanup":   pkg.RunCleanup,
+	"correlate": pkg.RunCorrelate,
+	"depscan":   pkg.RunDepScan,
+	"passive":   pkg.RunPassive,
+	"verify":    pkg.RunVerify,
+
+	"verify-report": pkg.RunVerifyReport,
+}
+
+// Exit statuses of command-line runs
+const (
+	exitClean    = 0 // No finding reached the fail threshold
+	exitFindings = 1 // Findings reached the fail threshold
+	exitError    = 2 // The command or its options failed
+)
+
+// runCommand runs a command-line command, prints its traffic statistics,
+// writes the summary of the run to stderr as a JSON line and returns the
+// exit status
+func runCommand(name string, run func(args []string) error, args []string) int {
+	start := time.Now()
+	results := &model.Results{}
+	stopRecording := eventbus.Subscribe("*", func(event eventbus.Event) {
+		if finding, ok := event.Data.(model.Finding); ok && event.Type == eventbus.FindingNew {
+			results.AddFinding(finding)
+		} else {
+			results.AddTarget(event.Target)
+		}
+	})
+	gate := policy.Watch()
+	stopStreaming := streamFindings()
+	traffic := stats.Watch()
+	budget.Start()
+
+	err := run(args)
+	gate.Stop()
+	stopRecording()
+	stopStreaming()
+	traffic.Stop()
+	traffic.Print(os.Stdout)
+
+	summary := results.Summary(name, start)
+	summary.FailOn = strings.ToLower(string(policy.Current().FailOn))
+	summary.Failures = len(gate.Failures())
+	summary.Incomplete = budget.Stopped()
+	for _, note := range summary.Incomplete {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", note)
+	}
+	switch {
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		summary.Status, summary.ExitCode, summary.Error = "error", exitError, err.Error()
+	case summary.Failures > 0:
+		fmt.Fprintf(os.Stderr, "Error: %d finding(s) at or above the %s fail threshold\n",
+			summary.Failures, summary.FailOn)
+		summary.Status, summary.ExitCode = "findings", exitFindings
+	default:
+		summary.Status, summary.ExitCode = "clean", exitClean
+	}
+
+	json.NewEncoder(os.Stderr).Encode(struct {
+		model.Summary
+		Stats stats.Report `json:"stats"`
+	}{summary, traffic.Report()})
+	return summary.ExitCode
+}
+
 // main is the entry point for the application
 func main() {
+	configureHeadless()
+	loadConfig()
+	loadRedaction()
+	loadEgress()
+	loadSigningKey()
+	stopSession := configureSession()
+	defer stopSession()
+	output := config.Get().Output
+	logging.SetRotation(int64(output.MaxLogSizeMB)<<20, output.LogBackups)
+	configureColors()
+	configureVerbosity()
+	configurePolicy()
+	configureTiming()
+	configureBandwidth()
+	configureRobots()
+	configureBudget()
+	configureDedupe()
+	loadExclusions()
+	stopHooks := registerHooks()
+	defer stopHooks()
+	stopRecording := recordFindings()
+	defer stopRecording()
+
 	// Handle command line arguments
 	if len(os.Args) > 1 {
-		switch strings.ToLower(os.Args[1]) {
+		name := strings.ToLower(os.Args[1])
+		if run, ok := commands[name]; ok {
+			stopWatching := watchSettings(name)
+			status := runCommand(name, run, os.Args[2:])
+			stopWatching()
+			stopSession()
+			stopHooks()
+			os.Exit(status)
+		}
+
+		switch name {
 		case "--help", "-h", "help":
 			showHelp()
 			return
-		case "--version", "-v":
+		case "--version", "-v": // -V, -v itself selects verbose output
 			fmt.Println(mainBanner)
 			fmt.Println("\nGopherStrike v1.0.0")
 			fmt.Println("Advanced Security Reconnaissance Tool")
@@ -324,7 +1242,7 @@ func main() {
 		default:
 			fmt.Printf("Unknown option: %s\n", os.Args[1])
 			fmt.Println("Use --help for usage information")
-			os.Exit(1)
+			os.Exit(exitError)
 		}
 	}
 
@@ -358,18 +1276,18 @@ func main() {
 	}
 
 	// Create OSINT logs directory with secure permissions
-	if err := os.MkdirAll("logs/osint", 0750); err != nil {
+	if err := os.MkdirAll(logging.Dir("osint"), 0750); err != nil {
 		fmt.Printf("Warning: Failed to create OSINT logs directory: %v\n", err)
 	}
 
 	// Create resolver logs directory with secure permissions
-	if err := os.MkdirAll("logs/resolver", 0750); err != nil {
+	if err := os.MkdirAll(logging.Dir("resolver"), 0750); err != nil {
 		fmt.Printf("Warning: Failed to create resolver logs directory: %v\n", err)
 	}
 
-	// Create webvuln logs directory with secure permissions
-	if err := os.MkdirAll("logs/webvuln", 0750); err != nil {
-		fmt.Printf("Warning: Failed to create webvuln logs directory: %v\n", err)
+	// Create the active workspace directory with secure permissions
+	if err := os.MkdirAll(artifacts.Default().WorkspaceDir(), 0750); err != nil {
+		fmt.Printf("Warning: Failed to create workspace directory: %v\n", err)
 	}
 
 	// Use the text-based menu directly
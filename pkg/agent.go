@@ -0,0 +1,32 @@
+// pkg/agent.go
+package pkg
+
+import (
+	"GopherStrike/pkg/distributed"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// RunAgent runs a worker agent for a coordinator until it is interrupted
+func RunAgent(args []string) error {
+	options := distributed.DefaultAgentOptions()
+
+	fs := flag.NewFlagSet("agent", flag.ContinueOnError)
+	fs.StringVar(&options.Coordinator, "coordinator", options.Coordinator, "gRPC address of the coordinator")
+	fs.StringVar(&options.Name, "name", options.Name, "Agent name, e.g. its vantage point")
+	fs.IntVar(&options.Concurrency, "concurrency", options.Concurrency, "Number of tasks to run at once")
+	fs.DurationVar(&options.DrainTimeout, "drain-timeout", options.DrainTimeout, "How long running tasks may take to finish on shutdown before they are stopped")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("[+] Starting agent %s for coordinator %s\n", options.Name, options.Coordinator)
+	return distributed.NewAgent(options).Run(ctx)
+}
@@ -0,0 +1,123 @@
+// pkg/ampcheck.go
+package pkg
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/budget"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/targets"
+	"GopherStrike/pkg/tools/audit/amplification"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// RunAmpCheck probes the addresses of a target's IP ranges for services
+// that can be abused for UDP amplification: open recursive DNS resolvers and
+// NTP servers answering monlist or mode 6 queries
+func RunAmpCheck(args []string) error {
+	// The preset provides the defaults the other flags override
+	preset, err := presets.FromArgs(args)
+	if err != nil {
+		return err
+	}
+	options, err := amplification.PresetCheckOptions(preset)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("ampcheck", flag.ContinueOnError)
+	fs.String("preset", preset, "Option preset: quick, normal or deep")
+	hostsFile := fs.String("hosts", "", "File of addresses, CIDRs, address ranges and hostnames")
+	inventory := fs.Bool("inventory", false, "Probe the addresses of the workspace assets")
+	workspace := fs.String("workspace", os.Getenv(artifacts.WorkspaceEnvVar), "Workspace to read the inventory from")
+	fs.IntVar(&options.Timeout, "timeout", options.Timeout, "Seconds to wait for UDP responses")
+	fs.IntVar(&options.Concurrency, "concurrency", options.Concurrency, "Addresses probed in parallel")
+	fs.IntVar(&options.MaxHosts, "max-hosts", options.MaxHosts, "Refuse targets expanding to more addresses")
+	fs.StringVar(&options.QueryName, "query", options.QueryName, "Name queried with ANY to estimate DNS amplification")
+	jsonOutput := fs.Bool("json", false, "Print the results as JSON")
+	output := fs.String("output", "", "Write the results to this file instead of stdout")
+	maxDuration := fs.Duration("max-duration", 0, "Stop probing after this long, e.g. 30m, and keep the partial results (0: no limit)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	entries := fs.Args()
+	if *hostsFile != "" {
+		fileEntries, err := targets.LoadFile(*hostsFile, options.MaxHosts)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, fileEntries...)
+	}
+	if *inventory {
+		assets, err := artifacts.NewStore(artifacts.DefaultRoot, *workspace).Inventory()
+		if err != nil {
+			return err
+		}
+		for _, asset := range assets {
+			entries = append(entries, asset.IPs...)
+		}
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no targets to probe, pass them as arguments, with --hosts or --inventory")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := budget.Context(ctx, "ampcheck", *maxDuration)
+	defer cancel()
+	addresses, err := amplification.ExpandTargets(ctx, entries, options.MaxHosts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[i] Probing %d address(es) for DNS and NTP amplification...\n", len(addresses))
+	results := amplification.NewChecker(options).CheckHosts(ctx, strings.Join(entries, ","), addresses)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %v", err)
+		}
+		defer file.Close()
+		redacted := redact.Writer(file)
+		defer redacted.Close()
+		w = redacted
+	}
+
+	exposed := []amplification.HostResult{}
+	for _, result := range results {
+		if len(result.Exposures) > 0 {
+			exposed = append(exposed, result)
+		}
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(exposed, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	if len(exposed) == 0 {
+		fmt.Fprintf(w, "[+] None of the %d address(es) can be abused for amplification\n", len(addresses))
+		return nil
+	}
+	fmt.Fprintf(w, "%-16s %-8s %-14s %-8s %-10s %-10s %s\n", "Address", "Service", "Check", "Severity", "Request", "Response", "Factor")
+	for _, result := range exposed {
+		for _, exposure := range result.Exposures {
+			fmt.Fprintf(w, "%-16s %-8s %-14s %-8s %-10d %-10d %.1fx\n", result.IP, exposure.Service, exposure.Check,
+				exposure.Severity, exposure.RequestBytes, exposure.ResponseBytes, exposure.Factor)
+		}
+	}
+	fmt.Fprintf(w, "\n[!] %d of %d address(es) can be abused for amplification\n", len(exposed), len(addresses))
+	return nil
+}
@@ -0,0 +1,132 @@
+package artifacts
+
+import (
+	"GopherStrike/pkg/redact"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// blobsDir is the per-workspace directory holding the response bodies
+	// referenced by findings, named after the hash of their content:
+	//
+	//	workspaces/<workspace>/blobs/<first 2 hex digits>/<sha256>.gz
+	blobsDir = "blobs"
+
+	// blobPrefix starts the references to stored bodies
+	blobPrefix = "sha256:"
+
+	// blobGracePeriod keeps the bodies stored recently from being pruned, as
+	// the findings of a running scan referencing them may not be recorded yet
+	blobGracePeriod = time.Hour
+)
+
+// PutBlob stores a response body with its secrets redacted and returns its
+// reference, "sha256:" followed by the hash of the redacted content. A body
+// stored before is not written again, so identical pages referenced by many
+// findings take the space of one. Bodies are compressed with gzip.
+func (s *Store) PutBlob(data []byte) (string, error) {
+	data = redact.Bytes(data)
+	sum := sha256.Sum256(data)
+	ref := blobPrefix + hex.EncodeToString(sum[:])
+	path, _ := s.blobPath(ref)
+	if _, err := os.Stat(path); err == nil {
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return ref, nil
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write(data)
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress body: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create blob directory: %v", err)
+	}
+
+	// Written under a temporary name first, so that a concurrent writer of
+	// the same body or a reader never sees it partially written
+	temp, err := os.CreateTemp(filepath.Dir(path), ".blob-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to write body: %v", err)
+	}
+	if _, err := temp.Write(compressed.Bytes()); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return "", fmt.Errorf("failed to write body: %v", err)
+	}
+	temp.Close()
+	if err := os.Rename(temp.Name(), path); err != nil {
+		os.Remove(temp.Name())
+		return "", fmt.Errorf("failed to write body: %v", err)
+	}
+	return ref, nil
+}
+
+// Blob returns the body stored under a reference returned by PutBlob
+func (s *Store) Blob(ref string) ([]byte, error) {
+	path, err := s.blobPath(ref)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("body not found: %s", ref)
+	}
+	return ReadArtifact(path)
+}
+
+// blobPath returns the file of a blob reference
+func (s *Store) blobPath(ref string) (string, error) {
+	sum, ok := strings.CutPrefix(ref, blobPrefix)
+	if _, err := hex.DecodeString(sum); !ok || err != nil || len(sum) != 2*sha256.Size {
+		return "", fmt.Errorf("invalid body reference: %q", ref)
+	}
+	return filepath.Join(s.WorkspaceDir(), blobsDir, sum[:2], sum+compressedExt), nil
+}
+
+// pruneBlobs removes the stored bodies no finding of the workspace
+// references anymore, except those stored during the grace period, and
+// records them in the report
+func (s *Store) pruneBlobs(dryRun bool, report *CleanupReport) error {
+	findings, err := s.Findings()
+	if err != nil {
+		return err
+	}
+	referenced := make(map[string]bool, len(findings))
+	for _, f := range findings {
+		if f.Body != "" {
+			path, _ := s.blobPath(f.Body)
+			referenced[path] = true
+		}
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(s.WorkspaceDir(), blobsDir, "*", "*"+compressedExt))
+	for _, path := range paths {
+		if referenced[path] {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || time.Since(info.ModTime()) < blobGracePeriod {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %v", path, err)
+			}
+			// Remove fails for directories that still hold bodies
+			os.Remove(filepath.Dir(path))
+		}
+		report.Removed = append(report.Removed, s.relativePath(path))
+		report.Freed += info.Size()
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package artifacts
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OSINT entity types
+const (
+	EntityDomain      = "domain"
+	EntityHostname    = "hostname"
+	EntityIPv4        = "ipv4"
+	EntityIPv6        = "ipv6"
+	EntityEmail       = "email"
+	EntityCertificate = "certificate"
+)
+
+// emailPattern matches email addresses in harvester results
+var emailPattern = regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)
+
+// Entity is an OSINT entity discovered for a target
+type Entity struct {
+	Type   string    `json:"type"`
+	Value  string    `json:"value"`
+	Target string    `json:"target"`
+	Parent string    `json:"parent,omitempty"` // Value of the entity this one was discovered from
+	Seen   time.Time `json:"seen"`
+}
+
+// Entities returns the domains, host names, IPs, emails and certificates
+// discovered across the workspace
+func (s *Store) Entities() ([]Entity, error) {
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+
+	entities := []Entity{}
+	seen := make(map[string]bool)
+	add := func(entity Entity) {
+		key := entity.Type + "|" + entity.Value + "|" + entity.Parent
+		if entity.Value == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		entities = append(entities, entity)
+	}
+
+	for _, target := range targets {
+		artifacts, err := s.List(target)
+		if err != nil {
+			return nil, err
+		}
+
+		lastSeen := time.Time{}
+		for _, artifact := range artifacts {
+			if artifact.ModTime.After(lastSeen) {
+				lastSeen = artifact.ModTime
+			}
+		}
+
+		if ipType := ipEntityType(target); ipType != "" {
+			add(Entity{Type: ipType, Value: target, Target: target, Seen: lastSeen})
+		} else {
+			add(Entity{Type: EntityDomain, Value: target, Target: target, Seen: lastSeen})
+		}
+
+		for _, artifact := range artifacts {
+			content, err := ReadArtifact(artifact.Path)
+			if err != nil {
+				continue
+			}
+
+			if artifact.Kind == KindRecon && strings.HasPrefix(artifact.Name, "emails") {
+				for _, email := range emailPattern.FindAllString(string(content), -1) {
+					add(Entity{Type: EntityEmail, Value: strings.ToLower(email), Target: target, Parent: target, Seen: artifact.ModTime})
+				}
+			}
+
+			var data interface{}
+			if json.Unmarshal(content, &data) != nil {
+				continue
+			}
+			collectEntities(data, target, target, artifact.ModTime, add)
+		}
+	}
+
+	sort.SliceStable(entities, func(i, j int) bool {
+		if entities[i].Target != entities[j].Target {
+			return entities[i].Target < entities[j].Target
+		}
+		return entities[i].Type < entities[j].Type
+	})
+
+	return entities, nil
+}
+
+// collectEntities walks a decoded JSON result and reports the entities it describes
+func collectEntities(v interface{}, target, parent string, seen time.Time, add func(Entity)) {
+	switch value := v.(type) {
+	case []interface{}:
+		for _, item := range value {
+			collectEntities(item, target, parent, seen, add)
+		}
+	case map[string]interface{}:
+		for _, key := range []string{"hostname", "name"} {
+			name, ok := value[key].(string)
+			if !ok || !looksLikeHost(name) {
+				continue
+			}
+			normalized := NormalizeTarget(name)
+			if normalized == "" || normalized == target {
+				continue
+			}
+			if !strings.HasSuffix(normalized, "."+target) {
+				return
+			}
+			add(Entity{Type: EntityHostname, Value: normalized, Target: target, Parent: target, Seen: seen})
+			parent = normalized
+			break
+		}
+
+		if issuer, ok := fieldValue(value, "issuer").(string); ok && issuer != "" {
+			cert := issuer
+			if expiration, ok := fieldValue(value, "expiration").(string); ok && expiration != "" {
+				cert = fmt.Sprintf("%s (expires %s)", issuer, expiration)
+			}
+			add(Entity{Type: EntityCertificate, Value: cert, Target: target, Parent: parent, Seen: seen})
+		}
+
+		for _, key := range objectKeys(value) {
+			field := value[key]
+			switch strings.ToLower(key) {
+			case "ip", "ips", "ip_address", "ip_addresses", "ipv4", "ipv6":
+				for _, ip := range stringValues(field) {
+					if ipType := ipEntityType(ip); ipType != "" {
+						add(Entity{Type: ipType, Value: ip, Target: target, Parent: parent, Seen: seen})
+					}
+				}
+			case "email", "emails":
+				for _, email := range stringValues(field) {
+					if emailPattern.MatchString(email) {
+						add(Entity{Type: EntityEmail, Value: strings.ToLower(email), Target: target, Parent: parent, Seen: seen})
+					}
+				}
+			default:
+				collectEntities(field, target, parent, seen, add)
+			}
+		}
+	}
+}
+
+// ipEntityType returns the entity type of an IP address, or "" if s is not one
+func ipEntityType(s string) string {
+	ip := net.ParseIP(s)
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return EntityIPv4
+	default:
+		return EntityIPv6
+	}
+}
+
+// maltegoTypes maps entity types to Maltego entity type names
+var maltegoTypes = map[string]string{
+	EntityDomain:      "maltego.Domain",
+	EntityHostname:    "maltego.DNSName",
+	EntityIPv4:        "maltego.IPv4Address",
+	EntityIPv6:        "maltego.IPv6Address",
+	EntityEmail:       "maltego.EmailAddress",
+	EntityCertificate: "maltego.X509Certificate",
+}
+
+// WriteMaltegoCSV writes entities as a CSV entity list for Maltego's
+// "Import Graph from Table" wizard. Each row holds an entity and the entity
+// it was discovered from, so the import can link them.
+func WriteMaltegoCSV(w io.Writer, entities []Entity) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"Entity Type", "Value", "Linked Entity Type", "Linked Value"}); err != nil {
+		return err
+	}
+
+	types := make(map[string]string)
+	for _, entity := range entities {
+		if entity.Parent == "" {
+			types[entity.Value] = entity.Type
+		}
+	}
+
+	for _, entity := range entities {
+		row := []string{maltegoTypes[entity.Type], entity.Value, "", ""}
+		if entity.Parent != "" && entity.Parent != entity.Value {
+			parentType := types[entity.Parent]
+			if parentType == "" {
+				parentType = EntityHostname
+			}
+			row[2], row[3] = maltegoTypes[parentType], entity.Parent
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// spiderFootTypes maps entity types to SpiderFoot event types
+var spiderFootTypes = map[string]string{
+	EntityDomain:      "DOMAIN_NAME",
+	EntityHostname:    "INTERNET_NAME",
+	EntityIPv4:        "IP_ADDRESS",
+	EntityIPv6:        "IPV6_ADDRESS",
+	EntityEmail:       "EMAILADDR",
+	EntityCertificate: "SSL_CERTIFICATE_ISSUER",
+}
+
+// WriteSpiderFootCSV writes entities in SpiderFoot's CSV result layout
+// (Scan Name, Updated, Type, Module, Source, F/P, Data)
+func WriteSpiderFootCSV(w io.Writer, scanName string, entities []Entity) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"Scan Name", "Updated", "Type", "Module", "Source", "F/P", "Data"}); err != nil {
+		return err
+	}
+
+	for _, entity := range entities {
+		source := entity.Parent
+		if source == "" {
+			source = "ROOT"
+		}
+		row := []string{
+			scanName,
+			entity.Seen.Format("2006-01-02 15:04:05"),
+			spiderFootTypes[entity.Type],
+			"GopherStrike",
+			source,
+			"0",
+			entity.Value,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
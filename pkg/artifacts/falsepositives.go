@@ -0,0 +1,67 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// falsePositivesFile holds the findings marked as false positives, keyed by finding
+const falsePositivesFile = "false_positives.json"
+
+// FalsePositive is a finding a user marked as not being a real issue
+type FalsePositive struct {
+	Tool     string    `json:"tool"`
+	Target   string    `json:"target"`
+	Name     string    `json:"name"`
+	URL      string    `json:"url,omitempty"`
+	MarkedAt time.Time `json:"marked_at"`
+}
+
+// FalsePositives returns the false positives of the workspace, keyed by
+// the finding keys they were marked with
+func (s *Store) FalsePositives() (map[string]FalsePositive, error) {
+	falsePositives := make(map[string]FalsePositive)
+
+	data, err := os.ReadFile(filepath.Join(s.WorkspaceDir(), falsePositivesFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return falsePositives, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &falsePositives); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", falsePositivesFile, err)
+	}
+	return falsePositives, nil
+}
+
+// SetFalsePositive marks the finding with the given key as a false
+// positive, or removes the mark if falsePositive is nil
+func (s *Store) SetFalsePositive(key string, falsePositive *FalsePositive) error {
+	falsePositives, err := s.FalsePositives()
+	if err != nil {
+		return err
+	}
+
+	if falsePositive == nil {
+		delete(falsePositives, key)
+	} else {
+		if falsePositive.MarkedAt.IsZero() {
+			falsePositive.MarkedAt = time.Now()
+		}
+		falsePositives[key] = *falsePositive
+	}
+
+	data, err := json.MarshalIndent(falsePositives, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.WorkspaceDir(), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.WorkspaceDir(), falsePositivesFile), data, 0644)
+}
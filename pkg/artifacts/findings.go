@@ -0,0 +1,99 @@
+package artifacts
+
+import (
+	"GopherStrike/pkg/model"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// findingsFile holds the findings of the workspace as JSON lines. A finding
+// is appended again each time it is reported or verified, the latest record
+// of an ID superseding the earlier ones.
+const findingsFile = "findings.jsonl"
+
+// findingsMutex serializes the appends of the findings recorded concurrently
+var findingsMutex sync.Mutex
+
+// RecordFinding appends a finding to the workspace findings, setting its ID
+// and its status, open, if they are missing
+func (s *Store) RecordFinding(f model.Finding) error {
+	if f.ID == "" {
+		f.ID = model.FindingID(f)
+	}
+	if f.Status == "" {
+		f.Status = model.StatusOpen
+	}
+
+	findingsMutex.Lock()
+	defer findingsMutex.Unlock()
+	if err := os.MkdirAll(s.WorkspaceDir(), 0755); err != nil {
+		return err
+	}
+	writer, err := AppendStreamWriter(filepath.Join(s.WorkspaceDir(), findingsFile))
+	if err != nil {
+		return err
+	}
+	if err := writer.WriteJSON(f); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// Findings returns the latest record of each finding of the workspace,
+// sorted by ID
+func (s *Store) Findings() ([]model.Finding, error) {
+	latest := make(map[string]model.Finding)
+	err := ReadStream(filepath.Join(s.WorkspaceDir(), findingsFile), func(record json.RawMessage) error {
+		var f model.Finding
+		if err := json.Unmarshal(record, &f); err != nil {
+			return fmt.Errorf("failed to parse %s: %v", findingsFile, err)
+		}
+		if f.ID != "" {
+			latest[f.ID] = f
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	findings := make([]model.Finding, 0, len(latest))
+	for _, f := range latest {
+		findings = append(findings, f)
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].ID < findings[j].ID })
+	return findings, nil
+}
+
+// Finding returns the finding with the given ID, or the only one whose ID
+// starts with it
+func (s *Store) Finding(id string) (model.Finding, error) {
+	findings, err := s.Findings()
+	if err != nil {
+		return model.Finding{}, err
+	}
+
+	id = strings.ToLower(strings.TrimSpace(id))
+	matches := []model.Finding{}
+	for _, f := range findings {
+		if f.ID == id {
+			return f, nil
+		}
+		if id != "" && strings.HasPrefix(f.ID, id) {
+			matches = append(matches, f)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return model.Finding{}, fmt.Errorf("no finding with ID %q in workspace %s", id, s.WorkspaceDir())
+	case 1:
+		return matches[0], nil
+	}
+	return model.Finding{}, fmt.Errorf("%d findings have an ID starting with %q, give more of it", len(matches), id)
+}
@@ -0,0 +1,409 @@
+package artifacts
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Node types of the relationship graph
+const (
+	NodeDomain     = "domain"
+	NodeSubdomain  = "subdomain"
+	NodeIP         = "ip"
+	NodePort       = "port"
+	NodeTechnology = "technology"
+	NodeFinding    = "finding"
+)
+
+// Relations between graph nodes
+const (
+	RelHasSubdomain = "HAS_SUBDOMAIN"
+	RelResolvesTo   = "RESOLVES_TO"
+	RelHasPort      = "HAS_PORT"
+	RelRuns         = "RUNS"
+	RelHasFinding   = "HAS_FINDING"
+)
+
+// GraphNode is an entity of the attack surface
+type GraphNode struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	Label      string            `json:"label"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// GraphEdge is a directed relationship between two nodes
+type GraphEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Relation string `json:"relation"`
+}
+
+// Graph is the relationship graph of a workspace:
+// domain → subdomains → IPs → ports → technologies, with findings attached to hosts
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+
+	nodeIndex map[string]int
+	edgeIndex map[GraphEdge]bool
+}
+
+// NewGraph creates an empty graph
+func NewGraph() *Graph {
+	return &Graph{
+		Nodes:     []GraphNode{},
+		Edges:     []GraphEdge{},
+		nodeIndex: make(map[string]int),
+		edgeIndex: make(map[GraphEdge]bool),
+	}
+}
+
+// AddNode adds a node if it does not exist yet and returns its ID
+func (g *Graph) AddNode(id, nodeType, label string, properties map[string]string) string {
+	if idx, exists := g.nodeIndex[id]; exists {
+		for key, value := range properties {
+			if g.Nodes[idx].Properties == nil {
+				g.Nodes[idx].Properties = make(map[string]string)
+			}
+			g.Nodes[idx].Properties[key] = value
+		}
+		return id
+	}
+
+	g.nodeIndex[id] = len(g.Nodes)
+	g.Nodes = append(g.Nodes, GraphNode{
+		ID:         id,
+		Type:       nodeType,
+		Label:      label,
+		Properties: properties,
+	})
+	return id
+}
+
+// AddEdge adds a relationship between two existing nodes, ignoring duplicates
+func (g *Graph) AddEdge(from, to, relation string) {
+	edge := GraphEdge{From: from, To: to, Relation: relation}
+	if from == to || g.edgeIndex[edge] {
+		return
+	}
+	g.edgeIndex[edge] = true
+	g.Edges = append(g.Edges, edge)
+}
+
+// BuildGraph builds the relationship graph from all results of the workspace
+func (s *Store) BuildGraph() (*Graph, error) {
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+
+	graph := NewGraph()
+	for _, target := range targets {
+		hostID := graph.AddNode(hostNodeID(target), NodeDomain, target, nil)
+
+		artifacts, err := s.List(target)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, artifact := range artifacts {
+			content, err := ReadArtifact(artifact.Path)
+			if err != nil {
+				continue
+			}
+
+			var data interface{}
+			if json.Unmarshal(content, &data) != nil {
+				continue
+			}
+			collectGraphData(graph, data, target, hostID)
+		}
+	}
+
+	return graph, nil
+}
+
+// collectGraphData walks a decoded JSON result and adds the entities it
+// describes to the graph, attached to the given host
+func collectGraphData(g *Graph, v interface{}, host, hostID string) {
+	switch value := v.(type) {
+	case []interface{}:
+		for _, item := range value {
+			collectGraphData(g, item, host, hostID)
+		}
+	case map[string]interface{}:
+		// Objects describing a subdomain become their own host nodes
+		for _, key := range []string{"hostname", "name"} {
+			name, ok := value[key].(string)
+			if !ok || !looksLikeHost(name) {
+				continue
+			}
+			normalized := NormalizeTarget(name)
+			if normalized == "" || normalized == host {
+				continue
+			}
+			if !strings.HasSuffix(normalized, "."+host) {
+				// Unrelated host, not part of this target's graph
+				return
+			}
+			subID := g.AddNode(hostNodeID(normalized), NodeSubdomain, normalized, nil)
+			g.AddEdge(hostID, subID, RelHasSubdomain)
+			host, hostID = normalized, subID
+			break
+		}
+
+		// Ports hang off the object's IP address when it has one
+		portParent := hostID
+		keys := objectKeys(value)
+		for _, key := range keys {
+			field := value[key]
+			switch strings.ToLower(key) {
+			case "ip", "ips", "ip_address", "ip_addresses", "ipv4", "ipv6":
+				for _, ip := range stringValues(field) {
+					if net.ParseIP(ip) == nil {
+						continue
+					}
+					ipID := g.AddNode("ip:"+ip, NodeIP, ip, nil)
+					g.AddEdge(hostID, ipID, RelResolvesTo)
+					if strings.EqualFold(key, "ip_address") {
+						portParent = ipID
+					}
+				}
+			}
+		}
+
+		if finding := findingLabel(value); finding != "" {
+			severity := strings.ToLower(fmt.Sprint(fieldValue(value, "severity")))
+			findingID := g.AddNode(fmt.Sprintf("finding:%s:%s", host, finding), NodeFinding, finding,
+				map[string]string{"severity": severity})
+			g.AddEdge(hostID, findingID, RelHasFinding)
+		}
+
+		for _, key := range keys {
+			field := value[key]
+			switch strings.ToLower(key) {
+			case "ip", "ips", "ip_address", "ip_addresses", "ipv4", "ipv6", "hostname", "name", "severity":
+				// Handled above
+			case "ports", "open_ports":
+				for _, port := range intValues(field) {
+					if port > 0 && port < 65536 {
+						g.AddEdge(portParent, g.addPortNode(portParent, port), RelHasPort)
+					}
+				}
+			case "services":
+				services, ok := field.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				for _, portStr := range objectKeys(services) {
+					port, err := strconv.Atoi(portStr)
+					name, _ := services[portStr].(string)
+					if err != nil || name == "" {
+						continue
+					}
+					portID := g.addPortNode(portParent, port)
+					g.AddEdge(portParent, portID, RelHasPort)
+					g.AddEdge(portID, g.addTechnologyNode(name), RelRuns)
+				}
+			case "technologies":
+				for _, tech := range stringValues(field) {
+					g.AddEdge(hostID, g.addTechnologyNode(tech), RelRuns)
+				}
+			case "product_name":
+				if tech := joinVersion(field, value["product_version"]); tech != "" {
+					g.AddEdge(hostID, g.addTechnologyNode(tech), RelRuns)
+				}
+			case "os":
+				if tech := joinVersion(field, value["os_version"]); tech != "" {
+					g.AddEdge(hostID, g.addTechnologyNode(tech), RelRuns)
+				}
+			case "headers":
+				if headers, ok := field.(map[string]interface{}); ok {
+					for name, header := range headers {
+						switch strings.ToLower(name) {
+						case "server", "x-powered-by":
+							g.AddEdge(hostID, g.addTechnologyNode(fmt.Sprint(header)), RelRuns)
+						}
+					}
+				}
+			default:
+				collectGraphData(g, field, host, hostID)
+			}
+		}
+	}
+}
+
+// addPortNode adds a port node scoped to its parent host or IP
+func (g *Graph) addPortNode(parentID string, port int) string {
+	return g.AddNode(fmt.Sprintf("port:%s:%d", parentID, port), NodePort, fmt.Sprintf("%d/tcp", port), nil)
+}
+
+// addTechnologyNode adds a technology node shared by all hosts running it
+func (g *Graph) addTechnologyNode(name string) string {
+	name = strings.TrimSpace(name)
+	return g.AddNode("tech:"+strings.ToLower(name), NodeTechnology, name, nil)
+}
+
+// hostNodeID returns the node ID of a domain or subdomain
+func hostNodeID(host string) string {
+	return "host:" + host
+}
+
+// findingLabel returns a label for an object describing a finding, or "" if it is not one
+func findingLabel(object map[string]interface{}) string {
+	severity, ok := fieldValue(object, "severity").(string)
+	if !ok || SeverityRank(severity) == -1 {
+		return ""
+	}
+	for _, key := range []string{"id", "title", "description"} {
+		if label, ok := fieldValue(object, key).(string); ok && label != "" {
+			return truncateLabel(label, 80)
+		}
+	}
+	return ""
+}
+
+// fieldValue returns a field of a JSON object, matching the key case-insensitively
+func fieldValue(object map[string]interface{}, key string) interface{} {
+	for k, v := range object {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
+
+// truncateLabel shortens long labels
+func truncateLabel(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
+
+// objectKeys returns the keys of a JSON object in order, so graphs are built deterministically
+func objectKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedKeys returns the keys of a properties map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteDOT writes the graph in Graphviz DOT format
+func (g *Graph) WriteDOT(w io.Writer) error {
+	shapes := map[string]string{
+		NodeDomain:     "doubleoctagon",
+		NodeSubdomain:  "octagon",
+		NodeIP:         "box",
+		NodePort:       "circle",
+		NodeTechnology: "component",
+		NodeFinding:    "note",
+	}
+
+	var b bytes.Buffer
+	b.WriteString("digraph gopherstrike {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s];\n", quoteString(node.ID), quoteString(node.Label), shapes[node.Type])
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", quoteString(edge.From), quoteString(edge.To), quoteString(edge.Relation))
+	}
+	b.WriteString("}\n")
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// quoteString quotes a string as a DOT identifier or Cypher string literal
+func quoteString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// WriteGraphML writes the graph in GraphML format
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	b.WriteString(`  <key id="type" for="node" attr.name="type" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="label" for="node" attr.name="label" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="severity" for="node" attr.name="severity" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="relation" for="edge" attr.name="relation" attr.type="string"/>` + "\n")
+	b.WriteString(`  <graph id="gopherstrike" edgedefault="directed">` + "\n")
+
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "    <node id=\"%s\">\n", xmlEscape(node.ID))
+		fmt.Fprintf(&b, "      <data key=\"type\">%s</data>\n", xmlEscape(node.Type))
+		fmt.Fprintf(&b, "      <data key=\"label\">%s</data>\n", xmlEscape(node.Label))
+		if severity := node.Properties["severity"]; severity != "" {
+			fmt.Fprintf(&b, "      <data key=\"severity\">%s</data>\n", xmlEscape(severity))
+		}
+		b.WriteString("    </node>\n")
+	}
+	for i, edge := range g.Edges {
+		fmt.Fprintf(&b, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, xmlEscape(edge.From), xmlEscape(edge.To))
+		fmt.Fprintf(&b, "      <data key=\"relation\">%s</data>\n", xmlEscape(edge.Relation))
+		b.WriteString("    </edge>\n")
+	}
+
+	b.WriteString("  </graph>\n</graphml>\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// xmlEscape escapes a string for XML attribute and text content
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// WriteCypher writes the graph as Neo4j Cypher statements that can be run
+// with cypher-shell or pasted into the Neo4j browser
+func (g *Graph) WriteCypher(w io.Writer) error {
+	labels := map[string]string{
+		NodeDomain:     "Domain",
+		NodeSubdomain:  "Subdomain",
+		NodeIP:         "IP",
+		NodePort:       "Port",
+		NodeTechnology: "Technology",
+		NodeFinding:    "Finding",
+	}
+
+	var b bytes.Buffer
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "MERGE (n:%s {id: %s}) SET n.label = %s", labels[node.Type], quoteString(node.ID), quoteString(node.Label))
+		for _, key := range sortedKeys(node.Properties) {
+			fmt.Fprintf(&b, ", n.%s = %s", key, quoteString(node.Properties[key]))
+		}
+		b.WriteString(";\n")
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "MATCH (a {id: %s}), (b {id: %s}) MERGE (a)-[:%s]->(b);\n",
+			quoteString(edge.From), quoteString(edge.To), edge.Relation)
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
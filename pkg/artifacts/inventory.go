@@ -0,0 +1,289 @@
+package artifacts
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Asset summarizes everything known about a single target in the workspace
+type Asset struct {
+	Host         string         `json:"host"`
+	IPs          []string       `json:"ips"`
+	OpenPorts    []int          `json:"open_ports"`
+	Technologies []string       `json:"technologies"`
+	Findings     map[string]int `json:"findings"` // Finding count per severity
+	Artifacts    int            `json:"artifacts"`
+	LastScanned  time.Time      `json:"last_scanned"`
+}
+
+// HighestSeverity returns the highest severity with at least one finding, or "" if none
+func (a *Asset) HighestSeverity() string {
+	for i := len(severityLevels) - 1; i >= 0; i-- {
+		if a.Findings[severityLevels[i]] > 0 {
+			return severityLevels[i]
+		}
+	}
+	return ""
+}
+
+// Inventory builds an asset summary for every target of the workspace, with
+// the riskiest assets first
+func (s *Store) Inventory() ([]Asset, error) {
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+
+	assets := make([]Asset, 0, len(targets))
+	for _, target := range targets {
+		artifacts, err := s.List(target)
+		if err != nil {
+			return nil, err
+		}
+
+		asset := Asset{
+			Host:     target,
+			Findings: make(map[string]int),
+		}
+		for _, artifact := range artifacts {
+			content, err := ReadArtifact(artifact.Path)
+			if err != nil {
+				continue
+			}
+
+			asset.Artifacts++
+			if artifact.ModTime.After(asset.LastScanned) {
+				asset.LastScanned = artifact.ModTime
+			}
+
+			for _, match := range severityPattern.FindAllSubmatch(content, -1) {
+				severity := strings.ToLower(string(match[1]))
+				if severity == "none" {
+					severity = "info"
+				}
+				asset.Findings[severity]++
+			}
+
+			var data interface{}
+			if json.Unmarshal(content, &data) == nil {
+				collectAssetData(data, &asset)
+			}
+		}
+
+		sort.Strings(asset.IPs)
+		sort.Ints(asset.OpenPorts)
+		sort.Strings(asset.Technologies)
+		assets = append(assets, asset)
+	}
+
+	sort.SliceStable(assets, func(i, j int) bool {
+		ri, rj := SeverityRank(assets[i].HighestSeverity()), SeverityRank(assets[j].HighestSeverity())
+		if ri != rj {
+			return ri > rj
+		}
+		return assets[i].Host < assets[j].Host
+	})
+
+	return assets, nil
+}
+
+// collectAssetData walks a decoded JSON result and records IPs, ports and
+// technologies belonging to the asset. Objects describing another host (for
+// example the subdomains of a domain) are skipped.
+func collectAssetData(v interface{}, asset *Asset) {
+	switch value := v.(type) {
+	case []interface{}:
+		for _, item := range value {
+			collectAssetData(item, asset)
+		}
+	case map[string]interface{}:
+		if describesOtherHost(value, asset.Host) {
+			return
+		}
+
+		for key, field := range value {
+			switch strings.ToLower(key) {
+			case "ip", "ips", "ip_address", "ip_addresses", "ipv4", "ipv6":
+				for _, ip := range stringValues(field) {
+					if net.ParseIP(ip) != nil {
+						asset.IPs = appendUnique(asset.IPs, ip)
+					}
+				}
+			case "ports", "open_ports":
+				for _, port := range intValues(field) {
+					if port > 0 && port < 65536 && !containsInt(asset.OpenPorts, port) {
+						asset.OpenPorts = append(asset.OpenPorts, port)
+					}
+				}
+			case "technologies":
+				for _, tech := range stringValues(field) {
+					asset.Technologies = appendUnique(asset.Technologies, tech)
+				}
+			case "product_name":
+				asset.Technologies = appendUnique(asset.Technologies, joinVersion(field, value["product_version"]))
+			case "os":
+				asset.Technologies = appendUnique(asset.Technologies, joinVersion(field, value["os_version"]))
+			case "resources":
+				// Scripts and stylesheets of web pages naming their library
+				for _, item := range objectValues(field) {
+					if library, ok := fieldValue(item, "library").(string); ok {
+						asset.Technologies = appendUnique(asset.Technologies, joinVersion(library, fieldValue(item, "version")))
+					}
+				}
+			case "headers":
+				if headers, ok := field.(map[string]interface{}); ok {
+					for name, header := range headers {
+						switch strings.ToLower(name) {
+						case "server", "x-powered-by":
+							asset.Technologies = appendUnique(asset.Technologies, fmt.Sprint(header))
+						}
+					}
+				}
+			default:
+				collectAssetData(field, asset)
+			}
+		}
+	}
+}
+
+// describesOtherHost reports whether a JSON object is about a host other than the asset
+func describesOtherHost(object map[string]interface{}, host string) bool {
+	for _, key := range []string{"hostname", "name"} {
+		name, ok := object[key].(string)
+		if !ok || !looksLikeHost(name) {
+			continue
+		}
+		if normalized := NormalizeTarget(name); normalized != "" && normalized != host {
+			return true
+		}
+	}
+	return false
+}
+
+// looksLikeHost reports whether a string could be a host name or IP address
+func looksLikeHost(s string) bool {
+	return strings.Contains(s, ".") && !strings.ContainsAny(s, " \t/")
+}
+
+// joinVersion formats a product name with an optional version
+func joinVersion(name, version interface{}) string {
+	n, _ := name.(string)
+	if n == "" {
+		return ""
+	}
+	if v, ok := version.(string); ok && v != "" {
+		return n + " " + v
+	}
+	return n
+}
+
+// stringValues returns the string or strings held by a JSON value
+func stringValues(v interface{}) []string {
+	switch value := v.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := []string{}
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// objectValues returns the objects held by a JSON array
+func objectValues(v interface{}) []map[string]interface{} {
+	items, _ := v.([]interface{})
+	objects := []map[string]interface{}{}
+	for _, item := range items {
+		if object, ok := item.(map[string]interface{}); ok {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// intValues returns the number or numbers held by a JSON value
+func intValues(v interface{}) []int {
+	switch value := v.(type) {
+	case float64:
+		return []int{int(value)}
+	case []interface{}:
+		values := []int{}
+		for _, item := range value {
+			if n, ok := item.(float64); ok {
+				values = append(values, int(n))
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// appendUnique appends a non-empty string if it is not already present
+func appendUnique(slice []string, s string) []string {
+	s = strings.TrimSpace(s)
+	if s == "" || containsString(slice, s) {
+		return slice
+	}
+	return append(slice, s)
+}
+
+// containsInt checks if an int is in a slice
+func containsInt(slice []int, n int) bool {
+	for _, item := range slice {
+		if item == n {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteInventoryCSV writes assets as CSV, one row per asset
+func WriteInventoryCSV(w io.Writer, assets []Asset) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"host", "ips", "open_ports", "technologies"}
+	for i := len(severityLevels) - 1; i >= 0; i-- {
+		header = append(header, severityLevels[i])
+	}
+	header = append(header, "artifacts", "last_scanned")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, asset := range assets {
+		ports := make([]string, len(asset.OpenPorts))
+		for i, port := range asset.OpenPorts {
+			ports[i] = strconv.Itoa(port)
+		}
+
+		row := []string{
+			asset.Host,
+			strings.Join(asset.IPs, ";"),
+			strings.Join(ports, ";"),
+			strings.Join(asset.Technologies, ";"),
+		}
+		for i := len(severityLevels) - 1; i >= 0; i-- {
+			row = append(row, strconv.Itoa(asset.Findings[severityLevels[i]]))
+		}
+		row = append(row, strconv.Itoa(asset.Artifacts), asset.LastScanned.Format(time.RFC3339))
+
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,243 @@
+package artifacts
+
+import (
+	"GopherStrike/pkg/signing"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// compressedExt is appended to the name of compressed artifacts
+const compressedExt = ".gz"
+
+// RetentionPolicy limits how long and how much of a workspace's results are kept
+type RetentionPolicy struct {
+	MaxAge        time.Duration // Remove results older than this (0 keeps them)
+	MaxSize       int64         // Remove the oldest results until the workspace is smaller, in bytes (0 is unlimited)
+	Compress      bool          // Compress JSON results older than CompressAfter with gzip
+	CompressAfter time.Duration
+	DryRun        bool // Report what would be done without changing anything
+}
+
+// CleanupReport lists what a cleanup removed and compressed
+type CleanupReport struct {
+	Removed    []string `json:"removed"`
+	Compressed []string `json:"compressed"`
+	Freed      int64    `json:"freed"` // Bytes freed by removing and compressing results
+	Size       int64    `json:"size"`  // Size of the remaining results
+}
+
+// ReadArtifact returns the content of an artifact, decompressing it if it
+// was compressed by a cleanup
+func ReadArtifact(path string) ([]byte, error) {
+	if !strings.HasSuffix(path, compressedExt) {
+		return os.ReadFile(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s: %v", path, err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// Cleanup applies a retention policy to the workspace. Results older than
+// the maximum age are removed first, old JSON results are then compressed,
+// and finally the oldest results are removed until the size limit is met.
+// Stored response bodies no finding references anymore are removed too.
+func (s *Store) Cleanup(policy RetentionPolicy) (CleanupReport, error) {
+	report := CleanupReport{Removed: []string{}, Compressed: []string{}}
+
+	artifacts, err := s.List("")
+	if err != nil {
+		return report, err
+	}
+	sort.Slice(artifacts, func(i, j int) bool {
+		return artifacts[i].ModTime.Before(artifacts[j].ModTime)
+	})
+
+	now := time.Now()
+	kept := []Artifact{}
+	for _, artifact := range artifacts {
+		if policy.MaxAge > 0 && now.Sub(artifact.ModTime) > policy.MaxAge {
+			if err := s.removeArtifact(artifact, policy.DryRun, &report); err != nil {
+				return report, err
+			}
+			continue
+		}
+		kept = append(kept, artifact)
+	}
+
+	if policy.Compress {
+		for i, artifact := range kept {
+			if !strings.HasSuffix(artifact.Name, ".json") || now.Sub(artifact.ModTime) <= policy.CompressAfter {
+				continue
+			}
+			compressed, err := s.compressArtifact(artifact, policy.DryRun)
+			if err != nil {
+				return report, err
+			}
+			report.Compressed = append(report.Compressed, s.relativePath(artifact.Path))
+			report.Freed += artifact.Size - compressed.Size
+			kept[i] = compressed
+		}
+	}
+
+	for _, artifact := range kept {
+		report.Size += artifact.Size
+	}
+	for len(kept) > 0 && policy.MaxSize > 0 && report.Size > policy.MaxSize {
+		if err := s.removeArtifact(kept[0], policy.DryRun, &report); err != nil {
+			return report, err
+		}
+		report.Size -= kept[0].Size
+		kept = kept[1:]
+	}
+
+	if err := s.pruneBlobs(policy.DryRun, &report); err != nil {
+		return report, err
+	}
+	if !policy.DryRun {
+		s.removeEmptyDirs()
+	}
+	return report, nil
+}
+
+// removeArtifact deletes an artifact and records it in the report
+func (s *Store) removeArtifact(artifact Artifact, dryRun bool, report *CleanupReport) error {
+	if !dryRun {
+		if err := os.Remove(artifact.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %v", artifact.Path, err)
+		}
+		// The signature of a compressed artifact is named after the original
+		os.Remove(strings.TrimSuffix(artifact.Path, compressedExt) + signing.Ext)
+		if err := s.moveTags(artifact.Path, ""); err != nil {
+			return err
+		}
+	}
+	report.Removed = append(report.Removed, s.relativePath(artifact.Path))
+	report.Freed += artifact.Size
+	return nil
+}
+
+// compressArtifact replaces an artifact with a gzip-compressed copy that
+// keeps its modification time, tags and signature, which still verifies the
+// decompressed content. In a dry run the artifact is left alone and its
+// compressed size is estimated.
+func (s *Store) compressArtifact(artifact Artifact, dryRun bool) (Artifact, error) {
+	compressed := artifact
+	compressed.Name += compressedExt
+	compressed.Path += compressedExt
+
+	content, err := os.ReadFile(artifact.Path)
+	if err != nil {
+		return artifact, err
+	}
+	counter := &countingWriter{}
+	var out io.Writer = counter
+	var file *os.File
+	if !dryRun {
+		file, err = os.OpenFile(compressed.Path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return artifact, fmt.Errorf("failed to compress %s: %v", artifact.Path, err)
+		}
+		out = io.MultiWriter(file, counter)
+	}
+
+	gz := gzip.NewWriter(out)
+	gz.Name = artifact.Name
+	gz.ModTime = artifact.ModTime
+	_, err = gz.Write(content)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if file != nil {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	compressed.Size = counter.n
+	if err != nil {
+		if !dryRun {
+			os.Remove(compressed.Path)
+		}
+		return artifact, fmt.Errorf("failed to compress %s: %v", artifact.Path, err)
+	}
+	if dryRun {
+		return compressed, nil
+	}
+
+	if err := os.Chtimes(compressed.Path, artifact.ModTime, artifact.ModTime); err != nil {
+		return artifact, err
+	}
+	if err := os.Remove(artifact.Path); err != nil {
+		return artifact, err
+	}
+	return compressed, s.moveTags(artifact.Path, compressed.Path)
+}
+
+// countingWriter counts the bytes written to it
+type countingWriter struct {
+	n int64
+}
+
+// Write counts p
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// moveTags moves the manual tags of an artifact to its new path, or drops
+// them if newPath is empty
+func (s *Store) moveTags(oldPath, newPath string) error {
+	tags, err := s.loadTags()
+	if err != nil {
+		return err
+	}
+	oldRel := s.relativePath(oldPath)
+	if _, tagged := tags[oldRel]; !tagged {
+		return nil
+	}
+
+	if newPath != "" {
+		tags[s.relativePath(newPath)] = tags[oldRel]
+	}
+	delete(tags, oldRel)
+	data, err := json.MarshalIndent(tags, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.WorkspaceDir(), tagsFile), data, 0644)
+}
+
+// removeEmptyDirs removes the kind and target directories left empty
+func (s *Store) removeEmptyDirs() {
+	targets, err := s.Targets()
+	if err != nil {
+		return
+	}
+	for _, target := range targets {
+		hostDir := filepath.Join(s.WorkspaceDir(), targetsDir, target)
+		kinds, _ := os.ReadDir(hostDir)
+		for _, kind := range kinds {
+			if kind.IsDir() {
+				// Remove fails for directories that still hold files
+				os.Remove(filepath.Join(hostDir, kind.Name()))
+			}
+		}
+		os.Remove(hostDir)
+	}
+}
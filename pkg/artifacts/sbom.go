@@ -0,0 +1,268 @@
+package artifacts
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Component types of the SBOMs, as named by CycloneDX
+const (
+	ComponentApplication     = "application"
+	ComponentFramework       = "framework"
+	ComponentLibrary         = "library"
+	ComponentOperatingSystem = "operating-system"
+)
+
+// Technology is a piece of software detected on an asset
+type Technology struct {
+	Name    string
+	Version string
+	Type    string // One of the component types
+}
+
+// technologyTypes classifies well-known technologies that are not
+// standalone applications. The others are applications.
+var technologyTypes = map[string]string{
+	"php": ComponentFramework, "asp.net": ComponentFramework, "express": ComponentFramework,
+	"django": ComponentFramework, "rails": ComponentFramework, "laravel": ComponentFramework,
+	"spring": ComponentFramework, "flask": ComponentFramework, "next.js": ComponentFramework,
+	"nuxt": ComponentFramework, "phusion passenger": ComponentFramework, "openssl": ComponentLibrary,
+	"jquery": ComponentLibrary, "jquery-ui": ComponentLibrary, "jquery-migrate": ComponentLibrary,
+	"bootstrap": ComponentLibrary, "react": ComponentLibrary, "react-dom": ComponentLibrary,
+	"vue": ComponentLibrary, "angular": ComponentLibrary, "angular.js": ComponentLibrary,
+	"lodash": ComponentLibrary, "underscore": ComponentLibrary, "moment": ComponentLibrary,
+	"d3": ComponentLibrary, "popper.js": ComponentLibrary, "font-awesome": ComponentLibrary,
+	"axios": ComponentLibrary, "handlebars": ComponentLibrary, "swiper": ComponentLibrary,
+	"ubuntu": ComponentOperatingSystem, "debian": ComponentOperatingSystem, "centos": ComponentOperatingSystem,
+	"windows": ComponentOperatingSystem, "linux": ComponentOperatingSystem, "freebsd": ComponentOperatingSystem,
+	"red hat": ComponentOperatingSystem, "alpine": ComponentOperatingSystem,
+}
+
+// parentheticalPattern matches comments of Server headers such as (Ubuntu)
+var parentheticalPattern = regexp.MustCompile(`\([^)]*\)`)
+
+// ParseTechnologies splits a technology of the inventory into the
+// products it names: "Apache/2.4.41 (Unix) OpenSSL/1.1.1d" names Apache
+// 2.4.41 and OpenSSL 1.1.1d, "nginx 1.18.0" names nginx 1.18.0
+func ParseTechnologies(s string) []Technology {
+	s = strings.TrimSpace(parentheticalPattern.ReplaceAllString(s, " "))
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	technologies := []Technology{}
+	for _, field := range fields {
+		if name, version, found := strings.Cut(field, "/"); found && name != "" {
+			technologies = append(technologies, newTechnology(name, version))
+		}
+	}
+	if len(technologies) > 0 {
+		return technologies
+	}
+
+	last := fields[len(fields)-1]
+	if len(fields) > 1 && strings.IndexAny(strings.TrimPrefix(last, "v"), "0123456789") == 0 {
+		return []Technology{newTechnology(strings.Join(fields[:len(fields)-1], " "), last)}
+	}
+	return []Technology{newTechnology(s, "")}
+}
+
+// newTechnology creates a technology, classified by its name
+func newTechnology(name, version string) Technology {
+	technology := Technology{Name: name, Version: version, Type: ComponentApplication}
+	if t, ok := technologyTypes[strings.ToLower(name)]; ok {
+		technology.Type = t
+	}
+	return technology
+}
+
+// PURL returns the package URL of a technology: an npm package for the
+// libraries served to browsers, a generic package otherwise
+func (t Technology) PURL() string {
+	kind := "generic"
+	if t.Type == ComponentLibrary && t.Name != "openssl" {
+		kind = "npm"
+	}
+	purl := "pkg:" + kind + "/" + strings.ReplaceAll(strings.ToLower(t.Name), " ", "-")
+	if t.Version != "" {
+		purl += "@" + t.Version
+	}
+	return purl
+}
+
+// assetTechnologies returns the technologies of an asset without duplicates
+func assetTechnologies(asset Asset) []Technology {
+	technologies := []Technology{}
+	seen := make(map[string]bool)
+	for _, tech := range asset.Technologies {
+		for _, technology := range ParseTechnologies(tech) {
+			key := strings.ToLower(technology.Name + "@" + technology.Version)
+			if !seen[key] {
+				seen[key] = true
+				technologies = append(technologies, technology)
+			}
+		}
+	}
+	return technologies
+}
+
+// cycloneDXComponent is a component of a CycloneDX BOM
+type cycloneDXComponent struct {
+	BOMRef     string               `json:"bom-ref"`
+	Type       string               `json:"type"`
+	Name       string               `json:"name"`
+	Version    string               `json:"version,omitempty"`
+	PURL       string               `json:"purl,omitempty"`
+	Properties []cycloneDXProperty  `json:"properties,omitempty"`
+	Components []cycloneDXComponent `json:"components,omitempty"`
+}
+
+// cycloneDXProperty is a name-value property of a CycloneDX component
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// WriteCycloneDX writes the technologies detected on the assets as a
+// CycloneDX 1.5 JSON BOM. Each asset is an application component holding
+// its technologies, with its addresses and ports as properties.
+func WriteCycloneDX(w io.Writer, workspace string, assets []Asset) error {
+	components := []cycloneDXComponent{}
+	dependencies := []map[string]interface{}{}
+	for _, asset := range assets {
+		component := cycloneDXComponent{
+			BOMRef: "asset:" + asset.Host,
+			Type:   ComponentApplication,
+			Name:   asset.Host,
+		}
+		for _, ip := range asset.IPs {
+			component.Properties = append(component.Properties, cycloneDXProperty{"gopherstrike:ip", ip})
+		}
+		for _, port := range asset.OpenPorts {
+			component.Properties = append(component.Properties, cycloneDXProperty{"gopherstrike:open_port", fmt.Sprint(port)})
+		}
+
+		refs := []string{}
+		for _, technology := range assetTechnologies(asset) {
+			ref := component.BOMRef + "/" + technology.Name + "@" + technology.Version
+			component.Components = append(component.Components, cycloneDXComponent{
+				BOMRef:  ref,
+				Type:    technology.Type,
+				Name:    technology.Name,
+				Version: technology.Version,
+				PURL:    technology.PURL(),
+			})
+			refs = append(refs, ref)
+		}
+		components = append(components, component)
+		dependencies = append(dependencies, map[string]interface{}{"ref": component.BOMRef, "dependsOn": refs})
+	}
+
+	bom := map[string]interface{}{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.5",
+		"serialNumber": "urn:uuid:" + newUUID(),
+		"version":      1,
+		"metadata": map[string]interface{}{
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+			"tools": map[string]interface{}{
+				"components": []map[string]string{{"type": ComponentApplication, "name": "GopherStrike", "version": "1.0.0"}},
+			},
+			"component": map[string]string{"bom-ref": "workspace:" + workspace, "type": ComponentApplication, "name": workspace},
+		},
+		"components":   components,
+		"dependencies": dependencies,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bom)
+}
+
+// spdxIDPattern matches the characters SPDX identifiers may not contain
+var spdxIDPattern = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// WriteSPDX writes the technologies detected on the assets as an SPDX 2.3
+// JSON document. The document describes a package per asset, which
+// contains a package per technology.
+func WriteSPDX(w io.Writer, workspace string, assets []Asset) error {
+	packages := []map[string]interface{}{}
+	relationships := []map[string]string{}
+	spdxID := func(parts ...string) string {
+		return "SPDXRef-" + spdxIDPattern.ReplaceAllString(strings.Join(parts, "-"), "-")
+	}
+
+	for _, asset := range assets {
+		assetID := spdxID("Asset", asset.Host)
+		packages = append(packages, map[string]interface{}{
+			"SPDXID":                assetID,
+			"name":                  asset.Host,
+			"downloadLocation":      "NOASSERTION",
+			"filesAnalyzed":         false,
+			"primaryPackagePurpose": "APPLICATION",
+		})
+		relationships = append(relationships, map[string]string{
+			"spdxElementId":      "SPDXRef-DOCUMENT",
+			"relationshipType":   "DESCRIBES",
+			"relatedSpdxElement": assetID,
+		})
+
+		for _, technology := range assetTechnologies(asset) {
+			id := spdxID("Asset", asset.Host, technology.Name, technology.Version)
+			pkg := map[string]interface{}{
+				"SPDXID":                id,
+				"name":                  technology.Name,
+				"downloadLocation":      "NOASSERTION",
+				"filesAnalyzed":         false,
+				"primaryPackagePurpose": strings.ToUpper(strings.ReplaceAll(technology.Type, "-", "_")),
+				"externalRefs": []map[string]string{{
+					"referenceCategory": "PACKAGE-MANAGER",
+					"referenceType":     "purl",
+					"referenceLocator":  technology.PURL(),
+				}},
+			}
+			if technology.Version != "" {
+				pkg["versionInfo"] = technology.Version
+			}
+			packages = append(packages, pkg)
+			relationships = append(relationships, map[string]string{
+				"spdxElementId":      assetID,
+				"relationshipType":   "CONTAINS",
+				"relatedSpdxElement": id,
+			})
+		}
+	}
+
+	document := map[string]interface{}{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              "GopherStrike workspace " + workspace,
+		"documentNamespace": "https://spdx.org/spdxdocs/gopherstrike-" + workspace + "-" + newUUID(),
+		"creationInfo": map[string]interface{}{
+			"created":  time.Now().UTC().Format(time.RFC3339),
+			"creators": []string{"Tool: GopherStrike-1.0.0"},
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40 // Version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,259 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tagsFile holds manually assigned tags, keyed by artifact path relative to the workspace
+const tagsFile = "tags.json"
+
+// Severity levels recognized in stored results, from lowest to highest
+var severityLevels = []string{"info", "low", "medium", "high", "critical"}
+
+// severityPattern matches severity fields in JSON results and "Severity: X" lines in text results
+var severityPattern = regexp.MustCompile(`(?i)"?severity"?\s*[:=]\s*"?(critical|high|medium|low|info|none)\b`)
+
+// Query describes the filters applied by Search. Zero values match everything.
+type Query struct {
+	Target      string    // Exact target host
+	Kind        Kind      // Artifact kind
+	Tags        []string  // All tags must be present
+	Text        string    // Case-insensitive free-text match on content
+	MinSeverity string    // Only results containing findings at or above this severity
+	Since       time.Time // Modified at or after
+	Until       time.Time // Modified before
+}
+
+// Match is an artifact returned by Search together with its tags and severities
+type Match struct {
+	Artifact
+	Tags       []string `json:"tags"`
+	Severities []string `json:"severities"`
+}
+
+// SeverityRank returns the rank of a severity name, or -1 if it is unknown
+func SeverityRank(severity string) int {
+	severity = strings.ToLower(severity)
+	for i, level := range severityLevels {
+		if level == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// Search returns all artifacts of the workspace matching the query, newest first
+func (s *Store) Search(query Query) ([]Match, error) {
+	minRank := -1
+	if query.MinSeverity != "" {
+		minRank = SeverityRank(query.MinSeverity)
+		if minRank == -1 {
+			return nil, fmt.Errorf("unknown severity: %s", query.MinSeverity)
+		}
+	}
+
+	artifacts, err := s.List(query.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	manualTags, err := s.loadTags()
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.ToLower(query.Text)
+	matches := []Match{}
+	for _, artifact := range artifacts {
+		if query.Kind != "" && artifact.Kind != query.Kind {
+			continue
+		}
+		if !query.Since.IsZero() && artifact.ModTime.Before(query.Since) {
+			continue
+		}
+		if !query.Until.IsZero() && !artifact.ModTime.Before(query.Until) {
+			continue
+		}
+
+		content, err := ReadArtifact(artifact.Path)
+		if err != nil {
+			continue
+		}
+
+		if text != "" && !strings.Contains(strings.ToLower(string(content)), text) {
+			continue
+		}
+
+		severities := extractSeverities(content)
+		if minRank != -1 && !hasSeverityAtLeast(severities, minRank) {
+			continue
+		}
+
+		tags := artifactTags(artifact, severities, manualTags[s.relativePath(artifact.Path)])
+		if !hasAllTags(tags, query.Tags) {
+			continue
+		}
+
+		matches = append(matches, Match{
+			Artifact:   artifact,
+			Tags:       tags,
+			Severities: severities,
+		})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].ModTime.After(matches[j].ModTime)
+	})
+
+	return matches, nil
+}
+
+// AddTags assigns tags to a stored artifact
+func (s *Store) AddTags(path string, tags ...string) error {
+	rel := s.relativePath(path)
+	if rel == "" {
+		return fmt.Errorf("not an artifact of workspace %s: %s", s.Workspace, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	allTags, err := s.loadTags()
+	if err != nil {
+		return err
+	}
+
+	existing := allTags[rel]
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag != "" && !containsString(existing, tag) {
+			existing = append(existing, tag)
+		}
+	}
+	sort.Strings(existing)
+	allTags[rel] = existing
+
+	data, err := json.MarshalIndent(allTags, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.WorkspaceDir(), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.WorkspaceDir(), tagsFile), data, 0644)
+}
+
+// loadTags reads the manually assigned tags of the workspace
+func (s *Store) loadTags() (map[string][]string, error) {
+	tags := make(map[string][]string)
+
+	data, err := os.ReadFile(filepath.Join(s.WorkspaceDir(), tagsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tags, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", tagsFile, err)
+	}
+	return tags, nil
+}
+
+// relativePath returns path relative to the workspace, or "" if it lies outside it
+func (s *Store) relativePath(path string) string {
+	absWorkspace, err := filepath.Abs(s.WorkspaceDir())
+	if err != nil {
+		return ""
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+
+	rel, err := filepath.Rel(absWorkspace, absPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// artifactTags combines automatic tags (kind and severities) with manual tags
+func artifactTags(artifact Artifact, severities, manual []string) []string {
+	tags := []string{string(artifact.Kind)}
+	for _, severity := range severities {
+		if !containsString(tags, severity) {
+			tags = append(tags, severity)
+		}
+	}
+	for _, tag := range manual {
+		if !containsString(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// extractSeverities returns the distinct severities mentioned in a result, highest first
+func extractSeverities(content []byte) []string {
+	found := make(map[string]bool)
+	for _, match := range severityPattern.FindAllSubmatch(content, -1) {
+		severity := strings.ToLower(string(match[1]))
+		if severity == "none" {
+			severity = "info"
+		}
+		found[severity] = true
+	}
+
+	severities := []string{}
+	for i := len(severityLevels) - 1; i >= 0; i-- {
+		if found[severityLevels[i]] {
+			severities = append(severities, severityLevels[i])
+		}
+	}
+	return severities
+}
+
+// hasSeverityAtLeast checks whether any severity reaches the given rank
+func hasSeverityAtLeast(severities []string, minRank int) bool {
+	for _, severity := range severities {
+		if SeverityRank(severity) >= minRank {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAllTags checks whether every wanted tag is present
+func hasAllTags(tags, wanted []string) bool {
+	for _, tag := range wanted {
+		if !containsString(tags, normalizeTag(tag)) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeTag lowercases and trims a tag
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// containsString checks if a string is in a slice
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
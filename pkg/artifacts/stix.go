@@ -0,0 +1,162 @@
+package artifacts
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// stixNamespace is the UUIDv5 namespace STIX 2.1 defines for deterministic identifiers
+var stixNamespace = [16]byte{0x00, 0xab, 0xed, 0xb4, 0xaa, 0x42, 0x46, 0x6c, 0x9c, 0x01, 0xfe, 0xd2, 0x33, 0x15, 0xa9, 0xb7}
+
+// cvePattern matches CVE identifiers in finding labels
+var cvePattern = regexp.MustCompile(`(?i)\bCVE-\d{4}-\d{4,}\b`)
+
+// stixTimestamp is the timestamp format required by STIX 2.1
+const stixTimestamp = "2006-01-02T15:04:05.000Z"
+
+// WriteSTIXBundle writes the discovered entities as indicators, and the
+// findings of the graph as vulnerabilities, in a STIX 2.1 bundle
+func WriteSTIXBundle(w io.Writer, entities []Entity, graph *Graph) error {
+	now := time.Now().UTC().Format(stixTimestamp)
+	identityID := stixID("identity", "GopherStrike")
+
+	objects := []map[string]interface{}{
+		{
+			"type":           "identity",
+			"spec_version":   "2.1",
+			"id":             identityID,
+			"created":        now,
+			"modified":       now,
+			"name":           "GopherStrike",
+			"identity_class": "system",
+		},
+	}
+
+	// Indicators for every network entity, keyed by value so findings can refer to them
+	indicators := make(map[string]string)
+	for _, entity := range entities {
+		pattern := stixPattern(entity)
+		if pattern == "" {
+			continue
+		}
+		id := stixID("indicator", pattern)
+		if _, exists := indicators[entity.Value]; exists {
+			continue
+		}
+		indicators[entity.Value] = id
+
+		validFrom := entity.Seen
+		if validFrom.IsZero() {
+			validFrom = time.Now()
+		}
+		objects = append(objects, map[string]interface{}{
+			"type":            "indicator",
+			"spec_version":    "2.1",
+			"id":              id,
+			"created":         now,
+			"modified":        now,
+			"created_by_ref":  identityID,
+			"name":            fmt.Sprintf("%s %s", entity.Type, entity.Value),
+			"description":     fmt.Sprintf("Discovered by GopherStrike for target %s", entity.Target),
+			"indicator_types": []string{"unknown"},
+			"pattern":         pattern,
+			"pattern_type":    "stix",
+			"valid_from":      validFrom.UTC().Format(stixTimestamp),
+			"labels":          []string{entity.Type},
+		})
+	}
+
+	// Vulnerabilities for every finding, related to the indicator of their host
+	hosts := make(map[string]string)
+	for _, edge := range graph.Edges {
+		if edge.Relation == RelHasFinding {
+			hosts[edge.To] = edge.From
+		}
+	}
+	for _, node := range graph.Nodes {
+		if node.Type != NodeFinding {
+			continue
+		}
+
+		host := strings.TrimPrefix(hosts[node.ID], "host:")
+		id := stixID("vulnerability", node.ID)
+		vuln := map[string]interface{}{
+			"type":           "vulnerability",
+			"spec_version":   "2.1",
+			"id":             id,
+			"created":        now,
+			"modified":       now,
+			"created_by_ref": identityID,
+			"name":           node.Label,
+			"description":    fmt.Sprintf("%s finding on %s", node.Properties["severity"], host),
+			"labels":         []string{node.Properties["severity"]},
+		}
+		if cve := cvePattern.FindString(node.Label); cve != "" {
+			vuln["external_references"] = []map[string]string{
+				{"source_name": "cve", "external_id": strings.ToUpper(cve)},
+			}
+		}
+		objects = append(objects, vuln)
+
+		if indicatorID, ok := indicators[host]; ok {
+			objects = append(objects, map[string]interface{}{
+				"type":              "relationship",
+				"spec_version":      "2.1",
+				"id":                stixID("relationship", indicatorID+id),
+				"created":           now,
+				"modified":          now,
+				"created_by_ref":    identityID,
+				"relationship_type": "related-to",
+				"source_ref":        id,
+				"target_ref":        indicatorID,
+			})
+		}
+	}
+
+	bundle := map[string]interface{}{
+		"type":    "bundle",
+		"id":      stixID("bundle", now),
+		"objects": objects,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bundle)
+}
+
+// stixPattern returns the STIX pattern matching an entity, or "" for unsupported types
+func stixPattern(entity Entity) string {
+	value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(entity.Value)
+	switch entity.Type {
+	case EntityDomain, EntityHostname:
+		return fmt.Sprintf("[domain-name:value = '%s']", value)
+	case EntityIPv4:
+		return fmt.Sprintf("[ipv4-addr:value = '%s']", value)
+	case EntityIPv6:
+		return fmt.Sprintf("[ipv6-addr:value = '%s']", value)
+	case EntityEmail:
+		return fmt.Sprintf("[email-addr:value = '%s']", value)
+	case EntityCertificate:
+		issuer := strings.SplitN(value, " (expires ", 2)[0]
+		return fmt.Sprintf("[x509-certificate:issuer = '%s']", issuer)
+	}
+	return ""
+}
+
+// stixID builds a deterministic STIX identifier (UUIDv5) for an object type and name
+func stixID(objectType, name string) string {
+	h := sha1.New()
+	h.Write(stixNamespace[:])
+	h.Write([]byte(objectType + ":" + name))
+	sum := h.Sum(nil)
+
+	sum[6] = (sum[6] & 0x0f) | 0x50 // Version 5
+	sum[8] = (sum[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%s--%x-%x-%x-%x-%x", objectType, sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
@@ -0,0 +1,309 @@
+// Package artifacts provides a central store that organizes tool output
+// per workspace and per target host, so results from different tools about
+// the same host end up together:
+//
+//	workspaces/<workspace>/targets/<host>/<kind>/<file>
+package artifacts
+
+import (
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/signing"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRoot is the directory holding all workspaces
+	DefaultRoot = "workspaces"
+
+	// DefaultWorkspace is used when no workspace has been selected
+	DefaultWorkspace = "default"
+
+	// WorkspaceEnvVar selects the active workspace
+	WorkspaceEnvVar = "GOPHERSTRIKE_WORKSPACE"
+
+	// targetsDir is the per-workspace directory holding target hierarchies
+	targetsDir = "targets"
+)
+
+// Kind identifies the category of an artifact within a target directory
+type Kind string
+
+const (
+	KindSubdomains Kind = "subdomains"
+	KindPorts      Kind = "ports"
+	KindWeb        Kind = "web"
+	KindReports    Kind = "reports"
+	KindDNS        Kind = "dns"
+	KindOSINT      Kind = "osint"
+	KindRecon      Kind = "recon"
+)
+
+// Artifact describes a single file held by the store
+type Artifact struct {
+	Workspace string    `json:"workspace"`
+	Target    string    `json:"target"`
+	Kind      Kind      `json:"kind"`
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+}
+
+// Store manages the artifact hierarchy of a single workspace
+type Store struct {
+	Root      string
+	Workspace string
+}
+
+// NewStore creates a store for the given root directory and workspace name
+func NewStore(root, workspace string) *Store {
+	if root == "" {
+		root = DefaultRoot
+	}
+	workspace = sanitizeComponent(workspace)
+	if workspace == "" {
+		workspace = DefaultWorkspace
+	}
+	return &Store{
+		Root:      root,
+		Workspace: workspace,
+	}
+}
+
+// Default returns a store for the workspace selected through the
+// GOPHERSTRIKE_WORKSPACE environment variable, falling back to "default"
+func Default() *Store {
+	return NewStore(DefaultRoot, os.Getenv(WorkspaceEnvVar))
+}
+
+// WorkspaceDir returns the root directory of the store's workspace
+func (s *Store) WorkspaceDir() string {
+	return filepath.Join(s.Root, s.Workspace)
+}
+
+// TargetDir returns the directory for a target and artifact kind, creating it if needed
+func (s *Store) TargetDir(target string, kind Kind) (string, error) {
+	host := NormalizeTarget(target)
+	if host == "" {
+		return "", fmt.Errorf("invalid target: %q", target)
+	}
+	if kind == "" {
+		return "", fmt.Errorf("artifact kind is required")
+	}
+
+	dir := filepath.Join(s.WorkspaceDir(), targetsDir, host, sanitizeComponent(string(kind)))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create artifact directory: %v", err)
+	}
+	return dir, nil
+}
+
+// Path returns the full path for a named artifact of a target, creating its directory
+func (s *Store) Path(target string, kind Kind, name string) (string, error) {
+	dir, err := s.TargetDir(target, kind)
+	if err != nil {
+		return "", err
+	}
+
+	name = filepath.Base(name)
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		return "", fmt.Errorf("invalid artifact name: %q", name)
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Lookup returns the full path of an existing artifact of a target
+func (s *Store) Lookup(target string, kind Kind, name string) (string, error) {
+	host := NormalizeTarget(target)
+	if host == "" {
+		return "", fmt.Errorf("invalid target: %q", target)
+	}
+
+	name = filepath.Base(name)
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		return "", fmt.Errorf("invalid artifact name: %q", name)
+	}
+
+	path := filepath.Join(s.WorkspaceDir(), targetsDir, host, sanitizeComponent(string(kind)), name)
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return "", fmt.Errorf("artifact not found: %s", name)
+	}
+	return path, nil
+}
+
+// WriteFile stores raw data as a named artifact, with its secrets redacted,
+// signs it if signing is on and returns its path
+func (s *Store) WriteFile(target string, kind Kind, name string, data []byte) (string, error) {
+	path, err := s.Path(target, kind, name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, redact.Bytes(data), 0644); err != nil {
+		return "", fmt.Errorf("failed to write artifact: %v", err)
+	}
+	if err := signing.SignFile(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// WriteJSON stores a value as an indented JSON artifact and returns its path
+func (s *Store) WriteJSON(target string, kind Kind, name string, v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode artifact: %v", err)
+	}
+	return s.WriteFile(target, kind, name, data)
+}
+
+// Targets returns the names of all targets with stored artifacts
+func (s *Store) Targets() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.WorkspaceDir(), targetsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	targets := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			targets = append(targets, entry.Name())
+		}
+	}
+	sort.Strings(targets)
+	return targets, nil
+}
+
+// List returns all artifacts of a target, or of every target when target is empty
+func (s *Store) List(target string) ([]Artifact, error) {
+	targets := []string{}
+	if target != "" {
+		targets = append(targets, NormalizeTarget(target))
+	} else {
+		all, err := s.Targets()
+		if err != nil {
+			return nil, err
+		}
+		targets = all
+	}
+
+	artifacts := []Artifact{}
+	for _, host := range targets {
+		hostDir := filepath.Join(s.WorkspaceDir(), targetsDir, host)
+		kinds, err := os.ReadDir(hostDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, kindEntry := range kinds {
+			if !kindEntry.IsDir() {
+				continue
+			}
+			kindDir := filepath.Join(hostDir, kindEntry.Name())
+			files, err := os.ReadDir(kindDir)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, file := range files {
+				// Signatures belong to the artifact they sign
+				if file.IsDir() || strings.HasSuffix(file.Name(), signing.Ext) {
+					continue
+				}
+				info, err := file.Info()
+				if err != nil {
+					continue
+				}
+				artifacts = append(artifacts, Artifact{
+					Workspace: s.Workspace,
+					Target:    host,
+					Kind:      Kind(kindEntry.Name()),
+					Name:      file.Name(),
+					Path:      filepath.Join(kindDir, file.Name()),
+					Size:      info.Size(),
+					ModTime:   info.ModTime(),
+				})
+			}
+		}
+	}
+
+	return artifacts, nil
+}
+
+// Glob returns the paths of artifacts of the given kind, across all targets,
+// whose file names match pattern
+func (s *Store) Glob(kind Kind, pattern string) ([]string, error) {
+	return filepath.Glob(filepath.Join(s.WorkspaceDir(), targetsDir, "*", sanitizeComponent(string(kind)), pattern))
+}
+
+// TimestampedName builds an artifact file name of the form prefix_YYYYMMDD_HHMMSS.ext
+func TimestampedName(prefix, ext string) string {
+	timestamp := time.Now().Format("20060102_150405")
+	ext = strings.TrimPrefix(ext, ".")
+	if prefix == "" {
+		return fmt.Sprintf("%s.%s", timestamp, ext)
+	}
+	return fmt.Sprintf("%s_%s.%s", sanitizeComponent(prefix), timestamp, ext)
+}
+
+// NormalizeTarget reduces a URL, host:port or hostname to the bare, lowercase
+// host name used as the target directory name
+func NormalizeTarget(target string) string {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return ""
+	}
+
+	if strings.Contains(target, "://") {
+		if u, err := url.Parse(target); err == nil && u.Host != "" {
+			target = u.Host
+		}
+	}
+
+	// Drop any path, query or fragment left over
+	if idx := strings.IndexAny(target, "/?#"); idx != -1 {
+		target = target[:idx]
+	}
+
+	// Strip userinfo and port
+	if idx := strings.LastIndex(target, "@"); idx != -1 {
+		target = target[idx+1:]
+	}
+	if host, _, err := net.SplitHostPort(target); err == nil {
+		target = host
+	}
+	target = strings.Trim(target, "[]")
+
+	return sanitizeComponent(strings.ToLower(strings.TrimSuffix(target, ".")))
+}
+
+// sanitizeComponent makes a string safe for use as a single path component
+func sanitizeComponent(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+
+	return strings.Trim(b.String(), ".")
+}
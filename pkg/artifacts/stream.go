@@ -0,0 +1,158 @@
+package artifacts
+
+import (
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/signing"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// StreamWriter writes results to an artifact as they are found, so that a
+// long scan that crashes or runs out of memory keeps what it found so far.
+// Every write goes straight to the file, with its secrets redacted; it is
+// safe for concurrent use.
+type StreamWriter struct {
+	mu    sync.Mutex
+	path  string
+	file  *os.File
+	count int
+}
+
+// NewStreamWriter creates or truncates the file at path for streaming
+func NewStreamWriter(path string) (*StreamWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	return &StreamWriter{path: path, file: file}, nil
+}
+
+// AppendStreamWriter opens the file at path for streaming after the records
+// it already holds, creating it if needed, for a scan resuming another
+func AppendStreamWriter(path string) (*StreamWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	return &StreamWriter{path: path, file: file}, nil
+}
+
+// CreateStream creates a named artifact of a target for streaming
+func (s *Store) CreateStream(target string, kind Kind, name string) (*StreamWriter, error) {
+	path, err := s.Path(target, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	return NewStreamWriter(path)
+}
+
+// Path returns the path of the streamed file
+func (w *StreamWriter) Path() string {
+	return w.path
+}
+
+// Count returns the number of records written with WriteJSON
+func (w *StreamWriter) Count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.count
+}
+
+// Write appends p to the file. Each call is written whole, so lines written
+// with a single call are never interleaved.
+func (w *StreamWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return 0, fmt.Errorf("%s is closed", w.path)
+	}
+	if _, err := w.file.Write(redact.Bytes(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteJSON appends a value as a single line of JSON
+func (w *StreamWriter) WriteJSON(v interface{}) error {
+	var line bytes.Buffer
+	if err := json.NewEncoder(&line).Encode(v); err != nil {
+		return fmt.Errorf("failed to encode result: %v", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return fmt.Errorf("%s is closed", w.path)
+	}
+	if _, err := w.file.Write(redact.Bytes(line.Bytes())); err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+// Close closes the file and signs it if signing is on. Closing a closed
+// writer does nothing.
+func (w *StreamWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		return err
+	}
+	return signing.SignFile(w.path)
+}
+
+// ReadStream calls fn with each record of a JSON lines artifact, including
+// one compressed by a cleanup. An incomplete last line, as left by a scan
+// that was killed while writing, is ignored.
+func ReadStream(path string, fn func(json.RawMessage) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var in io.Reader = file
+	if strings.HasSuffix(path, compressedExt) {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("failed to decompress %s: %v", path, err)
+		}
+		defer gz.Close()
+		in = gz
+	}
+
+	reader := bufio.NewReader(in)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// Without a newline the last record was cut short
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			return fmt.Errorf("invalid record in %s: %s", path, line)
+		}
+		if err := fn(json.RawMessage(line)); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Package bandwidth caps the bytes per second the scans send and receive,
+// all connections of the run sharing the cap, so that scans from constrained
+// links or against fragile targets stay within the agreed bandwidth. The cap
+// is set with --max-bandwidth and applied to the connections of the shared
+// HTTP transport; connections dialed while no cap is set are not throttled.
+package bandwidth
+
+import (
+	"GopherStrike/pkg/scope"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bucket is a token bucket of bytes. Tokens may go negative: a transfer
+// larger than the tokens left is let through, and the next ones wait for
+// the debt to be paid.
+type bucket struct {
+	mutex  sync.Mutex
+	rate   float64 // Bytes per second, 0 for no cap
+	tokens float64
+	last   time.Time
+}
+
+// limiter is the bucket shared by every throttled connection
+var limiter = &bucket{}
+
+// units are the multipliers of the size suffixes, in powers of 1024
+var units = map[string]float64{
+	"":  1,
+	"B": 1,
+	"K": 1 << 10, "KB": 1 << 10, "KIB": 1 << 10,
+	"M": 1 << 20, "MB": 1 << 20, "MIB": 1 << 20,
+	"G": 1 << 30, "GB": 1 << 30, "GIB": 1 << 30,
+}
+
+// Parse returns the bytes per second of a cap such as 512KB, 2MB or 1.5M,
+// with an optional /s suffix. Units are powers of 1024. An empty cap, or 0,
+// means no cap.
+func Parse(s string) (int64, error) {
+	size := strings.ToUpper(strings.TrimSpace(s))
+	size = strings.TrimSpace(strings.TrimSuffix(size, "/S"))
+	if size == "" {
+		return 0, nil
+	}
+	i := strings.IndexFunc(size, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(size)
+	}
+	value, err := strconv.ParseFloat(size[:i], 64)
+	unit, ok := units[strings.TrimSpace(size[i:])]
+	if err != nil || !ok || value < 0 || value*unit > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid bandwidth %q, use e.g. 512KB or 2MB", s)
+	}
+	return int64(value * unit), nil
+}
+
+// Format returns a cap in B/s, KB/s or MB/s
+func Format(bytesPerSecond int64) string {
+	switch {
+	case bytesPerSecond >= 1<<20:
+		return strconv.FormatFloat(float64(bytesPerSecond)/(1<<20), 'f', -1, 64) + " MB/s"
+	case bytesPerSecond >= 1<<10:
+		return strconv.FormatFloat(float64(bytesPerSecond)/(1<<10), 'f', -1, 64) + " KB/s"
+	}
+	return strconv.FormatInt(bytesPerSecond, 10) + " B/s"
+}
+
+// Set sets the cap in bytes per second; 0 removes it
+func Set(bytesPerSecond int64) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	limiter.rate = float64(bytesPerSecond)
+	limiter.tokens = limiter.burst()
+	limiter.last = time.Now()
+}
+
+// Limit returns the cap in bytes per second, 0 if there is none
+func Limit() int64 {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	return int64(limiter.rate)
+}
+
+// burst returns the bytes that may be transferred at once: a tenth of a
+// second of the cap, and at least 1 KB. The caller must hold the mutex.
+func (b *bucket) burst() float64 {
+	return math.Max(b.rate/10, 1024)
+}
+
+// chunk returns the most bytes a connection reads or writes at once
+func (b *bucket) chunk() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return int(b.burst())
+}
+
+// take takes n bytes from the bucket and returns how long to wait for them
+func (b *bucket) take(n int) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.rate <= 0 {
+		return 0
+	}
+	now := time.Now()
+	b.tokens = math.Min(b.burst(), b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// wait takes n bytes from the bucket, sleeping until they are available
+func (b *bucket) wait(n int) {
+	if delay := b.take(n); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// DialContext wraps a dial function so that the connections it dials share
+// the cap while one is set
+func DialContext(dial scope.DialFunc) scope.DialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		c, err := dial(ctx, network, address)
+		if err != nil || Limit() == 0 {
+			return c, err
+		}
+		return &conn{Conn: c, bucket: limiter}, nil
+	}
+}
+
+// conn is a connection throttled by a bucket
+type conn struct {
+	net.Conn
+	bucket *bucket
+}
+
+// Read reads at most a burst of bytes and waits for them to fit the cap
+func (c *conn) Read(p []byte) (int, error) {
+	if chunk := c.bucket.chunk(); len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := c.Conn.Read(p)
+	c.bucket.wait(n)
+	return n, err
+}
+
+// Write writes a burst of bytes at a time, each once it fits the cap
+func (c *conn) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		end := len(p)
+		if chunk := c.bucket.chunk(); end-written > chunk {
+			end = written + chunk
+		}
+		c.bucket.wait(end - written)
+		n, err := c.Conn.Write(p[written:end])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,89 @@
+package bandwidth
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]int64{
+		"":         0,
+		"0":        0,
+		"2048":     2048,
+		"512KB":    512 << 10,
+		"512 kb/s": 512 << 10,
+		"1.5M":     3 << 19,
+		"2MiB":     2 << 20,
+		"1g":       1 << 30,
+	}
+	for s, want := range tests {
+		if got, err := Parse(s); err != nil || got != want {
+			t.Errorf("Parse(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"fast", "10TB", "-5KB", "1.2.3MB"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) succeeded", s)
+		}
+	}
+
+	for bytes, want := range map[int64]string{512: "512 B/s", 512 << 10: "512 KB/s", 3 << 19: "1.5 MB/s"} {
+		if got := Format(bytes); got != want {
+			t.Errorf("Format(%d) = %q, want %q", bytes, got, want)
+		}
+	}
+}
+
+func TestDialContext(t *testing.T) {
+	defer Set(0)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	received := make(chan int64, 2)
+	go func() {
+		for {
+			server, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			n, _ := io.Copy(io.Discard, server)
+			received <- n
+		}
+	}()
+
+	var dialer net.Dialer
+	dial := DialContext(dialer.DialContext)
+	send := func() time.Duration {
+		conn, err := dial(context.Background(), "tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("dial error = %v", err)
+		}
+		start := time.Now()
+		if n, err := conn.Write(make([]byte, 40<<10)); n != 40<<10 || err != nil {
+			t.Fatalf("Write() = %d, %v", n, err)
+		}
+		elapsed := time.Since(start)
+		conn.Close()
+		if n := <-received; n != 40<<10 {
+			t.Errorf("received %d bytes, want %d", n, 40<<10)
+		}
+		return elapsed
+	}
+
+	// 40 KB at 100 KB/s, the first 10 KB at once
+	Set(100 << 10)
+	if elapsed := send(); elapsed < 250*time.Millisecond {
+		t.Errorf("40 KB sent in %s at 100 KB/s", elapsed)
+	}
+
+	Set(0)
+	if elapsed := send(); elapsed > 200*time.Millisecond {
+		t.Errorf("40 KB sent in %s without a cap", elapsed)
+	}
+}
@@ -0,0 +1,505 @@
+// Package browser drives a headless Chrome or Chromium through the DevTools
+// protocol, to see what a page does once its scripts run: the dialogs it
+// opens, what it logs to the console, its DOM and a screenshot of it.
+package browser
+
+import (
+	"GopherStrike/pkg/scope"
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Executables looked for on the PATH when no browser is given
+var Executables = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome", "msedge"}
+
+// origin is the only origin allowed on the DevTools endpoint, so that the
+// pages loaded, which may be hostile, can't drive the browser through it
+const origin = "http://127.0.0.1"
+
+// ErrNotFound is returned when no browser is installed
+var ErrNotFound = errors.New("no Chrome or Chromium browser found, install one or give its path")
+
+// Page is what a page did while it loaded
+type Page struct {
+	URL        string
+	Dialogs    []string // Messages of the alert, confirm and prompt dialogs it opened
+	Console    []string // Messages it logged to the console
+	DOM        string   // Document once loaded, after its scripts ran
+	Screenshot []byte   // PNG screenshot of the viewport
+	Blocked    []string // URLs it requested that are excluded from scanning
+}
+
+// Browser is a running headless browser
+type Browser struct {
+	cmd      *exec.Cmd
+	dir      string      // Profile directory, removed on Close
+	endpoint string      // Base URL of the DevTools HTTP endpoint
+	proxy    *localProxy // Proxy of all the browser's connections
+}
+
+// Find returns the path of the browser executable: path itself if given,
+// otherwise the first of Executables on the PATH
+func Find(path string) (string, error) {
+	if path != "" {
+		return exec.LookPath(path)
+	}
+	for _, name := range Executables {
+		if found, err := exec.LookPath(name); err == nil {
+			return found, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// Launch starts a headless browser with a throwaway profile. Every
+// connection of the browser, loopback ones included, goes through a local
+// proxy that connects with dial and ctx, or scope.DialContext if dial is
+// nil, so hostnames are resolved by dial rather than the browser.
+func Launch(ctx context.Context, path string, dial scope.DialFunc) (*Browser, error) {
+	path, err := Find(path)
+	if err != nil {
+		return nil, err
+	}
+	if dial == nil {
+		dial = scope.DialContext(nil)
+	}
+	proxy, err := startProxy(ctx, dial)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "gopherstrike-browser-")
+	if err != nil {
+		proxy.close()
+		return nil, err
+	}
+
+	args := []string{
+		"--headless=new",
+		"--disable-gpu",
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--disable-extensions",
+		"--hide-scrollbars",
+		"--mute-audio",
+		"--window-size=1280,800",
+		"--remote-debugging-address=127.0.0.1",
+		"--remote-debugging-port=0",
+		"--remote-allow-origins=" + origin,
+		"--user-data-dir=" + dir,
+		"--proxy-server=http://" + proxy.address(),
+		// Loopback addresses bypass the proxy unless told otherwise
+		"--proxy-bypass-list=<-loopback>",
+		// Nor does the browser look up names itself, to prefetch them
+		"--host-resolver-rules=MAP * ~NOTFOUND , EXCLUDE 127.0.0.1",
+	}
+	// Chrome refuses to run its sandbox as root
+	if os.Geteuid() == 0 {
+		args = append(args, "--no-sandbox")
+	}
+	cmd := exec.Command(path, append(args, "about:blank")...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		proxy.close()
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		proxy.close()
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to start %s: %v", path, err)
+	}
+	b := &Browser{cmd: cmd, dir: dir, proxy: proxy}
+
+	// The browser prints its DevTools address once it listens
+	found := make(chan string, 1)
+	go func() {
+		pattern := regexp.MustCompile(`DevTools listening on ws://([^/\s]+)/`)
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			if match := pattern.FindStringSubmatch(scanner.Text()); match != nil {
+				found <- match[1]
+				break
+			}
+		}
+		io.Copy(io.Discard, stderr)
+		close(found)
+	}()
+
+	timer := time.NewTimer(30 * time.Second)
+	defer timer.Stop()
+	select {
+	case address, ok := <-found:
+		if !ok {
+			b.Close()
+			return nil, fmt.Errorf("%s exited before it could be driven", path)
+		}
+		b.endpoint = "http://" + address
+		return b, nil
+	case <-timer.C:
+		b.Close()
+		return nil, fmt.Errorf("%s did not start in time", path)
+	case <-ctx.Done():
+		b.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// Close stops the browser and removes its profile
+func (b *Browser) Close() error {
+	if b.cmd != nil && b.cmd.Process != nil {
+		b.cmd.Process.Kill()
+		b.cmd.Wait()
+	}
+	if b.proxy != nil {
+		b.proxy.close()
+	}
+	if b.dir != "" {
+		return os.RemoveAll(b.dir)
+	}
+	return nil
+}
+
+// target is a tab as listed by the DevTools HTTP endpoint
+type target struct {
+	ID                   string `json:"id"`
+	WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+}
+
+// Load opens a URL in a new tab, sending headers with its requests to the
+// origin of the URL, and returns what the page did until it loaded and
+// settle elapsed. The requests to other origins, such as third-party
+// scripts, go without the headers, which may hold credentials. Requests
+// that scope.CheckUnresolved refuses with ctx are blocked; the addresses of
+// the others are checked when the browser's proxy connects to them. Dialogs are accepted as
+// they open so that the page goes on loading.
+func (b *Browser) Load(ctx context.Context, pageURL string, headers map[string]string, settle time.Duration) (*Page, error) {
+	start, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	tab, err := b.newTab(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer b.closeTab(tab)
+
+	config, err := websocket.NewConfig(tab.WebSocketDebuggerURL, origin)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the browser: %v", err)
+	}
+	defer ws.Close()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c := newConn(ctx, ws)
+
+	page := &Page{URL: pageURL}
+	loaded := false
+	c.events = func(method string, params json.RawMessage) {
+		switch method {
+		case "Page.javascriptDialogOpening":
+			var dialog struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			}
+			json.Unmarshal(params, &dialog)
+			page.Dialogs = append(page.Dialogs, fmt.Sprintf("%s(%q)", dialog.Type, dialog.Message))
+			c.send("Page.handleJavaScriptDialog", map[string]interface{}{"accept": true})
+		case "Runtime.consoleAPICalled":
+			var call struct {
+				Type string `json:"type"`
+				Args []struct {
+					Value       interface{} `json:"value"`
+					Description string      `json:"description"`
+				} `json:"args"`
+			}
+			json.Unmarshal(params, &call)
+			values := []string{}
+			for _, arg := range call.Args {
+				if arg.Value != nil {
+					values = append(values, fmt.Sprint(arg.Value))
+				} else {
+					values = append(values, arg.Description)
+				}
+			}
+			page.Console = append(page.Console, call.Type+": "+strings.Join(values, " "))
+		case "Page.loadEventFired":
+			loaded = true
+		case "Fetch.requestPaused":
+			var paused pausedRequest
+			json.Unmarshal(params, &paused)
+			// Redirects and resources are held to the exclusions as well,
+			// without a lookup here that would leak names a proxy resolves
+			if err := scope.CheckUnresolved(ctx, paused.Request.URL); err != nil {
+				page.Blocked = append(page.Blocked, paused.Request.URL)
+				c.send("Fetch.failRequest", map[string]string{"requestId": paused.RequestID, "errorReason": "BlockedByClient"})
+				return
+			}
+			c.send("Fetch.continueRequest", paused.continueParams(start, headers))
+		}
+	}
+
+	// Every request is paused until it is checked against the exclusions
+	// and continueParams decides on its headers
+	for _, method := range []string{"Page.enable", "Runtime.enable", "Network.enable"} {
+		if _, err := c.call(method, nil); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := c.call("Fetch.enable", map[string]interface{}{"patterns": []map[string]string{{"urlPattern": "*"}}}); err != nil {
+		return nil, err
+	}
+	if _, err := c.call("Page.navigate", map[string]interface{}{"url": pageURL}); err != nil {
+		return nil, err
+	}
+
+	// Wait for the load event, then give timers and handlers time to fire
+	for !loaded {
+		if err := c.next(nil); err != nil {
+			return nil, err
+		}
+	}
+	wait := time.After(settle)
+	for {
+		err := c.next(wait)
+		if err == errSettled {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := c.call("Runtime.evaluate", map[string]interface{}{"expression": "document.documentElement.outerHTML", "returnByValue": true})
+	if err != nil {
+		return nil, err
+	}
+	var dom struct {
+		Result struct {
+			Value string `json:"value"`
+		} `json:"result"`
+	}
+	json.Unmarshal(result, &dom)
+	page.DOM = dom.Result.Value
+
+	result, err = c.call("Page.captureScreenshot", map[string]interface{}{"format": "png"})
+	if err != nil {
+		return nil, err
+	}
+	var screenshot struct {
+		Data string `json:"data"`
+	}
+	json.Unmarshal(result, &screenshot)
+	if page.Screenshot, err = base64.StdEncoding.DecodeString(screenshot.Data); err != nil {
+		return nil, fmt.Errorf("invalid screenshot: %v", err)
+	}
+	return page, nil
+}
+
+// pausedRequest is a request held by the Fetch domain
+type pausedRequest struct {
+	RequestID string `json:"requestId"`
+	Request   struct {
+		URL     string            `json:"url"`
+		Headers map[string]string `json:"headers"`
+	} `json:"request"`
+}
+
+// headerEntry is a header of Fetch.continueRequest
+type headerEntry struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// continueParams returns the parameters continuing a paused request: with
+// headers added if it goes to the origin of start, and without them
+// otherwise, as they may be carried over from a redirect
+func (p pausedRequest) continueParams(start *url.URL, headers map[string]string) map[string]interface{} {
+	params := map[string]interface{}{"requestId": p.RequestID}
+	if len(headers) == 0 {
+		return params
+	}
+
+	allowed := false
+	if u, err := url.Parse(p.Request.URL); err == nil {
+		allowed = u.Scheme == start.Scheme && strings.EqualFold(u.Host, start.Host)
+	}
+	entries := []headerEntry{}
+	changed := allowed
+	for name, value := range p.Request.Headers {
+		if hasHeader(headers, name) {
+			changed = true
+			continue
+		}
+		entries = append(entries, headerEntry{name, value})
+	}
+	if !changed {
+		return params
+	}
+	if allowed {
+		for name, value := range headers {
+			entries = append(entries, headerEntry{name, value})
+		}
+	}
+	params["headers"] = entries
+	return params
+}
+
+// hasHeader reports whether headers hold a header, whatever the case of its
+// name
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// newTab opens a blank tab
+func (b *Browser) newTab(ctx context.Context) (target, error) {
+	var tab target
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, b.endpoint+"/json/new?about:blank", nil)
+	if err != nil {
+		return tab, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return tab, fmt.Errorf("failed to open a tab: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return tab, fmt.Errorf("failed to open a tab: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tab); err != nil {
+		return tab, fmt.Errorf("failed to open a tab: %v", err)
+	}
+	return tab, nil
+}
+
+// closeTab closes a tab opened by newTab
+func (b *Browser) closeTab(tab target) {
+	resp, err := http.Get(b.endpoint + "/json/close/" + url.PathEscape(tab.ID))
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+// conn is a DevTools protocol connection to a tab. Calls are synchronous:
+// events received while waiting for a response are passed to events.
+type conn struct {
+	ws       *websocket.Conn
+	ctx      context.Context
+	events   func(method string, params json.RawMessage)
+	lastID   int
+	replies  map[int]message
+	incoming chan message
+	err      error // Why incoming was closed
+}
+
+// message is a command, response or event of the protocol
+type message struct {
+	ID     int             `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// errSettled is returned by next when its wait is over
+var errSettled = errors.New("no more messages")
+
+// newConn starts reading the messages of a connection, until ctx is done
+func newConn(ctx context.Context, ws *websocket.Conn) *conn {
+	c := &conn{ws: ws, ctx: ctx, replies: make(map[int]message), incoming: make(chan message)}
+	go func() {
+		defer close(c.incoming)
+		for {
+			var m message
+			if err := websocket.JSON.Receive(ws, &m); err != nil {
+				c.err = err
+				return
+			}
+			select {
+			case c.incoming <- m:
+			case <-ctx.Done():
+				c.err = ctx.Err()
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// send sends a command without waiting for its response and returns its ID
+func (c *conn) send(method string, params interface{}) (int, error) {
+	c.lastID++
+	command := struct {
+		ID     int         `json:"id"`
+		Method string      `json:"method"`
+		Params interface{} `json:"params,omitempty"`
+	}{c.lastID, method, params}
+	return c.lastID, websocket.JSON.Send(c.ws, command)
+}
+
+// call sends a command and returns its result
+func (c *conn) call(method string, params interface{}) (json.RawMessage, error) {
+	id, err := c.send(method, params)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if reply, ok := c.replies[id]; ok {
+			delete(c.replies, id)
+			if reply.Error != nil {
+				return nil, fmt.Errorf("%s: %s", method, reply.Error.Message)
+			}
+			return reply.Result, nil
+		}
+		if err := c.next(nil); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// next handles one message, keeping responses for call and passing events
+// on. It returns errSettled if none came before wait fires.
+func (c *conn) next(wait <-chan time.Time) error {
+	select {
+	case m, ok := <-c.incoming:
+		if !ok {
+			return c.err
+		}
+		if m.Method != "" {
+			if c.events != nil {
+				c.events(m.Method, m.Params)
+			}
+		} else {
+			c.replies[m.ID] = m
+		}
+		return nil
+	case <-wait:
+		return errSettled
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	}
+}
@@ -0,0 +1,235 @@
+package browser
+
+import (
+	"GopherStrike/pkg/scope"
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// devtools fakes the DevTools endpoint of a browser loading a page that
+// opens an alert and logs to the console
+func devtools(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json/new", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "use PUT", http.StatusMethodNotAllowed)
+			return
+		}
+		json.NewEncoder(w).Encode(target{ID: "tab", WebSocketDebuggerURL: "ws" + strings.TrimPrefix(server.URL, "http") + "/devtools/page/tab"})
+	})
+	mux.HandleFunc("/json/close/tab", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Handle("/devtools/page/tab", websocket.Handler(func(ws *websocket.Conn) {
+		if got := ws.Config().Origin.String(); got != origin {
+			t.Errorf("origin = %s, want %s", got, origin)
+		}
+		event := func(method string, params interface{}) {
+			websocket.JSON.Send(ws, map[string]interface{}{"method": method, "params": params})
+		}
+		reply := func(id int, result interface{}) {
+			websocket.JSON.Send(ws, map[string]interface{}{"id": id, "result": result})
+		}
+
+		// The page loads a third-party script and redirects to another site
+		// with the headers of the page carried over
+		requests := map[string]map[string]string{
+			"page":     {"url": "https://example.com/?q=<svg>", "User-Agent": "Chrome"},
+			"cdn":      {"url": "https://cdn.example.net/app.js", "User-Agent": "Chrome"},
+			"redirect": {"url": "https://example.org/", "Cookie": "session=1"},
+		}
+		continued := 0
+		for {
+			var command struct {
+				ID     int                    `json:"id"`
+				Method string                 `json:"method"`
+				Params map[string]interface{} `json:"params"`
+			}
+			if err := websocket.JSON.Receive(ws, &command); err != nil {
+				return
+			}
+			switch command.Method {
+			case "Page.navigate":
+				reply(command.ID, map[string]string{"frameId": "frame"})
+				for id, request := range requests {
+					headers := map[string]string{}
+					for name, value := range request {
+						if name != "url" {
+							headers[name] = value
+						}
+					}
+					event("Fetch.requestPaused", map[string]interface{}{"requestId": id, "request": map[string]interface{}{"url": request["url"], "headers": headers}})
+				}
+			case "Fetch.failRequest":
+				reply(command.ID, struct{}{})
+				if id := command.Params["requestId"]; id != "cdn" {
+					t.Errorf("request %s blocked, want the excluded one only", id)
+				}
+				if continued++; continued == len(requests) {
+					event("Page.javascriptDialogOpening", map[string]string{"type": "alert", "message": "XSS"})
+				}
+			case "Fetch.continueRequest":
+				reply(command.ID, struct{}{})
+				if id := command.Params["requestId"]; id == "cdn" {
+					t.Error("the excluded request was continued")
+				}
+				id, _ := command.Params["requestId"].(string)
+				sent := map[string]string{}
+				entries, rewritten := command.Params["headers"].([]interface{})
+				for _, entry := range entries {
+					header, _ := entry.(map[string]interface{})
+					sent[header["name"].(string)] = header["value"].(string)
+				}
+				if id == "page" && (sent["Cookie"] != "session=1" || sent["User-Agent"] != "Chrome") {
+					t.Errorf("page headers = %v, want the session cookie added", sent)
+				}
+				if id != "page" && rewritten && sent["Cookie"] != "" {
+					t.Errorf("%s headers = %v, want no session cookie", id, sent)
+				}
+				if id == "redirect" && !rewritten {
+					t.Error("the cookie carried over to another site was kept")
+				}
+				// The page blocks on its dialog until it is handled
+				if continued++; continued == len(requests) {
+					event("Page.javascriptDialogOpening", map[string]string{"type": "alert", "message": "XSS"})
+				}
+			case "Page.handleJavaScriptDialog":
+				reply(command.ID, struct{}{})
+				event("Runtime.consoleAPICalled", map[string]interface{}{"type": "log", "args": []map[string]interface{}{{"value": "fired"}, {"value": 1}}})
+				event("Page.loadEventFired", map[string]float64{"timestamp": 1})
+			case "Runtime.evaluate":
+				reply(command.ID, map[string]interface{}{"result": map[string]string{"type": "string", "value": "<html><body><svg onload=\"alert('XSS')\"></svg></body></html>"}})
+			case "Page.captureScreenshot":
+				reply(command.ID, map[string]string{"data": base64.StdEncoding.EncodeToString([]byte("\x89PNG"))})
+			default:
+				reply(command.ID, struct{}{})
+			}
+		}
+	}))
+	server = httptest.NewServer(mux)
+	return server
+}
+
+func TestLoad(t *testing.T) {
+	server := devtools(t)
+	defer server.Close()
+
+	exclusions, err := scope.Parse(strings.NewReader("*.example.net\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope.Set(exclusions)
+	defer scope.Set(nil)
+
+	b := &Browser{endpoint: server.URL}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	page, err := b.Load(ctx, "https://example.com/?q=<svg>", map[string]string{"Cookie": "session=1"}, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(page.Dialogs) != 1 || page.Dialogs[0] != `alert("XSS")` {
+		t.Errorf("Dialogs = %q, want the alert", page.Dialogs)
+	}
+	if len(page.Console) != 1 || page.Console[0] != "log: fired 1" {
+		t.Errorf("Console = %q, want the log", page.Console)
+	}
+	if len(page.Blocked) != 1 || page.Blocked[0] != "https://cdn.example.net/app.js" {
+		t.Errorf("Blocked = %q, want the excluded script", page.Blocked)
+	}
+	if !strings.Contains(page.DOM, "onload") || string(page.Screenshot) != "\x89PNG" {
+		t.Errorf("DOM = %q, Screenshot = %q", page.DOM, page.Screenshot)
+	}
+}
+
+func TestFind(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if _, err := Find(""); err != ErrNotFound {
+		t.Errorf("Find() error = %v, want ErrNotFound", err)
+	}
+	if _, err := Find("no-such-browser"); err == nil {
+		t.Error("Find() of a missing executable succeeded")
+	}
+}
+
+func TestProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page of " + r.Host))
+	}))
+	defer backend.Close()
+
+	// Names reach dial as the browser gave them, unresolved
+	var dialed []string
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		if strings.HasPrefix(address, "excluded.example:") {
+			return nil, &scope.ExcludedError{Target: address, Rule: "excluded.example"}
+		}
+		return net.Dial(network, backend.Listener.Addr().String())
+	}
+	p, err := startProxy(context.Background(), dial)
+	if err != nil {
+		t.Fatalf("startProxy() error = %v", err)
+	}
+	defer p.close()
+	proxyURL, _ := url.Parse("http://" + p.address())
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	resp, err := client.Get("http://target.example/")
+	if err != nil {
+		t.Fatalf("GET through the proxy error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "page of target.example" {
+		t.Errorf("forwarded body = %q", body)
+	}
+
+	resp, err = client.Get("http://excluded.example/")
+	if err != nil {
+		t.Fatalf("GET through the proxy error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("excluded target status = %d, want 403", resp.StatusCode)
+	}
+
+	// Tunnels carry the connections to HTTPS sites
+	conn, err := net.Dial("tcp", p.address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("CONNECT target.example:443 HTTP/1.1\r\nHost: target.example:443\r\n\r\n"))
+	reader := bufio.NewReader(conn)
+	tunnel, err := http.ReadResponse(reader, nil)
+	if err != nil || tunnel.StatusCode != http.StatusOK {
+		t.Fatalf("CONNECT response = %v, %v", tunnel, err)
+	}
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: tunneled.example\r\nConnection: close\r\n\r\n"))
+	resp, err = http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("response through the tunnel error = %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	if string(body) != "page of tunneled.example" {
+		t.Errorf("tunneled body = %q", body)
+	}
+
+	want := "target.example:80 excluded.example:80 target.example:443"
+	if got := strings.Join(dialed, " "); got != want {
+		t.Errorf("dialed %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,153 @@
+package browser
+
+import (
+	"GopherStrike/pkg/scope"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+	"time"
+)
+
+// localProxy is the HTTP proxy the browser sends all its connections to.
+// It connects with the dial function of the caller, so that the browser
+// follows the same routes and exclusions as the other connections of the
+// scan, and hostnames are resolved, or not, the way dial resolves them
+// rather than by the browser.
+type localProxy struct {
+	listener net.Listener
+	server   *http.Server
+	dial     scope.DialFunc
+	ctx      context.Context
+	forward  *httputil.ReverseProxy
+
+	mu      sync.Mutex
+	tunnels map[net.Conn]bool
+}
+
+// startProxy listens on a loopback port for the browser's connections,
+// dialing them with ctx, which carries the targets allowed to the scan
+func startProxy(ctx context.Context, dial scope.DialFunc) (*localProxy, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	p := &localProxy{listener: listener, dial: dial, ctx: ctx, tunnels: map[net.Conn]bool{}}
+	p.forward = &httputil.ReverseProxy{
+		// Requests to a proxy already hold the absolute URL to forward to
+		Rewrite: func(r *httputil.ProxyRequest) {},
+		Transport: &http.Transport{
+			DialContext:           p.dialContext,
+			ResponseHeaderTimeout: time.Minute,
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), proxyStatus(err))
+		},
+	}
+	p.server = &http.Server{Handler: p, ErrorLog: log.New(io.Discard, "", 0)}
+	go p.server.Serve(listener)
+	return p, nil
+}
+
+// address is the host:port of the proxy
+func (p *localProxy) address() string {
+	return p.listener.Addr().String()
+}
+
+// dialContext connects to address with the proxy's context, for as long
+// as the request asking for it is going on
+func (p *localProxy) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	dialCtx, cancel := context.WithCancel(p.ctx)
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+	return p.dial(dialCtx, network, address)
+}
+
+func (p *localProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		if !r.URL.IsAbs() {
+			http.Error(w, "not a proxy request", http.StatusBadRequest)
+			return
+		}
+		p.forward.ServeHTTP(w, r)
+		return
+	}
+
+	upstream, err := p.dialContext(r.Context(), "tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), proxyStatus(err))
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "tunnels not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, buffered, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	p.track(conn, true)
+	defer p.track(conn, false)
+	defer conn.Close()
+	defer upstream.Close()
+
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		return
+	}
+	// The browser may have sent the start of the tunnel with the request
+	if n := buffered.Reader.Buffered(); n > 0 {
+		start, _ := buffered.Reader.Peek(n)
+		if _, err := upstream.Write(start); err != nil {
+			return
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		io.Copy(upstream, conn)
+		upstream.Close()
+		close(done)
+	}()
+	io.Copy(conn, upstream)
+	conn.Close()
+	<-done
+}
+
+// track records the open tunnels, which the server doesn't close once
+// hijacked
+func (p *localProxy) track(conn net.Conn, open bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if open {
+		p.tunnels[conn] = true
+	} else {
+		delete(p.tunnels, conn)
+	}
+}
+
+// close stops the proxy and its connections
+func (p *localProxy) close() {
+	p.server.Close()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for conn := range p.tunnels {
+		conn.Close()
+	}
+}
+
+// proxyStatus is the status answering a connection that failed with err:
+// 403 for the excluded targets, 502 otherwise
+func proxyStatus(err error) int {
+	var excluded *scope.ExcludedError
+	if errors.As(err, &excluded) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadGateway
+}
@@ -0,0 +1,117 @@
+// Package budget bounds the time scans may take. The run budget, set with
+// --max-duration before the command, covers every scan of a run; a tool may
+// also be given a budget of its own with its --max-duration option. A scan
+// whose budget elapses is canceled like an interrupted one: it starts no new
+// work, and the results gathered so far are saved as usual. The scans cut
+// short are recorded so that the run summary and the reports can note that
+// their coverage is incomplete.
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	mutex    sync.Mutex
+	limit    time.Duration // Run budget, 0 for no limit
+	deadline time.Time     // End of the run budget, zero for no limit
+	scans    []scan        // Scans with a budget in this run
+)
+
+// ErrExceeded is the cause of the cancellation of a scan whose budget elapsed
+var ErrExceeded = errors.New("time budget exceeded")
+
+// scan is a scan with a budget
+type scan struct {
+	tool   string
+	budget time.Duration
+	ctx    context.Context
+}
+
+// Parse returns the duration of a budget such as 90s, 45m or 1h30m. An
+// empty budget, or 0, means no limit.
+func Parse(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, use e.g. 30m or 2h", s)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid duration %q, it cannot be negative", s)
+	}
+	return d, nil
+}
+
+// SetLimit sets the run budget, started by Start; 0 removes it
+func SetLimit(d time.Duration) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	limit = d
+}
+
+// Limit returns the run budget, 0 if there is none
+func Limit() time.Duration {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return limit
+}
+
+// Start starts a run: the run budget begins to elapse and the scans stopped
+// in the previous run are forgotten
+func Start() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	deadline = time.Time{}
+	if limit > 0 {
+		deadline = time.Now().Add(limit)
+	}
+	scans = nil
+}
+
+// Context returns a context for a scan by tool that is canceled when the
+// run budget or the tool's own budget, if it is not 0, elapses, whichever
+// comes first. The scan is recorded as stopped if a budget cut it short.
+func Context(parent context.Context, tool string, own time.Duration) (context.Context, context.CancelFunc) {
+	mutex.Lock()
+	end, budget := deadline, limit
+	mutex.Unlock()
+	if own > 0 && (end.IsZero() || time.Now().Add(own).Before(end)) {
+		end, budget = time.Now().Add(own), own
+	}
+	if end.IsZero() {
+		return context.WithCancel(parent)
+	}
+
+	ctx, cancel := context.WithDeadlineCause(parent, end, ErrExceeded)
+	mutex.Lock()
+	scans = append(scans, scan{tool: tool, budget: budget, ctx: ctx})
+	mutex.Unlock()
+	return ctx, cancel
+}
+
+// Exceeded reports whether a scan's context was canceled as its budget
+// elapsed, rather than by an interruption or as the scan finished
+func Exceeded(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrExceeded)
+}
+
+// Stopped returns notes on the scans of the run a budget cut short
+func Stopped() []string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	notes := []string{}
+	for _, s := range scans {
+		if Exceeded(s.ctx) {
+			notes = append(notes, fmt.Sprintf("%s stopped after its %s time budget, its coverage is incomplete", s.tool, s.budget))
+		}
+	}
+	return notes
+}
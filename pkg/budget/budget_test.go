@@ -0,0 +1,75 @@
+package budget
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]time.Duration{"": 0, "0": 0, "90s": 90 * time.Second, " 1h30m ": 90 * time.Minute}
+	for s, want := range tests {
+		if got, err := Parse(s); err != nil || got != want {
+			t.Errorf("Parse(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"30", "-5m", "soon"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) succeeded", s)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	defer func() { SetLimit(0); Start() }()
+
+	// Without a budget the scan only ends when it is canceled
+	SetLimit(0)
+	Start()
+	ctx, cancel := Context(context.Background(), "certcheck", 0)
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Context() without a budget has a deadline")
+	}
+	cancel()
+
+	// The tool's budget elapses
+	ctx, cancel = Context(context.Background(), "certcheck", 10*time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	if !Exceeded(ctx) {
+		t.Errorf("Exceeded() = false after the budget elapsed")
+	}
+
+	// An interrupted scan and a scan that finished in time are not noted
+	parent, interrupt := context.WithCancel(context.Background())
+	ctx, cancel = Context(parent, "sshaudit", time.Millisecond)
+	defer cancel()
+	interrupt()
+	time.Sleep(5 * time.Millisecond)
+	ctx, cancel = Context(context.Background(), "smtpcheck", time.Hour)
+	cancel()
+	if Exceeded(ctx) {
+		t.Error("Exceeded() = true for a scan that finished in time")
+	}
+	stopped := Stopped()
+	if len(stopped) != 1 || !strings.HasPrefix(stopped[0], "certcheck stopped after its 10ms time budget") {
+		t.Errorf("Stopped() = %q", stopped)
+	}
+
+	// The run budget bounds the tools' own budgets
+	SetLimit(10 * time.Millisecond)
+	Start()
+	if len(Stopped()) != 0 {
+		t.Errorf("Stopped() = %q after Start()", Stopped())
+	}
+	ctx, cancel = Context(context.Background(), "passive", time.Hour)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Second {
+		t.Errorf("Context() deadline = %v, %v, want the run deadline", deadline, ok)
+	}
+	<-ctx.Done()
+	if stopped := Stopped(); len(stopped) != 1 || !strings.Contains(stopped[0], "10ms") {
+		t.Errorf("Stopped() = %q", stopped)
+	}
+}
@@ -0,0 +1,129 @@
+// pkg/certcheck.go
+package pkg
+
+import (
+	"GopherStrike/pkg/budget"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/tools/audit/certcheck"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+// RunCertCheck checks the TLS certificates of a list of hosts and probes
+// them for known TLS flaws. It fails when a host has a problem or can't be
+// checked, so scheduled runs can alert on the exit status; every problem is
+// also published as a finding for the event hooks and SIEM output.
+func RunCertCheck(args []string) error {
+	// The preset provides the defaults the other flags override
+	preset, err := presets.FromArgs(args)
+	if err != nil {
+		return err
+	}
+	options, err := certcheck.PresetCheckOptions(preset)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("certcheck", flag.ContinueOnError)
+	fs.String("preset", preset, "Option preset: quick, normal or deep")
+	hostsFile := fs.String("hosts", "", "File with one host, host:port or URL per line")
+	fs.IntVar(&options.WarnDays, "warn-days", options.WarnDays, "Report certificates expiring within this many days")
+	fs.IntVar(&options.CriticalDays, "critical-days", options.CriticalDays, "Report certificates expiring within this many days as high severity")
+	fs.IntVar(&options.Timeout, "timeout", options.Timeout, "Connection timeout in seconds")
+	fs.IntVar(&options.Concurrency, "concurrency", options.Concurrency, "Hosts checked in parallel")
+	fs.BoolVar(&options.Probes, "probes", options.Probes, "Probe for Heartbleed, CCS injection, insecure renegotiation and ROBOT")
+	jsonOutput := fs.Bool("json", false, "Print the results as JSON")
+	output := fs.String("output", "", "Write the results to this file instead of stdout")
+	maxDuration := fs.Duration("max-duration", 0, "Stop checking after this long, e.g. 30m, and keep the partial results (0: no limit)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	hosts := fs.Args()
+	if *hostsFile != "" {
+		fileHosts, err := certcheck.LoadHosts(*hostsFile)
+		if err != nil {
+			return err
+		}
+		hosts = append(hosts, fileHosts...)
+	}
+	if len(hosts) == 0 {
+		return fmt.Errorf("no hosts to check, pass them as arguments or with --hosts")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := budget.Context(ctx, "certcheck", *maxDuration)
+	defer cancel()
+	results := certcheck.NewChecker(options).CheckHosts(ctx, hosts)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %v", err)
+		}
+		defer file.Close()
+		redacted := redact.Writer(file)
+		defer redacted.Close()
+		w = redacted
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+	} else {
+		printCertTable(w, results)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" || len(result.Problems) > 0 {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d host(s) failed the certificate check", failed, len(results))
+	}
+	return nil
+}
+
+// printCertTable renders the certificate check results as a text table
+// followed by the problems of each host
+func printCertTable(w io.Writer, results []certcheck.CertResult) {
+	fmt.Fprintf(w, "%-32s %-9s %-11s %-24s %s\n", "Host", "Days Left", "Expires", "Signature", "Problems")
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Fprintf(w, "%-32s %-9s %-11s %-24s %s\n", truncate(result.Address, 32), "-", "-", "-", "error")
+			continue
+		}
+		fmt.Fprintf(w, "%-32s %-9d %-11s %-24s %d\n", truncate(result.Address, 32), result.DaysLeft,
+			result.NotAfter.Format("2006-01-02"), result.SignatureAlgorithm, len(result.Problems))
+	}
+
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Fprintf(w, "\n[-] %s: %s\n", result.Host, result.Error)
+			continue
+		}
+		if len(result.Problems) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n[!] %s\n", result.Address)
+		for _, problem := range result.Problems {
+			if problem.Reference != "" {
+				fmt.Fprintf(w, "    [%s] %s (%s)\n", problem.Severity, problem.Description, problem.Reference)
+				continue
+			}
+			fmt.Fprintf(w, "    [%s] %s\n", problem.Severity, problem.Description)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// pkg/cleanup.go
+package pkg
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/config"
+	"GopherStrike/pkg/logging"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// day is the unit of the retention settings
+const day = 24 * time.Hour
+
+// RunCleanup applies the retention settings to the results of a workspace
+// and to the rotated log files
+func RunCleanup(args []string) error {
+	output := config.Get().Output
+	fs := flag.NewFlagSet("cleanup", flag.ContinueOnError)
+	workspace := fs.String("workspace", os.Getenv(artifacts.WorkspaceEnvVar), "Workspace to clean up")
+	maxAge := fs.Int("max-age", output.RetentionDays, "Remove results and rotated logs older than this many days (0 keeps them)")
+	maxSize := fs.Int("max-size", output.MaxResultsSizeMB, "Remove the oldest results until the workspace is below this many MB (0 is unlimited)")
+	compress := fs.Bool("compress", output.CompressResults, "Compress old JSON results with gzip")
+	compressAfter := fs.Int("compress-after", output.CompressAfterDays, "Compress JSON results older than this many days")
+	dryRun := fs.Bool("dry-run", false, "Show what would be removed or compressed without changing anything")
+	jsonOutput := fs.Bool("json", false, "Print the cleanup report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *maxAge < 0 || *maxSize < 0 || *compressAfter < 0 {
+		return fmt.Errorf("--max-age, --max-size and --compress-after cannot be negative")
+	}
+	if *maxAge == 0 && *maxSize == 0 && !*compress {
+		return fmt.Errorf("nothing to clean up: set --max-age, --max-size or --compress (or retention_days, max_results_size_mb or compress_results in the output settings)")
+	}
+
+	store := artifacts.NewStore(artifacts.DefaultRoot, *workspace)
+	report, err := store.Cleanup(artifacts.RetentionPolicy{
+		MaxAge:        time.Duration(*maxAge) * day,
+		MaxSize:       int64(*maxSize) << 20,
+		Compress:      *compress,
+		CompressAfter: time.Duration(*compressAfter) * day,
+		DryRun:        *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+	logs, logsFreed, err := logging.CleanupLogs(time.Duration(*maxAge)*day, *dryRun)
+	if err != nil {
+		return fmt.Errorf("failed to clean up logs: %v", err)
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(struct {
+			artifacts.CleanupReport
+			Logs      []string `json:"logs"`
+			LogsFreed int64    `json:"logs_freed"`
+			DryRun    bool     `json:"dry_run"`
+		}{report, logs, logsFreed, *dryRun}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	removeVerb, compressVerb := "Removed", "Compressed"
+	if *dryRun {
+		removeVerb, compressVerb = "Would remove", "Would compress"
+	}
+	for _, path := range append(report.Removed, logs...) {
+		fmt.Printf("[-] %s %s\n", removeVerb, path)
+	}
+	for _, path := range report.Compressed {
+		fmt.Printf("[+] %s %s\n", compressVerb, path)
+	}
+	fmt.Printf("[i] Workspace %s: %d result(s) removed, %d compressed, %s freed, %s of results left\n",
+		store.Workspace, len(report.Removed), len(report.Compressed), formatBytes(report.Freed), formatBytes(report.Size))
+	if len(logs) > 0 {
+		fmt.Printf("[i] Logs: %d rotated file(s) removed, %s freed\n", len(logs), formatBytes(logsFreed))
+	}
+	return nil
+}
+
+// formatBytes formats a size in bytes with a binary unit
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -2,10 +2,17 @@
 package config
 
 import (
+	"GopherStrike/pkg/bandwidth"
+	"GopherStrike/pkg/budget"
+	"GopherStrike/pkg/dedup"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/timing"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 )
@@ -29,6 +36,12 @@ type Config struct {
 	
 	// Tool-specific settings
 	Tools ToolsConfig `json:"tools"`
+	
+	// Hooks run on scan events
+	Hooks []HookConfig `json:"hooks"`
+	
+	// Severity policy applied to findings
+	Policy PolicyConfig `json:"policy"`
 }
 
 // GeneralConfig contains general application settings
@@ -59,6 +72,7 @@ type NetworkConfig struct {
 	UserAgent       string   `json:"user_agent"`        // Default user agent
 	DNSServers      []string `json:"dns_servers"`       // Custom DNS servers
 	RateLimit       int      `json:"rate_limit"`        // Requests per second
+	MaxBandwidth    string   `json:"max_bandwidth"`     // Bytes per second shared by all HTTP connections, e.g. 512KB; empty for no cap
 }
 
 // ScanningConfig contains scanning-related settings
@@ -69,6 +83,28 @@ type ScanningConfig struct {
 	SkipHostCheck    bool     `json:"skip_host_check"`    // Skip host availability check
 	SaveAllResults   bool     `json:"save_all_results"`   // Save all results, not just positive
 	AutoSaveInterval int      `json:"auto_save_interval"` // Auto-save interval in seconds
+	ExclusionsFile   string   `json:"exclusions_file"`    // Hosts, ranges and URLs that must never be scanned
+	Timing           string   `json:"timing"`             // Timing profile, T0 (paranoid) to T5 (insane); empty is normal
+	PII              PIIConfig `json:"pii"`               // Personal data detected in scanned content
+	IgnoreRobots       bool    `json:"ignore_robots"`         // Crawl without reading robots.txt or waiting for crawl delays
+	MaxRequestsPerHost int     `json:"max_requests_per_host"` // Requests a crawler sends to one host, 0 for no limit
+	Dedupe             string  `json:"dedupe"`                // Targets scanned when one stage feeds the next: all, or representative for one per group
+	MaxDuration        string  `json:"max_duration"`          // Time budget of a command-line run, e.g. 2h; empty for no limit
+}
+
+// PIIConfig selects the detectors of personal data applied to response
+// bodies and bucket listings
+type PIIConfig struct {
+	Detectors []string     `json:"detectors"` // Built-in detectors, e.g. credit_card, us_ssn, phone; empty enables them all
+	Custom    []PIIPattern `json:"custom"`    // Additional detectors
+}
+
+// PIIPattern is a custom detector of personal data
+type PIIPattern struct {
+	Name     string `json:"name"`     // e.g. employee_id
+	Pattern  string `json:"pattern"`  // Regular expression matching the data
+	Severity string `json:"severity"` // critical, high, medium, low or info
+	Luhn     bool   `json:"luhn"`     // Only keep matches whose digits pass the Luhn check
 }
 
 // OutputConfig contains output-related settings
@@ -80,7 +116,52 @@ type OutputConfig struct {
 	ColorOutput      bool     `json:"color_output"`       // Use colored output
 	TimestampFormat  string   `json:"timestamp_format"`   // Timestamp format
 	CompressResults  bool     `json:"compress_results"`   // Compress result files
+	CompressAfterDays int     `json:"compress_after_days"` // Age in days after which JSON results are compressed
+	RetentionDays    int      `json:"retention_days"`     // Remove results and rotated logs older than this many days (0 keeps them)
+	MaxResultsSizeMB int      `json:"max_results_size_mb"` // Remove the oldest results beyond this size per workspace (0 is unlimited)
+	MaxLogSizeMB     int      `json:"max_log_size_mb"`    // Rotate log files at this size (0 disables rotation)
+	LogBackups       int      `json:"log_backups"`        // Rotated log files kept per log
 	ExportFormats    []string `json:"export_formats"`     // Enabled export formats
+	SIEM             SIEMConfig `json:"siem"`             // SIEM streaming settings
+	LiveSeverity     string   `json:"live_severity"`      // Print findings of this severity or higher on the console as soon as they are found; "none" disables
+	SigningKey       string   `json:"signing_key"`        // Ed25519 PEM key signing results and reports, created if missing; empty disables signing
+}
+
+// SIEMConfig contains settings for streaming findings to a SIEM over syslog
+type SIEMConfig struct {
+	Enabled       bool   `json:"enabled"`         // Stream findings as they are discovered
+	Format        string `json:"format"`          // cef, leef
+	Protocol      string `json:"protocol"`        // udp, tcp, tls
+	Address       string `json:"address"`         // host:port of the syslog receiver
+	TLSSkipVerify bool   `json:"tls_skip_verify"` // Skip certificate verification for tls
+}
+
+// PolicyConfig adjusts the severity of findings and decides when a
+// command-line run fails
+type PolicyConfig struct {
+	FailOn    string             `json:"fail_on"`   // Exit with an error when a finding has this severity or higher (empty never fails)
+	Overrides []SeverityOverride `json:"overrides"` // Severity changes, the first matching override applies
+}
+
+// SeverityOverride sets the severity of the findings it matches. Empty
+// fields match any finding; in name and target, * matches any text.
+type SeverityOverride struct {
+	Tool     string `json:"tool"`     // e.g. webvuln, certcheck
+	Category string `json:"category"` // e.g. XSS, CVE
+	Name     string `json:"name"`     // e.g. "*TLS 1.0*"
+	Target   string `json:"target"`   // e.g. "*.staging.example.com"
+	Severity string `json:"severity"` // critical, high, medium, low or info
+}
+
+// HookConfig describes an action run when a scan event is published
+type HookConfig struct {
+	Event   string            `json:"event"`   // Event type, e.g. finding.new, or a pattern such as scan.* or *
+	Type    string            `json:"type"`    // command, webhook, file
+	Command []string          `json:"command"` // Program and arguments for command hooks; the event is passed as JSON on stdin
+	URL     string            `json:"url"`     // URL the event is POSTed to for webhook hooks
+	Headers map[string]string `json:"headers"` // Extra headers for webhook hooks
+	Path    string            `json:"path"`    // File events are appended to as JSON lines for file hooks
+	Timeout int               `json:"timeout"` // Timeout in seconds for commands and webhooks
 }
 
 // ToolsConfig contains tool-specific settings
@@ -127,12 +208,16 @@ var (
 	mu       sync.RWMutex
 )
 
-// Get returns the global configuration instance
+// Get returns the global configuration instance. Reload replaces the
+// instance instead of modifying it, so a configuration returned before a
+// reload keeps its settings consistent while it is read.
 func Get() *Config {
 	once.Do(func() {
 		instance = &Config{}
 		instance.LoadDefaults()
 	})
+	mu.RLock()
+	defer mu.RUnlock()
 	return instance
 }
 
@@ -171,6 +256,7 @@ func (c *Config) LoadDefaults() {
 		SkipHostCheck:    false,
 		SaveAllResults:   false,
 		AutoSaveInterval: 300,
+		MaxRequestsPerHost: 500,
 	}
 	
 	c.Output = OutputConfig{
@@ -181,7 +267,19 @@ func (c *Config) LoadDefaults() {
 		ColorOutput:      true,
 		TimestampFormat:  time.RFC3339,
 		CompressResults:  false,
+		CompressAfterDays: 7,
+		RetentionDays:    0,
+		MaxResultsSizeMB: 0,
+		MaxLogSizeMB:     10,
+		LogBackups:       5,
 		ExportFormats:    []string{"json", "csv", "txt"},
+		LiveSeverity:     "high",
+		SIEM: SIEMConfig{
+			Enabled:  false,
+			Format:   "cef",
+			Protocol: "udp",
+			Address:  "localhost:514",
+		},
 	}
 	
 	c.Tools = ToolsConfig{
@@ -266,6 +364,8 @@ func (c *Config) GetString(path string) string {
 		return c.Network.UserAgent
 	case "output.default_format":
 		return c.Output.DefaultFormat
+	case "scanning.exclusions_file":
+		return c.Scanning.ExclusionsFile
 	default:
 		return ""
 	}
@@ -363,9 +463,131 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("default threads must be between 1 and 100")
 	}
 	
+	if c.Scanning.MaxRequestsPerHost < 0 {
+		return fmt.Errorf("max requests per host cannot be negative")
+	}
+	
+	// Validate retention settings
+	if c.Output.CompressAfterDays < 0 || c.Output.RetentionDays < 0 || c.Output.MaxResultsSizeMB < 0 ||
+		c.Output.MaxLogSizeMB < 0 || c.Output.LogBackups < 0 {
+		return fmt.Errorf("retention and log rotation settings cannot be negative")
+	}
+	
+	// Validate the live console threshold
+	if c.Output.LiveSeverity != "" {
+		if _, err := model.ParseSeverity(c.Output.LiveSeverity); err != nil {
+			return fmt.Errorf("invalid live severity: %v", err)
+		}
+	}
+	
+	// Validate SIEM settings
+	if c.Output.SIEM.Enabled {
+		switch c.Output.SIEM.Format {
+		case "cef", "leef":
+		default:
+			return fmt.Errorf("invalid SIEM format: %s", c.Output.SIEM.Format)
+		}
+		switch c.Output.SIEM.Protocol {
+		case "udp", "tcp", "tls":
+		default:
+			return fmt.Errorf("invalid SIEM protocol: %s", c.Output.SIEM.Protocol)
+		}
+		if c.Output.SIEM.Address == "" {
+			return fmt.Errorf("SIEM address is required when SIEM output is enabled")
+		}
+	}
+	
+	// Validate hooks
+	for i, hook := range c.Hooks {
+		if err := hook.Validate(); err != nil {
+			return fmt.Errorf("hook %d: %v", i+1, err)
+		}
+	}
+	
+	// Validate the timing profile
+	if _, err := timing.Parse(c.Scanning.Timing); err != nil {
+		return err
+	}
+
+	// Validate the dedupe policy
+	if _, err := dedup.ParsePolicy(c.Scanning.Dedupe); err != nil {
+		return err
+	}
+
+	// Validate the bandwidth cap
+	if _, err := bandwidth.Parse(c.Network.MaxBandwidth); err != nil {
+		return fmt.Errorf("invalid max_bandwidth: %v", err)
+	}
+
+	// Validate the time budget
+	if _, err := budget.Parse(c.Scanning.MaxDuration); err != nil {
+		return fmt.Errorf("invalid max_duration: %v", err)
+	}
+	
+	// Validate the severity policy
+	if c.Policy.FailOn != "" {
+		if _, err := model.ParseSeverity(c.Policy.FailOn); err != nil {
+			return fmt.Errorf("invalid fail_on: %v", err)
+		}
+	}
+	for i, override := range c.Policy.Overrides {
+		if _, err := model.ParseSeverity(override.Severity); err != nil {
+			return fmt.Errorf("severity override %d: %v", i+1, err)
+		}
+	}
+	
+	// Validate the custom PII detectors
+	for i, pattern := range c.Scanning.PII.Custom {
+		if pattern.Name == "" {
+			return fmt.Errorf("custom PII detector %d: name is required", i+1)
+		}
+		if _, err := regexp.Compile(pattern.Pattern); err != nil {
+			return fmt.Errorf("custom PII detector %s: invalid pattern: %v", pattern.Name, err)
+		}
+		if _, err := model.ParseSeverity(pattern.Severity); err != nil {
+			return fmt.Errorf("custom PII detector %s: %v", pattern.Name, err)
+		}
+	}
+	
 	return nil
 }
 
+// Validate checks that a hook has the settings its type needs
+func (h HookConfig) Validate() error {
+	if h.Event == "" {
+		return fmt.Errorf("event is required")
+	}
+	switch h.Type {
+	case "command":
+		if len(h.Command) == 0 {
+			return fmt.Errorf("command is required for command hooks")
+		}
+	case "webhook":
+		if !strings.HasPrefix(h.URL, "http://") && !strings.HasPrefix(h.URL, "https://") {
+			return fmt.Errorf("webhook URL must be http or https: %q", h.URL)
+		}
+	case "file":
+		if h.Path == "" {
+			return fmt.Errorf("path is required for file hooks")
+		}
+	default:
+		return fmt.Errorf("invalid hook type: %s (use command, webhook or file)", h.Type)
+	}
+	if h.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+	return nil
+}
+
+// DefaultConfigFile returns the path of the user's configuration file, or
+// the file named by GOPHERSTRIKE_CONFIG
+func DefaultConfigFile() string {
+	if path := os.Getenv(FileEnvVar); path != "" {
+		return path
+	}
+	return filepath.Join(getHomeDir(), ".gopherstrike", "config.json")
+}
+
 // getHomeDir returns the user's home directory
 func getHomeDir() string {
 	home, err := os.UserHomeDir()
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// EnvPrefix starts the environment variables that override settings
+	EnvPrefix = "GOPHERSTRIKE_"
+
+	// FileEnvVar names the configuration file to use instead of the default one
+	FileEnvVar = "GOPHERSTRIKE_CONFIG"
+)
+
+// LoadFromEnv overrides settings with environment variables named after
+// their JSON path: GOPHERSTRIKE_NETWORK_TIMEOUT sets network.timeout and
+// GOPHERSTRIKE_OUTPUT_SIEM_ADDRESS sets output.siem.address. Lists are
+// comma-separated. Hooks and maps can only be set in the configuration file.
+func (c *Config) LoadFromEnv() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return loadEnv(reflect.ValueOf(c).Elem(), EnvPrefix)
+}
+
+// envName returns the environment variable of a struct field, or "" if the
+// field is not serialized
+func envName(field reflect.StructField, prefix string) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return ""
+	}
+	return prefix + strings.ToUpper(name)
+}
+
+// settableFromEnv reports whether a setting of type t can be written as an
+// environment variable
+func settableFromEnv(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// loadEnv sets the fields of v from the environment variables starting with prefix
+func loadEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := envName(field, prefix)
+		if key == "" {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct {
+			if err := loadEnv(v.Field(i), key+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, set := os.LookupEnv(key)
+		if !set {
+			continue
+		}
+		if !settableFromEnv(field.Type) {
+			return fmt.Errorf("%s cannot be set from the environment, use the configuration file", key)
+		}
+		if err := setFromEnv(v.Field(i), raw); err != nil {
+			return fmt.Errorf("invalid %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// setFromEnv parses raw into a setting
+func setFromEnv(value reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+		value.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		value.SetInt(int64(n))
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	}
+	return nil
+}
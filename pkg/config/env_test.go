@@ -0,0 +1,73 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(c *Config) bool
+		wantErr string
+	}{
+		{
+			name:  "Number",
+			env:   map[string]string{"GOPHERSTRIKE_NETWORK_TIMEOUT": "12"},
+			check: func(c *Config) bool { return c.Network.Timeout == 12 },
+		},
+		{
+			name:  "Nested section",
+			env:   map[string]string{"GOPHERSTRIKE_OUTPUT_SIEM_ENABLED": "true", "GOPHERSTRIKE_OUTPUT_SIEM_ADDRESS": "siem:514"},
+			check: func(c *Config) bool { return c.Output.SIEM.Enabled && c.Output.SIEM.Address == "siem:514" },
+		},
+		{
+			name: "List",
+			env:  map[string]string{"GOPHERSTRIKE_NETWORK_DNS_SERVERS": "9.9.9.9, 1.1.1.1,"},
+			check: func(c *Config) bool {
+				return reflect.DeepEqual(c.Network.DNSServers, []string{"9.9.9.9", "1.1.1.1"})
+			},
+		},
+		{
+			name:  "Unrelated variables",
+			env:   map[string]string{"GOPHERSTRIKE_WORKSPACE": "acme"},
+			check: func(c *Config) bool { return c.Network.Timeout == 30 },
+		},
+		{
+			name:    "Invalid number",
+			env:     map[string]string{"GOPHERSTRIKE_SCANNING_DEFAULT_THREADS": "many"},
+			wantErr: "invalid GOPHERSTRIKE_SCANNING_DEFAULT_THREADS",
+		},
+		{
+			name:    "Hooks",
+			env:     map[string]string{"GOPHERSTRIKE_HOOKS": "[]"},
+			wantErr: "GOPHERSTRIKE_HOOKS cannot be set from the environment",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			c := &Config{}
+			c.LoadDefaults()
+
+			err := c.LoadFromEnv()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadFromEnv() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFromEnv() error = %v", err)
+			}
+			if !tt.check(c) {
+				t.Errorf("LoadFromEnv() did not apply %v", tt.env)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Reload reads the configuration file again, with the environment overrides,
+// and replaces the settings in use if they are valid. An invalid file leaves
+// the settings in use as they are, so that a mistake while editing it does
+// not take down a running server. A missing file restores the defaults, as
+// at startup. The new settings are in a new instance returned by Get.
+func Reload(filename string) error {
+	fresh := &Config{}
+	fresh.LoadDefaults()
+	if err := fresh.LoadFromFile(filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := fresh.LoadFromEnv(); err != nil {
+		return err
+	}
+	if err := fresh.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %v", err)
+	}
+
+	// Readers of the previous configuration keep it unchanged
+	Get()
+	mu.Lock()
+	defer mu.Unlock()
+	instance = fresh
+	return nil
+}
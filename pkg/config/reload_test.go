@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	saved := Get()
+	defer func() {
+		mu.Lock()
+		instance = saved
+		mu.Unlock()
+	}()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"network":{"timeout":5},"scanning":{"timing":"T2"}}`), 0644)
+
+	// Reloading while the settings are read is safe
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = Get().Network.Timeout
+		}
+	}()
+	if err := Reload(path); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	<-done
+	if Get().Network.Timeout != 5 || Get().Scanning.Timing != "T2" || Get().Network.RateLimit != 10 {
+		t.Errorf("Reload() = %+v %+v, want the file over the defaults", Get().Network, Get().Scanning)
+	}
+	if saved.Network.Timeout != 30 || saved.Scanning.Timing != "" {
+		t.Errorf("Reload() modified the previous configuration: %+v", saved.Network)
+	}
+
+	// Invalid files keep the settings in use
+	for _, content := range []string{`{"network":`, `{"scanning":{"timing":"T9"}}`} {
+		os.WriteFile(path, []byte(content), 0644)
+		if err := Reload(path); err == nil {
+			t.Errorf("Reload(%s) succeeded", content)
+		}
+		if Get().Network.Timeout != 5 {
+			t.Errorf("Reload(%s) changed the settings", content)
+		}
+	}
+
+	t.Setenv("GOPHERSTRIKE_NETWORK_TIMEOUT", "7")
+	os.Remove(path)
+	if err := Reload(path); err != nil {
+		t.Fatalf("Reload() of a removed file error = %v", err)
+	}
+	if Get().Network.Timeout != 7 || Get().Scanning.Timing != "" {
+		t.Errorf("Reload() of a removed file = %+v, want the defaults and environment", Get().Network)
+	}
+}
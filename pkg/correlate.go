@@ -0,0 +1,162 @@
+// pkg/correlate.go
+package pkg
+
+import (
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/tools/osint"
+	"GopherStrike/pkg/tools/webvuln"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// RunCorrelate correlates servers, saved OSINT results and the products
+// identified by web vulnerability scans with the vulnerability databases
+// selected with --sources. Arguments are result files saved by the OSINT
+// tool, web scan reports, or hosts whose server information is gathered
+// first. Only matches reaching --min-confidence are reported and published
+// as findings.
+func RunCorrelate(args []string) error {
+	fs := flag.NewFlagSet("correlate", flag.ContinueOnError)
+	minConfidence := fs.Float64("min-confidence", osint.DefaultConfidenceThreshold, "Minimum confidence of the matches reported (0.0-1.0)")
+	apiKey := fs.String("api-key", os.Getenv("NVD_API_KEY"), "NVD API key (also NVD_API_KEY)")
+	vulnOptions := osint.DefaultVulnDBOptions()
+	sources := fs.String("sources", strings.Join(vulnOptions.Sources, ","), "Vulnerability databases to query (comma-separated: nvd, osv, github, vulners)")
+	portList := fs.String("ports", "", "Ports to gather server information from (comma-separated, default common ports)")
+	jsonOutput := fs.Bool("json", false, "Print the results as JSON")
+	output := fs.String("output", "", "Write the results to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *minConfidence < 0 || *minConfidence > 1 {
+		return fmt.Errorf("invalid --min-confidence %v, must be between 0.0 and 1.0", *minConfidence)
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("nothing to correlate, pass hosts or result files as arguments")
+	}
+
+	var ports []int
+	if *portList != "" {
+		for _, item := range strings.Split(*portList, ",") {
+			port, err := strconv.Atoi(strings.TrimSpace(item))
+			if err != nil || port < 1 || port > 65535 {
+				return fmt.Errorf("invalid port %q", item)
+			}
+			ports = append(ports, port)
+		}
+	}
+
+	vulnOptions.Sources = strings.Split(*sources, ",")
+	vulnOptions.NVDAPIKey = *apiKey
+	db, err := osint.NewVulnDB(vulnOptions)
+	if err != nil {
+		return err
+	}
+	if multi, ok := db.(*osint.MultiConnector); ok {
+		multi.OnError = func(source string, err error) {
+			fmt.Fprintf(os.Stderr, "Warning: %s query failed: %v\n", source, err)
+		}
+	}
+
+	correlator := osint.NewCorrelator(db)
+	correlator.MatchThreshold = *minConfidence
+
+	results := make([]*osint.ScanResult, 0, fs.NArg())
+	for _, arg := range fs.Args() {
+		var inputs []*osint.ScanResult
+		if _, err := os.Stat(arg); err == nil {
+			if inputs, err = loadCorrelationInputs(arg); err != nil {
+				return err
+			}
+		} else {
+			serverInfo, err := osint.GatherServerInfo(arg, ports)
+			if err != nil {
+				return fmt.Errorf("failed to gather server information of %s: %v", arg, err)
+			}
+			inputs = []*osint.ScanResult{osint.NewServerScanResult(arg, serverInfo)}
+		}
+		for _, result := range inputs {
+			if err := correlator.CorrelateScanResults(result); err != nil {
+				return fmt.Errorf("failed to correlate %s: %v", arg, err)
+			}
+			results = append(results, result)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %v", err)
+		}
+		defer file.Close()
+		redacted := redact.Writer(file)
+		defer redacted.Close()
+		w = redacted
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+	printCorrelationTable(w, results)
+	return nil
+}
+
+// loadCorrelationInputs reads a file saved by the OSINT tool, or a report
+// of the web vulnerability scanner whose identified products, such as CI/CD
+// consoles, are correlated one by one
+func loadCorrelationInputs(filename string) ([]*osint.ScanResult, error) {
+	result, err := osint.LoadCorrelationInput(filename)
+	if err == nil {
+		return []*osint.ScanResult{result}, nil
+	}
+
+	data, readErr := os.ReadFile(filename)
+	if readErr != nil {
+		return nil, err
+	}
+	var report webvuln.Report
+	if json.Unmarshal(data, &report) != nil || len(report.Products) == 0 {
+		return nil, err
+	}
+	results := make([]*osint.ScanResult, 0, len(report.Products))
+	for _, product := range report.Products {
+		host := product.URL
+		if parsed, err := url.Parse(product.URL); err == nil && parsed.Hostname() != "" {
+			host = parsed.Hostname()
+		}
+		results = append(results, osint.NewServerScanResult(host, &osint.ServerInfo{
+			Hostname:       host,
+			ProductName:    product.Name,
+			ProductVersion: product.Version,
+			CPE:            product.CPE,
+		}))
+	}
+	return results, nil
+}
+
+// printCorrelationTable renders the matched vulnerabilities of each target
+// as a text table
+func printCorrelationTable(w io.Writer, results []*osint.ScanResult) {
+	fmt.Fprintf(w, "%-28s %-16s %-9s %-5s %-10s %-9s %s\n", "Target", "CVE", "Severity", "CVSS", "Confidence", "Source", "Title")
+	for _, result := range results {
+		if len(result.Vulnerabilities) == 0 {
+			fmt.Fprintf(w, "%-28s %-16s\n", truncate(result.Target, 28), "-")
+			continue
+		}
+		for _, vuln := range result.Vulnerabilities {
+			fmt.Fprintf(w, "%-28s %-16s %-9s %-5.1f %9.0f%% %-9s %s\n", truncate(result.Target, 28), vuln.ID,
+				vuln.Severity, vuln.CVSS, result.ConfidenceScore[vuln.ID]*100, result.MatchSources[vuln.ID], truncate(vuln.Title, 50))
+		}
+	}
+}
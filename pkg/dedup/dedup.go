@@ -0,0 +1,210 @@
+// Package dedup finds the targets that would be scanned more than once when
+// the results of one stage feed the next: subdomains resolving to the same
+// addresses, as on CDN-backed scopes, and URLs that only differ by their
+// scheme. Exact duplicates are always dropped; the policy decides whether
+// every target of a group is scanned or only the one standing for it.
+package dedup
+
+import (
+	"GopherStrike/pkg/urlnorm"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Policies
+const (
+	All            = "all"            // Scan every distinct target
+	Representative = "representative" // Scan one target of each group
+)
+
+var (
+	defaultPolicy = All
+	mutex         sync.RWMutex
+)
+
+// ParsePolicy normalizes a policy name, returning an error if it is
+// unknown. An empty name selects All.
+func ParsePolicy(name string) (string, error) {
+	switch name = strings.ToLower(strings.TrimSpace(name)); name {
+	case "":
+		return All, nil
+	case All, Representative:
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown dedupe policy %q, use %s or %s", name, All, Representative)
+}
+
+// SetDefault sets the policy of the stages that do not choose one
+func SetDefault(policy string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	defaultPolicy = policy
+}
+
+// Default returns the policy of the stages that do not choose one
+func Default() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return defaultPolicy
+}
+
+// Group is a set of targets leading to the same work
+type Group struct {
+	Key            string   `json:"key"`                  // Addresses or URL the targets share
+	Representative string   `json:"representative"`       // Target scanned for the group under the Representative policy
+	Duplicates     []string `json:"duplicates,omitempty"` // The other targets
+}
+
+// Hosts groups hostnames by the set of addresses they resolve to. Names
+// without addresses are groups of their own, and a name listed twice is
+// kept once. Groups and their names keep the order of names, the first
+// name of a group standing for it.
+func Hosts(names []string, addresses map[string][]string) []Group {
+	groups := []Group{}
+	index := map[string]int{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		host := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+
+		key := addressKey(addresses[name])
+		if key == "" {
+			groups = append(groups, Group{Key: host, Representative: name})
+			continue
+		}
+		if i, ok := index[key]; ok {
+			groups[i].Duplicates = append(groups[i].Duplicates, name)
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, Group{Key: key, Representative: name})
+	}
+	return groups
+}
+
+// addressKey returns the sorted, distinct addresses of a host joined by
+// commas
+func addressKey(addresses []string) string {
+	set := map[string]bool{}
+	for _, address := range addresses {
+		if address = strings.TrimSpace(address); address != "" {
+			set[address] = true
+		}
+	}
+	sorted := make([]string, 0, len(set))
+	for address := range set {
+		sorted = append(sorted, address)
+	}
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// Addresses returns the distinct addresses of hosts, in the order of names
+func Addresses(names []string, addresses map[string][]string) []string {
+	seen := map[string]bool{}
+	distinct := []string{}
+	for _, name := range names {
+		for _, address := range addresses[name] {
+			if address = strings.TrimSpace(address); address != "" && !seen[address] {
+				seen[address] = true
+				distinct = append(distinct, address)
+			}
+		}
+	}
+	return distinct
+}
+
+// Distinct returns targets without those listed before, compared without
+// case, in order
+func Distinct(targets []string) []string {
+	seen := map[string]bool{}
+	distinct := []string{}
+	for _, target := range targets {
+		key := strings.ToLower(strings.TrimSpace(target))
+		if key != "" && !seen[key] {
+			seen[key] = true
+			distinct = append(distinct, target)
+		}
+	}
+	return distinct
+}
+
+// URLs groups URLs that only differ by their scheme, http or https, HTTPS
+// standing for the group. URLs with the same canonical form are kept once.
+// Groups keep the order of urls.
+func URLs(urls []string) []Group {
+	groups := []Group{}
+	index := map[string]int{}
+	seen := urlnorm.NewSet()
+	for _, rawURL := range urls {
+		if !seen.Add(rawURL) {
+			continue
+		}
+		key := schemelessKey(rawURL)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(groups)
+			groups = append(groups, Group{Key: key, Representative: rawURL})
+			continue
+		}
+
+		// HTTPS stands for the group, whichever came first
+		group := &groups[i]
+		if isHTTPS(rawURL) && !isHTTPS(group.Representative) {
+			group.Duplicates = append(group.Duplicates, group.Representative)
+			group.Representative = rawURL
+		} else {
+			group.Duplicates = append(group.Duplicates, rawURL)
+		}
+	}
+	return groups
+}
+
+// schemelessKey returns the canonical form of a web URL without its scheme,
+// or the canonical URL itself for other schemes
+func schemelessKey(rawURL string) string {
+	canonical := urlnorm.String(rawURL)
+	u, err := url.Parse(canonical)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return canonical
+	}
+	return strings.TrimPrefix(canonical, u.Scheme+":")
+}
+
+// isHTTPS reports whether a URL uses HTTPS
+func isHTTPS(rawURL string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(rawURL)), "https:")
+}
+
+// Select returns the targets to scan under a policy: the representative of
+// each group, or every target
+func Select(groups []Group, policy string) []string {
+	targets := []string{}
+	for _, group := range groups {
+		targets = append(targets, group.Representative)
+		if policy != Representative {
+			targets = append(targets, group.Duplicates...)
+		}
+	}
+	return targets
+}
+
+// Skipped returns the groups whose duplicates a policy does not scan
+func Skipped(groups []Group, policy string) []Group {
+	if policy != Representative {
+		return nil
+	}
+	skipped := []Group{}
+	for _, group := range groups {
+		if len(group.Duplicates) > 0 {
+			skipped = append(skipped, group)
+		}
+	}
+	return skipped
+}
@@ -0,0 +1,79 @@
+package dedup
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHosts(t *testing.T) {
+	names := []string{"www.example.com", "api.example.com", "cdn.example.com", "WWW.example.com.", "old.example.com"}
+	addresses := map[string][]string{
+		"www.example.com": {"192.0.2.1", "192.0.2.2"},
+		"api.example.com": {"192.0.2.3"},
+		"cdn.example.com": {"192.0.2.2", "192.0.2.1", "192.0.2.1"},
+	}
+	want := []Group{
+		{Key: "192.0.2.1,192.0.2.2", Representative: "www.example.com", Duplicates: []string{"cdn.example.com"}},
+		{Key: "192.0.2.3", Representative: "api.example.com"},
+		{Key: "old.example.com", Representative: "old.example.com"},
+	}
+	groups := Hosts(names, addresses)
+	if !reflect.DeepEqual(groups, want) {
+		t.Fatalf("Hosts() = %+v, want %+v", groups, want)
+	}
+
+	if got, want := Select(groups, All), []string{"www.example.com", "cdn.example.com", "api.example.com", "old.example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Select(all) = %q, want %q", got, want)
+	}
+	if got, want := Select(groups, Representative), []string{"www.example.com", "api.example.com", "old.example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Select(representative) = %q, want %q", got, want)
+	}
+	if got := Skipped(groups, All); got != nil {
+		t.Errorf("Skipped(all) = %+v, want none", got)
+	}
+	if got := Skipped(groups, Representative); !reflect.DeepEqual(got, want[:1]) {
+		t.Errorf("Skipped(representative) = %+v, want %+v", got, want[:1])
+	}
+
+	if got, want := Addresses(names, addresses), []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Addresses() = %q, want %q", got, want)
+	}
+}
+
+func TestURLs(t *testing.T) {
+	groups := URLs([]string{
+		"http://example.com/login",
+		"https://example.com/",
+		"https://example.com/login",
+		"HTTPS://example.com:443/login",
+		"http://example.com/",
+		"ftp://example.com/",
+	})
+	want := []Group{
+		{Key: "//example.com/login", Representative: "https://example.com/login", Duplicates: []string{"http://example.com/login"}},
+		{Key: "//example.com/", Representative: "https://example.com/", Duplicates: []string{"http://example.com/"}},
+		{Key: "ftp://example.com/", Representative: "ftp://example.com/"},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("URLs() = %+v, want %+v", groups, want)
+	}
+}
+
+func TestDistinct(t *testing.T) {
+	got := Distinct([]string{"10.0.0.1", "Host.example.com", "", "host.example.com", "10.0.0.1"})
+	if want := []string{"10.0.0.1", "Host.example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Distinct() = %q, want %q", got, want)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := map[string]string{"": All, "all": All, " Representative ": Representative}
+	for name, want := range tests {
+		if got, err := ParsePolicy(name); err != nil || got != want {
+			t.Errorf("ParsePolicy(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParsePolicy("first"); err == nil {
+		t.Error("ParsePolicy() accepted an unknown policy")
+	}
+}
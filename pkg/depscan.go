@@ -0,0 +1,194 @@
+// pkg/depscan.go
+package pkg
+
+import (
+	"GopherStrike/pkg/budget"
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/tools/audit/depscan"
+	"GopherStrike/pkg/tools/osint"
+	"GopherStrike/pkg/tools/reporting"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+)
+
+// RunDepScan looks up the dependencies declared in a target's repository
+// (go.mod, package-lock.json, requirements.txt or a CycloneDX SBOM) in OSV,
+// optionally completed by the NVD, and reports the vulnerable versions.
+// Arguments are repository directories or manifest files.
+func RunDepScan(args []string) error {
+	options := depscan.DefaultScanOptions()
+	fs := flag.NewFlagSet("depscan", flag.ContinueOnError)
+	target := fs.String("target", "", "Name of the assessed target (default: the first path)")
+	nvd := fs.Bool("nvd", false, "Complete the CVEs found with their NVD records (CVSS scores, references)")
+	apiKey := fs.String("api-key", os.Getenv("NVD_API_KEY"), "NVD API key (also NVD_API_KEY)")
+	fs.BoolVar(&options.IncludeDev, "include-dev", false, "Look up development dependencies too")
+	fs.IntVar(&options.Concurrency, "concurrency", options.Concurrency, "Components looked up in parallel")
+	report := fs.String("report", "", "Write a report with a software composition section to this .md or .html file")
+	jsonOutput := fs.Bool("json", false, "Print the results as JSON")
+	output := fs.String("output", "", "Write the results to this file instead of stdout")
+	maxDuration := fs.Duration("max-duration", 0, "Stop looking up components after this long, e.g. 30m, and keep the partial results (0: no limit)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("nothing to scan, pass repository directories or manifest files as arguments")
+	}
+	if *target == "" {
+		abs, err := filepath.Abs(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		*target = filepath.Base(abs)
+	}
+
+	var nvdConnector osint.VulnDBConnector
+	if *nvd {
+		nvdConnector = osint.NewNVDConnector(*apiKey)
+	}
+	scanner := depscan.NewScanner(options, osint.NewOSVConnector(), nvdConnector)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := budget.Context(ctx, "depscan", *maxDuration)
+	defer cancel()
+	result := scanner.Scan(ctx, *target, fs.Args())
+	for _, err := range result.Errors {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+	}
+	if len(result.Manifests) == 0 {
+		return fmt.Errorf("no supported manifest found (go.mod, package-lock.json, requirements.txt or a CycloneDX JSON SBOM)")
+	}
+	failed := 0
+	for _, c := range result.Components {
+		if c.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 && failed == len(result.Components)-result.Unchecked {
+		return fmt.Errorf("none of the %d component(s) could be looked up, first error: %s", failed, firstLookupError(result))
+	}
+
+	if *report != "" {
+		if err := writeDepScanReport(*report, result); err != nil {
+			return fmt.Errorf("failed to write the report: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Report written to %s\n", *report)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %v", err)
+		}
+		defer file.Close()
+		redacted := redact.Writer(file)
+		defer redacted.Close()
+		w = redacted
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+	} else {
+		printDepScanTable(w, result)
+	}
+
+	return nil
+}
+
+// firstLookupError returns the error of the first component that could not
+// be looked up
+func firstLookupError(result *depscan.ScanResult) string {
+	for _, c := range result.Components {
+		if c.Error != "" {
+			return c.Error
+		}
+	}
+	return ""
+}
+
+// writeDepScanReport writes a report of the vulnerable components, in
+// Markdown or, for .html files, HTML
+func writeDepScanReport(path string, result *depscan.ScanResult) error {
+	options := reporting.DefaultReportOptions()
+	options.Title = "Software Composition Report: " + result.Target
+	options.OutputFile = path
+	if strings.EqualFold(filepath.Ext(path), ".html") {
+		options.Format = string(reporting.FormatHTML)
+	}
+
+	generator := reporting.NewReportGenerator(options)
+	for _, c := range result.Components {
+		component := reporting.Component{
+			Name:      c.Name,
+			Version:   c.Version,
+			Ecosystem: c.Ecosystem,
+			Manifest:  c.Manifest,
+			Severity:  c.Severity(),
+		}
+		for _, vuln := range c.Vulnerabilities {
+			component.Vulnerabilities = append(component.Vulnerabilities, vuln.ID)
+
+			finding := depscan.Finding(result.Target, c.Component, vuln)
+			generator.AddVulnerability(reporting.Vulnerability{
+				Title:           finding.Name,
+				Description:     vuln.Description,
+				Severity:        finding.Severity,
+				Status:          reporting.StatusOpen,
+				CVSS:            vuln.CVSS,
+				AffectedTargets: []string{result.Target, c.Manifest},
+				Remediation:     strings.Join(vuln.Mitigations, "; "),
+				References:      vuln.References,
+				Tags:            []string{"depscan", finding.Category},
+			})
+		}
+		generator.AddComponent(component)
+	}
+	for _, note := range budget.Stopped() {
+		generator.AddIncomplete(note)
+	}
+
+	report, err := generator.GenerateReport()
+	if err != nil {
+		return err
+	}
+	return generator.SaveReport(report)
+}
+
+// printDepScanTable renders the vulnerable components as a text table
+// followed by their vulnerabilities
+func printDepScanTable(w io.Writer, result *depscan.ScanResult) {
+	vulnerable := result.Vulnerable()
+	fmt.Fprintf(w, "%d manifest(s), %d component(s), %d vulnerable, %d not checked (unpinned or unknown ecosystem)\n\n",
+		len(result.Manifests), len(result.Components), len(vulnerable), result.Unchecked)
+	if len(vulnerable) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "%-40s %-20s %-10s %-9s %-5s %s\n", "Component", "Version", "Ecosystem", "Severity", "Vulns", "Manifest")
+	for _, c := range vulnerable {
+		fmt.Fprintf(w, "%-40s %-20s %-10s %-9s %-5d %s\n", truncate(c.Name, 40), truncate(c.Version, 20),
+			c.Ecosystem, c.Severity(), len(c.Vulnerabilities), c.Manifest)
+	}
+
+	for _, c := range vulnerable {
+		fmt.Fprintf(w, "\n[!] %s@%s\n", c.Name, c.Version)
+		for _, vuln := range c.Vulnerabilities {
+			fmt.Fprintf(w, "    [%s] %s %s\n", depscan.VulnerabilitySeverity(vuln), vuln.ID, truncate(vuln.Title, 80))
+			for _, mitigation := range vuln.Mitigations {
+				fmt.Fprintf(w, "        %s\n", mitigation)
+			}
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package distributed
+
+import (
+	"GopherStrike/pkg/scope"
+	"GopherStrike/pkg/tools/webvuln"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// AgentOptions contains options for a worker agent
+type AgentOptions struct {
+	Coordinator    string        // Address of the coordinator
+	Name           string        // Name shown by the coordinator, e.g. the vantage point
+	Concurrency    int           // Number of tasks to run at once
+	Timeout        time.Duration // Timeout for each DNS lookup or port connection
+	ReconnectDelay time.Duration
+	DrainTimeout   time.Duration // How long running tasks may take to finish when the agent stops
+}
+
+// DefaultAgentOptions returns default options for a worker agent
+func DefaultAgentOptions() AgentOptions {
+	hostname, _ := os.Hostname()
+	return AgentOptions{
+		Coordinator:    "127.0.0.1:9090",
+		Name:           hostname,
+		Concurrency:    2,
+		Timeout:        2 * time.Second,
+		ReconnectDelay: 5 * time.Second,
+		DrainTimeout:   25 * time.Second,
+	}
+}
+
+// Agent receives tasks from a coordinator and streams their results back
+type Agent struct {
+	options AgentOptions
+}
+
+// NewAgent creates a worker agent
+func NewAgent(options AgentOptions) *Agent {
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+	return &Agent{options: options}
+}
+
+// Run works for the coordinator until the context is cancelled, reconnecting
+// whenever the connection is lost. Once cancelled, the agent takes no more
+// tasks and reports those it is running before it returns; tasks still
+// running after the drain timeout are stopped and report what they found.
+func (a *Agent) Run(ctx context.Context) error {
+	for {
+		err := a.session(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		fmt.Printf("[!] Lost connection to coordinator: %v\n", err)
+		fmt.Printf("[i] Reconnecting in %s...\n", a.options.ReconnectDelay)
+
+		select {
+		case <-time.After(a.options.ReconnectDelay):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// session registers with the coordinator and runs tasks until the connection fails
+func (a *Agent) session(ctx context.Context) error {
+	conn, err := grpc.NewClient(a.options.Coordinator,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(JSONCodec{})),
+	)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// The streams outlive ctx, so that the tasks running when it is cancelled
+	// can still report
+	streamCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	hostname, _ := os.Hostname()
+	registration := new(RegisterResponse)
+	err = conn.Invoke(ctx, "/"+serviceName+"/Register", &RegisterRequest{
+		Name:        a.options.Name,
+		Hostname:    hostname,
+		Concurrency: a.options.Concurrency,
+	}, registration)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[+] Registered with %s as %s\n", a.options.Coordinator, registration.AgentID)
+
+	report, err := conn.NewStream(streamCtx, reportStreamDesc, "/"+serviceName+"/Report")
+	if err != nil {
+		return err
+	}
+
+	tasks, err := conn.NewStream(streamCtx, tasksStreamDesc, "/"+serviceName+"/Tasks")
+	if err != nil {
+		return err
+	}
+	if err := tasks.SendMsg(&TasksRequest{AgentID: registration.AgentID}); err != nil {
+		return err
+	}
+	if err := tasks.CloseSend(); err != nil {
+		return err
+	}
+
+	received := make(chan *Task)
+	failed := make(chan error, 1)
+	go func() {
+		for {
+			task := new(Task)
+			if err := tasks.RecvMsg(task); err != nil {
+				failed <- err
+				return
+			}
+			select {
+			case received <- task:
+			case <-streamCtx.Done():
+				return
+			}
+		}
+	}()
+
+	work, stopWork := context.WithCancel(streamCtx)
+	defer stopWork()
+	var wg sync.WaitGroup
+	var sendMutex sync.Mutex
+	defer wg.Wait()
+
+	for {
+		select {
+		case task := <-received:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				fmt.Printf("[i] Running task %s (%s on %s)\n", task.ID, task.Type, task.Target)
+				result := a.runTask(work, task)
+				result.AgentID = registration.AgentID
+
+				sendMutex.Lock()
+				defer sendMutex.Unlock()
+				if err := report.SendMsg(result); err != nil {
+					fmt.Printf("[!] Failed to report task %s: %v\n", task.ID, err)
+					cancel()
+				}
+			}()
+		case err := <-failed:
+			cancel()
+			return err
+		case <-ctx.Done():
+			a.drain(&wg, stopWork)
+			// Wait for the coordinator to acknowledge the reports; the tasks
+			// it had sent but the agent did not start are queued again once
+			// the tasks stream ends
+			if err := report.CloseSend(); err == nil {
+				report.RecvMsg(new(ReportAck))
+			}
+			return nil
+		}
+	}
+}
+
+// drain waits for the running tasks to finish, stopping them once the drain
+// timeout elapsed
+func (a *Agent) drain(wg *sync.WaitGroup, stopWork context.CancelFunc) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	fmt.Println("[i] Finishing the running tasks...")
+	select {
+	case <-done:
+	case <-time.After(a.options.DrainTimeout):
+		fmt.Println("[!] Drain timeout elapsed, stopping the running tasks")
+		stopWork()
+		<-done
+	}
+}
+
+// runTask executes a task
+func (a *Agent) runTask(ctx context.Context, task *Task) *TaskResult {
+	result := &TaskResult{TaskID: task.ID}
+	started := time.Now()
+	defer func() { result.Seconds = time.Since(started).Seconds() }()
+
+	switch task.Type {
+	case TaskSubdomains:
+		result.Subdomains = resolveSubdomains(ctx, task.Target, task.Items, a.options.Timeout)
+	case TaskPorts:
+		if err := scope.Check(ctx, task.Target); err != nil {
+			result.Error = err.Error()
+			break
+		}
+		result.OpenPorts = scanPorts(ctx, task.Target, task.PortStart, task.PortEnd, a.options.Timeout)
+	case TaskURLs:
+		result.URLs = scanURLs(task.Items)
+	default:
+		result.Error = fmt.Sprintf("unsupported task type: %s", task.Type)
+	}
+
+	return result
+}
+
+// resolveSubdomains resolves each word as a subdomain of the domain
+func resolveSubdomains(ctx context.Context, domain string, words []string, timeout time.Duration) []SubdomainHit {
+	hits := []SubdomainHit{}
+	for _, word := range words {
+		if ctx.Err() != nil {
+			break
+		}
+
+		word = strings.Trim(strings.TrimSpace(word), ".")
+		if word == "" {
+			continue
+		}
+		name := word + "." + domain
+
+		lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+		ips, err := net.DefaultResolver.LookupHost(lookupCtx, name)
+		cancel()
+		if err == nil && len(ips) > 0 {
+			hits = append(hits, SubdomainHit{Name: name, IPs: ips})
+		}
+	}
+	return hits
+}
+
+// scanPorts runs a TCP connect scan of a port range. Connections to
+// excluded hosts and addresses are refused by the scope guard.
+func scanPorts(ctx context.Context, host string, start, end int, timeout time.Duration) []int {
+	open := []int{}
+	dialer := &net.Dialer{Timeout: timeout}
+	dial := scope.DialContext(dialer.DialContext)
+	for port := start; port <= end; port++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		conn, err := dial(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err == nil {
+			conn.Close()
+			open = append(open, port)
+		}
+	}
+	return open
+}
+
+// scanURLs runs the web vulnerability scanner with default options on each
+// URL that passes the pre-scan checks
+func scanURLs(urls []string) []URLResult {
+	options := webvuln.DefaultScanOptions()
+	options.GenerateHTML = false
+
+	results := []URLResult{}
+	for _, target := range urls {
+		result := URLResult{URL: target, Findings: []string{}}
+
+		scanner := webvuln.NewScanner(options)
+		scanTarget := webvuln.ScanTarget{URL: target, Method: "GET"}
+		if err := scanner.Preflight(context.Background(), scanTarget).Err(); err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		report, err := scanner.Scan(scanTarget)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			for _, scan := range report.Results {
+				for _, test := range scan.TestResults {
+					result.Findings = append(result.Findings,
+						fmt.Sprintf("%s %s in %s", test.Severity, scan.VulnerabilityType, test.Parameter))
+				}
+			}
+		}
+
+		results = append(results, result)
+	}
+	return results
+}
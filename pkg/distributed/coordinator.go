@@ -0,0 +1,526 @@
+package distributed
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/budget"
+	"GopherStrike/pkg/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// ErrDraining is returned for jobs submitted while the coordinator shuts down
+var ErrDraining = errors.New("coordinator is shutting down")
+
+// Coordinator splits jobs into tasks and hands them out to registered agents
+type Coordinator struct {
+	store  *artifacts.Store
+	server *grpc.Server
+
+	mutex     sync.Mutex
+	agents    map[string]*agentState
+	jobs      map[string]*Job
+	tasks     map[string]*Task  // Tasks not yet completed, by ID
+	assigned  map[string]string // Task ID -> agent ID
+	queue     []*Task
+	wake      chan struct{} // Closed and replaced whenever tasks are queued
+	draining  bool          // Set on shutdown, when no more tasks are handed out
+	nextJob   int
+	nextAgent int
+}
+
+// agentState tracks a registered agent
+type agentState struct {
+	info  AgentInfo
+	slots chan struct{} // One slot per task the agent may run at once
+}
+
+// NewCoordinator creates a coordinator that saves merged results to the store
+func NewCoordinator(store *artifacts.Store) *Coordinator {
+	c := &Coordinator{
+		store:    store,
+		agents:   make(map[string]*agentState),
+		jobs:     make(map[string]*Job),
+		tasks:    make(map[string]*Task),
+		assigned: make(map[string]string),
+		wake:     make(chan struct{}),
+	}
+	c.server = grpc.NewServer(grpc.ForceServerCodec(JSONCodec{}))
+	c.server.RegisterService(&serviceDesc, c)
+	return c
+}
+
+// Serve accepts agent connections on the listener
+func (c *Coordinator) Serve(listener net.Listener) error {
+	return c.server.Serve(listener)
+}
+
+// ListenAndServe accepts agent connections on the address
+func (c *Coordinator) ListenAndServe(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", address, err)
+	}
+	return c.Serve(listener)
+}
+
+// Stop disconnects all agents and stops the coordinator
+func (c *Coordinator) Stop() {
+	c.server.Stop()
+}
+
+// Submit splits a job into tasks and queues them for the agents
+func (c *Coordinator) Submit(req JobRequest) (Job, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.draining {
+		return Job{}, ErrDraining
+	}
+
+	skipped, err := dedupeJob(&req, c.jobs[req.From])
+	if err != nil {
+		return Job{}, err
+	}
+	if artifacts.NormalizeTarget(req.Target) == "" {
+		return Job{}, fmt.Errorf("invalid target: %q", req.Target)
+	}
+	limit, err := budget.Parse(req.MaxDuration)
+	if err != nil {
+		return Job{}, fmt.Errorf("invalid max_duration: %v", err)
+	}
+
+	c.nextJob++
+	id := "job" + strconv.Itoa(c.nextJob)
+	tasks, err := splitJob(id, req)
+	if err != nil {
+		c.nextJob--
+		return Job{}, err
+	}
+
+	job := &Job{
+		ID:        id,
+		Type:      req.Type,
+		Target:    req.Target,
+		Status:    StatusRunning,
+		Tasks:     len(tasks),
+		Agents:    []string{},
+		Skipped:   skipped,
+		Submitted: time.Now(),
+	}
+	c.jobs[id] = job
+
+	for _, task := range tasks {
+		c.tasks[task.ID] = task
+	}
+	c.enqueue(tasks, false)
+	if limit > 0 {
+		time.AfterFunc(limit, func() { c.expire(id, limit) })
+	}
+
+	return *job, nil
+}
+
+// Job returns a copy of the job with the given ID
+func (c *Coordinator) Job(id string) (Job, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	job, ok := c.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Jobs returns a copy of every job, newest first
+func (c *Coordinator) Jobs() []Job {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	jobs := make([]Job, 0, len(c.jobs))
+	for _, job := range c.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Submitted.After(jobs[j].Submitted)
+	})
+	return jobs
+}
+
+// Agents returns the registered agents
+func (c *Coordinator) Agents() []AgentInfo {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	agents := make([]AgentInfo, 0, len(c.agents))
+	for _, agent := range c.agents {
+		agents = append(agents, agent.info)
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		return agents[i].ID < agents[j].ID
+	})
+	return agents
+}
+
+// Register adds an agent
+func (c *Coordinator) Register(ctx context.Context, req *RegisterRequest) (*RegisterResponse, error) {
+	concurrency := req.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	address := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		address = p.Addr.String()
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.nextAgent++
+	id := "agent" + strconv.Itoa(c.nextAgent)
+	c.agents[id] = &agentState{
+		info: AgentInfo{
+			ID:          id,
+			Name:        req.Name,
+			Hostname:    req.Hostname,
+			Address:     address,
+			Concurrency: concurrency,
+			LastSeen:    time.Now(),
+		},
+		slots: make(chan struct{}, concurrency),
+	}
+
+	fmt.Printf("[+] Agent %s registered: %s (%s)\n", id, req.Name, address)
+	return &RegisterResponse{AgentID: id}, nil
+}
+
+// Tasks streams tasks to an agent as it has free slots. Tasks still running
+// when the stream ends are queued again for other agents.
+func (c *Coordinator) Tasks(req *TasksRequest, stream grpc.ServerStream) error {
+	c.mutex.Lock()
+	agent, ok := c.agents[req.AgentID]
+	if ok {
+		agent.info.Connected = true
+	}
+	c.mutex.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown agent: %s", req.AgentID)
+	}
+
+	defer c.disconnect(req.AgentID)
+
+	ctx := stream.Context()
+	for {
+		select {
+		case agent.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+
+		task := c.next(ctx, req.AgentID)
+		if task == nil {
+			return nil
+		}
+		if err := stream.SendMsg(task); err != nil {
+			return err
+		}
+	}
+}
+
+// Report receives the results of an agent's tasks
+func (c *Coordinator) Report(stream grpc.ServerStream) error {
+	received := 0
+	for {
+		result := new(TaskResult)
+		if err := stream.RecvMsg(result); err != nil {
+			if err == io.EOF {
+				return stream.SendMsg(&ReportAck{Received: received})
+			}
+			return err
+		}
+		received++
+		c.complete(result)
+	}
+}
+
+// enqueue adds tasks to the queue and wakes waiting agents. The caller must hold the mutex.
+func (c *Coordinator) enqueue(tasks []*Task, front bool) {
+	if front {
+		c.queue = append(tasks, c.queue...)
+	} else {
+		c.queue = append(c.queue, tasks...)
+	}
+	close(c.wake)
+	c.wake = make(chan struct{})
+}
+
+// next waits for a queued task and assigns it to the agent
+func (c *Coordinator) next(ctx context.Context, agentID string) *Task {
+	for {
+		c.mutex.Lock()
+		if len(c.queue) > 0 {
+			task := c.queue[0]
+			c.queue = c.queue[1:]
+			c.assigned[task.ID] = agentID
+			if agent, ok := c.agents[agentID]; ok {
+				agent.info.Running++
+			}
+			c.mutex.Unlock()
+			return task
+		}
+		wake := c.wake
+		c.mutex.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// disconnect marks an agent as gone and queues its unfinished tasks again.
+// While draining, they are dropped instead, as no agent would run them.
+func (c *Coordinator) disconnect(agentID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	requeue := []*Task{}
+	for taskID, assignee := range c.assigned {
+		if assignee == agentID {
+			delete(c.assigned, taskID)
+			if task, ok := c.tasks[taskID]; ok {
+				requeue = append(requeue, task)
+			}
+		}
+	}
+	if c.draining {
+		for _, task := range requeue {
+			delete(c.tasks, task.ID)
+			job := c.jobs[task.JobID]
+			job.Canceled++
+			job.Incomplete = shutdownNote(job)
+			if job.Completed+job.Failed+job.Canceled == job.Tasks {
+				c.finish(job)
+			}
+		}
+		requeue = nil
+	}
+	if len(requeue) > 0 {
+		sort.Slice(requeue, func(i, j int) bool { return requeue[i].ID < requeue[j].ID })
+		c.enqueue(requeue, true)
+		fmt.Printf("[!] Agent %s disconnected, %d task(s) requeued\n", agentID, len(requeue))
+	}
+
+	if agent, ok := c.agents[agentID]; ok {
+		agent.info.Connected = false
+		agent.info.Running = 0
+	}
+}
+
+// complete merges a task result into its job, and saves the job once all of its tasks are done
+func (c *Coordinator) complete(result *TaskResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if agent, ok := c.agents[result.AgentID]; ok {
+		if agent.info.Running > 0 {
+			agent.info.Running--
+		}
+		agent.info.Completed++
+		agent.info.LastSeen = time.Now()
+		select {
+		case <-agent.slots:
+		default:
+		}
+	}
+
+	task, ok := c.tasks[result.TaskID]
+	if !ok {
+		// Already completed, e.g. by an agent that picked up a requeued task
+		return
+	}
+	delete(c.tasks, result.TaskID)
+	delete(c.assigned, result.TaskID)
+
+	job := c.jobs[task.JobID]
+	if result.Error != "" {
+		job.Failed++
+		job.Errors = append(job.Errors, fmt.Sprintf("%s: %s", task.ID, result.Error))
+		metrics.Errors.Inc("distributed")
+	} else {
+		job.Completed++
+	}
+	if !containsString(job.Agents, result.AgentID) {
+		job.Agents = append(job.Agents, result.AgentID)
+	}
+	job.Subdomains = append(job.Subdomains, result.Subdomains...)
+	if task.Target == job.Target {
+		job.OpenPorts = append(job.OpenPorts, result.OpenPorts...)
+	} else if len(result.OpenPorts) > 0 {
+		if job.HostPorts == nil {
+			job.HostPorts = map[string][]int{}
+		}
+		job.HostPorts[task.Target] = append(job.HostPorts[task.Target], result.OpenPorts...)
+	}
+	job.URLs = append(job.URLs, result.URLs...)
+	job.TaskStats = append(job.TaskStats, taskStats(task, result))
+
+	if job.Completed+job.Failed+job.Canceled < job.Tasks {
+		return
+	}
+	c.finish(job)
+}
+
+// expire drops the queued tasks of a job whose time budget elapsed. The
+// tasks agents are running still report their results, after which the job
+// completes with the results gathered. The caller must not hold the mutex.
+func (c *Coordinator) expire(jobID string, limit time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	job := c.jobs[jobID]
+	if job.Status != StatusRunning || c.drop(job) == 0 {
+		return
+	}
+
+	job.Incomplete = fmt.Sprintf("stopped after its %s time budget, %d of %d task(s) not run", limit, job.Canceled, job.Tasks)
+	fmt.Printf("[!] Distributed %s scan of %s %s\n", job.Type, job.Target, job.Incomplete)
+	if job.Completed+job.Failed+job.Canceled == job.Tasks {
+		c.finish(job)
+	}
+}
+
+// drop removes the queued tasks of a job, counting them as canceled, and
+// returns how many there were. The caller must hold the mutex.
+func (c *Coordinator) drop(job *Job) int {
+	dropped := 0
+	queue := c.queue[:0]
+	for _, task := range c.queue {
+		if task.JobID == job.ID {
+			delete(c.tasks, task.ID)
+			dropped++
+			continue
+		}
+		queue = append(queue, task)
+	}
+	c.queue = queue
+	job.Canceled += dropped
+	return dropped
+}
+
+// shutdownNote explains why a job stopped by a shutdown is incomplete
+func shutdownNote(job *Job) string {
+	return fmt.Sprintf("stopped by the shutdown of the coordinator, %d of %d task(s) not run", job.Canceled, job.Tasks)
+}
+
+// Drain stops taking jobs and handing out tasks, and waits for the agents to
+// report the tasks they are running, until ctx is done. The tasks not handed
+// out yet are dropped, so that the running jobs complete with the results
+// gathered and are saved.
+func (c *Coordinator) Drain(ctx context.Context) {
+	c.mutex.Lock()
+	c.draining = true
+	for _, job := range c.jobs {
+		if job.Status != StatusRunning {
+			continue
+		}
+		if c.drop(job) > 0 {
+			job.Incomplete = shutdownNote(job)
+		}
+		if job.Completed+job.Failed+job.Canceled == job.Tasks {
+			c.finish(job)
+		}
+	}
+	c.mutex.Unlock()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		c.mutex.Lock()
+		running := len(c.assigned)
+		c.mutex.Unlock()
+		if running == 0 {
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// finish marks a job as completed and saves its merged results. The caller
+// must hold the mutex.
+func (c *Coordinator) finish(job *Job) {
+	job.Status = StatusCompleted
+	job.Finished = time.Now()
+	sort.Slice(job.Subdomains, func(i, j int) bool { return job.Subdomains[i].Name < job.Subdomains[j].Name })
+	sort.Ints(job.OpenPorts)
+	for _, ports := range job.HostPorts {
+		sort.Ints(ports)
+	}
+	metrics.Scans.Inc("distributed", job.Status)
+
+	path, err := c.store.WriteJSON(job.Target, artifactKind(job.Type), artifacts.TimestampedName("distributed_"+job.Type, "json"), job)
+	if err != nil {
+		fmt.Printf("[!] Failed to save results of %s: %v\n", job.ID, err)
+		return
+	}
+	job.Artifact = path
+	fmt.Printf("[+] Distributed %s scan of %s complete: %s\n", job.Type, job.Target, path)
+}
+
+// taskStats returns the statistics of a completed task
+func taskStats(task *Task, result *TaskResult) TaskStats {
+	stats := TaskStats{
+		Task:    task.ID,
+		Agent:   result.AgentID,
+		Items:   len(task.Items),
+		Hits:    len(result.Subdomains) + len(result.OpenPorts),
+		Seconds: result.Seconds,
+		Error:   result.Error,
+	}
+	if task.Type == TaskPorts {
+		stats.Items = task.PortEnd - task.PortStart + 1
+	}
+	for _, url := range result.URLs {
+		if len(url.Findings) > 0 {
+			stats.Hits++
+		}
+	}
+	return stats
+}
+
+// artifactKind returns the artifact kind the results of a task type are stored as
+func artifactKind(taskType string) artifacts.Kind {
+	switch taskType {
+	case TaskSubdomains:
+		return artifacts.KindSubdomains
+	case TaskPorts:
+		return artifacts.KindPorts
+	default:
+		return artifacts.KindWeb
+	}
+}
+
+// containsString checks if a string is in a slice
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
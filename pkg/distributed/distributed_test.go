@@ -0,0 +1,320 @@
+package distributed
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/scope"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitJob(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       JobRequest
+		wantTasks int
+		wantError bool
+	}{
+		{"Subdomain chunks", JobRequest{Type: TaskSubdomains, Items: []string{"a", "b", "c", "d", "e"}, ChunkSize: 2}, 3, false},
+		{"URL batch", JobRequest{Type: TaskURLs, Items: []string{"https://example.com/"}}, 1, false},
+		{"Port ranges", JobRequest{Type: TaskPorts, Ports: "1-2500", ChunkSize: 1000}, 3, false},
+		{"Default ports", JobRequest{Type: TaskPorts}, 2, false},
+		{"Single port", JobRequest{Type: TaskPorts, Ports: "443"}, 1, false},
+		{"Host range", JobRequest{Type: TaskPorts, Target: "10.0.0.1-3", Ports: "1-2000", ChunkSize: 1000}, 6, false},
+		{"Invalid host range", JobRequest{Type: TaskPorts, Target: "10.0.0.9-1"}, 0, true},
+		{"Invalid port range", JobRequest{Type: TaskPorts, Ports: "100-1"}, 0, true},
+		{"No items", JobRequest{Type: TaskSubdomains}, 0, true},
+		{"Unknown type", JobRequest{Type: "dns"}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := splitJob("job1", tt.req)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("splitJob() error = %v, wantError %v", err, tt.wantError)
+			}
+			if len(tasks) != tt.wantTasks {
+				t.Errorf("splitJob() returned %d tasks, want %d", len(tasks), tt.wantTasks)
+			}
+		})
+	}
+
+	tasks, _ := splitJob("job1", JobRequest{Type: TaskPorts, Ports: "1-2500", ChunkSize: 1000})
+	if last := tasks[len(tasks)-1]; last.PortStart != 2001 || last.PortEnd != 2500 {
+		t.Errorf("last port chunk = %d-%d, want 2001-2500", last.PortStart, last.PortEnd)
+	}
+
+	exclusions, err := scope.Parse(strings.NewReader("10.0.0.2\n10.0.1.0/24\n"))
+	if err != nil {
+		t.Fatalf("scope.Parse() error = %v", err)
+	}
+	scope.Set(exclusions)
+	defer scope.Set(nil)
+	tasks, _ = splitJob("job1", JobRequest{Type: TaskPorts, Target: "10.0.0.1-3", Ports: "1-1000"})
+	if len(tasks) != 2 || tasks[0].Target != "10.0.0.1" || tasks[1].Target != "10.0.0.3" {
+		t.Errorf("splitJob() with 10.0.0.2 excluded returned %d tasks", len(tasks))
+	}
+	if _, err := splitJob("job1", JobRequest{Type: TaskPorts, Target: "10.0.1.5", Ports: "1-1000"}); err == nil {
+		t.Errorf("splitJob() accepted an excluded target")
+	}
+}
+
+func TestSplitJobWordlist(t *testing.T) {
+	// Temporary files are outside the paths the validator allows
+	saved := validateWordlist
+	validateWordlist = func(string) error { return nil }
+	defer func() { validateWordlist = saved }()
+
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("www\nmail\n# comment\ndev\napi\nvpn\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := splitJob("job1", JobRequest{Type: TaskSubdomains, Wordlist: path, ChunkSize: 2})
+	if err != nil {
+		t.Fatalf("splitJob() error = %v", err)
+	}
+	if len(tasks) != 3 || strings.Join(tasks[1].Items, ",") != "dev,api" || strings.Join(tasks[2].Items, ",") != "vpn" {
+		t.Errorf("splitJob() tasks = %+v", tasks)
+	}
+
+	if _, err := splitJob("job1", JobRequest{Type: TaskURLs, Wordlist: path}); err == nil {
+		t.Error("splitJob() accepted a wordlist for a urls job")
+	}
+	if _, err := splitJob("job1", JobRequest{Type: TaskSubdomains, Wordlist: filepath.Join(t.TempDir(), "missing.txt")}); err == nil {
+		t.Error("splitJob() accepted a missing wordlist")
+	}
+}
+
+func TestSubmitFrom(t *testing.T) {
+	coordinator := NewCoordinator(artifacts.NewStore(t.TempDir(), "test"))
+	coordinator.jobs["job1"] = &Job{
+		ID:     "job1",
+		Type:   TaskSubdomains,
+		Target: "example.com",
+		Status: StatusCompleted,
+		Subdomains: []SubdomainHit{
+			{Name: "www.example.com", IPs: []string{"192.0.2.1", "192.0.2.2"}},
+			{Name: "api.example.com", IPs: []string{"192.0.2.3"}},
+			{Name: "cdn.example.com", IPs: []string{"192.0.2.2", "192.0.2.1"}},
+		},
+	}
+	coordinator.nextJob = 1
+	queued := func() []string {
+		items := []string{}
+		for _, task := range coordinator.queue {
+			if task.Type == TaskPorts {
+				items = append(items, task.Target)
+			} else {
+				items = append(items, strings.Join(task.Items, ","))
+			}
+		}
+		coordinator.queue = nil
+		return items
+	}
+
+	// Each address is port scanned once
+	if _, err := coordinator.Submit(JobRequest{Type: TaskPorts, From: "job1", Ports: "443"}); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if got := strings.Join(queued(), " "); got != "192.0.2.1 192.0.2.2 192.0.2.3" {
+		t.Errorf("ports job hosts = %s", got)
+	}
+
+	// One subdomain behind the same addresses is scanned for URLs
+	job, err := coordinator.Submit(JobRequest{Type: TaskURLs, From: "job1", Dedupe: "representative"})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if got := strings.Join(queued(), " "); got != "https://www.example.com/,https://api.example.com/" {
+		t.Errorf("urls job items = %s", got)
+	}
+	if job.Target != "example.com" || len(job.Skipped) != 1 || job.Skipped[0].Duplicates[0] != "cdn.example.com" {
+		t.Errorf("urls job = %+v", job)
+	}
+
+	// Under the all policy only the URLs listed twice are dropped, HTTPS
+	// coming first
+	job, err = coordinator.Submit(JobRequest{Type: TaskURLs, Target: "example.com", Items: []string{
+		"http://example.com/", "https://example.com/", "https://EXAMPLE.com:443/",
+	}})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if got := strings.Join(queued(), " "); got != "https://example.com/,http://example.com/" || len(job.Skipped) != 0 {
+		t.Errorf("urls job items = %s, skipped %+v", got, job.Skipped)
+	}
+
+	for _, req := range []JobRequest{
+		{Type: TaskURLs, From: "job9"},
+		{Type: TaskSubdomains, From: "job1"},
+		{Type: TaskPorts, From: "job2"},
+		{Type: TaskURLs, From: "job1", Items: []string{"https://example.com/"}},
+		{Type: TaskURLs, From: "job1", Dedupe: "some"},
+	} {
+		if _, err := coordinator.Submit(req); err == nil {
+			t.Errorf("Submit(%+v) succeeded", req)
+		}
+	}
+}
+
+func TestSubmitMaxDuration(t *testing.T) {
+	coordinator := NewCoordinator(artifacts.NewStore(t.TempDir(), "test"))
+	if _, err := coordinator.Submit(JobRequest{Type: TaskPorts, Target: "10.0.0.5", MaxDuration: "soon"}); err == nil {
+		t.Error("Submit() accepted an invalid max_duration")
+	}
+
+	// No agent runs the tasks before the budget elapses
+	job, err := coordinator.Submit(JobRequest{Type: TaskPorts, Target: "10.0.0.5", Ports: "1-3000", ChunkSize: 1000, MaxDuration: "20ms"})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, _ = coordinator.Job(job.ID)
+		if job.Status == StatusCompleted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not stop: %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if job.Canceled != 3 || !strings.Contains(job.Incomplete, "3 of 3 task(s) not run") || job.Artifact == "" {
+		t.Errorf("stopped job = %+v", job)
+	}
+	if len(coordinator.queue) != 0 || len(coordinator.tasks) != 0 {
+		t.Errorf("%d task(s) still queued", len(coordinator.queue))
+	}
+}
+
+func TestDrain(t *testing.T) {
+	coordinator := NewCoordinator(artifacts.NewStore(t.TempDir(), "test"))
+	job, err := coordinator.Submit(JobRequest{Type: TaskPorts, Target: "10.0.0.5", Ports: "1-3000", ChunkSize: 1000})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	running := coordinator.next(context.Background(), "agent1")
+
+	drained := make(chan struct{})
+	go func() {
+		coordinator.Drain(context.Background())
+		close(drained)
+	}()
+
+	// The task handed out is waited for, the others are dropped
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := coordinator.Submit(JobRequest{Type: TaskPorts, Target: "10.0.0.6", Ports: "1-10"}); err == ErrDraining {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Submit() still accepts jobs while draining")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case <-drained:
+		t.Fatal("Drain() returned before the running task reported")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	coordinator.complete(&TaskResult{TaskID: running.ID, AgentID: "agent1", OpenPorts: []int{22}})
+	<-drained
+	job, _ = coordinator.Job(job.ID)
+	if job.Status != StatusCompleted || job.Canceled != 2 || !strings.Contains(job.Incomplete, "shutdown") || job.Artifact == "" {
+		t.Errorf("drained job = %+v, want it saved with the results gathered", job)
+	}
+	if len(job.OpenPorts) != 1 {
+		t.Errorf("open ports = %v, want those of the running task", job.OpenPorts)
+	}
+}
+
+func TestDistributedPortScan(t *testing.T) {
+	// A local service for the agent to find
+	service, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer service.Close()
+	go func() {
+		for {
+			conn, err := service.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	port := service.Addr().(*net.TCPAddr).Port
+
+	root := t.TempDir()
+	coordinator := NewCoordinator(artifacts.NewStore(root, "test"))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go coordinator.Serve(listener)
+	defer coordinator.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	options := DefaultAgentOptions()
+	options.Coordinator = listener.Addr().String()
+	options.Name = "test-agent"
+	options.Timeout = 500 * time.Millisecond
+	go NewAgent(options).Run(ctx)
+
+	ports := strconv.Itoa(port-2) + "-" + strconv.Itoa(port+2)
+	job, err := coordinator.Submit(JobRequest{Type: TaskPorts, Target: "127.0.0.1", Ports: ports, ChunkSize: 2})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if job.Tasks != 3 {
+		t.Fatalf("job has %d tasks, want 3", job.Tasks)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		job, _ = coordinator.Job(job.ID)
+		if job.Status == StatusCompleted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not complete: %+v", job)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if len(job.OpenPorts) == 0 || !containsPort(job.OpenPorts, port) {
+		t.Errorf("open ports = %v, want %d", job.OpenPorts, port)
+	}
+	if len(job.TaskStats) != 3 || job.TaskStats[0].Items != 2 {
+		t.Errorf("task stats = %+v", job.TaskStats)
+	}
+	if len(job.Agents) != 1 {
+		t.Errorf("agents = %v, want one agent", job.Agents)
+	}
+	if _, err := os.Stat(job.Artifact); err != nil {
+		t.Errorf("results were not saved: %v", err)
+	}
+
+	agents := coordinator.Agents()
+	if len(agents) != 1 || agents[0].Name != "test-agent" || agents[0].Completed != 3 {
+		t.Errorf("unexpected agents: %+v", agents)
+	}
+}
+
+func containsPort(ports []int, port int) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+)
+
+// serviceName is the fully qualified name of the coordinator service
+const serviceName = "gopherstrike.distributed.Coordinator"
+
+// JSONCodec encodes gRPC messages as JSON, so GopherStrike's gRPC services need no
+// generated protobuf code
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (JSONCodec) Name() string {
+	return "json"
+}
+
+// coordinatorService is implemented by the coordinator
+type coordinatorService interface {
+	Register(ctx context.Context, req *RegisterRequest) (*RegisterResponse, error)
+	Tasks(req *TasksRequest, stream grpc.ServerStream) error
+	Report(stream grpc.ServerStream) error
+}
+
+// serviceDesc describes the coordinator service:
+//
+//	rpc Register(RegisterRequest) returns (RegisterResponse)
+//	rpc Tasks(TasksRequest) returns (stream Task)
+//	rpc Report(stream TaskResult) returns (ReportAck)
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*coordinatorService)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Register", Handler: registerHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "Tasks", Handler: tasksHandler, ServerStreams: true},
+		{StreamName: "Report", Handler: reportHandler, ClientStreams: true},
+	},
+}
+
+func registerHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(RegisterRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(coordinatorService).Register(ctx, req)
+	}
+
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/Register"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(coordinatorService).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
+
+func tasksHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := new(TasksRequest)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(coordinatorService).Tasks(req, stream)
+}
+
+func reportHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(coordinatorService).Report(stream)
+}
+
+// Stream descriptors used by the agent
+var (
+	tasksStreamDesc  = &grpc.StreamDesc{StreamName: "Tasks", ServerStreams: true}
+	reportStreamDesc = &grpc.StreamDesc{StreamName: "Report", ClientStreams: true}
+)
@@ -0,0 +1,352 @@
+// Package distributed spreads scans across GopherStrike agents. Agents register
+// with a coordinator over gRPC, receive chunks of a scan as tasks and stream
+// their results back, so large scopes can be scanned from several vantage points.
+package distributed
+
+import (
+	"GopherStrike/pkg/dedup"
+	"GopherStrike/pkg/scope"
+	"GopherStrike/pkg/targets"
+	"GopherStrike/pkg/validator"
+	"GopherStrike/pkg/wordlist"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Task types
+const (
+	TaskSubdomains = "subdomains" // Resolve a chunk of subdomain candidates
+	TaskPorts      = "ports"      // Connect scan a range of TCP ports
+	TaskURLs       = "urls"       // Run the web vulnerability scanner on a batch of URLs
+)
+
+// Job states
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+)
+
+// RegisterRequest is sent by an agent when it connects
+type RegisterRequest struct {
+	Name        string `json:"name"`
+	Hostname    string `json:"hostname"`
+	Concurrency int    `json:"concurrency"` // Number of tasks the agent runs at once
+}
+
+// RegisterResponse assigns an ID to a registered agent
+type RegisterResponse struct {
+	AgentID string `json:"agent_id"`
+}
+
+// TasksRequest opens the stream of tasks for an agent
+type TasksRequest struct {
+	AgentID string `json:"agent_id"`
+}
+
+// Task is a chunk of a job assigned to an agent
+type Task struct {
+	ID        string   `json:"id"`
+	JobID     string   `json:"job_id"`
+	Type      string   `json:"type"`
+	Target    string   `json:"target"`
+	Items     []string `json:"items,omitempty"` // Subdomain words or URLs
+	PortStart int      `json:"port_start,omitempty"`
+	PortEnd   int      `json:"port_end,omitempty"`
+}
+
+// SubdomainHit is a subdomain that resolved
+type SubdomainHit struct {
+	Name string   `json:"name"`
+	IPs  []string `json:"ips"`
+}
+
+// URLResult is the outcome of scanning a single URL
+type URLResult struct {
+	URL      string   `json:"url"`
+	Findings []string `json:"findings"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// TaskResult is streamed back by an agent when it finishes a task
+type TaskResult struct {
+	TaskID     string         `json:"task_id"`
+	AgentID    string         `json:"agent_id"`
+	Error      string         `json:"error,omitempty"`
+	Subdomains []SubdomainHit `json:"subdomains,omitempty"`
+	OpenPorts  []int          `json:"open_ports,omitempty"`
+	URLs       []URLResult    `json:"urls,omitempty"`
+	Seconds    float64        `json:"seconds"` // Time the agent spent on the task
+}
+
+// TaskStats are the statistics of a completed task
+type TaskStats struct {
+	Task    string  `json:"task"`
+	Agent   string  `json:"agent"`
+	Items   int     `json:"items"` // Words, ports or URLs of the task
+	Hits    int     `json:"hits"`  // Subdomains resolved, ports open or URLs with findings
+	Seconds float64 `json:"seconds"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// ReportAck acknowledges the results an agent sent
+type ReportAck struct {
+	Received int `json:"received"`
+}
+
+// JobRequest describes a scan to distribute
+type JobRequest struct {
+	Type        string   `json:"type"`
+	Target      string   `json:"target"`
+	Items       []string `json:"items,omitempty"`    // Subdomain words, URLs or the hosts of a ports job
+	Wordlist    string   `json:"wordlist,omitempty"` // Wordlist on the coordinator with the subdomain words, instead of items
+	Ports       string   `json:"ports,omitempty"`    // Port range, e.g. "1-1024"
+	ChunkSize   int      `json:"chunk_size,omitempty"`
+	From        string   `json:"from,omitempty"`         // Completed subdomains job whose hosts a ports or urls job scans, instead of items
+	Dedupe      string   `json:"dedupe,omitempty"`       // Dedupe policy, all or representative; the configured one if empty
+	MaxDuration string   `json:"max_duration,omitempty"` // Time budget, e.g. "2h"; tasks not started by then are dropped
+}
+
+// Job is a distributed scan and its merged results
+type Job struct {
+	ID         string           `json:"id"`
+	Type       string           `json:"type"`
+	Target     string           `json:"target"`
+	Status     string           `json:"status"`
+	Tasks      int              `json:"tasks"`
+	Completed  int              `json:"completed"`
+	Failed     int              `json:"failed"`
+	Canceled   int              `json:"canceled,omitempty"` // Tasks dropped as the time budget elapsed
+	Errors     []string         `json:"errors,omitempty"`
+	Agents     []string         `json:"agents"` // Agents that contributed results
+	Subdomains []SubdomainHit   `json:"subdomains,omitempty"`
+	OpenPorts  []int            `json:"open_ports,omitempty"`
+	HostPorts  map[string][]int `json:"host_ports,omitempty"` // Open ports per host when the target covers several hosts
+	URLs       []URLResult      `json:"urls,omitempty"`
+	TaskStats  []TaskStats      `json:"task_stats,omitempty"`
+	Skipped    []dedup.Group    `json:"skipped,omitempty"`    // Targets left out as another target of their group was scanned
+	Incomplete string           `json:"incomplete,omitempty"` // Why the job did not cover its whole scope
+	Artifact   string           `json:"artifact,omitempty"`
+	Submitted  time.Time        `json:"submitted"`
+	Finished   time.Time        `json:"finished,omitempty"`
+}
+
+// AgentInfo describes a registered agent
+type AgentInfo struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Hostname    string    `json:"hostname"`
+	Address     string    `json:"address"`
+	Concurrency int       `json:"concurrency"`
+	Connected   bool      `json:"connected"`
+	Running     int       `json:"running"`
+	Completed   int       `json:"completed"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// validateWordlist checks the wordlists jobs name, keeping system files
+// out of reach as the subdomain scanner does for the wordlists it is given
+var validateWordlist = (&validator.FilePathValidator{
+	MustExist:    true,
+	AllowedExts:  []string{".txt", ".lst", ".wordlist", ".gz", ""},
+	MaxSizeBytes: 1 << 30,
+}).Validate
+
+// defaultChunkSize returns the default number of items per task for a task type
+func defaultChunkSize(taskType string) int {
+	switch taskType {
+	case TaskPorts:
+		return 1000
+	case TaskURLs:
+		return 10
+	default:
+		return 500
+	}
+}
+
+// parsePortRange parses a "start-end" port range or a single port
+func parsePortRange(s string) (int, int, error) {
+	if s == "" {
+		return 1, 1024, nil
+	}
+
+	parts := strings.SplitN(s, "-", 2)
+	start, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port range: %s", s)
+	}
+	end := start
+	if len(parts) == 2 {
+		if end, err = strconv.Atoi(strings.TrimSpace(parts[1])); err != nil {
+			return 0, 0, fmt.Errorf("invalid port range: %s", s)
+		}
+	}
+
+	if start < 1 || end > 65535 || start > end {
+		return 0, 0, fmt.Errorf("invalid port range: %s", s)
+	}
+	return start, end, nil
+}
+
+// dedupeJob fills in the items of a job that follows a subdomains job, and
+// drops the items that would be scanned twice. A ports job scans each
+// address of the subdomains once. A urls job scans the subdomains over
+// HTTPS, one of those behind the same addresses under the representative
+// policy, which also scans one of the http and https URLs of a page. The
+// groups of targets the policy leaves out are returned.
+func dedupeJob(req *JobRequest, source *Job) ([]dedup.Group, error) {
+	policy := dedup.Default()
+	if req.Dedupe != "" {
+		var err error
+		if policy, err = dedup.ParsePolicy(req.Dedupe); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.From != "" {
+		switch {
+		case source == nil:
+			return nil, fmt.Errorf("unknown job: %s", req.From)
+		case req.Type != TaskPorts && req.Type != TaskURLs:
+			return nil, fmt.Errorf("only ports and urls jobs can follow another job")
+		case source.Type != TaskSubdomains:
+			return nil, fmt.Errorf("%s is not a subdomains job", req.From)
+		case source.Status != StatusCompleted:
+			return nil, fmt.Errorf("%s is not completed", req.From)
+		case len(req.Items) > 0 || req.Wordlist != "":
+			return nil, fmt.Errorf("a job following another takes its items from it")
+		}
+		if req.Target == "" {
+			req.Target = source.Target
+		}
+
+		names := make([]string, 0, len(source.Subdomains))
+		addresses := make(map[string][]string, len(source.Subdomains))
+		for _, hit := range source.Subdomains {
+			names = append(names, hit.Name)
+			addresses[hit.Name] = hit.IPs
+		}
+		if req.Type == TaskPorts {
+			req.Items = dedup.Addresses(names, addresses)
+			if len(req.Items) == 0 {
+				return nil, fmt.Errorf("%s found no addresses to scan", req.From)
+			}
+			return nil, nil
+		}
+		groups := dedup.Hosts(names, addresses)
+		for _, host := range dedup.Select(groups, policy) {
+			req.Items = append(req.Items, "https://"+host+"/")
+		}
+		return dedup.Skipped(groups, policy), nil
+	}
+
+	switch req.Type {
+	case TaskURLs:
+		groups := dedup.URLs(req.Items)
+		req.Items = dedup.Select(groups, policy)
+		return dedup.Skipped(groups, policy), nil
+	case TaskPorts:
+		req.Items = dedup.Distinct(req.Items)
+	}
+	return nil, nil
+}
+
+// splitJob splits a job request into tasks of at most ChunkSize items
+func splitJob(jobID string, req JobRequest) ([]*Task, error) {
+	chunk := req.ChunkSize
+	if chunk <= 0 {
+		chunk = defaultChunkSize(req.Type)
+	}
+
+	tasks := []*Task{}
+	newTask := func() *Task {
+		task := &Task{
+			ID:     fmt.Sprintf("%s-%d", jobID, len(tasks)+1),
+			JobID:  jobID,
+			Type:   req.Type,
+			Target: req.Target,
+		}
+		tasks = append(tasks, task)
+		return task
+	}
+
+	switch req.Type {
+	case TaskSubdomains, TaskURLs:
+		if req.Wordlist != "" {
+			if req.Type != TaskSubdomains || len(req.Items) > 0 {
+				return nil, fmt.Errorf("a wordlist can only replace the items of a subdomains job")
+			}
+			if err := validateWordlist(req.Wordlist); err != nil {
+				return nil, err
+			}
+			// Huge wordlists are read a chunk at a time rather than sent as items
+			chunks, err := wordlist.OpenChunks(context.Background(), req.Wordlist, chunk, 0)
+			if err != nil {
+				return nil, err
+			}
+			for c := range chunks.Chunks {
+				newTask().Items = c.Words
+			}
+			if err := chunks.Err(); err != nil {
+				return nil, fmt.Errorf("failed to read wordlist: %v", err)
+			}
+			if len(tasks) == 0 {
+				return nil, fmt.Errorf("no items to scan")
+			}
+			break
+		}
+		if len(req.Items) == 0 {
+			return nil, fmt.Errorf("no items to scan")
+		}
+		for i := 0; i < len(req.Items); i += chunk {
+			end := i + chunk
+			if end > len(req.Items) {
+				end = len(req.Items)
+			}
+			newTask().Items = req.Items[i:end]
+		}
+	case TaskPorts:
+		start, end, err := parsePortRange(req.Ports)
+		if err != nil {
+			return nil, err
+		}
+		// The target may be a range or list of hosts, or the hosts are
+		// given as items; each gets its own tasks
+		hosts := []string{req.Target}
+		if len(req.Items) > 0 {
+			hosts = req.Items
+		} else if req.Target != "" {
+			expanded, err := targets.Parse(req.Target, targets.DefaultMaxHosts)
+			if err != nil {
+				return nil, err
+			}
+			if len(expanded) > 1 {
+				hosts = expanded
+			}
+		}
+		for _, host := range hosts {
+			// Hosts excluded on the coordinator are never handed to agents
+			if scope.Active().ExcludesHost(host) {
+				continue
+			}
+			for port := start; port <= end; port += chunk {
+				task := newTask()
+				task.Target = host
+				task.PortStart = port
+				task.PortEnd = port + chunk - 1
+				if task.PortEnd > end {
+					task.PortEnd = end
+				}
+			}
+		}
+		if len(tasks) == 0 {
+			return nil, fmt.Errorf("%s is excluded from scanning", req.Target)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported task type: %s", req.Type)
+	}
+
+	return tasks, nil
+}
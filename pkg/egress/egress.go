@@ -0,0 +1,424 @@
+// Package egress routes the connections of the tools through proxies, per
+// tool and per target group: OSINT through Tor, active scanning of an
+// internal range through a jump host, the rest direct. The routes come from
+// the egress file of the workspace and apply to every tool once set. A
+// route can require its proxy, so that a connection it can't carry fails
+// instead of leaking out directly.
+package egress
+
+import (
+	"GopherStrike/pkg/scope"
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// File is the name of the egress routes file in a workspace directory
+const File = "egress.txt"
+
+// Direct is the proxy of routes that connect directly
+const Direct = "direct"
+
+// Routes is a parsed egress routes file
+type Routes struct {
+	Path   string
+	groups map[string]*group
+	routes []Route
+}
+
+// Route sends the connections of tools to a target group through a proxy
+type Route struct {
+	Tools    []string // Tool names; * matches every tool
+	Group    string   // Target group; * matches every target
+	Proxy    *url.URL // nil connects directly
+	Required bool     // Refuse connections the proxy can't carry, or when it is unreachable
+}
+
+// group is a named set of targets
+type group struct {
+	networks []*net.IPNet
+	hosts    map[string]bool
+	domains  []string // Parents of wildcard entries, matching the domain and its subdomains
+}
+
+// LeakError is returned when a connection would bypass a required proxy
+type LeakError struct {
+	Tool    string
+	Target  string
+	Network string
+	Proxy   string
+	Reason  string
+}
+
+func (e *LeakError) Error() string {
+	return fmt.Sprintf("%s connection of %s to %s blocked, %s must go through %s: %s", e.Network, e.Tool, e.Target, e.Tool, e.Proxy, e.Reason)
+}
+
+// Parse reads egress routes, one per line. Empty lines and lines starting
+// with # are skipped. The first route matching a connection applies; an
+// unmatched connection is direct. Each line is one of:
+//
+//	group internal 10.0.0.0/8 *.corp.example.com   a target group of addresses,
+//	                                               ranges, hosts and domains
+//	route osint * socks5h://127.0.0.1:9050 required
+//	route webvuln,dirbruteforce internal http://jump:3128
+//	route * * direct
+//
+// Routes name the tools (comma-separated, or *), the target group (or *)
+// and the proxy: socks5 (names resolved locally), socks5h (names resolved
+// by the proxy), http (CONNECT tunnels) or direct. With required, UDP
+// traffic and connections failing to reach the proxy are refused rather
+// than sent directly.
+func Parse(r io.Reader) (*Routes, error) {
+	routes := &Routes{groups: map[string]*group{}}
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := routes.add(strings.Fields(line)); err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading egress routes: %v", err)
+	}
+	for _, route := range routes.routes {
+		if _, ok := routes.groups[route.Group]; !ok && route.Group != "*" {
+			return nil, fmt.Errorf("route to unknown group %q", route.Group)
+		}
+	}
+	return routes, nil
+}
+
+// Load reads an egress routes file
+func Load(path string) (*Routes, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open egress routes: %v", err)
+	}
+	defer file.Close()
+
+	routes, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid egress routes %s: %v", path, err)
+	}
+	routes.Path = path
+	return routes, nil
+}
+
+// add parses one group or route line
+func (r *Routes) add(fields []string) error {
+	switch fields[0] {
+	case "group":
+		if len(fields) < 3 {
+			return fmt.Errorf("a group needs a name and targets")
+		}
+		g, ok := r.groups[fields[1]]
+		if !ok {
+			g = &group{hosts: map[string]bool{}}
+			r.groups[fields[1]] = g
+		}
+		for _, entry := range fields[2:] {
+			if err := g.add(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "route":
+		if len(fields) < 4 || len(fields) > 5 || (len(fields) == 5 && fields[4] != "required") {
+			return fmt.Errorf("a route is: route <tools> <group> <proxy> [required]")
+		}
+		route := Route{Tools: strings.Split(fields[1], ","), Group: fields[2], Required: len(fields) == 5}
+		if fields[3] != Direct {
+			u, err := url.Parse(fields[3])
+			if err != nil || u.Host == "" {
+				return fmt.Errorf("invalid proxy %q", fields[3])
+			}
+			switch u.Scheme {
+			case "socks5", "socks5h", "http":
+			default:
+				return fmt.Errorf("unsupported proxy scheme %q, use socks5, socks5h or http", u.Scheme)
+			}
+			route.Proxy = u
+		} else if route.Required {
+			return fmt.Errorf("a direct route can't require a proxy")
+		}
+		r.routes = append(r.routes, route)
+		return nil
+	}
+	return fmt.Errorf("unknown directive %q, expected group or route", fields[0])
+}
+
+// add parses one target of a group
+func (g *group) add(entry string) error {
+	if strings.Contains(entry, "/") {
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR range: %s", entry)
+		}
+		g.networks = append(g.networks, network)
+		return nil
+	}
+	if ip := net.ParseIP(entry); ip != nil {
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		g.networks = append(g.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		return nil
+	}
+
+	host := normalizeHost(entry)
+	if domain, found := strings.CutPrefix(host, "*."); found {
+		g.domains = append(g.domains, domain)
+		host = domain
+	} else {
+		g.hosts[host] = true
+	}
+	if host == "" || strings.ContainsAny(host, ":*?[] ") {
+		return fmt.Errorf("invalid hostname: %s", entry)
+	}
+	return nil
+}
+
+// normalizeHost lowercases a hostname and strips the trailing dot
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+}
+
+// contains reports whether a hostname or address literal is in the group
+func (g *group) contains(host string) bool {
+	host = normalizeHost(strings.Trim(host, "[]"))
+	if ip := net.ParseIP(host); ip != nil {
+		for _, network := range g.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	if g.hosts[host] {
+		return true
+	}
+	for _, domain := range g.domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the first route of a tool's connections to host, or nil if
+// they are direct
+func (r *Routes) Match(tool, host string) *Route {
+	if r == nil {
+		return nil
+	}
+	for i, route := range r.routes {
+		if !matchesTool(route.Tools, tool) {
+			continue
+		}
+		if route.Group == "*" || r.groups[route.Group].contains(host) {
+			return &r.routes[i]
+		}
+	}
+	return nil
+}
+
+// Len returns the number of routes
+func (r *Routes) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.routes)
+}
+
+// matchesTool reports whether a route's tools include tool
+func matchesTool(tools []string, tool string) bool {
+	for _, name := range tools {
+		if name == "*" || strings.EqualFold(name, tool) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	active *Routes
+	mu     sync.RWMutex
+)
+
+// Set makes routes the ones applied by DialContext. A nil value connects
+// every tool directly.
+func Set(r *Routes) {
+	mu.Lock()
+	defer mu.Unlock()
+	active = r
+}
+
+// Active returns the applied routes, or nil if there are none
+func Active() *Routes {
+	mu.RLock()
+	defer mu.RUnlock()
+	return active
+}
+
+// DialContext wraps a dial function so that the connections of tool follow
+// the active routes. Direct connections go through the scope guard; proxied
+// ones are checked against the exclusions before the proxy is asked for
+// them, without looking up the names the proxy resolves, and dial connects
+// to the proxy.
+func DialContext(tool string, dial scope.DialFunc) scope.DialFunc {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	direct := scope.DialContext(dial)
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		route := Active().Match(tool, host)
+		if route == nil || route.Proxy == nil {
+			return direct(ctx, network, address)
+		}
+
+		leak := &LeakError{Tool: tool, Target: address, Network: network, Proxy: route.Proxy.Redacted()}
+		if !strings.HasPrefix(network, "tcp") {
+			if route.Required {
+				leak.Reason = "the proxy only carries TCP"
+				return nil, leak
+			}
+			return direct(ctx, network, address)
+		}
+		// socks5 names are resolved once here and the checked addresses are
+		// the ones the proxy is asked for; other proxies resolve the names
+		// themselves, so they are not looked up locally
+		targets := []string{address}
+		if route.Proxy.Scheme == "socks5" {
+			addresses, err := scope.Resolve(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			if len(addresses) == 0 {
+				return nil, fmt.Errorf("no address for %s", host)
+			}
+			targets = targets[:0]
+			for _, ip := range addresses {
+				targets = append(targets, net.JoinHostPort(ip, port))
+			}
+		} else if err := scope.CheckUnresolved(ctx, address); err != nil {
+			return nil, err
+		}
+
+		for _, target := range targets {
+			var conn net.Conn
+			conn, err = route.dial(ctx, dial, target)
+			if err == nil {
+				return conn, nil
+			}
+		}
+		if route.Required || ctx.Err() != nil {
+			return nil, err
+		}
+		return direct(ctx, network, address)
+	}
+}
+
+// contextDialer adapts a dial function to the dialer interfaces of the
+// SOCKS client
+type contextDialer scope.DialFunc
+
+func (d contextDialer) Dial(network, address string) (net.Conn, error) {
+	return d(context.Background(), network, address)
+}
+
+func (d contextDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return d(ctx, network, address)
+}
+
+// dial connects to address through the route's proxy, using dial to reach
+// the proxy
+func (r *Route) dial(ctx context.Context, dial scope.DialFunc, address string) (net.Conn, error) {
+	switch r.Proxy.Scheme {
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if r.Proxy.User != nil {
+			password, _ := r.Proxy.User.Password()
+			auth = &proxy.Auth{User: r.Proxy.User.Username(), Password: password}
+		}
+		dialer, err := proxy.SOCKS5("tcp", r.Proxy.Host, auth, contextDialer(dial))
+		if err != nil {
+			return nil, err
+		}
+		conn, err := dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", address)
+		if err != nil {
+			return nil, fmt.Errorf("proxy %s: %v", r.Proxy.Redacted(), err)
+		}
+		return conn, nil
+	default:
+		return r.connect(ctx, dial, address)
+	}
+}
+
+// bufferedConn is a connection whose first bytes were read ahead
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// connect opens a CONNECT tunnel to address through an HTTP proxy
+func (r *Route) connect(ctx context.Context, dial scope.DialFunc, address string) (net.Conn, error) {
+	conn, err := dial(ctx, "tcp", r.Proxy.Host)
+	if err != nil {
+		return nil, fmt.Errorf("proxy %s: %v", r.Proxy.Redacted(), err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	req := &http.Request{Method: http.MethodConnect, URL: &url.URL{Opaque: address}, Host: address, Header: http.Header{}}
+	if r.Proxy.User != nil {
+		password, _ := r.Proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(r.Proxy.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %v", r.Proxy.Redacted(), err)
+	}
+
+	// Services such as SSH and SMTP speak first, their banner may follow
+	// the response in the same read
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %v", r.Proxy.Redacted(), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused the tunnel to %s: %s", r.Proxy.Redacted(), address, resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
@@ -0,0 +1,382 @@
+package egress
+
+import (
+	"GopherStrike/pkg/scope"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestRoutes(t *testing.T) {
+	routes, err := Parse(strings.NewReader(`
+# Engagement egress
+group internal 10.0.0.0/8 192.168.1.5 *.corp.example.com
+group partner portal.partner.example
+route osint * socks5h://127.0.0.1:9050 required
+route webvuln,dirbruteforce internal http://jump:3128
+route * partner direct
+route * * socks5://127.0.0.1:1080
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if routes.Len() != 4 {
+		t.Fatalf("Len() = %d, want 4", routes.Len())
+	}
+
+	tests := []struct {
+		tool, host string
+		want       string // Proxy of the matched route, "" when direct
+	}{
+		{"osint", "example.com", "socks5h://127.0.0.1:9050"},
+		{"webvuln", "10.1.2.3", "http://jump:3128"},
+		{"DirBruteforce", "[192.168.1.5]", "http://jump:3128"},
+		{"webvuln", "intranet.corp.example.com.", "http://jump:3128"},
+		{"webvuln", "corp.example.com", "http://jump:3128"},
+		{"webvuln", "PORTAL.partner.example", ""},
+		{"sshaudit", "10.1.2.3", "socks5://127.0.0.1:1080"},
+		{"webvuln", "notcorp.example.com", "socks5://127.0.0.1:1080"},
+	}
+	for _, tt := range tests {
+		route := routes.Match(tt.tool, tt.host)
+		got := ""
+		if route != nil && route.Proxy != nil {
+			got = route.Proxy.String()
+		}
+		if got != tt.want {
+			t.Errorf("Match(%q, %q) proxy = %q, want %q", tt.tool, tt.host, got, tt.want)
+		}
+	}
+	if route := (*Routes)(nil).Match("osint", "example.com"); route != nil {
+		t.Errorf("nil routes Match() = %+v, want nil", route)
+	}
+
+	for _, line := range []string{
+		"route * * direct required",
+		"route * * ftp://proxy:21",
+		"route * * socks5://",
+		"route * lab direct",
+		"route osint *",
+		"group lab 10.0.0.0/33",
+		"group lab",
+		"proxy * * direct",
+	} {
+		if _, err := Parse(strings.NewReader(line)); err == nil {
+			t.Errorf("Parse(%q) succeeded", line)
+		}
+	}
+}
+
+// connectProxy starts an HTTP proxy opening CONNECT tunnels and counting
+// them, and returns its address
+func connectProxy(t *testing.T, tunnels *int32) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				req, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil || req.Method != http.MethodConnect {
+					return
+				}
+				target, err := net.Dial("tcp", req.Host)
+				if err != nil {
+					fmt.Fprint(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+					return
+				}
+				defer target.Close()
+				atomic.AddInt32(tunnels, 1)
+				fmt.Fprint(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+				go io.Copy(target, conn)
+				io.Copy(conn, target)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// bannerServer starts a server greeting its clients, and returns its address
+func bannerServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			fmt.Fprint(conn, "SSH-2.0-OpenSSH_9.6\r\n")
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestDialContext(t *testing.T) {
+	var tunnels int32
+	proxyAddress := connectProxy(t, &tunnels)
+	target := bannerServer(t)
+
+	// An unused port stands for an unreachable proxy
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	down := listener.Addr().String()
+	listener.Close()
+
+	routes, err := Parse(strings.NewReader(fmt.Sprintf(`
+route proxied * http://%s required
+route fallback * http://%s
+route mandated * http://%s required
+`, proxyAddress, down, down)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	Set(routes)
+	defer Set(nil)
+
+	banner := func(tool string) (string, error) {
+		conn, err := DialContext(tool, nil)(context.Background(), "tcp", target)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		return bufio.NewReader(conn).ReadString('\n')
+	}
+
+	// The banner sent with the tunnel response isn't lost
+	if got, err := banner("proxied"); err != nil || got != "SSH-2.0-OpenSSH_9.6\r\n" {
+		t.Errorf("proxied banner = %q, %v", got, err)
+	}
+	if n := atomic.LoadInt32(&tunnels); n != 1 {
+		t.Errorf("tunnels = %d, want 1", n)
+	}
+
+	// Unrouted tools and optional proxies that are down connect directly
+	for _, tool := range []string{"direct", "fallback"} {
+		if _, err := banner(tool); err != nil {
+			t.Errorf("%s connection error = %v", tool, err)
+		}
+	}
+	if n := atomic.LoadInt32(&tunnels); n != 1 {
+		t.Errorf("tunnels = %d after direct connections, want 1", n)
+	}
+
+	// A required proxy never leaks
+	if _, err := banner("mandated"); err == nil {
+		t.Error("connection through an unreachable required proxy succeeded")
+	}
+	_, err = DialContext("proxied", nil)(context.Background(), "udp", "127.0.0.1:53")
+	var leak *LeakError
+	if !errors.As(err, &leak) || leak.Tool != "proxied" || leak.Network != "udp" {
+		t.Errorf("UDP through a required proxy error = %v, want a LeakError", err)
+	}
+}
+
+// socksProxy starts a SOCKS5 proxy connecting its clients to target,
+// whatever they ask for, and sends the requested hosts to requested
+func socksProxy(t *testing.T, target string, requested chan<- string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				greeting := make([]byte, 3)
+				if _, err := io.ReadFull(conn, greeting); err != nil {
+					return
+				}
+				conn.Write([]byte{5, 0})
+				header := make([]byte, 4)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				var host string
+				switch header[3] {
+				case 1:
+					ip := make([]byte, 4)
+					io.ReadFull(conn, ip)
+					host = net.IP(ip).String()
+				case 3:
+					length := make([]byte, 1)
+					io.ReadFull(conn, length)
+					name := make([]byte, length[0])
+					io.ReadFull(conn, name)
+					host = string(name)
+				}
+				io.ReadFull(conn, make([]byte, 2))
+				requested <- host
+
+				upstream, err := net.Dial("tcp", target)
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(upstream, conn)
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestProxyResolvedNamesNotLookedUp(t *testing.T) {
+	requested := make(chan string, 4)
+	proxyAddress := socksProxy(t, bannerServer(t), requested)
+
+	// Any local lookup fails the test
+	var lookups int32
+	resolver := net.DefaultResolver
+	net.DefaultResolver = &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+		atomic.AddInt32(&lookups, 1)
+		return nil, errors.New("local DNS lookup")
+	}}
+	defer func() { net.DefaultResolver = resolver }()
+
+	exclusions, err := scope.Parse(strings.NewReader("192.0.2.0/24\nblocked.example\n"))
+	if err != nil {
+		t.Fatalf("scope.Parse() error = %v", err)
+	}
+	scope.Set(exclusions)
+	defer scope.Set(nil)
+	routes, err := Parse(strings.NewReader("route osint * socks5h://" + proxyAddress + " required\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	Set(routes)
+	defer Set(nil)
+
+	conn, err := DialContext("osint", nil)(context.Background(), "tcp", "target.example:22")
+	if err != nil {
+		t.Fatalf("DialContext() error = %v", err)
+	}
+	conn.Close()
+	if host := <-requested; host != "target.example" {
+		t.Errorf("proxy asked for %q, want the hostname", host)
+	}
+
+	// Excluded names are refused before reaching the proxy
+	var excluded *scope.ExcludedError
+	if _, err := DialContext("osint", nil)(context.Background(), "tcp", "blocked.example:22"); !errors.As(err, &excluded) {
+		t.Errorf("DialContext(blocked.example) error = %v, want excluded", err)
+	}
+	if n := atomic.LoadInt32(&lookups); n != 0 {
+		t.Errorf("%d local DNS lookups for a socks5h route", n)
+	}
+}
+
+// rebindingResolver makes the default resolver answer the first A query with
+// first and the later ones with then, like a rebinding DNS server
+func rebindingResolver(t *testing.T, first, then string) {
+	t.Helper()
+	var queries int32
+	resolver := net.DefaultResolver
+	net.DefaultResolver = &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() {
+			defer server.Close()
+			length := make([]byte, 2)
+			if _, err := io.ReadFull(server, length); err != nil {
+				return
+			}
+			query := make([]byte, int(length[0])<<8|int(length[1]))
+			if _, err := io.ReadFull(server, query); err != nil {
+				return
+			}
+			var request dnsmessage.Message
+			if err := request.Unpack(query); err != nil || len(request.Questions) == 0 {
+				return
+			}
+			question := request.Questions[0]
+			answer := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: request.ID, Response: true, Authoritative: true},
+				Questions: request.Questions,
+			}
+			if question.Type == dnsmessage.TypeA {
+				ip := then
+				if atomic.AddInt32(&queries, 1) == 1 {
+					ip = first
+				}
+				var a [4]byte
+				copy(a[:], net.ParseIP(ip).To4())
+				answer.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+					Body:   &dnsmessage.AResource{A: a},
+				}}
+			}
+			packed, err := answer.Pack()
+			if err != nil {
+				return
+			}
+			server.Write(append([]byte{byte(len(packed) >> 8), byte(len(packed))}, packed...))
+		}()
+		return client, nil
+	}}
+	t.Cleanup(func() { net.DefaultResolver = resolver })
+}
+
+func TestLocallyResolvedNamesDialChecked(t *testing.T) {
+	requested := make(chan string, 4)
+	proxyAddress := socksProxy(t, bannerServer(t), requested)
+	rebindingResolver(t, "127.0.0.1", "192.0.2.1")
+
+	exclusions, err := scope.Parse(strings.NewReader("192.0.2.0/24\n"))
+	if err != nil {
+		t.Fatalf("scope.Parse() error = %v", err)
+	}
+	scope.Set(exclusions)
+	defer scope.Set(nil)
+	routes, err := Parse(strings.NewReader("route osint * socks5://" + proxyAddress + " required\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	Set(routes)
+	defer Set(nil)
+
+	conn, err := DialContext("osint", nil)(context.Background(), "tcp", "rebind.example:22")
+	if err != nil {
+		t.Fatalf("DialContext() error = %v", err)
+	}
+	conn.Close()
+	if host := <-requested; host != "127.0.0.1" {
+		t.Errorf("proxy asked for %q, want the checked address 127.0.0.1", host)
+	}
+
+	// The name now resolves to an excluded address
+	var excluded *scope.ExcludedError
+	if _, err := DialContext("osint", nil)(context.Background(), "tcp", "rebind.example:22"); !errors.As(err, &excluded) {
+		t.Errorf("DialContext() after rebinding error = %v, want excluded", err)
+	}
+}
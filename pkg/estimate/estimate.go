@@ -0,0 +1,154 @@
+// Package estimate predicts the cost of a scan before it is launched: the
+// requests it sends, how long they take at the concurrency, pace and
+// bandwidth cap in use, and the bytes transferred. The tools count their
+// requests from the wordlists, payload levels and targets selected, and the
+// estimate is shown for confirmation, so that a level 5 scan of a large scope
+// is not fired by mistake. Estimates are upper bounds: tools stop early on
+// quiet targets, and the latency and response sizes of the target are
+// unknown until it is scanned.
+package estimate
+
+import (
+	"GopherStrike/pkg/bandwidth"
+	"GopherStrike/pkg/budget"
+	"GopherStrike/pkg/term"
+	"GopherStrike/pkg/timing"
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultLatency is the assumed round trip of a request
+	DefaultLatency = 300 * time.Millisecond
+
+	// DefaultResponseSize is the assumed size of a response, headers included
+	DefaultResponseSize = 16 << 10
+
+	// requestSize is the assumed size of a request line and its headers
+	requestSize = 600
+)
+
+// Estimate is the predicted cost of a scan
+type Estimate struct {
+	Targets      int           // Targets scanned, 1 if 0
+	Requests     int64         // Requests sent to each target, at most
+	Workers      int           // Requests in flight at once, 1 if 0
+	Latency      time.Duration // Time of a request, DefaultLatency if 0
+	ResponseSize int64         // Bytes received per request, DefaultResponseSize if 0
+	Budget       time.Duration // The scan's own time budget, 0 for none
+	Notes        []string      // What the estimate leaves out or assumes
+}
+
+// Total returns the requests sent to all targets
+func (e Estimate) Total() int64 {
+	return e.Requests * int64(max(e.Targets, 1))
+}
+
+// Bytes returns the bytes sent and received by all requests
+func (e Estimate) Bytes() int64 {
+	size := e.ResponseSize
+	if size <= 0 {
+		size = DefaultResponseSize
+	}
+	return e.Total() * (requestSize + size)
+}
+
+// Duration returns the time the requests take: the workers send them in
+// parallel, but no faster than the pause of the timing profile between
+// probes or the bandwidth cap allow
+func (e Estimate) Duration() time.Duration {
+	latency := e.Latency
+	if latency <= 0 {
+		latency = DefaultLatency
+	}
+	workers := int64(max(e.Workers, 1))
+	d := time.Duration((e.Total()+workers-1)/workers) * latency
+
+	if delay := timing.Current().Delay; delay > 0 {
+		d = max(d, time.Duration(e.Total())*delay)
+	}
+	if rate := bandwidth.Limit(); rate > 0 {
+		d = max(d, time.Duration(float64(e.Bytes())/float64(rate)*float64(time.Second)))
+	}
+	return d
+}
+
+// limit returns the budget that stops the scan first, 0 for none
+func (e Estimate) limit() time.Duration {
+	limit := budget.Limit()
+	if e.Budget > 0 && (limit == 0 || e.Budget < limit) {
+		limit = e.Budget
+	}
+	return limit
+}
+
+// Print writes the estimate
+func (e Estimate) Print(w io.Writer) {
+	requests := FormatCount(e.Total()) + " requests"
+	if e.Targets > 1 {
+		requests += fmt.Sprintf(" (%s to each of %d targets)", FormatCount(e.Requests), e.Targets)
+	}
+	fmt.Fprintf(w, "\n[+] Estimated cost: up to %s\n", requests)
+	fmt.Fprintf(w, "    - Duration: ~%s with %d parallel requests\n", FormatDuration(e.Duration()), max(e.Workers, 1))
+	fmt.Fprintf(w, "    - Transferred: ~%s\n", FormatBytes(e.Bytes()))
+	if limit := e.limit(); limit > 0 && e.Duration() > limit {
+		fmt.Fprintf(w, "[!] The time budget of %s stops the scan before it completes\n", limit)
+	}
+	for _, note := range e.Notes {
+		fmt.Fprintf(w, "[i] %s\n", note)
+	}
+}
+
+// Confirm prints the estimate and asks whether to launch the scan. A nil
+// reader reads standard input word by word, like the prompts of the tools
+// using fmt.Scanln. Headless runs are not asked and always go ahead.
+func Confirm(w io.Writer, reader *bufio.Reader, e Estimate) bool {
+	e.Print(w)
+	if term.Headless() {
+		return true
+	}
+
+	fmt.Fprint(w, "[?] Launch the scan? (Y/n): ")
+	var answer string
+	if reader != nil {
+		answer, _ = reader.ReadString('\n')
+	} else {
+		fmt.Scanln(&answer)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+// FormatCount returns n with thousands separators, e.g. 12,345
+func FormatCount(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0 && s[i-1] != '-'; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// FormatBytes returns a size in B, KB, MB or GB
+func FormatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return strconv.FormatFloat(float64(n)/(1<<30), 'f', 1, 64) + " GB"
+	case n >= 1<<20:
+		return strconv.FormatFloat(float64(n)/(1<<20), 'f', 1, 64) + " MB"
+	case n >= 1<<10:
+		return strconv.FormatFloat(float64(n)/(1<<10), 'f', 1, 64) + " KB"
+	}
+	return strconv.FormatInt(n, 10) + " B"
+}
+
+// FormatDuration returns d rounded to the second, or to the minute past an hour
+func FormatDuration(d time.Duration) string {
+	if d >= time.Hour {
+		return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,84 @@
+package estimate
+
+import (
+	"GopherStrike/pkg/bandwidth"
+	"GopherStrike/pkg/budget"
+	"GopherStrike/pkg/term"
+	"GopherStrike/pkg/timing"
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	defer timing.Set(timing.Current())
+	defer bandwidth.Set(0)
+
+	e := Estimate{Targets: 2, Requests: 500, Workers: 10, Latency: 100 * time.Millisecond, ResponseSize: 1400}
+	if e.Total() != 1000 || e.Bytes() != 2_000_000 {
+		t.Errorf("Total(), Bytes() = %d, %d, want 1000, 2000000", e.Total(), e.Bytes())
+	}
+	if got := e.Duration(); got != 10*time.Second {
+		t.Errorf("Duration() = %s, want the workers sharing the requests", got)
+	}
+
+	// The pause between probes and the bandwidth cap slow the scan down
+	timing.Set(timing.Profiles[timing.Polite])
+	if got := e.Duration(); got != 400*time.Second {
+		t.Errorf("polite Duration() = %s, want one probe every 0.4 seconds", got)
+	}
+	timing.Set(timing.Profiles[timing.Normal])
+	bandwidth.Set(1000)
+	if got := e.Duration(); got != 2000*time.Second {
+		t.Errorf("capped Duration() = %s, want the bytes at the cap", got)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	defer budget.SetLimit(0)
+	budget.SetLimit(time.Minute)
+
+	var out strings.Builder
+	Estimate{Targets: 3, Requests: 4000, Workers: 4, Notes: []string{"Recursion is not counted"}}.Print(&out)
+	for _, want := range []string{"up to 12,000 requests (4,000 to each of 3 targets)", "~15m0s with 4 parallel", "~194.4 MB", "time budget of 1m0s", "Recursion is not counted"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Print() = %q, missing %q", out.String(), want)
+		}
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	defer term.SetHeadless(false)
+
+	tests := map[string]bool{"\n": true, "y\n": true, "YES\n": true, "n\n": false, "no\n": false}
+	for answer, want := range tests {
+		var out strings.Builder
+		if got := Confirm(&out, bufio.NewReader(strings.NewReader(answer)), Estimate{Requests: 1}); got != want {
+			t.Errorf("Confirm(%q) = %v, want %v", answer, got, want)
+		}
+	}
+
+	term.SetHeadless(true)
+	if !Confirm(&strings.Builder{}, bufio.NewReader(strings.NewReader("n\n")), Estimate{Requests: 1}) {
+		t.Error("Confirm() in headless mode asked")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for n, want := range map[int64]string{0: "0", 999: "999", 1000: "1,000", 1234567: "1,234,567"} {
+		if got := FormatCount(n); got != want {
+			t.Errorf("FormatCount(%d) = %s, want %s", n, got, want)
+		}
+	}
+	for n, want := range map[int64]string{512: "512 B", 1536: "1.5 KB", 3 << 30: "3.0 GB"} {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %s, want %s", n, got, want)
+		}
+	}
+	for d, want := range map[time.Duration]string{90 * time.Second: "1m30s", 150*time.Minute + 20*time.Second: "2h30m"} {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%s) = %s, want %s", d, got, want)
+		}
+	}
+}
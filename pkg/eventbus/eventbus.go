@@ -0,0 +1,148 @@
+// Package eventbus distributes scan events inside GopherStrike and runs the
+// hooks users configure for them
+package eventbus
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	ScanStarted   = "scan.started"
+	FindingNew    = "finding.new"
+	ScanCompleted = "scan.completed"
+)
+
+// Scan outcomes reported by scan.completed
+const (
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Event is something that happened during a scan
+type Event struct {
+	Type   string      `json:"type"`
+	Time   time.Time   `json:"time"`
+	Tool   string      `json:"tool"`
+	Target string      `json:"target"`
+	Status string      `json:"status,omitempty"` // Outcome, for scan.completed
+	Error  string      `json:"error,omitempty"`
+	Job    string      `json:"job,omitempty"`  // ID of the server scan job the event belongs to
+	Data   interface{} `json:"data,omitempty"` // e.g. the finding for finding.new
+}
+
+// jobKey is the context key of the job ID set by WithJob
+type jobKey struct{}
+
+// WithJob returns a context whose events, such as the findings emitted with
+// it, belong to a scan job of the server
+func WithJob(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, jobKey{}, id)
+}
+
+// JobFrom returns the scan job ID set by WithJob, or ""
+func JobFrom(ctx context.Context) string {
+	id, _ := ctx.Value(jobKey{}).(string)
+	return id
+}
+
+// Handler is called with published events
+type Handler func(Event)
+
+// Bus delivers published events to the handlers subscribed to them
+type Bus struct {
+	handlers map[int]subscription
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+// subscription is a handler and the event pattern it listens to
+type subscription struct {
+	pattern string
+	handler Handler
+}
+
+// NewBus creates an event bus
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[int]subscription)}
+}
+
+// Subscribe calls handler for every event matching pattern and returns a
+// function that removes the subscription. Patterns are an event type, a
+// prefix such as "scan.*", or "*" for all events.
+func (b *Bus) Subscribe(pattern string, handler Handler) func() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	b.handlers[id] = subscription{pattern: pattern, handler: handler}
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+// Publish delivers an event to the matching handlers. Handlers run
+// synchronously, so they must not block.
+func (b *Bus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mutex.RLock()
+	handlers := []Handler{}
+	for _, sub := range b.handlers {
+		if Match(sub.pattern, event.Type) {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	b.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// Match reports whether an event type matches a pattern
+func Match(pattern, eventType string) bool {
+	if pattern == "*" || pattern == eventType {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasSuffix(prefix, ".") {
+		return strings.HasPrefix(eventType, prefix)
+	}
+	return false
+}
+
+// defaultBus is the bus used by the package-level functions
+var defaultBus = NewBus()
+
+// Subscribe subscribes a handler to events of the default bus
+func Subscribe(pattern string, handler Handler) func() {
+	return defaultBus.Subscribe(pattern, handler)
+}
+
+// Publish publishes an event on the default bus
+func Publish(event Event) {
+	defaultBus.Publish(event)
+}
+
+// ScanFinished publishes scan.completed for a scan, deriving its status from
+// the error it ended with
+func ScanFinished(tool, target string, cancelled bool, err error, data interface{}) {
+	event := Event{Type: ScanCompleted, Tool: tool, Target: target, Status: StatusCompleted, Data: data}
+	switch {
+	case cancelled:
+		event.Status = StatusCancelled
+	case err != nil:
+		event.Status = StatusFailed
+		event.Error = err.Error()
+	}
+	Publish(event)
+}
@@ -0,0 +1,191 @@
+package eventbus
+
+import (
+	"GopherStrike/pkg/config"
+	"GopherStrike/pkg/egress"
+	"GopherStrike/pkg/redact"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		eventType string
+		want      bool
+	}{
+		{"*", ScanStarted, true},
+		{ScanStarted, ScanStarted, true},
+		{ScanStarted, ScanCompleted, false},
+		{"scan.*", ScanCompleted, true},
+		{"scan.*", FindingNew, false},
+		{"scan*", ScanStarted, false},
+		{"", ScanStarted, false},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.eventType); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.eventType, got, tt.want)
+		}
+	}
+}
+
+func TestBusSubscribe(t *testing.T) {
+	bus := NewBus()
+
+	received := []string{}
+	unsubscribe := bus.Subscribe("scan.*", func(event Event) {
+		received = append(received, event.Type)
+		if event.Time.IsZero() {
+			t.Error("published event has no time")
+		}
+	})
+
+	bus.Publish(Event{Type: ScanStarted})
+	bus.Publish(Event{Type: FindingNew})
+	bus.Publish(Event{Type: ScanCompleted})
+	unsubscribe()
+	bus.Publish(Event{Type: ScanStarted})
+
+	if strings.Join(received, ",") != ScanStarted+","+ScanCompleted {
+		t.Errorf("received %v, want the two scan events before unsubscribing", received)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	dir := t.TempDir()
+	bus := NewBus()
+
+	var webhookBody []byte
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		webhookBody, _ = io.ReadAll(r.Body)
+	}))
+	defer webhook.Close()
+
+	eventsFile := filepath.Join(dir, "events", "all.jsonl")
+	commandFile := filepath.Join(dir, "command.txt")
+	hooks := []config.HookConfig{
+		{Event: "*", Type: "file", Path: eventsFile},
+		{Event: FindingNew, Type: "webhook", URL: webhook.URL, Headers: map[string]string{"X-Token": "secret"}},
+		{Event: ScanCompleted, Type: "command", Command: []string{"sh", "-c", `echo "$GOPHERSTRIKE_EVENT $GOPHERSTRIKE_TARGET $GOPHERSTRIKE_STATUS" > "$0"`, commandFile}},
+	}
+
+	stops := []func(){}
+	for _, hook := range hooks {
+		stop, err := bus.AddHook(hook)
+		if err != nil {
+			t.Fatalf("AddHook() error = %v", err)
+		}
+		stops = append(stops, stop)
+	}
+
+	bus.Publish(Event{Type: ScanStarted, Tool: "webvuln", Target: "example.com"})
+	bus.Publish(Event{Type: FindingNew, Tool: "webvuln", Target: "example.com", Data: map[string]string{"name": "XSS"}})
+	bus.Publish(Event{Type: ScanCompleted, Tool: "webvuln", Target: "example.com", Status: StatusCompleted})
+
+	// Stopping waits for the queued events to be handled
+	for _, stop := range stops {
+		stop()
+	}
+
+	data, err := os.ReadFile(eventsFile)
+	if err != nil {
+		t.Fatalf("file hook did not write: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 3 {
+		t.Errorf("file hook wrote %d events, want 3", len(lines))
+	}
+
+	var event Event
+	if err := json.Unmarshal(webhookBody, &event); err != nil || event.Type != FindingNew {
+		t.Errorf("webhook received %q, want the finding.new event", webhookBody)
+	}
+
+	output, err := os.ReadFile(commandFile)
+	if err != nil {
+		t.Fatalf("command hook did not run: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != "scan.completed example.com completed" {
+		t.Errorf("command hook saw %q", got)
+	}
+}
+
+func TestFileHookRedacted(t *testing.T) {
+	rules, err := redact.Parse(strings.NewReader("param:session\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	redact.Set(rules)
+	defer redact.Set(nil)
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	bus := NewBus()
+	stop, err := bus.AddHook(config.HookConfig{Event: "*", Type: "file", Path: path})
+	if err != nil {
+		t.Fatalf("AddHook() error = %v", err)
+	}
+	bus.Publish(Event{Type: FindingNew, Tool: "webvuln", Target: "example.com", Data: map[string]string{"url": "https://example.com/?session=abc123"}})
+	stop()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("file hook did not write: %v", err)
+	}
+	if strings.Contains(string(data), "abc123") || !strings.Contains(string(data), "session=[REDACTED]") {
+		t.Errorf("file hook wrote %s, want the session redacted", data)
+	}
+}
+
+func TestWebhookFollowsEgressRoutes(t *testing.T) {
+	requests := 0
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer webhook.Close()
+
+	// A required route through a proxy that is down lets nothing out
+	routes, err := egress.Parse(strings.NewReader("route hooks * http://127.0.0.1:1 required\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	egress.Set(routes)
+	defer egress.Set(nil)
+
+	bus := NewBus()
+	stop, err := bus.AddHook(config.HookConfig{Event: "*", Type: "webhook", URL: webhook.URL})
+	if err != nil {
+		t.Fatalf("AddHook() error = %v", err)
+	}
+	bus.Publish(Event{Type: FindingNew, Tool: "webvuln", Target: "example.com"})
+	stop()
+
+	if requests != 0 {
+		t.Errorf("webhook received %d requests despite the required route", requests)
+	}
+}
+
+func TestAddHookRejectsInvalidConfig(t *testing.T) {
+	invalid := []config.HookConfig{
+		{Type: "file", Path: "events.jsonl"},
+		{Event: "*", Type: "email"},
+		{Event: "*", Type: "command"},
+		{Event: "*", Type: "webhook", URL: "ftp://example.com"},
+		{Event: "*", Type: "file"},
+	}
+
+	for _, hook := range invalid {
+		if _, err := NewBus().AddHook(hook); err == nil {
+			t.Errorf("AddHook(%+v) expected an error", hook)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package eventbus
+
+import (
+	"GopherStrike/pkg/config"
+	"GopherStrike/pkg/httpclient"
+	"GopherStrike/pkg/redact"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultHookTimeout applies to commands and webhooks without a timeout
+	defaultHookTimeout = 10 * time.Second
+
+	// hookQueueSize is the number of events queued per hook; events are dropped
+	// while a hook is further behind
+	hookQueueSize = 100
+)
+
+// hook runs a configured action for events, one at a time in the background
+type hook struct {
+	config config.HookConfig
+	name   string
+	events chan Event
+	client *http.Client
+}
+
+// AddHook subscribes a configured hook to the bus. The returned function
+// unsubscribes it and waits for queued events to be handled.
+func (b *Bus) AddHook(cfg config.HookConfig) (func(), error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	timeout := defaultHookTimeout
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	h := &hook{
+		config: cfg,
+		name:   fmt.Sprintf("%s hook for %s", cfg.Type, cfg.Event),
+		events: make(chan Event, hookQueueSize),
+		client: httpclient.New("hooks", timeout, nil),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for event := range h.events {
+			if err := h.run(event, timeout); err != nil {
+				fmt.Printf("[!] %s failed: %v\n", h.name, err)
+			}
+		}
+	}()
+
+	var mutex sync.Mutex
+	stopped := false
+	unsubscribe := b.Subscribe(cfg.Event, func(event Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if stopped {
+			return
+		}
+		select {
+		case h.events <- event:
+		default:
+			fmt.Printf("[!] %s is falling behind, dropped %s event\n", h.name, event.Type)
+		}
+	})
+
+	return func() {
+		unsubscribe()
+		mutex.Lock()
+		if !stopped {
+			stopped = true
+			close(h.events)
+		}
+		mutex.Unlock()
+		wg.Wait()
+	}, nil
+}
+
+// RegisterHooks adds hooks to the default bus. The returned function removes
+// them again after their queued events have been handled.
+func RegisterHooks(hooks []config.HookConfig) (func(), error) {
+	stops := []func(){}
+	stopAll := func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+
+	for i, cfg := range hooks {
+		stop, err := defaultBus.AddHook(cfg)
+		if err != nil {
+			stopAll()
+			return nil, fmt.Errorf("hook %d: %v", i+1, err)
+		}
+		stops = append(stops, stop)
+	}
+	return stopAll, nil
+}
+
+// run executes the hook's action for an event
+func (h *hook) run(event Event, timeout time.Duration) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+
+	switch h.config.Type {
+	case "command":
+		return h.runCommand(event, payload, timeout)
+	case "webhook":
+		return h.callWebhook(payload)
+	case "file":
+		return h.appendFile(payload)
+	}
+	return fmt.Errorf("invalid hook type: %s", h.config.Type)
+}
+
+// runCommand runs the command with the event as JSON on stdin and its main
+// fields in environment variables
+func (h *hook) runCommand(event Event, payload []byte, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.config.Command[0], h.config.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"GOPHERSTRIKE_EVENT="+event.Type,
+		"GOPHERSTRIKE_TOOL="+event.Tool,
+		"GOPHERSTRIKE_TARGET="+event.Target,
+		"GOPHERSTRIKE_STATUS="+event.Status,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// callWebhook POSTs the event as JSON
+func (h *hook) callWebhook(payload []byte) error {
+	req, err := http.NewRequest("POST", h.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GopherStrike/1.0")
+	for name, value := range h.config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// appendFile appends the event to the file as a JSON line, with its secrets
+// redacted
+func (h *hook) appendFile(payload []byte) error {
+	if err := os.MkdirAll(filepath.Dir(h.config.Path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(h.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(redact.Bytes(append(payload, '\n')))
+	return err
+}
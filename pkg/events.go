@@ -0,0 +1,54 @@
+// pkg/events.go
+package pkg
+
+import (
+	"GopherStrike/pkg/server"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// APITokenEnvVar holds the API token used by commands that call a server
+const APITokenEnvVar = "GOPHERSTRIKE_API_TOKEN"
+
+// RunEvents streams events from the gRPC API of a server to stdout, one JSON
+// object per line
+func RunEvents(args []string) error {
+	fs := flag.NewFlagSet("events", flag.ContinueOnError)
+	address := fs.String("server", "127.0.0.1:9091", "gRPC address of the server")
+	token := fs.String("token", os.Getenv(APITokenEnvVar), "API token (default from "+APITokenEnvVar+")")
+	scan := fs.String("scan", "", "Only stream events of this scan, until it finishes")
+	types := fs.String("types", "", "Comma-separated event types, e.g. scan.*,finding.new")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	req := server.EventsRequest{JobID: *scan}
+	for _, t := range strings.Split(*types, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			req.Types = append(req.Types, t)
+		}
+	}
+
+	client, err := server.NewClient(*address, *token)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	encoder := json.NewEncoder(os.Stdout)
+	err = client.Events(ctx, req, func(event server.Event) error {
+		return encoder.Encode(event)
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
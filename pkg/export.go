@@ -0,0 +1,95 @@
+// pkg/export.go
+package pkg
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/redact"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// RunExport exports the results of the active workspace for use in other tools
+func RunExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "dot", "Export format: dot, graphml, neo4j, maltego, spiderfoot, stix, cyclonedx or spdx")
+	output := fs.String("output", "", "Write the export to this file instead of stdout")
+	workspace := fs.String("workspace", os.Getenv(artifacts.WorkspaceEnvVar), "Workspace to export")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store := artifacts.NewStore(artifacts.DefaultRoot, *workspace)
+
+	var write func(io.Writer) error
+	switch strings.ToLower(*format) {
+	case "dot", "graphml", "neo4j", "cypher":
+		graph, err := store.BuildGraph()
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(*format) {
+		case "dot":
+			write = graph.WriteDOT
+		case "graphml":
+			write = graph.WriteGraphML
+		default:
+			write = graph.WriteCypher
+		}
+	case "maltego", "spiderfoot":
+		entities, err := store.Entities()
+		if err != nil {
+			return err
+		}
+		if strings.ToLower(*format) == "maltego" {
+			write = func(w io.Writer) error { return artifacts.WriteMaltegoCSV(w, entities) }
+		} else {
+			write = func(w io.Writer) error { return artifacts.WriteSpiderFootCSV(w, store.Workspace, entities) }
+		}
+	case "stix":
+		entities, err := store.Entities()
+		if err != nil {
+			return err
+		}
+		graph, err := store.BuildGraph()
+		if err != nil {
+			return err
+		}
+		write = func(w io.Writer) error { return artifacts.WriteSTIXBundle(w, entities, graph) }
+	case "cyclonedx", "spdx":
+		assets, err := store.Inventory()
+		if err != nil {
+			return err
+		}
+		if strings.ToLower(*format) == "cyclonedx" {
+			write = func(w io.Writer) error { return artifacts.WriteCycloneDX(w, store.Workspace, assets) }
+		} else {
+			write = func(w io.Writer) error { return artifacts.WriteSPDX(w, store.Workspace, assets) }
+		}
+	default:
+		return fmt.Errorf("unsupported format: %s", *format)
+	}
+
+	if *output == "" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %v", err)
+	}
+	defer file.Close()
+
+	redacted := redact.Writer(file)
+	if err := write(redacted); err != nil {
+		return err
+	}
+	if err := redacted.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("[+] Workspace %s exported to: %s\n", store.Workspace, *output)
+	return nil
+}
@@ -0,0 +1,92 @@
+// Package httpclient builds the HTTP clients of the scanning tools. Each
+// request passes the scope exclusions, redirects included, then the pacing
+// and retries of the timing profile, debug logging, the stats and metrics of
+// the tool, and the session being recorded or replayed; its connections are
+// dialed along the tool's egress routes, under the bandwidth cap, and their
+// bytes are counted.
+package httpclient
+
+import (
+	"GopherStrike/pkg/bandwidth"
+	"GopherStrike/pkg/egress"
+	"GopherStrike/pkg/logging"
+	"GopherStrike/pkg/metrics"
+	"GopherStrike/pkg/scope"
+	"GopherStrike/pkg/session"
+	"GopherStrike/pkg/stats"
+	"GopherStrike/pkg/timing"
+	"net/http"
+	"time"
+)
+
+// New returns an HTTP client for a tool. The transport carries the tool's
+// TLS and connection settings; nil uses a copy of http.DefaultTransport. Its
+// dial function is wrapped, so the transport must not be shared with
+// clients created elsewhere.
+func New(tool string, timeout time.Duration, transport *http.Transport) *http.Client {
+	if transport == nil {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	var dial scope.DialFunc
+	if transport.DialContext != nil {
+		dial = transport.DialContext
+	}
+	transport.DialContext = stats.CountBytes(bandwidth.DialContext(egress.DialContext(tool, dial)))
+
+	profile := timing.Current()
+	return &http.Client{
+		Transport: scope.Transport(&timingTransport{
+			retries: profile.RetriesFor(0),
+			next:    &debugTransport{tool: tool, next: stats.Transport(metrics.InstrumentTransport(tool, session.Transport(tool, transport)))},
+		}),
+		Timeout: profile.TimeoutFor(timeout),
+	}
+}
+
+// timingTransport paces requests to the timing profile and retries the
+// requests without a body that fail before getting a response
+type timingTransport struct {
+	retries int
+	next    http.RoundTripper
+}
+
+// RoundTrip waits for the pause of the timing profile and sends the request.
+// A replayed session is not paced, as its requests do not reach the target.
+func (t *timingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if session.Replaying() {
+			return t.next.RoundTrip(req)
+		}
+		if err := timing.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+		resp, err := t.next.RoundTrip(req)
+		if err == nil || attempt >= t.retries || req.Body != nil || req.Context().Err() != nil {
+			return resp, err
+		}
+		logging.Global.Debug("Retrying %s %s after: %v", req.Method, req.URL, err)
+	}
+}
+
+// debugTransport logs every request and its outcome at debug level
+type debugTransport struct {
+	tool string
+	next http.RoundTripper
+}
+
+// RoundTrip sends the request and logs it with the response status and time
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if logging.CurrentVerbosity() < logging.Debug {
+		return t.next.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		logging.Global.Debug("[%s] %s %s failed after %s: %v", t.tool, req.Method, req.URL, elapsed, err)
+		return nil, err
+	}
+	logging.Global.Debug("[%s] %s %s -> %d, %d bytes in %s", t.tool, req.Method, req.URL, resp.StatusCode, resp.ContentLength, elapsed)
+	return resp, nil
+}
@@ -0,0 +1,106 @@
+// pkg/inventory.go
+package pkg
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/redact"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// RunInventory prints or exports the asset inventory of the active workspace
+func RunInventory(args []string) error {
+	fs := flag.NewFlagSet("inventory", flag.ContinueOnError)
+	format := fs.String("format", "table", "Output format: table, csv or json")
+	output := fs.String("output", "", "Write the inventory to this file instead of stdout")
+	workspace := fs.String("workspace", os.Getenv(artifacts.WorkspaceEnvVar), "Workspace to inventory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store := artifacts.NewStore(artifacts.DefaultRoot, *workspace)
+	assets, err := store.Inventory()
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %v", err)
+		}
+		defer file.Close()
+		redacted := redact.Writer(file)
+		defer redacted.Close()
+		w = redacted
+	}
+
+	switch strings.ToLower(*format) {
+	case "csv":
+		err = artifacts.WriteInventoryCSV(w, assets)
+	case "json":
+		var data []byte
+		data, err = json.MarshalIndent(assets, "", "  ")
+		if err == nil {
+			_, err = fmt.Fprintln(w, string(data))
+		}
+	case "table":
+		printInventoryTable(w, store.Workspace, assets)
+	default:
+		return fmt.Errorf("unsupported format: %s", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		fmt.Printf("[+] Inventory of %d assets saved to: %s\n", len(assets), *output)
+	}
+	return nil
+}
+
+// printInventoryTable renders the inventory as a text table
+func printInventoryTable(w io.Writer, workspace string, assets []artifacts.Asset) {
+	if len(assets) == 0 {
+		fmt.Fprintf(w, "[-] No assets found in workspace %s\n", workspace)
+		return
+	}
+
+	fmt.Fprintf(w, "[+] %d assets in workspace %s\n\n", len(assets), workspace)
+	fmt.Fprintf(w, "%-28s %-18s %-18s %-26s %-4s %-4s %-4s %-4s %-4s %s\n",
+		"Host", "IPs", "Open Ports", "Technologies", "Crit", "High", "Med", "Low", "Info", "Last Scanned")
+	fmt.Fprintln(w, strings.Repeat("-", 135))
+
+	for _, asset := range assets {
+		ports := make([]string, len(asset.OpenPorts))
+		for i, port := range asset.OpenPorts {
+			ports[i] = strconv.Itoa(port)
+		}
+
+		fmt.Fprintf(w, "%-28s %-18s %-18s %-26s %-4d %-4d %-4d %-4d %-4d %s\n",
+			truncate(asset.Host, 28),
+			truncate(strings.Join(asset.IPs, ","), 18),
+			truncate(strings.Join(ports, ","), 18),
+			truncate(strings.Join(asset.Technologies, ","), 26),
+			asset.Findings["critical"],
+			asset.Findings["high"],
+			asset.Findings["medium"],
+			asset.Findings["low"],
+			asset.Findings["info"],
+			asset.LastScanned.Format("2006-01-02 15:04"))
+	}
+}
+
+// truncate shortens a string to max characters for table output
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
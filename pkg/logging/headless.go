@@ -0,0 +1,39 @@
+// pkg/logging/headless.go
+package logging
+
+import "io"
+
+// headless is set once the loggers write JSON lines to the console only
+var headless bool
+
+// SetHeadless makes the global and module loggers write their messages as
+// JSON lines to the console only. Containers collect their logs from stdout,
+// and log files would be lost with the container.
+func SetHeadless() {
+	verbosityMu.Lock()
+	headless = true
+	loggers := append([]*Logger{Global}, moduleLoggers...)
+	verbosityMu.Unlock()
+
+	for _, logger := range loggers {
+		logger.useHeadless()
+	}
+}
+
+// isHeadless reports whether SetHeadless was called
+func isHeadless() bool {
+	verbosityMu.RLock()
+	defer verbosityMu.RUnlock()
+	return headless
+}
+
+// useHeadless switches a logger to JSON lines on its console
+func (l *Logger) useHeadless() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.formatter = &JSONFormatter{}
+	for level := range l.writers {
+		l.writers[level] = []io.Writer{l.console}
+	}
+}
@@ -2,6 +2,9 @@
 package logging
 
 import (
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/term"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -40,6 +43,7 @@ type Logger struct {
 	level          LogLevel
 	writers        map[LogLevel][]io.Writer
 	formatter      Formatter
+	console        io.Writer
 	enableConsole  bool
 	consoleLevel   LogLevel
 	showTimestamp  bool
@@ -52,38 +56,49 @@ type Formatter interface {
 	Format(level LogLevel, msg string, source string, timestamp time.Time) string
 }
 
+// levelColors are the colors of the level names on the terminal
+var levelColors = map[LogLevel]term.Color{
+	DEBUG:    term.Cyan,
+	INFO:     term.Green,
+	WARNING:  term.Yellow,
+	ERROR:    term.Red,
+	CRITICAL: term.Magenta,
+}
+
 // DefaultFormatter is the default log formatter
 type DefaultFormatter struct {
 	colored bool
 }
 
-// Format formats a log message with the default format
+// Format formats a log message with the default format. The level is
+// colored when colors are enabled for the terminal.
 func (f *DefaultFormatter) Format(level LogLevel, msg string, source string, timestamp time.Time) string {
-	var levelColor string
-	var resetColor = "\033[0m"
-
-	// ANSI colors
+	levelName := levelNames[level]
 	if f.colored {
-		switch level {
-		case DEBUG:
-			levelColor = "\033[36m" // Cyan
-		case INFO:
-			levelColor = "\033[32m" // Green
-		case WARNING:
-			levelColor = "\033[33m" // Yellow
-		case ERROR:
-			levelColor = "\033[31m" // Red
-		case CRITICAL:
-			levelColor = "\033[35m" // Magenta
-		}
+		levelName = term.Colorize(levelColors[level], levelName)
 	}
 
-	// Build log message with or without colors
 	timeStr := timestamp.Format("2006-01-02 15:04:05")
-	if f.colored {
-		return fmt.Sprintf("%s [%s%s%s] %s: %s", timeStr, levelColor, levelNames[level], resetColor, source, msg)
+	return fmt.Sprintf("%s [%s] %s: %s", timeStr, levelName, source, msg)
+}
+
+// JSONFormatter formats log messages as JSON objects for log collectors
+type JSONFormatter struct{}
+
+// Format formats a log message as a single-line JSON object
+func (f *JSONFormatter) Format(level LogLevel, msg string, source string, timestamp time.Time) string {
+	entry := struct {
+		Time    time.Time `json:"time"`
+		Level   string    `json:"level"`
+		Source  string    `json:"source,omitempty"`
+		Message string    `json:"message"`
+	}{timestamp, levelNames[level], source, msg}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Sprintf(`{"level":"ERROR","message":"failed to format log message: %v"}`, err)
 	}
-	return fmt.Sprintf("%s [%s] %s: %s", timeStr, levelNames[level], source, msg)
+	return string(data)
 }
 
 // New creates a new logger with the specified log level
@@ -92,20 +107,20 @@ func New(level LogLevel) *Logger {
 		level:          level,
 		writers:        make(map[LogLevel][]io.Writer),
 		formatter:      &DefaultFormatter{colored: true},
+		console:        os.Stdout,
 		enableConsole:  true,
-		consoleLevel:   INFO,
+		consoleLevel:   CurrentVerbosity().consoleLevel(),
 		showTimestamp:  true,
 		showSource:     true,
 		sourceRelative: true,
 	}
 
-	// Add stdout as the default writer for all levels
-	stdout := os.Stdout
-	logger.writers[DEBUG] = []io.Writer{stdout}
-	logger.writers[INFO] = []io.Writer{stdout}
-	logger.writers[WARNING] = []io.Writer{stdout}
-	logger.writers[ERROR] = []io.Writer{stdout}
-	logger.writers[CRITICAL] = []io.Writer{stdout}
+	// Add the console as the default writer for all levels
+	logger.writers[DEBUG] = []io.Writer{logger.console}
+	logger.writers[INFO] = []io.Writer{logger.console}
+	logger.writers[WARNING] = []io.Writer{logger.console}
+	logger.writers[ERROR] = []io.Writer{logger.console}
+	logger.writers[CRITICAL] = []io.Writer{logger.console}
 
 	return logger
 }
@@ -121,8 +136,9 @@ func (l *Logger) AddFileHandler(filePath string, level LogLevel) error {
 		return fmt.Errorf("failed to create log directory: %w", err)
 	}
 
-	// Open the log file with secure permissions (owner read/write only)
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	// Open the log file with secure permissions (owner read/write only),
+	// rotating it when it grows too large
+	file, err := openLogFile(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to open log file: %w", err)
 	}
@@ -163,6 +179,20 @@ func (l *Logger) SetConsoleLevel(level LogLevel) {
 	l.consoleLevel = level
 }
 
+// SetConsole replaces the writer used for console output
+func (l *Logger) SetConsole(console io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for level, writers := range l.writers {
+		for i, writer := range writers {
+			if writer == l.console {
+				l.writers[level][i] = console
+			}
+		}
+	}
+	l.console = console
+}
+
 // SetTimestampDisplay enables or disables showing timestamps
 func (l *Logger) SetTimestampDisplay(show bool) {
 	l.mu.Lock()
@@ -194,6 +224,9 @@ func (l *Logger) log(level LogLevel, format string, args ...interface{}) {
 	if level < l.level {
 		return
 	}
+	if l == Global {
+		globalFilesOnce.Do(addGlobalFileHandlers)
+	}
 
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -202,7 +235,7 @@ func (l *Logger) log(level LogLevel, format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
 	
 	// Sanitize the message to remove sensitive data
-	msg = sanitizeLogMessage(msg)
+	msg = redact.String(sanitizeLogMessage(msg))
 
 	// Get source information
 	source := ""
@@ -214,9 +247,17 @@ func (l *Logger) log(level LogLevel, format string, args ...interface{}) {
 	timestamp := time.Now()
 	logEntry := l.formatter.Format(level, msg, source, timestamp)
 
-	// Write to all writers for this level
+	// Write to all writers for this level, without colors unless the
+	// writer is a terminal
 	for _, writer := range l.writers[level] {
-		fmt.Fprintln(writer, logEntry)
+		if writer == l.console && (!l.enableConsole || level < l.consoleLevel) {
+			continue
+		}
+		if file, ok := writer.(*os.File); ok && term.IsTerminal(file) {
+			fmt.Fprintln(writer, logEntry)
+		} else {
+			fmt.Fprintln(writer, term.Strip(logEntry))
+		}
 	}
 }
 
@@ -245,13 +286,30 @@ func (l *Logger) Critical(format string, args ...interface{}) {
 	l.log(CRITICAL, format, args...)
 }
 
-// Create a global logger instance
+// logsRoot is the directory holding the log directories of the modules
+const logsRoot = "logs"
+
+// Dir returns the log directory of a module. Paths are built with the
+// platform's separator, so they also work on Windows.
+func Dir(module string) string {
+	return filepath.Join(logsRoot, strings.ToLower(module))
+}
+
+// Create a global logger instance. Its log files are created when it first
+// writes, so importing the package leaves the working directory alone.
 var Global = New(INFO)
 
-// Initialize the global logger with file handlers for each module
-func init() {
+// globalFilesOnce adds the global logger's file handlers once
+var globalFilesOnce sync.Once
+
+// addGlobalFileHandlers adds file handlers for each module to the global logger
+func addGlobalFileHandlers() {
+	if isHeadless() {
+		return
+	}
+
 	// Ensure logs directory exists
-	if err := os.MkdirAll("logs", 0755); err != nil {
+	if err := os.MkdirAll(logsRoot, 0755); err != nil {
 		log.Printf("Warning: Failed to create logs directory: %v", err)
 		return
 	}
@@ -260,13 +318,13 @@ func init() {
 	modules := []string{"general", "subdomain", "osint", "webvuln", "s3scan", "email", "dirbrute", "resolver"}
 
 	for _, module := range modules {
-		logPath := filepath.Join("logs", module, "activity.log")
+		logPath := filepath.Join(Dir(module), "activity.log")
 		if err := Global.AddFileHandler(logPath, INFO); err != nil {
 			log.Printf("Warning: Failed to create log file for %s: %v", module, err)
 		}
 
 		// Add separate error log file
-		errLogPath := filepath.Join("logs", module, "errors.log")
+		errLogPath := filepath.Join(Dir(module), "errors.log")
 		if err := Global.AddFileHandler(errLogPath, ERROR); err != nil {
 			log.Printf("Warning: Failed to create error log file for %s: %v", module, err)
 		}
@@ -320,17 +378,27 @@ func GetModuleLogger(moduleName string) *Logger {
 	logger := New(Global.level)
 
 	// Configure the logger with module-specific settings
-	logPath := filepath.Join("logs", strings.ToLower(moduleName), "activity.log")
-	errLogPath := filepath.Join("logs", strings.ToLower(moduleName), "errors.log")
+	logPath := filepath.Join(Dir(moduleName), "activity.log")
+	errLogPath := filepath.Join(Dir(moduleName), "errors.log")
+
+	// Attempt to add file handlers, except in headless mode
+	if isHeadless() {
+		logger.useHeadless()
+	} else {
+		if err := logger.AddFileHandler(logPath, INFO); err != nil {
+			log.Printf("Warning: Failed to create log file for %s: %v", moduleName, err)
+		}
 
-	// Attempt to add file handlers
-	if err := logger.AddFileHandler(logPath, INFO); err != nil {
-		log.Printf("Warning: Failed to create log file for %s: %v", moduleName, err)
+		if err := logger.AddFileHandler(errLogPath, ERROR); err != nil {
+			log.Printf("Warning: Failed to create error log file for %s: %v", moduleName, err)
+		}
 	}
 
-	if err := logger.AddFileHandler(errLogPath, ERROR); err != nil {
-		log.Printf("Warning: Failed to create error log file for %s: %v", moduleName, err)
-	}
+	// Follow the verbosity, including changes made later on
+	verbosityMu.Lock()
+	moduleLoggers = append(moduleLoggers, logger)
+	verbosityMu.Unlock()
+	logger.applyVerbosity(CurrentVerbosity())
 
 	return logger
 }
@@ -149,3 +149,46 @@ func TestGetModuleLogger(t *testing.T) {
 		os.RemoveAll(logDir)
 	}
 }
+
+func TestJSONFormatter(t *testing.T) {
+	formatter := &JSONFormatter{}
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	got := formatter.Format(WARNING, `slow "response"`, "scan.go:12", timestamp)
+	want := `{"time":"2024-01-02T03:04:05Z","level":"WARNING","source":"scan.go:12","message":"slow \"response\""}`
+	if got != want {
+		t.Errorf("Format() = %s, want %s", got, want)
+	}
+}
+
+func TestLogRotation(t *testing.T) {
+	SetRotation(200, 2)
+	defer SetRotation(DefaultMaxLogSize, DefaultLogBackups)
+
+	logPath := filepath.Join(t.TempDir(), "activity.log")
+	logger := New(INFO)
+	if err := logger.AddFileHandler(logPath, INFO); err != nil {
+		t.Fatalf("Failed to add file handler: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		logger.Info("message %d with enough text to fill the log file quickly", i)
+	}
+
+	for _, path := range []string{logPath, logPath + ".1", logPath + ".2"} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("%s missing after rotation: %v", filepath.Base(path), err)
+		}
+		if info.Size() > 200 {
+			t.Errorf("%s is %d bytes, want at most 200", filepath.Base(path), info.Size())
+		}
+	}
+	if _, err := os.Stat(logPath + ".3"); !os.IsNotExist(err) {
+		t.Error("more rotated files kept than configured")
+	}
+
+	content, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(content), "message 19") {
+		t.Errorf("latest message not in the current log file:\n%s", content)
+	}
+}
@@ -0,0 +1,146 @@
+// pkg/logging/rotate.go
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Default rotation settings, changed with SetRotation
+const (
+	DefaultMaxLogSize = 10 << 20
+	DefaultLogBackups = 5
+)
+
+var (
+	maxLogSize = int64(DefaultMaxLogSize)
+	logBackups = DefaultLogBackups
+	logFiles   = make(map[string]*rotatingFile)
+	logFilesMu sync.Mutex
+)
+
+// backupPattern matches the names of rotated log files, such as activity.log.2
+var backupPattern = regexp.MustCompile(`\.log\.\d+$`)
+
+// SetRotation sets the size at which log files are rotated and the number
+// of rotated files kept. A size of 0 disables rotation.
+func SetRotation(maxSize int64, backups int) {
+	logFilesMu.Lock()
+	defer logFilesMu.Unlock()
+	maxLogSize = maxSize
+	logBackups = backups
+}
+
+// rotatingFile is a log file that is renamed to file.1, file.2 and so on
+// when it grows beyond the maximum size. Loggers writing to the same path
+// share it.
+type rotatingFile struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	size int64
+}
+
+// openLogFile returns the rotating file for a path, opening it if needed
+func openLogFile(path string) (*rotatingFile, error) {
+	logFilesMu.Lock()
+	defer logFilesMu.Unlock()
+
+	if f, ok := logFiles[path]; ok {
+		return f, nil
+	}
+	f := &rotatingFile{path: path}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	logFiles[path] = f
+	return f, nil
+}
+
+// open opens the file for appending with owner-only permissions
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating the file first if p would take it over the maximum size
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	logFilesMu.Lock()
+	maxSize, backups := maxLogSize, logBackups
+	logFilesMu.Unlock()
+
+	if maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > maxSize {
+		if err := f.rotate(backups); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the rotated files up by one, dropping the oldest, and starts
+// a new file
+func (f *rotatingFile) rotate(backups int) error {
+	f.file.Close()
+	os.Remove(fmt.Sprintf("%s.%d", f.path, backups))
+	for i := backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+	}
+	if backups > 0 {
+		os.Rename(f.path, f.path+".1")
+	} else {
+		os.Remove(f.path)
+	}
+	return f.open()
+}
+
+// CleanupLogs removes the rotated log files under the logs directory that are
+// older than maxAge and returns their paths and total size. Log files in use
+// are kept. Nothing is removed in a dry run.
+func CleanupLogs(maxAge time.Duration, dryRun bool) ([]string, int64, error) {
+	removed := []string{}
+	var freed int64
+	if maxAge <= 0 {
+		return removed, 0, nil
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	err := filepath.Walk(logsRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !backupPattern.MatchString(path) || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if !dryRun {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+		removed = append(removed, path)
+		freed += info.Size()
+		return nil
+	})
+	return removed, freed, err
+}
@@ -0,0 +1,104 @@
+// pkg/logging/verbosity.go
+package logging
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// Verbosity is how much GopherStrike writes to the console
+type Verbosity int
+
+const (
+	// Quiet writes only errors, to stderr, leaving stdout to the findings
+	Quiet Verbosity = iota - 1
+	// Normal writes warnings and errors
+	Normal
+	// Verbose (-v) also writes informational messages and the tools' progress details
+	Verbose
+	// Debug (-vv) also writes debug messages such as every HTTP request
+	Debug
+)
+
+var (
+	verbosity     = Normal
+	verbosityMu   sync.RWMutex
+	moduleLoggers []*Logger
+)
+
+// consoleLevel returns the lowest level written to the console at a verbosity
+func (v Verbosity) consoleLevel() LogLevel {
+	switch {
+	case v <= Quiet:
+		return ERROR
+	case v == Normal:
+		return WARNING
+	case v == Verbose:
+		return INFO
+	}
+	return DEBUG
+}
+
+// CurrentVerbosity returns the verbosity selected on the command line
+func CurrentVerbosity() Verbosity {
+	verbosityMu.RLock()
+	defer verbosityMu.RUnlock()
+	return verbosity
+}
+
+// SetVerbosity applies a verbosity to the global and module loggers. In
+// quiet mode their console output goes to stderr.
+func SetVerbosity(v Verbosity) {
+	verbosityMu.Lock()
+	verbosity = v
+	loggers := append([]*Logger{Global}, moduleLoggers...)
+	verbosityMu.Unlock()
+
+	for _, logger := range loggers {
+		logger.applyVerbosity(v)
+	}
+}
+
+// applyVerbosity sets the levels and console of a logger for a verbosity
+func (l *Logger) applyVerbosity(v Verbosity) {
+	level := INFO
+	if v >= Debug {
+		level = DEBUG
+	}
+	var console io.Writer = os.Stdout
+	if v <= Quiet {
+		console = os.Stderr
+	}
+
+	l.SetLevel(level)
+	l.SetConsoleLevel(v.consoleLevel())
+	l.SetConsole(console)
+}
+
+// ParseVerbosity removes -q/--quiet, -v/--verbose and -vv from args and
+// returns the verbosity they select. Each -v raises the verbosity by one;
+// arguments after "--" are left alone.
+func ParseVerbosity(args []string) (Verbosity, []string) {
+	v := Normal
+	remaining := []string{}
+	for i, arg := range args {
+		if arg == "--" {
+			remaining = append(remaining, args[i:]...)
+			break
+		}
+		switch arg {
+		case "-q", "--quiet":
+			v = Quiet
+		case "-v", "--verbose":
+			if v < Debug {
+				v++
+			}
+		case "-vv":
+			v = Debug
+		default:
+			remaining = append(remaining, arg)
+		}
+	}
+	return v, remaining
+}
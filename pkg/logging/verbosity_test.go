@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseVerbosity(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		want     Verbosity
+		wantArgs []string
+	}{
+		{"Default", []string{"gs", "sshaudit", "host"}, Normal, []string{"gs", "sshaudit", "host"}},
+		{"Quiet", []string{"gs", "-q", "sshaudit", "host"}, Quiet, []string{"gs", "sshaudit", "host"}},
+		{"Verbose after command", []string{"gs", "certcheck", "--verbose", "host"}, Verbose, []string{"gs", "certcheck", "host"}},
+		{"Repeated", []string{"gs", "-v", "-v", "ampcheck"}, Debug, []string{"gs", "ampcheck"}},
+		{"Debug", []string{"gs", "-vv", "-v"}, Debug, []string{"gs"}},
+		{"After terminator", []string{"gs", "smtpcheck", "--", "-q"}, Normal, []string{"gs", "smtpcheck", "--", "-q"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args := ParseVerbosity(tt.args)
+			if got != tt.want {
+				t.Errorf("ParseVerbosity(%v) = %d, want %d", tt.args, got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("ParseVerbosity(%v) left %v, want %v", tt.args, args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestConsoleLevel(t *testing.T) {
+	tests := []struct {
+		verbosity Verbosity
+		want      []string
+		notWant   []string
+	}{
+		{Quiet, []string{"ERROR"}, []string{"WARNING", "INFO"}},
+		{Normal, []string{"WARNING", "ERROR"}, []string{"INFO"}},
+		{Verbose, []string{"INFO", "WARNING"}, []string{"DEBUG"}},
+		{Debug, []string{"DEBUG", "INFO"}, nil},
+	}
+
+	for _, tt := range tests {
+		var console, file bytes.Buffer
+		logger := New(DEBUG)
+		logger.SetConsole(&console)
+		logger.SetConsoleLevel(tt.verbosity.consoleLevel())
+		logger.writers[DEBUG] = append(logger.writers[DEBUG], &file)
+
+		logger.Debug("request sent")
+		logger.Info("scan started")
+		logger.Warning("slow response")
+		logger.Error("scan failed")
+
+		for _, want := range tt.want {
+			if !strings.Contains(console.String(), want) {
+				t.Errorf("verbosity %d: console missing %s:\n%s", tt.verbosity, want, console.String())
+			}
+		}
+		for _, notWant := range tt.notWant {
+			if strings.Contains(console.String(), notWant) {
+				t.Errorf("verbosity %d: console contains %s:\n%s", tt.verbosity, notWant, console.String())
+			}
+		}
+		// Other writers are not affected by the console level
+		if !strings.Contains(file.String(), "request sent") {
+			t.Errorf("verbosity %d: debug message not written to other writers", tt.verbosity)
+		}
+	}
+}
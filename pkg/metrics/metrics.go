@@ -0,0 +1,230 @@
+// Package metrics collects operational metrics and exposes them in the
+// Prometheus text format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric types of the exposition format
+const (
+	typeCounter = "counter"
+	typeGauge   = "gauge"
+)
+
+// Vec is a counter or gauge, optionally split by labels
+type Vec struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+	values map[string]float64
+	mutex  sync.Mutex
+}
+
+// registry holds every metric in registration order
+var (
+	registry      []*Vec
+	registryMutex sync.Mutex
+)
+
+// NewCounter registers a counter with the given label names
+func NewCounter(name, help string, labels ...string) *Vec {
+	return register(name, help, typeCounter, labels)
+}
+
+// NewGauge registers a gauge with the given label names
+func NewGauge(name, help string, labels ...string) *Vec {
+	return register(name, help, typeGauge, labels)
+}
+
+func register(name, help, kind string, labels []string) *Vec {
+	v := &Vec{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		values: make(map[string]float64),
+	}
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry = append(registry, v)
+	return v
+}
+
+// Add adds delta to the series with the given label values
+func (v *Vec) Add(delta float64, labelValues ...string) {
+	key := v.key(labelValues)
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.values[key] += delta
+}
+
+// Inc increments the series with the given label values
+func (v *Vec) Inc(labelValues ...string) {
+	v.Add(1, labelValues...)
+}
+
+// Dec decrements the series with the given label values
+func (v *Vec) Dec(labelValues ...string) {
+	v.Add(-1, labelValues...)
+}
+
+// Set sets the series with the given label values
+func (v *Vec) Set(value float64, labelValues ...string) {
+	key := v.key(labelValues)
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.values[key] = value
+}
+
+// Value returns the current value of the series with the given label values
+func (v *Vec) Value(labelValues ...string) float64 {
+	key := v.key(labelValues)
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return v.values[key]
+}
+
+// key builds the series key, padding or truncating label values to the label names
+func (v *Vec) key(labelValues []string) string {
+	values := make([]string, len(v.labels))
+	copy(values, labelValues)
+	return strings.Join(values, "\xff")
+}
+
+// write writes the metric in the Prometheus text exposition format
+func (v *Vec) write(w io.Writer) error {
+	v.mutex.Lock()
+	keys := make([]string, 0, len(v.values))
+	for key := range v.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]float64, len(keys))
+	for i, key := range keys {
+		values[i] = v.values[key]
+	}
+	v.mutex.Unlock()
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, v.help, v.name, v.kind); err != nil {
+		return err
+	}
+
+	// Unlabelled metrics are always exposed, even before their first update
+	if len(v.labels) == 0 && len(keys) == 0 {
+		keys, values = []string{""}, []float64{0}
+	}
+
+	for i, key := range keys {
+		series := v.name
+		if len(v.labels) > 0 {
+			parts := strings.Split(key, "\xff")
+			pairs := make([]string, len(v.labels))
+			for j, label := range v.labels {
+				pairs[j] = fmt.Sprintf("%s=\"%s\"", label, escapeLabel(parts[j]))
+			}
+			series += "{" + strings.Join(pairs, ",") + "}"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", series, formatValue(values[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeLabel escapes a label value for the exposition format
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formatValue formats a sample value, using integers where possible
+func formatValue(f float64) string {
+	if f == float64(int64(f)) {
+		return fmt.Sprintf("%d", int64(f))
+	}
+	return fmt.Sprintf("%g", f)
+}
+
+// WriteText writes all registered metrics in the Prometheus text format
+func WriteText(w io.Writer) error {
+	registryMutex.Lock()
+	metrics := make([]*Vec, len(registry))
+	copy(metrics, registry)
+	registryMutex.Unlock()
+
+	uptime.Set(time.Since(startTime).Seconds())
+
+	for _, v := range metrics {
+		if err := v.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler serves the registered metrics for Prometheus to scrape
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteText(w)
+	})
+}
+
+// instrumentedTransport counts the HTTP requests a tool sends
+type instrumentedTransport struct {
+	tool string
+	next http.RoundTripper
+}
+
+// RoundTrip sends the request and records it
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	HTTPRequests.Inc(t.tool)
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		Errors.Inc(t.tool)
+	}
+	return resp, err
+}
+
+// InstrumentTransport wraps an HTTP transport so the requests of a tool are counted
+func InstrumentTransport(tool string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &instrumentedTransport{tool: tool, next: next}
+}
+
+var startTime = time.Now()
+
+// Metrics exposed by GopherStrike
+var (
+	ScansRunning = NewGauge("gopherstrike_scans_running", "Number of scans currently running.", "tool")
+	ScansQueued  = NewGauge("gopherstrike_scans_queued", "Number of scans waiting in the queue.")
+	Scans        = NewCounter("gopherstrike_scans_total", "Number of finished scans.", "tool", "status")
+	HTTPRequests = NewCounter("gopherstrike_http_requests_total", "Number of HTTP requests sent by scanners.", "tool")
+	APIRequests  = NewCounter("gopherstrike_api_requests_total", "Number of requests served by the API server.", "method", "code")
+	Errors       = NewCounter("gopherstrike_errors_total", "Number of errors encountered by scanners.", "tool")
+	Findings     = NewCounter("gopherstrike_findings_total", "Number of findings reported.", "tool", "severity")
+
+	uptime = NewGauge("gopherstrike_uptime_seconds", "Seconds since the process started.")
+)
+
+// RecordFinding counts a finding, normalizing the severity name
+func RecordFinding(tool, severity string) {
+	severity = strings.ToLower(severity)
+	if severity == "" || severity == "none" {
+		severity = "info"
+	}
+	Findings.Inc(tool, severity)
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	counter := NewCounter("test_events_total", "Test events.", "kind")
+	counter.Inc("a")
+	counter.Add(2, "b\"quoted")
+	gauge := NewGauge("test_level", "Test level.")
+	gauge.Set(1.5)
+
+	var b strings.Builder
+	if err := WriteText(&b); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	text := b.String()
+
+	tests := []struct {
+		name     string
+		contains string
+	}{
+		{"Counter type", "# TYPE test_events_total counter"},
+		{"Labelled series", `test_events_total{kind="a"} 1`},
+		{"Escaped label", `test_events_total{kind="b\"quoted"} 2`},
+		{"Gauge", "test_level 1.5"},
+		{"Unused unlabelled metric", "gopherstrike_uptime_seconds "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(text, tt.contains) {
+				t.Errorf("WriteText() output does not contain %q:\n%s", tt.contains, text)
+			}
+		})
+	}
+}
+
+func TestRecordFinding(t *testing.T) {
+	high, info := Findings.Value("test", "high"), Findings.Value("test", "info")
+
+	RecordFinding("test", "High")
+	RecordFinding("test", "None")
+
+	if got := Findings.Value("test", "high") - high; got != 1 {
+		t.Errorf("new high findings = %v, want 1", got)
+	}
+	if got := Findings.Value("test", "info") - info; got != 1 {
+		t.Errorf("new info findings = %v, want 1", got)
+	}
+}
+
+func TestInstrumentTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	before := HTTPRequests.Value("transport-test")
+	client := &http.Client{Transport: InstrumentTransport("transport-test", nil)}
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if got := HTTPRequests.Value("transport-test") - before; got != 1 {
+		t.Errorf("new requests = %v, want 1", got)
+	}
+}
@@ -0,0 +1,280 @@
+// Package model defines the result schema shared by the tools: findings,
+// which are problems worth reporting, and assets, which are things
+// discovered about a target such as hosts, addresses and paths. Each tool
+// converts its own results to this schema so that the SIEM output, triage,
+// reports and the asset inventory handle every tool the same way.
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severity rates how serious a finding is
+type Severity string
+
+// Severity levels, from the lowest to the highest
+const (
+	SeverityNone     Severity = "None" // CVSS rating of a score of 0
+	SeverityInfo     Severity = "Info"
+	SeverityLow      Severity = "Low"
+	SeverityMedium   Severity = "Medium"
+	SeverityHigh     Severity = "High"
+	SeverityCritical Severity = "Critical"
+)
+
+// Severities lists the severity levels from the lowest to the highest.
+// SeverityNone ranks with SeverityInfo and is not listed.
+var Severities = []Severity{SeverityInfo, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// ParseSeverity returns the severity named by s, ignoring case
+func ParseSeverity(s string) (Severity, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, string(SeverityNone)) {
+		return SeverityNone, nil
+	}
+	for _, severity := range Severities {
+		if strings.EqualFold(s, string(severity)) {
+			return severity, nil
+		}
+	}
+	return "", fmt.Errorf("unknown severity: %s (expected critical, high, medium, low or info)", s)
+}
+
+// Rank returns the position of a severity in Severities, or -1 if it is
+// unknown. Names are compared without case.
+func (s Severity) Rank() int {
+	severity, err := ParseSeverity(string(s))
+	if err != nil {
+		return -1
+	}
+	if severity == SeverityNone {
+		return 0
+	}
+	for i, level := range Severities {
+		if level == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// Canonical returns the level named by s with its standard capitalization.
+// Unknown names and SeverityNone count as SeverityInfo.
+func (s Severity) Canonical() Severity {
+	severity, err := ParseSeverity(string(s))
+	if err != nil || severity == SeverityNone {
+		return SeverityInfo
+	}
+	return severity
+}
+
+// AtLeast reports whether s is as serious as min or more
+func (s Severity) AtLeast(min Severity) bool {
+	return s.Rank() >= 0 && s.Rank() >= min.Rank()
+}
+
+// Finding statuses
+const (
+	StatusOpen  = "open"  // Reported, or still reproduced when verified
+	StatusFixed = "fixed" // No longer reproduced when verified
+)
+
+// Finding is a single problem reported by a tool
+type Finding struct {
+	ID          string    `json:"id,omitempty"` // Identifies the problem across scans, see FindingID
+	Tool        string    `json:"tool"`
+	Target      string    `json:"target"`
+	Category    string    `json:"category"` // e.g. XSS, SQL_INJECTION, CVE
+	Name        string    `json:"name"`
+	Severity    Severity  `json:"severity"`
+	Description string    `json:"description,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	Evidence    string    `json:"evidence,omitempty"` // Request, payload or response excerpt supporting the finding
+	Body        string    `json:"body,omitempty"`     // Reference to the full response body in the workspace body store, see artifacts.Store.Blob
+	Time        time.Time `json:"time"`
+	Status      string    `json:"status,omitempty"`     // StatusOpen or StatusFixed
+	UpdatedAt   time.Time `json:"updated_at,omitempty"` // When the finding was last verified
+	Replay      *Replay   `json:"replay,omitempty"`     // Requests reproducing the finding, nil if it cannot be replayed
+}
+
+// FindingID returns the ID of a finding: a hash of its tool, target,
+// category, name and URL, so the same problem reported by two scans has the
+// same ID
+func FindingID(f Finding) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{f.Tool, f.Target, f.Category, f.Name, f.URL}, "|")))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Replay holds the requests that produced a finding and the response
+// showing that it reproduces
+type Replay struct {
+	Requests []ReplayRequest `json:"requests"`
+	Match    string          `json:"match"` // Text the response to the last request contains while the finding reproduces
+}
+
+// ReplayRequest is an HTTP request sent again to verify a finding
+type ReplayRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// Asset types
+const (
+	AssetDomain = "domain"
+	AssetIP     = "ip"
+	AssetURL    = "url"
+	AssetPath   = "path"
+	AssetPort   = "port"
+)
+
+// Asset is something a tool discovered about a target
+type Asset struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Target     string            `json:"target"`
+	Tool       string            `json:"tool"`
+	Attributes map[string]string `json:"attributes,omitempty"` // Details such as the HTTP status of a path
+	Time       time.Time         `json:"time"`
+}
+
+// Results collects the findings and assets of a scan. It is safe for
+// concurrent use, so the workers of a tool can add to it directly.
+type Results struct {
+	mu       sync.Mutex
+	findings []Finding
+	assets   []Asset
+	targets  map[string]bool
+}
+
+// AddFinding adds a finding, setting its time if it has none
+func (r *Results) AddFinding(f Finding) {
+	if f.Time.IsZero() {
+		f.Time = time.Now()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.findings = append(r.findings, f)
+}
+
+// AddAsset adds an asset, setting its time if it has none
+func (r *Results) AddAsset(a Asset) {
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.assets = append(r.assets, a)
+}
+
+// AddTarget records a target that was scanned, whether or not anything
+// was found about it
+func (r *Results) AddTarget(target string) {
+	if target == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.targets == nil {
+		r.targets = make(map[string]bool)
+	}
+	r.targets[target] = true
+}
+
+// Findings returns the findings, most serious first
+func (r *Results) Findings() []Finding {
+	r.mu.Lock()
+	findings := append([]Finding{}, r.findings...)
+	r.mu.Unlock()
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity.Rank() > findings[j].Severity.Rank()
+	})
+	return findings
+}
+
+// Assets returns the assets in the order they were added
+func (r *Results) Assets() []Asset {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Asset{}, r.assets...)
+}
+
+// Counts returns the number of findings at each severity
+func (r *Results) Counts() map[Severity]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := make(map[Severity]int)
+	for _, f := range r.findings {
+		counts[f.Severity.Canonical()]++
+	}
+	return counts
+}
+
+// Targets returns the targets added and those of the findings and assets,
+// sorted and without duplicates
+func (r *Results) Targets() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seen := make(map[string]bool)
+	for target := range r.targets {
+		seen[target] = true
+	}
+	for _, f := range r.findings {
+		seen[f.Target] = true
+	}
+	for _, a := range r.assets {
+		seen[a.Target] = true
+	}
+	delete(seen, "")
+
+	targets := make([]string, 0, len(seen))
+	for target := range seen {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// Summary describes a command-line run for scripts and CI/CD pipelines
+type Summary struct {
+	Command    string         `json:"command"`
+	Status     string         `json:"status"` // clean, findings or error
+	ExitCode   int            `json:"exit_code"`
+	Error      string         `json:"error,omitempty"`
+	Start      time.Time      `json:"start"`
+	Duration   float64        `json:"duration_seconds"`
+	Targets    []string       `json:"targets"`
+	Findings   int            `json:"findings"`
+	Severities map[string]int `json:"severities"` // Every level in lowercase, with its count
+	FailOn     string         `json:"fail_on,omitempty"`
+	Failures   int            `json:"failures"`             // Findings at or above FailOn
+	Incomplete []string       `json:"incomplete,omitempty"` // Scans stopped by their time budget before covering their targets
+}
+
+// Summary summarizes the results of a run of command that started at start.
+// The caller fills in the status, exit code and fail threshold.
+func (r *Results) Summary(command string, start time.Time) Summary {
+	counts := r.Counts()
+	severities := make(map[string]int, len(Severities))
+	findings := 0
+	for _, severity := range Severities {
+		severities[strings.ToLower(string(severity))] = counts[severity]
+		findings += counts[severity]
+	}
+	return Summary{
+		Command:    command,
+		Start:      start,
+		Duration:   time.Since(start).Seconds(),
+		Targets:    r.Targets(),
+		Findings:   findings,
+		Severities: severities,
+	}
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		name     string
+		want     Severity
+		wantRank int
+		wantErr  bool
+	}{
+		{"critical", SeverityCritical, 4, false},
+		{"High", SeverityHigh, 3, false},
+		{" MEDIUM ", SeverityMedium, 2, false},
+		{"low", SeverityLow, 1, false},
+		{"info", SeverityInfo, 0, false},
+		{"None", SeverityNone, 0, false},
+		{"severe", "", -1, true},
+		{"", "", -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSeverity(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSeverity(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSeverity(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+			if rank := Severity(tt.name).Rank(); rank != tt.wantRank {
+				t.Errorf("Rank(%q) = %d, want %d", tt.name, rank, tt.wantRank)
+			}
+		})
+	}
+}
+
+func TestSeverityAtLeast(t *testing.T) {
+	tests := []struct {
+		severity Severity
+		min      Severity
+		want     bool
+	}{
+		{"high", SeverityHigh, true},
+		{SeverityCritical, SeverityHigh, true},
+		{SeverityMedium, SeverityHigh, false},
+		{"unknown", SeverityInfo, false},
+		{SeverityNone, SeverityInfo, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.severity.AtLeast(tt.min); got != tt.want {
+			t.Errorf("%q.AtLeast(%q) = %v, want %v", tt.severity, tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestResults(t *testing.T) {
+	var results Results
+	var wg sync.WaitGroup
+	severities := []Severity{"low", SeverityCritical, "HIGH", SeverityNone, "bogus"}
+	for i, severity := range severities {
+		wg.Add(2)
+		go func(i int, severity Severity) {
+			defer wg.Done()
+			results.AddFinding(Finding{Tool: "test", Name: fmt.Sprintf("finding %d", i), Severity: severity})
+		}(i, severity)
+		go func(i int) {
+			defer wg.Done()
+			results.AddAsset(Asset{Type: AssetPath, Value: fmt.Sprintf("/%d", i)})
+		}(i)
+	}
+	wg.Wait()
+
+	findings := results.Findings()
+	if len(findings) != len(severities) {
+		t.Fatalf("Findings() returned %d findings, want %d", len(findings), len(severities))
+	}
+	if findings[0].Severity != SeverityCritical || findings[1].Severity != "HIGH" {
+		t.Errorf("Findings() not sorted by severity: %v, %v", findings[0].Severity, findings[1].Severity)
+	}
+	for _, f := range findings {
+		if f.Time.IsZero() {
+			t.Errorf("finding %q has no time", f.Name)
+		}
+	}
+	if len(results.Assets()) != len(severities) {
+		t.Errorf("Assets() returned %d assets, want %d", len(results.Assets()), len(severities))
+	}
+
+	counts := results.Counts()
+	want := map[Severity]int{SeverityCritical: 1, SeverityHigh: 1, SeverityLow: 1, SeverityInfo: 2}
+	for severity, n := range want {
+		if counts[severity] != n {
+			t.Errorf("Counts()[%s] = %d, want %d", severity, counts[severity], n)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	results := &Results{}
+	results.AddTarget("b.example.com")
+	results.AddTarget("")
+	results.AddFinding(Finding{Target: "a.example.com", Severity: SeverityHigh})
+	results.AddFinding(Finding{Target: "b.example.com", Severity: "unknown"})
+	results.AddAsset(Asset{Target: "c.example.com"})
+
+	start := time.Now().Add(-2 * time.Second)
+	summary := results.Summary("webvuln", start)
+	if summary.Command != "webvuln" || !summary.Start.Equal(start) || summary.Duration < 2 {
+		t.Errorf("Summary() = %+v, want command webvuln and a duration of 2s or more", summary)
+	}
+	if got := strings.Join(summary.Targets, ","); got != "a.example.com,b.example.com,c.example.com" {
+		t.Errorf("Summary().Targets = %s", got)
+	}
+	if summary.Findings != 2 {
+		t.Errorf("Summary().Findings = %d, want 2", summary.Findings)
+	}
+	want := map[string]int{"info": 1, "low": 0, "medium": 0, "high": 1, "critical": 0}
+	if len(summary.Severities) != len(want) {
+		t.Errorf("Summary().Severities = %v, want %v", summary.Severities, want)
+	}
+	for severity, n := range want {
+		if summary.Severities[severity] != n {
+			t.Errorf("Summary().Severities[%s] = %d, want %d", severity, summary.Severities[severity], n)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// pkg/passive.go
+package pkg
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/budget"
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/tools/subdomain/passive"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// RunPassive looks up the subdomains of domains in passive datasets
+// (VirusTotal, SecurityTrails, AlienVault OTX and Chaos) without sending
+// a query to the target's name servers. The API keys come from
+// tools.osint_scanner.api_keys or the sources' environment variables.
+func RunPassive(args []string) error {
+	options := passive.DefaultOptions()
+	fs := flag.NewFlagSet("passive", flag.ContinueOnError)
+	sources := fs.String("sources", "", "Comma-separated sources to query (default: every source with a key)")
+	timeout := fs.Int("timeout", int(options.Timeout/time.Second), "Request timeout in seconds")
+	jsonOutput := fs.Bool("json", false, "Print the results as JSON")
+	output := fs.String("output", "", "Write the results to this file instead of stdout")
+	maxDuration := fs.Duration("max-duration", 0, "Stop querying sources after this long, e.g. 30m, and keep the partial results (0: no limit)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	domains := fs.Args()
+	if len(domains) == 0 {
+		return fmt.Errorf("no domains to look up, pass them as arguments")
+	}
+	if *sources != "" {
+		options.Sources = strings.Split(*sources, ",")
+	}
+	options.Timeout = time.Duration(*timeout) * time.Second
+
+	selected, skipped, err := passive.New(options)
+	if err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "[*] Skipping sources without an API key: %s\n", strings.Join(skipped, ", "))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := budget.Context(ctx, "passive", *maxDuration)
+	defer cancel()
+	results := []*passive.Result{}
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.Trim(strings.TrimSpace(domain), "."))
+		result := passive.Enumerate(ctx, domain, selected)
+		if filename, err := artifacts.Default().WriteJSON(domain, artifacts.KindSubdomains,
+			artifacts.TimestampedName("passive", "json"), result); err != nil {
+			fmt.Fprintf(os.Stderr, "[-] Failed to save the results of %s: %v\n", domain, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "[+] Results of %s saved to %s\n", domain, filename)
+		}
+		results = append(results, result)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %v", err)
+		}
+		defer file.Close()
+		redacted := redact.Writer(file)
+		defer redacted.Close()
+		w = redacted
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+	} else {
+		for _, result := range results {
+			printPassiveResult(w, result)
+		}
+	}
+	return nil
+}
+
+// printPassiveResult lists the subdomains of a domain with the sources that
+// know them, followed by the statistics of each source
+func printPassiveResult(w io.Writer, result *passive.Result) {
+	fmt.Fprintf(w, "\n%s: %d subdomain(s)\n", result.Domain, len(result.Subdomains))
+	for _, subdomain := range result.Subdomains {
+		fmt.Fprintf(w, "  %-48s %s\n", subdomain.Name, strings.Join(subdomain.Sources, ", "))
+	}
+
+	fmt.Fprintf(w, "\n  %-16s %-7s %-8s %s\n", "Source", "Found", "Seconds", "Error")
+	for _, stats := range result.Sources {
+		fmt.Fprintf(w, "  %-16s %-7d %-8.1f %s\n", stats.Source, stats.Found, stats.Seconds, truncate(stats.Error, 60))
+	}
+}
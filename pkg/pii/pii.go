@@ -0,0 +1,264 @@
+// Package pii detects personal data, such as national identifiers, phone
+// numbers and payment card numbers, in the content fetched by scans:
+// response bodies and bucket listings. The detectors in use are chosen in
+// the scanning.pii settings of the configuration, which can add custom ones.
+package pii
+
+import (
+	"GopherStrike/pkg/config"
+	"GopherStrike/pkg/model"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Detector finds one kind of personal data
+type Detector struct {
+	Name        string
+	Description string // e.g. "payment card number"
+	Severity    model.Severity
+	Pattern     *regexp.Regexp
+	Validate    func(match string) bool // Rejects false positives; nil accepts every match
+}
+
+// Builtins are the built-in detectors
+var Builtins = []Detector{
+	{
+		Name:        "credit_card",
+		Description: "payment card number",
+		Severity:    model.SeverityHigh,
+		Pattern:     regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Validate:    validCard,
+	},
+	{
+		Name:        "us_ssn",
+		Description: "US social security number",
+		Severity:    model.SeverityHigh,
+		Pattern:     regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+		Validate:    validSSN,
+	},
+	{
+		Name:        "uk_nino",
+		Description: "UK National Insurance number",
+		Severity:    model.SeverityHigh,
+		Pattern:     regexp.MustCompile(`\b[A-CEGHJ-PR-TW-Z][A-CEGHJ-NPR-TW-Z] ?\d{2} ?\d{2} ?\d{2} ?[A-D]\b`),
+	},
+	{
+		Name:        "ca_sin",
+		Description: "Canadian social insurance number",
+		Severity:    model.SeverityHigh,
+		Pattern:     regexp.MustCompile(`\b\d{3}[ -]\d{3}[ -]\d{3}\b`),
+		Validate:    func(match string) bool { return Luhn(digits(match)) },
+	},
+	{
+		Name:        "phone",
+		Description: "phone number",
+		Severity:    model.SeverityLow,
+		Pattern:     regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?\(?\b\d{3}\)?[ .-]\d{3}[ .-]\d{4}\b`),
+	},
+}
+
+// digits returns the digits of a string
+func digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Luhn reports whether a number passes the Luhn checksum used by payment
+// cards and Canadian social insurance numbers
+func Luhn(number string) bool {
+	if len(number) < 2 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// validCard accepts numbers of 13 to 19 digits of the major card networks
+// (American Express, Visa, Mastercard, Discover) that pass the Luhn check.
+// Runs of a single digit are left out.
+func validCard(match string) bool {
+	number := digits(match)
+	if len(number) < 13 || len(number) > 19 || !strings.ContainsRune("3456", rune(number[0])) ||
+		strings.Count(number, number[:1]) == len(number) {
+		return false
+	}
+	return Luhn(number)
+}
+
+// validSSN rejects the area, group and serial numbers never assigned
+func validSSN(match string) bool {
+	parts := strings.Split(match, "-")
+	area := parts[0]
+	return area != "000" && area != "666" && area[0] != '9' && parts[1] != "00" && parts[2] != "0000"
+}
+
+// Redact masks the letters and digits of a match but the last four
+func Redact(match string) string {
+	runes := []rune(match)
+	kept := 0
+	for i := len(runes) - 1; i >= 0; i-- {
+		r := runes[i]
+		if !(r >= '0' && r <= '9') && !(r >= 'A' && r <= 'Z') && !(r >= 'a' && r <= 'z') {
+			continue
+		}
+		if kept < 4 {
+			kept++
+			continue
+		}
+		runes[i] = '*'
+	}
+	return string(runes)
+}
+
+// Match counts the data a detector found in a text
+type Match struct {
+	Detector    string         `json:"detector"`
+	Description string         `json:"description"`
+	Severity    model.Severity `json:"severity"`
+	Count       int            `json:"count"`   // Distinct values found
+	Samples     []string       `json:"samples"` // First values found, redacted
+}
+
+// maxSamples is the number of redacted values kept per match
+const maxSamples = 3
+
+// Set is a set of detectors
+type Set struct {
+	Detectors []Detector
+}
+
+// New builds the set of detectors of the PII settings: the named built-in
+// detectors, or all of them, and the custom ones
+func New(cfg config.PIIConfig) (*Set, error) {
+	set := &Set{}
+	if len(cfg.Detectors) == 0 {
+		set.Detectors = append(set.Detectors, Builtins...)
+	}
+	for _, name := range cfg.Detectors {
+		found := false
+		for _, detector := range Builtins {
+			if detector.Name == name {
+				set.Detectors = append(set.Detectors, detector)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown PII detector: %s", name)
+		}
+	}
+
+	for _, custom := range cfg.Custom {
+		pattern, err := regexp.Compile(custom.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("custom PII detector %s: invalid pattern: %v", custom.Name, err)
+		}
+		severity, err := model.ParseSeverity(custom.Severity)
+		if err != nil {
+			return nil, fmt.Errorf("custom PII detector %s: %v", custom.Name, err)
+		}
+		detector := Detector{Name: custom.Name, Description: strings.ReplaceAll(custom.Name, "_", " "), Severity: severity, Pattern: pattern}
+		if custom.Luhn {
+			detector.Validate = func(match string) bool { return Luhn(digits(match)) }
+		}
+		set.Detectors = append(set.Detectors, detector)
+	}
+	return set, nil
+}
+
+// Scan returns the personal data found in a text, most serious first
+func (s *Set) Scan(text string) []Match {
+	matches := []Match{}
+	for _, detector := range s.Detectors {
+		match := Match{Detector: detector.Name, Description: detector.Description, Severity: detector.Severity}
+		seen := make(map[string]bool)
+		for _, value := range detector.Pattern.FindAllString(text, -1) {
+			if seen[value] || (detector.Validate != nil && !detector.Validate(value)) {
+				continue
+			}
+			seen[value] = true
+			match.Count++
+			if len(match.Samples) < maxSamples {
+				match.Samples = append(match.Samples, Redact(value))
+			}
+		}
+		if match.Count > 0 {
+			matches = append(matches, match)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Severity.Rank() > matches[j].Severity.Rank()
+	})
+	return matches
+}
+
+// Summary describes a match for a finding
+func (m Match) Summary() string {
+	return fmt.Sprintf("%d %s(s) (samples: %s)", m.Count, m.Description, strings.Join(m.Samples, ", "))
+}
+
+// Finding converts a match in the content of url to a data exposure finding
+func Finding(tool, target, url string, m Match) model.Finding {
+	return model.Finding{
+		Tool:        tool,
+		Target:      target,
+		Category:    "DATA_EXPOSURE",
+		Name:        fmt.Sprintf("Exposed %s", m.Description),
+		Severity:    m.Severity,
+		Description: "Content exposes " + m.Summary(),
+		URL:         url,
+		Evidence:    "Redacted samples: " + strings.Join(m.Samples, ", "),
+	}
+}
+
+var (
+	current   *Set
+	currentMu sync.Mutex
+)
+
+// Current returns the detectors of the configuration, built the first time
+// they are needed. Invalid settings fall back to the built-in detectors.
+func Current() *Set {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	if current == nil {
+		set, err := New(config.Get().Scanning.PII)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, using the built-in PII detectors\n", err)
+			set = &Set{Detectors: Builtins}
+		}
+		current = set
+	}
+	return current
+}
+
+// Reset drops the detectors in use, so that they are built again from the
+// configuration once it was reloaded
+func Reset() {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = nil
+}
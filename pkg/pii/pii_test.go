@@ -0,0 +1,80 @@
+package pii
+
+import (
+	"GopherStrike/pkg/config"
+	"GopherStrike/pkg/model"
+	"reflect"
+	"testing"
+)
+
+func TestLuhn(t *testing.T) {
+	tests := map[string]bool{
+		"4111111111111111": true,
+		"4111111111111112": false,
+		"046454286":        true,
+		"0":                false,
+		"41x1":             false,
+	}
+	for number, want := range tests {
+		if got := Luhn(number); got != want {
+			t.Errorf("Luhn(%q) = %v, want %v", number, got, want)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := map[string]string{
+		"4111 1111 1111 1111": "**** **** **** 1111",
+		"123-45-6789":         "***-**-6789",
+		"AB 12 34 56 C":       "** ** *4 56 C",
+	}
+	for match, want := range tests {
+		if got := Redact(match); got != want {
+			t.Errorf("Redact(%q) = %q, want %q", match, got, want)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	set, err := New(config.PIIConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := `Order 1: card 4111 1111 1111 1111, card 4111-1111-1111-1112 (invalid), card 4111111111111111 again
+		SSN 123-45-6789 and 666-12-3456 (never assigned), NINO AB 12 34 56 C, SIN 046 454 286
+		Call (555) 123-4567. Order number 1234567890123456 is not a card.`
+	got := map[string]int{}
+	for _, match := range set.Scan(text) {
+		got[match.Detector] = match.Count
+	}
+	want := map[string]int{"credit_card": 2, "us_ssn": 1, "uk_nino": 1, "ca_sin": 1, "phone": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Scan() counts = %v, want %v", got, want)
+	}
+
+	matches := set.Scan("Call +1 555 123 4567 or pay with 4111 1111 1111 1111")
+	if len(matches) != 2 || matches[0].Detector != "credit_card" || matches[0].Samples[0] != "**** **** **** 1111" {
+		t.Errorf("Scan() = %+v, want the card first with a redacted sample", matches)
+	}
+}
+
+func TestNew(t *testing.T) {
+	set, err := New(config.PIIConfig{
+		Detectors: []string{"credit_card"},
+		Custom:    []config.PIIPattern{{Name: "employee_id", Pattern: `\bEMP-\d{6}\b`, Severity: "medium"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := set.Scan("EMP-004217 called 555-123-4567")
+	if len(matches) != 1 || matches[0].Detector != "employee_id" || matches[0].Severity != model.SeverityMedium {
+		t.Errorf("Scan() = %+v, want only the custom detector", matches)
+	}
+
+	if _, err := New(config.PIIConfig{Detectors: []string{"passport"}}); err == nil {
+		t.Error("unknown detector accepted")
+	}
+	if _, err := New(config.PIIConfig{Custom: []config.PIIPattern{{Name: "bad", Pattern: "(", Severity: "low"}}}); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
@@ -0,0 +1,154 @@
+// Package policy applies the severity policy of the configuration to
+// findings: overrides raise or lower the severity of matching findings
+// before they are reported, and the fail threshold decides whether a
+// command-line run failed, so that scans can gate CI/CD pipelines.
+package policy
+
+import (
+	"GopherStrike/pkg/config"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/model"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// override is a severity override with its patterns compiled
+type override struct {
+	config.SeverityOverride
+	severity model.Severity
+	name     *regexp.Regexp
+	target   *regexp.Regexp
+}
+
+// Policy holds the severity overrides and the fail threshold
+type Policy struct {
+	FailOn    model.Severity // Empty never fails
+	overrides []override
+}
+
+// New builds a policy from the policy settings
+func New(cfg config.PolicyConfig) (*Policy, error) {
+	p := &Policy{}
+	if cfg.FailOn != "" {
+		failOn, err := model.ParseSeverity(cfg.FailOn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fail_on: %v", err)
+		}
+		p.FailOn = failOn
+	}
+
+	for i, o := range cfg.Overrides {
+		severity, err := model.ParseSeverity(o.Severity)
+		if err != nil {
+			return nil, fmt.Errorf("severity override %d: %v", i+1, err)
+		}
+		p.overrides = append(p.overrides, override{
+			SeverityOverride: o,
+			severity:         severity,
+			name:             compilePattern(o.Name),
+			target:           compilePattern(o.Target),
+		})
+	}
+	return p, nil
+}
+
+// compilePattern turns a pattern where * matches any text into a
+// case-insensitive regular expression, or nil for an empty pattern
+func compilePattern(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("(?i)^" + strings.Join(parts, ".*") + "$")
+}
+
+// matches reports whether an override applies to a finding
+func (o override) matches(f model.Finding) bool {
+	return (o.Tool == "" || strings.EqualFold(o.Tool, f.Tool)) &&
+		(o.Category == "" || strings.EqualFold(o.Category, f.Category)) &&
+		(o.name == nil || o.name.MatchString(f.Name)) &&
+		(o.target == nil || o.target.MatchString(f.Target))
+}
+
+// Apply returns the finding with the severity of the first matching override
+func (p *Policy) Apply(f model.Finding) model.Finding {
+	for _, o := range p.overrides {
+		if o.matches(f) {
+			f.Severity = o.severity
+			break
+		}
+	}
+	return f
+}
+
+// Fails reports whether a finding reaches the fail threshold
+func (p *Policy) Fails(f model.Finding) bool {
+	return p.FailOn != "" && f.Severity.AtLeast(p.FailOn)
+}
+
+var (
+	current   *Policy
+	currentMu sync.Mutex
+)
+
+// Current returns the policy in use, built from the configuration the first
+// time it is needed. An invalid policy is reported by the configuration
+// validation, so it is replaced by one that changes nothing.
+func Current() *Policy {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	if current == nil {
+		p, err := New(config.Get().Policy)
+		if err != nil {
+			p = &Policy{}
+		}
+		current = p
+	}
+	return current
+}
+
+// Set replaces the policy in use
+func Set(p *Policy) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = p
+}
+
+// Gate counts the findings that reach the fail threshold of the policy in use
+type Gate struct {
+	mu       sync.Mutex
+	failures []model.Finding
+	stop     func()
+}
+
+// Watch starts counting the findings reported from now on
+func Watch() *Gate {
+	g := &Gate{}
+	g.stop = eventbus.Subscribe(eventbus.FindingNew, func(event eventbus.Event) {
+		finding, ok := event.Data.(model.Finding)
+		if !ok || !Current().Fails(finding) {
+			return
+		}
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.failures = append(g.failures, finding)
+	})
+	return g
+}
+
+// Stop stops counting findings
+func (g *Gate) Stop() {
+	g.stop()
+}
+
+// Failures returns the findings that reached the fail threshold
+func (g *Gate) Failures() []model.Finding {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]model.Finding{}, g.failures...)
+}
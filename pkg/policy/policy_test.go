@@ -0,0 +1,77 @@
+package policy
+
+import (
+	"GopherStrike/pkg/config"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/model"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	p, err := New(config.PolicyConfig{Overrides: []config.SeverityOverride{
+		{Tool: "certcheck", Name: "*TLS 1.0*", Severity: "low"},
+		{Target: "*.staging.example.com", Severity: "info"},
+		{Category: "xss", Severity: "critical"},
+		{Category: "XSS", Severity: "medium"}, // Never applies, the first match wins
+	}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		finding model.Finding
+		want    model.Severity
+	}{
+		{"Name pattern", model.Finding{Tool: "certcheck", Name: "Protocol TLS 1.0 enabled", Severity: "high"}, model.SeverityLow},
+		{"Name pattern of another tool", model.Finding{Tool: "sshaudit", Name: "Protocol TLS 1.0 enabled", Severity: "high"}, "high"},
+		{"Target pattern", model.Finding{Tool: "webvuln", Target: "api.STAGING.example.com", Severity: model.SeverityHigh}, model.SeverityInfo},
+		{"Target pattern mismatch", model.Finding{Tool: "webvuln", Target: "staging.example.com.evil", Severity: model.SeverityHigh}, model.SeverityHigh},
+		{"First match wins", model.Finding{Tool: "webvuln", Category: "XSS", Severity: model.SeverityHigh}, model.SeverityCritical},
+		{"No match", model.Finding{Tool: "osint", Category: "CVE", Severity: model.SeverityMedium}, model.SeverityMedium},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Apply(tt.finding).Severity; got != tt.want {
+				t.Errorf("Apply().Severity = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, cfg := range []config.PolicyConfig{
+		{FailOn: "severe"},
+		{Overrides: []config.SeverityOverride{{Tool: "webvuln", Severity: ""}}},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v) should fail", cfg)
+		}
+	}
+}
+
+func TestGate(t *testing.T) {
+	p, err := New(config.PolicyConfig{FailOn: "high"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	Set(p)
+	defer Set(nil)
+
+	gate := Watch()
+	for _, severity := range []model.Severity{"low", "HIGH", model.SeverityCritical, model.SeverityMedium} {
+		eventbus.Publish(eventbus.Event{Type: eventbus.FindingNew, Data: model.Finding{Tool: "test", Severity: severity}})
+	}
+	gate.Stop()
+	eventbus.Publish(eventbus.Event{Type: eventbus.FindingNew, Data: model.Finding{Tool: "test", Severity: model.SeverityCritical}})
+
+	if failures := gate.Failures(); len(failures) != 2 {
+		t.Errorf("Failures() = %d findings, want 2", len(failures))
+	}
+
+	Set(&Policy{})
+	if (&Policy{}).Fails(model.Finding{Severity: model.SeverityCritical}) {
+		t.Error("a policy without fail threshold should never fail")
+	}
+}
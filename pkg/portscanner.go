@@ -1,11 +1,18 @@
 package pkg
 
 import (
+	"GopherStrike/pkg/scope"
+	"GopherStrike/pkg/targets"
+	"bufio"
+	"context"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 )
 
 // hasRequiredPrivileges checks if the current process has the required privileges
@@ -57,8 +64,18 @@ func RunPortScanner() error {
 		return nil
 	}
 	
+	fmt.Print("Enter targets (address, CIDR, range, hostname or a list of them): ")
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	addresses, err := portScanTargets(strings.TrimSpace(line))
+	if err != nil {
+		return err
+	}
+	if len(addresses) == 0 {
+		return fmt.Errorf("no targets to scan")
+	}
+
 	// Execute the Python script with proper environment
-	cmd := exec.Command("python3", scriptPath)
+	cmd := exec.Command("python3", append([]string{scriptPath}, addresses...)...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -73,4 +90,37 @@ func RunPortScanner() error {
 	fmt.Scanln()
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// portScanTargets expands target expressions (see targets.Expand) into the
+// addresses the port scanner scans. Hostnames are scanned at their first
+// address, and excluded targets are left out.
+func portScanTargets(expression string) ([]string, error) {
+	expanded, err := targets.Expand([]string{expression}, targets.DefaultMaxHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := []string{}
+	seen := map[string]bool{}
+	for _, target := range expanded {
+		host := target
+		if strings.Contains(target, "://") {
+			if u, err := url.Parse(target); err == nil {
+				host = u.Hostname()
+			}
+		} else if h, _, err := net.SplitHostPort(target); err == nil {
+			host = h
+		}
+		resolved, err := scope.Resolve(context.Background(), host)
+		if err != nil {
+			fmt.Printf("[-] Skipping %s: %v\n", target, err)
+			continue
+		}
+		if len(resolved) > 0 && !seen[resolved[0]] {
+			seen[resolved[0]] = true
+			addresses = append(addresses, resolved[0])
+		}
+	}
+	return addresses, nil
+}
@@ -0,0 +1,232 @@
+// Package preflight checks that a web target can be scanned before a scan
+// starts: its name resolves, its TLS handshake succeeds and it answers a
+// plain request with a 2xx or 3xx status. A target failing them would
+// otherwise give an empty report without saying why, so each problem comes
+// with a hint of what to do about it.
+package preflight
+
+import (
+	"GopherStrike/pkg/scope"
+	"GopherStrike/pkg/term"
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Checks
+const (
+	CheckScope = "scope"
+	CheckDNS   = "dns"
+	CheckTLS   = "tls"
+	CheckHTTP  = "http"
+)
+
+// TLS alerts sent by servers refusing the client
+const (
+	alertHandshakeFailure    = 40
+	alertBadCertificate      = 42
+	alertCertificateRequired = 116
+)
+
+// Problem is a check that failed
+type Problem struct {
+	Check   string
+	Message string
+	Hint    string // What to do about it
+	Fatal   bool   // The scan can't reach the target at all
+}
+
+// Error returns the problem and its hint
+func (p Problem) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", p.Check, p.Message, p.Hint)
+}
+
+// Report is the outcome of the checks of a target
+type Report struct {
+	Target    string
+	Addresses []string // Addresses the host resolves to
+	TLS       string   // Negotiated TLS version, "" over plain HTTP
+	Status    int      // Status of the baseline request
+	Problems  []Problem
+}
+
+// Err returns the first fatal problem, or nil if the target can be scanned
+func (r Report) Err() error {
+	for _, problem := range r.Problems {
+		if problem.Fatal {
+			return problem
+		}
+	}
+	return nil
+}
+
+// Check checks a target URL. get sends the baseline request the way the
+// scan will, with its client, headers and credentials.
+func Check(ctx context.Context, target string, get func() (*http.Response, error)) Report {
+	report := Report{Target: target}
+	u, err := url.Parse(target)
+	if err != nil || u.Hostname() == "" {
+		report.Problems = append(report.Problems, Problem{CheckHTTP, fmt.Sprintf("invalid URL %q", target), "give a URL such as https://example.com/", true})
+		return report
+	}
+	host := u.Hostname()
+
+	if err := scope.Check(ctx, target); err != nil {
+		report.Problems = append(report.Problems, Problem{CheckScope, err.Error(), "remove the exclusion or pick another target", true})
+		return report
+	}
+
+	if net.ParseIP(host) == nil {
+		addresses, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			report.Problems = append(report.Problems, dnsProblem(host, err))
+			return report
+		}
+		report.Addresses = addresses
+	}
+
+	resp, err := get()
+	if err != nil {
+		report.Problems = append(report.Problems, requestProblem(u, err))
+		return report
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	resp.Body.Close()
+
+	report.Status = resp.StatusCode
+	if resp.TLS != nil {
+		report.TLS = tls.VersionName(resp.TLS.Version)
+	}
+	if problem, ok := statusProblem(resp); ok {
+		report.Problems = append(report.Problems, problem)
+	}
+	return report
+}
+
+// dnsProblem explains a failed lookup
+func dnsProblem(host string, err error) Problem {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return Problem{CheckDNS, host + " does not exist (NXDOMAIN)",
+			"check the spelling, or connect to the VPN or network where the name resolves", true}
+	case errors.As(err, &dnsErr) && dnsErr.IsTimeout:
+		return Problem{CheckDNS, "no answer from the DNS servers for " + host,
+			"check the network connection and the DNS servers in use", true}
+	}
+	return Problem{CheckDNS, fmt.Sprintf("lookup of %s failed: %v", host, err), "check the network connection and the DNS servers in use", true}
+}
+
+// requestProblem explains a baseline request that got no response
+func requestProblem(u *url.URL, err error) Problem {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostnameErr      x509.HostnameError
+		invalidErr       x509.CertificateInvalidError
+		alert            tls.AlertError
+		excluded         *scope.ExcludedError
+		opErr            *net.OpError
+	)
+	switch {
+	case errors.As(err, &excluded):
+		return Problem{CheckScope, excluded.Error(), "the target redirects to an excluded host; scan the final URL or remove the exclusion", true}
+	case errors.As(err, &unknownAuthority):
+		return Problem{CheckTLS, "the certificate is signed by an unknown authority",
+			"the target uses a private CA or a TLS-inspecting proxy is in the way; ignore SSL errors if that is expected", true}
+	case errors.As(err, &hostnameErr):
+		return Problem{CheckTLS, "the certificate is not valid for " + u.Hostname(),
+			fmt.Sprintf("scan a name the certificate is for (%s) or ignore SSL errors", strings.Join(hostnameErr.Certificate.DNSNames, ", ")), true}
+	case errors.As(err, &invalidErr) && invalidErr.Reason == x509.Expired:
+		return Problem{CheckTLS, "the certificate has expired or is not valid yet", "ignore SSL errors to scan it anyway", true}
+	case errors.As(err, &invalidErr):
+		return Problem{CheckTLS, "the certificate is invalid: " + invalidErr.Error(), "ignore SSL errors to scan it anyway", true}
+	case errors.As(err, &alert) && (alert == alertCertificateRequired || alert == alertBadCertificate):
+		return Problem{CheckTLS, "the server requires a client certificate",
+			"the target pins its clients with mutual TLS; scan through a proxy presenting an allowed certificate", true}
+	case errors.As(err, &alert) && alert == alertHandshakeFailure:
+		return Problem{CheckTLS, "the TLS handshake failed: no protocol version or cipher in common",
+			"the server may only accept legacy TLS, which the scanners refuse", true}
+	case errors.As(err, &opErr) && opErr.Timeout():
+		return Problem{CheckHTTP, "connecting to " + u.Host + " timed out",
+			"a firewall may drop your traffic, or the port is closed; check the port and that your address is allowed", true}
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return Problem{CheckHTTP, "could not connect to " + u.Host + ": " + opErr.Err.Error(),
+			"check the port and that the service is up", true}
+	case strings.Contains(err.Error(), "tls: "):
+		return Problem{CheckTLS, "the TLS handshake failed: " + err.Error(), "check the scheme and port, https:// against a plain HTTP port fails", true}
+	}
+	return Problem{CheckHTTP, "the request failed: " + err.Error(), "check the URL and the network connection", true}
+}
+
+// statusProblem explains a baseline response other than 2xx or 3xx. The
+// scan runs against such a response page, so its results would be empty.
+func statusProblem(resp *http.Response) (Problem, bool) {
+	server := ""
+	if name := resp.Header.Get("Server"); name != "" {
+		server = " (server: " + name + ")"
+	}
+	status := fmt.Sprintf("the target answers %s%s", resp.Status, server)
+
+	switch code := resp.StatusCode; {
+	case code < 400:
+		return Problem{}, false
+	case code == http.StatusUnauthorized || code == http.StatusProxyAuthRequired:
+		return Problem{CheckHTTP, status, "add credentials, cookies or an authorization header, or only the login page is scanned", false}, true
+	case code == http.StatusForbidden || code == http.StatusUnavailableForLegalReasons:
+		return Problem{CheckHTTP, status, "the site may block your country (geo-blocking), your address or scanners; scan from an allowed location or have your address allow-listed", false}, true
+	case code == http.StatusNotFound:
+		return Problem{CheckHTTP, status, "check the path of the URL; the tests would run against a not found page", false}, true
+	case code == http.StatusTooManyRequests:
+		return Problem{CheckHTTP, status, "the target rate limits you already; use a slower timing profile such as --timing polite", false}, true
+	case code >= 500:
+		return Problem{CheckHTTP, status, "the target is failing or a proxy can't reach it; results would be unreliable", false}, true
+	}
+	return Problem{CheckHTTP, status, "check the URL and the request settings", false}, true
+}
+
+// Confirm prints the report and, if a check failed, asks whether to scan
+// anyway. A nil reader reads standard input word by word, like the prompts
+// of the tools using fmt.Scanln. Headless runs are not asked: they go ahead
+// unless the target can't be reached at all.
+func Confirm(w io.Writer, reader *bufio.Reader, report Report) bool {
+	checks := []string{}
+	if len(report.Addresses) > 0 {
+		checks = append(checks, fmt.Sprintf("resolves to %s", strings.Join(report.Addresses, ", ")))
+	}
+	if report.TLS != "" {
+		checks = append(checks, report.TLS)
+	}
+	if report.Status != 0 {
+		checks = append(checks, fmt.Sprintf("status %d", report.Status))
+	}
+	if len(report.Problems) == 0 {
+		fmt.Fprintf(w, "[+] Pre-scan checks passed: %s\n", strings.Join(checks, ", "))
+		return true
+	}
+
+	for _, problem := range report.Problems {
+		fmt.Fprintf(w, "[!] Pre-scan %s check failed: %s\n", strings.ToUpper(problem.Check), problem.Message)
+		fmt.Fprintf(w, "    Hint: %s\n", problem.Hint)
+	}
+	if term.Headless() {
+		return report.Err() == nil
+	}
+
+	fmt.Fprint(w, "[?] Scan anyway? (y/N): ")
+	var answer string
+	if reader != nil {
+		answer, _ = reader.ReadString('\n')
+	} else {
+		fmt.Scanln(&answer)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,94 @@
+package preflight
+
+import (
+	"GopherStrike/pkg/term"
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+	mux.HandleFunc("/blocked", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "cloudflare")
+		w.WriteHeader(http.StatusForbidden)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusUnauthorized) })
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	tlsServer := httptest.NewTLSServer(mux)
+	defer tlsServer.Close()
+
+	// A port nothing listens on
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	closed := "http://" + listener.Addr().String() + "/"
+	listener.Close()
+
+	tests := []struct {
+		name       string
+		target     string
+		client     *http.Client
+		wantCheck  string // "" for no problem
+		wantFatal  bool
+		wantSubstr string
+	}{
+		{"Reachable", server.URL + "/", http.DefaultClient, "", false, ""},
+		{"TLS", tlsServer.URL + "/", tlsServer.Client(), "", false, ""},
+		{"Unknown authority", tlsServer.URL + "/", &http.Client{}, CheckTLS, true, "unknown authority"},
+		{"Refused", closed, http.DefaultClient, CheckHTTP, true, "could not connect"},
+		{"Forbidden", server.URL + "/blocked", http.DefaultClient, CheckHTTP, false, "server: cloudflare"},
+		{"Authentication", server.URL + "/login", http.DefaultClient, CheckHTTP, false, "401"},
+		{"No such host", "https://gopherstrike-test.invalid/", http.DefaultClient, CheckDNS, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Check(context.Background(), tt.target, func() (*http.Response, error) { return tt.client.Get(tt.target) })
+			if tt.wantCheck == "" {
+				if len(report.Problems) != 0 || report.Status != http.StatusOK {
+					t.Errorf("Check() = %+v, want no problems", report)
+				}
+				return
+			}
+			if len(report.Problems) != 1 {
+				t.Fatalf("Check() problems = %+v, want one", report.Problems)
+			}
+			problem := report.Problems[0]
+			if problem.Check != tt.wantCheck || problem.Fatal != tt.wantFatal || !strings.Contains(problem.Message, tt.wantSubstr) || problem.Hint == "" {
+				t.Errorf("Check() problem = %+v, want a %s problem with %q, fatal %v", problem, tt.wantCheck, tt.wantSubstr, tt.wantFatal)
+			}
+			if (report.Err() != nil) != tt.wantFatal {
+				t.Errorf("Err() = %v, want fatal %v", report.Err(), tt.wantFatal)
+			}
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	defer term.SetHeadless(false)
+
+	passed := Report{Addresses: []string{"192.0.2.1"}, TLS: "TLS 1.3", Status: 200}
+	warning := Report{Status: 403, Problems: []Problem{{Check: CheckHTTP, Message: "the target answers 403 Forbidden", Hint: "geo-blocking"}}}
+	fatal := Report{Problems: []Problem{{Check: CheckDNS, Message: "example.invalid does not exist (NXDOMAIN)", Hint: "check the spelling", Fatal: true}}}
+
+	var out strings.Builder
+	if !Confirm(&out, bufio.NewReader(strings.NewReader("")), passed) || !strings.Contains(out.String(), "192.0.2.1, TLS 1.3, status 200") {
+		t.Errorf("Confirm() of passed checks = %q", out.String())
+	}
+	for answer, want := range map[string]bool{"\n": false, "n\n": false, "y\n": true} {
+		if got := Confirm(&strings.Builder{}, bufio.NewReader(strings.NewReader(answer)), warning); got != want {
+			t.Errorf("Confirm(%q) = %v, want %v", answer, got, want)
+		}
+	}
+
+	// Headless runs only stop at fatal problems
+	term.SetHeadless(true)
+	if !Confirm(&strings.Builder{}, nil, warning) || Confirm(&strings.Builder{}, nil, fatal) {
+		t.Error("headless Confirm() should go ahead on warnings and stop on fatal problems")
+	}
+}
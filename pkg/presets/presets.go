@@ -0,0 +1,84 @@
+// Package presets names the option sets the tools bundle for fast, regular
+// and thorough scans. Each tool maps a preset to its own options with a
+// PresetXxxOptions function; this package only handles the names, the menu
+// prompt and the --preset flag.
+package presets
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// Scan presets, from the fastest to the most thorough
+const (
+	Quick  = "quick"
+	Normal = "normal"
+	Deep   = "deep"
+)
+
+// Names lists the presets from the fastest to the most thorough
+func Names() []string {
+	return []string{Quick, Normal, Deep}
+}
+
+// Validate normalizes a preset name, returning an error if it is unknown.
+// An empty name selects Normal.
+func Validate(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "":
+		return Normal, nil
+	case Quick, Normal, Deep:
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown preset %q, use %s", name, strings.Join(Names(), ", "))
+}
+
+// FromArgs returns the preset selected with --preset in command line
+// arguments, so that it can provide the defaults of the other flags before
+// they are parsed
+func FromArgs(args []string) (string, error) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+		if value, found := strings.CutPrefix(name, "preset="); found {
+			return Validate(value)
+		}
+		if name == "preset" && i+1 < len(args) {
+			return Validate(args[i+1])
+		}
+	}
+	return Normal, nil
+}
+
+// Prompt asks which preset to use on the interactive menu. descriptions
+// tells what each preset does for the tool; an empty or unknown answer
+// selects Normal. The answer is read from the reader the tool reads its
+// other answers from, or with fmt.Scanln if reader is nil, so that no
+// buffered input is lost.
+func Prompt(reader *bufio.Reader, descriptions map[string]string) string {
+	fmt.Println("\n[+] Scan presets")
+	for _, name := range Names() {
+		fmt.Printf("    %-7s %s\n", name, descriptions[name])
+	}
+	fmt.Printf("[?] Preset (%s) [default: %s]: ", strings.Join(Names(), "/"), Normal)
+	var answer string
+	if reader != nil {
+		answer, _ = reader.ReadString('\n')
+	} else {
+		fmt.Scanln(&answer)
+	}
+
+	name, err := Validate(answer)
+	if err != nil {
+		fmt.Printf("[!] %v. Using %s.\n", err, Normal)
+		return Normal
+	}
+	return name
+}
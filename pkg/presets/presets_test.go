@@ -0,0 +1,78 @@
+package presets
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"quick", Quick, false},
+		{" Deep\n", Deep, false},
+		{"", Normal, false},
+		{"thorough", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := Validate(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("Validate(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFromArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"No preset", []string{"--timeout", "5", "example.com"}, Normal, false},
+		{"Separate value", []string{"--timeout", "5", "--preset", "quick", "example.com"}, Quick, false},
+		{"Equals sign", []string{"-preset=deep"}, Deep, false},
+		{"After terminator", []string{"--", "--preset", "deep"}, Normal, false},
+		{"Unknown preset", []string{"--preset", "fast"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromArgs(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FromArgs(%v) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{"quick\n", Quick},
+		{"\n", Normal},
+		{"everything\n", Normal},
+	}
+
+	for _, tt := range tests {
+		reader := bufio.NewReader(strings.NewReader(tt.answer + "next answer\n"))
+		if got := Prompt(reader, map[string]string{}); got != tt.want {
+			t.Errorf("Prompt(%q) = %q, want %q", tt.answer, got, tt.want)
+		}
+		if rest, _ := reader.ReadString('\n'); rest != "next answer\n" {
+			t.Errorf("Prompt(%q) consumed the next answer, left %q", tt.answer, rest)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// Package redact masks the secrets of an engagement, such as session
+// cookies, API keys and the client's name, in what is written to disk: the
+// logs, the stored results and findings, the evidence and the reports. The
+// rules come from the redaction file of the workspace and apply to every
+// tool once set.
+package redact
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// File is the name of the redaction rules file in a workspace directory
+const File = "redaction.txt"
+
+// Mask replaces the redacted text
+const Mask = "[REDACTED]"
+
+// Rules is a parsed redaction rules file
+type Rules struct {
+	Path  string
+	rules []rule
+}
+
+// rule is a pattern of secrets
+type rule struct {
+	re    *regexp.Regexp
+	keyed bool // The first group is the name of the value and is kept
+}
+
+// Parse reads redaction rules, one per line. Empty lines and lines starting
+// with # are skipped. Each line is one of:
+//
+//	Acme Corp            text masked wherever it appears, ignoring case
+//	param:sessionid      the value of a cookie, query or form parameter
+//	header:X-Api-Key     the value of a header
+//	re:sk_live_\w+       a regular expression
+func Parse(r io.Reader) (*Rules, error) {
+	rules := &Rules{}
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := rules.add(line); err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading redaction rules: %v", err)
+	}
+	return rules, nil
+}
+
+// Load reads a redaction rules file
+func Load(path string) (*Rules, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open redaction rules: %v", err)
+	}
+	defer file.Close()
+
+	rules, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redaction rules %s: %v", path, err)
+	}
+	rules.Path = path
+	return rules, nil
+}
+
+// add parses one rule. The patterns of parameters and headers mask the value
+// only, through the end of the value, so what they belong to stays readable.
+func (r *Rules) add(entry string) error {
+	var pattern string
+	keyed := false
+	switch {
+	case strings.HasPrefix(entry, "re:"):
+		pattern = strings.TrimPrefix(entry, "re:")
+	case strings.HasPrefix(entry, "param:"):
+		name := strings.TrimSpace(strings.TrimPrefix(entry, "param:"))
+		if name == "" {
+			return fmt.Errorf("missing parameter name")
+		}
+		pattern, keyed = `(?i)(\b`+regexp.QuoteMeta(name)+`=)[^;&\s"'<>\\]+`, true
+	case strings.HasPrefix(entry, "header:"):
+		name := strings.TrimSpace(strings.TrimPrefix(entry, "header:"))
+		if name == "" {
+			return fmt.Errorf("missing header name")
+		}
+		pattern, keyed = `(?i)(\b`+regexp.QuoteMeta(name)+`"?\s*[:=]\s*\[?"?)[^\r\n"\\]+`, true
+	default:
+		// Also match the text as escaped in JSON results and HTML reports,
+		// e.g. & as \u0026 and &amp;
+		alternatives := []string{regexp.QuoteMeta(entry)}
+		for _, escaped := range []string{jsonEscape(entry), html.EscapeString(entry)} {
+			if escaped != entry {
+				alternatives = append(alternatives, regexp.QuoteMeta(escaped))
+			}
+		}
+		pattern = `(?i)(?:` + strings.Join(alternatives, "|") + `)`
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	r.rules = append(r.rules, rule{re: re, keyed: keyed})
+	return nil
+}
+
+// jsonEscape returns text as it appears inside a JSON string
+func jsonEscape(text string) string {
+	data, _ := json.Marshal(text)
+	return string(data[1 : len(data)-1])
+}
+
+// Len returns the number of rules
+func (r *Rules) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.rules)
+}
+
+// String masks the secrets in s
+func (r *Rules) String(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, rule := range r.rules {
+		if rule.keyed {
+			s = rule.re.ReplaceAllString(s, "${1}"+Mask)
+		} else {
+			s = rule.re.ReplaceAllLiteralString(s, Mask)
+		}
+	}
+	return s
+}
+
+// Bytes masks the secrets in b
+func (r *Rules) Bytes(b []byte) []byte {
+	if r.Len() == 0 {
+		return b
+	}
+	return []byte(r.String(string(b)))
+}
+
+var (
+	mutex  sync.RWMutex
+	active *Rules
+)
+
+// Set makes rules the ones applied by String, Bytes and Writer. A nil value
+// removes all rules.
+func Set(r *Rules) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	active = r
+}
+
+// Active returns the applied rules, or nil if there are none
+func Active() *Rules {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return active
+}
+
+// String masks the secrets in s with the applied rules
+func String(s string) string {
+	return Active().String(s)
+}
+
+// Bytes masks the secrets in b with the applied rules
+func Bytes(b []byte) []byte {
+	return Active().Bytes(b)
+}
+
+// Writer returns a writer masking the secrets written to w with the applied
+// rules. Writes are held until a line is complete, so that a secret split
+// across two writes is masked too; Close writes what is left of the last
+// line, without closing w.
+func Writer(w io.Writer) io.WriteCloser {
+	return &writer{w: w}
+}
+
+// writer masks the secrets of the lines written to w
+type writer struct {
+	w       io.Writer
+	pending []byte // Incomplete last line
+}
+
+// Write masks the secrets of the complete lines and writes them. It reports
+// the length of p as written, as callers check it against what they passed.
+func (w *writer) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	end := bytes.LastIndexByte(w.pending, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+	lines := w.pending[:end+1]
+	w.pending = append([]byte(nil), w.pending[end+1:]...)
+	if _, err := w.w.Write(Bytes(lines)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close masks the secrets of the incomplete last line and writes it
+func (w *writer) Close() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	_, err := w.w.Write(Bytes(w.pending))
+	w.pending = nil
+	return err
+}
@@ -2,7 +2,12 @@
 package resolver
 
 import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/targets"
+	"GopherStrike/pkg/wordlist"
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -37,7 +42,9 @@ func RunHostResolver() error {
 			resolveSubdomains(resolver)
 		case "4": // Configure resolver settings
 			configureResolverSettings(resolver)
-		case "5": // Return to main menu
+		case "5": // Check DNSSEC
+			checkDNSSEC(resolver)
+		case "6": // Return to main menu
 			fmt.Println("Returning to main menu...")
 			return nil
 		default:
@@ -54,7 +61,8 @@ func printMainMenu() {
 	fmt.Println("2. Resolve Multiple Hostnames")
 	fmt.Println("3. Resolve Subdomains")
 	fmt.Println("4. Configure Settings")
-	fmt.Println("5. Return to Main Menu")
+	fmt.Println("5. Check DNSSEC")
+	fmt.Println("6. Return to Main Menu")
 }
 
 // resolveSingleHost resolves a single hostname
@@ -77,7 +85,7 @@ func resolveSingleHost(resolver *HostResolver) {
 	// Option to save
 	saveChoice := getInput("Save result to file? (y/n)")
 	if strings.ToLower(saveChoice) == "y" {
-		saveResultToFile([]ResolveResult{result}, hostname)
+		saveResultToFile([]ResolveResult{result}, hostname, "resolve")
 	}
 }
 
@@ -94,27 +102,35 @@ func resolveMultipleHosts(resolver *HostResolver) {
 
 	switch choice {
 	case "1": // Enter manually
-		fmt.Println("Enter one hostname per line. Enter a blank line when done.")
+		fmt.Println("Enter hostnames, IPs, CIDRs (10.0.0.0/24) or ranges (10.0.0.1-50), comma-separated or one per line.")
+		fmt.Println("Enter a blank line when done.")
 		reader := bufio.NewReader(os.Stdin)
+		var expressions []string
 		for {
 			fmt.Print("> ")
-			hostname, _ := reader.ReadString('\n')
-			hostname = strings.TrimSpace(hostname)
-			if hostname == "" {
+			line, _ := reader.ReadString('\n')
+			line = strings.TrimSpace(line)
+			if line == "" {
 				break
 			}
-			hostnames = append(hostnames, hostname)
+			expressions = append(expressions, line)
+		}
+		var err error
+		hostnames, err = targets.Expand(expressions, targets.DefaultMaxHosts)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
 		}
 
 	case "2": // Load from file
-		filePath := getInput("Enter path to hostnames file")
+		filePath := getInput("Enter path to targets file")
 		var err error
-		hostnames, err = loadHostnamesFromFile(filePath)
+		hostnames, err = targets.LoadFile(filePath, targets.DefaultMaxHosts)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			return
 		}
-		fmt.Printf("Loaded %d hostnames from file.\n", len(hostnames))
+		fmt.Printf("Loaded %d targets from file.\n", len(hostnames))
 
 	case "3": // Go back
 		return
@@ -161,11 +177,13 @@ func resolveMultipleHosts(resolver *HostResolver) {
 
 	// Display results summary
 	displayResolutionSummary(results)
+	displayProviderSummary(results)
+	displayCNAMERisks(results)
 
 	// Option to save
 	saveChoice := getInput("Save results to file? (y/n)")
 	if strings.ToLower(saveChoice) == "y" {
-		saveResultToFile(results, "bulk_resolution")
+		saveResultToFile(results, "", "bulk_resolution")
 	}
 }
 
@@ -260,11 +278,49 @@ func resolveSubdomains(resolver *HostResolver) {
 
 	// Display resolved subdomains
 	displaySubdomainsResults(results)
+	displayProviderSummary(results)
+	displayCNAMERisks(results)
 
 	// Option to save
 	saveChoice := getInput("Save results to file? (y/n)")
 	if strings.ToLower(saveChoice) == "y" {
-		saveResultToFile(results, fmt.Sprintf("subdomains_%s", domain))
+		saveResultToFile(results, domain, "subdomains")
+	}
+}
+
+// checkDNSSEC checks the DNSSEC setup of one or more zones
+func checkDNSSEC(resolver *HostResolver) {
+	fmt.Println("\n--- DNSSEC Check ---")
+	fmt.Printf("Validating resolver: %s\n", resolver.dnssecServer())
+	input := getInput("Enter zones to check (comma-separated, e.g., example.com)")
+	if input == "" {
+		fmt.Println("Error: Domain cannot be empty.")
+		return
+	}
+
+	var results []DNSSECResult
+	for _, domain := range strings.Split(input, ",") {
+		if domain = strings.TrimSpace(domain); domain == "" {
+			continue
+		}
+		fmt.Printf("\nChecking DNSSEC of %s...\n", domain)
+		ctx, cancel := context.WithTimeout(context.Background(), 6*resolver.Timeout*time.Duration(resolver.MaxRetries+1))
+		result := resolver.CheckDNSSEC(ctx, domain)
+		cancel()
+		displayDNSSECResult(result)
+		results = append(results, result)
+	}
+
+	saveChoice := getInput("Save results to file? (y/n)")
+	if strings.ToLower(saveChoice) == "y" {
+		for _, result := range results {
+			filename, err := artifacts.Default().WriteJSON(result.Domain, artifacts.KindDNS, artifacts.TimestampedName("dnssec", "json"), result)
+			if err != nil {
+				fmt.Printf("Error writing file: %v\n", err)
+				continue
+			}
+			fmt.Printf("Results saved to %s\n", filename)
+		}
 	}
 }
 
@@ -276,8 +332,10 @@ func configureResolverSettings(resolver *HostResolver) {
 	fmt.Printf("3. Max Retries: %d\n", resolver.MaxRetries)
 	fmt.Printf("4. IPv4 Only: %t\n", resolver.IPv4Only)
 	fmt.Printf("5. IPv6 Only: %t\n", resolver.IPv6Only)
-	fmt.Printf("6. Clear Cache\n")
-	fmt.Printf("7. Return to Main Menu\n")
+	fmt.Printf("6. Follow CNAME Chains: %t\n", resolver.FollowCNAMEs)
+	fmt.Printf("7. Look Up Hosting (ASN, Country): %t\n", resolver.EnrichIPs)
+	fmt.Printf("8. Clear Cache\n")
+	fmt.Printf("9. Return to Main Menu\n")
 
 	choice := getInput("Select a setting to change")
 
@@ -322,11 +380,23 @@ func configureResolverSettings(resolver *HostResolver) {
 		resolver.WithIPv6Only(strings.ToLower(ipv6OnlyStr) == "y")
 		fmt.Println("IPv6 Only setting updated.")
 
-	case "6": // Clear Cache
+	case "6": // Follow CNAME chains
+		followStr := getInput("Record CNAME chains and flag dangling ones? (y/n)")
+		resolver.WithCNAMEs(strings.ToLower(followStr) == "y")
+		resolver.ClearCache()
+		fmt.Println("CNAME setting updated.")
+
+	case "7": // Look up hosting
+		enrichStr := getInput("Look up the ASN, organization and country of addresses? (y/n)")
+		resolver.WithIPInfo(strings.ToLower(enrichStr) == "y")
+		resolver.ClearCache()
+		fmt.Println("Hosting lookup setting updated.")
+
+	case "8": // Clear Cache
 		resolver.ClearCache()
 		fmt.Println("Resolution cache cleared.")
 
-	case "7": // Return
+	case "9": // Return
 		return
 
 	default:
@@ -354,16 +424,85 @@ func displayResolveResult(result ResolveResult) {
 		}
 	}
 
+	if len(result.Hosting) > 0 {
+		fmt.Println("\nHosting:")
+		for _, info := range result.Hosting {
+			fmt.Printf("- %s: %s\n", info.IP, formatHosting(info))
+		}
+	}
+
+	if len(result.CNAMEChain) > 0 {
+		fmt.Printf("\nCNAME Chain: %s -> %s\n", result.Hostname, strings.Join(result.CNAMEChain, " -> "))
+	}
+	if risk := result.CNAMERisk; risk != nil {
+		fmt.Printf("\n[%s] %s\n", risk.Severity, risk.Description)
+	}
+
 	if result.Error != "" {
 		fmt.Printf("\nError: %s\n", result.Error)
 	}
 }
 
+// displayCNAMERisks prints the risky CNAME chains of resolution results
+func displayCNAMERisks(results []ResolveResult) {
+	first := true
+	for _, result := range results {
+		risk := result.CNAMERisk
+		if risk == nil {
+			continue
+		}
+		if first {
+			fmt.Println("\n=== CNAME Chains at Risk ===")
+			first = false
+		}
+		fmt.Printf("[%s] %s -> %s\n", risk.Severity, result.Hostname, strings.Join(result.CNAMEChain, " -> "))
+		fmt.Printf("    %s\n", risk.Description)
+	}
+}
+
+// displayDNSSECResult prints a DNSSEC check result
+func displayDNSSECResult(result DNSSECResult) {
+	fmt.Println("\n=== DNSSEC Result ===")
+	fmt.Printf("Zone: %s\n", result.Domain)
+	if result.Error != "" {
+		fmt.Printf("Error: %s\n", result.Error)
+		return
+	}
+	fmt.Printf("Status: %s (validated by %s: %t)\n", result.Status, result.Server, result.Validated)
+
+	if len(result.DS) > 0 {
+		fmt.Println("\nDS Records:")
+		for _, ds := range result.DS {
+			fmt.Printf("- key %d, %s, %s\n", ds.KeyTag, ds.Algorithm, ds.DigestType)
+		}
+	}
+	if len(result.DNSKEYs) > 0 {
+		fmt.Println("\nDNSKEY Records:")
+		for _, key := range result.DNSKEYs {
+			role := "ZSK"
+			if key.SEP {
+				role = "KSK"
+			}
+			size := ""
+			if key.Bits > 0 {
+				size = fmt.Sprintf(", %d bits", key.Bits)
+			}
+			fmt.Printf("- key %d, %s, %s%s\n", key.KeyTag, role, key.Algorithm, size)
+		}
+	}
+	if len(result.Problems) > 0 {
+		fmt.Println("\nProblems:")
+		for _, problem := range result.Problems {
+			fmt.Printf("- [%s] %s\n", problem.Severity, problem.Description)
+		}
+	}
+}
+
 // displayResolutionSummary prints a summary of multiple resolution results
 func displayResolutionSummary(results []ResolveResult) {
 	fmt.Println("\n=== Resolution Summary ===")
-	fmt.Printf("%-40s %-15s %-7s\n", "HOSTNAME", "STATUS", "IPs")
-	fmt.Printf("%s\n", strings.Repeat("-", 70))
+	fmt.Printf("%-40s %-15s %-7s %s\n", "HOSTNAME", "STATUS", "IPs", "HOSTED BY")
+	fmt.Printf("%s\n", strings.Repeat("-", 100))
 
 	for _, result := range results {
 		status := "Resolved"
@@ -371,11 +510,53 @@ func displayResolutionSummary(results []ResolveResult) {
 			status = "Failed"
 		}
 
+		hostedBy := ""
+		if len(result.Hosting) > 0 {
+			hostedBy = truncateString(formatHosting(result.Hosting[0]), 36)
+		}
+
 		ipCount := len(result.IPv4) + len(result.IPv6)
-		fmt.Printf("%-40s %-15s %-7d\n", truncateString(result.Hostname, 40), status, ipCount)
+		fmt.Printf("%-40s %-15s %-7d %s\n", truncateString(result.Hostname, 40), status, ipCount, hostedBy)
 	}
 }
 
+// displayProviderSummary prints the hosts and addresses of resolution
+// results grouped by the provider hosting them
+func displayProviderSummary(results []ResolveResult) {
+	summaries := SummarizeProviders(results)
+	if len(summaries) == 0 {
+		return
+	}
+
+	fmt.Println("\n=== Hosting Providers ===")
+	fmt.Printf("%-36s %-6s %-6s %-20s %s\n", "PROVIDER", "HOSTS", "IPs", "ASNs", "COUNTRIES")
+	fmt.Printf("%s\n", strings.Repeat("-", 85))
+	for _, summary := range summaries {
+		asns := make([]string, 0, len(summary.ASNs))
+		for _, asn := range summary.ASNs {
+			asns = append(asns, fmt.Sprintf("AS%d", asn))
+		}
+		fmt.Printf("%-36s %-6d %-6d %-20s %s\n", truncateString(summary.Provider, 36), summary.Hosts, summary.IPs,
+			truncateString(strings.Join(asns, ","), 20), strings.Join(summary.Countries, ","))
+	}
+}
+
+// formatHosting describes where an address is hosted, such as
+// "AS13335 CLOUDFLARENET (US)"
+func formatHosting(info IPInfo) string {
+	if info.ASN == 0 {
+		return "unknown"
+	}
+	hosting := fmt.Sprintf("AS%d", info.ASN)
+	if info.Organization != "" {
+		hosting += " " + info.Organization
+	}
+	if info.Country != "" {
+		hosting += fmt.Sprintf(" (%s)", info.Country)
+	}
+	return hosting
+}
+
 // displaySubdomainsResults prints subdomain resolution results
 func displaySubdomainsResults(results []ResolveResult) {
 	fmt.Println("\n=== Resolved Subdomains ===")
@@ -406,18 +587,13 @@ func displaySubdomainsResults(results []ResolveResult) {
 	}
 }
 
-// saveResultToFile saves resolution results to a file
-func saveResultToFile(results []ResolveResult, baseName string) {
-	// Create logs directory
-	logsDir := filepath.Join("logs", "resolver")
-	if err := os.MkdirAll(logsDir, 0755); err != nil {
-		fmt.Printf("Warning: Failed to create logs directory: %v\n", err)
-		return
-	}
-
+// saveResultToFile saves resolution results to a file. Results about a single
+// target are stored in that target's DNS artifact directory; results spanning
+// several hosts are kept in the resolver log directory.
+func saveResultToFile(results []ResolveResult, target, baseName string) {
 	// Generate timestamp for the filename
 	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	filename := filepath.Join(logsDir, fmt.Sprintf("%s_%s.json", baseName, timestamp))
+	name := fmt.Sprintf("%s_%s.json", baseName, timestamp)
 
 	// Marshal to JSON
 	data, err := json.MarshalIndent(results, "", "  ")
@@ -426,8 +602,18 @@ func saveResultToFile(results []ResolveResult, baseName string) {
 		return
 	}
 
-	// Write to file
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	var filename string
+	if target != "" {
+		filename, err = artifacts.Default().WriteFile(target, artifacts.KindDNS, name, data)
+	} else {
+		// Create logs directory
+		logsDir := filepath.Join("logs", "resolver")
+		if err = os.MkdirAll(logsDir, 0755); err == nil {
+			filename = filepath.Join(logsDir, name)
+			err = os.WriteFile(filename, redact.Bytes(data), 0644)
+		}
+	}
+	if err != nil {
 		fmt.Printf("Error writing file: %v\n", err)
 		return
 	}
@@ -437,22 +623,17 @@ func saveResultToFile(results []ResolveResult, baseName string) {
 
 // loadHostnamesFromFile loads hostnames from a file, one per line
 func loadHostnamesFromFile(filePath string) ([]string, error) {
-	file, err := os.Open(filePath)
+	words, err := wordlist.Open(context.Background(), filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %v", err)
 	}
-	defer file.Close()
 
 	var hostnames []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		hostname := strings.TrimSpace(scanner.Text())
-		if hostname != "" && !strings.HasPrefix(hostname, "#") {
-			hostnames = append(hostnames, hostname)
-		}
+	for hostname := range words.Words {
+		hostnames = append(hostnames, hostname)
 	}
 
-	if err := scanner.Err(); err != nil {
+	if err := words.Err(); err != nil {
 		return nil, fmt.Errorf("error reading file: %v", err)
 	}
 
@@ -0,0 +1,206 @@
+// pkg/resolver/cname.go
+package resolver
+
+import (
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/siem"
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/net/publicsuffix"
+)
+
+// maxCNAMEHops is the longest CNAME chain followed
+const maxCNAMEHops = 10
+
+// SeverityInfo rates CNAME chains worth reviewing that are not broken
+const SeverityInfo = "info"
+
+// CNAMERisk flags a CNAME chain that may let someone else serve the
+// hostname's content
+type CNAMERisk struct {
+	Target      string `json:"target"`            // Name of the chain at risk
+	Service     string `json:"service,omitempty"` // Third-party service it belongs to
+	Dangling    bool   `json:"dangling"`          // The end of the chain does not exist
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+// saasProviders are the hosting services whose resources are claimed by
+// name, so that a CNAME to a released resource can be claimed again by
+// anyone. Patterns match the end of the CNAME target.
+var saasProviders = []struct {
+	Service string
+	Pattern *regexp.Regexp
+}{
+	{"AWS S3", regexp.MustCompile(`\.s3(?:[.-][a-z0-9-]+)*\.amazonaws\.com$`)},
+	{"AWS CloudFront", regexp.MustCompile(`\.cloudfront\.net$`)},
+	{"AWS Elastic Beanstalk", regexp.MustCompile(`\.elasticbeanstalk\.com$`)},
+	{"Azure", regexp.MustCompile(`\.(?:azurewebsites\.net|cloudapp\.net|cloudapp\.azure\.com|trafficmanager\.net|blob\.core\.windows\.net|azureedge\.net|azure-api\.net)$`)},
+	{"Bitbucket", regexp.MustCompile(`\.bitbucket\.io$`)},
+	{"Fastly", regexp.MustCompile(`\.fastly\.net$`)},
+	{"Firebase", regexp.MustCompile(`\.(?:firebaseapp\.com|web\.app)$`)},
+	{"Fly.io", regexp.MustCompile(`\.fly\.dev$`)},
+	{"Ghost", regexp.MustCompile(`\.ghost\.io$`)},
+	{"GitHub Pages", regexp.MustCompile(`\.github\.io$`)},
+	{"Help Scout", regexp.MustCompile(`\.helpscoutdocs\.com$`)},
+	{"Heroku", regexp.MustCompile(`\.(?:herokuapp\.com|herokudns\.com|herokussl\.com)$`)},
+	{"Netlify", regexp.MustCompile(`\.netlify\.(?:app|com)$`)},
+	{"Pantheon", regexp.MustCompile(`\.pantheonsite\.io$`)},
+	{"ReadMe", regexp.MustCompile(`\.readme\.io$`)},
+	{"Shopify", regexp.MustCompile(`\.myshopify\.com$`)},
+	{"Surge", regexp.MustCompile(`\.surge\.sh$`)},
+	{"Unbounce", regexp.MustCompile(`\.unbouncepages\.com$`)},
+	{"Vercel", regexp.MustCompile(`\.(?:vercel\.app|now\.sh)$`)},
+	{"WordPress.com", regexp.MustCompile(`\.wordpress\.com$`)},
+	{"WP Engine", regexp.MustCompile(`\.wpengine\.com$`)},
+	{"Zendesk", regexp.MustCompile(`\.zendesk\.com$`)},
+}
+
+// saasService returns the hosting service a name belongs to, or ""
+func saasService(name string) string {
+	for _, provider := range saasProviders {
+		if provider.Pattern.MatchString(name) {
+			return provider.Service
+		}
+	}
+	return ""
+}
+
+// queryServer returns the server CNAME queries are sent to: the first
+// configured DNS server, else the first name server of /etc/resolv.conf
+func (r *HostResolver) queryServer() string {
+	if len(r.DNSServers) > 0 {
+		return r.dnssecServer()
+	}
+	if file, err := os.Open("/etc/resolv.conf"); err == nil {
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if fields := strings.Fields(scanner.Text()); len(fields) >= 2 && fields[0] == "nameserver" {
+				return net.JoinHostPort(fields[1], "53")
+			}
+		}
+	}
+	return DefaultDNSSECServer
+}
+
+// CNAMEChain follows the CNAME records of a hostname and returns the names
+// it is an alias of, in order. nxdomain reports whether the last name of
+// the chain does not exist.
+func (r *HostResolver) CNAMEChain(ctx context.Context, hostname string) (chain []string, nxdomain bool, err error) {
+	server := r.queryServer()
+	name := strings.ToLower(strings.TrimSuffix(hostname, "."))
+	seen := map[string]bool{name: true}
+	for hop := 0; hop < maxCNAMEHops; hop++ {
+		answer, err := r.exchange(ctx, server, name, dnsmessage.TypeCNAME, false)
+		if err != nil {
+			return chain, false, err
+		}
+		if answer.RCode == dnsmessage.RCodeNameError {
+			return chain, true, nil
+		}
+
+		target := ""
+		for _, rr := range answer.Answers {
+			cname, ok := rr.Body.(*dnsmessage.CNAMEResource)
+			if ok && strings.EqualFold(strings.TrimSuffix(rr.Header.Name.String(), "."), name) {
+				target = strings.ToLower(strings.TrimSuffix(cname.CNAME.String(), "."))
+			}
+		}
+		if target == "" {
+			return chain, false, nil
+		}
+		if seen[target] {
+			return chain, false, fmt.Errorf("CNAME loop at %s", target)
+		}
+		seen[target] = true
+		chain = append(chain, target)
+		name = target
+	}
+	return chain, false, fmt.Errorf("CNAME chain of %s longer than %d names", hostname, maxCNAMEHops)
+}
+
+// AssessCNAMEChain rates the CNAME chain of a hostname. A chain ending in
+// a name that does not exist is dangling, and a dangling chain through a
+// hosting service may be taken over by claiming the released resource; so
+// may a chain through a hosting service that resolves to no address. A
+// chain resolving to a third-party service is only worth reviewing. Chains
+// raising no concern return nil.
+func AssessCNAMEChain(hostname string, chain []string, nxdomain, resolved bool) *CNAMERisk {
+	if len(chain) == 0 {
+		return nil
+	}
+	last := chain[len(chain)-1]
+	risk := &CNAMERisk{Target: last, Dangling: nxdomain}
+	for i := len(chain) - 1; i >= 0 && risk.Service == ""; i-- {
+		if risk.Service = saasService(chain[i]); risk.Service != "" {
+			risk.Target = chain[i]
+		}
+	}
+
+	switch {
+	case nxdomain && risk.Service != "":
+		risk.Severity = SeverityHigh
+		risk.Description = fmt.Sprintf("%s is an alias of %s (%s), which does not exist; whoever claims the %s resource of that name serves the content of %s (subdomain takeover)",
+			hostname, last, risk.Service, risk.Service, hostname)
+	case nxdomain:
+		risk.Severity = SeverityMedium
+		risk.Description = fmt.Sprintf("%s is an alias of %s, which does not exist; if the domain of %s can be registered, %s can be taken over",
+			hostname, last, last, hostname)
+	case !resolved && risk.Service != "":
+		risk.Severity = SeverityMedium
+		risk.Description = fmt.Sprintf("%s is an alias of %s (%s), which resolves to no address; the %s resource may have been released and be claimable",
+			hostname, last, risk.Service, risk.Service)
+	case risk.Service != "" && !sameSite(hostname, risk.Target):
+		risk.Severity = SeverityInfo
+		risk.Description = fmt.Sprintf("%s is served by %s through %s; remove the record before releasing the %s resource",
+			hostname, risk.Service, risk.Target, risk.Service)
+	default:
+		return nil
+	}
+	return risk
+}
+
+// sameSite reports whether two names share their registrable domain
+func sameSite(a, b string) bool {
+	siteA, errA := publicsuffix.EffectiveTLDPlusOne(a)
+	siteB, errB := publicsuffix.EffectiveTLDPlusOne(b)
+	return errA == nil && errB == nil && siteA == siteB
+}
+
+// recordCNAMEChain follows the CNAME chain of a resolved hostname into its
+// result and reports a risky chain as a finding
+func (r *HostResolver) recordCNAMEChain(ctx context.Context, result *ResolveResult) {
+	if net.ParseIP(result.Hostname) != nil {
+		return
+	}
+	chain, nxdomain, err := r.CNAMEChain(ctx, result.Hostname)
+	result.CNAMEChain = chain
+	if err != nil && len(chain) == 0 {
+		return
+	}
+
+	result.CNAMERisk = AssessCNAMEChain(result.Hostname, chain, nxdomain, result.Resolved)
+	if risk := result.CNAMERisk; risk != nil {
+		name := fmt.Sprintf("CNAME of %s to %s", result.Hostname, risk.Service)
+		if risk.Dangling {
+			name = fmt.Sprintf("Dangling CNAME of %s", result.Hostname)
+		}
+		siem.Emit(siem.Finding{
+			Tool:        "resolver",
+			Target:      result.Hostname,
+			Category:    "DNS_CNAME",
+			Name:        name,
+			Severity:    model.Severity(risk.Severity),
+			Description: risk.Description,
+			Evidence:    strings.Join(append([]string{result.Hostname}, chain...), " -> "),
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveCNAMEs answers CNAME queries of a fake DNS server from a map of
+// aliases; names that are neither aliases nor in exists do not exist
+func serveCNAMEs(t *testing.T, aliases map[string]string, exists map[string]bool) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buffer := make([]byte, 4096)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if query.Unpack(buffer[:n]) != nil || len(query.Questions) != 1 {
+				continue
+			}
+			question := query.Questions[0]
+			name := strings.TrimSuffix(question.Name.String(), ".")
+			header := dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true}
+			target, alias := aliases[name]
+			if !alias && !exists[name] {
+				header.RCode = dnsmessage.RCodeNameError
+			}
+
+			builder := dnsmessage.NewBuilder(nil, header)
+			builder.StartQuestions()
+			builder.Question(question)
+			builder.StartAnswers()
+			if alias {
+				builder.CNAMEResource(dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 300},
+					dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName(target + ".")})
+			}
+			if response, err := builder.Finish(); err == nil {
+				conn.WriteTo(response, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestCNAMEChain(t *testing.T) {
+	server := serveCNAMEs(t, map[string]string{
+		"www.example.com":             "example.com.cdn.example.net",
+		"example.com.cdn.example.net": "edge.example.net",
+		"old.example.com":             "old-app.herokuapp.com",
+		"loop.example.com":            "loop2.example.com",
+		"loop2.example.com":           "loop.example.com",
+	}, map[string]bool{"edge.example.net": true, "plain.example.com": true})
+	resolver := testResolver(server)
+
+	tests := []struct {
+		hostname string
+		chain    []string
+		nxdomain bool
+		wantErr  bool
+	}{
+		{"www.example.com", []string{"example.com.cdn.example.net", "edge.example.net"}, false, false},
+		{"old.example.com", []string{"old-app.herokuapp.com"}, true, false},
+		{"plain.example.com", nil, false, false},
+		{"loop.example.com", []string{"loop2.example.com"}, false, true},
+	}
+	for _, tt := range tests {
+		chain, nxdomain, err := resolver.CNAMEChain(context.Background(), tt.hostname)
+		if !reflect.DeepEqual(chain, tt.chain) || nxdomain != tt.nxdomain || (err != nil) != tt.wantErr {
+			t.Errorf("CNAMEChain(%s) = %v, %t, %v, want %v, %t, error %t", tt.hostname, chain, nxdomain, err, tt.chain, tt.nxdomain, tt.wantErr)
+		}
+	}
+}
+
+func TestAssessCNAMEChain(t *testing.T) {
+	tests := []struct {
+		name     string
+		chain    []string
+		nxdomain bool
+		resolved bool
+		severity string // "" for no risk
+		service  string
+	}{
+		{"no chain", nil, false, true, "", ""},
+		{"takeover", []string{"old-app.herokuapp.com"}, true, false, SeverityHigh, "Heroku"},
+		{"dangling", []string{"app.expired-domain.com"}, true, false, SeverityMedium, ""},
+		{"released bucket", []string{"assets.s3.amazonaws.com"}, false, false, SeverityMedium, "AWS S3"},
+		{"third-party", []string{"shop.myshopify.com", "shops.myshopify.com"}, false, true, SeverityInfo, "Shopify"},
+		{"own infrastructure", []string{"lb.example.com"}, false, true, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			risk := AssessCNAMEChain("www.example.com", tt.chain, tt.nxdomain, tt.resolved)
+			if tt.severity == "" {
+				if risk != nil {
+					t.Errorf("AssessCNAMEChain() = %+v, want no risk", risk)
+				}
+				return
+			}
+			if risk == nil || risk.Severity != tt.severity || risk.Service != tt.service {
+				t.Errorf("AssessCNAMEChain() = %+v, want severity %s and service %q", risk, tt.severity, tt.service)
+			}
+		})
+	}
+}
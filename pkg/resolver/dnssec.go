@@ -0,0 +1,537 @@
+// pkg/resolver/dnssec.go
+package resolver
+
+import (
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/siem"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DefaultDNSSECServer is the resolver DNSSEC is checked with when no DNS
+// server is configured. It must validate, setting the AD bit of the
+// answers it could authenticate, which the system resolver may not do.
+const DefaultDNSSECServer = "1.1.1.1:53"
+
+// Resource record types of DNSSEC, which dnsmessage has no constants for
+const (
+	typeDS     dnsmessage.Type = 43
+	typeRRSIG  dnsmessage.Type = 46
+	typeDNSKEY dnsmessage.Type = 48
+)
+
+// Severity levels of DNSSEC problems
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// DNSSEC statuses of a zone, as RFC 4035 names them
+const (
+	StatusSecure        = "secure"        // The resolver authenticated the answers
+	StatusInsecure      = "insecure"      // The zone is not signed, or not anchored at its parent
+	StatusBogus         = "bogus"         // The signatures do not validate
+	StatusIndeterminate = "indeterminate" // The resolver does not validate
+)
+
+// algorithms are the names of the DNSSEC signing algorithms and whether
+// RFC 8624 still recommends signing with them
+var algorithms = map[uint8]struct {
+	Name string
+	Weak bool
+}{
+	1:  {"RSAMD5", true},
+	3:  {"DSA", true},
+	5:  {"RSASHA1", true},
+	6:  {"DSA-NSEC3-SHA1", true},
+	7:  {"RSASHA1-NSEC3-SHA1", true},
+	8:  {"RSASHA256", false},
+	10: {"RSASHA512", false},
+	12: {"ECC-GOST", true},
+	13: {"ECDSAP256SHA256", false},
+	14: {"ECDSAP384SHA384", false},
+	15: {"ED25519", false},
+	16: {"ED448", false},
+}
+
+// digestTypes are the names of the DS digest algorithms
+var digestTypes = map[uint8]string{1: "SHA-1", 2: "SHA-256", 3: "GOST", 4: "SHA-384"}
+
+// minRSABits is the smallest RSA key size considered strong enough
+const minRSABits = 2048
+
+// algorithmName returns the name of a signing algorithm
+func algorithmName(algorithm uint8) string {
+	if known, ok := algorithms[algorithm]; ok {
+		return known.Name
+	}
+	return fmt.Sprintf("algorithm %d", algorithm)
+}
+
+// DSRecord is a delegation signer record the parent zone publishes
+type DSRecord struct {
+	KeyTag     uint16 `json:"key_tag"`
+	Algorithm  string `json:"algorithm"`
+	DigestType string `json:"digest_type"`
+
+	algorithm  uint8
+	digestType uint8
+}
+
+// DNSKEYRecord is a public key of the zone
+type DNSKEYRecord struct {
+	KeyTag    uint16 `json:"key_tag"`
+	Flags     uint16 `json:"flags"`
+	SEP       bool   `json:"sep"` // Key signing key, which the DS records point to
+	Algorithm string `json:"algorithm"`
+	Bits      int    `json:"bits,omitempty"` // Modulus size of RSA keys
+
+	algorithm uint8
+}
+
+// DNSSECProblem is an issue found with the DNSSEC setup of a zone
+type DNSSECProblem struct {
+	Check       string `json:"check"` // unsigned, chain, signature or algorithm
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+// DNSSECResult is the DNSSEC check of one domain
+type DNSSECResult struct {
+	Domain    string          `json:"domain"`
+	Server    string          `json:"server"`
+	Status    string          `json:"status"`
+	DS        []DSRecord      `json:"ds,omitempty"`
+	DNSKEYs   []DNSKEYRecord  `json:"dnskeys,omitempty"`
+	Validated bool            `json:"validated"` // The resolver set the AD bit
+	Problems  []DNSSECProblem `json:"problems,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// dnssecServer returns the resolver DNSSEC is checked with
+func (r *HostResolver) dnssecServer() string {
+	if len(r.DNSServers) == 0 {
+		return DefaultDNSSECServer
+	}
+	server := strings.TrimSpace(r.DNSServers[0])
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return server
+}
+
+// CheckDNSSEC checks the DNSSEC setup of a domain: the DS records of its
+// parent, its DNSKEY records and their signatures, whether the resolver
+// validates its answers and the strength of the algorithms. Unsigned zones
+// and broken chains of trust are reported as findings.
+func (r *HostResolver) CheckDNSSEC(ctx context.Context, domain string) DNSSECResult {
+	domain = strings.ToLower(strings.Trim(strings.TrimSpace(domain), "."))
+	result := DNSSECResult{Domain: domain, Server: r.dnssecServer()}
+	if err := r.checkDNSSEC(ctx, &result); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	for _, problem := range result.Problems {
+		siem.Emit(siem.Finding{
+			Tool:        "resolver",
+			Target:      domain,
+			Category:    "DNSSEC",
+			Name:        fmt.Sprintf("DNSSEC %s problem on %s", problem.Check, domain),
+			Severity:    model.Severity(problem.Severity),
+			Description: problem.Description,
+		})
+	}
+	return result
+}
+
+// checkDNSSEC fills in the DNSSEC check of a domain
+func (r *HostResolver) checkDNSSEC(ctx context.Context, result *DNSSECResult) error {
+	addProblem := func(check, severity, format string, args ...interface{}) {
+		result.Problems = append(result.Problems, DNSSECProblem{Check: check, Severity: severity, Description: fmt.Sprintf(format, args...)})
+	}
+
+	// The DS records are answered by the parent zone, and only validate
+	// when the DNSKEY records are readable, so both are queried with
+	// checking disabled and validation is tested separately
+	dsAnswer, err := r.exchange(ctx, result.Server, result.Domain, typeDS, true)
+	if err != nil {
+		return fmt.Errorf("DS query failed: %v", err)
+	}
+	for _, rr := range dsAnswer.Answers {
+		if ds, ok := parseDS(rr); ok {
+			result.DS = append(result.DS, ds)
+		}
+	}
+
+	keyAnswer, err := r.exchange(ctx, result.Server, result.Domain, typeDNSKEY, true)
+	if err != nil {
+		return fmt.Errorf("DNSKEY query failed: %v", err)
+	}
+	var signatures []rrsig
+	for _, rr := range keyAnswer.Answers {
+		if key, ok := parseDNSKEY(rr); ok {
+			result.DNSKEYs = append(result.DNSKEYs, key)
+		} else if sig, ok := parseRRSIG(rr); ok && sig.typeCovered == typeDNSKEY {
+			signatures = append(signatures, sig)
+		}
+	}
+
+	switch {
+	case len(result.DS) == 0 && len(result.DNSKEYs) == 0:
+		result.Status = StatusInsecure
+		addProblem("unsigned", SeverityLow, "The zone %s is not signed with DNSSEC; its records can be spoofed by an on-path attacker or a poisoned resolver cache", result.Domain)
+		return nil
+	case len(result.DS) == 0:
+		result.Status = StatusInsecure
+		addProblem("chain", SeverityMedium, "The zone %s publishes DNSKEY records but its parent has no DS record for it, so resolvers cannot authenticate its signatures", result.Domain)
+	case len(result.DNSKEYs) == 0:
+		result.Status = StatusBogus
+		addProblem("chain", SeverityHigh, "The parent of %s publishes DS records but the zone serves no DNSKEY record; validating resolvers fail to resolve it", result.Domain)
+		return nil
+	}
+
+	if len(result.DS) > 0 {
+		matched := false
+		for _, ds := range result.DS {
+			for _, key := range result.DNSKEYs {
+				matched = matched || (ds.KeyTag == key.KeyTag && ds.algorithm == key.algorithm)
+			}
+		}
+		if !matched {
+			result.Status = StatusBogus
+			addProblem("chain", SeverityHigh, "No DNSKEY record of %s matches the key tags of the DS records of its parent (%s); the chain of trust is broken, usually by a key rollover that did not update the parent",
+				result.Domain, dsKeyTags(result.DS))
+		}
+	}
+
+	now := time.Now()
+	if len(signatures) == 0 {
+		addProblem("signature", SeverityHigh, "The DNSKEY records of %s are not signed", result.Domain)
+	}
+	for _, sig := range signatures {
+		if expiration := serialTime(sig.expiration, now); expiration.Before(now) {
+			addProblem("signature", SeverityHigh, "The signature of the DNSKEY records of %s by key %d expired on %s",
+				result.Domain, sig.keyTag, expiration.UTC().Format(time.RFC3339))
+		} else if inception := serialTime(sig.inception, now); inception.After(now) {
+			addProblem("signature", SeverityHigh, "The signature of the DNSKEY records of %s by key %d is not valid before %s",
+				result.Domain, sig.keyTag, inception.UTC().Format(time.RFC3339))
+		}
+	}
+	r.checkAlgorithms(result, addProblem)
+
+	if len(result.DS) > 0 {
+		if err := r.checkValidation(ctx, result, addProblem); err != nil {
+			return err
+		}
+	}
+	if result.Status == "" {
+		result.Status = StatusIndeterminate
+	}
+	return nil
+}
+
+// checkValidation asks the resolver for the SOA record of the zone with
+// checking enabled. A validating resolver sets the AD bit of an answer it
+// authenticated and fails with SERVFAIL on one it could not; the same
+// query with checking disabled tells a bogus zone from a failing server.
+func (r *HostResolver) checkValidation(ctx context.Context, result *DNSSECResult, addProblem func(check, severity, format string, args ...interface{})) error {
+	answer, err := r.exchange(ctx, result.Server, result.Domain, dnsmessage.TypeSOA, false)
+	if err != nil {
+		return fmt.Errorf("SOA query failed: %v", err)
+	}
+
+	switch {
+	case answer.RCode == dnsmessage.RCodeSuccess && answer.AuthenticData:
+		result.Validated = true
+		if result.Status == "" {
+			result.Status = StatusSecure
+		}
+	case answer.RCode == dnsmessage.RCodeServerFailure:
+		unchecked, err := r.exchange(ctx, result.Server, result.Domain, dnsmessage.TypeSOA, true)
+		if err == nil && unchecked.RCode == dnsmessage.RCodeSuccess {
+			result.Status = StatusBogus
+			addProblem("chain", SeverityHigh, "Validation of %s fails at %s: the zone resolves only with checking disabled, so validating resolvers cannot resolve it",
+				result.Domain, result.Server)
+		}
+	default:
+		if result.Status == "" {
+			result.Status = StatusIndeterminate // The resolver does not validate
+		}
+	}
+	return nil
+}
+
+// checkAlgorithms reports deprecated signing and digest algorithms and
+// short RSA keys
+func (r *HostResolver) checkAlgorithms(result *DNSSECResult, addProblem func(check, severity, format string, args ...interface{})) {
+	reported := make(map[string]bool)
+	for _, key := range result.DNSKEYs {
+		if algorithms[key.algorithm].Weak && !reported[key.Algorithm] {
+			reported[key.Algorithm] = true
+			addProblem("algorithm", SeverityMedium, "The zone %s is signed with %s, which RFC 8624 no longer recommends; roll over to ECDSAP256SHA256 or RSASHA256",
+				result.Domain, key.Algorithm)
+		}
+		if key.Bits > 0 && key.Bits < minRSABits {
+			addProblem("algorithm", SeverityMedium, "The %s key %d of %s is only %d bits long; RSA keys should have at least %d bits",
+				key.Algorithm, key.KeyTag, result.Domain, key.Bits, minRSABits)
+		}
+	}
+
+	strongDigest := false
+	for _, ds := range result.DS {
+		strongDigest = strongDigest || ds.digestType != 1
+	}
+	if len(result.DS) > 0 && !strongDigest {
+		addProblem("algorithm", SeverityLow, "The DS records of %s only use SHA-1 digests; publish a SHA-256 DS record at the parent", result.Domain)
+	}
+}
+
+// dsKeyTags lists the key tags of DS records
+func dsKeyTags(records []DSRecord) string {
+	tags := make([]string, 0, len(records))
+	for _, ds := range records {
+		tags = append(tags, fmt.Sprint(ds.KeyTag))
+	}
+	return strings.Join(tags, ", ")
+}
+
+// rrsig is the part of an RRSIG record the checks use
+type rrsig struct {
+	typeCovered dnsmessage.Type
+	algorithm   uint8
+	expiration  uint32
+	inception   uint32
+	keyTag      uint16
+}
+
+// unknownData returns the data of a record of a type dnsmessage does not
+// parse
+func unknownData(rr dnsmessage.Resource, rrType dnsmessage.Type) ([]byte, bool) {
+	body, ok := rr.Body.(*dnsmessage.UnknownResource)
+	if !ok || rr.Header.Type != rrType {
+		return nil, false
+	}
+	return body.Data, true
+}
+
+// parseDS parses a DS record: key tag, algorithm, digest type and digest
+func parseDS(rr dnsmessage.Resource) (DSRecord, bool) {
+	data, ok := unknownData(rr, typeDS)
+	if !ok || len(data) < 4 {
+		return DSRecord{}, false
+	}
+	ds := DSRecord{KeyTag: binary.BigEndian.Uint16(data), algorithm: data[2], digestType: data[3]}
+	ds.Algorithm = algorithmName(ds.algorithm)
+	ds.DigestType = digestTypes[ds.digestType]
+	if ds.DigestType == "" {
+		ds.DigestType = fmt.Sprintf("digest %d", ds.digestType)
+	}
+	return ds, true
+}
+
+// parseDNSKEY parses a DNSKEY record: flags, protocol, algorithm and key
+func parseDNSKEY(rr dnsmessage.Resource) (DNSKEYRecord, bool) {
+	data, ok := unknownData(rr, typeDNSKEY)
+	if !ok || len(data) < 4 {
+		return DNSKEYRecord{}, false
+	}
+	key := DNSKEYRecord{
+		KeyTag:    KeyTag(data),
+		Flags:     binary.BigEndian.Uint16(data),
+		algorithm: data[3],
+	}
+	key.SEP = key.Flags&1 != 0
+	key.Algorithm = algorithmName(key.algorithm)
+	switch key.algorithm {
+	case 1, 5, 7, 8, 10:
+		key.Bits = rsaModulusBits(data[4:])
+	}
+	return key, true
+}
+
+// parseRRSIG parses the fixed fields of an RRSIG record
+func parseRRSIG(rr dnsmessage.Resource) (rrsig, bool) {
+	data, ok := unknownData(rr, typeRRSIG)
+	if !ok || len(data) < 18 {
+		return rrsig{}, false
+	}
+	return rrsig{
+		typeCovered: dnsmessage.Type(binary.BigEndian.Uint16(data)),
+		algorithm:   data[2],
+		expiration:  binary.BigEndian.Uint32(data[8:]),
+		inception:   binary.BigEndian.Uint32(data[12:]),
+		keyTag:      binary.BigEndian.Uint16(data[16:]),
+	}, true
+}
+
+// KeyTag computes the key tag of a DNSKEY record from its data, as in
+// RFC 4034 appendix B
+func KeyTag(data []byte) uint16 {
+	var sum uint32
+	for i, b := range data {
+		if i&1 == 0 {
+			sum += uint32(b) << 8
+		} else {
+			sum += uint32(b)
+		}
+	}
+	sum += sum >> 16 & 0xffff
+	return uint16(sum & 0xffff)
+}
+
+// rsaModulusBits returns the size of the modulus of an RSA public key in
+// the RFC 3110 format: exponent length, exponent and modulus
+func rsaModulusBits(key []byte) int {
+	if len(key) < 1 {
+		return 0
+	}
+	exponentLength, offset := int(key[0]), 1
+	if exponentLength == 0 {
+		if len(key) < 3 {
+			return 0
+		}
+		exponentLength, offset = int(binary.BigEndian.Uint16(key[1:])), 3
+	}
+	modulus := key[min(offset+exponentLength, len(key)):]
+	for len(modulus) > 0 && modulus[0] == 0 {
+		modulus = modulus[1:]
+	}
+	if len(modulus) == 0 {
+		return 0
+	}
+	bits := len(modulus) * 8
+	for b := modulus[0]; b&0x80 == 0; b <<= 1 {
+		bits--
+	}
+	return bits
+}
+
+// serialTime converts a signature time, in seconds modulo 2^32 as RFC 4034
+// defines it, to the time closest to now
+func serialTime(value uint32, now time.Time) time.Time {
+	delta := int64(int32(value - uint32(now.Unix())))
+	return now.Add(time.Duration(delta) * time.Second)
+}
+
+// exchange sends a query with the DNSSEC OK bit to a server over UDP, and
+// again over TCP when the answer is truncated
+func (r *HostResolver) exchange(ctx context.Context, server, name string, qtype dnsmessage.Type, checkingDisabled bool) (*dnsmessage.Message, error) {
+	query, id, err := buildQuery(name, qtype, checkingDisabled)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= r.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(r.RetryDelay)
+		}
+		answer, err := r.exchangeOver(ctx, "udp", server, query, id)
+		if err == nil && answer.Truncated {
+			answer, err = r.exchangeOver(ctx, "tcp", server, query, id)
+		}
+		if err == nil {
+			return answer, nil
+		}
+		if lastErr = err; ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// buildQuery packs a recursive query for a name with an EDNS0 record
+// asking for DNSSEC records
+func buildQuery(name string, qtype dnsmessage.Type, checkingDisabled bool) ([]byte, uint16, error) {
+	qname, err := dnsmessage.NewName(strings.TrimSuffix(name, ".") + ".")
+	if err != nil {
+		return nil, 0, err
+	}
+	var idBytes [2]byte
+	rand.Read(idBytes[:])
+	id := binary.BigEndian.Uint16(idBytes[:])
+
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true, CheckingDisabled: checkingDisabled})
+	builder.EnableCompression()
+	if err := builder.StartQuestions(); err != nil {
+		return nil, 0, err
+	}
+	if err := builder.Question(dnsmessage.Question{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, 0, err
+	}
+	if err := builder.StartAdditionals(); err != nil {
+		return nil, 0, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(4096, dnsmessage.RCodeSuccess, true); err != nil {
+		return nil, 0, err
+	}
+	if err := builder.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, 0, err
+	}
+	query, err := builder.Finish()
+	return query, id, err
+}
+
+// exchangeOver sends a packed query over UDP or TCP and parses the answer
+func (r *HostResolver) exchangeOver(ctx context.Context, network, server string, query []byte, id uint16) (*dnsmessage.Message, error) {
+	dialer := net.Dialer{Timeout: r.Timeout}
+	conn, err := dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(r.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	var response []byte
+	if network == "tcp" {
+		packet := make([]byte, 2+len(query))
+		binary.BigEndian.PutUint16(packet, uint16(len(query)))
+		copy(packet[2:], query)
+		if _, err := conn.Write(packet); err != nil {
+			return nil, err
+		}
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		response = make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, response); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		buffer := make([]byte, 65535)
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return nil, err
+		}
+		response = buffer[:n]
+	}
+
+	var answer dnsmessage.Message
+	if err := answer.Unpack(response); err != nil {
+		return nil, fmt.Errorf("invalid answer: %v", err)
+	}
+	if answer.ID != id {
+		return nil, errors.New("answer to another query")
+	}
+	return &answer, nil
+}
@@ -0,0 +1,214 @@
+package resolver
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeZone is what the fake DNS server answers for a zone
+type fakeZone struct {
+	ds       [][]byte
+	dnskeys  [][]byte
+	rrsigs   [][]byte
+	validate bool // Set the AD bit of checked SOA answers
+	bogus    bool // Fail checked SOA queries with SERVFAIL
+}
+
+// serveDNS answers the queries of a fake DNS server from a zone
+func serveDNS(t *testing.T, zone fakeZone) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buffer := make([]byte, 4096)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if query.Unpack(buffer[:n]) != nil || len(query.Questions) != 1 {
+				continue
+			}
+			question := query.Questions[0]
+			header := dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true}
+			var records [][]byte
+			var rrType dnsmessage.Type
+			switch question.Type {
+			case typeDS:
+				records, rrType = zone.ds, typeDS
+			case typeDNSKEY:
+				records, rrType = zone.dnskeys, typeDNSKEY
+			case dnsmessage.TypeSOA:
+				if !query.CheckingDisabled && zone.bogus {
+					header.RCode = dnsmessage.RCodeServerFailure
+				}
+				header.AuthenticData = !query.CheckingDisabled && zone.validate
+			}
+
+			builder := dnsmessage.NewBuilder(nil, header)
+			builder.StartQuestions()
+			builder.Question(question)
+			builder.StartAnswers()
+			rrHeader := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 300}
+			for _, data := range records {
+				builder.UnknownResource(rrHeader, dnsmessage.UnknownResource{Type: rrType, Data: data})
+			}
+			if rrType == typeDNSKEY {
+				for _, data := range zone.rrsigs {
+					builder.UnknownResource(rrHeader, dnsmessage.UnknownResource{Type: typeRRSIG, Data: data})
+				}
+			}
+			response, err := builder.Finish()
+			if err == nil {
+				conn.WriteTo(response, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// dnskeyData builds the data of a DNSKEY record
+func dnskeyData(flags uint16, algorithm uint8, key []byte) []byte {
+	data := make([]byte, 4, 4+len(key))
+	binary.BigEndian.PutUint16(data, flags)
+	data[2], data[3] = 3, algorithm
+	return append(data, key...)
+}
+
+// rsaKey builds an RSA public key with a modulus of the given size
+func rsaKey(modulusBytes int) []byte {
+	modulus := make([]byte, modulusBytes)
+	modulus[0] = 0xc1
+	return append([]byte{3, 1, 0, 1}, modulus...) // Exponent 65537
+}
+
+// dsData builds the data of a DS record
+func dsData(keyTag uint16, algorithm, digestType uint8) []byte {
+	data := make([]byte, 4, 4+32)
+	binary.BigEndian.PutUint16(data, keyTag)
+	data[2], data[3] = algorithm, digestType
+	return append(data, make([]byte, 32)...)
+}
+
+// rrsigData builds the data of an RRSIG record over DNSKEY records
+func rrsigData(keyTag uint16, inception, expiration time.Time) []byte {
+	data := make([]byte, 18)
+	binary.BigEndian.PutUint16(data, uint16(typeDNSKEY))
+	data[2] = 8
+	binary.BigEndian.PutUint32(data[8:], uint32(expiration.Unix()))
+	binary.BigEndian.PutUint32(data[12:], uint32(inception.Unix()))
+	binary.BigEndian.PutUint16(data[16:], keyTag)
+	return append(data, 0) // Root signer name
+}
+
+// testResolver returns a resolver asking a fake server
+func testResolver(server string) *HostResolver {
+	return NewHostResolver().WithDNSServers([]string{server}).WithTimeout(time.Second).WithRetries(0)
+}
+
+// problemChecks returns the checks of the problems of a result
+func problemChecks(result DNSSECResult) map[string]string {
+	checks := make(map[string]string)
+	for _, problem := range result.Problems {
+		checks[problem.Check] = problem.Severity
+	}
+	return checks
+}
+
+func TestCheckDNSSECSecure(t *testing.T) {
+	ksk := dnskeyData(257, 13, make([]byte, 64))
+	tag := KeyTag(ksk)
+	now := time.Now()
+	server := serveDNS(t, fakeZone{
+		ds:       [][]byte{dsData(tag, 13, 2)},
+		dnskeys:  [][]byte{ksk},
+		rrsigs:   [][]byte{rrsigData(tag, now.Add(-time.Hour), now.Add(7*24*time.Hour))},
+		validate: true,
+	})
+
+	result := testResolver(server).CheckDNSSEC(context.Background(), "Example.com.")
+	if result.Error != "" {
+		t.Fatalf("CheckDNSSEC() error = %s", result.Error)
+	}
+	if result.Domain != "example.com" || result.Status != StatusSecure || !result.Validated {
+		t.Errorf("CheckDNSSEC() = %s, %s, validated %t, want example.com, secure, validated", result.Domain, result.Status, result.Validated)
+	}
+	if len(result.Problems) != 0 {
+		t.Errorf("Problems = %v, want none", result.Problems)
+	}
+}
+
+func TestCheckDNSSECUnsigned(t *testing.T) {
+	server := serveDNS(t, fakeZone{})
+
+	result := testResolver(server).CheckDNSSEC(context.Background(), "example.com")
+	if result.Status != StatusInsecure {
+		t.Errorf("Status = %s, want %s", result.Status, StatusInsecure)
+	}
+	if checks := problemChecks(result); checks["unsigned"] != SeverityLow {
+		t.Errorf("Problems = %v, want a low unsigned problem", result.Problems)
+	}
+}
+
+func TestCheckDNSSECBrokenChain(t *testing.T) {
+	ksk := dnskeyData(257, 13, make([]byte, 64))
+	tag := KeyTag(ksk)
+	now := time.Now()
+	server := serveDNS(t, fakeZone{
+		ds:      [][]byte{dsData(tag+1, 13, 2)}, // Left over from a rollover
+		dnskeys: [][]byte{ksk},
+		rrsigs:  [][]byte{rrsigData(tag, now.Add(-48*time.Hour), now.Add(-time.Hour))},
+		bogus:   true,
+	})
+
+	result := testResolver(server).CheckDNSSEC(context.Background(), "example.com")
+	if result.Status != StatusBogus {
+		t.Errorf("Status = %s, want %s", result.Status, StatusBogus)
+	}
+	checks := problemChecks(result)
+	if checks["chain"] != SeverityHigh || checks["signature"] != SeverityHigh {
+		t.Errorf("Problems = %v, want high chain and signature problems", result.Problems)
+	}
+}
+
+func TestCheckDNSSECWeakAlgorithms(t *testing.T) {
+	ksk := dnskeyData(257, 5, rsaKey(128)) // RSASHA1 with a 1024-bit key
+	tag := KeyTag(ksk)
+	now := time.Now()
+	server := serveDNS(t, fakeZone{
+		ds:       [][]byte{dsData(tag, 5, 1)},
+		dnskeys:  [][]byte{ksk},
+		rrsigs:   [][]byte{rrsigData(tag, now.Add(-time.Hour), now.Add(time.Hour))},
+		validate: true,
+	})
+
+	result := testResolver(server).CheckDNSSEC(context.Background(), "example.com")
+	if len(result.DNSKEYs) != 1 || result.DNSKEYs[0].Bits != 1024 || !result.DNSKEYs[0].SEP {
+		t.Fatalf("DNSKEYs = %+v, want one 1024-bit key signing key", result.DNSKEYs)
+	}
+	algorithmProblems := 0
+	for _, problem := range result.Problems {
+		if problem.Check == "algorithm" {
+			algorithmProblems++
+		}
+	}
+	if algorithmProblems != 3 {
+		t.Errorf("Problems = %v, want algorithm, key size and digest problems", result.Problems)
+	}
+}
+
+func TestSerialTime(t *testing.T) {
+	now := time.Unix(1<<32+1000, 0) // After the 32-bit wrap in 2106
+	if got := serialTime(2000, now); !got.Equal(time.Unix(1<<32+2000, 0)) {
+		t.Errorf("serialTime() = %d, want %d", got.Unix(), int64(1<<32+2000))
+	}
+}
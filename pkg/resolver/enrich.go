@@ -0,0 +1,227 @@
+// pkg/resolver/enrich.go
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Zones of the Team Cymru IP to ASN mapping service, answered over DNS
+const (
+	cymruOrigin  = "origin.asn.cymru.com"
+	cymruOrigin6 = "origin6.asn.cymru.com"
+	cymruASN     = "asn.cymru.com"
+)
+
+// IPInfo tells where an address is hosted: the autonomous system announcing
+// it, the organization running that system and the country the prefix is
+// registered in
+type IPInfo struct {
+	IP           string `json:"ip"`
+	ASN          int    `json:"asn,omitempty"`
+	Prefix       string `json:"prefix,omitempty"`
+	Country      string `json:"country,omitempty"`
+	Registry     string `json:"registry,omitempty"`
+	Organization string `json:"organization,omitempty"`
+}
+
+// Provider returns the name the address is grouped by: its organization,
+// else its ASN, else "unknown"
+func (i IPInfo) Provider() string {
+	switch {
+	case i.Organization != "":
+		return i.Organization
+	case i.ASN != 0:
+		return fmt.Sprintf("AS%d", i.ASN)
+	}
+	return "unknown"
+}
+
+// cymruOriginName returns the name whose TXT record maps an address to the
+// ASN announcing it: its reversed octets, or reversed nibbles for IPv6,
+// under the origin zone
+func cymruOriginName(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.%s", ip4[3], ip4[2], ip4[1], ip4[0], cymruOrigin)
+	}
+	ip16 := ip.To16()
+	if ip16 == nil {
+		return ""
+	}
+	var b strings.Builder
+	for i := len(ip16) - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "%x.%x.", ip16[i]&0x0f, ip16[i]>>4)
+	}
+	return b.String() + cymruOrigin6
+}
+
+// cymruFields splits a Team Cymru TXT record into its fields
+func cymruFields(record string) []string {
+	fields := strings.Split(record, "|")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// parseCymruOrigin parses an origin record into info, such as
+// "13335 | 104.16.0.0/13 | US | arin | 2014-03-28". Of the ASNs of a prefix
+// announced by several systems, the first is kept.
+func parseCymruOrigin(record string, info *IPInfo) bool {
+	fields := cymruFields(record)
+	if len(fields) < 4 {
+		return false
+	}
+	asns := strings.Fields(fields[0])
+	if len(asns) == 0 {
+		return false
+	}
+	asn, err := strconv.Atoi(asns[0])
+	if err != nil {
+		return false
+	}
+	info.ASN = asn
+	info.Prefix = fields[1]
+	info.Country = strings.ToUpper(fields[2])
+	info.Registry = fields[3]
+	return true
+}
+
+// parseCymruASN returns the organization of an ASN record, such as
+// "13335 | US | arin | 2010-07-14 | CLOUDFLARENET, US", without its
+// country suffix
+func parseCymruASN(record string) string {
+	fields := cymruFields(record)
+	if len(fields) < 5 {
+		return ""
+	}
+	name := fields[4]
+	if i := strings.LastIndex(name, ", "); i > 0 && len(name)-i == 4 {
+		name = name[:i]
+	}
+	return name
+}
+
+// LookupIPInfo returns where an address is hosted, from the Team Cymru IP
+// to ASN mapping. Results are cached, as are the organizations of ASNs,
+// which many addresses share.
+func (r *HostResolver) LookupIPInfo(ctx context.Context, address string) (IPInfo, error) {
+	info := IPInfo{IP: address}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return info, fmt.Errorf("invalid IP address %q", address)
+	}
+
+	r.cacheLock.RLock()
+	cached, found := r.ipInfo[address]
+	r.cacheLock.RUnlock()
+	if found {
+		return cached, nil
+	}
+
+	resolver := r.netResolver()
+	records, err := resolver.LookupTXT(ctx, cymruOriginName(ip))
+	if err != nil {
+		return info, fmt.Errorf("ASN lookup of %s failed: %v", address, err)
+	}
+	for _, record := range records {
+		if parseCymruOrigin(record, &info) {
+			break
+		}
+	}
+
+	if info.ASN != 0 {
+		r.cacheLock.RLock()
+		organization, found := r.asnNames[info.ASN]
+		r.cacheLock.RUnlock()
+		if !found {
+			if records, err := resolver.LookupTXT(ctx, fmt.Sprintf("AS%d.%s", info.ASN, cymruASN)); err == nil && len(records) > 0 {
+				organization = parseCymruASN(records[0])
+			}
+			r.cacheLock.Lock()
+			r.asnNames[info.ASN] = organization
+			r.cacheLock.Unlock()
+		}
+		info.Organization = organization
+	}
+
+	r.cacheLock.Lock()
+	r.ipInfo[address] = info
+	r.cacheLock.Unlock()
+	return info, nil
+}
+
+// enrichResult looks up where each address of a result is hosted. Addresses
+// whose lookup fails are listed without hosting details.
+func (r *HostResolver) enrichResult(ctx context.Context, result *ResolveResult) {
+	for _, address := range append(append([]string{}, result.IPv4...), result.IPv6...) {
+		info, _ := r.LookupIPInfo(ctx, address)
+		result.Hosting = append(result.Hosting, info)
+	}
+}
+
+// ProviderSummary counts the hosts and addresses of resolution results
+// hosted by one provider
+type ProviderSummary struct {
+	Provider  string   `json:"provider"`
+	ASNs      []int    `json:"asns,omitempty"`
+	Countries []string `json:"countries,omitempty"`
+	Hosts     int      `json:"hosts"`
+	IPs       int      `json:"ips"`
+}
+
+// SummarizeProviders groups the addresses of resolution results by the
+// provider hosting them, most hosts first. A host with addresses at two
+// providers counts for both.
+func SummarizeProviders(results []ResolveResult) []ProviderSummary {
+	type group struct {
+		summary   ProviderSummary
+		asns      map[int]bool
+		countries map[string]bool
+		ips       map[string]bool
+	}
+	groups := make(map[string]*group)
+	for _, result := range results {
+		counted := make(map[string]bool)
+		for _, info := range result.Hosting {
+			provider := info.Provider()
+			g := groups[provider]
+			if g == nil {
+				g = &group{summary: ProviderSummary{Provider: provider}, asns: map[int]bool{}, countries: map[string]bool{}, ips: map[string]bool{}}
+				groups[provider] = g
+			}
+			if !counted[provider] {
+				counted[provider] = true
+				g.summary.Hosts++
+			}
+			if info.ASN != 0 && !g.asns[info.ASN] {
+				g.asns[info.ASN] = true
+				g.summary.ASNs = append(g.summary.ASNs, info.ASN)
+			}
+			if info.Country != "" && !g.countries[info.Country] {
+				g.countries[info.Country] = true
+				g.summary.Countries = append(g.summary.Countries, info.Country)
+			}
+			g.ips[info.IP] = true
+		}
+	}
+
+	summaries := make([]ProviderSummary, 0, len(groups))
+	for _, g := range groups {
+		g.summary.IPs = len(g.ips)
+		sort.Ints(g.summary.ASNs)
+		sort.Strings(g.summary.Countries)
+		summaries = append(summaries, g.summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Hosts != summaries[j].Hosts {
+			return summaries[i].Hosts > summaries[j].Hosts
+		}
+		return summaries[i].Provider < summaries[j].Provider
+	})
+	return summaries
+}
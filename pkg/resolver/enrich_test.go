@@ -0,0 +1,122 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveTXT answers TXT queries of a fake DNS server from a map of records
+// and counts the queries
+func serveTXT(t *testing.T, records map[string]string, queries *int32) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buffer := make([]byte, 4096)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if query.Unpack(buffer[:n]) != nil || len(query.Questions) != 1 {
+				continue
+			}
+			atomic.AddInt32(queries, 1)
+			question := query.Questions[0]
+			header := dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true}
+			record, found := records[strings.TrimSuffix(question.Name.String(), ".")]
+			if !found {
+				header.RCode = dnsmessage.RCodeNameError
+			}
+
+			builder := dnsmessage.NewBuilder(nil, header)
+			builder.StartQuestions()
+			builder.Question(question)
+			builder.StartAnswers()
+			if found && question.Type == dnsmessage.TypeTXT {
+				builder.TXTResource(dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 300},
+					dnsmessage.TXTResource{TXT: []string{record}})
+			}
+			if response, err := builder.Finish(); err == nil {
+				conn.WriteTo(response, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestCymruOriginName(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"104.16.132.229", "229.132.16.104.origin.asn.cymru.com"},
+		{"2606:4700::1", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.7.4.6.0.6.2.origin6.asn.cymru.com"},
+	}
+	for _, tt := range tests {
+		if got := cymruOriginName(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("cymruOriginName(%s) = %s, want %s", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestLookupIPInfo(t *testing.T) {
+	var queries int32
+	server := serveTXT(t, map[string]string{
+		"229.132.16.104.origin.asn.cymru.com": "13335 209242 | 104.16.0.0/13 | US | arin | 2014-03-28",
+		"1.132.16.104.origin.asn.cymru.com":   "13335 | 104.16.0.0/13 | US | arin | 2014-03-28",
+		"AS13335.asn.cymru.com":               "13335 | US | arin | 2010-07-14 | CLOUDFLARENET, US",
+	}, &queries)
+	resolver := testResolver(server)
+
+	info, err := resolver.LookupIPInfo(context.Background(), "104.16.132.229")
+	if err != nil {
+		t.Fatalf("LookupIPInfo() error = %v", err)
+	}
+	want := IPInfo{IP: "104.16.132.229", ASN: 13335, Prefix: "104.16.0.0/13", Country: "US", Registry: "arin", Organization: "CLOUDFLARENET"}
+	if info != want {
+		t.Errorf("LookupIPInfo() = %+v, want %+v", info, want)
+	}
+
+	// A second address of the same ASN reuses its organization
+	before := atomic.LoadInt32(&queries)
+	if info, _ := resolver.LookupIPInfo(context.Background(), "104.16.132.1"); info.Organization != "CLOUDFLARENET" {
+		t.Errorf("Organization = %q, want CLOUDFLARENET", info.Organization)
+	}
+	if atomic.LoadInt32(&queries)-before != 1 {
+		t.Errorf("queries = %d, want only the origin query", atomic.LoadInt32(&queries)-before)
+	}
+
+	if _, err := resolver.LookupIPInfo(context.Background(), "not-an-ip"); err == nil {
+		t.Error("LookupIPInfo() accepted an invalid address")
+	}
+}
+
+func TestSummarizeProviders(t *testing.T) {
+	cloudflare := func(ip string) IPInfo {
+		return IPInfo{IP: ip, ASN: 13335, Country: "US", Organization: "CLOUDFLARENET"}
+	}
+	results := []ResolveResult{
+		{Hostname: "www.example.com", Hosting: []IPInfo{cloudflare("104.16.0.1"), cloudflare("104.16.0.2")}},
+		{Hostname: "api.example.com", Hosting: []IPInfo{cloudflare("104.16.0.1"), {IP: "3.5.0.1", ASN: 16509, Country: "DE", Organization: "AMAZON-02"}}},
+		{Hostname: "old.example.com"},
+	}
+
+	want := []ProviderSummary{
+		{Provider: "CLOUDFLARENET", ASNs: []int{13335}, Countries: []string{"US"}, Hosts: 2, IPs: 2},
+		{Provider: "AMAZON-02", ASNs: []int{16509}, Countries: []string{"DE"}, Hosts: 1, IPs: 1},
+	}
+	if got := SummarizeProviders(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("SummarizeProviders() = %+v, want %+v", got, want)
+	}
+}
@@ -2,6 +2,7 @@
 package resolver
 
 import (
+	"GopherStrike/pkg/timing"
 	"context"
 	"fmt"
 	"net"
@@ -17,6 +18,11 @@ type ResolveResult struct {
 	IPv6     []string `json:"ipv6,omitempty"`
 	Error    string   `json:"error,omitempty"`
 	Resolved bool     `json:"resolved"`
+	// Names the hostname is an alias of, from its CNAME to its canonical name
+	CNAMEChain []string   `json:"cname_chain,omitempty"`
+	CNAMERisk  *CNAMERisk `json:"cname_risk,omitempty"`
+	// Where each address is hosted
+	Hosting []IPInfo `json:"hosting,omitempty"`
 }
 
 // HostResolver provides methods for resolving hostnames and discovering subdomains
@@ -33,18 +39,30 @@ type HostResolver struct {
 	IPv4Only bool
 	// Whether to resolve only IPv6 addresses
 	IPv6Only bool
+	// Whether to record the CNAME chain of each hostname and flag dangling ones
+	FollowCNAMEs bool
+	// Whether to look up the ASN, organization and country of each address
+	EnrichIPs bool
 	// Cache resolved entries to avoid repeated queries
 	cache     map[string]ResolveResult
+	ipInfo    map[string]IPInfo
+	asnNames  map[int]string
 	cacheLock sync.RWMutex
 }
 
-// NewHostResolver creates a new host resolver with default settings
+// NewHostResolver creates a new host resolver with default settings,
+// bounded by the timing profile
 func NewHostResolver() *HostResolver {
+	profile := timing.Current()
 	return &HostResolver{
-		Timeout:    5 * time.Second,
-		MaxRetries: 2,
-		RetryDelay: 500 * time.Millisecond,
-		cache:      make(map[string]ResolveResult),
+		Timeout:      profile.TimeoutFor(5 * time.Second),
+		MaxRetries:   profile.RetriesFor(2),
+		RetryDelay:   profile.DelayFor(500 * time.Millisecond),
+		FollowCNAMEs: true,
+		EnrichIPs:    true,
+		cache:        make(map[string]ResolveResult),
+		ipInfo:       make(map[string]IPInfo),
+		asnNames:     make(map[int]string),
 	}
 }
 
@@ -80,11 +98,41 @@ func (r *HostResolver) WithIPv6Only(ipv6Only bool) *HostResolver {
 	return r
 }
 
+// WithCNAMEs configures whether the CNAME chains of hostnames are followed
+func (r *HostResolver) WithCNAMEs(follow bool) *HostResolver {
+	r.FollowCNAMEs = follow
+	return r
+}
+
+// WithIPInfo configures whether the hosting of resolved addresses is looked up
+func (r *HostResolver) WithIPInfo(enrich bool) *HostResolver {
+	r.EnrichIPs = enrich
+	return r
+}
+
 // ClearCache clears the resolution cache
 func (r *HostResolver) ClearCache() {
 	r.cacheLock.Lock()
 	defer r.cacheLock.Unlock()
 	r.cache = make(map[string]ResolveResult)
+	r.ipInfo = make(map[string]IPInfo)
+	r.asnNames = make(map[int]string)
+}
+
+// netResolver returns the resolver of the configured DNS server, or the
+// system resolver
+func (r *HostResolver) netResolver() *net.Resolver {
+	if len(r.DNSServers) == 0 {
+		return &net.Resolver{}
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			// Use the first DNS server in the list
+			d := net.Dialer{Timeout: r.Timeout}
+			return d.DialContext(ctx, "udp", r.DNSServers[0])
+		},
+	}
 }
 
 // ResolveHost resolves a hostname to IP addresses
@@ -108,19 +156,7 @@ func (r *HostResolver) ResolveHost(hostname string) (ResolveResult, error) {
 	}
 
 	// Create custom resolver if DNS servers are specified
-	var resolver *net.Resolver
-	if len(r.DNSServers) > 0 {
-		resolver = &net.Resolver{
-			PreferGo: true,
-			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-				// Use the first DNS server in the list
-				d := net.Dialer{Timeout: r.Timeout}
-				return d.DialContext(ctx, "udp", r.DNSServers[0])
-			},
-		}
-	} else {
-		resolver = &net.Resolver{}
-	}
+	resolver := r.netResolver()
 
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), r.Timeout)
@@ -154,6 +190,21 @@ func (r *HostResolver) ResolveHost(hostname string) (ResolveResult, error) {
 	// Consider resolved if we found any IP addresses
 	result.Resolved = len(result.IPv4) > 0 || len(result.IPv6) > 0
 
+	// Follow the CNAME chain, which matters most when it leads nowhere
+	if r.FollowCNAMEs {
+		cnameCtx, cnameCancel := context.WithTimeout(context.Background(), r.Timeout*maxCNAMEHops)
+		r.recordCNAMEChain(cnameCtx, &result)
+		cnameCancel()
+	}
+
+	// Look up where the addresses are hosted
+	if r.EnrichIPs && result.Resolved {
+		addresses := len(result.IPv4) + len(result.IPv6)
+		enrichCtx, enrichCancel := context.WithTimeout(context.Background(), time.Duration(addresses+1)*r.Timeout)
+		r.enrichResult(enrichCtx, &result)
+		enrichCancel()
+	}
+
 	// Store in cache
 	r.cacheLock.Lock()
 	r.cache[hostname] = result
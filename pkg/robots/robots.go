@@ -0,0 +1,319 @@
+// Package robots makes the crawling tools follow the crawl policies of the
+// sites they visit: robots.txt rules (RFC 9309), crawl delays and a budget
+// of requests per host. Tools check each URL with a Guard before fetching
+// it. The policies can be ignored with --ignore-robots or the
+// scanning.ignore_robots setting, for authorized tests of a site's content.
+package robots
+
+import (
+	"GopherStrike/pkg/config"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProductToken is the name the tools go by in robots.txt user-agent lines
+const ProductToken = "GopherStrike"
+
+// MaxCrawlDelay caps the crawl delays honored, so that a site asking for
+// hours between requests is visited slowly rather than never
+const MaxCrawlDelay = 30 * time.Second
+
+var (
+	// ErrDisallowed is returned for URLs the robots.txt rules of their host disallow
+	ErrDisallowed = errors.New("disallowed by robots.txt")
+	// ErrBudgetExhausted is returned once the requests allowed per host are used
+	ErrBudgetExhausted = errors.New("request budget of the host exhausted")
+)
+
+// rule is an allow or disallow rule of a robots.txt group
+type rule struct {
+	allow   bool
+	length  int // Length of the path pattern, the longest matching rule wins
+	pattern *regexp.Regexp
+}
+
+// Rules are the robots.txt rules applying to the tools on one host
+type Rules struct {
+	rules      []rule
+	CrawlDelay time.Duration
+}
+
+// group is a group of robots.txt lines for some user agents
+type group struct {
+	agents     []string
+	rules      []rule
+	crawlDelay time.Duration
+}
+
+// compileRule turns a path pattern, where * matches any characters and a
+// final $ anchors the end of the path, into a regular expression
+func compileRule(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(normalizePath(part))
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// normalizePath percent-encodes the path of a pattern the way URL paths
+// are compared, so that /caf%C3%A9 and /café match each other. The query is
+// kept as it is.
+func normalizePath(pattern string) string {
+	path, query, hasQuery := strings.Cut(pattern, "?")
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	path = (&url.URL{Path: path}).EscapedPath()
+	if hasQuery {
+		return path + "?" + query
+	}
+	return path
+}
+
+// Parse reads a robots.txt file and returns the rules of the groups naming
+// the product token, or of the * groups if none does
+func Parse(r io.Reader, productToken string) *Rules {
+	groups := []*group{}
+	var current *group
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil || (value == "" && key == "disallow") {
+				continue // An empty disallow allows everything
+			}
+			current.rules = append(current.rules, rule{allow: key == "allow", length: len(value), pattern: compileRule(value)})
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	token := strings.ToLower(productToken)
+	rules := &Rules{}
+	for _, wildcard := range []bool{false, true} {
+		matched := false
+		for _, g := range groups {
+			for _, agent := range g.agents {
+				if (wildcard && agent == "*") || (!wildcard && agent == token) {
+					matched = true
+					rules.rules = append(rules.rules, g.rules...)
+					if g.crawlDelay > rules.CrawlDelay {
+						rules.CrawlDelay = g.crawlDelay
+					}
+					break
+				}
+			}
+		}
+		// Only the most specific groups apply
+		if matched {
+			break
+		}
+	}
+	return rules
+}
+
+// DisallowAll returns rules disallowing every path, assumed when a site's
+// robots.txt cannot be read because of a server error
+func DisallowAll() *Rules {
+	return &Rules{rules: []rule{{allow: false, length: 1, pattern: regexp.MustCompile("^/")}}}
+}
+
+// Allowed reports whether the rules allow fetching a path, with its query.
+// The longest matching rule decides, allow winning ties; robots.txt itself
+// is always allowed.
+func (r *Rules) Allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+	matched := -1
+	allowed := true
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > matched || (rule.length == matched && rule.allow) {
+			matched = rule.length
+			allowed = rule.allow
+		}
+	}
+	return allowed
+}
+
+// Options controls how the crawl policies of sites are followed
+type Options struct {
+	Ignore             bool // Neither read robots.txt nor wait for crawl delays
+	MaxRequestsPerHost int  // Requests allowed per host, 0 for no limit
+}
+
+var (
+	defaults    *Options
+	defaultsMux sync.Mutex
+)
+
+// Defaults returns the options of the scanning.ignore_robots and
+// scanning.max_requests_per_host settings, unless SetDefaults replaced them
+func Defaults() Options {
+	defaultsMux.Lock()
+	defer defaultsMux.Unlock()
+	if defaults != nil {
+		return *defaults
+	}
+	scanning := config.Get().Scanning
+	return Options{Ignore: scanning.IgnoreRobots, MaxRequestsPerHost: scanning.MaxRequestsPerHost}
+}
+
+// SetDefaults replaces the default options, for command-line overrides
+func SetDefaults(options Options) {
+	defaultsMux.Lock()
+	defer defaultsMux.Unlock()
+	defaults = &options
+}
+
+// host is the crawl state of one host
+type host struct {
+	once     sync.Once
+	rules    *Rules
+	requests int
+	next     time.Time // Earliest time of the next request after the crawl delay
+}
+
+// Guard checks URLs against the crawl policies of their hosts, fetching
+// each robots.txt once
+type Guard struct {
+	client  *http.Client
+	options Options
+
+	mutex sync.Mutex
+	hosts map[string]*host
+}
+
+// NewGuard creates a guard fetching robots.txt files with client
+func NewGuard(client *http.Client, options Options) *Guard {
+	return &Guard{client: client, options: options, hosts: make(map[string]*host)}
+}
+
+// Check reports whether a URL may be fetched: it returns ErrDisallowed or
+// ErrBudgetExhausted if not, and otherwise waits for the crawl delay of
+// the host since its previous request.
+func (g *Guard) Check(ctx context.Context, link *url.URL) error {
+	key := strings.ToLower(link.Scheme + "://" + link.Host)
+	g.mutex.Lock()
+	h, exists := g.hosts[key]
+	if !exists {
+		h = &host{}
+		g.hosts[key] = h
+	}
+	g.mutex.Unlock()
+
+	if !g.options.Ignore {
+		h.once.Do(func() { h.rules = g.fetch(ctx, link) })
+		if !h.rules.Allowed(link.RequestURI()) {
+			return ErrDisallowed
+		}
+	}
+
+	g.mutex.Lock()
+	if g.options.MaxRequestsPerHost > 0 && h.requests >= g.options.MaxRequestsPerHost {
+		g.mutex.Unlock()
+		return ErrBudgetExhausted
+	}
+	h.requests++
+	wait := time.Duration(0)
+	if !g.options.Ignore && h.rules.CrawlDelay > 0 {
+		delay := h.rules.CrawlDelay
+		if delay > MaxCrawlDelay {
+			delay = MaxCrawlDelay
+		}
+		now := time.Now()
+		if h.next.After(now) {
+			wait = h.next.Sub(now)
+		}
+		h.next = now.Add(wait + delay)
+	}
+	g.mutex.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetch reads the robots.txt of a URL's host. Missing files allow
+// everything and server errors disallow everything, as RFC 9309 asks;
+// unreachable hosts allow everything, their pages failing anyway.
+func (g *Guard) fetch(ctx context.Context, link *url.URL) *Rules {
+	robotsURL := fmt.Sprintf("%s://%s/robots.txt", link.Scheme, link.Host)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return &Rules{}
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return &Rules{}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return DisallowAll()
+	case resp.StatusCode != http.StatusOK:
+		return &Rules{}
+	}
+	// RFC 9309 requires parsing at least 500 KiB
+	return Parse(io.LimitReader(resp.Body, 512<<10), ProductToken)
+}
@@ -0,0 +1,117 @@
+package robots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	rules := Parse(strings.NewReader(`# Crawlers
+User-agent: *
+Disallow: /
+
+User-agent: Googlebot
+User-agent: GopherStrike
+Disallow: /private/
+Allow: /private/press/
+Disallow: /*.pdf$
+Disallow: /search?q=
+Crawl-delay: 2.5
+
+User-agent: other
+Disallow:
+`), ProductToken)
+
+	tests := map[string]bool{
+		"/":                        true,
+		"/robots.txt":              true,
+		"/private/":                false,
+		"/private/report.html":     false,
+		"/private/press/2024.html": true,
+		"/docs/manual.pdf":         false,
+		"/docs/manual.pdf?page=2":  true,
+		"/search?q=test":           false,
+		"/search":                  true,
+	}
+	for path, want := range tests {
+		if got := rules.Allowed(path); got != want {
+			t.Errorf("Allowed(%q) = %v, want %v", path, got, want)
+		}
+	}
+	if rules.CrawlDelay != 2500*time.Millisecond {
+		t.Errorf("CrawlDelay = %v, want 2.5s", rules.CrawlDelay)
+	}
+
+	// Without a group of its own, the * group applies
+	rules = Parse(strings.NewReader("User-agent: *\nDisallow: /admin\n"), ProductToken)
+	if rules.Allowed("/admin/login") || !rules.Allowed("/about") {
+		t.Error("the * group does not apply")
+	}
+	// A group of its own allowing everything replaces the * group
+	rules = Parse(strings.NewReader("User-agent: *\nDisallow: /\n\nUser-agent: gopherstrike\nDisallow:\n"), ProductToken)
+	if !rules.Allowed("/about") {
+		t.Error("the product group allowing everything does not apply")
+	}
+}
+
+func TestGuard(t *testing.T) {
+	var robotsFetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsFetches.Add(1)
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\nCrawl-delay: 0.05\n")
+		}
+	}))
+	defer server.Close()
+
+	page := func(path string) *url.URL {
+		link, _ := url.Parse(server.URL + path)
+		return link
+	}
+	guard := NewGuard(server.Client(), Options{MaxRequestsPerHost: 3})
+	ctx := context.Background()
+
+	start := time.Now()
+	for _, path := range []string{"/", "/a", "/b"} {
+		if err := guard.Check(ctx, page(path)); err != nil {
+			t.Fatalf("Check(%s) error = %v", path, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("3 requests took %v, want the crawl delay between them", elapsed)
+	}
+	if err := guard.Check(ctx, page("/private/x")); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("Check(/private/x) error = %v, want ErrDisallowed", err)
+	}
+	if err := guard.Check(ctx, page("/c")); !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("Check(/c) error = %v, want ErrBudgetExhausted", err)
+	}
+	if n := robotsFetches.Load(); n != 1 {
+		t.Errorf("robots.txt fetched %d times, want 1", n)
+	}
+
+	ignoring := NewGuard(server.Client(), Options{Ignore: true})
+	if err := ignoring.Check(ctx, page("/private/x")); err != nil {
+		t.Errorf("Check() ignoring robots.txt error = %v", err)
+	}
+}
+
+func TestGuardServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	link, _ := url.Parse(server.URL + "/")
+	if err := NewGuard(server.Client(), Options{}).Check(context.Background(), link); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("Check() error = %v, want ErrDisallowed after a server error", err)
+	}
+}
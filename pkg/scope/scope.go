@@ -0,0 +1,396 @@
+// Package scope enforces the do-not-scan exclusions of an engagement. The
+// exclusions file lists addresses, CIDR ranges, hostnames and URL patterns
+// that must never be contacted; the HTTP clients and dialers of the scanning
+// tools check every connection against it, so an excluded target is refused
+// whichever tool is run.
+package scope
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// EnvVar selects the exclusions file, overriding the scanning.exclusions_file setting
+const EnvVar = "GOPHERSTRIKE_EXCLUSIONS"
+
+// Exclusions is a parsed exclusions file
+type Exclusions struct {
+	Path     string
+	networks []*net.IPNet
+	hosts    map[string]bool
+	domains  []string // Parents of wildcard entries, matching the domain and its subdomains
+	patterns []*regexp.Regexp
+}
+
+// ExcludedError is returned when a target matches an exclusion
+type ExcludedError struct {
+	Target string
+	Rule   string
+}
+
+func (e *ExcludedError) Error() string {
+	return fmt.Sprintf("%s is excluded from scanning (%s)", e.Target, e.Rule)
+}
+
+// Parse reads exclusions, one per line. Empty lines and lines starting
+// with # are skipped. Each line is one of:
+//
+//	192.0.2.10                an IPv4 or IPv6 address
+//	192.0.2.0/24              a CIDR range
+//	www.example.com           a hostname
+//	*.example.com             a domain and all of its subdomains
+//	re:^https?://[^/]+/admin  a regular expression matched against full URLs
+func Parse(r io.Reader) (*Exclusions, error) {
+	e := &Exclusions{hosts: map[string]bool{}}
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := e.add(line); err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading exclusions: %v", err)
+	}
+	return e, nil
+}
+
+// Load reads an exclusions file
+func Load(path string) (*Exclusions, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open exclusions file: %v", err)
+	}
+	defer file.Close()
+
+	e, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclusions file %s: %v", path, err)
+	}
+	e.Path = path
+	return e, nil
+}
+
+// add parses one exclusion
+func (e *Exclusions) add(entry string) error {
+	if pattern, found := strings.CutPrefix(entry, "re:"); found {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid URL pattern %q: %v", pattern, err)
+		}
+		e.patterns = append(e.patterns, re)
+		return nil
+	}
+
+	if strings.Contains(entry, "/") {
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR range: %s", entry)
+		}
+		e.networks = append(e.networks, network)
+		return nil
+	}
+
+	if ip := net.ParseIP(entry); ip != nil {
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		e.networks = append(e.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		return nil
+	}
+
+	host := normalizeHost(entry)
+	if domain, found := strings.CutPrefix(host, "*."); found {
+		host = domain
+		e.domains = append(e.domains, domain)
+	} else {
+		e.hosts[host] = true
+	}
+	if host == "" || strings.ContainsAny(host, ":*?[] ") {
+		return fmt.Errorf("invalid hostname: %s", entry)
+	}
+	return nil
+}
+
+// Len returns the number of exclusions
+func (e *Exclusions) Len() int {
+	if e == nil {
+		return 0
+	}
+	return len(e.networks) + len(e.hosts) + len(e.domains) + len(e.patterns)
+}
+
+// normalizeHost lowercases a hostname and strips the trailing dot
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+}
+
+// matchIP returns the range excluding an address
+func (e *Exclusions) matchIP(ip net.IP) (string, bool) {
+	for _, network := range e.networks {
+		if network.Contains(ip) {
+			return network.String(), true
+		}
+	}
+	return "", false
+}
+
+// matchHost returns the exclusion matching a hostname or address literal
+func (e *Exclusions) matchHost(host string) (string, bool) {
+	host = normalizeHost(strings.Trim(host, "[]"))
+	if ip := net.ParseIP(host); ip != nil {
+		return e.matchIP(ip)
+	}
+	if e.hosts[host] {
+		return host, true
+	}
+	for _, domain := range e.domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return "*." + domain, true
+		}
+	}
+	return "", false
+}
+
+// ExcludesIP reports whether an address is excluded
+func (e *Exclusions) ExcludesIP(ip net.IP) bool {
+	if e == nil {
+		return false
+	}
+	_, excluded := e.matchIP(ip)
+	return excluded
+}
+
+// ExcludesHost reports whether a hostname or address literal is excluded.
+// Hostnames are not resolved; use Check to also test their addresses.
+func (e *Exclusions) ExcludesHost(host string) bool {
+	if e == nil {
+		return false
+	}
+	_, excluded := e.matchHost(host)
+	return excluded
+}
+
+// ExcludesURL reports whether a URL matches a pattern or its host is excluded
+func (e *Exclusions) ExcludesURL(rawURL string) bool {
+	return e.checkURL(rawURL) != nil
+}
+
+// checkURL returns an ExcludedError if a URL matches a pattern or its host is excluded
+func (e *Exclusions) checkURL(rawURL string) error {
+	if e == nil {
+		return nil
+	}
+	for _, pattern := range e.patterns {
+		if pattern.MatchString(rawURL) {
+			return &ExcludedError{Target: rawURL, Rule: "re:" + pattern.String()}
+		}
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	if rule, excluded := e.matchHost(u.Hostname()); excluded {
+		return &ExcludedError{Target: rawURL, Rule: rule}
+	}
+	return nil
+}
+
+// resolve returns the addresses of a host, or an ExcludedError if the host
+// or any of its addresses is excluded
+func (e *Exclusions) resolve(ctx context.Context, host string) ([]string, error) {
+	if rule, excluded := e.matchHost(host); excluded {
+		return nil, &ExcludedError{Target: host, Rule: rule}
+	}
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+		return []string{ip.String()}, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	addresses := []string{}
+	for _, addr := range addrs {
+		if rule, excluded := e.matchIP(addr.IP); excluded {
+			return nil, &ExcludedError{Target: fmt.Sprintf("%s (%s)", host, addr.IP), Rule: rule}
+		}
+		addresses = append(addresses, addr.IP.String())
+	}
+	return addresses, nil
+}
+
+var (
+	active *Exclusions
+	mu     sync.RWMutex
+)
+
+// Set makes exclusions the ones enforced by Check, DialContext and
+// Transport. A nil value removes all exclusions.
+func Set(e *Exclusions) {
+	mu.Lock()
+	defer mu.Unlock()
+	active = e
+}
+
+// Active returns the enforced exclusions, or nil if there are none
+func Active() *Exclusions {
+	mu.RLock()
+	defer mu.RUnlock()
+	return active
+}
+
+// allowedKey is the context key of the targets allowed by WithAllowed
+type allowedKey struct{}
+
+// WithAllowed returns a context limiting the connections made with it to
+// the targets matching allowed, such as the scope of a tenant: Check,
+// DialContext and Transport refuse the others. Hostnames are matched as
+// given, without resolving them, and the exclusions still apply.
+func WithAllowed(ctx context.Context, allowed *Exclusions) context.Context {
+	return context.WithValue(ctx, allowedKey{}, allowed)
+}
+
+// checkAllowed returns an ExcludedError if the context limits the targets
+// and a target, given as a hostname, address, host:port or URL, is not one
+// of them
+func checkAllowed(ctx context.Context, target string) error {
+	allowed, limited := ctx.Value(allowedKey{}).(*Exclusions)
+	if !limited {
+		return nil
+	}
+	matched := false
+	if allowed != nil {
+		if strings.Contains(target, "://") {
+			matched = allowed.checkURL(target) != nil
+		} else {
+			if host, _, err := net.SplitHostPort(target); err == nil {
+				target = host
+			}
+			_, matched = allowed.matchHost(target)
+		}
+	}
+	if !matched {
+		return &ExcludedError{Target: target, Rule: "outside the allowed targets"}
+	}
+	return nil
+}
+
+// Check returns an ExcludedError if a target, given as a hostname, address,
+// host:port or URL, is excluded or not allowed by the context. Hostnames are
+// resolved and refused if any of their addresses is excluded.
+func Check(ctx context.Context, target string) error {
+	if err := checkAllowed(ctx, target); err != nil {
+		return err
+	}
+	e := Active()
+	if e.Len() == 0 {
+		return nil
+	}
+	if strings.Contains(target, "://") {
+		if err := e.checkURL(target); err != nil {
+			return err
+		}
+		if u, err := url.Parse(target); err == nil {
+			target = u.Hostname()
+		}
+	} else if host, _, err := net.SplitHostPort(target); err == nil {
+		target = host
+	}
+	_, err := e.resolve(ctx, target)
+	if _, excluded := err.(*ExcludedError); excluded {
+		return err
+	}
+	return nil
+}
+
+// CheckUnresolved is Check without resolving hostnames, for connections
+// whose names are resolved by a proxy, where a local lookup would leak them.
+// Hostnames are matched against the exclusions as given.
+func CheckUnresolved(ctx context.Context, target string) error {
+	if err := checkAllowed(ctx, target); err != nil {
+		return err
+	}
+	e := Active()
+	if e.Len() == 0 {
+		return nil
+	}
+	if strings.Contains(target, "://") {
+		return e.checkURL(target)
+	}
+	if host, _, err := net.SplitHostPort(target); err == nil {
+		target = host
+	}
+	if rule, excluded := e.matchHost(target); excluded {
+		return &ExcludedError{Target: target, Rule: rule}
+	}
+	return nil
+}
+
+// Resolve returns the addresses of host, refusing it if it is excluded or
+// not allowed by the context, or if any of its addresses is excluded. Callers
+// that connect to the addresses themselves dial these, so that the checked
+// addresses are the ones connected to.
+func Resolve(ctx context.Context, host string) ([]string, error) {
+	if err := checkAllowed(ctx, host); err != nil {
+		return nil, err
+	}
+	e := Active()
+	if e == nil {
+		e = &Exclusions{}
+	}
+	return e.resolve(ctx, host)
+}
+
+// DialFunc is the signature of net.Dialer.DialContext
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// DialContext wraps a dial function so that it refuses excluded hosts and
+// addresses, and those the context does not allow. Hostnames are resolved
+// once and the checked addresses are dialed, so a changing DNS answer
+// cannot lead to an excluded address.
+func DialContext(dial DialFunc) DialFunc {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if err := checkAllowed(ctx, address); err != nil {
+			return nil, err
+		}
+		e := Active()
+		if e.Len() == 0 {
+			return dial(ctx, network, address)
+		}
+
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		addresses, err := e.resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var lastErr error
+		for _, ip := range addresses {
+			conn, err := dial(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
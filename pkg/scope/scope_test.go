@@ -0,0 +1,233 @@
+package scope
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testExclusions = `# client-mandated exclusions
+192.0.2.10
+198.51.100.0/24
+2001:db8::/32
+payments.example.com
+*.internal.example.org
+re:^https?://[^/]+/admin
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantLen int
+		wantErr bool
+	}{
+		{"Every kind of exclusion", testExclusions, 6, false},
+		{"Empty file", "# nothing excluded\n\n", 0, false},
+		{"Invalid CIDR", "10.0.0.0/33\n", 0, true},
+		{"Invalid pattern", "re:(unclosed\n", 0, true},
+		{"Invalid hostname", "bad host name\n", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Len() != tt.wantLen {
+				t.Errorf("Parse().Len() = %d, want %d", got.Len(), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestExcludes(t *testing.T) {
+	exclusions, err := Parse(strings.NewReader(testExclusions))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	hosts := []struct {
+		host string
+		want bool
+	}{
+		{"192.0.2.10", true},
+		{"192.0.2.11", false},
+		{"198.51.100.77", true},
+		{"[2001:db8::1]", true},
+		{"Payments.Example.com.", true},
+		{"www.example.com", false},
+		{"internal.example.org", true},
+		{"db.internal.example.org", true},
+		{"notinternal.example.org", false},
+	}
+	for _, tt := range hosts {
+		if got := exclusions.ExcludesHost(tt.host); got != tt.want {
+			t.Errorf("ExcludesHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+
+	urls := []struct {
+		url  string
+		want bool
+	}{
+		{"https://www.example.com/admin/users", true},
+		{"https://www.example.com/login", false},
+		{"http://payments.example.com:8080/", true},
+		{"http://198.51.100.5/index.html", true},
+	}
+	for _, tt := range urls {
+		if got := exclusions.ExcludesURL(tt.url); got != tt.want {
+			t.Errorf("ExcludesURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+
+	var none *Exclusions
+	if none.ExcludesHost("192.0.2.10") || none.ExcludesURL("https://www.example.com/admin") {
+		t.Errorf("nil exclusions exclude targets")
+	}
+}
+
+func TestDialContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	tests := []struct {
+		name       string
+		exclusions string
+		address    string
+		wantErr    bool
+	}{
+		{"No exclusions", "", "127.0.0.1:" + port, false},
+		{"Address allowed", "192.0.2.10\n", "127.0.0.1:" + port, false},
+		{"Address excluded", "127.0.0.0/8\n", "127.0.0.1:" + port, true},
+		{"Resolved address excluded", "127.0.0.1\n", "localhost:" + port, true},
+		{"Hostname excluded", "localhost\n", "localhost:" + port, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exclusions, err := Parse(strings.NewReader(tt.exclusions))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			Set(exclusions)
+			defer Set(nil)
+
+			dialer := &net.Dialer{}
+			conn, err := DialContext(dialer.DialContext)(context.Background(), "tcp", tt.address)
+			if conn != nil {
+				conn.Close()
+			}
+			var excluded *ExcludedError
+			if errors.As(err, &excluded) != tt.wantErr {
+				t.Errorf("DialContext(%s) error = %v, want excluded %v", tt.address, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.Redirect(w, r, "/admin", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exclusions, err := Parse(strings.NewReader(testExclusions))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	Set(exclusions)
+	defer Set(nil)
+
+	client := &http.Client{Transport: Transport(nil)}
+	resp, err := client.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("Get(/) error = %v", err)
+	}
+	resp.Body.Close()
+
+	var excluded *ExcludedError
+	if _, err := client.Get(server.URL + "/admin"); !errors.As(err, &excluded) {
+		t.Errorf("Get(/admin) error = %v, want excluded", err)
+	}
+	if _, err := client.Get(server.URL + "/login"); !errors.As(err, &excluded) {
+		t.Errorf("Get(/login) redirecting to /admin error = %v, want excluded", err)
+	}
+}
+
+func TestWithAllowed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/away" {
+			http.Redirect(w, r, "http://localhost:1/", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	allowed, err := Parse(strings.NewReader("127.0.0.0/8\n*.acme.com\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	ctx := WithAllowed(context.Background(), allowed)
+
+	var excluded *ExcludedError
+	for target, want := range map[string]bool{
+		"www.acme.com":          true,
+		"www.acme.com:443":      true,
+		"https://www.acme.com/": true,
+		"example.com":           false,
+		"https://example.com/":  false,
+	} {
+		if err := Check(ctx, target); (err == nil) != want {
+			t.Errorf("Check(%q) error = %v, want allowed %v", target, err, want)
+		}
+	}
+	if err := Check(WithAllowed(context.Background(), nil), "www.acme.com"); !errors.As(err, &excluded) {
+		t.Errorf("Check() with nothing allowed error = %v, want excluded", err)
+	}
+
+	if _, err := DialContext(nil)(ctx, "tcp", "example.com:80"); !errors.As(err, &excluded) {
+		t.Errorf("DialContext(example.com) error = %v, want excluded", err)
+	}
+
+	// Redirects leaving the allowed targets are refused
+	client := &http.Client{Transport: Transport(nil)}
+	get := func(url string) error {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	if err := get(server.URL + "/"); err != nil {
+		t.Errorf("Get(/) error = %v", err)
+	}
+	if err := get(server.URL + "/away"); !errors.As(err, &excluded) {
+		t.Errorf("Get(/away) redirecting to localhost error = %v, want excluded", err)
+	}
+}
@@ -0,0 +1,36 @@
+package scope
+
+import (
+	"net/http"
+)
+
+// guardedTransport refuses requests for excluded or disallowed URLs before
+// they are sent
+type guardedTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	err := checkAllowed(req.Context(), req.URL.String())
+	if err == nil {
+		err = Active().checkURL(req.URL.String())
+	}
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+// Transport wraps a round tripper so that requests for excluded URLs and
+// hosts, and those their context does not allow, are refused. Redirects go
+// through the transport again, so they are checked as well.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &guardedTransport{next: next}
+}
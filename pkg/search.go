@@ -0,0 +1,126 @@
+// pkg/search.go
+package pkg
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// stringList is a flag value that may be repeated or comma-separated
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// RunSearch searches stored results of the active workspace
+func RunSearch(args []string) error {
+	var tags stringList
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.Var(&tags, "tag", "Only results with this tag (repeatable or comma-separated)")
+	target := fs.String("target", "", "Only results for this target host")
+	kind := fs.String("kind", "", "Only results of this kind (subdomains, dns, web, osint, recon, reports)")
+	text := fs.String("text", "", "Free-text search in result contents")
+	severity := fs.String("severity", "", "Only results with findings at or above this severity")
+	since := fs.String("since", "", "Only results modified on or after this date (YYYY-MM-DD)")
+	until := fs.String("until", "", "Only results modified before this date (YYYY-MM-DD)")
+	workspace := fs.String("workspace", os.Getenv(artifacts.WorkspaceEnvVar), "Workspace to search")
+	jsonOutput := fs.Bool("json", false, "Print results as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	query := artifacts.Query{
+		Target:      *target,
+		Kind:        artifacts.Kind(strings.ToLower(*kind)),
+		Tags:        tags,
+		Text:        *text,
+		MinSeverity: *severity,
+	}
+
+	var err error
+	if *since != "" {
+		if query.Since, err = time.ParseInLocation("2006-01-02", *since, time.Local); err != nil {
+			return fmt.Errorf("invalid --since date: %v", err)
+		}
+	}
+	if *until != "" {
+		if query.Until, err = time.ParseInLocation("2006-01-02", *until, time.Local); err != nil {
+			return fmt.Errorf("invalid --until date: %v", err)
+		}
+	}
+
+	store := artifacts.NewStore(artifacts.DefaultRoot, *workspace)
+	matches, err := store.Search(query)
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(matches, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(matches) == 0 {
+		fmt.Printf("[-] No results found in workspace %s\n", store.Workspace)
+		return nil
+	}
+
+	fmt.Printf("[+] Found %d results in workspace %s\n\n", len(matches), store.Workspace)
+	fmt.Printf("%-30s %-11s %-17s %-30s %s\n", "Target", "Kind", "Modified", "Tags", "Path")
+	fmt.Println(strings.Repeat("-", 120))
+	for _, match := range matches {
+		fmt.Printf("%-30s %-11s %-17s %-30s %s\n",
+			match.Target,
+			match.Kind,
+			match.ModTime.Format("2006-01-02 15:04"),
+			strings.Join(match.Tags, ","),
+			match.Path)
+	}
+
+	return nil
+}
+
+// RunTag assigns tags to a stored result of the active workspace
+func RunTag(args []string) error {
+	fs := flag.NewFlagSet("tag", flag.ContinueOnError)
+	workspace := fs.String("workspace", os.Getenv(artifacts.WorkspaceEnvVar), "Workspace of the result")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 2 {
+		return fmt.Errorf("usage: GopherStrike tag [--workspace name] <result-path> <tag>[,<tag>...]")
+	}
+
+	var tags stringList
+	for _, arg := range fs.Args()[1:] {
+		tags.Set(arg)
+	}
+
+	store := artifacts.NewStore(artifacts.DefaultRoot, *workspace)
+	if err := store.AddTags(fs.Arg(0), tags...); err != nil {
+		return err
+	}
+
+	fmt.Printf("[+] Tagged %s with: %s\n", fs.Arg(0), strings.Join(tags, ", "))
+	return nil
+}
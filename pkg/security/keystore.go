@@ -21,6 +21,7 @@ import (
 type SecureKeyStore struct {
 	filePath   string
 	masterKey  []byte
+	salt       []byte // Salt the master key was derived with
 	data       map[string]string
 	mutex      sync.RWMutex
 	gcm        cipher.AEAD
@@ -51,6 +52,7 @@ func NewSecureKeyStore(filePath string, password string) (*SecureKeyStore, error
 	// Use PBKDF2 with SHA-256 for key derivation
 	iterations := 100000 // NIST recommended minimum
 	store.masterKey = pbkdf2.Key([]byte(password), salt, iterations, 32, sha256.New)
+	store.salt = salt
 	
 	// Create AES-GCM cipher
 	block, err := aes.NewCipher(store.masterKey)
@@ -154,15 +156,10 @@ func (ks *SecureKeyStore) saveToFile() error {
 	// Encrypt the data
 	ciphertext := ks.gcm.Seal(nil, nonce, jsonData, nil)
 	
-	// Generate new salt for this save
-	salt := make([]byte, 32)
-	if _, err := rand.Read(salt); err != nil {
-		return fmt.Errorf("failed to generate salt: %w", err)
-	}
-	
-	// Create the encrypted data structure
+	// Create the encrypted data structure, with the salt the key was derived
+	// from so the password can decrypt it again
 	encData := EncryptedData{
-		Salt:                    base64.StdEncoding.EncodeToString(salt),
+		Salt:                    base64.StdEncoding.EncodeToString(ks.salt),
 		Nonce:                   base64.StdEncoding.EncodeToString(nonce),
 		EncryptedKeys:           base64.StdEncoding.EncodeToString(ciphertext),
 		KeyDerivationIterations: 100000,
@@ -240,6 +237,7 @@ func (ks *SecureKeyStore) loadFromFile(password string) error {
 	
 	// Update master key for future operations
 	ks.masterKey = derivedKey
+	ks.salt = salt
 	ks.gcm = gcm
 	
 	return nil
@@ -277,6 +275,7 @@ func (ks *SecureKeyStore) ChangePassword(oldPassword, newPassword string) error
 	
 	// Update the store
 	ks.masterKey = newKey
+	ks.salt = salt
 	ks.gcm = newGCM
 	
 	// Save with new encryption
@@ -0,0 +1,38 @@
+package security
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSecureKeyStoreReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+
+	store, err := NewSecureKeyStore(path, "correct horse")
+	if err != nil {
+		t.Fatalf("NewSecureKeyStore() error = %v", err)
+	}
+	if err := store.Set("shodan", "secret-value"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	reopened, err := NewSecureKeyStore(path, "correct horse")
+	if err != nil {
+		t.Fatalf("reopening the keystore failed: %v", err)
+	}
+	if value, err := reopened.Get("shodan"); err != nil || value != "secret-value" {
+		t.Errorf("Get() = %q, %v, want the stored value", value, err)
+	}
+
+	// Saving again from the reopened store must keep it readable
+	if err := reopened.Set("censys", "other"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := NewSecureKeyStore(path, "correct horse"); err != nil {
+		t.Errorf("reopening after a second save failed: %v", err)
+	}
+
+	if _, err := NewSecureKeyStore(path, "wrong password"); err == nil {
+		t.Error("NewSecureKeyStore() with a wrong password expected an error")
+	}
+}
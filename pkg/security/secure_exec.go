@@ -9,7 +9,6 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
-	"syscall"
 	"time"
 )
 
@@ -106,20 +105,7 @@ func NewSecureCommand(name string, args []string, options SecureCommandOptions)
 	}
 	
 	// Set user/group on Unix systems
-	if runtime.GOOS != "windows" && (options.UID != nil || options.GID != nil) {
-		cmd.SysProcAttr = &syscall.SysProcAttr{}
-		if options.UID != nil {
-			cmd.SysProcAttr.Credential = &syscall.Credential{
-				Uid: uint32(*options.UID),
-			}
-		}
-		if options.GID != nil {
-			if cmd.SysProcAttr.Credential == nil {
-				cmd.SysProcAttr.Credential = &syscall.Credential{}
-			}
-			cmd.SysProcAttr.Credential.Gid = uint32(*options.GID)
-		}
-	}
+	setCredentials(cmd, options)
 	
 	// Store cancel function for cleanup
 	secureCmd := &SecureCommand{
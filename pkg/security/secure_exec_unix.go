@@ -0,0 +1,22 @@
+//go:build !windows
+
+package security
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setCredentials runs the command as the user and group of the options
+func setCredentials(cmd *exec.Cmd, options SecureCommandOptions) {
+	if options.UID == nil && options.GID == nil {
+		return
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: &syscall.Credential{}}
+	if options.UID != nil {
+		cmd.SysProcAttr.Credential.Uid = uint32(*options.UID)
+	}
+	if options.GID != nil {
+		cmd.SysProcAttr.Credential.Gid = uint32(*options.GID)
+	}
+}
@@ -0,0 +1,7 @@
+package security
+
+import "os/exec"
+
+// setCredentials does nothing on Windows, where commands cannot be started
+// as another user by ID
+func setCredentials(cmd *exec.Cmd, options SecureCommandOptions) {}
@@ -0,0 +1,77 @@
+// pkg/serve.go
+package pkg
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/config"
+	"GopherStrike/pkg/server"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// RunServer runs the API server until it is interrupted
+func RunServer(args []string) error {
+	options := server.DefaultServerOptions()
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.StringVar(&options.Address, "listen", options.Address, "Address to listen on")
+	fs.StringVar(&options.AgentAddress, "agents", "", "Accept worker agents over gRPC on this address (e.g. :9090)")
+	fs.StringVar(&options.GRPCAddress, "grpc", "", "Serve the gRPC API with streaming scan events on this address (e.g. :9091)")
+	fs.IntVar(&options.MaxConcurrentScans, "max-scans", options.MaxConcurrentScans, "Maximum number of scans running at once")
+	fs.IntVar(&options.MaxScansPerTarget, "max-per-target", options.MaxScansPerTarget, "Maximum number of scans running at once against the same target")
+	fs.DurationVar(&options.DrainTimeout, "drain-timeout", options.DrainTimeout, "How long running scans may take to finish on shutdown before they are cancelled")
+	workspace := fs.String("workspace", os.Getenv(artifacts.WorkspaceEnvVar), "Workspace to store results in")
+	auth := fs.Bool("auth", config.Get().Security.RequireAuth, "Require API tokens (manage users with the users command)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *workspace != "" {
+		options.Workspace = *workspace
+	}
+
+	if *auth {
+		users, err := openUserStore()
+		if err != nil {
+			return err
+		}
+		if len(users.List()) == 0 {
+			return fmt.Errorf("authentication is enabled but there are no users; add one with: users add <name> --role admin")
+		}
+		options.Users = users
+	} else {
+		fmt.Println("[!] Authentication is disabled, anyone who can reach the API can launch scans")
+	}
+
+	srv := server.NewServer(options)
+
+	// On SIGTERM, e.g. from Kubernetes, the running scans finish and save
+	// their results before the server stops
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	stopped := make(chan error, 1)
+	go func() {
+		<-sigChan
+		fmt.Printf("\n[i] Shutting down API server, waiting up to %s for running scans...\n", options.DrainTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), options.DrainTimeout+10*time.Second)
+		defer cancel()
+		stopped <- srv.Shutdown(ctx)
+	}()
+
+	fmt.Printf("[+] API server listening on %s (workspace: %s)\n", options.Address, options.Workspace)
+	fmt.Printf("[i] Prometheus metrics available at http://%s/metrics\n", options.Address)
+	if options.AgentAddress != "" {
+		fmt.Printf("[+] Accepting worker agents on %s\n", options.AgentAddress)
+	}
+	if options.GRPCAddress != "" {
+		fmt.Printf("[+] gRPC API listening on %s\n", options.GRPCAddress)
+	}
+	if err := srv.ListenAndServe(); err != nil {
+		return err
+	}
+	return <-stopped
+}
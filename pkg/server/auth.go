@@ -0,0 +1,323 @@
+package server
+
+import (
+	"GopherStrike/pkg/security"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Roles, from least to most privileged
+const (
+	RoleReadOnly = "read-only" // Read scans, results and reports
+	RoleOperator = "operator"  // Also launch and cancel scans
+	RoleAdmin    = "admin"     // Also manage users
+)
+
+// roleLevels orders the roles by privilege
+var roleLevels = map[string]int{
+	RoleReadOnly: 1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// userKeyPrefix prefixes the keystore keys holding users
+const userKeyPrefix = "api_user:"
+
+// tokenPrefix makes API tokens easy to recognize, e.g. by secret scanners
+const tokenPrefix = "gst_"
+
+// userNamePattern matches valid user names
+var userNamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,64}$`)
+
+// errUnauthorized is returned for missing or unknown tokens
+var errUnauthorized = errors.New("missing or invalid API token")
+
+// User is an API user. Only a hash of the token is stored.
+type User struct {
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	Tenant    string    `json:"tenant,omitempty"` // Tenant the user belongs to; users of no tenant see every tenant
+	TokenHash string    `json:"token_hash"`
+	Created   time.Time `json:"created"`
+}
+
+// UserStore manages API users in the encrypted keystore
+type UserStore struct {
+	keystore *security.SecureKeyStore
+	mutex    sync.Mutex
+}
+
+// NewUserStore creates a user store backed by an encrypted keystore
+func NewUserStore(keystore *security.SecureKeyStore) *UserStore {
+	return &UserStore{keystore: keystore}
+}
+
+// ValidRole reports whether a role name is known
+func ValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// Add creates a user and returns its API token, which is not stored and
+// cannot be shown again
+func (us *UserStore) Add(name, role string) (string, error) {
+	return us.AddToTenant(name, role, "")
+}
+
+// AddToTenant creates a user of a tenant, or of no tenant if tenant is
+// empty, and returns its API token
+func (us *UserStore) AddToTenant(name, role, tenant string) (string, error) {
+	if !userNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid user name: %q", name)
+	}
+	if !ValidRole(role) {
+		return "", fmt.Errorf("invalid role: %s (use %s, %s or %s)", role, RoleAdmin, RoleOperator, RoleReadOnly)
+	}
+
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+
+	if us.keystore.Exists(userKeyPrefix + name) {
+		return "", fmt.Errorf("user already exists: %s", name)
+	}
+	if tenant != "" {
+		if _, err := us.getTenant(tenant); err != nil {
+			return "", err
+		}
+	}
+	return us.issueToken(User{Name: name, Role: role, Tenant: tenant, Created: time.Now()})
+}
+
+// Rotate replaces the API token of a user and returns the new token
+func (us *UserStore) Rotate(name string) (string, error) {
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+
+	user, err := us.get(name)
+	if err != nil {
+		return "", err
+	}
+	return us.issueToken(user)
+}
+
+// Remove deletes a user
+func (us *UserStore) Remove(name string) error {
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+
+	if !us.keystore.Exists(userKeyPrefix + name) {
+		return fmt.Errorf("user not found: %s", name)
+	}
+	return us.keystore.Delete(userKeyPrefix + name)
+}
+
+// List returns all users sorted by name
+func (us *UserStore) List() []User {
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+
+	users := []User{}
+	for _, key := range us.keystore.List() {
+		if !strings.HasPrefix(key, userKeyPrefix) {
+			continue
+		}
+		if user, err := us.get(strings.TrimPrefix(key, userKeyPrefix)); err == nil {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+	return users
+}
+
+// Authenticate returns the user an API token belongs to
+func (us *UserStore) Authenticate(token string) (User, error) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return User{}, errUnauthorized
+	}
+	hash := hashToken(token)
+
+	for _, user := range us.List() {
+		if subtle.ConstantTimeCompare([]byte(user.TokenHash), []byte(hash)) == 1 {
+			return user, nil
+		}
+	}
+	return User{}, errUnauthorized
+}
+
+// get loads a user from the keystore. The caller must hold the mutex.
+func (us *UserStore) get(name string) (User, error) {
+	value, err := us.keystore.Get(userKeyPrefix + name)
+	if err != nil {
+		return User{}, fmt.Errorf("user not found: %s", name)
+	}
+
+	var user User
+	if err := json.Unmarshal([]byte(value), &user); err != nil {
+		return User{}, fmt.Errorf("failed to decode user %s: %v", name, err)
+	}
+	return user, nil
+}
+
+// issueToken generates a new token for a user and saves the user. The caller must hold the mutex.
+func (us *UserStore) issueToken(user User) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	token := tokenPrefix + hex.EncodeToString(secret)
+	user.TokenHash = hashToken(token)
+
+	data, err := json.Marshal(user)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode user: %v", err)
+	}
+	if err := us.keystore.Set(userKeyPrefix+user.Name, string(data)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// hashToken hashes an API token for storage and comparison
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// userContextKey is the request context key of the authenticated user
+type userContextKey struct{}
+
+// requestUser returns the authenticated user of a request, if any
+func requestUser(r *http.Request) (User, bool) {
+	user, ok := r.Context().Value(userContextKey{}).(User)
+	return user, ok
+}
+
+// authenticate returns the user of a bearer token given as an Authorization value
+func (s *Server) authenticate(authorization string) (User, error) {
+	token, found := strings.CutPrefix(authorization, "Bearer ")
+	if !found {
+		return User{}, errUnauthorized
+	}
+	return s.options.Users.Authenticate(strings.TrimSpace(token))
+}
+
+// hasRole reports whether a user has at least the given role
+func hasRole(user User, role string) bool {
+	return roleLevels[user.Role] >= roleLevels[role]
+}
+
+// requireRole wraps a handler so it is only served to users with at least the
+// given role. Without a user store, authentication is disabled.
+func (s *Server) requireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.options.Users == nil {
+			next(w, r)
+			return
+		}
+
+		user, err := s.authenticate(r.Header.Get("Authorization"))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="GopherStrike"`)
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		if !hasRole(user, role) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("role %s is required", role))
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), userContextKey{}, user)))
+	}
+}
+
+// userRequest is the body of a request to create a user
+type userRequest struct {
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// userResponse describes a user, with its token when one was just issued
+type userResponse struct {
+	Name    string    `json:"name"`
+	Role    string    `json:"role"`
+	Tenant  string    `json:"tenant,omitempty"`
+	Created time.Time `json:"created"`
+	Token   string    `json:"token,omitempty"`
+}
+
+// The admins of a tenant only manage the users of their tenant
+func (s *Server) handleListUsers(w http.ResponseWriter, r *http.Request) {
+	tenant := requestTenant(r)
+	users := []userResponse{}
+	for _, user := range s.options.Users.List() {
+		if tenant == "" || user.Tenant == tenant {
+			users = append(users, userResponse{Name: user.Name, Role: user.Role, Tenant: user.Tenant, Created: user.Created})
+		}
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
+func (s *Server) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	var req userRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return
+	}
+
+	if tenant := requestTenant(r); tenant != "" {
+		if req.Tenant != "" && req.Tenant != tenant {
+			writeError(w, http.StatusForbidden, fmt.Errorf("users can only be added to tenant %s", tenant))
+			return
+		}
+		req.Tenant = tenant
+	}
+
+	token, err := s.options.Users.AddToTenant(req.Name, req.Role, req.Tenant)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, userResponse{Name: req.Name, Role: req.Role, Tenant: req.Tenant, Created: time.Now(), Token: token})
+}
+
+func (s *Server) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if current, ok := requestUser(r); ok && current.Name == name {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("cannot remove the current user"))
+		return
+	}
+	if tenant := requestTenant(r); tenant != "" && !s.tenantHasUser(tenant, name) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("user not found: %s", name))
+		return
+	}
+
+	if err := s.options.Users.Remove(name); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tenantHasUser reports whether a user belongs to a tenant
+func (s *Server) tenantHasUser(tenant, name string) bool {
+	for _, user := range s.options.Users.List() {
+		if user.Name == name {
+			return user.Tenant == tenant
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"GopherStrike/pkg/distributed"
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// Client calls the gRPC API of a GopherStrike server
+type Client struct {
+	conn  *grpc.ClientConn
+	token string
+}
+
+// NewClient creates a client for the gRPC API at address. The token is only
+// needed when the server requires authentication.
+func NewClient(address, token string) (*Client, error) {
+	conn, err := grpc.NewClient(address,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(distributed.JSONCodec{})),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, token: token}, nil
+}
+
+// Close closes the connection to the server
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Submit queues a scan
+func (c *Client) Submit(ctx context.Context, req ScanRequest) (Job, error) {
+	var job Job
+	err := c.conn.Invoke(c.withToken(ctx), "/"+scansServiceName+"/Submit", &req, &job)
+	return job, err
+}
+
+// Get returns a scan
+func (c *Client) Get(ctx context.Context, id string) (Job, error) {
+	var job Job
+	err := c.conn.Invoke(c.withToken(ctx), "/"+scansServiceName+"/Get", &JobRequest{ID: id}, &job)
+	return job, err
+}
+
+// Cancel removes a scan from the queue or stops it
+func (c *Client) Cancel(ctx context.Context, id string) (Job, error) {
+	var job Job
+	err := c.conn.Invoke(c.withToken(ctx), "/"+scansServiceName+"/Cancel", &JobRequest{ID: id}, &job)
+	return job, err
+}
+
+// Events calls fn with every event matching the request until the stream
+// ends, the context is cancelled or fn returns an error
+func (c *Client) Events(ctx context.Context, req EventsRequest, fn func(Event) error) error {
+	stream, err := c.conn.NewStream(c.withToken(ctx), eventsStreamDesc, "/"+scansServiceName+"/Events")
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(&req); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+
+	for {
+		event := new(Event)
+		if err := stream.RecvMsg(event); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := fn(*event); err != nil {
+			return err
+		}
+	}
+}
+
+// withToken adds the API token to the call metadata
+func (c *Client) withToken(ctx context.Context) context.Context {
+	if c.token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.token)
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// dashboardFiles holds the single-page dashboard served at /
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// defaultFindingsLimit is the number of results returned by /api/findings by default
+const defaultFindingsLimit = 50
+
+// artifactResponse describes a stored artifact and where to download it
+type artifactResponse struct {
+	Target     string         `json:"target"`
+	Kind       artifacts.Kind `json:"kind"`
+	Name       string         `json:"name"`
+	Size       int64          `json:"size"`
+	ModTime    time.Time      `json:"mod_time"`
+	Severities []string       `json:"severities,omitempty"`
+	Download   string         `json:"download"`
+}
+
+// newArtifactResponse converts a search match for the API
+func newArtifactResponse(match artifacts.Match) artifactResponse {
+	return artifactResponse{
+		Target:     match.Target,
+		Kind:       match.Kind,
+		Name:       match.Name,
+		Size:       match.Size,
+		ModTime:    match.ModTime,
+		Severities: match.Severities,
+		Download:   "/api/artifacts/" + url.PathEscape(match.Target) + "/" + url.PathEscape(string(match.Kind)) + "/" + url.PathEscape(match.Name),
+	}
+}
+
+// dashboardHandler serves the embedded dashboard assets
+func dashboardHandler() http.Handler {
+	static, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServerFS(static)
+}
+
+func (s *Server) handleInventory(w http.ResponseWriter, r *http.Request) {
+	assets, err := s.storeFor(requestTenant(r)).Inventory()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, assets)
+}
+
+func (s *Server) handleFindings(w http.ResponseWriter, r *http.Request) {
+	limit := defaultFindingsLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %q", value))
+			return
+		}
+		limit = n
+	}
+
+	severity := r.URL.Query().Get("severity")
+	if severity == "" {
+		severity = "low"
+	}
+
+	matches, err := s.storeFor(requestTenant(r)).Search(artifacts.Query{
+		Target:      r.URL.Query().Get("target"),
+		MinSeverity: severity,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	findings := []artifactResponse{}
+	for _, match := range matches {
+		if len(findings) == limit {
+			break
+		}
+		findings = append(findings, newArtifactResponse(match))
+	}
+	writeJSON(w, http.StatusOK, findings)
+}
+
+func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
+	matches, err := s.storeFor(requestTenant(r)).Search(artifacts.Query{Kind: artifacts.KindReports})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	reports := []artifactResponse{}
+	for _, match := range matches {
+		reports = append(reports, newArtifactResponse(match))
+	}
+	writeJSON(w, http.StatusOK, reports)
+}
+
+func (s *Server) handleDownloadArtifact(w http.ResponseWriter, r *http.Request) {
+	path, err := s.storeFor(requestTenant(r)).Lookup(r.PathValue("target"), artifacts.Kind(r.PathValue("kind")), r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(path)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeFile(w, r, path)
+}
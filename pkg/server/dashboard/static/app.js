@@ -0,0 +1,208 @@
+// GopherStrike dashboard. Everything shown here comes from the REST API,
+// authenticated with the token saved in the browser.
+(function () {
+  "use strict";
+
+  var REFRESH_INTERVAL = 5000;
+  var TOKEN_KEY = "gopherstrike_token";
+
+  function token() {
+    return localStorage.getItem(TOKEN_KEY) || "";
+  }
+
+  function request(path) {
+    var headers = {};
+    if (token()) {
+      headers["Authorization"] = "Bearer " + token();
+    }
+    return fetch(path, { headers: headers }).then(function (resp) {
+      if (!resp.ok) {
+        return resp.json().catch(function () { return {}; }).then(function (body) {
+          throw new Error(body.error || resp.status + " " + resp.statusText);
+        });
+      }
+      return resp;
+    });
+  }
+
+  function getJSON(path) {
+    return request(path).then(function (resp) { return resp.json(); });
+  }
+
+  // download fetches an artifact with the token and hands it to the browser
+  function download(path, name) {
+    request(path).then(function (resp) { return resp.blob(); }).then(function (blob) {
+      var link = document.createElement("a");
+      link.href = URL.createObjectURL(blob);
+      link.download = name;
+      document.body.appendChild(link);
+      link.click();
+      link.remove();
+      URL.revokeObjectURL(link.href);
+    }).catch(showError);
+  }
+
+  function el(tag, text, className) {
+    var node = document.createElement(tag);
+    if (text !== undefined && text !== null) {
+      node.textContent = text;
+    }
+    if (className) {
+      node.className = className;
+    }
+    return node;
+  }
+
+  function badge(label, severity) {
+    return el("span", label, "badge " + (severity || label));
+  }
+
+  function downloadLink(path, name) {
+    var link = el("a", name);
+    link.addEventListener("click", function () { download(path, name); });
+    return link;
+  }
+
+  function formatTime(value) {
+    if (!value || value.indexOf("0001-") === 0) {
+      return "";
+    }
+    return new Date(value).toLocaleString();
+  }
+
+  function formatSize(bytes) {
+    if (bytes < 1024) {
+      return bytes + " B";
+    }
+    if (bytes < 1024 * 1024) {
+      return (bytes / 1024).toFixed(1) + " KB";
+    }
+    return (bytes / 1024 / 1024).toFixed(1) + " MB";
+  }
+
+  function fillTable(id, rows, columns, empty) {
+    var body = document.getElementById(id);
+    body.textContent = "";
+    if (rows.length === 0) {
+      var cell = el("td", empty, "muted");
+      cell.colSpan = columns;
+      body.appendChild(el("tr")).appendChild(cell);
+      return;
+    }
+    rows.forEach(function (cells) {
+      var row = el("tr");
+      cells.forEach(function (value) {
+        var cell = el("td");
+        if (value instanceof Node) {
+          cell.appendChild(value);
+        } else {
+          cell.textContent = value === undefined || value === null ? "" : value;
+        }
+        row.appendChild(cell);
+      });
+      body.appendChild(row);
+    });
+  }
+
+  // artifactLink builds a download link from the saved path of a scan's results
+  function artifactLink(path) {
+    if (!path) {
+      return "";
+    }
+    var parts = path.split(/[\\/]/);
+    if (parts.length < 3) {
+      return path;
+    }
+    var name = parts[parts.length - 1];
+    var url = "/api/artifacts/" + parts.slice(-3).map(encodeURIComponent).join("/");
+    return downloadLink(url, name);
+  }
+
+  function severityBadges(severities) {
+    var span = el("span");
+    (severities || []).forEach(function (severity) {
+      span.appendChild(badge(severity));
+    });
+    return span;
+  }
+
+  function findingCounts(findings) {
+    var span = el("span");
+    ["critical", "high", "medium", "low"].forEach(function (severity) {
+      if (findings && findings[severity]) {
+        span.appendChild(badge(severity + ": " + findings[severity], severity));
+      }
+    });
+    return span;
+  }
+
+  function loadScans() {
+    return getJSON("/api/scans").then(function (jobs) {
+      fillTable("scans", jobs.map(function (job) {
+        return [
+          job.id, job.tool, job.target, job.priority, job.user,
+          badge(job.status), formatTime(job.submitted),
+          job.error ? el("span", job.error, "error") : artifactLink(job.artifact)
+        ];
+      }), 8, "No scans submitted yet");
+    });
+  }
+
+  function loadFindings() {
+    return getJSON("/api/findings").then(function (findings) {
+      fillTable("findings", findings.map(function (finding) {
+        return [
+          finding.target, finding.kind, severityBadges(finding.severities),
+          downloadLink(finding.download, finding.name), formatTime(finding.mod_time)
+        ];
+      }), 5, "No findings yet");
+    });
+  }
+
+  function loadInventory() {
+    return getJSON("/api/inventory").then(function (assets) {
+      fillTable("inventory", assets.map(function (asset) {
+        return [
+          asset.host, (asset.ips || []).join(", "), (asset.open_ports || []).join(", "),
+          (asset.technologies || []).join(", "), findingCounts(asset.findings),
+          formatTime(asset.last_scanned)
+        ];
+      }), 6, "No assets in this workspace");
+    });
+  }
+
+  function loadReports() {
+    return getJSON("/api/reports").then(function (reports) {
+      fillTable("reports", reports.map(function (report) {
+        return [
+          report.target, downloadLink(report.download, report.name),
+          formatSize(report.size), formatTime(report.mod_time)
+        ];
+      }), 4, "No reports generated yet");
+    });
+  }
+
+  function showError(err) {
+    var status = document.getElementById("status");
+    status.textContent = err.message;
+    status.className = "error";
+  }
+
+  function refresh() {
+    Promise.all([loadScans(), loadFindings(), loadInventory(), loadReports()]).then(function () {
+      var status = document.getElementById("status");
+      status.textContent = "Updated " + new Date().toLocaleTimeString();
+      status.className = "muted";
+    }).catch(showError);
+  }
+
+  document.getElementById("token").value = token();
+  document.getElementById("token-form").addEventListener("submit", function (event) {
+    event.preventDefault();
+    localStorage.setItem(TOKEN_KEY, document.getElementById("token").value.trim());
+    refresh();
+  });
+
+  refresh();
+  setInterval(refresh, REFRESH_INTERVAL);
+})();
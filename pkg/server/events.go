@@ -0,0 +1,115 @@
+package server
+
+import (
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/metrics"
+	"GopherStrike/pkg/siem"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Event types
+const (
+	EventScanQueued    = "scan.queued"
+	EventScanStarted   = eventbus.ScanStarted
+	EventScanCompleted = eventbus.ScanCompleted
+	EventScanFailed    = "scan.failed"
+	EventScanCancelled = "scan.cancelled"
+	EventFindingNew    = eventbus.FindingNew
+)
+
+// eventBuffer is the number of events buffered per subscriber; events are
+// dropped for subscribers that fall further behind
+const eventBuffer = 256
+
+// Event reports a change of a scan's state or a new finding
+type Event struct {
+	Type    string        `json:"type"`
+	Time    time.Time     `json:"time"`
+	Job     *Job          `json:"job,omitempty"`     // Scan the event belongs to, if any
+	Finding *siem.Finding `json:"finding,omitempty"` // Set for finding.new
+}
+
+// subscribe returns a channel receiving all events from now on, and a function
+// that closes it
+func (s *Server) subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBuffer)
+
+	s.subscriberMutex.Lock()
+	defer s.subscriberMutex.Unlock()
+
+	s.nextSubscriber++
+	id := s.nextSubscriber
+	s.subscribers[id] = ch
+	return ch, func() {
+		s.subscriberMutex.Lock()
+		defer s.subscriberMutex.Unlock()
+		if _, ok := s.subscribers[id]; ok {
+			delete(s.subscribers, id)
+			close(ch)
+		}
+	}
+}
+
+// publish sends an event to every subscriber without blocking
+func (s *Server) publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	s.subscriberMutex.Lock()
+	defer s.subscriberMutex.Unlock()
+
+	for _, ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+			metrics.Errors.Inc("events")
+		}
+	}
+}
+
+// publishJob sends an event about a job. The caller must hold the mutex.
+func (s *Server) publishJob(eventType string, job *Job) {
+	copied := *job
+	s.publish(Event{Type: eventType, Job: &copied})
+}
+
+// handleFinding publishes a finding, attributed to the scan job it was
+// emitted for, and records it in the workspace of the job's tenant. Findings
+// of no scan of the server go to its own workspace.
+func (s *Server) handleFinding(event eventbus.Event) {
+	f, ok := event.Data.(siem.Finding)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	store := s.store
+	if job, ok := s.jobs[event.Job]; ok && event.Job != "" {
+		copied := *job
+		s.publish(Event{Type: EventFindingNew, Time: f.Time, Job: &copied, Finding: &f})
+		store = s.storeFor(job.Tenant)
+	} else {
+		s.publish(Event{Type: EventFindingNew, Time: f.Time, Finding: &f})
+	}
+	s.mutex.Unlock()
+
+	if err := store.RecordFinding(f); err != nil {
+		metrics.Errors.Inc("findings")
+		fmt.Fprintf(os.Stderr, "Warning: failed to record finding %s: %v\n", f.ID, err)
+	}
+}
+
+// finishedEvent returns the event type reporting a job's final status
+func finishedEvent(status string) string {
+	switch status {
+	case StatusFailed:
+		return EventScanFailed
+	case StatusCancelled:
+		return EventScanCancelled
+	default:
+		return EventScanCompleted
+	}
+}
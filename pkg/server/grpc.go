@@ -0,0 +1,277 @@
+package server
+
+import (
+	"GopherStrike/pkg/distributed"
+	"GopherStrike/pkg/eventbus"
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// scansServiceName is the fully qualified name of the gRPC scan service
+const scansServiceName = "gopherstrike.server.Scans"
+
+// JobRequest identifies a scan in gRPC calls
+type JobRequest struct {
+	ID string `json:"id"`
+}
+
+// EventsRequest selects the events streamed by the Events call
+type EventsRequest struct {
+	JobID string   `json:"job_id"` // Only events of this scan; the stream ends when it finishes
+	Types []string `json:"types"`  // Event types, e.g. "finding.new" or "scan.*"; empty for all
+}
+
+// scansService is the gRPC scan service:
+//
+//	rpc Submit(ScanRequest) returns (Job)
+//	rpc Get(JobRequest) returns (Job)
+//	rpc Cancel(JobRequest) returns (Job)
+//	rpc Events(EventsRequest) returns (stream Event)
+type scansService interface {
+	Submit(ctx context.Context, req *ScanRequest) (*Job, error)
+	Get(ctx context.Context, req *JobRequest) (*Job, error)
+	Cancel(ctx context.Context, req *JobRequest) (*Job, error)
+	Events(req *EventsRequest, stream grpc.ServerStream) error
+}
+
+var scansServiceDesc = grpc.ServiceDesc{
+	ServiceName: scansServiceName,
+	HandlerType: (*scansService)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Submit", Handler: submitHandler},
+		{MethodName: "Get", Handler: getHandler},
+		{MethodName: "Cancel", Handler: cancelHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "Events", Handler: eventsHandler, ServerStreams: true},
+	},
+}
+
+func submitHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(ScanRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(scansService).Submit(ctx, req)
+	}
+
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + scansServiceName + "/Submit"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(scansService).Submit(ctx, req.(*ScanRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
+
+func getHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(JobRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(scansService).Get(ctx, req)
+	}
+
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + scansServiceName + "/Get"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(scansService).Get(ctx, req.(*JobRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
+
+func cancelHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(JobRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(scansService).Cancel(ctx, req)
+	}
+
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + scansServiceName + "/Cancel"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(scansService).Cancel(ctx, req.(*JobRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
+
+func eventsHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := new(EventsRequest)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(scansService).Events(req, stream)
+}
+
+// Stream descriptor used by the client
+var eventsStreamDesc = &grpc.StreamDesc{StreamName: "Events", ServerStreams: true}
+
+// newGRPCServer creates the gRPC server exposing the scan service
+func (s *Server) newGRPCServer() *grpc.Server {
+	server := grpc.NewServer(grpc.ForceServerCodec(distributed.JSONCodec{}))
+	server.RegisterService(&scansServiceDesc, &grpcScans{server: s})
+	return server
+}
+
+// ServeGRPC serves the gRPC API on the listener
+func (s *Server) ServeGRPC(listener net.Listener) error {
+	return s.grpc.Serve(listener)
+}
+
+// grpcScans implements the gRPC scan service on top of the server
+type grpcScans struct {
+	server *Server
+}
+
+func (g *grpcScans) Submit(ctx context.Context, req *ScanRequest) (*Job, error) {
+	user, err := g.server.authorizeRPC(ctx, RoleOperator)
+	if err != nil {
+		return nil, err
+	}
+	req.SubmittedBy, req.Tenant = user.Name, user.Tenant
+
+	job, err := g.server.Submit(*req)
+	if err == errDraining {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	created, _ := g.server.Job(job.ID)
+	return &created, nil
+}
+
+func (g *grpcScans) Get(ctx context.Context, req *JobRequest) (*Job, error) {
+	user, err := g.server.authorizeRPC(ctx, RoleReadOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	job, ok := g.server.Job(req.ID)
+	if !ok || !visible(job, user.Tenant) {
+		return nil, status.Error(codes.NotFound, errJobNotFound.Error())
+	}
+	return &job, nil
+}
+
+func (g *grpcScans) Cancel(ctx context.Context, req *JobRequest) (*Job, error) {
+	user, err := g.server.authorizeRPC(ctx, RoleOperator)
+	if err != nil {
+		return nil, err
+	}
+
+	if job, ok := g.server.Job(req.ID); ok && !visible(job, user.Tenant) {
+		return nil, status.Error(codes.NotFound, errJobNotFound.Error())
+	}
+	job, err := g.server.Cancel(req.ID)
+	if err != nil {
+		code := codes.FailedPrecondition
+		if err == errJobNotFound {
+			code = codes.NotFound
+		}
+		return nil, status.Error(code, err.Error())
+	}
+	return &job, nil
+}
+
+// Events streams events as they happen. For a single scan, the stream ends
+// after the scan has finished. Tenant users only receive the events of their
+// tenant's scans.
+func (g *grpcScans) Events(req *EventsRequest, stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	user, err := g.server.authorizeRPC(ctx, RoleReadOnly)
+	if err != nil {
+		return err
+	}
+
+	// Subscribe before looking at the scan, so no event is missed in between
+	events, unsubscribe := g.server.subscribe()
+	defer unsubscribe()
+
+	if req.JobID != "" {
+		job, ok := g.server.Job(req.JobID)
+		if !ok || !visible(job, user.Tenant) {
+			return status.Error(codes.NotFound, errJobNotFound.Error())
+		}
+		if isFinished(job.Status) {
+			event := Event{Type: finishedEvent(job.Status), Time: job.Finished, Job: &job}
+			if matchesEventTypes(event.Type, req.Types) {
+				return stream.SendMsg(&event)
+			}
+			return nil
+		}
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if req.JobID != "" && (event.Job == nil || event.Job.ID != req.JobID) {
+				continue
+			}
+			if user.Tenant != "" && (event.Job == nil || event.Job.Tenant != user.Tenant) {
+				continue
+			}
+			if matchesEventTypes(event.Type, req.Types) {
+				if err := stream.SendMsg(&event); err != nil {
+					return err
+				}
+			}
+			if req.JobID != "" && event.Finding == nil && isFinished(event.Job.Status) {
+				return nil
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// authorizeRPC checks the bearer token in the call metadata. Without a user
+// store, authentication is disabled.
+func (s *Server) authorizeRPC(ctx context.Context, role string) (User, error) {
+	if s.options.Users == nil {
+		return User{}, nil
+	}
+
+	authorization := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			authorization = values[0]
+		}
+	}
+
+	user, err := s.authenticate(authorization)
+	if err != nil {
+		return User{}, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if !hasRole(user, role) {
+		return User{}, status.Errorf(codes.PermissionDenied, "role %s is required", role)
+	}
+	return user, nil
+}
+
+// isFinished reports whether a job status is final
+func isFinished(jobStatus string) bool {
+	return jobStatus == StatusCompleted || jobStatus == StatusFailed || jobStatus == StatusCancelled
+}
+
+// matchesEventTypes checks an event type against a filter such as
+// ["scan.*", "finding.new"]. An empty filter matches everything.
+func matchesEventTypes(eventType string, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if eventbus.Match(t, eventType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/budget"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// errJobNotFound is returned for unknown job IDs
+var errJobNotFound = errors.New("scan not found")
+
+// errDraining is returned for scans submitted while the server shuts down
+var errDraining = errors.New("server is shutting down")
+
+// Submit queues a scan and starts it as soon as the concurrency limits allow
+func (s *Server) Submit(req ScanRequest) (*Job, error) {
+	if _, ok := runners[req.Tool]; !ok {
+		return nil, fmt.Errorf("unsupported tool: %s", req.Tool)
+	}
+	host := artifacts.NormalizeTarget(req.Target)
+	if host == "" {
+		return nil, fmt.Errorf("invalid target: %q", req.Target)
+	}
+	limit, err := budget.Parse(req.MaxDuration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid max_duration: %v", err)
+	}
+	if req.Tenant != "" {
+		if s.options.Users == nil {
+			return nil, fmt.Errorf("%w: %s", errTenantNotFound, req.Tenant)
+		}
+		tenant, err := s.options.Users.Tenant(req.Tenant)
+		if err != nil {
+			return nil, err
+		}
+		if !tenant.Allows(req.Target) {
+			return nil, fmt.Errorf("%s is outside the scope of tenant %s", req.Target, req.Tenant)
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.draining {
+		return nil, errDraining
+	}
+
+	s.nextID++
+	job := &Job{
+		ID:        strconv.Itoa(s.nextID),
+		Tool:      req.Tool,
+		Target:    req.Target,
+		Priority:  req.Priority,
+		User:      req.SubmittedBy,
+		Tenant:    req.Tenant,
+		Status:    StatusQueued,
+		Submitted: time.Now(),
+		host:      host,
+		budget:    limit,
+	}
+	s.jobs[job.ID] = job
+	s.queue = append(s.queue, job)
+	metrics.ScansQueued.Inc()
+	s.publishJob(EventScanQueued, job)
+
+	s.dispatch()
+	return job, nil
+}
+
+// Cancel removes a queued scan from the queue or stops a running one
+func (s *Server) Cancel(id string) (Job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, errJobNotFound
+	}
+
+	switch job.Status {
+	case StatusQueued:
+		for i, queued := range s.queue {
+			if queued == job {
+				s.queue = append(s.queue[:i], s.queue[i+1:]...)
+				break
+			}
+		}
+		s.cancelQueued(job)
+	case StatusRunning:
+		// The job is marked as cancelled once its runner returns
+		job.cancel()
+	default:
+		return *job, fmt.Errorf("scan %s has already %s", id, job.Status)
+	}
+
+	return *job, nil
+}
+
+// cancelQueued marks a job removed from the queue as cancelled. The caller
+// must hold the mutex.
+func (s *Server) cancelQueued(job *Job) {
+	metrics.ScansQueued.Dec()
+	job.Status = StatusCancelled
+	job.Finished = time.Now()
+	metrics.Scans.Inc(job.Tool, job.Status)
+	s.publishJob(EventScanCancelled, job)
+}
+
+// drain stops taking scans, cancels the queued ones and waits for the
+// running ones to finish and save their results. Scans still running after
+// the drain timeout, or once ctx is done, are cancelled.
+func (s *Server) drain(ctx context.Context) {
+	s.mutex.Lock()
+	s.draining = true
+	for _, job := range s.queue {
+		job.Error = "the server shut down before the scan started"
+		s.cancelQueued(job)
+	}
+	s.queue = nil
+	s.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(done)
+	}()
+	timeout := time.NewTimer(s.options.DrainTimeout)
+	defer timeout.Stop()
+	select {
+	case <-done:
+		return
+	case <-timeout.C:
+	case <-ctx.Done():
+	}
+
+	s.mutex.Lock()
+	for _, job := range s.jobs {
+		if job.Status == StatusRunning {
+			job.cancel()
+		}
+	}
+	s.mutex.Unlock()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// dispatch starts queued jobs, highest priority first, while the concurrency
+// limits allow. The caller must hold the mutex.
+func (s *Server) dispatch() {
+	sort.SliceStable(s.queue, func(i, j int) bool {
+		return s.queue[i].Priority > s.queue[j].Priority
+	})
+
+	active := 0
+	for _, count := range s.running {
+		active += count
+	}
+
+	waiting := s.queue[:0]
+	for _, job := range s.queue {
+		if active >= s.options.MaxConcurrentScans || s.running[job.host] >= s.options.MaxScansPerTarget {
+			waiting = append(waiting, job)
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		if job.budget > 0 {
+			ctx, cancel = context.WithTimeoutCause(context.Background(), job.budget, budget.ErrExceeded)
+		}
+		job.cancel = cancel
+		job.Status = StatusRunning
+		job.Started = time.Now()
+		s.running[job.host]++
+		active++
+		metrics.ScansQueued.Dec()
+		s.publishJob(EventScanStarted, job)
+
+		s.active.Add(1)
+		go s.run(ctx, job)
+	}
+	s.queue = waiting
+}
+
+// run executes a job, records its outcome and starts the next queued jobs
+func (s *Server) run(ctx context.Context, job *Job) {
+	defer s.active.Done()
+	metrics.ScansRunning.Inc(job.Tool)
+	// The findings emitted with the context belong to the job
+	path, err := runners[job.Tool](eventbus.WithJob(s.scopeContext(ctx, job), job.ID), s.storeFor(job.Tenant), job.Target)
+	metrics.ScansRunning.Dec(job.Tool)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job.Finished = time.Now()
+	switch {
+	case ctx.Err() != nil && !budget.Exceeded(ctx):
+		job.Status = StatusCancelled
+	case err != nil:
+		job.Status = StatusFailed
+		job.Error = err.Error()
+		metrics.Errors.Inc(job.Tool)
+	default:
+		job.Status = StatusCompleted
+		job.Artifact = path
+		if budget.Exceeded(ctx) {
+			job.Incomplete = fmt.Sprintf("stopped after its %s time budget, the target was not fully scanned", job.budget)
+		}
+	}
+	job.cancel()
+	metrics.Scans.Inc(job.Tool, job.Status)
+	s.publishJob(finishedEvent(job.Status), job)
+
+	s.running[job.host]--
+	if s.running[job.host] == 0 {
+		delete(s.running, job.host)
+	}
+	s.dispatch()
+}
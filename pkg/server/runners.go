@@ -0,0 +1,66 @@
+package server
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/tools/recon/s3scanner"
+	"GopherStrike/pkg/tools/webvuln"
+	"context"
+	"strings"
+)
+
+// runner runs a scan against a target and returns the path of the saved
+// results. Runners stop early when the context is cancelled.
+type runner func(ctx context.Context, store *artifacts.Store, target string) (string, error)
+
+// runners maps tool names accepted by the API to their runners
+var runners = map[string]runner{
+	"webvuln":   runWebVuln,
+	"s3scanner": runS3Scanner,
+}
+
+// thirdPartyTools lists the tools sending their requests to third-party
+// services rather than to the target, such as the S3 endpoints of s3scanner
+var thirdPartyTools = map[string]bool{
+	"s3scanner": true,
+}
+
+// runWebVuln runs a web vulnerability scan with the default options
+func runWebVuln(ctx context.Context, store *artifacts.Store, target string) (string, error) {
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		target = "https://" + target
+	}
+
+	options := webvuln.DefaultScanOptions()
+	options.GenerateHTML = false
+
+	scanner := webvuln.NewScanner(options)
+	scanner.Store = store
+	scanTarget := webvuln.ScanTarget{URL: target, Method: "GET"}
+	if err := scanner.Preflight(ctx, scanTarget).Err(); err != nil {
+		return "", err
+	}
+	report, err := scanner.ScanContext(ctx, scanTarget)
+	if err != nil {
+		return "", err
+	}
+
+	return store.WriteJSON(target, artifacts.KindWeb, artifacts.TimestampedName("scan", "json"), report)
+}
+
+// runS3Scanner looks for S3 buckets named after the target
+func runS3Scanner(ctx context.Context, store *artifacts.Store, target string) (string, error) {
+	options := s3scanner.DefaultS3ScanOptions()
+	options.Verbose = false
+
+	path, err := store.Path(target, artifacts.KindRecon, artifacts.TimestampedName("s3buckets", "txt"))
+	if err != nil {
+		return "", err
+	}
+	options.OutputFile = path
+
+	scanner := s3scanner.NewScanner(options)
+	if _, err := scanner.ScanTargetContext(ctx, target); err != nil {
+		return "", err
+	}
+	return path, nil
+}
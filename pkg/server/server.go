@@ -0,0 +1,420 @@
+// Package server runs GopherStrike as a long-running API server
+package server
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/distributed"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/metrics"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Job states
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// ServerOptions contains options for the API server
+type ServerOptions struct {
+	Address            string
+	Workspace          string
+	AgentAddress       string        // gRPC address worker agents connect to; empty disables distributed scanning
+	GRPCAddress        string        // Address of the gRPC API; empty disables it
+	MaxConcurrentScans int           // Scans running at once across all targets
+	MaxScansPerTarget  int           // Scans running at once against the same target
+	Users              *UserStore    // API users; nil disables authentication
+	DrainTimeout       time.Duration // How long running scans may take to finish when the server shuts down
+}
+
+// DefaultServerOptions returns default options for the API server
+func DefaultServerOptions() ServerOptions {
+	return ServerOptions{
+		Address:            "127.0.0.1:8080",
+		Workspace:          artifacts.DefaultWorkspace,
+		MaxConcurrentScans: 2,
+		MaxScansPerTarget:  1,
+		DrainTimeout:       25 * time.Second,
+	}
+}
+
+// ScanRequest is the body of a request to start a scan
+type ScanRequest struct {
+	Tool        string `json:"tool"`
+	Target      string `json:"target"`
+	Priority    int    `json:"priority"`               // Higher priorities run first
+	MaxDuration string `json:"max_duration,omitempty"` // Time budget, e.g. "30m", after which the scan stops and keeps its partial results
+
+	SubmittedBy string `json:"-"` // Set from the authenticated user
+	Tenant      string `json:"-"` // Tenant of the authenticated user, whose scope and workspace the scan uses
+}
+
+// Job is a scan submitted to the server
+type Job struct {
+	ID         string    `json:"id"`
+	Tool       string    `json:"tool"`
+	Target     string    `json:"target"`
+	Priority   int       `json:"priority"`
+	User       string    `json:"user,omitempty"`   // User who submitted the scan
+	Tenant     string    `json:"tenant,omitempty"` // Tenant the scan belongs to
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	Artifact   string    `json:"artifact,omitempty"`   // Path of the saved results
+	Incomplete string    `json:"incomplete,omitempty"` // Why the scan did not cover the whole target
+	Submitted  time.Time `json:"submitted"`
+	Started    time.Time `json:"started,omitempty"`
+	Finished   time.Time `json:"finished,omitempty"`
+
+	host   string
+	budget time.Duration
+	cancel context.CancelFunc
+}
+
+// Server is the GopherStrike API server
+type Server struct {
+	options     ServerOptions
+	store       *artifacts.Store
+	coordinator *distributed.Coordinator
+	jobs        map[string]*Job
+	queue       []*Job         // Jobs waiting to run
+	running     map[string]int // Running jobs per target host
+	nextID      int
+	draining    bool           // Set on shutdown, when scans are no longer taken
+	active      sync.WaitGroup // Running jobs
+	mutex       sync.Mutex
+	http        *http.Server
+	grpc        *grpc.Server
+
+	subscribers         map[int]chan Event
+	nextSubscriber      int
+	subscriberMutex     sync.Mutex
+	unsubscribeFindings func()
+}
+
+// NewServer creates an API server
+func NewServer(options ServerOptions) *Server {
+	if options.MaxConcurrentScans < 1 {
+		options.MaxConcurrentScans = 1
+	}
+	if options.MaxScansPerTarget < 1 {
+		options.MaxScansPerTarget = 1
+	}
+
+	s := &Server{
+		options:     options,
+		store:       artifacts.NewStore(artifacts.DefaultRoot, options.Workspace),
+		jobs:        make(map[string]*Job),
+		running:     make(map[string]int),
+		subscribers: make(map[int]chan Event),
+	}
+	s.unsubscribeFindings = eventbus.Subscribe(eventbus.FindingNew, s.handleFinding)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /readyz", s.handleReady)
+	mux.HandleFunc("GET /metrics", s.requireRole(RoleReadOnly, allTenants(metrics.Handler().ServeHTTP)))
+	mux.HandleFunc("GET /api/scans", s.requireRole(RoleReadOnly, s.handleListScans))
+	mux.HandleFunc("POST /api/scans", s.requireRole(RoleOperator, s.handleCreateScan))
+	mux.HandleFunc("GET /api/scans/{id}", s.requireRole(RoleReadOnly, s.handleGetScan))
+	mux.HandleFunc("DELETE /api/scans/{id}", s.requireRole(RoleOperator, s.handleCancelScan))
+	mux.HandleFunc("GET /api/inventory", s.requireRole(RoleReadOnly, s.handleInventory))
+	mux.HandleFunc("GET /api/findings", s.requireRole(RoleReadOnly, s.handleFindings))
+	mux.HandleFunc("GET /api/reports", s.requireRole(RoleReadOnly, s.handleReports))
+	mux.HandleFunc("GET /api/artifacts/{target}/{kind}/{name}", s.requireRole(RoleReadOnly, s.handleDownloadArtifact))
+
+	// The dashboard itself is public; it asks for an API token to call the API
+	mux.Handle("GET /{$}", dashboardHandler())
+	mux.Handle("GET /static/", dashboardHandler())
+
+	if options.AgentAddress != "" {
+		s.coordinator = distributed.NewCoordinator(s.store)
+		mux.HandleFunc("GET /api/agents", s.requireRole(RoleReadOnly, allTenants(s.handleListAgents)))
+		mux.HandleFunc("GET /api/distributed", s.requireRole(RoleReadOnly, allTenants(s.handleListDistributed)))
+		mux.HandleFunc("POST /api/distributed", s.requireRole(RoleOperator, allTenants(s.handleCreateDistributed)))
+		mux.HandleFunc("GET /api/distributed/{id}", s.requireRole(RoleReadOnly, allTenants(s.handleGetDistributed)))
+	}
+
+	if options.Users != nil {
+		mux.HandleFunc("GET /api/users", s.requireRole(RoleAdmin, s.handleListUsers))
+		mux.HandleFunc("POST /api/users", s.requireRole(RoleAdmin, s.handleCreateUser))
+		mux.HandleFunc("DELETE /api/users/{name}", s.requireRole(RoleAdmin, s.handleDeleteUser))
+		mux.HandleFunc("GET /api/tenants", s.requireRole(RoleAdmin, allTenants(s.handleListTenants)))
+		mux.HandleFunc("POST /api/tenants", s.requireRole(RoleAdmin, allTenants(s.handleCreateTenant)))
+		mux.HandleFunc("DELETE /api/tenants/{name}", s.requireRole(RoleAdmin, allTenants(s.handleDeleteTenant)))
+	}
+
+	s.http = &http.Server{
+		Addr:              options.Address,
+		Handler:           instrument(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if options.GRPCAddress != "" {
+		s.grpc = s.newGRPCServer()
+	}
+	return s
+}
+
+// Handler returns the HTTP handler of the server
+func (s *Server) Handler() http.Handler {
+	return s.http.Handler
+}
+
+// ListenAndServe serves the API, and the coordinator and gRPC API if enabled,
+// until the server is shut down
+func (s *Server) ListenAndServe() error {
+	if s.coordinator != nil {
+		listener, err := net.Listen("tcp", s.options.AgentAddress)
+		if err != nil {
+			return fmt.Errorf("failed to listen for agents on %s: %v", s.options.AgentAddress, err)
+		}
+		go s.coordinator.Serve(listener)
+	}
+	if s.grpc != nil {
+		listener, err := net.Listen("tcp", s.options.GRPCAddress)
+		if err != nil {
+			return fmt.Errorf("failed to listen for gRPC clients on %s: %v", s.options.GRPCAddress, err)
+		}
+		go s.ServeGRPC(listener)
+	}
+
+	err := s.http.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops taking scans and waits for the running ones, and the tasks
+// agents are running, to finish and save their results, then stops accepting
+// requests and waits for active requests to finish. The API keeps answering
+// while the scans drain, but /readyz reports the server as not ready.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var wg sync.WaitGroup
+	if s.coordinator != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			drainCtx, cancel := context.WithTimeout(ctx, s.options.DrainTimeout)
+			defer cancel()
+			s.coordinator.Drain(drainCtx)
+		}()
+	}
+	s.drain(ctx)
+	wg.Wait()
+
+	if s.coordinator != nil {
+		s.coordinator.Stop()
+	}
+	if s.grpc != nil {
+		// Event streams never end on their own, so don't wait for them
+		s.grpc.Stop()
+	}
+	s.unsubscribeFindings()
+	return s.http.Shutdown(ctx)
+}
+
+// Job returns a copy of the job with the given ID
+func (s *Server) Job(id string) (Job, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Jobs returns a copy of every job, newest first
+func (s *Server) Jobs() []Job {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Submitted.After(jobs[j].Submitted)
+	})
+	return jobs
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReady reports whether the server takes scans: not while it shuts
+// down, nor when the workspace cannot be written
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	draining, queued := s.draining, len(s.queue)
+	running := 0
+	for _, count := range s.running {
+		running += count
+	}
+	s.mutex.Unlock()
+
+	ready := struct {
+		Status  string `json:"status"`
+		Reason  string `json:"reason,omitempty"`
+		Running int    `json:"running"`
+		Queued  int    `json:"queued"`
+	}{Status: "ready", Running: running, Queued: queued}
+	if err := os.MkdirAll(s.store.WorkspaceDir(), 0750); err != nil {
+		ready.Status, ready.Reason = "not ready", fmt.Sprintf("workspace unavailable: %v", err)
+	}
+	if draining {
+		ready.Status, ready.Reason = "not ready", errDraining.Error()
+	}
+	if ready.Reason != "" {
+		writeJSON(w, http.StatusServiceUnavailable, ready)
+		return
+	}
+	writeJSON(w, http.StatusOK, ready)
+}
+
+func (s *Server) handleListScans(w http.ResponseWriter, r *http.Request) {
+	tenant := requestTenant(r)
+	jobs := []Job{}
+	for _, job := range s.Jobs() {
+		if visible(job, tenant) {
+			jobs = append(jobs, job)
+		}
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Server) handleCreateScan(w http.ResponseWriter, r *http.Request) {
+	var req ScanRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return
+	}
+
+	if user, ok := requestUser(r); ok {
+		req.SubmittedBy, req.Tenant = user.Name, user.Tenant
+	}
+
+	job, err := s.Submit(req)
+	if err == errDraining {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	created, _ := s.Job(job.ID)
+	writeJSON(w, http.StatusAccepted, created)
+}
+
+func (s *Server) handleGetScan(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.Job(r.PathValue("id"))
+	if !ok || !visible(job, requestTenant(r)) {
+		writeError(w, http.StatusNotFound, errJobNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleCancelScan(w http.ResponseWriter, r *http.Request) {
+	if job, ok := s.Job(r.PathValue("id")); ok && !visible(job, requestTenant(r)) {
+		writeError(w, http.StatusNotFound, errJobNotFound)
+		return
+	}
+	job, err := s.Cancel(r.PathValue("id"))
+	if err != nil {
+		status := http.StatusConflict
+		if err == errJobNotFound {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleListAgents(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.coordinator.Agents())
+}
+
+func (s *Server) handleListDistributed(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.coordinator.Jobs())
+}
+
+func (s *Server) handleCreateDistributed(w http.ResponseWriter, r *http.Request) {
+	var req distributed.JobRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 10<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return
+	}
+
+	job, err := s.coordinator.Submit(req)
+	if err == distributed.ErrDraining {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func (s *Server) handleGetDistributed(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.coordinator.Job(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// instrument counts the requests served by a handler
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		metrics.APIRequests.Inc(r.Method, strconv.Itoa(recorder.status))
+	})
+}
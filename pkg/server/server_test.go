@@ -0,0 +1,694 @@
+package server
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/metrics"
+	"GopherStrike/pkg/scope"
+	"GopherStrike/pkg/security"
+	"GopherStrike/pkg/siem"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServerEndpoints(t *testing.T) {
+	srv := NewServer(ServerOptions{Address: "127.0.0.1:0", Workspace: "test"})
+	notFound := metrics.APIRequests.Value("GET", "404")
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		contains   string
+	}{
+		{"Health", "GET", "/health", "", http.StatusOK, `"ok"`},
+		{"Liveness", "GET", "/healthz", "", http.StatusOK, `"ok"`},
+		{"Readiness", "GET", "/readyz", "", http.StatusOK, `"status":"ready"`},
+		{"Metrics", "GET", "/metrics", "", http.StatusOK, "# TYPE gopherstrike_scans_running gauge"},
+		{"List scans", "GET", "/api/scans", "", http.StatusOK, "[]"},
+		{"Unknown scan", "GET", "/api/scans/42", "", http.StatusNotFound, "scan not found"},
+		{"Unsupported tool", "POST", "/api/scans", `{"tool":"nope","target":"example.com"}`, http.StatusBadRequest, "unsupported tool"},
+		{"Invalid target", "POST", "/api/scans", `{"tool":"webvuln","target":""}`, http.StatusBadRequest, "invalid target"},
+		{"Invalid body", "POST", "/api/scans", `{`, http.StatusBadRequest, "invalid request body"},
+		{"Invalid time budget", "POST", "/api/scans", `{"tool":"webvuln","target":"example.com","max_duration":"soon"}`, http.StatusBadRequest, "invalid max_duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.contains) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.contains)
+			}
+		})
+	}
+
+	// API requests are counted in the metrics
+	if got := metrics.APIRequests.Value("GET", "404") - notFound; got != 1 {
+		t.Errorf("counted %v new 404 responses, want 1", got)
+	}
+}
+
+func TestScanQueue(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan string, 10)
+	runners["test"] = func(ctx context.Context, store *artifacts.Store, target string) (string, error) {
+		started <- target
+		select {
+		case <-release:
+			return "", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	defer delete(runners, "test")
+
+	srv := NewServer(ServerOptions{Workspace: "test", MaxConcurrentScans: 2, MaxScansPerTarget: 1})
+
+	first, _ := srv.Submit(ScanRequest{Tool: "test", Target: "a.example.com"})
+	second, _ := srv.Submit(ScanRequest{Tool: "test", Target: "a.example.com"}) // Waits for the same target
+	low, _ := srv.Submit(ScanRequest{Tool: "test", Target: "b.example.com"})
+	<-started
+	<-started
+
+	// Both slots are busy, so new jobs queue up and the higher priority goes first
+	cancelled, _ := srv.Submit(ScanRequest{Tool: "test", Target: "c.example.com"})
+	high, _ := srv.Submit(ScanRequest{Tool: "test", Target: "d.example.com", Priority: 10})
+
+	status := func(id string) string {
+		job, _ := srv.Job(id)
+		return job.Status
+	}
+	if status(first.ID) != StatusRunning || status(low.ID) != StatusRunning {
+		t.Fatalf("first jobs for different targets should run")
+	}
+	if status(second.ID) != StatusQueued {
+		t.Errorf("second job for the same target = %s, want queued", status(second.ID))
+	}
+
+	if _, err := srv.Cancel(cancelled.ID); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if status(cancelled.ID) != StatusCancelled {
+		t.Errorf("cancelled job = %s, want cancelled", status(cancelled.ID))
+	}
+
+	// Cancelling a running job frees its slot for the highest priority job
+	if _, err := srv.Cancel(low.ID); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if target := <-started; target != "d.example.com" {
+		t.Errorf("next started job = %s, want the high priority one", target)
+	}
+	if status(low.ID) != StatusCancelled || status(high.ID) != StatusRunning {
+		t.Errorf("unexpected states: low %s, high %s", status(low.ID), status(high.ID))
+	}
+
+	if _, err := srv.Cancel(low.ID); err == nil {
+		t.Error("Cancel() of a finished job expected an error")
+	}
+	if _, err := srv.Cancel("999"); err != errJobNotFound {
+		t.Errorf("Cancel() of an unknown job error = %v, want %v", err, errJobNotFound)
+	}
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for status(second.ID) != StatusCompleted {
+		if time.Now().After(deadline) {
+			t.Fatalf("queued job did not complete: %s", status(second.ID))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestScanTimeBudget(t *testing.T) {
+	runners["test"] = func(ctx context.Context, store *artifacts.Store, target string) (string, error) {
+		<-ctx.Done()
+		return "partial.json", nil // The results gathered before the budget elapsed
+	}
+	defer delete(runners, "test")
+
+	srv := NewServer(ServerOptions{Workspace: "test", MaxConcurrentScans: 2, MaxScansPerTarget: 1})
+	job, err := srv.Submit(ScanRequest{Tool: "test", Target: "a.example.com", MaxDuration: "20ms"})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, _ := srv.Job(job.ID)
+		if got.Status != StatusRunning {
+			if got.Status != StatusCompleted || got.Artifact != "partial.json" || !strings.Contains(got.Incomplete, "20ms time budget") {
+				t.Errorf("job = %+v, want it completed with its partial results", got)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the scan did not stop when its budget elapsed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestShutdownDrain(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan string, 10)
+	runners["test"] = func(ctx context.Context, store *artifacts.Store, target string) (string, error) {
+		started <- target
+		select {
+		case <-release:
+			return "results.json", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	defer delete(runners, "test")
+
+	srv := NewServer(ServerOptions{Workspace: "test", MaxConcurrentScans: 1, MaxScansPerTarget: 1, DrainTimeout: 5 * time.Second})
+	running, _ := srv.Submit(ScanRequest{Tool: "test", Target: "a.example.com"})
+	queued, _ := srv.Submit(ScanRequest{Tool: "test", Target: "b.example.com"})
+	<-started
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- srv.Shutdown(context.Background()) }()
+
+	// While draining, the server is not ready and takes no scans, but the
+	// running scan finishes and keeps its results
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+		if rec.Code == http.StatusServiceUnavailable {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("/readyz still ready while shutting down")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/api/scans", strings.NewReader(`{"tool":"test","target":"c.example.com"}`)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("scan submitted while draining: status %d, want 503", rec.Code)
+	}
+	if job, _ := srv.Job(queued.ID); job.Status != StatusCancelled {
+		t.Errorf("queued job = %s, want cancelled", job.Status)
+	}
+
+	close(release)
+	if err := <-stopped; err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if job, _ := srv.Job(running.ID); job.Status != StatusCompleted || job.Artifact != "results.json" {
+		t.Errorf("running job = %+v, want it completed with its results", job)
+	}
+}
+
+func TestShutdownDrainTimeout(t *testing.T) {
+	runners["test"] = func(ctx context.Context, store *artifacts.Store, target string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	defer delete(runners, "test")
+
+	srv := NewServer(ServerOptions{Workspace: "test", MaxConcurrentScans: 1, MaxScansPerTarget: 1, DrainTimeout: 20 * time.Millisecond})
+	job, _ := srv.Submit(ScanRequest{Tool: "test", Target: "a.example.com"})
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if got, _ := srv.Job(job.ID); got.Status != StatusCancelled {
+		t.Errorf("job still running after the drain timeout = %s, want cancelled", got.Status)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	keystore, err := security.NewSecureKeyStore(filepath.Join(t.TempDir(), "keystore.json"), "password")
+	if err != nil {
+		t.Fatalf("NewSecureKeyStore() error = %v", err)
+	}
+	users := NewUserStore(keystore)
+
+	tokens := make(map[string]string)
+	for _, role := range []string{RoleAdmin, RoleOperator, RoleReadOnly} {
+		token, err := users.Add(role+"-user", role)
+		if err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+		tokens[role] = token
+	}
+	if _, err := users.Add("admin-user", RoleAdmin); err == nil {
+		t.Error("Add() of an existing user expected an error")
+	}
+	if _, err := users.Add("bad name", RoleAdmin); err == nil {
+		t.Error("Add() with an invalid name expected an error")
+	}
+
+	srv := NewServer(ServerOptions{Workspace: "test", Users: users})
+
+	tests := []struct {
+		name       string
+		token      string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"Health is public", "", "GET", "/health", "", http.StatusOK},
+		{"No token", "", "GET", "/api/scans", "", http.StatusUnauthorized},
+		{"Unknown token", "gst_0000", "GET", "/api/scans", "", http.StatusUnauthorized},
+		{"Read-only can read", tokens[RoleReadOnly], "GET", "/api/scans", "", http.StatusOK},
+		{"Read-only can scrape metrics", tokens[RoleReadOnly], "GET", "/metrics", "", http.StatusOK},
+		{"Read-only cannot scan", tokens[RoleReadOnly], "POST", "/api/scans", `{"tool":"nope","target":"example.com"}`, http.StatusForbidden},
+		{"Operator can scan", tokens[RoleOperator], "POST", "/api/scans", `{"tool":"nope","target":"example.com"}`, http.StatusBadRequest},
+		{"Operator cannot manage users", tokens[RoleOperator], "GET", "/api/users", "", http.StatusForbidden},
+		{"Admin can manage users", tokens[RoleAdmin], "GET", "/api/users", "", http.StatusOK},
+		{"Admin adds user", tokens[RoleAdmin], "POST", "/api/users", `{"name":"new","role":"operator"}`, http.StatusCreated},
+		{"Admin cannot remove itself", tokens[RoleAdmin], "DELETE", "/api/users/admin-user", "", http.StatusBadRequest},
+		{"Admin removes user", tokens[RoleAdmin], "DELETE", "/api/users/new", "", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+
+	// Rotating a token revokes the old one
+	if _, err := users.Rotate("operator-user"); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if _, err := users.Authenticate(tokens[RoleOperator]); err == nil {
+		t.Error("old token still authenticates after rotation")
+	}
+
+	// The gRPC API checks the same tokens
+	grpcSrv := NewServer(ServerOptions{Workspace: "test", Users: users, GRPCAddress: "127.0.0.1:0"})
+	defer grpcSrv.Shutdown(context.Background())
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go grpcSrv.ServeGRPC(listener)
+
+	for _, tt := range []struct {
+		token string
+		want  codes.Code
+	}{
+		{"", codes.Unauthenticated},
+		{tokens[RoleReadOnly], codes.PermissionDenied},
+		{tokens[RoleAdmin], codes.InvalidArgument},
+	} {
+		client, err := NewClient(listener.Addr().String(), tt.token)
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		_, err = client.Submit(context.Background(), ScanRequest{Tool: "nope", Target: "example.com"})
+		if status.Code(err) != tt.want {
+			t.Errorf("gRPC Submit() error = %v, want %s", err, tt.want)
+		}
+		client.Close()
+	}
+}
+
+func TestTenants(t *testing.T) {
+	var inScope, outOfScope error // Scope checks of the links followed by the scan
+	runners["test"] = func(ctx context.Context, store *artifacts.Store, target string) (string, error) {
+		inScope = scope.Check(ctx, "https://login.acme.com/")
+		outOfScope = scope.Check(ctx, "https://www.globex.com/")
+		siem.EmitContext(ctx, siem.Finding{Tool: "test", Target: target, Name: "Weak password", Severity: "high"})
+		path, err := store.Path(target, artifacts.KindWeb, "scan.txt")
+		if err != nil {
+			return "", err
+		}
+		return path, os.WriteFile(path, []byte("done"), 0644)
+	}
+	defer delete(runners, "test")
+
+	keystore, err := security.NewSecureKeyStore(filepath.Join(t.TempDir(), "keystore.json"), "password")
+	if err != nil {
+		t.Fatalf("NewSecureKeyStore() error = %v", err)
+	}
+	users := NewUserStore(keystore)
+	if _, err := users.AddTenant("acme", []string{"*.acme.com"}); err != nil {
+		t.Fatalf("AddTenant() error = %v", err)
+	}
+	if _, err := users.AddTenant("globex", []string{"10.0.0.0/24"}); err != nil {
+		t.Fatalf("AddTenant() error = %v", err)
+	}
+	if _, err := users.AddTenant("empty", nil); err == nil {
+		t.Error("AddTenant() without a scope expected an error")
+	}
+	// Names are used as is for the workspaces
+	for _, name := range []string{".", "..", "acme.", ".acme", "a/b"} {
+		if _, err := users.AddTenant(name, []string{"*.acme.com"}); err == nil {
+			t.Errorf("AddTenant(%q) expected an error", name)
+		}
+	}
+	if _, err := users.AddToTenant("nobody", RoleOperator, "initech"); err == nil {
+		t.Error("AddToTenant() of an unknown tenant expected an error")
+	}
+
+	tokens := make(map[string]string)
+	for name, tenant := range map[string]string{"acme-admin": "acme", "acme-op": "acme", "globex-op": "globex", "admin": ""} {
+		role := RoleOperator
+		if strings.HasSuffix(name, "admin") {
+			role = RoleAdmin
+		}
+		token, err := users.AddToTenant(name, role, tenant)
+		if err != nil {
+			t.Fatalf("AddToTenant() error = %v", err)
+		}
+		tokens[name] = token
+	}
+	if err := users.RemoveTenant("acme"); err == nil {
+		t.Error("RemoveTenant() of a tenant with users expected an error")
+	}
+
+	srv := NewServer(ServerOptions{Workspace: "test", Users: users})
+	defer srv.Shutdown(context.Background())
+	srv.store = artifacts.NewStore(t.TempDir(), "test")
+
+	request := func(token, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := request(tokens["acme-op"], "POST", "/api/scans", `{"tool":"test","target":"www.acme.com"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("in-scope scan status = %d, want %d (%s)", rec.Code, http.StatusAccepted, rec.Body.String())
+	}
+	var job Job
+	json.Unmarshal(rec.Body.Bytes(), &job)
+	if job.Tenant != "acme" {
+		t.Errorf("job tenant = %q, want acme", job.Tenant)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for current, _ := srv.Job(job.ID); current.Status != StatusCompleted; current, _ = srv.Job(job.ID) {
+		if time.Now().After(deadline) {
+			t.Fatalf("tenant job did not complete: %s %s", current.Status, current.Error)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := os.Stat(filepath.Join(srv.store.Root, "tenants", "acme")); err != nil {
+		t.Errorf("tenant results not in its workspace: %v", err)
+	}
+	if inScope != nil || outOfScope == nil {
+		t.Errorf("scan scope checks = %v, %v, want the tenant's scope enforced", inScope, outOfScope)
+	}
+	// The findings of the scan are recorded in the tenant's workspace only
+	if findings, err := srv.storeFor("acme").Findings(); err != nil || len(findings) != 1 {
+		t.Errorf("tenant findings = %v, %v, want the scan's finding", findings, err)
+	}
+	if findings, _ := srv.store.Findings(); len(findings) != 0 {
+		t.Errorf("server workspace findings = %v, want none", findings)
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"Out of scope scan", tokens["acme-op"], "POST", "/api/scans", `{"tool":"test","target":"10.0.0.5"}`, http.StatusBadRequest},
+		{"Other tenant's scan", tokens["globex-op"], "GET", "/api/scans/" + job.ID, "", http.StatusNotFound},
+		{"Other tenant cannot cancel", tokens["globex-op"], "DELETE", "/api/scans/" + job.ID, "", http.StatusNotFound},
+		{"Own tenant's scan", tokens["acme-op"], "GET", "/api/scans/" + job.ID, "", http.StatusOK},
+		{"Users of no tenant see all", tokens["admin"], "GET", "/api/scans/" + job.ID, "", http.StatusOK},
+		{"Tenant admin cannot manage tenants", tokens["acme-admin"], "GET", "/api/tenants", "", http.StatusForbidden},
+		{"Admin lists tenants", tokens["admin"], "GET", "/api/tenants", "", http.StatusOK},
+		{"Tenant users cannot scrape metrics", tokens["acme-admin"], "GET", "/metrics", "", http.StatusForbidden},
+		{"Users of no tenant scrape metrics", tokens["admin"], "GET", "/metrics", "", http.StatusOK},
+		{"Admin cannot remove a tenant with users", tokens["admin"], "DELETE", "/api/tenants/acme", "", http.StatusConflict},
+		{"Tenant admin cannot add users elsewhere", tokens["acme-admin"], "POST", "/api/users", `{"name":"spy","role":"operator","tenant":"globex"}`, http.StatusForbidden},
+		{"Tenant admin adds own users", tokens["acme-admin"], "POST", "/api/users", `{"name":"acme-ro","role":"read-only"}`, http.StatusCreated},
+		{"Tenant admin cannot remove other users", tokens["acme-admin"], "DELETE", "/api/users/globex-op", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := request(tt.token, tt.method, tt.path, tt.body); rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+
+	// Listings only show the tenant's own scans, users and results
+	for _, tt := range []struct {
+		token, path, want string
+		visible           bool
+	}{
+		{tokens["acme-op"], "/api/scans", "www.acme.com", true},
+		{tokens["globex-op"], "/api/scans", "www.acme.com", false},
+		{tokens["acme-op"], "/api/inventory", "www.acme.com", true},
+		{tokens["globex-op"], "/api/inventory", "www.acme.com", false},
+		{tokens["acme-admin"], "/api/users", "acme-op", true},
+		{tokens["acme-admin"], "/api/users", "globex-op", false},
+	} {
+		body := request(tt.token, "GET", tt.path, "").Body.String()
+		if strings.Contains(body, tt.want) != tt.visible {
+			t.Errorf("GET %s shows %s = %v, want %v: %s", tt.path, tt.want, !tt.visible, tt.visible, body)
+		}
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	srv := NewServer(ServerOptions{Address: "127.0.0.1:0", Workspace: "test"})
+	srv.store = artifacts.NewStore(t.TempDir(), "test")
+
+	if _, err := srv.store.WriteJSON("example.com", artifacts.KindWeb, "scan_1.json", map[string]string{"severity": "high"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.store.WriteFile("example.com", artifacts.KindReports, "security_report_1.html", []byte("<h1>Report</h1>")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		contains   string
+	}{
+		{"Index", "/", http.StatusOK, "<title>GopherStrike Dashboard</title>"},
+		{"Script", "/static/app.js", http.StatusOK, "/api/findings"},
+		{"Unknown page", "/nope", http.StatusNotFound, ""},
+		{"Findings", "/api/findings", http.StatusOK, `"download":"/api/artifacts/example.com/web/scan_1.json"`},
+		{"Findings above high", "/api/findings?severity=critical", http.StatusOK, "[]"},
+		{"Invalid limit", "/api/findings?limit=0", http.StatusBadRequest, "invalid limit"},
+		{"Reports", "/api/reports", http.StatusOK, `"name":"security_report_1.html"`},
+		{"Inventory", "/api/inventory", http.StatusOK, `"host":"example.com"`},
+		{"Download", "/api/artifacts/example.com/reports/security_report_1.html", http.StatusOK, "<h1>Report</h1>"},
+		{"Missing artifact", "/api/artifacts/example.com/reports/missing.html", http.StatusNotFound, "artifact not found"},
+		{"Traversal", "/api/artifacts/example.com/..%2F..%2F..%2F/tags.json", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.contains) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tt.contains)
+			}
+		})
+	}
+}
+
+func TestFindingsOfConcurrentScans(t *testing.T) {
+	// Both scans run before either reports its finding, a distinct one
+	var started sync.WaitGroup
+	started.Add(2)
+	var emitted int32
+	runners["test"] = func(ctx context.Context, store *artifacts.Store, target string) (string, error) {
+		started.Done()
+		both := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(both)
+		}()
+		select {
+		case <-both:
+		case <-time.After(5 * time.Second):
+			return "", fmt.Errorf("the other scan did not start")
+		}
+		name := fmt.Sprintf("Weak password %d", atomic.AddInt32(&emitted, 1))
+		siem.EmitContext(ctx, siem.Finding{Tool: "test", Target: target, Name: name, Severity: "high"})
+		return "", nil
+	}
+	defer delete(runners, "test")
+
+	keystore, err := security.NewSecureKeyStore(filepath.Join(t.TempDir(), "keystore.json"), "password")
+	if err != nil {
+		t.Fatalf("NewSecureKeyStore() error = %v", err)
+	}
+	users := NewUserStore(keystore)
+	for _, tenant := range []string{"acme", "globex"} {
+		if _, err := users.AddTenant(tenant, []string{"*.example.com"}); err != nil {
+			t.Fatalf("AddTenant() error = %v", err)
+		}
+	}
+
+	srv := NewServer(ServerOptions{Workspace: "test", Users: users, MaxConcurrentScans: 2, MaxScansPerTarget: 2})
+	defer srv.Shutdown(context.Background())
+	srv.store = artifacts.NewStore(t.TempDir(), "test")
+
+	jobs := []*Job{}
+	for _, tenant := range []string{"acme", "globex"} {
+		job, err := srv.Submit(ScanRequest{Tool: "test", Target: "www.example.com", Tenant: tenant})
+		if err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+		jobs = append(jobs, job)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for _, job := range jobs {
+		for current, _ := srv.Job(job.ID); current.Status != StatusCompleted; current, _ = srv.Job(job.ID) {
+			if time.Now().After(deadline) {
+				t.Fatalf("job %s did not complete: %s %s", job.ID, current.Status, current.Error)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Each tenant gets the finding of its own scan only
+	for _, tenant := range []string{"acme", "globex"} {
+		if findings, err := srv.storeFor(tenant).Findings(); err != nil || len(findings) != 1 {
+			t.Errorf("%s findings = %v, %v, want its scan's finding", tenant, findings, err)
+		}
+	}
+	if findings, _ := srv.store.Findings(); len(findings) != 0 {
+		t.Errorf("server workspace findings = %v, want none", findings)
+	}
+}
+
+func TestGRPCEvents(t *testing.T) {
+	release := make(chan struct{})
+	runners["test"] = func(ctx context.Context, store *artifacts.Store, target string) (string, error) {
+		<-release
+		siem.EmitContext(ctx, siem.Finding{Tool: "test", Target: "https://" + target + "/login", Name: "Weak password", Severity: "high"})
+		return "", nil
+	}
+	defer delete(runners, "test")
+
+	srv := NewServer(ServerOptions{Workspace: "test", GRPCAddress: "127.0.0.1:0"})
+	defer srv.Shutdown(context.Background())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.ServeGRPC(listener)
+
+	client, err := NewClient(listener.Addr().String(), "")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	job, err := client.Submit(ctx, ScanRequest{Tool: "test", Target: "example.com"})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if _, err := client.Get(ctx, "999"); status.Code(err) != codes.NotFound {
+		t.Errorf("Get() of an unknown scan error = %v, want NotFound", err)
+	}
+
+	// Release the scan once the event stream is subscribed
+	go func() {
+		for {
+			srv.subscriberMutex.Lock()
+			subscribed := len(srv.subscribers) > 0
+			srv.subscriberMutex.Unlock()
+			if subscribed {
+				close(release)
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	types := []string{}
+	err = client.Events(ctx, EventsRequest{JobID: job.ID}, func(event Event) error {
+		types = append(types, event.Type)
+		if event.Type == EventFindingNew && (event.Finding == nil || event.Finding.Name != "Weak password") {
+			t.Errorf("finding event = %+v, want the emitted finding", event.Finding)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Events() error = %v", err)
+	}
+
+	want := []string{EventFindingNew, EventScanCompleted}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", types, want)
+	}
+
+	// Streaming a finished scan returns its final event right away
+	types = nil
+	client.Events(ctx, EventsRequest{JobID: job.ID, Types: []string{"scan.*"}}, func(event Event) error {
+		types = append(types, event.Type)
+		return nil
+	})
+	if len(types) != 1 || types[0] != EventScanCompleted {
+		t.Errorf("events of a finished scan = %v, want [%s]", types, EventScanCompleted)
+	}
+}
+
+func TestMatchesEventTypes(t *testing.T) {
+	tests := []struct {
+		eventType string
+		types     []string
+		want      bool
+	}{
+		{EventScanStarted, nil, true},
+		{EventScanStarted, []string{"scan.*"}, true},
+		{EventFindingNew, []string{"scan.*"}, false},
+		{EventFindingNew, []string{"scan.completed", "finding.new"}, true},
+		{"scanner.x", []string{"scan.*"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesEventTypes(tt.eventType, tt.types); got != tt.want {
+			t.Errorf("matchesEventTypes(%q, %v) = %v, want %v", tt.eventType, tt.types, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/scope"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// tenantKeyPrefix prefixes the keystore keys holding tenants
+	tenantKeyPrefix = "api_tenant:"
+
+	// tenantsDir is the directory of the artifact root holding the tenant
+	// workspaces, apart from the server's own workspace
+	tenantsDir = "tenants"
+)
+
+// errOtherTenants is returned for endpoints serving every tenant at once
+var errOtherTenants = errors.New("not available to tenant users")
+
+// errTenantNotFound is returned for unknown tenants
+var errTenantNotFound = errors.New("tenant not found")
+
+// Tenant is a business unit sharing the server with others. Its users only
+// see its own scans and results, which are kept in the workspace named
+// after it under tenants/, and can only scan the targets of its scope.
+type Tenant struct {
+	Name    string    `json:"name"`
+	Scope   []string  `json:"scope"` // Targets the tenant may scan, in the syntax of exclusions files
+	Created time.Time `json:"created"`
+}
+
+// Allows reports whether a target, a host or a URL, is in the tenant's
+// scope. Hostnames are matched as given, without resolving them.
+func (t Tenant) Allows(target string) bool {
+	rules := t.rules()
+	if rules == nil {
+		return false
+	}
+	if strings.Contains(target, "://") {
+		return rules.ExcludesURL(target)
+	}
+	return rules.ExcludesHost(artifacts.NormalizeTarget(target))
+}
+
+// rules returns the parsed scope of the tenant, nil if it is invalid
+func (t Tenant) rules() *scope.Exclusions {
+	rules, err := scope.Parse(strings.NewReader(strings.Join(t.Scope, "\n")))
+	if err != nil {
+		return nil
+	}
+	return rules
+}
+
+// scopeContext limits the connections of a tenant's scan to its scope,
+// including the pages, redirects and resources the scan reaches from the
+// submitted target. Tools whose requests go to third-party services rather
+// than the target are only checked at submission.
+func (s *Server) scopeContext(ctx context.Context, job *Job) context.Context {
+	if job.Tenant == "" || thirdPartyTools[job.Tool] {
+		return ctx
+	}
+	var rules *scope.Exclusions
+	if s.options.Users != nil {
+		if tenant, err := s.options.Users.Tenant(job.Tenant); err == nil {
+			rules = tenant.rules()
+		}
+	}
+	// A tenant removed since allows nothing
+	return scope.WithAllowed(ctx, rules)
+}
+
+// AddTenant creates a tenant allowed to scan the targets of its scope
+func (us *UserStore) AddTenant(name string, targets []string) (Tenant, error) {
+	// The name is used as is for the workspace, so that no two tenants
+	// share one
+	if !userNamePattern.MatchString(name) || artifacts.NewStore("", name).Workspace != name {
+		return Tenant{}, fmt.Errorf("invalid tenant name: %q", name)
+	}
+	if len(targets) == 0 {
+		return Tenant{}, fmt.Errorf("the scope of a tenant needs at least one target")
+	}
+	if _, err := scope.Parse(strings.NewReader(strings.Join(targets, "\n"))); err != nil {
+		return Tenant{}, fmt.Errorf("invalid scope: %v", err)
+	}
+
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+
+	if us.keystore.Exists(tenantKeyPrefix + name) {
+		return Tenant{}, fmt.Errorf("tenant already exists: %s", name)
+	}
+	tenant := Tenant{Name: name, Scope: targets, Created: time.Now()}
+	data, err := json.Marshal(tenant)
+	if err != nil {
+		return Tenant{}, fmt.Errorf("failed to encode tenant: %v", err)
+	}
+	if err := us.keystore.Set(tenantKeyPrefix+name, string(data)); err != nil {
+		return Tenant{}, err
+	}
+	return tenant, nil
+}
+
+// Tenant returns the tenant with the given name
+func (us *UserStore) Tenant(name string) (Tenant, error) {
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+	return us.getTenant(name)
+}
+
+// Tenants returns all tenants sorted by name
+func (us *UserStore) Tenants() []Tenant {
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+
+	tenants := []Tenant{}
+	for _, key := range us.keystore.List() {
+		if !strings.HasPrefix(key, tenantKeyPrefix) {
+			continue
+		}
+		if tenant, err := us.getTenant(strings.TrimPrefix(key, tenantKeyPrefix)); err == nil {
+			tenants = append(tenants, tenant)
+		}
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].Name < tenants[j].Name })
+	return tenants
+}
+
+// RemoveTenant deletes a tenant without users. Its workspace is kept.
+func (us *UserStore) RemoveTenant(name string) error {
+	for _, user := range us.List() {
+		if user.Tenant == name {
+			return fmt.Errorf("tenant %s still has users, remove them first", name)
+		}
+	}
+
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+
+	if !us.keystore.Exists(tenantKeyPrefix + name) {
+		return fmt.Errorf("%w: %s", errTenantNotFound, name)
+	}
+	return us.keystore.Delete(tenantKeyPrefix + name)
+}
+
+// getTenant loads a tenant from the keystore. The caller must hold the mutex.
+func (us *UserStore) getTenant(name string) (Tenant, error) {
+	value, err := us.keystore.Get(tenantKeyPrefix + name)
+	if err != nil {
+		return Tenant{}, fmt.Errorf("%w: %s", errTenantNotFound, name)
+	}
+
+	var tenant Tenant
+	if err := json.Unmarshal([]byte(value), &tenant); err != nil {
+		return Tenant{}, fmt.Errorf("failed to decode tenant %s: %v", name, err)
+	}
+	return tenant, nil
+}
+
+// requestTenant returns the tenant of the user of a request, or "" for users
+// of no tenant, who see every tenant, and when authentication is disabled
+func requestTenant(r *http.Request) string {
+	user, _ := requestUser(r)
+	return user.Tenant
+}
+
+// visible reports whether a job can be seen by the users of a tenant
+func visible(job Job, tenant string) bool {
+	return tenant == "" || job.Tenant == tenant
+}
+
+// storeFor returns the results store of a tenant: the workspace named after
+// it under tenants/, or the server's workspace for users of no tenant
+func (s *Server) storeFor(tenant string) *artifacts.Store {
+	if tenant == "" {
+		return s.store
+	}
+	return artifacts.NewStore(filepath.Join(s.store.Root, tenantsDir), tenant)
+}
+
+// allTenants wraps a handler serving every tenant at once, such as the
+// distributed scans, so that it is refused to tenant users
+func allTenants(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if requestTenant(r) != "" {
+			writeError(w, http.StatusForbidden, errOtherTenants)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// tenantRequest is the body of a request to create a tenant
+type tenantRequest struct {
+	Name  string   `json:"name"`
+	Scope []string `json:"scope"`
+}
+
+func (s *Server) handleListTenants(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.options.Users.Tenants())
+}
+
+func (s *Server) handleCreateTenant(w http.ResponseWriter, r *http.Request) {
+	var req tenantRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return
+	}
+
+	tenant, err := s.options.Users.AddTenant(req.Name, req.Scope)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, tenant)
+}
+
+func (s *Server) handleDeleteTenant(w http.ResponseWriter, r *http.Request) {
+	if err := s.options.Users.RemoveTenant(r.PathValue("name")); err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, errTenantNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,287 @@
+// Package session records the session of a command-line run, its command
+// line, configuration, random seed, HTTP exchanges and findings, to a JSON
+// lines file, and replays it. A replayed run executes the recorded command
+// again with the recorded settings and seed, the HTTP clients answering from
+// the recording instead of the network, and reports where it departs from
+// the recording: requests that were not recorded, recorded requests that
+// were not sent again and findings that differ. Sessions help debug a scan
+// whose results changed and evidence to clients how a test was performed.
+package session
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/model"
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Version is the version of the session file format
+const Version = 1
+
+// errNotRecorded is returned for a request of a replay that was not recorded
+var errNotRecorded = errors.New("request not in the recorded session")
+
+// Record types of a session file
+const (
+	TypeHeader   = "session"
+	TypeExchange = "exchange"
+	TypeFinding  = "finding"
+)
+
+// Header describes a recorded run
+type Header struct {
+	Version int             `json:"version"`
+	Args    []string        `json:"args"`             // Command line, without the program name
+	Config  json.RawMessage `json:"config,omitempty"` // Configuration of the run, without the API keys
+	Seed    uint64          `json:"seed"`             // Seed of the random values of the scans
+	Started time.Time       `json:"started"`
+}
+
+// record is a line of a session file
+type record struct {
+	Type     string         `json:"type"`
+	Header   *Header        `json:"header,omitempty"`
+	Exchange *Exchange      `json:"exchange,omitempty"`
+	Finding  *model.Finding `json:"finding,omitempty"`
+}
+
+// Session is a recorded run, as loaded for replay
+type Session struct {
+	Header    Header
+	Exchanges []Exchange
+	Findings  []model.Finding
+}
+
+// Discrepancies are the differences between a replayed run and its recording
+type Discrepancies struct {
+	Replayed      int             `json:"replayed"`       // Requests answered from the recording
+	NotRecorded   []string        `json:"not_recorded"`   // Requests sent in the replay only
+	NotReplayed   []string        `json:"not_replayed"`   // Requests sent in the recording only
+	FindingsLost  []model.Finding `json:"findings_lost"`  // Findings of the recording only
+	FindingsAdded []model.Finding `json:"findings_added"` // Findings of the replay only
+}
+
+// Empty reports whether the replay matched the recording
+func (d Discrepancies) Empty() bool {
+	return len(d.NotRecorded) == 0 && len(d.NotReplayed) == 0 && len(d.FindingsLost) == 0 && len(d.FindingsAdded) == 0
+}
+
+var (
+	mutex     sync.Mutex
+	writer    *artifacts.StreamWriter // Session being recorded
+	replaying *Session                // Session being replayed
+	random    *rand.ChaCha8           // Source of random values while recording or replaying
+	sequence  int                     // Exchanges recorded
+	pending   map[string][]Exchange   // Recorded exchanges not replayed yet, by request
+	missing   []string                // Requests of the replay that were not recorded
+	replayed  int                     // Requests answered from the recording
+	findings  []model.Finding         // Findings of the replay
+)
+
+// Record starts recording a run to the file at path. args is the command
+// line and config the configuration of the run; API keys are left out.
+func Record(path string, args []string, config interface{}) error {
+	var seed [8]byte
+	if _, err := crand.Read(seed[:]); err != nil {
+		return err
+	}
+	header := Header{
+		Version: Version,
+		Args:    args,
+		Seed:    binary.LittleEndian.Uint64(seed[:]),
+		Started: time.Now(),
+	}
+	var err error
+	if header.Config, err = snapshot(config); err != nil {
+		return err
+	}
+
+	w, err := artifacts.NewStreamWriter(path)
+	if err != nil {
+		return err
+	}
+	if err := w.WriteJSON(record{Type: TypeHeader, Header: &header}); err != nil {
+		w.Close()
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	writer, replaying, sequence = w, nil, 0
+	random = newRandom(header.Seed)
+	return nil
+}
+
+// snapshot returns the configuration as JSON, without its API keys
+func snapshot(config interface{}) (json.RawMessage, error) {
+	if config == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record the configuration: %v", err)
+	}
+	var settings map[string]interface{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return data, nil
+	}
+	if tools, ok := settings["tools"].(map[string]interface{}); ok {
+		for _, tool := range tools {
+			if tool, ok := tool.(map[string]interface{}); ok {
+				delete(tool, "api_keys")
+			}
+		}
+	}
+	return json.Marshal(settings)
+}
+
+// Load reads a session file
+func Load(path string) (*Session, error) {
+	s := &Session{}
+	found := false
+	err := artifacts.ReadStream(path, func(line json.RawMessage) error {
+		var r record
+		if err := json.Unmarshal(line, &r); err != nil {
+			return fmt.Errorf("invalid session record: %v", err)
+		}
+		switch {
+		case r.Type == TypeHeader && r.Header != nil:
+			s.Header, found = *r.Header, true
+		case r.Type == TypeExchange && r.Exchange != nil:
+			s.Exchanges = append(s.Exchanges, *r.Exchange)
+		case r.Type == TypeFinding && r.Finding != nil:
+			s.Findings = append(s.Findings, *r.Finding)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s is not a session file", path)
+	}
+	if s.Header.Version > Version {
+		return nil, fmt.Errorf("%s was recorded by a newer version (format %d)", path, s.Header.Version)
+	}
+	if len(s.Header.Args) == 0 {
+		return nil, fmt.Errorf("%s has no command to replay", path)
+	}
+	return s, nil
+}
+
+// Replay starts replaying a session: the random values come from its seed
+// and the HTTP clients answer from its exchanges
+func Replay(s *Session) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	writer, replaying = nil, s
+	random = newRandom(s.Header.Seed)
+	pending = make(map[string][]Exchange)
+	for _, exchange := range s.Exchanges {
+		key := exchange.Request.key()
+		pending[key] = append(pending[key], exchange)
+	}
+	missing, replayed, findings = nil, 0, nil
+}
+
+// Replaying reports whether a session is being replayed
+func Replaying() bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return replaying != nil
+}
+
+// AddFinding records a finding of the run, to be compared when the session
+// is replayed
+func AddFinding(f model.Finding) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	switch {
+	case writer != nil:
+		if err := writer.WriteJSON(record{Type: TypeFinding, Finding: &f}); err != nil {
+			fmt.Printf("Warning: failed to record finding in the session: %v\n", err)
+		}
+	case replaying != nil:
+		findings = append(findings, f)
+	}
+}
+
+// Stop stops recording or replaying. For a replay it returns how the run
+// departed from the recording.
+func Stop() (Discrepancies, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	random = nil
+	if writer != nil {
+		err := writer.Close()
+		writer = nil
+		return Discrepancies{}, err
+	}
+	if replaying == nil {
+		return Discrepancies{}, nil
+	}
+
+	d := Discrepancies{Replayed: replayed, NotRecorded: missing}
+	for _, exchanges := range pending {
+		for _, exchange := range exchanges {
+			d.NotReplayed = append(d.NotReplayed, exchange.Request.String())
+		}
+	}
+	sort.Strings(d.NotReplayed)
+	d.FindingsLost = difference(replaying.Findings, findings)
+	d.FindingsAdded = difference(findings, replaying.Findings)
+	replaying, pending, missing, findings = nil, nil, nil, nil
+	return d, nil
+}
+
+// difference returns the findings of a whose ID is not in b
+func difference(a, b []model.Finding) []model.Finding {
+	ids := make(map[string]bool)
+	for _, f := range b {
+		ids[findingID(f)] = true
+	}
+	var only []model.Finding
+	for _, f := range a {
+		if id := findingID(f); !ids[id] {
+			ids[id] = true
+			only = append(only, f)
+		}
+	}
+	return only
+}
+
+// findingID returns the ID of a finding, computing it if it is missing
+func findingID(f model.Finding) string {
+	if f.ID != "" {
+		return f.ID
+	}
+	return model.FindingID(f)
+}
+
+// newRandom returns the source of random values of a seed
+func newRandom(seed uint64) *rand.ChaCha8 {
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], seed)
+	return rand.NewChaCha8(key)
+}
+
+// Read fills b with random values, for the tokens and markers the scans
+// send. While a session is recorded or replayed they derive from its seed,
+// so a replay sends the same values as long as the scan draws them in the
+// same order; otherwise they come from crypto/rand.
+func Read(b []byte) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if random == nil {
+		crand.Read(b)
+		return
+	}
+	random.Read(b)
+}
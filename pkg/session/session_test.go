@@ -0,0 +1,155 @@
+package session
+
+import (
+	"GopherStrike/pkg/model"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %s %d", r.Method, r.URL.Path, body, hits)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: Transport("test", http.DefaultTransport)}
+
+	get := func(path string) (string, error) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+	post := func(body string) (string, error) {
+		resp, err := client.Post(server.URL+"/login", "text/plain", strings.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		return string(data), err
+	}
+
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	config := map[string]interface{}{
+		"scanning": map[string]interface{}{"timing": "polite"},
+		"tools":    map[string]interface{}{"osint_scanner": map[string]interface{}{"api_keys": map[string]string{"shodan": "secret"}}},
+	}
+	if err := Record(path, []string{"passive", "example.com"}, config); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	token := make([]byte, 8)
+	Read(token)
+	recorded := []string{}
+	for _, send := range []func() (string, error){
+		func() (string, error) { return get("/a") },
+		func() (string, error) { return post("user=admin") },
+		func() (string, error) { return get("/a") },
+	} {
+		body, err := send()
+		if err != nil {
+			t.Fatalf("request error = %v", err)
+		}
+		recorded = append(recorded, body)
+	}
+	AddFinding(model.Finding{Tool: "test", Name: "Kept", URL: server.URL + "/a"})
+	AddFinding(model.Finding{Tool: "test", Name: "Lost", URL: server.URL + "/login"})
+	if _, err := Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(s.Exchanges) != 3 || len(s.Findings) != 2 || !reflect.DeepEqual(s.Header.Args, []string{"passive", "example.com"}) {
+		t.Fatalf("Load() = %+v", s)
+	}
+	if strings.Contains(string(s.Header.Config), "secret") || !strings.Contains(string(s.Header.Config), "polite") {
+		t.Errorf("recorded configuration = %s, want the settings without the API keys", s.Header.Config)
+	}
+	if got := s.Exchanges[0].Request.Header.Get("Authorization"); got != "[redacted]" {
+		t.Errorf("recorded Authorization = %q, want it redacted", got)
+	}
+
+	// The replay gets the same random values and responses without
+	// reaching the server
+	Replay(s)
+	if !Replaying() {
+		t.Error("Replaying() = false")
+	}
+	replayedToken := make([]byte, 8)
+	Read(replayedToken)
+	if !bytes.Equal(token, replayedToken) {
+		t.Errorf("Read() = %x in the replay, want %x", replayedToken, token)
+	}
+	for i, want := range recorded[:2] {
+		var body string
+		if i == 0 {
+			body, err = get("/a")
+		} else {
+			body, err = post("user=admin")
+		}
+		if err != nil || body != want {
+			t.Errorf("replayed response = %q, %v, want %q", body, err, want)
+		}
+	}
+	if _, err := get("/b"); !errors.Is(err, errNotRecorded) {
+		t.Errorf("unrecorded request error = %v, want errNotRecorded", err)
+	}
+	if hits != 3 {
+		t.Errorf("server got %d requests, want the 3 recorded ones", hits)
+	}
+	AddFinding(model.Finding{Tool: "test", Name: "Kept", URL: server.URL + "/a"})
+	AddFinding(model.Finding{Tool: "test", Name: "Added", URL: server.URL + "/b"})
+
+	d, err := Stop()
+	if err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if d.Replayed != 2 || !reflect.DeepEqual(d.NotRecorded, []string{"GET " + server.URL + "/b"}) ||
+		!reflect.DeepEqual(d.NotReplayed, []string{"GET " + server.URL + "/a"}) {
+		t.Errorf("Stop() = %+v", d)
+	}
+	if len(d.FindingsLost) != 1 || d.FindingsLost[0].Name != "Lost" || len(d.FindingsAdded) != 1 || d.FindingsAdded[0].Name != "Added" {
+		t.Errorf("Stop() findings lost %+v, added %+v", d.FindingsLost, d.FindingsAdded)
+	}
+	if Replaying() || d.Empty() {
+		t.Errorf("Replaying() = %v, Empty() = %v after the replay", Replaying(), d.Empty())
+	}
+
+	// Outside a session requests reach the server
+	if _, err := get("/b"); err != nil || hits != 4 {
+		t.Errorf("request outside a session = %v, %d hits", err, hits)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Load(filepath.Join(dir, "missing.jsonl")); err == nil {
+		t.Error("Load() of a missing file succeeded")
+	}
+	path := filepath.Join(dir, "results.jsonl")
+	if err := os.WriteFile(path, []byte("{\"type\":\"exchange\",\"exchange\":{\"seq\":1}}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() of a file without header succeeded")
+	}
+}
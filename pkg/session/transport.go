@@ -0,0 +1,163 @@
+package session
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// redactedHeaders are the request headers whose values are not recorded
+var redactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "X-Api-Key", "X-Apikey"}
+
+// Request is a recorded HTTP request
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// key identifies the request when matching a replay with its recording
+func (r Request) key() string {
+	return r.Method + " " + r.URL + "\n" + string(r.Body)
+}
+
+// String returns the method and URL of the request
+func (r Request) String() string {
+	return r.Method + " " + r.URL
+}
+
+// Response is a recorded HTTP response
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+// Exchange is a recorded request with its response, or the error it failed
+// with
+type Exchange struct {
+	Seq      int           `json:"seq"`
+	Tool     string        `json:"tool"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+	Request  Request       `json:"request"`
+	Response *Response     `json:"response,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Transport wraps the transport of a tool's HTTP client: while a session is
+// recorded the exchanges are written to it, and while one is replayed the
+// requests are answered from it without reaching the network
+func Transport(tool string, next http.RoundTripper) http.RoundTripper {
+	return &transport{tool: tool, next: next}
+}
+
+// transport records or replays the exchanges of a tool
+type transport struct {
+	tool string
+	next http.RoundTripper
+}
+
+// RoundTrip sends the request, or answers it from the replayed session
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	mutex.Lock()
+	recording, replay := writer != nil, replaying != nil
+	mutex.Unlock()
+	if !recording && !replay {
+		return t.next.RoundTrip(req)
+	}
+
+	request, err := captureRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if replay {
+		return answer(req, request)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	exchange := Exchange{Tool: t.tool, Time: start, Duration: time.Since(start), Request: request}
+	if err != nil {
+		exchange.Error = err.Error()
+	} else {
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if readErr != nil {
+			return nil, readErr
+		}
+		exchange.Response = &Response{StatusCode: resp.StatusCode, Header: resp.Header.Clone(), Body: body}
+	}
+
+	mutex.Lock()
+	if writer != nil {
+		sequence++
+		exchange.Seq = sequence
+		if writeErr := writer.WriteJSON(record{Type: TypeExchange, Exchange: &exchange}); writeErr != nil {
+			fmt.Printf("Warning: failed to record %s in the session: %v\n", request, writeErr)
+		}
+	}
+	mutex.Unlock()
+	return resp, err
+}
+
+// captureRequest returns the request as recorded, restoring its body for
+// sending
+func captureRequest(req *http.Request) (Request, error) {
+	request := Request{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone()}
+	for _, name := range redactedHeaders {
+		if request.Header.Get(name) != "" {
+			request.Header.Set(name, "[redacted]")
+		}
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return Request{}, err
+		}
+		request.Body = body
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return request, nil
+}
+
+// answer returns the recorded response to a request of the replay. Identical
+// requests get the recorded responses in the order they were recorded.
+func answer(req *http.Request, request Request) (*http.Response, error) {
+	mutex.Lock()
+	key := request.key()
+	exchanges := pending[key]
+	if len(exchanges) == 0 {
+		missing = append(missing, request.String())
+		mutex.Unlock()
+		return nil, fmt.Errorf("%s: %w", request, errNotRecorded)
+	}
+	exchange := exchanges[0]
+	if len(exchanges) == 1 {
+		delete(pending, key)
+	} else {
+		pending[key] = exchanges[1:]
+	}
+	replayed++
+	mutex.Unlock()
+
+	if exchange.Response == nil {
+		return nil, fmt.Errorf("%s (recorded): %s", request, exchange.Error)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.Response.StatusCode, http.StatusText(exchange.Response.StatusCode)),
+		StatusCode:    exchange.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        exchange.Response.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(exchange.Response.Body)),
+		ContentLength: int64(len(exchange.Response.Body)),
+		Request:       req,
+	}, nil
+}
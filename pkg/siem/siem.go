@@ -0,0 +1,338 @@
+// Package siem streams findings to a SIEM as CEF or LEEF messages over syslog
+package siem
+
+import (
+	"GopherStrike/pkg/config"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/metrics"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/policy"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	vendor  = "GopherStrike"
+	product = "GopherStrike"
+	version = "1.0.0"
+
+	// facilityLocal0 is the syslog facility used for all messages
+	facilityLocal0 = 16
+
+	dialTimeout = 5 * time.Second
+)
+
+// Finding is a single finding reported by a tool
+type Finding = model.Finding
+
+// Sink sends findings to a syslog receiver
+type Sink struct {
+	format   string
+	protocol string
+	address  string
+	tlsConf  *tls.Config
+	hostname string
+	conn     net.Conn
+	mutex    sync.Mutex
+}
+
+// NewSink creates a sink from the SIEM output settings and connects to the receiver
+func NewSink(cfg config.SIEMConfig) (*Sink, error) {
+	format := strings.ToLower(cfg.Format)
+	if format != "cef" && format != "leef" {
+		return nil, fmt.Errorf("unsupported SIEM format: %s", cfg.Format)
+	}
+
+	protocol := strings.ToLower(cfg.Protocol)
+	if protocol != "udp" && protocol != "tcp" && protocol != "tls" {
+		return nil, fmt.Errorf("unsupported SIEM protocol: %s", cfg.Protocol)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+
+	s := &Sink{
+		format:   format,
+		protocol: protocol,
+		address:  cfg.Address,
+		hostname: hostname,
+	}
+	if protocol == "tls" {
+		s.tlsConf = &tls.Config{InsecureSkipVerify: cfg.TLSSkipVerify}
+	}
+
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// connect opens the connection to the syslog receiver
+func (s *Sink) connect() error {
+	var conn net.Conn
+	var err error
+
+	switch s.protocol {
+	case "tls":
+		dialer := &net.Dialer{Timeout: dialTimeout}
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.address, s.tlsConf)
+	default:
+		conn, err = net.DialTimeout(s.protocol, s.address, dialTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SIEM at %s: %v", s.address, err)
+	}
+
+	s.conn = conn
+	return nil
+}
+
+// Send formats a finding and sends it as a syslog message. Stream connections
+// are re-established once if the write fails.
+func (s *Sink) Send(f Finding) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if f.Time.IsZero() {
+		f.Time = time.Now()
+	}
+
+	var payload string
+	if s.format == "leef" {
+		payload = FormatLEEF(f)
+	} else {
+		payload = FormatCEF(f)
+	}
+
+	message := s.syslogMessage(f, payload)
+	if s.protocol != "udp" {
+		// Stream transports use newline framing
+		message += "\n"
+	}
+
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.conn.Write([]byte(message)); err != nil {
+		if s.protocol == "udp" {
+			return err
+		}
+		s.conn.Close()
+		if err := s.connect(); err != nil {
+			s.conn = nil
+			return err
+		}
+		_, err = s.conn.Write([]byte(message))
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection to the receiver
+func (s *Sink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// syslogMessage wraps a payload in an RFC 5424 syslog header
+func (s *Sink) syslogMessage(f Finding, payload string) string {
+	priority := facilityLocal0*8 + syslogSeverity(f.Severity)
+	return fmt.Sprintf("<%d>1 %s %s %s - - - %s",
+		priority, f.Time.UTC().Format(time.RFC3339), s.hostname, product, payload)
+}
+
+// FormatCEF formats a finding as an ArcSight Common Event Format message
+func FormatCEF(f Finding) string {
+	header := strings.Join([]string{
+		"CEF:0",
+		cefHeaderEscape(vendor),
+		cefHeaderEscape(product),
+		cefHeaderEscape(version),
+		cefHeaderEscape(signatureID(f)),
+		cefHeaderEscape(f.Name),
+		strconv.Itoa(numericSeverity(f.Severity)),
+	}, "|")
+
+	extensions := []string{
+		"rt=" + strconv.FormatInt(f.Time.UnixMilli(), 10),
+		"cat=" + cefExtensionEscape(f.Category),
+		"dhost=" + cefExtensionEscape(f.Target),
+		"cs1Label=tool",
+		"cs1=" + cefExtensionEscape(f.Tool),
+	}
+	if f.URL != "" {
+		extensions = append(extensions, "request="+cefExtensionEscape(f.URL))
+	}
+	if f.Description != "" {
+		extensions = append(extensions, "msg="+cefExtensionEscape(f.Description))
+	}
+
+	return header + "|" + strings.Join(extensions, " ")
+}
+
+// FormatLEEF formats a finding as an IBM QRadar Log Event Extended Format message
+func FormatLEEF(f Finding) string {
+	header := strings.Join([]string{
+		"LEEF:1.0",
+		leefEscape(vendor),
+		leefEscape(product),
+		leefEscape(version),
+		leefEscape(signatureID(f)),
+	}, "|")
+
+	attributes := []string{
+		"devTime=" + f.Time.UTC().Format("Jan 02 2006 15:04:05"),
+		"devTimeFormat=MMM dd yyyy HH:mm:ss",
+		"sev=" + strconv.Itoa(numericSeverity(f.Severity)),
+		"cat=" + leefEscape(f.Category),
+		"dst=" + leefEscape(f.Target),
+		"name=" + leefEscape(f.Name),
+		"tool=" + leefEscape(f.Tool),
+	}
+	if f.URL != "" {
+		attributes = append(attributes, "url="+leefEscape(f.URL))
+	}
+	if f.Description != "" {
+		attributes = append(attributes, "msg="+leefEscape(f.Description))
+	}
+
+	return header + "|" + strings.Join(attributes, "\t")
+}
+
+// signatureID identifies the kind of finding
+func signatureID(f Finding) string {
+	if f.Category != "" {
+		return f.Tool + ":" + f.Category
+	}
+	return f.Tool
+}
+
+// numericSeverity maps a severity name to the 0-10 scale used by CEF and LEEF
+func numericSeverity(severity model.Severity) int {
+	switch strings.ToLower(string(severity)) {
+	case "critical":
+		return 10
+	case "high":
+		return 8
+	case "medium":
+		return 5
+	case "low":
+		return 3
+	default:
+		return 1
+	}
+}
+
+// syslogSeverity maps a severity name to a syslog severity level
+func syslogSeverity(severity model.Severity) int {
+	switch strings.ToLower(string(severity)) {
+	case "critical":
+		return 2 // crit
+	case "high":
+		return 3 // err
+	case "medium":
+		return 4 // warning
+	case "low":
+		return 5 // notice
+	default:
+		return 6 // info
+	}
+}
+
+// cefHeaderEscape escapes a CEF header field
+func cefHeaderEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ").Replace(s)
+}
+
+// cefExtensionEscape escapes a CEF extension value
+func cefExtensionEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`).Replace(s)
+}
+
+// leefEscape removes characters that would break LEEF framing
+func leefEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\t", " ", "\n", " ", "\r", " ").Replace(s)
+}
+
+var (
+	globalSink *Sink
+	globalOnce sync.Once
+)
+
+// Emit reports a finding: its severity is adjusted by the severity policy,
+// then it is counted in the metrics, published as a finding.new event and
+// sent to the SIEM configured in the output settings. Nothing is sent when
+// SIEM output is disabled, and connection problems are reported once.
+func Emit(f Finding) {
+	EmitContext(context.Background(), f)
+}
+
+// EmitContext is Emit for a scan run with ctx: the finding.new event
+// carries the server scan job of ctx, if any, which the finding belongs to
+func EmitContext(ctx context.Context, f Finding) {
+	f = policy.Current().Apply(f)
+	metrics.RecordFinding(f.Tool, strings.ToLower(string(f.Severity)))
+	if f.Time.IsZero() {
+		f.Time = time.Now()
+	}
+	if f.ID == "" {
+		f.ID = model.FindingID(f)
+	}
+
+	eventbus.Publish(eventbus.Event{Type: eventbus.FindingNew, Time: f.Time, Tool: f.Tool, Target: f.Target, Job: eventbus.JobFrom(ctx), Data: f})
+
+	globalOnce.Do(func() {
+		cfg := config.Get().Output.SIEM
+		if !cfg.Enabled {
+			return
+		}
+		sink, err := NewSink(cfg)
+		if err != nil {
+			fmt.Printf("[!] SIEM output disabled: %v\n", err)
+			return
+		}
+		globalSink = sink
+	})
+
+	if globalSink == nil {
+		return
+	}
+	if err := globalSink.Send(f); err != nil {
+		fmt.Printf("[!] Failed to send finding to SIEM: %v\n", err)
+	}
+}
+
+// WriteFindings writes the findings emitted from now on to w as JSON lines,
+// one finding per line, until the returned function is called
+func WriteFindings(w io.Writer) func() {
+	var mutex sync.Mutex
+	encoder := json.NewEncoder(w)
+	return eventbus.Subscribe(eventbus.FindingNew, func(event eventbus.Event) {
+		if finding, ok := event.Data.(Finding); ok {
+			mutex.Lock()
+			defer mutex.Unlock()
+			encoder.Encode(finding)
+		}
+	})
+}
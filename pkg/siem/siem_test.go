@@ -0,0 +1,172 @@
+package siem
+
+import (
+	"GopherStrike/pkg/config"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFinding() Finding {
+	return Finding{
+		Tool:        "webvuln",
+		Target:      "example.com",
+		Category:    "XSS",
+		Name:        "XSS in q|search",
+		Severity:    "High",
+		Description: "Payload a=b reflected",
+		URL:         "https://example.com/?q=1",
+		Time:        time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestFormatCEF(t *testing.T) {
+	message := FormatCEF(testFinding())
+
+	tests := []struct {
+		name     string
+		contains string
+	}{
+		{"Header", "CEF:0|GopherStrike|GopherStrike|1.0.0|webvuln:XSS|"},
+		{"Escaped name", `XSS in q\|search|8|`},
+		{"Target", "dhost=example.com"},
+		{"Tool", "cs1Label=tool cs1=webvuln"},
+		{"Escaped message", `msg=Payload a\=b reflected`},
+		{"Timestamp", "rt=1704164645000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(message, tt.contains) {
+				t.Errorf("FormatCEF() = %q, want it to contain %q", message, tt.contains)
+			}
+		})
+	}
+}
+
+func TestFormatLEEF(t *testing.T) {
+	message := FormatLEEF(testFinding())
+
+	if !strings.HasPrefix(message, "LEEF:1.0|GopherStrike|GopherStrike|1.0.0|webvuln:XSS|") {
+		t.Errorf("FormatLEEF() has unexpected header: %q", message)
+	}
+	for _, attribute := range []string{"sev=8", "dst=example.com", "cat=XSS", "devTime=Jan 02 2024 03:04:05"} {
+		if !strings.Contains(message, "\t"+attribute) && !strings.Contains(message, "|"+attribute) {
+			t.Errorf("FormatLEEF() = %q, want attribute %q", message, attribute)
+		}
+	}
+}
+
+func TestSinkUDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	sink, err := NewSink(config.SIEMConfig{Format: "cef", Protocol: "udp", Address: listener.LocalAddr().String()})
+	if err != nil {
+		t.Fatalf("NewSink() error = %v", err)
+	}
+	defer sink.Close()
+
+	if err := sink.Send(testFinding()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	buf := make([]byte, 4096)
+	listener.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("failed to read message: %v", err)
+	}
+
+	message := string(buf[:n])
+	// local0 (16) * 8 + err (3)
+	if !strings.HasPrefix(message, "<131>1 2024-01-02T03:04:05Z ") {
+		t.Errorf("unexpected syslog header: %q", message)
+	}
+	if !strings.Contains(message, " GopherStrike - - - CEF:0|") {
+		t.Errorf("message does not carry a CEF payload: %q", message)
+	}
+}
+
+func TestSinkTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		received <- line
+	}()
+
+	sink, err := NewSink(config.SIEMConfig{Format: "leef", Protocol: "tcp", Address: listener.Addr().String()})
+	if err != nil {
+		t.Fatalf("NewSink() error = %v", err)
+	}
+	defer sink.Close()
+
+	if err := sink.Send(testFinding()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	select {
+	case line := <-received:
+		if !strings.HasSuffix(line, "\n") || !strings.Contains(line, "LEEF:1.0|") {
+			t.Errorf("unexpected message: %q", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+}
+
+func TestNewSinkRejectsInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.SIEMConfig
+	}{
+		{"Unknown format", config.SIEMConfig{Format: "json", Protocol: "udp", Address: "127.0.0.1:514"}},
+		{"Unknown protocol", config.SIEMConfig{Format: "cef", Protocol: "http", Address: "127.0.0.1:514"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSink(tt.cfg); err == nil {
+				t.Error("NewSink() expected an error")
+			}
+		})
+	}
+}
+
+func TestWriteFindings(t *testing.T) {
+	var out bytes.Buffer
+	stop := WriteFindings(&out)
+	Emit(testFinding())
+	stop()
+	Emit(testFinding())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("WriteFindings() wrote %d lines, want 1:\n%s", len(lines), out.String())
+	}
+	var finding Finding
+	if err := json.Unmarshal([]byte(lines[0]), &finding); err != nil {
+		t.Fatalf("line is not JSON: %v", err)
+	}
+	if finding.Name != testFinding().Name || !finding.Time.Equal(testFinding().Time) {
+		t.Errorf("WriteFindings() wrote %+v, want %+v", finding, testFinding())
+	}
+}
@@ -0,0 +1,119 @@
+// pkg/signing.go
+package pkg
+
+import (
+	"GopherStrike/pkg/config"
+	"GopherStrike/pkg/signing"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// reportCheck is the outcome of the verification of a signed file
+type reportCheck struct {
+	Path  string `json:"path"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// RunVerifyReport checks reports and results against their detached
+// signatures, so that recipients can confirm they were not altered.
+// Directories are searched for the signed files they hold.
+func RunVerifyReport(args []string) error {
+	fset := flag.NewFlagSet("verify-report", flag.ContinueOnError)
+	defaultKey := ""
+	if key := config.Get().Output.SigningKey; key != "" {
+		defaultKey = key + signing.PublicExt
+	}
+	keyPath := fset.String("key", defaultKey, "Public key of the signer (PEM)")
+	jsonOutput := fset.Bool("json", false, "Print the results as JSON")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() == 0 {
+		return fmt.Errorf("usage: GopherStrike verify-report [--key signer.pub] [--json] file|directory...")
+	}
+	if *keyPath == "" {
+		return fmt.Errorf("no public key, pass the signer's with --key")
+	}
+	key, err := signing.LoadPublicKey(*keyPath)
+	if err != nil {
+		return err
+	}
+
+	paths := []string{}
+	for _, arg := range fset.Args() {
+		found, err := signedFiles(arg)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, found...)
+	}
+
+	checks := []reportCheck{}
+	invalid := 0
+	for _, path := range paths {
+		check := reportCheck{Path: path, Valid: true}
+		if err := signing.VerifyFile(path, key); err != nil {
+			check.Valid, check.Error = false, err.Error()
+			invalid++
+		}
+		checks = append(checks, check)
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(checks, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Printf("[*] Verifying with key %s (%s)\n", signing.Fingerprint(key), *keyPath)
+		for _, check := range checks {
+			if check.Valid {
+				fmt.Printf("[+] %s: signature valid\n", check.Path)
+			} else {
+				fmt.Printf("[-] %s: %s\n", check.Path, check.Error)
+			}
+		}
+	}
+
+	if len(checks) == 0 {
+		return fmt.Errorf("no signed files found")
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d files failed verification", invalid, len(checks))
+	}
+	return nil
+}
+
+// signedFiles returns the file at path, or the signed files of a directory
+func signedFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files := []string{}
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.HasSuffix(file, signing.Ext) {
+			return err
+		}
+		signature := file + signing.Ext
+		if _, err := os.Stat(signature); os.IsNotExist(err) {
+			signature = strings.TrimSuffix(file, ".gz") + signing.Ext
+		}
+		if _, err := os.Stat(signature); err == nil {
+			files = append(files, file)
+		}
+		return nil
+	})
+	return files, err
+}
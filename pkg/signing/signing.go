@@ -0,0 +1,219 @@
+// Package signing signs the results and reports the tools write with an
+// Ed25519 key, in detached .sig files next to them, so that recipients can
+// confirm a deliverable was not altered after it was produced. The key is a
+// PKCS #8 PEM file set with output.signing_key; its public half, written next
+// to it as a .pub file, is what recipients verify with.
+package signing
+
+import (
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Ext is the extension of signature files, appended to the signed file's name
+const Ext = ".sig"
+
+// PublicExt is the extension of the public key file, appended to the name
+// of the private key file
+const PublicExt = ".pub"
+
+// ErrInvalid is returned when a file does not match its signature
+var ErrInvalid = errors.New("signature does not match, the file was altered or signed with another key")
+
+var (
+	mutex  sync.RWMutex
+	signer ed25519.PrivateKey // Key signing the files, nil when signing is off
+)
+
+// LoadOrCreateKey reads the private key at path, generating it and its
+// public key file if it does not exist yet. created reports whether it was
+// generated.
+func LoadOrCreateKey(path string) (key ed25519.PrivateKey, created bool, err error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		key, err = generateKey(path)
+		return key, err == nil, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read signing key: %v", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, false, fmt.Errorf("%s is not a PEM private key", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid signing key %s: %v", path, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, false, fmt.Errorf("%s is not an Ed25519 key", path)
+	}
+	return key, false, nil
+}
+
+// generateKey writes a new private key at path, readable by its owner only,
+// and its public key next to it
+func generateKey(path string) (ed25519.PrivateKey, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create signing key: %v", err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600); err != nil {
+		return nil, fmt.Errorf("failed to create signing key: %v", err)
+	}
+	if err := os.WriteFile(path+PublicExt, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write public key: %v", err)
+	}
+	return private, nil
+}
+
+// LoadPublicKey reads a PEM public key, or the public half of a PEM private
+// key
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM key", path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s holds a %s, not a key", path, strings.ToLower(block.Type))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid key %s: %v", path, err)
+	}
+	switch key := parsed.(type) {
+	case ed25519.PublicKey:
+		return key, nil
+	case ed25519.PrivateKey:
+		return key.Public().(ed25519.PublicKey), nil
+	}
+	return nil, fmt.Errorf("%s is not an Ed25519 key", path)
+}
+
+// Fingerprint returns the SHA-256 fingerprint of a public key, shortened to
+// 16 hex digits, to tell keys apart
+func Fingerprint(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// SetKey makes key the one signing files; nil turns signing off
+func SetKey(key ed25519.PrivateKey) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	signer = key
+}
+
+// Enabled reports whether files are signed
+func Enabled() bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return signer != nil
+}
+
+// SignFile writes the detached signature of the file at path to path.sig,
+// if signing is on
+func SignFile(path string) error {
+	mutex.RLock()
+	key := signer
+	mutex.RUnlock()
+	if key == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to sign %s: %v", path, err)
+	}
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, data))
+	if err := os.WriteFile(path+Ext, []byte(signature+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to sign %s: %v", path, err)
+	}
+	return nil
+}
+
+// VerifyFile checks the file at path against its signature in path.sig. A
+// result compressed by a cleanup, path.gz, is checked decompressed against
+// the signature of the original, path.sig.
+func VerifyFile(path string, key ed25519.PublicKey) error {
+	sigPath := path + Ext
+	if _, err := os.Stat(sigPath); os.IsNotExist(err) && strings.HasSuffix(path, ".gz") {
+		sigPath = strings.TrimSuffix(path, ".gz") + Ext
+	}
+	encoded, err := os.ReadFile(sigPath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no signature, %s is missing", filepath.Base(sigPath))
+	}
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid signature file %s", filepath.Base(sigPath))
+	}
+
+	data, err := readSigned(path, sigPath)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, data, signature) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// readSigned returns the content the signature at sigPath covers: the file at
+// path, decompressed if it is a compressed copy of the signed file
+func readSigned(path, sigPath string) ([]byte, error) {
+	if sigPath == path+Ext {
+		return os.ReadFile(path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s: %v", path, err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,85 @@
+package signing
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	defer SetKey(nil)
+	dir := t.TempDir()
+	report := filepath.Join(dir, "report.html")
+	if err := os.WriteFile(report, []byte("<h1>Findings</h1>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Signing is off until a key is set
+	if err := SignFile(report); err != nil || Enabled() {
+		t.Fatalf("SignFile() without key = %v, Enabled() = %v", err, Enabled())
+	}
+	if _, err := os.Stat(report + Ext); !os.IsNotExist(err) {
+		t.Fatalf("signature written without a key")
+	}
+
+	keyPath := filepath.Join(dir, "keys", "signing.pem")
+	key, created, err := LoadOrCreateKey(keyPath)
+	if err != nil || !created {
+		t.Fatalf("LoadOrCreateKey() = %v, %v, want a new key", created, err)
+	}
+	if info, err := os.Stat(keyPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("private key mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+	if again, created, err := LoadOrCreateKey(keyPath); err != nil || created || !again.Equal(key) {
+		t.Errorf("LoadOrCreateKey() of the existing key = %v, %v", created, err)
+	}
+
+	SetKey(key)
+	if err := SignFile(report); err != nil {
+		t.Fatalf("SignFile() error = %v", err)
+	}
+	public, err := LoadPublicKey(keyPath + PublicExt)
+	if err != nil {
+		t.Fatalf("LoadPublicKey() error = %v", err)
+	}
+	if fromPrivate, err := LoadPublicKey(keyPath); err != nil || !fromPrivate.Equal(public) || Fingerprint(public) != Fingerprint(fromPrivate) {
+		t.Errorf("LoadPublicKey() of the private key = %v", err)
+	}
+	if err := VerifyFile(report, public); err != nil {
+		t.Errorf("VerifyFile() error = %v", err)
+	}
+
+	// An altered file, another key and a missing signature are rejected
+	os.WriteFile(report, []byte("<h1>No findings</h1>"), 0644)
+	if err := VerifyFile(report, public); !errors.Is(err, ErrInvalid) {
+		t.Errorf("VerifyFile() of an altered file = %v, want ErrInvalid", err)
+	}
+	other, _, _ := LoadOrCreateKey(filepath.Join(dir, "other.pem"))
+	SetKey(other)
+	SignFile(report)
+	if err := VerifyFile(report, public); !errors.Is(err, ErrInvalid) {
+		t.Errorf("VerifyFile() with another key = %v, want ErrInvalid", err)
+	}
+	os.Remove(report + Ext)
+	if err := VerifyFile(report, public); err == nil {
+		t.Error("VerifyFile() without signature succeeded")
+	}
+
+	// A result compressed by a cleanup keeps the signature of the original
+	SetKey(key)
+	result := filepath.Join(dir, "scan.json")
+	os.WriteFile(result, []byte(`{"findings":[]}`), 0644)
+	SignFile(result)
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(`{"findings":[]}`))
+	gz.Close()
+	os.WriteFile(result+".gz", compressed.Bytes(), 0644)
+	os.Remove(result)
+	if err := VerifyFile(result+".gz", public); err != nil {
+		t.Errorf("VerifyFile() of a compressed result = %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+// pkg/smtpcheck.go
+package pkg
+
+import (
+	"GopherStrike/pkg/budget"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/tools/audit/certcheck"
+	"GopherStrike/pkg/tools/audit/smtpcheck"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+// RunSMTPCheck tests the mail servers of a list of domains for open
+// relaying, missing or broken STARTTLS and VRFY/EXPN user enumeration. Only
+// SMTP envelopes are exchanged; no mail is ever sent.
+func RunSMTPCheck(args []string) error {
+	// The preset provides the defaults the other flags override
+	preset, err := presets.FromArgs(args)
+	if err != nil {
+		return err
+	}
+	options, err := smtpcheck.PresetCheckOptions(preset)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("smtpcheck", flag.ContinueOnError)
+	fs.String("preset", preset, "Option preset: quick, normal or deep")
+	hostsFile := fs.String("hosts", "", "File with one domain or server:port per line")
+	fs.StringVar(&options.HeloName, "helo", options.HeloName, "Name to send in EHLO")
+	fs.IntVar(&options.Timeout, "timeout", options.Timeout, "Connection timeout in seconds")
+	fs.IntVar(&options.Concurrency, "concurrency", options.Concurrency, "Servers checked in parallel")
+	jsonOutput := fs.Bool("json", false, "Print the results as JSON")
+	output := fs.String("output", "", "Write the results to this file instead of stdout")
+	maxDuration := fs.Duration("max-duration", 0, "Stop checking after this long, e.g. 30m, and keep the partial results (0: no limit)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	entries := fs.Args()
+	if *hostsFile != "" {
+		fileEntries, err := certcheck.LoadHosts(*hostsFile)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, fileEntries...)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no domains to check, pass them as arguments or with --hosts")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := budget.Context(ctx, "smtpcheck", *maxDuration)
+	defer cancel()
+	results := smtpcheck.NewChecker(options).CheckDomains(ctx, entries)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %v", err)
+		}
+		defer file.Close()
+		redacted := redact.Writer(file)
+		defer redacted.Close()
+		w = redacted
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+	} else {
+		printSMTPTable(w, results)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	if failed == len(results) {
+		return fmt.Errorf("none of the %d mail server(s) could be checked", len(results))
+	}
+	return nil
+}
+
+// printSMTPTable renders the SMTP check results as a text table followed by
+// the problems of each server
+func printSMTPTable(w io.Writer, results []smtpcheck.ServerResult) {
+	fmt.Fprintf(w, "%-24s %-32s %-9s %-8s %s\n", "Domain", "Server", "STARTTLS", "TLS", "Problems")
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Fprintf(w, "%-24s %-32s %-9s %-8s %s\n", truncate(result.Domain, 24), truncate(result.Address, 32), "-", "-", "error")
+			continue
+		}
+		starttls, version := "no", "-"
+		if result.STARTTLS {
+			starttls = "yes"
+		}
+		if result.TLSVersion != "" {
+			version = result.TLSVersion
+		}
+		fmt.Fprintf(w, "%-24s %-32s %-9s %-8s %d\n", truncate(result.Domain, 24), truncate(result.Address, 32),
+			starttls, version, len(result.Problems))
+	}
+
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Fprintf(w, "\n[-] %s: %s\n", result.Address, result.Error)
+			continue
+		}
+		if len(result.Problems) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n[!] %s (%s)\n", result.Address, result.Domain)
+		for _, problem := range result.Problems {
+			fmt.Fprintf(w, "    [%s] %s\n", problem.Severity, problem.Description)
+		}
+	}
+}
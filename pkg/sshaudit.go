@@ -0,0 +1,150 @@
+// pkg/sshaudit.go
+package pkg
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/budget"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/targets"
+	"GopherStrike/pkg/tools/audit/sshaudit"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// RunSSHAudit enumerates the key exchange, host key, cipher and MAC
+// algorithms of SSH servers and reports the deprecated or weak ones. Servers
+// come from the arguments, a hosts file, or the workspace inventory assets
+// with port 22 open.
+func RunSSHAudit(args []string) error {
+	// The preset provides the defaults the other flags override
+	preset, err := presets.FromArgs(args)
+	if err != nil {
+		return err
+	}
+	options, err := sshaudit.PresetAuditOptions(preset)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("sshaudit", flag.ContinueOnError)
+	fs.String("preset", preset, "Option preset: quick, normal or deep")
+	hostsFile := fs.String("hosts", "", "File of hosts, host:port entries, addresses, CIDRs and address ranges")
+	inventory := fs.Bool("inventory", false, "Audit the workspace assets with port 22 open")
+	workspace := fs.String("workspace", os.Getenv(artifacts.WorkspaceEnvVar), "Workspace to read the inventory from")
+	fs.IntVar(&options.Timeout, "timeout", options.Timeout, "Connection timeout in seconds")
+	fs.IntVar(&options.Concurrency, "concurrency", options.Concurrency, "Servers audited in parallel")
+	jsonOutput := fs.Bool("json", false, "Print the results as JSON")
+	output := fs.String("output", "", "Write the results to this file instead of stdout")
+	maxDuration := fs.Duration("max-duration", 0, "Stop auditing after this long, e.g. 30m, and keep the partial results (0: no limit)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	hosts, err := targets.Expand(fs.Args(), targets.DefaultMaxHosts)
+	if err != nil {
+		return err
+	}
+	if *hostsFile != "" {
+		fileHosts, err := targets.LoadFile(*hostsFile, targets.DefaultMaxHosts)
+		if err != nil {
+			return err
+		}
+		hosts = append(hosts, fileHosts...)
+	}
+	if *inventory {
+		assets, err := artifacts.NewStore(artifacts.DefaultRoot, *workspace).Inventory()
+		if err != nil {
+			return err
+		}
+		for _, asset := range assets {
+			for _, port := range asset.OpenPorts {
+				if port == 22 {
+					hosts = append(hosts, asset.Host)
+					break
+				}
+			}
+		}
+	}
+	if len(hosts) == 0 {
+		return fmt.Errorf("no SSH servers to audit, pass them as arguments, with --hosts or --inventory")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := budget.Context(ctx, "sshaudit", *maxDuration)
+	defer cancel()
+	results := sshaudit.NewAuditor(options).AuditHosts(ctx, hosts)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %v", err)
+		}
+		defer file.Close()
+		redacted := redact.Writer(file)
+		defer redacted.Close()
+		w = redacted
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+	} else {
+		printSSHAuditTable(w, results)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	if failed == len(results) {
+		return fmt.Errorf("none of the %d SSH server(s) could be audited", len(results))
+	}
+	return nil
+}
+
+// printSSHAuditTable renders the SSH audit results as a text table followed
+// by the weak algorithms of each server
+func printSSHAuditTable(w io.Writer, results []sshaudit.AuditResult) {
+	fmt.Fprintf(w, "%-32s %-36s %-6s %-6s %-4s %s\n", "Server", "Banner", "High", "Medium", "Low", "Weaknesses")
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Fprintf(w, "%-32s %-36s %-6s %-6s %-4s %s\n", truncate(result.Address, 32), "-", "-", "-", "-", "error")
+			continue
+		}
+		counts := map[string]int{}
+		for _, weakness := range result.Weaknesses {
+			counts[weakness.Severity]++
+		}
+		fmt.Fprintf(w, "%-32s %-36s %-6d %-6d %-4d %d\n", truncate(result.Address, 32),
+			truncate(strings.TrimPrefix(result.Banner, "SSH-2.0-"), 36), counts[sshaudit.SeverityHigh],
+			counts[sshaudit.SeverityMedium], counts[sshaudit.SeverityLow], len(result.Weaknesses))
+	}
+
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Fprintf(w, "\n[-] %s: %s\n", result.Host, result.Error)
+			continue
+		}
+		if len(result.Weaknesses) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n[!] %s\n", result.Address)
+		for _, weakness := range result.Weaknesses {
+			fmt.Fprintf(w, "    [%s] %s %s: %s (%s)\n", weakness.Severity, weakness.Kind,
+				weakness.Algorithm, weakness.Reason, weakness.Reference)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Package stats counts the traffic of scans per host: requests, bytes sent
+// and received, latency, errors by cause and HTTP responses by class. The
+// HTTP clients of the tools and the dialers of the audit tools record into
+// every collector started with Watch, so a run can show how much effort it
+// took, which helps tune timing and evidences the work in reports.
+package stats
+
+import (
+	"GopherStrike/pkg/scope"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Error causes
+const (
+	ErrorTimeout  = "timeout"
+	ErrorDNS      = "dns"
+	ErrorRefused  = "refused"
+	ErrorReset    = "reset"
+	ErrorTLS      = "tls"
+	ErrorExcluded = "excluded"
+	ErrorCanceled = "canceled"
+	ErrorOther    = "other"
+)
+
+// HostStats is the traffic with one host, or with every host for the total
+type HostStats struct {
+	Host          string           `json:"host,omitempty"`
+	Requests      int64            `json:"requests"` // HTTP requests and connections of other protocols
+	BytesSent     int64            `json:"bytes_sent"`
+	BytesReceived int64            `json:"bytes_received"`
+	Latency       time.Duration    `json:"-"` // Total time to the response or connection
+	AvgLatencyMs  float64          `json:"avg_latency_ms"`
+	Errors        map[string]int64 `json:"errors,omitempty"`    // Failed requests by cause, e.g. timeout, dns
+	Responses     map[string]int64 `json:"responses,omitempty"` // HTTP responses by class, e.g. 2xx, 4xx
+}
+
+// ErrorCount returns the number of failed requests
+func (h HostStats) ErrorCount() int64 {
+	var n int64
+	for _, count := range h.Errors {
+		n += count
+	}
+	return n
+}
+
+// add adds the traffic of other to h
+func (h *HostStats) add(other *HostStats) {
+	h.Requests += other.Requests
+	h.BytesSent += other.BytesSent
+	h.BytesReceived += other.BytesReceived
+	h.Latency += other.Latency
+	for cause, n := range other.Errors {
+		h.count(&h.Errors, cause, n)
+	}
+	for class, n := range other.Responses {
+		h.count(&h.Responses, class, n)
+	}
+}
+
+// count adds n to the key of a map that is created when needed
+func (h *HostStats) count(m *map[string]int64, key string, n int64) {
+	if *m == nil {
+		*m = make(map[string]int64)
+	}
+	(*m)[key] += n
+}
+
+// finish fills in the average latency
+func (h HostStats) finish() HostStats {
+	if h.Requests > 0 {
+		h.AvgLatencyMs = float64(h.Latency.Microseconds()) / float64(h.Requests) / 1000
+	}
+	return h
+}
+
+// Collector counts the traffic of the scans run while it watches
+type Collector struct {
+	mu    sync.Mutex
+	hosts map[string]*HostStats
+	start time.Time
+}
+
+var (
+	collectors   = make(map[*Collector]bool)
+	collectorsMu sync.Mutex
+)
+
+// Watch starts counting the traffic sent from now on
+func Watch() *Collector {
+	c := &Collector{hosts: make(map[string]*HostStats), start: time.Now()}
+	collectorsMu.Lock()
+	defer collectorsMu.Unlock()
+	collectors[c] = true
+	return c
+}
+
+// Stop stops counting traffic
+func (c *Collector) Stop() {
+	collectorsMu.Lock()
+	defer collectorsMu.Unlock()
+	delete(collectors, c)
+}
+
+// record applies update to the stats of a host in every collector
+func record(host string, update func(h *HostStats)) {
+	collectorsMu.Lock()
+	defer collectorsMu.Unlock()
+	for c := range collectors {
+		c.mu.Lock()
+		h, ok := c.hosts[host]
+		if !ok {
+			h = &HostStats{Host: host}
+			c.hosts[host] = h
+		}
+		update(h)
+		c.mu.Unlock()
+	}
+}
+
+// RecordRequest records a request to a host and its outcome: the HTTP
+// status, or 0 for other protocols, or the error it failed with
+func RecordRequest(host string, latency time.Duration, status int, err error) {
+	record(host, func(h *HostStats) {
+		h.Requests++
+		h.Latency += latency
+		if err != nil {
+			h.count(&h.Errors, Classify(err), 1)
+		} else if status > 0 {
+			h.count(&h.Responses, fmt.Sprintf("%dxx", status/100), 1)
+		}
+	})
+}
+
+// recordBytes records the bytes sent to and received from a host
+func recordBytes(host string, sent, received int64) {
+	record(host, func(h *HostStats) {
+		h.BytesSent += sent
+		h.BytesReceived += received
+	})
+}
+
+// Classify returns the cause of a request error
+func Classify(err error) string {
+	var dnsErr *net.DNSError
+	var excluded *scope.ExcludedError
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var alert tls.AlertError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &excluded):
+		return ErrorExcluded
+	case errors.As(err, &dnsErr):
+		return ErrorDNS
+	case errors.Is(err, context.Canceled):
+		return ErrorCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorReset
+	case errors.As(err, &certErr), errors.As(err, &recordErr), errors.As(err, &alert),
+		errors.As(err, &unknownAuthority), errors.As(err, &hostnameErr):
+		return ErrorTLS
+	}
+	return ErrorOther
+}
+
+// Hosts returns the stats of each host, sorted by host
+func (c *Collector) Hosts() []HostStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hosts := make([]HostStats, 0, len(c.hosts))
+	for _, h := range c.hosts {
+		var copied HostStats
+		copied.add(h)
+		copied.Host = h.Host
+		hosts = append(hosts, copied.finish())
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Host < hosts[j].Host })
+	return hosts
+}
+
+// Total returns the stats of every host together
+func (c *Collector) Total() HostStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var total HostStats
+	for _, h := range c.hosts {
+		total.add(h)
+	}
+	return total.finish()
+}
+
+// Report is the traffic of a scan, as written to run summaries
+type Report struct {
+	Total HostStats   `json:"total"`
+	Hosts []HostStats `json:"hosts"`
+}
+
+// Report returns the traffic counted so far
+func (c *Collector) Report() Report {
+	return Report{Total: c.Total(), Hosts: c.Hosts()}
+}
+
+// Print writes the statistics of the scan and of each host as a table.
+// Nothing is written if no traffic was counted.
+func (c *Collector) Print(w io.Writer) {
+	total := c.Total()
+	if total.Requests == 0 {
+		return
+	}
+	elapsed := time.Since(c.start).Seconds()
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(total.Requests) / elapsed
+	}
+
+	fmt.Fprintf(w, "\n[i] Scan statistics: %d requests in %.1fs (%.1f/s), %s sent, %s received, %.0fms average latency\n",
+		total.Requests, elapsed, rate, FormatBytes(total.BytesSent), FormatBytes(total.BytesReceived), total.AvgLatencyMs)
+	if len(total.Errors) > 0 {
+		fmt.Fprintf(w, "[i] Errors: %s\n", formatCounts(total.Errors))
+	}
+	if len(total.Responses) > 0 {
+		fmt.Fprintf(w, "[i] Responses: %s\n", formatCounts(total.Responses))
+	}
+
+	hosts := c.Hosts()
+	if len(hosts) < 2 {
+		return
+	}
+	fmt.Fprintf(w, "\n%-40s %9s %10s %10s %9s %7s %7s %7s\n", "Host", "Requests", "Sent", "Received", "Latency", "Errors", "4xx", "5xx")
+	for _, h := range hosts {
+		fmt.Fprintf(w, "%-40s %9d %10s %10s %7.0fms %7d %7d %7d\n", h.Host, h.Requests,
+			FormatBytes(h.BytesSent), FormatBytes(h.BytesReceived), h.AvgLatencyMs,
+			h.ErrorCount(), h.Responses["4xx"], h.Responses["5xx"])
+	}
+}
+
+// formatCounts lists counts as "key n" sorted by key
+func formatCounts(counts map[string]int64) string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s %d", key, counts[key])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatBytes formats a byte count with a binary unit, e.g. 1.5 MB
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exp := float64(n)/unit, 0
+	for value >= unit && exp < 3 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "KMGT"[exp])
+}
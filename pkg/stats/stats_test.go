@@ -0,0 +1,126 @@
+package stats
+
+import (
+	"GopherStrike/pkg/scope"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"DNS", &net.DNSError{Err: "no such host", Name: "nx.example", IsNotFound: true}, ErrorDNS},
+		{"DNS timeout", &net.DNSError{Err: "timeout", Name: "slow.example", IsTimeout: true}, ErrorDNS},
+		{"Deadline", fmt.Errorf("request: %w", context.DeadlineExceeded), ErrorTimeout},
+		{"Canceled", context.Canceled, ErrorCanceled},
+		{"Refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, ErrorRefused},
+		{"Reset", io.ErrUnexpectedEOF, ErrorReset},
+		{"Excluded", &scope.ExcludedError{Target: "10.0.0.1", Rule: "10.0.0.0/8"}, ErrorExcluded},
+		{"Other", errors.New("boom"), ErrorOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, strings.Repeat("x", 1000))
+	}))
+	defer server.Close()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = CountBytes((&net.Dialer{}).DialContext)
+	client := &http.Client{Transport: Transport(transport)}
+
+	before := Watch()
+	before.Stop()
+	c := Watch()
+	for _, path := range []string{"/", "/", "/missing"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	// A refused connection of another protocol
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	closed := listener.Addr().String()
+	listener.Close()
+	if _, err := DialContext((&net.Dialer{}).DialContext)(context.Background(), "tcp", closed); err == nil {
+		t.Fatal("DialContext() to a closed port should fail")
+	}
+	c.Stop()
+	client.Get(server.URL) // Not counted
+
+	total := c.Total()
+	if total.Requests != 4 {
+		t.Errorf("Total().Requests = %d, want 4", total.Requests)
+	}
+	if total.Responses["2xx"] != 2 || total.Responses["4xx"] != 1 {
+		t.Errorf("Total().Responses = %v, want 2xx 2 and 4xx 1", total.Responses)
+	}
+	if total.Errors[ErrorRefused] != 1 || total.ErrorCount() != 1 {
+		t.Errorf("Total().Errors = %v, want refused 1", total.Errors)
+	}
+	if total.BytesReceived < 2000 || total.BytesSent == 0 {
+		t.Errorf("Total() bytes sent %d, received %d, want some sent and 2000 or more received", total.BytesSent, total.BytesReceived)
+	}
+	if hosts := c.Hosts(); len(hosts) != 1 || hosts[0].Host != "127.0.0.1" || hosts[0].Requests != 4 {
+		t.Errorf("Hosts() = %+v, want 4 requests to 127.0.0.1", hosts)
+	}
+	if before.Total().Requests != 0 {
+		t.Errorf("a stopped collector counted %d requests", before.Total().Requests)
+	}
+
+	var b strings.Builder
+	c.Print(&b)
+	if !strings.Contains(b.String(), "4 requests") || !strings.Contains(b.String(), "refused 1") {
+		t.Errorf("Print() = %q, want the request and error counts", b.String())
+	}
+	b.Reset()
+	before.Print(&b)
+	if b.Len() != 0 {
+		t.Errorf("Print() without traffic = %q, want nothing", b.String())
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:       "0 B",
+		1023:    "1023 B",
+		1536:    "1.5 KB",
+		5 << 20: "5.0 MB",
+		3 << 30: "3.0 GB",
+	}
+	for n, want := range tests {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
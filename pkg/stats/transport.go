@@ -0,0 +1,92 @@
+package stats
+
+import (
+	"GopherStrike/pkg/scope"
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// countingTransport records each HTTP request with its latency and outcome
+type countingTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip sends the request and records it for its host
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	RecordRequest(req.URL.Hostname(), time.Since(start), status, err)
+	return resp, err
+}
+
+// Transport wraps an HTTP transport so its requests are counted. The bytes
+// are counted by the connections of a dial function wrapped with CountBytes.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return &countingTransport{next: next}
+}
+
+// countingConn counts the bytes read from and written to a connection
+type countingConn struct {
+	net.Conn
+	host string
+}
+
+// Read reads from the connection and counts the bytes received
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		recordBytes(c.host, 0, int64(n))
+	}
+	return n, err
+}
+
+// Write writes to the connection and counts the bytes sent
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	if n > 0 {
+		recordBytes(c.host, int64(n), 0)
+	}
+	return n, err
+}
+
+// hostOf returns the host of a host:port address
+func hostOf(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
+
+// CountBytes wraps a dial function so the bytes of its connections are
+// counted. The requests are counted by the HTTP transport using it.
+func CountBytes(dial scope.DialFunc) scope.DialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		return &countingConn{Conn: conn, host: hostOf(address)}, nil
+	}
+}
+
+// DialContext wraps the dial function of a tool speaking a protocol other
+// than HTTP: each connection counts as a request, with the time it took to
+// connect as its latency, and its bytes are counted
+func DialContext(dial scope.DialFunc) scope.DialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		start := time.Now()
+		conn, err := dial(ctx, network, address)
+		RecordRequest(hostOf(address), time.Since(start), 0, err)
+		if err != nil {
+			return nil, err
+		}
+		return &countingConn{Conn: conn, host: hostOf(address)}, nil
+	}
+}
@@ -0,0 +1,153 @@
+// Package targets parses the target expressions the scanning tools accept:
+// addresses, CIDR ranges, address ranges, comma lists and hostnames, given
+// on the command line or in files
+package targets
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxHosts is the largest number of targets an expression expands
+// to unless a tool sets its own limit
+const DefaultMaxHosts = 65536
+
+// expansion collects targets in order, without duplicates and up to a limit
+type expansion struct {
+	targets  []string
+	seen     map[string]bool
+	maxHosts int
+}
+
+// add appends a target unless it was seen before
+func (e *expansion) add(target string) error {
+	if e.seen[target] {
+		return nil
+	}
+	if e.maxHosts > 0 && len(e.targets) >= e.maxHosts {
+		return fmt.Errorf("targets expand to more than %d hosts", e.maxHosts)
+	}
+	e.seen[target] = true
+	e.targets = append(e.targets, target)
+	return nil
+}
+
+// addRange appends the IPv4 addresses from first to last
+func (e *expansion) addRange(first, last uint32) error {
+	for n := uint64(first); n <= uint64(last); n++ {
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, uint32(n))
+		if err := e.add(ip.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Expand parses target expressions into individual targets, in order and
+// without duplicates. Each expression is a comma or space separated list of:
+//
+//	192.0.2.10                 an IPv4 or IPv6 address
+//	192.0.2.0/24               an IPv4 CIDR range, without the network and broadcast addresses
+//	192.0.2.1-50               a range of the last octet
+//	192.0.2.1-192.0.2.50       a range between two addresses
+//	www.example.com            a hostname, optionally with a port, left unresolved
+//	https://www.example.com/   a URL, left as is
+//
+// An error is returned if the targets exceed maxHosts, or 0 for no limit.
+func Expand(expressions []string, maxHosts int) ([]string, error) {
+	e := &expansion{targets: []string{}, seen: map[string]bool{}, maxHosts: maxHosts}
+	for _, expression := range expressions {
+		for _, token := range strings.FieldsFunc(expression, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		}) {
+			if err := e.parse(token); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return e.targets, nil
+}
+
+// Parse expands a single target expression
+func Parse(expression string, maxHosts int) ([]string, error) {
+	return Expand([]string{expression}, maxHosts)
+}
+
+// LoadFile expands the target expressions in a file, one or more per line.
+// Empty lines and lines starting with # are skipped.
+func LoadFile(path string, maxHosts int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open targets file: %v", err)
+	}
+	defer file.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading targets file: %v", err)
+	}
+	return Expand(lines, maxHosts)
+}
+
+// parse expands one token of an expression
+func (e *expansion) parse(token string) error {
+	if strings.Contains(token, "://") {
+		return e.add(token)
+	}
+
+	if strings.Contains(token, "/") {
+		ip, network, err := net.ParseCIDR(token)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR range: %s", token)
+		}
+		if ip.To4() == nil {
+			return fmt.Errorf("IPv6 ranges are not supported: %s", token)
+		}
+		ones, bits := network.Mask.Size()
+		first := binary.BigEndian.Uint32(network.IP.To4())
+		last := first | (1<<uint(bits-ones) - 1)
+		if bits-ones > 1 {
+			first, last = first+1, last-1
+		}
+		return e.addRange(first, last)
+	}
+
+	if start, end, found := strings.Cut(token, "-"); found {
+		if first := net.ParseIP(start).To4(); first != nil {
+			last := net.ParseIP(end).To4()
+			if last == nil {
+				octet, err := strconv.Atoi(end)
+				if err != nil || octet < 0 || octet > 255 {
+					return fmt.Errorf("invalid address range: %s", token)
+				}
+				last = net.IPv4(first[0], first[1], first[2], byte(octet)).To4()
+			}
+			from, to := binary.BigEndian.Uint32(first), binary.BigEndian.Uint32(last)
+			if from > to {
+				return fmt.Errorf("invalid address range: %s", token)
+			}
+			if e.maxHosts > 0 && uint64(to-from) >= uint64(e.maxHosts) {
+				return fmt.Errorf("targets expand to more than %d hosts", e.maxHosts)
+			}
+			return e.addRange(from, to)
+		}
+	}
+
+	if ip := net.ParseIP(token); ip != nil {
+		return e.add(ip.String())
+	}
+	return e.add(strings.ToLower(strings.TrimSuffix(token, ".")))
+}
@@ -0,0 +1,65 @@
+package targets
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name        string
+		expressions []string
+		maxHosts    int
+		want        []string
+		wantErr     bool
+	}{
+		{"Address", []string{"192.0.2.7"}, 0, []string{"192.0.2.7"}, false},
+		{"CIDR without network and broadcast", []string{"192.0.2.0/30"}, 0, []string{"192.0.2.1", "192.0.2.2"}, false},
+		{"Point-to-point CIDR", []string{"192.0.2.8/31"}, 0, []string{"192.0.2.8", "192.0.2.9"}, false},
+		{"Single address CIDR", []string{"192.0.2.8/32"}, 0, []string{"192.0.2.8"}, false},
+		{"Last octet range", []string{"10.0.0.1-3"}, 0, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, false},
+		{"Address range across octets", []string{"10.0.0.254-10.0.1.1"}, 0, []string{"10.0.0.254", "10.0.0.255", "10.0.1.0", "10.0.1.1"}, false},
+		{"Comma list", []string{"10.0.0.1, example.com,10.0.0.2"}, 0, []string{"10.0.0.1", "example.com", "10.0.0.2"}, false},
+		{"Duplicates", []string{"10.0.0.2", "10.0.0.1-3", "Example.com.", "example.com"}, 0, []string{"10.0.0.2", "10.0.0.1", "10.0.0.3", "example.com"}, false},
+		{"Hostname with dash and port", []string{"my-host.example.com:2222"}, 0, []string{"my-host.example.com:2222"}, false},
+		{"URL", []string{"https://example.com/login"}, 0, []string{"https://example.com/login"}, false},
+		{"IPv6 address", []string{"2001:db8::1"}, 0, []string{"2001:db8::1"}, false},
+		{"IPv6 range", []string{"2001:db8::/64"}, 0, nil, true},
+		{"Invalid CIDR", []string{"10.0.0.0/33"}, 0, nil, true},
+		{"Reversed range", []string{"10.0.0.50-1"}, 0, nil, true},
+		{"Invalid octet", []string{"10.0.0.1-300"}, 0, nil, true},
+		{"Too many hosts", []string{"10.0.0.0/16"}, 4096, nil, true},
+		{"Too many hosts in a range", []string{"10.0.0.0-10.255.255.255"}, 4096, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expand(tt.expressions, tt.maxHosts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expand(%v) error = %v, wantErr %v", tt.expressions, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expand(%v) = %v, want %v", tt.expressions, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.txt")
+	content := "# scope\nexample.com\n\n10.0.0.1-2, 10.0.0.8/31\n  api.example.com  \n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	got, err := LoadFile(path, 0)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	want := []string{"example.com", "10.0.0.1", "10.0.0.2", "10.0.0.8", "10.0.0.9", "api.example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadFile() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+// pkg/tenants.go
+package pkg
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// RunTenants manages the tenants of the API server, the business units
+// sharing it with their own users, scope and workspace
+func RunTenants(args []string) error {
+	usage := fmt.Errorf("usage: tenants add <name> --scope targets | tenants list | tenants remove <name>")
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch args[0] {
+	case "add":
+		fs := flag.NewFlagSet("tenants add", flag.ContinueOnError)
+		targets := fs.String("scope", "", "Comma-separated targets the tenant may scan: hosts, *.domains, CIDR ranges or re:URL patterns")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return usage
+		}
+		scope := []string{}
+		for _, target := range strings.Split(*targets, ",") {
+			if target = strings.TrimSpace(target); target != "" {
+				scope = append(scope, target)
+			}
+		}
+
+		users, err := openUserStore()
+		if err != nil {
+			return err
+		}
+		tenant, err := users.AddTenant(fs.Arg(0), scope)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[+] Tenant %s added, scope: %s\n", tenant.Name, strings.Join(tenant.Scope, ", "))
+		fmt.Printf("[i] Add its users with: users add <name> --role operator --tenant %s\n", tenant.Name)
+
+	case "remove":
+		if len(args) != 2 {
+			return usage
+		}
+		users, err := openUserStore()
+		if err != nil {
+			return err
+		}
+		if err := users.RemoveTenant(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("[+] Tenant %s removed, its workspace is kept\n", args[1])
+
+	case "list":
+		users, err := openUserStore()
+		if err != nil {
+			return err
+		}
+		list := users.Tenants()
+		if len(list) == 0 {
+			fmt.Println("[i] No tenants. Add one with: tenants add <name> --scope example.com")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSCOPE\tCREATED")
+		for _, tenant := range list {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", tenant.Name, strings.Join(tenant.Scope, ","), tenant.Created.Format("2006-01-02 15:04"))
+		}
+		w.Flush()
+
+	default:
+		return usage
+	}
+
+	return nil
+}
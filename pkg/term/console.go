@@ -0,0 +1,71 @@
+package term
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+)
+
+var (
+	escapes     bool
+	escapesOnce sync.Once
+)
+
+// SupportsEscapes reports whether stdout is a terminal that interprets ANSI
+// escape sequences. On Windows it first enables virtual terminal processing
+// of the console, which consoles older than Windows 10 do not support.
+func SupportsEscapes() bool {
+	escapesOnce.Do(func() {
+		escapes = IsTerminal(os.Stdout) && enableVirtualTerminal(os.Stdout)
+	})
+	return escapes
+}
+
+// ClearScreen clears the terminal with escape sequences where they are
+// supported, and with the system's clear command otherwise. Nothing is
+// written when stdout is not a terminal.
+func ClearScreen() {
+	if !IsTerminal(os.Stdout) {
+		return
+	}
+	if SupportsEscapes() {
+		fmt.Fprint(os.Stdout, "\033[H\033[2J\033[3J")
+		return
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "cls")
+	default:
+		cmd = exec.Command("clear")
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Run()
+}
+
+// HeadlessEnvVar enables headless mode when set to a true value
+const HeadlessEnvVar = "GOPHERSTRIKE_HEADLESS"
+
+var (
+	headless   bool
+	headlessMu sync.RWMutex
+)
+
+// SetHeadless turns headless mode on or off. In headless mode, as in a
+// container, nobody answers prompts: code that would ask for a missing
+// option must fail with an error naming it instead.
+func SetHeadless(on bool) {
+	headlessMu.Lock()
+	defer headlessMu.Unlock()
+	headless = on
+}
+
+// Headless reports whether prompts are forbidden
+func Headless() bool {
+	headlessMu.RLock()
+	defer headlessMu.RUnlock()
+	return headless
+}
@@ -0,0 +1,186 @@
+// Package term writes terminal output: ANSI colors that are dropped when the
+// output is not a terminal or the user disabled them, severity coloring, and
+// column-aligned tables
+package term
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Color is an ANSI SGR escape sequence
+type Color string
+
+// Colors used by the tools
+const (
+	Reset   Color = "\033[0m"
+	Bold    Color = "\033[1m"
+	Red     Color = "\033[31m"
+	Green   Color = "\033[32m"
+	Yellow  Color = "\033[33m"
+	Blue    Color = "\033[34m"
+	Magenta Color = "\033[35m"
+	Cyan    Color = "\033[36m"
+	BoldRed Color = "\033[1;31m"
+)
+
+// NoColorEnvVar disables colors when set to any value, see https://no-color.org
+const NoColorEnvVar = "NO_COLOR"
+
+var (
+	enabled     bool
+	enabledOnce sync.Once
+	mu          sync.RWMutex
+)
+
+// Enabled reports whether colors are written. By default they are when
+// stdout is a terminal that supports escape sequences, NO_COLOR is not set
+// and TERM is not "dumb".
+func Enabled() bool {
+	enabledOnce.Do(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		enabled = detect()
+	})
+	mu.RLock()
+	defer mu.RUnlock()
+	return enabled
+}
+
+// SetEnabled turns colors on or off, e.g. for --no-color or the
+// color_output setting
+func SetEnabled(on bool) {
+	enabledOnce.Do(func() {})
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = on
+}
+
+// detect decides whether stdout supports colors
+func detect() bool {
+	if _, set := os.LookupEnv(NoColorEnvVar); set {
+		return false
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return SupportsEscapes()
+}
+
+// IsTerminal reports whether a file is a terminal rather than a pipe or file
+func IsTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Colorize wraps s in a color, or returns it unchanged if colors are disabled
+func Colorize(color Color, s string) string {
+	if !Enabled() || color == "" {
+		return s
+	}
+	return string(color) + s + string(Reset)
+}
+
+// SeverityColor returns the color of a finding severity
+func SeverityColor(severity string) Color {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return BoldRed
+	case "high":
+		return Red
+	case "medium":
+		return Yellow
+	case "low":
+		return Green
+	case "info":
+		return Cyan
+	}
+	return ""
+}
+
+// Severity returns a severity name in its color
+func Severity(severity string) string {
+	return Colorize(SeverityColor(severity), severity)
+}
+
+// escapePattern matches ANSI escape sequences
+var escapePattern = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// Strip removes ANSI escape sequences from s
+func Strip(s string) string {
+	return escapePattern.ReplaceAllString(s, "")
+}
+
+// Width returns the number of characters s occupies on the terminal
+func Width(s string) int {
+	return utf8.RuneCountInString(Strip(s))
+}
+
+// Pad pads s with spaces to width characters, ignoring escape sequences
+func Pad(s string, width int) string {
+	if padding := width - Width(s); padding > 0 {
+		return s + strings.Repeat(" ", padding)
+	}
+	return s
+}
+
+// Table collects rows and writes them with aligned columns. Cells may be
+// colored; their escape sequences do not count towards the column width.
+type Table struct {
+	headers []string
+	rows    [][]string
+}
+
+// NewTable creates a table with column headers
+func NewTable(headers ...string) *Table {
+	return &Table{headers: headers}
+}
+
+// AddRow appends a row. Missing cells are left empty.
+func (t *Table) AddRow(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// Len returns the number of rows
+func (t *Table) Len() int {
+	return len(t.rows)
+}
+
+// Render writes the headers and rows, separating columns by two spaces.
+// The last column is not padded.
+func (t *Table) Render(w io.Writer) error {
+	widths := make([]int, len(t.headers))
+	for _, row := range append([][]string{t.headers}, t.rows...) {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if width := Width(cell); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+
+	for _, row := range append([][]string{t.headers}, t.rows...) {
+		var line strings.Builder
+		for i := range widths {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			if i == len(widths)-1 {
+				line.WriteString(cell)
+			} else {
+				line.WriteString(Pad(cell, widths[i]) + "  ")
+			}
+		}
+		if _, err := io.WriteString(w, strings.TrimRight(line.String(), " ")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
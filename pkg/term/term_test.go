@@ -0,0 +1,107 @@
+package term
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestColorize(t *testing.T) {
+	defer SetEnabled(Enabled())
+
+	SetEnabled(true)
+	if got := Colorize(Red, "open"); got != "\033[31mopen\033[0m" {
+		t.Errorf("Colorize() = %q with colors enabled", got)
+	}
+	if got := Severity("unknown"); got != "unknown" {
+		t.Errorf("Severity() = %q, want an uncolored unknown severity", got)
+	}
+
+	SetEnabled(false)
+	if got := Colorize(Red, "open"); got != "open" {
+		t.Errorf("Colorize() = %q with colors disabled, want plain text", got)
+	}
+	if got := Severity("High"); got != "High" {
+		t.Errorf("Severity() = %q with colors disabled, want plain text", got)
+	}
+}
+
+func TestSeverityColor(t *testing.T) {
+	tests := []struct {
+		severity string
+		want     Color
+	}{
+		{"critical", BoldRed},
+		{"High", Red},
+		{"MEDIUM", Yellow},
+		{"low", Green},
+		{"info", Cyan},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := SeverityColor(tt.severity); got != tt.want {
+			t.Errorf("SeverityColor(%q) = %q, want %q", tt.severity, got, tt.want)
+		}
+	}
+}
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"plain", 5},
+		{"\033[1;31mcritical\033[0m", 8},
+		{"héllo", 5},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := Width(tt.s); got != tt.want {
+			t.Errorf("Width(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestTable(t *testing.T) {
+	defer SetEnabled(Enabled())
+	SetEnabled(true)
+
+	table := NewTable("Port", "State", "Service")
+	table.AddRow("22", Colorize(Green, "open"), "ssh")
+	table.AddRow("8080", "filtered")
+
+	var out bytes.Buffer
+	if err := table.Render(&out); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := "Port  State     Service\n" +
+		"22    \033[32mopen\033[0m      ssh\n" +
+		"8080  filtered\n"
+	if out.String() != want {
+		t.Errorf("Render() =\n%q\nwant\n%q", out.String(), want)
+	}
+	if got := Strip(out.String()); got != "Port  State     Service\n22    open      ssh\n8080  filtered\n" {
+		t.Errorf("Strip() = %q", got)
+	}
+}
+
+func TestClearScreenNotTerminal(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	ClearScreen()
+	os.Stdout = stdout
+	writer.Close()
+
+	written, _ := io.ReadAll(reader)
+	if len(written) != 0 {
+		t.Errorf("ClearScreen() wrote %q to a pipe, want nothing", written)
+	}
+}
@@ -0,0 +1,182 @@
+// Package timing defines the timing profiles selected with --timing, named
+// after nmap's templates: from paranoid, which probes one target at a time
+// with long pauses to stay under IDS thresholds, to insane, meant for lab
+// networks. A profile does not replace the settings of the tools; it bounds
+// them, so slow profiles only ever slow a tool down and fast profiles only
+// ever speed it up. Normal leaves every tool with its own settings.
+package timing
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Profile bounds the concurrency, timeouts, retries and delays of the tools.
+// Zero fields leave the setting of the tool unchanged.
+type Profile struct {
+	Level       int           // 0 (paranoid) to 5 (insane), selected with T0 to T5
+	Name        string        // e.g. paranoid, polite
+	Concurrency int           // Parallel workers per tool
+	Timeout     time.Duration // Connection and request timeout
+	Retries     int           // Retries of failed requests
+	Delay       time.Duration // Pause between the probes of a scan
+	Description string
+}
+
+// Profile levels
+const (
+	Paranoid = iota
+	Sneaky
+	Polite
+	Normal
+	Aggressive
+	Insane
+)
+
+// Profiles lists the timing profiles from the slowest to the fastest
+var Profiles = []Profile{
+	{Level: Paranoid, Name: "paranoid", Concurrency: 1, Timeout: 60 * time.Second, Retries: 3, Delay: 15 * time.Second,
+		Description: "One probe every 15 seconds, to stay under IDS thresholds"},
+	{Level: Sneaky, Name: "sneaky", Concurrency: 1, Timeout: 30 * time.Second, Retries: 2, Delay: 3 * time.Second,
+		Description: "One probe every 3 seconds"},
+	{Level: Polite, Name: "polite", Concurrency: 2, Timeout: 20 * time.Second, Retries: 2, Delay: 400 * time.Millisecond,
+		Description: "Two workers and a 0.4 second pause between probes, to spare fragile targets"},
+	{Level: Normal, Name: "normal",
+		Description: "The settings of each tool"},
+	{Level: Aggressive, Name: "aggressive", Concurrency: 50, Timeout: 5 * time.Second, Retries: 1,
+		Description: "At least 50 workers, 5 second timeouts and no pauses, for fast and reliable networks"},
+	{Level: Insane, Name: "insane", Concurrency: 200, Timeout: 2 * time.Second,
+		Description: "At least 200 workers, 2 second timeouts, no retries and no pauses; for lab use, results may be missed"},
+}
+
+// Parse returns the profile named by s: T0 to T5, 0 to 5 or a profile
+// name, ignoring case. An empty name selects Normal.
+func Parse(s string) (Profile, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return Profiles[Normal], nil
+	}
+	if level, err := strconv.Atoi(strings.TrimPrefix(s, "t")); err == nil && level >= 0 && level < len(Profiles) {
+		return Profiles[level], nil
+	}
+	for _, p := range Profiles {
+		if s == p.Name {
+			return p, nil
+		}
+	}
+	return Profile{}, fmt.Errorf("unknown timing profile %q, use T0 to T5 or paranoid, sneaky, polite, normal, aggressive, insane", s)
+}
+
+// String returns the profile as T<level> (name)
+func (p Profile) String() string {
+	return fmt.Sprintf("T%d (%s)", p.Level, p.Name)
+}
+
+// slow reports whether the profile slows the tools down
+func (p Profile) slow() bool {
+	return p.Level < Normal
+}
+
+// Threads returns the number of parallel workers for a tool that would use n
+func (p Profile) Threads(n int) int {
+	switch {
+	case p.Concurrency == 0:
+		return n
+	case p.slow():
+		return min(n, p.Concurrency)
+	default:
+		return max(n, p.Concurrency)
+	}
+}
+
+// TimeoutFor returns the timeout for a tool that would wait d
+func (p Profile) TimeoutFor(d time.Duration) time.Duration {
+	switch {
+	case p.Timeout == 0:
+		return d
+	case p.slow():
+		return max(d, p.Timeout)
+	default:
+		return min(d, p.Timeout)
+	}
+}
+
+// TimeoutSeconds is TimeoutFor for the timeouts in seconds of tool options
+func (p Profile) TimeoutSeconds(seconds int) int {
+	return int(p.TimeoutFor(time.Duration(seconds) * time.Second).Seconds())
+}
+
+// RetriesFor returns the number of retries for a tool that would retry n times
+func (p Profile) RetriesFor(n int) int {
+	switch {
+	case p.Level == Normal:
+		return n
+	case p.slow():
+		return max(n, p.Retries)
+	default:
+		return min(n, p.Retries)
+	}
+}
+
+// DelayFor returns the pause between probes for a tool that would pause d
+func (p Profile) DelayFor(d time.Duration) time.Duration {
+	switch {
+	case p.Level == Normal:
+		return d
+	case p.slow():
+		return max(d, p.Delay)
+	default:
+		return min(d, p.Delay)
+	}
+}
+
+var (
+	current   = Profiles[Normal]
+	currentMu sync.Mutex
+	lastProbe time.Time
+)
+
+// Current returns the profile in use
+func Current() Profile {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	return current
+}
+
+// Set selects the profile in use
+func Set(p Profile) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = p
+}
+
+// Wait paces the probes of every tool to the delay of the profile in use:
+// it returns once the delay has passed since the previous probe started,
+// or when ctx is done. Profiles without a delay return at once.
+func Wait(ctx context.Context) error {
+	currentMu.Lock()
+	delay := current.Delay
+	if delay == 0 {
+		currentMu.Unlock()
+		return nil
+	}
+	next := lastProbe.Add(delay)
+	if now := time.Now(); next.Before(now) {
+		next = now
+	}
+	lastProbe = next
+	currentMu.Unlock()
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
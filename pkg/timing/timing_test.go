@@ -0,0 +1,108 @@
+package timing
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		valid bool
+	}{
+		{"", "normal", true},
+		{"T0", "paranoid", true},
+		{"t2", "polite", true},
+		{"4", "aggressive", true},
+		{" Insane ", "insane", true},
+		{"sneaky", "sneaky", true},
+		{"T6", "", false},
+		{"-1", "", false},
+		{"fast", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			p, err := Parse(tt.input)
+			if (err == nil) != tt.valid {
+				t.Fatalf("Parse(%q) error = %v, want valid %v", tt.input, err, tt.valid)
+			}
+			if p.Name != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, p.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestBounds(t *testing.T) {
+	tests := []struct {
+		level   int
+		threads int
+		timeout int
+		retries int
+		delay   time.Duration
+	}{
+		// A tool using 10 workers, a 10 second timeout, 1 retry and a 100ms delay
+		{Paranoid, 1, 60, 3, 15 * time.Second},
+		{Polite, 2, 20, 2, 400 * time.Millisecond},
+		{Normal, 10, 10, 1, 100 * time.Millisecond},
+		{Aggressive, 50, 5, 1, 0},
+		{Insane, 200, 2, 0, 0},
+	}
+
+	for _, tt := range tests {
+		p := Profiles[tt.level]
+		t.Run(p.Name, func(t *testing.T) {
+			if got := p.Threads(10); got != tt.threads {
+				t.Errorf("Threads(10) = %d, want %d", got, tt.threads)
+			}
+			if got := p.TimeoutSeconds(10); got != tt.timeout {
+				t.Errorf("TimeoutSeconds(10) = %d, want %d", got, tt.timeout)
+			}
+			if got := p.RetriesFor(1); got != tt.retries {
+				t.Errorf("RetriesFor(1) = %d, want %d", got, tt.retries)
+			}
+			if got := p.DelayFor(100 * time.Millisecond); got != tt.delay {
+				t.Errorf("DelayFor(100ms) = %s, want %s", got, tt.delay)
+			}
+		})
+	}
+
+	// Slow profiles never speed a tool up, fast ones never slow it down
+	if got := Profiles[Polite].Threads(1); got != 1 {
+		t.Errorf("polite Threads(1) = %d, want 1", got)
+	}
+	if got := Profiles[Aggressive].Threads(100); got != 100 {
+		t.Errorf("aggressive Threads(100) = %d, want 100", got)
+	}
+}
+
+func TestWait(t *testing.T) {
+	defer Set(Profiles[Normal])
+
+	Set(Profiles[Normal])
+	if err := Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() without delay error = %v", err)
+	}
+
+	Set(Profile{Level: Polite, Delay: 20 * time.Millisecond})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("3 probes took %s, want at least 40ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	Set(Profile{Level: Paranoid, Delay: time.Hour})
+	Wait(ctx)
+	if err := Wait(ctx); err != context.Canceled {
+		t.Errorf("Wait() after cancel error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -2,14 +2,17 @@
 package tools
 
 import (
-	"bufio"
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/estimate"
+	"GopherStrike/pkg/httpclient"
+	"GopherStrike/pkg/timing"
+	"GopherStrike/pkg/urlnorm"
+	"GopherStrike/pkg/wordlist"
 	"context"
-	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -54,6 +57,36 @@ type ScanOptions struct {
 	CheckSSL     bool   // Whether to check SSL certificates
 	Timeout      int    // Timeout in seconds for each check
 	ResolveIPs   bool   // Whether to resolve IPs
+	ChunkSize    int    // Words checked between two checkpoints, DefaultChunkSize if 0
+
+	// Resume is the checkpoint of an interrupted scan to resume, nil for a new scan
+	Resume *Checkpoint
+}
+
+// Assumed cost of the DNS lookup of a name
+const (
+	lookupLatency = 100 * time.Millisecond
+	lookupSize    = 200
+)
+
+// EstimateSubdomains predicts the cost of scanning domains with a wordlist of
+// words: a lookup per word, shared by the threads. A resumed scan skips the
+// names its checkpoint already checked.
+func EstimateSubdomains(domains, words int, options ScanOptions) estimate.Estimate {
+	if options.Resume != nil {
+		words = max(words-options.Resume.Checked, 0)
+	}
+	e := estimate.Estimate{
+		Targets:      domains,
+		Requests:     int64(words),
+		Workers:      options.Threads,
+		Latency:      lookupLatency,
+		ResponseSize: lookupSize,
+	}
+	if options.CheckHTTP {
+		e.Notes = append(e.Notes, "The HTTP requests to the names found are not counted")
+	}
+	return e
 }
 
 // ScanSubdomains performs subdomain enumeration for a target domain
@@ -82,6 +115,9 @@ func ScanSubdomains(domain string, options ScanOptions) (*ScanResult, error) {
 	if options.Timeout < 1 {
 		options.Timeout = 5
 	}
+	profile := timing.Current()
+	options.Threads = profile.Threads(options.Threads)
+	options.Timeout = profile.TimeoutSeconds(options.Timeout)
 
 	// Initialize result
 	result := &ScanResult{
@@ -90,64 +126,146 @@ func ScanSubdomains(domain string, options ScanOptions) (*ScanResult, error) {
 		Results:   []SubdomainResult{},
 	}
 
-	// Load wordlist
-	words, err := loadWordlist(options.WordlistPath)
+	// Count the wordlist; its words are read from disk a chunk at a time
+	total, err := wordlist.Count(options.WordlistPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load wordlist: %w", err)
 	}
+	if options.ChunkSize < 1 {
+		options.ChunkSize = DefaultChunkSize
+	}
 
-	fmt.Printf("Loaded %d subdomain names from %s\n", len(words), options.WordlistPath)
-
-	// Setup concurrency with channels
-	wordChan := make(chan string, len(words))
-	resultChan := make(chan SubdomainResult, len(words))
-
-	// Start worker goroutines
-	var wg sync.WaitGroup
-	for i := 0; i < options.Threads; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for word := range wordChan {
-				checkSubdomain(word, domain, options, resultChan)
-			}
-		}()
+	// A resumed scan continues the checkpoint, its results and its files
+	checkpoint := options.Resume
+	if checkpoint != nil {
+		if !strings.EqualFold(checkpoint.Domain, domain) || checkpoint.Wordlist != options.WordlistPath {
+			return nil, fmt.Errorf("checkpoint %s is for another domain or wordlist", checkpoint.Path())
+		}
+		if checkpoint.WordlistSize != total {
+			return nil, fmt.Errorf("wordlist %s changed since checkpoint %s", options.WordlistPath, checkpoint.Path())
+		}
+		options.ChunkSize = checkpoint.ChunkSize
+		result.TimeStamp = checkpoint.TimeStamp
+		result.Results = append(result.Results, checkpoint.Active...)
+		result.Active = len(checkpoint.Active)
+		fmt.Printf("Resuming at chunk %d/%d, %d names already checked\n",
+			checkpoint.NextChunk+1, checkpoint.TotalChunks, checkpoint.Checked)
+	} else {
+		checkpoint = &Checkpoint{
+			Domain:       domain,
+			Wordlist:     options.WordlistPath,
+			WordlistSize: total,
+			ChunkSize:    options.ChunkSize,
+			TotalChunks:  wordlist.ChunkCount(total, options.ChunkSize),
+			TimeStamp:    result.TimeStamp,
+			Active:       []SubdomainResult{},
+			Chunks:       []ChunkStats{},
+		}
 	}
 
-	// Feed words to workers
-	for _, word := range words {
-		wordChan <- word
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chunks, err := wordlist.OpenChunks(ctx, options.WordlistPath, options.ChunkSize, checkpoint.NextChunk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load wordlist: %w", err)
 	}
-	close(wordChan)
 
-	// Close result channel when all workers are done
-	go func() {
-		wg.Wait()
-		close(resultChan)
+	fmt.Printf("Loaded %d subdomain names from %s in %d chunk(s) of %d\n",
+		total, options.WordlistPath, checkpoint.TotalChunks, options.ChunkSize)
+
+	// Every check is written to a JSON lines artifact as it completes, so a
+	// scan that crashes keeps its progress and only the active subdomains
+	// have to be held in memory
+	var stream *artifacts.StreamWriter
+	if checkpoint.Stream != "" {
+		stream, err = artifacts.AppendStreamWriter(checkpoint.Stream)
+	} else {
+		stream, err = artifacts.Default().CreateStream(domain, artifacts.KindSubdomains,
+			fmt.Sprintf("subdomains_%s.jsonl", result.TimeStamp))
+	}
+	if err != nil {
+		fmt.Printf("Warning: Failed to create results file, keeping results in memory: %v\n", err)
+		stream = nil
+	} else {
+		checkpoint.Stream = stream.Path()
+		fmt.Printf("Writing every check to %s\n", stream.Path())
+	}
+	defer func() {
+		if stream != nil {
+			stream.Close()
+		}
 	}()
 
+	// Words that differ only in case name the same host and are checked once
+	probed := urlnorm.NewSet()
+
 	// Process results
 	fmt.Println("Scanning subdomains (each dot represents 10 checks)...")
 
-	count := 0
-	for subResult := range resultChan {
-		result.Results = append(result.Results, subResult)
+	count := checkpoint.Checked
+	for chunk := range chunks.Chunks {
+		stats := ChunkStats{Index: chunk.Index, Words: len(chunk.Words)}
+		chunkStart := time.Now()
+
+		for subResult := range scanChunk(ctx, chunk.Words, domain, options, probed) {
+			if stream != nil {
+				if err := stream.WriteJSON(subResult); err != nil {
+					fmt.Printf("\nWarning: Failed to write results file, keeping results in memory: %v\n", err)
+					stream.Close()
+					stream = nil
+					checkpoint.Stream = ""
+				}
+			}
 
-		if subResult.Active {
-			result.Active++
+			stats.Checked++
+			if subResult.Active {
+				stats.Active++
+				result.Active++
+				checkpoint.Active = append(checkpoint.Active, subResult)
+			} else if subResult.Error != "" && !strings.Contains(subResult.Error, "no such host") {
+				stats.Errors++
+			}
+			if subResult.Active || stream == nil {
+				result.Results = append(result.Results, subResult)
+			}
+
+			count++
+			if count%10 == 0 {
+				fmt.Print(".")
+			}
+			if count%500 == 0 {
+				fmt.Printf(" %d/%d\n", count, total)
+			}
 		}
 
-		count++
-		if count%10 == 0 {
-			fmt.Print(".")
+		// The checkpoint moves past a chunk once all of its words are checked
+		stats.Seconds = time.Since(chunkStart).Seconds()
+		if stats.Seconds > 0 {
+			stats.Rate = float64(stats.Checked) / stats.Seconds
+		}
+		checkpoint.Chunks = append(checkpoint.Chunks, stats)
+		checkpoint.NextChunk = chunk.Index + 1
+		checkpoint.Checked = count
+		if err := checkpoint.Save(); err != nil {
+			fmt.Printf("\nWarning: Failed to save checkpoint: %v\n", err)
 		}
-		if count%500 == 0 {
-			fmt.Printf(" %d/%d\n", count, len(words))
+		if checkpoint.TotalChunks > 1 {
+			fmt.Printf("\nChunk %d/%d: %d checked, %d active, %d errors in %.1fs (%.0f/s)\n",
+				chunk.Index+1, checkpoint.TotalChunks, stats.Checked, stats.Active, stats.Errors, stats.Seconds, stats.Rate)
 		}
 	}
 
+	if err := chunks.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read wordlist: %w", err)
+	}
+
+	checkpoint.Completed = true
+	if err := checkpoint.Save(); err != nil {
+		fmt.Printf("Warning: Failed to save checkpoint: %v\n", err)
+	}
+
 	// Finalize results
-	result.TotalFound = len(result.Results)
+	result.TotalFound = count
 	result.Duration = time.Since(startTime).Seconds()
 
 	// Save results to file
@@ -155,12 +273,54 @@ func ScanSubdomains(domain string, options ScanOptions) (*ScanResult, error) {
 		fmt.Printf("Warning: Failed to save results: %v\n", err)
 	}
 
-	fmt.Printf("\nCompleted %d subdomain checks in %.2f seconds\n", len(words), result.Duration)
+	fmt.Printf("\nCompleted %d subdomain checks in %.2f seconds\n", count, result.Duration)
 	fmt.Printf("Found %d active subdomains\n", result.Active)
 
 	return result, nil
 }
 
+// scanChunk checks the words of a chunk with the scan's workers and returns
+// their results, the channel being closed once every word is checked
+func scanChunk(ctx context.Context, words []string, domain string, options ScanOptions, probed *urlnorm.Set) <-chan SubdomainResult {
+	resultChan := make(chan SubdomainResult, options.Threads)
+	wordChan := make(chan string)
+
+	var wg sync.WaitGroup
+	for i := 0; i < options.Threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for word := range wordChan {
+				if !probed.Add(fmt.Sprintf("https://%s.%s", word, domain)) {
+					continue
+				}
+				if timing.Wait(ctx) != nil {
+					return
+				}
+				checkSubdomain(word, domain, options, resultChan)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(wordChan)
+		for _, word := range words {
+			select {
+			case wordChan <- word:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Close result channel when all workers are done
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+	return resultChan
+}
+
 // checkSubdomain checks if a subdomain exists and gathers information about it
 func checkSubdomain(word, domain string, options ScanOptions, resultChan chan<- SubdomainResult) {
 	startTime := time.Now()
@@ -216,12 +376,10 @@ func checkSubdomain(word, domain string, options ScanOptions, resultChan chan<-
 
 // checkHTTPStatus checks HTTP status of a domain
 func checkHTTPStatus(domain string, timeout int) (int, error) {
-	client := &http.Client{
-		Timeout: time.Duration(timeout) * time.Second,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			// Don't follow redirects
-			return http.ErrUseLastResponse
-		},
+	client := httpclient.New("subtapper", time.Duration(timeout)*time.Second, nil)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		// Don't follow redirects
+		return http.ErrUseLastResponse
 	}
 
 	// Try HTTPS first
@@ -241,50 +399,15 @@ func checkHTTPStatus(domain string, timeout int) (int, error) {
 	return 0, err
 }
 
-// loadWordlist loads a wordlist file
-func loadWordlist(path string) ([]string, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	var words []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		word := strings.TrimSpace(scanner.Text())
-		if word != "" && !strings.HasPrefix(word, "#") {
-			words = append(words, word)
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-
-	return words, nil
-}
-
-// saveResults saves the scan results to a JSON file
+// saveResults saves the scan results to the domain's subdomains artifact directory
 func saveResults(result *ScanResult) error {
-	// Create logs directory
-	os.MkdirAll("logs", 0755)
-
-	// Create filename
-	filename := filepath.Join("logs", fmt.Sprintf("subdomains_%s_%s.json",
-		result.Domain, result.TimeStamp))
+	name := fmt.Sprintf("subdomains_%s.json", result.TimeStamp)
 
-	// Marshal to JSON
-	data, err := json.MarshalIndent(result, "", "  ")
+	filename, err := artifacts.Default().WriteJSON(result.Domain, artifacts.KindSubdomains, name, result)
 	if err != nil {
 		return err
 	}
 
-	// Write to file
-	if err := os.WriteFile(filename, data, 0644); err != nil {
-		return err
-	}
-
 	fmt.Printf("\nResults saved to %s\n", filename)
 	return nil
 }
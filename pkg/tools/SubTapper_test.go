@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/redact"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSavedResultsRedacted(t *testing.T) {
+	// The results go to the workspaces of the working directory
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Chdir() error = %v", err)
+	}
+	defer os.Chdir(wd)
+
+	rules, err := redact.Parse(strings.NewReader("payroll-acme\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	redact.Set(rules)
+	defer redact.Set(nil)
+
+	subdomain := SubdomainResult{Name: "payroll-acme.example.com", IPs: []string{"192.0.2.10"}, Active: true}
+	if err := saveResults(&ScanResult{Domain: "example.com", TimeStamp: "20240816-143015", Results: []SubdomainResult{subdomain}}); err != nil {
+		t.Fatalf("saveResults() error = %v", err)
+	}
+	stream, err := artifacts.Default().CreateStream("example.com", artifacts.KindSubdomains, "subdomains_20240816-143015.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.WriteJSON(subdomain); err != nil {
+		t.Fatal(err)
+	}
+	stream.Close()
+
+	dir, err := artifacts.Default().TargetDir("example.com", artifacts.KindSubdomains)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"subdomains_20240816-143015.json", "subdomains_20240816-143015.jsonl"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "payroll-acme") || !strings.Contains(string(data), "[REDACTED].example.com") {
+			t.Errorf("%s not redacted:\n%s", name, data)
+		}
+	}
+}
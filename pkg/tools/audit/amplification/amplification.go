@@ -0,0 +1,404 @@
+// pkg/tools/audit/amplification/amplification.go
+package amplification
+
+import (
+	"GopherStrike/pkg/egress"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/scope"
+	"GopherStrike/pkg/siem"
+	"GopherStrike/pkg/stats"
+	"GopherStrike/pkg/targets"
+	"GopherStrike/pkg/timing"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severity levels of amplification exposures
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+)
+
+// Exposure is a service answering small spoofable UDP requests with larger
+// responses
+type Exposure struct {
+	Service       string  `json:"service"` // dns or ntp
+	Check         string  `json:"check"`   // open-resolver, monlist or readvar
+	Severity      string  `json:"severity"`
+	RequestBytes  int     `json:"request_bytes"`
+	ResponseBytes int     `json:"response_bytes"`
+	Packets       int     `json:"packets"`
+	Factor        float64 `json:"factor"` // Response bytes per request byte
+	Description   string  `json:"description"`
+}
+
+// HostResult is the amplification check of one address
+type HostResult struct {
+	IP        string     `json:"ip"`
+	Exposures []Exposure `json:"exposures,omitempty"`
+}
+
+// CheckOptions contains options for the amplification checker
+type CheckOptions struct {
+	Timeout     int    // Seconds to wait for UDP responses
+	Concurrency int    // Addresses checked in parallel
+	MaxHosts    int    // Largest number of addresses a scan expands to
+	QueryName   string // Name queried for the DNS amplification estimate
+	DNSPort     int
+	NTPPort     int
+}
+
+// DefaultCheckOptions returns the default checker options
+func DefaultCheckOptions() CheckOptions {
+	return CheckOptions{
+		Timeout:     2,
+		Concurrency: 50,
+		MaxHosts:    4096,
+		QueryName:   "isc.org",
+		DNSPort:     53,
+		NTPPort:     123,
+	}
+}
+
+// PresetCheckOptions returns the checker options of a preset. Quick probes
+// many addresses at once and waits one second for answers; deep waits five
+// seconds, catching slow and rate-limited responders.
+func PresetCheckOptions(preset string) (CheckOptions, error) {
+	preset, err := presets.Validate(preset)
+	if err != nil {
+		return CheckOptions{}, err
+	}
+
+	options := DefaultCheckOptions()
+	switch preset {
+	case presets.Quick:
+		options.Timeout = 1
+		options.Concurrency = 200
+	case presets.Deep:
+		options.Timeout = 5
+		options.Concurrency = 20
+	}
+
+	profile := timing.Current()
+	options.Concurrency = profile.Threads(options.Concurrency)
+	options.Timeout = profile.TimeoutSeconds(options.Timeout)
+	return options, nil
+}
+
+// recursionProbeName is resolved to tell recursive resolvers from
+// authoritative-only servers
+const recursionProbeName = "example.com"
+
+// DNS record types and header flags used by the probes
+const (
+	dnsTypeA     = 1
+	dnsTypeOPT   = 41
+	dnsTypeANY   = 255
+	dnsFlagQR    = 0x8000
+	dnsFlagRD    = 0x0100
+	dnsFlagRA    = 0x0080
+	dnsRcodeMask = 0x000f
+)
+
+// ntpMonlistRequest is a mode 7 MON_GETLIST_1 request to the XNTPD
+// implementation, padded as ntpdc sends it
+var ntpMonlistRequest = append([]byte{0x17, 0x00, 0x03, 0x2a}, make([]byte, 44)...)
+
+// ntpReadvarRequest is a mode 6 READVAR control request for the system
+// variables
+var ntpReadvarRequest = []byte{0x16, 0x02, 0x00, 0x01, 0, 0, 0, 0, 0, 0, 0, 0}
+
+// Checker probes addresses for DNS and NTP amplification exposure
+type Checker struct {
+	options CheckOptions
+}
+
+// NewChecker creates an amplification checker
+func NewChecker(options CheckOptions) *Checker {
+	defaults := DefaultCheckOptions()
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+	if options.QueryName == "" {
+		options.QueryName = defaults.QueryName
+	}
+	if options.DNSPort == 0 {
+		options.DNSPort = defaults.DNSPort
+	}
+	if options.NTPPort == 0 {
+		options.NTPPort = defaults.NTPPort
+	}
+	return &Checker{options: options}
+}
+
+// ExpandTargets turns target expressions (see targets.Expand) into the
+// IPv4 addresses to probe, resolving hostnames. Excluded hosts and
+// addresses are left out.
+func ExpandTargets(ctx context.Context, entries []string, maxHosts int) ([]string, error) {
+	expanded, err := targets.Expand(entries, maxHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := []string{}
+	seen := map[string]bool{}
+	for _, target := range expanded {
+		ips := []string{target}
+		if ip := net.ParseIP(target); ip == nil {
+			resolved, err := net.DefaultResolver.LookupIP(ctx, "ip4", target)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve %s: %v", target, err)
+			}
+			ips = ips[:0]
+			for _, ip := range resolved {
+				ips = append(ips, ip.String())
+			}
+		} else if ip.To4() == nil {
+			return nil, fmt.Errorf("only IPv4 addresses are supported: %s", target)
+		}
+
+		for _, ip := range ips {
+			if seen[ip] || scope.Active().ExcludesHost(target) || scope.Active().ExcludesIP(net.ParseIP(ip)) {
+				continue
+			}
+			if maxHosts > 0 && len(addresses) >= maxHosts {
+				return nil, fmt.Errorf("targets expand to more than %d hosts", maxHosts)
+			}
+			seen[ip] = true
+			addresses = append(addresses, ip)
+		}
+	}
+	return addresses, nil
+}
+
+// CheckHosts probes the addresses in parallel and returns the results in
+// their order. Every exposure is reported as a finding.
+func (c *Checker) CheckHosts(ctx context.Context, target string, addresses []string) []HostResult {
+	eventbus.Publish(eventbus.Event{Type: eventbus.ScanStarted, Tool: "amplification", Target: target})
+	results := make([]HostResult, len(addresses))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.options.Concurrency)
+	for i, ip := range addresses {
+		wg.Add(1)
+		go func(i int, ip string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if timing.Wait(ctx) != nil {
+				results[i] = HostResult{IP: ip}
+				return
+			}
+
+			results[i] = c.CheckHost(ctx, ip)
+			for _, exposure := range results[i].Exposures {
+				siem.Emit(siem.Finding{
+					Tool:        "amplification",
+					Target:      ip,
+					Category:    "UDP_AMPLIFICATION",
+					Name:        fmt.Sprintf("%s amplification (%s) on %s", strings.ToUpper(exposure.Service), exposure.Check, ip),
+					Severity:    model.Severity(exposure.Severity),
+					Description: exposure.Description,
+				})
+			}
+		}(i, ip)
+	}
+	wg.Wait()
+
+	exposed := 0
+	for _, result := range results {
+		if len(result.Exposures) > 0 {
+			exposed++
+		}
+	}
+	eventbus.ScanFinished("amplification", target, ctx.Err() != nil, nil, map[string]int{"hosts": len(addresses), "exposed": exposed})
+	return results
+}
+
+// CheckHost probes one address for an open DNS resolver and NTP monlist
+// and readvar responses
+func (c *Checker) CheckHost(ctx context.Context, ip string) HostResult {
+	result := HostResult{IP: ip}
+	if exposure, ok := c.checkDNS(ctx, ip); ok {
+		result.Exposures = append(result.Exposures, exposure)
+	}
+	result.Exposures = append(result.Exposures, c.checkNTP(ctx, ip)...)
+	return result
+}
+
+// exchange sends a UDP request and collects the responses until the
+// timeout. Amplifying services often answer with several datagrams.
+func (c *Checker) exchange(ctx context.Context, address string, request []byte, single bool) ([][]byte, error) {
+	dialer := &net.Dialer{}
+	conn, err := stats.DialContext(egress.DialContext("amplification", dialer.DialContext))(ctx, "udp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(time.Duration(c.options.Timeout) * time.Second))
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	responses := [][]byte{}
+	buffer := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			break
+		}
+		responses = append(responses, append([]byte{}, buffer[:n]...))
+		if single {
+			break
+		}
+	}
+	return responses, nil
+}
+
+// newExposure computes the amplification factor of the responses
+func newExposure(service, check, severity string, request []byte, responses [][]byte) Exposure {
+	exposure := Exposure{
+		Service:      service,
+		Check:        check,
+		Severity:     severity,
+		RequestBytes: len(request),
+		Packets:      len(responses),
+	}
+	for _, response := range responses {
+		exposure.ResponseBytes += len(response)
+	}
+	exposure.Factor = float64(exposure.ResponseBytes) / float64(exposure.RequestBytes)
+	return exposure
+}
+
+// checkDNS reports a server that resolves names it isn't authoritative for,
+// estimating the amplification with an ANY query with a large EDNS buffer
+func (c *Checker) checkDNS(ctx context.Context, ip string) (Exposure, bool) {
+	address := net.JoinHostPort(ip, strconv.Itoa(c.options.DNSPort))
+
+	query, id := dnsQuery(recursionProbeName, dnsTypeA, false)
+	responses, err := c.exchange(ctx, address, query, true)
+	if err != nil || len(responses) == 0 || !recursiveAnswer(responses[0], id) {
+		return Exposure{}, false
+	}
+
+	// Open resolvers that refuse ANY are still abusable, so the recursion
+	// probe is the estimate when the ANY query goes unanswered
+	request, responses := query, responses
+	anyQuery, anyID := dnsQuery(c.options.QueryName, dnsTypeANY, true)
+	if anyResponses, err := c.exchange(ctx, address, anyQuery, true); err == nil && len(anyResponses) > 0 && dnsResponseTo(anyResponses[0], anyID) {
+		request, responses = anyQuery, anyResponses
+	}
+
+	exposure := newExposure("dns", "open-resolver", SeverityHigh, request, responses)
+	exposure.Description = fmt.Sprintf("Open recursive DNS resolver: a %d-byte query returns %d bytes (amplification factor %.1fx)",
+		exposure.RequestBytes, exposure.ResponseBytes, exposure.Factor)
+	return exposure, true
+}
+
+// checkNTP reports mode 7 monlist and mode 6 readvar responses
+func (c *Checker) checkNTP(ctx context.Context, ip string) []Exposure {
+	address := net.JoinHostPort(ip, strconv.Itoa(c.options.NTPPort))
+	exposures := []Exposure{}
+
+	if responses, err := c.exchange(ctx, address, ntpMonlistRequest, false); err == nil && monlistAnswer(responses) {
+		exposure := newExposure("ntp", "monlist", SeverityHigh, ntpMonlistRequest, responses)
+		exposure.Description = fmt.Sprintf("NTP monlist enabled (CVE-2013-5211): a %d-byte request returns %d bytes in %d packet(s) (amplification factor %.1fx)",
+			exposure.RequestBytes, exposure.ResponseBytes, exposure.Packets, exposure.Factor)
+		exposures = append(exposures, exposure)
+	}
+
+	if responses, err := c.exchange(ctx, address, ntpReadvarRequest, false); err == nil && readvarAnswer(responses) {
+		exposure := newExposure("ntp", "readvar", SeverityMedium, ntpReadvarRequest, responses)
+		exposure.Description = fmt.Sprintf("NTP mode 6 queries answered: a %d-byte READVAR request returns %d bytes (amplification factor %.1fx)",
+			exposure.RequestBytes, exposure.ResponseBytes, exposure.Factor)
+		exposures = append(exposures, exposure)
+	}
+	return exposures
+}
+
+// dnsQuery builds a recursive query for name and returns it with its ID.
+// With edns, an OPT record advertises a 4096-byte UDP buffer.
+func dnsQuery(name string, qtype uint16, edns bool) ([]byte, uint16) {
+	var random [2]byte
+	rand.Read(random[:])
+	id := binary.BigEndian.Uint16(random[:])
+
+	additional := uint16(0)
+	if edns {
+		additional = 1
+	}
+	query := binary.BigEndian.AppendUint16(nil, id)
+	query = binary.BigEndian.AppendUint16(query, dnsFlagRD)
+	query = binary.BigEndian.AppendUint16(query, 1) // questions
+	query = binary.BigEndian.AppendUint16(query, 0)
+	query = binary.BigEndian.AppendUint16(query, 0)
+	query = binary.BigEndian.AppendUint16(query, additional)
+
+	for _, label := range strings.Split(strings.Trim(name, "."), ".") {
+		if label != "" {
+			query = append(query, byte(len(label)))
+			query = append(query, label...)
+		}
+	}
+	query = append(query, 0)
+	query = binary.BigEndian.AppendUint16(query, qtype)
+	query = binary.BigEndian.AppendUint16(query, 1) // class IN
+
+	if edns {
+		query = append(query, 0) // root name
+		query = binary.BigEndian.AppendUint16(query, dnsTypeOPT)
+		query = binary.BigEndian.AppendUint16(query, 4096) // UDP payload size
+		query = append(query, 0, 0, 0, 0, 0, 0)            // extended rcode, version, flags, no data
+	}
+	return query, id
+}
+
+// dnsResponseTo reports whether response answers the query with id
+func dnsResponseTo(response []byte, id uint16) bool {
+	return len(response) >= 12 && binary.BigEndian.Uint16(response[:2]) == id &&
+		binary.BigEndian.Uint16(response[2:4])&dnsFlagQR != 0
+}
+
+// recursiveAnswer reports whether response is a successful answer with
+// recursion available
+func recursiveAnswer(response []byte, id uint16) bool {
+	if !dnsResponseTo(response, id) {
+		return false
+	}
+	flags := binary.BigEndian.Uint16(response[2:4])
+	answers := binary.BigEndian.Uint16(response[6:8])
+	return flags&dnsFlagRA != 0 && flags&dnsRcodeMask == 0 && answers > 0
+}
+
+// monlistAnswer reports whether the responses are mode 7 monlist replies
+// carrying data
+func monlistAnswer(responses [][]byte) bool {
+	for _, response := range responses {
+		if len(response) > 8 && response[0]&0x80 != 0 && response[0]&0x07 == 7 && response[3] == 0x2a {
+			return true
+		}
+	}
+	return false
+}
+
+// readvarAnswer reports whether the responses are mode 6 replies carrying
+// data
+func readvarAnswer(responses [][]byte) bool {
+	for _, response := range responses {
+		if len(response) > 12 && response[0]&0x07 == 6 && response[1]&0x80 != 0 {
+			return true
+		}
+	}
+	return false
+}
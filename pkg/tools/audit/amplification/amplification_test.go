@@ -0,0 +1,152 @@
+package amplification
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// serveUDP starts a UDP server that answers each request with the
+// datagrams returned by reply and returns its port
+func serveUDP(t *testing.T, reply func(request []byte) [][]byte) int {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.ListenPacket() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buffer := make([]byte, 2048)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			for _, response := range reply(append([]byte{}, buffer[:n]...)) {
+				conn.WriteTo(response, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// dnsServer answers queries with a response of size bytes, with recursion
+// available if recursive
+func dnsServer(recursive bool, size int) func([]byte) [][]byte {
+	return func(request []byte) [][]byte {
+		response := make([]byte, size)
+		copy(response, request[:2])
+		flags := uint16(dnsFlagQR | dnsFlagRD)
+		if recursive {
+			flags |= dnsFlagRA
+		}
+		binary.BigEndian.PutUint16(response[2:4], flags)
+		binary.BigEndian.PutUint16(response[6:8], 1) // one answer
+		return [][]byte{response}
+	}
+}
+
+// ntpServer answers monlist requests with packets datagrams of 440 bytes
+// and readvar requests with a 468-byte reply
+func ntpServer(packets int, readvar bool) func([]byte) [][]byte {
+	return func(request []byte) [][]byte {
+		switch {
+		case request[0]&0x07 == 7:
+			responses := [][]byte{}
+			for i := 0; i < packets; i++ {
+				response := make([]byte, 440)
+				response[0], response[3] = 0x97, 0x2a
+				responses = append(responses, response)
+			}
+			return responses
+		case request[0]&0x07 == 6 && readvar:
+			response := make([]byte, 468)
+			response[0], response[1] = 0x16, 0x82
+			return [][]byte{response}
+		}
+		return nil
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	noReply := func([]byte) [][]byte { return nil }
+
+	tests := []struct {
+		name string
+		dns  func([]byte) [][]byte
+		ntp  func([]byte) [][]byte
+		want []string // service/check/severity/packets of each exposure
+	}{
+		{
+			name: "Nothing exposed",
+			dns:  dnsServer(false, 512),
+			ntp:  noReply,
+		},
+		{
+			name: "Open resolver",
+			dns:  dnsServer(true, 3000),
+			ntp:  noReply,
+			want: []string{"dns/open-resolver/high/1"},
+		},
+		{
+			name: "NTP monlist and readvar",
+			dns:  noReply,
+			ntp:  ntpServer(6, true),
+			want: []string{"ntp/monlist/high/6", "ntp/readvar/medium/1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultCheckOptions()
+			options.Timeout = 1
+			options.DNSPort = serveUDP(t, tt.dns)
+			options.NTPPort = serveUDP(t, tt.ntp)
+
+			result := NewChecker(options).CheckHost(context.Background(), "127.0.0.1")
+			got := []string{}
+			for _, exposure := range result.Exposures {
+				got = append(got, exposure.Service+"/"+exposure.Check+"/"+exposure.Severity+"/"+strconv.Itoa(exposure.Packets))
+				if exposure.Factor != float64(exposure.ResponseBytes)/float64(exposure.RequestBytes) || exposure.Factor <= 1 {
+					t.Errorf("%s: factor = %.2f for %d/%d bytes", exposure.Check, exposure.Factor, exposure.ResponseBytes, exposure.RequestBytes)
+				}
+			}
+			if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("CheckHost() exposures = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []string
+		maxHosts int
+		want     []string
+		wantErr  bool
+	}{
+		{"Address", []string{"192.0.2.7"}, 0, []string{"192.0.2.7"}, false},
+		{"Range without network and broadcast", []string{"192.0.2.0/30"}, 0, []string{"192.0.2.1", "192.0.2.2"}, false},
+		{"Point-to-point range", []string{"192.0.2.8/31"}, 0, []string{"192.0.2.8", "192.0.2.9"}, false},
+		{"Duplicates", []string{"192.0.2.1", "192.0.2.0/30"}, 0, []string{"192.0.2.1", "192.0.2.2"}, false},
+		{"Too many addresses", []string{"10.0.0.0/16"}, 4096, nil, true},
+		{"IPv6 range", []string{"2001:db8::/64"}, 0, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandTargets(context.Background(), tt.entries, tt.maxHosts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandTargets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandTargets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
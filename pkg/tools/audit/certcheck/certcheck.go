@@ -0,0 +1,441 @@
+// pkg/tools/audit/certcheck/certcheck.go
+package certcheck
+
+import (
+	"GopherStrike/pkg/egress"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/siem"
+	"GopherStrike/pkg/stats"
+	"GopherStrike/pkg/timing"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severity levels of certificate problems
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+)
+
+// Problem is an issue found with a host's certificate or TLS implementation
+type Problem struct {
+	Check       string `json:"check"` // expiry, signature, key, chain, hostname, heartbleed, ccs, renegotiation or robot
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+	Reference   string `json:"reference,omitempty"` // CVEs and advisories of TLS flaws
+}
+
+// CertResult is the certificate check of one host
+type CertResult struct {
+	Host               string    `json:"host"`
+	Address            string    `json:"address"`
+	Subject            string    `json:"subject,omitempty"`
+	Issuer             string    `json:"issuer,omitempty"`
+	DNSNames           []string  `json:"dns_names,omitempty"`
+	NotAfter           time.Time `json:"not_after,omitempty"`
+	DaysLeft           int       `json:"days_left"`
+	SignatureAlgorithm string    `json:"signature_algorithm,omitempty"`
+	ChainLength        int       `json:"chain_length"` // Certificates sent by the server
+	Problems           []Problem `json:"problems,omitempty"`
+	Error              string    `json:"error,omitempty"`
+}
+
+// CheckOptions contains options for the certificate checker
+type CheckOptions struct {
+	Timeout      int            // Connection timeout in seconds
+	Concurrency  int            // Hosts checked in parallel
+	WarnDays     int            // Report certificates expiring within this many days
+	CriticalDays int            // Report certificates expiring this soon as high severity
+	Probes       bool           // Probe for Heartbleed, CCS injection, insecure renegotiation and ROBOT
+	RootCAs      *x509.CertPool // Trusted roots; the system roots if nil
+}
+
+// DefaultCheckOptions returns the default checker options
+func DefaultCheckOptions() CheckOptions {
+	return CheckOptions{
+		Timeout:      10,
+		Concurrency:  10,
+		WarnDays:     30,
+		CriticalDays: 7,
+		Probes:       true,
+	}
+}
+
+// PresetCheckOptions returns the checker options of a preset. Quick checks
+// many hosts at once with a short timeout and skips the TLS flaw probes;
+// deep waits longer for slow hosts and warns about certificates expiring
+// within 60 days.
+func PresetCheckOptions(preset string) (CheckOptions, error) {
+	preset, err := presets.Validate(preset)
+	if err != nil {
+		return CheckOptions{}, err
+	}
+
+	options := DefaultCheckOptions()
+	switch preset {
+	case presets.Quick:
+		options.Timeout = 5
+		options.Concurrency = 50
+		options.Probes = false
+	case presets.Deep:
+		options.Timeout = 30
+		options.Concurrency = 5
+		options.WarnDays = 60
+	}
+
+	profile := timing.Current()
+	options.Concurrency = profile.Threads(options.Concurrency)
+	options.Timeout = profile.TimeoutSeconds(options.Timeout)
+	return options, nil
+}
+
+// weakSignatures are signature algorithms with practical collision attacks
+var weakSignatures = map[x509.SignatureAlgorithm]bool{
+	x509.MD2WithRSA:    true,
+	x509.MD5WithRSA:    true,
+	x509.SHA1WithRSA:   true,
+	x509.DSAWithSHA1:   true,
+	x509.ECDSAWithSHA1: true,
+}
+
+// Checker checks the certificates of TLS servers
+type Checker struct {
+	options CheckOptions
+	now     func() time.Time
+}
+
+// NewChecker creates a certificate checker
+func NewChecker(options CheckOptions) *Checker {
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+	return &Checker{options: options, now: time.Now}
+}
+
+// ParseHost splits a host entry such as "example.com", "example.com:8443"
+// or "https://example.com/path" into the server name and the address to
+// connect to, defaulting to port 443
+func ParseHost(entry string) (string, string, error) {
+	entry = strings.TrimSpace(entry)
+	if _, rest, found := strings.Cut(entry, "://"); found {
+		entry = rest
+	}
+	entry, _, _ = strings.Cut(entry, "/")
+	if entry == "" {
+		return "", "", fmt.Errorf("empty host")
+	}
+
+	host, port, err := net.SplitHostPort(entry)
+	if err != nil {
+		host, port = strings.Trim(entry, "[]"), "443"
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("invalid host: %s", entry)
+	}
+	return host, net.JoinHostPort(host, port), nil
+}
+
+// LoadHosts reads host entries from a file, one per line. Empty lines and
+// lines starting with # are skipped.
+func LoadHosts(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hosts file: %v", err)
+	}
+	defer file.Close()
+
+	hosts := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			hosts = append(hosts, line)
+		}
+	}
+	return hosts, scanner.Err()
+}
+
+// CheckHosts checks the hosts in parallel and returns the results in the
+// order of the hosts. Every problem is reported as a finding, so the
+// configured event hooks and SIEM output can alert on it.
+func (c *Checker) CheckHosts(ctx context.Context, hosts []string) []CertResult {
+	results := make([]CertResult, len(hosts))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.options.Concurrency)
+	for i, entry := range hosts {
+		wg.Add(1)
+		go func(i int, entry string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			timing.Wait(ctx) // A canceled scan ends in the check itself
+			results[i] = c.CheckHost(ctx, entry)
+			for _, problem := range results[i].Problems {
+				finding := siem.Finding{
+					Tool:        "certcheck",
+					Target:      results[i].Host,
+					Category:    "TLS_CERTIFICATE",
+					Name:        fmt.Sprintf("Certificate %s problem on %s", problem.Check, results[i].Address),
+					Severity:    model.Severity(problem.Severity),
+					Description: problem.Description,
+				}
+				if problem.Reference != "" {
+					finding.Category = "TLS_VULNERABILITY"
+					finding.Name = fmt.Sprintf("TLS %s vulnerability on %s", problem.Check, results[i].Address)
+					finding.Description = fmt.Sprintf("%s (%s)", problem.Description, problem.Reference)
+				}
+				siem.Emit(finding)
+			}
+		}(i, entry)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// CheckHost connects to a host and checks the certificate chain it presents,
+// then probes its TLS implementation for known flaws if enabled
+func (c *Checker) CheckHost(ctx context.Context, entry string) CertResult {
+	host, address, err := ParseHost(entry)
+	if err != nil {
+		return CertResult{Host: entry, Error: err.Error()}
+	}
+	result := CertResult{Host: host, Address: address}
+	eventbus.Publish(eventbus.Event{Type: eventbus.ScanStarted, Tool: "certcheck", Target: host})
+
+	chain, err := c.fetchChain(ctx, host, address)
+	if err != nil {
+		result.Error = err.Error()
+		eventbus.ScanFinished("certcheck", host, ctx.Err() != nil, err, nil)
+		return result
+	}
+
+	leaf := chain[0]
+	result.Subject = leaf.Subject.String()
+	result.Issuer = leaf.Issuer.String()
+	result.DNSNames = leaf.DNSNames
+	result.NotAfter = leaf.NotAfter
+	result.SignatureAlgorithm = leaf.SignatureAlgorithm.String()
+	result.ChainLength = len(chain)
+
+	result.Problems = append(result.Problems, c.checkExpiry(chain)...)
+	result.Problems = append(result.Problems, checkAlgorithms(chain)...)
+	result.Problems = append(result.Problems, c.checkChain(chain)...)
+	if err := leaf.VerifyHostname(host); err != nil {
+		result.Problems = append(result.Problems, Problem{
+			Check:       "hostname",
+			Severity:    SeverityHigh,
+			Description: fmt.Sprintf("Certificate is not valid for %s (valid for %s)", host, strings.Join(certNames(leaf), ", ")),
+		})
+	}
+	result.DaysLeft = int(leaf.NotAfter.Sub(c.now()).Hours() / 24)
+	if c.options.Probes {
+		result.Problems = append(result.Problems, c.probeVulnerabilities(ctx, host, address)...)
+	}
+
+	eventbus.ScanFinished("certcheck", host, false, nil, map[string]int{"problems": len(result.Problems), "days_left": result.DaysLeft})
+	return result
+}
+
+// fetchChain returns the certificates the server presents. Verification is
+// done by the checks, so that broken chains can still be inspected.
+func (c *Checker) fetchChain(ctx context.Context, host, address string) ([]*x509.Certificate, error) {
+	config := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+	}
+	if net.ParseIP(host) != nil {
+		config.ServerName = ""
+	}
+
+	timeout := time.Duration(c.options.Timeout) * time.Second
+	dialer := &net.Dialer{Timeout: timeout}
+	rawConn, err := stats.DialContext(egress.DialContext("certcheck", dialer.DialContext))(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("TLS connection to %s failed: %v", address, err)
+	}
+	conn := tls.Client(rawConn, config)
+	defer conn.Close()
+
+	handshakeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := conn.HandshakeContext(handshakeCtx); err != nil {
+		return nil, fmt.Errorf("TLS connection to %s failed: %v", address, err)
+	}
+
+	chain := conn.ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%s presented no certificate", address)
+	}
+	return chain, nil
+}
+
+// checkExpiry reports expired certificates in the chain and a leaf expiring
+// within WarnDays
+func (c *Checker) checkExpiry(chain []*x509.Certificate) []Problem {
+	now := c.now()
+	problems := []Problem{}
+
+	for i, cert := range chain {
+		if now.Before(cert.NotBefore) {
+			problems = append(problems, Problem{
+				Check:       "expiry",
+				Severity:    SeverityHigh,
+				Description: fmt.Sprintf("Certificate %s is not valid before %s", cert.Subject.CommonName, cert.NotBefore.Format("2006-01-02")),
+			})
+		}
+
+		days := int(cert.NotAfter.Sub(now).Hours() / 24)
+		switch {
+		case now.After(cert.NotAfter):
+			problems = append(problems, Problem{
+				Check:       "expiry",
+				Severity:    SeverityCritical,
+				Description: fmt.Sprintf("Certificate %s expired on %s", cert.Subject.CommonName, cert.NotAfter.Format("2006-01-02")),
+			})
+		case i > 0:
+			// Intermediates are renewed with the leaf, only expired ones matter
+		case days < c.options.CriticalDays:
+			problems = append(problems, Problem{
+				Check:       "expiry",
+				Severity:    SeverityHigh,
+				Description: fmt.Sprintf("Certificate expires in %d day(s), on %s", days, cert.NotAfter.Format("2006-01-02")),
+			})
+		case days < c.options.WarnDays:
+			problems = append(problems, Problem{
+				Check:       "expiry",
+				Severity:    SeverityMedium,
+				Description: fmt.Sprintf("Certificate expires in %d day(s), on %s", days, cert.NotAfter.Format("2006-01-02")),
+			})
+		}
+	}
+	return problems
+}
+
+// checkAlgorithms reports weak signature algorithms and short keys. The
+// signature of a self-signed root isn't checked by clients, so it's skipped.
+func checkAlgorithms(chain []*x509.Certificate) []Problem {
+	problems := []Problem{}
+	for _, cert := range chain {
+		if weakSignatures[cert.SignatureAlgorithm] && !selfSigned(cert) {
+			problems = append(problems, Problem{
+				Check:       "signature",
+				Severity:    SeverityHigh,
+				Description: fmt.Sprintf("Certificate %s is signed with the weak %s algorithm", cert.Subject.CommonName, cert.SignatureAlgorithm),
+			})
+		}
+
+		switch key := cert.PublicKey.(type) {
+		case *rsa.PublicKey:
+			if bits := key.N.BitLen(); bits < 2048 {
+				problems = append(problems, Problem{
+					Check:       "key",
+					Severity:    SeverityMedium,
+					Description: fmt.Sprintf("Certificate %s has a %d-bit RSA key, 2048 bits is the minimum", cert.Subject.CommonName, bits),
+				})
+			}
+		case *ecdsa.PublicKey:
+			if bits := key.Curve.Params().BitSize; bits < 256 {
+				problems = append(problems, Problem{
+					Check:       "key",
+					Severity:    SeverityMedium,
+					Description: fmt.Sprintf("Certificate %s has a %d-bit ECDSA key", cert.Subject.CommonName, bits),
+				})
+			}
+		}
+	}
+	return problems
+}
+
+// checkChain verifies the chain against the trusted roots and tells missing
+// intermediates apart from self-signed and otherwise untrusted certificates.
+// Expiry and weak algorithms are reported by their own checks, so the chain
+// is verified at a time all certificates are valid, and from the issuer of
+// the last certificate with a weak signature, which Go refuses to verify.
+func (c *Checker) checkChain(chain []*x509.Certificate) []Problem {
+	if len(chain) == 1 && selfSigned(chain[0]) {
+		return []Problem{{Check: "chain", Severity: SeverityHigh, Description: "Certificate is self-signed"}}
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if weakSignatures[chain[i].SignatureAlgorithm] && !selfSigned(chain[i]) {
+			if i == len(chain)-1 {
+				return nil
+			}
+			chain = chain[i+1:]
+			break
+		}
+	}
+
+	leaf := chain[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	verifyTime := c.now()
+	for _, cert := range chain {
+		if verifyTime.After(cert.NotAfter) {
+			verifyTime = cert.NotAfter.Add(-time.Second)
+		}
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         c.options.RootCAs,
+		Intermediates: intermediates,
+		CurrentTime:   verifyTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err == nil {
+		return nil
+	}
+
+	problem := Problem{Check: "chain", Severity: SeverityHigh}
+	last := chain[len(chain)-1]
+	var unknown x509.UnknownAuthorityError
+	switch {
+	case errors.As(err, &unknown) && !selfSigned(last):
+		problem.Description = fmt.Sprintf("Chain is incomplete: %s, the issuer of %s, is neither sent by the server nor a trusted root", last.Issuer.CommonName, last.Subject.CommonName)
+	default:
+		problem.Description = fmt.Sprintf("Certificate chain is not trusted: %v", err)
+	}
+	return []Problem{problem}
+}
+
+// selfSigned reports whether a certificate is signed by its own key
+func selfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// certNames returns the names a certificate is valid for
+func certNames(cert *x509.Certificate) []string {
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	if len(names) == 0 && cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	sort.Strings(names)
+	return names
+}
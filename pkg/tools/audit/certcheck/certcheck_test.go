@@ -0,0 +1,199 @@
+package certcheck
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testNow is the time the tests check certificates at
+var testNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// issuedCert is a certificate with its private key
+type issuedCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue creates a certificate from template, signed by parent or self-signed
+// if parent is nil
+func issue(t *testing.T, template *x509.Certificate, parent *issuedCert) issuedCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	if template.NotBefore.IsZero() {
+		template.NotBefore = testNow.AddDate(-1, 0, 0)
+	}
+	if template.NotAfter.IsZero() {
+		template.NotAfter = testNow.AddDate(1, 0, 0)
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	return issuedCert{cert: cert, key: key}
+}
+
+// serveChain starts a TLS server presenting chain and returns its address
+func serveChain(t *testing.T, chain ...issuedCert) string {
+	t.Helper()
+	certificate := tls.Certificate{PrivateKey: chain[0].key}
+	for _, issued := range chain {
+		certificate.Certificate = append(certificate.Certificate, issued.cert.Raw)
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{certificate}})
+	if err != nil {
+		t.Fatalf("tls.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestCheckHost(t *testing.T) {
+	root := issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Test Root"}, IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}, nil)
+	intermediate := issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Test Intermediate"}, IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}, &root)
+
+	leaf := func(template x509.Certificate) *x509.Certificate {
+		template.Subject = pkix.Name{CommonName: "server"}
+		if template.DNSNames == nil {
+			template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+		}
+		return &template
+	}
+
+	tests := []struct {
+		name  string
+		chain func() []issuedCert
+		want  []Problem
+	}{
+		{
+			name:  "Valid",
+			chain: func() []issuedCert { return []issuedCert{issue(t, leaf(x509.Certificate{}), &intermediate), intermediate} },
+		},
+		{
+			name: "Expiring soon",
+			chain: func() []issuedCert {
+				return []issuedCert{issue(t, leaf(x509.Certificate{NotAfter: testNow.AddDate(0, 0, 20)}), &intermediate), intermediate}
+			},
+			want: []Problem{{Check: "expiry", Severity: SeverityMedium, Description: "Certificate expires in 20 day(s), on 2025-06-21"}},
+		},
+		{
+			name: "Expired",
+			chain: func() []issuedCert {
+				return []issuedCert{issue(t, leaf(x509.Certificate{NotAfter: testNow.AddDate(0, 0, -3)}), &intermediate), intermediate}
+			},
+			want: []Problem{{Check: "expiry", Severity: SeverityCritical, Description: "Certificate server expired on 2025-05-29"}},
+		},
+		{
+			name: "Weak signature",
+			chain: func() []issuedCert {
+				return []issuedCert{issue(t, leaf(x509.Certificate{SignatureAlgorithm: x509.ECDSAWithSHA1}), &intermediate), intermediate}
+			},
+			want: []Problem{{Check: "signature", Severity: SeverityHigh, Description: "Certificate server is signed with the weak ECDSA-SHA1 algorithm"}},
+		},
+		{
+			name:  "Missing intermediate",
+			chain: func() []issuedCert { return []issuedCert{issue(t, leaf(x509.Certificate{}), &intermediate)} },
+			want: []Problem{{Check: "chain", Severity: SeverityHigh,
+				Description: "Chain is incomplete: Test Intermediate, the issuer of server, is neither sent by the server nor a trusted root"}},
+		},
+		{
+			name:  "Self-signed",
+			chain: func() []issuedCert { return []issuedCert{issue(t, leaf(x509.Certificate{}), nil)} },
+			want:  []Problem{{Check: "chain", Severity: SeverityHigh, Description: "Certificate is self-signed"}},
+		},
+		{
+			name: "Hostname mismatch",
+			chain: func() []issuedCert {
+				return []issuedCert{issue(t, leaf(x509.Certificate{DNSNames: []string{"www.example.com", "example.com"}}), &intermediate), intermediate}
+			},
+			want: []Problem{{Check: "hostname", Severity: SeverityHigh, Description: "Certificate is not valid for 127.0.0.1 (valid for example.com, www.example.com)"}},
+		},
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	options := DefaultCheckOptions()
+	options.RootCAs = roots
+	checker := NewChecker(options)
+	checker.now = func() time.Time { return testNow }
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := serveChain(t, tt.chain()...)
+			result := checker.CheckHost(context.Background(), address)
+			if result.Error != "" {
+				t.Fatalf("CheckHost() error = %s", result.Error)
+			}
+			if result.Address != address || result.Host != "127.0.0.1" {
+				t.Errorf("CheckHost() host = %s, address = %s, want 127.0.0.1 and %s", result.Host, result.Address, address)
+			}
+			if len(result.Problems) != len(tt.want) || (len(tt.want) > 0 && !reflect.DeepEqual(result.Problems, tt.want)) {
+				t.Errorf("CheckHost() problems = %+v, want %+v", result.Problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHost(t *testing.T) {
+	tests := []struct {
+		entry       string
+		wantHost    string
+		wantAddress string
+		wantErr     bool
+	}{
+		{"example.com", "example.com", "example.com:443", false},
+		{"example.com:8443", "example.com", "example.com:8443", false},
+		{"https://example.com/login", "example.com", "example.com:443", false},
+		{"https://example.com:9443/", "example.com", "example.com:9443", false},
+		{"[2001:db8::1]:443", "2001:db8::1", "[2001:db8::1]:443", false},
+		{"2001:db8::1", "2001:db8::1", "[2001:db8::1]:443", false},
+		{"https://", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			host, address, err := ParseHost(tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHost(%q) error = %v, wantErr %v", tt.entry, err, tt.wantErr)
+			}
+			if host != tt.wantHost || address != tt.wantAddress {
+				t.Errorf("ParseHost(%q) = %s, %s, want %s, %s", tt.entry, host, address, tt.wantHost, tt.wantAddress)
+			}
+		})
+	}
+}
@@ -0,0 +1,527 @@
+// pkg/tools/audit/certcheck/probes.go
+package certcheck
+
+import (
+	"GopherStrike/pkg/egress"
+	"GopherStrike/pkg/stats"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+)
+
+// The probes speak TLS 1.2 and earlier at the record level: crypto/tls
+// can't send the malformed and out-of-order messages they rely on. None of
+// them completes a handshake or sends application data.
+
+// TLS record content types
+const (
+	recordChangeCipherSpec = 20
+	recordAlert            = 21
+	recordHandshake        = 22
+	recordHeartbeat        = 24
+)
+
+// TLS handshake message types
+const (
+	handshakeClientHello       = 1
+	handshakeServerHello       = 2
+	handshakeCertificate       = 11
+	handshakeServerHelloDone   = 14
+	handshakeClientKeyExchange = 16
+)
+
+// TLS extensions read or sent by the probes
+const (
+	extensionServerName      = 0
+	extensionSupportedGroups = 10
+	extensionPointFormats    = 11
+	extensionSignatureAlgs   = 13
+	extensionHeartbeat       = 15
+	extensionRenegotiation   = 0xff01
+)
+
+// TLS alert descriptions the probes tell apart
+const (
+	alertBadRecordMAC     = 20
+	alertDecryptionFailed = 21
+)
+
+// probeWait bounds the wait for a reply a patched server never sends, such
+// as the answer to an invalid heartbeat
+const probeWait = 3 * time.Second
+
+// probeCiphers are offered by the probes that need any handshake: ECDHE and
+// RSA key exchanges, with the renegotiation SCSV
+var probeCiphers = []uint16{
+	0xc02f, 0xc030, 0xc02b, 0xc02c, 0xc013, 0xc014, 0xc009, 0xc00a,
+	0x009c, 0x009d, 0x002f, 0x0035, 0x000a, 0x009e, 0x009f, 0x0033, 0x0039,
+	0x00ff,
+}
+
+// rsaCiphers use RSA key exchange, the one ROBOT targets
+var rsaCiphers = []uint16{0x009c, 0x009d, 0x003c, 0x003d, 0x002f, 0x0035, 0x000a}
+
+// alertError is a fatal alert sent by the server
+type alertError byte
+
+func (e alertError) Error() string {
+	return fmt.Sprintf("TLS alert %d", byte(e))
+}
+
+// serverHello is what the server sent up to ServerHelloDone
+type serverHello struct {
+	version     uint16
+	extensions  map[uint16][]byte
+	certificate []byte // DER of the leaf certificate
+}
+
+// recordConn reads and writes TLS records on a connection
+type recordConn struct {
+	conn    net.Conn
+	version uint16 // Record version, the negotiated one after ServerHello
+}
+
+// dialProbe connects to address for a probe
+func (c *Checker) dialProbe(ctx context.Context, address string) (*recordConn, error) {
+	timeout := time.Duration(c.options.Timeout) * time.Second
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := stats.DialContext(egress.DialContext("certcheck", dialer.DialContext))(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	return &recordConn{conn: conn, version: 0x0301}, nil
+}
+
+// writeRecord sends one record
+func (r *recordConn) writeRecord(contentType byte, payload []byte) error {
+	header := []byte{contentType, byte(r.version >> 8), byte(r.version), byte(len(payload) >> 8), byte(len(payload))}
+	_, err := r.conn.Write(append(header, payload...))
+	return err
+}
+
+// readRecord reads one record. A fatal alert is returned as alertError.
+func (r *recordConn) readRecord() (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r.conn, header); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(header[3:]))
+	if _, err := io.ReadFull(r.conn, payload); err != nil {
+		return 0, nil, err
+	}
+	if header[0] == recordAlert && len(payload) == 2 && payload[0] == 2 {
+		return header[0], payload, alertError(payload[1])
+	}
+	return header[0], payload, nil
+}
+
+// waitAlert waits up to probeWait for the server's reply to a probe and
+// returns the alert it sent, io.EOF if it closed the connection, or a
+// timeout error if it stayed silent
+func (r *recordConn) waitAlert() (byte, error) {
+	r.conn.SetReadDeadline(time.Now().Add(probeWait))
+	for {
+		_, _, err := r.readRecord()
+		var alert alertError
+		if errors.As(err, &alert) {
+			return byte(alert), nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// clientHello builds a TLS 1.2 ClientHello for serverName with the ciphers,
+// announcing heartbeat support if asked
+func clientHello(serverName string, ciphers []uint16, heartbeat bool) []byte {
+	body := []byte{0x03, 0x03}
+	random := make([]byte, 32)
+	rand.Read(random)
+	body = append(body, random...)
+	body = append(body, 0) // No session ID
+
+	body = binary.BigEndian.AppendUint16(body, uint16(2*len(ciphers)))
+	for _, cipher := range ciphers {
+		body = binary.BigEndian.AppendUint16(body, cipher)
+	}
+	body = append(body, 1, 0) // Null compression
+
+	extensions := []byte{}
+	extension := func(id uint16, data []byte) {
+		extensions = binary.BigEndian.AppendUint16(extensions, id)
+		extensions = binary.BigEndian.AppendUint16(extensions, uint16(len(data)))
+		extensions = append(extensions, data...)
+	}
+	if serverName != "" && net.ParseIP(serverName) == nil {
+		name := []byte{0}
+		name = binary.BigEndian.AppendUint16(name, uint16(len(serverName)))
+		name = append(name, serverName...)
+		extension(extensionServerName, append(binary.BigEndian.AppendUint16(nil, uint16(len(name))), name...))
+	}
+	extension(extensionSupportedGroups, []byte{0, 6, 0, 29, 0, 23, 0, 24})
+	extension(extensionPointFormats, []byte{1, 0})
+	extension(extensionSignatureAlgs, []byte{0, 16, 4, 1, 5, 1, 6, 1, 4, 3, 5, 3, 6, 3, 8, 4, 2, 1})
+	if heartbeat {
+		extension(extensionHeartbeat, []byte{1}) // The server may send requests
+	}
+	body = binary.BigEndian.AppendUint16(body, uint16(len(extensions)))
+	body = append(body, extensions...)
+
+	return handshakeMessage(handshakeClientHello, body)
+}
+
+// handshakeMessage frames a handshake message body
+func handshakeMessage(messageType byte, body []byte) []byte {
+	return append([]byte{messageType, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}, body...)
+}
+
+// hello sends a ClientHello and reads the server's messages up to
+// ServerHelloDone. Records then use the version the server chose.
+func (r *recordConn) hello(serverName string, ciphers []uint16, heartbeat bool) (*serverHello, error) {
+	if err := r.writeRecord(recordHandshake, clientHello(serverName, ciphers, heartbeat)); err != nil {
+		return nil, err
+	}
+
+	hello := &serverHello{extensions: map[uint16][]byte{}}
+	pending := []byte{}
+	for {
+		contentType, payload, err := r.readRecord()
+		if err != nil {
+			return nil, err
+		}
+		if contentType != recordHandshake {
+			return nil, fmt.Errorf("unexpected record type %d", contentType)
+		}
+		pending = append(pending, payload...)
+
+		for len(pending) >= 4 {
+			length := int(pending[1])<<16 | int(pending[2])<<8 | int(pending[3])
+			if len(pending) < 4+length {
+				break
+			}
+			messageType, body := pending[0], pending[4:4+length]
+			pending = pending[4+length:]
+
+			switch messageType {
+			case handshakeServerHello:
+				if err := hello.parseServerHello(body); err != nil {
+					return nil, err
+				}
+				r.version = hello.version
+			case handshakeCertificate:
+				if len(body) >= 6 {
+					certLength := int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+					if len(body) >= 6+certLength {
+						hello.certificate = body[6 : 6+certLength]
+					}
+				}
+			case handshakeServerHelloDone:
+				if hello.version == 0 {
+					return nil, fmt.Errorf("ServerHelloDone without ServerHello")
+				}
+				return hello, nil
+			}
+		}
+	}
+}
+
+// parseServerHello reads the version and extensions of a ServerHello
+func (h *serverHello) parseServerHello(body []byte) error {
+	if len(body) < 38 {
+		return fmt.Errorf("short ServerHello")
+	}
+	h.version = binary.BigEndian.Uint16(body)
+	rest := body[34:]
+	sessionLength := int(rest[0])
+	if len(rest) < 1+sessionLength+3 {
+		return fmt.Errorf("short ServerHello")
+	}
+	rest = rest[1+sessionLength+3:] // Session ID, cipher and compression
+	if len(rest) < 2 {
+		return nil // No extensions
+	}
+	rest = rest[2:]
+	for len(rest) >= 4 {
+		id, length := binary.BigEndian.Uint16(rest), int(binary.BigEndian.Uint16(rest[2:]))
+		if len(rest) < 4+length {
+			return fmt.Errorf("malformed ServerHello extensions")
+		}
+		h.extensions[id] = rest[4 : 4+length]
+		rest = rest[4+length:]
+	}
+	return nil
+}
+
+// probeVulnerabilities runs the TLS vulnerability probes against a server.
+// A server refusing a probe's handshake, such as one without TLS 1.2 and
+// earlier or RSA key exchange, isn't exposed to that flaw.
+func (c *Checker) probeVulnerabilities(ctx context.Context, host, address string) []Problem {
+	problems := []Problem{}
+	for _, probe := range []func(context.Context, string, string) (Problem, bool){
+		c.probeHeartbleed,
+		c.probeCCSInjection,
+		c.probeRenegotiation,
+		c.probeROBOT,
+	} {
+		if ctx.Err() != nil {
+			break
+		}
+		if problem, ok := probe(ctx, host, address); ok {
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+// probeHeartbleed sends a heartbeat claiming 16 bytes more than it carries.
+// Patched servers discard it (RFC 6520); vulnerable ones answer with the
+// extra bytes read from their memory, which are dropped unread.
+func (c *Checker) probeHeartbleed(ctx context.Context, host, address string) (Problem, bool) {
+	conn, err := c.dialProbe(ctx, address)
+	if err != nil {
+		return Problem{}, false
+	}
+	defer conn.conn.Close()
+
+	hello, err := conn.hello(host, probeCiphers, true)
+	if err != nil {
+		return Problem{}, false
+	}
+	if _, ok := hello.extensions[extensionHeartbeat]; !ok {
+		return Problem{}, false
+	}
+
+	padding := make([]byte, 16)
+	rand.Read(padding)
+	request := append([]byte{1, 0, byte(len(padding) + 16)}, padding...)
+	if err := conn.writeRecord(recordHeartbeat, request); err != nil {
+		return Problem{}, false
+	}
+
+	conn.conn.SetReadDeadline(time.Now().Add(probeWait))
+	for {
+		contentType, payload, err := conn.readRecord()
+		if err != nil {
+			return Problem{}, false
+		}
+		if contentType == recordHeartbeat && len(payload) > len(request) {
+			return Problem{
+				Check:       "heartbleed",
+				Severity:    SeverityCritical,
+				Description: "Heartbleed: the server answers heartbeats claiming more data than they carry with its own memory, leaking private keys, credentials and session data",
+				Reference:   "CVE-2014-0160",
+			}, true
+		}
+	}
+}
+
+// probeCCSInjection sends a ChangeCipherSpec before any key exchange,
+// followed by a record. Patched servers reject the early ChangeCipherSpec;
+// vulnerable ones accept it and fail to decrypt the record with the empty
+// master secret they derived.
+func (c *Checker) probeCCSInjection(ctx context.Context, host, address string) (Problem, bool) {
+	conn, err := c.dialProbe(ctx, address)
+	if err != nil {
+		return Problem{}, false
+	}
+	defer conn.conn.Close()
+
+	if _, err := conn.hello(host, probeCiphers, false); err != nil {
+		return Problem{}, false
+	}
+	if err := conn.writeRecord(recordChangeCipherSpec, []byte{1}); err != nil {
+		return Problem{}, false
+	}
+	conn.writeRecord(recordAlert, []byte{1, 0}) // Would be encrypted after the ChangeCipherSpec
+
+	alert, err := conn.waitAlert()
+	if err != nil || (alert != alertBadRecordMAC && alert != alertDecryptionFailed) {
+		return Problem{}, false
+	}
+	return Problem{
+		Check:       "ccs",
+		Severity:    SeverityHigh,
+		Description: "CCS injection: the server accepts a ChangeCipherSpec before the key exchange, letting a man in the middle force known session keys",
+		Reference:   "CVE-2014-0224",
+	}, true
+}
+
+// probeRenegotiation reports servers that ignore the renegotiation SCSV of
+// the ClientHello, which don't support secure renegotiation
+func (c *Checker) probeRenegotiation(ctx context.Context, host, address string) (Problem, bool) {
+	conn, err := c.dialProbe(ctx, address)
+	if err != nil {
+		return Problem{}, false
+	}
+	defer conn.conn.Close()
+
+	hello, err := conn.hello(host, probeCiphers, false)
+	if err != nil {
+		return Problem{}, false
+	}
+	if _, ok := hello.extensions[extensionRenegotiation]; ok {
+		return Problem{}, false
+	}
+	return Problem{
+		Check:       "renegotiation",
+		Severity:    SeverityMedium,
+		Description: "Secure renegotiation is not supported: if the server renegotiates, a man in the middle can prefix the client's requests with its own",
+		Reference:   "CVE-2009-3555, RFC 5746",
+	}, true
+}
+
+// robotVariants are the PKCS#1 v1.5 premaster secret encodings of the ROBOT
+// test: a valid one, then wrong leading bytes, a misplaced separator, no
+// separator and a wrong premaster version
+var robotVariants = []string{"valid", "wrong leading bytes", "misplaced separator", "no separator", "wrong version"}
+
+// robotMessage encodes the premaster secret variant for a key of size bytes
+func robotMessage(variant string, size int) []byte {
+	message := make([]byte, size)
+	message[0], message[1] = 0x00, 0x02
+	padding := message[2 : size-49]
+	rand.Read(padding)
+	for i := range padding {
+		if padding[i] == 0 {
+			padding[i] = 0x55
+		}
+	}
+	message[size-49] = 0x00
+	message[size-48], message[size-47] = 0x03, 0x03
+	rand.Read(message[size-46:])
+
+	switch variant {
+	case "wrong leading bytes":
+		message[0], message[1] = 0x41, 0x17
+	case "misplaced separator":
+		message[size-49] = 0x11
+		message[size-2], message[size-1] = 0x00, 0x11
+	case "no separator":
+		for i := 2; i < size; i++ {
+			if message[i] == 0 {
+				message[i] = 0x11
+			}
+		}
+	case "wrong version":
+		message[size-48], message[size-47] = 0x02, 0x02
+	}
+	return message
+}
+
+// probeROBOT sends RSA key exchanges whose premaster secrets are encoded
+// correctly and in four malformed ways, each followed by ChangeCipherSpec
+// and a Finished the server can't verify. A server answering a malformed
+// encoding differently from the valid one is a Bleichenbacher padding
+// oracle. Differences are confirmed by a second round.
+func (c *Checker) probeROBOT(ctx context.Context, host, address string) (Problem, bool) {
+	round := func() ([]string, bool) {
+		answers := make([]string, len(robotVariants))
+		for i, variant := range robotVariants {
+			answer, ok := c.robotAnswer(ctx, host, address, variant)
+			if !ok {
+				return nil, false
+			}
+			answers[i] = answer
+		}
+		return answers, true
+	}
+
+	first, ok := round()
+	if !ok || !differ(first) {
+		return Problem{}, false
+	}
+	second, ok := round()
+	if !ok {
+		return Problem{}, false
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			return Problem{}, false // Noise, such as a flaky network
+		}
+	}
+
+	oracles := []string{}
+	for i, answer := range first[1:] {
+		if answer != first[0] {
+			oracles = append(oracles, robotVariants[i+1])
+		}
+	}
+	return Problem{
+		Check:       "robot",
+		Severity:    SeverityHigh,
+		Description: fmt.Sprintf("ROBOT: the server answers malformed RSA key exchanges (%s) differently from valid ones, an oracle for decrypting recorded sessions and signing with its key", strings.Join(oracles, ", ")),
+		Reference:   "https://robotattack.org/, CVE-2017-13099",
+	}, true
+}
+
+// robotAnswer sends one RSA key exchange variant and returns the server's
+// answer: the alert, a closed connection or silence. It fails when the
+// server has no RSA key exchange.
+func (c *Checker) robotAnswer(ctx context.Context, host, address, variant string) (string, bool) {
+	conn, err := c.dialProbe(ctx, address)
+	if err != nil {
+		return "", false
+	}
+	defer conn.conn.Close()
+
+	hello, err := conn.hello(host, rsaCiphers, false)
+	if err != nil || hello.certificate == nil {
+		return "", false
+	}
+	cert, err := x509.ParseCertificate(hello.certificate)
+	if err != nil {
+		return "", false
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return "", false
+	}
+
+	size := (key.N.BitLen() + 7) / 8
+	m := new(big.Int).SetBytes(robotMessage(variant, size))
+	encrypted := new(big.Int).Exp(m, big.NewInt(int64(key.E)), key.N).FillBytes(make([]byte, size))
+	exchange := binary.BigEndian.AppendUint16(nil, uint16(size))
+	exchange = append(exchange, encrypted...)
+
+	finished := make([]byte, 40)
+	rand.Read(finished)
+	if conn.writeRecord(recordHandshake, handshakeMessage(handshakeClientKeyExchange, exchange)) != nil ||
+		conn.writeRecord(recordChangeCipherSpec, []byte{1}) != nil ||
+		conn.writeRecord(recordHandshake, finished) != nil {
+		return "", false
+	}
+
+	alert, err := conn.waitAlert()
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return fmt.Sprintf("alert %d", alert), true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout", true
+	case errors.Is(err, io.EOF):
+		return "closed", true
+	default:
+		return "reset", true
+	}
+}
+
+// differ reports whether the answers are not all the same
+func differ(answers []string) bool {
+	for _, answer := range answers[1:] {
+		if answer != answers[0] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package certcheck
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeTLSServer answers the probes at the record level, with the flaws
+// enabled
+type fakeTLSServer struct {
+	heartbleed    bool
+	ccs           bool
+	renegotiation bool // No secure renegotiation
+	robot         bool
+	key           *rsa.PrivateKey
+	cert          []byte
+}
+
+// serve starts the fake server and returns its address
+func (f *fakeTLSServer) serve(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "server"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	f.key = key
+	if f.cert, err = x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key); err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.handle(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// handle answers one probe connection
+func (f *fakeTLSServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := &recordConn{conn: conn, version: 0x0303}
+	if _, _, err := r.readRecord(); err != nil {
+		return
+	}
+
+	extensions := []byte{}
+	if f.heartbleed {
+		extensions = append(extensions, 0, extensionHeartbeat, 0, 1, 1)
+	}
+	if !f.renegotiation {
+		extensions = append(extensions, 0xff, 0x01, 0, 1, 0)
+	}
+	hello := append([]byte{3, 3}, make([]byte, 32)...)
+	hello = append(hello, 0, 0x00, 0x2f, 0)
+	hello = binary.BigEndian.AppendUint16(hello, uint16(len(extensions)))
+	hello = append(hello, extensions...)
+
+	certificates := []byte{0, byte(len(f.cert) >> 8), byte(len(f.cert))}
+	certificates = append(certificates, f.cert...)
+	certificates = append([]byte{0, byte(len(certificates) >> 8), byte(len(certificates))}, certificates...)
+
+	flight := handshakeMessage(handshakeServerHello, hello)
+	flight = append(flight, handshakeMessage(handshakeCertificate, certificates)...)
+	flight = append(flight, handshakeMessage(handshakeServerHelloDone, nil)...)
+	r.writeRecord(recordHandshake, flight)
+
+	alert := func(description byte) { r.writeRecord(recordAlert, []byte{2, description}) }
+	ccsSeen := false
+	for {
+		contentType, payload, err := r.readRecord()
+		if err != nil {
+			return
+		}
+		switch {
+		case contentType == recordHeartbeat && f.heartbleed:
+			claimed := int(binary.BigEndian.Uint16(payload[1:]))
+			r.writeRecord(recordHeartbeat, make([]byte, 3+claimed+16))
+		case contentType == recordChangeCipherSpec && !f.ccs:
+			alert(10)
+			return
+		case contentType == recordChangeCipherSpec:
+			ccsSeen = true
+		case contentType == recordAlert && ccsSeen:
+			alert(alertBadRecordMAC)
+			return
+		case contentType == recordHandshake && payload[0] == handshakeClientKeyExchange:
+			size := f.key.Size()
+			c := new(big.Int).SetBytes(payload[6 : 6+size])
+			m := new(big.Int).Exp(c, f.key.D, f.key.N).FillBytes(make([]byte, size))
+			valid := m[0] == 0 && m[1] == 2 && m[size-49] == 0 && m[size-48] == 3 && m[size-47] == 3
+			if f.robot && !valid {
+				alert(51) // decrypt_error
+			} else {
+				alert(alertBadRecordMAC)
+			}
+			return
+		}
+	}
+}
+
+func TestProbeVulnerabilities(t *testing.T) {
+	checker := NewChecker(CheckOptions{Timeout: 5, Concurrency: 1})
+	probe := func(server *fakeTLSServer) []Problem {
+		return checker.probeVulnerabilities(context.Background(), "127.0.0.1", server.serve(t))
+	}
+
+	problems := probe(&fakeTLSServer{heartbleed: true, ccs: true, renegotiation: true, robot: true})
+	checks := []string{}
+	for _, problem := range problems {
+		checks = append(checks, problem.Check)
+		if problem.Reference == "" {
+			t.Errorf("%s problem has no reference", problem.Check)
+		}
+	}
+	if want := []string{"heartbleed", "ccs", "renegotiation", "robot"}; !reflect.DeepEqual(checks, want) {
+		t.Errorf("vulnerable server problems = %v, want %v", checks, want)
+	}
+	if len(problems) == 4 && problems[3].Description != "ROBOT: the server answers malformed RSA key exchanges "+
+		"(wrong leading bytes, misplaced separator, no separator, wrong version) differently from valid ones, "+
+		"an oracle for decrypting recorded sessions and signing with its key" {
+		t.Errorf("ROBOT description = %q", problems[3].Description)
+	}
+
+	// A patched server without heartbeats rejects the early ChangeCipherSpec
+	// and answers every RSA key exchange alike
+	if problems := probe(&fakeTLSServer{}); len(problems) != 0 {
+		t.Errorf("patched server problems = %+v, want none", problems)
+	}
+}
@@ -0,0 +1,223 @@
+// pkg/tools/audit/depscan/depscan.go
+package depscan
+
+import (
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/siem"
+	"GopherStrike/pkg/timing"
+	"GopherStrike/pkg/tools/osint"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ComponentResult is a component and the known vulnerabilities of its version
+type ComponentResult struct {
+	Component
+	Vulnerabilities []osint.Vulnerability `json:"vulnerabilities,omitempty"`
+	Error           string                `json:"error,omitempty"`
+}
+
+// Severity returns the highest severity of the vulnerabilities of the
+// component, SeverityNone if it has none
+func (r ComponentResult) Severity() model.Severity {
+	severity := model.SeverityNone
+	for _, vuln := range r.Vulnerabilities {
+		if s := VulnerabilitySeverity(vuln); s.Rank() > severity.Rank() {
+			severity = s
+		}
+	}
+	return severity
+}
+
+// ScanResult is the software composition of a target: the components
+// declared in its manifests and their vulnerabilities
+type ScanResult struct {
+	Target     string            `json:"target"`
+	Manifests  []string          `json:"manifests"`
+	Components []ComponentResult `json:"components"`
+	Unchecked  int               `json:"unchecked"` // Components without a pinned version or a known ecosystem
+	Errors     []string          `json:"errors,omitempty"`
+}
+
+// Vulnerable returns the components with known vulnerabilities, most
+// serious first
+func (r *ScanResult) Vulnerable() []ComponentResult {
+	vulnerable := make([]ComponentResult, 0)
+	for _, c := range r.Components {
+		if len(c.Vulnerabilities) > 0 {
+			vulnerable = append(vulnerable, c)
+		}
+	}
+	sort.SliceStable(vulnerable, func(i, j int) bool {
+		return vulnerable[i].Severity().Rank() > vulnerable[j].Severity().Rank()
+	})
+	return vulnerable
+}
+
+// ScanOptions contains options for the dependency scanner
+type ScanOptions struct {
+	Concurrency int  // Components looked up in parallel
+	IncludeDev  bool // Look up development dependencies too
+}
+
+// DefaultScanOptions returns the default scanner options
+func DefaultScanOptions() ScanOptions {
+	return ScanOptions{
+		Concurrency: timing.Current().Threads(10),
+	}
+}
+
+// Scanner looks up the components of manifests in vulnerability databases
+type Scanner struct {
+	options ScanOptions
+	osv     osint.VulnDBConnector
+	nvd     osint.VulnDBConnector
+}
+
+// NewScanner creates a dependency scanner matching components against osv,
+// which knows them by package. If nvd is not nil, the CVEs found are looked
+// up there too for their CVSS scores and references.
+func NewScanner(options ScanOptions, osv, nvd osint.VulnDBConnector) *Scanner {
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+	return &Scanner{options: options, osv: osv, nvd: nvd}
+}
+
+// Scan parses the manifests of the paths, repository directories or
+// manifest files, and looks up each component version once. Every
+// vulnerability of a component is reported as a finding about target.
+func (s *Scanner) Scan(ctx context.Context, target string, paths []string) *ScanResult {
+	result := &ScanResult{Target: target, Manifests: []string{}, Components: []ComponentResult{}}
+	eventbus.Publish(eventbus.Event{Type: eventbus.ScanStarted, Tool: "depscan", Target: target})
+
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		manifests, err := FindManifests(path)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		for _, manifest := range manifests {
+			components, err := ParseManifest(manifest)
+			if err != nil {
+				result.Errors = append(result.Errors, err.Error())
+				continue
+			}
+			result.Manifests = append(result.Manifests, manifest)
+			for _, c := range components {
+				if (c.Dev && !s.options.IncludeDev) || seen[c.Key()] {
+					continue
+				}
+				seen[c.Key()] = true
+				if c.Version == "" || c.Ecosystem == "" {
+					result.Unchecked++
+				}
+				result.Components = append(result.Components, ComponentResult{Component: c})
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.options.Concurrency)
+	for i := range result.Components {
+		c := &result.Components[i]
+		if c.Version == "" || c.Ecosystem == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := timing.Wait(ctx); err != nil {
+				c.Error = err.Error()
+				return
+			}
+			c.Vulnerabilities, c.Error = s.lookup(c.Component)
+			for _, vuln := range c.Vulnerabilities {
+				siem.Emit(Finding(target, c.Component, vuln))
+			}
+		}()
+	}
+	wg.Wait()
+
+	vulnerable := len(result.Vulnerable())
+	var err error
+	if len(result.Manifests) == 0 {
+		err = fmt.Errorf("no supported manifest found")
+	}
+	eventbus.ScanFinished("depscan", target, ctx.Err() != nil, err, map[string]int{
+		"components": len(result.Components),
+		"vulnerable": vulnerable,
+	})
+	return result
+}
+
+// lookup returns the vulnerabilities of a component version, merged with
+// the NVD records of their CVEs if the scanner has an NVD connector
+func (s *Scanner) lookup(c Component) ([]osint.Vulnerability, string) {
+	version := c.Version
+	if c.Ecosystem == EcosystemGo {
+		// OSV knows Go module versions without their v prefix
+		version = strings.TrimPrefix(version, "v")
+	}
+	vulns, err := s.osv.Search(osint.SearchQuery{
+		Products: []string{c.Ecosystem + ":" + c.Name},
+		Versions: []string{version},
+	})
+	if err != nil {
+		return nil, err.Error()
+	}
+	if s.nvd == nil {
+		return vulns, ""
+	}
+
+	records := make([]osint.Vulnerability, 0)
+	for _, vuln := range vulns {
+		if !strings.HasPrefix(vuln.ID, "CVE-") {
+			continue
+		}
+		// A missing NVD record leaves the OSV one as it is
+		if record, err := s.nvd.GetByID(vuln.ID); err == nil {
+			records = append(records, *record)
+		}
+	}
+	return osint.MergeVulnerabilities(vulns, records), ""
+}
+
+// VulnerabilitySeverity returns the severity of a vulnerability. Some
+// databases, such as the Go vulnerability database, rate none: they count
+// as medium rather than hiding a known vulnerability among informational ones.
+func VulnerabilitySeverity(vuln osint.Vulnerability) model.Severity {
+	if vuln.Severity.Rank() <= 0 {
+		return model.SeverityMedium
+	}
+	return vuln.Severity.Canonical()
+}
+
+// Finding converts a vulnerability of a component to a finding about target
+func Finding(target string, c Component, vuln osint.Vulnerability) model.Finding {
+	description := vuln.Title
+	if len(vuln.Mitigations) > 0 {
+		description = strings.TrimSpace(description + ". " + strings.Join(vuln.Mitigations, "; "))
+	}
+	finding := model.Finding{
+		Tool:        "depscan",
+		Target:      target,
+		Category:    "VULNERABLE_DEPENDENCY",
+		Name:        fmt.Sprintf("%s in %s %s@%s", vuln.ID, c.Ecosystem, c.Name, c.Version),
+		Severity:    VulnerabilitySeverity(vuln),
+		Description: description,
+		Evidence:    "Declared in " + c.Manifest,
+	}
+	if len(vuln.References) > 0 {
+		finding.URL = vuln.References[0]
+	}
+	return finding
+}
@@ -0,0 +1,255 @@
+package depscan
+
+import (
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/tools/osint"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeFile writes a file under dir, creating its directories
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// keys returns the keys of the components
+func keys(components []Component) []string {
+	result := make([]string, len(components))
+	for i, c := range components {
+		result[i] = c.Key()
+	}
+	return result
+}
+
+func TestParseGoMod(t *testing.T) {
+	components, err := parseGoMod([]byte(`module example.com/app
+
+go 1.21
+
+require github.com/gin-gonic/gin v1.9.0
+
+require (
+	golang.org/x/net v0.7.0 // indirect
+	github.com/old/lib v1.0.0
+	example.com/local v0.0.0
+)
+
+replace github.com/old/lib => github.com/new/lib v1.2.0
+
+replace example.com/local => ../local
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Go:github.com/gin-gonic/gin@v1.9.0",
+		"Go:golang.org/x/net@v0.7.0",
+		"Go:github.com/new/lib@v1.2.0",
+		"Go:example.com/local@v0.0.0",
+	}
+	if got := keys(components); !reflect.DeepEqual(got, want) {
+		t.Fatalf("components = %v, want %v", got, want)
+	}
+	if !components[1].Indirect || components[0].Indirect {
+		t.Errorf("indirect flags = %v, %v, want false, true", components[0].Indirect, components[1].Indirect)
+	}
+}
+
+func TestParsePackageLock(t *testing.T) {
+	v3 := `{"lockfileVersion": 3, "packages": {
+		"": {"name": "app"},
+		"node_modules/lodash": {"version": "4.17.20"},
+		"node_modules/@babel/core": {"version": "7.0.0", "dev": true},
+		"node_modules/express/node_modules/qs": {"version": "6.5.2"},
+		"node_modules/shared": {"link": true}
+	}}`
+	components, err := parsePackageLock([]byte(v3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"npm:@babel/core@7.0.0", "npm:lodash@4.17.20", "npm:qs@6.5.2"}
+	if got := keys(components); !reflect.DeepEqual(got, want) {
+		t.Fatalf("v3 components = %v, want %v", got, want)
+	}
+	if !components[0].Dev || !components[2].Indirect || components[0].PURL != "pkg:npm/%40babel/core@7.0.0" {
+		t.Errorf("v3 components = %+v", components)
+	}
+
+	v1 := `{"lockfileVersion": 1, "dependencies": {
+		"express": {"version": "4.16.0", "dependencies": {"qs": {"version": "6.5.1"}}}
+	}}`
+	components, err = parsePackageLock([]byte(v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"npm:express@4.16.0", "npm:qs@6.5.1"}
+	if got := keys(components); !reflect.DeepEqual(got, want) {
+		t.Errorf("v1 components = %v, want %v", got, want)
+	}
+}
+
+func TestParseRequirements(t *testing.T) {
+	components, err := parseRequirements([]byte(`# production
+-r base.txt
+Django[argon2]==4.2.1
+requests >= 2.0
+Jinja2==2.10 ; python_version >= "3"
+Flask_Login==0.4.*  # wildcard
+git+https://github.com/org/repo.git#egg=repo
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"PyPI:django@4.2.1", "PyPI:requests@", "PyPI:jinja2@2.10", "PyPI:flask-login@"}
+	if got := keys(components); !reflect.DeepEqual(got, want) {
+		t.Errorf("components = %v, want %v", got, want)
+	}
+}
+
+func TestParseCycloneDX(t *testing.T) {
+	components, err := parseCycloneDX([]byte(`{"bomFormat": "CycloneDX", "specVersion": "1.5", "components": [
+		{"type": "library", "group": "org.apache.logging.log4j", "name": "log4j-core", "version": "2.14.1",
+		 "purl": "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1?type=jar",
+		 "components": [{"type": "library", "name": "Jinja2", "version": "2.10", "purl": "pkg:pypi/Jinja2@2.10"}]},
+		{"type": "library", "name": "internal-tool", "version": "1.0"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Maven:org.apache.logging.log4j:log4j-core@2.14.1",
+		"PyPI:jinja2@2.10",
+		":internal-tool@1.0",
+	}
+	if got := keys(components); !reflect.DeepEqual(got, want) {
+		t.Errorf("components = %v, want %v", got, want)
+	}
+
+	if _, err := parseCycloneDX([]byte(`{"spdxVersion": "SPDX-2.3"}`)); err == nil {
+		t.Error("SPDX document accepted as CycloneDX")
+	}
+}
+
+func TestParsePURL(t *testing.T) {
+	tests := []struct {
+		purl                     string
+		ecosystem, name, version string
+		ok                       bool
+	}{
+		{"pkg:golang/github.com/gin-gonic/gin@v1.9.0", EcosystemGo, "github.com/gin-gonic/gin", "v1.9.0", true},
+		{"pkg:npm/%40angular/core@16.0.0", EcosystemNPM, "@angular/core", "16.0.0", true},
+		{"pkg:gem/rails@7.0.4#lib", EcosystemRubyGems, "rails", "7.0.4", true},
+		{"pkg:cargo/serde", EcosystemCrates, "serde", "", true},
+		{"pkg:deb/debian/openssl@1.1.1n", "", "", "", false},
+		{"github.com/gin-gonic/gin", "", "", "", false},
+	}
+	for _, tt := range tests {
+		ecosystem, name, version, ok := ParsePURL(tt.purl)
+		if ecosystem != tt.ecosystem || name != tt.name || version != tt.version || ok != tt.ok {
+			t.Errorf("ParsePURL(%q) = %q, %q, %q, %v, want %q, %q, %q, %v", tt.purl,
+				ecosystem, name, version, ok, tt.ecosystem, tt.name, tt.version, tt.ok)
+		}
+	}
+}
+
+func TestFindManifests(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "go.mod", "module example.com/app\n")
+	writeFile(t, dir, "web/package-lock.json", "{}")
+	writeFile(t, dir, "web/node_modules/lib/package-lock.json", "{}")
+	writeFile(t, dir, "api/requirements-dev.txt", "")
+	writeFile(t, dir, "api/README.md", "")
+
+	manifests, err := FindManifests(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "api/requirements-dev.txt"),
+		filepath.Join(dir, "go.mod"),
+		filepath.Join(dir, "web/package-lock.json"),
+	}
+	if !reflect.DeepEqual(manifests, want) {
+		t.Errorf("manifests = %v, want %v", manifests, want)
+	}
+}
+
+// stubDB is a vulnerability database knowing the vulnerabilities of
+// "ecosystem:name@version" entries and records by ID
+type stubDB struct {
+	vulns   map[string][]osint.Vulnerability
+	records map[string]osint.Vulnerability
+}
+
+func (s stubDB) Search(query osint.SearchQuery) ([]osint.Vulnerability, error) {
+	return s.vulns[query.Products[0]+"@"+query.Versions[0]], nil
+}
+
+func (s stubDB) GetByID(id string) (*osint.Vulnerability, error) {
+	record, ok := s.records[id]
+	if !ok {
+		return nil, fmt.Errorf("vulnerability not found: %s", id)
+	}
+	return &record, nil
+}
+
+func (s stubDB) GetUpdates(since time.Time) ([]osint.Vulnerability, error) { return nil, nil }
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "go.mod", "module example.com/app\n\nrequire golang.org/x/net v0.7.0\n")
+	writeFile(t, dir, "requirements.txt", "jinja2==2.10\nrequests\nflask==2.3.2\n")
+
+	osv := stubDB{vulns: map[string][]osint.Vulnerability{
+		"Go:golang.org/x/net@0.7.0": {{ID: "CVE-2023-45288", Title: "HTTP/2 CONTINUATION flood", Aliases: []string{"GO-2024-2687"}}},
+		"PyPI:jinja2@2.10": {{
+			ID:          "CVE-2019-10906",
+			Title:       "Sandbox escape",
+			Severity:    model.SeverityHigh,
+			Mitigations: []string{"Upgrade PyPI/jinja2 to 2.10.1 or later"},
+		}},
+	}}
+	nvd := stubDB{records: map[string]osint.Vulnerability{
+		"CVE-2023-45288": {ID: "CVE-2023-45288", CVSS: 7.5, Severity: model.SeverityHigh},
+	}}
+
+	result := NewScanner(ScanOptions{Concurrency: 2}, osv, nvd).Scan(context.Background(), "app", []string{dir})
+	if len(result.Manifests) != 2 || len(result.Components) != 4 || result.Unchecked != 1 {
+		t.Fatalf("scanned %d manifests, %d components, %d unchecked, want 2, 4, 1",
+			len(result.Manifests), len(result.Components), result.Unchecked)
+	}
+
+	vulnerable := result.Vulnerable()
+	if len(vulnerable) != 2 {
+		t.Fatalf("%d vulnerable components, want 2", len(vulnerable))
+	}
+	net := vulnerable[0]
+	if net.Name != "golang.org/x/net" || net.Vulnerabilities[0].CVSS != 7.5 || net.Severity() != model.SeverityHigh {
+		t.Errorf("golang.org/x/net = %+v, want the NVD score and severity", net)
+	}
+
+	finding := Finding("app", vulnerable[1].Component, vulnerable[1].Vulnerabilities[0])
+	if finding.Name != "CVE-2019-10906 in PyPI jinja2@2.10" || finding.Severity != model.SeverityHigh ||
+		finding.Description != "Sandbox escape. Upgrade PyPI/jinja2 to 2.10.1 or later" {
+		t.Errorf("finding = %+v", finding)
+	}
+}
+
+func TestVulnerabilitySeverityOfUnratedVulnerabilities(t *testing.T) {
+	if got := VulnerabilitySeverity(osint.Vulnerability{ID: "GO-2024-2687"}); got != model.SeverityMedium {
+		t.Errorf("unrated vulnerability = %s, want Medium", got)
+	}
+}
@@ -0,0 +1,410 @@
+// pkg/tools/audit/depscan/manifests.go
+package depscan
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Ecosystems of the components, named as in OSV
+const (
+	EcosystemGo        = "Go"
+	EcosystemNPM       = "npm"
+	EcosystemPyPI      = "PyPI"
+	EcosystemMaven     = "Maven"
+	EcosystemRubyGems  = "RubyGems"
+	EcosystemCrates    = "crates.io"
+	EcosystemNuGet     = "NuGet"
+	EcosystemPackagist = "Packagist"
+)
+
+// Component is a dependency declared in a manifest
+type Component struct {
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"` // Empty when the manifest does not pin it
+	Ecosystem string `json:"ecosystem,omitempty"`
+	PURL      string `json:"purl,omitempty"`
+	Manifest  string `json:"manifest"`
+	Dev       bool   `json:"dev,omitempty"`      // Development dependency only
+	Indirect  bool   `json:"indirect,omitempty"` // Dependency of a dependency
+}
+
+// Key identifies a component version across manifests
+func (c Component) Key() string {
+	return c.Ecosystem + ":" + c.Name + "@" + c.Version
+}
+
+// skippedDirs are not searched for manifests: they hold the dependencies
+// themselves or version control data
+var skippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+	".venv":        true,
+	"venv":         true,
+	"__pycache__":  true,
+}
+
+// IsManifest reports whether a file name is that of a supported manifest:
+// go.mod, package-lock.json, requirements files and CycloneDX SBOMs
+func IsManifest(name string) bool {
+	name = strings.ToLower(name)
+	switch {
+	case name == "go.mod", name == "package-lock.json", name == "npm-shrinkwrap.json":
+		return true
+	case strings.HasPrefix(name, "requirements") && strings.HasSuffix(name, ".txt"):
+		return true
+	case name == "bom.json", name == "sbom.json", strings.HasSuffix(name, ".cdx.json"):
+		return true
+	}
+	return false
+}
+
+// FindManifests returns the manifests of a path: the path itself if it is a
+// file, or the manifests found in the directory tree it names
+func FindManifests(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	manifests := make([]string, 0)
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != path && skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if IsManifest(d.Name()) {
+			manifests = append(manifests, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(manifests)
+	return manifests, nil
+}
+
+// ParseManifest reads the components declared in a manifest, selecting the
+// parser by the file name or, for other JSON files, by their content
+func ParseManifest(path string) ([]Component, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var components []Component
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case name == "go.mod":
+		components, err = parseGoMod(data)
+	case name == "package-lock.json", name == "npm-shrinkwrap.json":
+		components, err = parsePackageLock(data)
+	case strings.HasSuffix(name, ".txt"):
+		components, err = parseRequirements(data)
+	case strings.HasSuffix(name, ".json"):
+		components, err = parseCycloneDX(data)
+	default:
+		return nil, fmt.Errorf("%s is not a supported manifest (go.mod, package-lock.json, requirements.txt or a CycloneDX JSON SBOM)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %v", path, err)
+	}
+	for i := range components {
+		components[i].Manifest = path
+	}
+	return components, nil
+}
+
+// parseGoMod parses the requirements of a go.mod, applying its replace
+// directives that name another module version
+func parseGoMod(data []byte) ([]Component, error) {
+	components := make([]Component, 0)
+	replaced := make(map[string][2]string)
+
+	directive := func(verb string, fields []string, indirect bool) {
+		switch verb {
+		case "require":
+			if len(fields) >= 2 {
+				components = append(components, Component{
+					Name:      strings.Trim(fields[0], `"`),
+					Version:   fields[1],
+					Ecosystem: EcosystemGo,
+					Indirect:  indirect,
+				})
+			}
+		case "replace":
+			// module [version] => replacement [version]; local paths have no version
+			for i, field := range fields {
+				if field == "=>" && len(fields) == i+3 {
+					replaced[fields[0]] = [2]string{fields[i+1], fields[i+2]}
+				}
+			}
+		}
+	}
+
+	block := ""
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		indirect := strings.Contains(line, "// indirect")
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+			} else {
+				directive(block, fields, indirect)
+			}
+			continue
+		}
+		if fields[len(fields)-1] == "(" {
+			block = fields[0]
+			continue
+		}
+		directive(fields[0], fields[1:], indirect)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, c := range components {
+		if r, ok := replaced[c.Name]; ok {
+			components[i].Name, components[i].Version = r[0], r[1]
+		}
+		components[i].PURL = "pkg:golang/" + components[i].Name + "@" + components[i].Version
+	}
+	return components, nil
+}
+
+// parsePackageLock parses an npm lockfile: the packages of lockfile
+// versions 2 and 3, or the nested dependencies of version 1
+func parsePackageLock(data []byte) ([]Component, error) {
+	var lock struct {
+		Packages map[string]struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+			Dev     bool   `json:"dev"`
+			Link    bool   `json:"link"`
+		} `json:"packages"`
+		Dependencies map[string]npmDependency `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	components := make([]Component, 0)
+	if len(lock.Packages) > 0 {
+		for path, p := range lock.Packages {
+			// The root package is the project itself
+			i := strings.LastIndex(path, "node_modules/")
+			if i < 0 || p.Link {
+				continue
+			}
+			name := path[i+len("node_modules/"):]
+			if p.Name != "" {
+				name = p.Name
+			}
+			components = append(components, npmComponent(name, p.Version, p.Dev, strings.Count(path, "node_modules/") > 1))
+		}
+	} else {
+		var walk func(deps map[string]npmDependency, nested bool)
+		walk = func(deps map[string]npmDependency, nested bool) {
+			for name, dep := range deps {
+				components = append(components, npmComponent(name, dep.Version, dep.Dev, nested))
+				walk(dep.Dependencies, true)
+			}
+		}
+		walk(lock.Dependencies, false)
+	}
+
+	sort.Slice(components, func(i, j int) bool { return components[i].Key() < components[j].Key() })
+	return components, nil
+}
+
+// npmDependency is a dependency of a version 1 npm lockfile
+type npmDependency struct {
+	Version      string                   `json:"version"`
+	Dev          bool                     `json:"dev"`
+	Dependencies map[string]npmDependency `json:"dependencies"`
+}
+
+// npmComponent creates the component of an npm package. Nested packages are
+// counted as indirect, though a lockfile does not tell which of the
+// top-level ones the project requires itself.
+func npmComponent(name, version string, dev, nested bool) Component {
+	return Component{
+		Name:      name,
+		Version:   version,
+		Ecosystem: EcosystemNPM,
+		PURL:      "pkg:npm/" + strings.Replace(name, "@", "%40", 1) + "@" + version,
+		Dev:       dev,
+		Indirect:  nested,
+	}
+}
+
+// requirementPattern matches a requirement such as Django[argon2]==4.2.1,
+// the name, extras, operator and version
+var requirementPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*(===|==|~=|>=|<=|!=|>|<)?\s*([^\s,;]*)`)
+
+// parseRequirements parses a pip requirements file. Only versions pinned
+// with == are kept; the others depend on what pip resolves at install time.
+func parseRequirements(data []byte) ([]Component, error) {
+	components := make([]Component, 0)
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		// Comments, options such as -r other.txt and URLs
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") || strings.Contains(line, "://") {
+			continue
+		}
+		m := requirementPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		name := normalizePyPIName(m[1])
+		version := ""
+		if (m[3] == "==" || m[3] == "===") && !strings.Contains(m[4], "*") {
+			version = m[4]
+		}
+		component := Component{Name: name, Version: version, Ecosystem: EcosystemPyPI, PURL: "pkg:pypi/" + name}
+		if version != "" {
+			component.PURL += "@" + version
+		}
+		components = append(components, component)
+	}
+	return components, scanner.Err()
+}
+
+// pypiSeparators are the runs of characters PEP 503 folds into a hyphen
+var pypiSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePyPIName normalizes a Python package name as in PEP 503
+func normalizePyPIName(name string) string {
+	return strings.ToLower(pypiSeparators.ReplaceAllString(name, "-"))
+}
+
+// cycloneDXComponent is a component of a CycloneDX SBOM, which may nest others
+type cycloneDXComponent struct {
+	Type       string               `json:"type"`
+	Group      string               `json:"group"`
+	Name       string               `json:"name"`
+	Version    string               `json:"version"`
+	PURL       string               `json:"purl"`
+	Scope      string               `json:"scope"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+// parseCycloneDX parses the components of a CycloneDX JSON SBOM. Their
+// ecosystem comes from their package URL.
+func parseCycloneDX(data []byte) ([]Component, error) {
+	var bom struct {
+		BOMFormat  string               `json:"bomFormat"`
+		Components []cycloneDXComponent `json:"components"`
+	}
+	if err := json.Unmarshal(data, &bom); err != nil {
+		return nil, err
+	}
+	if bom.BOMFormat != "CycloneDX" {
+		return nil, fmt.Errorf("not a CycloneDX SBOM")
+	}
+
+	components := make([]Component, 0)
+	var walk func(list []cycloneDXComponent)
+	walk = func(list []cycloneDXComponent) {
+		for _, c := range list {
+			component := Component{Name: c.Name, Version: c.Version, PURL: c.PURL, Dev: c.Scope == "excluded"}
+			if c.Group != "" {
+				component.Name = c.Group + "/" + c.Name
+			}
+			if ecosystem, name, version, ok := ParsePURL(c.PURL); ok {
+				component.Ecosystem, component.Name = ecosystem, name
+				if version != "" {
+					component.Version = version
+				}
+			}
+			components = append(components, component)
+			walk(c.Components)
+		}
+	}
+	walk(bom.Components)
+	return components, nil
+}
+
+// purlEcosystems maps package URL types to OSV ecosystems
+var purlEcosystems = map[string]string{
+	"golang":   EcosystemGo,
+	"npm":      EcosystemNPM,
+	"pypi":     EcosystemPyPI,
+	"maven":    EcosystemMaven,
+	"gem":      EcosystemRubyGems,
+	"cargo":    EcosystemCrates,
+	"nuget":    EcosystemNuGet,
+	"composer": EcosystemPackagist,
+}
+
+// ParsePURL returns the OSV ecosystem, package name and version of a package
+// URL such as pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1. Names
+// follow the ecosystem: Maven joins the group and artifact with a colon, the
+// others with a slash.
+func ParsePURL(purl string) (ecosystem, name, version string, ok bool) {
+	if !strings.HasPrefix(purl, "pkg:") {
+		return "", "", "", false
+	}
+	rest := strings.TrimPrefix(purl, "pkg:")
+	if i := strings.IndexAny(rest, "?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.LastIndex(rest, "@"); i > 0 && !strings.HasSuffix(rest[:i], "/") {
+		version, _ = url.PathUnescape(rest[i+1:])
+		rest = rest[:i]
+	}
+	slash := strings.Index(rest, "/")
+	if slash < 0 {
+		return "", "", "", false
+	}
+	ecosystem, ok = purlEcosystems[strings.ToLower(rest[:slash])]
+	if !ok {
+		return "", "", "", false
+	}
+
+	parts := strings.Split(rest[slash+1:], "/")
+	for i, part := range parts {
+		parts[i], _ = url.PathUnescape(part)
+	}
+	name = strings.Join(parts, "/")
+	if ecosystem == EcosystemMaven {
+		name = strings.Join(parts, ":")
+	}
+	if ecosystem == EcosystemPyPI {
+		name = normalizePyPIName(name)
+	}
+	return ecosystem, name, version, name != ""
+}
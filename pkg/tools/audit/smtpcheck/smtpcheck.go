@@ -0,0 +1,379 @@
+// pkg/tools/audit/smtpcheck/smtpcheck.go
+package smtpcheck
+
+import (
+	"GopherStrike/pkg/egress"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/siem"
+	"GopherStrike/pkg/stats"
+	"GopherStrike/pkg/timing"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severity levels of SMTP problems
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// relayRecipient is the external address relaying is tested with. Only the
+// envelope is sent; the session is reset before any DATA.
+const relayRecipient = "relay-test@example.net"
+
+// Problem is an issue found with a mail server
+type Problem struct {
+	Check       string `json:"check"` // relay, starttls, auth, vrfy or expn
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+// ServerResult is the SMTP check of one mail server
+type ServerResult struct {
+	Domain     string    `json:"domain"`
+	Server     string    `json:"server"`
+	Address    string    `json:"address"`
+	Banner     string    `json:"banner,omitempty"`
+	Extensions []string  `json:"extensions,omitempty"`
+	STARTTLS   bool      `json:"starttls"`
+	TLSVersion string    `json:"tls_version,omitempty"`
+	Problems   []Problem `json:"problems,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// CheckOptions contains options for the SMTP checker
+type CheckOptions struct {
+	Timeout     int    // Connection and command timeout in seconds
+	Concurrency int    // Servers checked in parallel
+	HeloName    string // Name sent in EHLO
+}
+
+// DefaultCheckOptions returns the default checker options
+func DefaultCheckOptions() CheckOptions {
+	return CheckOptions{
+		Timeout:     15,
+		Concurrency: 5,
+		HeloName:    "gopherstrike.invalid",
+	}
+}
+
+// PresetCheckOptions returns the checker options of a preset. Quick checks
+// many servers at once with a short timeout; deep waits out tarpitting and
+// greylisting servers, one or two at a time.
+func PresetCheckOptions(preset string) (CheckOptions, error) {
+	preset, err := presets.Validate(preset)
+	if err != nil {
+		return CheckOptions{}, err
+	}
+
+	options := DefaultCheckOptions()
+	switch preset {
+	case presets.Quick:
+		options.Timeout = 8
+		options.Concurrency = 20
+	case presets.Deep:
+		options.Timeout = 45
+		options.Concurrency = 2
+	}
+
+	profile := timing.Current()
+	options.Concurrency = profile.Threads(options.Concurrency)
+	options.Timeout = profile.TimeoutSeconds(options.Timeout)
+	return options, nil
+}
+
+// Checker tests mail servers for open relaying, STARTTLS and user
+// enumeration without sending mail
+type Checker struct {
+	options  CheckOptions
+	lookupMX func(ctx context.Context, name string) ([]*net.MX, error)
+}
+
+// NewChecker creates an SMTP checker
+func NewChecker(options CheckOptions) *Checker {
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+	if options.HeloName == "" {
+		options.HeloName = DefaultCheckOptions().HeloName
+	}
+	return &Checker{options: options, lookupMX: net.DefaultResolver.LookupMX}
+}
+
+// target is a mail server to check on behalf of a domain
+type target struct {
+	domain  string
+	server  string
+	address string
+}
+
+// resolveTargets turns entries into mail servers. An entry with a port is
+// checked directly; otherwise its MX servers are checked on port 25, or the
+// domain itself if it has no MX records (the implicit MX of RFC 5321).
+func (c *Checker) resolveTargets(ctx context.Context, entries []string) []target {
+	targets := []target{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if host, _, err := net.SplitHostPort(entry); err == nil {
+			targets = append(targets, target{domain: host, server: host, address: entry})
+			continue
+		}
+
+		domain := strings.TrimSuffix(strings.Trim(entry, "[]"), ".")
+		records, err := c.lookupMX(ctx, domain)
+		if err != nil || len(records) == 0 {
+			targets = append(targets, target{domain: domain, server: domain, address: net.JoinHostPort(domain, "25")})
+			continue
+		}
+		sort.Slice(records, func(i, j int) bool { return records[i].Pref < records[j].Pref })
+		for _, record := range records {
+			server := strings.TrimSuffix(record.Host, ".")
+			targets = append(targets, target{domain: domain, server: server, address: net.JoinHostPort(server, "25")})
+		}
+	}
+	return targets
+}
+
+// CheckDomains checks the mail servers of the entries in parallel. Every
+// problem is reported as a finding.
+func (c *Checker) CheckDomains(ctx context.Context, entries []string) []ServerResult {
+	targets := c.resolveTargets(ctx, entries)
+	results := make([]ServerResult, len(targets))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.options.Concurrency)
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t target) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			timing.Wait(ctx) // A canceled scan ends in the check itself
+			results[i] = c.CheckServer(ctx, t.domain, t.address)
+			for _, problem := range results[i].Problems {
+				siem.Emit(siem.Finding{
+					Tool:        "smtpcheck",
+					Target:      results[i].Domain,
+					Category:    "SMTP_MISCONFIGURATION",
+					Name:        fmt.Sprintf("SMTP %s problem on %s", problem.Check, results[i].Address),
+					Severity:    model.Severity(problem.Severity),
+					Description: problem.Description,
+				})
+			}
+		}(i, t)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// CheckServer runs the SMTP checks against the server at address, which
+// receives mail for domain
+func (c *Checker) CheckServer(ctx context.Context, domain, address string) ServerResult {
+	server, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return ServerResult{Domain: domain, Address: address, Error: err.Error()}
+	}
+	result := ServerResult{Domain: domain, Server: server, Address: address}
+	eventbus.Publish(eventbus.Event{Type: eventbus.ScanStarted, Tool: "smtpcheck", Target: address})
+
+	if err := c.check(ctx, &result); err != nil {
+		result.Error = err.Error()
+		eventbus.ScanFinished("smtpcheck", address, ctx.Err() != nil, err, nil)
+		return result
+	}
+
+	eventbus.ScanFinished("smtpcheck", address, false, nil, map[string]int{"problems": len(result.Problems)})
+	return result
+}
+
+// session is an SMTP conversation with a server
+type session struct {
+	conn net.Conn
+	text *textproto.Conn
+}
+
+// cmd sends a command and returns the server's reply code and message
+func (s *session) cmd(format string, args ...interface{}) (int, string, error) {
+	id, err := s.text.Cmd(format, args...)
+	if err != nil {
+		return 0, "", err
+	}
+	s.text.StartResponse(id)
+	defer s.text.EndResponse(id)
+	return s.text.ReadResponse(0)
+}
+
+// ehlo greets the server and returns the advertised extensions by keyword
+func (s *session) ehlo(name string) (map[string]string, error) {
+	code, msg, err := s.cmd("EHLO %s", name)
+	if err != nil {
+		return nil, err
+	}
+	if code != 250 {
+		return nil, fmt.Errorf("EHLO rejected: %d %s", code, msg)
+	}
+
+	extensions := map[string]string{}
+	for _, line := range strings.Split(msg, "\n")[1:] {
+		keyword, params, _ := strings.Cut(line, " ")
+		extensions[strings.ToUpper(keyword)] = params
+	}
+	return extensions, nil
+}
+
+// check holds the conversation with the server. Enumeration and relaying
+// are tried in plaintext, as an unauthenticated sender would; STARTTLS is
+// negotiated last.
+func (c *Checker) check(ctx context.Context, result *ServerResult) error {
+	timeout := time.Duration(c.options.Timeout) * time.Second
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := stats.DialContext(egress.DialContext("smtpcheck", dialer.DialContext))(ctx, "tcp", result.Address)
+	if err != nil {
+		return fmt.Errorf("connection to %s failed: %v", result.Address, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	s := &session{conn: conn, text: textproto.NewConn(conn)}
+	code, banner, err := s.text.ReadResponse(0)
+	if err != nil {
+		return fmt.Errorf("no SMTP greeting from %s: %v", result.Address, err)
+	}
+	if code != 220 {
+		return fmt.Errorf("server refused the connection: %d %s", code, banner)
+	}
+	result.Banner = strings.Split(banner, "\n")[0]
+
+	extensions, err := s.ehlo(c.options.HeloName)
+	if err != nil {
+		return err
+	}
+	for keyword := range extensions {
+		result.Extensions = append(result.Extensions, keyword)
+	}
+	sort.Strings(result.Extensions)
+
+	if mechanisms, ok := extensions["AUTH"]; ok {
+		result.Problems = append(result.Problems, Problem{
+			Check:       "auth",
+			Severity:    SeverityMedium,
+			Description: fmt.Sprintf("Authentication (%s) is offered before STARTTLS, exposing credentials in cleartext", mechanisms),
+		})
+	}
+	result.Problems = append(result.Problems, s.checkEnumeration()...)
+	if problem, ok := s.checkRelay(result.Domain); ok {
+		result.Problems = append(result.Problems, problem)
+	}
+
+	if _, ok := extensions["STARTTLS"]; !ok {
+		result.Problems = append(result.Problems, Problem{
+			Check:       "starttls",
+			Severity:    SeverityHigh,
+			Description: "STARTTLS is not supported, mail to this server travels in cleartext",
+		})
+		s.cmd("QUIT")
+		return nil
+	}
+	result.STARTTLS = true
+	result.Problems = append(result.Problems, c.checkSTARTTLS(s, result)...)
+	return nil
+}
+
+// checkEnumeration reports VRFY and EXPN commands that confirm addresses
+func (s *session) checkEnumeration() []Problem {
+	problems := []Problem{}
+	for _, command := range []string{"VRFY", "EXPN"} {
+		code, msg, err := s.cmd("%s postmaster", command)
+		if err != nil || (code != 250 && code != 251) {
+			continue
+		}
+		problems = append(problems, Problem{
+			Check:       strings.ToLower(command),
+			Severity:    SeverityMedium,
+			Description: fmt.Sprintf("%s is enabled and confirms addresses (%d %s), allowing user enumeration", command, code, strings.Split(msg, "\n")[0]),
+		})
+	}
+	return problems
+}
+
+// checkRelay offers the server mail for an external recipient from an
+// external, a local and the null sender. A recipient accepted for any of
+// them makes the server an open relay. No message is ever sent.
+func (s *session) checkRelay(domain string) (Problem, bool) {
+	senders := []string{"relay-test@example.org", "postmaster@" + domain, ""}
+
+	accepted := []string{}
+	for _, sender := range senders {
+		code, _, err := s.cmd("MAIL FROM:<%s>", sender)
+		if err == nil && code == 250 {
+			code, _, err = s.cmd("RCPT TO:<%s>", relayRecipient)
+			if err == nil && (code == 250 || code == 251) {
+				accepted = append(accepted, "<"+sender+">")
+			}
+		}
+		if _, _, err := s.cmd("RSET"); err != nil {
+			break
+		}
+	}
+	if len(accepted) == 0 {
+		return Problem{}, false
+	}
+
+	return Problem{
+		Check:       "relay",
+		Severity:    SeverityHigh,
+		Description: fmt.Sprintf("Open relay: recipient %s was accepted from sender(s) %s without authentication", relayRecipient, strings.Join(accepted, ", ")),
+	}, true
+}
+
+// checkSTARTTLS upgrades the session to TLS and reports failed handshakes
+// and legacy protocol versions
+func (c *Checker) checkSTARTTLS(s *session, result *ServerResult) []Problem {
+	code, msg, err := s.cmd("STARTTLS")
+	if err != nil || code != 220 {
+		return []Problem{{
+			Check:       "starttls",
+			Severity:    SeverityHigh,
+			Description: fmt.Sprintf("STARTTLS is advertised but refused: %d %s", code, msg),
+		}}
+	}
+
+	tlsConn := tls.Client(s.conn, &tls.Config{
+		ServerName:         result.Server,
+		InsecureSkipVerify: true, // opportunistic TLS; only the protocol is checked
+		MinVersion:         tls.VersionTLS10,
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		return []Problem{{
+			Check:       "starttls",
+			Severity:    SeverityHigh,
+			Description: fmt.Sprintf("STARTTLS handshake failed: %v", err),
+		}}
+	}
+
+	state := tlsConn.ConnectionState()
+	result.TLSVersion = tls.VersionName(state.Version)
+	s.text = textproto.NewConn(tlsConn)
+	s.cmd("QUIT")
+
+	if state.Version < tls.VersionTLS12 {
+		return []Problem{{
+			Check:       "starttls",
+			Severity:    SeverityMedium,
+			Description: fmt.Sprintf("STARTTLS negotiates the deprecated %s protocol", result.TLSVersion),
+		}}
+	}
+	return nil
+}
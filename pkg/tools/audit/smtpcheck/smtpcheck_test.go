@@ -0,0 +1,199 @@
+package smtpcheck
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer configures the behavior of a fake SMTP server
+type fakeServer struct {
+	starttls bool
+	auth     bool
+	vrfy     bool
+	relay    bool // Accept external recipients
+	commands []string
+	done     chan struct{}
+}
+
+// selfSigned returns a TLS certificate for the fake server
+func selfSigned(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "mail.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// serve starts the fake server and returns its address
+func (f *fakeServer) serve(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	config := &tls.Config{Certificates: []tls.Certificate{selfSigned(t)}}
+
+	f.done = make(chan struct{})
+	go func() {
+		defer close(f.done)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		fmt.Fprint(conn, "220 mail.test ESMTP ready\r\n")
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			f.commands = append(f.commands, line)
+			verb, arg, _ := strings.Cut(line, " ")
+
+			switch strings.ToUpper(verb) {
+			case "EHLO":
+				reply := "250-mail.test\r\n250-SIZE 1000000\r\n"
+				if f.auth {
+					reply += "250-AUTH PLAIN LOGIN\r\n"
+				}
+				if f.starttls {
+					reply += "250-STARTTLS\r\n"
+				}
+				fmt.Fprint(conn, reply+"250 8BITMIME\r\n")
+			case "VRFY":
+				if f.vrfy {
+					fmt.Fprint(conn, "250 Postmaster <postmaster@mail.test>\r\n")
+				} else {
+					fmt.Fprint(conn, "252 Cannot VRFY user\r\n")
+				}
+			case "EXPN":
+				fmt.Fprint(conn, "502 EXPN not implemented\r\n")
+			case "MAIL", "RSET":
+				fmt.Fprint(conn, "250 OK\r\n")
+			case "RCPT":
+				if f.relay || strings.HasSuffix(arg, "@mail.test>") {
+					fmt.Fprint(conn, "250 Accepted\r\n")
+				} else {
+					fmt.Fprint(conn, "554 Relay access denied\r\n")
+				}
+			case "STARTTLS":
+				fmt.Fprint(conn, "220 Go ahead\r\n")
+				tlsConn := tls.Server(conn, config)
+				if tlsConn.Handshake() != nil {
+					return
+				}
+				conn, reader = tlsConn, bufio.NewReader(tlsConn)
+			case "QUIT":
+				fmt.Fprint(conn, "221 Bye\r\n")
+				return
+			default:
+				fmt.Fprint(conn, "500 Unknown command\r\n")
+			}
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestCheckServer(t *testing.T) {
+	tests := []struct {
+		name   string
+		server *fakeServer
+		want   []string // check/severity of each problem
+	}{
+		{
+			name:   "Hardened",
+			server: &fakeServer{starttls: true},
+		},
+		{
+			name:   "No STARTTLS",
+			server: &fakeServer{},
+			want:   []string{"starttls/high"},
+		},
+		{
+			name:   "Open relay with VRFY and plaintext AUTH",
+			server: &fakeServer{starttls: true, auth: true, vrfy: true, relay: true},
+			want:   []string{"auth/medium", "vrfy/medium", "relay/high"},
+		},
+	}
+
+	checker := NewChecker(DefaultCheckOptions())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := tt.server.serve(t)
+			result := checker.CheckServer(context.Background(), "mail.test", address)
+			if result.Error != "" {
+				t.Fatalf("CheckServer() error = %s", result.Error)
+			}
+			if result.Banner != "mail.test ESMTP ready" || result.STARTTLS != tt.server.starttls {
+				t.Errorf("CheckServer() banner = %q, starttls = %v", result.Banner, result.STARTTLS)
+			}
+			if tt.server.starttls && result.TLSVersion != "TLS 1.3" {
+				t.Errorf("CheckServer() TLS version = %q, want TLS 1.3", result.TLSVersion)
+			}
+
+			got := []string{}
+			for _, problem := range result.Problems {
+				got = append(got, problem.Check+"/"+problem.Severity)
+			}
+			if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("CheckServer() problems = %v, want %v", got, tt.want)
+			}
+
+			<-tt.server.done
+			for _, command := range tt.server.commands {
+				if strings.EqualFold(command, "DATA") {
+					t.Errorf("CheckServer() sent DATA")
+				}
+			}
+		})
+	}
+}
+
+func TestResolveTargets(t *testing.T) {
+	checker := NewChecker(DefaultCheckOptions())
+	checker.lookupMX = func(ctx context.Context, name string) ([]*net.MX, error) {
+		if name == "example.com" {
+			return []*net.MX{{Host: "mx2.example.com.", Pref: 20}, {Host: "mx1.example.com.", Pref: 10}}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+
+	got := checker.resolveTargets(context.Background(), []string{"example.com", "example.org", "smtp.example.net:587"})
+	want := []target{
+		{domain: "example.com", server: "mx1.example.com", address: "mx1.example.com:25"},
+		{domain: "example.com", server: "mx2.example.com", address: "mx2.example.com:25"},
+		{domain: "example.org", server: "example.org", address: "example.org:25"},
+		{domain: "smtp.example.net", server: "smtp.example.net", address: "smtp.example.net:587"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveTargets() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,422 @@
+// pkg/tools/audit/sshaudit/sshaudit.go
+package sshaudit
+
+import (
+	"GopherStrike/pkg/egress"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/siem"
+	"GopherStrike/pkg/stats"
+	"GopherStrike/pkg/timing"
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severity levels of weak algorithms
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// Algorithm kinds negotiated in the key exchange
+const (
+	KindKex     = "kex"
+	KindHostKey = "host key"
+	KindCipher  = "cipher"
+	KindMAC     = "mac"
+)
+
+// clientBanner is the identification string sent to servers
+const clientBanner = "SSH-2.0-GopherStrike_1.0"
+
+// msgKexInit is the SSH_MSG_KEXINIT message number
+const msgKexInit = 20
+
+// maxPacketLength bounds the KEXINIT packet read from servers
+const maxPacketLength = 256 * 1024
+
+// Weakness is a deprecated or weak algorithm an SSH server offers
+type Weakness struct {
+	Kind      string `json:"kind"`
+	Algorithm string `json:"algorithm"`
+	Severity  string `json:"severity"`
+	Reason    string `json:"reason"`
+	Reference string `json:"reference"`
+}
+
+// AuditResult is the SSH audit of one server
+type AuditResult struct {
+	Host       string     `json:"host"`
+	Address    string     `json:"address"`
+	Banner     string     `json:"banner,omitempty"`
+	Kex        []string   `json:"kex,omitempty"`
+	HostKeys   []string   `json:"host_keys,omitempty"`
+	Ciphers    []string   `json:"ciphers,omitempty"`
+	MACs       []string   `json:"macs,omitempty"`
+	Weaknesses []Weakness `json:"weaknesses,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// AuditOptions contains options for the SSH auditor
+type AuditOptions struct {
+	Timeout     int // Connection timeout in seconds
+	Concurrency int // Servers audited in parallel
+}
+
+// DefaultAuditOptions returns the default auditor options
+func DefaultAuditOptions() AuditOptions {
+	return AuditOptions{
+		Timeout:     10,
+		Concurrency: 10,
+	}
+}
+
+// PresetAuditOptions returns the auditor options of a preset. Quick audits
+// many servers at once with a short timeout; deep waits longer for slow
+// servers and those delaying their banner.
+func PresetAuditOptions(preset string) (AuditOptions, error) {
+	preset, err := presets.Validate(preset)
+	if err != nil {
+		return AuditOptions{}, err
+	}
+
+	options := DefaultAuditOptions()
+	switch preset {
+	case presets.Quick:
+		options.Timeout = 5
+		options.Concurrency = 50
+	case presets.Deep:
+		options.Timeout = 30
+		options.Concurrency = 5
+	}
+
+	profile := timing.Current()
+	options.Concurrency = profile.Threads(options.Concurrency)
+	options.Timeout = profile.TimeoutSeconds(options.Timeout)
+	return options, nil
+}
+
+// weakAlgorithm describes why an algorithm is weak
+type weakAlgorithm struct {
+	Severity  string
+	Reason    string
+	Reference string
+}
+
+// weakAlgorithms lists deprecated and weak algorithms per kind. Names ending
+// in * match as a prefix.
+var weakAlgorithms = map[string]map[string]weakAlgorithm{
+	KindKex: {
+		"diffie-hellman-group1-sha1":            {SeverityHigh, "1024-bit Diffie-Hellman group with SHA-1, breakable by state-level attackers (Logjam)", "RFC 9142"},
+		"diffie-hellman-group14-sha1":           {SeverityMedium, "SHA-1 key exchange hash", "RFC 9142"},
+		"diffie-hellman-group-exchange-sha1":    {SeverityMedium, "SHA-1 key exchange hash", "RFC 9142"},
+		"rsa1024-sha1":                          {SeverityHigh, "1024-bit RSA key exchange with SHA-1", "RFC 9142"},
+		"gss-group1-sha1-*":                     {SeverityHigh, "1024-bit Diffie-Hellman group with SHA-1", "RFC 9142"},
+		"gss-gex-sha1-*":                        {SeverityMedium, "SHA-1 key exchange hash", "RFC 9142"},
+		"ecdh-sha2-nistp*":                      {SeverityLow, "NIST curves with unexplained parameter choices; prefer curve25519-sha256", "https://safecurves.cr.yp.to/"},
+		"diffie-hellman-group14-sha256@ssh.com": {SeverityLow, "Non-standard name of diffie-hellman-group14-sha256", "RFC 8268"},
+	},
+	KindHostKey: {
+		"ssh-dss":                      {SeverityHigh, "1024-bit DSA keys with SHA-1 signatures, removed from OpenSSH", "RFC 9142, OpenSSH 7.0 release notes"},
+		"ssh-dss-cert-v01@openssh.com": {SeverityHigh, "1024-bit DSA keys with SHA-1 signatures, removed from OpenSSH", "RFC 9142, OpenSSH 7.0 release notes"},
+		"ssh-rsa":                      {SeverityMedium, "RSA with SHA-1 signatures, disabled by default since OpenSSH 8.8; use rsa-sha2-256/512", "RFC 8332, OpenSSH 8.8 release notes"},
+		"ssh-rsa-cert-v01@openssh.com": {SeverityMedium, "RSA certificates with SHA-1 signatures", "RFC 8332, OpenSSH 8.8 release notes"},
+		"ecdsa-sha2-nistp*":            {SeverityLow, "NIST curves with unexplained parameter choices; prefer ssh-ed25519", "https://safecurves.cr.yp.to/"},
+	},
+	KindCipher: {
+		"none":                        {SeverityHigh, "No encryption", "RFC 4253 section 6.3"},
+		"des-cbc":                     {SeverityHigh, "56-bit DES", "RFC 8758"},
+		"3des-cbc":                    {SeverityHigh, "64-bit block cipher vulnerable to Sweet32 and CBC plaintext recovery", "RFC 8758, CVE-2016-2183"},
+		"blowfish-cbc":                {SeverityHigh, "64-bit block cipher vulnerable to Sweet32", "RFC 8758, CVE-2016-2183"},
+		"cast128-cbc":                 {SeverityHigh, "64-bit block cipher vulnerable to Sweet32", "RFC 8758, CVE-2016-2183"},
+		"idea-cbc":                    {SeverityHigh, "64-bit block cipher vulnerable to Sweet32", "RFC 8758, CVE-2016-2183"},
+		"arcfour*":                    {SeverityHigh, "RC4 stream cipher with known keystream biases", "RFC 8758"},
+		"rijndael-cbc@lysator.liu.se": {SeverityMedium, "CBC mode, vulnerable to plaintext recovery", "CVE-2008-5161"},
+		"aes128-cbc":                  {SeverityMedium, "CBC mode, vulnerable to plaintext recovery", "CVE-2008-5161"},
+		"aes192-cbc":                  {SeverityMedium, "CBC mode, vulnerable to plaintext recovery", "CVE-2008-5161"},
+		"aes256-cbc":                  {SeverityMedium, "CBC mode, vulnerable to plaintext recovery", "CVE-2008-5161"},
+	},
+	KindMAC: {
+		"none":                      {SeverityHigh, "No integrity protection", "RFC 4253 section 6.4"},
+		"hmac-md5*":                 {SeverityHigh, "MD5 based MAC", "RFC 6151"},
+		"hmac-sha1-96*":             {SeverityMedium, "SHA-1 based MAC truncated to 96 bits", "RFC 6194"},
+		"hmac-sha1":                 {SeverityLow, "SHA-1 based MAC in encrypt-and-MAC mode; prefer hmac-sha2-256-etm@openssh.com", "RFC 6194"},
+		"hmac-ripemd160*":           {SeverityLow, "RIPEMD-160 based MAC, removed from OpenSSH 7.6", "OpenSSH 7.6 release notes"},
+		"umac-64@openssh.com":       {SeverityLow, "64-bit tag in encrypt-and-MAC mode", "RFC 4418"},
+		"umac-64-etm@openssh.com":   {SeverityLow, "64-bit tag", "RFC 4418"},
+		"hmac-sha1-etm@openssh.com": {SeverityLow, "SHA-1 based MAC", "RFC 6194"},
+	},
+}
+
+// lookupWeakness returns the weakness of an algorithm, if any
+func lookupWeakness(kind, algorithm string) (weakAlgorithm, bool) {
+	if weak, ok := weakAlgorithms[kind][algorithm]; ok {
+		return weak, true
+	}
+	for name, weak := range weakAlgorithms[kind] {
+		if strings.HasSuffix(name, "*") && strings.HasPrefix(algorithm, strings.TrimSuffix(name, "*")) {
+			return weak, true
+		}
+	}
+	return weakAlgorithm{}, false
+}
+
+// Auditor audits the algorithms offered by SSH servers
+type Auditor struct {
+	options AuditOptions
+}
+
+// NewAuditor creates an SSH auditor
+func NewAuditor(options AuditOptions) *Auditor {
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+	return &Auditor{options: options}
+}
+
+// ParseHost splits a host entry such as "example.com" or "example.com:2222"
+// into the host and the address to connect to, defaulting to port 22
+func ParseHost(entry string) (string, string, error) {
+	entry = strings.TrimPrefix(strings.TrimSpace(entry), "ssh://")
+	if entry == "" {
+		return "", "", fmt.Errorf("empty host")
+	}
+
+	host, port, err := net.SplitHostPort(entry)
+	if err != nil {
+		host, port = strings.Trim(entry, "[]"), "22"
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("invalid host: %s", entry)
+	}
+	return host, net.JoinHostPort(host, port), nil
+}
+
+// AuditHosts audits the hosts in parallel and returns the results in the
+// order of the hosts. Every weakness is reported as a finding.
+func (a *Auditor) AuditHosts(ctx context.Context, hosts []string) []AuditResult {
+	results := make([]AuditResult, len(hosts))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, a.options.Concurrency)
+	for i, entry := range hosts {
+		wg.Add(1)
+		go func(i int, entry string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			timing.Wait(ctx) // A canceled scan ends in the check itself
+			results[i] = a.AuditHost(ctx, entry)
+			for _, weakness := range results[i].Weaknesses {
+				siem.Emit(siem.Finding{
+					Tool:        "sshaudit",
+					Target:      results[i].Host,
+					Category:    "SSH_WEAK_ALGORITHM",
+					Name:        fmt.Sprintf("Weak SSH %s algorithm %s on %s", weakness.Kind, weakness.Algorithm, results[i].Address),
+					Severity:    model.Severity(weakness.Severity),
+					Description: fmt.Sprintf("%s (%s)", weakness.Reason, weakness.Reference),
+				})
+			}
+		}(i, entry)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// AuditHost reads the algorithms a server offers in its key exchange init
+// and flags the weak ones. No authentication is attempted.
+func (a *Auditor) AuditHost(ctx context.Context, entry string) AuditResult {
+	host, address, err := ParseHost(entry)
+	if err != nil {
+		return AuditResult{Host: entry, Error: err.Error()}
+	}
+	result := AuditResult{Host: host, Address: address}
+	eventbus.Publish(eventbus.Event{Type: eventbus.ScanStarted, Tool: "sshaudit", Target: host})
+
+	if err := a.readKexInit(ctx, &result); err != nil {
+		result.Error = err.Error()
+		eventbus.ScanFinished("sshaudit", host, ctx.Err() != nil, err, nil)
+		return result
+	}
+
+	offered := []struct {
+		kind       string
+		algorithms []string
+	}{
+		{KindKex, result.Kex},
+		{KindHostKey, result.HostKeys},
+		{KindCipher, result.Ciphers},
+		{KindMAC, result.MACs},
+	}
+	for _, list := range offered {
+		for _, algorithm := range list.algorithms {
+			if weak, ok := lookupWeakness(list.kind, algorithm); ok {
+				result.Weaknesses = append(result.Weaknesses, Weakness{
+					Kind:      list.kind,
+					Algorithm: algorithm,
+					Severity:  weak.Severity,
+					Reason:    weak.Reason,
+					Reference: weak.Reference,
+				})
+			}
+		}
+	}
+	if weakness, ok := terrapin(result); ok {
+		result.Weaknesses = append(result.Weaknesses, weakness)
+	}
+
+	eventbus.ScanFinished("sshaudit", host, false, nil, map[string]int{"weaknesses": len(result.Weaknesses)})
+	return result
+}
+
+// terrapin flags servers open to the Terrapin prefix truncation attack:
+// ChaCha20-Poly1305 or encrypt-then-MAC modes without strict key exchange
+func terrapin(result AuditResult) (Weakness, bool) {
+	for _, kex := range result.Kex {
+		if kex == "kex-strict-s-v00@openssh.com" {
+			return Weakness{}, false
+		}
+	}
+
+	vulnerable := []string{}
+	for _, cipher := range result.Ciphers {
+		if cipher == "chacha20-poly1305@openssh.com" {
+			vulnerable = append(vulnerable, cipher)
+		}
+	}
+	for _, mac := range result.MACs {
+		if strings.HasSuffix(mac, "-etm@openssh.com") {
+			vulnerable = append(vulnerable, mac)
+			break
+		}
+	}
+	if len(vulnerable) == 0 {
+		return Weakness{}, false
+	}
+
+	return Weakness{
+		Kind:      KindKex,
+		Algorithm: strings.Join(vulnerable, ", "),
+		Severity:  SeverityMedium,
+		Reason:    "Prefix truncation attack (Terrapin) possible: strict key exchange (kex-strict-s-v00@openssh.com) is not offered",
+		Reference: "CVE-2023-48795",
+	}, true
+}
+
+// readKexInit exchanges identification strings with the server and parses
+// its SSH_MSG_KEXINIT into the result
+func (a *Auditor) readKexInit(ctx context.Context, result *AuditResult) error {
+	timeout := time.Duration(a.options.Timeout) * time.Second
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := stats.DialContext(egress.DialContext("sshaudit", dialer.DialContext))(ctx, "tcp", result.Address)
+	if err != nil {
+		return fmt.Errorf("connection to %s failed: %v", result.Address, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if _, err := fmt.Fprintf(conn, "%s\r\n", clientBanner); err != nil {
+		return fmt.Errorf("failed to send identification: %v", err)
+	}
+
+	// Servers may send other lines before their identification string
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("no SSH identification from %s: %v", result.Address, err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, "SSH-") {
+			result.Banner = line
+			break
+		}
+	}
+	if !strings.HasPrefix(result.Banner, "SSH-2.0-") && !strings.HasPrefix(result.Banner, "SSH-1.99-") {
+		return fmt.Errorf("unsupported SSH protocol version: %s", result.Banner)
+	}
+
+	payload, err := readPacket(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read key exchange init: %v", err)
+	}
+	return parseKexInit(payload, result)
+}
+
+// readPacket reads an unencrypted binary packet and returns its payload
+func readPacket(r io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header[:4])
+	padding := uint32(header[4])
+	if length < padding+1 || length > maxPacketLength {
+		return nil, fmt.Errorf("invalid packet length %d", length)
+	}
+
+	body := make([]byte, length-1)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body[:len(body)-int(padding)], nil
+}
+
+// parseKexInit parses the algorithm name-lists of a SSH_MSG_KEXINIT payload:
+// the message number, a 16-byte cookie, then the kex, host key, ciphers and
+// MACs (client to server and server to client) among other lists
+func parseKexInit(payload []byte, result *AuditResult) error {
+	if len(payload) < 17 {
+		return fmt.Errorf("truncated key exchange init: %d bytes", len(payload))
+	}
+	if payload[0] != msgKexInit {
+		return fmt.Errorf("expected key exchange init, got message %d", payload[0])
+	}
+	rest := payload[17:]
+
+	lists := make([][]string, 6)
+	for i := range lists {
+		if len(rest) < 4 {
+			return fmt.Errorf("truncated key exchange init")
+		}
+		length := binary.BigEndian.Uint32(rest[:4])
+		if uint32(len(rest)-4) < length {
+			return fmt.Errorf("truncated key exchange init")
+		}
+		if length > 0 {
+			lists[i] = strings.Split(string(rest[4:4+length]), ",")
+		}
+		rest = rest[4+length:]
+	}
+
+	result.Kex = lists[0]
+	result.HostKeys = lists[1]
+	result.Ciphers = union(lists[2], lists[3])
+	result.MACs = union(lists[4], lists[5])
+	return nil
+}
+
+// union returns the names of both lists, in order and without duplicates
+func union(a, b []string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, name := range append(append([]string{}, a...), b...) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,205 @@
+package sshaudit
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// kexInitPacket builds an unencrypted SSH_MSG_KEXINIT binary packet offering
+// the algorithms, the same in both directions
+func kexInitPacket(kex, hostKeys, ciphers, macs []string) []byte {
+	payload := []byte{msgKexInit}
+	payload = append(payload, make([]byte, 16)...)
+	lists := [][]string{kex, hostKeys, ciphers, ciphers, macs, macs, {"none"}, {"none"}, nil, nil}
+	for _, list := range lists {
+		name := strings.Join(list, ",")
+		payload = binary.BigEndian.AppendUint32(payload, uint32(len(name)))
+		payload = append(payload, name...)
+	}
+	payload = append(payload, 0, 0, 0, 0, 0)
+
+	padding := 8 - (len(payload)+5)%8
+	if padding < 4 {
+		padding += 8
+	}
+	packet := binary.BigEndian.AppendUint32(nil, uint32(len(payload)+padding+1))
+	packet = append(packet, byte(padding))
+	packet = append(packet, payload...)
+	return append(packet, make([]byte, padding)...)
+}
+
+// serveSSH starts a fake SSH server that sends banner and packet to every
+// client and returns its address
+func serveSSH(t *testing.T, banner string, packet []byte) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte(banner + "\r\n"))
+			conn.Write(packet)
+			bufio.NewReader(conn).ReadString('\n')
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestAuditHost(t *testing.T) {
+	modern := []string{"curve25519-sha256", "kex-strict-s-v00@openssh.com"}
+
+	tests := []struct {
+		name     string
+		banner   string
+		kex      []string
+		hostKeys []string
+		ciphers  []string
+		macs     []string
+		want     []string // kind/algorithm/severity of each weakness
+		wantErr  bool
+	}{
+		{
+			name:     "Hardened",
+			banner:   "SSH-2.0-OpenSSH_9.6",
+			kex:      modern,
+			hostKeys: []string{"ssh-ed25519", "rsa-sha2-512"},
+			ciphers:  []string{"chacha20-poly1305@openssh.com", "aes256-gcm@openssh.com"},
+			macs:     []string{"hmac-sha2-256-etm@openssh.com"},
+		},
+		{
+			name:     "Legacy",
+			banner:   "SSH-2.0-OpenSSH_5.3",
+			kex:      []string{"diffie-hellman-group14-sha1", "diffie-hellman-group1-sha1"},
+			hostKeys: []string{"ssh-rsa", "ssh-dss"},
+			ciphers:  []string{"aes128-ctr", "aes128-cbc", "3des-cbc", "arcfour256"},
+			macs:     []string{"hmac-sha2-256", "hmac-md5-96", "hmac-sha1"},
+			want: []string{
+				"kex/diffie-hellman-group14-sha1/medium", "kex/diffie-hellman-group1-sha1/high",
+				"host key/ssh-rsa/medium", "host key/ssh-dss/high",
+				"cipher/aes128-cbc/medium", "cipher/3des-cbc/high", "cipher/arcfour256/high",
+				"mac/hmac-md5-96/high", "mac/hmac-sha1/low",
+			},
+		},
+		{
+			name:     "Terrapin",
+			banner:   "SSH-2.0-OpenSSH_8.9",
+			kex:      []string{"curve25519-sha256", "ecdh-sha2-nistp256"},
+			hostKeys: []string{"ssh-ed25519"},
+			ciphers:  []string{"chacha20-poly1305@openssh.com", "aes256-ctr"},
+			macs:     []string{"umac-128-etm@openssh.com", "hmac-sha2-512-etm@openssh.com"},
+			want: []string{
+				"kex/ecdh-sha2-nistp256/low",
+				"kex/chacha20-poly1305@openssh.com, umac-128-etm@openssh.com/medium",
+			},
+		},
+		{
+			name:    "SSH-1 only",
+			banner:  "SSH-1.5-Cisco-1.25",
+			wantErr: true,
+		},
+	}
+
+	auditor := NewAuditor(DefaultAuditOptions())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := serveSSH(t, tt.banner, kexInitPacket(tt.kex, tt.hostKeys, tt.ciphers, tt.macs))
+			result := auditor.AuditHost(context.Background(), address)
+			if (result.Error != "") != tt.wantErr {
+				t.Fatalf("AuditHost() error = %q, wantErr %v", result.Error, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result.Banner != tt.banner || !reflect.DeepEqual(result.Ciphers, tt.ciphers) || !reflect.DeepEqual(result.MACs, tt.macs) {
+				t.Errorf("AuditHost() banner = %s, ciphers = %v, macs = %v", result.Banner, result.Ciphers, result.MACs)
+			}
+
+			got := []string{}
+			for _, weakness := range result.Weaknesses {
+				got = append(got, weakness.Kind+"/"+weakness.Algorithm+"/"+weakness.Severity)
+				if weakness.Reference == "" {
+					t.Errorf("weakness %s has no reference", weakness.Algorithm)
+				}
+			}
+			if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("AuditHost() weaknesses = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKexInitMalformed(t *testing.T) {
+	for _, payload := range [][]byte{
+		nil,
+		{msgKexInit},
+		append([]byte{msgKexInit}, make([]byte, 15)...),
+		append([]byte{msgKexInit + 1}, make([]byte, 16)...),
+		append([]byte{msgKexInit}, make([]byte, 18)...),
+	} {
+		if err := parseKexInit(payload, &AuditResult{}); err == nil {
+			t.Errorf("parseKexInit(%v) accepted a malformed payload", payload)
+		}
+	}
+}
+
+func TestParseHost(t *testing.T) {
+	tests := []struct {
+		entry       string
+		wantHost    string
+		wantAddress string
+		wantErr     bool
+	}{
+		{"example.com", "example.com", "example.com:22", false},
+		{"example.com:2222", "example.com", "example.com:2222", false},
+		{"ssh://10.0.0.5", "10.0.0.5", "10.0.0.5:22", false},
+		{"[2001:db8::1]:22", "2001:db8::1", "[2001:db8::1]:22", false},
+		{"2001:db8::1", "2001:db8::1", "[2001:db8::1]:22", false},
+		{" ", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			host, address, err := ParseHost(tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHost(%q) error = %v, wantErr %v", tt.entry, err, tt.wantErr)
+			}
+			if host != tt.wantHost || address != tt.wantAddress {
+				t.Errorf("ParseHost(%q) = %s, %s, want %s, %s", tt.entry, host, address, tt.wantHost, tt.wantAddress)
+			}
+		})
+	}
+}
+
+func TestPresetAuditOptions(t *testing.T) {
+	quick, err := PresetAuditOptions("quick")
+	if err != nil {
+		t.Fatalf("PresetAuditOptions(quick) error = %v", err)
+	}
+	deep, err := PresetAuditOptions("deep")
+	if err != nil {
+		t.Fatalf("PresetAuditOptions(deep) error = %v", err)
+	}
+	normal, err := PresetAuditOptions("")
+	if err != nil || normal != DefaultAuditOptions() {
+		t.Errorf("PresetAuditOptions(\"\") = %+v, %v, want the defaults", normal, err)
+	}
+	if quick.Timeout >= normal.Timeout || deep.Timeout <= normal.Timeout {
+		t.Errorf("preset timeouts quick %d, normal %d, deep %d are not increasing", quick.Timeout, normal.Timeout, deep.Timeout)
+	}
+	if _, err := PresetAuditOptions("slow"); err == nil {
+		t.Errorf("PresetAuditOptions(slow) accepted an unknown preset")
+	}
+}
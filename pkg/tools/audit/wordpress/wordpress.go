@@ -0,0 +1,528 @@
+// pkg/tools/audit/wordpress/wordpress.go
+package wordpress
+
+import (
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/httpclient"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/siem"
+	"GopherStrike/pkg/timing"
+	"GopherStrike/pkg/tools/osint"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity levels of WordPress problems
+const (
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// maxBodySize caps the bytes read of a response
+const maxBodySize = 2 << 20
+
+// popularPlugins are probed by deep scans when the pages don't reference
+// them: widely installed plugins with a history of vulnerabilities
+var popularPlugins = []string{
+	"akismet", "contact-form-7", "elementor", "woocommerce", "wordpress-seo",
+	"wpforms-lite", "classic-editor", "jetpack", "all-in-one-seo-pack", "wordfence",
+	"really-simple-ssl", "litespeed-cache", "wp-file-manager", "duplicator", "updraftplus",
+	"revslider", "wp-super-cache", "w3-total-cache", "ninja-forms", "redirection",
+}
+
+// Problem is an issue found with a WordPress site
+type Problem struct {
+	Check       string `json:"check"` // users, authors, xmlrpc, multicall or pingback
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+// User is an account found on the site
+type User struct {
+	ID     int    `json:"id,omitempty"`
+	Slug   string `json:"slug"` // The login name, unless changed by a plugin
+	Name   string `json:"name,omitempty"`
+	Source string `json:"source"` // rest or author archive
+}
+
+// Vulnerability is a known vulnerability matching a component version
+type Vulnerability struct {
+	ID         string  `json:"id"`
+	Title      string  `json:"title"`
+	Severity   string  `json:"severity"`
+	CVSS       float64 `json:"cvss"`
+	Confidence float64 `json:"confidence"` // Correlation confidence, 0.0-1.0
+}
+
+// Component is the WordPress core, a plugin or a theme
+type Component struct {
+	Slug            string          `json:"slug"`
+	Name            string          `json:"name,omitempty"`
+	Version         string          `json:"version,omitempty"`
+	VersionSource   string          `json:"version_source,omitempty"` // Where the version was read
+	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// XMLRPC describes the XML-RPC interface of the site
+type XMLRPC struct {
+	Enabled bool     `json:"enabled"`
+	Methods []string `json:"methods,omitempty"`
+}
+
+// Result is the enumeration of one WordPress site
+type Result struct {
+	URL      string      `json:"url"`
+	Detected bool        `json:"detected"`
+	Core     Component   `json:"core"`
+	Plugins  []Component `json:"plugins,omitempty"`
+	Themes   []Component `json:"themes,omitempty"`
+	Users    []User      `json:"users,omitempty"`
+	XMLRPC   XMLRPC      `json:"xmlrpc"`
+	Problems []Problem   `json:"problems,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// ScanOptions contains options for the WordPress scanner
+type ScanOptions struct {
+	Timeout      int  // Request timeout in seconds
+	MaxAuthorID  int  // Author archives tried, from ?author=1 up; 0 skips them
+	ProbePlugins bool // Also look for popular plugins the pages don't reference
+}
+
+// DefaultScanOptions returns the default scanner options
+func DefaultScanOptions() ScanOptions {
+	return ScanOptions{
+		Timeout:     15,
+		MaxAuthorID: 10,
+	}
+}
+
+// PresetScanOptions returns the scanner options of a preset. Quick relies
+// on the REST API and tries a few author archives; deep tries more of them
+// and probes the readme of popular plugins.
+func PresetScanOptions(preset string) (ScanOptions, error) {
+	preset, err := presets.Validate(preset)
+	if err != nil {
+		return ScanOptions{}, err
+	}
+
+	options := DefaultScanOptions()
+	switch preset {
+	case presets.Quick:
+		options.Timeout = 8
+		options.MaxAuthorID = 3
+	case presets.Deep:
+		options.Timeout = 30
+		options.MaxAuthorID = 50
+		options.ProbePlugins = true
+	}
+
+	options.Timeout = timing.Current().TimeoutSeconds(options.Timeout)
+	return options, nil
+}
+
+// Scanner enumerates the users, plugins and themes of WordPress sites and
+// checks their XML-RPC interface, without logging in or sending pingbacks
+type Scanner struct {
+	options ScanOptions
+	client  *http.Client
+}
+
+// NewScanner creates a WordPress scanner
+func NewScanner(options ScanOptions) *Scanner {
+	if options.Timeout < 1 {
+		options.Timeout = DefaultScanOptions().Timeout
+	}
+	return &Scanner{
+		options: options,
+		client:  httpclient.New("wpscan", time.Duration(options.Timeout)*time.Second, nil),
+	}
+}
+
+// Patterns of the pages and files read
+var (
+	generatorRegex     = regexp.MustCompile(`(?i)<meta[^>]+name=["']generator["'][^>]+content=["']WordPress\s*([0-9][0-9.]*)`)
+	feedGeneratorRegex = regexp.MustCompile(`<generator>https?://wordpress\.org/\?v=([0-9][0-9.]*)</generator>`)
+	componentRegex     = regexp.MustCompile(`/wp-content/(plugins|themes)/([A-Za-z0-9_.-]+)/[^"'\s?]*(?:\?ver=([0-9][0-9A-Za-z.-]*))?`)
+	readmeNameRegex    = regexp.MustCompile(`(?m)^===\s*(.+?)\s*===\s*$`)
+	stableTagRegex     = regexp.MustCompile(`(?mi)^Stable tag:\s*([0-9][0-9A-Za-z.-]*)`)
+	changelogRegex     = regexp.MustCompile(`(?s)==\s*Changelog\s*==.*?\n=+\s*v?([0-9][0-9A-Za-z.-]*)`)
+	themeNameRegex     = regexp.MustCompile(`(?mi)^\s*\*?\s*Theme Name:\s*(.+?)\s*$`)
+	themeVersionRegex  = regexp.MustCompile(`(?mi)^\s*\*?\s*Version:\s*([0-9][0-9A-Za-z.-]*)`)
+	authorPathRegex    = regexp.MustCompile(`/author/([^/?#]+)`)
+	authorClassRegex   = regexp.MustCompile(`\bauthor-([A-Za-z0-9_.-]+)\s+author-([0-9]+)\b`)
+	xmlrpcMethodRegex  = regexp.MustCompile(`<string>([^<]+)</string>`)
+)
+
+// Scan enumerates the WordPress site at target, a URL or host. A site not
+// running WordPress is reported as not detected, without error. Every
+// problem is reported as a finding.
+func (s *Scanner) Scan(ctx context.Context, target string) Result {
+	if !strings.Contains(target, "://") {
+		target = "https://" + target
+	}
+	result := Result{URL: target}
+	eventbus.Publish(eventbus.Event{Type: eventbus.ScanStarted, Tool: "wpscan", Target: target})
+
+	resp, body, err := s.get(ctx, target)
+	if err != nil {
+		result.Error = err.Error()
+		eventbus.ScanFinished("wpscan", target, ctx.Err() != nil, err, nil)
+		return result
+	}
+	// Follow the redirects of the home page, such as http to https
+	final := *resp.Request.URL
+	final.RawQuery, final.Fragment = "", ""
+	base := strings.TrimSuffix(final.String(), "/")
+	result.URL = base + "/"
+
+	if !detect(resp, body) {
+		eventbus.ScanFinished("wpscan", target, false, nil, map[string]int{"problems": 0})
+		return result
+	}
+	result.Detected = true
+
+	result.Core = s.coreVersion(ctx, base, body)
+	result.Plugins, result.Themes = s.components(ctx, base, body)
+	result.Users = s.enumerateUsers(ctx, base, &result)
+	s.checkXMLRPC(ctx, base, &result)
+
+	for _, problem := range result.Problems {
+		siem.Emit(siem.Finding{
+			Tool:        "wpscan",
+			Target:      result.URL,
+			Category:    "WORDPRESS",
+			Name:        fmt.Sprintf("WordPress %s problem on %s", problem.Check, result.URL),
+			Severity:    model.Severity(problem.Severity),
+			Description: problem.Description,
+		})
+	}
+
+	eventbus.ScanFinished("wpscan", target, ctx.Err() != nil, nil, map[string]int{
+		"users":    len(result.Users),
+		"plugins":  len(result.Plugins),
+		"themes":   len(result.Themes),
+		"problems": len(result.Problems),
+	})
+	return result
+}
+
+// get requests a page and returns the response with its body
+func (s *Scanner) get(ctx context.Context, rawURL string) (*http.Response, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.do(req)
+}
+
+// do sends a request and reads its response body
+func (s *Scanner) do(req *http.Request) (*http.Response, string, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, "", err
+	}
+	return resp, string(data), nil
+}
+
+// detect reports whether a home page is served by WordPress: it links
+// wp-content or wp-includes assets, announces the REST API or names
+// WordPress as its generator
+func detect(resp *http.Response, body string) bool {
+	if strings.Contains(body, "/wp-content/") || strings.Contains(body, "/wp-includes/") {
+		return true
+	}
+	for _, link := range resp.Header.Values("Link") {
+		if strings.Contains(link, "api.w.org") {
+			return true
+		}
+	}
+	return generatorRegex.MatchString(body)
+}
+
+// coreVersion reads the WordPress version from the generator meta tag of
+// the home page, or from the generator of the feed when the tag is removed
+func (s *Scanner) coreVersion(ctx context.Context, base, home string) Component {
+	core := Component{Slug: "wordpress", Name: "WordPress"}
+	if match := generatorRegex.FindStringSubmatch(home); match != nil {
+		core.Version, core.VersionSource = strings.TrimSuffix(match[1], "."), "generator meta"
+		return core
+	}
+	if resp, body, err := s.get(ctx, base+"/feed/"); err == nil && resp.StatusCode == http.StatusOK {
+		if match := feedGeneratorRegex.FindStringSubmatch(body); match != nil {
+			core.Version, core.VersionSource = strings.TrimSuffix(match[1], "."), "feed generator"
+		}
+	}
+	return core
+}
+
+// components finds the plugins and themes whose assets the home page
+// references, and the popular plugins when probing, and reads their
+// versions
+func (s *Scanner) components(ctx context.Context, base, home string) (plugins, themes []Component) {
+	assetVersions := map[string]map[string]string{"plugins": {}, "themes": {}}
+	for _, match := range componentRegex.FindAllStringSubmatch(home, -1) {
+		kind, slug := match[1], strings.ToLower(match[2])
+		if _, seen := assetVersions[kind][slug]; !seen || assetVersions[kind][slug] == "" {
+			assetVersions[kind][slug] = match[3]
+		}
+	}
+	if s.options.ProbePlugins {
+		for _, slug := range popularPlugins {
+			if _, seen := assetVersions["plugins"][slug]; !seen {
+				assetVersions["plugins"][slug] = ""
+			}
+		}
+	}
+
+	for _, kind := range []string{"plugins", "themes"} {
+		slugs := make([]string, 0, len(assetVersions[kind]))
+		for slug := range assetVersions[kind] {
+			slugs = append(slugs, slug)
+		}
+		sort.Strings(slugs)
+
+		for _, slug := range slugs {
+			var component Component
+			var found bool
+			if kind == "plugins" {
+				component, found = s.pluginVersion(ctx, base, slug)
+			} else {
+				component, found = s.themeVersion(ctx, base, slug)
+			}
+			// Probed plugins only count when their readme exists
+			referenced := strings.Contains(home, "/wp-content/"+kind+"/"+slug+"/")
+			if !found && !referenced {
+				continue
+			}
+			if component.Version == "" && assetVersions[kind][slug] != "" {
+				component.Version, component.VersionSource = assetVersions[kind][slug], "asset version"
+			}
+			if kind == "plugins" {
+				plugins = append(plugins, component)
+			} else {
+				themes = append(themes, component)
+			}
+		}
+	}
+	return plugins, themes
+}
+
+// pluginVersion reads the name and version of a plugin from its readme:
+// the stable tag, or the latest changelog entry when the tag is trunk
+func (s *Scanner) pluginVersion(ctx context.Context, base, slug string) (Component, bool) {
+	component := Component{Slug: slug}
+	resp, body, err := s.get(ctx, base+"/wp-content/plugins/"+slug+"/readme.txt")
+	if err != nil || resp.StatusCode != http.StatusOK || !readmeNameRegex.MatchString(body) {
+		return component, false
+	}
+	component.Name = readmeNameRegex.FindStringSubmatch(body)[1]
+	if match := stableTagRegex.FindStringSubmatch(body); match != nil {
+		component.Version, component.VersionSource = match[1], "readme stable tag"
+	} else if match := changelogRegex.FindStringSubmatch(body); match != nil {
+		component.Version, component.VersionSource = match[1], "readme changelog"
+	}
+	return component, true
+}
+
+// themeVersion reads the name and version of a theme from the header of
+// its stylesheet
+func (s *Scanner) themeVersion(ctx context.Context, base, slug string) (Component, bool) {
+	component := Component{Slug: slug}
+	resp, body, err := s.get(ctx, base+"/wp-content/themes/"+slug+"/style.css")
+	if err != nil || resp.StatusCode != http.StatusOK || !themeNameRegex.MatchString(body) {
+		return component, false
+	}
+	component.Name = themeNameRegex.FindStringSubmatch(body)[1]
+	if match := themeVersionRegex.FindStringSubmatch(body); match != nil {
+		component.Version, component.VersionSource = match[1], "style.css"
+	}
+	return component, true
+}
+
+// enumerateUsers lists the users of the REST API, through the plain
+// rest_route parameter when the pretty /wp-json/ path is blocked, then
+// tries the author archives, which redirect to or render the author slug
+func (s *Scanner) enumerateUsers(ctx context.Context, base string, result *Result) []User {
+	users := []User{}
+	seen := map[string]bool{}
+	for _, path := range []string{"/wp-json/wp/v2/users?per_page=100", "/?rest_route=/wp/v2/users&per_page=100"} {
+		resp, body, err := s.get(ctx, base+path)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			continue
+		}
+		var listed []struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+			Slug string `json:"slug"`
+		}
+		if json.Unmarshal([]byte(body), &listed) != nil || len(listed) == 0 {
+			continue
+		}
+		for _, user := range listed {
+			if user.Slug != "" && !seen[user.Slug] {
+				seen[user.Slug] = true
+				users = append(users, User{ID: user.ID, Slug: user.Slug, Name: user.Name, Source: "rest"})
+			}
+		}
+		result.Problems = append(result.Problems, Problem{
+			Check:       "users",
+			Severity:    SeverityMedium,
+			Description: fmt.Sprintf("The REST API lists %d user(s) without authentication at %s: %s", len(users), path, userSlugs(users)),
+		})
+		break
+	}
+
+	archived := []User{}
+	for id := 1; id <= s.options.MaxAuthorID && ctx.Err() == nil; id++ {
+		resp, body, err := s.get(ctx, base+"/?author="+strconv.Itoa(id))
+		if err != nil || resp.StatusCode != http.StatusOK {
+			continue
+		}
+		slug := ""
+		if match := authorPathRegex.FindStringSubmatch(resp.Request.URL.Path); match != nil {
+			slug, _ = url.PathUnescape(match[1])
+		} else if match := authorClassRegex.FindStringSubmatch(body); match != nil && match[2] == strconv.Itoa(id) {
+			slug = match[1]
+		}
+		if slug == "" {
+			continue
+		}
+		archived = append(archived, User{ID: id, Slug: slug, Source: "author archive"})
+		if !seen[slug] {
+			seen[slug] = true
+			users = append(users, archived[len(archived)-1])
+		}
+	}
+	if len(archived) > 0 {
+		result.Problems = append(result.Problems, Problem{
+			Check:       "authors",
+			Severity:    SeverityMedium,
+			Description: fmt.Sprintf("Author archives (?author=N) reveal %d user slug(s): %s", len(archived), userSlugs(archived)),
+		})
+	}
+	return users
+}
+
+// userSlugs joins the slugs of users
+func userSlugs(users []User) string {
+	slugs := make([]string, len(users))
+	for i, user := range users {
+		slugs[i] = user.Slug
+	}
+	return strings.Join(slugs, ", ")
+}
+
+// xmlrpcListMethods asks the XML-RPC interface for its methods
+const xmlrpcListMethods = `<?xml version="1.0"?><methodCall><methodName>system.listMethods</methodName><params></params></methodCall>`
+
+// checkXMLRPC lists the methods of xmlrpc.php. The interface accepts
+// password guesses outside the login form, system.multicall packs hundreds
+// of them in one request and pingback.ping makes the site request URLs of
+// the caller's choice. The methods themselves are never called.
+func (s *Scanner) checkXMLRPC(ctx context.Context, base string, result *Result) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/xmlrpc.php", strings.NewReader(xmlrpcListMethods))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "text/xml")
+	resp, body, err := s.do(req)
+	if err != nil || resp.StatusCode != http.StatusOK || !strings.Contains(body, "<methodResponse>") {
+		return
+	}
+
+	result.XMLRPC.Enabled = true
+	methods := map[string]bool{}
+	for _, match := range xmlrpcMethodRegex.FindAllStringSubmatch(body, -1) {
+		methods[match[1]] = true
+		result.XMLRPC.Methods = append(result.XMLRPC.Methods, match[1])
+	}
+
+	result.Problems = append(result.Problems, Problem{
+		Check:       "xmlrpc",
+		Severity:    SeverityLow,
+		Description: fmt.Sprintf("XML-RPC is enabled at /xmlrpc.php with %d method(s), accepting password guesses outside the login form and its protections", len(result.XMLRPC.Methods)),
+	})
+	if methods["system.multicall"] {
+		result.Problems = append(result.Problems, Problem{
+			Check:       "multicall",
+			Severity:    SeverityMedium,
+			Description: "system.multicall is available, letting one request try hundreds of passwords and evade login rate limits",
+		})
+	}
+	if methods["pingback.ping"] {
+		result.Problems = append(result.Problems, Problem{
+			Check:       "pingback",
+			Severity:    SeverityMedium,
+			Description: "pingback.ping is available, letting anyone make the site request arbitrary URLs (SSRF, port scanning, DDoS reflection)",
+		})
+	}
+}
+
+// Correlate looks up the known vulnerabilities of the core, plugins and
+// themes with a version. Plugins and themes are matched by name, or by
+// slug as CPE product with a wordpress target software. The matches are
+// published as findings by the correlator.
+func (r *Result) Correlate(correlator *osint.Correlator) error {
+	host := r.URL
+	if parsed, err := url.Parse(r.URL); err == nil && parsed.Hostname() != "" {
+		host = parsed.Hostname()
+	}
+
+	components := []*Component{&r.Core}
+	for i := range r.Plugins {
+		components = append(components, &r.Plugins[i])
+	}
+	for i := range r.Themes {
+		components = append(components, &r.Themes[i])
+	}
+
+	for _, component := range components {
+		if component.Version == "" {
+			continue // Without a version, every past vulnerability would match
+		}
+		name := component.Name
+		if name == "" {
+			name = component.Slug
+		}
+		vendor := "*"
+		if component == &r.Core {
+			vendor = "wordpress"
+		}
+		scan := osint.NewServerScanResult(host, &osint.ServerInfo{
+			Hostname:       host,
+			ProductName:    name,
+			ProductVersion: component.Version,
+			CPE:            fmt.Sprintf("cpe:2.3:a:%s:%s:%s:*:*:*:*:wordpress:*:*", vendor, component.Slug, component.Version),
+		})
+		if err := correlator.CorrelateScanResults(scan); err != nil {
+			return fmt.Errorf("failed to correlate %s %s: %v", name, component.Version, err)
+		}
+		component.Vulnerabilities = nil
+		for _, vuln := range scan.Vulnerabilities {
+			component.Vulnerabilities = append(component.Vulnerabilities, Vulnerability{
+				ID:         vuln.ID,
+				Title:      vuln.Title,
+				Severity:   string(vuln.Severity),
+				CVSS:       vuln.CVSS,
+				Confidence: scan.ConfidenceScore[vuln.ID],
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package wordpress
+
+import (
+	"GopherStrike/pkg/tools/osint"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSite serves a WordPress site leaking its users and version details
+func fakeSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Query().Get("author") {
+		case "":
+		case "1":
+			http.Redirect(w, r, "/author/admin/", http.StatusMovedPermanently)
+			return
+		case "3":
+			fmt.Fprint(w, `<body class="archive author author-editor author-3">`)
+			return
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("rest_route") != "" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `<html><head><meta name="generator" content="WordPress 6.1.1" />
+<link rel="stylesheet" href="/wp-content/themes/blogger/style.css?ver=6.1.1">
+<script src="/wp-content/plugins/contact-form-7/includes/js/index.js?ver=5.3.1"></script>
+<script src="/wp-content/plugins/slider/js/slider.js?ver=2.0"></script>
+</head><body>Blog</body></html>`)
+	})
+	mux.HandleFunc("/author/admin/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<body class="archive author">`)
+	})
+	mux.HandleFunc("/wp-content/plugins/contact-form-7/readme.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "=== Contact Form 7 ===\nContributors: takayukister\nStable tag: 5.3.2\n")
+	})
+	mux.HandleFunc("/wp-content/themes/blogger/style.css", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "/*\nTheme Name: Blogger\nVersion: 1.4\n*/\n")
+	})
+	mux.HandleFunc("/wp-json/wp/v2/users", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"Site Admin","slug":"admin"}]`)
+	})
+	mux.HandleFunc("/xmlrpc.php", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || !strings.Contains(string(body), "system.listMethods") {
+			http.Error(w, "XML-RPC server accepts POST requests only.", http.StatusMethodNotAllowed)
+			return
+		}
+		fmt.Fprint(w, `<?xml version="1.0"?><methodResponse><params><param><value><array><data>
+<value><string>system.multicall</string></value><value><string>pingback.ping</string></value>
+<value><string>wp.getUsersBlogs</string></value></data></array></value></param></params></methodResponse>`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestScan(t *testing.T) {
+	server := fakeSite(t)
+	result := NewScanner(ScanOptions{Timeout: 5, MaxAuthorID: 4}).Scan(context.Background(), server.URL)
+	if result.Error != "" || !result.Detected {
+		t.Fatalf("Scan() = %+v, want a detected site", result)
+	}
+
+	if result.Core.Version != "6.1.1" || result.Core.VersionSource != "generator meta" {
+		t.Errorf("core = %+v, want 6.1.1 from the generator meta", result.Core)
+	}
+	wantPlugins := []Component{
+		{Slug: "contact-form-7", Name: "Contact Form 7", Version: "5.3.2", VersionSource: "readme stable tag"},
+		{Slug: "slider", Version: "2.0", VersionSource: "asset version"},
+	}
+	if !reflect.DeepEqual(result.Plugins, wantPlugins) {
+		t.Errorf("plugins = %+v, want %+v", result.Plugins, wantPlugins)
+	}
+	wantThemes := []Component{{Slug: "blogger", Name: "Blogger", Version: "1.4", VersionSource: "style.css"}}
+	if !reflect.DeepEqual(result.Themes, wantThemes) {
+		t.Errorf("themes = %+v, want %+v", result.Themes, wantThemes)
+	}
+
+	wantUsers := []User{
+		{ID: 1, Slug: "admin", Name: "Site Admin", Source: "rest"},
+		{ID: 3, Slug: "editor", Source: "author archive"},
+	}
+	if !reflect.DeepEqual(result.Users, wantUsers) {
+		t.Errorf("users = %+v, want %+v", result.Users, wantUsers)
+	}
+
+	checks := []string{}
+	for _, problem := range result.Problems {
+		checks = append(checks, problem.Check)
+	}
+	if want := []string{"users", "authors", "xmlrpc", "multicall", "pingback"}; !reflect.DeepEqual(checks, want) {
+		t.Errorf("problems = %v, want %v", checks, want)
+	}
+	if !result.XMLRPC.Enabled || len(result.XMLRPC.Methods) != 3 {
+		t.Errorf("XML-RPC = %+v, want 3 methods", result.XMLRPC)
+	}
+}
+
+func TestScanNotWordPress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body>Static site</body></html>")
+	}))
+	defer server.Close()
+
+	result := NewScanner(ScanOptions{Timeout: 5}).Scan(context.Background(), server.URL)
+	if result.Error != "" || result.Detected || len(result.Problems) != 0 {
+		t.Errorf("Scan() = %+v, want an undetected site without problems", result)
+	}
+}
+
+// stubConnector is a VulnDBConnector returning fixed results
+type stubConnector struct {
+	vulns []osint.Vulnerability
+}
+
+func (s stubConnector) Search(query osint.SearchQuery) ([]osint.Vulnerability, error) {
+	return s.vulns, nil
+}
+func (s stubConnector) GetByID(id string) (*osint.Vulnerability, error) {
+	return nil, fmt.Errorf("vulnerability not found: %s", id)
+}
+func (s stubConnector) GetUpdates(since time.Time) ([]osint.Vulnerability, error) {
+	return s.vulns, nil
+}
+
+func TestCorrelate(t *testing.T) {
+	vuln := osint.Vulnerability{
+		ID:       "CVE-2020-35489",
+		Title:    "Contact Form 7 unrestricted file upload",
+		Severity: "critical",
+		CVSS:     9.8,
+		CPEs: []osint.CPEMatch{{
+			Criteria:            "cpe:2.3:a:rocklobster:contact_form_7:*:*:*:*:*:wordpress:*:*",
+			VersionEndExcluding: "5.3.2",
+		}},
+	}
+	correlator := osint.NewCorrelator(stubConnector{vulns: []osint.Vulnerability{vuln}})
+
+	result := Result{
+		URL:     "https://blog.example.com/",
+		Core:    Component{Slug: "wordpress", Name: "WordPress"},
+		Plugins: []Component{{Slug: "contact-form-7", Name: "Contact Form 7", Version: "5.3.1"}},
+		Themes:  []Component{{Slug: "blogger", Name: "Blogger"}},
+	}
+	if err := result.Correlate(correlator); err != nil {
+		t.Fatalf("Correlate() error = %v", err)
+	}
+	vulns := result.Plugins[0].Vulnerabilities
+	if len(vulns) != 1 || vulns[0].ID != vuln.ID || vulns[0].Confidence < osint.DefaultConfidenceThreshold {
+		t.Errorf("plugin vulnerabilities = %+v, want %s", vulns, vuln.ID)
+	}
+	if len(result.Core.Vulnerabilities) != 0 || len(result.Themes[0].Vulnerabilities) != 0 {
+		t.Error("components without a version were correlated")
+	}
+
+	// The fixed version doesn't match by CPE
+	result.Plugins[0].Version = "5.3.2"
+	if err := result.Correlate(correlator); err != nil {
+		t.Fatalf("Correlate() error = %v", err)
+	}
+	if vulns := result.Plugins[0].Vulnerabilities; len(vulns) != 0 {
+		t.Errorf("fixed plugin vulnerabilities = %+v, want none", vulns)
+	}
+}
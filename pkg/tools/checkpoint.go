@@ -0,0 +1,127 @@
+// checkpoint.go
+package tools
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultChunkSize is the number of words of a subdomain scan checked
+// between two checkpoints
+const DefaultChunkSize = 10000
+
+// ChunkStats are the statistics of one chunk of a subdomain scan
+type ChunkStats struct {
+	Index   int     `json:"index"`
+	Words   int     `json:"words"`
+	Checked int     `json:"checked"` // Words left after removing duplicates
+	Active  int     `json:"active"`
+	Errors  int     `json:"errors"` // Lookups that failed for another reason than a missing name
+	Seconds float64 `json:"seconds"`
+	Rate    float64 `json:"rate"` // Checks per second
+}
+
+// Checkpoint records the progress of a subdomain scan after each chunk of
+// its wordlist, so that a scan that was interrupted resumes with the chunk
+// it was in rather than from the start
+type Checkpoint struct {
+	Domain       string            `json:"domain"`
+	Wordlist     string            `json:"wordlist"`
+	WordlistSize int               `json:"wordlist_size"`
+	ChunkSize    int               `json:"chunk_size"`
+	TotalChunks  int               `json:"total_chunks"`
+	NextChunk    int               `json:"next_chunk"`
+	Checked      int               `json:"checked"`
+	TimeStamp    string            `json:"time_stamp"` // Of the scan, naming its result files
+	Stream       string            `json:"stream,omitempty"`
+	Active       []SubdomainResult `json:"active"`
+	Chunks       []ChunkStats      `json:"chunks"`
+	Completed    bool              `json:"completed"`
+	Updated      time.Time         `json:"updated"`
+
+	path string
+}
+
+// checkpointName returns the artifact name of the checkpoint of a scan
+func checkpointName(timeStamp string) string {
+	return fmt.Sprintf("subdomains_%s.checkpoint.json", timeStamp)
+}
+
+// Path returns the file the checkpoint is saved to
+func (c *Checkpoint) Path() string {
+	return c.path
+}
+
+// Save writes the checkpoint. It is written to a temporary file first, so
+// that a scan killed while saving keeps its previous checkpoint.
+func (c *Checkpoint) Save() error {
+	if c.path == "" {
+		path, err := artifacts.Default().Path(c.Domain, artifacts.KindSubdomains, checkpointName(c.TimeStamp))
+		if err != nil {
+			return err
+		}
+		c.path = path
+	}
+
+	c.Updated = time.Now()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return err
+	}
+	// A copy compressed by a cleanup is superseded
+	os.Remove(c.path + ".gz")
+	return nil
+}
+
+// LoadCheckpoint reads the checkpoint of a scan, which a cleanup may have
+// compressed. A compressed checkpoint is saved again uncompressed.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := artifacts.ReadArtifact(path)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &Checkpoint{}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %v", path, err)
+	}
+	checkpoint.path = strings.TrimSuffix(path, ".gz")
+	return checkpoint, nil
+}
+
+// FindCheckpoint returns the latest checkpoint of an unfinished scan of a
+// domain with a wordlist, or nil if there is none
+func FindCheckpoint(domain, wordlistPath string) *Checkpoint {
+	dir, err := artifacts.Default().TargetDir(domain, artifacts.KindSubdomains)
+	if err != nil {
+		return nil
+	}
+	// Checkpoints of scans left for long may have been compressed by a cleanup
+	paths, _ := filepath.Glob(filepath.Join(dir, "subdomains_*.checkpoint.json"))
+	compressed, _ := filepath.Glob(filepath.Join(dir, "subdomains_*.checkpoint.json.gz"))
+	paths = append(paths, compressed...)
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+
+	for _, path := range paths {
+		checkpoint, err := LoadCheckpoint(path)
+		if err != nil || checkpoint.Completed {
+			continue
+		}
+		if strings.EqualFold(checkpoint.Domain, domain) && checkpoint.Wordlist == wordlistPath {
+			return checkpoint
+		}
+	}
+	return nil
+}
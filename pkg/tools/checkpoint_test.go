@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFindCompressedCheckpoint(t *testing.T) {
+	// The checkpoints go to the workspaces of the working directory
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Chdir() error = %v", err)
+	}
+	defer os.Chdir(wd)
+
+	checkpoint := &Checkpoint{Domain: "example.com", Wordlist: "words.txt", TotalChunks: 4, NextChunk: 2, TimeStamp: "20240816-143015"}
+	if err := checkpoint.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// A cleanup compresses the checkpoint of a scan left for a week
+	old := time.Now().Add(-7 * 24 * time.Hour)
+	os.Chtimes(checkpoint.Path(), old, old)
+	report, err := artifacts.Default().Cleanup(artifacts.RetentionPolicy{Compress: true, CompressAfter: time.Hour})
+	if err != nil || len(report.Compressed) != 1 {
+		t.Fatalf("Cleanup() = %+v, %v, want the checkpoint compressed", report, err)
+	}
+
+	found := FindCheckpoint("example.com", "words.txt")
+	if found == nil || found.NextChunk != 2 {
+		t.Fatalf("FindCheckpoint() = %+v, want the compressed checkpoint", found)
+	}
+	if found.Path() != checkpoint.Path() {
+		t.Errorf("Path() = %q, want %q", found.Path(), checkpoint.Path())
+	}
+
+	// Saved again, it replaces the compressed copy
+	found.NextChunk = 3
+	if err := found.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := os.Stat(checkpoint.Path() + ".gz"); !os.IsNotExist(err) {
+		t.Errorf("compressed checkpoint kept: %v", err)
+	}
+	if found := FindCheckpoint("example.com", "words.txt"); found == nil || found.NextChunk != 3 {
+		t.Errorf("FindCheckpoint() = %+v, want the saved checkpoint", found)
+	}
+}
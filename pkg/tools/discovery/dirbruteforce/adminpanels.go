@@ -0,0 +1,334 @@
+// pkg/tools/discovery/dirbruteforce/adminpanels.go
+package dirbruteforce
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/session"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// adminPaths are the paths checked in admin panel mode when no wordlist is
+// given: login pages, administration areas and the consoles of common
+// products
+var adminPaths = []string{
+	// Generic login pages and administration areas
+	"admin", "admin/login", "admin/login.php", "admin/index.php", "admin.php",
+	"administrator", "administration", "adminpanel", "admin-console", "admincp",
+	"_admin", "backend", "cms", "console", "control", "controlpanel", "cp",
+	"dashboard", "login", "login.php", "login.html", "login.aspx", "login.jsp",
+	"signin", "sign-in", "auth/login", "account/login", "accounts/login",
+	"manage", "management", "panel", "portal", "secure", "siteadmin",
+	"sysadmin", "staff", "moderator", "webadmin",
+
+	// Content management systems
+	"wp-admin", "wp-login.php", "administrator/index.php", "user/login",
+	"index.php/admin", "typo3", "umbraco", "bitrix/admin", "ghost",
+	"sitecore/login", "craft", "admin/config.php",
+
+	// Database and server administration
+	"phpmyadmin", "phpMyAdmin", "pma", "adminer", "adminer.php", "webmin",
+	"cpanel", "manager/html", "host-manager/html", "jmx-console",
+	"web-console", "console/login/LoginForm.jsp", "solr", "rabbitmq",
+
+	// DevOps and monitoring consoles
+	"jenkins/login", "grafana/login", "app/kibana", "sessions/new",
+	"users/sign_in", "portainer", "nagios", "zabbix", "prometheus",
+
+	// Remote access and network devices
+	"owa", "owa/auth/logon.aspx", "vpn/index.html", "remote/login", "webfig",
+	"cgi-bin/luci",
+}
+
+// adminProduct identifies a product from its login page
+type adminProduct struct {
+	Name   string
+	Marker *regexp.Regexp // Matches the page, the realm of a challenge or the Server header
+	Header string         // Header identifying the product, besides Marker
+}
+
+// adminProducts are the products recognized on the portals found
+var adminProducts = []adminProduct{
+	{"phpMyAdmin", regexp.MustCompile(`(?i)<title>[^<]*phpMyAdmin|name="pma_username"`), ""},
+	{"Adminer", regexp.MustCompile(`(?i)<title>[^<]*Adminer|adminer\.org`), ""},
+	{"WordPress", regexp.MustCompile(`(?i)wp-login\.php|name="wp-submit"`), ""},
+	{"Joomla", regexp.MustCompile(`(?i)content="Joomla!|option=com_login`), ""},
+	{"Drupal", regexp.MustCompile(`(?i)data-drupal-|Drupal\.settings|id="user-login-form"`), ""},
+	{"Magento", regexp.MustCompile(`(?i)Magento Admin|mage/adminhtml`), ""},
+	{"Apache Tomcat Manager", regexp.MustCompile(`(?i)Tomcat (?:Host )?Manager`), ""},
+	{"JBoss/WildFly", regexp.MustCompile(`(?i)ManagementRealm|JBoss|WildFly`), ""},
+	{"Oracle WebLogic", regexp.MustCompile(`(?i)WebLogic Server`), ""},
+	{"Jenkins", regexp.MustCompile(`(?i)<title>[^<]*Jenkins`), "X-Jenkins"},
+	{"GitLab", regexp.MustCompile(`(?i)content="GitLab"`), ""},
+	{"Grafana", regexp.MustCompile(`(?i)<title>\s*Grafana\s*</title>|grafanaBootData`), ""},
+	{"Kibana", regexp.MustCompile(`(?i)<title>\s*(?:Kibana|Elastic)\s*</title>`), "kbn-name"},
+	{"SonarQube", regexp.MustCompile(`(?i)<title>[^<]*SonarQube`), ""},
+	{"Portainer", regexp.MustCompile(`(?i)<title>\s*Portainer`), ""},
+	{"RabbitMQ Management", regexp.MustCompile(`(?i)<title>\s*RabbitMQ Management`), ""},
+	{"Webmin", regexp.MustCompile(`(?i)MiniServ|<title>[^<]*(?:Webmin|Usermin)`), ""},
+	{"cPanel", regexp.MustCompile(`(?i)cpsrvd|<title>[^<]*cPanel`), ""},
+	{"Outlook Web App", regexp.MustCompile(`(?i)Outlook Web App|/owa/auth/`), ""},
+	{"Citrix Gateway", regexp.MustCompile(`(?i)Citrix Gateway|NetScaler`), ""},
+	{"FortiGate", regexp.MustCompile(`(?i)ftnt-fortinet|FortiGate`), ""},
+	{"pfSense", regexp.MustCompile(`(?i)pfSense`), ""},
+	{"MikroTik RouterOS", regexp.MustCompile(`(?i)RouterOS|mikrotik`), ""},
+	{"OpenWrt LuCI", regexp.MustCompile(`(?i)LuCI|OpenWrt`), ""},
+}
+
+// AuthPortal is an authentication portal found in admin panel mode, with
+// what the default credential and brute force protection tests need to
+// submit credentials to it
+type AuthPortal struct {
+	URL           string `json:"url"`               // Page asking for credentials
+	Path          string `json:"path"`              // Path checked that led to it
+	Scheme        string `json:"scheme"`            // "form", or the HTTP authentication scheme such as "basic"
+	Realm         string `json:"realm,omitempty"`   // Realm of an HTTP authentication challenge
+	Product       string `json:"product,omitempty"` // Product recognized on the page, if any
+	LoginURL      string `json:"login_url,omitempty"`
+	Method        string `json:"method,omitempty"`
+	UsernameField string `json:"username_field,omitempty"`
+	PasswordField string `json:"password_field,omitempty"`
+}
+
+// signature identifies a portal regardless of the page showing it, so a
+// site serving its login page on every path is not reported once per path
+func (p AuthPortal) signature() string {
+	return strings.Join([]string{p.Scheme, p.Realm, p.Product, p.Method, p.UsernameField, p.PasswordField}, "|")
+}
+
+var (
+	formPattern     = regexp.MustCompile(`(?is)<form\b([^>]*)>(.*?)</form>`)
+	inputPattern    = regexp.MustCompile(`(?is)<input\b([^>]*)>`)
+	attrPattern     = regexp.MustCompile(`(?is)([a-z_:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	realmPattern    = regexp.MustCompile(`(?i)realm\s*=\s*"([^"]*)"`)
+	usernamePattern = regexp.MustCompile(`(?i)user|login|email|account|name`)
+)
+
+// maxPortalBody is how much of a page is read to find a login form
+const maxPortalBody = 512 * 1024
+
+// attributes returns the attributes of an HTML tag, lowercased names to values
+func attributes(tag string) map[string]string {
+	attrs := make(map[string]string)
+	for _, match := range attrPattern.FindAllStringSubmatch(tag, -1) {
+		attrs[strings.ToLower(match[1])] = match[2] + match[3] + match[4]
+	}
+	return attrs
+}
+
+// detectAuthPortal reports whether a response asks for credentials, with an
+// HTTP authentication challenge or a login form, and describes the portal.
+// pageURL is the URL of the response after redirects.
+func detectAuthPortal(pageURL string, status int, header http.Header, body string) (AuthPortal, bool) {
+	portal := AuthPortal{URL: pageURL}
+
+	challenge := header.Get("WWW-Authenticate")
+	switch {
+	case status == http.StatusUnauthorized && challenge != "":
+		portal.Scheme = strings.ToLower(strings.Fields(challenge)[0])
+		if match := realmPattern.FindStringSubmatch(challenge); match != nil {
+			portal.Realm = match[1]
+		}
+		portal.LoginURL = pageURL
+		portal.Method = "GET"
+	case status == http.StatusOK:
+		if !parseLoginForm(pageURL, body, &portal) {
+			return AuthPortal{}, false
+		}
+		portal.Scheme = "form"
+	default:
+		return AuthPortal{}, false
+	}
+
+	fingerprint := body + "\n" + portal.Realm + "\n" + header.Get("Server")
+	for _, product := range adminProducts {
+		if (product.Header != "" && header.Get(product.Header) != "") || product.Marker.MatchString(fingerprint) {
+			portal.Product = product.Name
+			break
+		}
+	}
+	return portal, true
+}
+
+// parseLoginForm fills in the form fields of a portal from the first form of
+// a page with a password field. Pages rendering a password field outside of
+// a form, such as single page applications, submit to the page itself.
+func parseLoginForm(pageURL, body string, portal *AuthPortal) bool {
+	for _, form := range formPattern.FindAllStringSubmatch(body, -1) {
+		if !loginFields(form[2], portal) {
+			continue
+		}
+		attrs := attributes(form[1])
+		portal.LoginURL = pageURL
+		if action := strings.TrimSpace(attrs["action"]); action != "" {
+			if base, err := url.Parse(pageURL); err == nil {
+				if ref, err := url.Parse(action); err == nil {
+					portal.LoginURL = base.ResolveReference(ref).String()
+				}
+			}
+		}
+		portal.Method = "GET"
+		if method := strings.ToUpper(strings.TrimSpace(attrs["method"])); method != "" {
+			portal.Method = method
+		}
+		return true
+	}
+
+	if loginFields(body, portal) {
+		portal.LoginURL = pageURL
+		portal.Method = "POST"
+		return true
+	}
+	return false
+}
+
+// loginFields finds the password field among the inputs of an HTML fragment
+// and the username field that goes with it: the visible text input named
+// like a user name, or else the last one before the password
+func loginFields(fragment string, portal *AuthPortal) bool {
+	username, fallback, password := "", "", ""
+	for _, input := range inputPattern.FindAllStringSubmatch(fragment, -1) {
+		attrs := attributes(input[1])
+		name := attrs["name"]
+		if name == "" {
+			name = attrs["id"]
+		}
+		switch strings.ToLower(attrs["type"]) {
+		case "password":
+			if password == "" {
+				password = name
+			}
+		case "", "text", "email":
+			if password != "" {
+				continue
+			}
+			if username == "" && usernamePattern.MatchString(name) {
+				username = name
+			}
+			fallback = name
+		}
+	}
+	if password == "" {
+		return false
+	}
+	if username == "" {
+		username = fallback
+	}
+	portal.UsernameField = username
+	portal.PasswordField = password
+	return true
+}
+
+// checkPortal reads the body of the response to a path in admin panel mode
+// and returns the authentication portal it shows, if any
+func checkPortal(resp *http.Response, path string) *AuthPortal {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxPortalBody))
+	portal, ok := detectAuthPortal(resp.Request.URL.String(), resp.StatusCode, resp.Header, string(body))
+	if !ok {
+		return nil
+	}
+	portal.Path = path
+	return &portal
+}
+
+// detectCatchAll requests a path that does not exist and records the portal
+// it shows, so that a site answering every path with its login page does not
+// report it once per path checked
+func (d *DirScanner) detectCatchAll(baseURL string) {
+	b := make([]byte, 4)
+	session.Read(b)
+	result := d.checkPath(baseURL, "gs"+hex.EncodeToString(b))
+	if result.portal != nil {
+		d.catchAll = result.portal.signature()
+		fmt.Printf("[!] Every path shows a login page; only other portals and %s are reported\n", result.portal.URL)
+	}
+}
+
+// addPortal records a portal the first time it is found and prints it
+func (d *DirScanner) addPortal(portal AuthPortal) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	// The login page every path shows is reported once, at its own URL
+	key := portal.signature()
+	if key == d.catchAll {
+		if d.catchAllSeen {
+			return
+		}
+		d.catchAllSeen = true
+	} else {
+		key = portal.LoginURL + "|" + key
+	}
+	if d.portalKeys[key] {
+		return
+	}
+	d.portalKeys[key] = true
+	d.portals = append(d.portals, portal)
+	fmt.Printf("[portal] %s\n", portal.Summary())
+}
+
+// Portals returns the authentication portals found in admin panel mode,
+// sorted by URL
+func (d *DirScanner) Portals() []AuthPortal {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	portals := append([]AuthPortal{}, d.portals...)
+	sort.Slice(portals, func(i, j int) bool { return portals[i].URL < portals[j].URL })
+	return portals
+}
+
+// Summary describes a portal on one line
+func (p AuthPortal) Summary() string {
+	summary := p.URL
+	if p.Product != "" {
+		summary += " - " + p.Product
+	}
+	if p.Scheme == "form" {
+		summary += fmt.Sprintf(" (login form: %s %s, fields %s/%s)", p.Method, p.LoginURL, p.UsernameField, p.PasswordField)
+	} else {
+		summary += fmt.Sprintf(" (HTTP %s authentication", p.Scheme)
+		if p.Realm != "" {
+			summary += fmt.Sprintf(", realm %q", p.Realm)
+		}
+		summary += ")"
+	}
+	return summary
+}
+
+// AdminPanelOptions switches options to admin panel discovery: the built-in
+// admin panel paths without extensions, with the authentication portals
+// found reported separately
+func AdminPanelOptions(options BruteforceOptions) BruteforceOptions {
+	options.AdminPanels = true
+	options.WordlistPath = ""
+	options.Extensions = []string{""}
+	options.FollowRedirects = true
+	return options
+}
+
+// reportPortals lists the authentication portals found and saves them to
+// the target's web artifact directory, where the login URL and form fields
+// of each are ready for the default credential and brute force protection
+// tests
+func reportPortals(targetURL string, portals []AuthPortal) error {
+	fmt.Printf("\n=== Authentication portals (%d) ===\n", len(portals))
+	if len(portals) == 0 {
+		return nil
+	}
+	for _, portal := range portals {
+		fmt.Println(portal.Summary())
+	}
+
+	filename, err := artifacts.Default().WriteJSON(targetURL, artifacts.KindWeb, artifacts.TimestampedName("auth-portals", "json"), portals)
+	if err != nil {
+		return fmt.Errorf("failed to save authentication portals: %v", err)
+	}
+	fmt.Printf("[+] Authentication portals saved to: %s\n", filename)
+	return nil
+}
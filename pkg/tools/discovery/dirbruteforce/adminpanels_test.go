@@ -0,0 +1,143 @@
+package dirbruteforce
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDetectAuthPortal(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		body   string
+		want   *AuthPortal
+	}{
+		{
+			"login form",
+			200, http.Header{},
+			`<form id="login" method="post" action="../session"><input type="hidden" name="csrf" value="x">
+			<input type="text" name="user_login"><input type="password" name="user_pass"></form>`,
+			&AuthPortal{Scheme: "form", LoginURL: "https://example.com/session", Method: "POST", UsernameField: "user_login", PasswordField: "user_pass"},
+		},
+		{
+			"search form before the login form",
+			200, http.Header{},
+			`<form action="/search"><input name="q"></form>
+			<form><input name="mail" type="email"><input name="pwd" type="password"></form>`,
+			&AuthPortal{Scheme: "form", LoginURL: "https://example.com/admin/login", Method: "GET", UsernameField: "mail", PasswordField: "pwd"},
+		},
+		{
+			"password field outside of a form",
+			200, http.Header{},
+			`<div id="app"><input id="username"><input id="password" type="password"></div>`,
+			&AuthPortal{Scheme: "form", LoginURL: "https://example.com/admin/login", Method: "POST", UsernameField: "username", PasswordField: "password"},
+		},
+		{
+			"product",
+			200, http.Header{},
+			`<title>phpMyAdmin</title><form method="post" action="index.php"><input name="pma_username"><input type="password" name="pma_password"></form>`,
+			&AuthPortal{Scheme: "form", Product: "phpMyAdmin", LoginURL: "https://example.com/admin/index.php", Method: "POST", UsernameField: "pma_username", PasswordField: "pma_password"},
+		},
+		{
+			"basic authentication",
+			401, http.Header{"Www-Authenticate": {`Basic realm="Tomcat Manager Application"`}},
+			"",
+			&AuthPortal{Scheme: "basic", Realm: "Tomcat Manager Application", Product: "Apache Tomcat Manager", LoginURL: "https://example.com/admin/login", Method: "GET"},
+		},
+		{"no password field", 200, http.Header{}, `<form><input name="q"></form>`, nil},
+		{"forbidden", 403, http.Header{}, `<input type="password" name="p">`, nil},
+		{"401 without a challenge", 401, http.Header{}, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := detectAuthPortal("https://example.com/admin/login", tt.status, tt.header, tt.body)
+			if tt.want == nil {
+				if ok {
+					t.Errorf("detectAuthPortal() = %+v, want no portal", got)
+				}
+				return
+			}
+			tt.want.URL = "https://example.com/admin/login"
+			if !ok || !reflect.DeepEqual(got, *tt.want) {
+				t.Errorf("detectAuthPortal() = %+v, %v, want %+v", got, ok, *tt.want)
+			}
+		})
+	}
+}
+
+func TestAdminPanelScan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/admin", "/administrator":
+			http.Redirect(w, r, "/admin/login", http.StatusFound)
+		case "/admin/login":
+			fmt.Fprint(w, `<form method="post"><input name="username"><input type="password" name="password"></form>`)
+		case "/manager/html":
+			w.Header().Set("WWW-Authenticate", `Basic realm="Tomcat Manager Application"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/dashboard":
+			fmt.Fprint(w, `<h1>Public dashboard</h1>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	options := AdminPanelOptions(DefaultBruteforceOptions())
+	scanner, err := NewDirScanner(options)
+	if err != nil {
+		t.Fatalf("NewDirScanner() error = %v", err)
+	}
+	results, err := scanner.Scan(server.URL)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(results) != 5 {
+		t.Errorf("got %d paths, want 5: %+v", len(results), results)
+	}
+
+	portals := scanner.Portals()
+	if len(portals) != 2 {
+		t.Fatalf("got %d portals, want 2: %+v", len(portals), portals)
+	}
+	if portals[0].URL != server.URL+"/admin/login" || portals[0].Scheme != "form" || portals[0].Method != "POST" {
+		t.Errorf("form portal = %+v", portals[0])
+	}
+	if portals[1].URL != server.URL+"/manager/html" || portals[1].Product != "Apache Tomcat Manager" {
+		t.Errorf("basic authentication portal = %+v", portals[1])
+	}
+}
+
+func TestAdminPanelScanCatchAll(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/phpmyadmin" {
+			fmt.Fprint(w, `<title>phpMyAdmin</title><form method="post"><input name="pma_username"><input type="password" name="pma_password"></form>`)
+			return
+		}
+		// A single page application answering every path with its login page
+		fmt.Fprint(w, `<div id="app"><input id="email" type="email"><input id="password" type="password"></div>`)
+	}))
+	defer server.Close()
+
+	scanner, err := NewDirScanner(AdminPanelOptions(DefaultBruteforceOptions()))
+	if err != nil {
+		t.Fatalf("NewDirScanner() error = %v", err)
+	}
+	if _, err := scanner.Scan(server.URL); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	// The login page shown on every path is reported once
+	products := make(map[string]int)
+	for _, portal := range scanner.Portals() {
+		products[portal.Product]++
+	}
+	if want := map[string]int{"": 1, "phpMyAdmin": 1}; !reflect.DeepEqual(products, want) {
+		t.Errorf("portal products = %v, want %v", products, want)
+	}
+}
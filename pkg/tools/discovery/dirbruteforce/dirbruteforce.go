@@ -2,7 +2,16 @@
 package dirbruteforce
 
 import (
-	"bufio"
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/estimate"
+	"GopherStrike/pkg/httpclient"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/preflight"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/term"
+	"GopherStrike/pkg/timing"
+	"GopherStrike/pkg/urlnorm"
+	"GopherStrike/pkg/wordlist"
 	"context"
 	"fmt"
 	"net/http"
@@ -11,6 +20,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -30,6 +40,24 @@ type PathResult struct {
 	ContentLength int64
 	ResponseTime  time.Duration
 	Interesting   bool
+
+	portal *AuthPortal // Authentication portal shown, in admin panel mode
+}
+
+// Asset converts a path result into the asset shared with the other tools
+func (r PathResult) Asset() model.Asset {
+	return model.Asset{
+		Type:   model.AssetPath,
+		Value:  r.URL,
+		Target: artifacts.NormalizeTarget(r.URL),
+		Tool:   "dirbruteforce",
+		Attributes: map[string]string{
+			"path":           r.Path,
+			"status":         strconv.Itoa(r.StatusCode),
+			"content_type":   r.ContentType,
+			"content_length": strconv.FormatInt(r.ContentLength, 10),
+		},
+	}
 }
 
 // BruteforceOptions contains options for directory bruteforcing
@@ -48,6 +76,9 @@ type BruteforceOptions struct {
 	WaitTime        int // Time to wait between requests in milliseconds
 	Cookies         []string
 	Headers         map[string]string
+	AdminPanels     bool   // Report the authentication portals found; without a wordlist, check the built-in admin panel paths
+	HistoryFile     string // Records the URLs tested, across runs; "" to keep no history
+	Differential    bool   // Skip the URLs of HistoryFile, tested in previous runs
 }
 
 // DefaultBruteforceOptions returns the default options
@@ -59,7 +90,7 @@ func DefaultBruteforceOptions() BruteforceOptions {
 		Timeout:         10,
 		FollowRedirects: true,
 		StatusCodes:     []int{200, 201, 202, 203, 204, 301, 302, 307, 401, 403},
-		OutputFile:      "logs/discovery/directories.txt",
+		OutputFile:      "", // Defaults to the target's artifact directory
 		UserAgent:       "GopherStrike DirBruteForce/1.0",
 		ExcludeLength:   []int64{},
 		Recursive:       false,
@@ -70,22 +101,65 @@ func DefaultBruteforceOptions() BruteforceOptions {
 	}
 }
 
+// PresetDescriptions tells what each scan preset does
+var PresetDescriptions = map[string]string{
+	presets.Quick:  "Directories only, 30 threads and a 5 second timeout",
+	presets.Normal: "Directories and .html, .php, .js and .txt files",
+	presets.Deep:   "Also backups, archives, configuration files and server-side scripts",
+}
+
+// PresetBruteforceOptions returns the bruteforce options of a preset
+func PresetBruteforceOptions(preset string) (BruteforceOptions, error) {
+	preset, err := presets.Validate(preset)
+	if err != nil {
+		return BruteforceOptions{}, err
+	}
+
+	options := DefaultBruteforceOptions()
+	switch preset {
+	case presets.Quick:
+		options.Extensions = []string{""}
+		options.Threads = 30
+		options.Timeout = 5
+	case presets.Deep:
+		options.Extensions = append(options.Extensions,
+			".bak", ".old", ".zip", ".tar.gz", ".sql", ".json", ".xml", ".conf", ".env", ".asp", ".aspx", ".jsp")
+		options.Timeout = 15
+	}
+
+	profile := timing.Current()
+	options.Threads = profile.Threads(options.Threads)
+	options.Timeout = profile.TimeoutSeconds(options.Timeout)
+	if profile.Level > timing.Normal {
+		options.WaitTime = 0 // The HTTP client paces the requests of slow profiles
+	}
+	return options, nil
+}
+
 // DirScanner represents a directory scanner
 type DirScanner struct {
 	options     BruteforceOptions
 	results     []PathResult
 	client      *http.Client
-	wordlist    []string
+	wordlist    string // Resolved wordlist path
+	wordCount   int
 	statusCodes map[int]StatusCodeInfo
+	output      *artifacts.StreamWriter // Receives each result as it is found
+	history     *wordlist.History       // URLs tested in this and previous runs
+	skipped     atomic.Int64            // URLs skipped in differential mode
 	mutex       sync.Mutex
+
+	// Admin panel mode
+	portals      []AuthPortal
+	portalKeys   map[string]bool
+	catchAll     string // Signature of the login page shown on every path, if any
+	catchAllSeen bool
 }
 
 // NewDirScanner creates a new directory scanner
 func NewDirScanner(options BruteforceOptions) (*DirScanner, error) {
 	// Configure HTTP client
-	httpClient := &http.Client{
-		Timeout: time.Duration(options.Timeout) * time.Second,
-	}
+	httpClient := httpclient.New("dirbruteforce", time.Duration(options.Timeout)*time.Second, nil)
 
 	// Configure redirect policy
 	if !options.FollowRedirects {
@@ -94,10 +168,16 @@ func NewDirScanner(options BruteforceOptions) (*DirScanner, error) {
 		}
 	}
 
-	// Load wordlist
-	wordlist, err := loadWordlist(options.WordlistPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load wordlist: %v", err)
+	// Find the wordlist and count its words; they are read from disk during
+	// the scan. Admin panel mode has its own paths when no wordlist is given.
+	wordlistPath, wordCount := "", len(adminPaths)
+	if !options.AdminPanels || options.WordlistPath != "" {
+		wordlistPath = findWordlist(options.WordlistPath)
+		var err error
+		wordCount, err = wordlist.Count(wordlistPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load wordlist: %v", err)
+		}
 	}
 
 	// Initialize status code information
@@ -106,52 +186,61 @@ func NewDirScanner(options BruteforceOptions) (*DirScanner, error) {
 	return &DirScanner{
 		options:     options,
 		client:      httpClient,
-		wordlist:    wordlist,
+		wordlist:    wordlistPath,
+		wordCount:   wordCount,
 		results:     []PathResult{},
 		statusCodes: statusCodes,
 		mutex:       sync.Mutex{},
+		portalKeys:  make(map[string]bool),
 	}, nil
 }
 
-// loadWordlist loads a wordlist from a file
-func loadWordlist(path string) ([]string, error) {
-	// Check if file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		// Try relative paths if not found
-		altPaths := []string{
-			filepath.Join("wordlists", filepath.Base(path)),
-			filepath.Join("..", "wordlists", filepath.Base(path)),
-			filepath.Join("..", "..", "wordlists", filepath.Base(path)),
-		}
+// notFoundSize is the assumed size of the responses to missing paths, most
+// of those a bruteforce gets
+const notFoundSize = 2 << 10
 
-		for _, altPath := range altPaths {
-			if _, err := os.Stat(altPath); err == nil {
-				path = altPath
-				break
-			}
-		}
+// Estimate predicts the cost of scanning targets: a request per word and
+// extension, shared by the threads
+func (d *DirScanner) Estimate(targets int) estimate.Estimate {
+	e := estimate.Estimate{
+		Targets:      targets,
+		Requests:     int64(d.wordCount * len(d.options.Extensions)),
+		Workers:      d.options.Threads,
+		Latency:      estimate.DefaultLatency + time.Duration(d.options.WaitTime)*time.Millisecond,
+		ResponseSize: notFoundSize,
 	}
-
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
+	if d.options.AdminPanels {
+		e.Requests++ // The catch-all check
 	}
-	defer file.Close()
-
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line != "" && !strings.HasPrefix(line, "#") {
-			lines = append(lines, line)
-		}
+	if d.options.Differential {
+		e.Notes = append(e.Notes, "Differential mode skips the paths tested in previous runs, which is not counted")
 	}
+	return e
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+// findWordlist returns the path of a wordlist, looking in the wordlists
+// directories when it does not exist as given. Standard input and URLs are
+// returned as they are.
+func findWordlist(path string) string {
+	if !wordlist.IsFile(path) {
+		return path
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return path
 	}
 
-	return lines, nil
+	// Try relative paths if not found
+	altPaths := []string{
+		filepath.Join("wordlists", filepath.Base(path)),
+		filepath.Join("..", "wordlists", filepath.Base(path)),
+		filepath.Join("..", "..", "wordlists", filepath.Base(path)),
+	}
+	for _, altPath := range altPaths {
+		if _, err := os.Stat(altPath); err == nil {
+			return altPath
+		}
+	}
+	return path
 }
 
 // initStatusCodes initializes status code information
@@ -185,25 +274,58 @@ func (d *DirScanner) Scan(baseURL string) ([]PathResult, error) {
 
 	// Clear previous results
 	d.results = []PathResult{}
+	d.portals = []AuthPortal{}
+	d.portalKeys = make(map[string]bool)
+	d.catchAll, d.catchAllSeen = "", false
 
 	fmt.Printf("[+] Starting directory bruteforce on: %s\n", baseURL)
-	fmt.Printf("[+] Using wordlist: %s (%d words)\n", d.options.WordlistPath, len(d.wordlist))
+	if d.wordlist == "" {
+		fmt.Printf("[+] Using the built-in admin panel paths (%d words)\n", d.wordCount)
+	} else {
+		fmt.Printf("[+] Using wordlist: %s (%d words)\n", d.options.WordlistPath, d.wordCount)
+	}
+	if d.options.AdminPanels {
+		d.detectCatchAll(baseURL)
+	}
 	fmt.Printf("[+] Using %d threads and %d extensions\n", d.options.Threads, len(d.options.Extensions))
 
+	// Write results to the output file as they are found, so a crash
+	// part way through a long scan keeps them
+	if d.options.OutputFile != "" {
+		if err := d.createOutput(); err != nil {
+			return nil, fmt.Errorf("failed to create output file: %v", err)
+		}
+		defer d.closeOutput()
+		fmt.Printf("[+] Writing results to: %s\n", d.options.OutputFile)
+	}
+
+	// Record the URLs tested, so that differential runs skip them
+	d.skipped.Store(0)
+	if d.options.HistoryFile != "" {
+		history, err := wordlist.OpenHistory(d.options.HistoryFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the history of tested paths: %v", err)
+		}
+		d.history = history
+		defer func() {
+			history.Close()
+			d.history = nil
+		}()
+		if d.options.Differential {
+			fmt.Printf("[+] Differential mode: skipping the %d URLs tested in previous runs\n", history.Len())
+		}
+	}
+
 	// Create a context for cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Generate the paths to check
-	paths := d.generatePaths()
-	fmt.Printf("[+] Generated %d paths to check\n", len(paths))
-
-	// Create a channel for paths
-	pathCh := make(chan string, len(paths))
-	for _, path := range paths {
-		pathCh <- path
+	// Generate the paths to check as the wordlist is read
+	pathCh, wordsErr, err := d.generatePaths(ctx, baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load wordlist: %v", err)
 	}
-	close(pathCh)
+	fmt.Printf("[+] Checking up to %d paths\n", d.wordCount*len(d.options.Extensions))
 
 	// Create a wait group for goroutines
 	var wg sync.WaitGroup
@@ -223,10 +345,16 @@ func (d *DirScanner) Scan(baseURL string) ([]PathResult, error) {
 						time.Sleep(time.Duration(d.options.WaitTime) * time.Millisecond)
 					}
 
-					// Check the path
+					// Check the path; a path that got no response is tested again next time
 					result := d.checkPath(baseURL, path)
+					if d.history != nil && result.StatusCode != 0 {
+						d.history.Add(result.URL)
+					}
 					if d.isInterestingResult(result) {
 						d.addResult(result)
+						if result.portal != nil {
+							d.addPortal(*result.portal)
+						}
 
 						// Print the result
 						statusInfo, found := d.statusCodes[result.StatusCode]
@@ -235,24 +363,23 @@ func (d *DirScanner) Scan(baseURL string) ([]PathResult, error) {
 							statusCategory = statusInfo.Category
 						}
 
-						// Format the output based on the status category
-						var statusOutput string
+						// Color the status code based on its category
+						var statusColor term.Color
 						switch statusCategory {
 						case "success":
-							statusOutput = fmt.Sprintf("\033[32m%d\033[0m", result.StatusCode) // Green
+							statusColor = term.Green
 						case "redirect":
-							statusOutput = fmt.Sprintf("\033[33m%d\033[0m", result.StatusCode) // Yellow
+							statusColor = term.Yellow
 						case "clientError":
 							if result.StatusCode == 403 {
-								statusOutput = fmt.Sprintf("\033[35m%d\033[0m", result.StatusCode) // Purple for 403
+								statusColor = term.Magenta
 							} else {
-								statusOutput = fmt.Sprintf("\033[31m%d\033[0m", result.StatusCode) // Red
+								statusColor = term.Red
 							}
 						case "serverError":
-							statusOutput = fmt.Sprintf("\033[31;1m%d\033[0m", result.StatusCode) // Bright Red
-						default:
-							statusOutput = fmt.Sprintf("%d", result.StatusCode)
+							statusColor = term.BoldRed
 						}
+						statusOutput := term.Colorize(statusColor, strconv.Itoa(result.StatusCode))
 
 						fmt.Printf("[%s] %-50s %9d bytes   %6dms\n",
 							statusOutput,
@@ -267,51 +394,100 @@ func (d *DirScanner) Scan(baseURL string) ([]PathResult, error) {
 
 	// Wait for all goroutines to finish
 	wg.Wait()
-
-	// Save results
-	if d.options.OutputFile != "" {
-		err := d.saveResults()
-		if err != nil {
-			fmt.Printf("[!] Error saving results: %v\n", err)
-		}
+	if skipped := d.skipped.Load(); skipped > 0 {
+		fmt.Printf("[+] Skipped %d paths tested in previous runs\n", skipped)
 	}
 
+	if err := wordsErr(); err != nil {
+		return d.results, fmt.Errorf("failed to read wordlist: %v", err)
+	}
 	return d.results, nil
 }
 
-// generatePaths generates paths to check
-func (d *DirScanner) generatePaths() []string {
-	var paths []string
+// generatePaths streams the paths to check, combining each word of the
+// wordlist with the extensions as it is read. Paths that lead to a URL
+// already generated, such as duplicate words, are skipped, as are those
+// tested in previous runs in differential mode. The returned
+// function waits for the wordlist to be read and returns the error that
+// ended it, if any.
+func (d *DirScanner) generatePaths(ctx context.Context, baseURL string) (<-chan string, func() error, error) {
+	words, wordsErr, err := d.openWords(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	for _, word := range d.wordlist {
-		// Skip comments and empty lines
-		if strings.HasPrefix(word, "#") || word == "" {
-			continue
+	paths := make(chan string, d.options.Threads)
+	go func() {
+		defer close(paths)
+		generated := urlnorm.NewSet()
+		for word := range words {
+			for _, path := range wordPaths(word, d.options.Extensions) {
+				if !generated.Add(baseURL + path) {
+					continue
+				}
+				if d.options.Differential && d.history != nil && d.history.Tested(baseURL+path) {
+					d.skipped.Add(1)
+					continue
+				}
+				select {
+				case paths <- path:
+				case <-ctx.Done():
+					return
+				}
+			}
 		}
+	}()
+	return paths, wordsErr, nil
+}
 
-		// Add the word with each extension
-		for _, ext := range d.options.Extensions {
-			// Handle special case where extension is empty
-			if ext == "" {
-				paths = append(paths, word)
-				continue
-			}
+// openWords streams the words of the wordlist, or the built-in admin panel
+// paths in admin panel mode without a wordlist
+func (d *DirScanner) openWords(ctx context.Context) (<-chan string, func() error, error) {
+	if d.wordlist != "" {
+		stream, err := wordlist.Open(ctx, d.wordlist)
+		if err != nil {
+			return nil, nil, err
+		}
+		return stream.Words, stream.Err, nil
+	}
 
-			// If the word already has an extension that matches one of our extensions, don't add another
-			if hasExtension(word, d.options.Extensions) {
-				paths = append(paths, word)
-				continue
+	words := make(chan string)
+	go func() {
+		defer close(words)
+		for _, word := range adminPaths {
+			select {
+			case words <- word:
+			case <-ctx.Done():
+				return
 			}
+		}
+	}()
+	return words, func() error { return nil }, nil
+}
 
-			// Add extension (ensure it starts with a dot)
-			if !strings.HasPrefix(ext, ".") {
-				ext = "." + ext
-			}
+// wordPaths returns the paths to check for a word, one per extension
+func wordPaths(word string, extensions []string) []string {
+	paths := make([]string, 0, len(extensions))
+	for _, ext := range extensions {
+		// Handle special case where extension is empty
+		if ext == "" {
+			paths = append(paths, word)
+			continue
+		}
 
-			paths = append(paths, word+ext)
+		// If the word already has an extension that matches one of our extensions, don't add another
+		if hasExtension(word, extensions) {
+			paths = append(paths, word)
+			continue
+		}
+
+		// Add extension (ensure it starts with a dot)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
 		}
-	}
 
+		paths = append(paths, word+ext)
+	}
 	return paths
 }
 
@@ -333,18 +509,23 @@ func hasExtension(word string, extensions []string) bool {
 	return false
 }
 
-// checkPath checks a single path and returns the result
-func (d *DirScanner) checkPath(baseURL, path string) PathResult {
-	url := baseURL + path
-	result := PathResult{
-		Path: path,
-		URL:  url,
-	}
+// Preflight checks that the target can be scanned, sending a request for
+// the base URL with the scan's headers and cookies
+func (d *DirScanner) Preflight(ctx context.Context, baseURL string) preflight.Report {
+	return preflight.Check(ctx, baseURL, func() (*http.Response, error) {
+		req, err := d.newRequest(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		return d.client.Do(req.WithContext(ctx))
+	})
+}
 
-	// Create a request
+// newRequest returns a GET request for a URL with the scan's headers and cookies
+func (d *DirScanner) newRequest(url string) (*http.Request, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return result
+		return nil, err
 	}
 
 	// Set headers
@@ -363,6 +544,21 @@ func (d *DirScanner) checkPath(baseURL, path string) PathResult {
 			})
 		}
 	}
+	return req, nil
+}
+
+// checkPath checks a single path and returns the result
+func (d *DirScanner) checkPath(baseURL, path string) PathResult {
+	url := baseURL + path
+	result := PathResult{
+		Path: path,
+		URL:  url,
+	}
+
+	req, err := d.newRequest(url)
+	if err != nil {
+		return result
+	}
 
 	// Send the request and time it
 	startTime := time.Now()
@@ -379,6 +575,9 @@ func (d *DirScanner) checkPath(baseURL, path string) PathResult {
 	result.StatusCode = resp.StatusCode
 	result.ContentType = resp.Header.Get("Content-Type")
 	result.ContentLength = resp.ContentLength
+	if d.options.AdminPanels {
+		result.portal = checkPortal(resp, path)
+	}
 
 	return result
 }
@@ -408,49 +607,64 @@ func (d *DirScanner) isInterestingResult(result PathResult) bool {
 	return true
 }
 
-// addResult adds a result to the results slice
+// addResult adds a result to the results slice and writes it to the output file
 func (d *DirScanner) addResult(result PathResult) {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 	d.results = append(d.results, result)
+
+	if d.output != nil {
+		_, err := fmt.Fprintf(d.output, "%-6d  %-48s  %-13d  %dms\n",
+			result.StatusCode,
+			result.Path,
+			result.ContentLength,
+			result.ResponseTime.Milliseconds())
+		if err != nil {
+			fmt.Printf("[!] Error saving result: %v\n", err)
+		}
+	}
 }
 
-// saveResults saves the scan results to a file
-func (d *DirScanner) saveResults() error {
+// createOutput creates the output file and writes its header
+func (d *DirScanner) createOutput() error {
 	// Create directory if it doesn't exist
-	dir := d.options.OutputFile[:strings.LastIndex(d.options.OutputFile, "/")]
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(d.options.OutputFile), 0755); err != nil {
 		return err
 	}
 
-	// Create output file
-	file, err := os.Create(d.options.OutputFile)
+	output, err := artifacts.NewStreamWriter(d.options.OutputFile)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
 	// Write header
-	file.WriteString("# Directory Bruteforce Results\n")
-	file.WriteString("# Generated by GopherStrike DirBruteForce\n")
-	file.WriteString("# " + time.Now().Format(time.RFC3339) + "\n\n")
-
-	// Write results
-	file.WriteString("STATUS  PATH                                               SIZE           TIME\n")
-	file.WriteString("------  ------------------------------------------------  -------------  ------\n")
-
-	for _, result := range d.results {
-		file.WriteString(fmt.Sprintf("%-6d  %-48s  %-13d  %dms\n",
-			result.StatusCode,
-			result.Path,
-			result.ContentLength,
-			result.ResponseTime.Milliseconds()))
+	_, err = fmt.Fprintf(output, "# Directory Bruteforce Results\n"+
+		"# Generated by GopherStrike DirBruteForce\n"+
+		"# %s\n\n"+
+		"STATUS  PATH                                               SIZE           TIME\n"+
+		"------  ------------------------------------------------  -------------  ------\n",
+		time.Now().Format(time.RFC3339))
+	if err != nil {
+		output.Close()
+		return err
 	}
 
-	fmt.Printf("[+] Results saved to: %s\n", d.options.OutputFile)
+	d.mutex.Lock()
+	d.output = output
+	d.mutex.Unlock()
 	return nil
 }
 
+// closeOutput closes the output file once the scan is over
+func (d *DirScanner) closeOutput() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if err := d.output.Close(); err != nil {
+		fmt.Printf("[!] Error saving results: %v\n", err)
+	}
+	d.output = nil
+}
+
 // RunDirBruteforce is the main entry point for the directory bruteforcing tool
 func RunDirBruteforce() error {
 	fmt.Println("\n[+] Directory Bruteforcing Tool")
@@ -471,26 +685,58 @@ func RunDirBruteforce() error {
 	}
 
 	// Configure options
-	options := DefaultBruteforceOptions()
+	options, err := PresetBruteforceOptions(presets.Prompt(nil, PresetDescriptions))
+	if err != nil {
+		return err
+	}
+
+	// Ask for admin panel mode
+	fmt.Print("[?] Admin panel discovery only (login pages and consoles, reports the authentication portals)? (y/N): ")
+	var adminInput string
+	fmt.Scanln(&adminInput)
+	if strings.HasPrefix(strings.ToLower(adminInput), "y") {
+		options = AdminPanelOptions(options)
+	}
 
 	// Ask for wordlist
-	fmt.Printf("[?] Enter wordlist path (default: %s): ", options.WordlistPath)
+	defaultWordlist := options.WordlistPath
+	if options.AdminPanels {
+		defaultWordlist = "built-in admin panel paths"
+	}
+	fmt.Printf("[?] Enter wordlist path (default: %s): ", defaultWordlist)
 	var wordlistPath string
 	fmt.Scanln(&wordlistPath)
 	if wordlistPath != "" {
 		options.WordlistPath = wordlistPath
 	}
 
-	// Ask for extensions
-	fmt.Printf("[?] Enter file extensions to check (comma-separated, default: %s): ", strings.Join(options.Extensions, ","))
-	var extensionsInput string
-	fmt.Scanln(&extensionsInput)
-	if extensionsInput != "" {
-		options.Extensions = strings.Split(extensionsInput, ",")
-		// Trim spaces
-		for i, ext := range options.Extensions {
-			options.Extensions[i] = strings.TrimSpace(ext)
+	// Ask for extensions; admin panel paths carry theirs
+	if !options.AdminPanels {
+		fmt.Printf("[?] Enter file extensions to check (comma-separated, default: %s): ", strings.Join(options.Extensions, ","))
+		var extensionsInput string
+		fmt.Scanln(&extensionsInput)
+		if extensionsInput != "" {
+			options.Extensions = strings.Split(extensionsInput, ",")
+			// Trim spaces
+			for i, ext := range options.Extensions {
+				options.Extensions[i] = strings.TrimSpace(ext)
+			}
+		}
+	}
+
+	// Offer to skip the paths tested against the target in previous runs
+	options.HistoryFile, err = artifacts.Default().Path(targetURL, artifacts.KindWeb, "tested-paths.txt")
+	if err != nil {
+		return err
+	}
+	if history, err := wordlist.OpenHistory(options.HistoryFile); err == nil {
+		if tested := history.Len(); tested > 0 {
+			fmt.Printf("[?] %d URLs of %s were tested in previous runs. Only test the new ones? (y/N): ", tested, targetURL)
+			var differential string
+			fmt.Scanln(&differential)
+			options.Differential = strings.HasPrefix(strings.ToLower(differential), "y")
 		}
+		history.Close()
 	}
 
 	// Ask for threads
@@ -504,11 +750,24 @@ func RunDirBruteforce() error {
 		}
 	}
 
+	// Default to the target's web artifact directory
+	if options.OutputFile == "" {
+		defaultOutput, err := artifacts.Default().Path(targetURL, artifacts.KindWeb, artifacts.TimestampedName("directories", "txt"))
+		if err != nil {
+			return err
+		}
+		options.OutputFile = defaultOutput
+	}
+
 	// Ask for output file
-	fmt.Printf("[?] Save results to file? (default: %s, leave empty for no file): ", options.OutputFile)
+	fmt.Printf("[?] Save results to file? (default: %s, enter 'none' for no file): ", options.OutputFile)
 	var outputFile string
 	fmt.Scanln(&outputFile)
-	options.OutputFile = outputFile
+	if strings.ToLower(outputFile) == "none" {
+		options.OutputFile = ""
+	} else if outputFile != "" {
+		options.OutputFile = outputFile
+	}
 
 	// Create scanner and run scan
 	scanner, err := NewDirScanner(options)
@@ -516,6 +775,17 @@ func RunDirBruteforce() error {
 		return fmt.Errorf("failed to create scanner: %w", err)
 	}
 
+	// Make sure the target answers, then show what the scan will cost
+	fmt.Println("\n[+] Checking the target...")
+	if !preflight.Confirm(os.Stdout, nil, scanner.Preflight(context.Background(), targetURL)) {
+		fmt.Println("[i] Scan cancelled")
+		return nil
+	}
+	if !estimate.Confirm(os.Stdout, nil, scanner.Estimate(1)) {
+		fmt.Println("[i] Scan cancelled")
+		return nil
+	}
+
 	results, err := scanner.Scan(targetURL)
 	if err != nil {
 		return err
@@ -550,5 +820,8 @@ func RunDirBruteforce() error {
 		fmt.Printf("\n[+] Results saved to: %s\n", options.OutputFile)
 	}
 
+	if options.AdminPanels {
+		return reportPortals(targetURL, scanner.Portals())
+	}
 	return nil
 }
@@ -0,0 +1,85 @@
+package dirbruteforce
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	os.WriteFile(path, []byte("admin\nbackup\n# comment\nlogin\n"), 0644)
+
+	options := DefaultBruteforceOptions()
+	options.WordlistPath = path
+	scanner, err := NewDirScanner(options)
+	if err != nil {
+		t.Fatalf("NewDirScanner() error = %v", err)
+	}
+
+	e := scanner.Estimate(2)
+	want := int64(scanner.wordCount * len(options.Extensions))
+	if e.Requests != want || e.Total() != 2*want || e.Workers != options.Threads {
+		t.Errorf("Estimate() = %d requests, %d total, %d workers, want %d per target with %d threads", e.Requests, e.Total(), e.Workers, want, options.Threads)
+	}
+
+	// Admin panel mode checks its built-in paths and the catch-all page
+	scanner, err = NewDirScanner(AdminPanelOptions(DefaultBruteforceOptions()))
+	if err != nil {
+		t.Fatalf("NewDirScanner() error = %v", err)
+	}
+	if e := scanner.Estimate(1); e.Requests != int64(len(adminPaths)*len(scanner.options.Extensions)+1) {
+		t.Errorf("admin panel Estimate() = %d requests, want the %d admin paths and the catch-all check", e.Requests, len(adminPaths))
+	}
+}
+
+func TestDifferential(t *testing.T) {
+	var requested []string
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requested = append(requested, r.URL.Path)
+		mutex.Unlock()
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	words := filepath.Join(dir, "words.txt")
+	options := DefaultBruteforceOptions()
+	options.WordlistPath = words
+	options.Extensions = []string{""}
+	options.HistoryFile = filepath.Join(dir, "tested-paths.txt")
+
+	scan := func(wordlist string, differential bool) []string {
+		requested = nil
+		os.WriteFile(words, []byte(wordlist), 0644)
+		options.Differential = differential
+		scanner, err := NewDirScanner(options)
+		if err != nil {
+			t.Fatalf("NewDirScanner() error = %v", err)
+		}
+		if _, err := scanner.Scan(server.URL); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		sort.Strings(requested)
+		return requested
+	}
+
+	if got := scan("admin\nlogin\n", false); !reflect.DeepEqual(got, []string{"/admin", "/login"}) {
+		t.Errorf("first run requested %v", got)
+	}
+
+	// Only the words added since are tested in differential mode
+	if got := scan("admin\nlogin\nbackup\n", true); !reflect.DeepEqual(got, []string{"/backup"}) {
+		t.Errorf("differential run requested %v, want the new word only", got)
+	}
+	if got := scan("admin\nlogin\nbackup\n", false); len(got) != 3 {
+		t.Errorf("full run requested %v, want every word", got)
+	}
+}
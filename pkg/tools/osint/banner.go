@@ -0,0 +1,184 @@
+// pkg/tools/osint/banner.go
+package osint
+
+import (
+	"GopherStrike/pkg/egress"
+	"GopherStrike/pkg/stats"
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// bannerTimeout is how long a service has to answer a probe
+	bannerTimeout = 5 * time.Second
+	// greetingWait is how long a service is given to speak first before
+	// the client speaks
+	greetingWait = 2 * time.Second
+)
+
+// tlsServices names the services of the ports that speak TLS from the
+// first byte, rather than after STARTTLS
+var tlsServices = map[int]string{
+	443:  "HTTPS",
+	465:  "SMTPS",
+	563:  "NNTPS",
+	636:  "LDAPS",
+	853:  "DNS over TLS",
+	990:  "FTPS",
+	992:  "Telnet over TLS",
+	993:  "IMAPS",
+	995:  "POP3S",
+	5061: "SIP over TLS",
+	5986: "WinRM over HTTPS",
+	6697: "IRC over TLS",
+	8443: "HTTPS",
+}
+
+// httpBannerPorts are the ports whose services wait for an HTTP request,
+// so that waiting for a greeting is skipped
+var httpBannerPorts = map[int]bool{
+	80: true, 443: true, 591: true, 3000: true, 5985: true, 5986: true, 8000: true,
+	8008: true, 8080: true, 8081: true, 8443: true, 8888: true, 9000: true, 9200: true,
+}
+
+// bannerProbe returns the message sent to a service that did not speak
+// first: the SSH identification on SSH ports, else an HTTP HEAD request,
+// which most services on unusual ports answer
+func bannerProbe(host string, port int) string {
+	if port == 22 || port == 2222 {
+		return "SSH-2.0-GopherStrike\r\n"
+	}
+	return fmt.Sprintf("HEAD / HTTP/1.0\r\nHost: %s\r\nUser-Agent: Mozilla/5.0 GopherStrike OSINT Scanner\r\n\r\n", host)
+}
+
+// printableBanner reports whether a banner is text rather than the binary
+// answer of another protocol, such as a TLS alert
+func printableBanner(banner string) bool {
+	for _, r := range banner {
+		if !unicode.IsPrint(r) && r != '\t' {
+			return false
+		}
+	}
+	return banner != ""
+}
+
+// isTimeout reports whether a read ended at its deadline
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// readReply reads a reply line, with the continuation lines of multi-line
+// replies such as "220-Welcome" up to "220 Ready", joined by " | "
+func readReply(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	reply := strings.TrimSpace(line)
+	if len(reply) >= 4 && reply[3] == '-' {
+		code := reply[:3]
+		for i := 0; i < 50; i++ {
+			next, err := reader.ReadString('\n')
+			if next = strings.TrimSpace(next); next != "" {
+				reply += " | " + next
+			}
+			if err != nil || strings.HasPrefix(next, code+" ") {
+				break
+			}
+		}
+	}
+	return reply, err
+}
+
+// readHTTPBanner reads the head of an HTTP response and returns its status
+// line with its Server header, such as "HTTP/1.1 200 OK; Server: nginx/1.18.0"
+func readHTTPBanner(reader *bufio.Reader) string {
+	status, _ := reader.ReadString('\n')
+	status = strings.TrimSpace(status)
+	if !strings.HasPrefix(status, "HTTP/") {
+		return status // Another protocol answered
+	}
+	for i := 0; i < 100; i++ {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if err != nil || line == "" {
+			break
+		}
+		if name, value, found := strings.Cut(line, ":"); found && strings.EqualFold(name, "server") {
+			return status + "; Server: " + strings.TrimSpace(value)
+		}
+	}
+	return status
+}
+
+// grabBanner connects to a port, over TLS if asked, and returns the banner
+// of its service. Services that speak first are read; FTP servers are also
+// asked for their system type. Services that wait for the client are sent
+// the probe of their port. connected reports whether the port accepted the
+// connection and completed the TLS handshake.
+func grabBanner(host, serverName string, port int, useTLS bool) (banner string, connected bool) {
+	addr := net.JoinHostPort(host, fmt.Sprintf("%d", port))
+	dialer := &net.Dialer{Timeout: bannerTimeout}
+	conn, err := stats.DialContext(egress.DialContext("osint", dialer.DialContext))(context.Background(), "tcp", addr)
+	if err != nil {
+		return "", false
+	}
+	defer conn.Close()
+
+	if useTLS {
+		tlsConn := tls.Client(conn, &tls.Config{InsecureSkipVerify: true, ServerName: serverName})
+		tlsConn.SetDeadline(time.Now().Add(bannerTimeout))
+		if err := tlsConn.Handshake(); err != nil {
+			return "", false
+		}
+		conn = tlsConn
+	}
+	reader := bufio.NewReader(conn)
+
+	if !httpBannerPorts[port] {
+		conn.SetDeadline(time.Now().Add(greetingWait))
+		greeting, err := readReply(reader)
+		if printableBanner(greeting) {
+			if strings.HasPrefix(greeting, "220") && (port == 21 || port == 990 || strings.Contains(strings.ToUpper(greeting), "FTP")) {
+				conn.SetDeadline(time.Now().Add(bannerTimeout))
+				if _, err := conn.Write([]byte("SYST\r\n")); err == nil {
+					if system, _ := readReply(reader); strings.HasPrefix(system, "215") {
+						greeting += " | " + system
+					}
+				}
+			}
+			return greeting, true
+		}
+		if err != nil && !isTimeout(err) {
+			return "", true // The service closed the connection
+		}
+	}
+
+	conn.SetDeadline(time.Now().Add(bannerTimeout))
+	probeHost := serverName
+	if probeHost == "" {
+		probeHost = host
+	}
+	if strings.Contains(probeHost, ":") {
+		probeHost = "[" + probeHost + "]" // IPv6 address
+	}
+	probe := bannerProbe(probeHost, port)
+	if _, err := conn.Write([]byte(probe)); err != nil {
+		return "", true
+	}
+	if strings.HasPrefix(probe, "HEAD ") {
+		banner = readHTTPBanner(reader)
+	} else {
+		banner, _ = readReply(reader)
+	}
+	if !printableBanner(banner) {
+		return "", true
+	}
+	return banner, true
+}
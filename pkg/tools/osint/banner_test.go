@@ -0,0 +1,116 @@
+package osint
+
+import (
+	"bufio"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveBanner runs a TCP service on a local port, handling each connection
+// with handle, and returns its port
+func serveBanner(t *testing.T, listener net.Listener, handle func(conn net.Conn)) int {
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(5 * time.Second))
+				handle(conn)
+			}()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+// listen opens a local TCP listener
+func listen(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return listener
+}
+
+// shortGreetingWait shortens the wait for services speaking first
+func shortGreetingWait(t *testing.T) {
+	previous := greetingWait
+	greetingWait = 200 * time.Millisecond
+	t.Cleanup(func() { greetingWait = previous })
+}
+
+func TestGetBannerFTPSystem(t *testing.T) {
+	shortGreetingWait(t)
+	port := serveBanner(t, listen(t), func(conn net.Conn) {
+		conn.Write([]byte("220-Welcome\r\n220 ProFTPD 1.3.5 Server ready\r\n"))
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		if strings.TrimSpace(line) == "SYST" {
+			conn.Write([]byte("215 UNIX Type: L8\r\n"))
+		}
+	})
+
+	banner, service, overTLS := getBanner("127.0.0.1", "", port)
+	if banner != "220-Welcome | 220 ProFTPD 1.3.5 Server ready | 215 UNIX Type: L8" || service != "FTP" || overTLS {
+		t.Errorf("getBanner() = %q, %q, %t, want the greeting with the system type over FTP", banner, service, overTLS)
+	}
+}
+
+func TestGetBannerHTTPProbe(t *testing.T) {
+	shortGreetingWait(t)
+	port := serveBanner(t, listen(t), func(conn net.Conn) {
+		request, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil || request.Method != http.MethodHead {
+			return
+		}
+		conn.Write([]byte("HTTP/1.1 200 OK\r\nDate: Mon, 01 Jan 2024 00:00:00 GMT\r\nServer: nginx/1.18.0 (Ubuntu)\r\n\r\n"))
+	})
+
+	banner, service, _ := getBanner("127.0.0.1", "", port)
+	if banner != "HTTP/1.1 200 OK; Server: nginx/1.18.0 (Ubuntu)" || service != "HTTP" {
+		t.Errorf("getBanner() = %q, %q, want the status line and Server header over HTTP", banner, service)
+	}
+
+	info := &ServerInfo{}
+	processServiceBanner(info, port, banner)
+	if info.ProductName != "Nginx" || info.ProductVersion != "1.18.0" {
+		t.Errorf("product = %s %s, want Nginx 1.18.0", info.ProductName, info.ProductVersion)
+	}
+}
+
+func TestGetBannerTLS(t *testing.T) {
+	shortGreetingWait(t)
+	// Borrow the certificate of an httptest TLS server
+	certServer := httptest.NewTLSServer(http.NotFoundHandler())
+	certServer.Close()
+	listener := tls.NewListener(listen(t), &tls.Config{Certificates: certServer.TLS.Certificates})
+
+	port := serveBanner(t, listener, func(conn net.Conn) {
+		conn.Write([]byte("* OK [CAPABILITY IMAP4rev1] Dovecot ready.\r\n"))
+	})
+
+	banner, service, overTLS := getBanner("127.0.0.1", "", port)
+	if banner != "* OK [CAPABILITY IMAP4rev1] Dovecot ready." || service != "IMAP over TLS" || !overTLS {
+		t.Errorf("getBanner() = %q, %q, %t, want the IMAP greeting over TLS", banner, service, overTLS)
+	}
+}
+
+func TestGetBannerClosedPort(t *testing.T) {
+	listener := listen(t)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	if banner, _, _ := getBanner("127.0.0.1", "", port); banner != "" {
+		t.Errorf("getBanner() = %q, want no banner", banner)
+	}
+}
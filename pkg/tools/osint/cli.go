@@ -2,7 +2,11 @@
 package osint
 
 import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/logging"
+	"GopherStrike/pkg/redact"
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -12,9 +16,8 @@ import (
 	"time"
 )
 
-const (
-	LogDirectory = "logs/osint"
-)
+// LogDirectory holds the OSINT scan results and logs
+var LogDirectory = logging.Dir("osint")
 
 // OSINTCmdOptions holds command line options for the OSINT tool
 type OSINTCmdOptions struct {
@@ -35,7 +38,7 @@ func RunOSINTScanner() error {
 
 	// Initialize options
 	options := OSINTCmdOptions{
-		ConfidenceThreshold: 0.6,
+		ConfidenceThreshold: DefaultConfidenceThreshold,
 		OutputFormat:        "text",
 	}
 
@@ -54,11 +57,11 @@ func RunOSINTScanner() error {
 		case "1": // Lookup vulnerability
 			lookupVulnerability()
 		case "2": // Gather server information
-			gatherServerInformation()
+			gatherServerInformation(&options)
 		case "3": // Gather firmware information
-			gatherFirmwareInformation()
+			gatherFirmwareInformation(&options)
 		case "4": // Correlate scan results
-			correlateResults()
+			correlateResults(&options)
 		case "5": // Settings
 			configureSettings(&options)
 		case "6": // Return to main menu
@@ -116,11 +119,11 @@ func lookupVulnerability() {
 func searchByCVE(cveID string) {
 	fmt.Printf("\nSearching for %s...\n", cveID)
 
-	// Create NVD connector
-	nvd := NewNVDConnector("")
+	// Create the vulnerability database connector
+	db := newVulnDB("")
 
 	// Search for vulnerability
-	vuln, err := nvd.GetByID(cveID)
+	vuln, err := db.GetByID(cveID)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		return
@@ -140,8 +143,8 @@ func searchByCVE(cveID string) {
 func searchByKeywords(keywords []string) {
 	fmt.Printf("\nSearching for keywords: %s\n", strings.Join(keywords, ", "))
 
-	// Create NVD connector
-	nvd := NewNVDConnector("")
+	// Create the vulnerability database connector
+	db := newVulnDB("")
 
 	// Create search query
 	query := SearchQuery{
@@ -150,7 +153,7 @@ func searchByKeywords(keywords []string) {
 	}
 
 	// Search for vulnerabilities
-	vulns, err := nvd.Search(query)
+	vulns, err := db.Search(query)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		return
@@ -186,8 +189,8 @@ func searchByProduct(product, version string) {
 	}
 	fmt.Println("...")
 
-	// Create NVD connector
-	nvd := NewNVDConnector("")
+	// Create the vulnerability database connector
+	db := newVulnDB("")
 
 	// Create search query
 	query := SearchQuery{
@@ -201,7 +204,7 @@ func searchByProduct(product, version string) {
 	}
 
 	// Search for vulnerabilities
-	vulns, err := nvd.Search(query)
+	vulns, err := db.Search(query)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		return
@@ -230,7 +233,7 @@ func searchByProduct(product, version string) {
 }
 
 // gatherServerInformation collects information about a server
-func gatherServerInformation() {
+func gatherServerInformation(options *OSINTCmdOptions) {
 	fmt.Println("\n--- Server Information Gathering ---")
 	target := getInput("Enter target IP or hostname")
 
@@ -257,6 +260,15 @@ func gatherServerInformation() {
 		return
 	}
 
+	// Compare the product version with the latest release
+	checker, err := NewVersionChecker()
+	if err != nil {
+		fmt.Printf("[!] %v\n", err)
+	}
+	if err := checkLatestVersion(checker, serverInfo); err != nil {
+		fmt.Printf("[-] Could not check the latest version: %v\n", err)
+	}
+
 	// Display results
 	displayServerInfo(serverInfo)
 
@@ -269,22 +281,12 @@ func gatherServerInformation() {
 	// Option to correlate with vulnerabilities
 	correlateChoice := getInput("Correlate with vulnerability database? (y/n)")
 	if strings.ToLower(correlateChoice) == "y" {
-		// Create NVD connector
-		nvd := NewNVDConnector("")
-
-		// Create correlator
-		correlator := NewCorrelator(nvd)
+		correlator := newCorrelator(options)
 
 		fmt.Println("\nCorrelating with vulnerability database...")
 
 		// Create scan result
-		scanResult := &ScanResult{
-			ID:         fmt.Sprintf("server_%s_%d", target, time.Now().Unix()),
-			Target:     target,
-			ScanType:   "ServerInfo",
-			ScanDate:   time.Now(),
-			ServerInfo: serverInfo,
-		}
+		scanResult := NewServerScanResult(target, serverInfo)
 
 		// Correlate
 		err = correlator.CorrelateScanResults(scanResult)
@@ -305,15 +307,32 @@ func gatherServerInformation() {
 }
 
 // gatherFirmwareInformation collects information about device firmware
-func gatherFirmwareInformation() {
+func gatherFirmwareInformation(options *OSINTCmdOptions) {
 	fmt.Println("\n--- Firmware Information Gathering ---")
-	fmt.Println("Enter firmware details:")
 
-	// Gather firmware information manually
-	deviceType := getInput("Device type (e.g., Router, Switch, Camera)")
-	manufacturer := getInput("Manufacturer")
-	model := getInput("Model")
-	firmwareVersion := getInput("Firmware version")
+	// Detect the device from the network or its web interface, then ask for what is missing
+	firmwareInfo := &FirmwareInfo{}
+	if target := getInput("Device IP or web interface URL (leave empty to enter details manually)"); target != "" {
+		if strings.Contains(target, "://") {
+			firmwareInfo = detectFirmwareInfo(target)
+		} else {
+			firmwareInfo = probeFirmwareInfo(target)
+		}
+	}
+
+	fmt.Println("Enter firmware details:")
+	if firmwareInfo.DeviceType == "" {
+		firmwareInfo.DeviceType = getInput("Device type (e.g., Router, Switch, Camera)")
+	}
+	if firmwareInfo.Manufacturer == "" {
+		firmwareInfo.Manufacturer = getInput("Manufacturer")
+	}
+	if firmwareInfo.Model == "" {
+		firmwareInfo.Model = getInput("Model")
+	}
+	if firmwareInfo.FirmwareVersion == "" {
+		firmwareInfo.FirmwareVersion = getInput("Firmware version")
+	}
 
 	// Parse release date
 	releaseDate := time.Time{}
@@ -327,19 +346,27 @@ func gatherFirmwareInformation() {
 		}
 	}
 
-	// Create firmware info
-	firmwareInfo := &FirmwareInfo{
-		DeviceType:      deviceType,
-		Manufacturer:    manufacturer,
-		Model:           model,
-		FirmwareVersion: firmwareVersion,
-		ReleaseDate:     releaseDate,
-	}
+	firmwareInfo.ReleaseDate = releaseDate
 
 	// Check for EOL status
 	eolChoice := getInput("Is this firmware version EOL (End of Life)? (y/n)")
 	firmwareInfo.EOLStatus = strings.ToLower(eolChoice) == "y"
 
+	// Compare the firmware with the latest version from the vendor feeds, or
+	// the one the user knows of
+	checker, err := NewVersionChecker()
+	if err != nil {
+		fmt.Printf("[!] %v\n", err)
+	}
+	checkLatestFirmware(checker, firmwareInfo)
+	if firmwareInfo.VersionStatus == nil && firmwareInfo.FirmwareVersion != "" {
+		if latest := getInput("Latest firmware version (leave empty if unknown)"); latest != "" {
+			firmwareInfo.LatestVersion = latest
+			firmwareInfo.VersionStatus = NewVersionStatus(firmwareInfo.FirmwareVersion, latest, "", firmwareInfo.EOLStatus)
+			firmwareInfo.VersionStatus.Source = "user"
+		}
+	}
+
 	if firmwareInfo.EOLStatus {
 		eolDateStr := getInput("EOL date (YYYY-MM-DD, leave empty if unknown)")
 		if eolDateStr != "" {
@@ -365,22 +392,12 @@ func gatherFirmwareInformation() {
 	// Option to correlate with vulnerabilities
 	correlateChoice := getInput("Correlate with vulnerability database? (y/n)")
 	if strings.ToLower(correlateChoice) == "y" {
-		// Create NVD connector
-		nvd := NewNVDConnector("")
-
-		// Create correlator
-		correlator := NewCorrelator(nvd)
+		correlator := newCorrelator(options)
 
 		fmt.Println("\nCorrelating with vulnerability database...")
 
 		// Create scan result
-		scanResult := &ScanResult{
-			ID:           fmt.Sprintf("firmware_%s_%s_%d", manufacturer, model, time.Now().Unix()),
-			Target:       fmt.Sprintf("%s %s", manufacturer, model),
-			ScanType:     "FirmwareInfo",
-			ScanDate:     time.Now(),
-			FirmwareInfo: firmwareInfo,
-		}
+		scanResult := NewFirmwareScanResult(firmwareInfo)
 
 		// Correlate
 		err := correlator.CorrelateScanResults(scanResult)
@@ -400,8 +417,65 @@ func gatherFirmwareInformation() {
 	}
 }
 
+// detectFirmwareInfo identifies a device from its web interface and returns
+// the details it found, which are empty if it could not be identified
+func detectFirmwareInfo(target string) *FirmwareInfo {
+	fingerprints, err := LoadDeviceFingerprints()
+	if err != nil {
+		fmt.Printf("[!] %v, using the built-in fingerprints\n", err)
+		fingerprints = DefaultDeviceFingerprints
+	}
+
+	fmt.Printf("\n[+] Detecting the device at %s...\n", target)
+	match, err := DetectDevice(context.Background(), target, fingerprints)
+	if err != nil {
+		fmt.Printf("[-] %v\n", err)
+		return &FirmwareInfo{}
+	}
+	if match == nil {
+		fmt.Println("[-] No known router or IoT web interface found")
+		return &FirmwareInfo{}
+	}
+
+	fmt.Printf("[+] %s (confidence %.0f%%): %s\n", match.Name, match.Confidence*100, strings.Join(match.Evidence, ", "))
+	if match.Model != "" {
+		fmt.Printf("[+] Model: %s\n", match.Model)
+	}
+	if match.FirmwareVersion != "" {
+		fmt.Printf("[+] Firmware version: %s\n", match.FirmwareVersion)
+	}
+	return match.FirmwareInfo()
+}
+
+// probeFirmwareInfo probes a device over UPnP, SNMP and its web interface
+// and returns what it reports
+func probeFirmwareInfo(address string) *FirmwareInfo {
+	fingerprints, err := LoadDeviceFingerprints()
+	if err != nil {
+		fmt.Printf("[!] %v, using the built-in fingerprints\n", err)
+		fingerprints = DefaultDeviceFingerprints
+	}
+	options := DefaultDeviceProbeOptions()
+	if community := getInput("SNMP community (default public)"); community != "" {
+		options.Community = community
+	}
+
+	fmt.Printf("\n[+] Probing %s over UPnP, SNMP and HTTP...\n", address)
+	probe, err := ProbeDevice(context.Background(), address, options, fingerprints)
+	if err != nil {
+		fmt.Printf("[-] %v\n", err)
+		return &FirmwareInfo{}
+	}
+
+	fmt.Printf("[+] Answered: %s\n", strings.Join(probe.Sources, ", "))
+	for _, evidence := range probe.Evidence {
+		fmt.Printf("[+] %s\n", evidence)
+	}
+	return probe.Info
+}
+
 // correlateResults loads previous scan results and correlates them with vulnerabilities
-func correlateResults() {
+func correlateResults(options *OSINTCmdOptions) {
 	fmt.Println("\n--- Correlate Previous Scan Results ---")
 
 	// List available scan results
@@ -436,11 +510,7 @@ func correlateResults() {
 		return
 	}
 
-	// Create NVD connector
-	nvd := NewNVDConnector("")
-
-	// Create correlator
-	correlator := NewCorrelator(nvd)
+	correlator := newCorrelator(options)
 
 	fmt.Println("\nCorrelating with vulnerability database...")
 
@@ -461,6 +531,28 @@ func correlateResults() {
 	}
 }
 
+// newCorrelator creates a correlator on the vulnerability databases with
+// the API key and confidence threshold of the settings
+func newCorrelator(options *OSINTCmdOptions) *Correlator {
+	correlator := NewCorrelator(newVulnDB(options.APIKey))
+	correlator.MatchThreshold = options.ConfidenceThreshold
+	return correlator
+}
+
+// newVulnDB creates the connector of the default vulnerability databases,
+// with the NVD API key of the settings if one is set
+func newVulnDB(apiKey string) VulnDBConnector {
+	vulnOptions := DefaultVulnDBOptions()
+	if apiKey != "" {
+		vulnOptions.NVDAPIKey = apiKey
+	}
+	db, err := NewVulnDB(vulnOptions)
+	if err != nil {
+		return NewNVDConnector(vulnOptions.NVDAPIKey)
+	}
+	return db
+}
+
 // configureSettings allows changing OSINT tool settings
 func configureSettings(options *OSINTCmdOptions) {
 	fmt.Println("\n--- Settings ---")
@@ -535,6 +627,12 @@ func displayVulnerability(vuln Vulnerability) {
 	}
 
 	fmt.Printf("Source: %s\n", vuln.Source)
+	if len(vuln.Provenance) > 1 {
+		fmt.Println("\nFound In:")
+		for _, p := range vuln.Provenance {
+			fmt.Printf("- %s %s %s\n", p.Source, p.ID, p.URL)
+		}
+	}
 }
 
 // displayVulnerabilityList prints a list of vulnerabilities
@@ -584,6 +682,24 @@ func displayServerInfo(info *ServerInfo) {
 		fmt.Println()
 	}
 
+	if info.VersionStatus != nil {
+		fmt.Printf("Version: %s\n", info.VersionStatus)
+	}
+
+	if len(info.Technologies) > 0 {
+		fmt.Println("\nTechnologies:")
+		for _, t := range info.Technologies {
+			fmt.Printf("- %s", t.Product)
+			if t.Version != "" {
+				fmt.Printf(" %s", t.Version)
+			}
+			if t.CPE != "" {
+				fmt.Printf(" (%s)", t.CPE)
+			}
+			fmt.Println()
+		}
+	}
+
 	if len(info.Ports) > 0 {
 		fmt.Println("\nOpen Ports:")
 		for _, port := range info.Ports {
@@ -624,7 +740,9 @@ func displayFirmwareInfo(info *FirmwareInfo) {
 		fmt.Printf("Release Date: %s\n", info.ReleaseDate.Format("2006-01-02"))
 	}
 
-	if info.LatestVersion != "" {
+	if info.VersionStatus != nil {
+		fmt.Printf("Version: %s\n", info.VersionStatus)
+	} else if info.LatestVersion != "" {
 		fmt.Printf("Latest Version: %s\n", info.LatestVersion)
 	}
 
@@ -642,12 +760,18 @@ func displayScanResult(result *ScanResult) {
 	fmt.Printf("Target: %s\n", result.Target)
 	fmt.Printf("Scan Type: %s\n", result.ScanType)
 	fmt.Printf("Scan Date: %s\n", result.ScanDate.Format("2006-01-02 15:04:05"))
+	if result.ServerInfo != nil && result.ServerInfo.VersionStatus != nil {
+		fmt.Printf("Version: %s\n", result.ServerInfo.VersionStatus)
+	}
+	if result.FirmwareInfo != nil && result.FirmwareInfo.VersionStatus != nil {
+		fmt.Printf("Firmware: %s\n", result.FirmwareInfo.VersionStatus)
+	}
 
 	if len(result.Vulnerabilities) > 0 {
 		fmt.Printf("\nVulnerabilities Found: %d\n", len(result.Vulnerabilities))
 		fmt.Printf("Overall Risk Score: %.1f/10\n", result.RiskScore)
 
-		fmt.Printf("\n%-15s %-10s %-7s %-15s %s\n", "CVE ID", "Severity", "CVSS", "Confidence", "Title")
+		fmt.Printf("\n%-15s %-10s %-7s %-15s %-10s %s\n", "CVE ID", "Severity", "CVSS", "Confidence", "Source", "Title")
 		fmt.Printf("%s\n", strings.Repeat("-", 100))
 
 		for _, vuln := range result.Vulnerabilities {
@@ -673,10 +797,10 @@ func displayScanResult(result *ScanResult) {
 				title = title[:42] + "..."
 			}
 
-			fmt.Printf("%-15s %-10s %-7.1f %-15s %s\n",
+			fmt.Printf("%-15s %-10s %-7.1f %-15s %-10s %s\n",
 				vuln.ID, vuln.Severity, vuln.CVSS,
 				fmt.Sprintf("%.1f%%(%s)", confidence*100, confidenceLevel),
-				title)
+				result.MatchSources[vuln.ID], title)
 		}
 	} else {
 		fmt.Println("\nNo vulnerabilities found.")
@@ -697,7 +821,7 @@ func saveVulnerabilityToFile(vuln Vulnerability) {
 	}
 
 	// Write to file
-	err = os.WriteFile(filename, data, 0644)
+	err = os.WriteFile(filename, redact.Bytes(data), 0644)
 	if err != nil {
 		fmt.Printf("Error writing file: %v\n", err)
 		return
@@ -706,21 +830,18 @@ func saveVulnerabilityToFile(vuln Vulnerability) {
 	fmt.Printf("Vulnerability saved to %s\n", filename)
 }
 
-// saveServerInfoToFile saves server information to a file
+// saveServerInfoToFile saves server information to the target's OSINT artifact directory
 func saveServerInfoToFile(info *ServerInfo) {
 	// Create filename
 	timestamp := time.Now().Format("20060102_150405")
-	filename := filepath.Join(LogDirectory, fmt.Sprintf("server_%s_%s.json", info.IPAddress, timestamp))
+	name := fmt.Sprintf("server_%s_%s.json", info.IPAddress, timestamp)
 
-	// Create JSON data
-	data, err := json.MarshalIndent(info, "", "  ")
-	if err != nil {
-		fmt.Printf("Error creating JSON: %v\n", err)
-		return
+	target := info.Hostname
+	if target == "" {
+		target = info.IPAddress
 	}
 
-	// Write to file
-	err = os.WriteFile(filename, data, 0644)
+	filename, err := artifacts.Default().WriteJSON(target, artifacts.KindOSINT, name, info)
 	if err != nil {
 		fmt.Printf("Error writing file: %v\n", err)
 		return
@@ -745,7 +866,7 @@ func saveFirmwareInfoToFile(info *FirmwareInfo) {
 	}
 
 	// Write to file
-	err = os.WriteFile(filename, data, 0644)
+	err = os.WriteFile(filename, redact.Bytes(data), 0644)
 	if err != nil {
 		fmt.Printf("Error writing file: %v\n", err)
 		return
@@ -754,21 +875,26 @@ func saveFirmwareInfoToFile(info *FirmwareInfo) {
 	fmt.Printf("Firmware information saved to %s\n", filename)
 }
 
-// saveScanResultToFile saves a scan result to a file
+// saveScanResultToFile saves a scan result to a file. Server scans are stored
+// with the target's other artifacts; firmware scans have no host and stay in
+// the OSINT log directory.
 func saveScanResultToFile(result *ScanResult) {
 	// Create filename
 	timestamp := time.Now().Format("20060102_150405")
-	filename := filepath.Join(LogDirectory, fmt.Sprintf("scan_%s_%s.json", result.ID, timestamp))
+	name := fmt.Sprintf("scan_%s_%s.json", result.ID, timestamp)
 
-	// Create JSON data
-	data, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		fmt.Printf("Error creating JSON: %v\n", err)
-		return
+	var filename string
+	var err error
+	if result.ServerInfo != nil {
+		filename, err = artifacts.Default().WriteJSON(result.Target, artifacts.KindOSINT, name, result)
+	} else {
+		filename = filepath.Join(LogDirectory, name)
+		var data []byte
+		data, err = json.MarshalIndent(result, "", "  ")
+		if err == nil {
+			err = os.WriteFile(filename, redact.Bytes(data), 0644)
+		}
 	}
-
-	// Write to file
-	err = os.WriteFile(filename, data, 0644)
 	if err != nil {
 		fmt.Printf("Error writing file: %v\n", err)
 		return
@@ -777,10 +903,20 @@ func saveScanResultToFile(result *ScanResult) {
 	fmt.Printf("Scan result saved to %s\n", filename)
 }
 
-// listScanFiles returns a list of scan result files
+// listScanFiles returns a list of scan result files from the OSINT log
+// directory and the OSINT artifacts of every target in the workspace
 func listScanFiles() ([]string, error) {
-	pattern := filepath.Join(LogDirectory, "scan_*.json")
-	return filepath.Glob(pattern)
+	files, err := filepath.Glob(filepath.Join(LogDirectory, "scan_*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	targetFiles, err := artifacts.Default().Glob(artifacts.KindOSINT, "scan_*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(files, targetFiles...), nil
 }
 
 // loadScanResult loads a scan result from a file
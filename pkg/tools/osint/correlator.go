@@ -2,7 +2,11 @@
 package osint
 
 import (
+	"GopherStrike/pkg/siem"
+	"encoding/json"
 	"fmt"
+	"os"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -16,17 +20,40 @@ const (
 	ConfidenceLow    ConfidenceLevel = "Low"
 )
 
+// DefaultConfidenceThreshold is the minimum confidence score of the matches reported
+const DefaultConfidenceThreshold = 0.6
+
+// Sources of matches, from the most to the least reliable
+const (
+	MatchCPE      = "CPE"      // A CPE of the vulnerability names the product and covers the version
+	MatchAffected = "affected" // The affected systems of the vulnerability name the product
+	MatchKeyword  = "keyword"  // The title or description of the vulnerability mention it
+)
+
+// DefaultSourceWeights weight the fields matched by each source. A product
+// and version matched by keywords alone just reach the default threshold.
+var DefaultSourceWeights = map[string]float64{
+	MatchCPE:      1.0,
+	MatchAffected: 0.9,
+	MatchKeyword:  0.75,
+}
+
+// cpeNameRegex matches what CPEs write as underscores
+var cpeNameRegex = regexp.MustCompile(`[^a-z0-9.]+`)
+
 // Correlator is the correlation engine that matches server/firmware info with vulnerabilities
 type Correlator struct {
 	VulnDB         VulnDBConnector
-	MatchThreshold float64 // Minimum confidence score to include in results (0-1)
+	MatchThreshold float64            // Minimum confidence score to include in results (0-1)
+	SourceWeights  map[string]float64 // Weight of the fields matched by each source
 }
 
 // NewCorrelator creates a new correlation engine with the given vulnerability database
 func NewCorrelator(vulnDB VulnDBConnector) *Correlator {
 	return &Correlator{
 		VulnDB:         vulnDB,
-		MatchThreshold: 0.6, // Default threshold is 60%
+		MatchThreshold: DefaultConfidenceThreshold,
+		SourceWeights:  DefaultSourceWeights,
 	}
 }
 
@@ -57,7 +84,7 @@ func (c *Correlator) CorrelateServerInfo(serverInfo *ServerInfo) ([]MatchResult,
 
 	// Calculate matches
 	for _, vuln := range vulns {
-		matchScore, matchReasons, matchedFields := calculateServerMatchScore(serverInfo, vuln)
+		matchScore, matchReasons, matchedFields, source := calculateServerMatchScore(serverInfo, vuln, c.SourceWeights)
 
 		// Only include matches above threshold
 		if matchScore >= c.MatchThreshold {
@@ -67,6 +94,7 @@ func (c *Correlator) CorrelateServerInfo(serverInfo *ServerInfo) ([]MatchResult,
 				ConfidenceScore: matchScore,
 				MatchReason:     strings.Join(matchReasons, "; "),
 				MatchedFields:   matchedFields,
+				Source:          source,
 			})
 		}
 	}
@@ -106,7 +134,7 @@ func (c *Correlator) CorrelateFirmwareInfo(firmwareInfo *FirmwareInfo) ([]MatchR
 
 	// Calculate matches
 	for _, vuln := range vulns {
-		matchScore, matchReasons, matchedFields := calculateFirmwareMatchScore(firmwareInfo, vuln)
+		matchScore, matchReasons, matchedFields, source := calculateFirmwareMatchScore(firmwareInfo, vuln, c.SourceWeights)
 
 		// Only include matches above threshold
 		if matchScore >= c.MatchThreshold {
@@ -116,6 +144,7 @@ func (c *Correlator) CorrelateFirmwareInfo(firmwareInfo *FirmwareInfo) ([]MatchR
 				ConfidenceScore: matchScore,
 				MatchReason:     strings.Join(matchReasons, "; "),
 				MatchedFields:   matchedFields,
+				Source:          source,
 			})
 		}
 	}
@@ -145,6 +174,7 @@ func (c *Correlator) CorrelateScanResults(scanResult *ScanResult) error {
 
 			if !found {
 				scanResult.Vulnerabilities = append(scanResult.Vulnerabilities, match.Vulnerability)
+				emitFinding(scanResult.Target, match.Vulnerability)
 			}
 
 			// Add confidence score
@@ -152,6 +182,10 @@ func (c *Correlator) CorrelateScanResults(scanResult *ScanResult) error {
 				scanResult.ConfidenceScore = make(map[string]float64)
 			}
 			scanResult.ConfidenceScore[match.Vulnerability.ID] = match.ConfidenceScore
+			if scanResult.MatchSources == nil {
+				scanResult.MatchSources = make(map[string]string)
+			}
+			scanResult.MatchSources[match.Vulnerability.ID] = match.Source
 		}
 	}
 
@@ -175,6 +209,7 @@ func (c *Correlator) CorrelateScanResults(scanResult *ScanResult) error {
 
 			if !found {
 				scanResult.Vulnerabilities = append(scanResult.Vulnerabilities, match.Vulnerability)
+				emitFinding(scanResult.Target, match.Vulnerability)
 			}
 
 			// Add confidence score
@@ -182,69 +217,78 @@ func (c *Correlator) CorrelateScanResults(scanResult *ScanResult) error {
 				scanResult.ConfidenceScore = make(map[string]float64)
 			}
 			scanResult.ConfidenceScore[match.Vulnerability.ID] = match.ConfidenceScore
+			if scanResult.MatchSources == nil {
+				scanResult.MatchSources = make(map[string]string)
+			}
+			scanResult.MatchSources[match.Vulnerability.ID] = match.Source
 		}
 	}
 
+	// Matches kept from an earlier correlation must reach the threshold too
+	FilterByConfidence(scanResult, c.MatchThreshold)
+
 	// Calculate overall risk score based on vulnerability severities and confidence
 	scanResult.RiskScore = calculateRiskScore(scanResult)
 
 	return nil
 }
 
-// calculateServerMatchScore calculates a confidence score for a server-vulnerability match
-func calculateServerMatchScore(serverInfo *ServerInfo, vuln Vulnerability) (float64, []string, []string) {
+// calculateServerMatchScore calculates a confidence score for a server-vulnerability match.
+// Each matched field counts by the weight of the source it was matched by.
+func calculateServerMatchScore(serverInfo *ServerInfo, vuln Vulnerability, weights map[string]float64) (float64, []string, []string, string) {
 	var score float64 = 0
 	reasons := make([]string, 0)
 	matchedFields := make([]string, 0)
+	best := ""
 
-	// Look for product name in vulnerability information
 	// Product name match is worth 50% of the score
+	cpes := matchingCPEs(vuln, "", serverInfo.ProductName)
+	if len(cpes) == 0 && serverInfo.CPE != "" {
+		// The CPE of the fingerprint names products whose CPE differs from their name
+		vendor, product, _ := CPEMatch{Criteria: serverInfo.CPE}.Fields()
+		cpes = matchingCPEs(vuln, vendor, product)
+	}
 	if serverInfo.ProductName != "" {
-		productLower := strings.ToLower(serverInfo.ProductName)
-
-		// Check if product name appears in vulnerability title, description or affected systems
-		titleDescMatch := strings.Contains(strings.ToLower(vuln.Title), productLower) ||
-			strings.Contains(strings.ToLower(vuln.Description), productLower)
-
-		systemsMatch := false
-		for _, system := range vuln.AffectedSystems {
-			if strings.Contains(strings.ToLower(system), productLower) {
-				systemsMatch = true
-				break
-			}
+		source := ""
+		switch {
+		case len(cpes) > 0:
+			source = MatchCPE
+		case mentionsIn(vuln.AffectedSystems, serverInfo.ProductName):
+			source = MatchAffected
+		case mentions(vuln, serverInfo.ProductName):
+			source = MatchKeyword
 		}
-
-		if titleDescMatch || systemsMatch {
-			score += 0.5
-			reasons = append(reasons, fmt.Sprintf("Product '%s' mentioned in vulnerability", serverInfo.ProductName))
+		if source != "" {
+			score += 0.5 * sourceWeight(weights, source)
+			best = betterSource(best, source)
+			reasons = append(reasons, fmt.Sprintf("Product '%s' matched (%s)", serverInfo.ProductName, source))
 			matchedFields = append(matchedFields, "ProductName")
 		}
 	}
 
 	// Version match is worth 30% of the score
 	if serverInfo.ProductVersion != "" {
-		versionLower := strings.ToLower(serverInfo.ProductVersion)
-
-		// Check if version appears in vulnerability information
-		if strings.Contains(strings.ToLower(vuln.Title), versionLower) ||
-			strings.Contains(strings.ToLower(vuln.Description), versionLower) {
-			score += 0.3
+		source := ""
+		if cpe := coveringCPE(cpes, serverInfo.ProductVersion); cpe != nil {
+			source = MatchCPE
+			reasons = append(reasons, fmt.Sprintf("Version '%s' affected per %s", serverInfo.ProductVersion, cpe.Criteria))
+		} else if mentions(vuln, serverInfo.ProductVersion) {
+			source = MatchKeyword
 			reasons = append(reasons, fmt.Sprintf("Version '%s' mentioned in vulnerability", serverInfo.ProductVersion))
+		}
+		if source != "" {
+			score += 0.3 * sourceWeight(weights, source)
+			best = betterSource(best, source)
 			matchedFields = append(matchedFields, "ProductVersion")
 		}
 	}
 
 	// OS match is worth 20% of the score
-	if serverInfo.OS != "" {
-		osLower := strings.ToLower(serverInfo.OS)
-
-		// Check if OS appears in vulnerability information
-		if strings.Contains(strings.ToLower(vuln.Title), osLower) ||
-			strings.Contains(strings.ToLower(vuln.Description), osLower) {
-			score += 0.2
-			reasons = append(reasons, fmt.Sprintf("OS '%s' mentioned in vulnerability", serverInfo.OS))
-			matchedFields = append(matchedFields, "OS")
-		}
+	if serverInfo.OS != "" && mentions(vuln, serverInfo.OS) {
+		score += 0.2 * sourceWeight(weights, MatchKeyword)
+		best = betterSource(best, MatchKeyword)
+		reasons = append(reasons, fmt.Sprintf("OS '%s' mentioned in vulnerability", serverInfo.OS))
+		matchedFields = append(matchedFields, "OS")
 	}
 
 	// Recency bonus - newer vulnerabilities are more likely to be relevant
@@ -272,50 +316,69 @@ func calculateServerMatchScore(serverInfo *ServerInfo, vuln Vulnerability) (floa
 		score = 1.0
 	}
 
-	return score, reasons, matchedFields
+	return score, reasons, matchedFields, best
 }
 
-// calculateFirmwareMatchScore calculates a confidence score for a firmware-vulnerability match
-func calculateFirmwareMatchScore(firmwareInfo *FirmwareInfo, vuln Vulnerability) (float64, []string, []string) {
+// calculateFirmwareMatchScore calculates a confidence score for a firmware-vulnerability match.
+// Each matched field counts by the weight of the source it was matched by.
+func calculateFirmwareMatchScore(firmwareInfo *FirmwareInfo, vuln Vulnerability, weights map[string]float64) (float64, []string, []string, string) {
 	var score float64 = 0
 	reasons := make([]string, 0)
 	matchedFields := make([]string, 0)
+	best := ""
 
 	// Manufacturer match is worth 30% of the score
 	if firmwareInfo.Manufacturer != "" {
-		manufacturerLower := strings.ToLower(firmwareInfo.Manufacturer)
-
-		// Check if manufacturer appears in vulnerability information
-		if strings.Contains(strings.ToLower(vuln.Title), manufacturerLower) ||
-			strings.Contains(strings.ToLower(vuln.Description), manufacturerLower) {
-			score += 0.3
-			reasons = append(reasons, fmt.Sprintf("Manufacturer '%s' mentioned in vulnerability", firmwareInfo.Manufacturer))
+		source := ""
+		switch {
+		case len(matchingCPEs(vuln, firmwareInfo.Manufacturer, "")) > 0:
+			source = MatchCPE
+		case mentionsIn(vuln.AffectedSystems, firmwareInfo.Manufacturer):
+			source = MatchAffected
+		case mentions(vuln, firmwareInfo.Manufacturer):
+			source = MatchKeyword
+		}
+		if source != "" {
+			score += 0.3 * sourceWeight(weights, source)
+			best = betterSource(best, source)
+			reasons = append(reasons, fmt.Sprintf("Manufacturer '%s' matched (%s)", firmwareInfo.Manufacturer, source))
 			matchedFields = append(matchedFields, "Manufacturer")
 		}
 	}
 
 	// Model match is worth 30% of the score
+	cpes := matchingCPEs(vuln, firmwareInfo.Manufacturer, firmwareInfo.Model)
 	if firmwareInfo.Model != "" {
-		modelLower := strings.ToLower(firmwareInfo.Model)
-
-		// Check if model appears in vulnerability information
-		if strings.Contains(strings.ToLower(vuln.Title), modelLower) ||
-			strings.Contains(strings.ToLower(vuln.Description), modelLower) {
-			score += 0.3
-			reasons = append(reasons, fmt.Sprintf("Model '%s' mentioned in vulnerability", firmwareInfo.Model))
+		source := ""
+		switch {
+		case len(cpes) > 0:
+			source = MatchCPE
+		case mentionsIn(vuln.AffectedSystems, firmwareInfo.Model):
+			source = MatchAffected
+		case mentions(vuln, firmwareInfo.Model):
+			source = MatchKeyword
+		}
+		if source != "" {
+			score += 0.3 * sourceWeight(weights, source)
+			best = betterSource(best, source)
+			reasons = append(reasons, fmt.Sprintf("Model '%s' matched (%s)", firmwareInfo.Model, source))
 			matchedFields = append(matchedFields, "Model")
 		}
 	}
 
 	// Firmware version match is worth 40% of the score
 	if firmwareInfo.FirmwareVersion != "" {
-		versionLower := strings.ToLower(firmwareInfo.FirmwareVersion)
-
-		// Check if version appears in vulnerability information
-		if strings.Contains(strings.ToLower(vuln.Title), versionLower) ||
-			strings.Contains(strings.ToLower(vuln.Description), versionLower) {
-			score += 0.4
+		source := ""
+		if cpe := coveringCPE(cpes, firmwareInfo.FirmwareVersion); cpe != nil {
+			source = MatchCPE
+			reasons = append(reasons, fmt.Sprintf("Firmware version '%s' affected per %s", firmwareInfo.FirmwareVersion, cpe.Criteria))
+		} else if mentions(vuln, firmwareInfo.FirmwareVersion) {
+			source = MatchKeyword
 			reasons = append(reasons, fmt.Sprintf("Firmware version '%s' mentioned in vulnerability", firmwareInfo.FirmwareVersion))
+		}
+		if source != "" {
+			score += 0.4 * sourceWeight(weights, source)
+			best = betterSource(best, source)
 			matchedFields = append(matchedFields, "FirmwareVersion")
 		}
 	}
@@ -333,7 +396,99 @@ func calculateFirmwareMatchScore(firmwareInfo *FirmwareInfo, vuln Vulnerability)
 		score = 1.0
 	}
 
-	return score, reasons, matchedFields
+	return score, reasons, matchedFields, best
+}
+
+// sourceWeight returns the weight of a match source, 1 if it has none
+func sourceWeight(weights map[string]float64, source string) float64 {
+	if weight, ok := weights[source]; ok {
+		return weight
+	}
+	return 1
+}
+
+// betterSource returns the more reliable of two match sources
+func betterSource(a, b string) string {
+	rank := map[string]int{MatchKeyword: 1, MatchAffected: 2, MatchCPE: 3}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// mentions reports whether the title or description of a vulnerability mention s
+func mentions(vuln Vulnerability, s string) bool {
+	s = strings.ToLower(s)
+	return strings.Contains(strings.ToLower(vuln.Title), s) ||
+		strings.Contains(strings.ToLower(vuln.Description), s)
+}
+
+// mentionsIn reports whether one of the systems mentions s
+func mentionsIn(systems []string, s string) bool {
+	s = strings.ToLower(s)
+	for _, system := range systems {
+		if strings.Contains(strings.ToLower(system), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// cpeName normalizes a name as CPEs write it, e.g. "Apache HTTP Server" as apache_http_server
+func cpeName(s string) string {
+	return strings.Trim(cpeNameRegex.ReplaceAllString(strings.ToLower(s), "_"), "_")
+}
+
+// matchingCPEs returns the CPEs of a vulnerability with the vendor and
+// product. The product matches the CPE product, with the vendor or alone,
+// and device models match CPE products such as r7000_firmware; an empty
+// vendor or product matches any.
+func matchingCPEs(vuln Vulnerability, vendor, product string) []CPEMatch {
+	if vendor == "" && product == "" {
+		return nil
+	}
+	vendor, product = cpeName(vendor), cpeName(product)
+	matches := make([]CPEMatch, 0)
+	for _, cpe := range vuln.CPEs {
+		cpeVendor, cpeProduct, _ := cpe.Fields()
+		if vendor != "" && cpeVendor != vendor {
+			continue
+		}
+		if product != "" && product != cpeProduct && product != cpeVendor+"_"+cpeProduct &&
+			strings.TrimSuffix(cpeProduct, "_firmware") != product {
+			continue
+		}
+		matches = append(matches, cpe)
+	}
+	return matches
+}
+
+// coveringCPE returns the first of the CPEs that covers version, or nil
+func coveringCPE(cpes []CPEMatch, version string) *CPEMatch {
+	for i := range cpes {
+		if cpes[i].Covers(version) {
+			return &cpes[i]
+		}
+	}
+	return nil
+}
+
+// FilterByConfidence removes the vulnerabilities of a scan result whose
+// confidence score is below min and returns how many it removed.
+// Vulnerabilities without a score are kept.
+func FilterByConfidence(scanResult *ScanResult, min float64) int {
+	kept := make([]Vulnerability, 0, len(scanResult.Vulnerabilities))
+	for _, vuln := range scanResult.Vulnerabilities {
+		if score, found := scanResult.ConfidenceScore[vuln.ID]; found && score < min {
+			delete(scanResult.ConfidenceScore, vuln.ID)
+			delete(scanResult.MatchSources, vuln.ID)
+			continue
+		}
+		kept = append(kept, vuln)
+	}
+	removed := len(scanResult.Vulnerabilities) - len(kept)
+	scanResult.Vulnerabilities = kept
+	return removed
 }
 
 // calculateRiskScore calculates an overall risk score for a scan result
@@ -397,3 +552,57 @@ func calculateRiskScore(scanResult *ScanResult) float64 {
 
 	return normalizedScore
 }
+
+// emitFinding streams a matched vulnerability to the configured SIEM
+func emitFinding(target string, vuln Vulnerability) {
+	siem.Emit(vuln.Finding(target))
+}
+
+// LoadCorrelationInput reads a file saved by the OSINT tool to correlate
+// it: a scan result, server information or firmware information
+func LoadCorrelationInput(filename string) (*ScanResult, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ScanResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid result in %s: %v", filename, err)
+	}
+	if result.ServerInfo != nil || result.FirmwareInfo != nil {
+		return &result, nil
+	}
+
+	var serverInfo ServerInfo
+	if err := json.Unmarshal(data, &serverInfo); err == nil && serverInfo.ProductName != "" {
+		return NewServerScanResult(serverInfo.IPAddress, &serverInfo), nil
+	}
+	var firmwareInfo FirmwareInfo
+	if err := json.Unmarshal(data, &firmwareInfo); err == nil && firmwareInfo.Manufacturer != "" {
+		return NewFirmwareScanResult(&firmwareInfo), nil
+	}
+	return nil, fmt.Errorf("%s holds no server or firmware information", filename)
+}
+
+// NewServerScanResult creates the scan result to correlate server information
+func NewServerScanResult(target string, serverInfo *ServerInfo) *ScanResult {
+	return &ScanResult{
+		ID:         fmt.Sprintf("server_%s_%d", target, time.Now().Unix()),
+		Target:     target,
+		ScanType:   "ServerInfo",
+		ScanDate:   time.Now(),
+		ServerInfo: serverInfo,
+	}
+}
+
+// NewFirmwareScanResult creates the scan result to correlate firmware information
+func NewFirmwareScanResult(firmwareInfo *FirmwareInfo) *ScanResult {
+	return &ScanResult{
+		ID:           fmt.Sprintf("firmware_%s_%s_%d", firmwareInfo.Manufacturer, firmwareInfo.Model, time.Now().Unix()),
+		Target:       fmt.Sprintf("%s %s", firmwareInfo.Manufacturer, firmwareInfo.Model),
+		ScanType:     "FirmwareInfo",
+		ScanDate:     time.Now(),
+		FirmwareInfo: firmwareInfo,
+	}
+}
@@ -0,0 +1,112 @@
+package osint
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeVulnDB returns the same vulnerabilities for every search
+type fakeVulnDB []Vulnerability
+
+func (db fakeVulnDB) Search(query SearchQuery) ([]Vulnerability, error) { return db, nil }
+func (db fakeVulnDB) GetByID(id string) (*Vulnerability, error)         { return nil, nil }
+func (db fakeVulnDB) GetUpdates(since time.Time) ([]Vulnerability, error) {
+	return nil, nil
+}
+
+func TestCPEMatchCovers(t *testing.T) {
+	tests := []struct {
+		match   CPEMatch
+		version string
+		want    bool
+	}{
+		{CPEMatch{Criteria: "cpe:2.3:a:apache:http_server:2.4.29:*:*:*:*:*:*:*"}, "2.4.29", true},
+		{CPEMatch{Criteria: "cpe:2.3:a:apache:http_server:2.4.29:*:*:*:*:*:*:*"}, "2.4.30", false},
+		{CPEMatch{Criteria: "cpe:2.3:a:apache:http_server:*:*:*:*:*:*:*:*", VersionStartIncluding: "2.4.0", VersionEndExcluding: "2.4.52"}, "2.4.29", true},
+		{CPEMatch{Criteria: "cpe:2.3:a:apache:http_server:*:*:*:*:*:*:*:*", VersionStartIncluding: "2.4.0", VersionEndExcluding: "2.4.52"}, "2.4.52", false},
+		{CPEMatch{Criteria: "cpe:2.3:a:openbsd:openssh:*:*:*:*:*:*:*:*", VersionEndIncluding: "8.5p1"}, "8.2p1", true},
+		{CPEMatch{Criteria: "cpe:2.3:o:netgear:r7000_firmware:*:*:*:*:*:*:*:*"}, "1.0.11.116", true},
+	}
+	for _, tt := range tests {
+		if got := tt.match.Covers(tt.version); got != tt.want {
+			t.Errorf("%+v.Covers(%s) = %v, want %v", tt.match, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestCorrelatorSourceWeighting(t *testing.T) {
+	byCPE := Vulnerability{
+		ID:          "CVE-2021-44790",
+		Title:       "Buffer overflow in mod_lua",
+		Description: "A carefully crafted request body can cause a buffer overflow in the mod_lua multipart parser.",
+		Severity:    SeverityCritical,
+		CVSS:        9.8,
+		CPEs: []CPEMatch{{
+			Criteria:            "cpe:2.3:a:apache:http_server:*:*:*:*:*:*:*:*",
+			VersionEndIncluding: "2.4.51",
+		}},
+	}
+	byKeyword := Vulnerability{
+		ID:          "CVE-2018-1312",
+		Title:       "Apache HTTP Server 2.4.29 digest authentication replay",
+		Description: "In Apache HTTP Server 2.4.29, the nonce of HTTP Digest authentication could be replayed.",
+		Severity:    SeverityHigh,
+		CVSS:        7.5,
+	}
+	otherProduct := Vulnerability{
+		ID:          "CVE-2021-23017",
+		Description: "A security issue in the nginx resolver.",
+		Severity:    SeverityHigh,
+		CPEs:        []CPEMatch{{Criteria: "cpe:2.3:a:f5:nginx:*:*:*:*:*:*:*:*"}},
+	}
+	serverInfo := &ServerInfo{IPAddress: "192.0.2.10", ProductName: "Apache HTTP Server", ProductVersion: "2.4.29"}
+
+	tests := []struct {
+		name      string
+		threshold float64
+		want      map[string]string // Matched vulnerabilities and their source
+	}{
+		{"Default threshold", DefaultConfidenceThreshold, map[string]string{"CVE-2021-44790": MatchCPE, "CVE-2018-1312": MatchKeyword}},
+		{"CPE matches only", 0.7, map[string]string{"CVE-2021-44790": MatchCPE}},
+		{"Nothing", 0.95, map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			correlator := NewCorrelator(fakeVulnDB{byCPE, byKeyword, otherProduct})
+			correlator.MatchThreshold = tt.threshold
+			result := NewServerScanResult("192.0.2.10", serverInfo)
+			if err := correlator.CorrelateScanResults(result); err != nil {
+				t.Fatalf("CorrelateScanResults() error = %v", err)
+			}
+
+			if len(result.Vulnerabilities) != len(tt.want) {
+				t.Fatalf("CorrelateScanResults() matched %d vulnerabilities, want %v", len(result.Vulnerabilities), tt.want)
+			}
+			for _, vuln := range result.Vulnerabilities {
+				if source, ok := tt.want[vuln.ID]; !ok || result.MatchSources[vuln.ID] != source {
+					t.Errorf("%s matched by %q, want %v", vuln.ID, result.MatchSources[vuln.ID], tt.want)
+				}
+				if result.ConfidenceScore[vuln.ID] < tt.threshold {
+					t.Errorf("%s confidence %.2f is below the threshold %.2f", vuln.ID, result.ConfidenceScore[vuln.ID], tt.threshold)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterByConfidence(t *testing.T) {
+	result := &ScanResult{
+		Vulnerabilities: []Vulnerability{{ID: "CVE-1"}, {ID: "CVE-2"}, {ID: "CVE-3"}},
+		ConfidenceScore: map[string]float64{"CVE-1": 0.9, "CVE-2": 0.5},
+		MatchSources:    map[string]string{"CVE-1": MatchCPE, "CVE-2": MatchKeyword},
+	}
+	if removed := FilterByConfidence(result, 0.6); removed != 1 {
+		t.Errorf("FilterByConfidence() removed %d, want 1", removed)
+	}
+	if len(result.Vulnerabilities) != 2 || result.Vulnerabilities[0].ID != "CVE-1" || result.Vulnerabilities[1].ID != "CVE-3" {
+		t.Errorf("FilterByConfidence() kept %+v, want CVE-1 and the unscored CVE-3", result.Vulnerabilities)
+	}
+	if _, found := result.MatchSources["CVE-2"]; found {
+		t.Error("FilterByConfidence() kept the source of a removed match")
+	}
+}
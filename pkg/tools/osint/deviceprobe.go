@@ -0,0 +1,497 @@
+// pkg/tools/osint/deviceprobe.go
+package osint
+
+import (
+	"GopherStrike/pkg/egress"
+	"GopherStrike/pkg/httpclient"
+	"GopherStrike/pkg/scope"
+	"GopherStrike/pkg/stats"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DeviceProbeOptions configures the network probes of a device
+type DeviceProbeOptions struct {
+	Timeout   time.Duration // Time to wait for each probe
+	Community string        // SNMP community
+	UPnP      bool
+	SNMP      bool
+	HTTP      bool
+}
+
+// DefaultDeviceProbeOptions returns the default device probe options
+func DefaultDeviceProbeOptions() DeviceProbeOptions {
+	return DeviceProbeOptions{
+		Timeout:   3 * time.Second,
+		Community: "public",
+		UPnP:      true,
+		SNMP:      true,
+		HTTP:      true,
+	}
+}
+
+// DeviceProbe is what the network probes learned about a device. Each
+// detail comes from the first probe that found it, in the order UPnP, SNMP,
+// web interface, as the structured answers are the most reliable.
+type DeviceProbe struct {
+	Address  string        `json:"address"`
+	Info     *FirmwareInfo `json:"info"`
+	Sources  []string      `json:"sources"`  // Probes that answered
+	Evidence []string      `json:"evidence"` // Where each detail came from
+}
+
+// deviceDetails are the details a single probe found
+type deviceDetails struct {
+	source, deviceType, manufacturer, model, firmware string
+}
+
+// upnpRoot is the part of a UPnP device description that names the device.
+// Firmware versions are not standard; some devices add them as extra elements.
+type upnpRoot struct {
+	Device struct {
+		DeviceType       string `xml:"deviceType"`
+		FriendlyName     string `xml:"friendlyName"`
+		Manufacturer     string `xml:"manufacturer"`
+		ModelName        string `xml:"modelName"`
+		ModelNumber      string `xml:"modelNumber"`
+		ModelDescription string `xml:"modelDescription"`
+		FirmwareVersion  string `xml:"firmwareVersion"`
+		SoftwareVersion  string `xml:"softwareVersion"`
+	} `xml:"device"`
+}
+
+// upnpDeviceTypes names the standard UPnP device types
+var upnpDeviceTypes = map[string]string{
+	"InternetGatewayDevice": "Router",
+	"WANDevice":             "Router",
+	"WLANAccessPointDevice": "Access point",
+	"MediaServer":           "Media server",
+	"MediaRenderer":         "Media player",
+	"Printer":               "Printer",
+	"DigitalSecurityCamera": "Camera",
+}
+
+// upnpVersionRegex matches model numbers that are firmware versions
+var upnpVersionRegex = regexp.MustCompile(`^[vV]?\d+(\.\d+)+`)
+
+// upnpDescriptionPaths are tried when the device does not answer SSDP
+var upnpDescriptionPaths = []string{
+	"http://%s:5000/rootDesc.xml",
+	"http://%s:49152/description.xml",
+	"http://%s:1900/rootDesc.xml",
+}
+
+// sysDescr vendors, firmware and models, for the descriptions of common
+// network devices such as "Cisco IOS Software, C2960 Software (...),
+// Version 12.2(55)SE5" or "RouterOS RB951Ui-2HnD"
+var (
+	snmpVendors = []struct{ keyword, vendor string }{
+		{"cisco", "Cisco"}, {"routeros", "MikroTik"}, {"mikrotik", "MikroTik"}, {"junos", "Juniper"},
+		{"juniper", "Juniper"}, {"jetdirect", "HP"}, {"procurve", "HP"}, {"aruba", "Aruba"},
+		{"fortigate", "Fortinet"}, {"netgear", "NETGEAR"}, {"edgeos", "Ubiquiti"}, {"ubnt", "Ubiquiti"},
+		{"zyxel", "Zyxel"}, {"d-link", "D-Link"}, {"tp-link", "TP-Link"}, {"synology", "Synology"},
+		{"qnap", "QNAP"}, {"hikvision", "Hikvision"}, {"axis", "Axis"}, {"huawei", "Huawei"},
+		{"draytek", "DrayTek"}, {"brother", "Brother"}, {"ricoh", "Ricoh"}, {"xerox", "Xerox"},
+	}
+	snmpFirmwareRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bVersion\s+v?(\d[\w.()-]*)`),
+		regexp.MustCompile(`(?i)RouterOS\s+v?(\d+\.\d+(?:\.\d+)?)`),
+		regexp.MustCompile(`(?i)JUNOS\s+(\d[\w.-]*)`),
+		regexp.MustCompile(`(?i)\b(?:firmware|fw|sw)[ :_-]*v?(\d+(?:\.\d+)+[\w.-]*)`),
+	}
+	snmpModelRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)Cisco IOS Software,\s*(?:[\w-]+\s+)?(C\d+\w*)\s+Software`),
+		regexp.MustCompile(`(?i)RouterOS\s+(RB[\w-]+|CCR[\w-]+|CRS[\w-]+|hAP[\w -]*)`),
+		regexp.MustCompile(`(?i)JETDIRECT,\s*(J\w+)`),
+		regexp.MustCompile(`(?i)\bmodel[ :]+([\w-]*\d[\w-]*)`),
+	}
+)
+
+// ProbeDevice probes a device at address, an IP address or host, over
+// UPnP, SNMP and its web interface and merges what they report
+func ProbeDevice(ctx context.Context, address string, options DeviceProbeOptions, fingerprints []DeviceFingerprint) (*DeviceProbe, error) {
+	if err := scope.Check(ctx, address); err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	found := make([]*deviceDetails, 3)
+	probes := []struct {
+		enabled bool
+		probe   func() *deviceDetails
+	}{
+		{options.UPnP, func() *deviceDetails { return probeUPnP(ctx, address, options.Timeout) }},
+		{options.SNMP, func() *deviceDetails { return probeSNMP(ctx, address, options.Community, options.Timeout) }},
+		{options.HTTP, func() *deviceDetails { return probeWebInterface(ctx, address, fingerprints) }},
+	}
+	for i, p := range probes {
+		if !p.enabled {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, probe func() *deviceDetails) {
+			defer wg.Done()
+			found[i] = probe()
+		}(i, p.probe)
+	}
+	wg.Wait()
+
+	result := &DeviceProbe{Address: address, Info: &FirmwareInfo{}, Sources: []string{}, Evidence: []string{}}
+	for _, d := range found {
+		if d == nil {
+			continue
+		}
+		result.Sources = append(result.Sources, d.source)
+		for _, field := range []struct {
+			name  string
+			value string
+			dest  *string
+		}{
+			{"device type", d.deviceType, &result.Info.DeviceType},
+			{"manufacturer", d.manufacturer, &result.Info.Manufacturer},
+			{"model", d.model, &result.Info.Model},
+			{"firmware version", d.firmware, &result.Info.FirmwareVersion},
+		} {
+			if field.value != "" && *field.dest == "" {
+				*field.dest = field.value
+				result.Evidence = append(result.Evidence, fmt.Sprintf("%s %q from %s", field.name, field.value, d.source))
+			}
+		}
+	}
+	if len(result.Sources) == 0 {
+		return nil, fmt.Errorf("no UPnP, SNMP or known web interface answered on %s", address)
+	}
+	return result, nil
+}
+
+// probeUPnP asks the device for the location of its UPnP description over
+// SSDP, or tries the usual locations, and reads the description
+func probeUPnP(ctx context.Context, address string, timeout time.Duration) *deviceDetails {
+	client := httpclient.New("osint", timeout, nil)
+	locations := []string{}
+	if location := ssdpLocation(ctx, address, timeout); location != "" {
+		locations = append(locations, location)
+	}
+	host := address
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	for _, path := range upnpDescriptionPaths {
+		locations = append(locations, fmt.Sprintf(path, host))
+	}
+
+	for _, location := range locations {
+		// A description elsewhere than on the device is not trusted
+		if u, err := url.Parse(location); err != nil || u.Hostname() != address {
+			continue
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", location, nil)
+		if err != nil {
+			continue
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			continue
+		}
+		if details := parseUPnPDescription(body); details != nil {
+			return details
+		}
+	}
+	return nil
+}
+
+// ssdpLocation sends an SSDP search to the device and returns the location
+// of its description, or "" if it does not answer
+func ssdpLocation(ctx context.Context, address string, timeout time.Duration) string {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := stats.DialContext(egress.DialContext("osint", dialer.DialContext))(ctx, "udp", net.JoinHostPort(address, "1900"))
+	if err != nil {
+		return ""
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	request := "M-SEARCH * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\nMAN: \"ssdp:discover\"\r\nMX: 1\r\nST: upnp:rootdevice\r\n\r\n"
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return ""
+	}
+	buffer := make([]byte, 4096)
+	n, err := conn.Read(buffer)
+	if err != nil {
+		return ""
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buffer[:n])), nil)
+	if err != nil {
+		return ""
+	}
+	resp.Body.Close()
+	return resp.Header.Get("Location")
+}
+
+// parseUPnPDescription reads the device details of a UPnP description
+func parseUPnPDescription(data []byte) *deviceDetails {
+	var root upnpRoot
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil
+	}
+	device := root.Device
+	if device.Manufacturer == "" && device.ModelName == "" {
+		return nil
+	}
+
+	details := &deviceDetails{source: "UPnP", manufacturer: strings.TrimSpace(device.Manufacturer)}
+	details.model = strings.TrimSpace(device.ModelName)
+	if number := strings.TrimSpace(device.ModelNumber); number != "" && !strings.Contains(details.model, number) {
+		// Model numbers are either part of the model or, on many routers, the firmware version
+		if upnpVersionRegex.MatchString(number) {
+			details.firmware = strings.TrimLeft(number, "vV")
+		} else {
+			details.model = strings.TrimSpace(details.model + " " + number)
+		}
+	}
+	for _, version := range []string{device.FirmwareVersion, device.SoftwareVersion} {
+		if version = strings.TrimSpace(version); version != "" {
+			details.firmware = version
+			break
+		}
+	}
+
+	// urn:schemas-upnp-org:device:InternetGatewayDevice:1
+	parts := strings.Split(device.DeviceType, ":")
+	if len(parts) >= 4 {
+		details.deviceType = parts[3]
+		if name, ok := upnpDeviceTypes[parts[3]]; ok {
+			details.deviceType = name
+		}
+	}
+	return details
+}
+
+// probeSNMP reads the sysDescr of the device over SNMPv2c
+func probeSNMP(ctx context.Context, address, community string, timeout time.Duration) *deviceDetails {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := stats.DialContext(egress.DialContext("osint", dialer.DialContext))(ctx, "udp", net.JoinHostPort(address, "161"))
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	requestID := int(time.Now().UnixNano() & 0x7fffffff)
+	if _, err := conn.Write(snmpGetRequest(community, requestID, sysDescrOID)); err != nil {
+		return nil
+	}
+	buffer := make([]byte, 65535)
+	n, err := conn.Read(buffer)
+	if err != nil {
+		return nil
+	}
+	description, err := parseSNMPResponse(buffer[:n], requestID)
+	if err != nil || description == "" {
+		return nil
+	}
+	return parseSysDescr(description)
+}
+
+// parseSysDescr reads the vendor, device type, model and firmware version
+// from a sysDescr
+func parseSysDescr(description string) *deviceDetails {
+	details := &deviceDetails{source: "SNMP"}
+	lower := strings.ToLower(description)
+	for _, v := range snmpVendors {
+		if strings.Contains(lower, v.keyword) {
+			details.manufacturer = v.vendor
+			break
+		}
+	}
+	switch {
+	case strings.Contains(lower, "printer") || strings.Contains(lower, "jetdirect"):
+		details.deviceType = "Printer"
+	case strings.Contains(lower, "camera") || strings.Contains(lower, "hikvision"):
+		details.deviceType = "Camera"
+	case strings.Contains(lower, "switch") || strings.Contains(lower, "procurve"):
+		details.deviceType = "Switch"
+	case strings.Contains(lower, "firewall") || strings.Contains(lower, "fortigate"):
+		details.deviceType = "Firewall"
+	case strings.Contains(lower, "router") || strings.Contains(lower, "routeros"):
+		details.deviceType = "Router"
+	}
+	for _, re := range snmpModelRegexes {
+		if m := re.FindStringSubmatch(description); m != nil {
+			details.model = strings.TrimSpace(m[1])
+			break
+		}
+	}
+	for _, re := range snmpFirmwareRegexes {
+		if m := re.FindStringSubmatch(description); m != nil {
+			details.firmware = strings.TrimRight(m[1], ".,")
+			break
+		}
+	}
+	return details
+}
+
+// probeWebInterface identifies the web interface of the device over HTTP,
+// then HTTPS
+func probeWebInterface(ctx context.Context, address string, fingerprints []DeviceFingerprint) *deviceDetails {
+	host := address
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	for _, scheme := range []string{"http", "https"} {
+		match, err := DetectDevice(ctx, scheme+"://"+host, fingerprints)
+		if err != nil || match == nil {
+			continue
+		}
+		return &deviceDetails{
+			source:       "web interface (" + match.Name + ")",
+			deviceType:   match.DeviceType,
+			manufacturer: match.Manufacturer,
+			model:        match.Model,
+			firmware:     match.FirmwareVersion,
+		}
+	}
+	return nil
+}
+
+// sysDescrOID is SNMPv2-MIB::sysDescr.0, 1.3.6.1.2.1.1.1.0
+var sysDescrOID = []byte{0x2b, 0x06, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00}
+
+// ASN.1 BER tags used by SNMP
+const (
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berNull        = 0x05
+	berOID         = 0x06
+	berSequence    = 0x30
+	snmpGet        = 0xa0
+	snmpResponse   = 0xa2
+)
+
+// berEncode encodes a BER element
+func berEncode(tag byte, value []byte) []byte {
+	length := len(value)
+	var header []byte
+	switch {
+	case length < 0x80:
+		header = []byte{tag, byte(length)}
+	case length < 0x100:
+		header = []byte{tag, 0x81, byte(length)}
+	default:
+		header = []byte{tag, 0x82, byte(length >> 8), byte(length)}
+	}
+	return append(header, value...)
+}
+
+// berInt encodes a non-negative integer
+func berInt(n int) []byte {
+	value := []byte{byte(n)}
+	for n > 0xff {
+		n >>= 8
+		value = append([]byte{byte(n)}, value...)
+	}
+	if value[0]&0x80 != 0 {
+		value = append([]byte{0}, value...)
+	}
+	return berEncode(berInteger, value)
+}
+
+// snmpGetRequest builds an SNMPv2c GetRequest for one object
+func snmpGetRequest(community string, requestID int, oid []byte) []byte {
+	varbind := berEncode(berSequence, append(berEncode(berOID, oid), berNull, 0))
+	pdu := berEncode(snmpGet, bytes.Join([][]byte{
+		berInt(requestID), berInt(0), berInt(0), berEncode(berSequence, varbind),
+	}, nil))
+	return berEncode(berSequence, bytes.Join([][]byte{
+		berInt(1), berEncode(berOctetString, []byte(community)), pdu,
+	}, nil))
+}
+
+// berDecode splits the first BER element off data
+func berDecode(data []byte) (byte, []byte, []byte, error) {
+	if len(data) < 2 {
+		return 0, nil, nil, fmt.Errorf("truncated BER element")
+	}
+	tag, length, offset := data[0], int(data[1]), 2
+	if length&0x80 != 0 {
+		octets := length & 0x7f
+		if octets == 0 || octets > 2 || len(data) < 2+octets {
+			return 0, nil, nil, fmt.Errorf("unsupported BER length")
+		}
+		length = 0
+		for _, b := range data[2 : 2+octets] {
+			length = length<<8 | int(b)
+		}
+		offset += octets
+	}
+	if len(data) < offset+length {
+		return 0, nil, nil, fmt.Errorf("truncated BER element")
+	}
+	return tag, data[offset : offset+length], data[offset+length:], nil
+}
+
+// parseSNMPResponse returns the value of the first object of an SNMP
+// response to the request with requestID, if it is a string
+func parseSNMPResponse(data []byte, requestID int) (string, error) {
+	// Message: version, community, PDU
+	tag, message, _, err := berDecode(data)
+	if err != nil || tag != berSequence {
+		return "", fmt.Errorf("not an SNMP message")
+	}
+	var pdu []byte
+	for i := 0; i < 3; i++ {
+		if tag, pdu, message, err = berDecode(message); err != nil {
+			return "", err
+		}
+	}
+	if tag != snmpResponse {
+		return "", fmt.Errorf("not an SNMP response")
+	}
+
+	// PDU: request ID, error status, error index, variable bindings
+	fields := make([][]byte, 4)
+	for i := range fields {
+		if _, fields[i], pdu, err = berDecode(pdu); err != nil {
+			return "", err
+		}
+	}
+	if !bytes.Equal(berInt(requestID)[2:], fields[0]) {
+		return "", fmt.Errorf("response to another request")
+	}
+	if len(fields[1]) != 1 || fields[1][0] != 0 {
+		return "", fmt.Errorf("SNMP error status %v", fields[1])
+	}
+
+	// First binding: name, value
+	_, binding, _, err := berDecode(fields[3])
+	if err != nil {
+		return "", err
+	}
+	if _, _, binding, err = berDecode(binding); err != nil {
+		return "", err
+	}
+	tag, value, _, err := berDecode(binding)
+	if err != nil {
+		return "", err
+	}
+	if tag != berOctetString {
+		return "", fmt.Errorf("sysDescr is not a string")
+	}
+	return strings.TrimSpace(string(value)), nil
+}
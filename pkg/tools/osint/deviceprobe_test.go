@@ -0,0 +1,120 @@
+package osint
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSNMPMessages(t *testing.T) {
+	request := snmpGetRequest("public", 0x1234abcd, sysDescrOID)
+	tag, message, rest, err := berDecode(request)
+	if err != nil || tag != berSequence || len(rest) != 0 {
+		t.Fatalf("berDecode(request) = %#x, %v, rest %d, want one sequence", tag, err, len(rest))
+	}
+	if !bytes.Contains(message, []byte("public")) || !bytes.Contains(message, sysDescrOID) {
+		t.Errorf("request %x lacks the community or the OID", request)
+	}
+
+	// A response carrying a description longer than 127 bytes uses the long length form
+	description := "Cisco IOS Software, C2960 Software (C2960-LANBASEK9-M), Version 12.2(55)SE5, RELEASE SOFTWARE (fc1) " +
+		strings.Repeat("Technical Support: http://www.cisco.com/techsupport ", 3)
+	response := func(requestID, status int, value []byte) []byte {
+		varbind := berEncode(berSequence, append(berEncode(berOID, sysDescrOID), berEncode(berOctetString, value)...))
+		pdu := berEncode(snmpResponse, bytes.Join([][]byte{
+			berInt(requestID), berInt(status), berInt(0), berEncode(berSequence, varbind),
+		}, nil))
+		return berEncode(berSequence, bytes.Join([][]byte{berInt(1), berEncode(berOctetString, []byte("public")), pdu}, nil))
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{"sysDescr", response(0x1234abcd, 0, []byte(description)), strings.TrimSpace(description), false},
+		{"Other request", response(42, 0, []byte(description)), "", true},
+		{"Error status", response(0x1234abcd, 2, nil), "", true},
+		{"Request instead of response", request, "", true},
+		{"Truncated", response(0x1234abcd, 0, []byte(description))[:40], "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSNMPResponse(tt.data, 0x1234abcd)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseSNMPResponse() = %q, %v, want %q, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSysDescr(t *testing.T) {
+	tests := []struct {
+		description string
+		want        deviceDetails
+	}{
+		{
+			"Cisco IOS Software, C2960 Software (C2960-LANBASEK9-M), Version 12.2(55)SE5, RELEASE SOFTWARE (fc1)",
+			deviceDetails{manufacturer: "Cisco", model: "C2960", firmware: "12.2(55)SE5"},
+		},
+		{
+			"RouterOS RB951Ui-2HnD",
+			deviceDetails{manufacturer: "MikroTik", deviceType: "Router", model: "RB951Ui-2HnD"},
+		},
+		{
+			"HP ETHERNET MULTI-ENVIRONMENT,ROM none,JETDIRECT,JD153,EEPROM JSI23900",
+			deviceDetails{manufacturer: "HP", deviceType: "Printer", model: "JD153"},
+		},
+		{
+			"Linux nas 4.4.59+ #25426 SMP PREEMPT x86_64, synology DS918+ firmware 7.1.1",
+			deviceDetails{manufacturer: "Synology", firmware: "7.1.1"},
+		},
+	}
+	for _, tt := range tests {
+		got := parseSysDescr(tt.description)
+		tt.want.source = "SNMP"
+		if *got != tt.want {
+			t.Errorf("parseSysDescr(%q) = %+v, want %+v", tt.description, *got, tt.want)
+		}
+	}
+}
+
+func TestParseUPnPDescription(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+		want *deviceDetails
+	}{
+		{
+			name: "Gateway with the firmware as model number",
+			xml: `<?xml version="1.0"?><root xmlns="urn:schemas-upnp-org:device-1-0"><device>
+				<deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+				<friendlyName>Home Router</friendlyName><manufacturer>NETGEAR, Inc.</manufacturer>
+				<modelName>R7000</modelName><modelNumber>V1.0.11.116</modelNumber></device></root>`,
+			want: &deviceDetails{source: "UPnP", deviceType: "Router", manufacturer: "NETGEAR, Inc.", model: "R7000", firmware: "1.0.11.116"},
+		},
+		{
+			name: "Media server with a software version",
+			xml: `<root><device><deviceType>urn:schemas-upnp-org:device:MediaServer:1</deviceType>
+				<manufacturer>Synology Inc</manufacturer><modelName>DS918+</modelName><modelNumber>DSM</modelNumber>
+				<softwareVersion>7.1.1-42962</softwareVersion></device></root>`,
+			want: &deviceDetails{source: "UPnP", deviceType: "Media server", manufacturer: "Synology Inc", model: "DS918+ DSM", firmware: "7.1.1-42962"},
+		},
+		{
+			name: "Vendor device type",
+			xml:  `<root><device><deviceType>urn:schemas-wifialliance-org:device:WFADevice:1</deviceType><manufacturer>TP-Link</manufacturer><modelName>Archer C7</modelName></device></root>`,
+			want: &deviceDetails{source: "UPnP", deviceType: "WFADevice", manufacturer: "TP-Link", model: "Archer C7"},
+		},
+		{name: "No device", xml: `<root><specVersion><major>1</major></specVersion></root>`},
+		{name: "Not XML", xml: `<html><body>Not found</body>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseUPnPDescription([]byte(tt.xml))
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("parseUPnPDescription() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
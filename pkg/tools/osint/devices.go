@@ -0,0 +1,398 @@
+// pkg/tools/osint/devices.go
+package osint
+
+import (
+	"GopherStrike/pkg/httpclient"
+	"context"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DeviceFingerprint identifies the web interface of a router or IoT device.
+// Text is compared without case.
+type DeviceFingerprint struct {
+	Name            string   `json:"name"`
+	Manufacturer    string   `json:"manufacturer"`
+	DeviceType      string   `json:"device_type"`      // Router, Camera, NAS, etc.
+	Titles          []string `json:"titles"`           // Text in the page title
+	Headers         []string `json:"headers"`          // Text in the Server header or the authentication realm
+	Body            []string `json:"body"`             // Text in the page
+	Favicons        []string `json:"favicons"`         // MD5 hashes of /favicon.ico in hexadecimal
+	Paths           []string `json:"paths"`            // Paths that only exist on the device, e.g. /HNAP1/
+	ModelPattern    string   `json:"model_pattern"`    // Regular expression capturing the model as its first group
+	FirmwarePattern string   `json:"firmware_pattern"` // Regular expression capturing the firmware version
+}
+
+// DeviceFingerprintsFile holds fingerprints added to the built-in ones
+const DeviceFingerprintsFile = "fingerprints/devices.json"
+
+// DefaultDeviceFingerprints are the admin interfaces of common routers and
+// IoT devices
+var DefaultDeviceFingerprints = []DeviceFingerprint{
+	{
+		Name: "NETGEAR router", Manufacturer: "NETGEAR", DeviceType: "Router",
+		Titles: []string{"NETGEAR"}, Headers: []string{"NETGEAR"}, Body: []string{"routerlogin.net"},
+		Paths:        []string{"/currentsetting.htm"},
+		ModelPattern: `(?i)(?:NETGEAR\s+|Model=)([A-Z]{1,5}\d{3,5}[A-Z0-9]*(?:v\d)?)`,
+	},
+	{
+		Name: "TP-Link router", Manufacturer: "TP-Link", DeviceType: "Router",
+		Titles: []string{"TP-LINK", "TL-WR"}, Headers: []string{"TP-LINK"}, Body: []string{"tplinkwifi.net", "tplinklogin.net"},
+		ModelPattern: `(?i)\b(TL-[A-Z]+\d+[A-Z0-9]*|Archer\s?[A-Z]+\d+[A-Z0-9]*)`,
+	},
+	{
+		Name: "ASUS router", Manufacturer: "ASUS", DeviceType: "Router",
+		Titles: []string{"ASUS Wireless Router", "ASUS Login"}, Body: []string{"ASUSWRT", "router.asus.com"},
+		Paths:        []string{"/Main_Login.asp"},
+		ModelPattern: `\b((?:RT|GT|TUF|ZenWiFi)-[A-Z]*\d+[A-Z0-9_+]*)`,
+	},
+	{
+		Name: "Linksys router", Manufacturer: "Linksys", DeviceType: "Router",
+		Titles: []string{"Linksys Smart Wi-Fi", "Linksys"}, Headers: []string{"Linksys"}, Body: []string{"linksyssmartwifi.com"},
+		ModelPattern: `(?i)Linksys\s+((?:WRT|EA|E|MR|MX)\d{3,5}[A-Z0-9]*)`,
+	},
+	{
+		Name: "D-Link device", Manufacturer: "D-Link", DeviceType: "Router",
+		Titles: []string{"D-LINK"}, Headers: []string{"D-Link"}, Body: []string{"dlinkrouter.local"},
+		Paths:        []string{"/HNAP1/"},
+		ModelPattern: `(?i)\b((?:DIR|DCS|DSL|DNS|DWR|DAP|DSR)-\d+[A-Z0-9]*)`,
+	},
+	{
+		Name: "MikroTik RouterOS", Manufacturer: "MikroTik", DeviceType: "Router",
+		Titles: []string{"RouterOS router configuration page", "mikrotik routeros"}, Body: []string{"mikrotik"},
+		Paths:           []string{"/webfig/"},
+		FirmwarePattern: `RouterOS\s+v(\d+\.\d+(?:\.\d+)?)`,
+	},
+	{
+		Name: "Ubiquiti device", Manufacturer: "Ubiquiti", DeviceType: "Access point",
+		Titles: []string{"airOS", "UniFi", "EdgeOS"}, Body: []string{"ubnt", "ubiquiti"},
+		FirmwarePattern: `(?i)(?:airOS|EdgeOS)\s+v?(\d+\.\d+(?:\.\d+)?)`,
+	},
+	{
+		Name: "AVM FRITZ!Box", Manufacturer: "AVM", DeviceType: "Router",
+		Titles: []string{"FRITZ!Box"}, Body: []string{"fritz.box"},
+		ModelPattern:    `FRITZ!Box\s+(\d{4}(?:\s?(?:Cable|LTE|AX|5G))?)`,
+		FirmwarePattern: `(?i)FRITZ!OS[:\s]+(\d+\.\d+)`,
+	},
+	{
+		Name: "Zyxel device", Manufacturer: "Zyxel", DeviceType: "Router",
+		Titles: []string{"ZyXEL", "Zyxel"}, Headers: []string{"ZyXEL"},
+		ModelPattern: `(?i)\b((?:VMG|NBG|USG|EMG|NSA|ZyWALL\s?|LTE)\d{3,5}[A-Z0-9-]*)`,
+	},
+	{
+		Name: "DrayTek Vigor", Manufacturer: "DrayTek", DeviceType: "Router",
+		Titles: []string{"Vigor"}, Headers: []string{"Vigor", "DrayTek"},
+		ModelPattern: `(?i)(Vigor\s?\d{3,5}[A-Z0-9]*)`,
+	},
+	{
+		Name: "Huawei home gateway", Manufacturer: "Huawei", DeviceType: "Router",
+		Titles: []string{"HG8245", "HG8546", "HUAWEI"}, Headers: []string{"Huawei"},
+		ModelPattern: `\b((?:HG|EG|HS)\d{4}[A-Z0-9]*|B\d{3}-\d{3})\b`,
+	},
+	{
+		Name: "Cisco small business router", Manufacturer: "Cisco", DeviceType: "Router",
+		Titles: []string{"Cisco RV", "Small Business Router"}, Headers: []string{"Cisco RV"},
+		ModelPattern: `\b(RV\d{3}[A-Z0-9]*)`,
+	},
+	{
+		Name: "OpenWrt LuCI", Manufacturer: "OpenWrt", DeviceType: "Router",
+		Titles: []string{"LuCI", "OpenWrt"}, Body: []string{"luci-static", "Powered by LuCI"},
+		Paths:           []string{"/cgi-bin/luci"},
+		FirmwarePattern: `(?i)OpenWrt\s+(\d+\.\d+(?:\.\d+)?(?:-rc\d+)?)`,
+	},
+	{
+		Name: "Hikvision camera", Manufacturer: "Hikvision", DeviceType: "Camera",
+		Headers: []string{"App-webs", "DNVRS-Webs", "Hikvision"}, Body: []string{"doc/page/login.asp"},
+		Paths:        []string{"/doc/page/login.asp"},
+		ModelPattern: `\b(DS-\d[A-Z0-9-]+)`,
+	},
+	{
+		Name: "Axis camera", Manufacturer: "Axis", DeviceType: "Camera",
+		Titles: []string{"AXIS"}, Headers: []string{"AXIS"}, Body: []string{"axis-cgi"},
+		ModelPattern: `AXIS\s+([A-Z]\d{4}[A-Z0-9-]*)`,
+	},
+	{
+		Name: "Synology DiskStation", Manufacturer: "Synology", DeviceType: "NAS",
+		Titles: []string{"Synology DiskStation", "Synology Router"}, Body: []string{"synology"},
+		ModelPattern: `\b(DS\d{3,4}\+?|RT\d{4}ac)`,
+	},
+}
+
+// Signals and their weight in the confidence of a match. A match needs
+// matchThreshold, so the text of a page alone is not enough.
+const (
+	weightTitle    = 0.5
+	weightHeader   = 0.5
+	weightBody     = 0.25
+	weightFavicon  = 0.6
+	weightPath     = 0.35
+	matchThreshold = 0.5
+	maxPageSize    = 512 * 1024
+)
+
+// Patterns extracting the model and firmware version from any page, used
+// when the patterns of the fingerprint find nothing. Labels may be followed
+// by markup, quotes or separators, as in "Firmware Version:</td><td>V1.0.4"
+// or <ModelName>DIR-868L</ModelName>.
+var (
+	genericModelRegex    = regexp.MustCompile(`(?i)(?:model|product)[ _-]?(?:name|number|no\.?)?(?:<[^>]*>|[\s:="'>])+([a-z0-9-]*\d[a-z0-9-]*)`)
+	genericFirmwareRegex = regexp.MustCompile(`(?i)(?:firmware|fw)[ _-]?(?:version|ver)?(?:<[^>]*>|[\s:="'>])+v?(\d+(?:\.\d+){1,3}(?:[._-][a-z0-9]+)*)`)
+	titleRegex           = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	realmRegex           = regexp.MustCompile(`(?i)realm="([^"]*)"`)
+)
+
+// DeviceMatch is a device identified from its web interface
+type DeviceMatch struct {
+	Name            string   `json:"name"` // Name of the fingerprint
+	Manufacturer    string   `json:"manufacturer"`
+	DeviceType      string   `json:"device_type"`
+	Model           string   `json:"model"`
+	FirmwareVersion string   `json:"firmware_version"`
+	URL             string   `json:"url"`
+	Confidence      float64  `json:"confidence"`
+	Evidence        []string `json:"evidence"`
+}
+
+// FirmwareInfo returns the match as firmware information for the correlator
+func (m *DeviceMatch) FirmwareInfo() *FirmwareInfo {
+	return &FirmwareInfo{
+		DeviceType:      m.DeviceType,
+		Manufacturer:    m.Manufacturer,
+		Model:           m.Model,
+		FirmwareVersion: m.FirmwareVersion,
+	}
+}
+
+// devicePage is a page fetched from the web interface
+type devicePage struct {
+	status int
+	title  string
+	header string // Server header and authentication realm
+	body   string
+}
+
+// text returns everything the model and firmware can be extracted from
+func (p *devicePage) text() string {
+	return p.title + "\n" + p.header + "\n" + p.body
+}
+
+// LoadDeviceFingerprints returns the built-in fingerprints and those of
+// DeviceFingerprintsFile, if it exists
+func LoadDeviceFingerprints() ([]DeviceFingerprint, error) {
+	fingerprints := append([]DeviceFingerprint{}, DefaultDeviceFingerprints...)
+	data, err := os.ReadFile(DeviceFingerprintsFile)
+	if os.IsNotExist(err) {
+		return fingerprints, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device fingerprints: %v", err)
+	}
+
+	var custom []DeviceFingerprint
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("invalid device fingerprints in %s: %v", DeviceFingerprintsFile, err)
+	}
+	for _, f := range custom {
+		for _, pattern := range []string{f.ModelPattern, f.FirmwarePattern} {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("invalid pattern in device fingerprint %s: %v", f.Name, err)
+			}
+		}
+	}
+	return append(custom, fingerprints...), nil
+}
+
+// DetectDevice fetches the web interface at target, a URL or a host, and
+// identifies the device with the fingerprints, extracting its model and
+// firmware version from the pages. It returns nil if no fingerprint matches.
+func DetectDevice(ctx context.Context, target string, fingerprints []DeviceFingerprint) (*DeviceMatch, error) {
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+	target = strings.TrimRight(target, "/")
+
+	// Devices use self-signed certificates
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	client := httpclient.New("osint", 10*time.Second, transport)
+
+	page, err := fetchDevicePage(ctx, client, target+"/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", target, err)
+	}
+	favicon := ""
+	if icon, err := fetchDevicePage(ctx, client, target+"/favicon.ico"); err == nil && icon.status == http.StatusOK && icon.body != "" {
+		sum := md5.Sum([]byte(icon.body))
+		favicon = hex.EncodeToString(sum[:])
+	}
+
+	best, bestScore, bestEvidence := -1, 0.0, []string(nil)
+	for i, f := range fingerprints {
+		if confidence, evidence := scorePage(f, page, favicon); confidence > bestScore {
+			best, bestScore, bestEvidence = i, confidence, evidence
+		}
+	}
+
+	// Paths tell devices apart when the page did not, unless the server
+	// answers every path
+	probed := make(map[string]*devicePage)
+	probe := func(path string) *devicePage {
+		if p, ok := probed[path]; ok {
+			return p
+		}
+		p, err := fetchDevicePage(ctx, client, target+path)
+		if err != nil || p.status != http.StatusOK {
+			p = nil
+		}
+		probed[path] = p
+		return p
+	}
+	if bestScore < matchThreshold && probe(fmt.Sprintf("/gopherstrike-%d", time.Now().UnixNano())) == nil {
+		for i, f := range fingerprints {
+			confidence, evidence := scorePage(f, page, favicon)
+			for _, path := range f.Paths {
+				p := probe(path)
+				if p == nil {
+					continue
+				}
+				confidence += weightPath
+				evidence = append(evidence, "path "+path)
+				// The login page is often only behind the path
+				if pathConfidence, pathEvidence := scorePage(f, p, ""); pathConfidence > 0 {
+					confidence += pathConfidence
+					for _, e := range pathEvidence {
+						evidence = append(evidence, e+" at "+path)
+					}
+				}
+			}
+			if confidence > bestScore {
+				best, bestScore, bestEvidence = i, confidence, evidence
+			}
+		}
+	}
+	if bestScore < matchThreshold {
+		return nil, nil
+	}
+
+	// The paths of the device often name the model and firmware, as the
+	// HNAP1 answer of D-Link devices does
+	fingerprint := fingerprints[best]
+	pages := []*devicePage{page}
+	for _, path := range fingerprint.Paths {
+		if p := probe(path); p != nil {
+			pages = append(pages, p)
+		}
+	}
+
+	sort.Strings(bestEvidence)
+	return &DeviceMatch{
+		Name:            fingerprint.Name,
+		Manufacturer:    fingerprint.Manufacturer,
+		DeviceType:      fingerprint.DeviceType,
+		Model:           extractDeviceValue(pages, fingerprint.ModelPattern, genericModelRegex),
+		FirmwareVersion: extractDeviceValue(pages, fingerprint.FirmwarePattern, genericFirmwareRegex),
+		URL:             target,
+		Confidence:      min(bestScore, 1),
+		Evidence:        bestEvidence,
+	}, nil
+}
+
+// fetchDevicePage fetches a page, following redirects to the login page
+func fetchDevicePage(ctx context.Context, client *http.Client, url string) (*devicePage, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 GopherStrike OSINT Scanner")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	page := &devicePage{status: resp.StatusCode, body: string(body), header: resp.Header.Get("Server")}
+	if m := realmRegex.FindStringSubmatch(resp.Header.Get("WWW-Authenticate")); m != nil {
+		page.header += "\n" + m[1]
+	}
+	if m := titleRegex.FindStringSubmatch(page.body); m != nil {
+		page.title = strings.TrimSpace(html.UnescapeString(m[1]))
+	}
+	return page, nil
+}
+
+// scorePage returns the confidence that a page belongs to the device of a
+// fingerprint and the signals that matched
+func scorePage(f DeviceFingerprint, page *devicePage, favicon string) (float64, []string) {
+	confidence := 0.0
+	evidence := []string{}
+	if s := findText(page.title, f.Titles); s != "" {
+		confidence += weightTitle
+		evidence = append(evidence, fmt.Sprintf("title contains %q", s))
+	}
+	if s := findText(page.header, f.Headers); s != "" {
+		confidence += weightHeader
+		evidence = append(evidence, fmt.Sprintf("server or realm contains %q", s))
+	}
+	if s := findText(page.body, f.Body); s != "" {
+		confidence += weightBody
+		evidence = append(evidence, fmt.Sprintf("page contains %q", s))
+	}
+	if favicon != "" {
+		for _, hash := range f.Favicons {
+			if strings.EqualFold(hash, favicon) {
+				confidence += weightFavicon
+				evidence = append(evidence, "favicon "+favicon)
+				break
+			}
+		}
+	}
+	return confidence, evidence
+}
+
+// findText returns the first of texts found in s, ignoring case
+func findText(s string, texts []string) string {
+	s = strings.ToLower(s)
+	for _, text := range texts {
+		if text != "" && strings.Contains(s, strings.ToLower(text)) {
+			return text
+		}
+	}
+	return ""
+}
+
+// extractDeviceValue returns the first group of the pattern of the
+// fingerprint in the pages, or else of the generic pattern
+func extractDeviceValue(pages []*devicePage, pattern string, generic *regexp.Regexp) string {
+	patterns := []*regexp.Regexp{}
+	if pattern != "" {
+		if re, err := regexp.Compile(pattern); err == nil {
+			patterns = append(patterns, re)
+		}
+	}
+	patterns = append(patterns, generic)
+
+	for _, re := range patterns {
+		for _, page := range pages {
+			if m := re.FindStringSubmatch(page.text()); len(m) > 1 && m[1] != "" {
+				return strings.TrimSpace(m[1])
+			}
+		}
+	}
+	return ""
+}
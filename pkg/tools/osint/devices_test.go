@@ -0,0 +1,99 @@
+package osint
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetectDevice(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		want     string
+		model    string
+		firmware string
+	}{
+		{
+			name: "NETGEAR realm and settings page",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/currentsetting.htm":
+					fmt.Fprint(w, "Firmware=V1.0.11.116_10.2.100\nRegTag=NA\nRegion=us\nModel=R7000\n")
+				case "/":
+					w.Header().Set("WWW-Authenticate", `Basic realm="NETGEAR R7000"`)
+					w.WriteHeader(http.StatusUnauthorized)
+				default:
+					http.NotFound(w, r)
+				}
+			},
+			want: "NETGEAR router", model: "R7000", firmware: "1.0.11.116_10.2.100",
+		},
+		{
+			name: "D-Link HNAP answer",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/HNAP1/":
+					fmt.Fprint(w, "<GetDeviceSettingsResponse><ModelName>DIR-868L</ModelName><FirmwareVersion>2.05B02</FirmwareVersion></GetDeviceSettingsResponse>")
+				case "/":
+					fmt.Fprint(w, "<html><head><title>D-LINK SYSTEMS, INC. | WIRELESS ROUTER | HOME</title></head></html>")
+				default:
+					http.NotFound(w, r)
+				}
+			},
+			want: "D-Link device", model: "DIR-868L", firmware: "2.05",
+		},
+		{
+			name: "OpenWrt found by its path",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/cgi-bin/luci":
+					fmt.Fprint(w, `<a href="https://github.com/openwrt/luci">Powered by LuCI openwrt-21.02</a> / OpenWrt 21.02.3 r16554`)
+				case "/":
+					fmt.Fprint(w, "<html><body>Loading</body></html>")
+				default:
+					http.NotFound(w, r)
+				}
+			},
+			want: "OpenWrt LuCI", firmware: "21.02.3",
+		},
+		{
+			name: "Unknown site",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, "<html><head><title>Welcome</title></head><body>Our product 2024 catalog</body></html>")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			match, err := DetectDevice(context.Background(), server.URL, DefaultDeviceFingerprints)
+			if err != nil {
+				t.Fatalf("DetectDevice() error = %v", err)
+			}
+			if tt.want == "" {
+				if match != nil {
+					t.Errorf("DetectDevice() = %s, want no match", match.Name)
+				}
+				return
+			}
+			if match == nil {
+				t.Fatalf("DetectDevice() found nothing, want %s", tt.want)
+			}
+			if match.Name != tt.want || match.Model != tt.model || match.FirmwareVersion != tt.firmware {
+				t.Errorf("DetectDevice() = %s, model %q, firmware %q, want %s, model %q, firmware %q",
+					match.Name, match.Model, match.FirmwareVersion, tt.want, tt.model, tt.firmware)
+			}
+
+			info := match.FirmwareInfo()
+			if info.Manufacturer == "" || info.Model != match.Model || info.FirmwareVersion != match.FirmwareVersion {
+				t.Errorf("FirmwareInfo() = %+v, want the details of the match", info)
+			}
+		})
+	}
+}
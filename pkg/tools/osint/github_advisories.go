@@ -0,0 +1,205 @@
+// pkg/tools/osint/github_advisories.go
+package osint
+
+import (
+	"GopherStrike/pkg/httpclient"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitHubAdvisoryConnector implements VulnDBConnector for the GitHub
+// Security Advisories of package ecosystems. A token raises the rate limit
+// of 60 requests per hour.
+type GitHubAdvisoryConnector struct {
+	Token       string
+	BaseURL     string
+	CacheDir    string
+	cacheExpiry time.Duration
+	cacheLock   sync.RWMutex
+	client      *http.Client
+}
+
+// githubAdvisory is an advisory of the global advisories API
+type githubAdvisory struct {
+	GHSAID      string    `json:"ghsa_id"`
+	CVEID       string    `json:"cve_id"`
+	HTMLURL     string    `json:"html_url"`
+	Summary     string    `json:"summary"`
+	Description string    `json:"description"`
+	Severity    string    `json:"severity"`
+	References  []string  `json:"references"`
+	PublishedAt time.Time `json:"published_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CVSS        struct {
+		Score float64 `json:"score"`
+	} `json:"cvss"`
+	Vulnerabilities []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		VulnerableVersionRange string `json:"vulnerable_version_range"`
+	} `json:"vulnerabilities"`
+}
+
+// NewGitHubAdvisoryConnector creates a new GitHub advisories connector with
+// an optional token
+func NewGitHubAdvisoryConnector(token string) *GitHubAdvisoryConnector {
+	cacheDir := filepath.Join("logs", defaultCacheDir, "github")
+	_ = os.MkdirAll(cacheDir, 0755)
+
+	return &GitHubAdvisoryConnector{
+		Token:       token,
+		BaseURL:     "https://api.github.com/advisories",
+		CacheDir:    cacheDir,
+		cacheExpiry: cacheDuration,
+		client:      httpclient.New("osint", 30*time.Second, nil),
+	}
+}
+
+// Search lists the advisories of the CVE IDs and of the products, given as
+// package names, optionally with a version as in lodash@4.17.20. The API
+// has no keyword search, so queries of keywords only find nothing.
+func (c *GitHubAdvisoryConnector) Search(query SearchQuery) ([]Vulnerability, error) {
+	cacheKey := generateCacheKey("search", query)
+	if vulns, found := c.checkCache(cacheKey); found {
+		return vulns, nil
+	}
+
+	requests := make([]url.Values, 0)
+	for _, id := range query.CVEIDs {
+		requests = append(requests, url.Values{"cve_id": {id}})
+	}
+	if len(query.Products) > 0 {
+		requests = append(requests, url.Values{"affects": {strings.Join(query.Products, ",")}})
+	}
+
+	vulns := make([]Vulnerability, 0)
+	for _, params := range requests {
+		if !query.FromDate.IsZero() {
+			params.Set("published", ">="+query.FromDate.Format("2006-01-02"))
+		}
+		if query.MaxResults > 0 {
+			params.Set("per_page", strconv.Itoa(min(query.MaxResults, 100)))
+		}
+		advisories, err := c.list(params)
+		if err != nil {
+			return nil, err
+		}
+		for _, advisory := range advisories {
+			vulns = append(vulns, advisory.vulnerability())
+		}
+	}
+
+	c.cacheResults(cacheKey, vulns)
+	return vulns, nil
+}
+
+// GetByID retrieves an advisory by its GHSA or CVE ID
+func (c *GitHubAdvisoryConnector) GetByID(id string) (*Vulnerability, error) {
+	params := url.Values{"cve_id": {id}}
+	if strings.HasPrefix(strings.ToUpper(id), "GHSA-") {
+		params = url.Values{"ghsa_id": {id}}
+	}
+	advisories, err := c.list(params)
+	if err != nil {
+		return nil, err
+	}
+	if len(advisories) == 0 {
+		return nil, fmt.Errorf("vulnerability not found: %s", id)
+	}
+	vuln := advisories[0].vulnerability()
+	return &vuln, nil
+}
+
+// GetUpdates lists the advisories updated since a given date
+func (c *GitHubAdvisoryConnector) GetUpdates(since time.Time) ([]Vulnerability, error) {
+	advisories, err := c.list(url.Values{"updated": {">=" + since.Format("2006-01-02")}, "per_page": {"100"}})
+	if err != nil {
+		return nil, err
+	}
+	vulns := make([]Vulnerability, 0, len(advisories))
+	for _, advisory := range advisories {
+		vulns = append(vulns, advisory.vulnerability())
+	}
+	return vulns, nil
+}
+
+// list requests the advisories matching params
+func (c *GitHubAdvisoryConnector) list(params url.Values) ([]githubAdvisory, error) {
+	req, err := http.NewRequest("GET", c.BaseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %s", resp.Status)
+	}
+
+	var advisories []githubAdvisory
+	if err := json.NewDecoder(resp.Body).Decode(&advisories); err != nil {
+		return nil, fmt.Errorf("error parsing response: %v", err)
+	}
+	return advisories, nil
+}
+
+// vulnerability converts an advisory. It is known by its CVE ID if it has
+// one, so it merges with the other databases.
+func (a githubAdvisory) vulnerability() Vulnerability {
+	vuln := Vulnerability{
+		ID:          a.GHSAID,
+		Title:       a.Summary,
+		Description: a.Description,
+		Severity:    parseSeverity(a.Severity),
+		CVSS:        a.CVSS.Score,
+		References:  a.References,
+		Published:   a.PublishedAt,
+		Modified:    a.UpdatedAt,
+		Source:      SourceGithub,
+		Provenance:  []Provenance{{Source: SourceGithub, ID: a.GHSAID, URL: a.HTMLURL}},
+	}
+	if a.CVEID != "" {
+		vuln.ID = a.CVEID
+		vuln.Aliases = []string{a.GHSAID}
+	}
+	for _, v := range a.Vulnerabilities {
+		system := v.Package.Ecosystem + "/" + v.Package.Name
+		if v.VulnerableVersionRange != "" {
+			system += " " + v.VulnerableVersionRange
+		}
+		vuln.AffectedSystems = append(vuln.AffectedSystems, system)
+	}
+	return vuln
+}
+
+// checkCache checks if cached results exist and are still valid
+func (c *GitHubAdvisoryConnector) checkCache(key string) ([]Vulnerability, bool) {
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
+	return readCache(c.CacheDir, key, c.cacheExpiry)
+}
+
+// cacheResults caches search results
+func (c *GitHubAdvisoryConnector) cacheResults(key string, vulns []Vulnerability) {
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
+	writeCache(c.CacheDir, key, vulns)
+}
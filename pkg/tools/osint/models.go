@@ -2,80 +2,145 @@
 package osint
 
 import (
+	"GopherStrike/pkg/model"
+	"strings"
 	"time"
 )
 
 // Severity represents the severity level of a vulnerability
-type Severity string
+type Severity = model.Severity
 
 const (
-	SeverityCritical Severity = "Critical"
-	SeverityHigh     Severity = "High"
-	SeverityMedium   Severity = "Medium"
-	SeverityLow      Severity = "Low"
-	SeverityNone     Severity = "None"
+	SeverityCritical = model.SeverityCritical
+	SeverityHigh     = model.SeverityHigh
+	SeverityMedium   = model.SeverityMedium
+	SeverityLow      = model.SeverityLow
+	SeverityNone     = model.SeverityNone
 )
 
 // Vulnerability represents a security vulnerability with its details
 type Vulnerability struct {
-	ID              string    `json:"id"`               // CVE ID
-	Title           string    `json:"title"`            // Short title
-	Description     string    `json:"description"`      // Detailed description
-	Severity        Severity  `json:"severity"`         // Severity level
-	CVSS            float64   `json:"cvss"`             // CVSS score
-	AffectedSystems []string  `json:"affected_systems"` // Affected systems/products
-	References      []string  `json:"references"`       // References URLs
-	Published       time.Time `json:"published"`        // Publication date
-	Modified        time.Time `json:"modified"`         // Last modification date
-	Exploits        []string  `json:"exploits"`         // Known exploits
-	Mitigations     []string  `json:"mitigations"`      // Recommended mitigations
-	Source          string    `json:"source"`           // Source of the information (NVD, ExploitDB, etc.)
+	ID              string       `json:"id"`                   // CVE ID
+	Title           string       `json:"title"`                // Short title
+	Description     string       `json:"description"`          // Detailed description
+	Severity        Severity     `json:"severity"`             // Severity level
+	CVSS            float64      `json:"cvss"`                 // CVSS score
+	AffectedSystems []string     `json:"affected_systems"`     // Affected systems/products
+	References      []string     `json:"references"`           // References URLs
+	Published       time.Time    `json:"published"`            // Publication date
+	Modified        time.Time    `json:"modified"`             // Last modification date
+	Exploits        []string     `json:"exploits"`             // Known exploits
+	Mitigations     []string     `json:"mitigations"`          // Recommended mitigations
+	Source          string       `json:"source"`               // Source of the information (NVD, ExploitDB, etc.)
+	CPEs            []CPEMatch   `json:"cpes,omitempty"`       // Vulnerable products and version ranges
+	Aliases         []string     `json:"aliases,omitempty"`    // Other IDs, e.g. GHSA-jfh8-c2jp-5v3q
+	Provenance      []Provenance `json:"provenance,omitempty"` // Databases the vulnerability was found in
+}
+
+// Provenance records a database a vulnerability was found in
+type Provenance struct {
+	Source string `json:"source"` // NVD, OSV, GitHub, Vulners
+	ID     string `json:"id"`     // ID in the database
+	URL    string `json:"url,omitempty"`
+}
+
+// CPEMatch is a vulnerable product, named by a CPE 2.3 such as
+// cpe:2.3:a:apache:http_server:*:*:*:*:*:*:*:*, and the range of its
+// vulnerable versions when the CPE does not name one
+type CPEMatch struct {
+	Criteria              string `json:"criteria"`
+	VersionStartIncluding string `json:"version_start_including,omitempty"`
+	VersionStartExcluding string `json:"version_start_excluding,omitempty"`
+	VersionEndIncluding   string `json:"version_end_including,omitempty"`
+	VersionEndExcluding   string `json:"version_end_excluding,omitempty"`
+}
+
+// Fields returns the vendor, product and version of the CPE
+func (m CPEMatch) Fields() (vendor, product, version string) {
+	parts := strings.Split(m.Criteria, ":")
+	if len(parts) < 6 {
+		return "", "", ""
+	}
+	return parts[3], parts[4], parts[5]
+}
+
+// Covers reports whether version is vulnerable: the version of the CPE, or
+// any version in the range, which is every version if it has no bounds
+func (m CPEMatch) Covers(version string) bool {
+	_, _, cpeVersion := m.Fields()
+	if cpeVersion != "*" && cpeVersion != "-" && cpeVersion != "" {
+		return CompareVersions(version, strings.ReplaceAll(cpeVersion, "\\", "")) == 0
+	}
+	return (m.VersionStartIncluding == "" || CompareVersions(version, m.VersionStartIncluding) >= 0) &&
+		(m.VersionStartExcluding == "" || CompareVersions(version, m.VersionStartExcluding) > 0) &&
+		(m.VersionEndIncluding == "" || CompareVersions(version, m.VersionEndIncluding) <= 0) &&
+		(m.VersionEndExcluding == "" || CompareVersions(version, m.VersionEndExcluding) < 0)
+}
+
+// Finding converts a vulnerability matched on a target into the finding
+// shared with the other tools
+func (v Vulnerability) Finding(target string) model.Finding {
+	return model.Finding{
+		Tool:        "osint",
+		Target:      target,
+		Category:    "CVE",
+		Name:        strings.TrimSpace(v.ID + " " + v.Title),
+		Severity:    v.Severity,
+		Description: v.Description,
+	}
 }
 
 // ServerInfo represents information about a server
 type ServerInfo struct {
-	IPAddress       string            `json:"ip_address"`
-	Hostname        string            `json:"hostname"`
-	OS              string            `json:"os"`
-	OSVersion       string            `json:"os_version"`
-	ProductName     string            `json:"product_name"`
-	ProductVersion  string            `json:"product_version"`
-	Ports           []int             `json:"ports"`
-	Services        map[int]string    `json:"services"` // Port to service mapping
-	Headers         map[string]string `json:"headers"`  // HTTP headers
-	Banners         map[int]string    `json:"banners"`  // Port to banner mapping
-	EOLDate         time.Time         `json:"eol_date"` // End of life date for OS/product
-	UpdateAvailable bool              `json:"update_available"`
-	FirstSeen       time.Time         `json:"first_seen"`
-	LastSeen        time.Time         `json:"last_seen"`
+	IPAddress       string             `json:"ip_address"`
+	Hostname        string             `json:"hostname"`
+	OS              string             `json:"os"`
+	OSVersion       string             `json:"os_version"`
+	ProductName     string             `json:"product_name"`
+	ProductVersion  string             `json:"product_version"`
+	CPE             string             `json:"cpe,omitempty"`          // CPE 2.3 of the product
+	Technologies    []ServiceDetection `json:"technologies,omitempty"` // Products found in headers and banners
+	Ports           []int              `json:"ports"`
+	Services        map[int]string     `json:"services"`            // Port to service mapping
+	Headers         map[string]string  `json:"headers"`             // HTTP headers
+	Banners         map[int]string     `json:"banners"`             // Port to banner mapping
+	TLSPorts        []int              `json:"tls_ports,omitempty"` // Ports whose banner was read over TLS
+	EOLDate         time.Time          `json:"eol_date"`            // End of life date for OS/product
+	UpdateAvailable bool               `json:"update_available"`
+	LatestVersion   string             `json:"latest_version"`           // Latest version of the product
+	VersionStatus   *VersionStatus     `json:"version_status,omitempty"` // Installed against latest version
+	FirstSeen       time.Time          `json:"first_seen"`
+	LastSeen        time.Time          `json:"last_seen"`
 }
 
 // FirmwareInfo represents information about device firmware
 type FirmwareInfo struct {
-	DeviceType      string    `json:"device_type"`      // Router, switch, camera, etc.
-	Manufacturer    string    `json:"manufacturer"`     // Device manufacturer
-	Model           string    `json:"model"`            // Device model
-	FirmwareVersion string    `json:"firmware_version"` // Current firmware version
-	ReleaseDate     time.Time `json:"release_date"`     // Release date of current version
-	LatestVersion   string    `json:"latest_version"`   // Latest available version
-	HasVulns        bool      `json:"has_vulns"`        // Has known vulnerabilities
-	Vulnerabilities []string  `json:"vulnerabilities"`  // References to vulnerabilities
-	EOLStatus       bool      `json:"eol_status"`       // End of life status
-	EOLDate         time.Time `json:"eol_date"`         // End of life date
+	DeviceType      string         `json:"device_type"`              // Router, switch, camera, etc.
+	Manufacturer    string         `json:"manufacturer"`             // Device manufacturer
+	Model           string         `json:"model"`                    // Device model
+	FirmwareVersion string         `json:"firmware_version"`         // Current firmware version
+	ReleaseDate     time.Time      `json:"release_date"`             // Release date of current version
+	LatestVersion   string         `json:"latest_version"`           // Latest available version
+	VersionStatus   *VersionStatus `json:"version_status,omitempty"` // Installed against latest version
+	HasVulns        bool           `json:"has_vulns"`                // Has known vulnerabilities
+	Vulnerabilities []string       `json:"vulnerabilities"`          // References to vulnerabilities
+	EOLStatus       bool           `json:"eol_status"`               // End of life status
+	EOLDate         time.Time      `json:"eol_date"`                 // End of life date
 }
 
 // ScanResult represents information from a vulnerability scan with matches
 type ScanResult struct {
 	ID              string             `json:"id"`
-	Target          string             `json:"target"`           // IP or hostname
-	ScanType        string             `json:"scan_type"`        // Type of scan
-	ScanDate        time.Time          `json:"scan_date"`        // Date of scan
-	ServerInfo      *ServerInfo        `json:"server_info"`      // Server information
-	FirmwareInfo    *FirmwareInfo      `json:"firmware_info"`    // Firmware information
-	Vulnerabilities []Vulnerability    `json:"vulnerabilities"`  // Matched vulnerabilities
-	RawData         interface{}        `json:"raw_data"`         // Raw scan data
-	ConfidenceScore map[string]float64 `json:"confidence_score"` // Confidence scores for each match
-	RiskScore       float64            `json:"risk_score"`       // Overall risk score
+	Target          string             `json:"target"`                  // IP or hostname
+	ScanType        string             `json:"scan_type"`               // Type of scan
+	ScanDate        time.Time          `json:"scan_date"`               // Date of scan
+	ServerInfo      *ServerInfo        `json:"server_info"`             // Server information
+	FirmwareInfo    *FirmwareInfo      `json:"firmware_info"`           // Firmware information
+	Vulnerabilities []Vulnerability    `json:"vulnerabilities"`         // Matched vulnerabilities
+	RawData         interface{}        `json:"raw_data"`                // Raw scan data
+	ConfidenceScore map[string]float64 `json:"confidence_score"`        // Confidence scores for each match
+	MatchSources    map[string]string  `json:"match_sources,omitempty"` // Most reliable source of each match
+	RiskScore       float64            `json:"risk_score"`              // Overall risk score
 }
 
 // MatchResult represents a match between scan data and vulnerability database
@@ -85,6 +150,7 @@ type MatchResult struct {
 	ConfidenceScore float64       `json:"confidence_score"`
 	MatchReason     string        `json:"match_reason"`
 	MatchedFields   []string      `json:"matched_fields"`
+	Source          string        `json:"source"` // Most reliable source of the match: CPE, affected or keyword
 }
 
 // SearchQuery represents a query to search for vulnerabilities
@@ -0,0 +1,227 @@
+// pkg/tools/osint/osv.go
+package osint
+
+import (
+	"GopherStrike/pkg/httpclient"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OSVConnector implements VulnDBConnector for OSV.dev, which aggregates the
+// advisories of package ecosystems (Go, npm, PyPI, Maven, Debian...) and
+// knows them by package rather than by product
+type OSVConnector struct {
+	BaseURL     string
+	CacheDir    string
+	cacheExpiry time.Duration
+	cacheLock   sync.RWMutex
+	client      *http.Client
+}
+
+// osvVulnerability is a vulnerability in the OSV schema
+type osvVulnerability struct {
+	ID        string    `json:"id"`
+	Summary   string    `json:"summary"`
+	Details   string    `json:"details"`
+	Aliases   []string  `json:"aliases"`
+	Published time.Time `json:"published"`
+	Modified  time.Time `json:"modified"`
+	Affected  []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Events []struct {
+				Fixed string `json:"fixed"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+	References []struct {
+		URL string `json:"url"`
+	} `json:"references"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// NewOSVConnector creates a new OSV.dev connector
+func NewOSVConnector() *OSVConnector {
+	cacheDir := filepath.Join("logs", defaultCacheDir, "osv")
+	_ = os.MkdirAll(cacheDir, 0755)
+
+	return &OSVConnector{
+		BaseURL:     "https://api.osv.dev/v1",
+		CacheDir:    cacheDir,
+		cacheExpiry: cacheDuration,
+		client:      httpclient.New("osint", 30*time.Second, nil),
+	}
+}
+
+// Search queries the vulnerabilities of the products, given as package
+// names, "ecosystem:name" such as npm:lodash, or package URLs such as
+// pkg:pypi/jinja2, at the first version of the query if any. OSV has no
+// keyword search, so queries of keywords only find nothing.
+func (c *OSVConnector) Search(query SearchQuery) ([]Vulnerability, error) {
+	cacheKey := generateCacheKey("search", query)
+	if vulns, found := c.checkCache(cacheKey); found {
+		return vulns, nil
+	}
+
+	vulns := make([]Vulnerability, 0)
+	for _, id := range query.CVEIDs {
+		vuln, err := c.GetByID(id)
+		if err != nil {
+			continue
+		}
+		vulns = append(vulns, *vuln)
+	}
+
+	for _, product := range query.Products {
+		request := map[string]interface{}{}
+		switch {
+		case strings.HasPrefix(product, "pkg:"):
+			request["package"] = map[string]string{"purl": product}
+		case strings.Contains(product, ":"):
+			parts := strings.SplitN(product, ":", 2)
+			request["package"] = map[string]string{"ecosystem": parts[0], "name": parts[1]}
+		default:
+			request["package"] = map[string]string{"name": product}
+		}
+		if len(query.Versions) > 0 {
+			request["version"] = query.Versions[0]
+		}
+
+		var response struct {
+			Vulns []osvVulnerability `json:"vulns"`
+		}
+		if err := c.post("/query", request, &response); err != nil {
+			return nil, err
+		}
+		for _, v := range response.Vulns {
+			vulns = append(vulns, v.vulnerability())
+		}
+	}
+
+	if query.MaxResults > 0 && len(vulns) > query.MaxResults {
+		vulns = vulns[:query.MaxResults]
+	}
+	c.cacheResults(cacheKey, vulns)
+	return vulns, nil
+}
+
+// GetByID retrieves a vulnerability by its OSV ID or one of its aliases,
+// such as a CVE ID
+func (c *OSVConnector) GetByID(id string) (*Vulnerability, error) {
+	resp, err := c.client.Get(c.BaseURL + "/vulns/" + url.PathEscape(id))
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("vulnerability not found: %s", id)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %s", resp.Status)
+	}
+
+	var v osvVulnerability
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return nil, fmt.Errorf("error parsing response: %v", err)
+	}
+	vuln := v.vulnerability()
+	return &vuln, nil
+}
+
+// GetUpdates is not supported: the OSV API has no query by date
+func (c *OSVConnector) GetUpdates(since time.Time) ([]Vulnerability, error) {
+	return nil, fmt.Errorf("OSV does not support listing updated vulnerabilities")
+}
+
+// post sends a JSON request to the API and decodes the response into v
+func (c *OSVConnector) post(path string, request interface{}, v interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Post(c.BaseURL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error making request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API error: %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error parsing response: %v", err)
+	}
+	return nil
+}
+
+// vulnerability converts an OSV vulnerability. It is known by its CVE ID
+// if it has one, so it merges with the other databases.
+func (v osvVulnerability) vulnerability() Vulnerability {
+	vuln := Vulnerability{
+		ID:          v.ID,
+		Title:       v.Summary,
+		Description: v.Details,
+		Severity:    parseSeverity(v.DatabaseSpecific.Severity),
+		Published:   v.Published,
+		Modified:    v.Modified,
+		Source:      SourceOSV,
+		Provenance:  []Provenance{{Source: SourceOSV, ID: v.ID, URL: "https://osv.dev/vulnerability/" + v.ID}},
+	}
+	for _, alias := range v.Aliases {
+		if strings.HasPrefix(alias, "CVE-") && !strings.HasPrefix(vuln.ID, "CVE-") {
+			vuln.Aliases = append(vuln.Aliases, vuln.ID)
+			vuln.ID = alias
+		} else {
+			vuln.Aliases = append(vuln.Aliases, alias)
+		}
+	}
+	if vuln.Title == "" {
+		vuln.Title = truncateString(v.Details, 80)
+	}
+	for _, affected := range v.Affected {
+		pkg := affected.Package.Ecosystem + "/" + affected.Package.Name
+		vuln.AffectedSystems = append(vuln.AffectedSystems, pkg)
+		for _, r := range affected.Ranges {
+			for _, event := range r.Events {
+				if event.Fixed != "" {
+					vuln.Mitigations = appendUnique(vuln.Mitigations, fmt.Sprintf("Upgrade %s to %s or later", pkg, event.Fixed))
+				}
+			}
+		}
+	}
+	for _, ref := range v.References {
+		vuln.References = append(vuln.References, ref.URL)
+	}
+	return vuln
+}
+
+// checkCache checks if cached results exist and are still valid
+func (c *OSVConnector) checkCache(key string) ([]Vulnerability, bool) {
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
+	return readCache(c.CacheDir, key, c.cacheExpiry)
+}
+
+// cacheResults caches search results
+func (c *OSVConnector) cacheResults(key string, vulns []Vulnerability) {
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
+	writeCache(c.CacheDir, key, vulns)
+}
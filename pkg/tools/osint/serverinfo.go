@@ -2,12 +2,10 @@
 package osint
 
 import (
-	"bufio"
+	"GopherStrike/pkg/httpclient"
 	"fmt"
-	"io"
 	"net"
 	"net/http"
-	"regexp"
 	"strings"
 	"time"
 )
@@ -21,27 +19,6 @@ type ProductInfo struct {
 	UpdateInfo string
 }
 
-var (
-	// Regular expressions for banner parsing
-	// Apache regex
-	apacheRegex = regexp.MustCompile(`Apache(?:/(\d+\.\d+(?:\.\d+)?))?`)
-
-	// Nginx regex
-	nginxRegex = regexp.MustCompile(`nginx(?:/(\d+\.\d+(?:\.\d+)?))?`)
-
-	// IIS regex
-	iisRegex = regexp.MustCompile(`Microsoft-IIS/(\d+\.\d+)`)
-
-	// OpenSSH regex
-	opensshRegex = regexp.MustCompile(`OpenSSH(?:_|/| )(\d+\.\d+(?:p\d+)?)`)
-
-	// Various OS regexes
-	ubuntuRegex  = regexp.MustCompile(`Ubuntu[/-](\d+\.\d+)`)
-	centosRegex  = regexp.MustCompile(`CentOS(?:/| )(\d+(?:\.\d+)?)`)
-	debianRegex  = regexp.MustCompile(`Debian (?:GNU/Linux )?(\d+(?:\.\d+)?)`)
-	windowsRegex = regexp.MustCompile(`(Windows) (?:NT )?(\d+\.\d+)`)
-)
-
 // GatherServerInfo collects server information from a target
 func GatherServerInfo(target string, ports []int) (*ServerInfo, error) {
 	// Initialize server info
@@ -72,7 +49,7 @@ func GatherServerInfo(target string, ports []int) (*ServerInfo, error) {
 
 	// If no ports provided, use common ones
 	if len(ports) == 0 {
-		ports = []int{21, 22, 25, 80, 443, 8080, 8443}
+		ports = []int{21, 22, 25, 80, 443, 465, 993, 995, 8080, 8443}
 	}
 
 	// Check for HTTP(S) service on common web ports
@@ -84,11 +61,14 @@ func GatherServerInfo(target string, ports []int) (*ServerInfo, error) {
 	// Gather banner information for other ports
 	otherPorts := filterExcludedPorts(ports, httpPorts)
 	for _, port := range otherPorts {
-		banner, service := getBanner(serverInfo.IPAddress, port)
+		banner, service, overTLS := getBanner(serverInfo.IPAddress, serverInfo.Hostname, port)
 		if banner != "" {
 			serverInfo.Banners[port] = banner
 			serverInfo.Services[port] = service
 			serverInfo.Ports = append(serverInfo.Ports, port)
+			if overTLS {
+				serverInfo.TLSPorts = append(serverInfo.TLSPorts, port)
+			}
 
 			// Try to identify OS/product from banner
 			processServiceBanner(serverInfo, port, banner)
@@ -125,12 +105,10 @@ func gatherHTTPInfo(serverInfo *ServerInfo, ports []int) {
 		}
 
 		// Make HTTP request with timeout
-		client := &http.Client{
-			Timeout: 10 * time.Second,
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				// Don't follow redirects
-				return http.ErrUseLastResponse
-			},
+		client := httpclient.New("osint", 10*time.Second, nil)
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			// Don't follow redirects
+			return http.ErrUseLastResponse
 		}
 
 		req, err := http.NewRequest("GET", url, nil)
@@ -161,6 +139,8 @@ func gatherHTTPInfo(serverInfo *ServerInfo, ports []int) {
 					processServerHeader(serverInfo, values[0])
 				case "x-powered-by":
 					processPoweredByHeader(serverInfo, values[0])
+				default:
+					processHTTPHeader(serverInfo, name, values[0])
 				}
 			}
 		}
@@ -170,34 +150,34 @@ func gatherHTTPInfo(serverInfo *ServerInfo, ports []int) {
 	}
 }
 
-// getBanner attempts to get a service banner from a port
-func getBanner(host string, port int) (string, string) {
-	// Connect with timeout
-	// Use net.JoinHostPort to properly handle IPv6 addresses
-	addr := net.JoinHostPort(host, fmt.Sprintf("%d", port))
-	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
-	if err != nil {
-		return "", ""
+// getBanner attempts to get a service banner from a port, speaking TLS on
+// the ports of TLS services and on ports that answered nothing in plaintext.
+// serverName is sent in the TLS handshake and HTTP probes when known.
+func getBanner(host, serverName string, port int) (string, string, bool) {
+	useTLS := tlsServices[port] != ""
+	banner, connected := grabBanner(host, serverName, port, useTLS)
+	if banner == "" && connected && !useTLS {
+		// Services on unusual ports often speak TLS
+		useTLS = true
+		banner, _ = grabBanner(host, serverName, port, true)
 	}
-	defer conn.Close()
-
-	// Set read deadline
-	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
-
-	// Try to read banner
-	reader := bufio.NewReader(conn)
-	banner, err := reader.ReadString('\n')
-	if err != nil && err != io.EOF {
-		return "", ""
+	if banner == "" {
+		return "", "", false
 	}
 
-	// Clean banner
-	banner = strings.TrimSpace(banner)
-
 	// Identify service based on port and banner
 	service := identifyService(port, banner)
+	if useTLS {
+		if name, found := tlsServices[port]; found {
+			service = name
+		} else if service == "HTTP" {
+			service = "HTTPS"
+		} else if service != "Unknown" {
+			service += " over TLS"
+		}
+	}
 
-	return banner, service
+	return banner, service, useTLS
 }
 
 // identifyService identifies a service based on port and banner
@@ -207,6 +187,9 @@ func identifyService(port int, banner string) string {
 		21:   "FTP",
 		22:   "SSH",
 		25:   "SMTP",
+		110:  "POP3",
+		143:  "IMAP",
+		587:  "SMTP",
 		80:   "HTTP",
 		443:  "HTTPS",
 		3306: "MySQL",
@@ -227,6 +210,10 @@ func identifyService(port int, banner string) string {
 
 	if strings.Contains(bannerLower, "ssh") {
 		return "SSH"
+	} else if strings.HasPrefix(banner, "* OK") {
+		return "IMAP"
+	} else if strings.HasPrefix(banner, "+OK") {
+		return "POP3"
 	} else if strings.Contains(bannerLower, "ftp") {
 		return "FTP"
 	} else if strings.Contains(bannerLower, "smtp") {
@@ -243,103 +230,97 @@ func identifyService(port int, banner string) string {
 	return "Unknown"
 }
 
-// processServerHeader extracts server information from the Server header
+// processServerHeader extracts server information from the Server header.
+// The first product found is the main product.
 func processServerHeader(serverInfo *ServerInfo, header string) {
-	// Check for Apache
-	if matches := apacheRegex.FindStringSubmatch(header); len(matches) > 1 {
-		serverInfo.ProductName = "Apache HTTP Server"
-		if matches[1] != "" {
-			serverInfo.ProductVersion = matches[1]
+	productSet, osSet := false, false
+	for _, detection := range detectServices(SourceServer, header) {
+		if detection.Kind == ServiceKindOS {
+			if !osSet {
+				serverInfo.OS = detection.Product
+				serverInfo.OSVersion = detection.Version
+				osSet = true
+			}
+			continue
 		}
-	}
 
-	// Check for Nginx
-	if matches := nginxRegex.FindStringSubmatch(header); len(matches) > 1 {
-		serverInfo.ProductName = "Nginx"
-		if matches[1] != "" {
-			serverInfo.ProductVersion = matches[1]
+		addTechnology(serverInfo, detection)
+		if !productSet {
+			setProduct(serverInfo, detection)
+			productSet = true
+		}
+		if detection.OS != "" && !osSet {
+			serverInfo.OS = detection.OS
 		}
-	}
-
-	// Check for IIS
-	if matches := iisRegex.FindStringSubmatch(header); len(matches) > 1 {
-		serverInfo.ProductName = "Microsoft IIS"
-		serverInfo.ProductVersion = matches[1]
-		serverInfo.OS = "Windows"
-	}
-
-	// Try to extract OS info
-	if matches := ubuntuRegex.FindStringSubmatch(header); len(matches) > 1 {
-		serverInfo.OS = "Ubuntu"
-		serverInfo.OSVersion = matches[1]
-	} else if matches := centosRegex.FindStringSubmatch(header); len(matches) > 1 {
-		serverInfo.OS = "CentOS"
-		serverInfo.OSVersion = matches[1]
-	} else if matches := debianRegex.FindStringSubmatch(header); len(matches) > 1 {
-		serverInfo.OS = "Debian"
-		serverInfo.OSVersion = matches[1]
-	} else if matches := windowsRegex.FindStringSubmatch(header); len(matches) > 1 {
-		serverInfo.OS = matches[1]
-		serverInfo.OSVersion = matches[2]
 	}
 }
 
 // processPoweredByHeader extracts information from X-Powered-By header
 func processPoweredByHeader(serverInfo *ServerInfo, header string) {
-	// Extract PHP version
-	phpRegex := regexp.MustCompile(`PHP/(\d+\.\d+(?:\.\d+)?)`)
-	if matches := phpRegex.FindStringSubmatch(header); len(matches) > 1 {
-		// Store as additional product if main product is already set
-		if serverInfo.ProductName != "" && serverInfo.ProductName != "PHP" {
-			serverInfo.ProductName += " with PHP " + matches[1]
-		} else {
-			serverInfo.ProductName = "PHP"
-			serverInfo.ProductVersion = matches[1]
+	for _, detection := range detectServices(SourcePoweredBy, header) {
+		if detection.Kind != ServiceKindProduct {
+			continue
 		}
-	}
+		addTechnology(serverInfo, detection)
 
-	// Extract ASP.NET version
-	aspNetRegex := regexp.MustCompile(`ASP\.NET(?:[/ ](\d+\.\d+(?:\.\d+)?))?`)
-	if matches := aspNetRegex.FindStringSubmatch(header); len(matches) > 0 {
 		// Store as additional product if main product is already set
-		if serverInfo.ProductName != "" && !strings.Contains(serverInfo.ProductName, "ASP.NET") {
-			serverInfo.ProductName += " with ASP.NET"
-			if matches[1] != "" {
-				serverInfo.ProductName += " " + matches[1]
-			}
-		} else {
-			serverInfo.ProductName = "ASP.NET"
-			if matches[1] != "" {
-				serverInfo.ProductVersion = matches[1]
+		switch {
+		case serverInfo.ProductName == "" || serverInfo.ProductName == detection.Product:
+			setProduct(serverInfo, detection)
+		case !strings.Contains(serverInfo.ProductName, detection.Product):
+			serverInfo.ProductName += " with " + detection.Product
+			if detection.Version != "" {
+				serverInfo.ProductName += " " + detection.Version
 			}
 		}
-		// ASP.NET implies Windows
-		serverInfo.OS = "Windows"
+		if detection.OS != "" {
+			serverInfo.OS = detection.OS
+		}
+	}
+}
+
+// processHTTPHeader extracts information from other headers, such as the
+// X-OWA-Version of Exchange, setting the main product if none is known
+func processHTTPHeader(serverInfo *ServerInfo, name, value string) {
+	for _, detection := range detectServices("header:"+name, value) {
+		if detection.Kind != ServiceKindProduct {
+			continue
+		}
+		addTechnology(serverInfo, detection)
+		if serverInfo.ProductName == "" {
+			setProduct(serverInfo, detection)
+		}
+		if detection.OS != "" && serverInfo.OS == "" {
+			serverInfo.OS = detection.OS
+		}
 	}
 }
 
 // processServiceBanner extracts information from service banners
 func processServiceBanner(serverInfo *ServerInfo, port int, banner string) {
-	// Extract SSH version
-	if port == 22 || strings.HasPrefix(strings.ToLower(banner), "ssh") {
-		if matches := opensshRegex.FindStringSubmatch(banner); len(matches) > 1 {
+	// HTTP probes of unusual ports return the Server header
+	banner, server, found := strings.Cut(banner, "; Server: ")
+	if found && serverInfo.ProductName == "" {
+		processServerHeader(serverInfo, server)
+	}
+
+	for _, detection := range detectServices(SourceBanner, banner) {
+		system := detection.OS
+		if detection.Kind == ServiceKindOS {
+			system = detection.Product
+		} else {
+			addTechnology(serverInfo, detection)
 			// Store as main product if not already set
 			if serverInfo.ProductName == "" {
-				serverInfo.ProductName = "OpenSSH"
-				serverInfo.ProductVersion = matches[1]
+				setProduct(serverInfo, detection)
 			}
 		}
 
-		// Try to extract OS from SSH banner
-		if serverInfo.OS == "" {
-			if strings.Contains(banner, "Ubuntu") {
-				serverInfo.OS = "Ubuntu"
-			} else if strings.Contains(banner, "Debian") {
-				serverInfo.OS = "Debian"
-			} else if strings.Contains(banner, "CentOS") {
-				serverInfo.OS = "CentOS"
-			} else if strings.Contains(banner, "Windows") {
-				serverInfo.OS = "Windows"
+		// Banners only fill in an unknown OS
+		if system != "" && serverInfo.OS == "" {
+			serverInfo.OS = system
+			if detection.Kind == ServiceKindOS {
+				serverInfo.OSVersion = detection.Version
 			}
 		}
 	}
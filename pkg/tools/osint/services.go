@@ -0,0 +1,208 @@
+// pkg/tools/osint/services.go
+package osint
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ServiceFingerprint identifies a product or operating system from the
+// Server and X-Powered-By headers, other HTTP headers or service banners
+type ServiceFingerprint struct {
+	Product string   `json:"product"`
+	Kind    string   `json:"kind,omitempty"` // product, the default, or os
+	Pattern string   `json:"pattern"`        // Regular expression whose first matching group is the version
+	Sources []string `json:"sources"`        // server, powered-by, banner or header:<name>, e.g. header:x-owa-version
+	CPE     string   `json:"cpe,omitempty"`  // CPE 2.3 of the product, {version} standing for the version
+	OS      string   `json:"os,omitempty"`   // Operating system the product runs on only
+
+	regex *regexp.Regexp
+}
+
+// Kinds of service fingerprints
+const (
+	ServiceKindProduct = "product"
+	ServiceKindOS      = "os"
+)
+
+// Sources of the text service fingerprints are matched against
+const (
+	SourceServer    = "server"
+	SourcePoweredBy = "powered-by"
+	SourceBanner    = "banner"
+)
+
+// ServiceFingerprintsFile holds fingerprints added to the built-in ones
+const ServiceFingerprintsFile = "fingerprints/services.json"
+
+// builtinServiceFingerprints are the fingerprints of common web servers,
+// frameworks, proxies and mail, FTP and SSH servers
+//
+//go:embed fingerprints/services.json
+var builtinServiceFingerprints []byte
+
+// ServiceDetection is a product or operating system identified by a
+// service fingerprint
+type ServiceDetection struct {
+	Product string `json:"product"`
+	Kind    string `json:"kind"`
+	Version string `json:"version,omitempty"`
+	CPE     string `json:"cpe,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Source  string `json:"source"`
+}
+
+var (
+	serviceFingerprints     []ServiceFingerprint
+	serviceFingerprintsOnce sync.Once
+)
+
+// parseServiceFingerprints decodes fingerprints and compiles their patterns
+func parseServiceFingerprints(data []byte, name string) ([]ServiceFingerprint, error) {
+	var fingerprints []ServiceFingerprint
+	if err := json.Unmarshal(data, &fingerprints); err != nil {
+		return nil, fmt.Errorf("invalid service fingerprints in %s: %v", name, err)
+	}
+	for i := range fingerprints {
+		f := &fingerprints[i]
+		if f.Product == "" || f.Pattern == "" || len(f.Sources) == 0 {
+			return nil, fmt.Errorf("service fingerprint %d in %s needs a product, a pattern and sources", i+1, name)
+		}
+		regex, err := regexp.Compile(f.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern in service fingerprint %s: %v", f.Product, err)
+		}
+		f.regex = regex
+		if f.Kind == "" {
+			f.Kind = ServiceKindProduct
+		}
+		for j := range f.Sources {
+			f.Sources[j] = strings.ToLower(f.Sources[j])
+		}
+	}
+	return fingerprints, nil
+}
+
+// LoadServiceFingerprints returns the built-in service fingerprints, after
+// the ones of ServiceFingerprintsFile if it exists
+func LoadServiceFingerprints() ([]ServiceFingerprint, error) {
+	fingerprints, err := parseServiceFingerprints(builtinServiceFingerprints, "built-in fingerprints")
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(ServiceFingerprintsFile)
+	if os.IsNotExist(err) {
+		return fingerprints, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service fingerprints: %v", err)
+	}
+
+	custom, err := parseServiceFingerprints(data, ServiceFingerprintsFile)
+	if err != nil {
+		return nil, err
+	}
+	return append(custom, fingerprints...), nil
+}
+
+// loadedServiceFingerprints returns the service fingerprints, loaded once.
+// An invalid fingerprints file is reported and the built-in ones are used.
+func loadedServiceFingerprints() []ServiceFingerprint {
+	serviceFingerprintsOnce.Do(func() {
+		fingerprints, err := LoadServiceFingerprints()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			fingerprints, _ = parseServiceFingerprints(builtinServiceFingerprints, "built-in fingerprints")
+		}
+		serviceFingerprints = fingerprints
+	})
+	return serviceFingerprints
+}
+
+// matchesSource reports whether a fingerprint applies to a source
+func (f ServiceFingerprint) matchesSource(source string) bool {
+	for _, s := range f.Sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// cpeFor returns the CPE of the product at version, "*" standing for an
+// unknown version
+func (f ServiceFingerprint) cpeFor(version string) string {
+	if f.CPE == "" {
+		return ""
+	}
+	if version == "" {
+		version = "*"
+	}
+	return strings.ReplaceAll(f.CPE, "{version}", version)
+}
+
+// DetectServices matches the fingerprints of a source against its text and
+// returns the products and operating systems found, in the order of the
+// fingerprints. Text matched by a product is not matched again, so more
+// specific fingerprints, listed first, shadow generic ones: Apache-Coyote is
+// Tomcat, not the Apache HTTP Server.
+func DetectServices(fingerprints []ServiceFingerprint, source, text string) []ServiceDetection {
+	source = strings.ToLower(source)
+	detections := make([]ServiceDetection, 0)
+	for _, f := range fingerprints {
+		if f.regex == nil || !f.matchesSource(source) {
+			continue
+		}
+		loc := f.regex.FindStringSubmatchIndex(text)
+		if loc == nil {
+			continue
+		}
+
+		version := ""
+		for i := 2; i+1 < len(loc); i += 2 {
+			if loc[i] >= 0 && loc[i+1] > loc[i] {
+				version = text[loc[i]:loc[i+1]]
+				break
+			}
+		}
+		detections = append(detections, ServiceDetection{
+			Product: f.Product,
+			Kind:    f.Kind,
+			Version: version,
+			CPE:     f.cpeFor(version),
+			OS:      f.OS,
+			Source:  source,
+		})
+		if f.Kind == ServiceKindProduct {
+			text = text[:loc[0]] + " " + text[loc[1]:]
+		}
+	}
+	return detections
+}
+
+// detectServices matches the loaded service fingerprints
+func detectServices(source, text string) []ServiceDetection {
+	return DetectServices(loadedServiceFingerprints(), source, text)
+}
+
+// addTechnology records a product found on a server once per version
+func addTechnology(serverInfo *ServerInfo, detection ServiceDetection) {
+	for _, t := range serverInfo.Technologies {
+		if t.Product == detection.Product && t.Version == detection.Version {
+			return
+		}
+	}
+	serverInfo.Technologies = append(serverInfo.Technologies, detection)
+}
+
+// setProduct makes a detection the main product of a server
+func setProduct(serverInfo *ServerInfo, detection ServiceDetection) {
+	serverInfo.ProductName = detection.Product
+	serverInfo.ProductVersion = detection.Version
+	serverInfo.CPE = detection.CPE
+}
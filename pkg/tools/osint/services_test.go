@@ -0,0 +1,129 @@
+package osint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectServices(t *testing.T) {
+	fingerprints, err := parseServiceFingerprints(builtinServiceFingerprints, "built-in fingerprints")
+	if err != nil {
+		t.Fatalf("built-in fingerprints: %v", err)
+	}
+
+	tests := []struct {
+		source  string
+		text    string
+		product string
+		version string
+		cpe     string
+	}{
+		{SourceServer, "Apache-Coyote/1.1", "Apache Tomcat", "", "cpe:2.3:a:apache:tomcat:*:*:*:*:*:*:*:*"},
+		{SourceServer, "Apache/2.4.41 (Ubuntu)", "Apache HTTP Server", "2.4.41", "cpe:2.3:a:apache:http_server:2.4.41:*:*:*:*:*:*:*"},
+		{SourceServer, "Jetty(9.4.44.v20210927)", "Jetty", "9.4.44", "cpe:2.3:a:eclipse:jetty:9.4.44:*:*:*:*:*:*:*"},
+		{SourceServer, "Microsoft-IIS/10.0", "Microsoft IIS", "10.0", "cpe:2.3:a:microsoft:internet_information_services:10.0:*:*:*:*:*:*:*"},
+		{SourceServer, "HAProxy version 2.4.22", "HAProxy", "2.4.22", "cpe:2.3:a:haproxy:haproxy:2.4.22:*:*:*:*:*:*:*"},
+		{SourcePoweredBy, "Express", "Express", "", "cpe:2.3:a:expressjs:express:*:*:*:*:*:*:*:*"},
+		{"header:via", "1.1 varnish (Varnish/6.0)", "Varnish", "6.0", "cpe:2.3:a:varnish_cache_project:varnish_cache:6.0:*:*:*:*:*:*:*"},
+		{"Header:X-OWA-Version", "15.1.2507.6", "Microsoft Exchange Server", "15.1.2507.6", "cpe:2.3:a:microsoft:exchange_server:15.1.2507.6:*:*:*:*:*:*:*"},
+		{SourceBanner, "220 mail.example.com ESMTP Exim 4.94.2 Mon, 01 Jan 2024", "Exim", "4.94.2", "cpe:2.3:a:exim:exim:4.94.2:*:*:*:*:*:*:*"},
+		{SourceBanner, "SSH-2.0-dropbear_2020.81", "Dropbear SSH", "2020.81", "cpe:2.3:a:dropbear_ssh_project:dropbear_ssh:2020.81:*:*:*:*:*:*:*"},
+	}
+	for _, tt := range tests {
+		detections := DetectServices(fingerprints, tt.source, tt.text)
+		if len(detections) == 0 {
+			t.Errorf("DetectServices(%s, %q) found nothing, want %s", tt.source, tt.text, tt.product)
+			continue
+		}
+		got := detections[0]
+		if got.Product != tt.product || got.Version != tt.version || got.CPE != tt.cpe {
+			t.Errorf("DetectServices(%s, %q) = %s %q %s, want %s %q %s", tt.source, tt.text,
+				got.Product, got.Version, got.CPE, tt.product, tt.version, tt.cpe)
+		}
+	}
+
+	// Tomcat shadows the Apache HTTP Server
+	for _, d := range DetectServices(fingerprints, SourceServer, "Apache-Coyote/1.1") {
+		if d.Product == "Apache HTTP Server" {
+			t.Error("Apache-Coyote detected as the Apache HTTP Server")
+		}
+	}
+	// Fingerprints only match their sources
+	if detections := DetectServices(fingerprints, SourceBanner, "Microsoft-IIS/10.0"); len(detections) != 0 {
+		t.Errorf("DetectServices() = %+v for a Server header in a banner", detections)
+	}
+}
+
+func TestProcessHeaders(t *testing.T) {
+	info := &ServerInfo{}
+	processServerHeader(info, "Apache/2.4.41 (Ubuntu) OpenSSL/1.1.1f")
+	processPoweredByHeader(info, "PHP/7.4.3")
+
+	if info.ProductName != "Apache HTTP Server with PHP 7.4.3" || info.ProductVersion != "2.4.41" {
+		t.Errorf("product = %s %s, want Apache HTTP Server with PHP 7.4.3 2.4.41", info.ProductName, info.ProductVersion)
+	}
+	if info.CPE != "cpe:2.3:a:apache:http_server:2.4.41:*:*:*:*:*:*:*" {
+		t.Errorf("CPE = %s, want the CPE of Apache", info.CPE)
+	}
+	if info.OS != "Ubuntu" {
+		t.Errorf("OS = %s, want Ubuntu", info.OS)
+	}
+	products := make([]string, 0)
+	for _, technology := range info.Technologies {
+		products = append(products, technology.Product)
+	}
+	if len(products) != 3 || products[0] != "Apache HTTP Server" || products[1] != "OpenSSL" || products[2] != "PHP" {
+		t.Errorf("technologies = %v, want Apache HTTP Server, OpenSSL and PHP", products)
+	}
+
+	info = &ServerInfo{}
+	processHTTPHeader(info, "X-OWA-Version", "15.1.2507.6")
+	if info.ProductName != "Microsoft Exchange Server" || info.OS != "Windows" {
+		t.Errorf("product = %s on %s, want Microsoft Exchange Server on Windows", info.ProductName, info.OS)
+	}
+}
+
+func TestProcessServiceBanner(t *testing.T) {
+	info := &ServerInfo{}
+	processServiceBanner(info, 22, "SSH-2.0-OpenSSH_8.2p1 Ubuntu-4ubuntu0.5")
+	if info.ProductName != "OpenSSH" || info.ProductVersion != "8.2p1" || info.OS != "Ubuntu" {
+		t.Errorf("banner gave %s %s on %s, want OpenSSH 8.2p1 on Ubuntu", info.ProductName, info.ProductVersion, info.OS)
+	}
+
+	// A later banner does not replace the main product
+	processServiceBanner(info, 21, "220 ProFTPD 1.3.5 Server (Debian)")
+	if info.ProductName != "OpenSSH" || info.OS != "Ubuntu" || len(info.Technologies) != 2 {
+		t.Errorf("second banner gave %s on %s with %+v, want OpenSSH on Ubuntu and ProFTPD among the technologies",
+			info.ProductName, info.OS, info.Technologies)
+	}
+}
+
+func TestLoadServiceFingerprints(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	os.MkdirAll(filepath.Dir(ServiceFingerprintsFile), 0755)
+	custom := `[{"product": "Acme Server", "pattern": "Apache/(\\d+)", "sources": ["server"]}]`
+	if err := os.WriteFile(ServiceFingerprintsFile, []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprints, err := LoadServiceFingerprints()
+	if err != nil {
+		t.Fatalf("LoadServiceFingerprints() error = %v", err)
+	}
+	// Custom fingerprints are tried first
+	if detections := DetectServices(fingerprints, SourceServer, "Apache/2.4.41"); len(detections) == 0 || detections[0].Product != "Acme Server" {
+		t.Errorf("DetectServices() = %+v, want the custom fingerprint first", detections)
+	}
+
+	os.WriteFile(ServiceFingerprintsFile, []byte(`[{"product": "Broken", "pattern": "(", "sources": ["server"]}]`), 0644)
+	if _, err := LoadServiceFingerprints(); err == nil {
+		t.Error("LoadServiceFingerprints() accepted an invalid pattern")
+	}
+}
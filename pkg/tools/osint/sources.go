@@ -0,0 +1,272 @@
+// pkg/tools/osint/sources.go
+package osint
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VulnDBOptions selects the vulnerability databases to query and their
+// credentials
+type VulnDBOptions struct {
+	Sources       []string // nvd, osv, github, vulners
+	NVDAPIKey     string
+	GitHubToken   string
+	VulnersAPIKey string
+}
+
+// DefaultVulnDBOptions returns the databases usable without credentials,
+// NVD, OSV and GitHub, plus Vulners when VULNERS_API_KEY is set, with the
+// credentials of the environment
+func DefaultVulnDBOptions() VulnDBOptions {
+	options := VulnDBOptions{
+		Sources:       []string{"nvd", "osv", "github"},
+		NVDAPIKey:     os.Getenv("NVD_API_KEY"),
+		GitHubToken:   os.Getenv("GITHUB_TOKEN"),
+		VulnersAPIKey: os.Getenv("VULNERS_API_KEY"),
+	}
+	if options.VulnersAPIKey != "" {
+		options.Sources = append(options.Sources, "vulners")
+	}
+	return options
+}
+
+// NewVulnDB creates the connector of the selected databases, merging their
+// results when there are several
+func NewVulnDB(options VulnDBOptions) (VulnDBConnector, error) {
+	multi := &MultiConnector{}
+	for _, source := range options.Sources {
+		switch strings.ToLower(strings.TrimSpace(source)) {
+		case "nvd":
+			multi.Sources = append(multi.Sources, SourceConnector{SourceNVD, NewNVDConnector(options.NVDAPIKey)})
+		case "osv":
+			multi.Sources = append(multi.Sources, SourceConnector{SourceOSV, NewOSVConnector()})
+		case "github", "ghsa":
+			multi.Sources = append(multi.Sources, SourceConnector{SourceGithub, NewGitHubAdvisoryConnector(options.GitHubToken)})
+		case "vulners":
+			if options.VulnersAPIKey == "" {
+				return nil, fmt.Errorf("the vulners source requires an API key (VULNERS_API_KEY)")
+			}
+			multi.Sources = append(multi.Sources, SourceConnector{SourceVulners, NewVulnersConnector(options.VulnersAPIKey)})
+		default:
+			return nil, fmt.Errorf("unknown vulnerability database %q, use nvd, osv, github or vulners", source)
+		}
+	}
+
+	switch len(multi.Sources) {
+	case 0:
+		return nil, fmt.Errorf("no vulnerability database selected")
+	case 1:
+		return multi.Sources[0].Connector, nil
+	}
+	return multi, nil
+}
+
+// SourceConnector is a vulnerability database and its name
+type SourceConnector struct {
+	Name      string
+	Connector VulnDBConnector
+}
+
+// MultiConnector implements VulnDBConnector over several databases. It
+// queries them in parallel and merges their results by CVE, recording the
+// databases each vulnerability was found in. A query fails only if every
+// database fails; the failures of the others go to OnError.
+type MultiConnector struct {
+	Sources []SourceConnector
+	OnError func(source string, err error)
+}
+
+// Search searches every database and merges the results
+func (m *MultiConnector) Search(query SearchQuery) ([]Vulnerability, error) {
+	return m.query(func(c VulnDBConnector) ([]Vulnerability, error) {
+		return c.Search(query)
+	})
+}
+
+// GetByID retrieves a vulnerability from every database and merges it
+func (m *MultiConnector) GetByID(id string) (*Vulnerability, error) {
+	vulns, err := m.query(func(c VulnDBConnector) ([]Vulnerability, error) {
+		vuln, err := c.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		return []Vulnerability{*vuln}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(vulns) == 0 {
+		return nil, fmt.Errorf("vulnerability not found: %s", id)
+	}
+	return &vulns[0], nil
+}
+
+// GetUpdates lists the vulnerabilities updated in every database that
+// supports it
+func (m *MultiConnector) GetUpdates(since time.Time) ([]Vulnerability, error) {
+	return m.query(func(c VulnDBConnector) ([]Vulnerability, error) {
+		return c.GetUpdates(since)
+	})
+}
+
+// query runs a query on every database in parallel and merges the results
+// in the order of the databases
+func (m *MultiConnector) query(run func(c VulnDBConnector) ([]Vulnerability, error)) ([]Vulnerability, error) {
+	results := make([][]Vulnerability, len(m.Sources))
+	errs := make([]error, len(m.Sources))
+	var wg sync.WaitGroup
+	for i, source := range m.Sources {
+		wg.Add(1)
+		go func(i int, c VulnDBConnector) {
+			defer wg.Done()
+			results[i], errs[i] = run(c)
+		}(i, source.Connector)
+	}
+	wg.Wait()
+
+	failures := make([]string, 0)
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", m.Sources[i].Name, err))
+		if m.OnError != nil {
+			m.OnError(m.Sources[i].Name, err)
+		}
+	}
+	if len(failures) == len(m.Sources) {
+		return nil, fmt.Errorf("every vulnerability database failed: %s", strings.Join(failures, "; "))
+	}
+	return MergeVulnerabilities(results...), nil
+}
+
+// MergeVulnerabilities merges lists of vulnerabilities from several
+// databases, deduplicating them by CVE ID, or by their own ID when they
+// have none. The first list wins for the title, description and severity,
+// which the later ones only fill in when missing; references, affected
+// systems, CPEs, exploits, aliases and provenance are combined.
+func MergeVulnerabilities(lists ...[]Vulnerability) []Vulnerability {
+	merged := make([]Vulnerability, 0)
+	index := make(map[string]int)
+	for _, list := range lists {
+		for _, vuln := range list {
+			key := mergeKey(vuln)
+			i, found := index[key]
+			if !found {
+				// Aliases are matched too, e.g. a GHSA ID reported without its CVE
+				for _, alias := range append([]string{vuln.ID}, vuln.Aliases...) {
+					if i, found = index[strings.ToUpper(alias)]; found {
+						break
+					}
+				}
+			}
+			if !found {
+				index[key] = len(merged)
+				for _, alias := range vuln.Aliases {
+					index[strings.ToUpper(alias)] = len(merged)
+				}
+				vuln.References = appendUnique(nil, vuln.References...)
+				merged = append(merged, vuln)
+				continue
+			}
+			mergeVulnerability(&merged[i], vuln)
+			for _, alias := range vuln.Aliases {
+				index[strings.ToUpper(alias)] = i
+			}
+		}
+	}
+	return merged
+}
+
+// mergeKey returns the CVE ID of a vulnerability, or its own ID
+func mergeKey(vuln Vulnerability) string {
+	for _, id := range append([]string{vuln.ID}, vuln.Aliases...) {
+		if strings.HasPrefix(strings.ToUpper(id), "CVE-") {
+			return strings.ToUpper(id)
+		}
+	}
+	return strings.ToUpper(vuln.ID)
+}
+
+// mergeVulnerability merges other into vuln
+func mergeVulnerability(vuln *Vulnerability, other Vulnerability) {
+	if vuln.Title == "" {
+		vuln.Title = other.Title
+	}
+	if vuln.Description == "" {
+		vuln.Description = other.Description
+	}
+	if vuln.CVSS == 0 && other.CVSS > 0 {
+		vuln.CVSS = other.CVSS
+	}
+	if vuln.Severity == "" || vuln.Severity == SeverityNone {
+		vuln.Severity = other.Severity
+	}
+	if vuln.Published.IsZero() || (!other.Published.IsZero() && other.Published.Before(vuln.Published)) {
+		vuln.Published = other.Published
+	}
+	if other.Modified.After(vuln.Modified) {
+		vuln.Modified = other.Modified
+	}
+
+	// A CVE ID names the vulnerability best
+	if !strings.HasPrefix(vuln.ID, "CVE-") && strings.HasPrefix(other.ID, "CVE-") {
+		vuln.Aliases = appendUnique(vuln.Aliases, vuln.ID)
+		vuln.ID = other.ID
+	} else if other.ID != vuln.ID {
+		vuln.Aliases = appendUnique(vuln.Aliases, other.ID)
+	}
+	vuln.Aliases = appendUnique(vuln.Aliases, other.Aliases...)
+	vuln.References = appendUnique(vuln.References, other.References...)
+	vuln.AffectedSystems = appendUnique(vuln.AffectedSystems, other.AffectedSystems...)
+	vuln.Exploits = appendUnique(vuln.Exploits, other.Exploits...)
+	vuln.Mitigations = appendUnique(vuln.Mitigations, other.Mitigations...)
+	for _, cpe := range other.CPEs {
+		known := false
+		for _, existing := range vuln.CPEs {
+			known = known || existing == cpe
+		}
+		if !known {
+			vuln.CPEs = append(vuln.CPEs, cpe)
+		}
+	}
+	for _, p := range other.Provenance {
+		known := false
+		for _, existing := range vuln.Provenance {
+			known = known || (existing.Source == p.Source && existing.ID == p.ID)
+		}
+		if !known {
+			vuln.Provenance = append(vuln.Provenance, p)
+		}
+	}
+}
+
+// appendUnique appends the values missing from list
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			found = found || existing == value
+		}
+		if !found && value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// ProvenanceSources returns the names of the databases a vulnerability was
+// found in, sorted
+func ProvenanceSources(vuln Vulnerability) []string {
+	sources := make([]string, 0, len(vuln.Provenance))
+	for _, p := range vuln.Provenance {
+		sources = appendUnique(sources, p.Source)
+	}
+	sort.Strings(sources)
+	return sources
+}
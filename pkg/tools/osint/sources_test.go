@@ -0,0 +1,212 @@
+package osint
+
+import (
+	"GopherStrike/pkg/egress"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeVulnerabilities(t *testing.T) {
+	nvd := []Vulnerability{{
+		ID:         "CVE-2021-44228",
+		Title:      "Log4Shell",
+		CVSS:       10,
+		Severity:   SeverityCritical,
+		References: []string{"https://logging.apache.org/log4j/2.x/security.html"},
+		Provenance: []Provenance{{Source: SourceNVD, ID: "CVE-2021-44228"}},
+	}}
+	github := []Vulnerability{{
+		ID:         "CVE-2021-44228",
+		Title:      "Remote code injection in Log4j",
+		Aliases:    []string{"GHSA-jfh8-c2jp-5v3q"},
+		References: []string{"https://logging.apache.org/log4j/2.x/security.html", "https://github.com/advisories/GHSA-jfh8-c2jp-5v3q"},
+		Provenance: []Provenance{{Source: SourceGithub, ID: "GHSA-jfh8-c2jp-5v3q"}},
+	}}
+	osv := []Vulnerability{
+		// Known by its GHSA ID only, matched through the alias
+		{
+			ID:              "GHSA-jfh8-c2jp-5v3q",
+			AffectedSystems: []string{"Maven/org.apache.logging.log4j:log4j-core"},
+			Provenance:      []Provenance{{Source: SourceOSV, ID: "GHSA-jfh8-c2jp-5v3q"}},
+		},
+		{
+			ID:         "GO-2022-0001",
+			Title:      "Unrelated",
+			Provenance: []Provenance{{Source: SourceOSV, ID: "GO-2022-0001"}},
+		},
+	}
+
+	merged := MergeVulnerabilities(nvd, github, osv)
+	if len(merged) != 2 {
+		t.Fatalf("merged %d vulnerabilities, want 2: %+v", len(merged), merged)
+	}
+	vuln := merged[0]
+	if vuln.ID != "CVE-2021-44228" || vuln.Title != "Log4Shell" || vuln.CVSS != 10 {
+		t.Errorf("merged vulnerability = %s %q CVSS %.1f, want the NVD fields", vuln.ID, vuln.Title, vuln.CVSS)
+	}
+	if len(vuln.References) != 2 {
+		t.Errorf("references = %v, want 2 distinct", vuln.References)
+	}
+	if len(vuln.AffectedSystems) != 1 {
+		t.Errorf("affected systems = %v, want the OSV package", vuln.AffectedSystems)
+	}
+	if got, want := ProvenanceSources(vuln), []string{SourceGithub, SourceNVD, SourceOSV}; !reflect.DeepEqual(got, want) {
+		t.Errorf("provenance sources = %v, want %v", got, want)
+	}
+	if merged[1].ID != "GO-2022-0001" {
+		t.Errorf("second vulnerability = %s, want GO-2022-0001", merged[1].ID)
+	}
+}
+
+func TestMergeVulnerabilitiesPrefersCVEID(t *testing.T) {
+	merged := MergeVulnerabilities(
+		[]Vulnerability{{ID: "GHSA-jfh8-c2jp-5v3q", Provenance: []Provenance{{Source: SourceOSV, ID: "GHSA-jfh8-c2jp-5v3q"}}}},
+		[]Vulnerability{{ID: "CVE-2021-44228", Aliases: []string{"GHSA-jfh8-c2jp-5v3q"}, Provenance: []Provenance{{Source: SourceGithub, ID: "GHSA-jfh8-c2jp-5v3q"}}}},
+	)
+	if len(merged) != 1 {
+		t.Fatalf("merged %d vulnerabilities, want 1", len(merged))
+	}
+	if merged[0].ID != "CVE-2021-44228" || !reflect.DeepEqual(merged[0].Aliases, []string{"GHSA-jfh8-c2jp-5v3q"}) {
+		t.Errorf("merged vulnerability = %s aliases %v, want the CVE ID with the GHSA alias", merged[0].ID, merged[0].Aliases)
+	}
+}
+
+// stubConnector is a VulnDBConnector returning fixed results
+type stubConnector struct {
+	vulns []Vulnerability
+	err   error
+}
+
+func (s stubConnector) Search(query SearchQuery) ([]Vulnerability, error) { return s.vulns, s.err }
+func (s stubConnector) GetByID(id string) (*Vulnerability, error) {
+	if s.err != nil || len(s.vulns) == 0 {
+		return nil, fmt.Errorf("vulnerability not found: %s", id)
+	}
+	return &s.vulns[0], nil
+}
+func (s stubConnector) GetUpdates(since time.Time) ([]Vulnerability, error) { return s.vulns, s.err }
+
+func TestMultiConnectorToleratesFailures(t *testing.T) {
+	var failed []string
+	multi := &MultiConnector{
+		Sources: []SourceConnector{
+			{SourceNVD, stubConnector{err: fmt.Errorf("API error: 503 Service Unavailable")}},
+			{SourceOSV, stubConnector{vulns: []Vulnerability{{ID: "CVE-2023-1234"}}}},
+		},
+		OnError: func(source string, err error) { failed = append(failed, source) },
+	}
+	vulns, err := multi.Search(SearchQuery{Products: []string{"lodash"}})
+	if err != nil || len(vulns) != 1 {
+		t.Fatalf("Search() = %v, %v, want the OSV result", vulns, err)
+	}
+	if !reflect.DeepEqual(failed, []string{SourceNVD}) {
+		t.Errorf("failed sources = %v, want [NVD]", failed)
+	}
+
+	multi.Sources[1].Connector = stubConnector{err: fmt.Errorf("timeout")}
+	if _, err := multi.Search(SearchQuery{}); err == nil {
+		t.Error("Search() succeeded with every database failing")
+	}
+}
+
+func TestNewVulnDB(t *testing.T) {
+	if _, err := NewVulnDB(VulnDBOptions{Sources: []string{"vulners"}}); err == nil {
+		t.Error("vulners without an API key accepted")
+	}
+	if _, err := NewVulnDB(VulnDBOptions{Sources: []string{"exploitdb"}}); err == nil {
+		t.Error("unknown database accepted")
+	}
+}
+
+func TestOSVConnectorSearch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Package struct {
+				Ecosystem string `json:"ecosystem"`
+				Name      string `json:"name"`
+			} `json:"package"`
+			Version string `json:"version"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		if r.URL.Path != "/query" || request.Package.Ecosystem != "npm" || request.Package.Name != "lodash" || request.Version != "4.17.20" {
+			t.Errorf("unexpected query %s %+v", r.URL.Path, request)
+		}
+		fmt.Fprint(w, `{"vulns": [{
+			"id": "GHSA-35jh-r3h4-6jhm",
+			"summary": "Command Injection in lodash",
+			"aliases": ["CVE-2021-23337"],
+			"affected": [{"package": {"ecosystem": "npm", "name": "lodash"}}],
+			"references": [{"url": "https://nvd.nist.gov/vuln/detail/CVE-2021-23337"}],
+			"database_specific": {"severity": "HIGH"}
+		}]}`)
+	}))
+	defer server.Close()
+
+	c := &OSVConnector{BaseURL: server.URL, CacheDir: t.TempDir(), client: server.Client()}
+	vulns, err := c.Search(SearchQuery{Products: []string{"npm:lodash"}, Versions: []string{"4.17.20"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vulns) != 1 {
+		t.Fatalf("found %d vulnerabilities, want 1", len(vulns))
+	}
+	vuln := vulns[0]
+	if vuln.ID != "CVE-2021-23337" || !reflect.DeepEqual(vuln.Aliases, []string{"GHSA-35jh-r3h4-6jhm"}) || vuln.Severity != SeverityHigh {
+		t.Errorf("vulnerability = %s aliases %v severity %s, want CVE-2021-23337 aliased GHSA-35jh-r3h4-6jhm, high", vuln.ID, vuln.Aliases, vuln.Severity)
+	}
+	if len(vuln.Provenance) != 1 || vuln.Provenance[0].Source != SourceOSV || vuln.Provenance[0].ID != "GHSA-35jh-r3h4-6jhm" {
+		t.Errorf("provenance = %+v, want OSV GHSA-35jh-r3h4-6jhm", vuln.Provenance)
+	}
+}
+
+func TestConnectorsFollowEgressRoutes(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	// The constructors create their caches in the working directory
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Chdir() error = %v", err)
+	}
+	defer os.Chdir(wd)
+
+	// A required route through a proxy that is down lets nothing out
+	routes, err := egress.Parse(strings.NewReader("route osint * http://127.0.0.1:1 required\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	egress.Set(routes)
+	defer egress.Set(nil)
+
+	osv := NewOSVConnector()
+	github := NewGitHubAdvisoryConnector("")
+	vulners := NewVulnersConnector("key")
+	nvd := NewNVDConnector("")
+	osv.BaseURL, github.BaseURL, vulners.BaseURL, nvd.BaseURL = server.URL, server.URL, server.URL, server.URL
+	checker, _ := NewVersionChecker()
+	checker.BaseURL = server.URL
+
+	query := SearchQuery{Keywords: []string{"lodash"}, Products: []string{"npm:lodash"}}
+	for name, connector := range map[string]VulnDBConnector{"OSV": osv, "GitHub": github, "Vulners": vulners, "NVD": nvd} {
+		if _, err := connector.Search(query); err == nil {
+			t.Errorf("%s searched despite the required route", name)
+		}
+	}
+	if _, err := checker.Check("Apache HTTP Server", "2.4.29"); err == nil {
+		t.Error("version checker fetched releases despite the required route")
+	}
+	if requests != 0 {
+		t.Errorf("server received %d requests, want none", requests)
+	}
+}
@@ -0,0 +1,418 @@
+// pkg/tools/osint/versions.go
+package osint
+
+import (
+	"GopherStrike/pkg/httpclient"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VersionFeedsFile lists the latest versions of products and firmware that
+// endoflife.date does not track, such as vendor firmware feeds
+const VersionFeedsFile = "fingerprints/versions.json"
+
+// endoflifeProducts maps keywords of the products named by the tools to
+// their endoflife.date identifiers, the more specific keywords first
+var endoflifeProducts = []struct{ keyword, id string }{
+	{"tomcat", "tomcat"}, {"apache", "apache-http-server"}, {"nginx", "nginx"},
+	{"php", "php"}, {"iis", "iis"}, {"mysql", "mysql"}, {"postgre", "postgresql"},
+	{"ubuntu", "ubuntu"}, {"debian", "debian"}, {"centos", "centos"},
+}
+
+// versionSegmentRegex splits versions into numbers and words
+var versionSegmentRegex = regexp.MustCompile(`\d+|[a-z]+`)
+
+// preReleases rank the words of pre-release versions, which come before the
+// release. Other words, such as the p1 of OpenSSH 8.2p1 or the SE5 of Cisco
+// IOS 12.2(55)SE5, come after it.
+var preReleases = map[string]int{"dev": 1, "snapshot": 1, "alpha": 2, "a": 2, "beta": 3, "b": 3, "pre": 4, "rc": 5}
+
+// versionSegments returns the numbers and words of a version, lowercased,
+// without a leading v
+func versionSegments(version string) []string {
+	version = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v")
+	return versionSegmentRegex.FindAllString(version, -1)
+}
+
+// CompareVersions compares two versions and returns -1, 0 or 1 if a is
+// older than, the same as or newer than b. Versions are compared number by
+// number, so 2.4.9 is older than 2.4.29, missing numbers count as 0 and
+// vendor suffixes such as 8.2p1, 2.05B02 or 1.0.11.116_10.2.100 are
+// compared in turn. Pre-releases such as 1.0rc1 are older than 1.0.
+func CompareVersions(a, b string) int {
+	as, bs := versionSegments(a), versionSegments(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if c := compareSegments(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareSegments compares two segments of versions, "" for a missing one
+func compareSegments(x, y string) int {
+	xNum, xErr := strconv.Atoi(x)
+	yNum, yErr := strconv.Atoi(y)
+	switch {
+	case x == y:
+		return 0
+	case xErr == nil && yErr == nil:
+		return compareInts(xNum, yNum)
+	case xErr == nil && y == "":
+		return compareInts(xNum, 0)
+	case x == "" && yErr == nil:
+		return compareInts(0, yNum)
+	}
+
+	// A word ranks below a number or a release if it marks a pre-release
+	rank := func(s string, err error) int {
+		switch {
+		case err == nil:
+			return 10
+		case s == "":
+			return 6
+		case preReleases[s] > 0:
+			return preReleases[s]
+		}
+		return 7
+	}
+	if c := compareInts(rank(x, xErr), rank(y, yErr)); c != 0 {
+		return c
+	}
+	return strings.Compare(x, y)
+}
+
+func compareInts(x, y int) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// Version updates, by the first number that changes
+const (
+	UpdateMajor = "major"
+	UpdateMinor = "minor"
+	UpdatePatch = "patch"
+)
+
+// UpdateKind returns whether going from installed to latest is a major,
+// minor or patch update, or "" if latest is not newer
+func UpdateKind(installed, latest string) string {
+	if CompareVersions(installed, latest) >= 0 {
+		return ""
+	}
+	is, ls := versionSegments(installed), versionSegments(latest)
+	for i := 0; i < 2; i++ {
+		var x, y string
+		if i < len(is) {
+			x = is[i]
+		}
+		if i < len(ls) {
+			y = ls[i]
+		}
+		if compareSegments(x, y) != 0 {
+			return []string{UpdateMajor, UpdateMinor}[i]
+		}
+	}
+	return UpdatePatch
+}
+
+// VersionStatus compares an installed version with the latest one
+type VersionStatus struct {
+	Installed    string    `json:"installed"`
+	Latest       string    `json:"latest"`                 // Latest version of the installed release
+	LatestMajor  string    `json:"latest_major,omitempty"` // Latest version of the newest release, if different
+	Update       string    `json:"update,omitempty"`       // major, minor or patch
+	ReleaseEOL   bool      `json:"release_eol"`            // The installed release gets no more fixes
+	ReleaseEOLOn time.Time `json:"release_eol_on"`
+	Urgency      Severity  `json:"urgency"`
+	Source       string    `json:"source"`
+}
+
+// String describes the status, e.g. "installed 2.4.29 vs latest 2.4.62
+// (patch update, urgency Low)"
+func (s VersionStatus) String() string {
+	text := fmt.Sprintf("installed %s vs latest %s", s.Installed, s.Latest)
+	if s.LatestMajor != "" && s.LatestMajor != s.Latest {
+		text += fmt.Sprintf(" (newest release %s)", s.LatestMajor)
+	}
+	details := []string{}
+	if s.Update != "" {
+		details = append(details, s.Update+" update")
+	} else {
+		details = append(details, "up to date")
+	}
+	if s.ReleaseEOL {
+		details = append(details, "release end of life")
+	}
+	details = append(details, "urgency "+string(s.Urgency))
+	return text + " (" + strings.Join(details, ", ") + ")"
+}
+
+// NewVersionStatus compares installed with the latest version of its
+// release and of the newest release. The urgency of the upgrade grows with
+// how far behind installed is: Low for a patch update, Medium for a minor
+// one, High for a major one and Critical if the release is end of life.
+func NewVersionStatus(installed, latest, latestMajor string, releaseEOL bool) *VersionStatus {
+	status := &VersionStatus{Installed: installed, Latest: latest, ReleaseEOL: releaseEOL, Urgency: SeverityNone}
+	if latestMajor != "" && CompareVersions(latestMajor, latest) > 0 {
+		status.LatestMajor = latestMajor
+	}
+
+	// The newest release matters once the installed one is end of life
+	target := latest
+	if releaseEOL && status.LatestMajor != "" {
+		target = status.LatestMajor
+	}
+	status.Update = UpdateKind(installed, target)
+	switch {
+	case releaseEOL:
+		status.Urgency = SeverityCritical
+	case status.Update == UpdateMajor:
+		status.Urgency = SeverityHigh
+	case status.Update == UpdateMinor:
+		status.Urgency = SeverityMedium
+	case status.Update == UpdatePatch:
+		status.Urgency = SeverityLow
+	}
+	return status
+}
+
+// VersionFeed is the latest version of a product or device model, from
+// VersionFeedsFile
+type VersionFeed struct {
+	Product string `json:"product"` // e.g. "NETGEAR R7000" or "OpenSSH"
+	Release string `json:"release,omitempty"`
+	Latest  string `json:"latest"`
+	EOL     bool   `json:"eol,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// endoflifeCycle is a release of a product on endoflife.date. EOL is false,
+// true or the end of life date.
+type endoflifeCycle struct {
+	Cycle  json.RawMessage `json:"cycle"`
+	Latest string          `json:"latest"`
+	EOL    interface{}     `json:"eol"`
+}
+
+// VersionChecker finds the latest versions of products, from the vendor
+// feeds first, then from endoflife.date
+type VersionChecker struct {
+	BaseURL string
+	Feeds   []VersionFeed
+	client  *http.Client
+	cache   map[string][]endoflifeCycle
+	mu      sync.Mutex
+}
+
+// NewVersionChecker creates a version checker with the feeds of
+// VersionFeedsFile
+func NewVersionChecker() (*VersionChecker, error) {
+	checker := &VersionChecker{
+		BaseURL: "https://endoflife.date/api",
+		client:  httpclient.New("osint", 15*time.Second, nil),
+		cache:   make(map[string][]endoflifeCycle),
+	}
+	data, err := os.ReadFile(VersionFeedsFile)
+	if os.IsNotExist(err) {
+		return checker, nil
+	}
+	if err != nil {
+		return checker, fmt.Errorf("failed to read version feeds: %v", err)
+	}
+	if err := json.Unmarshal(data, &checker.Feeds); err != nil {
+		return checker, fmt.Errorf("invalid version feeds in %s: %v", VersionFeedsFile, err)
+	}
+	return checker, nil
+}
+
+// Check compares the installed version of a product with its latest
+// version. It returns nil, nil if no source knows the product.
+func (c *VersionChecker) Check(product, installed string) (*VersionStatus, error) {
+	if product == "" || installed == "" {
+		return nil, nil
+	}
+	if status := c.checkFeeds(product, installed); status != nil {
+		return status, nil
+	}
+
+	id := endoflifeID(product)
+	if id == "" {
+		return nil, nil
+	}
+	cycles, err := c.cycles(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// The installed release is the longest cycle installed starts with
+	var release *endoflifeCycle
+	releaseLen := 0
+	newest := ""
+	for i, cycle := range cycles {
+		name := cycleName(cycle.Cycle)
+		if newest == "" || CompareVersions(cycle.Latest, newest) > 0 {
+			newest = cycle.Latest
+		}
+		if (installed == name || strings.HasPrefix(installed, name+".")) && len(name) > releaseLen {
+			release, releaseLen = &cycles[i], len(name)
+		}
+	}
+	if release == nil {
+		return nil, nil
+	}
+
+	eol, eolOn := cycleEOL(release.EOL)
+	status := NewVersionStatus(installed, release.Latest, newest, eol)
+	status.ReleaseEOLOn = eolOn
+	status.Source = "endoflife.date"
+	return status, nil
+}
+
+// checkFeeds looks the product up in the vendor feeds. Feeds with a release
+// only apply to the versions of that release.
+func (c *VersionChecker) checkFeeds(product, installed string) *VersionStatus {
+	var release, newest *VersionFeed
+	for i, feed := range c.Feeds {
+		if !strings.EqualFold(strings.TrimSpace(feed.Product), strings.TrimSpace(product)) {
+			continue
+		}
+		if feed.Release == "" || installed == feed.Release || strings.HasPrefix(installed, feed.Release+".") {
+			if release == nil || len(feed.Release) > len(release.Release) {
+				release = &c.Feeds[i]
+			}
+		}
+		if newest == nil || CompareVersions(feed.Latest, newest.Latest) > 0 {
+			newest = &c.Feeds[i]
+		}
+	}
+	if release == nil {
+		return nil
+	}
+
+	status := NewVersionStatus(installed, release.Latest, newest.Latest, release.EOL)
+	status.Source = VersionFeedsFile
+	if release.URL != "" {
+		status.Source = release.URL
+	}
+	return status
+}
+
+// cycles fetches the releases of a product from endoflife.date
+func (c *VersionChecker) cycles(id string) ([]endoflifeCycle, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cycles, ok := c.cache[id]; ok {
+		return cycles, nil
+	}
+
+	resp, err := c.client.Get(fmt.Sprintf("%s/%s.json", c.BaseURL, url.PathEscape(id)))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the releases of %s: %v", id, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error for %s: %s", id, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the releases of %s: %v", id, err)
+	}
+
+	var cycles []endoflifeCycle
+	if err := json.Unmarshal(body, &cycles); err != nil {
+		return nil, fmt.Errorf("invalid releases of %s: %v", id, err)
+	}
+	c.cache[id] = cycles
+	return cycles, nil
+}
+
+// endoflifeID returns the endoflife.date identifier of a product, or ""
+func endoflifeID(product string) string {
+	lower := strings.ToLower(product)
+	// "Apache HTTP Server with PHP 7.4" is checked as Apache
+	if i := strings.Index(lower, " with "); i >= 0 {
+		lower = lower[:i]
+	}
+	for _, p := range endoflifeProducts {
+		if strings.Contains(lower, p.keyword) {
+			return p.id
+		}
+	}
+	return ""
+}
+
+// cycleName returns the name of a cycle, which endoflife.date gives as a
+// string or a number
+func cycleName(raw json.RawMessage) string {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return name
+	}
+	return string(raw)
+}
+
+// cycleEOL returns whether a cycle is end of life and when it ended
+func cycleEOL(eol interface{}) (bool, time.Time) {
+	switch v := eol.(type) {
+	case bool:
+		return v, time.Time{}
+	case string:
+		date, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return false, time.Time{}
+		}
+		return time.Now().After(date), date
+	}
+	return false, time.Time{}
+}
+
+// checkLatestVersion fills in the latest version of the product of a server
+func checkLatestVersion(checker *VersionChecker, serverInfo *ServerInfo) error {
+	status, err := checker.Check(serverInfo.ProductName, serverInfo.ProductVersion)
+	if err != nil || status == nil {
+		return err
+	}
+	serverInfo.LatestVersion = status.Latest
+	serverInfo.VersionStatus = status
+	if status.Update != "" || status.ReleaseEOL {
+		serverInfo.UpdateAvailable = true
+	}
+	return nil
+}
+
+// checkLatestFirmware fills in the latest firmware version of a device from
+// the vendor feeds, by manufacturer and model or by model alone
+func checkLatestFirmware(checker *VersionChecker, info *FirmwareInfo) {
+	if info.FirmwareVersion == "" {
+		return
+	}
+	for _, product := range []string{strings.TrimSpace(info.Manufacturer + " " + info.Model), info.Model} {
+		if status := checker.checkFeeds(product, info.FirmwareVersion); status != nil {
+			info.LatestVersion = status.Latest
+			info.VersionStatus = status
+			return
+		}
+	}
+}
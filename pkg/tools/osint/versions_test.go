@@ -0,0 +1,131 @@
+package osint
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.4.29", "2.4.62", -1},
+		{"2.4.9", "2.4.29", -1},
+		{"1.20", "1.18.0", 1},
+		{"1.0", "1.0.0", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"8.2p1", "8.2", 1},
+		{"8.2p1", "8.9p1", -1},
+		{"1.0rc1", "1.0", -1},
+		{"1.0-beta2", "1.0-rc1", -1},
+		{"V1.0.11.116_10.2.100", "1.0.11.136_10.2.120", -1},
+		{"2.05B02", "2.05B01", 1},
+		{"12.2(55)SE5", "12.2(55)SE12", -1},
+		{"7.1.1-42962", "7.1.1-42962", 0},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := CompareVersions(tt.b, tt.a); got != -tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestNewVersionStatus(t *testing.T) {
+	tests := []struct {
+		installed, latest, latestMajor string
+		eol                            bool
+		update                         string
+		urgency                        Severity
+	}{
+		{"2.4.29", "2.4.62", "", false, UpdatePatch, SeverityLow},
+		{"8.1.2", "8.1.30", "8.3.12", false, UpdatePatch, SeverityLow},
+		{"8.0.30", "8.0.30", "8.3.12", true, UpdateMinor, SeverityCritical},
+		{"1.24.0", "1.26.2", "", false, UpdateMinor, SeverityMedium},
+		{"1.2", "2.0", "", false, UpdateMajor, SeverityHigh},
+		{"2.4.62", "2.4.62", "", false, "", SeverityNone},
+	}
+	for _, tt := range tests {
+		status := NewVersionStatus(tt.installed, tt.latest, tt.latestMajor, tt.eol)
+		if status.Update != tt.update || status.Urgency != tt.urgency {
+			t.Errorf("NewVersionStatus(%s, %s, %s, %v) = %s update, urgency %s, want %s, %s",
+				tt.installed, tt.latest, tt.latestMajor, tt.eol, status.Update, status.Urgency, tt.update, tt.urgency)
+		}
+	}
+
+	if got := NewVersionStatus("2.4.29", "2.4.62", "", false).String(); got != "installed 2.4.29 vs latest 2.4.62 (patch update, urgency Low)" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestVersionChecker(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/apache-http-server.json":
+			fmt.Fprint(w, `[{"cycle":"2.4","latest":"2.4.62","eol":false},{"cycle":"2.2","latest":"2.2.34","eol":"2017-07-01"}]`)
+		case "/ubuntu.json":
+			fmt.Fprint(w, `[{"cycle":"24.04","latest":"24.04.1","eol":"2029-05-31"},{"cycle":"18.04","latest":"18.04.6","eol":"2023-05-31"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	checker, _ := NewVersionChecker()
+	checker.BaseURL = server.URL
+	checker.Feeds = []VersionFeed{
+		{Product: "NETGEAR R7000", Latest: "1.0.11.136_10.2.120", EOL: true},
+		{Product: "OpenSSH", Release: "9", Latest: "9.9p1"},
+	}
+
+	tests := []struct {
+		product, installed string
+		want               string
+		urgency            Severity
+	}{
+		{"Apache HTTP Server", "2.4.29", "2.4.62", SeverityLow},
+		{"Apache HTTP Server with PHP 7.4.3", "2.2.15", "2.2.34", SeverityCritical},
+		{"Ubuntu", "18.04.2", "18.04.6", SeverityCritical},
+		{"netgear r7000", "V1.0.11.116_10.2.100", "1.0.11.136_10.2.120", SeverityCritical},
+		{"OpenSSH", "9.3p2", "9.9p1", SeverityMedium},
+		{"OpenSSH", "8.2p1", "", ""},
+		{"Apache HTTP Server", "3.0.0", "", ""},
+		{"Unknown Server", "1.0", "", ""},
+	}
+	for _, tt := range tests {
+		status, err := checker.Check(tt.product, tt.installed)
+		if err != nil {
+			t.Fatalf("Check(%s, %s) error = %v", tt.product, tt.installed, err)
+		}
+		if tt.want == "" {
+			if status != nil {
+				t.Errorf("Check(%s, %s) = %s, want no status", tt.product, tt.installed, status)
+			}
+			continue
+		}
+		if status == nil || status.Latest != tt.want || status.Urgency != tt.urgency {
+			t.Errorf("Check(%s, %s) = %v, want latest %s, urgency %s", tt.product, tt.installed, status, tt.want, tt.urgency)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("endoflife.date was asked %d times, want once per product", requests)
+	}
+
+	serverInfo := &ServerInfo{ProductName: "Apache HTTP Server", ProductVersion: "2.4.29"}
+	if err := checkLatestVersion(checker, serverInfo); err != nil || serverInfo.LatestVersion != "2.4.62" || !serverInfo.UpdateAvailable {
+		t.Errorf("checkLatestVersion() = %v, latest %q, update %v, want 2.4.62 and an update", err, serverInfo.LatestVersion, serverInfo.UpdateAvailable)
+	}
+	firmware := &FirmwareInfo{Manufacturer: "NETGEAR", Model: "R7000", FirmwareVersion: "1.0.9.88"}
+	checkLatestFirmware(checker, firmware)
+	if firmware.VersionStatus == nil || !strings.Contains(firmware.VersionStatus.String(), "vs latest 1.0.11.136_10.2.120") {
+		t.Errorf("checkLatestFirmware() = %v, want the latest firmware of the feed", firmware.VersionStatus)
+	}
+}
@@ -2,6 +2,7 @@
 package osint
 
 import (
+	"GopherStrike/pkg/httpclient"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -18,6 +20,8 @@ const (
 	SourceNVD       = "NVD"
 	SourceExploitDB = "ExploitDB"
 	SourceGithub    = "GitHub"
+	SourceOSV       = "OSV"
+	SourceVulners   = "Vulners"
 
 	// Cache duration - 24 hours
 	cacheDuration = 24 * time.Hour
@@ -120,7 +124,7 @@ func (c *NVDConnector) Search(query SearchQuery) ([]Vulnerability, error) {
 		req.Header.Add("apiKey", c.APIKey)
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := httpclient.New("osint", 30*time.Second, nil)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error making request: %v", err)
@@ -159,6 +163,18 @@ func (c *NVDConnector) Search(query SearchQuery) ([]Vulnerability, error) {
 				References []struct {
 					URL string `json:"url"`
 				} `json:"references"`
+				Configurations []struct {
+					Nodes []struct {
+						CPEMatch []struct {
+							Vulnerable            bool   `json:"vulnerable"`
+							Criteria              string `json:"criteria"`
+							VersionStartIncluding string `json:"versionStartIncluding"`
+							VersionStartExcluding string `json:"versionStartExcluding"`
+							VersionEndIncluding   string `json:"versionEndIncluding"`
+							VersionEndExcluding   string `json:"versionEndExcluding"`
+						} `json:"cpeMatch"`
+					} `json:"nodes"`
+				} `json:"configurations"`
 			} `json:"cve"`
 		} `json:"vulnerabilities"`
 	}
@@ -177,6 +193,7 @@ func (c *NVDConnector) Search(query SearchQuery) ([]Vulnerability, error) {
 			Modified:   item.CVE.LastModified,
 			Source:     SourceNVD,
 			References: make([]string, 0, len(item.CVE.References)),
+			Provenance: []Provenance{{Source: SourceNVD, ID: item.CVE.ID, URL: "https://nvd.nist.gov/vuln/detail/" + item.CVE.ID}},
 		}
 
 		// Get English description
@@ -213,6 +230,23 @@ func (c *NVDConnector) Search(query SearchQuery) ([]Vulnerability, error) {
 			vuln.References = append(vuln.References, ref.URL)
 		}
 
+		// Extract the vulnerable products
+		for _, config := range item.CVE.Configurations {
+			for _, node := range config.Nodes {
+				for _, match := range node.CPEMatch {
+					if match.Vulnerable {
+						vuln.CPEs = append(vuln.CPEs, CPEMatch{
+							Criteria:              match.Criteria,
+							VersionStartIncluding: match.VersionStartIncluding,
+							VersionStartExcluding: match.VersionStartExcluding,
+							VersionEndIncluding:   match.VersionEndIncluding,
+							VersionEndExcluding:   match.VersionEndExcluding,
+						})
+					}
+				}
+			}
+		}
+
 		vulns = append(vulns, vuln)
 	}
 
@@ -264,8 +298,20 @@ func (c *NVDConnector) GetUpdates(since time.Time) ([]Vulnerability, error) {
 func (c *NVDConnector) checkCache(key string) ([]Vulnerability, bool) {
 	c.cacheLock.RLock()
 	defer c.cacheLock.RUnlock()
+	return readCache(c.CacheDir, key, c.cacheExpiry)
+}
 
-	cacheFile := filepath.Join(c.CacheDir, key+".json")
+// cacheResults caches search results
+func (c *NVDConnector) cacheResults(key string, vulns []Vulnerability) {
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
+	writeCache(c.CacheDir, key, vulns)
+}
+
+// readCache reads the vulnerabilities cached under key in dir if they are
+// not older than expiry
+func readCache(dir, key string, expiry time.Duration) ([]Vulnerability, bool) {
+	cacheFile := filepath.Join(dir, key+".json")
 
 	// Check if cache file exists
 	info, err := os.Stat(cacheFile)
@@ -274,7 +320,7 @@ func (c *NVDConnector) checkCache(key string) ([]Vulnerability, bool) {
 	}
 
 	// Check if cache is expired
-	if time.Since(info.ModTime()) > c.cacheExpiry {
+	if time.Since(info.ModTime()) > expiry {
 		return nil, false
 	}
 
@@ -293,12 +339,9 @@ func (c *NVDConnector) checkCache(key string) ([]Vulnerability, bool) {
 	return vulns, true
 }
 
-// cacheResults caches search results
-func (c *NVDConnector) cacheResults(key string, vulns []Vulnerability) {
-	c.cacheLock.Lock()
-	defer c.cacheLock.Unlock()
-
-	cacheFile := filepath.Join(c.CacheDir, key+".json")
+// writeCache caches vulnerabilities under key in dir
+func writeCache(dir, key string, vulns []Vulnerability) {
+	cacheFile := filepath.Join(dir, key+".json")
 
 	// Marshal to JSON
 	data, err := json.Marshal(vulns)
@@ -328,6 +371,37 @@ func generateCacheKey(prefix string, data interface{}) string {
 	return fmt.Sprintf("%s_%x", prefix, hash)
 }
 
+// severityForCVSS rates a CVSS v3 base score
+func severityForCVSS(score float64) Severity {
+	switch {
+	case score >= 9:
+		return SeverityCritical
+	case score >= 7:
+		return SeverityHigh
+	case score >= 4:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	}
+	return SeverityNone
+}
+
+// parseSeverity rates the severity names of the databases, such as HIGH,
+// moderate or important
+func parseSeverity(name string) Severity {
+	switch strings.ToLower(name) {
+	case "critical":
+		return SeverityCritical
+	case "high", "important":
+		return SeverityHigh
+	case "medium", "moderate":
+		return SeverityMedium
+	case "low":
+		return SeverityLow
+	}
+	return SeverityNone
+}
+
 // truncateString truncates a string to the given maximum length
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
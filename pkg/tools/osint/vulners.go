@@ -0,0 +1,222 @@
+// pkg/tools/osint/vulners.go
+package osint
+
+import (
+	"GopherStrike/pkg/httpclient"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VulnersConnector implements VulnDBConnector for Vulners, whose full-text
+// search also covers vendor bulletins and exploits. It requires an API key.
+type VulnersConnector struct {
+	APIKey      string
+	BaseURL     string
+	CacheDir    string
+	cacheExpiry time.Duration
+	cacheLock   sync.RWMutex
+	client      *http.Client
+}
+
+// vulnersDocument is a bulletin of the Vulners API
+type vulnersDocument struct {
+	ID          string   `json:"id"`
+	Type        string   `json:"type"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Href        string   `json:"href"`
+	Published   string   `json:"published"`
+	Modified    string   `json:"modified"`
+	CVEList     []string `json:"cvelist"`
+	CVSS        struct {
+		Score float64 `json:"score"`
+	} `json:"cvss"`
+}
+
+// NewVulnersConnector creates a new Vulners connector with its API key
+func NewVulnersConnector(apiKey string) *VulnersConnector {
+	cacheDir := filepath.Join("logs", defaultCacheDir, "vulners")
+	_ = os.MkdirAll(cacheDir, 0755)
+
+	return &VulnersConnector{
+		APIKey:      apiKey,
+		BaseURL:     "https://vulners.com/api/v3",
+		CacheDir:    cacheDir,
+		cacheExpiry: cacheDuration,
+		client:      httpclient.New("osint", 30*time.Second, nil),
+	}
+}
+
+// Search runs a Lucene query of the keywords, products, versions and CVE IDs
+func (c *VulnersConnector) Search(query SearchQuery) ([]Vulnerability, error) {
+	cacheKey := generateCacheKey("search", query)
+	if vulns, found := c.checkCache(cacheKey); found {
+		return vulns, nil
+	}
+
+	terms := make([]string, 0)
+	for _, term := range append(append(append([]string{}, query.Keywords...), query.Products...), query.Versions...) {
+		terms = append(terms, quoteLucene(term))
+	}
+	if len(query.CVEIDs) > 0 {
+		ids := make([]string, len(query.CVEIDs))
+		for i, id := range query.CVEIDs {
+			ids[i] = quoteLucene(id)
+		}
+		terms = append(terms, "cvelist:("+strings.Join(ids, " OR ")+")")
+	}
+	if len(terms) == 0 {
+		return []Vulnerability{}, nil
+	}
+	lucene := strings.Join(terms, " AND ")
+	if !query.FromDate.IsZero() {
+		lucene += fmt.Sprintf(" AND published:[%s TO *]", query.FromDate.Format("2006-01-02"))
+	}
+
+	size := query.MaxResults
+	if size <= 0 {
+		size = 50
+	}
+	var data struct {
+		Search []struct {
+			Source vulnersDocument `json:"_source"`
+		} `json:"search"`
+	}
+	if err := c.post("/search/lucene/", map[string]interface{}{"query": lucene, "size": size}, &data); err != nil {
+		return nil, err
+	}
+
+	vulns := make([]Vulnerability, 0, len(data.Search))
+	for _, result := range data.Search {
+		vulns = append(vulns, result.Source.vulnerability())
+	}
+	c.cacheResults(cacheKey, vulns)
+	return vulns, nil
+}
+
+// GetByID retrieves a bulletin by its ID, such as a CVE ID
+func (c *VulnersConnector) GetByID(id string) (*Vulnerability, error) {
+	var data struct {
+		Documents map[string]vulnersDocument `json:"documents"`
+	}
+	if err := c.post("/search/id/", map[string]interface{}{"id": id}, &data); err != nil {
+		return nil, err
+	}
+	for _, document := range data.Documents {
+		vuln := document.vulnerability()
+		return &vuln, nil
+	}
+	return nil, fmt.Errorf("vulnerability not found: %s", id)
+}
+
+// GetUpdates lists the CVEs published since a given date
+func (c *VulnersConnector) GetUpdates(since time.Time) ([]Vulnerability, error) {
+	return c.Search(SearchQuery{Keywords: []string{"type:cve"}, FromDate: since, MaxResults: 100})
+}
+
+// post sends a request with the API key and decodes the data of the
+// response into data
+func (c *VulnersConnector) post(path string, request map[string]interface{}, data interface{}) error {
+	if c.APIKey == "" {
+		return fmt.Errorf("Vulners requires an API key")
+	}
+	request["apiKey"] = c.APIKey
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Post(c.BaseURL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error making request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API error: %s", resp.Status)
+	}
+
+	var envelope struct {
+		Result string          `json:"result"`
+		Data   json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("error parsing response: %v", err)
+	}
+	if envelope.Result != "OK" {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(envelope.Data, &failure)
+		return fmt.Errorf("API error: %s", failure.Error)
+	}
+	if err := json.Unmarshal(envelope.Data, data); err != nil {
+		return fmt.Errorf("error parsing response: %v", err)
+	}
+	return nil
+}
+
+// vulnerability converts a bulletin. Bulletins about a single CVE are known
+// by it, so they merge with the other databases.
+func (d vulnersDocument) vulnerability() Vulnerability {
+	vuln := Vulnerability{
+		ID:          d.ID,
+		Title:       d.Title,
+		Description: d.Description,
+		CVSS:        d.CVSS.Score,
+		Severity:    severityForCVSS(d.CVSS.Score),
+		Published:   parseVulnersTime(d.Published),
+		Modified:    parseVulnersTime(d.Modified),
+		Source:      SourceVulners,
+		Provenance:  []Provenance{{Source: SourceVulners, ID: d.ID, URL: d.Href}},
+	}
+	if d.Href != "" {
+		vuln.References = []string{d.Href}
+	}
+	if len(d.CVEList) == 1 && d.CVEList[0] != d.ID {
+		vuln.ID = d.CVEList[0]
+		vuln.Aliases = []string{d.ID}
+	}
+	if d.Type == "exploitdb" || d.Type == "packetstorm" || d.Type == "metasploit" {
+		vuln.Exploits = []string{d.Href}
+	}
+	return vuln
+}
+
+// parseVulnersTime parses the times of Vulners, which lack a time zone
+func parseVulnersTime(s string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// quoteLucene quotes a term of a Lucene query unless it names a field
+func quoteLucene(term string) string {
+	if strings.Contains(term, ":") && !strings.Contains(term, " ") {
+		return term
+	}
+	return `"` + strings.ReplaceAll(term, `"`, `\"`) + `"`
+}
+
+// checkCache checks if cached results exist and are still valid
+func (c *VulnersConnector) checkCache(key string) ([]Vulnerability, bool) {
+	c.cacheLock.RLock()
+	defer c.cacheLock.RUnlock()
+	return readCache(c.CacheDir, key, c.cacheExpiry)
+}
+
+// cacheResults caches search results
+func (c *VulnersConnector) cacheResults(key string, vulns []Vulnerability) {
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
+	writeCache(c.CacheDir, key, vulns)
+}
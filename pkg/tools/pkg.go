@@ -4,7 +4,6 @@ package tools
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 
 	"GopherStrike/pkg/tools/discovery/dirbruteforce"
 	"GopherStrike/pkg/tools/recon/emailharvester"
@@ -37,13 +36,6 @@ func RunS3Scanner() error {
 	fmt.Println("\n[+] S3 Bucket Scanner")
 	fmt.Println("    ===============")
 
-	// Create logs directory for S3 scanner
-	logDir := filepath.Join("logs", "s3scanner")
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		fmt.Printf("[-] Error creating log directory: %v\n", err)
-		return err
-	}
-
 	// Run the S3 bucket scanner
 	if err := s3scanner.RunS3BucketScanner(); err != nil {
 		fmt.Printf("[-] Error running S3 bucket scanner: %v\n", err)
@@ -58,13 +50,6 @@ func RunEmailHarvester() error {
 	fmt.Println("\n[+] Email Harvester")
 	fmt.Println("    ==============")
 
-	// Create logs directory for email harvester
-	logDir := filepath.Join("logs", "emailharvester")
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		fmt.Printf("[-] Error creating log directory: %v\n", err)
-		return err
-	}
-
 	// Run the email harvester
 	if err := emailharvester.RunEmailHarvester(); err != nil {
 		fmt.Printf("[-] Error running email harvester: %v\n", err)
@@ -79,13 +64,6 @@ func RunDirBruteforcer() error {
 	fmt.Println("\n[+] Directory Bruteforcing Tool")
 	fmt.Println("    ========================")
 
-	// Create logs directory for directory bruteforcer
-	logDir := filepath.Join("logs", "dirbruteforce")
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		fmt.Printf("[-] Error creating log directory: %v\n", err)
-		return err
-	}
-
 	// Run the directory bruteforcer
 	if err := dirbruteforce.RunDirBruteforce(); err != nil {
 		fmt.Printf("[-] Error running directory bruteforcer: %v\n", err)
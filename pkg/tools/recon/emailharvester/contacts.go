@@ -0,0 +1,265 @@
+// pkg/tools/recon/emailharvester/contacts.go
+package emailharvester
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// contactPaths are the pages where organisations usually list their staff
+// and phone numbers, visited besides the links found when harvesting contacts
+var contactPaths = []string{"/about", "/about-us", "/team", "/our-team", "/leadership", "/contact"}
+
+// PhoneResult represents a found phone number and its sources
+type PhoneResult struct {
+	Number  string // As written on the first page it was found on
+	Sources []EmailSource
+}
+
+// PersonResult represents a person named on the target's pages with their
+// job title
+type PersonResult struct {
+	Name    string
+	Title   string
+	Sources []EmailSource
+}
+
+// ContactReport gathers the contact intelligence harvested for a domain
+type ContactReport struct {
+	Domain string
+	Emails []EmailResult
+	Phones []PhoneResult
+	People []PersonResult
+}
+
+var (
+	// labelledPhonePattern matches numbers introduced by a label, such as
+	// Tel: 020 7946 0958
+	labelledPhonePattern = regexp.MustCompile(`(?i)\b(?:tel|telephone|phone|mobile|cell|fax|call(?: us)?(?: at| on)?)\b\.?\s*:?\s*(\+?[\d(][\d\s().-]{5,20}\d)`)
+	// internationalPhonePattern matches numbers in international format
+	internationalPhonePattern = regexp.MustCompile(`\+\d{1,3}(?:[\s.-]?\(?\d{1,5}\)?){2,5}`)
+	// northAmericanPhonePattern matches numbers such as (555) 010-4477
+	northAmericanPhonePattern = regexp.MustCompile(`\(\d{3}\)\s?\d{3}[\s.-]\d{4}\b`)
+
+	// namePattern matches personal names of two or three capitalized words,
+	// with an optional middle initial
+	namePattern = regexp.MustCompile(`^(?:Dr\.?\s|Prof\.?\s)?\p{Lu}[\p{Ll}'’-]+(?:\s\p{Lu}\.)?(?:\s\p{Lu}[\p{Ll}'’-]+){1,2}$`)
+	// titlePattern matches the job titles people are listed with
+	titlePattern = regexp.MustCompile(`(?i)\b(?:ceo|cto|cfo|coo|ciso|cio|cmo|chief|president|vp|founder|co-founder|director|manager|head of|engineer|developer|officer|lead|partner|consultant|analyst|architect|administrator|specialist|coordinator|designer|counsel|secretary|chair(?:man|woman|person)?|principal|owner|recruiter|accountant)\b`)
+	// nameTitleSeparator splits "Jane Doe, Head of Sales" and its variants
+	nameTitleSeparator = regexp.MustCompile(`\s*(?:,|\||\s[-–—]\s)\s*`)
+)
+
+// nonNameWords are capitalized words of headings and navigation that look
+// like names
+var nonNameWords = map[string]bool{
+	"About": true, "Our": true, "Team": true, "Contact": true, "Meet": true, "The": true,
+	"Read": true, "More": true, "Learn": true, "Home": true, "Privacy": true, "Policy": true,
+	"Terms": true, "Services": true, "Careers": true, "Join": true, "Us": true, "News": true,
+	"Company": true, "Leadership": true, "Board": true, "Directors": true, "Get": true, "In": true,
+	"Touch": true, "Sign": true, "Log": true, "View": true, "Profile": true, "Follow": true,
+}
+
+// normalizePhone returns the digits of a phone number, with its leading +,
+// or "" if it has too few or too many digits to be one
+func normalizePhone(number string) string {
+	normalized := digitsOf(number)
+	if len(normalized) < 7 || len(normalized) > 15 {
+		return ""
+	}
+	if strings.HasPrefix(strings.TrimSpace(number), "+") {
+		normalized = "+" + normalized
+	}
+	return normalized
+}
+
+// phoneKey identifies a phone number by its last nine digits, so that a
+// number written with and without its country code or trunk prefix, such
+// as +44 20 7946 0958 and 020 7946 0958, is the same number
+func phoneKey(number string) string {
+	digits := digitsOf(number)
+	if len(digits) > 9 {
+		return digits[len(digits)-9:]
+	}
+	return digits
+}
+
+// digitsOf returns the digits of a string
+func digitsOf(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ExtractPhones returns the phone numbers of an HTML page: tel: links,
+// numbers in international or North American format and numbers labelled
+// as phone or fax numbers. Bare digit runs are left out, being as often
+// dates, prices or identifiers. Numbers are listed once, as first written.
+func ExtractPhones(body string) []string {
+	phones := []string{}
+	seen := make(map[string]bool)
+	add := func(number string) {
+		number = strings.Trim(strings.TrimSpace(number), ".-")
+		if normalizePhone(number) != "" && !seen[phoneKey(number)] {
+			seen[phoneKey(number)] = true
+			phones = append(phones, number)
+		}
+	}
+
+	tokenizer := html.NewTokenizer(strings.NewReader(body))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return phones
+		case html.TextToken:
+			text := html.UnescapeString(string(tokenizer.Text()))
+			for _, match := range labelledPhonePattern.FindAllStringSubmatch(text, -1) {
+				add(match[1])
+			}
+			for _, pattern := range []*regexp.Regexp{internationalPhonePattern, northAmericanPhonePattern} {
+				for _, match := range pattern.FindAllString(text, -1) {
+					add(match)
+				}
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			for _, attr := range tagAttributes(tokenizer) {
+				if attr.Key == "href" && strings.HasPrefix(strings.ToLower(attr.Val), "tel:") {
+					add(attr.Val[len("tel:"):])
+				}
+			}
+		}
+	}
+}
+
+// Person is a person named on a page with their job title
+type Person struct {
+	Name  string
+	Title string
+}
+
+// isName reports whether a text looks like a personal name
+func isName(text string) bool {
+	if !namePattern.MatchString(text) || titlePattern.MatchString(text) {
+		return false
+	}
+	for _, word := range strings.Fields(text) {
+		if nonNameWords[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// isTitle reports whether a text looks like a job title
+func isTitle(text string) bool {
+	return len(text) <= 80 && titlePattern.MatchString(text)
+}
+
+// ExtractPeople returns the people an HTML page names with their job
+// titles, as team and about pages list them: a name followed by a title in
+// the next block of text, "Name, Title" or "Name - Title" in one block, and
+// schema.org Person objects of JSON-LD scripts. Names without a title are
+// left out, too many headings and links looking like names.
+func ExtractPeople(body string) []Person {
+	people := []Person{}
+	seen := make(map[string]bool)
+	add := func(name, title string) {
+		name = strings.Join(strings.Fields(name), " ")
+		title = strings.Join(strings.Fields(title), " ")
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			people = append(people, Person{Name: name, Title: title})
+		}
+	}
+
+	blocks := []string{}
+	skipped := ""
+	tokenizer := html.NewTokenizer(strings.NewReader(body))
+	for done := false; !done; {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			done = true
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "script", "style", "noscript":
+				skipped = string(name)
+				jsonLD := false
+				for _, attr := range tagAttributes(tokenizer) {
+					jsonLD = jsonLD || (attr.Key == "type" && strings.EqualFold(attr.Val, "application/ld+json"))
+				}
+				if jsonLD {
+					if tokenizer.Next() != html.TextToken {
+						skipped = "" // An empty script, whose end tag was read
+						continue
+					}
+					var document interface{}
+					if json.Unmarshal(tokenizer.Text(), &document) == nil {
+						for _, person := range jsonLDPeople(document) {
+							add(person.Name, person.Title)
+						}
+					}
+				}
+			}
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == skipped {
+				skipped = ""
+			}
+		case html.TextToken:
+			if skipped != "" {
+				continue
+			}
+			if text := strings.Join(strings.Fields(html.UnescapeString(string(tokenizer.Text()))), " "); text != "" {
+				blocks = append(blocks, text)
+			}
+		}
+	}
+
+	for i, block := range blocks {
+		if isName(block) {
+			if i+1 < len(blocks) && isTitle(blocks[i+1]) && !isName(blocks[i+1]) {
+				add(block, blocks[i+1])
+			}
+			continue
+		}
+		if parts := nameTitleSeparator.Split(block, 2); len(parts) == 2 && isName(parts[0]) && isTitle(parts[1]) {
+			add(parts[0], parts[1])
+		}
+	}
+	return people
+}
+
+// jsonLDPeople returns the schema.org Person objects of a JSON-LD document
+// that have a job title
+func jsonLDPeople(v interface{}) []Person {
+	people := []Person{}
+	switch value := v.(type) {
+	case map[string]interface{}:
+		name, _ := value["name"].(string)
+		title, _ := value["jobTitle"].(string)
+		if kind, _ := value["@type"].(string); kind == "Person" && name != "" && title != "" {
+			people = append(people, Person{Name: name, Title: title})
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			people = append(people, jsonLDPeople(value[key])...)
+		}
+	case []interface{}:
+		for _, item := range value {
+			people = append(people, jsonLDPeople(item)...)
+		}
+	}
+	return people
+}
@@ -0,0 +1,72 @@
+package emailharvester
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractPhones(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"tel link", `<a href="tel:+44-20-7946-0958">Call</a>`, []string{"+44-20-7946-0958"}},
+		{"international", `<p>Office: +1 555 010 4477</p>`, []string{"+1 555 010 4477"}},
+		{"north american", `<p>(555) 010-4477</p>`, []string{"(555) 010-4477"}},
+		{"labelled", `<p>Tel: 020 7946 0958, Fax. 020 7946 0959</p>`, []string{"020 7946 0958", "020 7946 0959"}},
+		{"duplicates", `<a href="tel:+15550104477">+1 (555) 010-4477</a>`, []string{"+15550104477"}},
+		{"dates and prices", `<p>Updated 2024-01-15, now 1999.00 for order 123456789</p>`, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractPhones(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractPhones() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractPeople(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Person
+	}{
+		{
+			"cards",
+			`<div class="member"><h3>Jane Doe</h3><p>Chief Technology Officer</p></div>
+			<div class="member"><h3>John Q. Smith</h3><p>Head of Sales</p></div>`,
+			[]Person{{"Jane Doe", "Chief Technology Officer"}, {"John Q. Smith", "Head of Sales"}},
+		},
+		{
+			"single block",
+			`<li>Maria García, Senior Software Engineer</li><li>Tom Brown – Founder &amp; CEO</li>`,
+			[]Person{{"Maria García", "Senior Software Engineer"}, {"Tom Brown", "Founder & CEO"}},
+		},
+		{
+			"json-ld",
+			`<script type="application/ld+json">{"@graph":[{"@type":"Person","name":"Ada Lovelace","jobTitle":"Analyst"}]}</script>`,
+			[]Person{{"Ada Lovelace", "Analyst"}},
+		},
+		{
+			"headings",
+			`<h2>Meet The Team</h2><p>Engineering Manager roles are open</p><a>Privacy Policy</a><p>Director</p>`,
+			[]Person{},
+		},
+		{
+			"names without titles",
+			`<p>Jane Doe</p><p>Loves hiking</p>`,
+			[]Person{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractPeople(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractPeople() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
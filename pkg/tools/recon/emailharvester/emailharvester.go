@@ -2,11 +2,20 @@
 package emailharvester
 
 import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/httpclient"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/robots"
+	"GopherStrike/pkg/timing"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"os"
-	"regexp"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -35,6 +44,9 @@ type HarvesterOptions struct {
 	IncludeSubdomains bool
 	MaxPages          int
 	SearchEngines     bool
+	Robots            robots.Options // robots.txt rules, crawl delays and request budget per host
+	Contacts          bool           // Also harvest phone numbers and people with their job titles
+	ContactsFile      string         // Contact intelligence report, written when set
 }
 
 // DefaultHarvesterOptions returns the default harvester options
@@ -43,7 +55,7 @@ func DefaultHarvesterOptions() HarvesterOptions {
 		MaxDepth:    2,
 		FollowLinks: true,
 		Timeout:     10,
-		OutputFile:  "logs/recon/emails.txt",
+		OutputFile:  "", // Set to the target's artifact directory by the CLI
 		ExcludedDomains: []string{
 			"facebook.com", "twitter.com", "linkedin.com",
 			"instagram.com", "youtube.com", "google.com",
@@ -51,28 +63,64 @@ func DefaultHarvesterOptions() HarvesterOptions {
 		IncludeSubdomains: true,
 		MaxPages:          100,
 		SearchEngines:     true,
+		Robots:            robots.Defaults(),
+		Contacts:          true,
 	}
 }
 
 // EmailHarvester represents an email harvester
 type EmailHarvester struct {
 	options     HarvesterOptions
-	results     map[string]EmailResult // Using map to deduplicate emails
+	results     map[string]EmailResult  // Using map to deduplicate emails
+	phones      map[string]PhoneResult  // Keyed by phoneKey
+	people      map[string]PersonResult // Keyed by lowercased name
 	visitedURLs map[string]bool
 	client      *http.Client
+	robots      *robots.Guard
+	skipped     int // URLs skipped by the crawl policies
 	mutex       sync.Mutex
 	domain      string
 }
 
+// PresetDescriptions tells what each harvesting preset does
+var PresetDescriptions = map[string]string{
+	presets.Quick:  "The target's pages only, 1 link deep and up to 20 pages",
+	presets.Normal: "Pages 2 links deep, up to 100 pages, and search engine results",
+	presets.Deep:   "Pages 4 links deep, up to 500 pages, and search engine results",
+}
+
+// PresetHarvesterOptions returns the harvester options of a preset
+func PresetHarvesterOptions(preset string) (HarvesterOptions, error) {
+	preset, err := presets.Validate(preset)
+	if err != nil {
+		return HarvesterOptions{}, err
+	}
+
+	options := DefaultHarvesterOptions()
+	switch preset {
+	case presets.Quick:
+		options.MaxDepth = 1
+		options.MaxPages = 20
+		options.SearchEngines = false
+	case presets.Deep:
+		options.MaxDepth = 4
+		options.MaxPages = 500
+	}
+
+	profile := timing.Current()
+	options.Timeout = profile.TimeoutSeconds(options.Timeout)
+	return options, nil
+}
+
 // NewEmailHarvester creates a new email harvester
 func NewEmailHarvester(options HarvesterOptions) *EmailHarvester {
-	client := &http.Client{
-		Timeout: time.Duration(options.Timeout) * time.Second,
-	}
+	client := httpclient.New("emailharvester", time.Duration(options.Timeout)*time.Second, nil)
 
 	return &EmailHarvester{
 		options:     options,
 		results:     make(map[string]EmailResult),
+		phones:      make(map[string]PhoneResult),
+		people:      make(map[string]PersonResult),
 		visitedURLs: make(map[string]bool),
 		client:      client,
 		mutex:       sync.Mutex{},
@@ -83,7 +131,11 @@ func NewEmailHarvester(options HarvesterOptions) *EmailHarvester {
 func (h *EmailHarvester) Harvest(domain string) ([]EmailResult, error) {
 	h.domain = domain
 	h.results = make(map[string]EmailResult)
+	h.phones = make(map[string]PhoneResult)
+	h.people = make(map[string]PersonResult)
 	h.visitedURLs = make(map[string]bool)
+	h.robots = robots.NewGuard(h.client, h.options.Robots)
+	h.skipped = 0
 
 	fmt.Printf("[+] Starting email harvesting for domain: %s\n", domain)
 
@@ -93,6 +145,13 @@ func (h *EmailHarvester) Harvest(domain string) ([]EmailResult, error) {
 		fmt.Sprintf("https://www.%s", domain),
 	}
 
+	// Add the pages listing staff and phone numbers if contacts are harvested
+	if h.options.Contacts {
+		for _, path := range contactPaths {
+			startingURLs = append(startingURLs, fmt.Sprintf("https://%s%s", domain, path))
+		}
+	}
+
 	// Add search engine queries if enabled
 	if h.options.SearchEngines {
 		searchEngineURLs := h.generateSearchEngineURLs(domain)
@@ -111,6 +170,10 @@ func (h *EmailHarvester) Harvest(domain string) ([]EmailResult, error) {
 
 	wg.Wait()
 
+	if h.skipped > 0 {
+		fmt.Printf("[i] Skipped %d URL(s) disallowed by robots.txt or beyond the request budget of their host\n", h.skipped)
+	}
+
 	// Convert results map to slice
 	resultSlice := make([]EmailResult, 0, len(h.results))
 	for _, result := range h.results {
@@ -124,6 +187,11 @@ func (h *EmailHarvester) Harvest(domain string) ([]EmailResult, error) {
 			fmt.Printf("[!] Error saving results: %v\n", err)
 		}
 	}
+	if h.options.Contacts && h.options.ContactsFile != "" {
+		if err := h.saveContacts(h.Contacts()); err != nil {
+			fmt.Printf("[!] Error saving contacts: %v\n", err)
+		}
+	}
 
 	return resultSlice, nil
 }
@@ -154,6 +222,18 @@ func (h *EmailHarvester) processURL(url string, depth int) {
 		}
 	}
 
+	// Follow the site's robots.txt, crawl delay and request budget
+	link, err := neturl.Parse(url)
+	if err != nil {
+		return
+	}
+	if err := h.robots.Check(context.Background(), link); err != nil {
+		h.mutex.Lock()
+		h.skipped++
+		h.mutex.Unlock()
+		return
+	}
+
 	// Get the page content
 	resp, err := h.client.Get(url)
 	if err != nil {
@@ -161,6 +241,11 @@ func (h *EmailHarvester) processURL(url string, depth int) {
 	}
 	defer resp.Body.Close()
 
+	// Only text content holds addresses and links worth parsing
+	if !isTextContent(resp.Header.Get("Content-Type")) {
+		return
+	}
+
 	// Read the response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -182,6 +267,16 @@ func (h *EmailHarvester) processURL(url string, depth int) {
 		}
 	}
 
+	// Extract phone numbers and people from the target's own pages
+	if h.options.Contacts && h.isDomainRelevant(url) {
+		for _, phone := range ExtractPhones(string(body)) {
+			h.addPhoneResult(phone, source)
+		}
+		for _, person := range ExtractPeople(string(body)) {
+			h.addPersonResult(person, source)
+		}
+	}
+
 	// Follow links if enabled and not at max depth
 	if h.options.FollowLinks && depth < h.options.MaxDepth {
 		links := h.extractLinks(string(body), url)
@@ -223,92 +318,42 @@ func (h *EmailHarvester) shouldIncludeEmail(email string) bool {
 	return false
 }
 
-// extractEmails extracts email addresses from text
-func (h *EmailHarvester) extractEmails(text string) []string {
-	// Email regex pattern
-	pattern := `[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`
-	re := regexp.MustCompile(pattern)
-
-	// Find all matches
-	matches := re.FindAllString(text, -1)
-
-	// Deduplicate
-	emailMap := make(map[string]bool)
-	for _, email := range matches {
-		emailMap[strings.ToLower(email)] = true
-	}
-
-	// Convert back to slice
-	uniqueEmails := make([]string, 0, len(emailMap))
-	for email := range emailMap {
-		uniqueEmails = append(uniqueEmails, email)
-	}
-
-	return uniqueEmails
+// extractEmails extracts email addresses from a page
+func (h *EmailHarvester) extractEmails(body string) []string {
+	return ExtractEmails(body)
 }
 
-// extractLinks extracts links from HTML
-func (h *EmailHarvester) extractLinks(html, baseURL string) []string {
-	// Link regex pattern - simple version, a real implementation would use an HTML parser
-	pattern := `href=["']([^"']+)["']`
-	re := regexp.MustCompile(pattern)
-
-	// Find all matches
-	matches := re.FindAllStringSubmatch(html, -1)
+// extractLinks extracts the links of a page to the target domain or subdomains
+func (h *EmailHarvester) extractLinks(body, pageURL string) []string {
+	base, err := neturl.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
 
-	// Process and filter links
 	links := make([]string, 0)
-	for _, match := range matches {
-		if len(match) < 2 {
-			continue
-		}
-
-		link := match[1]
-
-		// Handle relative URLs
-		if !strings.HasPrefix(link, "http") {
-			if strings.HasPrefix(link, "/") {
-				// Absolute path
-				parts := strings.Split(baseURL, "//")
-				if len(parts) > 1 {
-					domain := strings.Split(parts[1], "/")[0]
-					link = fmt.Sprintf("%s//%s%s", parts[0], domain, link)
-				}
-			} else {
-				// Relative path
-				if !strings.HasSuffix(baseURL, "/") {
-					baseURL += "/"
-				}
-				link = baseURL + link
-			}
-		}
-
-		// Only include links to the same domain or subdomains
+	for _, link := range ExtractLinks(body, base) {
 		if h.isDomainRelevant(link) {
 			links = append(links, link)
 		}
 	}
-
 	return links
 }
 
 // isDomainRelevant checks if a URL belongs to the target domain or subdomains
 func (h *EmailHarvester) isDomainRelevant(url string) bool {
-	// Extract domain from URL
-	parts := strings.Split(url, "//")
-	if len(parts) < 2 {
+	link, err := neturl.Parse(url)
+	if err != nil {
 		return false
 	}
-
-	domainPart := strings.Split(parts[1], "/")[0]
+	host := strings.ToLower(link.Hostname())
 
 	// Check if it's the target domain
-	if domainPart == h.domain || domainPart == "www."+h.domain {
+	if host == h.domain || host == "www."+h.domain {
 		return true
 	}
 
 	// Check if it's a subdomain
-	if h.options.IncludeSubdomains && strings.HasSuffix(domainPart, "."+h.domain) {
+	if h.options.IncludeSubdomains && strings.HasSuffix(host, "."+h.domain) {
 		return true
 	}
 
@@ -363,11 +408,122 @@ func (h *EmailHarvester) addEmailResult(email string, source EmailSource) {
 	}
 }
 
+// addPhoneResult adds a phone number to the results
+func (h *EmailHarvester) addPhoneResult(number string, source EmailSource) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	key := phoneKey(number)
+	result, exists := h.phones[key]
+	if !exists {
+		result = PhoneResult{Number: number}
+		fmt.Printf("[+] Found phone number: %s\n", number)
+	}
+	for _, s := range result.Sources {
+		if s.URL == source.URL {
+			return
+		}
+	}
+	result.Sources = append(result.Sources, source)
+	h.phones[key] = result
+}
+
+// addPersonResult adds a person to the results, keeping the first title
+// found for them
+func (h *EmailHarvester) addPersonResult(person Person, source EmailSource) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	key := strings.ToLower(person.Name)
+	result, exists := h.people[key]
+	if !exists {
+		result = PersonResult{Name: person.Name, Title: person.Title}
+		fmt.Printf("[+] Found person: %s (%s)\n", person.Name, person.Title)
+	}
+	for _, s := range result.Sources {
+		if s.URL == source.URL {
+			return
+		}
+	}
+	result.Sources = append(result.Sources, source)
+	h.people[key] = result
+}
+
+// Contacts returns the contact intelligence of the last harvest, sorted
+func (h *EmailHarvester) Contacts() ContactReport {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	report := ContactReport{Domain: h.domain}
+	for _, result := range h.results {
+		report.Emails = append(report.Emails, result)
+	}
+	for _, result := range h.phones {
+		report.Phones = append(report.Phones, result)
+	}
+	for _, result := range h.people {
+		report.People = append(report.People, result)
+	}
+	sort.Slice(report.Emails, func(i, j int) bool { return report.Emails[i].Email < report.Emails[j].Email })
+	sort.Slice(report.Phones, func(i, j int) bool { return report.Phones[i].Number < report.Phones[j].Number })
+	sort.Slice(report.People, func(i, j int) bool { return report.People[i].Name < report.People[j].Name })
+	return report
+}
+
+// saveContacts saves the contact intelligence report to a file
+func (h *EmailHarvester) saveContacts(report ContactReport) error {
+	if err := os.MkdirAll(filepath.Dir(h.options.ContactsFile), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(h.options.ContactsFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := redact.Writer(file)
+	defer w.Close()
+
+	io.WriteString(w, "# Contact Intelligence Report\n")
+	fmt.Fprintf(w, "# Domain: %s\n", report.Domain)
+	io.WriteString(w, "# Generated by GopherStrike EmailHarvester\n")
+	io.WriteString(w, "# "+time.Now().Format(time.RFC3339)+"\n\n")
+
+	fmt.Fprintf(w, "## People (%d)\n\n", len(report.People))
+	for _, person := range report.People {
+		fmt.Fprintf(w, "%s - %s\n", person.Name, person.Title)
+		writeSources(w, person.Sources)
+	}
+
+	fmt.Fprintf(w, "## Phone Numbers (%d)\n\n", len(report.Phones))
+	for _, phone := range report.Phones {
+		fmt.Fprintf(w, "%s\n", phone.Number)
+		writeSources(w, phone.Sources)
+	}
+
+	fmt.Fprintf(w, "## Email Addresses (%d)\n\n", len(report.Emails))
+	for _, result := range report.Emails {
+		fmt.Fprintf(w, "%s\n", result.Email)
+		writeSources(w, result.Sources)
+	}
+
+	fmt.Printf("[+] Contact report saved to: %s\n", h.options.ContactsFile)
+	return nil
+}
+
+// writeSources writes the sources of a result
+func writeSources(w io.Writer, sources []EmailSource) {
+	io.WriteString(w, "  Sources:\n")
+	for _, source := range sources {
+		fmt.Fprintf(w, "  - %s (%s)\n", source.URL, source.Type)
+	}
+	io.WriteString(w, "\n")
+}
+
 // saveResults saves the harvested emails to a file
 func (h *EmailHarvester) saveResults(results []EmailResult) error {
 	// Create directory if it doesn't exist
-	dir := h.options.OutputFile[:strings.LastIndex(h.options.OutputFile, "/")]
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(h.options.OutputFile), 0755); err != nil {
 		return err
 	}
 
@@ -377,23 +533,25 @@ func (h *EmailHarvester) saveResults(results []EmailResult) error {
 		return err
 	}
 	defer file.Close()
+	w := redact.Writer(file)
+	defer w.Close()
 
 	// Write header
-	file.WriteString("# Email Harvesting Results\n")
-	file.WriteString(fmt.Sprintf("# Domain: %s\n", h.domain))
-	file.WriteString("# Generated by GopherStrike EmailHarvester\n")
-	file.WriteString("# " + time.Now().Format(time.RFC3339) + "\n\n")
+	io.WriteString(w, "# Email Harvesting Results\n")
+	fmt.Fprintf(w, "# Domain: %s\n", h.domain)
+	io.WriteString(w, "# Generated by GopherStrike EmailHarvester\n")
+	io.WriteString(w, "# "+time.Now().Format(time.RFC3339)+"\n\n")
 
 	// Write results
 	for _, result := range results {
-		file.WriteString(fmt.Sprintf("%s\n", result.Email))
-		file.WriteString("  Sources:\n")
+		fmt.Fprintf(w, "%s\n", result.Email)
+		io.WriteString(w, "  Sources:\n")
 
 		for _, source := range result.Sources {
-			file.WriteString(fmt.Sprintf("  - %s (%s)\n", source.URL, source.Type))
+			fmt.Fprintf(w, "  - %s (%s)\n", source.URL, source.Type)
 		}
 
-		file.WriteString("\n")
+		io.WriteString(w, "\n")
 	}
 
 	fmt.Printf("[+] Results saved to: %s\n", h.options.OutputFile)
@@ -415,10 +573,25 @@ func RunEmailHarvester() error {
 	}
 
 	// Configure options
-	options := DefaultHarvesterOptions()
+	options, err := PresetHarvesterOptions(presets.Prompt(nil, PresetDescriptions))
+	if err != nil {
+		return err
+	}
+
+	// Results are kept in the target's recon artifact directory
+	outputFile, err := artifacts.Default().Path(domain, artifacts.KindRecon, artifacts.TimestampedName("emails", "txt"))
+	if err != nil {
+		return err
+	}
+	options.OutputFile = outputFile
+	contactsFile, err := artifacts.Default().Path(domain, artifacts.KindRecon, artifacts.TimestampedName("contacts", "txt"))
+	if err != nil {
+		return err
+	}
+	options.ContactsFile = contactsFile
 
 	// Configure max depth
-	fmt.Print("[?] Maximum crawl depth (default: 2): ")
+	fmt.Printf("[?] Maximum crawl depth (default: %d): ", options.MaxDepth)
 	var depthStr string
 	fmt.Scanln(&depthStr)
 
@@ -438,12 +611,19 @@ func RunEmailHarvester() error {
 	}
 
 	// Configure search engines
-	fmt.Print("[?] Use search engines for discovery? (Y/n): ")
+	if options.SearchEngines {
+		fmt.Print("[?] Use search engines for discovery? (Y/n): ")
+	} else {
+		fmt.Print("[?] Use search engines for discovery? (y/N): ")
+	}
 	var useSearchEngines string
 	fmt.Scanln(&useSearchEngines)
 
-	if strings.ToLower(useSearchEngines) == "n" {
+	switch strings.ToLower(useSearchEngines) {
+	case "n":
 		options.SearchEngines = false
+	case "y":
+		options.SearchEngines = true
 	}
 
 	// Create and run harvester
@@ -456,6 +636,10 @@ func RunEmailHarvester() error {
 
 	// Print summary
 	fmt.Printf("\n[+] Harvesting complete! Found %d email addresses\n", len(results))
+	if options.Contacts {
+		contacts := harvester.Contacts()
+		fmt.Printf("[+] Found %d phone numbers and %d people\n", len(contacts.Phones), len(contacts.People))
+	}
 
 	if options.OutputFile != "" {
 		fmt.Printf("[+] Results saved to: %s\n", options.OutputFile)
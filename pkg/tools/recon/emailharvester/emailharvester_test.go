@@ -0,0 +1,49 @@
+package emailharvester
+
+import (
+	"GopherStrike/pkg/redact"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSavedResultsRedacted(t *testing.T) {
+	rules, err := redact.Parse(strings.NewReader("Acme Corp\nparam:token\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	redact.Set(rules)
+	defer redact.Set(nil)
+
+	dir := t.TempDir()
+	h := &EmailHarvester{
+		options: HarvesterOptions{OutputFile: filepath.Join(dir, "emails.txt"), ContactsFile: filepath.Join(dir, "contacts.txt")},
+		domain:  "acme.example",
+	}
+	sources := []EmailSource{{URL: "https://acme.example/team?token=s3cr3t", Type: "webpage"}}
+	if err := h.saveResults([]EmailResult{{Email: "ceo@acme.example", Sources: sources}}); err != nil {
+		t.Fatalf("saveResults() error = %v", err)
+	}
+	report := ContactReport{
+		Domain: "acme.example",
+		Emails: []EmailResult{{Email: "ceo@acme.example", Sources: sources}},
+		People: []PersonResult{{Name: "Jane Doe", Title: "CEO of Acme Corp", Sources: sources}},
+	}
+	if err := h.saveContacts(report); err != nil {
+		t.Fatalf("saveContacts() error = %v", err)
+	}
+
+	for _, name := range []string{"emails.txt", "contacts.txt"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "s3cr3t") || strings.Contains(string(data), "Acme Corp") {
+			t.Errorf("%s not redacted:\n%s", name, data)
+		}
+		if !strings.Contains(string(data), "ceo@acme.example") || !strings.Contains(string(data), "token=[REDACTED]") {
+			t.Errorf("%s lost its results:\n%s", name, data)
+		}
+	}
+}
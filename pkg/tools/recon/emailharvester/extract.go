@@ -0,0 +1,211 @@
+// pkg/tools/recon/emailharvester/extract.go
+package emailharvester
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// linkAttributes maps tags to the attributes holding the URLs they link to
+var linkAttributes = map[string][]string{
+	"a":      {"href"},
+	"area":   {"href"},
+	"link":   {"href"},
+	"frame":  {"src"},
+	"iframe": {"src"},
+	"img":    {"src", "srcset"},
+	"source": {"src", "srcset"},
+	"form":   {"action"},
+}
+
+var (
+	// emailPattern matches plain email addresses
+	emailPattern = regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)
+
+	// obfuscatedAt matches the @ of addresses written to fool harvesters,
+	// such as name [at] example [dot] com or name(at)example.com
+	obfuscatedAt = regexp.MustCompile(`(?i)\s*[\[({]\s*at\s*[\])}]\s*|\s+at\s+`)
+	// obfuscatedDot matches their dots
+	obfuscatedDot = regexp.MustCompile(`(?i)\s*[\[({]\s*dot\s*[\])}]\s*|\s+dot\s+`)
+	// obfuscatedEmail matches a whole obfuscated address, whose @ is
+	// bracketed or whose dots are spelled out
+	obfuscatedEmail = regexp.MustCompile(`(?i)[a-z0-9._%+-]+(?:\s*[\[({]\s*at\s*[\])}]\s*[a-z0-9-]+(?:(?:\s*[\[({]\s*dot\s*[\])}]\s*|\s+dot\s+|\.)[a-z0-9-]+)+|` +
+		`\s+at\s+[a-z0-9-]+(?:(?:\s*[\[({]\s*dot\s*[\])}]\s*|\s+dot\s+)[a-z0-9-]+)+)`)
+
+	// refreshURL extracts the target of a meta refresh, such as 5; url=/next
+	refreshURL = regexp.MustCompile(`(?i)^\s*[\d.]+\s*[;,]\s*(?:url\s*=\s*)?['"]?([^'"]+)`)
+)
+
+// deobfuscate rewrites an obfuscated address as a plain one
+func deobfuscate(s string) string {
+	s = obfuscatedAt.ReplaceAllString(s, "@")
+	return obfuscatedDot.ReplaceAllString(s, ".")
+}
+
+// findEmails returns the plain and obfuscated addresses of a text
+func findEmails(text string) []string {
+	emails := emailPattern.FindAllString(text, -1)
+	for _, match := range obfuscatedEmail.FindAllString(text, -1) {
+		if email := deobfuscate(match); emailPattern.MatchString(email) {
+			emails = append(emails, emailPattern.FindString(email))
+		}
+	}
+	return emails
+}
+
+// ExtractEmails returns the addresses of an HTML page, lowercased and
+// sorted: in its text, comments and attributes, mailto: links and
+// addresses obfuscated as name [at] example [dot] com. Character
+// references such as &#64; are decoded first.
+func ExtractEmails(body string) []string {
+	found := make(map[string]bool)
+	add := func(text string) {
+		for _, email := range findEmails(text) {
+			found[strings.ToLower(strings.Trim(email, "."))] = true
+		}
+	}
+
+	tokenizer := html.NewTokenizer(strings.NewReader(body))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			emails := make([]string, 0, len(found))
+			for email := range found {
+				emails = append(emails, email)
+			}
+			sort.Strings(emails)
+			return emails
+		case html.TextToken, html.CommentToken:
+			add(html.UnescapeString(string(tokenizer.Text())))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			for _, attr := range tagAttributes(tokenizer) {
+				value := attr.Val
+				if strings.HasPrefix(strings.ToLower(value), "mailto:") {
+					// mailto:a@example.com,b@example.com?subject=Hello
+					value, _, _ = strings.Cut(value[len("mailto:"):], "?")
+					if unescaped, err := url.PathUnescape(value); err == nil {
+						value = unescaped
+					}
+					value = strings.ReplaceAll(value, ",", " ")
+				}
+				add(value)
+			}
+		}
+	}
+}
+
+// tagAttributes returns the attributes of the current tag token
+func tagAttributes(tokenizer *html.Tokenizer) []html.Attribute {
+	attrs := []html.Attribute{}
+	for {
+		key, value, more := tokenizer.TagAttr()
+		if len(key) > 0 {
+			attrs = append(attrs, html.Attribute{Key: string(key), Val: string(value)})
+		}
+		if !more {
+			return attrs
+		}
+	}
+}
+
+// srcsetURLs returns the URLs of a srcset attribute, such as
+// "small.jpg 480w, large.jpg 1080w"
+func srcsetURLs(srcset string) []string {
+	urls := []string{}
+	for _, candidate := range strings.Split(srcset, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			urls = append(urls, fields[0])
+		}
+	}
+	return urls
+}
+
+// ExtractLinks returns the http and https URLs an HTML page links to,
+// resolved against its URL and its <base> element: links, frames, images
+// and their srcset, form targets and meta refresh redirects. Fragments
+// are dropped and each URL is listed once.
+func ExtractLinks(body string, pageURL *url.URL) []string {
+	base := pageURL
+	links := []string{}
+	seen := make(map[string]bool)
+	add := func(ref string) {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			return
+		}
+		link, err := base.Parse(ref)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+			return
+		}
+		link.Fragment = ""
+		if !seen[link.String()] {
+			seen[link.String()] = true
+			links = append(links, link.String())
+		}
+	}
+
+	tokenizer := html.NewTokenizer(strings.NewReader(body))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			return links
+		}
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+
+		name, _ := tokenizer.TagName()
+		tag := string(name)
+		attrs := make(map[string]string)
+		for _, attr := range tagAttributes(tokenizer) {
+			attrs[strings.ToLower(attr.Key)] = attr.Val
+		}
+
+		switch tag {
+		case "base":
+			if href, err := pageURL.Parse(strings.TrimSpace(attrs["href"])); err == nil && attrs["href"] != "" {
+				base = href
+			}
+		case "meta":
+			if strings.EqualFold(attrs["http-equiv"], "refresh") {
+				if match := refreshURL.FindStringSubmatch(attrs["content"]); match != nil {
+					add(match[1])
+				}
+			}
+		default:
+			for _, attribute := range linkAttributes[tag] {
+				value, ok := attrs[attribute]
+				if !ok {
+					continue
+				}
+				if attribute == "srcset" {
+					for _, ref := range srcsetURLs(value) {
+						add(ref)
+					}
+				} else {
+					add(value)
+				}
+			}
+		}
+	}
+}
+
+// isTextContent reports whether a content type is worth parsing for
+// addresses and links: HTML, plain text, JSON, XML and scripts. Responses
+// without a content type are parsed too.
+func isTextContent(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if contentType == "" || strings.HasPrefix(contentType, "text/") {
+		return true
+	}
+	for _, kind := range []string{"html", "json", "xml", "javascript"} {
+		if strings.Contains(contentType, kind) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package emailharvester
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestExtractEmails(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"text", `<p>Write to Info@Example.com.</p>`, []string{"info@example.com"}},
+		{"mailto", `<a href="mailto:sales%40example.com?subject=Hi">Sales</a>`, []string{"sales@example.com"}},
+		{"mailto list", `<a href="mailto:a@example.com,b@example.com">Us</a>`, []string{"a@example.com", "b@example.com"}},
+		{"character references", `<span>jobs&#64;example&#46;com</span>`, []string{"jobs@example.com"}},
+		{"bracketed", `<li>john [at] example [dot] com</li>`, []string{"john@example.com"}},
+		{"parenthesized", `<li>jane(at)example.co.uk</li>`, []string{"jane@example.co.uk"}},
+		{"spelled out", `<li>press at example dot com</li>`, []string{"press@example.com"}},
+		{"prose", `<p>We met at home. Look at the docs.</p>`, []string{}},
+		{"comment", `<!-- admin@example.com -->`, []string{"admin@example.com"}},
+		{"attribute", `<div data-contact="hr@example.com"></div>`, []string{"hr@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractEmails(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractEmails() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractLinks(t *testing.T) {
+	page, _ := url.Parse("https://example.com/docs/index.html")
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"relative", `<a href="guide.html#intro">Guide</a>`, []string{"https://example.com/docs/guide.html"}},
+		{"absolute path", `<a href="/about">About</a><a href="/about">Again</a>`, []string{"https://example.com/about"}},
+		{"parent", `<a href="../team/">Team</a>`, []string{"https://example.com/team/"}},
+		{"other schemes", `<a href="mailto:a@example.com">Mail</a><a href="javascript:void(0)">JS</a>`, []string{}},
+		{"base", `<base href="https://cdn.example.com/v2/"><a href="page">Page</a>`, []string{"https://cdn.example.com/v2/page"}},
+		{"srcset", `<img src="a.png" srcset="a-480.png 480w, /img/a-1080.png 1080w">`,
+			[]string{"https://example.com/docs/a.png", "https://example.com/docs/a-480.png", "https://example.com/img/a-1080.png"}},
+		{"meta refresh", `<meta http-equiv="Refresh" content="5; URL='/moved'">`, []string{"https://example.com/moved"}},
+		{"frames", `<iframe src="//sub.example.com/embed"></iframe>`, []string{"https://sub.example.com/embed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractLinks(tt.body, page); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractLinks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,12 +2,23 @@
 package s3scanner
 
 import (
-	"bufio"
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/httpclient"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/pii"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/siem"
+	"GopherStrike/pkg/timing"
+	"GopherStrike/pkg/wordlist"
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,9 +33,13 @@ type S3BucketResult struct {
 	Public         bool
 	ListingEnabled bool
 	Objects        []string
+	PII            []pii.Match // Personal data named by the listed object keys
 	Error          string
 }
 
+// maxEvidenceObjects is the number of listed object keys included in a finding
+const maxEvidenceObjects = 20
+
 // S3ScanOptions contains options for the S3 scanner
 type S3ScanOptions struct {
 	Threads      int
@@ -42,7 +57,7 @@ func DefaultS3ScanOptions() S3ScanOptions {
 		Threads:      10,
 		Timeout:      5,
 		CheckListing: true,
-		OutputFile:   "logs/recon/s3buckets.txt",
+		OutputFile:   "", // Set to the target\'s artifact directory by the CLI
 		Verbose:      true,
 		WaitTime:     100,
 		WordlistPath: "", // Will be set based on user choice
@@ -57,15 +72,46 @@ type Scanner struct {
 	mutex   sync.Mutex
 }
 
+// PresetDescriptions tells what each scan preset does
+var PresetDescriptions = map[string]string{
+	presets.Quick:  "20 threads without delays, public buckets are not listed",
+	presets.Normal: "10 threads, listing the objects of public buckets",
+	presets.Deep:   "10 threads with a longer timeout for slow regions, listing public buckets",
+}
+
+// PresetS3ScanOptions returns the scan options of a preset
+func PresetS3ScanOptions(preset string) (S3ScanOptions, error) {
+	preset, err := presets.Validate(preset)
+	if err != nil {
+		return S3ScanOptions{}, err
+	}
+
+	options := DefaultS3ScanOptions()
+	switch preset {
+	case presets.Quick:
+		options.Threads = 20
+		options.Timeout = 3
+		options.WaitTime = 0
+		options.CheckListing = false
+	case presets.Deep:
+		options.Timeout = 15
+	}
+
+	profile := timing.Current()
+	options.Threads = profile.Threads(options.Threads)
+	options.Timeout = profile.TimeoutSeconds(options.Timeout)
+	if profile.Level > timing.Normal {
+		options.WaitTime = 0 // The HTTP client paces the requests of slow profiles
+	}
+	return options, nil
+}
+
 // NewScanner creates a new S3 bucket scanner
 func NewScanner(options S3ScanOptions) *Scanner {
-	client := &http.Client{
-		Timeout: time.Duration(options.Timeout) * time.Second,
-		// Skip SSL verification to catch misconfigured buckets
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
-	}
+	// Skip SSL verification to catch misconfigured buckets
+	client := httpclient.New("s3scanner", time.Duration(options.Timeout)*time.Second, &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	})
 
 	return &Scanner{
 		options: options,
@@ -77,6 +123,11 @@ func NewScanner(options S3ScanOptions) *Scanner {
 
 // ScanTarget scans a specific domain for S3 buckets
 func (s *Scanner) ScanTarget(target string) ([]S3BucketResult, error) {
+	return s.ScanTargetContext(context.Background(), target)
+}
+
+// ScanTargetContext scans a specific domain for S3 buckets, stopping when the context is cancelled
+func (s *Scanner) ScanTargetContext(ctx context.Context, target string) ([]S3BucketResult, error) {
 	s.results = []S3BucketResult{}
 
 	// Generate bucket names based on target domain
@@ -87,6 +138,7 @@ func (s *Scanner) ScanTarget(target string) ([]S3BucketResult, error) {
 
 	fmt.Printf("[+] Starting S3 bucket scan for: %s\n", target)
 	fmt.Printf("[+] Generated %d potential bucket names\n", len(bucketNames))
+	eventbus.Publish(eventbus.Event{Type: eventbus.ScanStarted, Tool: "s3scanner", Target: target})
 
 	// Channel for bucket names
 	bucketCh := make(chan string, len(bucketNames))
@@ -104,15 +156,43 @@ func (s *Scanner) ScanTarget(target string) ([]S3BucketResult, error) {
 		go func() {
 			defer wg.Done()
 			for bucketName := range bucketCh {
+				if ctx.Err() != nil {
+					return
+				}
+
 				// Check for rate limiting
 				if s.options.WaitTime > 0 {
 					time.Sleep(time.Duration(s.options.WaitTime) * time.Millisecond)
 				}
 
-				result := s.checkBucket(bucketName)
+				result := s.checkBucket(ctx, bucketName)
 				if result.Accessible {
 					s.addResult(result)
 
+					if result.Public {
+						severity, name, evidence := "medium", "Public S3 bucket", ""
+						if result.ListingEnabled {
+							severity, name = "high", "Public S3 bucket with directory listing"
+							objects := result.Objects
+							if len(objects) > maxEvidenceObjects {
+								objects = append(objects[:maxEvidenceObjects:maxEvidenceObjects], fmt.Sprintf("... and %d more", len(result.Objects)-maxEvidenceObjects))
+							}
+							evidence = "Listed objects:\n" + strings.Join(objects, "\n")
+						}
+						siem.EmitContext(ctx, siem.Finding{
+							Tool:     "s3scanner",
+							Target:   target,
+							Category: "S3_PUBLIC_BUCKET",
+							Name:     name + ": " + result.Bucket,
+							Severity: model.Severity(severity),
+							URL:      result.URL,
+							Evidence: evidence,
+						})
+						for _, match := range result.PII {
+							siem.EmitContext(ctx, pii.Finding("s3scanner", target, result.URL, match))
+						}
+					}
+
 					if s.options.Verbose {
 						accessInfo := "Private"
 						if result.Public {
@@ -134,6 +214,11 @@ func (s *Scanner) ScanTarget(target string) ([]S3BucketResult, error) {
 	// Wait for completion
 	wg.Wait()
 
+	if err := ctx.Err(); err != nil {
+		eventbus.ScanFinished("s3scanner", target, true, err, map[string]int{"buckets": len(s.results)})
+		return s.results, err
+	}
+
 	// Save results
 	if s.options.OutputFile != "" {
 		err = s.saveResults()
@@ -142,6 +227,7 @@ func (s *Scanner) ScanTarget(target string) ([]S3BucketResult, error) {
 		}
 	}
 
+	eventbus.ScanFinished("s3scanner", target, false, nil, map[string]int{"buckets": len(s.results)})
 	return s.results, nil
 }
 
@@ -220,17 +306,13 @@ func (s *Scanner) generateBucketNames(target string) ([]string, error) {
 
 // loadWordlist loads a custom wordlist and formats with the target
 func (s *Scanner) loadWordlist(target string) ([]string, error) {
-	file, err := os.Open(s.options.WordlistPath)
+	words, err := wordlist.Open(context.Background(), s.options.WordlistPath)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
 	buckets := []string{}
-
-	for scanner.Scan() {
-		pattern := scanner.Text()
+	for pattern := range words.Words {
 		if strings.Contains(pattern, "%s") {
 			buckets = append(buckets, fmt.Sprintf(pattern, target))
 		} else {
@@ -239,18 +321,23 @@ func (s *Scanner) loadWordlist(target string) ([]string, error) {
 		}
 	}
 
-	return buckets, scanner.Err()
+	return buckets, words.Err()
 }
 
 // checkBucket checks if an S3 bucket exists and is accessible
-func (s *Scanner) checkBucket(bucketName string) S3BucketResult {
+func (s *Scanner) checkBucket(ctx context.Context, bucketName string) S3BucketResult {
 	result := S3BucketResult{
 		Bucket: bucketName,
 		URL:    fmt.Sprintf("https://%s.s3.amazonaws.com", bucketName),
 	}
 
 	// Check if bucket exists
-	resp, err := s.client.Get(result.URL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, result.URL, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp, err := s.client.Do(req)
 	if err != nil {
 		result.Error = err.Error()
 		return result
@@ -276,6 +363,7 @@ func (s *Scanner) checkBucket(bucketName string) S3BucketResult {
 			// Extract object keys if listing is enabled
 			if result.ListingEnabled {
 				result.Objects = extractObjectKeys(bodyContent)
+				result.PII = pii.Current().Scan(strings.Join(result.Objects, "\n"))
 			}
 		}
 	}
@@ -326,8 +414,7 @@ func (s *Scanner) addResult(result S3BucketResult) {
 // saveResults saves the scan results to a file
 func (s *Scanner) saveResults() error {
 	// Create directory if it doesn't exist
-	dir := s.options.OutputFile[:strings.LastIndex(s.options.OutputFile, "/")]
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(s.options.OutputFile), 0755); err != nil {
 		return err
 	}
 
@@ -337,11 +424,13 @@ func (s *Scanner) saveResults() error {
 		return err
 	}
 	defer file.Close()
+	w := redact.Writer(file)
+	defer w.Close()
 
 	// Write header
-	file.WriteString("# S3 Bucket Scan Results\n")
-	file.WriteString("# Generated by GopherStrike S3Scanner\n")
-	file.WriteString("# " + time.Now().Format(time.RFC3339) + "\n\n")
+	io.WriteString(w, "# S3 Bucket Scan Results\n")
+	io.WriteString(w, "# Generated by GopherStrike S3Scanner\n")
+	io.WriteString(w, "# "+time.Now().Format(time.RFC3339)+"\n\n")
 
 	// Write results
 	for _, result := range s.results {
@@ -355,15 +444,20 @@ func (s *Scanner) saveResults() error {
 			listingInfo = " (Directory listing enabled)"
 		}
 
-		file.WriteString(fmt.Sprintf("%s - %s%s\n", result.URL, accessInfo, listingInfo))
+		fmt.Fprintf(w, "%s - %s%s\n", result.URL, accessInfo, listingInfo)
 
 		// Write object keys if available
 		if len(result.Objects) > 0 {
-			file.WriteString("  Objects:\n")
+			io.WriteString(w, "  Objects:\n")
 			for _, obj := range result.Objects {
-				file.WriteString(fmt.Sprintf("  - %s\n", obj))
+				fmt.Fprintf(w, "  - %s\n", obj)
 			}
-			file.WriteString("\n")
+			io.WriteString(w, "\n")
+		}
+
+		// Write the personal data found in the object keys
+		for _, match := range result.PII {
+			fmt.Fprintf(w, "  Exposed: %s\n", match.Summary())
 		}
 	}
 
@@ -386,7 +480,17 @@ func RunS3BucketScanner() error {
 	}
 
 	// Configure options
-	options := DefaultS3ScanOptions()
+	options, err := PresetS3ScanOptions(presets.Prompt(nil, PresetDescriptions))
+	if err != nil {
+		return err
+	}
+
+	// Results are kept in the target's recon artifact directory
+	outputFile, err := artifacts.Default().Path(target, artifacts.KindRecon, artifacts.TimestampedName("s3buckets", "txt"))
+	if err != nil {
+		return err
+	}
+	options.OutputFile = outputFile
 
 	// Ask for wordlist
 	fmt.Print("[?] Use custom wordlist for bucket names? (y/N): ")
@@ -399,7 +503,7 @@ func RunS3BucketScanner() error {
 	}
 
 	// Configure threads
-	fmt.Print("[?] Number of threads (default: 10): ")
+	fmt.Printf("[?] Number of threads (default: %d): ", options.Threads)
 	var threadsStr string
 	fmt.Scanln(&threadsStr)
 
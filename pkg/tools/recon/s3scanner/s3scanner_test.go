@@ -0,0 +1,44 @@
+package s3scanner
+
+import (
+	"GopherStrike/pkg/redact"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSavedResultsRedacted(t *testing.T) {
+	rules, err := redact.Parse(strings.NewReader("acme-payroll\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	redact.Set(rules)
+	defer redact.Set(nil)
+
+	output := filepath.Join(t.TempDir(), "s3.txt")
+	s := &Scanner{
+		options: S3ScanOptions{OutputFile: output},
+		results: []S3BucketResult{{
+			Bucket:         "acme-payroll",
+			URL:            "https://acme-payroll.s3.amazonaws.com",
+			Public:         true,
+			ListingEnabled: true,
+			Objects:        []string{"exports/acme-payroll-2024.csv"},
+		}},
+	}
+	if err := s.saveResults(); err != nil {
+		t.Fatalf("saveResults() error = %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "acme-payroll") {
+		t.Errorf("results not redacted:\n%s", data)
+	}
+	if !strings.Contains(string(data), "https://[REDACTED].s3.amazonaws.com - PUBLIC") {
+		t.Errorf("results lost the bucket:\n%s", data)
+	}
+}
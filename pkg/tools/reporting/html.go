@@ -0,0 +1,401 @@
+package reporting
+
+import (
+	"fmt"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// codeSpanRegex matches the `code` spans of the report texts
+var codeSpanRegex = regexp.MustCompile("`([^`]+)`")
+
+// htmlFinding is a vulnerability as laid out in the HTML report
+type htmlFinding struct {
+	Vulnerability
+	Number int
+	ID     string // Anchor of the finding's section
+	Level  string // Severity filter the finding falls under
+}
+
+// htmlSeverity is a severity level with its number of findings, shown as a
+// filter of the HTML report
+type htmlSeverity struct {
+	Name  VulnerabilitySeverity
+	Count int
+}
+
+// htmlFuncs are the helpers of the HTML report template
+var htmlFuncs = template.FuncMap{
+	"lower": func(s interface{}) string { return strings.ToLower(fmt.Sprint(s)) },
+	"inc":   func(i int) int { return i + 1 },
+	// inline escapes a text and renders its `code` spans
+	"inline": func(s string) template.HTML {
+		return template.HTML(codeSpanRegex.ReplaceAllString(template.HTMLEscapeString(s), "<code>$1</code>"))
+	},
+	"join": strings.Join,
+}
+
+// htmlTemplate lays out the HTML report. The findings can be filtered by
+// severity, status and words of their text, which hides their rows in the
+// summaries too, and collapsed, without leaving the file; the theme follows
+// the system's unless chosen with the toggle. Printing expands the findings
+// shown, in the light theme. Comments in the script would be stripped by the
+// template.
+var htmlTemplate = template.Must(template.New("report").Funcs(htmlFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>{{.Options.Title}}</title>
+<style>
+:root {
+    --bg: #ffffff; --fg: #333333; --muted: #777777; --heading: #2c3e50; --accent: #3498db;
+    --border: #dddddd; --panel: #f5f5f5; --header: #f2f2f2;
+    --critical: #c00000; --critical-bg: #ffdddd; --high: #e67e00; --high-bg: #ffeecc;
+    --medium: #827700; --medium-bg: #ffffcc; --low: #006600; --low-bg: #e6ffe6;
+    --info: #0066cc; --info-bg: #e6f2ff;
+    color-scheme: light;
+}
+@media (prefers-color-scheme: dark) {
+    :root:not([data-theme="light"]) {
+        --bg: #16181d; --fg: #d6d9de; --muted: #8b919a; --heading: #e6e9ee; --accent: #5dade2;
+        --border: #30343c; --panel: #1f2228; --header: #252930;
+        --critical: #ff6b6b; --critical-bg: #3d1a1a; --high: #ffa94d; --high-bg: #3d2a12;
+        --medium: #ffe066; --medium-bg: #3a3414; --low: #69db7c; --low-bg: #163020;
+        --info: #74c0fc; --info-bg: #142838;
+        color-scheme: dark;
+    }
+}
+:root[data-theme="dark"] {
+    --bg: #16181d; --fg: #d6d9de; --muted: #8b919a; --heading: #e6e9ee; --accent: #5dade2;
+    --border: #30343c; --panel: #1f2228; --header: #252930;
+    --critical: #ff6b6b; --critical-bg: #3d1a1a; --high: #ffa94d; --high-bg: #3d2a12;
+    --medium: #ffe066; --medium-bg: #3a3414; --low: #69db7c; --low-bg: #163020;
+    --info: #74c0fc; --info-bg: #142838;
+    color-scheme: dark;
+}
+body { font-family: Arial, sans-serif; line-height: 1.6; color: var(--fg); background: var(--bg); max-width: 1100px; margin: 0 auto; padding: 20px; }
+h1 { color: var(--heading); border-bottom: 2px solid var(--accent); padding-bottom: 10px; }
+h2 { color: var(--heading); border-bottom: 1px solid var(--border); padding-bottom: 5px; margin-top: 30px; }
+h4 { color: var(--heading); margin: 18px 0 6px; }
+a { color: var(--accent); }
+table { border-collapse: collapse; width: 100%; margin: 20px 0; }
+th, td { border: 1px solid var(--border); padding: 10px 12px; text-align: left; vertical-align: top; }
+th { background: var(--header); }
+code, pre { background: var(--panel); font-family: monospace; border-radius: 3px; }
+code { padding: 2px 5px; }
+pre { padding: 15px; overflow-x: auto; white-space: pre-wrap; word-break: break-word; }
+img { max-width: 100%; border: 1px solid var(--border); }
+.meta { color: var(--muted); margin: 0; }
+.confidential { font-weight: bold; color: var(--critical); }
+.severity { display: inline-block; min-width: 64px; padding: 1px 8px; border-radius: 10px; font-size: 0.85em; font-weight: bold; text-align: center; }
+.severity-critical { color: var(--critical); background: var(--critical-bg); }
+.severity-high { color: var(--high); background: var(--high-bg); }
+.severity-medium { color: var(--medium); background: var(--medium-bg); }
+.severity-low { color: var(--low); background: var(--low-bg); }
+.severity-info { color: var(--info); background: var(--info-bg); }
+.warning { border-left: 4px solid var(--high); background: var(--high-bg); padding: 8px 14px; }
+.toolbar { position: sticky; top: 0; z-index: 1; display: flex; flex-wrap: wrap; gap: 10px; align-items: center; padding: 10px 0; background: var(--bg); border-bottom: 1px solid var(--border); }
+.toolbar input[type="search"], .toolbar select, .toolbar button { font: inherit; color: var(--fg); background: var(--panel); border: 1px solid var(--border); border-radius: 4px; padding: 4px 8px; }
+.toolbar input[type="search"] { flex: 1; min-width: 200px; }
+.toolbar label.severity { cursor: pointer; }
+.toolbar .count { color: var(--muted); font-size: 0.9em; }
+.theme-toggle { position: fixed; top: 12px; right: 12px; }
+details.finding { border: 1px solid var(--border); border-radius: 5px; margin: 12px 0; padding: 0 14px; }
+details.finding > summary { cursor: pointer; padding: 10px 0; font-weight: bold; color: var(--heading); }
+details.finding[open] > summary { border-bottom: 1px solid var(--border); margin-bottom: 6px; }
+.tags code { margin-right: 4px; }
+.footer { margin-top: 40px; border-top: 1px solid var(--border); padding-top: 10px; font-size: 0.9em; color: var(--muted); }
+[hidden] { display: none !important; }
+@media print {
+    :root, :root[data-theme="dark"] {
+        --bg: #ffffff; --fg: #000000; --muted: #555555; --heading: #000000; --accent: #000000;
+        --border: #999999; --panel: #f5f5f5; --header: #eeeeee;
+        color-scheme: light;
+    }
+    body { max-width: none; padding: 0; font-size: 11pt; }
+    .toolbar, .theme-toggle { display: none !important; }
+    a { text-decoration: none; }
+    h2 { break-after: avoid; }
+    details.finding { break-inside: avoid; border: none; padding: 0; }
+    details.finding > summary { list-style: none; font-size: 1.15em; }
+    details.finding > summary::-webkit-details-marker { display: none; }
+    pre { white-space: pre-wrap; }
+    .severity { border: 1px solid currentColor; }
+}
+{{.CustomCSS}}
+</style>
+</head>
+<body>
+<button type="button" class="theme-toggle" id="theme-toggle" title="Switch between the light and dark themes">Theme</button>
+<h1>{{.Options.Title}}</h1>
+<p class="meta">Date: {{.Date}}</p>
+{{- with .Options.CompanyName}}
+<p class="meta">Prepared by: {{.}}</p>
+{{- end}}
+{{- with .Options.AuthorName}}
+<p class="meta">Author: {{.}}</p>
+{{- end}}
+{{- with .Options.ConfidentialityNote}}
+<p class="confidential">{{.}}</p>
+{{- end}}
+
+{{- if .Options.IncludeExecutive}}
+<h2 id="executive-summary">Executive Summary</h2>
+<p>{{.Summary}}</p>
+<table>
+<tr><th>Severity</th><th>Count</th></tr>
+{{- range .Severities}}
+<tr><td><span class="severity severity-{{lower .Name}}">{{.Name}}</span></td><td>{{.Count}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+<h2 id="scope">Scope</h2>
+{{- if .TargetScope}}
+<p>The assessment covered the following targets:</p>
+<ul>
+{{- range .TargetScope}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- else}}
+<p>No specific targets were identified in this report.</p>
+{{- end}}
+{{- if .Incomplete}}
+<div class="warning">
+<p><strong>Incomplete coverage:</strong> findings may be missing.</p>
+<ul>
+{{- range .Incomplete}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+</div>
+{{- end}}
+
+<h2 id="findings">Findings</h2>
+{{- if .Findings}}
+<div class="toolbar" id="toolbar">
+<input type="search" id="search" placeholder="Search titles, targets, evidence..." aria-label="Search the findings">
+{{- range .Severities}}
+<label class="severity severity-{{lower .Name}}"><input type="checkbox" name="severity" value="{{lower .Name}}" checked> {{.Name}} ({{.Count}})</label>
+{{- end}}
+<select id="status" aria-label="Status">
+<option value="">All statuses</option>
+{{- range .Statuses}}
+<option value="{{lower .}}">{{.}}</option>
+{{- end}}
+</select>
+<button type="button" id="expand">Expand all</button>
+<button type="button" id="collapse">Collapse all</button>
+<span class="count" id="count"></span>
+</div>
+<table id="summary">
+<tr><th>#</th><th>Title</th><th>Severity</th><th>Status</th></tr>
+{{- range .Findings}}
+<tr data-finding="{{.ID}}"><td>{{.Number}}</td><td><a href="#{{.ID}}">{{.Title}}</a></td><td><span class="severity severity-{{lower .Severity}}">{{.Severity}}</span></td><td>{{.Status}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No vulnerabilities were found during the assessment.</p>
+{{- end}}
+
+{{- if .Components}}
+<h2 id="software-composition">Software Composition</h2>
+<p>{{len .Components}} third-party components were analyzed, {{len .VulnerableComponents}} of them with known vulnerabilities.</p>
+{{- if .VulnerableComponents}}
+<table>
+<tr><th>Component</th><th>Version</th><th>Ecosystem</th><th>Severity</th><th>Vulnerabilities</th><th>Declared In</th></tr>
+{{- range .VulnerableComponents}}
+<tr><td>{{.Name}}</td><td>{{.Version}}</td><td>{{.Ecosystem}}</td><td><span class="severity severity-{{lower .Severity}}">{{.Severity}}</span></td><td>{{join .Vulnerabilities ", "}}</td><td>{{.Manifest}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+
+{{- if .Findings}}
+<h2 id="vulnerability-details">Vulnerability Details</h2>
+{{- $options := .Options}}
+{{- range .Findings}}
+<details class="finding" id="{{.ID}}" data-severity="{{.Level}}" data-status="{{lower .Status}}" open>
+<summary>{{.Number}}. {{.Title}} <span class="severity severity-{{lower .Severity}}">{{.Severity}}</span></summary>
+<table>
+<tr><th>Status</th><td>{{.Status}}</td></tr>
+{{- with .CWE}}
+<tr><th>CWE</th><td>{{.}}</td></tr>
+{{- end}}
+{{- if gt .CVSS 0.0}}
+<tr><th>CVSS</th><td>{{printf "%.1f" .CVSS}}</td></tr>
+{{- end}}
+{{- with .Tags}}
+<tr><th>Tags</th><td class="tags">{{range .}}<code>{{.}}</code>{{end}}</td></tr>
+{{- end}}
+</table>
+<h4>Description</h4>
+<p>{{inline .Description}}</p>
+{{- with .AffectedTargets}}
+<h4>Affected Targets</h4>
+<ul>
+{{- range .}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- with .Steps}}
+<h4>Steps to Reproduce</h4>
+<ol>
+{{- range .}}
+<li>{{inline .}}</li>
+{{- end}}
+</ol>
+{{- end}}
+{{- if and $options.IncludeEvidence .Evidence}}
+<h4>Evidence</h4>
+{{- range $i, $evidence := .Evidence}}
+<p><em>Evidence {{inc $i}}: {{$evidence.Description}}</em></p>
+{{- if eq $evidence.Type "screenshot"}}
+<img src="{{$evidence.Data}}" alt="{{$evidence.Description}}">
+{{- else}}
+<pre>{{$evidence.Data}}</pre>
+{{- end}}
+{{- end}}
+{{- end}}
+{{- with .Impact}}
+<h4>Impact</h4>
+<p>{{inline .}}</p>
+{{- end}}
+{{- if and $options.IncludeRemediation .Remediation}}
+<h4>Remediation</h4>
+<p>{{inline .Remediation}}</p>
+{{- end}}
+{{- with .References}}
+<h4>References</h4>
+<ul>
+{{- range .}}
+<li><a href="{{.}}">{{.}}</a></li>
+{{- end}}
+</ul>
+{{- end}}
+</details>
+{{- end}}
+{{- end}}
+
+{{- if and .Options.IncludeRemediation .Findings}}
+<h2 id="remediation-summary">Remediation Summary</h2>
+<p>Remediation efforts should be prioritized based on vulnerability severity:</p>
+{{- $findings := .Findings}}
+{{- range .Severities}}
+{{- if and .Count (ne .Name "Info")}}
+{{- $severity := .Name}}
+<h4>{{.Name}} Severity Issues</h4>
+<ul>
+{{- range $findings}}
+{{- if eq .Severity $severity}}
+<li data-finding="{{.ID}}"><a href="#{{.ID}}"><strong>{{.Title}}</strong></a>: {{inline .Remediation}}</li>
+{{- end}}
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}
+{{- end}}
+
+<div class="footer">
+<p>Generated by GopherStrike Security Reporting Tool on {{.Date}}</p>
+</div>
+<script>
+(function () {
+    var root = document.documentElement, key = "gopherstrike-report-theme";
+    try {
+        var saved = localStorage.getItem(key);
+        if (saved) { root.setAttribute("data-theme", saved); }
+    } catch (e) {}
+    document.getElementById("theme-toggle").addEventListener("click", function () {
+        var current = root.getAttribute("data-theme") ||
+            (window.matchMedia("(prefers-color-scheme: dark)").matches ? "dark" : "light");
+        var next = current === "dark" ? "light" : "dark";
+        root.setAttribute("data-theme", next);
+        try { localStorage.setItem(key, next); } catch (e) {}
+    });
+
+    var toolbar = document.getElementById("toolbar");
+    if (!toolbar) { return; }
+    var findings = document.querySelectorAll("details.finding");
+    var search = document.getElementById("search"), status = document.getElementById("status");
+
+    function apply() {
+        var severities = {};
+        toolbar.querySelectorAll("input[name=severity]:checked").forEach(function (box) { severities[box.value] = true; });
+        var words = search.value.toLowerCase().split(/\s+/).filter(Boolean), shown = 0;
+        findings.forEach(function (finding) {
+            var text = finding.textContent.toLowerCase();
+            var match = severities[finding.dataset.severity] &&
+                (!status.value || finding.dataset.status === status.value) &&
+                words.every(function (word) { return text.indexOf(word) >= 0; });
+            finding.hidden = !match;
+            document.querySelectorAll("[data-finding='" + finding.id + "']").forEach(function (row) { row.hidden = !match; });
+            if (match) { shown++; }
+        });
+        document.getElementById("count").textContent = shown + " of " + findings.length + " findings shown";
+    }
+    function expand(open) {
+        findings.forEach(function (finding) { finding.open = open; });
+    }
+
+    toolbar.addEventListener("input", apply);
+    toolbar.addEventListener("change", apply);
+    document.getElementById("expand").addEventListener("click", function () { expand(true); });
+    document.getElementById("collapse").addEventListener("click", function () { expand(false); });
+    window.addEventListener("beforeprint", function () { expand(true); });
+    apply();
+})();
+</script>
+</body>
+</html>
+`))
+
+// generateHTMLReport generates an HTML report
+func (r *ReportGenerator) generateHTMLReport(report *Report) (string, error) {
+	data := struct {
+		*Report
+		Date                 string
+		CustomCSS            template.CSS
+		Severities           []htmlSeverity
+		Statuses             []VulnerabilityStatus
+		Findings             []htmlFinding
+		VulnerableComponents []Component
+	}{
+		Report:    report,
+		Date:      report.GeneratedAt.Format("January 2, 2006"),
+		CustomCSS: template.CSS(report.Options.CustomCSS),
+	}
+
+	for _, severity := range []VulnerabilitySeverity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo} {
+		data.Severities = append(data.Severities, htmlSeverity{Name: severity, Count: report.SeverityCounts[severity]})
+	}
+	seen := map[VulnerabilityStatus]bool{}
+	for i, vuln := range report.Vulnerabilities {
+		data.Findings = append(data.Findings, htmlFinding{
+			Vulnerability: vuln,
+			Number:        i + 1,
+			ID:            "finding-" + strconv.Itoa(i+1),
+			Level:         strings.ToLower(string(vuln.Severity.Canonical())),
+		})
+		if vuln.Status != "" && !seen[vuln.Status] {
+			seen[vuln.Status] = true
+			data.Statuses = append(data.Statuses, vuln.Status)
+		}
+	}
+	for _, component := range report.Components {
+		if len(component.Vulnerabilities) > 0 {
+			data.VulnerableComponents = append(data.VulnerableComponents, component)
+		}
+	}
+
+	var output strings.Builder
+	if err := htmlTemplate.Execute(&output, data); err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
@@ -0,0 +1,70 @@
+package reporting
+
+import (
+	"GopherStrike/pkg/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateHTMLReport(t *testing.T) {
+	options := DefaultReportOptions()
+	options.Format = "html"
+	options.CustomCSS = "h1 { color: purple; }"
+	generator := NewReportGenerator(options)
+	generator.AddFinding(model.Finding{
+		Tool:        "webvuln",
+		Target:      "example.com",
+		Category:    "XSS",
+		Name:        "XSS in q",
+		Severity:    model.SeverityHigh,
+		Description: "The `q` parameter is reflected unescaped",
+		URL:         "https://example.com/search?q=1",
+		Evidence:    "GET https://example.com/search?q=<script>alert(1)</script>",
+		Time:        time.Now(),
+	})
+	generator.AddVulnerability(Vulnerability{Title: "Weak key exchange", Severity: SeverityInfo, Status: StatusFixed, AffectedTargets: []string{"example.com"}, Tags: []string{"sshaudit"}})
+	generator.AddComponent(Component{Name: "lodash", Version: "4.17.15", Ecosystem: "npm", Vulnerabilities: []string{"CVE-2020-8203"}, Severity: SeverityHigh})
+	generator.AddComponent(Component{Name: "react", Version: "18.2.0", Ecosystem: "npm"})
+
+	report, err := generator.GenerateReport()
+	if err != nil {
+		t.Fatalf("GenerateReport() error = %v", err)
+	}
+	html, err := generator.generateHTMLReport(report)
+	if err != nil {
+		t.Fatalf("generateHTMLReport() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`<details class="finding" id="finding-1" data-severity="high" data-status="open" open>`,
+		`<details class="finding" id="finding-2" data-severity="info" data-status="fixed" open>`,
+		`<tr data-finding="finding-1"><td>1</td><td><a href="#finding-1">XSS in q</a></td>`,
+		`<input type="checkbox" name="severity" value="critical" checked> Critical (0)`,
+		`<option value="fixed">Fixed</option>`,
+		`<p>The <code>q</code> parameter is reflected unescaped</p>`,
+		`q=&lt;script&gt;alert(1)&lt;/script&gt;</pre>`,
+		`2 third-party components were analyzed, 1 of them with known vulnerabilities.`,
+		`<td>lodash</td>`,
+		`@media print`,
+		`prefers-color-scheme: dark`,
+		`h1 { color: purple; }`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+	if strings.Contains(html, "<script>alert(1)") || strings.Contains(html, "<td>react</td>") {
+		t.Error("report holds unescaped evidence or components without vulnerabilities")
+	}
+
+	// Without findings there is nothing to filter
+	empty, err := NewReportGenerator(options).GenerateReport()
+	if err != nil {
+		t.Fatalf("GenerateReport() error = %v", err)
+	}
+	if html, err := generator.generateHTMLReport(empty); err != nil || strings.Contains(html, `id="toolbar"`) ||
+		!strings.Contains(html, "No vulnerabilities were found during the assessment.") {
+		t.Errorf("empty report = %v, want no filters and the no findings note", err)
+	}
+}
@@ -1,26 +1,26 @@
 package reporting
 
 import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/signing"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
-
-	"html/template"
-
-	"github.com/russross/blackfriday/v2"
 )
 
 // VulnerabilitySeverity represents the severity level of a vulnerability
-type VulnerabilitySeverity string
+type VulnerabilitySeverity = model.Severity
 
 const (
-	SeverityCritical VulnerabilitySeverity = "Critical"
-	SeverityHigh     VulnerabilitySeverity = "High"
-	SeverityMedium   VulnerabilitySeverity = "Medium"
-	SeverityLow      VulnerabilitySeverity = "Low"
-	SeverityInfo     VulnerabilitySeverity = "Info"
+	SeverityCritical = model.SeverityCritical
+	SeverityHigh     = model.SeverityHigh
+	SeverityMedium   = model.SeverityMedium
+	SeverityLow      = model.SeverityLow
+	SeverityInfo     = model.SeverityInfo
 )
 
 // VulnerabilityStatus represents the status of a vulnerability
@@ -60,6 +60,17 @@ type Vulnerability struct {
 	Tags            []string
 }
 
+// Component represents a third-party component of the assessed software,
+// such as a library declared in a dependency manifest
+type Component struct {
+	Name            string
+	Version         string
+	Ecosystem       string
+	Manifest        string
+	Vulnerabilities []string // IDs of the known vulnerabilities of the version
+	Severity        VulnerabilitySeverity
+}
+
 // ReportOptions represents options for report generation
 type ReportOptions struct {
 	Title               string
@@ -100,9 +111,11 @@ func DefaultReportOptions() ReportOptions {
 type Report struct {
 	Options         ReportOptions
 	Vulnerabilities []Vulnerability
+	Components      []Component
 	GeneratedAt     time.Time
 	SeverityCounts  map[VulnerabilitySeverity]int
 	TargetScope     []string
+	Incomplete      []string // Why the scans did not cover the whole scope
 	Summary         string
 	BodyHTML        string
 }
@@ -111,6 +124,8 @@ type Report struct {
 type ReportGenerator struct {
 	options         ReportOptions
 	vulnerabilities []Vulnerability
+	components      []Component
+	incomplete      []string
 }
 
 // NewReportGenerator creates a new report generator
@@ -134,11 +149,54 @@ func (r *ReportGenerator) AddVulnerability(vuln Vulnerability) {
 	r.vulnerabilities = append(r.vulnerabilities, vuln)
 }
 
+// AddFinding adds a finding reported by one of the tools to the report
+func (r *ReportGenerator) AddFinding(f model.Finding) {
+	vuln := Vulnerability{
+		Title:           f.Name,
+		Description:     f.Description,
+		Severity:        f.Severity.Canonical(),
+		Status:          StatusOpen,
+		AffectedTargets: []string{f.Target},
+		CreatedAt:       f.Time,
+		UpdatedAt:       f.Time,
+		Tags:            []string{f.Tool},
+	}
+	if f.Category != "" {
+		vuln.Tags = append(vuln.Tags, f.Category)
+	}
+	if f.URL != "" {
+		vuln.AffectedTargets = append(vuln.AffectedTargets, f.URL)
+	}
+	if f.Evidence != "" {
+		vuln.Evidence = []Evidence{{Description: "Reported by " + f.Tool, Type: "request", Data: f.Evidence}}
+	}
+	// Findings no longer reproduced by verify are reported as fixed
+	if f.Status == model.StatusFixed {
+		vuln.Status = StatusFixed
+		vuln.UpdatedAt = f.UpdatedAt
+	}
+	r.AddVulnerability(vuln)
+}
+
+// AddComponent adds a third-party component to the software composition
+// section of the report
+func (r *ReportGenerator) AddComponent(component Component) {
+	r.components = append(r.components, component)
+}
+
+// AddIncomplete notes in the scope section of the report why the scans did
+// not cover the whole scope, e.g. a scan stopped by its time budget
+func (r *ReportGenerator) AddIncomplete(note string) {
+	r.incomplete = append(r.incomplete, note)
+}
+
 // GenerateReport generates a report based on the options and vulnerabilities
 func (r *ReportGenerator) GenerateReport() (*Report, error) {
 	report := &Report{
 		Options:         r.options,
 		Vulnerabilities: r.vulnerabilities,
+		Components:      r.components,
+		Incomplete:      r.incomplete,
 		GeneratedAt:     time.Now(),
 		SeverityCounts:  make(map[VulnerabilitySeverity]int),
 		TargetScope:     []string{},
@@ -221,8 +279,11 @@ func (r *ReportGenerator) SaveReport(report *Report) error {
 		return err
 	}
 
-	// Write to file
-	return os.WriteFile(report.Options.OutputFile, []byte(content), 0644)
+	// Write to file, with the secrets redacted, and sign it
+	if err := os.WriteFile(report.Options.OutputFile, []byte(redact.String(content)), 0644); err != nil {
+		return err
+	}
+	return signing.SignFile(report.Options.OutputFile)
 }
 
 // generateMarkdownReport generates a Markdown report
@@ -247,12 +308,16 @@ func (r *ReportGenerator) generateMarkdownReport(report *Report) (string, error)
 
 	// Table of Contents
 	content.WriteString("## Table of Contents\n\n")
-	content.WriteString("1. [Executive Summary](#executive-summary)\n")
-	content.WriteString("2. [Scope](#scope)\n")
-	content.WriteString("3. [Findings Summary](#findings-summary)\n")
-	content.WriteString("4. [Vulnerability Details](#vulnerability-details)\n")
+	sections := []string{"Executive Summary", "Scope", "Findings Summary"}
+	if len(report.Components) > 0 {
+		sections = append(sections, "Software Composition")
+	}
+	sections = append(sections, "Vulnerability Details")
 	if report.Options.IncludeRemediation {
-		content.WriteString("5. [Remediation Summary](#remediation-summary)\n")
+		sections = append(sections, "Remediation Summary")
+	}
+	for i, section := range sections {
+		content.WriteString(fmt.Sprintf("%d. [%s](#%s)\n", i+1, section, strings.ToLower(strings.ReplaceAll(section, " ", "-"))))
 	}
 	content.WriteString("\n")
 
@@ -286,6 +351,13 @@ func (r *ReportGenerator) generateMarkdownReport(report *Report) (string, error)
 		content.WriteString("No specific targets were identified in this report.\n")
 	}
 	content.WriteString("\n")
+	if len(report.Incomplete) > 0 {
+		content.WriteString("**Incomplete coverage:** findings may be missing.\n\n")
+		for _, note := range report.Incomplete {
+			content.WriteString(fmt.Sprintf("* %s\n", note))
+		}
+		content.WriteString("\n")
+	}
 
 	// Findings Summary
 	content.WriteString("## Findings Summary\n\n")
@@ -302,6 +374,11 @@ func (r *ReportGenerator) generateMarkdownReport(report *Report) (string, error)
 	}
 	content.WriteString("\n")
 
+	// Software Composition
+	if len(report.Components) > 0 {
+		writeComponents(&content, report.Components)
+	}
+
 	// Vulnerability Details
 	content.WriteString("## Vulnerability Details\n\n")
 
@@ -408,143 +485,30 @@ func (r *ReportGenerator) generateMarkdownReport(report *Report) (string, error)
 	return content.String(), nil
 }
 
-// generateHTMLReport generates an HTML report
-func (r *ReportGenerator) generateHTMLReport(report *Report) (string, error) {
-	// First generate markdown
-	markdown, err := r.generateMarkdownReport(report)
-	if err != nil {
-		return "", err
-	}
-
-	// Convert markdown to HTML
-	html := blackfriday.Run([]byte(markdown))
-
-	// Create HTML document with basic styling
-	templateStr := `<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>{{.Title}}</title>
-    <style>
-        body {
-            font-family: Arial, sans-serif;
-            line-height: 1.6;
-            color: #333;
-            max-width: 1100px;
-            margin: 0 auto;
-            padding: 20px;
-        }
-        h1 {
-            color: #2c3e50;
-            border-bottom: 2px solid #3498db;
-            padding-bottom: 10px;
-        }
-        h2 {
-            color: #2c3e50;
-            border-bottom: 1px solid #ddd;
-            padding-bottom: 5px;
-            margin-top: 30px;
-        }
-        h3 {
-            color: #3498db;
-            margin-top: 25px;
-        }
-        table {
-            border-collapse: collapse;
-            width: 100%;
-            margin: 20px 0;
-        }
-        table, th, td {
-            border: 1px solid #ddd;
-        }
-        th, td {
-            padding: 12px;
-            text-align: left;
-        }
-        th {
-            background-color: #f2f2f2;
-            color: #333;
-        }
-        .severity-Critical {
-            background-color: #ffdddd;
-            color: #c00000;
-        }
-        .severity-High {
-            background-color: #ffeecc;
-            color: #e67e00;
-        }
-        .severity-Medium {
-            background-color: #ffffcc;
-            color: #827700;
-        }
-        .severity-Low {
-            background-color: #e6ffe6;
-            color: #006600;
-        }
-        .severity-Info {
-            background-color: #e6f2ff;
-            color: #0066cc;
-        }
-        code {
-            background-color: #f5f5f5;
-            padding: 2px 5px;
-            border-radius: 3px;
-            font-family: monospace;
-        }
-        pre {
-            background-color: #f5f5f5;
-            padding: 15px;
-            border-radius: 5px;
-            overflow-x: auto;
-            font-family: monospace;
-        }
-        .footer {
-            margin-top: 40px;
-            border-top: 1px solid #ddd;
-            padding-top: 10px;
-            font-size: 0.9em;
-            color: #777;
-        }
-        {{.CustomCSS}}
-    </style>
-</head>
-<body>
-    <div class="content">
-        {{.Content}}
-    </div>
-    <div class="footer">
-        <p>Generated by GopherStrike Security Reporting Tool on {{.Date}}</p>
-    </div>
-</body>
-</html>`
-
-	// Create template data
-	data := struct {
-		Title     string
-		Content   string
-		Date      string
-		CustomCSS string
-	}{
-		Title:     report.Options.Title,
-		Content:   string(html),
-		Date:      report.GeneratedAt.Format("January 2, 2006"),
-		CustomCSS: report.Options.CustomCSS,
-	}
-
-	// Execute template
-	tmpl, err := template.New("report").Parse(templateStr)
-	if err != nil {
-		return "", err
-	}
+// writeComponents writes the software composition section: the number of
+// components analyzed and a table of the vulnerable ones
+func writeComponents(content *strings.Builder, components []Component) {
+	content.WriteString("## Software Composition\n\n")
 
-	var output strings.Builder
-	err = tmpl.Execute(&output, data)
-	if err != nil {
-		return "", err
+	vulnerable := make([]Component, 0)
+	for _, component := range components {
+		if len(component.Vulnerabilities) > 0 {
+			vulnerable = append(vulnerable, component)
+		}
+	}
+	content.WriteString(fmt.Sprintf("%d third-party components were analyzed, %d of them with known vulnerabilities.\n\n",
+		len(components), len(vulnerable)))
+	if len(vulnerable) == 0 {
+		return
 	}
 
-	return output.String(), nil
+	content.WriteString("| Component | Version | Ecosystem | Severity | Vulnerabilities | Declared In |\n")
+	content.WriteString("|-----------|---------|-----------|----------|-----------------|-------------|\n")
+	for _, c := range vulnerable {
+		content.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s |\n",
+			c.Name, c.Version, c.Ecosystem, c.Severity, strings.Join(c.Vulnerabilities, ", "), c.Manifest))
+	}
+	content.WriteString("\n")
 }
 
 // RunReportGenerator is the main entry point for the report generator
@@ -578,6 +542,18 @@ func RunReportGenerator() error {
 	}
 	defaultOutput := fmt.Sprintf("reports/security_report%s", defaultExt)
 
+	// Reports about a single host are kept with that host's other artifacts
+	fmt.Print("[?] Target host for this report (leave empty for a general report): ")
+	var target string
+	fmt.Scanln(&target)
+	if target != "" {
+		targetOutput, err := artifacts.Default().Path(target, artifacts.KindReports, artifacts.TimestampedName("security_report", defaultExt))
+		if err != nil {
+			return err
+		}
+		defaultOutput = targetOutput
+	}
+
 	fmt.Printf("[?] Output file (default: %s): ", defaultOutput)
 	var outputFile string
 	fmt.Scanln(&outputFile)
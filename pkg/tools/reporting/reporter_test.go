@@ -0,0 +1,24 @@
+package reporting
+
+import (
+	"GopherStrike/pkg/model"
+	"testing"
+	"time"
+)
+
+func TestAddFindingStatus(t *testing.T) {
+	found := time.Date(2024, 8, 16, 14, 30, 0, 0, time.UTC)
+	verified := found.Add(48 * time.Hour)
+
+	generator := NewReportGenerator(DefaultReportOptions())
+	generator.AddFinding(model.Finding{Tool: "sshaudit", Target: "example.com", Name: "Weak key exchange", Severity: "none", Time: found})
+	generator.AddFinding(model.Finding{Tool: "sshaudit", Target: "example.com", Name: "Weak cipher", Severity: model.SeverityLow, Time: found, Status: model.StatusFixed, UpdatedAt: verified})
+
+	open, fixed := generator.vulnerabilities[0], generator.vulnerabilities[1]
+	if open.Status != StatusOpen || !open.UpdatedAt.Equal(found) || open.Severity != SeverityInfo {
+		t.Errorf("open finding = %s %s %v, want Open info found at %v", open.Status, open.Severity, open.UpdatedAt, found)
+	}
+	if fixed.Status != StatusFixed || !fixed.UpdatedAt.Equal(verified) {
+		t.Errorf("fixed finding = %s %v, want Fixed at %v", fixed.Status, fixed.UpdatedAt, verified)
+	}
+}
@@ -2,6 +2,7 @@
 package reporting
 
 import (
+	"GopherStrike/pkg/artifacts"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -80,9 +81,25 @@ func RunReportingModule() error {
 	}
 }
 
-// listExistingReports lists all existing reports in the reports directory
+// findReports returns reports matching pattern from the general reports
+// directory and from the reports artifact directory of every target
+func findReports(pattern string) ([]string, error) {
+	reports, err := filepath.Glob(filepath.Join("reports", pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	targetReports, err := artifacts.Default().Glob(artifacts.KindReports, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(reports, targetReports...), nil
+}
+
+// listExistingReports lists all existing reports in the reports directories
 func listExistingReports() error {
-	reports, err := filepath.Glob("reports/*.*")
+	reports, err := findReports("*.*")
 	if err != nil {
 		return err
 	}
@@ -112,7 +129,7 @@ func listExistingReports() error {
 
 		fmt.Printf("%d. %s (%s, %d bytes, created on %s)\n",
 			i+1,
-			filepath.Base(report),
+			report,
 			format,
 			info.Size(),
 			info.ModTime().Format("Jan 02, 2006 15:04:05"))
@@ -159,12 +176,12 @@ func viewReport(reportPath string) error {
 // convertReportFormat converts a report from one format to another
 func convertReportFormat() error {
 	// List Markdown reports only
-	mdReports, err := filepath.Glob("reports/*.md")
+	mdReports, err := findReports("*.md")
 	if err != nil {
 		return err
 	}
 
-	htmlReports, err := filepath.Glob("reports/*.html")
+	htmlReports, err := findReports("*.html")
 	if err != nil {
 		return err
 	}
@@ -196,7 +213,7 @@ func convertReportFormat() error {
 
 		fmt.Printf("%d. %s (%s, %d bytes)\n",
 			i+1,
-			filepath.Base(report),
+			report,
 			format,
 			info.Size())
 	}
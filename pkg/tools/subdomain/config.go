@@ -1,6 +1,7 @@
 package subdomain
 
 import (
+	"GopherStrike/pkg/logging"
 	"fmt"
 	"time"
 )
@@ -240,5 +241,5 @@ var DefaultConfig = struct {
 	OutputFormats:                 []string{FormatText, FormatJSON},
 	DomainVerificationTimeoutSecs: 3,
 	MaxThreads:                    100,
-	LogsDirectory:                 "logs/subdomains",
+	LogsDirectory:                 logging.Dir("subdomains"),
 }
@@ -4,6 +4,7 @@ package subdomain
 import (
 	"GopherStrike/pkg/tools"
 	"GopherStrike/pkg/validator"
+	"GopherStrike/pkg/wordlist"
 	"bufio"
 	"fmt"
 	"io"
@@ -93,6 +94,7 @@ func GetWordlistPath() (string, error) {
 	fmt.Println("- Kali Linux SecLists: /usr/share/seclists/Discovery/DNS/")
 	fmt.Println("- OWASP Amass: /usr/share/amass/wordlists/")
 	fmt.Println("- Custom wordlists: ~/wordlists/subdomains.txt")
+	fmt.Println("Gzipped files are read too, as are - for standard input and HTTPS URLs.")
 
 	for {
 		fmt.Print("\nEnter full path to wordlist: ")
@@ -105,7 +107,22 @@ func GetWordlistPath() (string, error) {
 		}
 
 		wordlistPath = strings.TrimSpace(wordlistPath)
-		
+
+		// Standard input and URLs are read once, then counted from their copy
+		if !wordlist.IsFile(wordlistPath) {
+			count, err := wordlist.Count(wordlistPath)
+			if err != nil {
+				fmt.Printf("Error: Cannot read wordlist: %v\n", err)
+				continue
+			}
+			if count == 0 {
+				fmt.Println("Error: Wordlist is empty. Please provide a wordlist with subdomain entries.")
+				continue
+			}
+			fmt.Printf("Wordlist has %d entries\n", count)
+			return wordlistPath, nil
+		}
+
 		// Expand home directory if using ~
 		expandedPath, err := ExpandHomeDir(wordlistPath)
 		if err != nil {
@@ -117,7 +134,7 @@ func GetWordlistPath() (string, error) {
 		// Validate file path using secure validator
 		fileValidator := &validator.FilePathValidator{
 			MustExist: true,
-			AllowedExts: []string{".txt", ".lst", ".wordlist", ""},
+			AllowedExts: []string{".txt", ".lst", ".wordlist", ".gz", ""},
 			MaxSizeBytes: 100 * 1024 * 1024, // 100MB max
 		}
 		wordlistPath = fileValidator.Sanitize(wordlistPath)
@@ -141,7 +158,7 @@ func GetWordlistPath() (string, error) {
 		fmt.Printf("Wordlist size: %s\n", FormatSize(fileSize))
 
 		// Check if the file is readable and count lines
-		file, err := os.Open(wordlistPath)
+		file, err := wordlist.Reader(wordlistPath)
 		if err != nil {
 			fmt.Printf("Error: Cannot open wordlist file: %v\n", err)
 			continue
@@ -317,6 +334,31 @@ func CustomizeOptions(options tools.ScanOptions) (tools.ScanOptions, error) {
 		break
 	}
 
+	// Checkpoint chunk size
+	for {
+		fmt.Printf("Words per checkpoint chunk (100-1000000, default: %d): ", options.ChunkSize)
+		input, err = reader.ReadString('\n')
+		if err != nil {
+			return options, fmt.Errorf("error reading input: %v", err)
+		}
+
+		input = strings.TrimSpace(input)
+		if input == "" {
+			break // Keep default
+		}
+
+		// Validate chunk size
+		intValidator := &validator.IntegerValidator{Min: 100, Max: 1000000}
+		if err := intValidator.Validate(input); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			continue
+		}
+		chunkSize, _ := strconv.Atoi(input)
+
+		options.ChunkSize = chunkSize
+		break
+	}
+
 	// Resolve IPs
 	fmt.Printf("Resolve IP addresses? (y/n, default: %t): ", options.ResolveIPs)
 	input, err = reader.ReadString('\n')
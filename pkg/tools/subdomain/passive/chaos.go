@@ -0,0 +1,50 @@
+// pkg/tools/subdomain/passive/chaos.go
+package passive
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// Chaos queries ProjectDiscovery's Chaos dataset of subdomains
+type Chaos struct {
+	BaseURL   string
+	Key       string
+	requester *Requester
+}
+
+func init() {
+	Register(Plugin{
+		Name:        "chaos",
+		EnvVar:      "CHAOS_API_KEY",
+		RequiresKey: true,
+		Interval:    time.Second,
+		New: func(key string, requester *Requester) Source {
+			return &Chaos{BaseURL: "https://dns.projectdiscovery.io", Key: key, requester: requester}
+		},
+	})
+}
+
+// Name returns the name of the source
+func (s *Chaos) Name() string {
+	return "chaos"
+}
+
+// Subdomains returns the subdomains of the domain. Chaos lists them as
+// labels under the domain, such as "www" for www.example.com.
+func (s *Chaos) Subdomains(ctx context.Context, domain string) ([]string, error) {
+	var response struct {
+		Subdomains []string `json:"subdomains"`
+	}
+	endpoint := s.BaseURL + "/dns/" + url.PathEscape(domain) + "/subdomains"
+	if err := s.requester.GetJSON(ctx, endpoint, map[string]string{"Authorization": s.Key}, &response); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(response.Subdomains))
+	for _, label := range response.Subdomains {
+		names = append(names, label+"."+domain)
+	}
+	return names, nil
+}
@@ -0,0 +1,55 @@
+// pkg/tools/subdomain/passive/otx.go
+package passive
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// OTX queries the passive DNS records AlienVault OTX holds of a domain.
+// It answers without an API key, with a lower quota.
+type OTX struct {
+	BaseURL   string
+	Key       string
+	requester *Requester
+}
+
+func init() {
+	Register(Plugin{
+		Name:     "otx",
+		EnvVar:   "OTX_API_KEY",
+		Interval: time.Second,
+		New: func(key string, requester *Requester) Source {
+			return &OTX{BaseURL: "https://otx.alienvault.com/api/v1", Key: key, requester: requester}
+		},
+	})
+}
+
+// Name returns the name of the source
+func (s *OTX) Name() string {
+	return "otx"
+}
+
+// Subdomains returns the host names of the domain's passive DNS records
+func (s *OTX) Subdomains(ctx context.Context, domain string) ([]string, error) {
+	var response struct {
+		PassiveDNS []struct {
+			Hostname string `json:"hostname"`
+		} `json:"passive_dns"`
+	}
+	headers := map[string]string{}
+	if s.Key != "" {
+		headers["X-OTX-API-KEY"] = s.Key
+	}
+	endpoint := s.BaseURL + "/indicators/domain/" + url.PathEscape(domain) + "/passive_dns"
+	if err := s.requester.GetJSON(ctx, endpoint, headers, &response); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(response.PassiveDNS))
+	for _, record := range response.PassiveDNS {
+		names = append(names, record.Hostname)
+	}
+	return names, nil
+}
@@ -0,0 +1,331 @@
+// Package passive finds the subdomains of a domain in third-party datasets
+// (VirusTotal, SecurityTrails, AlienVault OTX and Chaos) rather than by
+// resolving candidate names. Each dataset is a plugin registered with
+// Register; its API key comes from the tools.osint_scanner.api_keys settings
+// or its environment variable. Requests are paced to each source's quota,
+// and rate-limited responses are retried after the wait the source asks for.
+package passive
+
+import (
+	"GopherStrike/pkg/config"
+	"GopherStrike/pkg/httpclient"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source is a dataset of subdomains
+type Source interface {
+	Name() string
+	// Subdomains returns the names the dataset knows under a domain
+	Subdomains(ctx context.Context, domain string) ([]string, error)
+}
+
+// Plugin describes a source and how to create it
+type Plugin struct {
+	Name        string
+	EnvVar      string        // Environment variable holding the API key
+	RequiresKey bool          // Whether the source answers without a key
+	Interval    time.Duration // Time between two requests, from the source's free quota
+	New         func(key string, requester *Requester) Source
+}
+
+var (
+	plugins   = make(map[string]Plugin)
+	pluginsMu sync.Mutex
+)
+
+// Register makes a source available under its name, replacing any source
+// registered under the same name
+func Register(plugin Plugin) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	plugins[plugin.Name] = plugin
+}
+
+// Plugins returns the registered sources by name
+func Plugins() []Plugin {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	list := make([]Plugin, 0, len(plugins))
+	for _, plugin := range plugins {
+		list = append(list, plugin)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// ErrRateLimited is returned by a source still refusing requests for
+// exceeding its quota after the retries
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// Requester sends the requests of a source, pacing them to its quota and
+// retrying those refused with 429 Too Many Requests after the Retry-After
+// delay, up to MaxWait
+type Requester struct {
+	Source     string
+	Client     *http.Client
+	Interval   time.Duration
+	MaxRetries int
+	MaxWait    time.Duration
+
+	mu   sync.Mutex
+	next time.Time // Earliest time of the next request
+}
+
+// NewRequester creates the requester of a source
+func NewRequester(source string, interval, timeout time.Duration) *Requester {
+	return &Requester{
+		Source:     source,
+		Client:     httpclient.New("passive", timeout, nil),
+		Interval:   interval,
+		MaxRetries: 3,
+		MaxWait:    2 * time.Minute,
+	}
+}
+
+// wait waits for the turn of the next request
+func (r *Requester) wait(ctx context.Context) error {
+	r.mu.Lock()
+	now := time.Now()
+	delay := time.Duration(0)
+	if r.next.After(now) {
+		delay = r.next.Sub(now)
+	}
+	r.next = now.Add(delay + r.Interval)
+	r.mu.Unlock()
+	return sleep(ctx, delay)
+}
+
+// backoff delays the requests after a rate-limited response
+func (r *Requester) backoff(delay time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if next := time.Now().Add(delay); next.After(r.next) {
+		r.next = next
+	}
+}
+
+// sleep waits for a delay unless the context ends first
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryAfter returns the delay a rate-limited response asks for, in
+// seconds or as a date, or the fallback
+func retryAfter(resp *http.Response, fallback time.Duration) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return fallback
+}
+
+// GetJSON fetches a URL with headers and decodes its JSON body into v
+func (r *Requester) GetJSON(ctx context.Context, url string, headers map[string]string, v interface{}) error {
+	for attempt := 0; ; attempt++ {
+		if err := r.wait(ctx); err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+
+		resp, err := r.Client.Do(req)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 50<<20))
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			delay := retryAfter(resp, 2*r.Interval+time.Second)
+			if attempt >= r.MaxRetries || delay > r.MaxWait {
+				return fmt.Errorf("%w (retry after %s)", ErrRateLimited, delay.Round(time.Second))
+			}
+			r.backoff(delay)
+			continue
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			return fmt.Errorf("API key rejected (HTTP %d)", resp.StatusCode)
+		case resp.StatusCode == http.StatusNotFound:
+			return nil // The source knows nothing of the domain
+		case resp.StatusCode != http.StatusOK:
+			return fmt.Errorf("unexpected status: HTTP %d", resp.StatusCode)
+		}
+		if err := json.Unmarshal(body, v); err != nil {
+			return fmt.Errorf("invalid response: %v", err)
+		}
+		return nil
+	}
+}
+
+// Options selects the sources to query
+type Options struct {
+	Sources []string          // Names of the sources, every usable source if empty
+	Keys    map[string]string // API keys by source name
+	Timeout time.Duration     // Timeout of each request
+}
+
+// DefaultOptions returns every source with the API keys of the
+// configuration, or else of the sources' environment variables
+func DefaultOptions() Options {
+	keys := make(map[string]string)
+	for name, key := range config.Get().Tools.OSINTScanner.APIKeys {
+		keys[strings.ToLower(name)] = key
+	}
+	for _, plugin := range Plugins() {
+		if keys[plugin.Name] == "" && plugin.EnvVar != "" {
+			if key := os.Getenv(plugin.EnvVar); key != "" {
+				keys[plugin.Name] = key
+			}
+		}
+	}
+	return Options{Keys: keys, Timeout: 30 * time.Second}
+}
+
+// New creates the selected sources. Without a selection, the sources
+// lacking a required key are left out and named in skipped; a selected
+// source lacking its key is an error.
+func New(options Options) (sources []Source, skipped []string, err error) {
+	selected := options.Sources
+	if len(selected) == 0 {
+		for _, plugin := range Plugins() {
+			if plugin.RequiresKey && options.Keys[plugin.Name] == "" {
+				skipped = append(skipped, plugin.Name)
+				continue
+			}
+			selected = append(selected, plugin.Name)
+		}
+	}
+
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	for _, name := range selected {
+		name = strings.ToLower(strings.TrimSpace(name))
+		plugin, ok := plugins[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown subdomain source %q", name)
+		}
+		key := options.Keys[name]
+		if plugin.RequiresKey && key == "" {
+			return nil, nil, fmt.Errorf("the %s source requires an API key (tools.osint_scanner.api_keys.%s or %s)",
+				name, name, plugin.EnvVar)
+		}
+		sources = append(sources, plugin.New(key, NewRequester(name, plugin.Interval, options.Timeout)))
+	}
+	if len(sources) == 0 {
+		return nil, skipped, fmt.Errorf("no subdomain source has an API key")
+	}
+	return sources, skipped, nil
+}
+
+// Subdomain is a name found by one or more sources
+type Subdomain struct {
+	Name    string   `json:"name"`
+	Sources []string `json:"sources"`
+}
+
+// SourceStats is the outcome of querying one source
+type SourceStats struct {
+	Source  string  `json:"source"`
+	Found   int     `json:"found"` // Valid names, before merging
+	Seconds float64 `json:"seconds"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Result holds the merged names of every source
+type Result struct {
+	Domain     string        `json:"domain"`
+	Subdomains []Subdomain   `json:"subdomains"`
+	Sources    []SourceStats `json:"sources"`
+}
+
+// Normalize returns a name found by a source as a subdomain of domain, or
+// "" if it is not one: lowercased, without a wildcard label or final dot
+func Normalize(name, domain string) string {
+	name = strings.Trim(strings.ToLower(strings.TrimSpace(name)), ".")
+	name = strings.TrimPrefix(name, "*.")
+	domain = strings.Trim(strings.ToLower(domain), ".")
+	if name == domain || !strings.HasSuffix(name, "."+domain) {
+		return ""
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '-' && r != '.' && r != '_' {
+			return ""
+		}
+	}
+	return name
+}
+
+// Enumerate queries the sources in parallel and merges their names. A
+// source that fails is recorded in the statistics without failing the rest.
+func Enumerate(ctx context.Context, domain string, sources []Source) *Result {
+	result := &Result{Domain: domain, Subdomains: []Subdomain{}, Sources: make([]SourceStats, len(sources))}
+	found := make(map[string][]string)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source Source) {
+			defer wg.Done()
+			start := time.Now()
+			names, err := source.Subdomains(ctx, domain)
+			stats := SourceStats{Source: source.Name(), Seconds: time.Since(start).Seconds()}
+			if err != nil {
+				stats.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			seen := make(map[string]bool)
+			for _, name := range names {
+				if name = Normalize(name, domain); name != "" && !seen[name] {
+					seen[name] = true
+					found[name] = append(found[name], source.Name())
+				}
+			}
+			stats.Found = len(seen)
+			result.Sources[i] = stats
+		}(i, source)
+	}
+	wg.Wait()
+
+	for name, names := range found {
+		sort.Strings(names)
+		result.Subdomains = append(result.Subdomains, Subdomain{Name: name, Sources: names})
+	}
+	sort.Slice(result.Subdomains, func(i, j int) bool { return result.Subdomains[i].Name < result.Subdomains[j].Name })
+	return result
+}
@@ -0,0 +1,159 @@
+package passive
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"WWW.Example.com.", "www.example.com"},
+		{"*.dev.example.com", "dev.example.com"},
+		{"example.com", ""},
+		{"notexample.com", ""},
+		{"api.example.org", ""},
+		{"bad name.example.com", ""},
+		{"_dmarc.example.com", "_dmarc.example.com"},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.name, "example.com"); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+type staticSource struct {
+	name  string
+	names []string
+	err   error
+}
+
+func (s staticSource) Name() string { return s.name }
+
+func (s staticSource) Subdomains(ctx context.Context, domain string) ([]string, error) {
+	return s.names, s.err
+}
+
+func TestEnumerateMerges(t *testing.T) {
+	sources := []Source{
+		staticSource{name: "a", names: []string{"www.example.com", "API.example.com", "www.example.com"}},
+		staticSource{name: "b", names: []string{"api.example.com", "mail.example.com", "other.org"}},
+		staticSource{name: "c", names: []string{"dev.example.com"}, err: ErrRateLimited},
+	}
+
+	result := Enumerate(context.Background(), "example.com", sources)
+	want := []Subdomain{
+		{Name: "api.example.com", Sources: []string{"a", "b"}},
+		{Name: "dev.example.com", Sources: []string{"c"}},
+		{Name: "mail.example.com", Sources: []string{"b"}},
+		{Name: "www.example.com", Sources: []string{"a"}},
+	}
+	if !reflect.DeepEqual(result.Subdomains, want) {
+		t.Errorf("Subdomains = %v, want %v", result.Subdomains, want)
+	}
+	if result.Sources[0].Found != 2 || result.Sources[1].Found != 2 {
+		t.Errorf("Found = %d, %d, want 2, 2", result.Sources[0].Found, result.Sources[1].Found)
+	}
+	if result.Sources[2].Error == "" {
+		t.Error("the error of source c was not recorded")
+	}
+}
+
+func TestRequesterRetriesAfterRateLimit(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"subdomains": ["www", "api"]}`))
+	}))
+	defer server.Close()
+
+	requester := NewRequester("chaos", 0, 5*time.Second)
+	source := &Chaos{BaseURL: server.URL, Key: "key", requester: requester}
+	start := time.Now()
+	names, err := source.Subdomains(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("Subdomains() error = %v", err)
+	}
+	if want := []string{"www.example.com", "api.example.com"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Subdomains() = %v, want %v", names, want)
+	}
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+	if time.Since(start) < time.Second {
+		t.Error("the request was retried before the Retry-After delay")
+	}
+}
+
+func TestRequesterGivesUpOnRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	requester := NewRequester("otx", 0, 5*time.Second)
+	var v struct{}
+	if err := requester.GetJSON(context.Background(), server.URL, nil, &v); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("GetJSON() error = %v, want %v", err, ErrRateLimited)
+	}
+}
+
+func TestVirusTotalPagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-apikey") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("cursor") == "" {
+			w.Write([]byte(`{"data": [{"id": "www.example.com"}], "meta": {"cursor": "next"}}`))
+			return
+		}
+		w.Write([]byte(`{"data": [{"id": "api.example.com"}], "meta": {}}`))
+	}))
+	defer server.Close()
+
+	source := &VirusTotal{BaseURL: server.URL, Key: "key", MaxPages: 5, requester: NewRequester("virustotal", 0, 5*time.Second)}
+	names, err := source.Subdomains(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("Subdomains() error = %v", err)
+	}
+	if want := []string{"www.example.com", "api.example.com"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Subdomains() = %v, want %v", names, want)
+	}
+}
+
+func TestNewSkipsSourcesWithoutKey(t *testing.T) {
+	sources, skipped, err := New(Options{Keys: map[string]string{"chaos": "key"}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	names := []string{}
+	for _, source := range sources {
+		names = append(names, source.Name())
+	}
+	if want := []string{"chaos", "otx"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("sources = %v, want %v", names, want)
+	}
+	if want := []string{"securitytrails", "virustotal"}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped = %v, want %v", skipped, want)
+	}
+
+	if _, _, err := New(Options{Sources: []string{"virustotal"}}); err == nil {
+		t.Error("New() accepted a selected source without its key")
+	}
+}
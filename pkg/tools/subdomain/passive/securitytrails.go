@@ -0,0 +1,51 @@
+// pkg/tools/subdomain/passive/securitytrails.go
+package passive
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// SecurityTrails queries the subdomains SecurityTrails keeps of a domain,
+// including the inactive ones
+type SecurityTrails struct {
+	BaseURL   string
+	Key       string
+	requester *Requester
+}
+
+func init() {
+	Register(Plugin{
+		Name:        "securitytrails",
+		EnvVar:      "SECURITY_TRAILS_API_KEY",
+		RequiresKey: true,
+		Interval:    time.Second,
+		New: func(key string, requester *Requester) Source {
+			return &SecurityTrails{BaseURL: "https://api.securitytrails.com/v1", Key: key, requester: requester}
+		},
+	})
+}
+
+// Name returns the name of the source
+func (s *SecurityTrails) Name() string {
+	return "securitytrails"
+}
+
+// Subdomains returns the subdomains of the domain. SecurityTrails lists
+// them as labels under the domain, such as "www" for www.example.com.
+func (s *SecurityTrails) Subdomains(ctx context.Context, domain string) ([]string, error) {
+	var response struct {
+		Subdomains []string `json:"subdomains"`
+	}
+	endpoint := s.BaseURL + "/domain/" + url.PathEscape(domain) + "/subdomains?children_only=false&include_inactive=true"
+	if err := s.requester.GetJSON(ctx, endpoint, map[string]string{"APIKEY": s.Key}, &response); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(response.Subdomains))
+	for _, label := range response.Subdomains {
+		names = append(names, label+"."+domain)
+	}
+	return names, nil
+}
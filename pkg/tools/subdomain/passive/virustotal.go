@@ -0,0 +1,67 @@
+// pkg/tools/subdomain/passive/virustotal.go
+package passive
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// VirusTotal queries the subdomain relationship of VirusTotal domains,
+// 40 names per page
+type VirusTotal struct {
+	BaseURL   string
+	Key       string
+	MaxPages  int
+	requester *Requester
+}
+
+func init() {
+	Register(Plugin{
+		Name:        "virustotal",
+		EnvVar:      "VIRUSTOTAL_API_KEY",
+		RequiresKey: true,
+		Interval:    15 * time.Second, // The public API allows 4 requests a minute
+		New: func(key string, requester *Requester) Source {
+			return &VirusTotal{BaseURL: "https://www.virustotal.com/api/v3", Key: key, MaxPages: 25, requester: requester}
+		},
+	})
+}
+
+// Name returns the name of the source
+func (s *VirusTotal) Name() string {
+	return "virustotal"
+}
+
+// Subdomains returns the subdomains VirusTotal has seen, following the
+// pagination cursor up to MaxPages pages
+func (s *VirusTotal) Subdomains(ctx context.Context, domain string) ([]string, error) {
+	names := []string{}
+	cursor := ""
+	for page := 0; page < s.MaxPages; page++ {
+		query := url.Values{"limit": {"40"}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		var response struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+			Meta struct {
+				Cursor string `json:"cursor"`
+			} `json:"meta"`
+		}
+		endpoint := s.BaseURL + "/domains/" + url.PathEscape(domain) + "/subdomains?" + query.Encode()
+		if err := s.requester.GetJSON(ctx, endpoint, map[string]string{"x-apikey": s.Key}, &response); err != nil {
+			return names, err
+		}
+		for _, item := range response.Data {
+			names = append(names, item.ID)
+		}
+		if response.Meta.Cursor == "" || len(response.Data) == 0 {
+			break
+		}
+		cursor = response.Meta.Cursor
+	}
+	return names, nil
+}
@@ -2,10 +2,15 @@
 package subdomain
 
 import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/estimate"
+	"GopherStrike/pkg/redact"
 	"GopherStrike/pkg/tools"
+	"GopherStrike/pkg/wordlist"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -50,12 +55,6 @@ func RunScanner() error {
 	scanCtx := &ScanContext{
 		StartTime:     time.Now(),
 		OutputFormats: []string{FormatText, FormatJSON},
-		LogsDirectory: filepath.Join("logs", "subdomains"),
-	}
-
-	// Create logs directory
-	if err := EnsureDirectory(scanCtx.LogsDirectory); err != nil {
-		fmt.Printf("Warning: Failed to create logs directory: %v\n", err)
 	}
 
 	// Get the domain to scan
@@ -65,6 +64,12 @@ func RunScanner() error {
 	}
 	scanCtx.Domain = domain
 
+	// Results are kept in the target's subdomains artifact directory
+	scanCtx.LogsDirectory, err = artifacts.Default().TargetDir(domain, artifacts.KindSubdomains)
+	if err != nil {
+		return err
+	}
+
 	// Get the wordlist path
 	wordlistPath, err := GetWordlistPath()
 	if err != nil {
@@ -80,6 +85,19 @@ func RunScanner() error {
 		CheckSSL:     true,
 		Timeout:      5,
 		ResolveIPs:   true,
+		ChunkSize:    tools.DefaultChunkSize,
+	}
+
+	// Offer to resume an interrupted scan of the domain with this wordlist
+	if checkpoint := tools.FindCheckpoint(domain, wordlistPath); checkpoint != nil {
+		fmt.Printf("\nA scan of %s with this wordlist stopped at chunk %d/%d (%d names checked, %d active).\n",
+			domain, checkpoint.NextChunk+1, checkpoint.TotalChunks, checkpoint.Checked, len(checkpoint.Active))
+		fmt.Print("Resume it? (Y/n): ")
+		var answer string
+		fmt.Scanln(&answer)
+		if strings.ToLower(strings.TrimSpace(answer)) != "n" {
+			options.Resume = checkpoint
+		}
 	}
 
 	// Allow user to customize options
@@ -87,6 +105,9 @@ func RunScanner() error {
 	if err != nil {
 		return err
 	}
+	if options.Resume != nil {
+		options.ChunkSize = options.Resume.ChunkSize // A resumed scan keeps its chunks
+	}
 
 	// Summary before starting
 	fmt.Println("\n===== Scan Configuration =====")
@@ -97,10 +118,20 @@ func RunScanner() error {
 	fmt.Printf("SSL check:          %t\n", options.CheckSSL)
 	fmt.Printf("Connection timeout: %d seconds\n", options.Timeout)
 	fmt.Printf("Resolve IPs:        %t\n", options.ResolveIPs)
+	fmt.Printf("Checkpoint chunks:  %d words\n", options.ChunkSize)
+	if options.Resume != nil {
+		fmt.Printf("Resuming:           %s\n", options.Resume.Path())
+	}
 
-	// Confirm scan
-	fmt.Print("\nPress Enter to start the scan or Ctrl+C to abort...")
-	fmt.Scanln()
+	// Confirm scan, with what it will cost
+	words, err := wordlist.Count(wordlistPath)
+	if err != nil {
+		return fmt.Errorf("failed to load wordlist: %w", err)
+	}
+	if !estimate.Confirm(os.Stdout, nil, tools.EstimateSubdomains(1, words, options)) {
+		fmt.Println("Scan cancelled.")
+		return nil
+	}
 
 	fmt.Printf("\nStarting subdomain scan for: %s\n", domain)
 	fmt.Println("This may take a while depending on wordlist size...")
@@ -192,7 +223,7 @@ func SaveResults(scanCtx *ScanContext, result tools.ScanResult) error {
 		}
 	}
 
-	fmt.Printf("\nResults saved to: %s/%s.*\n", scanCtx.LogsDirectory, baseFilename)
+	fmt.Printf("\nResults saved to: %s.*\n", filepath.Join(scanCtx.LogsDirectory, baseFilename))
 	return nil
 }
 
@@ -216,29 +247,31 @@ func saveTextResults(scanCtx *ScanContext, result tools.ScanResult, filename str
 		return err
 	}
 	defer file.Close()
+	w := redact.Writer(file)
+	defer w.Close()
 
 	// Write header
-	fmt.Fprintf(file, "GopherStrike SubTapper - Subdomain Scan Results\n")
-	fmt.Fprintf(file, "=============================================\n\n")
-	fmt.Fprintf(file, "Target Domain: %s\n", scanCtx.Domain)
-	fmt.Fprintf(file, "Scan Time: %s\n", time.Now().Format(time.RFC1123))
-	fmt.Fprintf(file, "Duration: %.2f seconds\n", result.Duration)
-	fmt.Fprintf(file, "Wordlist: %s\n", scanCtx.WordlistPath)
-	fmt.Fprintf(file, "Total Subdomains Found: %d\n", result.TotalFound)
-	fmt.Fprintf(file, "Active Subdomains: %d\n\n", result.Active)
+	fmt.Fprintf(w, "GopherStrike SubTapper - Subdomain Scan Results\n")
+	fmt.Fprintf(w, "=============================================\n\n")
+	fmt.Fprintf(w, "Target Domain: %s\n", scanCtx.Domain)
+	fmt.Fprintf(w, "Scan Time: %s\n", time.Now().Format(time.RFC1123))
+	fmt.Fprintf(w, "Duration: %.2f seconds\n", result.Duration)
+	fmt.Fprintf(w, "Wordlist: %s\n", scanCtx.WordlistPath)
+	fmt.Fprintf(w, "Total Subdomains Found: %d\n", result.TotalFound)
+	fmt.Fprintf(w, "Active Subdomains: %d\n\n", result.Active)
 
 	// Write results table
-	fmt.Fprintf(file, "%-40s %-15s %-7s %-20s\n", "SUBDOMAIN", "STATUS", "HTTP", "IP ADDRESSES")
-	fmt.Fprintf(file, "%s\n", strings.Repeat("-", 90))
+	fmt.Fprintf(w, "%-40s %-15s %-7s %-20s\n", "SUBDOMAIN", "STATUS", "HTTP", "IP ADDRESSES")
+	fmt.Fprintf(w, "%s\n", strings.Repeat("-", 90))
 
 	// Write each subdomain
 	for _, subdomain := range result.Results {
-		writeSubdomainToFile(file, subdomain)
+		writeSubdomainToFile(w, subdomain)
 	}
 
 	// Write footer
-	fmt.Fprintf(file, "\n--- End of Report ---\n")
-	fmt.Fprintf(file, "Generated by GopherStrike SubTapper on %s\n", time.Now().Format(time.RFC1123))
+	fmt.Fprintf(w, "\n--- End of Report ---\n")
+	fmt.Fprintf(w, "Generated by GopherStrike SubTapper on %s\n", time.Now().Format(time.RFC1123))
 
 	return nil
 }
@@ -265,7 +298,7 @@ func saveJSONResults(scanCtx *ScanContext, result tools.ScanResult, filename str
 	}
 
 	// Write to file with secure permissions
-	return os.WriteFile(filename, jsonData, 0600)
+	return os.WriteFile(filename, redact.Bytes(jsonData), 0600)
 }
 
 // saveCSVResults saves the results in CSV format for spreadsheet import
@@ -276,9 +309,11 @@ func saveCSVResults(_ *ScanContext, result tools.ScanResult, filename string) er
 		return err
 	}
 	defer file.Close()
+	w := redact.Writer(file)
+	defer w.Close()
 
 	// Write CSV header
-	fmt.Fprintln(file, "Subdomain,Status,HTTP Status,IP Addresses,Error")
+	fmt.Fprintln(w, "Subdomain,Status,HTTP Status,IP Addresses,Error")
 
 	// Write each subdomain
 	for _, subdomain := range result.Results {
@@ -299,7 +334,7 @@ func saveCSVResults(_ *ScanContext, result tools.ScanResult, filename string) er
 		ips = strings.ReplaceAll(ips, ",", "\\,")
 		errorField := strings.ReplaceAll(subdomain.Error, ",", "\\,")
 
-		fmt.Fprintf(file, "%s,%s,%s,%s,%s\n",
+		fmt.Fprintf(w, "%s,%s,%s,%s,%s\n",
 			subdomainName, status, httpStatus, ips, errorField)
 	}
 
@@ -307,7 +342,7 @@ func saveCSVResults(_ *ScanContext, result tools.ScanResult, filename string) er
 }
 
 // writeSubdomainToFile writes a single subdomain entry to the results file
-func writeSubdomainToFile(file *os.File, subdomain tools.SubdomainResult) {
+func writeSubdomainToFile(w io.Writer, subdomain tools.SubdomainResult) {
 	status := "Inactive"
 	if subdomain.Active {
 		status = "Active"
@@ -323,6 +358,6 @@ func writeSubdomainToFile(file *os.File, subdomain tools.SubdomainResult) {
 		ipList = strings.Join(subdomain.IPs, ", ")
 	}
 
-	fmt.Fprintf(file, "%-40s %-15s %-7s %-20s\n",
+	fmt.Fprintf(w, "%-40s %-15s %-7s %-20s\n",
 		subdomain.Name, status, httpStatus, ipList)
 }
@@ -0,0 +1,50 @@
+package subdomain
+
+import (
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/tools"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSavedResultsRedacted(t *testing.T) {
+	rules, err := redact.Parse(strings.NewReader("payroll-acme\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	redact.Set(rules)
+	defer redact.Set(nil)
+
+	dir := t.TempDir()
+	scanCtx := &ScanContext{Domain: "example.com", WordlistPath: "words.txt", LogsDirectory: dir}
+	result := tools.ScanResult{
+		Domain:     "example.com",
+		Results:    []tools.SubdomainResult{{Name: "payroll-acme.example.com", IPs: []string{"192.0.2.10"}, Active: true, HTTPStatus: 200}},
+		TotalFound: 1,
+		Active:     1,
+	}
+
+	savers := map[string]func(*ScanContext, tools.ScanResult, string) error{
+		"results.txt":  saveTextResults,
+		"results.json": saveJSONResults,
+		"results.csv":  saveCSVResults,
+	}
+	for name, save := range savers {
+		filename := filepath.Join(dir, name)
+		if err := save(scanCtx, result, filename); err != nil {
+			t.Fatalf("saving %s error = %v", name, err)
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "payroll-acme") {
+			t.Errorf("%s not redacted:\n%s", name, data)
+		}
+		if !strings.Contains(string(data), "[REDACTED].example.com") || !strings.Contains(string(data), "192.0.2.10") {
+			t.Errorf("%s lost the subdomain:\n%s", name, data)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+// pkg/tools/webvuln/actuator.go
+package webvuln
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// actuatorEndpoint is a Spring Boot actuator endpoint and what it gives away
+type actuatorEndpoint struct {
+	Severity Severity
+	Reason   string
+	Download bool // Large binary or text, only its status is checked
+}
+
+// actuatorEndpoints are the actuator endpoints probed when the discovery
+// page is disabled, rated by what an attacker gains from them. shutdown is
+// never requested, as it is a POST that stops the application; it is only
+// reported when the discovery page lists it.
+var actuatorEndpoints = map[string]actuatorEndpoint{
+	"heapdump":         {SeverityCritical, "memory dump holding credentials, keys and session tokens", true},
+	"jolokia":          {SeverityCritical, "JMX over HTTP, may allow running code", false},
+	"gateway":          {SeverityCritical, "Spring Cloud Gateway routes, writable routes allow running code (CVE-2022-22947)", false},
+	"shutdown":         {SeverityHigh, "stops the application", false},
+	"env":              {SeverityHigh, "environment and configuration properties", false},
+	"configprops":      {SeverityHigh, "configuration properties of the beans", false},
+	"threaddump":       {SeverityHigh, "thread dump", false},
+	"logfile":          {SeverityHigh, "application log", true},
+	"httptrace":        {SeverityHigh, "recent requests with their headers and cookies", false},
+	"httpexchanges":    {SeverityHigh, "recent requests with their headers and cookies", false},
+	"trace":            {SeverityHigh, "recent requests with their headers and cookies", false},
+	"sessions":         {SeverityHigh, "user sessions, may allow deleting them", false},
+	"mappings":         {SeverityMedium, "every request mapping of the application", false},
+	"beans":            {SeverityMedium, "application beans and their dependencies", false},
+	"loggers":          {SeverityMedium, "log levels, writable to enable debug logging", false},
+	"conditions":       {SeverityMedium, "auto-configuration report", false},
+	"scheduledtasks":   {SeverityMedium, "scheduled tasks", false},
+	"caches":           {SeverityMedium, "caches, may allow evicting them", false},
+	"flyway":           {SeverityMedium, "database migrations", false},
+	"liquibase":        {SeverityMedium, "database migrations", false},
+	"auditevents":      {SeverityMedium, "authentication events with user names", false},
+	"integrationgraph": {SeverityMedium, "Spring Integration graph", false},
+	"quartz":           {SeverityMedium, "Quartz jobs and triggers", false},
+	"startup":          {SeverityLow, "startup steps", false},
+	"metrics":          {SeverityLow, "application metrics", false},
+	"prometheus":       {SeverityLow, "application metrics", false},
+	"info":             {SeverityLow, "build and version information", false},
+}
+
+// actuatorSecretKey matches the names of properties holding secrets
+var actuatorSecretKey = regexp.MustCompile(`(?i)(password|passwd|pwd|secret|credential|token|api[._-]?key|private[._-]?key|access[._-]?key)`)
+
+// ActuatorHealth reports whether a body is the response of the actuator
+// health endpoint, and whether it shows the health of the components
+func ActuatorHealth(body []byte) (ok, details bool) {
+	var health struct {
+		Status     string                 `json:"status"`
+		Components map[string]interface{} `json:"components"`
+		Details    map[string]interface{} `json:"details"`
+	}
+	if err := json.Unmarshal(body, &health); err != nil {
+		return false, false
+	}
+	switch health.Status {
+	case "UP", "DOWN", "OUT_OF_SERVICE", "UNKNOWN":
+		return true, len(health.Components) > 0 || len(health.Details) > 0
+	}
+	return false, false
+}
+
+// ActuatorLinks returns the endpoints listed by the actuator discovery
+// page, without the templated links to single entries such as
+// env-toMatch, sorted
+func ActuatorLinks(body []byte) []string {
+	var discovery struct {
+		Links map[string]struct {
+			Templated bool `json:"templated"`
+		} `json:"_links"`
+	}
+	if err := json.Unmarshal(body, &discovery); err != nil {
+		return nil
+	}
+	endpoints := make([]string, 0, len(discovery.Links))
+	for name, link := range discovery.Links {
+		if name == "self" || link.Templated {
+			continue
+		}
+		endpoints = append(endpoints, name)
+	}
+	sort.Strings(endpoints)
+	return endpoints
+}
+
+// ActuatorSecret is a property of the env endpoint holding a secret that
+// Spring did not mask
+type ActuatorSecret struct {
+	Source string // Property source, such as "systemEnvironment"
+	Name   string
+	Value  string
+}
+
+// ActuatorSecrets returns the unmasked secrets of an env endpoint response,
+// in the Spring Boot 2 and later format or the flat Spring Boot 1 one
+func ActuatorSecrets(body []byte) []ActuatorSecret {
+	var env struct {
+		PropertySources []struct {
+			Name       string `json:"name"`
+			Properties map[string]struct {
+				Value interface{} `json:"value"`
+			} `json:"properties"`
+		} `json:"propertySources"`
+	}
+	sources := make(map[string]map[string]interface{})
+	order := make([]string, 0)
+	if err := json.Unmarshal(body, &env); err == nil && env.PropertySources != nil {
+		for _, source := range env.PropertySources {
+			properties := make(map[string]interface{})
+			for name, property := range source.Properties {
+				properties[name] = property.Value
+			}
+			sources[source.Name] = properties
+			order = append(order, source.Name)
+		}
+	} else {
+		var legacy map[string]interface{}
+		if err := json.Unmarshal(body, &legacy); err != nil {
+			return nil
+		}
+		for name, value := range legacy {
+			if properties, ok := value.(map[string]interface{}); ok {
+				sources[name] = properties
+				order = append(order, name)
+			}
+		}
+		sort.Strings(order)
+	}
+
+	secrets := make([]ActuatorSecret, 0)
+	for _, source := range order {
+		names := make([]string, 0, len(sources[source]))
+		for name := range sources[source] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			value, ok := sources[source][name].(string)
+			if !ok || !actuatorSecretKey.MatchString(name) || strings.Trim(value, "*") == "" {
+				continue
+			}
+			secrets = append(secrets, ActuatorSecret{Source: source, Name: name, Value: value})
+		}
+	}
+	return secrets
+}
+
+// actuatorBases returns where the actuators may be mounted: under the
+// directory of the target, for applications with a context path, and at
+// the root of its origin
+func actuatorBases(targetURL *url.URL) []string {
+	origin := (&url.URL{Scheme: targetURL.Scheme, Host: targetURL.Host}).String()
+	bases := []string{origin + "/actuator"}
+	if dir := targetURL.Path[:strings.LastIndex(targetURL.Path, "/")+1]; dir != "" && dir != "/" {
+		bases = append([]string{origin + dir + "actuator"}, bases...)
+	}
+	return bases
+}
+
+// checkActuators enumerates the Spring Boot actuators once the health
+// endpoint answers: the endpoints listed by the discovery page, or the
+// known ones if it is disabled, each rated by what it exposes. With
+// ActuatorSecrets, the env endpoint is read for unmasked credentials.
+func (s *Scanner) checkActuators(target ScanTarget, payload Payload) []TestResult {
+	targetURL, err := url.Parse(target.URL)
+	if err != nil {
+		return nil
+	}
+
+	for _, base := range actuatorBases(targetURL) {
+		resp, err := s.sendRequest(target, "GET", base+"/health", map[string]string{"Accept": "application/json"}, "")
+		if err != nil {
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+		resp.Body.Close()
+		ok, details := ActuatorHealth(body)
+		if resp.StatusCode != 200 || !ok {
+			continue
+		}
+		return s.enumerateActuators(target, payload, base, details)
+	}
+	return nil
+}
+
+// enumerateActuators reports the endpoints exposed under an actuator base
+func (s *Scanner) enumerateActuators(target ScanTarget, payload Payload, base string, healthDetails bool) []TestResult {
+	// The discovery page lists the exposed endpoints, else each is tried
+	names := []string{}
+	discovered := false
+	if resp, err := s.sendRequest(target, "GET", base, map[string]string{"Accept": "application/json"}, ""); err == nil {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+		resp.Body.Close()
+		if resp.StatusCode == 200 {
+			names = ActuatorLinks(body)
+			discovered = len(names) > 0
+		}
+	}
+	if !discovered {
+		for name := range actuatorEndpoints {
+			if name != "shutdown" {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	exposed := make([]string, 0)
+	results := make([]TestResult, 0)
+	for _, name := range names {
+		if s.ctx.Err() != nil {
+			break
+		}
+		endpoint, known := actuatorEndpoints[name]
+		if name == "health" {
+			continue
+		}
+		if !known {
+			// Custom endpoints listed by the discovery page go in the evidence
+			exposed = append(exposed, name)
+			continue
+		}
+		if !discovered {
+			resp, err := s.sendRequest(target, "GET", base+"/"+name, nil, "")
+			if err != nil {
+				continue
+			}
+			if !endpoint.Download {
+				io.Copy(io.Discard, io.LimitReader(resp.Body, 1024*1024))
+			}
+			resp.Body.Close()
+			if resp.StatusCode != 200 {
+				continue
+			}
+		}
+
+		exposed = append(exposed, name)
+		results = append(results, TestResult{
+			Payload:     payload,
+			URL:         base + "/" + name,
+			Method:      "GET",
+			Description: fmt.Sprintf("Spring Boot actuator %s exposed: %s", name, endpoint.Reason),
+			Severity:    endpoint.Severity,
+		})
+	}
+
+	summary := fmt.Sprintf("Spring Boot actuators exposed at %s", base)
+	severity := SeverityInfo
+	if healthDetails {
+		summary += ", health shows component details"
+		severity = SeverityLow
+	}
+	evidence := "Exposed endpoints: health"
+	if len(exposed) > 0 {
+		evidence += ", " + strings.Join(exposed, ", ")
+	}
+	results = append([]TestResult{{
+		Payload:     payload,
+		URL:         base + "/health",
+		Method:      "GET",
+		Description: summary,
+		Severity:    severity,
+		Evidence:    evidence,
+	}}, results...)
+
+	if s.ScanOptions.ActuatorSecrets {
+		for _, name := range exposed {
+			if name == "env" {
+				results = append(results, s.checkActuatorEnv(target, payload, base+"/env")...)
+			}
+		}
+	}
+	return results
+}
+
+// checkActuatorEnv reads the env endpoint and reports the secrets Spring
+// did not mask, redacted
+func (s *Scanner) checkActuatorEnv(target ScanTarget, payload Payload, envURL string) []TestResult {
+	resp, err := s.sendRequest(target, "GET", envURL, map[string]string{"Accept": "application/json"}, "")
+	if err != nil {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil
+	}
+
+	results := make([]TestResult, 0)
+	for _, secret := range ActuatorSecrets(body) {
+		results = append(results, TestResult{
+			Payload:     payload,
+			URL:         envURL,
+			Method:      "GET",
+			Parameter:   secret.Name,
+			Description: fmt.Sprintf("Actuator env leaks %s from %s (sample: %s)", secret.Name, secret.Source, Redact(secret.Value)),
+			Severity:    SeverityCritical,
+		})
+	}
+	return results
+}
@@ -0,0 +1,333 @@
+// pkg/tools/webvuln/apidocs.go
+package webvuln
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of API documentation
+const (
+	APIDocSwagger       = "Swagger 2.0 specification"
+	APIDocOpenAPI       = "OpenAPI specification"
+	APIDocSwaggerUI     = "Swagger UI"
+	APIDocRedoc         = "Redoc"
+	APIDocGraphQLIDE    = "GraphQL IDE"
+	APIDocIntrospection = "GraphQL introspection"
+)
+
+// APIDocument is API documentation served by the target
+type APIDocument struct {
+	Kind       string
+	URL        string
+	Title      string
+	Version    string
+	SpecURL    string   // Specification loaded by a documentation page
+	Operations []string // "GET /users/{id}" for REST APIs, "query users" for GraphQL
+	Endpoints  []string // URLs of the GET operations, path parameters set to 1
+}
+
+// apiSpecPaths are where frameworks serve OpenAPI and Swagger
+// specifications by default
+var apiSpecPaths = []string{
+	"/swagger.json", "/swagger/v1/swagger.json", "/v2/api-docs", "/v3/api-docs",
+	"/openapi.json", "/api-docs", "/api/swagger.json", "/api/openapi.json",
+}
+
+// apiDocPaths are where documentation pages and GraphQL IDEs are served
+var apiDocPaths = []string{
+	"/swagger-ui.html", "/swagger-ui/", "/swagger/", "/docs", "/api/docs", "/redoc",
+	"/graphiql", "/playground", "/altair", "/graphql", "/api/graphql",
+}
+
+// graphQLPaths receive the introspection query
+var graphQLPaths = []string{"/graphql", "/api/graphql", "/v1/graphql", "/query"}
+
+// graphQLIntrospection asks for the root operations of a GraphQL schema
+const graphQLIntrospection = `{"query":"query{__schema{queryType{fields{name}} mutationType{fields{name}} subscriptionType{fields{name}}}}"}`
+
+var (
+	swaggerUIRegex  = regexp.MustCompile(`(?i)SwaggerUIBundle|swagger-ui(?:-bundle)?\.js|<div id="swagger-ui"`)
+	redocRegex      = regexp.MustCompile(`(?i)<redoc\b|redoc\.standalone\.js|Redoc\.init\(`)
+	graphQLIDERegex = regexp.MustCompile(`(?i)graphiql|GraphQL Playground|altair-graphql|embeddable-sandbox`)
+	specURLRegex    = regexp.MustCompile(`(?i)(?:\burl\s*:\s*|spec-url\s*=\s*|Redoc\.init\(\s*)["']([^"']+\.json[^"']*|[^"']*api-docs[^"']*)["']`)
+	pathParamRegex  = regexp.MustCompile(`\{[^}]+\}`)
+)
+
+// ParseAPISpec returns the operations of a Swagger 2.0 or OpenAPI 3 JSON
+// specification, or nil if the body is not one. The endpoints are
+// resolved against the base path or first server of the specification.
+func ParseAPISpec(specURL string, body []byte) *APIDocument {
+	var spec struct {
+		Swagger string `json:"swagger"`
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Title   string `json:"title"`
+			Version string `json:"version"`
+		} `json:"info"`
+		BasePath string `json:"basePath"`
+		Servers  []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(body, &spec); err != nil || spec.Paths == nil || (spec.Swagger == "" && spec.OpenAPI == "") {
+		return nil
+	}
+	location, err := url.Parse(specURL)
+	if err != nil {
+		return nil
+	}
+
+	document := &APIDocument{Kind: APIDocOpenAPI, URL: specURL, Title: spec.Info.Title, Version: spec.Info.Version}
+	base := &url.URL{Scheme: location.Scheme, Host: location.Host, Path: spec.BasePath}
+	if spec.Swagger != "" {
+		document.Kind = APIDocSwagger
+	} else if len(spec.Servers) > 0 {
+		if server, err := location.Parse(spec.Servers[0].URL); err == nil {
+			base = server
+		}
+	}
+
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		methods := make([]string, 0)
+		for method := range spec.Paths[path] {
+			switch method = strings.ToUpper(method); method {
+			case "GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS":
+				methods = append(methods, method)
+			}
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			document.Operations = append(document.Operations, method+" "+path)
+			if method == "GET" {
+				endpoint := *base
+				endpoint.Path = strings.TrimSuffix(base.Path, "/") + pathParamRegex.ReplaceAllString(path, "1")
+				document.Endpoints = append(document.Endpoints, endpoint.String())
+			}
+		}
+	}
+	return document
+}
+
+// ParseAPIDocPage returns the documentation served by a page: Swagger UI,
+// Redoc or a GraphQL IDE, with the specification it loads if named in the
+// page. It returns nil for other pages.
+func ParseAPIDocPage(pageURL, body string) *APIDocument {
+	document := &APIDocument{URL: pageURL}
+	switch {
+	case swaggerUIRegex.MatchString(body):
+		document.Kind = APIDocSwaggerUI
+	case redocRegex.MatchString(body):
+		document.Kind = APIDocRedoc
+	case graphQLIDERegex.MatchString(body):
+		document.Kind = APIDocGraphQLIDE
+		return document
+	default:
+		return nil
+	}
+
+	if match := specURLRegex.FindStringSubmatch(body); match != nil {
+		if page, err := url.Parse(pageURL); err == nil {
+			if spec := resolveLink(page, match[1]); spec != nil {
+				document.SpecURL = spec.String()
+			}
+		}
+	}
+	return document
+}
+
+// ParseGraphQLSchema returns the root operations of a response to the
+// introspection query, or nil if introspection is disabled
+func ParseGraphQLSchema(endpoint string, body []byte) *APIDocument {
+	type rootType struct {
+		Fields []struct {
+			Name string `json:"name"`
+		} `json:"fields"`
+	}
+	var response struct {
+		Data struct {
+			Schema *struct {
+				QueryType        *rootType `json:"queryType"`
+				MutationType     *rootType `json:"mutationType"`
+				SubscriptionType *rootType `json:"subscriptionType"`
+			} `json:"__schema"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || response.Data.Schema == nil {
+		return nil
+	}
+
+	document := &APIDocument{Kind: APIDocIntrospection, URL: endpoint}
+	schema := response.Data.Schema
+	for _, root := range []struct {
+		operation string
+		fields    *rootType
+	}{{"query", schema.QueryType}, {"mutation", schema.MutationType}, {"subscription", schema.SubscriptionType}} {
+		if root.fields == nil {
+			continue
+		}
+		for _, field := range root.fields.Fields {
+			document.Operations = append(document.Operations, root.operation+" "+field.Name)
+		}
+	}
+	return document
+}
+
+// apiDocumentationEnabled reports whether the API_DOCUMENTATION check runs,
+// in which case the documented endpoints are tested by testAPIResponses
+func (s *Scanner) apiDocumentationEnabled() bool {
+	if !s.ScanOptions.EnableInfoDisclosure {
+		return false
+	}
+	for _, payload := range s.payloads.GetPayloads(VulnTypeInfoDisclosure) {
+		if payload.Value == "API_DOCUMENTATION" {
+			return true
+		}
+	}
+	return false
+}
+
+// findAPIDocumentation probes the target's origin for specifications,
+// documentation pages, GraphQL IDEs and GraphQL introspection. The
+// documentation is looked up once per scan and shared by the
+// API_DOCUMENTATION check and testAPIResponses.
+func (s *Scanner) findAPIDocumentation(target ScanTarget) []APIDocument {
+	s.apiDocsOnce.Do(func() {
+		targetURL, err := url.Parse(target.URL)
+		if err != nil {
+			return
+		}
+		origin := (&url.URL{Scheme: targetURL.Scheme, Host: targetURL.Host}).String()
+
+		fetch := func(method, link string, headers map[string]string, body string) ([]byte, string, bool) {
+			resp, err := s.sendRequest(target, method, link, headers, body)
+			if err != nil {
+				return nil, "", false
+			}
+			defer resp.Body.Close()
+			data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+			return data, resp.Request.URL.String(), err == nil && resp.StatusCode == 200
+		}
+
+		// Specifications named by documentation pages are fetched too
+		specs := make([]string, 0, len(apiSpecPaths))
+		for _, path := range apiSpecPaths {
+			specs = append(specs, origin+path)
+		}
+		seen := make(map[string]bool)
+		for _, path := range apiDocPaths {
+			if s.ctx.Err() != nil {
+				return
+			}
+			body, final, ok := fetch("GET", origin+path, nil, "")
+			if !ok || seen[final] {
+				continue
+			}
+			seen[final] = true
+			if document := ParseAPIDocPage(final, string(body)); document != nil {
+				s.apiDocs = append(s.apiDocs, *document)
+				if document.SpecURL != "" {
+					specs = append(specs, document.SpecURL)
+				}
+			}
+		}
+
+		seen = make(map[string]bool)
+		for _, spec := range specs {
+			if seen[spec] || s.ctx.Err() != nil {
+				continue
+			}
+			seen[spec] = true
+			body, final, ok := fetch("GET", spec, map[string]string{"Accept": "application/json"}, "")
+			if !ok || (final != spec && seen[final]) {
+				continue
+			}
+			seen[final] = true
+			if document := ParseAPISpec(final, body); document != nil {
+				s.apiDocs = append(s.apiDocs, *document)
+			}
+		}
+
+		for _, path := range graphQLPaths {
+			if s.ctx.Err() != nil {
+				return
+			}
+			body, _, ok := fetch("POST", origin+path, map[string]string{"Content-Type": "application/json"}, graphQLIntrospection)
+			if !ok {
+				continue
+			}
+			if document := ParseGraphQLSchema(origin+path, body); document != nil {
+				s.apiDocs = append(s.apiDocs, *document)
+				break
+			}
+		}
+	})
+	return s.apiDocs
+}
+
+// documentedEndpoints returns the GET endpoints of the API specifications
+// found on the target
+func (s *Scanner) documentedEndpoints(target ScanTarget) []*url.URL {
+	endpoints := make([]*url.URL, 0)
+	for _, document := range s.findAPIDocumentation(target) {
+		for _, endpoint := range document.Endpoints {
+			if link, err := url.Parse(endpoint); err == nil {
+				endpoints = append(endpoints, link)
+			}
+		}
+	}
+	return endpoints
+}
+
+// checkAPIDocumentation reports the API documentation found on the target.
+// Specifications and IDEs map the attack surface, and GraphQL
+// introspection gives away the whole schema.
+func (s *Scanner) checkAPIDocumentation(target ScanTarget, payload Payload) []TestResult {
+	results := make([]TestResult, 0)
+	for _, document := range s.findAPIDocumentation(target) {
+		description := fmt.Sprintf("%s exposed", document.Kind)
+		if document.Title != "" {
+			description += ": " + strings.TrimSpace(document.Title+" "+document.Version)
+		}
+		if len(document.Operations) > 0 {
+			description += fmt.Sprintf(" (%d operations)", len(document.Operations))
+		}
+		severity, method := SeverityLow, "GET"
+		if document.Kind == APIDocIntrospection {
+			severity, method = SeverityMedium, "POST"
+		}
+
+		evidence := make([]string, 0)
+		if document.SpecURL != "" {
+			evidence = append(evidence, "Specification: "+document.SpecURL)
+		}
+		for i, operation := range document.Operations {
+			if i == 50 {
+				evidence = append(evidence, fmt.Sprintf("... and %d more", len(document.Operations)-50))
+				break
+			}
+			evidence = append(evidence, operation)
+		}
+
+		results = append(results, TestResult{
+			Payload:     payload,
+			URL:         document.URL,
+			Method:      method,
+			Description: description,
+			Severity:    severity,
+			Evidence:    strings.Join(evidence, "\n"),
+		})
+	}
+	return results
+}
@@ -0,0 +1,304 @@
+// pkg/tools/webvuln/apischema.go
+package webvuln
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// APISchema is the structure of the JSON responses of an API endpoint
+type APISchema struct {
+	Endpoint  string            // Method and path, numeric and UUID segments replaced by {id}
+	URLs      []string          // URLs answered with JSON
+	Fields    map[string]string // Field path, such as users[].email, to its JSON type
+	Sensitive []string          // Field paths leaking sensitive data
+}
+
+// sensitivePattern recognizes sensitive data in the string fields of API
+// responses, by field name or by value
+type sensitivePattern struct {
+	Kind     string
+	Severity Severity
+	Key      *regexp.Regexp // Field names holding the data, nil to match values only
+	Value    *regexp.Regexp
+}
+
+// sensitivePatterns are checked in order, the first match naming the leak.
+// Any value of a password field is a leak, hashed or not, while elsewhere
+// only the crypt formats are told apart from other strings.
+var sensitivePatterns = []sensitivePattern{
+	{
+		Kind:     "password hash",
+		Severity: SeverityHigh,
+		Key:      regexp.MustCompile(`(?i)^(password|passwd|pwd)([_-]?(hash|digest))?$|^hashed[_-]?password$`),
+		Value:    regexp.MustCompile(`^(\$2[abxy]?\$\d\d\$[./A-Za-z0-9]{53}|\$argon2(id|i|d)\$\S+|\$(1|5|6)\$[^$]+\$\S+|pbkdf2_sha\d+\$\S+)$`),
+	},
+	{
+		Kind:     "stack trace",
+		Severity: SeverityMedium,
+		Value: regexp.MustCompile(`Traceback \(most recent call last\)|\bat [\w$.]+\([\w$]+\.java:\d+\)|\bat [\w.<>]+\(.*\) in .+:line \d+|` +
+			`goroutine \d+ \[|\.go:\d+ \+0x|\bat .+ \(/.+\.js:\d+:\d+\)|#\d+ /.+\.php\(\d+\)`),
+	},
+	{
+		Kind:     "internal IP address",
+		Severity: SeverityMedium,
+		Value:    regexp.MustCompile(`\b(10(\.\d{1,3}){3}|172\.(1[6-9]|2\d|3[01])(\.\d{1,3}){2}|192\.168(\.\d{1,3}){2})\b`),
+	},
+	{
+		Kind:     "social security number",
+		Severity: SeverityHigh,
+		Value:    regexp.MustCompile(`^\d{3}-\d{2}-\d{4}$`),
+	},
+	{
+		Kind:     "email address",
+		Severity: SeverityLow,
+		Value:    regexp.MustCompile(`^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$`),
+	},
+}
+
+// apiPathPattern finds API paths quoted in pages and scripts, such as
+// fetch("/api/users")
+var apiPathPattern = regexp.MustCompile(`["'](/(?:api|rest|v\d+)/[^"'\s?#<>]*)`)
+
+// idSegmentPattern matches path segments identifying a resource
+var idSegmentPattern = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{24,})$`)
+
+// EndpointKey returns the endpoint a URL belongs to: /api/users/42 and
+// /api/users/7 are both GET /api/users/{id}
+func EndpointKey(method string, link *url.URL) string {
+	segments := strings.Split(link.Path, "/")
+	for i, segment := range segments {
+		if idSegmentPattern.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return method + " " + strings.Join(segments, "/")
+}
+
+// Redact keeps the first characters of a sample of sensitive data, enough
+// to recognize it in the response, and masks the rest
+func Redact(value string) string {
+	runes := []rune(value)
+	keep := len(runes) / 4
+	if keep > 4 {
+		keep = 4
+	}
+	masked := len(runes) - keep
+	if masked > 12 {
+		masked = 12
+	}
+	return string(runes[:keep]) + strings.Repeat("*", masked)
+}
+
+// apiField is a string field of a JSON response
+type apiField struct {
+	Path  string
+	Key   string
+	Value string
+}
+
+// walkJSON records the type of every field of a JSON value under prefix
+// and returns its string fields
+func walkJSON(prefix, key string, v interface{}, fields map[string]string) []apiField {
+	strs := []apiField{}
+	switch value := v.(type) {
+	case map[string]interface{}:
+		if prefix != "" {
+			fields[prefix] = "object"
+		}
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			strs = append(strs, walkJSON(path, k, value[k], fields)...)
+		}
+	case []interface{}:
+		if prefix != "" {
+			fields[prefix] = "array"
+		}
+		for _, item := range value {
+			strs = append(strs, walkJSON(prefix+"[]", key, item, fields)...)
+		}
+	case string:
+		fields[prefix] = "string"
+		strs = append(strs, apiField{Path: prefix, Key: key, Value: value})
+	case float64:
+		fields[prefix] = "number"
+	case bool:
+		fields[prefix] = "boolean"
+	case nil:
+		if _, known := fields[prefix]; !known {
+			fields[prefix] = "null"
+		}
+	}
+	return strs
+}
+
+// classifyField returns the sensitive pattern a string field matches
+func classifyField(field apiField, internalHost bool) (sensitivePattern, bool) {
+	if strings.TrimSpace(field.Value) == "" {
+		return sensitivePattern{}, false
+	}
+	for _, pattern := range sensitivePatterns {
+		// Internal addresses are expected from a target on an internal network
+		if pattern.Kind == "internal IP address" && internalHost {
+			continue
+		}
+		if pattern.Key != nil && pattern.Key.MatchString(field.Key) {
+			return pattern, true
+		}
+		if pattern.Value.MatchString(field.Value) {
+			return pattern, true
+		}
+	}
+	return sensitivePattern{}, false
+}
+
+// isInternalHost reports whether a host is a private or loopback address
+func isInternalHost(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsPrivate() || ip.IsLoopback())
+}
+
+// apiEndpoints returns the JSON responses met by the crawler, the GET
+// operations of the API specifications found by the API_DOCUMENTATION
+// check and the same-origin API paths quoted in the crawled pages
+func (s *Scanner) apiEndpoints(target ScanTarget) []*url.URL {
+	start, err := url.Parse(target.URL)
+	if err != nil {
+		return nil
+	}
+
+	endpoints := []*url.URL{}
+	seen := make(map[string]bool)
+	add := func(link *url.URL) {
+		if link != nil && sameOrigin(link, start) && !seen[link.String()] {
+			seen[link.String()] = true
+			endpoints = append(endpoints, link)
+		}
+	}
+
+	pages := s.crawl(target)
+	for _, response := range s.jsonPages {
+		add(response.URL)
+	}
+	if s.apiDocumentationEnabled() {
+		for _, link := range s.documentedEndpoints(target) {
+			add(link)
+		}
+	}
+	for _, page := range pages {
+		for _, match := range apiPathPattern.FindAllStringSubmatch(page.Body, -1) {
+			add(resolveLink(page.URL, match[1]))
+		}
+	}
+
+	maxEndpoints := s.ScanOptions.MaxCrawlPages
+	if maxEndpoints < 1 {
+		maxEndpoints = 1
+	}
+	if len(endpoints) > maxEndpoints {
+		endpoints = endpoints[:maxEndpoints]
+	}
+	return endpoints
+}
+
+// testAPIResponses records the structure of the JSON responses of the API
+// endpoints found while crawling and reports the fields leaking password
+// hashes, stack traces, internal addresses or personal data, once per
+// endpoint and field
+func (s *Scanner) testAPIResponses(target ScanTarget) {
+	result := ScanResult{
+		VulnerabilityType: VulnTypeInfoDisclosure,
+		TestResults:       make([]TestResult, 0),
+	}
+
+	schemas := make(map[string]*APISchema)
+	order := []string{}
+	reported := make(map[string]bool)
+	for _, link := range s.apiEndpoints(target) {
+		if s.ctx.Err() != nil {
+			break
+		}
+
+		body, ok := s.fetchJSON(target, link)
+		if !ok {
+			continue
+		}
+		var document interface{}
+		if err := json.Unmarshal(body, &document); err != nil {
+			continue
+		}
+
+		key := EndpointKey("GET", link)
+		schema, exists := schemas[key]
+		if !exists {
+			schema = &APISchema{Endpoint: key, Fields: make(map[string]string)}
+			schemas[key] = schema
+			order = append(order, key)
+		}
+		schema.URLs = append(schema.URLs, link.String())
+
+		internal := isInternalHost(link.Hostname())
+		for _, field := range walkJSON("", "", document, schema.Fields) {
+			pattern, sensitive := classifyField(field, internal)
+			if !sensitive || reported[key+" "+field.Path] {
+				continue
+			}
+			reported[key+" "+field.Path] = true
+			schema.Sensitive = append(schema.Sensitive, field.Path)
+
+			result.TestResults = append(result.TestResults, TestResult{
+				URL:       link.String(),
+				Method:    "GET",
+				Parameter: field.Path,
+				Description: fmt.Sprintf("API response of %s exposes a %s in field %s (sample: %s)",
+					key, pattern.Kind, field.Path, Redact(field.Value)),
+				Severity: pattern.Severity,
+			})
+		}
+	}
+
+	s.mutex.Lock()
+	for _, key := range order {
+		s.apiSchemas = append(s.apiSchemas, *schemas[key])
+	}
+	s.mutex.Unlock()
+
+	if len(result.TestResults) > 0 {
+		s.addResult(result)
+	}
+}
+
+// fetchJSON returns the body of a URL if it answers with JSON, reusing
+// the responses fetched by the crawler
+func (s *Scanner) fetchJSON(target ScanTarget, link *url.URL) ([]byte, bool) {
+	for _, response := range s.jsonPages {
+		if response.URL.String() == link.String() {
+			return []byte(response.Body), true
+		}
+	}
+
+	resp, err := s.sendRequest(target, "GET", link.String(), map[string]string{"Accept": "application/json"}, "")
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+	if !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 5<<20))
+	return body, err == nil
+}
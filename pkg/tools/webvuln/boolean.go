@@ -0,0 +1,127 @@
+// pkg/tools/webvuln/boolean.go
+package webvuln
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// booleanContexts place a condition (%s) after the original parameter
+// value, covering string and numeric parameters. With a true condition the
+// page must stay unchanged while a false one changes it.
+var booleanContexts = []string{
+	"' AND %s AND '1'='1",
+	" AND %s",
+	"' AND %s-- -",
+	") AND (%s",
+}
+
+// volatilePatterns match page content that changes between requests, like
+// dates, times, timestamps and nonces, and what to replace it with
+var volatilePatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}([T ]\d{2}:\d{2}(:\d{2})?(\.\d+)?(Z|[+-]\d{2}:?\d{2})?)?`), ""},
+	{regexp.MustCompile(`(Mon|Tue|Wed|Thu|Fri|Sat|Sun), \d{1,2} \w{3} \d{4} \d{2}:\d{2}:\d{2} \w+`), ""},
+	{regexp.MustCompile(`\b\d{1,2}:\d{2}(:\d{2})?\b`), ""},
+	{regexp.MustCompile(`\b1\d{9}(\d{3})?\b`), ""},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), ""},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{16,}\b`), ""},
+	{regexp.MustCompile(`(?i)(nonce|token|csrf[\w-]*)(["']?\s*[:=]\s*["']?)[\w+/=-]{8,}`), "$1$2"},
+}
+
+// normalizeResponse strips volatile content from a page and replaces
+// reflections of the sent value with the original value, so that two
+// responses only differ if the application behaved differently
+func normalizeResponse(statusCode int, body, sent, original string) string {
+	if sent != original {
+		for _, encode := range []func(string) string{html.EscapeString, url.QueryEscape, url.PathEscape} {
+			body = strings.ReplaceAll(body, encode(sent), encode(original))
+		}
+		body = strings.ReplaceAll(body, sent, original)
+	}
+
+	for _, volatile := range volatilePatterns {
+		body = volatile.pattern.ReplaceAllString(body, volatile.replacement)
+	}
+	return fmt.Sprintf("%d\n%s", statusCode, body)
+}
+
+// booleanResponse sends a value in a parameter and returns its normalized
+// response
+func (s *Scanner) booleanResponse(target ScanTarget, targetURL *url.URL, params url.Values, paramName, value string) (string, error) {
+	attempt, err := s.sendEncoded(target, targetURL, params, paramName, Payload{Value: value}, nil)
+	if err != nil {
+		return "", err
+	}
+	return normalizeResponse(attempt.StatusCode, attempt.Body, value, params.Get(paramName)), nil
+}
+
+// testBooleanBased tests a parameter for boolean-based blind SQL injection.
+// A context is reported if a true condition keeps the original page and a
+// false one changes it, twice in a row. Pages that change between two
+// unmodified requests even after normalization are skipped. It returns the
+// context that worked.
+func (s *Scanner) testBooleanBased(target ScanTarget, targetURL *url.URL, params url.Values, paramName string) (TestResult, string, bool) {
+	original := params.Get(paramName)
+
+	baseline, err := s.booleanResponse(target, targetURL, params, paramName, original)
+	if err != nil {
+		return TestResult{}, "", false
+	}
+	if again, err := s.booleanResponse(target, targetURL, params, paramName, original); err != nil || again != baseline {
+		if s.ScanOptions.VerboseMode {
+			fmt.Printf("[i] Page changes between requests, skipping boolean-based tests for '%s'\n", paramName)
+		}
+		return TestResult{}, "", false
+	}
+
+	for _, context := range booleanContexts {
+		trueValue, falseValue := original+fmt.Sprintf(context, "1=1"), original+fmt.Sprintf(context, "1=2")
+
+		// Both rounds must agree, so a page that merely flickers isn't reported
+		first, ok := s.booleanRound(target, targetURL, params, paramName, trueValue, falseValue, baseline)
+		if !ok {
+			continue
+		}
+		second, ok := s.booleanRound(target, targetURL, params, paramName, trueValue, falseValue, baseline)
+		if !ok || second != first {
+			continue
+		}
+
+		return TestResult{
+			Payload: Payload{
+				Value:       falseValue,
+				Type:        VulnTypeSQLInjection,
+				Description: "Boolean-based blind injection",
+				Level:       2,
+			},
+			URL:         payloadURL(targetURL, params, paramName, falseValue),
+			Method:      "GET",
+			Parameter:   paramName,
+			Description: fmt.Sprintf("Boolean-based Blind SQL Injection: '%s' keeps the original page while '%s' changes it", strings.TrimSpace(trueValue[len(original):]), strings.TrimSpace(falseValue[len(original):])),
+			Severity:    SeverityCritical,
+		}, context, true
+	}
+
+	return TestResult{}, "", false
+}
+
+// booleanRound sends the true and false condition of a context and returns the
+// false response if the true one matches the baseline and the false one
+// doesn't
+func (s *Scanner) booleanRound(target ScanTarget, targetURL *url.URL, params url.Values, paramName, trueValue, falseValue, baseline string) (string, bool) {
+	trueResponse, err := s.booleanResponse(target, targetURL, params, paramName, trueValue)
+	if err != nil || trueResponse != baseline {
+		return "", false
+	}
+	falseResponse, err := s.booleanResponse(target, targetURL, params, paramName, falseValue)
+	if err != nil || falseResponse == baseline {
+		return "", false
+	}
+	return falseResponse, true
+}
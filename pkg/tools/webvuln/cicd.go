@@ -0,0 +1,225 @@
+// pkg/tools/webvuln/cicd.go
+package webvuln
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// DetectedProduct is a product identified on the target, saved with the
+// report for the CVE correlator
+type DetectedProduct struct {
+	Name    string
+	Version string // "" if unknown
+	CPE     string // CPE 2.3, "*" standing for an unknown version
+	URL     string // Where the product was identified
+}
+
+// cicdAPI is an API endpoint of a CI/CD product that should require
+// authentication
+type cicdAPI struct {
+	Path     string
+	Marker   *regexp.Regexp // Matches the body of an answer with data
+	Version  *regexp.Regexp // First group is the product version, if the endpoint reveals it
+	Severity Severity
+	Issue    string
+}
+
+// cicdProduct is a CI/CD or version control console and its APIs
+type cicdProduct struct {
+	Name          string
+	CPE           string   // {version} stands for the version
+	Mounts        []string // Path prefixes the product is commonly served under
+	Console       string   // Login page
+	Marker        *regexp.Regexp
+	MarkerHeader  string // Header identifying the product, besides Marker
+	VersionHeader string
+	Version       *regexp.Regexp // First group is the version, in the console page
+	APIs          []cicdAPI
+}
+
+// cicdProducts are the consoles probed by the CICD_CONSOLES check
+var cicdProducts = []cicdProduct{
+	{
+		Name:          "Jenkins",
+		CPE:           "cpe:2.3:a:jenkins:jenkins:{version}:*:*:*:*:*:*:*",
+		Mounts:        []string{"", "/jenkins"},
+		Console:       "/login",
+		Marker:        regexp.MustCompile(`(?i)<title>[^<]*Jenkins|id="jenkins"`),
+		MarkerHeader:  "X-Jenkins",
+		VersionHeader: "X-Jenkins",
+		APIs: []cicdAPI{
+			{"/script", regexp.MustCompile(`(?i)Script Console`), nil, SeverityCritical, "script console runs Groovy code on the controller"},
+			{"/api/json", regexp.MustCompile(`"jobs"\s*:`), nil, SeverityHigh, "API lists the jobs"},
+			{"/computer/api/json", regexp.MustCompile(`"computer"\s*:`), nil, SeverityMedium, "API lists the build agents"},
+			{"/asynchPeople/api/json", regexp.MustCompile(`"users"\s*:`), nil, SeverityMedium, "API lists the users"},
+		},
+	},
+	{
+		Name:    "GitLab",
+		CPE:     "cpe:2.3:a:gitlab:gitlab:{version}:*:*:*:*:*:*:*",
+		Mounts:  []string{""},
+		Console: "/users/sign_in",
+		Marker:  regexp.MustCompile(`(?i)content="GitLab"|gon\.gitlab_url|GitLab (?:Community|Enterprise) Edition`),
+		APIs: []cicdAPI{
+			{"/api/v4/version", regexp.MustCompile(`"revision"\s*:`), regexp.MustCompile(`"version"\s*:\s*"(\d+(?:\.\d+)+)`), SeverityMedium, "version API answers"},
+			{"/api/v4/users?per_page=20", regexp.MustCompile(`"username"\s*:`), nil, SeverityMedium, "API lists the users"},
+			{"/api/v4/projects?per_page=20", regexp.MustCompile(`"path_with_namespace"\s*:`), nil, SeverityLow, "API lists the public projects"},
+			{"/users/sign_up", regexp.MustCompile(`id="new_new_user"`), nil, SeverityLow, "registration is open"},
+		},
+	},
+	{
+		Name:         "TeamCity",
+		CPE:          "cpe:2.3:a:jetbrains:teamcity:{version}:*:*:*:*:*:*:*",
+		Mounts:       []string{"", "/teamcity"},
+		Console:      "/login.html",
+		Marker:       regexp.MustCompile(`(?i)<title>[^<]*TeamCity`),
+		MarkerHeader: "TeamCity-Node-Id",
+		Version:      regexp.MustCompile(`(?i)TeamCity(?: Professional| Enterprise)?\s+(\d{4}\.\d+(?:\.\d+)?)`),
+		APIs: []cicdAPI{
+			{"/app/rest/users", regexp.MustCompile(`<users\b|"user"\s*:`), nil, SeverityHigh, "REST API lists the users"},
+			{"/guestAuth/app/rest/projects", regexp.MustCompile(`<projects\b|"project"\s*:`), nil, SeverityHigh, "guest API lists the projects"},
+			{"/app/rest/server", regexp.MustCompile(`<server\b|"buildNumber"\s*:`), regexp.MustCompile(`version(?:"\s*:\s*|=)"(\d{4}\.\d+(?:\.\d+)?)`), SeverityMedium, "REST API answers"},
+		},
+	},
+	{
+		Name:    "Drone",
+		CPE:     "cpe:2.3:a:drone:drone:{version}:*:*:*:*:*:*:*",
+		Mounts:  []string{""},
+		Console: "/login",
+		Marker:  regexp.MustCompile(`(?i)<title>\s*Drone\s*</title>`),
+		APIs: []cicdAPI{
+			{"/metrics", regexp.MustCompile(`(?m)^drone_\w+`), nil, SeverityMedium, "metrics are readable"},
+			{"/version", regexp.MustCompile(`github\.com/(?:drone|harness)/drone`), regexp.MustCompile(`"version"\s*:\s*"v?(\d+(?:\.\d+)+)`), SeverityLow, "version disclosed"},
+		},
+	},
+	{
+		Name:    "Argo CD",
+		CPE:     "cpe:2.3:a:linuxfoundation:argo-cd:{version}:*:*:*:*:*:*:*",
+		Mounts:  []string{""},
+		Console: "/login",
+		Marker:  regexp.MustCompile(`(?i)<title>\s*Argo CD\s*</title>`),
+		APIs: []cicdAPI{
+			{"/api/v1/applications", regexp.MustCompile(`"items"\s*:\s*\[\s*\{`), nil, SeverityHigh, "API lists the applications"},
+			{"/api/v1/clusters", regexp.MustCompile(`"items"\s*:\s*\[\s*\{`), nil, SeverityHigh, "API lists the clusters"},
+			{"/api/version", regexp.MustCompile(`"Version"\s*:\s*"v`), regexp.MustCompile(`"Version"\s*:\s*"v?(\d+(?:\.\d+)+)`), SeverityLow, "version disclosed"},
+		},
+	},
+}
+
+// cicdCPE returns the CPE of a product version
+func cicdCPE(template, version string) string {
+	if version == "" {
+		version = "*"
+	}
+	return strings.ReplaceAll(template, "{version}", version)
+}
+
+// checkCICDConsoles probes the target's origin for CI/CD and version
+// control consoles. A console is reported with its version, and each of its
+// APIs answering without authentication is reported by what it exposes.
+// The products identified are saved with the report for the CVE correlator.
+func (s *Scanner) checkCICDConsoles(target ScanTarget, payload Payload) []TestResult {
+	targetURL, err := url.Parse(target.URL)
+	if err != nil {
+		return nil
+	}
+	origin := (&url.URL{Scheme: targetURL.Scheme, Host: targetURL.Host}).String()
+
+	fetch := func(link string) (*http.Response, string, bool) {
+		resp, err := s.sendRequest(target, "GET", link, nil, "")
+		if err != nil {
+			return nil, "", false
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+		resp.Body.Close()
+		return resp, string(body), resp.StatusCode == 200
+	}
+
+	results := make([]TestResult, 0)
+	for _, product := range cicdProducts {
+		for _, mount := range product.Mounts {
+			if s.ctx.Err() != nil {
+				return results
+			}
+			base := origin + mount
+
+			// The console identifies the product; a version header on any
+			// response does too
+			found, version := false, ""
+			resp, body, ok := fetch(base + product.Console)
+			if resp != nil {
+				if product.MarkerHeader != "" && resp.Header.Get(product.MarkerHeader) != "" {
+					found = true
+				}
+				if ok && product.Marker.MatchString(body) {
+					found = true
+				}
+				if product.VersionHeader != "" {
+					version = resp.Header.Get(product.VersionHeader)
+				}
+				if product.Version != nil {
+					if match := product.Version.FindStringSubmatch(body); match != nil {
+						version = match[1]
+					}
+				}
+			}
+
+			exposed := make([]TestResult, 0)
+			for _, api := range product.APIs {
+				resp, body, ok := fetch(base + api.Path)
+				if !ok || !api.Marker.MatchString(body) {
+					continue
+				}
+				found = true
+				if api.Version != nil {
+					if match := api.Version.FindStringSubmatch(body); match != nil && version == "" {
+						version = match[1]
+					}
+				}
+				if product.VersionHeader != "" && version == "" {
+					version = resp.Header.Get(product.VersionHeader)
+				}
+				exposed = append(exposed, TestResult{
+					Payload:     payload,
+					URL:         base + api.Path,
+					Method:      "GET",
+					Description: fmt.Sprintf("%s %s reachable without authentication: %s", product.Name, api.Path, api.Issue),
+					Severity:    api.Severity,
+					Body:        body,
+				})
+			}
+			if !found {
+				continue
+			}
+
+			description := fmt.Sprintf("%s console exposed at %s", product.Name, base+product.Console)
+			if version != "" {
+				description += fmt.Sprintf(" (version %s)", version)
+			}
+			results = append(results, TestResult{
+				Payload:     payload,
+				URL:         base + product.Console,
+				Method:      "GET",
+				Description: description,
+				Severity:    SeverityLow,
+			})
+			results = append(results, exposed...)
+
+			s.mutex.Lock()
+			s.products = append(s.products, DetectedProduct{
+				Name:    product.Name,
+				Version: version,
+				CPE:     cicdCPE(product.CPE, version),
+				URL:     base + product.Console,
+			})
+			s.mutex.Unlock()
+			break
+		}
+	}
+	return results
+}
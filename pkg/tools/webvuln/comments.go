@@ -0,0 +1,253 @@
+// pkg/tools/webvuln/comments.go
+package webvuln
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxSourceMapScripts caps the scripts checked for source maps
+const maxSourceMapScripts = 20
+
+// Kinds of leaks found in HTML comments
+const (
+	LeakCredential       = "credential"
+	LeakConnectionString = "connection string"
+	LeakInternalHost     = "internal host"
+	LeakTodo             = "security note"
+)
+
+// CommentLeak is sensitive information in an HTML comment
+type CommentLeak struct {
+	Kind     string
+	Match    string
+	Severity Severity
+}
+
+// commentPattern recognizes a leak in a comment
+type commentPattern struct {
+	Kind     string
+	Severity Severity
+	Pattern  *regexp.Regexp
+}
+
+// commentPatterns are checked in order against each comment
+var commentPatterns = []commentPattern{
+	{LeakCredential, SeverityHigh, regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{LeakCredential, SeverityHigh, regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b|\bgh[pousr]_[A-Za-z0-9]{36}\b|\bxox[abprs]-[A-Za-z0-9-]{10,}|\bsk_live_[0-9a-zA-Z]{24,}|\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{LeakCredential, SeverityHigh, regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|secret|api[_-]?key|access[_-]?token|auth[_-]?token|client[_-]?secret)\b\s*[:=]\s*["']?[^\s"'<>]{4,}`)},
+	{LeakConnectionString, SeverityHigh, regexp.MustCompile(`\b(?:mysql|postgres(?:ql)?|mongodb(?:\+srv)?|redis|amqp|mssql|jdbc:[a-z]+)://[^\s"'<>]+`)},
+	{LeakTodo, SeverityMedium, regexp.MustCompile(`(?i)\b(?:TODO|FIXME|HACK|XXX)\b[^\n]*\b(?:password|credential|secret|token|api key|auth|admin|bypass|hardcoded|insecure|vulnerab)\w*`)},
+	{LeakInternalHost, SeverityLow, regexp.MustCompile(`(?i)\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:internal|local|lan|corp|intranet|localdomain)\b`)},
+	{LeakInternalHost, SeverityLow, regexp.MustCompile(`\b(?:10(?:\.\d{1,3}){3}|172\.(?:1[6-9]|2\d|3[01])(?:\.\d{1,3}){2}|192\.168(?:\.\d{1,3}){2})\b`)},
+}
+
+var (
+	htmlCommentRegex   = regexp.MustCompile(`(?s)<!--(.*?)-->`)
+	sourceMappingRegex = regexp.MustCompile(`[#@]\s*sourceMappingURL=([^\s'"*]+)`)
+)
+
+// HTMLComments returns the comments of a page, without the conditional
+// comments of old Internet Explorer versions
+func HTMLComments(body string) []string {
+	comments := make([]string, 0)
+	for _, match := range htmlCommentRegex.FindAllStringSubmatch(body, -1) {
+		comment := strings.TrimSpace(match[1])
+		if comment == "" || strings.HasPrefix(comment, "[if ") || strings.HasPrefix(comment, "<![endif]") {
+			continue
+		}
+		comments = append(comments, comment)
+	}
+	return comments
+}
+
+// AnalyzeComment returns the leaks of a comment, once per kind
+func AnalyzeComment(comment string) []CommentLeak {
+	leaks := make([]CommentLeak, 0)
+	kinds := make(map[string]bool)
+	for _, pattern := range commentPatterns {
+		if kinds[pattern.Kind] {
+			continue
+		}
+		if match := pattern.Pattern.FindString(comment); match != "" {
+			kinds[pattern.Kind] = true
+			leaks = append(leaks, CommentLeak{Kind: pattern.Kind, Match: match, Severity: pattern.Severity})
+		}
+	}
+	return leaks
+}
+
+// ParseSourceMap returns the source files listed by a source map, or ok
+// false if the body is not one
+func ParseSourceMap(body []byte) (sources []string, ok bool) {
+	var sourceMap struct {
+		Version  int      `json:"version"`
+		Sources  []string `json:"sources"`
+		Mappings *string  `json:"mappings"`
+	}
+	if err := json.Unmarshal(body, &sourceMap); err != nil || sourceMap.Mappings == nil {
+		return nil, false
+	}
+	return sourceMap.Sources, true
+}
+
+// truncate shortens text to n bytes, marking the cut
+func truncate(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	return text[:n] + "..."
+}
+
+// checkComments looks at the crawled pages for leaks in HTML comments,
+// generator meta tags naming the software and version, and scripts whose
+// source maps are served, which give away the original source code
+func (s *Scanner) checkComments(target ScanTarget, payload Payload) []TestResult {
+	start, err := url.Parse(target.URL)
+	if err != nil {
+		return nil
+	}
+
+	results := make([]TestResult, 0)
+	reported := make(map[string]bool)
+	scripts := make([]*url.URL, 0)
+	for _, page := range s.crawl(target) {
+		// Comments of shared templates are reported on the first page only
+		for _, comment := range HTMLComments(page.Body) {
+			for _, leak := range AnalyzeComment(comment) {
+				if reported[leak.Kind+leak.Match] {
+					continue
+				}
+				reported[leak.Kind+leak.Match] = true
+				results = append(results, TestResult{
+					Payload:     payload,
+					URL:         page.URL.String(),
+					Method:      "GET",
+					Description: fmt.Sprintf("HTML comment with %s: %s", leak.Kind, truncate(leak.Match, 80)),
+					Severity:    leak.Severity,
+					Evidence:    "<!-- " + truncate(comment, 500) + " -->",
+					Body:        page.Body,
+				})
+			}
+		}
+
+		for _, tag := range parseTags(page.Body) {
+			switch {
+			case tag.Name == "meta" && strings.EqualFold(tag.Attrs["name"], "generator") && tag.Attrs["content"] != "":
+				generator := tag.Attrs["content"]
+				if reported["generator"+generator] {
+					continue
+				}
+				reported["generator"+generator] = true
+				severity := SeverityInfo
+				if strings.ContainsAny(generator, "0123456789") {
+					severity = SeverityLow // The version narrows down the known vulnerabilities
+				}
+				results = append(results, TestResult{
+					Payload:     payload,
+					URL:         page.URL.String(),
+					Method:      "GET",
+					Description: fmt.Sprintf("Generator meta tag discloses %s", generator),
+					Severity:    severity,
+				})
+			case tag.Name == "script" && tag.Attrs["src"] != "":
+				if script := resolveLink(page.URL, tag.Attrs["src"]); script != nil && sameOrigin(script, start) &&
+					!reported["script"+script.String()] && len(scripts) < maxSourceMapScripts {
+					reported["script"+script.String()] = true
+					scripts = append(scripts, script)
+				}
+			}
+		}
+
+		// Inline scripts may reference their map too
+		if match := sourceMappingRegex.FindStringSubmatch(page.Body); match != nil {
+			if mapURL := resolveLink(page.URL, match[1]); mapURL != nil && sameOrigin(mapURL, start) {
+				results = append(results, s.checkSourceMap(target, payload, page.URL, mapURL, reported)...)
+			}
+		}
+	}
+
+	for _, script := range scripts {
+		results = append(results, s.checkScriptSourceMap(target, payload, script, reported)...)
+	}
+	return results
+}
+
+// checkScriptSourceMap finds the source map of a script from its
+// SourceMap header or sourceMappingURL comment, else tries the script URL
+// with .map appended
+func (s *Scanner) checkScriptSourceMap(target ScanTarget, payload Payload, script *url.URL, reported map[string]bool) []TestResult {
+	resp, err := s.sendRequest(target, "GET", script.String(), nil, "")
+	if err != nil {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 5*1024*1024))
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil
+	}
+
+	reference := resp.Header.Get("SourceMap")
+	if reference == "" {
+		reference = resp.Header.Get("X-SourceMap")
+	}
+	if reference == "" {
+		if match := sourceMappingRegex.FindSubmatch(body); match != nil {
+			reference = string(match[1])
+		}
+	}
+	if strings.HasPrefix(reference, "data:") {
+		return nil // Inlined maps ship with the script on purpose
+	}
+	if reference == "" {
+		reference = script.Path + ".map"
+	}
+	mapURL := resolveLink(script, reference)
+	if mapURL == nil || !sameOrigin(mapURL, script) {
+		return nil
+	}
+	return s.checkSourceMap(target, payload, script, mapURL, reported)
+}
+
+// checkSourceMap reports a source map that is served, listing its sources
+func (s *Scanner) checkSourceMap(target ScanTarget, payload Payload, from, mapURL *url.URL, reported map[string]bool) []TestResult {
+	if reported["map"+mapURL.String()] {
+		return nil
+	}
+	reported["map"+mapURL.String()] = true
+
+	resp, err := s.sendRequest(target, "GET", mapURL.String(), nil, "")
+	if err != nil {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 20*1024*1024))
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil
+	}
+	sources, ok := ParseSourceMap(body)
+	if !ok {
+		return nil
+	}
+
+	evidence := fmt.Sprintf("Source map of %s lists %d source files", from, len(sources))
+	for i, source := range sources {
+		if i == 20 {
+			evidence += fmt.Sprintf("\n... and %d more", len(sources)-20)
+			break
+		}
+		evidence += "\n" + source
+	}
+	return []TestResult{{
+		Payload:     payload,
+		URL:         mapURL.String(),
+		Method:      "GET",
+		Description: fmt.Sprintf("Source map exposed: original source of %d files", len(sources)),
+		Severity:    SeverityMedium,
+		Evidence:    evidence,
+	}}
+}
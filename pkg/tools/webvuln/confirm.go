@@ -0,0 +1,176 @@
+package webvuln
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/browser"
+	"GopherStrike/pkg/egress"
+	"GopherStrike/pkg/signing"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// confirmSettle is how long a loaded page is left to run its timers and
+// event handlers before its screenshot is taken
+const confirmSettle = time.Second
+
+// xssConfirmer loads the URLs of reflected XSS results in a headless
+// browser, started with the first of them. A payload opening a dialog or
+// logging to the console ran: its result is marked confirmed, with a
+// screenshot and the DOM around the payload as evidence. The others are left
+// as potential findings.
+type xssConfirmer struct {
+	scanner *Scanner
+	target  ScanTarget
+	headers map[string]string // The target's headers, cookies and credentials
+	browser *browser.Browser
+	failed  bool // The browser could not be started
+	count   int  // Results confirmed, numbering the screenshots
+}
+
+// newXSSConfirmer creates a confirmer for the results of a target
+func (s *Scanner) newXSSConfirmer(target ScanTarget) *xssConfirmer {
+	headers := map[string]string{}
+	for key, value := range target.Headers {
+		headers[key] = value
+	}
+	if len(target.Cookies) > 0 {
+		headers["Cookie"] = strings.Join(target.Cookies, "; ")
+	}
+	if target.BasicAuth.Username != "" {
+		credentials := target.BasicAuth.Username + ":" + target.BasicAuth.Password
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+	return &xssConfirmer{scanner: s, target: target, headers: headers}
+}
+
+// confirm loads the URL of a result and records whether its payload ran
+func (c *xssConfirmer) confirm(result *TestResult) {
+	s := c.scanner
+	if result.Method != "GET" || c.failed || s.ctx.Err() != nil {
+		return
+	}
+	if c.browser == nil {
+		// The browser follows the egress routes of the scan's requests
+		b, err := browser.Launch(s.ctx, s.ScanOptions.BrowserPath, egress.DialContext("webvuln", nil))
+		if err != nil {
+			fmt.Printf("[!] XSS confirmation skipped: %v\n", err)
+			c.failed = true
+			return
+		}
+		c.browser = b
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, time.Duration(s.ScanOptions.Timeout)*time.Second+confirmSettle)
+	page, err := c.browser.Load(ctx, result.URL, c.loadHeaders(result), confirmSettle)
+	cancel()
+	if err != nil {
+		result.Evidence = appendEvidence(result.Evidence, fmt.Sprintf("Not confirmed: the browser failed to load the page: %v", err))
+		return
+	}
+
+	triggers := append([]string{}, page.Dialogs...)
+	for _, message := range page.Console {
+		if strings.Contains(message, "XSS") {
+			triggers = append(triggers, "console."+message)
+		}
+	}
+	if len(triggers) == 0 {
+		result.Evidence = appendEvidence(result.Evidence, "Not confirmed: the payload did not run in a headless browser")
+		result.Confidence = ConfidenceTentative
+		return
+	}
+
+	c.count++
+	result.Confidence = ConfidenceCertain
+	result.Description = strings.Replace(result.Description, "Potential XSS", "Confirmed XSS", 1)
+	evidence := "Browser: " + strings.Join(triggers, ", ")
+	if snippet := domSnippet(page.DOM, result.Match, "alert(", "XSS"); snippet != "" {
+		evidence += "\nDOM: " + snippet
+	}
+	if path, err := s.saveScreenshot(c.target, result.Parameter, c.count, page.Screenshot); err == nil {
+		result.Screenshot = path
+		evidence += "\nScreenshot: " + path
+	} else {
+		fmt.Printf("[!] Failed to save the XSS screenshot: %v\n", err)
+	}
+	result.Evidence = appendEvidence(result.Evidence, evidence)
+}
+
+// loadHeaders returns the headers the page of a result is loaded with: the
+// target's, and the header or cookie carrying the payload
+func (c *xssConfirmer) loadHeaders(result *TestResult) map[string]string {
+	if len(result.Headers) == 0 {
+		return c.headers
+	}
+	headers := map[string]string{}
+	for name, value := range c.headers {
+		headers[name] = value
+	}
+	for name, value := range result.Headers {
+		if name == "Cookie" && headers[name] != "" {
+			value += "; " + headers[name]
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+// close stops the browser if it was started
+func (c *xssConfirmer) close() {
+	if c.browser != nil {
+		c.browser.Close()
+	}
+}
+
+// saveScreenshot stores the screenshot of a confirmed payload in the
+// ScreenshotDirectory, or with the target's web artifacts
+func (s *Scanner) saveScreenshot(target ScanTarget, parameter string, number int, png []byte) (string, error) {
+	name := artifacts.TimestampedName(fmt.Sprintf("xss_%s_%d", parameter, number), "png")
+	var path string
+	if s.ScanOptions.ScreenshotDirectory != "" {
+		if err := os.MkdirAll(s.ScanOptions.ScreenshotDirectory, 0755); err != nil {
+			return "", err
+		}
+		path = filepath.Join(s.ScanOptions.ScreenshotDirectory, filepath.Base(name))
+	} else {
+		var err error
+		if path, err = s.Store.Path(target.URL, artifacts.KindWeb, name); err != nil {
+			return "", err
+		}
+	}
+	if err := os.WriteFile(path, png, 0644); err != nil {
+		return "", err
+	}
+	return path, signing.SignFile(path)
+}
+
+// domSnippet returns the markup of the rendered document around the first
+// of markers it contains, on one line
+func domSnippet(dom string, markers ...string) string {
+	const around = 120
+	for _, marker := range markers {
+		if marker == "" {
+			continue
+		}
+		i := strings.Index(dom, marker)
+		if i < 0 {
+			continue
+		}
+		start, end := max(0, i-around), min(len(dom), i+len(marker)+around)
+		return strings.Join(strings.Fields(dom[start:end]), " ")
+	}
+	return ""
+}
+
+// appendEvidence adds a line to the evidence of a result
+func appendEvidence(evidence, line string) string {
+	if evidence == "" {
+		return line
+	}
+	return evidence + "\n" + line
+}
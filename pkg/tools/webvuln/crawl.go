@@ -0,0 +1,146 @@
+// pkg/tools/webvuln/crawl.go
+package webvuln
+
+import (
+	"GopherStrike/pkg/urlnorm"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxCrawlBody is the number of response body bytes read of a crawled page
+const maxCrawlBody = 5 << 20
+
+// crawledPage is an HTML page fetched by the crawler
+type crawledPage struct {
+	URL      *url.URL
+	Body     string
+	Response *http.Response // Status, headers and request of the page, body already read
+}
+
+// htmlTag is a start tag with lowercase attribute names
+type htmlTag struct {
+	Name  string
+	Attrs map[string]string
+}
+
+var (
+	tagPattern       = regexp.MustCompile(`(?s)<([a-zA-Z][a-zA-Z0-9]*)\b([^>]*)>`)
+	attributePattern = regexp.MustCompile(`(?s)([a-zA-Z_:][-a-zA-Z0-9_:.]*)(?:\s*=\s*("[^"]*"|'[^']*'|[^\s"'>]+))?`)
+)
+
+// parseTags returns the start tags of an HTML page. This is a lenient regex
+// scan rather than a full parser, which is enough to find resources and links.
+func parseTags(body string) []htmlTag {
+	tags := []htmlTag{}
+	for _, match := range tagPattern.FindAllStringSubmatch(body, -1) {
+		tag := htmlTag{Name: strings.ToLower(match[1]), Attrs: map[string]string{}}
+		for _, attr := range attributePattern.FindAllStringSubmatch(match[2], -1) {
+			name := strings.ToLower(attr[1])
+			if _, exists := tag.Attrs[name]; !exists {
+				tag.Attrs[name] = strings.Trim(attr[2], `"'`)
+			}
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// resolveLink resolves an attribute value against the page URL. It returns
+// nil for empty values and non-HTTP URLs such as data: or javascript:.
+func resolveLink(page *url.URL, value string) *url.URL {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	link, err := page.Parse(value)
+	if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+		return nil
+	}
+	link.Fragment = ""
+	return link
+}
+
+// sameOrigin reports whether two URLs share scheme, host and port
+func sameOrigin(a, b *url.URL) bool {
+	return a.Scheme == b.Scheme && strings.EqualFold(a.Host, b.Host)
+}
+
+// crawl fetches the target page and the same-origin pages it links to, up
+// to MaxCrawlPages. The pages are fetched once per scan and shared by the
+// tests that need them.
+func (s *Scanner) crawl(target ScanTarget) []crawledPage {
+	s.crawlOnce.Do(func() {
+		start, err := url.Parse(target.URL)
+		if err != nil {
+			return
+		}
+
+		maxPages := s.ScanOptions.MaxCrawlPages
+		if maxPages < 1 {
+			maxPages = 1
+		}
+
+		// Links that differ only in query order, fragments or session
+		// identifiers lead to the same page and are queued once
+		queue := []*url.URL{start}
+		queued := urlnorm.NewSet()
+		queued.Add(start.String())
+		skipped := 0
+		for len(queue) > 0 && len(s.pages) < maxPages && s.ctx.Err() == nil {
+			pageURL := queue[0]
+			queue = queue[1:]
+
+			// The site's robots.txt, crawl delay and request budget apply
+			// to the pages crawled, not to the tests of the target
+			if err := s.robots.Check(s.ctx, pageURL); err != nil {
+				skipped++
+				continue
+			}
+
+			resp, err := s.sendRequest(target, "GET", pageURL.String(), nil, "")
+			if err != nil {
+				continue
+			}
+			body, err := io.ReadAll(io.LimitReader(resp.Body, maxCrawlBody))
+			resp.Body.Close()
+			if err != nil {
+				continue
+			}
+			// API responses are kept for testAPIResponses
+			if strings.Contains(resp.Header.Get("Content-Type"), "json") {
+				s.jsonPages = append(s.jsonPages, crawledPage{URL: resp.Request.URL, Body: string(body), Response: resp})
+				continue
+			}
+			if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+				continue
+			}
+
+			// Redirects may have moved the page
+			page := crawledPage{URL: resp.Request.URL, Body: string(body), Response: resp}
+			s.pages = append(s.pages, page)
+
+			for _, tag := range parseTags(page.Body) {
+				if tag.Name != "a" {
+					continue
+				}
+				link := resolveLink(page.URL, tag.Attrs["href"])
+				if link == nil || !sameOrigin(link, start) || !queued.Add(link.String()) {
+					continue
+				}
+				queue = append(queue, link)
+			}
+		}
+
+		if s.ScanOptions.VerboseMode {
+			fmt.Printf("[i] Crawled %d page(s) of %s\n", len(s.pages), target.URL)
+			if skipped > 0 {
+				fmt.Printf("[i] Skipped %d page(s) disallowed by robots.txt or beyond the request budget\n", skipped)
+			}
+		}
+	})
+	return s.pages
+}
@@ -0,0 +1,174 @@
+// pkg/tools/webvuln/csp.go
+package webvuln
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CSPPolicy is a parsed Content-Security-Policy, mapping lowercase directive
+// names to their source expressions
+type CSPPolicy map[string][]string
+
+// ParseCSP parses the first policy of a Content-Security-Policy header value.
+// Repeated directives are ignored like browsers do.
+func ParseCSP(header string) CSPPolicy {
+	policy := CSPPolicy{}
+
+	// Headers joined with commas carry several policies
+	header, _, _ = strings.Cut(header, ",")
+
+	for _, directive := range strings.Split(header, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if _, exists := policy[name]; exists {
+			continue
+		}
+		policy[name] = fields[1:]
+	}
+	return policy
+}
+
+// Sources returns the sources that apply to a fetch directive, falling back to
+// default-src. The second result is false if neither is set, which allows
+// any source.
+func (p CSPPolicy) Sources(directive string) ([]string, bool) {
+	if sources, ok := p[directive]; ok {
+		return sources, true
+	}
+	if directive == "script-src-elem" || directive == "script-src-attr" {
+		if sources, ok := p["script-src"]; ok {
+			return sources, true
+		}
+	}
+	sources, ok := p["default-src"]
+	return sources, ok
+}
+
+// has reports whether a source list contains a keyword such as
+// 'unsafe-inline' or a source prefix such as 'nonce-
+func has(sources []string, prefix string) bool {
+	for _, source := range sources {
+		if strings.HasPrefix(strings.ToLower(source), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// cspBypassHosts are script hosts that serve attacker-controllable JavaScript,
+// so allowing them defeats a script-src allowlist
+var cspBypassHosts = []struct {
+	Host   string
+	Reason string
+}{
+	{"ajax.googleapis.com", "hosts AngularJS and JSONP endpoints"},
+	{"www.google.com", "has JSONP endpoints"},
+	{"accounts.google.com", "has JSONP endpoints"},
+	{"www.googleapis.com", "has JSONP endpoints"},
+	{"cdnjs.cloudflare.com", "hosts AngularJS"},
+	{"cdn.jsdelivr.net", "serves any npm package or GitHub file"},
+	{"unpkg.com", "serves any npm package"},
+	{"raw.githubusercontent.com", "serves any GitHub file"},
+	{"storage.googleapis.com", "serves any Cloud Storage bucket"},
+	{"s3.amazonaws.com", "serves any S3 bucket"},
+}
+
+// sourceHost returns the host part of a host source expression, e.g.
+// "*.example.com" for "https://*.example.com:443/js/"
+func sourceHost(source string) string {
+	if _, rest, found := strings.Cut(source, "://"); found {
+		source = rest
+	}
+	source, _, _ = strings.Cut(source, "/")
+	source, _, _ = strings.Cut(source, ":")
+	return strings.ToLower(source)
+}
+
+// sourceAllowsHost reports whether a host source expression matches a host
+func sourceAllowsHost(source, host string) bool {
+	pattern := sourceHost(source)
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return pattern == host
+}
+
+// analyzeCSP returns the weaknesses of a policy that let injected scripts
+// run or load despite it
+func analyzeCSP(targetURL string, policy CSPPolicy, reportOnly bool) []TestResult {
+	results := []TestResult{}
+	finding := func(directive, description string, severity Severity) {
+		results = append(results, TestResult{
+			Payload: Payload{
+				Value:       "Content-Security-Policy",
+				Type:        VulnTypeMisconfiguration,
+				Description: "Content Security Policy analysis",
+			},
+			URL:         targetURL,
+			Method:      "GET",
+			Parameter:   directive,
+			Description: "Weak Content Security Policy: " + description,
+			Severity:    severity,
+		})
+	}
+
+	if reportOnly {
+		finding("", "the policy is only sent as Content-Security-Policy-Report-Only, so browsers report violations but don't block them", SeverityMedium)
+	}
+
+	scripts, restricted := policy.Sources("script-src")
+	if !restricted {
+		finding("script-src", "neither script-src nor default-src is set, so scripts load from anywhere; set script-src to 'self' with nonces or hashes", SeverityHigh)
+	} else {
+		// Nonces, hashes and 'strict-dynamic' make browsers ignore 'unsafe-inline'
+		trusted := has(scripts, "'nonce-") || has(scripts, "'sha256-") || has(scripts, "'sha384-") || has(scripts, "'sha512-")
+		strictDynamic := has(scripts, "'strict-dynamic'")
+
+		if has(scripts, "'unsafe-inline'") && !trusted && !strictDynamic {
+			finding("script-src", "'unsafe-inline' lets injected inline scripts and event handlers run; use nonces or hashes instead", SeverityHigh)
+		}
+		if has(scripts, "'unsafe-eval'") {
+			finding("script-src", "'unsafe-eval' lets injected strings run through eval(), setTimeout() and Function()", SeverityMedium)
+		}
+
+		for _, source := range scripts {
+			switch lower := strings.ToLower(source); {
+			case strictDynamic || strings.HasPrefix(lower, "'"):
+				// 'strict-dynamic' makes browsers ignore host and scheme sources
+			case sourceHost(lower) == "*":
+				finding("script-src", "wildcard source '*' allows scripts from any host", SeverityHigh)
+			case lower == "http:" || lower == "https:" || lower == "data:" || lower == "blob:":
+				finding("script-src", fmt.Sprintf("scheme source '%s' allows scripts from any %s URL", source, strings.TrimSuffix(lower, ":")), SeverityHigh)
+			default:
+				for _, bypass := range cspBypassHosts {
+					if sourceAllowsHost(source, bypass.Host) {
+						finding("script-src", fmt.Sprintf("'%s' allows %s, which %s and bypasses the allowlist", source, bypass.Host, bypass.Reason), SeverityHigh)
+						break
+					}
+				}
+			}
+		}
+	}
+
+	objects, restricted := policy.Sources("object-src")
+	for _, source := range objects {
+		restricted = restricted && source != "*"
+	}
+	if !restricted {
+		finding("object-src", "plugins like Flash and PDF embeds load from anywhere; set object-src 'none'", SeverityMedium)
+	}
+
+	if _, set := policy["base-uri"]; !set {
+		finding("base-uri", "an injected <base> tag can point relative script URLs to another host; set base-uri 'self' or 'none'", SeverityLow)
+	}
+
+	if styles, restricted := policy.Sources("style-src"); restricted && has(styles, "'unsafe-inline'") && !has(styles, "'nonce-") {
+		finding("style-src", "'unsafe-inline' allows injected styles, which can exfiltrate page content through CSS selectors", SeverityLow)
+	}
+
+	return results
+}
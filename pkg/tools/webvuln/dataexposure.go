@@ -0,0 +1,36 @@
+// pkg/tools/webvuln/dataexposure.go
+package webvuln
+
+import (
+	"GopherStrike/pkg/pii"
+	"fmt"
+)
+
+// testDataExposure looks for personal data, such as payment card numbers
+// and national identifiers, in the crawled pages and the JSON responses met
+// while crawling, with one finding per page and kind of data
+func (s *Scanner) testDataExposure(target ScanTarget) {
+	result := ScanResult{
+		VulnerabilityType: VulnTypeDataExposure,
+		TestResults:       make([]TestResult, 0),
+	}
+
+	detectors := pii.Current()
+	pages := append(append([]crawledPage{}, s.crawl(target)...), s.jsonPages...)
+	for _, page := range pages {
+		for _, match := range detectors.Scan(page.Body) {
+			result.TestResults = append(result.TestResults, TestResult{
+				URL:         page.URL.String(),
+				Method:      "GET",
+				Parameter:   match.Detector,
+				Description: fmt.Sprintf("Response exposes %s", match.Summary()),
+				Severity:    match.Severity,
+				Body:        page.Body,
+			})
+		}
+	}
+
+	if len(result.TestResults) > 0 {
+		s.addResult(result)
+	}
+}
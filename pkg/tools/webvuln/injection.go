@@ -0,0 +1,136 @@
+package webvuln
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultInjectionHeaders are the request headers tested by the deep preset.
+// Applications commonly log, store or echo them back without the care given
+// to URL parameters.
+var DefaultInjectionHeaders = []string{"Referer", "X-Forwarded-For", "User-Agent"}
+
+// injectionPoint is a request header or a cookie that the injection tests
+// send payloads in, besides the URL parameters
+type injectionPoint struct {
+	Header string // Header carrying the payload
+	Cookie string // Cookie carrying the payload, in the Cookie header
+}
+
+// name identifies the injection point in findings, like a parameter name
+func (p injectionPoint) name() string {
+	if p.Cookie != "" {
+		return "cookie " + p.Cookie
+	}
+	return "header " + p.Header
+}
+
+// describe names the injection point in finding descriptions
+func (p injectionPoint) describe() string {
+	if p.Cookie != "" {
+		return "cookie '" + p.Cookie + "'"
+	}
+	return "header '" + p.Header + "'"
+}
+
+// injectionPoints returns the headers of InjectionHeaders and, with
+// InjectCookies, the cookies of the target
+func (s *Scanner) injectionPoints(target ScanTarget) []injectionPoint {
+	points := []injectionPoint{}
+	seen := map[string]bool{}
+	for _, header := range s.ScanOptions.InjectionHeaders {
+		header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+		if header == "" || header == "Cookie" || seen[header] {
+			continue
+		}
+		seen[header] = true
+		points = append(points, injectionPoint{Header: header})
+	}
+	if s.ScanOptions.InjectCookies {
+		for _, cookie := range target.Cookies {
+			if name, _, ok := strings.Cut(cookie, "="); ok && !seen["cookie "+name] {
+				seen["cookie "+name] = true
+				points = append(points, injectionPoint{Header: "Cookie", Cookie: name})
+			}
+		}
+	}
+	return points
+}
+
+// sendPayloadAt sends a payload in a header or cookie, first as is and then
+// with the encoding chains, like sendPayload does in parameters
+func (s *Scanner) sendPayloadAt(target ScanTarget, point injectionPoint, payload Payload) []payloadAttempt {
+	return s.sendChains(point.name(), payload, func(chain []string) (payloadAttempt, error) {
+		return s.sendEncodedAt(target, point, payload, chain)
+	})
+}
+
+// sendEncodedAt sends a payload encoded with a chain in a header or cookie
+func (s *Scanner) sendEncodedAt(target ScanTarget, point injectionPoint, payload Payload, chain []string) (payloadAttempt, error) {
+	value := EncodeChain(payload.Value, chain)
+	headers := map[string]string{point.Header: value}
+
+	// The injected cookie is written as is: net/http would strip the quotes
+	// and semicolons of payloads from cookie values. The other cookies of the
+	// target are appended to it.
+	if point.Cookie != "" {
+		headers["Cookie"] = point.Cookie + "=" + value
+		cookies := []string{}
+		for _, cookie := range target.Cookies {
+			if name, _, _ := strings.Cut(cookie, "="); name != point.Cookie {
+				cookies = append(cookies, cookie)
+			}
+		}
+		target.Cookies = cookies
+	}
+
+	resp, err := s.sendRequest(target, "GET", "", headers, "")
+	if err != nil {
+		return payloadAttempt{}, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return payloadAttempt{}, err
+	}
+
+	return payloadAttempt{
+		URL:        target.URL,
+		Headers:    headers,
+		Chain:      chain,
+		Reflected:  mutateChain(payload.Value, chain),
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		Blocked:    isBlocked(resp.StatusCode, string(body)),
+	}, nil
+}
+
+// mark returns the target with sqlmap's custom injection marker, "*", at the
+// end of the header or cookie, for the sqlmap command of a finding there
+func (p injectionPoint) mark(target ScanTarget) ScanTarget {
+	if p.Cookie != "" {
+		cookies := make([]string, len(target.Cookies))
+		for i, cookie := range target.Cookies {
+			if name, _, _ := strings.Cut(cookie, "="); name == p.Cookie {
+				cookie += "*"
+			}
+			cookies[i] = cookie
+		}
+		target.Cookies = cookies
+		return target
+	}
+
+	headers := map[string]string{}
+	value := ""
+	for name, v := range target.Headers {
+		if http.CanonicalHeaderKey(name) == p.Header {
+			value = v
+			continue
+		}
+		headers[name] = v
+	}
+	headers[p.Header] = value + "*"
+	target.Headers = headers
+	return target
+}
@@ -0,0 +1,38 @@
+// pkg/tools/webvuln/libraries.go
+package webvuln
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// libraryPatterns extract the name and version of a JavaScript library or
+// stylesheet from the URL it is served from: package CDNs (unpkg, jsDelivr),
+// library CDNs (cdnjs, Google Hosted Libraries) and versioned file names
+// such as jquery-3.5.1.min.js
+var libraryPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`/(@[\w.-]+/[\w.-]+|[\w.-]+)@(\d+\.\d+[\w.-]*)(?:/|$)`),
+	regexp.MustCompile(`/ajax/libs/([\w.-]+)/(\d+\.\d+[\w.-]*)/`),
+	regexp.MustCompile(`/([a-zA-Z][\w]*(?:[.-][a-zA-Z][\w]*)*)[.-]v?(\d+\.\d+(?:\.\d+)?)(?:[.-](?:min|slim|slim\.min|bundle|bundle\.min))?\.(?:js|css)$`),
+}
+
+// DetectLibrary returns the library and version a script or stylesheet URL
+// names, such as jquery and 3.5.1 for
+// https://code.jquery.com/jquery-3.5.1.min.js
+func DetectLibrary(resourceURL string) (string, string, bool) {
+	link, err := url.Parse(resourceURL)
+	if err != nil {
+		return "", "", false
+	}
+	path, err := url.PathUnescape(link.Path)
+	if err != nil {
+		path = link.Path
+	}
+	for _, pattern := range libraryPatterns {
+		if match := pattern.FindStringSubmatch(path); match != nil {
+			return strings.ToLower(match[1]), strings.TrimSuffix(match[2], "."), true
+		}
+	}
+	return "", "", false
+}
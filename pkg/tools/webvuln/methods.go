@@ -0,0 +1,228 @@
+// pkg/tools/webvuln/methods.go
+package webvuln
+
+import (
+	"GopherStrike/pkg/session"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// riskyMethods are the HTTP methods worth reporting when a server allows
+// them, with the reason
+var riskyMethods = map[string]struct {
+	severity Severity
+	reason   string
+}{
+	"PUT":       {SeverityMedium, "may allow uploading files"},
+	"DELETE":    {SeverityMedium, "may allow deleting resources"},
+	"CONNECT":   {SeverityMedium, "may allow using the server as a proxy"},
+	"TRACE":     {SeverityLow, "echoes requests back (Cross-Site Tracing)"},
+	"TRACK":     {SeverityLow, "echoes requests back (Cross-Site Tracing)"},
+	"PROPFIND":  {SeverityLow, "WebDAV, may list directories"},
+	"PROPPATCH": {SeverityLow, "WebDAV"},
+	"MKCOL":     {SeverityLow, "WebDAV, may allow creating directories"},
+	"COPY":      {SeverityLow, "WebDAV"},
+	"MOVE":      {SeverityLow, "WebDAV, may allow renaming files"},
+	"LOCK":      {SeverityLow, "WebDAV"},
+	"UNLOCK":    {SeverityLow, "WebDAV"},
+	"SEARCH":    {SeverityLow, "WebDAV"},
+}
+
+// tamperingMethods are sent to pages refusing GET, as access rules often
+// only list GET and POST. Arbitrary verbs are handled as GET by some servers.
+var tamperingMethods = []string{"HEAD", "POST", "PUT", "GSTRIKE"}
+
+// methodOverrideHeaders make frameworks handle a POST as another method
+var methodOverrideHeaders = []string{"X-HTTP-Method-Override", "X-HTTP-Method", "X-Method-Override"}
+
+// testInfoDisclosure runs the information disclosure checks of the payload
+// level
+func (s *Scanner) testInfoDisclosure(target ScanTarget) {
+	result := ScanResult{
+		VulnerabilityType: VulnTypeInfoDisclosure,
+		TestResults:       make([]TestResult, 0),
+	}
+
+	errorsSeen := make(map[string]bool)
+	for _, payload := range s.payloads.GetPayloads(VulnTypeInfoDisclosure) {
+		switch payload.Value {
+		case "ERROR_TRIGGER_SQL", "ERROR_TRIGGER_PATH", "STACK_TRACE":
+			result.TestResults = append(result.TestResults, s.checkErrorPages(target, payload, errorsSeen)...)
+		case "/.well-known/":
+			result.TestResults = append(result.TestResults, s.checkWellKnown(target, payload)...)
+		case "OPTIONS_METHOD":
+			result.TestResults = append(result.TestResults, s.checkAllowedMethods(target, payload)...)
+		case "TRACE_METHOD":
+			result.TestResults = append(result.TestResults, s.checkTrace(target, payload)...)
+		case "VERB_TAMPERING":
+			result.TestResults = append(result.TestResults, s.checkVerbTampering(target, payload)...)
+		case "COMMENTS_CHECK":
+			result.TestResults = append(result.TestResults, s.checkComments(target, payload)...)
+		case "API_DOCUMENTATION":
+			result.TestResults = append(result.TestResults, s.checkAPIDocumentation(target, payload)...)
+		case "DIRECTORY_LISTING":
+			result.TestResults = append(result.TestResults, s.checkDirectoryListings(target, payload)...)
+		}
+	}
+
+	if len(result.TestResults) > 0 {
+		s.addResult(result)
+	}
+}
+
+// AllowedMethods returns the methods a response to OPTIONS advertises in
+// its Allow, Public and Access-Control-Allow-Methods headers, uppercased
+// and sorted
+func AllowedMethods(header http.Header) []string {
+	seen := make(map[string]bool)
+	for _, name := range []string{"Allow", "Public", "Access-Control-Allow-Methods"} {
+		for _, value := range header.Values(name) {
+			for _, method := range strings.Split(value, ",") {
+				if method = strings.ToUpper(strings.TrimSpace(method)); method != "" {
+					seen[method] = true
+				}
+			}
+		}
+	}
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// checkAllowedMethods asks the target for its methods with OPTIONS and
+// reports the risky ones, in one finding as severe as the worst of them
+func (s *Scanner) checkAllowedMethods(target ScanTarget, payload Payload) []TestResult {
+	resp, err := s.sendRequest(target, "OPTIONS", "", nil, "")
+	if err != nil {
+		return nil
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil
+	}
+
+	severity := SeverityInfo
+	risky := make([]string, 0)
+	for _, method := range AllowedMethods(resp.Header) {
+		risk, found := riskyMethods[method]
+		if method == "*" {
+			risk.severity, risk.reason, found = SeverityMedium, "any method", true
+		}
+		if !found {
+			continue
+		}
+		if risk.severity.Rank() > severity.Rank() {
+			severity = risk.severity
+		}
+		risky = append(risky, fmt.Sprintf("%s (%s)", method, risk.reason))
+	}
+	if len(risky) == 0 {
+		return nil
+	}
+
+	return []TestResult{{
+		Payload:     payload,
+		URL:         target.URL,
+		Method:      "OPTIONS",
+		Description: fmt.Sprintf("Risky HTTP methods allowed: %s", strings.Join(risky, ", ")),
+		Severity:    severity,
+	}}
+}
+
+// checkTrace sends TRACE and TRACK with a marker header and reports the
+// methods whose response echoes it, which lets scripts read HttpOnly
+// cookies and credentials sent with the request (Cross-Site Tracing)
+func (s *Scanner) checkTrace(target ScanTarget, payload Payload) []TestResult {
+	b := make([]byte, 6)
+	session.Read(b)
+	marker := "gs" + hex.EncodeToString(b)
+
+	results := make([]TestResult, 0)
+	for _, method := range []string{"TRACE", "TRACK"} {
+		resp, err := s.sendRequest(target, method, "", map[string]string{"X-GopherStrike-Trace": marker}, "")
+		if err != nil {
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), marker) {
+			continue
+		}
+
+		description := fmt.Sprintf("HTTP %s method enabled: request headers are echoed back (Cross-Site Tracing)", method)
+		severity := SeverityLow
+		if len(target.Cookies) > 0 || target.BasicAuth.Username != "" || target.Headers["Authorization"] != "" {
+			description += ", including the cookies and credentials of the request"
+			severity = SeverityMedium
+		}
+		results = append(results, TestResult{
+			Payload:     payload,
+			URL:         target.URL,
+			Method:      method,
+			Description: description,
+			Severity:    severity,
+		})
+	}
+	return results
+}
+
+// checkVerbTampering reports the methods and method override headers that
+// get a page refusing GET with 401 or 403 to answer, which bypasses access
+// rules limited to some methods
+func (s *Scanner) checkVerbTampering(target ScanTarget, payload Payload) []TestResult {
+	resp, err := s.sendRequest(target, "GET", "", nil, "")
+	if err != nil {
+		return nil
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden {
+		return nil
+	}
+	refused := resp.StatusCode
+
+	results := make([]TestResult, 0)
+	bypassed := func(method string, headers map[string]string, how string, severity Severity) bool {
+		resp, err := s.sendRequest(target, method, "", headers, "")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return false
+		}
+		results = append(results, TestResult{
+			Payload:     payload,
+			URL:         target.URL,
+			Method:      method,
+			Description: fmt.Sprintf("HTTP verb tampering: GET is refused with %d but %s is answered with %d", refused, how, resp.StatusCode),
+			Severity:    severity,
+		})
+		return true
+	}
+
+	postAnswered := false
+	for _, method := range tamperingMethods {
+		// HEAD only reveals that the page exists and its headers
+		severity := SeverityHigh
+		if method == "HEAD" {
+			severity = SeverityMedium
+		}
+		if bypassed(method, nil, method, severity) && method == "POST" {
+			postAnswered = true
+		}
+	}
+	// Overrides only tell something if a plain POST is refused
+	if !postAnswered {
+		for _, header := range methodOverrideHeaders {
+			bypassed("POST", map[string]string{header: "GET"}, fmt.Sprintf("POST with %s: GET", header), SeverityHigh)
+		}
+	}
+	return results
+}
@@ -0,0 +1,129 @@
+// pkg/tools/webvuln/mixed.go
+package webvuln
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mixedContentSources maps tags to the attribute holding the URL they load.
+// Active content can read or change the page, so browsers block it over
+// http://; passive content is only displayed but can be swapped or tracked.
+var mixedContentSources = []struct {
+	Tag       string
+	Attribute string
+	Active    bool
+}{
+	{"script", "src", true},
+	{"link", "href", true}, // Stylesheets, icons and preloads
+	{"iframe", "src", true},
+	{"frame", "src", true},
+	{"object", "data", true},
+	{"embed", "src", true},
+	{"form", "action", true}, // Submits the form data in cleartext
+	{"img", "src", false},
+	{"audio", "src", false},
+	{"video", "src", false},
+	{"source", "src", false},
+	{"track", "src", false},
+}
+
+// insecureURLs lists the http:// URLs of one page and category
+type insecureURLs []string
+
+// add appends a URL once
+func (u *insecureURLs) add(link string) {
+	for _, existing := range *u {
+		if existing == link {
+			return
+		}
+	}
+	*u = append(*u, link)
+}
+
+// summary describes the URLs for a finding, listing the first few
+func (u insecureURLs) summary(kind string) string {
+	const listed = 3
+	shown := u
+	if len(shown) > listed {
+		shown = shown[:listed]
+	}
+	text := fmt.Sprintf("%d %s (%s", len(u), kind, strings.Join(shown, ", "))
+	if len(u) > listed {
+		text += fmt.Sprintf(", +%d more", len(u)-listed)
+	}
+	return text + ")"
+}
+
+// testMixedContent reports http:// resources embedded in the crawled https
+// pages and links from them to http:// URLs, with one finding per page
+func (s *Scanner) testMixedContent(target ScanTarget) {
+	result := ScanResult{
+		VulnerabilityType: VulnTypeMixedContent,
+		TestResults:       make([]TestResult, 0),
+	}
+
+	for _, page := range s.crawl(target) {
+		if page.URL.Scheme != "https" {
+			continue
+		}
+
+		var active, passive, links insecureURLs
+		for _, tag := range parseTags(page.Body) {
+			if tag.Name == "a" || tag.Name == "area" {
+				if link := resolveLink(page.URL, tag.Attrs["href"]); link != nil && link.Scheme == "http" {
+					links.add(link.String())
+				}
+				continue
+			}
+
+			for _, source := range mixedContentSources {
+				if tag.Name != source.Tag {
+					continue
+				}
+				link := resolveLink(page.URL, tag.Attrs[source.Attribute])
+				if link == nil || link.Scheme != "http" {
+					continue
+				}
+				if source.Active {
+					active.add(link.String())
+				} else {
+					passive.add(link.String())
+				}
+			}
+		}
+
+		parts := []string{}
+		severity := SeverityInfo
+		if len(links) > 0 {
+			parts = append(parts, links.summary("link(s) to http:// URLs"))
+			severity = SeverityLow
+		}
+		if len(passive) > 0 {
+			parts = append([]string{passive.summary("passive resource(s)")}, parts...)
+			severity = SeverityMedium
+		}
+		if len(active) > 0 {
+			parts = append([]string{active.summary("active resource(s) or form target(s)")}, parts...)
+			severity = SeverityHigh
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		title := "Mixed content on HTTPS page: "
+		if len(active)+len(passive) == 0 {
+			title = "Insecure links on HTTPS page: "
+		}
+		result.TestResults = append(result.TestResults, TestResult{
+			URL:         page.URL.String(),
+			Method:      "GET",
+			Description: title + strings.Join(parts, "; "),
+			Severity:    severity,
+		})
+	}
+
+	if len(result.TestResults) > 0 {
+		s.addResult(result)
+	}
+}
@@ -2,6 +2,14 @@
 package webvuln
 
 import (
+	"GopherStrike/pkg/logging"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/robots"
+	"GopherStrike/pkg/timing"
+	"fmt"
+	"net/url"
+	"sort"
 	"time"
 )
 
@@ -9,7 +17,7 @@ import (
 type VulnerabilityType string
 
 // Severity represents the severity level of a vulnerability
-type Severity string
+type Severity = model.Severity
 
 const (
 	// Vulnerability types
@@ -20,15 +28,58 @@ const (
 	VulnTypeMisconfiguration VulnerabilityType = "MISCONFIGURATION"
 	VulnTypeAuthWeak         VulnerabilityType = "AUTH_WEAK"
 	VulnTypeInfoDisclosure   VulnerabilityType = "INFO_DISCLOSURE"
+	VulnTypeSupplyChain      VulnerabilityType = "SUPPLY_CHAIN"
+	VulnTypeMixedContent     VulnerabilityType = "MIXED_CONTENT"
+	VulnTypeDataExposure     VulnerabilityType = "DATA_EXPOSURE"
+	VulnTypeCustom           VulnerabilityType = "CUSTOM_CHECK"
 
 	// Severity levels
-	SeverityCritical Severity = "Critical"
-	SeverityHigh     Severity = "High"
-	SeverityMedium   Severity = "Medium"
-	SeverityLow      Severity = "Low"
-	SeverityInfo     Severity = "Info"
+	SeverityCritical = model.SeverityCritical
+	SeverityHigh     = model.SeverityHigh
+	SeverityMedium   = model.SeverityMedium
+	SeverityLow      = model.SeverityLow
+	SeverityInfo     = model.SeverityInfo
 )
 
+// Confidence is how strongly the evidence of a test result backs it, from
+// an anomaly worth a look to a vulnerability proven to be exploitable
+type Confidence string
+
+const (
+	ConfidenceTentative Confidence = "Tentative" // An anomaly other causes can explain, like a response differing from the baseline
+	ConfidenceFirm      Confidence = "Firm"      // Evidence left by the vulnerability, like a SQL error or a reflection that would run
+	ConfidenceCertain   Confidence = "Certain"   // The vulnerability was exploited or observed directly, like a payload run in a browser
+)
+
+// typeConfidence is the confidence of the results of each type that their
+// test doesn't set
+var typeConfidence = map[VulnerabilityType]Confidence{
+	VulnTypeXSS:              ConfidenceFirm,
+	VulnTypeSQLInjection:     ConfidenceFirm,
+	VulnTypeCSRF:             ConfidenceTentative,
+	VulnTypeFileInclusion:    ConfidenceCertain,
+	VulnTypeMisconfiguration: ConfidenceFirm,
+	VulnTypeAuthWeak:         ConfidenceTentative,
+	VulnTypeInfoDisclosure:   ConfidenceFirm,
+	VulnTypeSupplyChain:      ConfidenceCertain,
+	VulnTypeMixedContent:     ConfidenceCertain,
+	VulnTypeDataExposure:     ConfidenceTentative,
+	VulnTypeCustom:           ConfidenceFirm,
+}
+
+// Rank orders confidences, from 0 for an unknown one to 3 for Certain
+func (c Confidence) Rank() int {
+	switch c {
+	case ConfidenceCertain:
+		return 3
+	case ConfidenceFirm:
+		return 2
+	case ConfidenceTentative:
+		return 1
+	}
+	return 0
+}
+
 // BasicAuth represents basic authentication credentials
 type BasicAuth struct {
 	Username string
@@ -47,8 +98,10 @@ type ScanTarget struct {
 // ScanOptions represents options for the vulnerability scanner
 type ScanOptions struct {
 	// Scan behavior options
-	PayloadLevel         int // 1-5, 1 being basic payloads, 5 being comprehensive
-	Timeout              int // In seconds
+	PayloadLevel         int           // 1-5, 1 being basic payloads, 5 being comprehensive
+	AutoTuneLevel        bool          // Start each parameter at level 1 and escalate to PayloadLevel only on anomalies
+	Timeout              int           // In seconds
+	MaxDuration          time.Duration // Time budget of the scan, after which it stops and keeps its partial results; 0 for no limit
 	MaxRedirects         int
 	IgnoreSSLErrors      bool
 	GenerateHTML         bool
@@ -57,15 +110,45 @@ type ScanOptions struct {
 	TestAllParams        bool
 	LogDirectory         string
 	MaxRequestsPerSecond int
+	EncodingChains       []string       // Payload encoding chains sent besides the plain payload, e.g. "case>url"
+	RetryBlocked         bool           // Retry payloads blocked by a WAF with alternate encodings
+	InjectionHeaders     []string       // Request headers the XSS and SQL injection tests send payloads in, besides URL parameters
+	InjectCookies        bool           // Send the XSS and SQL injection payloads in the values of the target's cookies too
+	MaxCrawlPages        int            // Same-origin pages fetched by the page-level checks, starting at the target
+	Robots               robots.Options // robots.txt rules, crawl delays and request budget followed by the crawler
 
 	// Vulnerability test options
 	EnableXSS              bool
 	EnableSQLInjection     bool
+	EnableBooleanSQLi      bool // Compare true and false conditions for boolean-based blind SQL injection
+	EnableTimeBasedSQLi    bool // Confirm time-based blind SQL injection with repeated sleep payloads
+	EnableDBMSFingerprint  bool // Identify the database behind suspected SQL injections
+	TimeBasedDelay         int  // Sleep in seconds requested by time-based payloads
 	EnableCSRF             bool
 	EnableFileInclusion    bool
 	EnableMisconfiguration bool
 	EnableAuthTesting      bool
 	EnableInfoDisclosure   bool
+	EnableSRICheck         bool   // Inventory external scripts and stylesheets and check their Subresource Integrity
+	EnableMixedContent     bool   // Find http:// resources and links on the crawled HTTPS pages
+	EnableAPISchema        bool   // Record the JSON structure of API endpoints and find fields leaking sensitive data
+	EnablePIIDetection     bool   // Find personal data (card numbers, national IDs, phone numbers) in the crawled responses
+	EnableScripts          bool   // Run the custom check scripts in ScriptsDirectory
+	ScriptsDirectory       string // Directory holding custom check scripts (*.star)
+
+	// File inclusion options
+	IntrusiveTests     bool            // Allow tests that run code on or write to the target (log poisoning, code wrappers, RFI)
+	FileInclusionOS    string          // "linux", "windows" or "" for both
+	FileInclusionFiles []InclusionFile // Files read by the file inclusion test; DefaultInclusionFiles() if empty
+
+	// XSS confirmation options
+	ConfirmXSS          bool   // Load reflected XSS payloads in a headless browser and screenshot those that run
+	BrowserPath         string // Chrome or Chromium used by ConfirmXSS; looked for on the PATH if empty
+	ScreenshotDirectory string // Where ConfirmXSS saves screenshots; the target's web artifacts if empty
+
+	// Misconfiguration options
+	ActuatorSecrets bool     // Read the env endpoint of exposed Spring Boot actuators for unmasked credentials
+	FrameworkPacks  []string // Framework check packs to run, see FrameworkPackNames; FrameworkPacksAuto for those fingerprinted
 
 	// Authentication testing options
 	LoginURL       string
@@ -77,18 +160,79 @@ type ScanOptions struct {
 
 // TestResult represents the result of an individual test
 type TestResult struct {
-	Payload     Payload
-	URL         string
-	Method      string
-	Parameter   string
-	Description string
-	Severity    Severity
+	Payload       Payload
+	URL           string
+	Method        string
+	Parameter     string
+	Description   string
+	Severity      Severity
+	DBMS          string            // Database identified behind a SQL injection, e.g. "MySQL 8.0.32"
+	SqlmapCommand string            // sqlmap command line to follow up a confirmed SQL injection
+	Evidence      string            // Response content backing the finding, such as a directory listing
+	Body          string            // Response body backing the finding, replaced by its reference in the workspace body store when emitted, unless it cannot be stored
+	Match         string            // Response text proving the vulnerability, looked for again by verify
+	Screenshot    string            // Screenshot of the payload running in a browser, for confirmed XSS
+	Headers       map[string]string // Request headers carrying the payload, for results in a header or cookie
+	Confidence    Confidence        // Strength of the evidence; the default of the vulnerability type if unset
+}
+
+// Finding converts a test result into the finding shared with the other tools
+func (t TestResult) Finding(vulnType VulnerabilityType) model.Finding {
+	target := t.URL
+	if parsed, err := url.Parse(t.URL); err == nil && parsed.Hostname() != "" {
+		target = parsed.Hostname()
+	}
+	description := t.Description
+	if t.DBMS != "" {
+		description = fmt.Sprintf("%s (DBMS: %s)", description, t.DBMS)
+	}
+	evidence := fmt.Sprintf("%s %s\nParameter: %s\nPayload: %s", t.Method, t.URL, t.Parameter, t.Payload.Value)
+	for name, value := range t.Headers {
+		evidence += fmt.Sprintf("\n%s: %s", name, value)
+	}
+	if t.SqlmapCommand != "" {
+		evidence += "\nFollow up: " + t.SqlmapCommand
+	}
+	if t.Confidence != "" {
+		evidence += "\nConfidence: " + string(t.Confidence)
+	}
+	if t.Evidence != "" {
+		evidence += "\n" + t.Evidence
+	}
+
+	return model.Finding{
+		Tool:        "webvuln",
+		Target:      target,
+		Category:    string(vulnType),
+		Name:        fmt.Sprintf("%s in %s", vulnType, t.Parameter),
+		Severity:    t.Severity,
+		Description: description,
+		URL:         t.URL,
+		Evidence:    evidence,
+		Body:        t.Body,
+		Replay:      t.replay(),
+	}
+}
+
+// replay returns the request to send again to verify the result, nil if
+// the result has no response text to look for. Only GET requests are
+// replayed, with the headers carrying the payload but without the scan's
+// credentials or cookies, which are not stored with the findings.
+func (t TestResult) replay() *model.Replay {
+	if t.Match == "" || t.Method != "GET" {
+		return nil
+	}
+	return &model.Replay{
+		Requests: []model.ReplayRequest{{Method: t.Method, URL: t.URL, Headers: t.Headers}},
+		Match:    t.Match,
+	}
 }
 
 // ScanResult represents the result of a vulnerability scan for a specific type
 type ScanResult struct {
 	VulnerabilityType VulnerabilityType
 	TestResults       []TestResult
+	emitted           int // Test results already reported as findings, see emit
 }
 
 // Payload represents a payload for vulnerability testing
@@ -96,7 +240,8 @@ type Payload struct {
 	Value       string
 	Type        VulnerabilityType
 	Description string
-	Level       int // Complexity level 1-5
+	Level       int  // Complexity level 1-5
+	Intrusive   bool // Runs code on or writes to the target; only sent with IntrusiveTests
 }
 
 // Report represents a vulnerability scan report
@@ -104,33 +249,119 @@ type Report struct {
 	Target      ScanTarget
 	ScanOptions ScanOptions
 	Results     []ScanResult
+	Resources   []ExternalResource // Scripts and stylesheets found on the crawled pages
+	APISchemas  []APISchema        // Structure of the JSON responses of the API endpoints found
+	Products    []DetectedProduct  // Products identified on the target, such as CI/CD consoles
+	Incomplete  string             // Why the scan did not cover the whole target
 	StartTime   time.Time
 	EndTime     time.Time
 }
 
+// VerificationQueue returns the test results left to verify by hand, those
+// short of Certain, in the order to work through them: the likeliest to be
+// real first, by confidence and then severity
+func (r *Report) VerificationQueue() []TestResult {
+	queue := []TestResult{}
+	for _, result := range r.Results {
+		for _, test := range result.TestResults {
+			if test.Confidence != ConfidenceCertain {
+				queue = append(queue, test)
+			}
+		}
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		if a, b := queue[i].Confidence.Rank(), queue[j].Confidence.Rank(); a != b {
+			return a > b
+		}
+		return queue[i].Severity.Rank() > queue[j].Severity.Rank()
+	})
+	return queue
+}
+
 // DefaultScanOptions returns default scan options
 func DefaultScanOptions() ScanOptions {
 	return ScanOptions{
 		PayloadLevel:         3,
+		AutoTuneLevel:        true,
 		Timeout:              10,
 		MaxRedirects:         5,
 		IgnoreSSLErrors:      false,
 		GenerateHTML:         true,
 		OutputFormat:         "text",
-		VerboseMode:          false,
+		VerboseMode:          logging.CurrentVerbosity() >= logging.Verbose,
 		TestAllParams:        true,
-		LogDirectory:         "logs/webvuln",
+		LogDirectory:         logging.Dir("webvuln"),
 		MaxRequestsPerSecond: 10,
+		RetryBlocked:         true,
+		MaxCrawlPages:        20,
+		Robots:               robots.Defaults(),
 
 		EnableXSS:              true,
 		EnableSQLInjection:     true,
+		EnableBooleanSQLi:      true,
+		EnableTimeBasedSQLi:    true,
+		EnableDBMSFingerprint:  true,
+		TimeBasedDelay:         3,
 		EnableCSRF:             true,
 		EnableFileInclusion:    true,
 		EnableMisconfiguration: true,
+		FrameworkPacks:         []string{FrameworkPacksAuto},
 		EnableAuthTesting:      false,
 		EnableInfoDisclosure:   true,
+		EnableSRICheck:         true,
+		EnableMixedContent:     true,
+		EnableAPISchema:        true,
+		EnablePIIDetection:     true,
+		EnableScripts:          true,
+		ScriptsDirectory:       "scripts",
 
 		BruteForceTest: false,
 		ScanForms:      true,
 	}
 }
+
+// PresetDescriptions tells what each scan preset does
+var PresetDescriptions = map[string]string{
+	presets.Quick:  "Level 1 payloads against the target page only: headers, misconfigurations and information disclosure",
+	presets.Normal: "Every default test at level 3, auto-tuned per parameter, on up to 20 crawled pages",
+	presets.Deep:   "Every test at level 5 on up to 100 crawled pages, with WAF evasion encodings, payloads in headers and cookies and every framework check pack",
+}
+
+// PresetScanOptions returns the scan options of a preset. Authentication
+// and intrusive tests need target details and are never enabled by presets.
+func PresetScanOptions(preset string) (ScanOptions, error) {
+	preset, err := presets.Validate(preset)
+	if err != nil {
+		return ScanOptions{}, err
+	}
+
+	options := DefaultScanOptions()
+	switch preset {
+	case presets.Quick:
+		options.PayloadLevel = 1
+		options.AutoTuneLevel = false
+		options.TestAllParams = false
+		options.RetryBlocked = false
+		options.MaxCrawlPages = 1
+		options.EnableXSS = false
+		options.EnableSQLInjection = false
+		options.EnableBooleanSQLi = false
+		options.EnableTimeBasedSQLi = false
+		options.EnableDBMSFingerprint = false
+		options.EnableCSRF = false
+		options.EnableFileInclusion = false
+		options.EnableScripts = false
+	case presets.Deep:
+		options.PayloadLevel = 5
+		options.AutoTuneLevel = false
+		options.MaxCrawlPages = 100
+		options.EncodingChains = []string{"url", "double-url", "case"}
+		options.InjectionHeaders = append([]string{}, DefaultInjectionHeaders...)
+		options.InjectCookies = true
+		options.FrameworkPacks = FrameworkPackNames()
+	}
+
+	profile := timing.Current()
+	options.Timeout = profile.TimeoutSeconds(options.Timeout)
+	return options, nil
+}
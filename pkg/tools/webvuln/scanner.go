@@ -2,8 +2,16 @@
 package webvuln
 
 import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/budget"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/httpclient"
+	"GopherStrike/pkg/robots"
+	"GopherStrike/pkg/siem"
+	"context"
 	"crypto/tls"
 	"fmt"
+	"html"
 	"io"
 	"net/http"
 	"net/url"
@@ -18,8 +26,21 @@ type Scanner struct {
 	payloads    *PayloadManager
 	ScanOptions ScanOptions
 	UserAgent   string
+	Store       *artifacts.Store // Workspace holding the response bodies and screenshots of the findings
 	Results     []ScanResult
 	mutex       sync.Mutex
+	ctx         context.Context
+
+	crawlOnce  sync.Once
+	robots     *robots.Guard      // Crawl policies of the crawled hosts
+	pages      []crawledPage      // Pages fetched by crawl
+	resources  []ExternalResource // Scripts and stylesheets inventoried by testSubresourceIntegrity
+	jsonPages  []crawledPage      // JSON responses met by crawl
+	apiSchemas []APISchema        // Endpoint structures recorded by testAPIResponses
+
+	apiDocsOnce sync.Once
+	apiDocs     []APIDocument // API documentation found by findAPIDocumentation
+	products    []DetectedProduct // Products identified by the checks, for the CVE correlator
 }
 
 // NewScanner creates a new web vulnerability scanner
@@ -49,15 +70,12 @@ func NewScanner(options ScanOptions) *Scanner {
 		}
 	}
 
-	client := &http.Client{
-		Transport: transport,
-		Timeout:   time.Duration(options.Timeout) * time.Second,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= options.MaxRedirects {
-				return fmt.Errorf("stopped after %d redirects", options.MaxRedirects)
-			}
-			return nil
-		},
+	client := httpclient.New("webvuln", time.Duration(options.Timeout)*time.Second, transport)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= options.MaxRedirects {
+			return fmt.Errorf("stopped after %d redirects", options.MaxRedirects)
+		}
+		return nil
 	}
 
 	return &Scanner{
@@ -65,9 +83,30 @@ func NewScanner(options ScanOptions) *Scanner {
 		payloads:    NewPayloadManager(options.PayloadLevel),
 		ScanOptions: options,
 		UserAgent:   "GopherStrike WebVulnScanner/1.0",
+		Store:       artifacts.Default(),
 		Results:     make([]ScanResult, 0),
 		mutex:       sync.Mutex{},
+		ctx:         context.Background(),
+	}
+}
+
+// ScanContext performs a full vulnerability scan that is aborted when the context is cancelled.
+// A scan stopped by its time budget returns the results gathered so far.
+func (s *Scanner) ScanContext(ctx context.Context, target ScanTarget) (*Report, error) {
+	s.ctx = ctx
+	defer func() { s.ctx = context.Background() }()
+
+	report, err := s.Scan(target)
+	if err != nil {
+		return nil, err
 	}
+	if err := ctx.Err(); err != nil {
+		if !budget.Exceeded(ctx) {
+			return nil, err
+		}
+		report.Incomplete = "stopped as its time budget elapsed, the target was not fully scanned"
+	}
+	return report, nil
 }
 
 // Scan performs a full vulnerability scan on the target
@@ -82,6 +121,13 @@ func (s *Scanner) Scan(target ScanTarget) (*Report, error) {
 
 	// Reset results for new scan
 	s.Results = make([]ScanResult, 0)
+	s.crawlOnce = sync.Once{}
+	s.robots = robots.NewGuard(s.client, s.ScanOptions.Robots)
+	s.pages = nil
+	s.resources = nil
+	s.jsonPages = nil
+	s.apiSchemas = nil
+	eventbus.Publish(eventbus.Event{Type: eventbus.ScanStarted, Tool: "webvuln", Target: target.URL})
 
 	var wg sync.WaitGroup
 
@@ -126,6 +172,14 @@ func (s *Scanner) Scan(target ScanTarget) (*Report, error) {
 		}()
 	}
 
+	if s.ScanOptions.EnableInfoDisclosure {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.testInfoDisclosure(target)
+		}()
+	}
+
 	if s.ScanOptions.EnableAuthTesting {
 		wg.Add(1)
 		go func() {
@@ -134,6 +188,46 @@ func (s *Scanner) Scan(target ScanTarget) (*Report, error) {
 		}()
 	}
 
+	if s.ScanOptions.EnableSRICheck {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.testSubresourceIntegrity(target)
+		}()
+	}
+
+	if s.ScanOptions.EnableMixedContent {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.testMixedContent(target)
+		}()
+	}
+
+	if s.ScanOptions.EnableAPISchema {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.testAPIResponses(target)
+		}()
+	}
+
+	if s.ScanOptions.EnablePIIDetection {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.testDataExposure(target)
+		}()
+	}
+
+	if s.ScanOptions.EnableScripts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.testScripts(target)
+		}()
+	}
+
 	// Wait for all tests to complete
 	wg.Wait()
 
@@ -142,10 +236,19 @@ func (s *Scanner) Scan(target ScanTarget) (*Report, error) {
 		Target:      target,
 		ScanOptions: s.ScanOptions,
 		Results:     s.Results,
+		Resources:   s.resources,
+		APISchemas:  s.apiSchemas,
+		Products:    s.products,
 		StartTime:   startTime,
 		EndTime:     time.Now(),
 	}
 
+	findings := 0
+	for _, result := range report.Results {
+		findings += len(result.TestResults)
+	}
+	eventbus.ScanFinished("webvuln", target.URL, s.ctx.Err() != nil, nil, map[string]int{"findings": findings})
+
 	return report, nil
 }
 
@@ -170,7 +273,7 @@ func (s *Scanner) sendRequest(target ScanTarget, method, path string, headers ma
 	}
 
 	// Create request
-	req, err := http.NewRequest(method, targetURL, strings.NewReader(body))
+	req, err := http.NewRequestWithContext(s.ctx, method, targetURL, strings.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -210,13 +313,50 @@ func (s *Scanner) sendRequest(target ScanTarget, method, path string, headers ma
 	return s.client.Do(req)
 }
 
-// addResult adds a scan result to the results list thread-safely
+// addResult adds a scan result to the results list thread-safely and
+// reports the findings not emitted yet
 func (s *Scanner) addResult(result ScanResult) {
+	s.emit(&result)
+
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	s.Results = append(s.Results, result)
 }
 
+// emit reports the findings of the test results added to a scan result
+// since the last call, giving those without a confidence the default of
+// their type. The response bodies backing them are moved to the body store
+// of the scanner's workspace, which keeps the pages shared by many findings once. The
+// tests going through parameters one by one emit each parameter's findings
+// once it is done, so that they can be followed up before a long scan ends.
+func (s *Scanner) emit(result *ScanResult) {
+	for i := result.emitted; i < len(result.TestResults); i++ {
+		test := &result.TestResults[i]
+		if test.Confidence == "" {
+			test.Confidence = typeConfidence[result.VulnerabilityType]
+		}
+		stored := true
+		if test.Body != "" {
+			// The reports reference the stored body rather than repeat it. A
+			// body that can't be stored stays in the report, and the finding
+			// stands on its evidence.
+			ref, err := s.Store.PutBlob([]byte(test.Body))
+			if err != nil {
+				fmt.Printf("[!] Failed to store the response body of %s: %v\n", test.URL, err)
+				stored = false
+			} else {
+				test.Body = ref
+			}
+		}
+		finding := test.Finding(result.VulnerabilityType)
+		if !stored {
+			finding.Body = ""
+		}
+		siem.EmitContext(s.ctx, finding)
+	}
+	result.emitted = len(result.TestResults)
+}
+
 // testXSS tests for Cross-Site Scripting vulnerabilities
 func (s *Scanner) testXSS(target ScanTarget) {
 	payloads := s.payloads.GetPayloads(VulnTypeXSS)
@@ -234,45 +374,75 @@ func (s *Scanner) testXSS(target ScanTarget) {
 			params.Add("test", "value")
 		}
 
-		// Test each parameter
-		for paramName := range params {
-			for _, payload := range payloads {
-				// Create a copy of the parameters and modify the test parameter
-				testParams := url.Values{}
-				for k, v := range params {
-					testParams[k] = v
-				}
-				testParams.Set(paramName, payload.Value)
+		baselineStatus := s.baselineStatus(target)
 
-				// Construct test URL
-				testURL := *targetURL
-				testURL.RawQuery = testParams.Encode()
+		// Reflected payloads are run in a browser as they are found
+		var confirmer *xssConfirmer
+		if s.ScanOptions.ConfirmXSS {
+			confirmer = s.newXSSConfirmer(target)
+			defer confirmer.close()
+		}
 
-				// Send request
-				resp, err := s.sendRequest(target, "GET", testURL.String(), nil, "")
-				if err != nil {
-					continue
-				}
+		// check probes where name is reflected, then looks for reflections
+		// of a payload sent in name that would run in their context, and
+		// confirms and reports those found once name is done
+		check := func(name, where string, send func(payload Payload) []payloadAttempt) {
+			firstResult := len(result.TestResults)
+			s.testPayloads(name, s.xssPayloads(name, payloads, send), func(payload Payload) bool {
+				anomaly := false
+				for _, attempt := range send(payload) {
+					// Check if the payload is reflected where it would run
+					match := payload.Value
+					ctx, ok := findExecutable(attempt.Body, match)
+					if !ok && attempt.Reflected != match {
+						match = attempt.Reflected
+						ctx, ok = findExecutable(attempt.Body, match)
+					}
+					if ok {
+						result.TestResults = append(result.TestResults, TestResult{
+							Payload:     payload,
+							URL:         attempt.URL,
+							Method:      "GET",
+							Parameter:   name,
+							Description: attempt.describe(fmt.Sprintf("Potential XSS: Payload reflected in response for %s (%s)", where, ctx)),
+							Severity:    SeverityHigh,
+							Match:       match,
+							Headers:     attempt.Headers,
+							Body:        attempt.Body,
+						})
+						return true
+					}
 
-				// Check if the payload is reflected in the response
-				body, err := io.ReadAll(resp.Body)
-				resp.Body.Close()
-				if err != nil {
-					continue
+					// A reflection that is escaped or doesn't break out of its
+					// context may still be exploitable with the evasion
+					// payloads of higher levels
+					if attempt.anomalous(baselineStatus) || strings.Contains(attempt.Body, payload.Value) || strings.Contains(attempt.Body, html.EscapeString(payload.Value)) {
+						anomaly = true
+					}
 				}
+				return anomaly
+			})
 
-				bodyStr := string(body)
-				if strings.Contains(bodyStr, payload.Value) {
-					result.TestResults = append(result.TestResults, TestResult{
-						Payload:     payload,
-						URL:         testURL.String(),
-						Method:      "GET",
-						Parameter:   paramName,
-						Description: fmt.Sprintf("Potential XSS: Payload reflected in response for parameter '%s'", paramName),
-						Severity:    SeverityHigh,
-					})
+			if confirmer != nil {
+				for i := firstResult; i < len(result.TestResults); i++ {
+					confirmer.confirm(&result.TestResults[i])
 				}
 			}
+			s.emit(&result)
+		}
+
+		// Test each parameter
+		for paramName := range params {
+			check(paramName, fmt.Sprintf("parameter '%s'", paramName), func(payload Payload) []payloadAttempt {
+				return s.sendPayload(target, targetURL, params, paramName, payload)
+			})
+		}
+
+		// Test the request headers and cookies
+		for _, point := range s.injectionPoints(target) {
+			check(point.name(), point.describe(), func(payload Payload) []payloadAttempt {
+				return s.sendPayloadAt(target, point, payload)
+			})
 		}
 	}
 
@@ -295,57 +465,15 @@ func (s *Scanner) testSQLInjection(target ScanTarget) {
 		TestResults:       make([]TestResult, 0),
 	}
 
-	// Test URL parameters
-	if targetURL, err := url.Parse(target.URL); err == nil {
-		params := targetURL.Query()
-
-		// Add a test parameter if none exist
-		if len(params) == 0 {
-			params.Add("id", "1")
-		}
-
-		// Test each parameter
-		for paramName := range params {
-			normalValue := params.Get(paramName)
-
-			// Get baseline response
-			baselineResp, err := s.sendRequest(target, "GET", "", nil, "")
-			if err != nil {
-				continue
-			}
-			baselineBody, err := io.ReadAll(baselineResp.Body)
-			baselineResp.Body.Close()
-			if err != nil {
-				continue
-			}
-			baselineContent := string(baselineBody)
-
-			// Test with SQL injection payloads
-			for _, payload := range payloads {
-				// Create a copy of the parameters and modify the test parameter
-				testParams := url.Values{}
-				for k, v := range params {
-					testParams[k] = v
+	// inject sends the payloads in name and looks for SQL errors and
+	// responses far from the baseline
+	inject := func(name string, send func(payload Payload) []payloadAttempt, baselineStatus int, baselineContent string, evidence *sqliEvidence) {
+		s.testPayloads(name, payloads, func(payload Payload) bool {
+			anomaly := false
+			for _, attempt := range send(payload) {
+				if attempt.anomalous(baselineStatus) {
+					anomaly = true
 				}
-				testParams.Set(paramName, payload.Value)
-
-				// Construct test URL
-				testURL := *targetURL
-				testURL.RawQuery = testParams.Encode()
-
-				// Send request
-				resp, err := s.sendRequest(target, "GET", testURL.String(), nil, "")
-				if err != nil {
-					continue
-				}
-
-				body, err := io.ReadAll(resp.Body)
-				resp.Body.Close()
-				if err != nil {
-					continue
-				}
-
-				bodyStr := string(body)
 
 				// Check for SQL error patterns
 				sqlErrorPatterns := []string{
@@ -355,15 +483,20 @@ func (s *Scanner) testSQLInjection(target ScanTarget) {
 				}
 
 				for _, pattern := range sqlErrorPatterns {
-					if strings.Contains(bodyStr, pattern) {
+					if strings.Contains(attempt.Body, pattern) {
 						result.TestResults = append(result.TestResults, TestResult{
 							Payload:     payload,
-							URL:         testURL.String(),
+							URL:         attempt.URL,
 							Method:      "GET",
-							Parameter:   paramName,
-							Description: fmt.Sprintf("Potential SQL Injection: Error pattern '%s' detected", pattern),
+							Parameter:   name,
+							Description: attempt.describe(fmt.Sprintf("Potential SQL Injection: Error pattern '%s' detected", pattern)),
 							Severity:    SeverityCritical,
+							Match:       pattern,
+							Headers:     attempt.Headers,
+							Body:        attempt.Body,
 						})
+						evidence.ErrorBodies = append(evidence.ErrorBodies, attempt.Body)
+						anomaly = true
 						break
 					}
 				}
@@ -371,21 +504,107 @@ func (s *Scanner) testSQLInjection(target ScanTarget) {
 				// Check for significant differences in response (could indicate blind SQLi)
 				// Using float calculations to avoid truncation warnings
 				baselineLen := float64(len(baselineContent))
-				responseLen := float64(len(bodyStr))
-				if resp.StatusCode != baselineResp.StatusCode &&
+				responseLen := float64(len(attempt.Body))
+				if !attempt.Blocked && attempt.StatusCode != baselineStatus &&
 					(responseLen < baselineLen*0.8 || responseLen > baselineLen*1.2) {
 					result.TestResults = append(result.TestResults, TestResult{
 						Payload:     payload,
-						URL:         testURL.String(),
+						URL:         attempt.URL,
 						Method:      "GET",
-						Parameter:   paramName,
-						Description: "Potential Blind SQL Injection: Response significantly different from baseline",
+						Parameter:   name,
+						Description: attempt.describe("Potential Blind SQL Injection: Response significantly different from baseline"),
 						Severity:    SeverityHigh,
+						Headers:     attempt.Headers,
+						Confidence:  ConfidenceTentative,
+						Body:        attempt.Body,
 					})
 				}
+			}
+			return anomaly
+		})
+	}
+
+	// baseline returns the status and body of the unmodified page
+	baseline := func() (int, string, bool) {
+		resp, err := s.sendRequest(target, "GET", "", nil, "")
+		if err != nil {
+			return 0, "", false
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return 0, "", false
+		}
+		return resp.StatusCode, string(body), true
+	}
+
+	// Test URL parameters
+	if targetURL, err := url.Parse(target.URL); err == nil {
+		params := targetURL.Query()
+
+		// Add a test parameter if none exist
+		if len(params) == 0 {
+			params.Add("id", "1")
+		}
+
+		// Test each parameter
+		for paramName := range params {
+			normalValue := params.Get(paramName)
+			firstResult := len(result.TestResults)
+			evidence := sqliEvidence{}
 
-				// Reset parameter to original value
-				params.Set(paramName, normalValue)
+			// Get baseline response
+			baselineStatus, baselineContent, ok := baseline()
+			if !ok {
+				continue
+			}
+
+			// Test with SQL injection payloads
+			inject(paramName, func(payload Payload) []payloadAttempt {
+				return s.sendPayload(target, targetURL, params, paramName, payload)
+			}, baselineStatus, baselineContent, &evidence)
+
+			if s.ScanOptions.EnableBooleanSQLi {
+				if booleanResult, context, found := s.testBooleanBased(target, targetURL, params, paramName); found {
+					result.TestResults = append(result.TestResults, booleanResult)
+					evidence.BooleanContext = context
+				}
+			}
+
+			if s.ScanOptions.EnableTimeBasedSQLi {
+				if timeResult, found := s.testTimeBased(target, targetURL, params, paramName); found {
+					result.TestResults = append(result.TestResults, timeResult)
+					evidence.DBMS = timeResult.DBMS
+				}
+			}
+
+			// Identify the database behind a suspected injection
+			if len(result.TestResults) > firstResult && s.ScanOptions.EnableDBMSFingerprint {
+				if dbms := s.fingerprintDBMS(target, targetURL, params, paramName, evidence); dbms != "" {
+					applyDBMS(result.TestResults[firstResult:], dbms)
+				}
+			}
+			addSqlmapCommands(target, payloadURL(targetURL, params, paramName, normalValue), paramName, result.TestResults[firstResult:])
+			s.emit(&result)
+		}
+	}
+
+	// Test the request headers and cookies. Only the error and response
+	// checks run there: the blind tests work on URL parameters.
+	if points := s.injectionPoints(target); len(points) > 0 {
+		if baselineStatus, baselineContent, ok := baseline(); ok {
+			for _, point := range points {
+				firstResult := len(result.TestResults)
+				evidence := sqliEvidence{}
+				inject(point.name(), func(payload Payload) []payloadAttempt {
+					return s.sendPayloadAt(target, point, payload)
+				}, baselineStatus, baselineContent, &evidence)
+
+				if dbms := matchErrors(evidence.ErrorBodies); dbms != nil && s.ScanOptions.EnableDBMSFingerprint {
+					applyDBMS(result.TestResults[firstResult:], dbms.Name)
+				}
+				addSqlmapCommands(point.mark(target), target.URL, "", result.TestResults[firstResult:])
+				s.emit(&result)
 			}
 		}
 	}
@@ -397,12 +616,23 @@ func (s *Scanner) testSQLInjection(target ScanTarget) {
 
 // testFileInclusion tests for File Inclusion vulnerabilities
 func (s *Scanner) testFileInclusion(target ScanTarget) {
-	payloads := s.payloads.GetPayloads(VulnTypeFileInclusion)
+	matchers := s.inclusionMatchers()
 	result := ScanResult{
 		VulnerabilityType: VulnTypeFileInclusion,
 		TestResults:       make([]TestResult, 0),
 	}
 
+	// Intrusive payloads run code on the target and need explicit consent
+	payloads := []Payload{}
+	seen := map[string]bool{}
+	for _, payload := range append(inclusionPayloads(matchers), s.payloads.GetPayloads(VulnTypeFileInclusion)...) {
+		if seen[payload.Value] || (payload.Intrusive && !s.ScanOptions.IntrusiveTests) {
+			continue
+		}
+		seen[payload.Value] = true
+		payloads = append(payloads, payload)
+	}
+
 	// Test URL parameters
 	if targetURL, err := url.Parse(target.URL); err == nil {
 		params := targetURL.Query()
@@ -430,56 +660,53 @@ func (s *Scanner) testFileInclusion(target ScanTarget) {
 				continue
 			}
 
-			// Test with file inclusion payloads
-			for _, payload := range payloads {
-				// Create a copy of the parameters and modify the test parameter
-				testParams := url.Values{}
-				for k, v := range params {
-					testParams[k] = v
-				}
-				testParams.Set(paramName, payload.Value)
-
-				// Construct test URL
-				testURL := *targetURL
-				testURL.RawQuery = testParams.Encode()
-
-				// Send request
-				resp, err := s.sendRequest(target, "GET", testURL.String(), nil, "")
-				if err != nil {
-					continue
-				}
+			// File content already on the unmodified page doesn't prove an inclusion
+			baseline, err := s.sendEncoded(target, targetURL, params, paramName, Payload{Value: params.Get(paramName)}, nil)
+			if err != nil {
+				continue
+			}
 
-				body, err := io.ReadAll(resp.Body)
-				resp.Body.Close()
-				if err != nil {
-					continue
+			// Test with file inclusion payloads. The first confirmed file
+			// ends the parameter's test, so only the shallowest working
+			// traversal is sent and reported.
+			confirmed := false
+			s.testPayloads(paramName, payloads, func(payload Payload) bool {
+				if confirmed {
+					return false
 				}
 
-				bodyStr := string(body)
-
-				// Check for file content patterns
-				fileContentPatterns := map[string][]string{
-					"../../../../../etc/passwd":            {"root:", "nobody:", "/bin/", "/home/"},
-					"/etc/passwd":                          {"root:", "nobody:", "/bin/", "/home/"},
-					"..\\..\\..\\..\\..\\windows\\win.ini": {"[extensions]", "[fonts]", "[mci extensions]"},
-				}
+				anomaly := false
+				for _, attempt := range s.sendPayload(target, targetURL, params, paramName, payload) {
+					if attempt.anomalous(baseline.StatusCode) {
+						anomaly = true
+					}
 
-				if patterns, exists := fileContentPatterns[payload.Value]; exists {
-					for _, pattern := range patterns {
-						if strings.Contains(bodyStr, pattern) {
-							result.TestResults = append(result.TestResults, TestResult{
-								Payload:     payload,
-								URL:         testURL.String(),
-								Method:      "GET",
-								Parameter:   paramName,
-								Description: fmt.Sprintf("File Inclusion Vulnerability: Found pattern '%s' in response", pattern),
-								Severity:    SeverityCritical,
-							})
-							break
-						}
+					if path, match, found := matchInclusion(matchers, attempt.Body, baseline.Body); found {
+						confirmed = true
+						result.TestResults = append(result.TestResults, TestResult{
+							Payload:     payload,
+							URL:         attempt.URL,
+							Method:      "GET",
+							Parameter:   paramName,
+							Description: attempt.describe(fmt.Sprintf("File Inclusion Vulnerability: Content of '/%s' found in response (%q)", path, match)),
+							Severity:    SeverityCritical,
+							Match:       match,
+							Body:        attempt.Body,
+						})
+						return true
 					}
 				}
+				return anomaly
+			})
+
+			if sourceResult, found := s.testSourceDisclosure(target, targetURL, params, paramName); found {
+				result.TestResults = append(result.TestResults, sourceResult)
 			}
+
+			if s.ScanOptions.IntrusiveTests {
+				result.TestResults = append(result.TestResults, s.testIntrusiveInclusion(target, targetURL, params, paramName)...)
+			}
+			s.emit(&result)
 		}
 	}
 
@@ -598,6 +825,7 @@ func (s *Scanner) testMisconfigurations(target ScanTarget) {
 				Method:      "GET",
 				Description: fmt.Sprintf("Missing security header: %s", header),
 				Severity:    SeverityMedium,
+				Confidence:  ConfidenceCertain,
 			})
 		} else if recommended != "" && !strings.Contains(headerValue, recommended) {
 			result.TestResults = append(result.TestResults, TestResult{
@@ -605,17 +833,43 @@ func (s *Scanner) testMisconfigurations(target ScanTarget) {
 				Method:      "GET",
 				Description: fmt.Sprintf("Misconfigured security header: %s (Value: %s, Recommended: %s)", header, headerValue, recommended),
 				Severity:    SeverityLow,
+				Confidence:  ConfidenceCertain,
 			})
 		}
 	}
 
+	// Check the Content Security Policy for bypasses
+	if policy := resp.Header.Get("Content-Security-Policy"); policy != "" {
+		result.TestResults = append(result.TestResults, analyzeCSP(target.URL, ParseCSP(policy), false)...)
+	} else if policy := resp.Header.Get("Content-Security-Policy-Report-Only"); policy != "" {
+		result.TestResults = append(result.TestResults, analyzeCSP(target.URL, ParseCSP(policy), true)...)
+	}
+
+	// Run the framework check packs selected or fingerprinted on the page
+	if len(s.ScanOptions.FrameworkPacks) > 0 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+		result.TestResults = append(result.TestResults, s.checkFrameworks(target, resp, string(body))...)
+	}
+
 	// Check for misconfigurations in common paths
 	for _, payload := range payloads {
+		// CI/CD consoles are probed on the target's origin
+		if payload.Value == "CICD_CONSOLES" {
+			result.TestResults = append(result.TestResults, s.checkCICDConsoles(target, payload)...)
+			continue
+		}
+
 		// Only test paths - skip header checks which we already did
 		if !strings.HasPrefix(payload.Value, "/") && !strings.Contains(payload.Value, ":") {
 			continue
 		}
 
+		// Spring Boot actuators are enumerated beyond the health endpoint
+		if payload.Value == "/actuator/health" {
+			result.TestResults = append(result.TestResults, s.checkActuators(target, payload)...)
+			continue
+		}
+
 		resp, err := s.sendRequest(target, "GET", payload.Value, nil, "")
 		if err != nil {
 			continue
@@ -635,6 +889,7 @@ func (s *Scanner) testMisconfigurations(target ScanTarget) {
 				Method:      "GET",
 				Description: fmt.Sprintf("Potential security misconfiguration: %s", payload.Description),
 				Severity:    SeverityHigh,
+				Confidence:  ConfidenceTentative,
 			})
 		}
 	}
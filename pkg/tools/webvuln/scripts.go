@@ -0,0 +1,283 @@
+// pkg/tools/webvuln/scripts.go
+package webvuln
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+const (
+	// scriptExtension is the file extension of custom check scripts
+	scriptExtension = ".star"
+
+	// maxScriptSteps bounds the work a script may do per page
+	maxScriptSteps = 10000000
+
+	// maxScriptBody is the number of response body bytes passed to scripts
+	maxScriptBody = 2 << 20
+)
+
+// Script is a custom check written in Starlark. It defines a function
+//
+//	def check(request, response):
+//	    return [{"name": ..., "severity": ..., "description": ...}]
+//
+// called for every crawled page and JSON response of the scan.
+type Script struct {
+	Name  string
+	Path  string
+	check *starlark.Function
+}
+
+// LoadScripts loads every custom check script in a directory. A missing
+// directory holds no scripts.
+func LoadScripts(dir string) ([]*Script, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+scriptExtension))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	scripts := []*Script{}
+	for _, path := range paths {
+		script, err := LoadScript(path)
+		if err != nil {
+			return nil, err
+		}
+		scripts = append(scripts, script)
+	}
+	return scripts, nil
+}
+
+// LoadScript loads a custom check script
+func LoadScript(path string) (*Script, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script: %v", err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), scriptExtension)
+	thread := &starlark.Thread{Name: name, Print: scriptPrint}
+	thread.SetMaxExecutionSteps(maxScriptSteps)
+
+	globals, err := starlark.ExecFileOptions(scriptFileOptions, thread, path, source, scriptBuiltins)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load script %s: %v", path, err)
+	}
+
+	check, ok := globals["check"].(*starlark.Function)
+	if !ok {
+		return nil, fmt.Errorf("script %s does not define a check(request, response) function", path)
+	}
+	if check.NumParams() != 2 {
+		return nil, fmt.Errorf("script %s: check must take (request, response)", path)
+	}
+
+	return &Script{Name: name, Path: path, check: check}, nil
+}
+
+// scriptFileOptions enables the language features scripts may use
+var scriptFileOptions = &syntax.FileOptions{
+	Set:             true,
+	While:           true,
+	TopLevelControl: true,
+	GlobalReassign:  true,
+}
+
+// scriptBuiltins are the helper functions available to scripts besides the
+// Starlark built-ins
+var scriptBuiltins = starlark.StringDict{
+	"regex_search": starlark.NewBuiltin("regex_search", regexSearch),
+	"struct":       starlark.NewBuiltin("struct", starlarkstruct.Make),
+}
+
+// regexSearch implements regex_search(pattern, text), returning the first
+// match or None
+func regexSearch(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern, text string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "pattern", &pattern, "text", &text); err != nil {
+		return nil, err
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fn.Name(), err)
+	}
+	loc := re.FindStringIndex(text)
+	if loc == nil {
+		return starlark.None, nil
+	}
+	return starlark.String(text[loc[0]:loc[1]]), nil
+}
+
+// scriptPrint shows print() output of scripts
+func scriptPrint(thread *starlark.Thread, msg string) {
+	fmt.Printf("[i] %s: %s\n", thread.Name, msg)
+}
+
+// Check runs the script for a page and returns its findings
+func (sc *Script) Check(req *http.Request, resp *http.Response, body []byte) ([]TestResult, error) {
+	thread := &starlark.Thread{Name: sc.Name, Print: scriptPrint}
+	thread.SetMaxExecutionSteps(maxScriptSteps)
+
+	value, err := starlark.Call(thread, sc.check, starlark.Tuple{scriptRequest(req), scriptResponse(resp, body)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return sc.findings(value, req)
+}
+
+// findings converts the value returned by check into test results. Scripts
+// may return None, a single finding or a list of findings.
+func (sc *Script) findings(value starlark.Value, req *http.Request) ([]TestResult, error) {
+	var items []starlark.Value
+	switch v := value.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case *starlark.Dict:
+		items = []starlark.Value{v}
+	case *starlark.List:
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, v.Index(i))
+		}
+	default:
+		return nil, fmt.Errorf("check returned %s, want a dict, a list of dicts or None", value.Type())
+	}
+
+	results := []TestResult{}
+	for _, item := range items {
+		finding, ok := item.(*starlark.Dict)
+		if !ok {
+			return nil, fmt.Errorf("check returned a list containing %s, want dicts", item.Type())
+		}
+
+		name := dictString(finding, "name")
+		if name == "" {
+			name = sc.Name
+		}
+		description := name
+		if detail := dictString(finding, "description"); detail != "" {
+			description = name + ": " + detail
+		}
+
+		results = append(results, TestResult{
+			URL:         req.URL.String(),
+			Method:      req.Method,
+			Parameter:   dictString(finding, "parameter"),
+			Description: fmt.Sprintf("[%s] %s", sc.Name, description),
+			Severity:    parseSeverity(dictString(finding, "severity")),
+		})
+	}
+	return results, nil
+}
+
+// scriptRequest exposes a request to scripts
+func scriptRequest(req *http.Request) starlark.Value {
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"method":  starlark.String(req.Method),
+		"url":     starlark.String(req.URL.String()),
+		"path":    starlark.String(req.URL.Path),
+		"query":   starlark.String(req.URL.RawQuery),
+		"headers": scriptHeaders(req.Header),
+	})
+}
+
+// scriptResponse exposes a response and its body to scripts
+func scriptResponse(resp *http.Response, body []byte) starlark.Value {
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"status":  starlark.MakeInt(resp.StatusCode),
+		"headers": scriptHeaders(resp.Header),
+		"body":    starlark.String(body),
+	})
+}
+
+// scriptHeaders converts headers to a dict keyed by lowercase header name,
+// joining repeated headers with ", "
+func scriptHeaders(header http.Header) *starlark.Dict {
+	dict := starlark.NewDict(len(header))
+	for name, values := range header {
+		dict.SetKey(starlark.String(strings.ToLower(name)), starlark.String(strings.Join(values, ", ")))
+	}
+	dict.Freeze()
+	return dict
+}
+
+// dictString returns a string value of a dict, or "" if it is missing
+func dictString(dict *starlark.Dict, key string) string {
+	value, found, err := dict.Get(starlark.String(key))
+	if err != nil || !found {
+		return ""
+	}
+	if s, ok := starlark.AsString(value); ok {
+		return s
+	}
+	return value.String()
+}
+
+// parseSeverity maps a severity name to a Severity, defaulting to Info
+func parseSeverity(name string) Severity {
+	switch strings.ToLower(name) {
+	case "critical":
+		return SeverityCritical
+	case "high":
+		return SeverityHigh
+	case "medium":
+		return SeverityMedium
+	case "low":
+		return SeverityLow
+	default:
+		return SeverityInfo
+	}
+}
+
+// testScripts runs the custom check scripts against the crawled pages and
+// the JSON responses met while crawling
+func (s *Scanner) testScripts(target ScanTarget) {
+	scripts, err := LoadScripts(s.ScanOptions.ScriptsDirectory)
+	if err != nil {
+		fmt.Printf("[!] Custom checks disabled: %v\n", err)
+		return
+	}
+	if len(scripts) == 0 {
+		return
+	}
+
+	result := ScanResult{
+		VulnerabilityType: VulnTypeCustom,
+		TestResults:       make([]TestResult, 0),
+	}
+	pages := append(append([]crawledPage{}, s.crawl(target)...), s.jsonPages...)
+	failed := map[string]bool{}
+	for _, page := range pages {
+		body := []byte(page.Body)
+		if len(body) > maxScriptBody {
+			body = body[:maxScriptBody]
+		}
+		for _, script := range scripts {
+			if failed[script.Name] {
+				continue
+			}
+			findings, err := script.Check(page.Response.Request, page.Response, body)
+			if err != nil {
+				// A failing script is reported once and skipped on the other pages
+				fmt.Printf("[!] Custom check %s failed: %v\n", script.Name, err)
+				failed[script.Name] = true
+				continue
+			}
+			result.TestResults = append(result.TestResults, findings...)
+		}
+	}
+
+	if len(result.TestResults) > 0 {
+		s.addResult(result)
+	}
+}
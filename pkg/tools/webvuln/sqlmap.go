@@ -0,0 +1,78 @@
+// pkg/tools/webvuln/sqlmap.go
+package webvuln
+
+import (
+	"sort"
+	"strings"
+)
+
+// sqlmapTechniques maps the SQL injection finding descriptions to the sqlmap
+// technique letters that reproduce them
+var sqlmapTechniques = map[string]string{
+	"Potential SQL Injection: Error": "E",
+	"Boolean-based":                  "B",
+	"Time-based":                     "T",
+}
+
+// sqlmapCommand returns a sqlmap command line that picks up a SQL injection
+// finding: the target URL with the original parameter values, the tested
+// parameter, the target's cookies, headers and credentials, and the DBMS and
+// technique if they are known. Without a parameter, the injection point is
+// marked with "*" in a header or cookie of the target.
+func sqlmapCommand(target ScanTarget, targetURL, paramName string, result TestResult) string {
+	args := []string{"sqlmap", "-u", shellQuote(targetURL)}
+	if paramName != "" {
+		args = append(args, "-p", shellQuote(paramName))
+	}
+
+	if len(target.Cookies) > 0 {
+		args = append(args, "--cookie="+shellQuote(strings.Join(target.Cookies, "; ")))
+	}
+
+	names := make([]string, 0, len(target.Headers))
+	for name := range target.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, "-H", shellQuote(name+": "+target.Headers[name]))
+	}
+
+	if target.BasicAuth.Username != "" {
+		args = append(args, "--auth-type=Basic", "--auth-cred="+shellQuote(target.BasicAuth.Username+":"+target.BasicAuth.Password))
+	}
+
+	for _, dbms := range dbmsFingerprints {
+		if result.DBMS == dbms.Name || strings.HasPrefix(result.DBMS, dbms.Name+" ") {
+			args = append(args, "--dbms="+shellQuote(dbms.Name))
+			break
+		}
+	}
+
+	for prefix, technique := range sqlmapTechniques {
+		if strings.HasPrefix(result.Description, prefix) {
+			args = append(args, "--technique="+technique)
+			break
+		}
+	}
+
+	return strings.Join(append(args, "--batch"), " ")
+}
+
+// addSqlmapCommands stores a sqlmap command with each confirmed (Critical)
+// SQL injection finding of a parameter
+func addSqlmapCommands(target ScanTarget, targetURL, paramName string, results []TestResult) {
+	for i := range results {
+		if results[i].Severity == SeverityCritical {
+			results[i].SqlmapCommand = sqlmapCommand(target, targetURL, paramName, results[i])
+		}
+	}
+}
+
+// shellQuote quotes a string for POSIX shells
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,168 @@
+// pkg/tools/webvuln/sri.go
+package webvuln
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ExternalResource is a script or stylesheet loaded by the crawled pages
+type ExternalResource struct {
+	URL        string
+	Type       string   // "script" or "stylesheet"
+	Origin     string   // Scheme, host and port serving the resource
+	ThirdParty bool     // Served from another origin than the target
+	Integrity  bool     // Every page loads it with an integrity attribute
+	Pages      []string // Pages loading the resource
+	Risk       Severity // Supply-chain risk: a compromised origin can change the resource
+	Library    string   // Library named by the URL, such as jquery
+	Version    string   // Version of the library
+}
+
+// resourceRisk rates the supply-chain risk of a resource. A third-party
+// script without integrity runs whatever its origin serves; a stylesheet can
+// restyle the page and leak its content through CSS selectors.
+func resourceRisk(resource ExternalResource) Severity {
+	switch {
+	case !resource.ThirdParty:
+		return SeverityInfo
+	case resource.Integrity:
+		return SeverityLow
+	case resource.Type == "script":
+		return SeverityHigh
+	default:
+		return SeverityMedium
+	}
+}
+
+// pageResources returns the external scripts and stylesheets of a page
+// with whether they carry an integrity attribute
+func pageResources(page crawledPage) []ExternalResource {
+	resources := []ExternalResource{}
+	for _, tag := range parseTags(page.Body) {
+		var resource ExternalResource
+		switch {
+		case tag.Name == "script":
+			resource = ExternalResource{URL: tag.Attrs["src"], Type: "script"}
+		case tag.Name == "link" && strings.Contains(strings.ToLower(tag.Attrs["rel"]), "stylesheet"):
+			resource = ExternalResource{URL: tag.Attrs["href"], Type: "stylesheet"}
+		default:
+			continue
+		}
+
+		link := resolveLink(page.URL, resource.URL)
+		if link == nil {
+			continue
+		}
+		resource.URL = link.String()
+		resource.Origin = link.Scheme + "://" + link.Host
+		resource.ThirdParty = !sameOrigin(link, page.URL)
+		resource.Integrity = strings.TrimSpace(tag.Attrs["integrity"]) != ""
+		resource.Library, resource.Version, _ = DetectLibrary(resource.URL)
+		resources = append(resources, resource)
+	}
+	return resources
+}
+
+// testSubresourceIntegrity inventories the scripts and stylesheets of the
+// crawled pages and reports third-party origins serving them without
+// Subresource Integrity
+func (s *Scanner) testSubresourceIntegrity(target ScanTarget) {
+	result := ScanResult{
+		VulnerabilityType: VulnTypeSupplyChain,
+		TestResults:       make([]TestResult, 0),
+	}
+
+	// Merge the resources of all pages
+	inventory := map[string]*ExternalResource{}
+	for _, page := range s.crawl(target) {
+		for _, resource := range pageResources(page) {
+			entry, exists := inventory[resource.URL]
+			if !exists {
+				entry = &ExternalResource{URL: resource.URL, Type: resource.Type, Origin: resource.Origin, ThirdParty: resource.ThirdParty, Integrity: true,
+					Library: resource.Library, Version: resource.Version}
+				inventory[resource.URL] = entry
+			}
+			entry.Integrity = entry.Integrity && resource.Integrity
+			if len(entry.Pages) == 0 || entry.Pages[len(entry.Pages)-1] != page.URL.String() {
+				entry.Pages = append(entry.Pages, page.URL.String())
+			}
+		}
+	}
+
+	resources := make([]ExternalResource, 0, len(inventory))
+	for _, entry := range inventory {
+		entry.Risk = resourceRisk(*entry)
+		resources = append(resources, *entry)
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Origin != resources[j].Origin {
+			return resources[i].Origin < resources[j].Origin
+		}
+		return resources[i].URL < resources[j].URL
+	})
+
+	// Report each third-party origin once
+	origins := []string{}
+	unprotected := map[string][]ExternalResource{}
+	for _, resource := range resources {
+		if !resource.ThirdParty || resource.Integrity {
+			continue
+		}
+		if _, exists := unprotected[resource.Origin]; !exists {
+			origins = append(origins, resource.Origin)
+		}
+		unprotected[resource.Origin] = append(unprotected[resource.Origin], resource)
+	}
+
+	for _, origin := range origins {
+		scripts, stylesheets := 0, 0
+		pages := map[string]bool{}
+		severity := SeverityMedium
+		for _, resource := range unprotected[origin] {
+			if resource.Type == "script" {
+				scripts++
+				severity = SeverityHigh
+			} else {
+				stylesheets++
+			}
+			for _, page := range resource.Pages {
+				pages[page] = true
+			}
+		}
+
+		result.TestResults = append(result.TestResults, TestResult{
+			URL:       unprotected[origin][0].URL,
+			Method:    "GET",
+			Parameter: origin,
+			Description: fmt.Sprintf("Third-party resources without Subresource Integrity: %s serves %d script(s) and %d stylesheet(s) to %d page(s)",
+				origin, scripts, stylesheets, len(pages)),
+			Severity: severity,
+		})
+	}
+
+	s.mutex.Lock()
+	s.resources = resources
+	s.mutex.Unlock()
+
+	if len(result.TestResults) > 0 {
+		s.addResult(result)
+	}
+}
+
+// supplyChainRisks returns the third-party resources of a report, riskiest
+// first
+func supplyChainRisks(report *Report) []ExternalResource {
+	severityOrder := []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo}
+
+	risks := []ExternalResource{}
+	for _, severity := range severityOrder {
+		for _, resource := range report.Resources {
+			if resource.ThirdParty && resource.Risk == severity {
+				risks = append(risks, resource)
+			}
+		}
+	}
+	return risks
+}
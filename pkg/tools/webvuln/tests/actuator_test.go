@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const actuatorEnv = `{
+  "activeProfiles": ["prod"],
+  "propertySources": [
+    {"name": "systemEnvironment", "properties": {
+      "DB_PASSWORD": {"value": "s3cr3t-db-pass", "origin": "System Environment Property \"DB_PASSWORD\""},
+      "JAVA_HOME": {"value": "/usr/lib/jvm/java-17"}
+    }},
+    {"name": "applicationConfig: [classpath:/application.yml]", "properties": {
+      "spring.datasource.password": {"value": "******"},
+      "jwt.secret": {"value": "c2lnbmluZy1rZXk="},
+      "server.port": {"value": 8080}
+    }}
+  ]
+}`
+
+func TestActuatorParsing(t *testing.T) {
+	if ok, details := webvuln.ActuatorHealth([]byte(`{"status":"UP","components":{"db":{"status":"UP"}}}`)); !ok || !details {
+		t.Errorf("ActuatorHealth() = %v, %v, want a health response with details", ok, details)
+	}
+	if ok, _ := webvuln.ActuatorHealth([]byte(`{"status":"ok"}`)); ok {
+		t.Error("ActuatorHealth() accepted a status other than Spring's")
+	}
+
+	links := webvuln.ActuatorLinks([]byte(`{"_links":{"self":{"href":"/actuator","templated":false},"env":{"href":"/actuator/env"},"env-toMatch":{"href":"/actuator/env/{toMatch}","templated":true},"heapdump":{"href":"/actuator/heapdump"}}}`))
+	if want := []string{"env", "heapdump"}; !reflect.DeepEqual(links, want) {
+		t.Errorf("ActuatorLinks() = %v, want %v", links, want)
+	}
+
+	secrets := webvuln.ActuatorSecrets([]byte(actuatorEnv))
+	want := []webvuln.ActuatorSecret{
+		{Source: "systemEnvironment", Name: "DB_PASSWORD", Value: "s3cr3t-db-pass"},
+		{Source: "applicationConfig: [classpath:/application.yml]", Name: "jwt.secret", Value: "c2lnbmluZy1rZXk="},
+	}
+	if !reflect.DeepEqual(secrets, want) {
+		t.Errorf("ActuatorSecrets() = %+v, want %+v", secrets, want)
+	}
+
+	legacy := webvuln.ActuatorSecrets([]byte(`{"profiles":[],"systemProperties":{"aws.secretKey":"wJalrXUtnFEMI","java.version":"1.8"}}`))
+	if len(legacy) != 1 || legacy[0].Name != "aws.secretKey" {
+		t.Errorf("ActuatorSecrets() = %+v for a Spring Boot 1 env", legacy)
+	}
+}
+
+func TestActuatorScan(t *testing.T) {
+	envRequested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/shop/actuator/health":
+			fmt.Fprint(w, `{"status":"UP"}`)
+		case "/shop/actuator":
+			fmt.Fprint(w, `{"_links":{"self":{"href":"/shop/actuator"},"health":{"href":"/shop/actuator/health"},"env":{"href":"/shop/actuator/env"},"heapdump":{"href":"/shop/actuator/heapdump"},"mappings":{"href":"/shop/actuator/mappings"},"custom":{"href":"/shop/actuator/custom"}}}`)
+		case "/shop/actuator/env":
+			envRequested = true
+			fmt.Fprint(w, actuatorEnv)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	scan := func(secrets bool) map[string]webvuln.TestResult {
+		options := webvuln.ScanOptions{
+			PayloadLevel:           5,
+			Timeout:                5,
+			MaxRedirects:           5,
+			EnableMisconfiguration: true,
+			ActuatorSecrets:        secrets,
+		}
+		report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/shop/index.html", Method: "GET"})
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		found := make(map[string]webvuln.TestResult)
+		for _, result := range report.Results {
+			for _, test := range result.TestResults {
+				if test.Payload.Value == "/actuator/health" {
+					found[test.URL+" "+test.Parameter] = test
+				}
+			}
+		}
+		return found
+	}
+
+	found := scan(false)
+	base := server.URL + "/shop/actuator"
+	want := map[string]webvuln.Severity{
+		base + "/health ":   webvuln.SeverityInfo,
+		base + "/env ":      webvuln.SeverityHigh,
+		base + "/heapdump ": webvuln.SeverityCritical,
+		base + "/mappings ": webvuln.SeverityMedium,
+	}
+	if len(found) != len(want) {
+		t.Fatalf("got %d actuator findings, want %d: %+v", len(found), len(want), found)
+	}
+	for key, severity := range want {
+		if found[key].Severity != severity {
+			t.Errorf("%s = %s, want %s", key, found[key].Severity, severity)
+		}
+	}
+	if evidence := found[base+"/health "].Evidence; evidence != "Exposed endpoints: health, custom, env, heapdump, mappings" {
+		t.Errorf("health evidence = %q", evidence)
+	}
+	if envRequested {
+		t.Error("env was read without ActuatorSecrets")
+	}
+
+	found = scan(true)
+	leak, ok := found[base+"/env DB_PASSWORD"]
+	if !ok || leak.Severity != webvuln.SeverityCritical || strings.Contains(leak.Description, "s3cr3t-db-pass") {
+		t.Errorf("DB_PASSWORD finding = %+v, want a critical finding with a redacted sample", leak)
+	}
+	if _, ok := found[base+"/env spring.datasource.password"]; ok {
+		t.Error("reported a property Spring masked")
+	}
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const swaggerSpec = `{
+  "swagger": "2.0",
+  "info": {"title": "Shop API", "version": "1.4"},
+  "basePath": "/api",
+  "paths": {
+    "/users/{id}": {"get": {}, "delete": {}, "parameters": []},
+    "/users": {"get": {}, "post": {}}
+  }
+}`
+
+func TestParseAPISpec(t *testing.T) {
+	document := webvuln.ParseAPISpec("http://example.com/v2/api-docs", []byte(swaggerSpec))
+	if document == nil {
+		t.Fatal("ParseAPISpec() = nil for a Swagger 2.0 specification")
+	}
+	if document.Kind != webvuln.APIDocSwagger || document.Title != "Shop API" || document.Version != "1.4" {
+		t.Errorf("ParseAPISpec() = %s %s %s", document.Kind, document.Title, document.Version)
+	}
+	wantOperations := []string{"GET /users", "POST /users", "DELETE /users/{id}", "GET /users/{id}"}
+	wantEndpoints := []string{"http://example.com/api/users", "http://example.com/api/users/1"}
+	if !reflect.DeepEqual(document.Operations, wantOperations) || !reflect.DeepEqual(document.Endpoints, wantEndpoints) {
+		t.Errorf("ParseAPISpec() = %v %v, want %v %v", document.Operations, document.Endpoints, wantOperations, wantEndpoints)
+	}
+
+	openAPI := `{"openapi":"3.0.1","info":{"title":"Orders"},"servers":[{"url":"/v1"}],"paths":{"/orders/{orderId}":{"get":{}}}}`
+	document = webvuln.ParseAPISpec("https://example.com/openapi.json", []byte(openAPI))
+	if document == nil || document.Kind != webvuln.APIDocOpenAPI || !reflect.DeepEqual(document.Endpoints, []string{"https://example.com/v1/orders/1"}) {
+		t.Errorf("ParseAPISpec() = %+v for an OpenAPI 3 specification", document)
+	}
+
+	if document := webvuln.ParseAPISpec("http://example.com/api-docs", []byte(`{"paths":{}}`)); document != nil {
+		t.Errorf("ParseAPISpec() = %+v for JSON without a version", document)
+	}
+}
+
+func TestParseAPIDocPage(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		kind string
+		spec string
+	}{
+		{"Swagger UI", `<div id="swagger-ui"></div><script>SwaggerUIBundle({url: "/v2/api-docs", dom_id: '#swagger-ui'})</script>`, webvuln.APIDocSwaggerUI, "http://example.com/v2/api-docs"},
+		{"Redoc", `<redoc spec-url="openapi.json"></redoc><script src="redoc.standalone.js"></script>`, webvuln.APIDocRedoc, "http://example.com/docs/openapi.json"},
+		{"GraphiQL", `<title>GraphiQL</title><div id="graphiql">Loading...</div>`, webvuln.APIDocGraphQLIDE, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := webvuln.ParseAPIDocPage("http://example.com/docs/", tt.body)
+			if document == nil || document.Kind != tt.kind || document.SpecURL != tt.spec {
+				t.Errorf("ParseAPIDocPage() = %+v, want %s loading %q", document, tt.kind, tt.spec)
+			}
+		})
+	}
+
+	if document := webvuln.ParseAPIDocPage("http://example.com/docs", "<html><body>User guide</body></html>"); document != nil {
+		t.Errorf("ParseAPIDocPage() = %+v for a plain page", document)
+	}
+}
+
+func TestAPIDocumentationScan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, "<html><body>Shop</body></html>")
+		case "/swagger-ui.html":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<div id="swagger-ui"></div><script>SwaggerUIBundle({url: "/v2/api-docs"})</script>`)
+		case "/v2/api-docs":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, swaggerSpec)
+		case "/api/users/1":
+			// Only reachable through the specification
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id": 1, "ssn": "123-45-6789"}`)
+		case "/graphql":
+			if r.Method != "POST" {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"data":{"__schema":{"queryType":{"fields":[{"name":"users"}]},"mutationType":{"fields":[{"name":"deleteUser"}]},"subscriptionType":null}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	options := webvuln.ScanOptions{
+		PayloadLevel:         5,
+		Timeout:              5,
+		MaxRedirects:         5,
+		MaxCrawlPages:        10,
+		EnableInfoDisclosure: true,
+		EnableAPISchema:      true,
+	}
+	report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	documents := make(map[string]webvuln.TestResult)
+	var leaks []webvuln.TestResult
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			if test.Payload.Value == "API_DOCUMENTATION" {
+				documents[test.URL] = test
+			}
+			if test.Parameter == "ssn" {
+				leaks = append(leaks, test)
+			}
+		}
+	}
+	if len(documents) != 3 {
+		t.Fatalf("got %d API documents, want Swagger UI, the specification and introspection: %+v", len(documents), documents)
+	}
+
+	if ui := documents[server.URL+"/swagger-ui.html"]; !strings.Contains(ui.Evidence, "Specification: "+server.URL+"/v2/api-docs") {
+		t.Errorf("Swagger UI evidence = %q, want the specification it loads", ui.Evidence)
+	}
+	spec := documents[server.URL+"/v2/api-docs"]
+	if spec.Description != "Swagger 2.0 specification exposed: Shop API 1.4 (4 operations)" || !strings.Contains(spec.Evidence, "DELETE /users/{id}") {
+		t.Errorf("specification = %q with evidence %q", spec.Description, spec.Evidence)
+	}
+	introspection := documents[server.URL+"/graphql"]
+	if introspection.Severity != webvuln.SeverityMedium || introspection.Method != "POST" || !strings.Contains(introspection.Evidence, "mutation deleteUser") {
+		t.Errorf("introspection = %s %s with evidence %q", introspection.Method, introspection.Severity, introspection.Evidence)
+	}
+
+	// The documented endpoints are tested for sensitive data
+	if len(leaks) != 1 || leaks[0].URL != server.URL+"/api/users/1" {
+		t.Errorf("got %+v, want the SSN of the documented endpoint /api/users/1", leaks)
+	}
+}
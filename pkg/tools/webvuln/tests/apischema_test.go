@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAPIResponses(t *testing.T) {
+	responses := map[string]string{
+		"/api/users/1": `{"id": 1, "email": "alice@example.com", "password": "$2b$12$KIXQJ8h6x9Zx1h2y3Z4u5OeWq7c8d9e0f1g2h3i4j5k6l7m8n9o0p",
+			"profile": {"backend": "10.0.0.12", "tags": ["admin"]}}`,
+		"/api/users/2":  `{"id": 2, "email": "bob@example.com", "password": "", "profile": null}`,
+		"/api/orders":   `{"error": "Traceback (most recent call last):\n  File \"app.py\", line 12", "items": []}`,
+		"/api/settings": `<html>not an API</html>`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body><a href="/api/users/1">Me</a>
+				<script>fetch("/api/users/2"); fetch('/api/orders'); fetch("/api/settings")</script></body></html>`)
+			return
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasPrefix(body, "{") {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "text/html")
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	options := webvuln.ScanOptions{
+		PayloadLevel:    1,
+		Timeout:         5,
+		MaxRedirects:    5,
+		MaxCrawlPages:   10,
+		EnableAPISchema: true,
+	}
+	report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	if len(report.APISchemas) != 2 {
+		t.Fatalf("recorded %d endpoints, want 2: %+v", len(report.APISchemas), report.APISchemas)
+	}
+	users := report.APISchemas[0]
+	if users.Endpoint != "GET /api/users/{id}" || len(users.URLs) != 2 {
+		t.Errorf("first endpoint = %s with %d URLs, want GET /api/users/{id} with 2", users.Endpoint, len(users.URLs))
+	}
+	if users.Fields["profile.tags[]"] != "string" || users.Fields["profile"] != "object" || users.Fields["id"] != "number" {
+		t.Errorf("fields = %v", users.Fields)
+	}
+
+	// The loopback test server is internal, so its internal addresses are not leaks
+	found := map[string]webvuln.Severity{}
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			found[test.Parameter] = test.Severity
+			if strings.Contains(test.Description, "KIXQJ8h6x9Zx1h2y3Z4u5") {
+				t.Errorf("sample is not redacted: %s", test.Description)
+			}
+		}
+	}
+	want := map[string]webvuln.Severity{
+		"password": webvuln.SeverityHigh,
+		"email":    webvuln.SeverityLow,
+		"error":    webvuln.SeverityMedium,
+	}
+	if fmt.Sprint(found) != fmt.Sprint(want) {
+		t.Errorf("sensitive fields = %v, want %v", found, want)
+	}
+}
+
+func TestEndpointKey(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/api/users/42":                                    "GET /api/users/{id}",
+		"https://example.com/api/orders/3f2b8c1e-9d4a-4b7e-8f6a-2c1d0e9b8a7f": "GET /api/orders/{id}",
+		"https://example.com/api/v2/items":                                    "GET /api/v2/items",
+	}
+	for raw, want := range tests {
+		link, _ := url.Parse(raw)
+		if got := webvuln.EndpointKey("GET", link); got != want {
+			t.Errorf("EndpointKey(%s) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	if got := webvuln.Redact("alice@example.com"); got != "alic************" {
+		t.Errorf("Redact() = %q", got)
+	}
+	if got := webvuln.Redact("abc"); got != "***" {
+		t.Errorf("Redact() = %q", got)
+	}
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// nonce returns a random hex string like the CSRF tokens of real pages
+func nonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func TestBooleanBasedSQLInjection(t *testing.T) {
+	mux := http.NewServeMux()
+
+	// Numeric injection in "WHERE id = <id>", on a page with a timestamp,
+	// a nonce and the reflected input
+	mux.HandleFunc("/product", func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		result := "<p>Widget, $9.99</p>"
+		switch {
+		case strings.ContainsAny(id, "')"):
+			w.WriteHeader(http.StatusInternalServerError)
+			result = "<p>Database error</p>"
+		case strings.Contains(id, "1=2"):
+			result = "<p>No products found</p>"
+		}
+		fmt.Fprintf(w, "<html><body><p>Generated %s</p><input name=\"csrf\" value=\"%s\">", time.Now().Format(time.RFC3339Nano), nonce())
+		fmt.Fprintf(w, "<p>Results for %s</p>%s</body></html>", html.EscapeString(id), result)
+	})
+
+	// Reflects its input but never runs it
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<html><body><p>Generated %s</p><p>Results for %s</p></body></html>",
+			time.Now().Format(time.RFC1123), html.EscapeString(r.URL.Query().Get("id")))
+	})
+
+	// Shows a random tip on every request
+	mux.HandleFunc("/random", func(w http.ResponseWriter, r *http.Request) {
+		tips := []string{"Stay hydrated", "Take breaks", "Back up your data"}
+		n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(tips))))
+		id := r.URL.Query().Get("id")
+		if strings.Contains(id, "1=2") {
+			fmt.Fprint(w, "<p>No products found</p>")
+		}
+		fmt.Fprintf(w, "<html><body><p>Tip: %s</p></body></html>", tips[n.Int64()])
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/product?id=7", true},
+		{"/search?id=7", false},
+		{"/random?id=7", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			options := webvuln.ScanOptions{
+				PayloadLevel:       1,
+				Timeout:            5,
+				MaxRedirects:       5,
+				EnableSQLInjection: true,
+				EnableBooleanSQLi:  true,
+			}
+			report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + tt.path, Method: "GET"})
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+
+			found := []string{}
+			for _, result := range report.Results {
+				for _, test := range result.TestResults {
+					if strings.HasPrefix(test.Description, "Boolean-based") {
+						found = append(found, test.Description)
+					}
+				}
+			}
+
+			if tt.want && (len(found) != 1 || !strings.Contains(found[0], "'AND 1=2'")) {
+				t.Errorf("findings = %v, want the numeric AND 1=2 pair", found)
+			}
+			if !tt.want && len(found) != 0 {
+				t.Errorf("unexpected findings %v", found)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCICDConsoleScan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/jenkins/login":
+			w.Header().Set("X-Jenkins", "2.401.1")
+			fmt.Fprint(w, `<html><head><title>Sign in [Jenkins]</title></head><body></body></html>`)
+		case "/jenkins/api/json":
+			w.Header().Set("X-Jenkins", "2.401.1")
+			fmt.Fprint(w, `{"_class":"hudson.model.Hudson","jobs":[{"name":"deploy-prod"}]}`)
+		case "/jenkins/script":
+			w.Header().Set("X-Jenkins", "2.401.1")
+			http.Error(w, "Authentication required", http.StatusForbidden)
+		case "/api/version":
+			fmt.Fprint(w, `{"Version":"v2.8.4+c279299","BuildDate":"2023-09-13T19:12:09Z"}`)
+		case "/login":
+			// Argo CD serves its UI on every route
+			fmt.Fprint(w, `<html><head><title>Argo CD</title></head><body></body></html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	options := webvuln.ScanOptions{
+		PayloadLevel:           4,
+		Timeout:                5,
+		MaxRedirects:           5,
+		EnableMisconfiguration: true,
+	}
+	report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	found := make(map[string]webvuln.TestResult)
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			if test.Payload.Value == "CICD_CONSOLES" {
+				found[test.URL] = test
+			}
+		}
+	}
+
+	want := map[string]struct {
+		severity webvuln.Severity
+		fragment string
+	}{
+		server.URL + "/jenkins/login":    {webvuln.SeverityLow, "Jenkins console exposed at " + server.URL + "/jenkins/login (version 2.401.1)"},
+		server.URL + "/jenkins/api/json": {webvuln.SeverityHigh, "Jenkins /api/json reachable without authentication: API lists the jobs"},
+		server.URL + "/login":            {webvuln.SeverityLow, "Argo CD console exposed"},
+		server.URL + "/api/version":      {webvuln.SeverityLow, "Argo CD /api/version reachable"},
+	}
+	if len(found) != len(want) {
+		t.Fatalf("got %d findings, want %d: %+v", len(found), len(want), found)
+	}
+	for link, w := range want {
+		if test := found[link]; test.Severity != w.severity || !strings.Contains(test.Description, w.fragment) {
+			t.Errorf("%s = %s %q, want %s %q", link, test.Severity, test.Description, w.severity, w.fragment)
+		}
+	}
+
+	products := []webvuln.DetectedProduct{
+		{Name: "Jenkins", Version: "2.401.1", CPE: "cpe:2.3:a:jenkins:jenkins:2.401.1:*:*:*:*:*:*:*", URL: server.URL + "/jenkins/login"},
+		{Name: "Argo CD", Version: "2.8.4", CPE: "cpe:2.3:a:linuxfoundation:argo-cd:2.8.4:*:*:*:*:*:*:*", URL: server.URL + "/login"},
+	}
+	if !reflect.DeepEqual(report.Products, products) {
+		t.Errorf("Products = %+v, want %+v", report.Products, products)
+	}
+}
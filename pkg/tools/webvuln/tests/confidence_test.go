@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConfidence(t *testing.T) {
+	// Reflects its input and answers every path, without security headers
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<html><body>Results for %s</body></html>", r.URL.Query().Get("q"))
+	}))
+	defer server.Close()
+
+	options := webvuln.ScanOptions{PayloadLevel: 3, AutoTuneLevel: true, Timeout: 5, MaxRedirects: 5, EnableXSS: true, EnableMisconfiguration: true}
+	report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/?q=test", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	confidences := map[string]webvuln.Confidence{}
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			if test.Confidence.Rank() == 0 {
+				t.Errorf("result %q has no confidence", test.Description)
+			}
+			switch {
+			case result.VulnerabilityType == webvuln.VulnTypeXSS:
+				confidences["xss"] = test.Confidence
+			case strings.HasPrefix(test.Description, "Missing security header"):
+				confidences["header"] = test.Confidence
+			case strings.HasPrefix(test.Description, "Potential security misconfiguration"):
+				confidences["path"] = test.Confidence
+			}
+		}
+	}
+	want := map[string]webvuln.Confidence{"xss": webvuln.ConfidenceFirm, "header": webvuln.ConfidenceCertain, "path": webvuln.ConfidenceTentative}
+	for kind, confidence := range want {
+		if confidences[kind] != confidence {
+			t.Errorf("%s confidence = %q, want %q", kind, confidences[kind], confidence)
+		}
+	}
+
+	// Certain results need no verification, the others come likeliest first
+	queue := report.VerificationQueue()
+	if len(queue) == 0 {
+		t.Fatal("VerificationQueue() is empty")
+	}
+	for i, test := range queue {
+		if test.Confidence == webvuln.ConfidenceCertain {
+			t.Errorf("queue[%d] = %q, a certain result", i, test.Description)
+		}
+		if i == 0 {
+			continue
+		}
+		previous := queue[i-1]
+		if previous.Confidence.Rank() < test.Confidence.Rank() ||
+			previous.Confidence == test.Confidence && previous.Severity.Rank() < test.Severity.Rank() {
+			t.Errorf("queue[%d] = [%s] [%s] after [%s] [%s]", i, test.Confidence, test.Severity, previous.Confidence, previous.Severity)
+		}
+	}
+}
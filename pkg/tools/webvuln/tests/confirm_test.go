@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/websocket"
+)
+
+// fakeBrowser writes a browser executable announcing a fake DevTools
+// endpoint, whose pages open an alert if their URL holds a script
+func fakeBrowser(t *testing.T) string {
+	var devtools *httptest.Server
+	var loading string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json/new", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"id": "tab", "webSocketDebuggerUrl": "ws" + strings.TrimPrefix(devtools.URL, "http") + "/devtools/page/tab"})
+	})
+	mux.HandleFunc("/json/close/tab", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Handle("/devtools/page/tab", websocket.Handler(func(ws *websocket.Conn) {
+		for {
+			var command struct {
+				ID     int                    `json:"id"`
+				Method string                 `json:"method"`
+				Params map[string]interface{} `json:"params"`
+			}
+			if err := websocket.JSON.Receive(ws, &command); err != nil {
+				return
+			}
+			var result interface{} = struct{}{}
+			switch command.Method {
+			case "Page.navigate":
+				loading, _ = command.Params["url"].(string)
+			case "Runtime.evaluate":
+				result = map[string]interface{}{"result": map[string]string{"value": "<html><body>Results for <script>alert('XSS')</script></body></html>"}}
+			case "Page.captureScreenshot":
+				result = map[string]string{"data": base64.StdEncoding.EncodeToString([]byte("\x89PNG"))}
+			}
+			websocket.JSON.Send(ws, map[string]interface{}{"id": command.ID, "result": result})
+
+			if command.Method == "Page.navigate" {
+				if strings.Contains(loading, "script") {
+					websocket.JSON.Send(ws, map[string]interface{}{"method": "Page.javascriptDialogOpening", "params": map[string]string{"type": "alert", "message": "XSS"}})
+				}
+				websocket.JSON.Send(ws, map[string]interface{}{"method": "Page.loadEventFired", "params": map[string]int{"timestamp": 1}})
+			}
+		}
+	}))
+	devtools = httptest.NewServer(mux)
+	t.Cleanup(devtools.Close)
+
+	path := filepath.Join(t.TempDir(), "chromium")
+	script := fmt.Sprintf("#!/bin/sh\necho 'DevTools listening on ws://%s/devtools/browser/fake' >&2\nexec sleep 60\n", strings.TrimPrefix(devtools.URL, "http://"))
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfirmXSS(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake browser is a shell script")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<html><body>Results for %s</body></html>", r.URL.Query().Get("q"))
+	}))
+	defer server.Close()
+
+	scan := func(browserPath string) []webvuln.TestResult {
+		options := webvuln.ScanOptions{
+			PayloadLevel:        1,
+			Timeout:             5,
+			MaxRedirects:        3,
+			EnableXSS:           true,
+			ConfirmXSS:          true,
+			BrowserPath:         browserPath,
+			ScreenshotDirectory: t.TempDir(),
+		}
+		report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/?q=test", Method: "GET"})
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		if len(report.Results) != 1 || len(report.Results[0].TestResults) == 0 {
+			t.Fatalf("Scan() results = %+v, want reflected XSS", report.Results)
+		}
+		return report.Results[0].TestResults
+	}
+
+	// Payloads running in the browser are confirmed, with their screenshot
+	confirmed := 0
+	for _, result := range scan(fakeBrowser(t)) {
+		if !strings.Contains(result.Payload.Value, "script") {
+			if result.Screenshot != "" || !strings.Contains(result.Evidence, "Not confirmed") || result.Confidence != webvuln.ConfidenceTentative {
+				t.Errorf("result of %q = %+v, want it left unconfirmed", result.Payload.Value, result)
+			}
+			continue
+		}
+		confirmed++
+		if !strings.HasPrefix(result.Description, "Confirmed XSS") || result.Confidence != webvuln.ConfidenceCertain || !strings.Contains(result.Evidence, `Browser: alert("XSS")`) || !strings.Contains(result.Evidence, "DOM: ") {
+			t.Errorf("result of %q = %+v, want it confirmed", result.Payload.Value, result)
+		}
+		if data, err := os.ReadFile(result.Screenshot); err != nil || string(data) != "\x89PNG" {
+			t.Errorf("screenshot %q = %q, %v", result.Screenshot, data, err)
+		}
+	}
+	if confirmed == 0 {
+		t.Error("no payload was confirmed")
+	}
+
+	// Without a browser the findings are kept as they are
+	for _, result := range scan(filepath.Join(t.TempDir(), "missing")) {
+		if !strings.HasPrefix(result.Description, "Potential XSS") || result.Screenshot != "" {
+			t.Errorf("result without a browser = %+v, want it unconfirmed", result)
+		}
+	}
+}
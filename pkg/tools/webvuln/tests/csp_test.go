@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCSP(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   webvuln.CSPPolicy
+	}{
+		{
+			name:   "Directives",
+			header: "default-src 'self'; Script-Src 'self' https://cdn.example.com; upgrade-insecure-requests",
+			want: webvuln.CSPPolicy{
+				"default-src":               {"'self'"},
+				"script-src":                {"'self'", "https://cdn.example.com"},
+				"upgrade-insecure-requests": {},
+			},
+		},
+		{
+			name:   "Repeated directive",
+			header: "script-src 'self';; script-src *",
+			want:   webvuln.CSPPolicy{"script-src": {"'self'"}},
+		},
+		{
+			name:   "Several policies",
+			header: "script-src 'none', script-src *",
+			want:   webvuln.CSPPolicy{"script-src": {"'none'"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := webvuln.ParseCSP(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCSP(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSPAnalysis(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		policy string
+		want   []string
+	}{
+		{
+			name:   "Strict policy",
+			header: "Content-Security-Policy",
+			policy: "default-src 'self'; script-src 'nonce-r4nd0m' 'strict-dynamic' 'unsafe-inline' https:; object-src 'none'; base-uri 'none'",
+		},
+		{
+			name:   "Unsafe keywords",
+			header: "Content-Security-Policy",
+			policy: "default-src 'self'; script-src 'self' 'unsafe-inline' 'unsafe-eval'; style-src 'self' 'unsafe-inline'; base-uri 'self'",
+			want: []string{
+				"'unsafe-inline' lets injected inline scripts",
+				"'unsafe-eval' lets injected strings run",
+				"'unsafe-inline' allows injected styles",
+			},
+		},
+		{
+			name:   "Wildcards and bypassable hosts",
+			header: "Content-Security-Policy",
+			policy: "script-src 'self' https://*.googleapis.com cdnjs.cloudflare.com data: *; object-src *; base-uri 'none'",
+			want: []string{
+				"'https://*.googleapis.com' allows ajax.googleapis.com",
+				"'cdnjs.cloudflare.com' allows cdnjs.cloudflare.com, which hosts AngularJS",
+				"scheme source 'data:' allows scripts from any data URL",
+				"wildcard source '*' allows scripts from any host",
+				"plugins like Flash and PDF embeds load from anywhere",
+			},
+		},
+		{
+			name:   "Report only",
+			header: "Content-Security-Policy-Report-Only",
+			policy: "img-src 'self'",
+			want: []string{
+				"only sent as Content-Security-Policy-Report-Only",
+				"neither script-src nor default-src is set",
+				"plugins like Flash and PDF embeds load from anywhere",
+				"an injected <base> tag",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set(tt.header, tt.policy)
+				fmt.Fprint(w, "<html><body>Home</body></html>")
+			}))
+			defer server.Close()
+
+			options := webvuln.ScanOptions{
+				PayloadLevel:           1,
+				Timeout:                5,
+				MaxRedirects:           5,
+				EnableMisconfiguration: true,
+			}
+			report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/", Method: "GET"})
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+
+			found := []string{}
+			for _, result := range report.Results {
+				for _, test := range result.TestResults {
+					if strings.HasPrefix(test.Description, "Weak Content Security Policy: ") {
+						found = append(found, test.Description)
+					}
+				}
+			}
+
+			for _, want := range tt.want {
+				matched := false
+				for _, description := range found {
+					matched = matched || strings.Contains(description, want)
+				}
+				if !matched {
+					t.Errorf("no finding containing %q in %q", want, found)
+				}
+			}
+			if len(found) != len(tt.want) {
+				t.Errorf("got %d findings %q, want %d", len(found), found, len(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDataExposure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body>Support: (555) 123-4567 <a href="/api/orders">Orders</a></body></html>`)
+		case "/api/orders":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `[{"card": "4111 1111 1111 1111"}, {"card": "5500 0000 0000 0004"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	options := webvuln.ScanOptions{
+		PayloadLevel:       1,
+		Timeout:            5,
+		MaxRedirects:       5,
+		MaxCrawlPages:      10,
+		EnablePIIDetection: true,
+	}
+	report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	found := map[string]webvuln.TestResult{}
+	for _, result := range report.Results {
+		if result.VulnerabilityType != webvuln.VulnTypeDataExposure {
+			t.Errorf("unexpected %s result", result.VulnerabilityType)
+		}
+		for _, test := range result.TestResults {
+			found[test.Parameter] = test
+		}
+	}
+	if len(found) != 2 {
+		t.Fatalf("found %v, want a phone number and card numbers", found)
+	}
+	card := found["credit_card"]
+	if card.Severity != webvuln.SeverityHigh || !strings.HasSuffix(card.URL, "/api/orders") ||
+		!strings.Contains(card.Description, "2 payment card number(s)") || strings.Contains(card.Description, "4111 1111") {
+		t.Errorf("card finding = %+v", card)
+	}
+	if found["phone"].Severity != webvuln.SeverityLow {
+		t.Errorf("phone finding = %+v", found["phone"])
+	}
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const apacheListing = `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN">
+<html><head><title>Index of /files</title></head><body>
+<h1>Index of /files</h1>
+<table><tr><th><a href="?C=N;O=D">Name</a></th><th><a href="?C=M;O=A">Last modified</a></th></tr>
+<tr><td><a href="/">Parent Directory</a></td></tr>
+<tr><td><a href="backup.sql">backup.sql</a></td></tr>
+<tr><td><a href="report.pdf">report.pdf</a></td></tr>
+<tr><td><a href=".git/">.git/</a></td></tr>
+<tr><td><a href="images/">images/</a></td></tr>
+</table><address>Apache/2.4.41 (Ubuntu) Server at example.com Port 80</address></body></html>`
+
+func TestParseDirectoryListing(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		body      string
+		server    string
+		entries   []string
+		sensitive []string
+	}{
+		{
+			name: "Apache", url: "http://example.com/files/", body: apacheListing, server: "Apache",
+			entries:   []string{"backup.sql", "report.pdf", ".git/", "images/"},
+			sensitive: []string{"backup.sql", ".git/"},
+		},
+		{
+			name: "nginx", url: "http://example.com/uploads/", server: "nginx",
+			body: `<html><head><title>Index of /uploads/</title></head><body><h1>Index of /uploads/</h1><hr><pre><a href="../">../</a>
+<a href="avatar.png">avatar.png</a>                                         01-Jan-2024 00:00    1024
+<a href="config.php.bak">config.php.bak</a>                                 01-Jan-2024 00:00     512
+</pre><hr></body></html>`,
+			entries:   []string{"avatar.png", "config.php.bak"},
+			sensitive: []string{"config.php.bak"},
+		},
+		{
+			name: "IIS", url: "http://example.com/docs/", server: "IIS",
+			body: `<html><head><title>example.com - /docs/</title></head><body><H1>example.com - /docs/</H1><hr>
+<pre><A HREF="/">[To Parent Directory]</A><br><br> 1/1/2024 12:00 AM        &lt;dir&gt; <A HREF="/docs/manual/">manual</A><br> 1/1/2024 12:00 AM 2048 <A HREF="/docs/web.config">web.config</A><br></pre><hr></body></html>`,
+			entries:   []string{"manual/", "web.config"},
+			sensitive: []string{"web.config"},
+		},
+		{
+			name: "not a listing", url: "http://example.com/", body: `<html><head><title>Home</title></head><body><a href="/a">A</a></body></html>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listing := webvuln.ParseDirectoryListing(tt.url, tt.body)
+			if tt.entries == nil {
+				if listing != nil {
+					t.Errorf("ParseDirectoryListing() = %+v, want nil", listing)
+				}
+				return
+			}
+			if listing == nil {
+				t.Fatal("ParseDirectoryListing() = nil, want a listing")
+			}
+			if listing.Server != tt.server || !reflect.DeepEqual(listing.Entries, tt.entries) || !reflect.DeepEqual(listing.Sensitive, tt.sensitive) {
+				t.Errorf("ParseDirectoryListing() = %s %v sensitive %v, want %s %v sensitive %v",
+					listing.Server, listing.Entries, listing.Sensitive, tt.server, tt.entries, tt.sensitive)
+			}
+		})
+	}
+}
+
+func TestDirectoryListingScan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/files/report.pdf">Report</a><img src="/static/img/logo.png"></body></html>`)
+		case "/files/":
+			fmt.Fprint(w, apacheListing)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	options := webvuln.ScanOptions{
+		PayloadLevel:         5,
+		Timeout:              5,
+		MaxRedirects:         5,
+		MaxCrawlPages:        5,
+		EnableInfoDisclosure: true,
+	}
+	report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	var listings []webvuln.TestResult
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			if test.Payload.Value == "DIRECTORY_LISTING" {
+				listings = append(listings, test)
+			}
+		}
+	}
+	if len(listings) != 1 {
+		t.Fatalf("got %d directory listings, want 1: %+v", len(listings), listings)
+	}
+	listing := listings[0]
+	if listing.URL != server.URL+"/files/" || listing.Severity != webvuln.SeverityHigh {
+		t.Errorf("listing = %s %s, want %s/files/ High", listing.URL, listing.Severity, server.URL)
+	}
+	if !strings.Contains(listing.Description, "backup.sql") || !strings.Contains(listing.Evidence, "report.pdf") {
+		t.Errorf("listing = %q with evidence %q, want backup.sql flagged and report.pdf in the snapshot", listing.Description, listing.Evidence)
+	}
+	if finding := listing.Finding(webvuln.VulnTypeInfoDisclosure); !strings.Contains(finding.Evidence, "images/") {
+		t.Errorf("finding evidence = %q, want the listing snapshot", finding.Evidence)
+	}
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEncodeChain(t *testing.T) {
+	tests := []struct {
+		payload string
+		chain   string
+		want    string
+	}{
+		{"<a b>", "url", "%3Ca+b%3E"},
+		{"<a b>", "double-url", "%253Ca%2Bb%253E"},
+		{"<a>", "unicode-escape", `\u003ca\u003e`},
+		{"<a>", "unicode-escape>url", "%5Cu003ca%5Cu003e"},
+		{"<a>", "html-entity", "&#x3c;a&#x3e;"},
+		{"ab", "hex", "%61%62"},
+		{"union select", "case", "UnIoN sElEcT"},
+		{"' OR 1=1 --", "sql-comment", "'/**/OR/**/1=1/**/--"},
+		{"' or 1=1", "sql-comment>case", "'/**/Or/**/1=1"},
+		{"UNION SELECT 1", "sql-version", "/*!50000UNION*/ /*!50000SELECT*/ 1"},
+		{"a b", "whitespace", "a\tb"},
+		{"<a>", "unknown", "<a>"},
+	}
+
+	pm := webvuln.NewPayloadManager(3)
+	for _, tt := range tests {
+		if got := pm.EncodePayload(tt.payload, tt.chain); got != tt.want {
+			t.Errorf("EncodePayload(%q, %q) = %q, want %q", tt.payload, tt.chain, got, tt.want)
+		}
+	}
+}
+
+func TestParseEncodingChain(t *testing.T) {
+	chain, err := webvuln.ParseEncodingChain(" Unicode-Escape > url>double-url ")
+	if err != nil {
+		t.Fatalf("ParseEncodingChain() error = %v", err)
+	}
+	if strings.Join(chain, ",") != "unicode-escape,url,double-url" {
+		t.Errorf("ParseEncodingChain() = %v", chain)
+	}
+
+	for _, invalid := range []string{"", ">", "url>rot13"} {
+		if _, err := webvuln.ParseEncodingChain(invalid); err == nil {
+			t.Errorf("ParseEncodingChain(%q) expected an error", invalid)
+		}
+	}
+}
+
+// setupWAFServer creates a test server reflecting its input behind a naive
+// WAF that blocks "script" and percent signs
+func setupWAFServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		input := r.URL.Query().Get("input")
+		if strings.Contains(input, "script") || strings.Contains(input, "%") {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "Request blocked")
+			return
+		}
+		fmt.Fprintf(w, "<html><body>You said: %s</body></html>", input)
+	}))
+}
+
+func TestRetryBlockedPayloads(t *testing.T) {
+	server := setupWAFServer()
+	defer server.Close()
+
+	scan := func(retry bool, chains []string) []string {
+		options := webvuln.ScanOptions{
+			PayloadLevel:   1,
+			Timeout:        5,
+			MaxRedirects:   5,
+			EnableXSS:      true,
+			RetryBlocked:   retry,
+			EncodingChains: chains,
+		}
+		report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/?input=test", Method: "GET"})
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+
+		descriptions := []string{}
+		for _, result := range report.Results {
+			for _, test := range result.TestResults {
+				if strings.Contains(test.Payload.Value, "script") {
+					descriptions = append(descriptions, test.Description)
+				}
+			}
+		}
+		return descriptions
+	}
+
+	if found := scan(false, nil); len(found) != 0 {
+		t.Errorf("without retries the blocked payload was reported: %v", found)
+	}
+
+	found := scan(true, nil)
+	if len(found) == 0 || !strings.HasSuffix(found[0], "(encoding: case)") {
+		t.Errorf("retried findings = %v, want one using the case encoding", found)
+	}
+
+	found = scan(false, []string{"case>whitespace"})
+	if len(found) == 0 || !strings.HasSuffix(found[0], "(encoding: case>whitespace)") {
+		t.Errorf("findings = %v, want one using the configured chain", found)
+	}
+}
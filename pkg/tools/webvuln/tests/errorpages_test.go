@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeErrorPage(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		kind   string
+		detail string
+	}{
+		{"Django", `<p>You're seeing this error because you have <code>DEBUG = True</code> in your Django settings file.</p>`, webvuln.DisclosureDebugPage, "Django debug mode"},
+		{"Java", "java.lang.NullPointerException\n\tat com.example.web.UserController.show(UserController.java:42)", webvuln.DisclosureStackTrace, "Java"},
+		{"MySQL", "SQLSTATE[42000]: You have an error in your SQL syntax; check the manual", webvuln.DisclosureSQLError, "MySQL"},
+		{"Windows path", `Could not find file 'C:\inetpub\wwwroot\app\config.xml'.`, webvuln.DisclosurePath, `C:\inetpub\wwwroot\app\config.xml`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			disclosures := webvuln.AnalyzeErrorPage(tt.body)
+			if len(disclosures) == 0 || disclosures[0].Kind != tt.kind || disclosures[0].Detail != tt.detail {
+				t.Errorf("AnalyzeErrorPage() = %+v, want %s (%s) first", disclosures, tt.kind, tt.detail)
+			}
+		})
+	}
+
+	if disclosures := webvuln.AnalyzeErrorPage("<html><body>Not found</body></html>"); len(disclosures) != 0 {
+		t.Errorf("AnalyzeErrorPage() = %+v for a plain page", disclosures)
+	}
+}
+
+func TestErrorPageScan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path != "/" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<b>Warning</b>: include(missing.php): failed to open stream in <b>/var/www/html/router.php</b> on line <b>12</b>")
+			return
+		}
+		if strings.Contains(r.URL.RawQuery, "%27") {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "Warning: mysql_fetch_array(): You have an error in your SQL syntax")
+			return
+		}
+		if body, _ := io.ReadAll(r.Body); r.Header.Get("Content-Type") == "application/json" && len(body) > 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "Traceback (most recent call last):\n  File \"/srv/app/views.py\", line 8, in post\njson.decoder.JSONDecodeError")
+			return
+		}
+		// The home page documents its own install path, which is no leak
+		fmt.Fprint(w, "<html><body>Installed in /opt/site/index.html</body></html>")
+	}))
+	defer server.Close()
+
+	options := webvuln.ScanOptions{
+		PayloadLevel:         2,
+		Timeout:              5,
+		MaxRedirects:         5,
+		EnableInfoDisclosure: true,
+	}
+	report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/?id=1", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	found := make(map[string]webvuln.TestResult)
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			found[test.Description] = test
+		}
+	}
+
+	want := []string{
+		"SQL error (MySQL) in response to an invalid value of parameter id",
+		"debug page (PHP display_errors) in response to a nonexistent route",
+		"absolute path (/var/www/html/router.php)",
+		"stack trace (Python) in response to a malformed JSON body",
+		"absolute path (/srv/app/views.py)",
+	}
+	for _, fragment := range want {
+		matched := false
+		for description, test := range found {
+			if strings.Contains(description, fragment) {
+				matched = true
+				if test.Evidence == "" {
+					t.Errorf("%q has no evidence", description)
+				}
+			}
+		}
+		if !matched {
+			t.Errorf("no finding with %q in %v", fragment, found)
+		}
+	}
+	for description := range found {
+		if strings.Contains(description, "/opt/site") {
+			t.Errorf("reported %q, which the target page shows too", description)
+		}
+	}
+}
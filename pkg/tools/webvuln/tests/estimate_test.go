@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEstimateScan(t *testing.T) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		fmt.Fprint(w, "<html><body>Nothing to see</body></html>")
+	}))
+	defer server.Close()
+
+	// The estimate of a quiet target matches the requests sent
+	for _, autoTune := range []bool{false, true} {
+		atomic.StoreInt64(&requests, 0)
+		options := webvuln.ScanOptions{PayloadLevel: 3, AutoTuneLevel: autoTune, Timeout: 5, MaxRedirects: 5, EnableXSS: true, EnableSQLInjection: true}
+		target := webvuln.ScanTarget{URL: server.URL + "/?q=test&page=2", Method: "GET"}
+		estimate := webvuln.EstimateScan(target, options)
+		if _, err := webvuln.NewScanner(options).Scan(target); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+
+		sent := atomic.LoadInt64(&requests)
+		if autoTune && (sent >= estimate.Requests || len(estimate.Notes) != 1 || !strings.Contains(estimate.Notes[0], fmt.Sprintf(" %d requests", sent))) {
+			t.Errorf("auto-tuned estimate = %d %v, want more than the %d requests sent and a note with them", estimate.Requests, estimate.Notes, sent)
+		}
+		if !autoTune && sent != estimate.Requests {
+			t.Errorf("estimate = %d requests, sent %d", estimate.Requests, sent)
+		}
+		if estimate.Workers != 2 {
+			t.Errorf("estimate workers = %d, want one per test", estimate.Workers)
+		}
+	}
+
+	// Levels, parameters and encoding chains add requests
+	cost := func(preset, target string, chains ...string) int64 {
+		options, _ := webvuln.PresetScanOptions(preset)
+		options.EncodingChains = chains
+		return webvuln.EstimateScan(webvuln.ScanTarget{URL: target}, options).Requests
+	}
+	quick, normal, deep := cost(presets.Quick, "https://example.com/"), cost(presets.Normal, "https://example.com/"), cost(presets.Deep, "https://example.com/")
+	if !(quick < normal && normal < deep) {
+		t.Errorf("estimates quick %d, normal %d, deep %d, want growing", quick, normal, deep)
+	}
+	if more := cost(presets.Normal, "https://example.com/?a=1&b=2"); more <= normal {
+		t.Errorf("estimate with two parameters = %d, want more than %d", more, normal)
+	}
+	if more := cost(presets.Normal, "https://example.com/", "url", "nonsense>chain"); more <= normal {
+		t.Errorf("estimate with an encoding chain = %d, want more than %d", more, normal)
+	}
+	options, _ := webvuln.PresetScanOptions(presets.Normal)
+	options.InjectionHeaders = []string{"Referer"}
+	if more := webvuln.EstimateScan(webvuln.ScanTarget{URL: "https://example.com/"}, options).Requests; more <= normal {
+		t.Errorf("estimate with an injected header = %d, want more than %d", more, normal)
+	}
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDBMSFingerprint(t *testing.T) {
+	mux := http.NewServeMux()
+
+	// MySQL showing its errors, including the version leaked by EXTRACTVALUE
+	mux.HandleFunc("/mysql", func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		switch {
+		case strings.Contains(id, "EXTRACTVALUE(1,CONCAT(0x7e,VERSION()))"):
+			fmt.Fprint(w, "XPATH syntax error: '~8.0.32-0ubuntu0.22.04.2'")
+		case strings.Contains(id, "'"):
+			fmt.Fprint(w, "You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version")
+		default:
+			fmt.Fprint(w, "<html><body>Widget</body></html>")
+		}
+	})
+
+	// PostgreSQL behind a generic error page, only identifiable through
+	// boolean conditions
+	mux.HandleFunc("/postgres", func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		switch {
+		case strings.ContainsAny(id, "'@") || strings.Contains(id, ") AND (") || strings.Contains(id, "CONNECTION_ID") ||
+			strings.Contains(id, "ROWNUM") || strings.Contains(id, "SQLITE_VERSION"):
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "<html><body>Something went wrong</body></html>")
+		case strings.Contains(id, "1=2"):
+			fmt.Fprint(w, "<html><body>No products</body></html>")
+		default:
+			fmt.Fprint(w, "<html><body>Widget</body></html>")
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/mysql?id=1", "MySQL 8.0.32-0ubuntu0.22.04.2"},
+		{"/postgres?id=1", "PostgreSQL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			options := webvuln.ScanOptions{
+				PayloadLevel:          1,
+				Timeout:               5,
+				MaxRedirects:          5,
+				EnableSQLInjection:    true,
+				EnableBooleanSQLi:     true,
+				EnableDBMSFingerprint: true,
+			}
+			report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + tt.path, Method: "GET"})
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+
+			if len(report.Results) == 0 {
+				t.Fatal("no SQL injection found")
+			}
+			for _, result := range report.Results {
+				for _, test := range result.TestResults {
+					if test.DBMS != tt.want {
+						t.Errorf("%q: DBMS = %q, want %q", test.Description, test.DBMS, tt.want)
+					}
+					if test.Severity != webvuln.SeverityCritical {
+						t.Errorf("%q: severity = %s, want a fingerprinted injection to be Critical", test.Description, test.Severity)
+					}
+				}
+			}
+		})
+	}
+}
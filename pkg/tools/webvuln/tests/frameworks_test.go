@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFrameworkPacks(t *testing.T) {
+	var laravelChecks int64
+	mux := http.NewServeMux()
+
+	// A WordPress site leaking its users and a configuration backup
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `<html><head><link rel="stylesheet" href="/wp-content/themes/blog/style.css"></head><body>Blog</body></html>`)
+	})
+	mux.HandleFunc("/wp-json/wp/v2/users", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1,"name":"admin","slug":"admin"}]`)
+	})
+	mux.HandleFunc("/wp-config.php.bak", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<?php\ndefine( 'DB_PASSWORD', 'hunter2' );\n")
+	})
+	mux.HandleFunc("/storage/logs/laravel.log", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&laravelChecks, 1)
+		http.NotFound(w, r)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	scan := func(packs ...string) []webvuln.TestResult {
+		options := webvuln.ScanOptions{PayloadLevel: 1, Timeout: 5, MaxRedirects: 5, EnableMisconfiguration: true, FrameworkPacks: packs}
+		report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/", Method: "GET"})
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		results := []webvuln.TestResult{}
+		for _, result := range report.Results {
+			for _, test := range result.TestResults {
+				if strings.Contains(test.Description, "misconfiguration: ") {
+					results = append(results, test)
+				}
+			}
+		}
+		return results
+	}
+
+	// The fingerprinted WordPress pack runs, the others don't
+	results := scan(webvuln.FrameworkPacksAuto)
+	found := map[string]webvuln.Severity{}
+	for _, result := range results {
+		found[result.Payload.Value] = result.Severity
+		if !strings.HasPrefix(result.Description, "WordPress misconfiguration: ") || result.Match == "" {
+			t.Errorf("result = %+v, want a WordPress finding with its match", result)
+		}
+	}
+	if found["/wp-config.php.bak"] != webvuln.SeverityCritical || found["/wp-json/wp/v2/users"] != webvuln.SeverityMedium || len(found) != 2 {
+		t.Errorf("WordPress findings = %v, want the configuration backup and the users", found)
+	}
+	if laravelChecks != 0 {
+		t.Error("the Laravel pack ran on a WordPress site")
+	}
+
+	// Selected packs run whatever the fingerprint
+	scan("laravel")
+	if laravelChecks != 1 {
+		t.Errorf("selected Laravel pack checked its log %d times, want 1", laravelChecks)
+	}
+	if results := scan(); len(results) != 0 {
+		t.Errorf("findings without packs = %+v, want none", results)
+	}
+
+	if _, err := webvuln.ParseFrameworkPacks("wordpress, rails"); err == nil {
+		t.Error("ParseFrameworkPacks() accepted an unknown pack")
+	}
+	if packs, err := webvuln.ParseFrameworkPacks("Auto, django"); err != nil || strings.Join(packs, ",") != "auto,django" {
+		t.Errorf("ParseFrameworkPacks() = %v, %v", packs, err)
+	}
+}
@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// echoPattern matches the PHP code the scanner injects to prove execution
+var echoPattern = regexp.MustCompile(`<\?php echo '(\w+)'\.'(\w+)'; \?>`)
+
+// runPHP "executes" injected echo statements
+func runPHP(code string) string {
+	return echoPattern.ReplaceAllString(code, "$1$2")
+}
+
+// setupInclusionServer creates a server that includes the file named by the
+// page parameter, like include($_GET['page'])
+func setupInclusionServer(intrusive *bool) *httptest.Server {
+	var mutex sync.Mutex
+	accessLog := ""
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/view", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		accessLog += r.UserAgent() + "\n"
+
+		page := r.URL.Query().Get("page")
+		if strings.Contains(r.UserAgent(), "<?php") || strings.HasPrefix(page, "data://") || page == "php://input" {
+			*intrusive = true
+		}
+
+		switch {
+		case page == "php://filter/convert.base64-encode/resource=home.php":
+			fmt.Fprint(w, base64.StdEncoding.EncodeToString([]byte("<?php $password = 's3cret'; include($_GET['page']); ?>")))
+		case strings.HasSuffix(page, "etc/passwd"):
+			fmt.Fprint(w, "root:x:0:0:root:/root:/bin/bash\ndaemon:x:1:1::/usr/sbin:/usr/sbin/nologin\n")
+		case strings.HasPrefix(page, "data://text/plain;base64,"):
+			code, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(page, "data://text/plain;base64,"))
+			fmt.Fprint(w, runPHP(string(code)))
+		case page == "php://input":
+			code, _ := io.ReadAll(r.Body)
+			fmt.Fprint(w, runPHP(string(code)))
+		case strings.HasSuffix(page, "var/log/apache2/access.log"):
+			fmt.Fprint(w, runPHP(accessLog))
+		default:
+			fmt.Fprint(w, "<html><body>Home</body></html>")
+		}
+	})
+
+	// Documentation page that always shows an example passwd line
+	mux.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<pre>root:x:0:0:root:/root:/bin/bash</pre>")
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestFileInclusion(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		os        string
+		intrusive bool
+		want      []string
+	}{
+		{
+			name: "Read-only checks",
+			path: "/view?page=home",
+			want: []string{
+				"Content of '/etc/passwd' found",
+				"Source code of 'home.php' disclosed",
+			},
+		},
+		{
+			name: "Windows only",
+			path: "/view?page=home",
+			os:   "windows",
+			want: []string{"Source code of 'home.php' disclosed"},
+		},
+		{
+			name:      "Intrusive checks",
+			path:      "/view?page=home",
+			intrusive: true,
+			want: []string{
+				"Content of '/etc/passwd' found",
+				"Source code of 'home.php' disclosed",
+				"[intrusive] Remote Code Execution through File Inclusion: data:// wrapper",
+				"[intrusive] Remote Code Execution through File Inclusion: php://input wrapper",
+				"[intrusive] Remote Code Execution through File Inclusion: log poisoning of var/log/apache2/access.log",
+			},
+		},
+		{
+			name: "File content on the unmodified page",
+			path: "/docs?page=home",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intrusiveSent := false
+			server := setupInclusionServer(&intrusiveSent)
+			defer server.Close()
+
+			options := webvuln.ScanOptions{
+				PayloadLevel:        1,
+				Timeout:             5,
+				MaxRedirects:        5,
+				EnableFileInclusion: true,
+				FileInclusionOS:     tt.os,
+				IntrusiveTests:      tt.intrusive,
+			}
+			report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + tt.path, Method: "GET"})
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+
+			found := []string{}
+			for _, result := range report.Results {
+				for _, test := range result.TestResults {
+					found = append(found, test.Description)
+				}
+			}
+
+			for _, want := range tt.want {
+				matched := false
+				for _, description := range found {
+					matched = matched || strings.Contains(description, want)
+				}
+				if !matched {
+					t.Errorf("no finding containing %q in %q", want, found)
+				}
+			}
+			if len(found) != len(tt.want) {
+				t.Errorf("got %d findings %q, want %d", len(found), found, len(tt.want))
+			}
+			if intrusiveSent != tt.intrusive {
+				t.Errorf("intrusive requests sent = %v, want %v", intrusiveSent, tt.intrusive)
+			}
+		})
+	}
+}
+
+func TestTraversalDepth(t *testing.T) {
+	// Pages are included from /var/www/html/pages, four directories deep
+	include := func(page string) string {
+		if path.Join("/var/www/html/pages", page) == "/etc/passwd" {
+			return "root:x:0:0:root:/root:/bin/bash\n"
+		}
+		return "<html><body>Home</body></html>"
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/view", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, include(r.URL.Query().Get("page")))
+	})
+	// Strips ../ in a single pass, which nested sequences survive
+	mux.HandleFunc("/filtered", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, include(strings.ReplaceAll(r.URL.Query().Get("page"), "../", "")))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/view?page=home", "Path traversal to etc/passwd (plain, depth 4)"},
+		{"/filtered?page=home", "Path traversal to etc/passwd (nested, depth 4)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			options := webvuln.ScanOptions{
+				PayloadLevel:        2,
+				Timeout:             5,
+				MaxRedirects:        5,
+				EnableFileInclusion: true,
+				FileInclusionOS:     "linux",
+			}
+			report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + tt.path, Method: "GET"})
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+
+			payloads := []string{}
+			for _, result := range report.Results {
+				for _, test := range result.TestResults {
+					if strings.HasPrefix(test.Description, "File Inclusion Vulnerability: Content of") {
+						payloads = append(payloads, test.Payload.Description)
+					}
+				}
+			}
+			if len(payloads) != 1 || payloads[0] != tt.want {
+				t.Errorf("confirmed payloads = %q, want only %q", payloads, tt.want)
+			}
+		})
+	}
+}
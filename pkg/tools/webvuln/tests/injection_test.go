@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHeaderAndCookieInjection(t *testing.T) {
+	// The page echoes the Referer and builds a query from the theme cookie
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if theme, err := r.Cookie("theme"); err == nil && strings.Contains(theme.Value, "'") {
+			fmt.Fprint(w, "You have an error in your SQL syntax near 'dark''")
+			return
+		}
+		if r.Header.Get("X-Forwarded-For") != "" && strings.ContainsAny(r.Header.Get("X-Forwarded-For"), "'\"") {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		fmt.Fprintf(w, "<html><body>Back to <a href=\"%s\">previous page</a></body></html>", r.Header.Get("Referer"))
+	}))
+	defer server.Close()
+
+	target := webvuln.ScanTarget{URL: server.URL + "/", Method: "GET", Cookies: []string{"session=abc", "theme=dark"}}
+	options := webvuln.ScanOptions{
+		PayloadLevel:       1,
+		Timeout:            5,
+		MaxRedirects:       3,
+		EnableXSS:          true,
+		EnableSQLInjection: true,
+		InjectionHeaders:   []string{"referer", "X-Forwarded-For"},
+		InjectCookies:      true,
+	}
+	report, err := webvuln.NewScanner(options).Scan(target)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	found := map[string]webvuln.TestResult{}
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			found[string(result.VulnerabilityType)+" "+test.Parameter] = test
+		}
+	}
+
+	xss, ok := found["XSS header Referer"]
+	if !ok || !strings.Contains(xss.Description, "header 'Referer'") || xss.Headers["Referer"] != xss.Payload.Value {
+		t.Errorf("XSS in the Referer header = %+v, found %v", xss, keys(found))
+	}
+	if replay := xss.Finding(webvuln.VulnTypeXSS).Replay; replay == nil || replay.Requests[0].Headers["Referer"] != xss.Payload.Value {
+		t.Errorf("replay of the header XSS = %+v, want the payload header", replay)
+	}
+
+	sqli, ok := found["SQL_INJECTION cookie theme"]
+	if !ok || !strings.HasPrefix(sqli.Headers["Cookie"], "theme=") {
+		t.Fatalf("SQL injection in the theme cookie = %+v, found %v", sqli, keys(found))
+	}
+	if !strings.Contains(sqli.SqlmapCommand, "--cookie='session=abc; theme=dark*'") || strings.Contains(sqli.SqlmapCommand, " -p ") {
+		t.Errorf("sqlmap command = %q, want the cookie marked for injection", sqli.SqlmapCommand)
+	}
+	if _, ok := found["XSS cookie session"]; ok {
+		t.Error("the session cookie is not reflected, want no XSS in it")
+	}
+
+	// Without injection points, only the URL is tested
+	options.InjectionHeaders, options.InjectCookies = nil, false
+	report, err = webvuln.NewScanner(options).Scan(target)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			if len(test.Headers) > 0 {
+				t.Errorf("result %+v in a header, want none without injection points", test)
+			}
+		}
+	}
+}
+
+// keys returns the keys of found results
+func keys(found map[string]webvuln.TestResult) []string {
+	names := []string{}
+	for name := range found {
+		names = append(names, name)
+	}
+	return names
+}
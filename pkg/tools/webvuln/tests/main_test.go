@@ -30,6 +30,7 @@ func setupTestEnvironment() {
 }
 
 func cleanupTestEnvironment() {
-	// Clean up test artifacts if needed
-	// For now, we'll leave the logs for inspection
+	// Remove the response bodies stored for the findings; the logs are left
+	// for inspection
+	os.RemoveAll("workspaces")
 }
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAllowedMethods(t *testing.T) {
+	header := http.Header{}
+	header.Add("Allow", "GET, post,OPTIONS")
+	header.Add("Public", "PROPFIND, GET")
+	header.Add("Access-Control-Allow-Methods", "DELETE")
+
+	want := []string{"DELETE", "GET", "OPTIONS", "POST", "PROPFIND"}
+	if got := webvuln.AllowedMethods(header); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllowedMethods() = %v, want %v", got, want)
+	}
+}
+
+func TestMethodChecks(t *testing.T) {
+	// The admin page only protects GET and POST, and echoes TRACE requests
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodOptions:
+			w.Header().Set("Allow", "GET, POST, OPTIONS, PUT, TRACE")
+		case http.MethodTrace:
+			w.Header().Set("Content-Type", "message/http")
+			r.Write(w)
+		case http.MethodGet, http.MethodPost:
+			if r.Header.Get("X-HTTP-Method-Override") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("admin"))
+		case "TRACK":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		default:
+			w.Write([]byte("admin"))
+		}
+	}))
+	defer server.Close()
+
+	options := webvuln.ScanOptions{
+		PayloadLevel:         4,
+		Timeout:              5,
+		MaxRedirects:         5,
+		EnableInfoDisclosure: true,
+	}
+	report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/admin", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	found := make(map[string]webvuln.TestResult)
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			key := test.Method
+			if strings.Contains(test.Description, "X-HTTP-Method-Override") {
+				key = "OVERRIDE"
+			}
+			found[key] = test
+		}
+	}
+
+	want := map[string]webvuln.Severity{
+		"OPTIONS":  webvuln.SeverityMedium, // PUT
+		"TRACE":    webvuln.SeverityLow,
+		"HEAD":     webvuln.SeverityMedium,
+		"PUT":      webvuln.SeverityHigh,
+		"GSTRIKE":  webvuln.SeverityHigh,
+		"OVERRIDE": webvuln.SeverityHigh,
+	}
+	for key, severity := range want {
+		test, ok := found[key]
+		if !ok {
+			t.Errorf("no %s finding in %+v", key, found)
+			continue
+		}
+		if test.Severity != severity {
+			t.Errorf("%s finding severity = %s, want %s (%s)", key, test.Severity, severity, test.Description)
+		}
+	}
+	if _, ok := found["TRACK"]; ok {
+		t.Error("TRACK reported although the server refuses it")
+	}
+	if len(found) != len(want) {
+		t.Errorf("got %d findings, want %d: %+v", len(found), len(want), found)
+	}
+	if !strings.Contains(found["OPTIONS"].Description, "PUT") || !strings.Contains(found["OPTIONS"].Description, "TRACE") {
+		t.Errorf("OPTIONS finding = %q, want PUT and TRACE listed", found["OPTIONS"].Description)
+	}
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// newTLSTestScanner creates a scanner that accepts the self-signed
+// certificate of an httptest TLS server by answering its confirmation prompt
+func newTLSTestScanner(t *testing.T, options webvuln.ScanOptions) *webvuln.Scanner {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() error = %v", err)
+	}
+	fmt.Fprintln(w, "y")
+	w.Close()
+
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() {
+		os.Stdin = stdin
+		r.Close()
+	}()
+
+	options.IgnoreSSLErrors = true
+	return webvuln.NewScanner(options)
+}
+
+func TestMixedContent(t *testing.T) {
+	pages := map[string]string{
+		"/": `<html><head>
+			<script src="http://cdn.example.com/app.js"></script>
+			<link rel="stylesheet" href="http://cdn.example.com/site.css">
+			<script src="/static/local.js"></script>
+			</head><body>
+			<img src="http://images.example.com/logo.png"> <img src="//images.example.com/banner.png">
+			<a href="/about">About</a> <a href="/contact">Contact</a> <a href="/clean">Clean</a>
+			</body></html>`,
+		"/about": `<html><body>
+			<a href="http://blog.example.com/">Blog</a> <a href="http://blog.example.com/">Blog again</a>
+			<a href="https://secure.example.com/">Secure</a>
+			</body></html>`,
+		"/contact": `<html><body>
+			<form action="http://forms.example.com/submit" method="post"><input name="email"></form>
+			<img src="http://images.example.com/map.png">
+			</body></html>`,
+		"/clean": `<html><body><img src="/logo.png"><a href="https://example.com/">Example</a></body></html>`,
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+
+	options := webvuln.ScanOptions{
+		PayloadLevel:       1,
+		Timeout:            5,
+		MaxRedirects:       5,
+		MaxCrawlPages:      10,
+		EnableMixedContent: true,
+	}
+	report, err := newTLSTestScanner(t, options).Scan(webvuln.ScanTarget{URL: server.URL + "/", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	// Protocol-relative URLs follow the page's https scheme
+	want := map[string]struct {
+		severity webvuln.Severity
+		parts    []string
+	}{
+		server.URL + "/": {webvuln.SeverityHigh, []string{
+			"Mixed content on HTTPS page: ",
+			"2 active resource(s) or form target(s) (http://cdn.example.com/app.js, http://cdn.example.com/site.css)",
+			"1 passive resource(s) (http://images.example.com/logo.png)",
+		}},
+		server.URL + "/about": {webvuln.SeverityLow, []string{
+			"Insecure links on HTTPS page: 1 link(s) to http:// URLs (http://blog.example.com/)",
+		}},
+		server.URL + "/contact": {webvuln.SeverityHigh, []string{
+			"1 active resource(s) or form target(s) (http://forms.example.com/submit)",
+			"1 passive resource(s) (http://images.example.com/map.png)",
+		}},
+	}
+
+	findings := 0
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			findings++
+			page, ok := want[test.URL]
+			if !ok {
+				t.Errorf("unexpected finding %q on %s", test.Description, test.URL)
+				continue
+			}
+			if test.Severity != page.severity {
+				t.Errorf("%s: severity = %s, want %s", test.URL, test.Severity, page.severity)
+			}
+			for _, part := range page.parts {
+				if !strings.Contains(test.Description, part) {
+					t.Errorf("%s: description %q doesn't contain %q", test.URL, test.Description, part)
+				}
+			}
+		}
+	}
+	if findings != len(want) {
+		t.Errorf("got %d findings, want one per affected page (%d)", findings, len(want))
+	}
+}
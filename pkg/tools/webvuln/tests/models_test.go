@@ -2,6 +2,7 @@ package tests
 
 import (
 	"GopherStrike/pkg/tools/webvuln"
+	"strings"
 	"testing"
 	"time"
 )
@@ -136,3 +137,33 @@ func TestReport(t *testing.T) {
 		t.Error("SQL Injection vulnerability not found in report")
 	}
 }
+
+func TestTestResultFinding(t *testing.T) {
+	result := webvuln.TestResult{
+		Payload:       webvuln.Payload{Value: "' OR '1'='1"},
+		URL:           "https://shop.example.com/item?id=1",
+		Method:        "GET",
+		Parameter:     "id",
+		Description:   "SQL Injection in 'id' parameter",
+		Severity:      webvuln.SeverityCritical,
+		DBMS:          "MySQL",
+		SqlmapCommand: "sqlmap -u 'https://shop.example.com/item?id=1' -p id",
+	}
+
+	finding := result.Finding(webvuln.VulnTypeSQLInjection)
+	if finding.Tool != "webvuln" || finding.Target != "shop.example.com" || finding.Category != "SQL_INJECTION" {
+		t.Errorf("Finding() = %+v, want webvuln SQL_INJECTION on shop.example.com", finding)
+	}
+	if finding.Name != "SQL_INJECTION in id" {
+		t.Errorf("Finding().Name = %q", finding.Name)
+	}
+	if finding.Severity != webvuln.SeverityCritical || finding.Severity.Rank() != 4 {
+		t.Errorf("Finding().Severity = %q, want Critical", finding.Severity)
+	}
+	if finding.Description != "SQL Injection in 'id' parameter (DBMS: MySQL)" {
+		t.Errorf("Finding().Description = %q", finding.Description)
+	}
+	if !strings.Contains(finding.Evidence, "Payload: ' OR '1'='1") || !strings.Contains(finding.Evidence, "Follow up: sqlmap") {
+		t.Errorf("Finding().Evidence = %q", finding.Evidence)
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeScript writes a custom check script to dir
+func writeScript(t *testing.T, dir, name, source string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCustomCheckScripts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The scripts run on the linked pages too
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `<html><body><a href="/db.php">Database</a></body></html>`)
+			return
+		}
+		w.Header().Set("X-Powered-By", "PHP/5.4.1")
+		fmt.Fprint(w, "<html><body>Warning: mysql_connect() failed in /var/www/db.php</body></html>")
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	writeScript(t, dir, "php_version.star", `
+def check(request, response):
+    powered = response.headers.get("x-powered-by", "")
+    if powered.startswith("PHP/5"):
+        return {"name": "Outdated PHP", "severity": "high", "description": powered}
+`)
+	writeScript(t, dir, "debug_output.star", `
+def check(request, response):
+    findings = []
+    path = regex_search(r"in (/[\w/.]+\.php)", response.body)
+    if path:
+        findings.append({"name": "Path disclosure", "severity": "low", "description": path})
+    return findings
+`)
+	writeScript(t, dir, "quiet.star", `
+def check(request, response):
+    return None
+`)
+
+	options := webvuln.ScanOptions{
+		Timeout:          5,
+		MaxRedirects:     5,
+		EnableScripts:    true,
+		ScriptsDirectory: dir,
+		MaxCrawlPages:    5,
+	}
+	report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL, Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	descriptions := []string{}
+	for _, result := range report.Results {
+		if result.VulnerabilityType != webvuln.VulnTypeCustom {
+			t.Errorf("unexpected result type %s", result.VulnerabilityType)
+			continue
+		}
+		for _, test := range result.TestResults {
+			descriptions = append(descriptions, fmt.Sprintf("%s %s", test.Severity, test.Description))
+		}
+	}
+
+	want := []string{
+		"Low [debug_output] Path disclosure: in /var/www/db.php",
+		"High [php_version] Outdated PHP: PHP/5.4.1",
+	}
+	if strings.Join(descriptions, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings = %q, want %q", descriptions, want)
+	}
+}
+
+func TestLoadScriptErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		errMsg string
+	}{
+		{"No check function", "x = 1\n", "does not define a check"},
+		{"Wrong parameters", "def check(response):\n    return None\n", "must take (request, response)"},
+		{"Syntax error", "def check(request, response)\n", "failed to load script"},
+		{"Endless loop", "def f():\n    while True:\n        pass\nf()\n", "too many steps"},
+	}
+
+	dir := t.TempDir()
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeScript(t, dir, fmt.Sprintf("script%d.star", i), tt.source)
+			_, err := webvuln.LoadScript(path)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("LoadScript() error = %v, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+
+	if scripts, err := webvuln.LoadScripts(filepath.Join(dir, "missing")); err != nil || len(scripts) != 0 {
+		t.Errorf("LoadScripts() of a missing directory = %d scripts, %v; want none", len(scripts), err)
+	}
+}
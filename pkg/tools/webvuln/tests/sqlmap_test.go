@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSqlmapCommand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Query().Get("id"), "'") {
+			fmt.Fprint(w, "You have an error in your SQL syntax near ''")
+			return
+		}
+		fmt.Fprint(w, "<html><body>Widget</body></html>")
+	}))
+	defer server.Close()
+
+	target := webvuln.ScanTarget{
+		URL:     server.URL + "/product?id=1",
+		Method:  "GET",
+		Headers: map[string]string{"X-Team": "red", "Authorization": "Bearer abc"},
+		Cookies: []string{"session=abc", "theme=dark"},
+	}
+	options := webvuln.ScanOptions{
+		PayloadLevel:          1,
+		Timeout:               5,
+		MaxRedirects:          5,
+		EnableSQLInjection:    true,
+		EnableDBMSFingerprint: true,
+	}
+	report, err := webvuln.NewScanner(options).Scan(target)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	want := fmt.Sprintf("sqlmap -u '%s/product?id=1' -p id --cookie='session=abc; theme=dark' "+
+		"-H 'Authorization: Bearer abc' -H 'X-Team: red' --dbms=MySQL --technique=E --batch", server.URL)
+
+	commands := 0
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			if test.Severity != webvuln.SeverityCritical {
+				if test.SqlmapCommand != "" {
+					t.Errorf("unconfirmed finding %q has a sqlmap command", test.Description)
+				}
+				continue
+			}
+			commands++
+			if test.SqlmapCommand != want {
+				t.Errorf("sqlmap command =\n%s\nwant\n%s", test.SqlmapCommand, want)
+			}
+		}
+	}
+	if commands == 0 {
+		t.Error("no confirmed SQL injection with a sqlmap command")
+	}
+}
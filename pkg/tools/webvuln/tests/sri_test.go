@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSubresourceIntegrity(t *testing.T) {
+	pages := map[string]string{
+		"/": `<html><head>
+			<script src="/static/app.js"></script>
+			<script src="https://cdn.example.com/jquery.min.js" integrity="sha384-abc" crossorigin="anonymous"></script>
+			<link rel="stylesheet" href="https://fonts.example.net/font.css">
+			<script src='https://widgets.example.org/chat.js'></script>
+			</head><body><a href="/about">About</a> <a href="https://other.example.com/">Elsewhere</a></body></html>`,
+		"/about": `<html><head>
+			<script src="https://cdn.example.com/jquery.min.js"></script>
+			<script src="https://widgets.example.org/chat.js"></script>
+			</head><body><a href="/">Home</a></body></html>`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+
+	options := webvuln.ScanOptions{
+		PayloadLevel:   1,
+		Timeout:        5,
+		MaxRedirects:   5,
+		MaxCrawlPages:  10,
+		EnableSRICheck: true,
+	}
+	report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	// jquery is loaded without integrity on /about, so it isn't protected
+	wantResources := map[string]struct {
+		thirdParty bool
+		integrity  bool
+		pages      int
+		risk       webvuln.Severity
+	}{
+		server.URL + "/static/app.js":           {false, false, 1, webvuln.SeverityInfo},
+		"https://cdn.example.com/jquery.min.js": {true, false, 2, webvuln.SeverityHigh},
+		"https://fonts.example.net/font.css":    {true, false, 1, webvuln.SeverityMedium},
+		"https://widgets.example.org/chat.js":   {true, false, 2, webvuln.SeverityHigh},
+	}
+	if len(report.Resources) != len(wantResources) {
+		t.Errorf("got %d resources, want %d: %+v", len(report.Resources), len(wantResources), report.Resources)
+	}
+	for _, resource := range report.Resources {
+		want, ok := wantResources[resource.URL]
+		if !ok {
+			t.Errorf("unexpected resource %s", resource.URL)
+			continue
+		}
+		if resource.ThirdParty != want.thirdParty || resource.Integrity != want.integrity || len(resource.Pages) != want.pages || resource.Risk != want.risk {
+			t.Errorf("%s: got third-party %v, integrity %v, %d pages, risk %s; want %v, %v, %d, %s", resource.URL,
+				resource.ThirdParty, resource.Integrity, len(resource.Pages), resource.Risk,
+				want.thirdParty, want.integrity, want.pages, want.risk)
+		}
+	}
+
+	wantFindings := map[string]webvuln.Severity{
+		"https://cdn.example.com":     webvuln.SeverityHigh,
+		"https://fonts.example.net":   webvuln.SeverityMedium,
+		"https://widgets.example.org": webvuln.SeverityHigh,
+	}
+	findings := 0
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			findings++
+			if severity, ok := wantFindings[test.Parameter]; !ok || test.Severity != severity {
+				t.Errorf("unexpected finding %q (%s) for %s", test.Description, test.Severity, test.Parameter)
+			}
+		}
+	}
+	if findings != len(wantFindings) {
+		t.Errorf("got %d findings, want one per unprotected origin (%d)", findings, len(wantFindings))
+	}
+}
+
+func TestDetectLibrary(t *testing.T) {
+	tests := []struct {
+		url              string
+		library, version string
+		ok               bool
+	}{
+		{"https://code.jquery.com/jquery-3.5.1.min.js", "jquery", "3.5.1", true},
+		{"https://cdnjs.cloudflare.com/ajax/libs/lodash.js/4.17.15/lodash.min.js", "lodash.js", "4.17.15", true},
+		{"https://unpkg.com/react@18.2.0/umd/react.production.min.js", "react", "18.2.0", true},
+		{"https://cdn.jsdelivr.net/npm/@popperjs/core@2.11.8/dist/umd/popper.min.js", "@popperjs/core", "2.11.8", true},
+		{"https://cdn.example.com/bootstrap.min.css", "", "", false},
+	}
+	for _, tt := range tests {
+		library, version, ok := webvuln.DetectLibrary(tt.url)
+		if library != tt.library || version != tt.version || ok != tt.ok {
+			t.Errorf("DetectLibrary(%q) = %q, %q, %v, want %q, %q, %v", tt.url, library, version, ok, tt.library, tt.version, tt.ok)
+		}
+	}
+}
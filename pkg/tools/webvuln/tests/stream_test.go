@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFindingsStreamedPerParameter(t *testing.T) {
+	var mutex sync.Mutex
+	found := map[string]bool{} // Parameters with a published finding
+	streamed := false
+	stop := eventbus.Subscribe(eventbus.FindingNew, func(event eventbus.Event) {
+		if finding, ok := event.Data.(model.Finding); ok && finding.Tool == "webvuln" {
+			mutex.Lock()
+			found[strings.TrimPrefix(finding.Name, "XSS in ")] = true
+			mutex.Unlock()
+		}
+	})
+	defer stop()
+
+	// Both parameters are reflected; while the second one is tested, the
+	// finding of the first one must already be out
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		mutex.Lock()
+		for _, tested := range []string{"a", "b"} {
+			if strings.Contains(query.Get(tested), "<") && len(found) > 0 && !found[tested] {
+				streamed = true
+			}
+		}
+		mutex.Unlock()
+		fmt.Fprintf(w, "<html><body>%s %s</body></html>", query.Get("a"), query.Get("b"))
+	}))
+	defer server.Close()
+
+	options := webvuln.ScanOptions{PayloadLevel: 1, Timeout: 5, MaxRedirects: 3, EnableXSS: true}
+	if _, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/?a=1&b=2", Method: "GET"}); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if !found["a"] || !found["b"] {
+		t.Fatalf("published findings for %v, want both parameters", found)
+	}
+	if !streamed {
+		t.Error("the finding of the first parameter was held until the test ended")
+	}
+}
+
+func TestFindingBodiesStored(t *testing.T) {
+	var mutex sync.Mutex
+	bodies := map[string]string{} // Body reference of the finding of each parameter
+	stop := eventbus.Subscribe(eventbus.FindingNew, func(event eventbus.Event) {
+		if finding, ok := event.Data.(model.Finding); ok && finding.Tool == "webvuln" {
+			mutex.Lock()
+			bodies[strings.TrimPrefix(finding.Name, "SQL_INJECTION in ")] = finding.Body
+			mutex.Unlock()
+		}
+	})
+	defer stop()
+
+	// Every parameter gets the same error page
+	const errorPage = "<html><body>You have an error in your SQL syntax near ''</body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.RawQuery, "%27") {
+			fmt.Fprint(w, errorPage)
+			return
+		}
+		fmt.Fprint(w, "<html><body>Results</body></html>")
+	}))
+	defer server.Close()
+
+	// The bodies go to the scanner's workspace
+	store := artifacts.NewStore(t.TempDir(), "engagement")
+	options := webvuln.ScanOptions{PayloadLevel: 1, Timeout: 5, MaxRedirects: 3, EnableSQLInjection: true}
+	scanner := webvuln.NewScanner(options)
+	scanner.Store = store
+	report, err := scanner.Scan(webvuln.ScanTarget{URL: server.URL + "/?a=1&b=2", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	ref := bodies["a"]
+	if !strings.HasPrefix(ref, "sha256:") || bodies["b"] != ref {
+		t.Fatalf("finding bodies = %v, want one shared reference", bodies)
+	}
+	data, err := store.Blob(ref)
+	if err != nil || string(data) != errorPage {
+		t.Errorf("Blob() = %q, %v, want the error page", data, err)
+	}
+	if _, err := artifacts.Default().Blob(ref); err == nil {
+		t.Error("body stored in the default workspace")
+	}
+
+	// The report references the body instead of holding it
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			if test.Body != "" && test.Body != ref {
+				t.Errorf("report body = %q, want %q", test.Body, ref)
+			}
+		}
+	}
+}
+
+func TestFindingBodiesUnstored(t *testing.T) {
+	var mutex sync.Mutex
+	bodies := []string{}
+	stop := eventbus.Subscribe(eventbus.FindingNew, func(event eventbus.Event) {
+		if finding, ok := event.Data.(model.Finding); ok && finding.Tool == "webvuln" {
+			mutex.Lock()
+			bodies = append(bodies, finding.Body)
+			mutex.Unlock()
+		}
+	})
+	defer stop()
+
+	const errorPage = "<html><body>You have an error in your SQL syntax near ''</body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, errorPage)
+	}))
+	defer server.Close()
+
+	// A workspace under a file can't hold bodies
+	root := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(root, nil, 0644)
+	options := webvuln.ScanOptions{PayloadLevel: 1, Timeout: 5, MaxRedirects: 3, EnableSQLInjection: true}
+	scanner := webvuln.NewScanner(options)
+	scanner.Store = artifacts.NewStore(root, "engagement")
+	report, err := scanner.Scan(webvuln.ScanTarget{URL: server.URL + "/?a=1", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	// The report keeps the body, the finding has no reference to it
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(bodies) == 0 {
+		t.Fatal("no finding published")
+	}
+	for _, body := range bodies {
+		if body != "" {
+			t.Errorf("finding body = %q, want none", body)
+		}
+	}
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			if test.Body != errorPage {
+				t.Errorf("report body = %q, want the error page", test.Body)
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sleepPattern matches the MySQL sleep call of time-based payloads
+var sleepPattern = regexp.MustCompile(`SLEEP\((\d+)\)`)
+
+func TestTimeBasedSQLInjection(t *testing.T) {
+	mux := http.NewServeMux()
+
+	// Runs the injected sleep, like a vulnerable MySQL backend
+	mux.HandleFunc("/vulnerable", func(w http.ResponseWriter, r *http.Request) {
+		if match := sleepPattern.FindStringSubmatch(r.URL.Query().Get("id")); match != nil {
+			seconds, _ := strconv.Atoi(match[1])
+			time.Sleep(time.Duration(seconds) * time.Second)
+		}
+		fmt.Fprint(w, "<html><body>Product</body></html>")
+	})
+
+	// Tarpits any input mentioning SLEEP, whatever the requested delay
+	mux.HandleFunc("/tarpit", func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Query().Get("id"), "SLEEP") {
+			time.Sleep(time.Second)
+		}
+		fmt.Fprint(w, "<html><body>Product</body></html>")
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	scan := func(path string) []string {
+		options := webvuln.ScanOptions{
+			PayloadLevel:        1,
+			Timeout:             5,
+			MaxRedirects:        5,
+			EnableSQLInjection:  true,
+			EnableTimeBasedSQLi: true,
+			TimeBasedDelay:      1,
+		}
+		report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + path, Method: "GET"})
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+
+		found := []string{}
+		for _, result := range report.Results {
+			for _, test := range result.TestResults {
+				if strings.HasPrefix(test.Description, "Time-based") {
+					found = append(found, test.Description)
+				}
+			}
+		}
+		return found
+	}
+
+	found := scan("/vulnerable?id=1")
+	if len(found) != 1 || !strings.Contains(found[0], "(MySQL)") {
+		t.Errorf("findings = %v, want one MySQL time-based finding", found)
+	}
+
+	if found := scan("/tarpit?id=1"); len(found) != 0 {
+		t.Errorf("a server slow for any sleep payload was reported: %v", found)
+	}
+}
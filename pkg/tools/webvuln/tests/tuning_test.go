@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAutoTuneLevel(t *testing.T) {
+	var requests int64
+	mux := http.NewServeMux()
+
+	// Never reflects its input
+	mux.HandleFunc("/quiet", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		fmt.Fprint(w, "<html><body>Nothing to see</body></html>")
+	})
+
+	// Escapes its input, except for a filter bypass only a level 2 payload uses
+	mux.HandleFunc("/escaped", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		input := r.URL.Query().Get("q")
+		if !strings.Contains(input, "fromCharCode") {
+			input = html.EscapeString(input)
+		}
+		fmt.Fprintf(w, "<html><body>You searched for %s</body></html>", input)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	scan := func(path string, autoTune bool) (*webvuln.Report, int64) {
+		atomic.StoreInt64(&requests, 0)
+		options := webvuln.ScanOptions{
+			PayloadLevel:  3,
+			AutoTuneLevel: autoTune,
+			Timeout:       5,
+			MaxRedirects:  5,
+			EnableXSS:     true,
+		}
+		report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + path, Method: "GET"})
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		return report, atomic.LoadInt64(&requests)
+	}
+
+	levelOne := int64(len(webvuln.NewPayloadManager(1).GetPayloads(webvuln.VulnTypeXSS)))
+	allLevels := int64(len(webvuln.NewPayloadManager(3).GetPayloads(webvuln.VulnTypeXSS)))
+
+	// A quiet parameter only gets the level 1 payloads besides the baseline
+	// and the reflection context probe
+	if _, sent := scan("/quiet?q=test", true); sent != levelOne+2 {
+		t.Errorf("auto-tuned scan of a quiet parameter sent %d requests, want %d", sent, levelOne+2)
+	}
+	if _, sent := scan("/quiet?q=test", false); sent != allLevels+2 {
+		t.Errorf("scan of a quiet parameter sent %d requests, want %d", sent, allLevels+2)
+	}
+
+	// An escaped reflection escalates to the level 2 payloads
+	report, _ := scan("/escaped?q=test", true)
+	found := false
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			if test.Payload.Level == 2 && test.Parameter == "q" {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Error("auto-tuned scan did not escalate to the level 2 payload for an escaped reflection")
+	}
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const securityTxt = `-----BEGIN PGP SIGNED MESSAGE-----
+Hash: SHA256
+
+Contact: mailto:security@example.com
+Contact: https://example.com/report
+Expires: 2020-01-01T00:00:00Z
+Policy: https://example.com/disclosure
+-----BEGIN PGP SIGNATURE-----
+iQIzBAEBCAAdFiEE
+-----END PGP SIGNATURE-----`
+
+const openIDConfiguration = `{
+  "issuer": "https://auth.example.com",
+  "authorization_endpoint": "https://auth.example.com/authorize",
+  "token_endpoint": "https://auth.example.com/token",
+  "jwks_uri": "https://auth.example.com/keys",
+  "registration_endpoint": "https://auth.example.com/register",
+  "grant_types_supported": ["authorization_code", "password"],
+  "response_types_supported": ["code", "id_token token"]
+}`
+
+func TestParseWellKnown(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		emails    []string
+		endpoints []string
+		appIDs    []string
+		issues    int
+		severity  webvuln.Severity
+	}{
+		{
+			name: "security.txt", body: securityTxt,
+			emails: []string{"security@example.com"}, endpoints: []string{"https://example.com/report"},
+			issues: 1, severity: webvuln.SeverityInfo,
+		},
+		{
+			name: "openid-configuration", body: openIDConfiguration,
+			endpoints: []string{"https://auth.example.com/authorize", "https://auth.example.com/token", "https://auth.example.com/keys", "https://auth.example.com/register"},
+			issues:    3, severity: webvuln.SeverityLow,
+		},
+		{
+			name:   "apple-app-site-association",
+			body:   `{"applinks":{"details":[{"appIDs":["ABCDE12345.com.example.app"],"components":[{"/":"/internal/beta/*"}]}]},"webcredentials":{"apps":["ABCDE12345.com.example.app","ABCDE12345.com.example.admin"]}}`,
+			appIDs: []string{"ABCDE12345.com.example.app", "ABCDE12345.com.example.admin"}, severity: webvuln.SeverityInfo,
+		},
+		{
+			name:   "assetlinks.json",
+			body:   `[{"relation":["delegate_permission/common.handle_all_urls"],"target":{"namespace":"android_app","package_name":"com.example.android","sha256_cert_fingerprints":["14:6D:E9"]}}]`,
+			appIDs: []string{"com.example.android"}, severity: webvuln.SeverityInfo,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := webvuln.ParseWellKnown(tt.name, []byte(tt.body))
+			if resource == nil {
+				t.Fatal("ParseWellKnown() = nil")
+			}
+			if !reflect.DeepEqual(resource.Emails, tt.emails) || !reflect.DeepEqual(resource.Endpoints, tt.endpoints) || !reflect.DeepEqual(resource.AppIDs, tt.appIDs) {
+				t.Errorf("ParseWellKnown() = emails %v endpoints %v apps %v, want %v %v %v",
+					resource.Emails, resource.Endpoints, resource.AppIDs, tt.emails, tt.endpoints, tt.appIDs)
+			}
+			if len(resource.Issues) != tt.issues || resource.Severity != tt.severity {
+				t.Errorf("ParseWellKnown() = issues %v severity %s, want %d issues %s", resource.Issues, resource.Severity, tt.issues, tt.severity)
+			}
+		})
+	}
+
+	// Soft 404 pages are not mistaken for the resources
+	for _, name := range []string{"security.txt", "openid-configuration", "apple-app-site-association", "assetlinks.json"} {
+		if resource := webvuln.ParseWellKnown(name, []byte("<html><body>Page not found</body></html>")); resource != nil {
+			t.Errorf("ParseWellKnown(%s) = %+v for an HTML page", name, resource)
+		}
+	}
+}
+
+func TestWellKnownScan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/security.txt":
+			fmt.Fprint(w, securityTxt)
+		case "/.well-known/openid-configuration":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, openIDConfiguration)
+		case "/.well-known/change-password":
+			http.Redirect(w, r, "/account/password", http.StatusFound)
+		case "/":
+			fmt.Fprint(w, "<html><body>Home</body></html>")
+		default:
+			// Soft 404
+			fmt.Fprint(w, "<html><body>Nothing here</body></html>")
+		}
+	}))
+	defer server.Close()
+
+	options := webvuln.ScanOptions{
+		PayloadLevel:         3,
+		Timeout:              5,
+		MaxRedirects:         5,
+		EnableInfoDisclosure: true,
+	}
+	report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + "/shop/", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	found := make(map[string]webvuln.TestResult)
+	for _, result := range report.Results {
+		for _, test := range result.TestResults {
+			if test.Payload.Value == "/.well-known/" {
+				found[test.URL] = test
+			}
+		}
+	}
+	if len(found) != 3 {
+		t.Fatalf("got %d well-known URIs, want 3: %+v", len(found), found)
+	}
+
+	security := found[server.URL+"/security.txt"]
+	if !strings.Contains(security.Description, "1 email") || !strings.Contains(security.Evidence, "email: security@example.com") {
+		t.Errorf("security.txt = %q with evidence %q", security.Description, security.Evidence)
+	}
+	openID := found[server.URL+"/.well-known/openid-configuration"]
+	if openID.Severity != webvuln.SeverityLow || !strings.Contains(openID.Evidence, "endpoint: https://auth.example.com/token") {
+		t.Errorf("openid-configuration = %s with evidence %q", openID.Severity, openID.Evidence)
+	}
+	changePassword := found[server.URL+"/.well-known/change-password"]
+	if changePassword.Evidence != "endpoint: "+server.URL+"/account/password" {
+		t.Errorf("change-password evidence = %q, want the password change page", changePassword.Evidence)
+	}
+}
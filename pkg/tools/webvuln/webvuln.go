@@ -2,11 +2,21 @@
 package webvuln
 
 import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/browser"
+	"GopherStrike/pkg/budget"
 	"GopherStrike/pkg/errors"
+	"GopherStrike/pkg/estimate"
+	"GopherStrike/pkg/preflight"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/signing"
+	"GopherStrike/pkg/term"
 	"GopherStrike/pkg/validator"
 	"bufio"
-	"encoding/json"
+	"context"
 	"fmt"
+	"html"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -39,7 +49,9 @@ func RunWebVulnScanner() error {
 	fmt.Printf("    - Timeout: %d seconds\n", options.Timeout)
 	fmt.Printf("    - Tests Enabled: ")
 	enabledTests := []string{}
-	if options.EnableXSS {
+	if options.EnableXSS && options.ConfirmXSS {
+		enabledTests = append(enabledTests, "XSS (browser-confirmed)")
+	} else if options.EnableXSS {
 		enabledTests = append(enabledTests, "XSS")
 	}
 	if options.EnableSQLInjection {
@@ -57,11 +69,49 @@ func RunWebVulnScanner() error {
 	if options.EnableAuthTesting {
 		enabledTests = append(enabledTests, "Auth Weaknesses")
 	}
+	if options.EnableSRICheck {
+		enabledTests = append(enabledTests, "Subresource Integrity")
+	}
+	if options.EnableMixedContent {
+		enabledTests = append(enabledTests, "Mixed Content")
+	}
+	if options.EnableAPISchema {
+		enabledTests = append(enabledTests, "API Responses")
+	}
+	if options.EnablePIIDetection {
+		enabledTests = append(enabledTests, "Personal Data")
+	}
 	fmt.Println(strings.Join(enabledTests, ", "))
+	if len(options.InjectionHeaders) > 0 || options.InjectCookies {
+		points := []string{"URL parameters"}
+		if len(options.InjectionHeaders) > 0 {
+			points = append(points, "headers "+strings.Join(options.InjectionHeaders, ", "))
+		}
+		if options.InjectCookies {
+			points = append(points, "cookies")
+		}
+		fmt.Printf("    - Injection Points: %s\n", strings.Join(points, "; "))
+	}
+	if options.EnableMisconfiguration && len(options.FrameworkPacks) > 0 {
+		fmt.Printf("    - Framework Packs: %s\n", strings.Join(options.FrameworkPacks, ", "))
+	}
 
 	// Initialize scanner
 	scanner := NewScanner(options)
 
+	// Make sure the target answers, then show what the scan will cost
+	// before firing it
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Println("\n[+] Checking the target...")
+	if !preflight.Confirm(os.Stdout, reader, scanner.Preflight(context.Background(), target)) {
+		fmt.Println("[i] Scan cancelled")
+		return nil
+	}
+	if !estimate.Confirm(os.Stdout, reader, EstimateScan(target, options)) {
+		fmt.Println("[i] Scan cancelled")
+		return nil
+	}
+
 	// Start scan with progress indicator
 	fmt.Println("\n[+] Scanning in progress...")
 
@@ -85,8 +135,10 @@ func RunWebVulnScanner() error {
 		}
 	}()
 
-	// Run the scan
-	report, err := scanner.Scan(target)
+	// Run the scan, within its time budget
+	ctx, cancel := budget.Context(context.Background(), "webvuln", options.MaxDuration)
+	defer cancel()
+	report, err := scanner.ScanContext(ctx, target)
 	doneChan <- true
 
 	if err != nil {
@@ -227,13 +279,40 @@ func getTargetDetails() (ScanTarget, error) {
 // configureScanOptions prompts the user for scan configuration options
 func configureScanOptions() (ScanOptions, error) {
 	reader := bufio.NewReader(os.Stdin)
-	options := DefaultScanOptions()
 
 	fmt.Println("\n[+] Scan Configuration")
 	fmt.Println("    ------------------")
 
+	preset := presets.Prompt(reader, PresetDescriptions)
+	options, err := PresetScanOptions(preset)
+	if err != nil {
+		return options, err
+	}
+
+	// askYesNo asks a yes/no question, keeping the current value on an empty answer
+	askYesNo := func(question string, current bool) bool {
+		hint := "y/N"
+		if current {
+			hint = "Y/n"
+		}
+		fmt.Printf("[?] %s (%s): ", question, hint)
+		answer, _ := reader.ReadString('\n')
+		answer = strings.TrimSpace(strings.ToLower(answer))
+		if answer == "" {
+			return current
+		}
+		return answer == "y" || answer == "yes"
+	}
+
+	// Additional options
+	options.IgnoreSSLErrors = askYesNo("Ignore SSL certificate errors?", options.IgnoreSSLErrors)
+
+	if !askYesNo("Customize the "+preset+" preset?", false) {
+		return options, nil
+	}
+
 	// Payload complexity level
-	fmt.Print("[?] Payload complexity level (1-5, higher = more thorough but slower) [default: 3]: ")
+	fmt.Printf("[?] Payload complexity level (1-5, higher = more thorough but slower) [default: %d]: ", options.PayloadLevel)
 	levelStr, _ := reader.ReadString('\n')
 	levelStr = strings.TrimSpace(levelStr)
 
@@ -242,12 +321,14 @@ func configureScanOptions() (ScanOptions, error) {
 		if err == nil && level >= 1 && level <= 5 {
 			options.PayloadLevel = level
 		} else {
-			fmt.Println("[!] Invalid level. Using default (3).")
+			fmt.Printf("[!] Invalid level. Using default (%d).\n", options.PayloadLevel)
 		}
 	}
 
+	options.AutoTuneLevel = askYesNo("Auto-tune the level per parameter (start at 1, escalate only on anomalies)?", options.AutoTuneLevel)
+
 	// Timeout
-	fmt.Print("[?] Request timeout in seconds [default: 10]: ")
+	fmt.Printf("[?] Request timeout in seconds [default: %d]: ", options.Timeout)
 	timeoutStr, _ := reader.ReadString('\n')
 	timeoutStr = strings.TrimSpace(timeoutStr)
 
@@ -256,10 +337,19 @@ func configureScanOptions() (ScanOptions, error) {
 		if err == nil && timeout > 0 {
 			options.Timeout = timeout
 		} else {
-			fmt.Println("[!] Invalid timeout. Using default (10).")
+			fmt.Printf("[!] Invalid timeout. Using default (%d).\n", options.Timeout)
 		}
 	}
 
+	// Time budget
+	fmt.Print("[?] Stop the scan after, e.g. 30m or 2h (empty for no limit): ")
+	durationStr, _ := reader.ReadString('\n')
+	if limit, err := budget.Parse(durationStr); err == nil {
+		options.MaxDuration = limit
+	} else {
+		fmt.Println("[!] Invalid duration. The scan runs until it completes.")
+	}
+
 	// Select vulnerability tests
 	fmt.Println("\n[+] Select vulnerability tests to run:")
 
@@ -270,25 +360,117 @@ func configureScanOptions() (ScanOptions, error) {
 	}{
 		{"XSS", "Cross-Site Scripting detection", &options.EnableXSS},
 		{"SQLi", "SQL Injection testing", &options.EnableSQLInjection},
+		{"Boolean-based SQLi", "Blind SQL Injection by comparing true and false conditions", &options.EnableBooleanSQLi},
+		{"Time-based SQLi", "Blind SQL Injection confirmed by repeated sleep payloads, slower", &options.EnableTimeBasedSQLi},
+		{"DBMS Fingerprinting", "Identify the database behind suspected SQL Injections", &options.EnableDBMSFingerprint},
 		{"File Inclusion", "Local/Remote File Inclusion detection", &options.EnableFileInclusion},
 		{"CSRF", "Cross-Site Request Forgery detection", &options.EnableCSRF},
 		{"Misconfigurations", "Security misconfigurations detection", &options.EnableMisconfiguration},
 		{"Auth Testing", "Authentication weaknesses testing", &options.EnableAuthTesting},
+		{"Subresource Integrity", "Third-party script and stylesheet inventory of up to " + strconv.Itoa(options.MaxCrawlPages) + " crawled pages", &options.EnableSRICheck},
+		{"Mixed Content", "http:// resources and links on the crawled HTTPS pages", &options.EnableMixedContent},
+		{"API Responses", "JSON structure of the API endpoints found and fields leaking sensitive data", &options.EnableAPISchema},
+		{"Personal Data", "Card numbers, national IDs and phone numbers in the crawled responses", &options.EnablePIIDetection},
+		{"Custom Checks", "Starlark scripts in the " + options.ScriptsDirectory + "/ directory", &options.EnableScripts},
 	}
 
 	for _, test := range tests {
-		fmt.Printf("[?] Enable %s testing (%s)? (Y/n): ", test.name, test.description)
-		answer, _ := reader.ReadString('\n')
-		answer = strings.TrimSpace(strings.ToLower(answer))
+		*test.enabled = askYesNo(fmt.Sprintf("Enable %s testing (%s)?", test.name, test.description), *test.enabled)
+	}
 
-		*test.enabled = answer == "" || answer == "y" || answer == "yes"
+	// Reflected XSS can be confirmed by running the payloads in a browser
+	if options.EnableXSS {
+		options.ConfirmXSS = askYesNo("Confirm reflected XSS in a headless browser and take screenshots?", options.ConfirmXSS)
+		if _, err := browser.Find(""); options.ConfirmXSS && err != nil {
+			fmt.Print("[?] No Chrome or Chromium on the PATH, path of the browser executable: ")
+			path, _ := reader.ReadString('\n')
+			options.BrowserPath = strings.TrimSpace(path)
+		}
 	}
 
-	// Additional options
-	fmt.Print("[?] Ignore SSL certificate errors? (y/N): ")
-	answer, _ := reader.ReadString('\n')
-	answer = strings.TrimSpace(strings.ToLower(answer))
-	options.IgnoreSSLErrors = answer == "y" || answer == "yes"
+	// Payload encoding chains
+	defaultChains := "none"
+	if len(options.EncodingChains) > 0 {
+		defaultChains = strings.Join(options.EncodingChains, ",")
+	}
+	fmt.Printf("[?] Payload encoding chains, comma-separated (e.g. url,case>sql-comment) [default: %s]\n    Transforms: %s: ", defaultChains, strings.Join(TransformNames(), ", "))
+	chains, _ := reader.ReadString('\n')
+	if strings.TrimSpace(chains) != "" {
+		options.EncodingChains = nil
+	}
+	for _, chain := range strings.Split(chains, ",") {
+		if strings.TrimSpace(chain) == "" {
+			continue
+		}
+		if _, err := ParseEncodingChain(chain); err != nil {
+			fmt.Printf("[!] Skipping encoding chain: %v\n", err)
+			continue
+		}
+		options.EncodingChains = append(options.EncodingChains, strings.TrimSpace(chain))
+	}
+
+	options.RetryBlocked = askYesNo("Retry payloads blocked by a WAF with alternate encodings?", options.RetryBlocked)
+
+	// Injection points besides the URL parameters
+	if options.EnableXSS || options.EnableSQLInjection {
+		defaultHeaders := "none"
+		if len(options.InjectionHeaders) > 0 {
+			defaultHeaders = strings.Join(options.InjectionHeaders, ",")
+		}
+		fmt.Printf("[?] Request headers to inject XSS and SQLi payloads in, comma-separated, or none (e.g. %s) [default: %s]: ", strings.Join(DefaultInjectionHeaders, ","), defaultHeaders)
+		headers, _ := reader.ReadString('\n')
+		switch headers = strings.TrimSpace(headers); {
+		case strings.EqualFold(headers, "none"):
+			options.InjectionHeaders = nil
+		case headers != "":
+			options.InjectionHeaders = nil
+			for _, header := range strings.Split(headers, ",") {
+				if header = strings.TrimSpace(header); header != "" {
+					options.InjectionHeaders = append(options.InjectionHeaders, header)
+				}
+			}
+		}
+		options.InjectCookies = askYesNo("Inject XSS and SQLi payloads in the values of the target's cookies?", options.InjectCookies)
+	}
+
+	// File inclusion configuration if enabled
+	if options.EnableFileInclusion {
+		fmt.Print("[?] Target OS for file inclusion (linux/windows/both) [default: both]: ")
+		targetOS, _ := reader.ReadString('\n')
+		targetOS = strings.TrimSpace(strings.ToLower(targetOS))
+		if targetOS == "linux" || targetOS == "windows" {
+			options.FileInclusionOS = targetOS
+		}
+
+		fmt.Println("[!] Intrusive tests run PHP code on the target and write to its access logs.")
+		options.IntrusiveTests = askYesNo("Enable intrusive file inclusion tests (only with written authorization)?", false)
+	}
+
+	// Actuator secrets are only looked for at the level probing actuators
+	if options.EnableMisconfiguration && options.PayloadLevel >= 5 {
+		fmt.Println("[!] Reading the actuator env endpoint retrieves live credentials of the target.")
+		options.ActuatorSecrets = askYesNo("Read exposed Spring Boot actuator env for leaked credentials (only with written authorization)?", false)
+	}
+
+	// Framework check packs, selected or run on the frameworks fingerprinted
+	if options.EnableMisconfiguration {
+		defaultPacks := "none"
+		if len(options.FrameworkPacks) > 0 {
+			defaultPacks = strings.Join(options.FrameworkPacks, ",")
+		}
+		fmt.Printf("[?] Framework check packs, comma-separated, %s for those fingerprinted on the target, or none (%s) [default: %s]: ", FrameworkPacksAuto, strings.Join(FrameworkPackNames(), ", "), defaultPacks)
+		packs, _ := reader.ReadString('\n')
+		switch packs = strings.TrimSpace(packs); {
+		case strings.EqualFold(packs, "none"):
+			options.FrameworkPacks = nil
+		case packs != "":
+			if parsed, err := ParseFrameworkPacks(packs); err == nil {
+				options.FrameworkPacks = parsed
+			} else {
+				fmt.Printf("[!] %v. Using default (%s).\n", err, defaultPacks)
+			}
+		}
+	}
 
 	// Auth testing configuration if enabled
 	if options.EnableAuthTesting {
@@ -317,6 +499,9 @@ func displayResults(report *Report) {
 	fmt.Println("    ------------")
 	fmt.Printf("[i] Target: %s\n", report.Target.URL)
 	fmt.Printf("[i] Scan Duration: %s\n", formatDuration(report.EndTime.Sub(report.StartTime)))
+	if report.Incomplete != "" {
+		fmt.Printf("[!] Incomplete coverage: %s\n", report.Incomplete)
+	}
 
 	// Count vulnerabilities by severity
 	vulnerabilityCounts := map[Severity]int{
@@ -344,6 +529,10 @@ func displayResults(report *Report) {
 	fmt.Printf("    - Low:      %d\n", vulnerabilityCounts[SeverityLow])
 	fmt.Printf("    - Info:     %d\n", vulnerabilityCounts[SeverityInfo])
 
+	displaySupplyChainRisks(report)
+	displayAPISchemas(report)
+	displayProducts(report)
+
 	if !vulnFound {
 		fmt.Println("\n[+] No vulnerabilities found!")
 		return
@@ -359,22 +548,8 @@ func displayResults(report *Report) {
 		for _, result := range report.Results {
 			for _, testResult := range result.TestResults {
 				if testResult.Severity == severity {
-					// Colorize output based on severity
-					var severityColor string
-					switch severity {
-					case SeverityCritical:
-						severityColor = "\033[1;31m" // Bold Red
-					case SeverityHigh:
-						severityColor = "\033[31m" // Red
-					case SeverityMedium:
-						severityColor = "\033[33m" // Yellow
-					case SeverityLow:
-						severityColor = "\033[32m" // Green
-					default:
-						severityColor = "\033[0m" // Reset
-					}
-
-					fmt.Printf("\n    %s[%s]\033[0m %s\n", severityColor, severity, testResult.Description)
+					label := term.Colorize(term.SeverityColor(string(severity)), "["+string(severity)+"]")
+					fmt.Printf("\n    %s %s\n", label, testResult.Description)
 					fmt.Printf("    URL: %s\n", testResult.URL)
 
 					if testResult.Method != "" {
@@ -385,39 +560,108 @@ func displayResults(report *Report) {
 						fmt.Printf("    Parameter: %s\n", testResult.Parameter)
 					}
 
+					if testResult.Confidence != "" {
+						fmt.Printf("    Confidence: %s\n", testResult.Confidence)
+					}
+
 					if testResult.Payload.Value != "" {
 						fmt.Printf("    Payload: %s\n", testResult.Payload.Value)
 					}
+
+					if testResult.DBMS != "" {
+						fmt.Printf("    DBMS: %s\n", testResult.DBMS)
+					}
+
+					if testResult.SqlmapCommand != "" {
+						fmt.Printf("    sqlmap: %s\n", testResult.SqlmapCommand)
+					}
+
+					if testResult.Evidence != "" {
+						fmt.Printf("    Evidence:\n      %s\n", strings.ReplaceAll(testResult.Evidence, "\n", "\n      "))
+					}
 				}
 			}
 		}
 	}
 
+	displayVerificationQueue(report)
+
 	fmt.Println("\n[i] Report saved to disk with full details.")
 }
 
-// saveReport saves the scan report to a file
-func saveReport(report *Report) error {
-	// Create logs directory if it doesn't exist
-	logsDir := filepath.Join("logs", "webvuln")
-	if err := os.MkdirAll(logsDir, 0755); err != nil {
-		return err
+// displayVerificationQueue prints the findings short of Certain in the
+// order to verify them by hand
+func displayVerificationQueue(report *Report) {
+	queue := report.VerificationQueue()
+	if len(queue) == 0 {
+		return
 	}
 
-	// Generate filename with timestamp
-	timestamp := time.Now().Format("20060102-150405")
-	hostname := strings.Replace(strings.Replace(report.Target.URL, "https://", "", 1), "http://", "", 1)
-	hostname = strings.Split(hostname, "/")[0] // Get just the hostname part
-	filename := filepath.Join(logsDir, fmt.Sprintf("scan_%s_%s.json", hostname, timestamp))
+	fmt.Println("\n[+] Manual Verification Order:")
+	for i, testResult := range queue {
+		fmt.Printf("    %d. [%s] [%s] %s\n", i+1, testResult.Confidence, testResult.Severity, testResult.Description)
+		fmt.Printf("       %s\n", testResult.URL)
+	}
+}
 
-	// Convert report to JSON
-	reportJSON, err := json.MarshalIndent(report, "", "  ")
-	if err != nil {
-		return err
+// displaySupplyChainRisks prints the third-party scripts and stylesheets
+// found on the crawled pages
+func displaySupplyChainRisks(report *Report) {
+	risks := supplyChainRisks(report)
+	if len(risks) == 0 {
+		return
+	}
+
+	fmt.Println("\n[+] Third-party Resources:")
+	fmt.Printf("    %-8s %-10s %-5s %-5s %s\n", "Risk", "Type", "SRI", "Pages", "URL")
+	for _, resource := range risks {
+		sri := "no"
+		if resource.Integrity {
+			sri = "yes"
+		}
+		fmt.Printf("    %-8s %-10s %-5s %-5d %s\n", resource.Risk, resource.Type, sri, len(resource.Pages), resource.URL)
+	}
+}
+
+// displayAPISchemas prints the API endpoints answering with JSON and the
+// number of fields of their responses leaking sensitive data
+func displayAPISchemas(report *Report) {
+	if len(report.APISchemas) == 0 {
+		return
 	}
 
-	// Write to file
-	err = os.WriteFile(filename, reportJSON, 0644)
+	fmt.Println("\n[+] API Endpoints:")
+	fmt.Printf("    %-6s %-9s %s\n", "Fields", "Sensitive", "Endpoint")
+	for _, schema := range report.APISchemas {
+		fmt.Printf("    %-6d %-9d %s\n", len(schema.Fields), len(schema.Sensitive), schema.Endpoint)
+	}
+}
+
+// displayProducts prints the products identified on the target, which
+// the correlate command matches against vulnerability databases
+func displayProducts(report *Report) {
+	if len(report.Products) == 0 {
+		return
+	}
+
+	fmt.Println("\n[+] Identified Products:")
+	for _, product := range report.Products {
+		version := product.Version
+		if version == "" {
+			version = "unknown version"
+		}
+		fmt.Printf("    %s %s at %s\n", product.Name, version, product.URL)
+	}
+}
+
+// saveReport saves the scan report to the target's web artifact directory
+func saveReport(report *Report) error {
+	// Generate filename with timestamp
+	timestamp := time.Now().Format("20060102-150405")
+	name := fmt.Sprintf("scan_%s.json", timestamp)
+
+	// Store the report alongside other results for the same host
+	filename, err := artifacts.Default().WriteJSON(report.Target.URL, artifacts.KindWeb, name, report)
 	if err != nil {
 		return err
 	}
@@ -455,6 +699,8 @@ func generateHTMLReport(report *Report, filename string) error {
         .vuln-low { background: #eeffee; border-left: 5px solid #00aa00; padding: 10px; margin: 10px 0; }
         .vuln-info { background: #f0f0f0; border-left: 5px solid #aaaaaa; padding: 10px; margin: 10px 0; }
         .details { font-family: monospace; }
+        .resources { border-collapse: collapse; }
+        .resources th, .resources td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
     </style>
 </head>
 <body>
@@ -466,11 +712,11 @@ func generateHTMLReport(report *Report, filename string) error {
             <h2>Scan Summary</h2>
             <p><strong>Target:</strong> %s</p>
             <p><strong>Scan Date:</strong> %s</p>
-            <p><strong>Scan Duration:</strong> %s</p>
+            <p><strong>Scan Duration:</strong> %s</p>%s
         </div>
         
         <h2>Vulnerabilities Found</h2>
-`, report.Target.URL, report.Target.URL, report.StartTime.Format("2006-01-02 15:04:05"), formatDuration(report.EndTime.Sub(report.StartTime)))
+`, report.Target.URL, report.Target.URL, report.StartTime.Format("2006-01-02 15:04:05"), formatDuration(report.EndTime.Sub(report.StartTime)), incompleteHTML(report))
 
 	// Count vulnerabilities by severity
 	vulnerabilityCounts := map[Severity]int{
@@ -497,11 +743,32 @@ func generateHTMLReport(report *Report, filename string) error {
             <p><strong>Info:</strong> %d</p>
         </div>
         
-        <h2>Detailed Findings</h2>
 `, vulnerabilityCounts[SeverityCritical], vulnerabilityCounts[SeverityHigh],
 		vulnerabilityCounts[SeverityMedium], vulnerabilityCounts[SeverityLow],
 		vulnerabilityCounts[SeverityInfo])
 
+	// Add the supply-chain risk table
+	if risks := supplyChainRisks(report); len(risks) > 0 {
+		htmlContent += `
+        <h2>Third-party Resources</h2>
+        <table class="resources">
+            <tr><th>Risk</th><th>Type</th><th>SRI</th><th>Pages</th><th>URL</th></tr>
+`
+		for _, resource := range risks {
+			sri := "no"
+			if resource.Integrity {
+				sri = "yes"
+			}
+			htmlContent += fmt.Sprintf("            <tr><td>%s</td><td>%s</td><td>%s</td><td>%d</td><td>%s</td></tr>\n",
+				resource.Risk, resource.Type, sri, len(resource.Pages), html.EscapeString(resource.URL))
+		}
+		htmlContent += "        </table>\n"
+	}
+
+	htmlContent += `
+        <h2>Detailed Findings</h2>
+`
+
 	// Sort results by severity (critical first)
 	severityOrder := []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo}
 
@@ -540,16 +807,54 @@ func generateHTMLReport(report *Report, filename string) error {
 						htmlContent += fmt.Sprintf("                <p><strong>Parameter:</strong> %s</p>\n", testResult.Parameter)
 					}
 
+					if testResult.Confidence != "" {
+						htmlContent += fmt.Sprintf("                <p><strong>Confidence:</strong> %s</p>\n", testResult.Confidence)
+					}
+
 					if testResult.Payload.Value != "" {
 						htmlContent += fmt.Sprintf("                <p><strong>Payload:</strong> %s</p>\n", testResult.Payload.Value)
 					}
 
+					if testResult.DBMS != "" {
+						htmlContent += fmt.Sprintf("                <p><strong>DBMS:</strong> %s</p>\n", testResult.DBMS)
+					}
+
+					if testResult.SqlmapCommand != "" {
+						htmlContent += fmt.Sprintf("                <p><strong>sqlmap:</strong> <code>%s</code></p>\n", html.EscapeString(testResult.SqlmapCommand))
+					}
+
+					if testResult.Evidence != "" {
+						htmlContent += fmt.Sprintf("                <p><strong>Evidence:</strong></p>\n                <pre>%s</pre>\n", html.EscapeString(testResult.Evidence))
+					}
+
+					if testResult.Screenshot != "" {
+						src := testResult.Screenshot
+						if rel, err := filepath.Rel(filepath.Dir(filename), src); err == nil {
+							src = filepath.ToSlash(rel)
+						}
+						htmlContent += fmt.Sprintf("                <p><strong>Screenshot:</strong></p>\n                <img src=\"%s\" alt=\"Screenshot of the payload running\" style=\"max-width: 100%%;\">\n", html.EscapeString(src))
+					}
+
 					htmlContent += "            </div>\n        </div>\n"
 				}
 			}
 		}
 	}
 
+	// Add the findings to verify by hand, in order
+	if queue := report.VerificationQueue(); len(queue) > 0 {
+		htmlContent += `
+        <h2>Manual Verification Order</h2>
+        <table class="resources">
+            <tr><th>#</th><th>Confidence</th><th>Severity</th><th>Finding</th><th>URL</th></tr>
+`
+		for i, testResult := range queue {
+			htmlContent += fmt.Sprintf("            <tr><td>%d</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+				i+1, testResult.Confidence, testResult.Severity, html.EscapeString(testResult.Description), html.EscapeString(testResult.URL))
+		}
+		htmlContent += "        </table>\n"
+	}
+
 	// Close HTML
 	htmlContent += `
     </div>
@@ -557,9 +862,20 @@ func generateHTMLReport(report *Report, filename string) error {
 </html>
 `
 
-	// Write to file
-	err := os.WriteFile(filename, []byte(htmlContent), 0644)
-	return err
+	// Write to file, with the secrets redacted, and sign it
+	if err := os.WriteFile(filename, []byte(redact.String(htmlContent)), 0644); err != nil {
+		return err
+	}
+	return signing.SignFile(filename)
+}
+
+// incompleteHTML returns the summary line noting why the scan did not cover
+// the whole target, if it did not
+func incompleteHTML(report *Report) string {
+	if report.Incomplete == "" {
+		return ""
+	}
+	return "\n            <p><strong>Incomplete coverage:</strong> " + html.EscapeString(report.Incomplete) + "</p>"
 }
 
 // formatDuration formats a duration as a human-readable string
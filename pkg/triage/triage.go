@@ -0,0 +1,401 @@
+// Package triage lets users browse the findings of a scan from the
+// interactive menu: filter them by severity, read their evidence, mark false
+// positives and open their URLs in a browser
+package triage
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/scope"
+	"GopherStrike/pkg/siem"
+	"GopherStrike/pkg/term"
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// pageSize is the number of findings listed per page
+const pageSize = 15
+
+// Collect records the findings published from now on. The returned function
+// stops recording and returns the findings in the order they were found.
+func Collect() func() []siem.Finding {
+	var mutex sync.Mutex
+	findings := []siem.Finding{}
+	unsubscribe := eventbus.Subscribe(eventbus.FindingNew, func(event eventbus.Event) {
+		if finding, ok := event.Data.(siem.Finding); ok {
+			mutex.Lock()
+			findings = append(findings, finding)
+			mutex.Unlock()
+		}
+	})
+
+	return func() []siem.Finding {
+		unsubscribe()
+		mutex.Lock()
+		defer mutex.Unlock()
+		return findings
+	}
+}
+
+// Stream prints the findings published from now on whose severity is min or
+// above to w as they are found, so that testers can follow them up while a
+// long scan goes on. The returned function stops printing.
+func Stream(w io.Writer, min model.Severity) func() {
+	var mutex sync.Mutex
+	return eventbus.Subscribe(eventbus.FindingNew, func(event eventbus.Event) {
+		finding, ok := event.Data.(siem.Finding)
+		if !ok || !finding.Severity.AtLeast(min) {
+			return
+		}
+		location := finding.URL
+		if location == "" {
+			location = finding.Target
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		// Start at the beginning of the line, over any progress indicator
+		clear := "\r"
+		if term.Enabled() {
+			clear = "\r\033[K"
+		}
+		label := term.Colorize(term.SeverityColor(string(finding.Severity)), "["+string(finding.Severity)+"]")
+		fmt.Fprintf(w, "%s[!] Found %s %s: %s\n    %s\n", clear, label, finding.Tool, finding.Name, location)
+	})
+}
+
+// Key identifies a finding across scans, so that a false positive stays
+// marked when the same issue is reported again
+func Key(f siem.Finding) string {
+	return strings.Join([]string{f.Tool, f.Target, f.Category, f.Name, f.URL}, "|")
+}
+
+// Browser is an interactive pager over the findings of a scan
+type Browser struct {
+	findings       []siem.Finding
+	store          *artifacts.Store
+	falsePositives map[string]artifacts.FalsePositive
+	minSeverity    string
+	showFalse      bool
+	page           int
+	in             *bufio.Reader
+	out            io.Writer
+	openURL        func(string) error
+}
+
+// NewBrowser creates a browser over findings, listed from the most to the
+// least severe. False positives are read from and saved to the store's
+// workspace.
+func NewBrowser(findings []siem.Finding, store *artifacts.Store, in io.Reader, out io.Writer) *Browser {
+	sorted := append([]siem.Finding{}, findings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Severity.Rank() > sorted[j].Severity.Rank()
+	})
+
+	b := &Browser{
+		findings: sorted,
+		store:    store,
+		in:       bufio.NewReader(in),
+		out:      out,
+		openURL:  openInBrowser,
+	}
+	falsePositives, err := store.FalsePositives()
+	if err != nil {
+		fmt.Fprintf(out, "[!] False positive marks unavailable: %v\n", err)
+		falsePositives = map[string]artifacts.FalsePositive{}
+	}
+	b.falsePositives = falsePositives
+	return b
+}
+
+// Browse lets the user browse findings until they quit. Nothing is shown
+// when there are no findings.
+func Browse(findings []siem.Finding, store *artifacts.Store, in io.Reader, out io.Writer) {
+	if len(findings) == 0 {
+		return
+	}
+	NewBrowser(findings, store, in, out).Run()
+}
+
+// Run lists the findings a page at a time and executes the user's commands
+// until they quit or the input ends
+func (b *Browser) Run() {
+	b.list()
+	for {
+		fmt.Fprint(b.out, "\n[?] Findings command (h for help, q to return to the menu): ")
+		line, err := b.in.ReadString('\n')
+		if err != nil && line == "" {
+			return
+		}
+
+		fields := strings.Fields(strings.ToLower(line))
+		command, argument := "n", ""
+		if len(fields) > 0 {
+			command = fields[0]
+		}
+		if len(fields) > 1 {
+			argument = fields[1]
+		}
+
+		switch command {
+		case "q", "quit":
+			return
+		case "n", "next":
+			if (b.page+1)*pageSize < len(b.visible()) {
+				b.page++
+			}
+			b.list()
+		case "p", "prev":
+			if b.page > 0 {
+				b.page--
+			}
+			b.list()
+		case "s", "severity":
+			b.filter(argument)
+		case "a", "all":
+			b.showFalse = !b.showFalse
+			b.page = 0
+			b.list()
+		case "v", "view":
+			if index, ok := b.lookup(argument); ok {
+				b.view(index)
+			}
+		case "f", "fp":
+			if index, ok := b.lookup(argument); ok {
+				b.toggleFalsePositive(index)
+			}
+		case "o", "open":
+			if index, ok := b.lookup(argument); ok {
+				b.open(index)
+			}
+		case "b", "body":
+			if index, ok := b.lookup(argument); ok {
+				b.body(index)
+			}
+		case "h", "help":
+			b.help()
+		default:
+			fmt.Fprintf(b.out, "[-] Unknown command %q\n", command)
+			b.help()
+		}
+	}
+}
+
+// help describes the browser commands
+func (b *Browser) help() {
+	fmt.Fprintln(b.out, "    n, Enter     next page            p           previous page")
+	fmt.Fprintln(b.out, "    s <level>    show findings at or above critical, high, medium, low or info (s all resets)")
+	fmt.Fprintln(b.out, "    v <#>        view a finding and its evidence")
+	fmt.Fprintln(b.out, "    f <#>        mark or unmark a finding as a false positive")
+	fmt.Fprintln(b.out, "    a            show or hide false positives")
+	fmt.Fprintln(b.out, "    o <#>        open the finding's URL in a browser")
+	fmt.Fprintln(b.out, "    b <#>        show the response body stored with a finding")
+	fmt.Fprintln(b.out, "    q            return to the menu")
+}
+
+// isFalsePositive reports whether the finding at index is marked as a false positive
+func (b *Browser) isFalsePositive(index int) bool {
+	_, marked := b.falsePositives[Key(b.findings[index])]
+	return marked
+}
+
+// visible returns the indexes of the findings passing the filters
+func (b *Browser) visible() []int {
+	minRank := artifacts.SeverityRank(b.minSeverity)
+	indexes := []int{}
+	for i, finding := range b.findings {
+		if minRank >= 0 && finding.Severity.Rank() < minRank {
+			continue
+		}
+		if !b.showFalse && b.isFalsePositive(i) {
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// list prints the current page of findings. Findings keep their number
+// when the filters change.
+func (b *Browser) list() {
+	indexes := b.visible()
+	filters := []string{}
+	if b.minSeverity != "" {
+		filters = append(filters, "severity "+b.minSeverity+" or above")
+	}
+	if !b.showFalse {
+		filters = append(filters, "false positives hidden")
+	}
+
+	if len(indexes) == 0 {
+		fmt.Fprintf(b.out, "\n[i] No findings match the filters (%s)\n", strings.Join(filters, ", "))
+		return
+	}
+
+	start := b.page * pageSize
+	end := start + pageSize
+	if end > len(indexes) {
+		end = len(indexes)
+	}
+	fmt.Fprintf(b.out, "\n[+] Findings %d-%d of %d (%s)\n", start+1, end, len(indexes), strings.Join(filters, ", "))
+	table := term.NewTable("#", "Severity", "Tool", "Target", "Name")
+	for _, index := range indexes[start:end] {
+		finding := b.findings[index]
+		name := shorten(finding.Name, 60)
+		if b.isFalsePositive(index) {
+			name += " [false positive]"
+		}
+		table.AddRow(strconv.Itoa(index+1), term.Severity(string(finding.Severity)), shorten(finding.Tool, 10),
+			shorten(finding.Target, 28), name)
+	}
+	table.Render(b.out)
+}
+
+// filter sets the lowest severity listed
+func (b *Browser) filter(severity string) {
+	switch {
+	case severity == "" || severity == "all":
+		b.minSeverity = ""
+	case artifacts.SeverityRank(severity) >= 0:
+		b.minSeverity = severity
+	default:
+		fmt.Fprintf(b.out, "[-] Unknown severity %q, use critical, high, medium, low, info or all\n", severity)
+		return
+	}
+	b.page = 0
+	b.list()
+}
+
+// lookup turns a finding number into an index
+func (b *Browser) lookup(argument string) (int, bool) {
+	number, err := strconv.Atoi(argument)
+	if err != nil || number < 1 || number > len(b.findings) {
+		fmt.Fprintf(b.out, "[-] Enter a finding number between 1 and %d\n", len(b.findings))
+		return 0, false
+	}
+	return number - 1, true
+}
+
+// view prints every detail of a finding
+func (b *Browser) view(index int) {
+	finding := b.findings[index]
+	fmt.Fprintf(b.out, "\n[i] Finding %d\n", index+1)
+	fields := []struct{ label, value string }{
+		{"ID", finding.ID},
+		{"Name", finding.Name},
+		{"Severity", string(finding.Severity)},
+		{"Category", finding.Category},
+		{"Tool", finding.Tool},
+		{"Target", finding.Target},
+		{"URL", finding.URL},
+		{"Description", finding.Description},
+		{"Body", finding.Body},
+	}
+	for _, field := range fields {
+		if field.value != "" {
+			fmt.Fprintf(b.out, "    %-12s %s\n", field.label+":", field.value)
+		}
+	}
+	if finding.Evidence != "" {
+		fmt.Fprintln(b.out, "    Evidence:")
+		for _, line := range strings.Split(finding.Evidence, "\n") {
+			fmt.Fprintf(b.out, "      %s\n", line)
+		}
+	}
+	if falsePositive, marked := b.falsePositives[Key(finding)]; marked {
+		fmt.Fprintf(b.out, "    Marked as a false positive on %s\n", falsePositive.MarkedAt.Format("2006-01-02 15:04"))
+	}
+}
+
+// body prints the response body stored with a finding
+func (b *Browser) body(index int) {
+	finding := b.findings[index]
+	if finding.Body == "" {
+		fmt.Fprintf(b.out, "[-] Finding %d has no stored response body\n", index+1)
+		return
+	}
+	data, err := b.store.Blob(finding.Body)
+	if err != nil {
+		fmt.Fprintf(b.out, "[-] Failed to read the body of finding %d: %v\n", index+1, err)
+		return
+	}
+	fmt.Fprintf(b.out, "\n[i] Response body of finding %d (%d bytes)\n", index+1, len(data))
+	fmt.Fprintln(b.out, string(data))
+}
+
+// toggleFalsePositive marks or unmarks a finding as a false positive and
+// saves the change to the workspace
+func (b *Browser) toggleFalsePositive(index int) {
+	finding := b.findings[index]
+	key := Key(finding)
+
+	if b.isFalsePositive(index) {
+		if err := b.store.SetFalsePositive(key, nil); err != nil {
+			fmt.Fprintf(b.out, "[-] Failed to unmark finding %d: %v\n", index+1, err)
+			return
+		}
+		delete(b.falsePositives, key)
+		fmt.Fprintf(b.out, "[+] Finding %d is no longer a false positive\n", index+1)
+		return
+	}
+
+	falsePositive := &artifacts.FalsePositive{Tool: finding.Tool, Target: finding.Target, Name: finding.Name, URL: finding.URL}
+	if err := b.store.SetFalsePositive(key, falsePositive); err != nil {
+		fmt.Fprintf(b.out, "[-] Failed to mark finding %d: %v\n", index+1, err)
+		return
+	}
+	b.falsePositives[key] = *falsePositive
+	fmt.Fprintf(b.out, "[+] Finding %d marked as a false positive in workspace %s\n", index+1, b.store.Workspace)
+}
+
+// open opens a finding's URL in the default browser. Only http and https
+// URLs outside the exclusions are opened.
+func (b *Browser) open(index int) {
+	finding := b.findings[index]
+	parsed, err := url.Parse(finding.URL)
+	if finding.URL == "" || err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		fmt.Fprintf(b.out, "[-] Finding %d has no web URL to open\n", index+1)
+		return
+	}
+	if scope.Active().ExcludesURL(finding.URL) {
+		fmt.Fprintf(b.out, "[-] %s is excluded from scanning\n", finding.URL)
+		return
+	}
+
+	if err := b.openURL(finding.URL); err != nil {
+		fmt.Fprintf(b.out, "[-] Failed to open %s: %v\n", finding.URL, err)
+		return
+	}
+	fmt.Fprintf(b.out, "[+] Opened %s\n", finding.URL)
+}
+
+// openInBrowser opens a URL with the desktop's default browser
+func openInBrowser(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	return cmd.Start()
+}
+
+// shorten truncates s to max characters
+func shorten(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
@@ -0,0 +1,167 @@
+package triage
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/eventbus"
+	"GopherStrike/pkg/model"
+	"GopherStrike/pkg/siem"
+	"GopherStrike/pkg/term"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var testFindings = []siem.Finding{
+	{Tool: "webvuln", Target: "example.com", Category: "MISCONFIGURATION", Name: "Missing HSTS header", Severity: "low", URL: "https://example.com/"},
+	{Tool: "webvuln", Target: "example.com", Category: "XSS", Name: "XSS in q", Severity: "high", URL: "https://example.com/search?q=1", Evidence: "GET https://example.com/search?q=1\nPayload: <script>"},
+	{Tool: "s3scanner", Target: "example.com", Category: "S3_PUBLIC_BUCKET", Name: "Public S3 bucket: example-backup", Severity: "medium", URL: "s3://example-backup"},
+}
+
+// browse runs a browser over the test findings with the given input and returns its output
+func browse(t *testing.T, store *artifacts.Store, input string, opened *[]string) string {
+	t.Helper()
+	var out bytes.Buffer
+	b := NewBrowser(testFindings, store, strings.NewReader(input), &out)
+	b.openURL = func(url string) error {
+		*opened = append(*opened, url)
+		return nil
+	}
+	b.Run()
+	return out.String()
+}
+
+func TestCollect(t *testing.T) {
+	collected := Collect()
+	eventbus.Publish(eventbus.Event{Type: eventbus.FindingNew, Data: testFindings[0]})
+	eventbus.Publish(eventbus.Event{Type: eventbus.ScanCompleted})
+	findings := collected()
+	eventbus.Publish(eventbus.Event{Type: eventbus.FindingNew, Data: testFindings[1]})
+
+	if len(findings) != 1 || findings[0].Name != testFindings[0].Name {
+		t.Errorf("Collect() = %v, want only the finding published while collecting", findings)
+	}
+}
+
+func TestStream(t *testing.T) {
+	term.SetEnabled(false)
+	var out bytes.Buffer
+	stop := Stream(&out, model.SeverityHigh)
+	for _, finding := range testFindings {
+		eventbus.Publish(eventbus.Event{Type: eventbus.FindingNew, Data: finding})
+	}
+	stop()
+	eventbus.Publish(eventbus.Event{Type: eventbus.FindingNew, Data: testFindings[1]})
+
+	want := "\r[!] Found [high] webvuln: XSS in q\n    https://example.com/search?q=1\n"
+	if out.String() != want {
+		t.Errorf("Stream() printed %q, want %q", out.String(), want)
+	}
+}
+
+func TestBrowser(t *testing.T) {
+	store := artifacts.NewStore(t.TempDir(), "triage")
+	var opened []string
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		notWant []string
+		opened  int
+	}{
+		{
+			name:  "Most severe first",
+			input: "q\n",
+			want:  []string{"Findings 1-3 of 3", "1  high      webvuln"},
+		},
+		{
+			name:    "Severity filter",
+			input:   "s medium\nq\n",
+			want:    []string{"Findings 1-2 of 2 (severity medium or above"},
+			notWant: []string{"3  low"},
+		},
+		{
+			name:  "Evidence",
+			input: "v 1\nq\n",
+			want:  []string{"Evidence:", "      Payload: <script>"},
+		},
+		{
+			name:   "Open web URLs only",
+			input:  "o 1\no 2\nq\n",
+			want:   []string{"[+] Opened https://example.com/search?q=1", "[-] Finding 2 has no web URL to open"},
+			opened: 1,
+		},
+		{
+			name:  "Invalid number",
+			input: "v 9\nq\n",
+			want:  []string{"Enter a finding number between 1 and 3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened = nil
+			out := browse(t, store, tt.input, &opened)
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output missing %q:\n%s", want, out)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(out[strings.LastIndex(out, "[+] Findings"):], notWant) {
+					t.Errorf("output contains %q after filtering:\n%s", notWant, out)
+				}
+			}
+			if len(opened) != tt.opened {
+				t.Errorf("opened %v, want %d URL(s)", opened, tt.opened)
+			}
+		})
+	}
+}
+
+func TestFalsePositives(t *testing.T) {
+	store := artifacts.NewStore(t.TempDir(), "triage")
+	var opened []string
+
+	out := browse(t, store, "f 2\nn\nq\n", &opened)
+	if !strings.Contains(out, "Finding 2 marked as a false positive") || !strings.Contains(out, "Findings 1-2 of 2") {
+		t.Fatalf("marking a false positive did not hide it:\n%s", out)
+	}
+
+	// The mark is kept in the workspace for the next scans
+	out = browse(t, store, "a\nq\n", &opened)
+	if !strings.Contains(out, "Findings 1-2 of 2") || !strings.Contains(out, "[false positive]") {
+		t.Errorf("false positive not remembered:\n%s", out)
+	}
+
+	out = browse(t, store, "f 2\nq\n", &opened)
+	if !strings.Contains(out, "Finding 2 is no longer a false positive") {
+		t.Errorf("unmarking failed:\n%s", out)
+	}
+	falsePositives, err := store.FalsePositives()
+	if err != nil || len(falsePositives) != 0 {
+		t.Errorf("FalsePositives() = %v, %v, want none", falsePositives, err)
+	}
+}
+
+func TestBrowserBody(t *testing.T) {
+	store := artifacts.NewStore(t.TempDir(), "triage")
+	ref, err := store.PutBlob([]byte("<html><body><script>alert(1)</script></body></html>"))
+	if err != nil {
+		t.Fatalf("PutBlob() error = %v", err)
+	}
+	findings := append([]siem.Finding{}, testFindings...)
+	findings[1].Body = ref
+
+	var out bytes.Buffer
+	NewBrowser(findings, store, strings.NewReader("v 1\nb 1\nb 2\nq\n"), &out).Run()
+	for _, want := range []string{
+		"Body:        " + ref,
+		"Response body of finding 1 (51 bytes)\n<html><body><script>alert(1)</script></body></html>",
+		"[-] Finding 2 has no stored response body",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Package urlnorm canonicalizes URLs so that tools can tell when two URLs
+// name the same endpoint and test it only once. The canonical form has a
+// lowercase scheme and host, no default port, no fragment, no session
+// identifiers and its query parameters sorted by name.
+package urlnorm
+
+import (
+	"hash/fnv"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// SessionParams are the query and path parameters that carry session
+// identifiers. They are compared without case and dropped from canonical URLs.
+var SessionParams = []string{
+	"aspsessionid",
+	"cfid",
+	"cftoken",
+	"jsessionid",
+	"phpsessid",
+	"sessionid",
+	"session_id",
+	"sid",
+}
+
+// defaultPorts are the ports implied by each scheme
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// isSessionParam reports whether a parameter name carries a session identifier
+func isSessionParam(name string) bool {
+	for _, param := range SessionParams {
+		if strings.EqualFold(name, param) {
+			return true
+		}
+	}
+	return false
+}
+
+// Canonicalize returns the canonical form of u, leaving u unchanged
+func Canonicalize(u *url.URL) *url.URL {
+	c := *u
+	c.User = nil
+	c.Fragment = ""
+	c.RawFragment = ""
+	c.Scheme = strings.ToLower(c.Scheme)
+
+	host, port := strings.ToLower(c.Hostname()), c.Port()
+	if port == defaultPorts[c.Scheme] {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	c.Host = host
+
+	// Remove path parameters such as ;jsessionid=... from each segment
+	if strings.Contains(c.Path, ";") {
+		segments := strings.Split(c.Path, "/")
+		for i, segment := range segments {
+			parts := strings.Split(segment, ";")
+			kept := parts[:1]
+			for _, param := range parts[1:] {
+				if !isSessionParam(strings.SplitN(param, "=", 2)[0]) {
+					kept = append(kept, param)
+				}
+			}
+			segments[i] = strings.Join(kept, ";")
+		}
+		c.Path = strings.Join(segments, "/")
+		c.RawPath = ""
+	}
+	if c.Path == "" && c.Host != "" {
+		c.Path = "/"
+	}
+
+	// Encode sorts the parameters by name
+	query := c.Query()
+	for name := range query {
+		if isSessionParam(name) {
+			delete(query, name)
+		}
+	}
+	c.RawQuery = query.Encode()
+	c.ForceQuery = false
+	return &c
+}
+
+// String returns the canonical form of a URL string, or rawURL itself if it
+// cannot be parsed
+func String(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return rawURL
+	}
+	return Canonicalize(u).String()
+}
+
+// Set records the canonical URLs that have been seen. It keeps a 64-bit hash
+// of each URL rather than the URL itself, so that scans of millions of
+// URLs stay small; it is safe for concurrent use.
+type Set struct {
+	mu   sync.Mutex
+	seen map[uint64]struct{}
+}
+
+// NewSet creates an empty set
+func NewSet() *Set {
+	return &Set{seen: make(map[uint64]struct{})}
+}
+
+// Add records a URL and reports whether it was new, that is whether no
+// URL with the same canonical form was added before
+func (s *Set) Add(rawURL string) bool {
+	h := fnv.New64a()
+	h.Write([]byte(String(rawURL)))
+	key := h.Sum64()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, seen := s.seen[key]; seen {
+		return false
+	}
+	s.seen[key] = struct{}{}
+	return true
+}
+
+// Len returns the number of distinct URLs added
+func (s *Set) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.seen)
+}
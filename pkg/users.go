@@ -0,0 +1,134 @@
+// pkg/users.go
+package pkg
+
+import (
+	"GopherStrike/pkg/config"
+	"GopherStrike/pkg/security"
+	"GopherStrike/pkg/server"
+	"GopherStrike/pkg/term"
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// KeystorePasswordEnvVar holds the keystore password for non-interactive use
+const KeystorePasswordEnvVar = "GOPHERSTRIKE_KEYSTORE_PASSWORD"
+
+// openUserStore opens the API users kept in the encrypted keystore
+func openUserStore() (*server.UserStore, error) {
+	password := os.Getenv(KeystorePasswordEnvVar)
+	if password == "" && !term.Headless() {
+		fmt.Print("[?] Keystore password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keystore password: %v", err)
+		}
+		password = strings.TrimSpace(line)
+	}
+	if password == "" {
+		return nil, fmt.Errorf("a keystore password is required (set %s)", KeystorePasswordEnvVar)
+	}
+
+	keystore, err := security.NewSecureKeyStore(config.Get().Security.APIKeyFile, password)
+	if err != nil {
+		return nil, err
+	}
+	return server.NewUserStore(keystore), nil
+}
+
+// RunUsers manages the users of the API server
+func RunUsers(args []string) error {
+	usage := fmt.Errorf("usage: users add <name> [--role admin|operator|read-only] [--tenant name] | users list | users rotate <name> | users remove <name>")
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch args[0] {
+	case "add":
+		fs := flag.NewFlagSet("users add", flag.ContinueOnError)
+		role := fs.String("role", server.RoleReadOnly, "Role: admin, operator or read-only")
+		tenant := fs.String("tenant", "", "Tenant the user belongs to (default: none, the user sees every tenant)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return usage
+		}
+		if !server.ValidRole(*role) {
+			return fmt.Errorf("invalid role: %s", *role)
+		}
+
+		users, err := openUserStore()
+		if err != nil {
+			return err
+		}
+		token, err := users.AddToTenant(fs.Arg(0), *role, *tenant)
+		if err != nil {
+			return err
+		}
+		if *tenant != "" {
+			fmt.Printf("[+] User %s added to tenant %s with role %s\n", fs.Arg(0), *tenant, *role)
+		} else {
+			fmt.Printf("[+] User %s added with role %s\n", fs.Arg(0), *role)
+		}
+		fmt.Printf("[+] API token: %s\n", token)
+		fmt.Println("[!] Store the token now, it cannot be shown again")
+
+	case "rotate":
+		if len(args) != 2 {
+			return usage
+		}
+		users, err := openUserStore()
+		if err != nil {
+			return err
+		}
+		token, err := users.Rotate(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[+] New API token for %s: %s\n", args[1], token)
+
+	case "remove":
+		if len(args) != 2 {
+			return usage
+		}
+		users, err := openUserStore()
+		if err != nil {
+			return err
+		}
+		if err := users.Remove(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("[+] User %s removed\n", args[1])
+
+	case "list":
+		users, err := openUserStore()
+		if err != nil {
+			return err
+		}
+		list := users.List()
+		if len(list) == 0 {
+			fmt.Println("[i] No users. Add one with: users add <name> --role admin")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tROLE\tTENANT\tCREATED")
+		for _, user := range list {
+			tenant := user.Tenant
+			if tenant == "" {
+				tenant = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", user.Name, user.Role, tenant, user.Created.Format("2006-01-02 15:04"))
+		}
+		w.Flush()
+
+	default:
+		return usage
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+// pkg/verify.go
+package pkg
+
+import (
+	"GopherStrike/pkg/artifacts"
+	"GopherStrike/pkg/httpclient"
+	"GopherStrike/pkg/verify"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// RunVerify replays the requests that produced a stored finding and reports
+// whether it still reproduces, recording the finding as open or fixed
+func RunVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	workspace := fs.String("workspace", os.Getenv(artifacts.WorkspaceEnvVar), "Workspace of the finding")
+	timeout := fs.Int("timeout", 10, "Request timeout in seconds")
+	insecure := fs.Bool("insecure", false, "Skip TLS certificate verification")
+	jsonOutput := fs.Bool("json", false, "Print the result as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: GopherStrike verify [--workspace name] [--timeout s] [--insecure] [--json] <finding-id>")
+	}
+
+	store := artifacts.NewStore(artifacts.DefaultRoot, *workspace)
+	finding, err := store.Finding(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: *insecure}
+	client := httpclient.New("verify", time.Duration(*timeout)*time.Second, transport)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	result, err := verify.Finding(ctx, client, finding)
+	if err != nil {
+		return fmt.Errorf("cannot verify finding %s: %v", finding.ID, err)
+	}
+	if err := store.RecordFinding(result.Finding); err != nil {
+		return fmt.Errorf("failed to update finding %s: %v", finding.ID, err)
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("[*] %s: %s (%s)\n", finding.ID, finding.Name, finding.URL)
+	if result.Reproduced {
+		fmt.Printf("[!] Still reproduces (HTTP %d), status: %s\n", result.StatusCode, result.Finding.Status)
+	} else {
+		fmt.Printf("[+] No longer reproduces (HTTP %d), status: %s\n", result.StatusCode, result.Finding.Status)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Package verify replays the requests that produced a stored finding to
+// tell whether the problem still reproduces, e.g. once a fix is deployed.
+package verify
+
+import (
+	"GopherStrike/pkg/model"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxBody bounds the bytes of a response searched for the match
+const maxBody = 5 << 20
+
+// ErrNotReplayable is returned for a finding stored without the requests
+// that produced it
+var ErrNotReplayable = errors.New("the finding has no requests to replay")
+
+// Result is the outcome of a verification
+type Result struct {
+	Finding    model.Finding `json:"finding"`
+	Reproduced bool          `json:"reproduced"`
+	StatusCode int           `json:"status_code"` // Status of the response to the last request
+}
+
+// Finding sends the finding's requests again, in order, and reports whether
+// the response to the last one still contains the match. The finding of the
+// result has its status and update time set accordingly. A request that
+// fails returns an error, the finding being left as it was.
+func Finding(ctx context.Context, client *http.Client, f model.Finding) (Result, error) {
+	if f.Replay == nil || len(f.Replay.Requests) == 0 {
+		return Result{}, ErrNotReplayable
+	}
+
+	result := Result{Finding: f}
+	var body []byte
+	for _, request := range f.Replay.Requests {
+		req, err := newRequest(ctx, request)
+		if err != nil {
+			return Result{}, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to replay %s %s: %v", request.Method, request.URL, err)
+		}
+		body, err = io.ReadAll(io.LimitReader(resp.Body, maxBody))
+		resp.Body.Close()
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to read the response of %s: %v", request.URL, err)
+		}
+		result.StatusCode = resp.StatusCode
+	}
+
+	result.Reproduced = strings.Contains(string(body), f.Replay.Match)
+	result.Finding.Status = model.StatusFixed
+	if result.Reproduced {
+		result.Finding.Status = model.StatusOpen
+	}
+	result.Finding.UpdatedAt = time.Now()
+	return result, nil
+}
+
+// newRequest builds a replayed request
+func newRequest(ctx context.Context, request model.ReplayRequest) (*http.Request, error) {
+	method := request.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if request.Body != "" {
+		body = strings.NewReader(request.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, request.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request to replay: %v", err)
+	}
+	for name, value := range request.Headers {
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
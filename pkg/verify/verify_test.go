@@ -0,0 +1,59 @@
+package verify
+
+import (
+	"GopherStrike/pkg/model"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFinding(t *testing.T) {
+	fixed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Test") != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if fixed {
+			fmt.Fprint(w, "Results for &lt;script&gt;")
+			return
+		}
+		fmt.Fprintf(w, "Results for %s", r.URL.Query().Get("q"))
+	}))
+	defer server.Close()
+
+	f := model.Finding{
+		ID:     "0123456789ab",
+		Status: model.StatusOpen,
+		Replay: &model.Replay{
+			Requests: []model.ReplayRequest{{URL: server.URL + "/?q=<script>", Headers: map[string]string{"X-Test": "1"}}},
+			Match:    "<script>",
+		},
+	}
+	result, err := Finding(context.Background(), server.Client(), f)
+	if err != nil {
+		t.Fatalf("Finding() error = %v", err)
+	}
+	if !result.Reproduced || result.Finding.Status != model.StatusOpen || result.Finding.UpdatedAt.IsZero() || result.StatusCode != http.StatusOK {
+		t.Errorf("Finding() = %+v, want the finding reproduced and still open", result)
+	}
+
+	fixed = true
+	if result, err = Finding(context.Background(), server.Client(), f); err != nil {
+		t.Fatalf("Finding() error = %v", err)
+	}
+	if result.Reproduced || result.Finding.Status != model.StatusFixed {
+		t.Errorf("Finding() = %+v, want the finding fixed", result)
+	}
+
+	if _, err := Finding(context.Background(), server.Client(), model.Finding{ID: f.ID}); !errors.Is(err, ErrNotReplayable) {
+		t.Errorf("Finding() without replay error = %v, want ErrNotReplayable", err)
+	}
+	server.Close()
+	if _, err := Finding(context.Background(), server.Client(), f); err == nil {
+		t.Error("Finding() succeeded against a closed server")
+	}
+}
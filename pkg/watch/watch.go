@@ -0,0 +1,67 @@
+// Package watch polls files for changes, so that the daemons can apply new
+// settings without a restart. Polling keeps it portable and free of
+// dependencies; the files watched are few and small.
+package watch
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// DefaultInterval is how often the daemons check their files
+const DefaultInterval = 2 * time.Second
+
+// state is what a change of a file is detected by
+type state struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// stat returns the state of the file at path
+func stat(path string) state {
+	info, err := os.Stat(path)
+	if err != nil {
+		return state{}
+	}
+	return state{exists: true, size: info.Size(), modTime: info.ModTime()}
+}
+
+// Watch checks the files at paths every interval until ctx is cancelled and
+// calls changed with those that were written, created or removed. A change
+// is reported once the file stayed the same for an interval, so that a file
+// being saved is not read half-written.
+func Watch(ctx context.Context, interval time.Duration, paths []string, changed func(paths []string)) {
+	last := make(map[string]state, len(paths))
+	for _, path := range paths {
+		last[path] = stat(path)
+	}
+	pending := map[string]bool{}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		settled := []string{}
+		for _, path := range paths {
+			current := stat(path)
+			switch {
+			case current != last[path]:
+				pending[path] = true
+				last[path] = current
+			case pending[path]:
+				delete(pending, path)
+				settled = append(settled, path)
+			}
+		}
+		if len(settled) > 0 {
+			changed(settled)
+		}
+	}
+}
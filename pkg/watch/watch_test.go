@@ -0,0 +1,58 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.json")
+	rules := filepath.Join(dir, "redaction.txt")
+	os.WriteFile(config, []byte(`{}`), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan []string, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Watch(ctx, 10*time.Millisecond, []string{config, rules}, func(paths []string) { changes <- paths })
+	}()
+
+	next := func() []string {
+		select {
+		case paths := <-changes:
+			return paths
+		case <-time.After(2 * time.Second):
+			t.Fatal("no change reported")
+			return nil
+		}
+	}
+
+	// Written, created and removed files are reported
+	time.Sleep(20 * time.Millisecond)
+	os.WriteFile(config, []byte(`{"network":{"timeout":5}}`), 0644)
+	if got := next(); !reflect.DeepEqual(got, []string{config}) {
+		t.Errorf("changed %v, want the configuration", got)
+	}
+	os.WriteFile(rules, []byte("Acme Corp\n"), 0644)
+	if got := next(); !reflect.DeepEqual(got, []string{rules}) {
+		t.Errorf("changed %v, want the created rules", got)
+	}
+	os.Remove(rules)
+	if got := next(); !reflect.DeepEqual(got, []string{rules}) {
+		t.Errorf("changed %v, want the removed rules", got)
+	}
+
+	cancel()
+	<-done
+	select {
+	case paths := <-changes:
+		t.Errorf("unexpected change %v", paths)
+	default:
+	}
+}
@@ -0,0 +1,88 @@
+package wordlist
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// History records the entries already tested against a target, across
+// runs, so that a differential scan only tests the entries added to a
+// wordlist since. Entries are appended to a file, one per line, as they are
+// tested; an interrupted run keeps those it got to. Only their hashes are
+// kept in memory.
+type History struct {
+	file   *os.File
+	tested map[uint64]bool
+	mutex  sync.Mutex
+}
+
+// OpenHistory loads the history kept in a file, creating it if needed
+func OpenHistory(path string) (*History, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &History{file: file, tested: make(map[uint64]bool)}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if entry := strings.TrimSpace(scanner.Text()); entry != "" {
+			h.tested[hashEntry(entry)] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read history %s: %v", path, err)
+	}
+	return h, nil
+}
+
+// hashEntry returns the key of an entry
+func hashEntry(entry string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(entry))
+	return h.Sum64()
+}
+
+// Tested reports whether an entry was tested before
+func (h *History) Tested(entry string) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.tested[hashEntry(entry)]
+}
+
+// Add records an entry as tested
+func (h *History) Add(entry string) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	key := hashEntry(entry)
+	if h.tested[key] {
+		return nil
+	}
+	if _, err := fmt.Fprintln(h.file, entry); err != nil {
+		return err
+	}
+	h.tested[key] = true
+	return nil
+}
+
+// Len returns the number of entries tested
+func (h *History) Len() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return len(h.tested)
+}
+
+// Close closes the history file
+func (h *History) Close() error {
+	return h.file.Close()
+}
@@ -0,0 +1,41 @@
+package wordlist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets", "example.com", "tested.txt")
+
+	history, err := OpenHistory(path)
+	if err != nil {
+		t.Fatalf("OpenHistory() error = %v", err)
+	}
+	for _, entry := range []string{"https://example.com/admin", "https://example.com/login", "https://example.com/admin"} {
+		if err := history.Add(entry); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if history.Len() != 2 || !history.Tested("https://example.com/admin") || history.Tested("https://example.com/backup") {
+		t.Errorf("history of %d entries, want admin and login", history.Len())
+	}
+	history.Close()
+
+	// The next run loads the entries and appends the new ones
+	history, err = OpenHistory(path)
+	if err != nil {
+		t.Fatalf("OpenHistory() error = %v", err)
+	}
+	defer history.Close()
+	if !history.Tested("https://example.com/login") || history.Len() != 2 {
+		t.Errorf("reopened history of %d entries, want the previous run's", history.Len())
+	}
+	history.Add("https://example.com/backup")
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "https://example.com/admin\nhttps://example.com/login\nhttps://example.com/backup\n" {
+		t.Errorf("history file = %q", content)
+	}
+}
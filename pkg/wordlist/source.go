@@ -0,0 +1,198 @@
+package wordlist
+
+import (
+	"GopherStrike/pkg/httpclient"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stdin names the standard input as a wordlist
+const Stdin = "-"
+
+var (
+	// CacheDir keeps the wordlists fetched from URLs
+	CacheDir = filepath.Join("logs", "cache", "wordlists")
+
+	// CacheExpiry is how long a fetched wordlist is used before it is
+	// fetched again
+	CacheExpiry = 7 * 24 * time.Hour
+
+	// FetchTimeout bounds the download of a wordlist
+	FetchTimeout = 10 * time.Minute
+
+	// fetchClient returns the client wordlists are fetched with
+	fetchClient = func() *http.Client { return httpclient.New("wordlist", FetchTimeout, nil) }
+
+	// stdinPath is the file standard input was copied to, so that it can be
+	// counted and then read
+	stdinPath  string
+	stdinErr   error
+	stdinMutex sync.Mutex
+)
+
+// IsURL reports whether a wordlist is fetched from a URL
+func IsURL(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://")
+}
+
+// IsFile reports whether a wordlist names a file rather than standard input
+// or a URL
+func IsFile(name string) bool {
+	return name != Stdin && !IsURL(name)
+}
+
+// Local returns the file a wordlist is read from: the file itself, the copy
+// of standard input, made once however many times it is read, or the cached
+// download of a URL. Wordlists are only fetched over HTTPS.
+func Local(name string) (string, error) {
+	switch {
+	case name == Stdin:
+		return spoolStdin()
+	case IsURL(name):
+		return fetch(name)
+	}
+	return name, nil
+}
+
+// Reader opens a wordlist to read its lines, decompressing it if it is
+// gzipped
+func Reader(name string) (io.ReadCloser, error) {
+	local, err := Local(name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(local)
+	if err != nil {
+		return nil, err
+	}
+
+	// gzip is recognized by its magic number rather than the extension, so
+	// compressed downloads and standard input are read too
+	reader := bufio.NewReader(file)
+	if magic, _ := reader.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to decompress %s: %v", name, err)
+		}
+		return &readCloser{Reader: gz, close: func() error { gz.Close(); return file.Close() }}, nil
+	}
+	return &readCloser{Reader: reader, close: file.Close}, nil
+}
+
+// readCloser closes the file under a reader
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+// Close closes the file
+func (r *readCloser) Close() error {
+	return r.close()
+}
+
+// spoolStdin copies standard input to a temporary file the first time it
+// is used as a wordlist
+func spoolStdin() (string, error) {
+	stdinMutex.Lock()
+	defer stdinMutex.Unlock()
+	if stdinPath != "" || stdinErr != nil {
+		return stdinPath, stdinErr
+	}
+
+	file, err := os.CreateTemp("", "gopherstrike-wordlist-*")
+	if err != nil {
+		stdinErr = err
+		return "", err
+	}
+	defer file.Close()
+	if _, err := io.Copy(file, os.Stdin); err != nil {
+		os.Remove(file.Name())
+		stdinErr = fmt.Errorf("failed to read wordlist from standard input: %v", err)
+		return "", stdinErr
+	}
+	stdinPath = file.Name()
+	return stdinPath, nil
+}
+
+// fetch downloads a wordlist to the cache, unless a copy younger than
+// CacheExpiry is there. A stale copy is used if the download fails.
+func fetch(link string) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(link), "https://") {
+		return "", fmt.Errorf("wordlist %s: only HTTPS URLs are fetched", link)
+	}
+
+	sum := sha256.Sum256([]byte(link))
+	cached := filepath.Join(CacheDir, hex.EncodeToString(sum[:8])+"-"+cacheName(link))
+	info, statErr := os.Stat(cached)
+	if statErr == nil && time.Since(info.ModTime()) < CacheExpiry {
+		return cached, nil
+	}
+
+	if err := download(link, cached); err != nil {
+		if statErr == nil {
+			return cached, nil
+		}
+		return "", err
+	}
+	return cached, nil
+}
+
+// cacheName returns a file name for the cached copy of a URL, from the last
+// element of its path
+func cacheName(link string) string {
+	name := path.Base(strings.SplitN(strings.SplitN(link, "?", 2)[0], "#", 2)[0])
+	name = strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+	if name == "" || strings.Trim(name, ".") == "" {
+		return "wordlist"
+	}
+	return name
+}
+
+// download saves a URL to a file, through a temporary file so that an
+// interrupted download does not replace the cached copy
+func download(link, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	resp, err := fetchClient().Get(link)
+	if err != nil {
+		return fmt.Errorf("failed to fetch wordlist: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch wordlist %s: %s", link, resp.Status)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to fetch wordlist %s: %v", link, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
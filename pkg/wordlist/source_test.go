@@ -0,0 +1,139 @@
+package wordlist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func readWords(t *testing.T, name string) []string {
+	t.Helper()
+	stream, err := Open(context.Background(), name)
+	if err != nil {
+		t.Fatalf("Open(%q) error = %v", name, err)
+	}
+	var words []string
+	for word := range stream.Words {
+		words = append(words, word)
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	return words
+}
+
+func gzipped(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGzip(t *testing.T) {
+	// Recognized by content, whatever the extension
+	for _, name := range []string{"words.txt.gz", "words.txt"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, gzipped(t, "# common\nadmin\nlogin\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := readWords(t, path), []string{"admin", "login"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Words = %q, want %q", name, got, want)
+		}
+		if count, err := Count(path); err != nil || count != 2 {
+			t.Errorf("%s: Count() = %d, %v, want 2", name, count, err)
+		}
+	}
+}
+
+func TestStdin(t *testing.T) {
+	saved, savedPath := os.Stdin, stdinPath
+	defer func() { os.Stdin, stdinPath, stdinErr = saved, savedPath, nil }()
+	stdinPath, stdinErr = "", nil
+
+	var err error
+	os.Stdin, err = os.Open(writeWordlist(t, "admin\nlogin\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Stdin.Close()
+
+	// Standard input is counted, then read
+	if count, err := Count(Stdin); err != nil || count != 2 {
+		t.Errorf("Count() = %d, %v, want 2", count, err)
+	}
+	if got, want := readWords(t, Stdin), []string{"admin", "login"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Words = %q, want %q", got, want)
+	}
+	os.Remove(stdinPath)
+}
+
+func TestURL(t *testing.T) {
+	requests := 0
+	content := gzipped(t, "admin\nlogin\n")
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/lists/common.txt.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	savedDir, savedExpiry, savedClient := CacheDir, CacheExpiry, fetchClient
+	defer func() { CacheDir, CacheExpiry, fetchClient = savedDir, savedExpiry, savedClient }()
+	CacheDir = t.TempDir()
+	fetchClient = server.Client
+
+	link := server.URL + "/lists/common.txt.gz"
+	want := []string{"admin", "login"}
+	if got := readWords(t, link); !reflect.DeepEqual(got, want) {
+		t.Errorf("Words = %q, want %q", got, want)
+	}
+	if count, err := Count(link); err != nil || count != 2 {
+		t.Errorf("Count() = %d, %v, want 2", count, err)
+	}
+	if requests != 1 {
+		t.Errorf("fetched %d times, want once", requests)
+	}
+
+	// An expired copy is fetched again, and still used if the fetch fails
+	CacheExpiry = 0
+	server.Close()
+	if got := readWords(t, link); !reflect.DeepEqual(got, want) {
+		t.Errorf("stale Words = %q, want %q", got, want)
+	}
+
+	if _, err := Count(server.URL + "/missing.txt"); err == nil {
+		t.Error("Count() succeeded for a URL that cannot be fetched")
+	}
+	if _, err := Count("http://example.com/words.txt"); err == nil {
+		t.Error("Count() fetched a wordlist over plain HTTP")
+	}
+}
+
+func TestCacheName(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/lists/common.txt?raw=1": "common.txt",
+		"https://example.com/a b.txt":                "a_b.txt",
+		"https://example.com/":                       "example.com",
+		"https://example.com":                        "example.com",
+	}
+	for link, want := range tests {
+		if got := cacheName(link); got != want {
+			t.Errorf("cacheName(%q) = %q, want %q", link, got, want)
+		}
+	}
+}
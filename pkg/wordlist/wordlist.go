@@ -0,0 +1,184 @@
+// Package wordlist reads wordlists from disk one word at a time, so lists
+// with millions of entries can drive a scan without being loaded into
+// memory. Blank lines and lines starting with # are skipped.
+//
+// A wordlist is named by its path, by "-" for standard input or by an HTTPS
+// URL, fetched once and cached. Gzipped wordlists are decompressed as they
+// are read.
+//
+// A History records the entries tested against a target in previous runs,
+// so that differential scans only test the new ones.
+package wordlist
+
+import (
+	"bufio"
+	"context"
+	"strings"
+)
+
+// bufferSize is how many words are read ahead of the consumer
+const bufferSize = 256
+
+// Stream yields the words of a wordlist as they are read
+type Stream struct {
+	// Words receives each word in order and is closed at the end of the
+	// file or when the context is cancelled
+	Words <-chan string
+
+	done chan struct{}
+	err  error
+}
+
+// Open starts reading a wordlist. Cancel ctx to stop reading early.
+func Open(ctx context.Context, path string) (*Stream, error) {
+	file, err := Reader(path)
+	if err != nil {
+		return nil, err
+	}
+
+	words := make(chan string, bufferSize)
+	s := &Stream{Words: words, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		defer close(words)
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			word, ok := parseLine(scanner.Text())
+			if !ok {
+				continue
+			}
+			select {
+			case words <- word:
+			case <-ctx.Done():
+				s.err = ctx.Err()
+				return
+			}
+		}
+		s.err = scanner.Err()
+	}()
+	return s, nil
+}
+
+// Err waits for the stream to end and returns the error that ended it, if any
+func (s *Stream) Err() error {
+	<-s.done
+	return s.err
+}
+
+// Count returns the number of words in a wordlist without keeping them
+func Count(path string) (int, error) {
+	file, err := Reader(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if _, ok := parseLine(scanner.Text()); ok {
+			count++
+		}
+	}
+	return count, scanner.Err()
+}
+
+// parseLine returns the word on a line, or false for blank lines and comments
+func parseLine(line string) (string, bool) {
+	word := strings.TrimSpace(line)
+	if word == "" || strings.HasPrefix(word, "#") {
+		return "", false
+	}
+	return word, true
+}
+
+// Chunk is a run of consecutive words of a wordlist
+type Chunk struct {
+	Index int // Position of the chunk in the wordlist, from 0
+	Words []string
+}
+
+// ChunkStream yields the chunks of a wordlist as they are read
+type ChunkStream struct {
+	// Chunks receives each chunk in order and is closed at the end of the
+	// file or when the context is cancelled
+	Chunks <-chan Chunk
+
+	done chan struct{}
+	err  error
+}
+
+// OpenChunks starts reading a wordlist in chunks of size words, beginning
+// with chunk first: the words of the chunks before it are skipped without
+// being kept, so a scan can resume where it stopped. Only one chunk at a
+// time is read ahead of the consumer.
+func OpenChunks(ctx context.Context, path string, size, first int) (*ChunkStream, error) {
+	if size < 1 {
+		size = 1
+	}
+	words, err := Open(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan Chunk, 1)
+	s := &ChunkStream{Chunks: chunks, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		defer close(chunks)
+
+		send := func(chunk Chunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		read := 0
+		chunk := Chunk{Index: first}
+		for word := range words.Words {
+			index := read / size
+			read++
+			if index < first {
+				continue
+			}
+			chunk.Words = append(chunk.Words, word)
+			if len(chunk.Words) == size {
+				if !send(chunk) {
+					break
+				}
+				chunk = Chunk{Index: index + 1}
+			}
+		}
+		if err := words.Err(); err != nil {
+			s.err = err
+			return
+		}
+		if ctx.Err() != nil {
+			s.err = ctx.Err()
+			return
+		}
+		if len(chunk.Words) > 0 {
+			send(chunk)
+		}
+	}()
+	return s, nil
+}
+
+// Err waits for the stream to end and returns the error that ended it, if any
+func (s *ChunkStream) Err() error {
+	<-s.done
+	return s.err
+}
+
+// ChunkCount returns the number of chunks of size words in a wordlist of total words
+func ChunkCount(total, size int) int {
+	if size < 1 {
+		size = 1
+	}
+	return (total + size - 1) / size
+}
@@ -0,0 +1,127 @@
+package wordlist
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeWordlist(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"Words", "admin\nlogin\nbackup\n", []string{"admin", "login", "backup"}},
+		{"Comments and blank lines", "# common\nadmin\n\n  \n#login\n", []string{"admin"}},
+		{"Whitespace and no final newline", "  admin \r\nlogin", []string{"admin", "login"}},
+		{"Empty", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeWordlist(t, tt.content)
+
+			stream, err := Open(context.Background(), path)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			var got []string
+			for word := range stream.Words {
+				got = append(got, word)
+			}
+			if err := stream.Err(); err != nil {
+				t.Errorf("Err() = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Words = %q, want %q", got, tt.want)
+			}
+
+			count, err := Count(path)
+			if err != nil {
+				t.Fatalf("Count() error = %v", err)
+			}
+			if count != len(tt.want) {
+				t.Errorf("Count() = %d, want %d", count, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	path := writeWordlist(t, strings.Repeat("word\n", 10*bufferSize))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	<-stream.Words
+	cancel()
+
+	if err := stream.Err(); err != context.Canceled {
+		t.Errorf("Err() = %v, want %v", err, context.Canceled)
+	}
+	for range stream.Words {
+	}
+}
+
+func TestOpenMissing(t *testing.T) {
+	if _, err := Open(context.Background(), filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Open() of a missing file should fail")
+	}
+	if _, err := Count(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Count() of a missing file should fail")
+	}
+}
+
+func TestChunks(t *testing.T) {
+	path := writeWordlist(t, "a\nb\n# skipped\nc\nd\ne\n")
+
+	tests := []struct {
+		name  string
+		size  int
+		first int
+		want  []Chunk
+	}{
+		{"All chunks", 2, 0, []Chunk{{0, []string{"a", "b"}}, {1, []string{"c", "d"}}, {2, []string{"e"}}}},
+		{"Resumed", 2, 1, []Chunk{{1, []string{"c", "d"}}, {2, []string{"e"}}}},
+		{"Single chunk", 10, 0, []Chunk{{0, []string{"a", "b", "c", "d", "e"}}}},
+		{"Past the end", 2, 3, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := OpenChunks(context.Background(), path, tt.size, tt.first)
+			if err != nil {
+				t.Fatalf("OpenChunks() error = %v", err)
+			}
+			var got []Chunk
+			for chunk := range stream.Chunks {
+				got = append(got, chunk)
+			}
+			if err := stream.Err(); err != nil {
+				t.Errorf("Err() = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Chunks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := ChunkCount(5, 2); got != 3 {
+		t.Errorf("ChunkCount(5, 2) = %d, want 3", got)
+	}
+}
@@ -0,0 +1,187 @@
+// pkg/wpscan.go
+package pkg
+
+import (
+	"GopherStrike/pkg/budget"
+	"GopherStrike/pkg/presets"
+	"GopherStrike/pkg/redact"
+	"GopherStrike/pkg/tools/audit/wordpress"
+	"GopherStrike/pkg/tools/osint"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// RunWPScan enumerates the users, plugins and themes of WordPress sites,
+// checks their XML-RPC interface and looks up the detected versions in the
+// vulnerability databases selected with --sources. It fails when a site
+// can't be scanned; problems and vulnerable versions are published as
+// findings.
+func RunWPScan(args []string) error {
+	// The preset provides the defaults the other flags override
+	preset, err := presets.FromArgs(args)
+	if err != nil {
+		return err
+	}
+	options, err := wordpress.PresetScanOptions(preset)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("wpscan", flag.ContinueOnError)
+	fs.String("preset", preset, "Option preset: quick, normal or deep")
+	fs.IntVar(&options.Timeout, "timeout", options.Timeout, "Request timeout in seconds")
+	fs.IntVar(&options.MaxAuthorID, "max-author-id", options.MaxAuthorID, "Try the author archives up to this user ID (0: skip them)")
+	fs.BoolVar(&options.ProbePlugins, "probe-plugins", options.ProbePlugins, "Also look for popular plugins the pages don't reference")
+	offline := fs.Bool("offline", false, "Skip the vulnerability lookup of the detected versions")
+	minConfidence := fs.Float64("min-confidence", osint.DefaultConfidenceThreshold, "Minimum confidence of the vulnerabilities reported (0.0-1.0)")
+	apiKey := fs.String("api-key", os.Getenv("NVD_API_KEY"), "NVD API key (also NVD_API_KEY)")
+	vulnOptions := osint.DefaultVulnDBOptions()
+	sources := fs.String("sources", strings.Join(vulnOptions.Sources, ","), "Vulnerability databases to query (comma-separated: nvd, osv, github, vulners)")
+	jsonOutput := fs.Bool("json", false, "Print the results as JSON")
+	output := fs.String("output", "", "Write the results to this file instead of stdout")
+	maxDuration := fs.Duration("max-duration", 0, "Stop scanning after this long, e.g. 30m, and keep the partial results (0: no limit)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *minConfidence < 0 || *minConfidence > 1 {
+		return fmt.Errorf("invalid --min-confidence %v, must be between 0.0 and 1.0", *minConfidence)
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("no sites to scan, pass their URLs as arguments")
+	}
+
+	var correlator *osint.Correlator
+	if !*offline {
+		vulnOptions.Sources = strings.Split(*sources, ",")
+		vulnOptions.NVDAPIKey = *apiKey
+		db, err := osint.NewVulnDB(vulnOptions)
+		if err != nil {
+			return err
+		}
+		if multi, ok := db.(*osint.MultiConnector); ok {
+			multi.OnError = func(source string, err error) {
+				fmt.Fprintf(os.Stderr, "Warning: %s query failed: %v\n", source, err)
+			}
+		}
+		correlator = osint.NewCorrelator(db)
+		correlator.MatchThreshold = *minConfidence
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := budget.Context(ctx, "wpscan", *maxDuration)
+	defer cancel()
+
+	scanner := wordpress.NewScanner(options)
+	results := make([]wordpress.Result, 0, fs.NArg())
+	for _, target := range fs.Args() {
+		if ctx.Err() != nil {
+			break
+		}
+		result := scanner.Scan(ctx, target)
+		if correlator != nil && result.Detected {
+			if err := result.Correlate(correlator); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+		results = append(results, result)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %v", err)
+		}
+		defer file.Close()
+		redacted := redact.Writer(file)
+		defer redacted.Close()
+		w = redacted
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+	} else {
+		printWPReport(w, results)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d site(s) could not be scanned", failed, len(results))
+	}
+	return nil
+}
+
+// printWPReport renders the components, users and problems of each site
+func printWPReport(w io.Writer, results []wordpress.Result) {
+	for i, result := range results {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		switch {
+		case result.Error != "":
+			fmt.Fprintf(w, "[-] %s: %s\n", result.URL, result.Error)
+			continue
+		case !result.Detected:
+			fmt.Fprintf(w, "[-] %s: WordPress not detected\n", result.URL)
+			continue
+		}
+
+		version := result.Core.Version
+		if version == "" {
+			version = "version unknown"
+		}
+		fmt.Fprintf(w, "[+] %s: WordPress %s\n", result.URL, version)
+		if len(result.Core.Vulnerabilities) > 0 {
+			printWPComponents(w, "Core", []wordpress.Component{result.Core})
+		}
+		printWPComponents(w, "Plugins", result.Plugins)
+		printWPComponents(w, "Themes", result.Themes)
+
+		if len(result.Users) > 0 {
+			fmt.Fprintln(w, "    Users:")
+			for _, user := range result.Users {
+				fmt.Fprintf(w, "      %-5d %-24s %-24s %s\n", user.ID, truncate(user.Slug, 24), truncate(user.Name, 24), user.Source)
+			}
+		}
+		if len(result.Problems) > 0 {
+			fmt.Fprintln(w, "    Problems:")
+			for _, problem := range result.Problems {
+				fmt.Fprintf(w, "      [%s] %s\n", problem.Severity, problem.Description)
+			}
+		}
+	}
+}
+
+// printWPComponents lists components with their versions and matched
+// vulnerabilities
+func printWPComponents(w io.Writer, title string, components []wordpress.Component) {
+	if len(components) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "    %s:\n", title)
+	for _, component := range components {
+		version := component.Version
+		if version == "" {
+			version = "-"
+		}
+		fmt.Fprintf(w, "      %-28s %-12s %s\n", truncate(component.Slug, 28), version, component.VersionSource)
+		for _, vuln := range component.Vulnerabilities {
+			fmt.Fprintf(w, "        %-16s %-9s %4.1f %3.0f%%  %s\n", vuln.ID, vuln.Severity, vuln.CVSS, vuln.Confidence*100, truncate(vuln.Title, 50))
+		}
+	}
+}
@@ -1,23 +1,8 @@
 package utils
 
-import (
-	"os"
-	"os/exec"
-	"runtime"
-)
+import "GopherStrike/pkg/term"
 
 // ClearScreen clears the terminal screen based on the OS
 func ClearScreen() {
-	var cmd *exec.Cmd
-	switch runtime.GOOS {
-	case "windows":
-		cmd = exec.Command("cmd", "/c", "cls") // Windows
-	default:
-		cmd = exec.Command("clear") // Linux/macOS
-	}
-	cmd.Stdout = os.Stdout
-	err := cmd.Run()
-	if err != nil {
-		return
-	}
+	term.ClearScreen()
 }